@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"unicode"
 )
 
 // TestCLI_SmokeTest runs the CLI and verifies basic functionality
@@ -98,7 +99,7 @@ func TestCLI_JSONOutput(t *testing.T) {
 	}
 	
 	// Look for JSON files in output
-	files, _ := filepath.Glob(filepath.Join(tempDir, "*.json"))
+	files, _ := filepath.Glob(filepath.Join(tempDir, "latest", "*.json"))
 	if len(files) > 0 {
 		// Try to parse the JSON
 		for _, f := range files {
@@ -172,3 +173,31 @@ func TestCLI_EmptyDirectory(t *testing.T) {
 	}
 }
 
+
+// TestCLI_HelpEnglish tests the help of every command has no Russian
+// left under -lang en
+func TestCLI_HelpEnglish(t *testing.T) {
+	buildCmd := exec.Command("go", "build", "-o", "test_cli", ".")
+	if err := buildCmd.Run(); err != nil {
+		t.Fatalf("Failed to build CLI: %v", err)
+	}
+	defer os.Remove("test_cli")
+
+	for _, args := range [][]string{
+		{"help"}, {"scan", "-h"}, {"encrypt", "-h"}, {"decrypt", "-h"}, {"explain", "-h"},
+		{"rules"}, {"rules", "test", "-h"}, {"report"}, {"report", "import", "-h"},
+		{"report", "merge", "-h"}, {"report", "diff", "-h"}, {"serve", "-h"}, {"hook"}, {"hook", "install", "-h"},
+	} {
+		cmd := exec.Command("./test_cli", append([]string{"-lang", "en"}, args...)...)
+		output, _ := cmd.CombinedOutput() // Some commands exit 1 without a subcommand
+		if len(output) == 0 {
+			t.Errorf("%v: no help printed", args)
+		}
+		for i, line := range strings.Split(string(output), "\n") {
+			if strings.IndexFunc(line, func(r rune) bool { return unicode.Is(unicode.Cyrillic, r) }) >= 0 {
+				t.Errorf("%v: line %d is in Russian: %q", args, i+1, line)
+				break
+			}
+		}
+	}
+}
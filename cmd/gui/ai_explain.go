@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+	"github.com/kacebover/password-finder/searcher"
+)
+
+// findingExplainer returns the analyzer of the "🤖 Объяснить" buttons. It is
+// kept for the session, so a finding asked about again is answered from its
+// cache
+func (sg *ScannerGUI) findingExplainer() *searcher.LocalAnalyzer {
+	if sg.explainer == nil {
+		sg.explainer = searcher.NewLocalAnalyzer()
+		sg.explainer.EnableAI(true)
+	}
+	return sg.explainer
+}
+
+// newExplainSection creates the "🤖 Объяснить" button of a finding and the
+// place its answer is shown in. Without Ollama the rule-based suggestion
+// is shown at once
+func (sg *ScannerGUI) newExplainSection(f *searcher.Finding) fyne.CanvasObject {
+	answer := container.NewVBox()
+	if f.Explanation != "" {
+		answer.Add(newExplanationLabel(f.Explanation))
+	}
+
+	var button *widget.Button
+	button = widget.NewButton(sg.ui.T("gui.explain"), func() {
+		fallback := func() string {
+			text, _ := searcher.NewLocalAnalyzer().ExplainFinding(context.Background(), f)
+			return text
+		}
+		if !searcher.Network().Allowed(searcher.NetworkAI) {
+			answer.Objects = []fyne.CanvasObject{newExplanationLabel(fallback()),
+				widget.NewLabel("   " + sg.ui.T("gui.explain.ai_off"))}
+			answer.Refresh()
+			return
+		}
+
+		button.Disable()
+		spinner := widget.NewProgressBarInfinite()
+		answer.Objects = []fyne.CanvasObject{spinner}
+		answer.Refresh()
+		explainer := sg.findingExplainer()
+		go func() {
+			var text, note string
+			if explainer.IsOllamaAvailable() {
+				var err error
+				if text, err = explainer.ExplainFinding(context.Background(), f); err != nil {
+					note = "   " + sg.ui.T("gui.explain.failed", userErrorMessage(sg.ui, err))
+				}
+			} else {
+				text, note = fallback(), "   "+sg.ui.T("gui.explain.no_ollama")
+			}
+			fyne.Do(func() {
+				spinner.Stop()
+				answer.Objects = []fyne.CanvasObject{newExplanationLabel(text)}
+				if note != "" {
+					answer.Add(widget.NewLabel(note))
+				} else {
+					// Saved reports carry the explanation
+					f.Explanation = text
+				}
+				answer.Refresh()
+				button.Enable()
+			})
+		}()
+	})
+	button.Importance = widget.LowImportance
+	return container.NewVBox(container.NewHBox(button), answer)
+}
+
+func newExplanationLabel(text string) *widget.Label {
+	label := widget.NewLabel("   💬 " + text)
+	label.Wrapping = fyne.TextWrapWord
+	return label
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kacebover/password-finder/locale"
+	"github.com/kacebover/password-finder/searcher"
+)
+
+// Identifiers used to associate .dllreport files with the GUI
+const (
+	reportMIMEType        = "application/x-data-leak-report"
+	reportProgID          = "DataLeakLocator.Report"
+	reportUTI             = "com.dataleaklocator.report"
+	reportTypeDescription = "Отчёт Data Leak Locator"
+	desktopFileName       = "data-leak-locator.desktop"
+	mimePackageFileName   = "data-leak-locator.xml"
+)
+
+// guiArgs is the parsed GUI command line
+type guiArgs struct {
+	registerAssociation bool
+	reportPath          string
+}
+
+// parseGUIArgs reads the optional report path and --register-file-association.
+// The -psn_* argument added by older macOS Finder versions is ignored
+func parseGUIArgs(l *locale.Localizer, args []string) (guiArgs, error) {
+	var parsed guiArgs
+	for _, arg := range args {
+		switch {
+		case arg == "--register-file-association" || arg == "-register-file-association":
+			parsed.registerAssociation = true
+		case strings.HasPrefix(arg, "-psn_"):
+			continue
+		case strings.HasPrefix(arg, "-"):
+			return parsed, errors.New(l.T("gui.args.unknown", arg))
+		case parsed.reportPath != "":
+			return parsed, errors.New(l.T("gui.args.one_report"))
+		default:
+			parsed.reportPath = arg
+		}
+	}
+	return parsed, nil
+}
+
+// registryEntry is one value written under HKEY_CURRENT_USER; an empty Name
+// is the key's default value
+type registryEntry struct {
+	Key   string
+	Name  string
+	Value string
+}
+
+// windowsRegistryEntries returns the per-user registry values that open
+// .dllreport files with exe
+func windowsRegistryEntries(exe string) []registryEntry {
+	classes := `Software\Classes\`
+	return []registryEntry{
+		{Key: classes + searcher.ReportExtension, Value: reportProgID},
+		{Key: classes + searcher.ReportExtension, Name: "Content Type", Value: reportMIMEType},
+		{Key: classes + reportProgID, Value: reportTypeDescription},
+		{Key: classes + reportProgID + `\DefaultIcon`, Value: fmt.Sprintf(`"%s",0`, exe)},
+		{Key: classes + reportProgID + `\shell\open\command`, Value: fmt.Sprintf(`"%s" "%%1"`, exe)},
+	}
+}
+
+// desktopEntry returns the freedesktop .desktop file that handles the report MIME type
+func desktopEntry(exe string) string {
+	return fmt.Sprintf(`[Desktop Entry]
+Type=Application
+Name=Data Leak Locator
+Comment=Просмотр отчётов о найденных утечках данных
+Exec="%s" %%f
+MimeType=%s;
+Terminal=false
+Categories=Utility;Security;
+`, strings.ReplaceAll(exe, `"`, `\"`), reportMIMEType)
+}
+
+// mimePackage returns the shared-mime-info definition of the report type
+func mimePackage() string {
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<mime-info xmlns="http://www.freedesktop.org/standards/shared-mime-info">
+  <mime-type type="%s">
+    <comment>Data Leak Locator report</comment>
+    <comment xml:lang="ru">%s</comment>
+    <sub-class-of type="application/json"/>
+    <glob pattern="*%s"/>
+  </mime-type>
+</mime-info>
+`, reportMIMEType, reportTypeDescription, searcher.ReportExtension)
+}
+
+// infoPlistDocumentTypes returns the Info.plist keys a macOS .app bundle
+// needs to open .dllreport files
+func infoPlistDocumentTypes() string {
+	ext := strings.TrimPrefix(searcher.ReportExtension, ".")
+	return fmt.Sprintf(`<key>CFBundleDocumentTypes</key>
+<array>
+  <dict>
+    <key>CFBundleTypeName</key>
+    <string>%[1]s</string>
+    <key>CFBundleTypeRole</key>
+    <string>Viewer</string>
+    <key>LSHandlerRank</key>
+    <string>Owner</string>
+    <key>LSItemContentTypes</key>
+    <array><string>%[2]s</string></array>
+  </dict>
+</array>
+<key>UTExportedTypeDeclarations</key>
+<array>
+  <dict>
+    <key>UTTypeIdentifier</key>
+    <string>%[2]s</string>
+    <key>UTTypeDescription</key>
+    <string>%[1]s</string>
+    <key>UTTypeConformsTo</key>
+    <array><string>public.json</string></array>
+    <key>UTTypeTagSpecification</key>
+    <dict>
+      <key>public.filename-extension</key>
+      <array><string>%[3]s</string></array>
+      <key>public.mime-type</key>
+      <string>%[4]s</string>
+    </dict>
+  </dict>
+</array>
+`, reportTypeDescription, reportUTI, ext, reportMIMEType)
+}
+
+// installFreedesktopAssociation writes the MIME package and .desktop file
+// under dataHome (normally ~/.local/share)
+func installFreedesktopAssociation(l *locale.Localizer, dataHome, exe string) error {
+	files := map[string]string{
+		filepath.Join(dataHome, "mime", "packages", mimePackageFileName): mimePackage(),
+		filepath.Join(dataHome, "applications", desktopFileName):         desktopEntry(exe),
+	}
+	for path, content := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			return fmt.Errorf("%s: %w", l.T("gui.association.write_error", path), err)
+		}
+	}
+	return nil
+}
+
+// associationExecutable returns the absolute path of the running GUI binary
+func associationExecutable(l *locale.Localizer) (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", l.T("gui.association.no_executable"), err)
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+	return exe, nil
+}
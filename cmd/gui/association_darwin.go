@@ -0,0 +1,36 @@
+//go:build darwin
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/kacebover/password-finder/locale"
+)
+
+// lsregister refreshes Launch Services for a single bundle
+const lsregister = "/System/Library/Frameworks/CoreServices.framework/Frameworks/LaunchServices.framework/Support/lsregister"
+
+// registerFileAssociation re-registers the .app bundle with Launch Services.
+// macOS reads document types from Info.plist, so a bare binary cannot be
+// associated; the required keys are printed instead
+func registerFileAssociation(l *locale.Localizer) (string, error) {
+	exe, err := associationExecutable(l)
+	if err != nil {
+		return "", err
+	}
+
+	i := strings.Index(exe, ".app/Contents/MacOS/")
+	if i < 0 {
+		return "", errors.New(l.T("gui.association.darwin_plist", infoPlistDocumentTypes()))
+	}
+	bundle := exe[:i+len(".app")]
+
+	if out, err := exec.Command(lsregister, "-f", bundle).CombinedOutput(); err != nil {
+		return "", fmt.Errorf("lsregister: %v: %s", err, strings.TrimSpace(string(out)))
+	}
+	return l.T("gui.association.darwin_done", bundle), nil
+}
@@ -0,0 +1,53 @@
+//go:build linux
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/kacebover/password-finder/locale"
+)
+
+// registerFileAssociation installs the MIME type and .desktop entry for the
+// current user and refreshes the desktop databases when the tools exist
+func registerFileAssociation(l *locale.Localizer) (string, error) {
+	exe, err := associationExecutable(l)
+	if err != nil {
+		return "", err
+	}
+
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	if err := installFreedesktopAssociation(l, dataHome, exe); err != nil {
+		return "", err
+	}
+
+	// Missing tools only delay the association until the next login
+	refresh := [][]string{
+		{"update-mime-database", filepath.Join(dataHome, "mime")},
+		{"update-desktop-database", filepath.Join(dataHome, "applications")},
+		{"xdg-mime", "default", desktopFileName, reportMIMEType},
+	}
+	var skipped []string
+	for _, cmd := range refresh {
+		if _, err := exec.LookPath(cmd[0]); err != nil {
+			skipped = append(skipped, cmd[0])
+			continue
+		}
+		exec.Command(cmd[0], cmd[1:]...).Run()
+	}
+
+	msg := l.T("gui.association.linux_done", reportMIMEType, dataHome)
+	if len(skipped) > 0 {
+		msg += l.T("gui.association.linux_skipped", skipped)
+	}
+	return msg, nil
+}
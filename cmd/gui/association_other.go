@@ -0,0 +1,15 @@
+//go:build !windows && !linux && !darwin
+
+package main
+
+import (
+	"errors"
+	"runtime"
+
+	"github.com/kacebover/password-finder/locale"
+)
+
+// registerFileAssociation is not implemented for this platform
+func registerFileAssociation(l *locale.Localizer) (string, error) {
+	return "", errors.New(l.T("gui.association.unsupported", runtime.GOOS))
+}
@@ -0,0 +1,35 @@
+//go:build windows
+
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/kacebover/password-finder/locale"
+)
+
+// registerFileAssociation writes the per-user registry keys for .dllreport
+// with reg.exe, so no administrator rights are needed
+func registerFileAssociation(l *locale.Localizer) (string, error) {
+	exe, err := associationExecutable(l)
+	if err != nil {
+		return "", err
+	}
+
+	for _, entry := range windowsRegistryEntries(exe) {
+		args := []string{"add", `HKCU\` + entry.Key}
+		if entry.Name == "" {
+			args = append(args, "/ve")
+		} else {
+			args = append(args, "/v", entry.Name)
+		}
+		args = append(args, "/t", "REG_SZ", "/d", entry.Value, "/f")
+
+		if out, err := exec.Command("reg", args...).CombinedOutput(); err != nil {
+			return "", fmt.Errorf("reg add %s: %v: %s", entry.Key, err, strings.TrimSpace(string(out)))
+		}
+	}
+	return l.T("gui.association.windows_done", exe), nil
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"path/filepath"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/kacebover/password-finder/searcher"
+)
+
+// buildBaselineBar creates the "Базовый отчёт" and comparison buttons and the
+// "только новые" filter, which stays disabled until a baseline is loaded
+func (sg *ScannerGUI) buildBaselineBar() fyne.CanvasObject {
+	sg.baselineLabel = widget.NewLabel("")
+	sg.baselineLabel.Truncation = fyne.TextTruncateEllipsis
+
+	sg.onlyNewCheck = widget.NewCheck(sg.ui.T("gui.baseline.only_new"), func(checked bool) {
+		sg.results.SetOnlyNewFilter(checked)
+		sg.refreshFilesList()
+	})
+	sg.onlyNewCheck.Disable()
+
+	baselineButton := widget.NewButton(sg.ui.T("gui.baseline.load"), func() {
+		dialog.ShowFileOpen(func(reader fyne.URIReadCloser, err error) {
+			if err != nil || reader == nil {
+				return
+			}
+			path := reader.URI().Path()
+			reader.Close()
+			go sg.loadBaseline(path)
+		}, sg.window)
+	})
+	baselineButton.Importance = widget.LowImportance
+
+	diffButton := widget.NewButton(sg.ui.T("gui.compare"), sg.showCompareDialog)
+	diffButton.Importance = widget.LowImportance
+
+	return container.NewBorder(nil, nil, container.NewHBox(baselineButton, diffButton), sg.onlyNewCheck, sg.baselineLabel)
+}
+
+// loadBaseline indexes a baseline file or saved report and tags the current
+// results against it
+func (sg *ScannerGUI) loadBaseline(path string) {
+	index, err := searcher.LoadBaselineIndex(path)
+	if err != nil {
+		fyne.Do(func() {
+			sg.showUserError(err)
+		})
+		return
+	}
+	sg.baseline.Store(index)
+
+	fyne.Do(func() {
+		sg.baselineLabel.SetText(sg.ui.T("gui.baseline.label", filepath.Base(path), index.Len()))
+		sg.onlyNewCheck.Enable()
+
+		if sg.resultData != nil && !sg.scanning.Load() {
+			newCount, known := index.TagResult(sg.resultData)
+			sg.results.Invalidate()
+			sg.statusLabel.SetText(sg.ui.T("gui.baseline.tagged", newCount, known))
+			if sg.lastScan != nil {
+				sg.updateSummaryBar(time.Duration(sg.resultData.EndTime-sg.resultData.StartTime)*time.Second, false)
+			}
+		}
+		sg.refreshFilesList()
+	})
+}
+
+// tagBaseline marks the findings of a finished scan or opened report as new
+// or known; without a baseline the findings stay untagged
+func (sg *ScannerGUI) tagBaseline(result *searcher.ScanResult) {
+	if index := sg.baseline.Load(); index != nil {
+		index.TagResult(result)
+	}
+}
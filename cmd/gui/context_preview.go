@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"github.com/kacebover/password-finder/searcher"
+)
+
+// contextLine is one line of the context preview
+type contextLine struct {
+	Number int
+	Text   string
+	Match  bool // The line of the finding
+}
+
+// contextPreviewLines lists the lines around a finding with their numbers
+func contextPreviewLines(f *searcher.Finding) []contextLine {
+	lines := make([]contextLine, 0, len(f.ContextBefore)+1+len(f.ContextAfter))
+	first := f.LineNumber - len(f.ContextBefore)
+	for i, text := range f.ContextBefore {
+		lines = append(lines, contextLine{Number: first + i, Text: text})
+	}
+	lines = append(lines, contextLine{Number: f.LineNumber, Text: f.Context, Match: true})
+	for i, text := range f.ContextAfter {
+		lines = append(lines, contextLine{Number: f.LineNumber + 1 + i, Text: text})
+	}
+	return lines
+}
+
+// newContextPreview shows the surrounding lines dimmed and the matching
+// line highlighted
+func newContextPreview(f *searcher.Finding) fyne.CanvasObject {
+	rows := container.NewVBox()
+	for _, line := range contextPreviewLines(f) {
+		text := canvas.NewText(fmt.Sprintf("%5d │ %s", line.Number, line.Text), theme.Color(colorNamePreviewText))
+		text.TextSize = 12
+		text.TextStyle.Monospace = true
+		if !line.Match {
+			rows.Add(text)
+			continue
+		}
+		text.Color = theme.Color(colorNamePreviewMatch)
+		text.TextStyle.Bold = true
+		highlight := canvas.NewRectangle(theme.Color(colorNamePreviewHighlight))
+		rows.Add(container.NewStack(highlight, text))
+	}
+	background := canvas.NewRectangle(theme.Color(colorNamePreview))
+	background.CornerRadius = 4
+	return container.NewStack(background, container.NewPadded(rows))
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"image/color"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/kacebover/password-finder/locale"
+	"github.com/kacebover/password-finder/searcher"
+)
+
+// colorCoverageBanner is the translucent yellow behind the coverage banner
+var colorCoverageBanner = color.NRGBA{R: 255, G: 193, B: 7, A: 70}
+
+// buildCoverageBanner creates the hidden banner shown above the results when
+// a requested capability could not run
+func (sg *ScannerGUI) buildCoverageBanner() fyne.CanvasObject {
+	sg.coverageLabel = widget.NewLabel("")
+	sg.coverageLabel.Wrapping = fyne.TextWrapWord
+
+	diagnosticsButton := widget.NewButton(sg.ui.T("gui.diagnostics"), sg.showDiagnostics)
+	diagnosticsButton.Importance = widget.WarningImportance
+
+	sg.coverageBanner = container.NewStack(
+		canvas.NewRectangle(colorCoverageBanner),
+		container.NewBorder(nil, nil, nil, diagnosticsButton, sg.coverageLabel),
+	)
+	sg.coverageBanner.Hide()
+	return sg.coverageBanner
+}
+
+// coverageBannerText returns the banner text, or "" if nothing was missing
+func coverageBannerText(l *locale.Localizer, report *searcher.CoverageReport) string {
+	warnings := searcher.LocalizeCoverageWarnings(l, report)
+	if len(warnings) == 0 {
+		return ""
+	}
+	return l.T("gui.coverage.incomplete", strings.Join(warnings, "; "))
+}
+
+// updateCoverageBanner shows or hides the banner for the current results
+func (sg *ScannerGUI) updateCoverageBanner() {
+	var report *searcher.CoverageReport
+	if sg.resultData != nil {
+		report = sg.resultData.Coverage
+	}
+
+	text := coverageBannerText(sg.ui, report)
+	if text == "" {
+		sg.coverageBanner.Hide()
+		return
+	}
+	sg.coverageLabel.SetText(text)
+	sg.coverageBanner.Show()
+}
+
+// showDiagnostics opens the "Диагностика" dialog with the state of the
+// external tools and the coverage of the last scan
+func (sg *ScannerGUI) showDiagnostics() {
+	// Probes run the tools, which may stall on slow PATH lookups
+	go func() {
+		statuses := searcher.NewDependencyChecker().CheckAll()
+		fyne.Do(func() { sg.showDiagnosticsDialog(statuses) })
+	}()
+}
+
+// dependencyLine describes the state of an external tool in the diagnostics
+func dependencyLine(l *locale.Localizer, status *searcher.DependencyStatus) string {
+	description := searcher.LocalizeDependencyDescription(l, status)
+	if !status.Available {
+		line := "❌ " + status.Name + " — " + description
+		if status.InstallHint != "" {
+			line += "\n     " + l.T("gui.dependency.install", status.InstallHint)
+		}
+		return line
+	}
+	line := "✅ " + status.Name + " — " + description
+	if status.Version != "" {
+		line += "\n     " + l.T("gui.dependency.version", status.Version)
+	}
+	if warning := searcher.LocalizeVersionWarning(l, status); warning != "" {
+		line = "⚠️" + strings.TrimPrefix(line, "✅") + "\n     " + l.T("gui.dependency.outdated", warning, status.InstallHint)
+	}
+	if len(status.Models) > 0 {
+		line += "\n     " + l.T("gui.dependency.models", strings.Join(status.Models, ", "))
+	}
+	return line
+}
+
+// showDiagnosticsDialog shows the diagnostics for the probed statuses
+func (sg *ScannerGUI) showDiagnosticsDialog(statuses map[string]*searcher.DependencyStatus) {
+	var objects []fyne.CanvasObject
+	objects = append(objects, widget.NewLabelWithStyle(sg.ui.T("gui.diagnostics.tools"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+	for _, name := range searcher.Dependencies().Names() {
+		status := statuses[name]
+		if status == nil {
+			continue
+		}
+		label := widget.NewLabel(dependencyLine(sg.ui, status))
+		label.Wrapping = fyne.TextWrapWord
+		objects = append(objects, label)
+	}
+
+	if sg.resultData != nil && sg.resultData.Coverage != nil {
+		coverage := widget.NewLabel(searcher.FormatCoverage(sg.ui, sg.resultData.Coverage))
+		coverage.Wrapping = fyne.TextWrapWord
+		objects = append(objects,
+			widget.NewSeparator(),
+			widget.NewLabelWithStyle(sg.ui.T("gui.diagnostics.coverage"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			coverage,
+		)
+	}
+
+	objects = append(objects, widget.NewSeparator(), sg.buildMemorySection())
+
+	var d dialog.Dialog
+	refreshButton := widget.NewButton(sg.ui.T("gui.diagnostics.refresh"), func() {
+		searcher.Dependencies().ForceRefresh()
+		d.Hide()
+		sg.showDiagnostics()
+	})
+	objects = append(objects, widget.NewSeparator(), refreshButton)
+
+	d = dialog.NewCustom(sg.ui.T("gui.diagnostics"), sg.ui.T("gui.close"), container.NewVScroll(container.NewVBox(objects...)), sg.window)
+	d.Resize(fyne.NewSize(650, 500))
+	d.Show()
+}
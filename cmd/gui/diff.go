@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"github.com/kacebover/password-finder/searcher"
+)
+
+// showCompareDialog picks a JSON report of an earlier scan to compare the
+// current results with
+func (sg *ScannerGUI) showCompareDialog() {
+	dialog.ShowFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil || reader == nil {
+			return
+		}
+		path := reader.URI().Path()
+		reader.Close()
+		go sg.compareWithPrevious(path)
+	}, sg.window)
+}
+
+// compareWithPrevious compares the current results with an earlier report:
+// new findings get the "NEW" badge and files whose findings were all fixed
+// are listed as fixed
+func (sg *ScannerGUI) compareWithPrevious(path string) {
+	if sg.resultData == nil || sg.scanning.Load() {
+		fyne.Do(func() {
+			dialog.ShowInformation(sg.ui.T("gui.compare.title"), sg.ui.T("gui.compare.scanning"), sg.window)
+		})
+		return
+	}
+
+	previous, err := searcher.LoadScanResult(path)
+	if err != nil {
+		fyne.Do(func() {
+			sg.showUserError(err)
+		})
+		return
+	}
+	diff := searcher.CompareResults(previous, sg.resultData)
+
+	fyne.Do(func() {
+		sg.results.ApplyDiff(diff)
+
+		sg.baselineLabel.SetText(fmt.Sprintf("⚖️ %s", filepath.Base(path)))
+		sg.onlyNewCheck.Enable()
+		sg.statusLabel.SetText(sg.ui.T("gui.compare.done", filepath.Base(path), len(diff.AddedFindings), len(diff.RemovedFindings), len(diff.PersistedFindings)))
+		sg.refreshFilesList()
+	})
+}
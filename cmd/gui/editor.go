@@ -0,0 +1,235 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"github.com/kacebover/password-finder/locale"
+)
+
+// Editors a finding can be opened in. Settings.Editor holds one of them,
+// EditorCustom meaning Settings.EditorTemplate
+const (
+	EditorVSCode    = "vscode"
+	EditorSublime   = "sublime"
+	EditorJetBrains = "idea"
+	EditorVim       = "vim"
+	EditorCustom    = "custom"
+)
+
+// knownEditors are the editors detected by DetectEditors, in the order
+// they are offered
+var knownEditors = []string{EditorVSCode, EditorSublime, EditorJetBrains, EditorVim}
+
+// editorTitles name the editors in the settings
+var editorTitles = map[string]string{
+	EditorVSCode:    "VS Code",
+	EditorSublime:   "Sublime Text",
+	EditorJetBrains: "JetBrains IDE",
+	EditorVim:       "Vim",
+}
+
+// editorBinaries are the commands of the editors
+var editorBinaries = map[string]string{
+	EditorVSCode:    "code",
+	EditorSublime:   "subl",
+	EditorJetBrains: "idea",
+	EditorVim:       "vim",
+}
+
+// terminals run vim outside macOS and Windows, in the order they are tried
+var terminals = []string{"x-terminal-emulator", "gnome-terminal", "konsole", "xfce4-terminal", "xterm"}
+
+// lookPath finds commands; tests replace it
+var lookPath = exec.LookPath
+
+// DetectEditors returns the known editors installed on this computer
+func DetectEditors() []string {
+	var found []string
+	for _, editor := range knownEditors {
+		if _, err := lookPath(editorBinaries[editor]); err != nil {
+			continue
+		}
+		if editor == EditorVim && runtime.GOOS != "darwin" && runtime.GOOS != "windows" && findTerminal() == "" {
+			continue
+		}
+		found = append(found, editor)
+	}
+	return found
+}
+
+// findTerminal returns the first terminal emulator installed, or ""
+func findTerminal() string {
+	for _, terminal := range terminals {
+		if _, err := lookPath(terminal); err == nil {
+			return terminal
+		}
+	}
+	return ""
+}
+
+// BuildEditorCommand returns the command opening file at line in editor:
+// one of the Editor constants or a template such as "myeditor {file} +{line}".
+// Arguments are passed without a shell, so paths need no quoting
+func BuildEditorCommand(l *locale.Localizer, editor, file string, line int) (*exec.Cmd, error) {
+	return buildEditorCommand(l, runtime.GOOS, editor, file, line)
+}
+
+func buildEditorCommand(l *locale.Localizer, goos, editor, file string, line int) (*exec.Cmd, error) {
+	if line < 1 {
+		line = 1
+	}
+	lineText := strconv.Itoa(line)
+
+	switch editor {
+	case EditorVSCode:
+		return exec.Command("code", "-g", file+":"+lineText), nil
+	case EditorSublime:
+		return exec.Command("subl", file+":"+lineText), nil
+	case EditorJetBrains:
+		return exec.Command("idea", "--line", lineText, file), nil
+	case EditorVim:
+		return vimCommand(l, goos, file, lineText)
+	case "", EditorCustom:
+		return nil, errors.New(l.T("gui.editor.none"))
+	}
+	return templateCommand(l, editor, file, lineText)
+}
+
+// vimCommand opens vim in a new terminal window
+func vimCommand(l *locale.Localizer, goos, file, line string) (*exec.Cmd, error) {
+	switch goos {
+	case "darwin":
+		// Terminal runs the line through the shell, quoted for it and then for AppleScript
+		script := "vim +" + line + " " + shellQuote(file)
+		script = strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(script)
+		return exec.Command("osascript", "-e", `tell application "Terminal" to do script "`+script+`"`, "-e", `tell application "Terminal" to activate`), nil
+	case "windows":
+		return exec.Command("cmd", "/c", "start", "", "vim", "+"+line, file), nil
+	}
+	terminal := findTerminal()
+	if terminal == "" {
+		return nil, errors.New(l.T("gui.editor.no_terminal"))
+	}
+	if terminal == "gnome-terminal" {
+		return exec.Command(terminal, "--", "vim", "+"+line, file), nil
+	}
+	return exec.Command(terminal, "-e", "vim", "+"+line, file), nil
+}
+
+// shellQuote quotes s for a POSIX shell
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// templateCommand fills {file} and {line} of a user template. The template
+// is split into arguments first, so a path with spaces stays one argument
+func templateCommand(l *locale.Localizer, template, file, line string) (*exec.Cmd, error) {
+	args, err := splitCommandLine(l, template)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) == 0 {
+		return nil, errors.New(l.T("gui.editor.empty"))
+	}
+	if !strings.Contains(template, "{file}") {
+		return nil, errors.New(l.T("gui.editor.no_file", template))
+	}
+	fill := strings.NewReplacer("{file}", file, "{line}", line)
+	for i, arg := range args {
+		args[i] = fill.Replace(arg)
+	}
+	return exec.Command(args[0], args[1:]...), nil
+}
+
+// splitCommandLine splits a command line at spaces outside single or double
+// quotes
+func splitCommandLine(l *locale.Localizer, s string) ([]string, error) {
+	var args []string
+	var arg strings.Builder
+	inArg := false
+	var quote rune
+	for _, r := range s {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			arg.WriteRune(r)
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t':
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		default:
+			arg.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, errors.New(l.T("gui.editor.quote", s))
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	return args, nil
+}
+
+// editorSetting returns the editor of the settings: the template for
+// EditorCustom, the first detected editor when none was chosen
+func (sg *ScannerGUI) editorSetting() string {
+	switch sg.settings.Editor {
+	case EditorCustom:
+		return sg.settings.EditorTemplate
+	case "":
+		if detected := DetectEditors(); len(detected) > 0 {
+			return detected[0]
+		}
+	}
+	return sg.settings.Editor
+}
+
+// openInEditor opens the file of a finding at its line. The editor runs in
+// the background; failures are shown in the status bar
+func (sg *ScannerGUI) openInEditor(file string, line int) {
+	showError := func(err error) {
+		sg.statusLabel.SetText(sg.ui.T("gui.editor_failed", err))
+	}
+	if _, err := os.Stat(file); err != nil {
+		showError(err)
+		return
+	}
+	cmd, err := BuildEditorCommand(sg.ui, sg.editorSetting(), file, line)
+	if err != nil {
+		showError(err)
+		return
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Start(); err != nil {
+		showError(err)
+		return
+	}
+	sg.statusLabel.SetText(sg.ui.T("gui.editor_opened", file, line))
+
+	go func() {
+		if err := cmd.Wait(); err != nil {
+			if text := strings.TrimSpace(stderr.String()); text != "" {
+				err = fmt.Errorf("%w: %s", err, text)
+			}
+			fyne.Do(func() {
+				showError(err)
+			})
+		}
+	}()
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"strings"
+
+	"fyne.io/fyne/v2/dialog"
+	"github.com/kacebover/password-finder/encryptor"
+	"github.com/kacebover/password-finder/locale"
+	"github.com/kacebover/password-finder/searcher"
+)
+
+// userErrorMessage turns an error of the searcher or encryptor package into
+// a message for the user; unknown errors keep their own text
+func userErrorMessage(l *locale.Localizer, err error) string {
+	if err == nil {
+		return ""
+	}
+
+	var searchDep *searcher.ErrDependencyMissing
+	var encryptDep *encryptor.ErrDependencyMissing
+	var searchFormat *searcher.ErrUnsupportedFormat
+	var encryptFormat *encryptor.ErrUnsupportedFormat
+	var weakPassword *encryptor.WeakPasswordError
+
+	switch {
+	case errors.Is(err, searcher.ErrCancelled), errors.Is(err, encryptor.ErrCancelled):
+		return l.T("gui.error.cancelled")
+	case errors.Is(err, encryptor.ErrWrongPassword):
+		return l.T("gui.error.wrong_password")
+	case errors.Is(err, encryptor.ErrEmptyPassword):
+		return l.T("gui.error.empty_password")
+	case errors.As(err, &weakPassword):
+		feedback := make([]string, len(weakPassword.Feedback))
+		for i, text := range weakPassword.Feedback {
+			feedback[i] = l.PasswordFeedback(text)
+		}
+		return l.T("gui.error.weak_password", strings.Join(feedback, ". "))
+	case errors.Is(err, searcher.ErrNotFound), errors.Is(err, encryptor.ErrNotFound), errors.Is(err, fs.ErrNotExist):
+		return withPath(l.T("gui.error.not_found"), err)
+	case errors.Is(err, searcher.ErrPermission), errors.Is(err, encryptor.ErrPermission), errors.Is(err, fs.ErrPermission):
+		return withPath(l.T("gui.error.permission"), err)
+	case errors.Is(err, searcher.ErrTooLarge), errors.Is(err, encryptor.ErrTooLarge):
+		return l.T("gui.error.too_large")
+	case errors.As(err, &searchDep):
+		return l.T("gui.error.dependency", searchDep.Name)
+	case errors.As(err, &encryptDep):
+		return l.T("gui.error.dependency", encryptDep.Name)
+	case errors.As(err, &searchFormat):
+		return l.T("gui.error.format", formatName(l, searchFormat.Ext))
+	case errors.As(err, &encryptFormat):
+		return l.T("gui.error.format", formatName(l, encryptFormat.Ext))
+	default:
+		return err.Error()
+	}
+}
+
+// showUserError shows err in an error dialog with a localized message
+func (sg *ScannerGUI) showUserError(err error) {
+	dialog.ShowError(errors.New(userErrorMessage(sg.ui, err)), sg.window)
+}
+
+// withPath appends the path of a file system error to message
+func withPath(message string, err error) string {
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		return message + ": " + pathErr.Path
+	}
+	return message
+}
+
+// formatName names an extension for the user
+func formatName(l *locale.Localizer, ext string) string {
+	if ext == "" {
+		return l.T("gui.error.no_extension")
+	}
+	return l.T("gui.error.extension", ext)
+}
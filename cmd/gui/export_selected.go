@@ -0,0 +1,127 @@
+package main
+
+import (
+	"errors"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/kacebover/password-finder/searcher"
+)
+
+// exportSelectedFormats are the report formats offered for the picked files
+var exportSelectedFormats = []string{searcher.FormatJSON, searcher.FormatCSV, searcher.FormatHTML}
+
+// selectedResult is the result limited to the picked files that are not
+// ignored, with the number of those files
+func (sg *ScannerGUI) selectedResult() (*searcher.ScanResult, int) {
+	var paths []string
+	for _, path := range sg.results.GetSelectedPaths() {
+		if !sg.isIgnored(path) {
+			paths = append(paths, path)
+		}
+	}
+	return sg.resultData.FilterByFiles(paths), len(paths)
+}
+
+// updateExportSelectedState enables "Экспорт выбранных" while files are
+// picked in a finished result
+func (sg *ScannerGUI) updateExportSelectedState(selected int) {
+	if sg.exportSelectedButton == nil {
+		return
+	}
+	if selected > 0 && sg.resultData != nil && !sg.scanning.Load() {
+		sg.exportSelectedButton.Enable()
+	} else {
+		sg.exportSelectedButton.Disable()
+	}
+}
+
+// onExportSelected asks for the formats of a report of the picked files.
+// One format is saved as a single file, several into a chosen folder
+func (sg *ScannerGUI) onExportSelected() {
+	if sg.resultData == nil {
+		dialog.ShowError(errors.New(sg.ui.T("gui.export.no_results")), sg.window)
+		return
+	}
+	result, files := sg.selectedResult()
+	if files == 0 {
+		dialog.ShowInformation(sg.ui.T("gui.export_selected_title"), sg.ui.T("gui.export_selected_none"), sg.window)
+		return
+	}
+
+	titles := make([]string, len(exportSelectedFormats))
+	for i, format := range exportSelectedFormats {
+		titles[i] = strings.ToUpper(format)
+	}
+	formatsCheck := widget.NewCheckGroup(titles, nil)
+	formatsCheck.Horizontal = true
+	formatsCheck.SetSelected(titles)
+
+	formItems := []*widget.FormItem{
+		widget.NewFormItem(sg.ui.T("gui.export_selected_files"), widget.NewLabel(sg.ui.T("gui.selected", files, result.TotalFindings()))),
+		widget.NewFormItem(sg.ui.T("gui.export_formats"), formatsCheck),
+	}
+	dialog.ShowForm(sg.ui.T("gui.export_selected_title"), sg.ui.T("gui.save"), sg.ui.T("gui.cancel_button"), formItems, func(confirm bool) {
+		if !confirm {
+			return
+		}
+		var formats []string
+		for i, format := range exportSelectedFormats {
+			for _, title := range formatsCheck.Selected {
+				if title == titles[i] {
+					formats = append(formats, format)
+				}
+			}
+		}
+		switch len(formats) {
+		case 0:
+			dialog.ShowError(errors.New(sg.ui.T("gui.export_no_format")), sg.window)
+		case 1:
+			sg.saveSelectedReport(result, formats[0])
+		default:
+			sg.exportSelectedReports(result, formats)
+		}
+	}, sg.window)
+}
+
+// saveSelectedReport writes the report of the picked files to a file chosen
+// by the user
+func (sg *ScannerGUI) saveSelectedReport(result *searcher.ScanResult, format string) {
+	save := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil || writer == nil {
+			return
+		}
+		path := writer.URI().Path()
+		writer.Close()
+
+		reporter := searcher.NewReportGenerator(result)
+		reporter.SetLocale(sg.ui)
+		if err := reporter.ExportFormat(format, path); err != nil {
+			sg.showUserError(err)
+			return
+		}
+		sg.statusLabel.SetText(sg.ui.T("gui.exported_selected", path))
+	}, sg.window)
+	save.SetFileName(sg.ui.T("report.file_name") + "." + format)
+	save.Show()
+}
+
+// exportSelectedReports writes the reports of the picked files into a new
+// timestamped folder of a folder chosen by the user, like the full export
+func (sg *ScannerGUI) exportSelectedReports(result *searcher.ScanResult, formats []string) {
+	dialog.ShowFolderOpen(func(uri fyne.ListableURI, err error) {
+		if err != nil || uri == nil {
+			return
+		}
+		reporter := searcher.NewReportGenerator(result)
+		reporter.SetLocale(sg.ui)
+		reportDir, err := reporter.GenerateReportFormats(uri.Path(), formats)
+		if err != nil {
+			sg.showUserError(err)
+			return
+		}
+		sg.statusLabel.SetText(sg.ui.T("gui.exported_selected", reportDir))
+	}, sg.window)
+}
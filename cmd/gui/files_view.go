@@ -0,0 +1,74 @@
+package main
+
+import (
+	"path/filepath"
+
+	"github.com/kacebover/password-finder/gui/controller"
+	"github.com/kacebover/password-finder/locale"
+	"github.com/kacebover/password-finder/searcher"
+)
+
+// newResultsModel creates the model of the files list. Ignored files are
+// left out; after the ignore list changes the model is invalidated
+func (sg *ScannerGUI) newResultsModel() *controller.ResultsModel {
+	results := controller.NewResultsModel()
+	results.SetIgnored(sg.isIgnored)
+	return results
+}
+
+// severityFilter returns the severity picked in the severity select, empty
+// for every level
+func (sg *ScannerGUI) severityFilter(title string) searcher.Severity {
+	for _, severity := range []searcher.Severity{searcher.Critical, searcher.High, searcher.Medium, searcher.Low} {
+		if title == sg.severityName(severity) {
+			return severity
+		}
+	}
+	return ""
+}
+
+// fileTypeExtensions returns the extensions of the file type picked in the
+// file type select, nil for every file
+func (sg *ScannerGUI) fileTypeExtensions(title string) []string {
+	switch title {
+	case sg.ui.T("gui.filetype.text"):
+		return controller.TextExtensions
+	case sg.ui.T("gui.filetype.documents"):
+		return controller.DocumentExtensions
+	case sg.ui.T("gui.filetype.images"):
+		return controller.ImageExtensions
+	case sg.ui.T("gui.filetype.archives"):
+		return controller.ArchiveExtensions
+	}
+	return nil
+}
+
+// markTruncated notes the files matching stopped in, in the language of l
+func markTruncated(l *locale.Localizer, results *controller.ResultsModel, result *searcher.ScanResult) {
+	for path, n := range result.Truncated {
+		if n > 0 {
+			results.SetTruncated(path, searcher.LocalizeTruncation(l, n))
+		}
+	}
+}
+
+// splitFilePath returns the archive on disk and the path inside it of a
+// file of the list, or the path of a file outside an archive with no inner
+// path
+func splitFilePath(file *controller.FileEntry) (archive, inner string) {
+	if len(file.Findings) > 0 && file.Findings[0].ArchivePath != "" {
+		return file.Findings[0].ArchivePath, file.Findings[0].InnerPath
+	}
+	// Findings of reports saved before the archive fields were kept
+	return searcher.SplitArchivePath(file.FilePath)
+}
+
+// fileDisplayName returns the name and the folder a file of the list is
+// shown with; a member of an archive is named with the archive chain
+func fileDisplayName(file *controller.FileEntry) (name, dir string) {
+	archive, inner := splitFilePath(file)
+	if inner == "" {
+		return filepath.Base(file.FilePath), filepath.Dir(file.FilePath)
+	}
+	return searcher.FormatArchivePath(filepath.Base(archive), inner), filepath.Dir(archive)
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/kacebover/password-finder/searcher"
+)
+
+// showImportResults opens the "Импорт результатов" dialog that merges
+// gitleaks/trufflehog findings into the current results or a saved report
+func (sg *ScannerGUI) showImportResults() {
+	if sg.scanning.Load() {
+		dialog.ShowError(errors.New(sg.ui.T("gui.import.scanning")), sg.window)
+		return
+	}
+
+	formatSelect := widget.NewSelect([]string{string(searcher.ImportGitleaks), string(searcher.ImportTrufflehog)}, nil)
+	formatSelect.SetSelected(string(searcher.ImportGitleaks))
+
+	resultsEntry := widget.NewEntry()
+	resultsEntry.SetPlaceHolder("findings.json")
+	mappingEntry := widget.NewEntry()
+	mappingEntry.SetPlaceHolder(sg.ui.T("gui.import.mapping_hint"))
+	reportEntry := widget.NewEntry()
+	reportEntry.SetPlaceHolder(sg.ui.T("gui.import.report_hint"))
+
+	formItems := []*widget.FormItem{
+		widget.NewFormItem(sg.ui.T("gui.import.format"), formatSelect),
+		widget.NewFormItem(sg.ui.T("gui.import.results"), sg.fileEntryWithBrowse(resultsEntry)),
+		widget.NewFormItem(sg.ui.T("gui.import.mapping"), sg.fileEntryWithBrowse(mappingEntry)),
+		widget.NewFormItem(sg.ui.T("gui.import.report"), sg.fileEntryWithBrowse(reportEntry)),
+	}
+
+	d := dialog.NewForm(sg.ui.T("gui.import.title"), sg.ui.T("gui.import.confirm"), sg.ui.T("gui.cancel_button"), formItems, func(confirm bool) {
+		if !confirm {
+			return
+		}
+		if strings.TrimSpace(resultsEntry.Text) == "" {
+			dialog.ShowError(errors.New(sg.ui.T("gui.import.no_results")), sg.window)
+			return
+		}
+		sg.statusLabel.SetText(sg.ui.T("gui.import.running"))
+		go sg.importResults(searcher.ImportFormat(formatSelect.Selected),
+			strings.TrimSpace(resultsEntry.Text), strings.TrimSpace(mappingEntry.Text), strings.TrimSpace(reportEntry.Text))
+	}, sg.window)
+	d.Resize(fyne.NewSize(600, 300))
+	d.Show()
+}
+
+// fileEntryWithBrowse puts a file picker button next to a path entry
+func (sg *ScannerGUI) fileEntryWithBrowse(entry *widget.Entry) fyne.CanvasObject {
+	browse := widget.NewButton("📂", func() {
+		dialog.ShowFileOpen(func(reader fyne.URIReadCloser, err error) {
+			if err != nil || reader == nil {
+				return
+			}
+			entry.SetText(reader.URI().Path())
+			reader.Close()
+		}, sg.window)
+	})
+	return container.NewBorder(nil, nil, nil, browse, entry)
+}
+
+// importResults runs the import in the background and shows the merged result
+func (sg *ScannerGUI) importResults(format searcher.ImportFormat, resultsPath, mappingPath, reportPath string) {
+	showError := func(err error) {
+		fyne.Do(func() {
+			sg.statusLabel.SetText(sg.ui.T("gui.import.error", err))
+			sg.showUserError(err)
+		})
+	}
+
+	var mapping searcher.RuleMapping
+	if mappingPath != "" {
+		var err error
+		if mapping, err = searcher.LoadRuleMapping(mappingPath); err != nil {
+			showError(err)
+			return
+		}
+	}
+
+	result := sg.resultData
+	if reportPath != "" {
+		var err error
+		if result, err = searcher.LoadScanResult(reportPath); err != nil {
+			showError(err)
+			return
+		}
+	}
+	if result == nil {
+		result = searcher.NewScanResult()
+	}
+
+	findings, err := searcher.NewImporter(format, mapping).ImportFile(resultsPath)
+	if err != nil {
+		showError(err)
+		return
+	}
+	added, duplicates := searcher.MergeFindings(result, findings)
+
+	sg.showLoadedResult(result, sg.ui.T("gui.import.done", filepath.Base(resultsPath), added, duplicates))
+}
+
+// openReport loads a saved report (e.g. a .dllreport passed on the command
+// line by the file association) into the results view
+func (sg *ScannerGUI) openReport(path string) {
+	sg.statusLabel.SetText(sg.ui.T("gui.report.loading", filepath.Base(path)))
+	go func() {
+		result, err := searcher.LoadScanResult(path)
+		if err != nil {
+			fyne.Do(func() {
+				sg.statusLabel.SetText(sg.ui.T("gui.report.open_error", err))
+				sg.showUserError(err)
+			})
+			return
+		}
+		sg.showLoadedResult(result, sg.ui.T("gui.report.opened", filepath.Base(path), result.TotalFindings()))
+	}()
+}
+
+// showLoadedResult replaces the results view with a result that did not come
+// from a scan in this session
+func (sg *ScannerGUI) showLoadedResult(result *searcher.ScanResult, status string) {
+	sg.tagBaseline(result)
+	fyne.Do(func() {
+		sg.resultData = result
+		sg.ignoreRoot = result.Root
+		sg.results.Clear()
+		sg.results.SetFindings(result.Findings)
+		markTruncated(sg.ui, sg.results, result)
+		sg.findingsCount.Store(int64(result.TotalFindings()))
+
+		sg.refreshFilesList()
+		sg.updateStatsUI()
+		sg.exportButton.Enable()
+		sg.updateCoverageBanner()
+		sg.updateErrorsTab()
+
+		sg.statusLabel.SetText(status)
+		sg.window.SetTitle(searcher.FormatSummaryTitle(sg.ui, result) + " — " + sg.ui.T("gui.title"))
+	})
+}
@@ -1,8 +1,9 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"image/color"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -23,25 +24,69 @@ import (
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 	"github.com/kacebover/password-finder/encryptor"
+	"github.com/kacebover/password-finder/fsutil"
+	"github.com/kacebover/password-finder/gui/controller"
+	"github.com/kacebover/password-finder/locale"
 	"github.com/kacebover/password-finder/searcher"
 )
 
-// Colors
-var (
-	colorCritical = color.NRGBA{R: 220, G: 53, B: 69, A: 255}
-	colorHigh     = color.NRGBA{R: 253, G: 126, B: 20, A: 255}
-	colorMedium   = color.NRGBA{R: 255, G: 193, B: 7, A: 255}
-	colorLow      = color.NRGBA{R: 40, G: 167, B: 69, A: 255}
-)
-
 // Settings holds app configuration
 type Settings struct {
-	MaxFileSize    int64
-	Concurrency    int
-	FollowSymlinks bool
-	ScanBinaries   bool
-	ExcludeDirs    []string
-	ExcludeExts    []string
+	MaxFileSize    int64    `json:"max_file_size"`
+	Concurrency    int      `json:"concurrency"`
+	FollowSymlinks bool     `json:"follow_symlinks"`
+	ExternalLinks  bool     `json:"external_symlinks"` // Follow links leading outside the scanned folder
+	ScanBinaries   bool     `json:"scan_binaries"`
+	Gitignore      bool     `json:"gitignore"` // Skip paths excluded by .gitignore files
+	Entropy        bool     `json:"entropy"`   // Report high-entropy strings no pattern matched
+	ExcludeDirs    []string `json:"exclude_dirs"`
+	ExcludeExts    []string `json:"exclude_exts"`
+
+	// Severity per pattern type replacing the one of the rules, e.g. email: low
+	SeverityOverrides map[string]string `json:"severity_overrides,omitempty"`
+
+	MinSeverity string `json:"min_severity,omitempty"` // Findings below it are dropped, "" keeps all
+	MaxPerFile  int    `json:"max_per_file,omitempty"` // Matching in a file stops after this many findings, 0 for no limit
+
+	// Notifications of finished scans, see searcher.Scanner.SetNotifier
+	WebhookURL   string `json:"webhook_url,omitempty"`
+	SlackWebhook string `json:"slack_webhook,omitempty"`
+	NotifyOn     string `json:"notify_on,omitempty"` // summary, critical or all; "" is summary
+
+	Language string `json:"language,omitempty"` // ru or en; "" follows the locale of the system
+
+	// Editor findings are opened in, see BuildEditorCommand; "" is the first one installed
+	Editor         string `json:"editor,omitempty"`
+	EditorTemplate string `json:"editor_template,omitempty"` // Command of EditorCustom, e.g. "myeditor {file} +{line}"
+
+	DisabledPatterns []string `json:"disabled_patterns,omitempty"` // Pattern types not searched for, e.g. email
+
+	Theme string `json:"theme,omitempty"` // ThemeLight or ThemeDark; "" follows the system
+}
+
+// localizer returns the language of the interface, the one of the
+// settings or else the one of the environment
+func (s *Settings) localizer() *locale.Localizer {
+	lang, err := locale.Parse(s.Language)
+	if err != nil {
+		lang = locale.FromEnv()
+	}
+	return locale.New(lang)
+}
+
+// notifier returns the notifiers of the settings, nil without any
+func (s *Settings) notifier() searcher.Notifier {
+	var notifiers searcher.Notifiers
+	if s.WebhookURL != "" {
+		notifiers = append(notifiers, searcher.NewGenericWebhook(s.WebhookURL))
+	}
+	if s.SlackWebhook != "" {
+		notifiers = append(notifiers, searcher.NewSlackWebhook(s.SlackWebhook))
+	}
+	if len(notifiers) == 0 {
+		return nil
+	}
+	return notifiers
 }
 
 func defaultSettings() *Settings {
@@ -55,12 +100,15 @@ func defaultSettings() *Settings {
 	}
 }
 
-// FileWithFindings groups all findings for a single file
-type FileWithFindings struct {
-	FilePath    string
-	Findings    []*searcher.Finding
-	Selected    bool
-	MaxSeverity searcher.Severity
+// scanRequest remembers the options of a scan so it can be repeated
+type scanRequest struct {
+	Dir          string
+	ScanDocs     bool
+	ScanArchives bool
+	EnableOCR    bool
+	EnableAI     bool
+	FileType     string
+	Groups       []string // Optional detector groups, e.g. finance
 }
 
 // ScannerGUI represents the GUI application
@@ -73,12 +121,13 @@ type ScannerGUI struct {
 	outputDir *widget.Entry
 
 	// Buttons
-	scanButton     *widget.Button
-	pauseButton    *widget.Button
-	cancelButton   *widget.Button
-	exportButton   *widget.Button
-	encryptButton  *widget.Button
-	settingsButton *widget.Button
+	scanButton           *widget.Button
+	pauseButton          *widget.Button
+	cancelButton         *widget.Button
+	exportButton         *widget.Button
+	exportSelectedButton *widget.Button
+	encryptButton        *widget.Button
+	settingsButton       *widget.Button
 
 	// Progress
 	progressBar   *widget.ProgressBar
@@ -96,19 +145,43 @@ type ScannerGUI struct {
 
 	// Results - grouped by file
 	filesList          *widget.List
-	filesData          []*FileWithFindings
-	filesMutex         sync.RWMutex
+	results            *controller.ResultsModel // Findings by file with the selection and filters, see newResultsModel
 	detailContainer    *fyne.Container
-	selectedFile       *FileWithFindings
+	detailFindings     []*searcher.Finding   // Findings of selectedFile, most severe first
+	detailShown        int                   // How many of them the details panel shows
+	detailMore         fyne.CanvasObject     // "Показать ещё" under the shown findings
+	selectedFile       *controller.FileEntry // Copy of the file shown in the details panel
 	selectAllCheck     *widget.Check
 	selectedCountLabel *widget.Label
 
+	// Summary bar shown above the results after a scan
+	summaryBar   *fyne.Container
+	summaryLabel *widget.Label
+	rescanButton *widget.Button
+	lastScan     *scanRequest
+
+	// Banner shown when a requested capability was unavailable
+	coverageBanner *fyne.Container
+	coverageLabel  *widget.Label
+
+	// "Ошибки" tab listing the files the last scan could not read
+	resultTabs *container.AppTabs
+	errorsTab  *container.TabItem
+	errorsList *widget.List
+	errorsData []searcher.ScanError
+
+	// Answers of the "🤖 Объяснить" buttons, see findingExplainer
+	explainer *searcher.LocalAnalyzer
+
+	// Baseline report the results are compared with
+	baseline      atomic.Pointer[searcher.BaselineIndex]
+	baselineLabel *widget.Label
+	onlyNewCheck  *widget.Check
+
 	// Search/Filter
 	searchEntry    *widget.Entry
 	severitySelect *widget.Select
 	fileTypeSelect *widget.Select
-	filterText     string
-	filterSeverity string
 	filterFileType string
 
 	// Scan options
@@ -116,65 +189,95 @@ type ScannerGUI struct {
 	scanArchivesCheck *widget.Check
 	enableOCRCheck    *widget.Check
 	enableAICheck     *widget.Check
+	financeCheck      *widget.Check
 	fileTypeFilter    *widget.Select
 
 	// State
-	resultData  *searcher.ScanResult
-	scanning    atomic.Bool
-	paused      atomic.Bool
-	cancelled   atomic.Bool
-	encrypting  atomic.Bool
-	scanMutex   sync.Mutex
-	settings    *Settings
-	ignoreList  map[string]bool
-	ignoreMutex sync.Mutex
+	resultData     *searcher.ScanResult
+	history        *controller.ResultHistory // Previous results are archived here
+	currentScanner atomic.Pointer[searcher.Scanner]
+	scanning       atomic.Bool
+	checking       atomic.Bool // Dependencies are probed before a scan starts
+	paused         atomic.Bool
+	cancelled      atomic.Bool
+	encrypting     atomic.Bool
+	scanMutex      sync.Mutex
+	cancelScan     context.CancelFunc // Stops the scan in progress, guarded by scanMutex
+	settings       *Settings
+	ignoreList     map[string]bool // Keys made by ignoreKey
+	ignoreRoot     string          // Scan root the ignore keys are relative to
+	ignoreMutex    sync.Mutex
+	statePath      string            // Settings file, empty when settings are not saved
+	ui             *locale.Localizer // Language of the interface and the reports, chosen at start
 
 	// Progress tracking
 	filesQueued    atomic.Int64
 	filesProcessed atomic.Int64
+	filesSkipped   atomic.Int64
+	scanErrors     atomic.Int64
 	findingsCount  atomic.Int64
+	listChanged    atomic.Bool // Live findings arrived since the list was redrawn
 	startTime      time.Time
+	pausedFor      atomic.Int64 // Paused time of the scan, kept after the scanner is gone
 }
 
 // NewScannerGUI creates a new GUI instance
 func NewScannerGUI() *ScannerGUI {
 	a := app.NewWithID("com.dataleaklocator.app")
-	w := a.NewWindow("🔍 Поиск Утечек Данных")
+	w := a.NewWindow("")
 	w.Resize(fyne.NewSize(1400, 900))
 	w.CenterOnScreen()
 
 	sg := &ScannerGUI{
 		app:        a,
 		window:     w,
-		filesData:  make([]*FileWithFindings, 0),
 		settings:   defaultSettings(),
 		ignoreList: make(map[string]bool),
+		history:    controller.DefaultResultHistory(),
 	}
+	sg.results = sg.newResultsModel()
 
+	state := sg.loadState()
+	sg.ui = sg.settings.localizer()
+	w.SetTitle(sg.ui.T("gui.title"))
+	sg.applyTheme()
 	sg.buildUI()
+	if state.OutputDir != "" {
+		sg.outputDir.SetText(state.OutputDir)
+	}
+	sg.scanDir.SetText(state.LastScanDir)
 	sg.setupShortcuts()
 	return sg
 }
 
 func (sg *ScannerGUI) buildUI() {
 	// === HEADER ===
-	titleText := canvas.NewText("🔍 Поиск Утечек Данных", theme.ForegroundColor())
+	titleText := canvas.NewText(sg.ui.T("gui.title"), theme.ForegroundColor())
 	titleText.TextSize = 28
 	titleText.TextStyle.Bold = true
 
-	subtitleText := canvas.NewText("Сканер Безопасности", theme.ForegroundColor())
+	subtitleText := canvas.NewText(sg.ui.T("gui.subtitle"), theme.ForegroundColor())
 	subtitleText.TextSize = 14
 
-	sg.settingsButton = widget.NewButton("⚙️ Настройки", sg.showSettings)
+	sg.settingsButton = widget.NewButton(sg.ui.T("gui.settings"), sg.showSettings)
 	sg.settingsButton.Importance = widget.LowImportance
 
-	helpButton := widget.NewButton("❓ Справка", sg.showHelp)
+	helpButton := widget.NewButton(sg.ui.T("gui.help"), sg.showHelp)
 	helpButton.Importance = widget.LowImportance
 
+	rulesButton := widget.NewButton(sg.ui.T("gui.rules_bench"), sg.showRulesBench)
+	rulesButton.Importance = widget.LowImportance
+
+	importButton := widget.NewButton(sg.ui.T("gui.import"), sg.showImportResults)
+	importButton.Importance = widget.LowImportance
+
+	diagnosticsButton := widget.NewButton(sg.ui.T("gui.diagnostics"), sg.showDiagnostics)
+	diagnosticsButton.Importance = widget.LowImportance
+
 	header := container.NewBorder(
 		nil, nil,
 		container.NewVBox(titleText, subtitleText),
-		container.NewHBox(sg.settingsButton, helpButton),
+		container.NewHBox(importButton, rulesButton, diagnosticsButton, sg.settingsButton, helpButton),
 	)
 
 	// === LEFT PANEL - CONTROLS ===
@@ -204,15 +307,15 @@ func (sg *ScannerGUI) buildUI() {
 
 func (sg *ScannerGUI) buildControlPanel() fyne.CanvasObject {
 	// Target directory section
-	dirLabel := widget.NewLabelWithStyle("📁 Целевая Директория", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	dirLabel := widget.NewLabelWithStyle(sg.ui.T("gui.scan_dir"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
 
 	sg.scanDir = widget.NewEntry()
-	sg.scanDir.SetPlaceHolder("Выберите или введите путь к директории...")
+	sg.scanDir.SetPlaceHolder(sg.ui.T("gui.scan_dir_placeholder"))
 
-	browseBtn := widget.NewButton("📂 Обзор...", func() {
+	browseBtn := widget.NewButton(sg.ui.T("gui.browse"), func() {
 		dialog.ShowFolderOpen(func(uri fyne.ListableURI, err error) {
 			if err != nil {
-				dialog.ShowError(err, sg.window)
+				sg.showUserError(err)
 				return
 			}
 			if uri != nil {
@@ -223,19 +326,19 @@ func (sg *ScannerGUI) buildControlPanel() fyne.CanvasObject {
 	browseBtn.Importance = widget.MediumImportance
 
 	// Quick access buttons
-	homeBtn := widget.NewButton("🏠 Домой", func() {
+	homeBtn := widget.NewButton(sg.ui.T("gui.home"), func() {
 		home, _ := os.UserHomeDir()
 		sg.scanDir.SetText(home)
 	})
 	homeBtn.Importance = widget.LowImportance
 
-	testBtn := widget.NewButton("📂 Тест", func() {
+	testBtn := widget.NewButton(sg.ui.T("gui.test_dir"), func() {
 		wd, _ := os.Getwd()
 		sg.scanDir.SetText(filepath.Join(wd, "testdata"))
 	})
 	testBtn.Importance = widget.LowImportance
 
-	cwdBtn := widget.NewButton("📁 Текущая", func() {
+	cwdBtn := widget.NewButton(sg.ui.T("gui.cwd"), func() {
 		wd, _ := os.Getwd()
 		sg.scanDir.SetText(wd)
 	})
@@ -251,11 +354,11 @@ func (sg *ScannerGUI) buildControlPanel() fyne.CanvasObject {
 	)
 
 	// Output directory section
-	outLabel := widget.NewLabelWithStyle("📊 Папка для Отчётов", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	outLabel := widget.NewLabelWithStyle(sg.ui.T("gui.output_dir"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
 	sg.outputDir = widget.NewEntry()
 	sg.outputDir.SetText("./reports")
 
-	outBrowseBtn := widget.NewButton("📂 Обзор...", func() {
+	outBrowseBtn := widget.NewButton(sg.ui.T("gui.browse"), func() {
 		dialog.ShowFolderOpen(func(uri fyne.ListableURI, err error) {
 			if err == nil && uri != nil {
 				sg.outputDir.SetText(uri.Path())
@@ -271,22 +374,26 @@ func (sg *ScannerGUI) buildControlPanel() fyne.CanvasObject {
 	)
 
 	// File type filter section
-	fileTypeLabel := widget.NewLabelWithStyle("📂 Типы Файлов", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	fileTypeLabel := widget.NewLabelWithStyle(sg.ui.T("gui.file_types"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
 	sg.fileTypeFilter = widget.NewSelect(
 		[]string{
-			"Все файлы",
-			"Только текст/код (.txt, .json, .env, .go, .py...)",
-			"Только документы (.pdf, .docx, .xlsx...)",
-			"Только изображения (.png, .jpg, .gif...)",
-			"Только архивы (.zip, .tar, .gz...)",
+			sg.ui.T("gui.filetype.all"),
+			sg.ui.T("gui.filetype.text"),
+			sg.ui.T("gui.filetype.documents"),
+			sg.ui.T("gui.filetype.images"),
+			sg.ui.T("gui.filetype.archives"),
 		},
 		func(s string) {
 			sg.filterFileType = s
+			sg.results.SetFileTypeFilter(sg.fileTypeExtensions(s))
+			if sg.filesList != nil {
+				sg.refreshFilesList()
+			}
 		},
 	)
-	sg.fileTypeFilter.SetSelected("Все файлы")
+	sg.fileTypeFilter.SetSelected(sg.ui.T("gui.filetype.all"))
 
-	fileTypeHint := widget.NewLabel("Какие файлы сканировать")
+	fileTypeHint := widget.NewLabel(sg.ui.T("gui.file_types_hint"))
 	fileTypeHint.TextStyle.Italic = true
 
 	fileTypeSection := container.NewVBox(
@@ -296,12 +403,13 @@ func (sg *ScannerGUI) buildControlPanel() fyne.CanvasObject {
 	)
 
 	// Additional scan options section
-	optionsLabel := widget.NewLabelWithStyle("⚙️ Доп. Обработка", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	optionsLabel := widget.NewLabelWithStyle(sg.ui.T("gui.options"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
 
-	sg.scanDocsCheck = widget.NewCheck("📄 Извлекать текст из PDF/DOCX/XLSX", nil)
-	sg.scanArchivesCheck = widget.NewCheck("📦 Сканировать внутри архивов", nil)
-	sg.enableOCRCheck = widget.NewCheck("🔍 OCR для изображений (нужен Tesseract)", nil)
-	sg.enableAICheck = widget.NewCheck("🤖 AI-анализ после скана (нужен Ollama)", nil)
+	sg.scanDocsCheck = widget.NewCheck(sg.ui.T("gui.option.documents"), nil)
+	sg.scanArchivesCheck = widget.NewCheck(sg.ui.T("gui.option.archives"), nil)
+	sg.enableOCRCheck = widget.NewCheck(sg.ui.T("gui.option.ocr"), nil)
+	sg.enableAICheck = widget.NewCheck(sg.ui.T("gui.option.ai"), nil)
+	sg.financeCheck = widget.NewCheck(sg.ui.T("gui.option.finance"), nil)
 
 	optionsSection := container.NewVBox(
 		fileTypeSection,
@@ -311,29 +419,36 @@ func (sg *ScannerGUI) buildControlPanel() fyne.CanvasObject {
 		sg.scanArchivesCheck,
 		sg.enableOCRCheck,
 		sg.enableAICheck,
+		sg.financeCheck,
 	)
 
 	// Control buttons
-	sg.scanButton = widget.NewButton("▶️ НАЧАТЬ СКАНИРОВАНИЕ", sg.onStartScan)
+	sg.scanButton = widget.NewButton(sg.ui.T("gui.start"), sg.onStartScan)
 	sg.scanButton.Importance = widget.HighImportance
 
-	sg.pauseButton = widget.NewButton("⏸️ Пауза", sg.onPauseScan)
+	sg.pauseButton = widget.NewButton(sg.ui.T("gui.pause"), sg.onPauseScan)
 	sg.pauseButton.Disable()
 
-	sg.cancelButton = widget.NewButton("⏹️ Отмена", sg.onCancelScan)
+	sg.cancelButton = widget.NewButton(sg.ui.T("gui.cancel"), sg.onCancelScan)
 	sg.cancelButton.Importance = widget.DangerImportance
 	sg.cancelButton.Disable()
 
-	sg.exportButton = widget.NewButton("💾 Экспорт Отчёта", sg.onExport)
+	sg.exportButton = widget.NewButton(sg.ui.T("gui.export"), sg.onExport)
 	sg.exportButton.Disable()
 
-	sg.encryptButton = widget.NewButton("🔐 Зашифровать", sg.onEncrypt)
+	sg.exportSelectedButton = widget.NewButton(sg.ui.T("gui.export_selected"), sg.onExportSelected)
+	sg.exportSelectedButton.Disable()
+
+	sg.encryptButton = widget.NewButton(sg.ui.T("gui.encrypt"), sg.onEncrypt)
 	sg.encryptButton.Importance = widget.HighImportance
 	sg.encryptButton.Disable()
 
-	controlButtons := container.NewGridWithColumns(2,
-		sg.scanButton, sg.pauseButton,
-		sg.cancelButton, sg.exportButton,
+	controlButtons := container.NewVBox(
+		container.NewGridWithColumns(2,
+			sg.scanButton, sg.pauseButton,
+			sg.cancelButton, sg.exportButton,
+		),
+		sg.exportSelectedButton,
 	)
 
 	encryptRow := container.NewVBox(
@@ -342,16 +457,16 @@ func (sg *ScannerGUI) buildControlPanel() fyne.CanvasObject {
 	)
 
 	// Progress section
-	progressLabel := widget.NewLabelWithStyle("📈 Прогресс", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	progressLabel := widget.NewLabelWithStyle(sg.ui.T("gui.progress"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
 
 	sg.progressBar = widget.NewProgressBar()
 	sg.progressBar.Min = 0
 	sg.progressBar.Max = 1
 	sg.progressBar.SetValue(0)
 
-	sg.progressLabel = widget.NewLabel("0 / 0 файлов")
-	sg.statusLabel = widget.NewLabel("Готов к сканированию")
-	sg.timeLabel = widget.NewLabel("Время: --")
+	sg.progressLabel = widget.NewLabel(sg.ui.T("gui.progress_empty"))
+	sg.statusLabel = widget.NewLabel(sg.ui.T("gui.ready"))
+	sg.timeLabel = widget.NewLabel(sg.ui.T("gui.time_empty"))
 
 	progressSection := container.NewVBox(
 		progressLabel,
@@ -361,7 +476,7 @@ func (sg *ScannerGUI) buildControlPanel() fyne.CanvasObject {
 	)
 
 	// Statistics section
-	statsLabel := widget.NewLabelWithStyle("📊 Статистика", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	statsLabel := widget.NewLabelWithStyle(sg.ui.T("gui.stats"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
 
 	sg.totalLabel = widget.NewLabel("0")
 	sg.totalLabel.TextStyle.Bold = true
@@ -372,15 +487,15 @@ func (sg *ScannerGUI) buildControlPanel() fyne.CanvasObject {
 	sg.filesLabel = widget.NewLabel("0")
 
 	statsGrid := container.NewGridWithColumns(5,
-		container.NewVBox(widget.NewLabel("Всего"), sg.totalLabel),
-		container.NewVBox(widget.NewLabel("🔴 Крит."), sg.criticalLabel),
-		container.NewVBox(widget.NewLabel("🟠 Выс."), sg.highLabel),
-		container.NewVBox(widget.NewLabel("🟡 Сред."), sg.mediumLabel),
-		container.NewVBox(widget.NewLabel("🟢 Низ."), sg.lowLabel),
+		container.NewVBox(widget.NewLabel(sg.ui.T("gui.total")), sg.totalLabel),
+		container.NewVBox(widget.NewLabel(sg.ui.T("gui.critical_short")), sg.criticalLabel),
+		container.NewVBox(widget.NewLabel(sg.ui.T("gui.high_short")), sg.highLabel),
+		container.NewVBox(widget.NewLabel(sg.ui.T("gui.medium_short")), sg.mediumLabel),
+		container.NewVBox(widget.NewLabel(sg.ui.T("gui.low_short")), sg.lowLabel),
 	)
 
 	filesRow := container.NewHBox(
-		widget.NewLabel("Файлов просканировано:"),
+		widget.NewLabel(sg.ui.T("gui.files_scanned")),
 		sg.filesLabel,
 	)
 
@@ -416,9 +531,7 @@ func (sg *ScannerGUI) buildResultsPanel() fyne.CanvasObject {
 	// Files list - grouped by file path
 	sg.filesList = widget.NewList(
 		func() int {
-			sg.filesMutex.RLock()
-			defer sg.filesMutex.RUnlock()
-			return len(sg.getFilteredFiles())
+			return sg.results.FilteredLen()
 		},
 		func() fyne.CanvasObject {
 			return sg.createFileItem()
@@ -429,54 +542,51 @@ func (sg *ScannerGUI) buildResultsPanel() fyne.CanvasObject {
 	)
 
 	sg.filesList.OnSelected = func(id widget.ListItemID) {
-		sg.filesMutex.RLock()
-		filtered := sg.getFilteredFiles()
-		if id < len(filtered) {
-			sg.selectedFile = filtered[id]
+		if file, ok := sg.results.FilteredFile(id); ok {
+			sg.selectedFile = &file
 		}
-		sg.filesMutex.RUnlock()
 		sg.updateDetailsPanel()
 	}
 
 	// Search and filter bar
 	sg.searchEntry = widget.NewEntry()
-	sg.searchEntry.SetPlaceHolder("🔍 Поиск по имени файла...")
+	sg.searchEntry.SetPlaceHolder(sg.ui.T("gui.search_placeholder"))
 	sg.searchEntry.OnChanged = func(s string) {
-		sg.filterText = s
+		sg.results.SetTextFilter(s)
 		sg.refreshFilesList()
 	}
 
 	sg.severitySelect = widget.NewSelect(
-		[]string{"Все уровни", "Критический", "Высокий", "Средний", "Низкий"},
+		[]string{sg.ui.T("gui.all_levels"), sg.ui.Severity(string(searcher.Critical)), sg.ui.Severity(string(searcher.High)), sg.ui.Severity(string(searcher.Medium)), sg.ui.Severity(string(searcher.Low))},
 		func(s string) {
-			sg.filterSeverity = s
+			sg.results.SetSeverityFilter(sg.severityFilter(s))
 			sg.refreshFilesList()
 		},
 	)
-	sg.severitySelect.SetSelected("Все уровни")
+	sg.severitySelect.SetSelected(sg.ui.T("gui.all_levels"))
 
 	filterBar := container.NewBorder(nil, nil, nil, sg.severitySelect, sg.searchEntry)
 
-	resultsHeader := widget.NewLabelWithStyle("📁 Файлы с уязвимостями", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	resultsHeader := widget.NewLabelWithStyle(sg.ui.T("gui.results"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
 
 	// Selection toolbar
-	sg.selectAllCheck = widget.NewCheck("Выбрать все", func(checked bool) {
+	sg.selectAllCheck = widget.NewCheck(sg.ui.T("gui.select_all"), func(checked bool) {
 		sg.toggleSelectAll(checked)
 	})
 
-	sg.selectedCountLabel = widget.NewLabel("0 файлов выбрано")
+	sg.selectedCountLabel = widget.NewLabel(sg.ui.T("gui.none_selected"))
 
-	selectCriticalBtn := widget.NewButton("🔴 Крит.", func() {
+	selectCriticalBtn := widget.NewButton(sg.ui.T("gui.critical_short"), func() {
 		sg.selectBySeverity(searcher.Critical)
 	})
 	selectCriticalBtn.Importance = widget.DangerImportance
 
-	selectHighBtn := widget.NewButton("🟠 Выс.", func() {
+	selectHighBtn := widget.NewButton(sg.ui.T("gui.high_short"), func() {
 		sg.selectBySeverity(searcher.High)
 	})
 	selectHighBtn.Importance = widget.WarningImportance
 
-	clearSelectionBtn := widget.NewButton("Сброс", func() {
+	clearSelectionBtn := widget.NewButton(sg.ui.T("gui.clear_selection"), func() {
 		sg.toggleSelectAll(false)
 		fyne.Do(func() {
 			sg.selectAllCheck.SetChecked(false)
@@ -498,10 +608,17 @@ func (sg *ScannerGUI) buildResultsPanel() fyne.CanvasObject {
 		layout.NewSpacer(),
 	)
 
+	sg.summaryLabel = widget.NewLabel("")
+	sg.summaryLabel.Wrapping = fyne.TextWrapWord
+	sg.rescanButton = widget.NewButton(sg.ui.T("gui.rescan"), sg.onRescan)
+	sg.rescanButton.Importance = widget.LowImportance
+	sg.summaryBar = container.NewBorder(nil, widget.NewSeparator(), nil, sg.rescanButton, sg.summaryLabel)
+	sg.summaryBar.Hide()
+
 	resultsPanel := container.NewBorder(
-		container.NewVBox(resultsHeader, filterBar, widget.NewSeparator(), selectionBar, selectedInfoBar, widget.NewSeparator()),
+		container.NewVBox(sg.summaryBar, sg.buildCoverageBanner(), resultsHeader, filterBar, sg.buildBaselineBar(), widget.NewSeparator(), selectionBar, selectedInfoBar, widget.NewSeparator()),
 		nil, nil, nil,
-		sg.filesList,
+		sg.buildResultTabs(),
 	)
 
 	return container.NewPadded(resultsPanel)
@@ -516,33 +633,35 @@ func (sg *ScannerGUI) createFileItem() fyne.CanvasObject {
 	severityIcon.CornerRadius = 6
 	severityIcon.SetMinSize(fyne.NewSize(12, 12))
 
-	fileName := widget.NewLabel("имя_файла.txt")
+	fileName := widget.NewLabel("file_name.txt")
 	fileName.TextStyle.Bold = true
 	fileName.Truncation = fyne.TextTruncateEllipsis
 
-	filePath := widget.NewLabel("/путь/к/файлу")
+	filePath := widget.NewLabel("/path/to/file")
 	filePath.Truncation = fyne.TextTruncateEllipsis
 
-	findingsCount := widget.NewLabel("0 уязвимостей")
+	findingsCount := widget.NewLabel("0")
 
 	iconContainer := container.NewCenter(severityIcon)
 
+	// Shown for files with findings missing from the baseline
+	newBadge := widget.NewLabelWithStyle("🆕 NEW", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	newBadge.Importance = widget.SuccessImportance
+	newBadge.Hide()
+
 	return container.NewHBox(
 		checkbox,
 		iconContainer,
 		container.NewVBox(fileName, filePath, findingsCount),
+		newBadge,
 	)
 }
 
 func (sg *ScannerGUI) updateFileItem(id widget.ListItemID, obj fyne.CanvasObject) {
-	sg.filesMutex.RLock()
-	filtered := sg.getFilteredFiles()
-	if id >= len(filtered) {
-		sg.filesMutex.RUnlock()
+	file, ok := sg.results.FilteredFile(id)
+	if !ok {
 		return
 	}
-	file := filtered[id]
-	sg.filesMutex.RUnlock()
 
 	hbox := obj.(*fyne.Container)
 	checkbox := hbox.Objects[0].(*widget.Check)
@@ -552,231 +671,106 @@ func (sg *ScannerGUI) updateFileItem(id widget.ListItemID, obj fyne.CanvasObject
 	fileNameLabel := vbox.Objects[0].(*widget.Label)
 	filePathLabel := vbox.Objects[1].(*widget.Label)
 	countLabel := vbox.Objects[2].(*widget.Label)
+	newBadge := hbox.Objects[3].(*widget.Label)
 
 	// IMPORTANT: Disable callback before setting checked state to avoid the scrolling bug
 	checkbox.OnChanged = nil
 	checkbox.SetChecked(file.Selected)
+	if len(file.Findings) == 0 {
+		checkbox.Disable()
+	} else {
+		checkbox.Enable()
+	}
 
 	// Create a closure that captures the file path, not the index
 	filePath := file.FilePath
 	checkbox.OnChanged = func(checked bool) {
-		sg.filesMutex.Lock()
-		for _, f := range sg.filesData {
-			if f.FilePath == filePath {
-				f.Selected = checked
-				break
-			}
-		}
-		sg.filesMutex.Unlock()
+		sg.results.SelectFile(filePath, checked)
 		sg.updateSelectedCount()
 		sg.updateEncryptButtonState()
 	}
 
 	// Set severity color based on max severity in file
-	switch file.MaxSeverity {
-	case searcher.Critical:
-		rect.FillColor = colorCritical
-	case searcher.High:
-		rect.FillColor = colorHigh
-	case searcher.Medium:
-		rect.FillColor = colorMedium
-	case searcher.Low:
-		rect.FillColor = colorLow
+	rect.FillColor = theme.Color(severityColorName(file.MaxSeverity))
+	if len(file.Findings) == 0 {
+		rect.FillColor = theme.DisabledColor()
 	}
 	rect.Refresh()
 
-	// Count findings by severity
-	var critical, high, medium, low int
-	for _, f := range file.Findings {
-		switch f.Severity {
-		case searcher.Critical:
-			critical++
-		case searcher.High:
-			high++
-		case searcher.Medium:
-			medium++
-		case searcher.Low:
-			low++
-		}
-	}
-
 	// Show file name (can be long, so truncate if needed)
-	fileName := filepath.Base(file.FilePath)
+	fileName, dirPath := fileDisplayName(&file)
 	if len(fileName) > 50 {
 		fileName = truncatePath(fileName, 50)
 	}
 	fileNameLabel.SetText(fileName)
 
 	// Show directory path (truncate to show beginning and end)
-	dirPath := filepath.Dir(file.FilePath)
 	if len(dirPath) > 60 {
 		dirPath = truncatePath(dirPath, 60)
 	}
 	filePathLabel.SetText(dirPath)
 
 	countParts := []string{}
-	if critical > 0 {
-		countParts = append(countParts, fmt.Sprintf("🔴%d", critical))
+	if file.Counts.Critical > 0 {
+		countParts = append(countParts, fmt.Sprintf("🔴%d", file.Counts.Critical))
 	}
-	if high > 0 {
-		countParts = append(countParts, fmt.Sprintf("🟠%d", high))
+	if file.Counts.High > 0 {
+		countParts = append(countParts, fmt.Sprintf("🟠%d", file.Counts.High))
 	}
-	if medium > 0 {
-		countParts = append(countParts, fmt.Sprintf("🟡%d", medium))
+	if file.Counts.Medium > 0 {
+		countParts = append(countParts, fmt.Sprintf("🟡%d", file.Counts.Medium))
 	}
-	if low > 0 {
-		countParts = append(countParts, fmt.Sprintf("🟢%d", low))
+	if file.Counts.Low > 0 {
+		countParts = append(countParts, fmt.Sprintf("🟢%d", file.Counts.Low))
 	}
-	countLabel.SetText(fmt.Sprintf("%d уязвимостей: %s", len(file.Findings), strings.Join(countParts, " ")))
-}
-
-func (sg *ScannerGUI) severityToRussian(s searcher.Severity) string {
-	switch s {
-	case searcher.Critical:
-		return "Критич."
-	case searcher.High:
-		return "Высокий"
-	case searcher.Medium:
-		return "Средний"
-	case searcher.Low:
-		return "Низкий"
-	default:
-		return string(s)
+	if file.Truncated != "" {
+		countParts = append(countParts, "✂️ "+file.Truncated)
 	}
-}
+	if file.Fixed > 0 {
+		countParts = append(countParts, sg.ui.T("gui.fixed_count", file.Fixed))
+	}
+	countLabel.SetText(sg.ui.T("gui.file_counts", len(file.Findings), strings.Join(countParts, " ")))
 
-func (sg *ScannerGUI) patternToRussian(p searcher.PatternType) string {
-	switch p {
-	case searcher.PatternPassword:
-		return "Пароль"
-	case searcher.PatternAPIKey:
-		return "API-ключ"
-	case searcher.PatternToken:
-		return "Токен"
-	case searcher.PatternPrivateKey:
-		return "Приватный ключ"
-	case searcher.PatternAWSKey:
-		return "AWS ключ"
-	case searcher.PatternGitHubToken:
-		return "GitHub токен"
-	case searcher.PatternEmail:
-		return "Email"
-	case searcher.PatternPhoneNumber:
-		return "Телефон"
-	case searcher.PatternSSN:
-		return "SSN"
-	case searcher.PatternCreditCard:
-		return "Банк. карта"
-	case searcher.PatternJSONSecret:
-		return "JSON секрет"
-	case searcher.PatternEnvVar:
-		return "Перем. окружения"
-	case searcher.PatternConnectionStr:
-		return "Строка подключ."
+	switch {
+	case file.HasNew():
+		newBadge.SetText("🆕 NEW")
+		newBadge.Show()
+	case len(file.Findings) == 0 && file.Fixed > 0:
+		newBadge.SetText(sg.ui.T("gui.fixed"))
+		newBadge.Show()
 	default:
-		return string(p)
+		newBadge.Hide()
 	}
 }
 
-func (sg *ScannerGUI) descriptionToRussian(desc string) string {
-	translations := map[string]string{
-		"Password assignment detected":             "Обнаружено присвоение пароля",
-		"API Key detected":                         "Обнаружен API-ключ",
-		"Authentication token detected":            "Обнаружен токен аутентификации",
-		"Private key detected":                     "Обнаружен приватный ключ",
-		"AWS Access Key detected":                  "Обнаружен AWS ключ доступа",
-		"GitHub token detected":                    "Обнаружен GitHub токен",
-		"Email address detected":                   "Обнаружен email адрес",
-		"Phone number detected":                    "Обнаружен номер телефона",
-		"Social Security Number detected":          "Обнаружен SSN",
-		"Credit card number detected":              "Обнаружен номер банк. карты",
-		"JSON secret detected":                     "Обнаружен секрет в JSON",
-		"YAML secret detected":                     "Обнаружен секрет в YAML",
-		"Environment variable assignment detected": "Обнаружена переменная окружения",
-		"Connection string detected":               "Обнаружена строка подключения",
-		"Hardcoded secret detected":                "Обнаружен захардкоженный секрет",
-		"IBAN detected":                            "Обнаружен IBAN",
-		"BIC code detected":                        "Обнаружен BIC код",
-		"Passport number detected":                 "Обнаружен номер паспорта",
-	}
-	if ru, ok := translations[desc]; ok {
-		return ru
-	}
-	return desc
+// severityName names a severity level in the language of the interface
+func (sg *ScannerGUI) severityName(s searcher.Severity) string {
+	return sg.ui.Severity(string(s))
 }
 
-// getFilteredFiles returns files matching current filters
-func (sg *ScannerGUI) getFilteredFiles() []*FileWithFindings {
-	var result []*FileWithFindings
-
-	// Map filter names to severity values
-	filterToSeverity := map[string]searcher.Severity{
-		"Критический": searcher.Critical,
-		"Высокий":     searcher.High,
-		"Средний":     searcher.Medium,
-		"Низкий":      searcher.Low,
-	}
-
-	for _, file := range sg.filesData {
-		// Check ignore list
-		if sg.ignoreList[file.FilePath] {
-			continue
-		}
-
-		// Check severity filter
-		if sg.filterSeverity != "" && sg.filterSeverity != "Все уровни" {
-			targetSeverity, ok := filterToSeverity[sg.filterSeverity]
-			if ok {
-				hasMatchingSeverity := false
-				for _, f := range file.Findings {
-					if f.Severity == targetSeverity {
-						hasMatchingSeverity = true
-						break
-					}
-				}
-				if !hasMatchingSeverity {
-					continue
-				}
-			}
-		}
-
-		// Check text filter
-		if sg.filterText != "" {
-			searchLower := strings.ToLower(sg.filterText)
-			// Search in file path and finding descriptions
-			matchFound := strings.Contains(strings.ToLower(file.FilePath), searchLower)
-			if !matchFound {
-				for _, f := range file.Findings {
-					if strings.Contains(strings.ToLower(f.Description), searchLower) ||
-						strings.Contains(strings.ToLower(string(f.PatternType)), searchLower) {
-						matchFound = true
-						break
-					}
-				}
-			}
-			if !matchFound {
-				continue
-			}
-		}
-
-		result = append(result, file)
+// severityIcon is the colored circle of a severity level
+func severityIcon(s searcher.Severity) string {
+	switch s {
+	case searcher.Critical:
+		return "🔴"
+	case searcher.High:
+		return "🟠"
+	case searcher.Medium:
+		return "🟡"
+	case searcher.Low:
+		return "🟢"
 	}
+	return ""
+}
 
-	// Sort by max severity (Critical first)
-	sort.Slice(result, func(i, j int) bool {
-		return result[i].MaxSeverity.Score() > result[j].MaxSeverity.Score()
-	})
-
-	return result
+// patternName names a pattern type in the language of the interface
+func (sg *ScannerGUI) patternName(p searcher.PatternType) string {
+	return sg.ui.Pattern(string(p))
 }
 
 // toggleSelectAll selects or deselects all visible files
 func (sg *ScannerGUI) toggleSelectAll(checked bool) {
-	sg.filesMutex.Lock()
-	for _, file := range sg.filesData {
-		file.Selected = checked
-	}
-	sg.filesMutex.Unlock()
+	sg.results.SelectAll(checked)
 
 	sg.refreshFilesList()
 	sg.updateSelectedCount()
@@ -785,16 +779,7 @@ func (sg *ScannerGUI) toggleSelectAll(checked bool) {
 
 // selectBySeverity selects all files containing findings of a given severity
 func (sg *ScannerGUI) selectBySeverity(severity searcher.Severity) {
-	sg.filesMutex.Lock()
-	for _, file := range sg.filesData {
-		for _, f := range file.Findings {
-			if f.Severity == severity {
-				file.Selected = true
-				break
-			}
-		}
-	}
-	sg.filesMutex.Unlock()
+	sg.results.SelectBySeverity(severity)
 
 	sg.refreshFilesList()
 	sg.updateSelectedCount()
@@ -803,25 +788,17 @@ func (sg *ScannerGUI) selectBySeverity(severity searcher.Severity) {
 
 // updateSelectedCount updates the label showing how many files are selected
 func (sg *ScannerGUI) updateSelectedCount() {
-	sg.filesMutex.RLock()
-	selectedCount := 0
-	totalFindings := 0
-	for _, file := range sg.filesData {
-		if file.Selected {
-			selectedCount++
-			totalFindings += len(file.Findings)
-		}
-	}
-	sg.filesMutex.RUnlock()
+	selectedCount, totalFindings := sg.results.SelectionCount()
 
 	fyne.Do(func() {
 		if sg.selectedCountLabel != nil {
 			if selectedCount == 0 {
-				sg.selectedCountLabel.SetText("0 файлов выбрано")
+				sg.selectedCountLabel.SetText(sg.ui.T("gui.none_selected"))
 			} else {
-				sg.selectedCountLabel.SetText(fmt.Sprintf("📁 %d файлов (%d уязвимостей)", selectedCount, totalFindings))
+				sg.selectedCountLabel.SetText(sg.ui.T("gui.selected", selectedCount, totalFindings))
 			}
 		}
+		sg.updateExportSelectedState(selectedCount)
 	})
 }
 
@@ -831,7 +808,7 @@ func (sg *ScannerGUI) updateEncryptButtonState() {
 		return
 	}
 
-	paths := sg.getSelectedFilePaths()
+	paths := sg.results.GetSelectedPaths()
 	canEncrypt := len(paths) > 0 && !sg.scanning.Load() && !sg.encrypting.Load()
 
 	fyne.Do(func() {
@@ -843,19 +820,20 @@ func (sg *ScannerGUI) updateEncryptButtonState() {
 	})
 }
 
-// getSelectedFilePaths returns selected file paths
-func (sg *ScannerGUI) getSelectedFilePaths() []string {
-	sg.filesMutex.RLock()
-	defer sg.filesMutex.RUnlock()
-
-	var paths []string
-	for _, file := range sg.filesData {
-		if file.Selected {
-			paths = append(paths, file.FilePath)
+// selectedManifestSource describes the selected files and their findings
+// for the manifest of the archive, see controller.ManifestSource
+func (sg *ScannerGUI) selectedManifestSource() *encryptor.ManifestSource {
+	findings := sg.results.SelectedFindings()
+
+	var root string
+	var scanTime time.Time
+	if result := sg.resultData; result != nil {
+		root = result.Root
+		if result.StartTime > 0 {
+			scanTime = time.Unix(result.StartTime, 0)
 		}
 	}
-
-	return paths
+	return controller.ManifestSource(findings, root, scanTime)
 }
 
 func (sg *ScannerGUI) refreshFilesList() {
@@ -866,12 +844,12 @@ func (sg *ScannerGUI) refreshFilesList() {
 
 func (sg *ScannerGUI) buildDetailsPanel() fyne.CanvasObject {
 	sg.detailContainer = container.NewVBox(
-		widget.NewLabel("Выберите файл для просмотра уязвимостей"),
+		widget.NewLabel(sg.ui.T("gui.select_file")),
 	)
 
 	scroll := container.NewScroll(sg.detailContainer)
 
-	detailsHeader := widget.NewLabelWithStyle("📋 Уязвимости в файле", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	detailsHeader := widget.NewLabelWithStyle(sg.ui.T("gui.details"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
 
 	return container.NewPadded(container.NewBorder(
 		container.NewVBox(detailsHeader, widget.NewSeparator()),
@@ -885,16 +863,19 @@ func (sg *ScannerGUI) clearDetailsPanel() {
 	sg.selectedFile = nil
 	fyne.Do(func() {
 		sg.detailContainer.Objects = []fyne.CanvasObject{
-			widget.NewLabel("Выберите файл для просмотра уязвимостей"),
+			widget.NewLabel(sg.ui.T("gui.select_file")),
 		}
 		sg.detailContainer.Refresh()
 	})
 }
 
+// detailsPageSize is how many findings the details panel adds at a time
+const detailsPageSize = 100
+
 func (sg *ScannerGUI) updateDetailsPanel() {
 	if sg.selectedFile == nil {
 		sg.detailContainer.Objects = []fyne.CanvasObject{
-			widget.NewLabel("Выберите файл для просмотра уязвимостей"),
+			widget.NewLabel(sg.ui.T("gui.select_file")),
 		}
 		sg.detailContainer.Refresh()
 		return
@@ -903,27 +884,32 @@ func (sg *ScannerGUI) updateDetailsPanel() {
 	file := sg.selectedFile
 	objects := []fyne.CanvasObject{}
 
-	// File header
-	fileHeader := widget.NewLabel(fmt.Sprintf("📁 %s", filepath.Base(file.FilePath)))
+	// File header; a member of an archive is shown with the archive chain
+	// and the folder opened is that of the archive
+	archive, inner := splitFilePath(file)
+	name, _ := fileDisplayName(file)
+	icon := "📁"
+	if inner != "" {
+		icon = "📦"
+	}
+	fileHeader := widget.NewLabel(fmt.Sprintf("%s %s", icon, name))
 	fileHeader.TextStyle.Bold = true
 	objects = append(objects, fileHeader)
 
-	filePath := widget.NewLabel(file.FilePath)
+	filePath := widget.NewLabel(searcher.FormatArchivePath(archive, inner))
 	filePath.Wrapping = fyne.TextWrapWord
 	objects = append(objects, filePath)
 
 	// Action buttons for file
-	openBtn := widget.NewButton("📂 Открыть в проводнике", func() {
-		sg.openInExplorer(file.FilePath)
+	openBtn := widget.NewButton(sg.ui.T("gui.open_folder"), func() {
+		sg.openInExplorer(archive)
 	})
 
-	ignoreBtn := widget.NewButton("🚫 Игнорировать файл", func() {
-		sg.ignoreMutex.Lock()
-		sg.ignoreList[file.FilePath] = true
-		sg.ignoreMutex.Unlock()
+	ignoreBtn := widget.NewButton(sg.ui.T("gui.ignore"), func() {
+		sg.ignoreFile(file.FilePath)
 		sg.refreshFilesList()
 		sg.updateStatsUI()
-		sg.statusLabel.SetText(fmt.Sprintf("Игнорировано: %s", filepath.Base(file.FilePath)))
+		sg.statusLabel.SetText(sg.ui.T("gui.ignored", filepath.Base(file.FilePath)))
 		sg.selectedFile = nil
 		sg.updateDetailsPanel()
 	})
@@ -933,7 +919,7 @@ func (sg *ScannerGUI) updateDetailsPanel() {
 	objects = append(objects, widget.NewSeparator())
 
 	// Findings summary
-	summaryLabel := widget.NewLabel(fmt.Sprintf("🔍 Найдено уязвимостей: %d", len(file.Findings)))
+	summaryLabel := widget.NewLabel(sg.ui.T("gui.findings_in_file", len(file.Findings)))
 	summaryLabel.TextStyle.Bold = true
 	objects = append(objects, summaryLabel)
 	objects = append(objects, widget.NewSeparator())
@@ -945,74 +931,125 @@ func (sg *ScannerGUI) updateDetailsPanel() {
 		return sortedFindings[i].Severity.Score() > sortedFindings[j].Severity.Score()
 	})
 
-	// List each finding
-	for i, f := range sortedFindings {
-		// Finding header with severity color
-		var severityIcon string
-		switch f.Severity {
-		case searcher.Critical:
-			severityIcon = "🔴"
-		case searcher.High:
-			severityIcon = "🟠"
-		case searcher.Medium:
-			severityIcon = "🟡"
-		case searcher.Low:
-			severityIcon = "🟢"
-		}
-
-		findingHeader := widget.NewLabel(fmt.Sprintf("%s #%d: %s [%s]",
-			severityIcon, i+1, sg.patternToRussian(f.PatternType), sg.severityToRussian(f.Severity)))
-		findingHeader.TextStyle.Bold = true
-		objects = append(objects, findingHeader)
-
-		// Location
-		lineLabel := widget.NewLabel(fmt.Sprintf("   📍 Строка %d, Колонка %d-%d", f.LineNumber, f.ColumnStart, f.ColumnEnd))
-		objects = append(objects, lineLabel)
-
-		// Description
-		descLabel := widget.NewLabel(fmt.Sprintf("   📝 %s", sg.descriptionToRussian(f.Description)))
-		descLabel.Wrapping = fyne.TextWrapWord
-		objects = append(objects, descLabel)
-
-		// Risk score
-		riskLabel := widget.NewLabel(fmt.Sprintf("   ⚠️ Риск: %.0f%% | Энтропия: %.2f", f.RiskScore, f.EntropyScore))
-		objects = append(objects, riskLabel)
-
-		// Context preview
-		contextText := canvas.NewText(fmt.Sprintf("   %s", f.Context), color.NRGBA{R: 200, G: 200, B: 200, A: 255})
-		contextText.TextSize = 12
-		contextBg := canvas.NewRectangle(color.NRGBA{R: 40, G: 40, B: 45, A: 255})
-		contextBg.CornerRadius = 4
-		contextContainer := container.NewStack(contextBg, container.NewPadded(contextText))
-		objects = append(objects, contextContainer)
-
-		// Masked matched text
-		maskedText := maskSensitiveText(f.MatchedText)
-		matchLabel := widget.NewLabel(fmt.Sprintf("   🎯 Найдено: %s", maskedText))
-		objects = append(objects, matchLabel)
-
-		// Copy button for this finding
-		copyBtn := widget.NewButton("📋 Копировать контекст", func() {
-			sg.window.Clipboard().SetContent(f.Context)
-			sg.statusLabel.SetText("✅ Скопировано в буфер обмена")
-		})
-		copyBtn.Importance = widget.LowImportance
-		objects = append(objects, container.NewHBox(layout.NewSpacer(), copyBtn))
+	// Findings are shown a page at a time, so a file with thousands of them
+	// does not build all their widgets at once
+	sg.detailFindings = sortedFindings
+	sg.detailShown = 0
+	sg.detailMore = nil
+	sg.detailContainer.Objects = objects
+	sg.showMoreFindings()
+}
 
-		if i < len(sortedFindings)-1 {
+// showMoreFindings adds the next page of findings of the selected file to
+// the details panel, with a button for the rest
+func (sg *ScannerGUI) showMoreFindings() {
+	objects := sg.detailContainer.Objects
+	if n := len(objects); n > 0 && sg.detailMore != nil && objects[n-1] == sg.detailMore {
+		objects = objects[:n-1]
+	}
+
+	end := sg.detailShown + detailsPageSize
+	if end > len(sg.detailFindings) {
+		end = len(sg.detailFindings)
+	}
+	for i := sg.detailShown; i < end; i++ {
+		if i > 0 {
 			objects = append(objects, widget.NewSeparator())
 		}
+		objects = append(objects, sg.findingDetails(sg.selectedFile, i, sg.detailFindings[i])...)
+	}
+	sg.detailShown = end
+
+	sg.detailMore = nil
+	if rest := len(sg.detailFindings) - end; rest > 0 {
+		shown := widget.NewLabel(sg.ui.T("gui.findings_shown", end, len(sg.detailFindings)))
+		more := widget.NewButton(sg.ui.T("gui.show_more", min(rest, detailsPageSize)), sg.showMoreFindings)
+		sg.detailMore = container.NewVBox(widget.NewSeparator(), shown, more)
+		objects = append(objects, sg.detailMore)
 	}
 
 	sg.detailContainer.Objects = objects
 	sg.detailContainer.Refresh()
 }
 
-func maskSensitiveText(text string) string {
-	if len(text) <= 8 {
-		return strings.Repeat("*", len(text))
+// findingDetails builds the widgets describing the i-th finding of file
+func (sg *ScannerGUI) findingDetails(file *controller.FileEntry, i int, f *searcher.Finding) []fyne.CanvasObject {
+	objects := []fyne.CanvasObject{}
+
+	// Finding header with severity color
+	findingHeader := widget.NewLabel(fmt.Sprintf("%s #%d: %s [%s]",
+		severityIcon(f.Severity), i+1, sg.patternName(f.PatternType), sg.severityName(f.Severity)))
+	findingHeader.TextStyle.Bold = true
+	objects = append(objects, findingHeader)
+
+	// Location (columns are shown 1-based, like in editors)
+	location := sg.ui.T("gui.location", f.LineNumber, f.ColumnStart+1, f.ColumnEnd)
+	// PDF findings name the page to open
+	if page := f.LocalizedPageLabel(sg.ui); page != "" {
+		location = sg.ui.T("gui.location_pdf", page, f.LineNumber)
+	}
+	lineLabel := widget.NewLabel(location)
+	objects = append(objects, lineLabel)
+
+	// Description
+	descLabel := widget.NewLabel(fmt.Sprintf("   📝 %s", searcher.LocalizeDescription(sg.ui, f.Description)))
+	descLabel.Wrapping = fyne.TextWrapWord
+	objects = append(objects, descLabel)
+
+	// Key of the value in JSON, YAML and .env files
+	if f.KeyPath != "" {
+		objects = append(objects, widget.NewLabel(sg.ui.T("gui.key_path", f.KeyPath)))
 	}
-	return text[:4] + strings.Repeat("*", len(text)-8) + text[len(text)-4:]
+
+	// Imported findings keep the tool and its rule id
+	if f.Source != "" {
+		objects = append(objects, widget.NewLabel(sg.ui.T("gui.source", f.Source, f.RuleID)))
+	}
+
+	// Risk score
+	riskLabel := widget.NewLabel(sg.ui.T("gui.risk", f.RiskScore, f.EntropyScore))
+	if f.PathAdjusted() {
+		riskLabel.SetText(sg.ui.T("gui.risk_path", f.RiskScore, f.BaseRiskScore, f.EntropyScore))
+	}
+	objects = append(objects, riskLabel)
+	if f.GitStatus != "" {
+		objects = append(objects, widget.NewLabel("   🌿 Git: "+searcher.LocalizeGitStatus(sg.ui, f.GitStatus)))
+	}
+	objects = append(objects, newScoreExplanation(sg.ui, f))
+
+	// Context preview with the surrounding lines
+	objects = append(objects, newContextPreview(f))
+
+	// Masked matched text
+	maskedText := searcher.MaskSecret(f.MatchedText)
+	matchLabel := widget.NewLabel(sg.ui.T("gui.match", maskedText))
+	objects = append(objects, matchLabel)
+
+	// The same secret in other files
+	if alsoFound := sg.newAlsoFoundIn(f, file.FilePath); alsoFound != nil {
+		objects = append(objects, alsoFound)
+	}
+
+	// Why it is risky and how to fix it, from the local model
+	objects = append(objects, sg.newExplainSection(f))
+
+	// Copy button for this finding
+	copyBtn := widget.NewButton(sg.ui.T("gui.copy_context"), func() {
+		sg.window.Clipboard().SetContent(f.Context)
+		sg.statusLabel.SetText(sg.ui.T("gui.copied"))
+	})
+	copyBtn.Importance = widget.LowImportance
+	editorBtn := widget.NewButton(sg.ui.T("gui.open_editor"), func() {
+		sg.openInEditor(f.FilePath, f.LineNumber)
+	})
+	editorBtn.Importance = widget.LowImportance
+	// The line of an archive member cannot be opened in an editor
+	if f.ArchivePath != "" {
+		editorBtn.Hide()
+	}
+	objects = append(objects, container.NewHBox(layout.NewSpacer(), editorBtn, copyBtn))
+
+	return objects
 }
 
 // truncatePath truncates a long path to show beginning and end with ellipsis
@@ -1042,7 +1079,7 @@ func (sg *ScannerGUI) openInExplorer(filePath string) {
 		cmd = exec.Command("xdg-open", dir)
 	}
 	if err := cmd.Start(); err != nil {
-		sg.statusLabel.SetText("❌ Не удалось открыть проводник")
+		sg.statusLabel.SetText(sg.ui.T("gui.reveal.error"))
 	}
 }
 
@@ -1058,20 +1095,23 @@ func (sg *ScannerGUI) setupShortcuts() {
 }
 
 func (sg *ScannerGUI) onStartScan() {
-	if sg.scanning.Load() {
+	if sg.scanning.Load() || sg.checking.Load() {
 		return
 	}
 
 	scanDir := sg.scanDir.Text
 	if scanDir == "" {
-		dialog.ShowError(fmt.Errorf("пожалуйста, выберите директорию для сканирования"), sg.window)
+		dialog.ShowError(errors.New(sg.ui.T("gui.scan.no_dir")), sg.window)
 		return
 	}
 
 	if _, err := os.Stat(scanDir); os.IsNotExist(err) {
-		dialog.ShowError(fmt.Errorf("директория не существует: %s", scanDir), sg.window)
+		dialog.ShowError(errors.New(sg.ui.T("gui.scan.dir_missing", scanDir)), sg.window)
 		return
 	}
+	sg.ignoreRoot = scanDir
+	sg.results.Invalidate()
+	sg.saveState()
 
 	// Check dependencies based on selected options
 	scanDocs := sg.scanDocsCheck != nil && sg.scanDocsCheck.Checked
@@ -1079,51 +1119,81 @@ func (sg *ScannerGUI) onStartScan() {
 	enableOCR := sg.enableOCRCheck != nil && sg.enableOCRCheck.Checked
 	enableAI := sg.enableAICheck != nil && sg.enableAICheck.Checked
 
-	depChecker := searcher.NewDependencyChecker()
-	depChecker.CheckAll()
+	// Ollama may only be contacted when AI analysis is on; a cached probe
+	// made under the old policy is stale
+	if network := searcher.Network(); network.Allowed(searcher.NetworkAI) != enableAI {
+		network.Enable(searcher.NetworkAI, enableAI)
+		searcher.Dependencies().ForceRefresh()
+	}
+	searcher.Network().Enable(searcher.NetworkNotifications, sg.settings.notifier() != nil)
+	searcher.Network().ResetBlocked()
 
+	// Probing runs tools and may wait on slow PATH lookups, so it stays
+	// off the UI thread
+	sg.checking.Store(true)
+	go func() {
+		warnings := dependencyWarnings(sg.ui, searcher.NewDependencyChecker(), enableOCR, scanDocs, enableAI)
+		// Re-probe on the next start so newly installed tools are picked up
+		if len(warnings) > 0 {
+			searcher.Dependencies().ForceRefresh()
+		}
+		fyne.Do(func() {
+			sg.checking.Store(false)
+			// Show warnings and ask to continue
+			if len(warnings) > 0 {
+				warningText := strings.Join(warnings, "\n\n")
+				dialog.ShowConfirm(sg.ui.T("gui.warning"), sg.ui.T("gui.dependency.continue", warningText), func(confirm bool) {
+					if confirm {
+						sg.confirmNewScan(func() {
+							sg.startScanWithOptions(scanDir, scanDocs, scanArchives, enableOCR, enableAI)
+						})
+					}
+				}, sg.window)
+				return
+			}
+
+			// Start scan with current options
+			sg.confirmNewScan(func() {
+				sg.startScanWithOptions(scanDir, scanDocs, scanArchives, enableOCR, enableAI)
+			})
+		})
+	}()
+}
+
+// dependencyWarnings returns the warnings about the tools the selected
+// options need that are missing or older than supported
+func dependencyWarnings(l *locale.Localizer, depChecker *searcher.DependencyChecker, enableOCR, scanDocs, enableAI bool) []string {
 	var warnings []string
 
 	if enableOCR && !depChecker.IsTesseractAvailable() {
-		missingDeps := depChecker.GetMissingDependencies()
-		installHint := "brew install tesseract" // fallback
-		if len(missingDeps) > 0 {
-			installHint = missingDeps[0].InstallHint
-		}
-		warnings = append(warnings, "⚠️ Tesseract OCR не установлен!\n   OCR изображений будет недоступен.\n   Установите: "+installHint)
+		warnings = append(warnings, l.T("gui.dependency.no_tesseract", depChecker.Status(searcher.DependencyTesseract).InstallHint))
 	}
 
 	if scanDocs && !depChecker.IsPopplerAvailable() {
-		for _, dep := range depChecker.GetMissingDependencies() {
-			if dep.Name == "Poppler (PDF utils)" {
-				warnings = append(warnings, "⚠️ Poppler не установлен!\n   OCR для сканированных PDF недоступен.\n   Установите: "+dep.InstallHint)
-				break
-			}
-		}
+		warnings = append(warnings, l.T("gui.dependency.no_poppler", depChecker.Status(searcher.DependencyPoppler).InstallHint))
 	}
 
 	if enableAI && !depChecker.IsOllamaAvailable() {
-		for _, dep := range depChecker.GetMissingDependencies() {
-			if dep.Name == "Ollama (AI)" {
-				warnings = append(warnings, "⚠️ Ollama не установлен или не запущен!\n   AI-анализ будет использовать базовый режим.\n   Установите: "+dep.InstallHint)
-				break
-			}
+		warnings = append(warnings, l.T("gui.dependency.no_ollama", depChecker.Status(searcher.DependencyOllama).InstallHint))
+	}
+
+	// Outdated versions are used anyway, the scan may just miss more
+	for _, dep := range []struct {
+		enabled bool
+		name    string
+	}{
+		{enableOCR, searcher.DependencyTesseract},
+		{scanDocs, searcher.DependencyPoppler},
+		{enableAI, searcher.DependencyOllama},
+	} {
+		if !dep.enabled {
+			continue
+		}
+		if status := depChecker.Status(dep.name); status.Outdated {
+			warnings = append(warnings, l.T("gui.dependency.outdated", searcher.LocalizeVersionWarning(l, status), status.InstallHint))
 		}
 	}
-
-	// Show warnings and ask to continue
-	if len(warnings) > 0 {
-		warningText := strings.Join(warnings, "\n\n")
-		dialog.ShowConfirm("Предупреждение", warningText+"\n\nПродолжить сканирование?", func(confirm bool) {
-			if confirm {
-				sg.startScanWithOptions(scanDir, scanDocs, scanArchives, enableOCR, enableAI)
-			}
-		}, sg.window)
-		return
-	}
-
-	// Start scan with current options
-	sg.startScanWithOptions(scanDir, scanDocs, scanArchives, enableOCR, enableAI)
+	return warnings
 }
 
 // startScanWithOptions starts the scan with the given options
@@ -1133,24 +1203,46 @@ func (sg *ScannerGUI) startScanWithOptions(scanDir string, scanDocs, scanArchive
 	sg.paused.Store(false)
 	sg.cancelled.Store(false)
 	sg.startTime = time.Now()
+	sg.pausedFor.Store(0)
 
-	sg.filesMutex.Lock()
-	sg.filesData = make([]*FileWithFindings, 0)
-	sg.selectedFile = nil
-	sg.filesMutex.Unlock()
+	// Only one result is kept in memory; the previous one goes to the history
+	sg.releaseResults()
 
 	sg.filesQueued.Store(0)
 	sg.filesProcessed.Store(0)
+	sg.filesSkipped.Store(0)
+	sg.scanErrors.Store(0)
 	sg.findingsCount.Store(0)
 
+	fileType := sg.filterFileType
+	if sg.fileTypeFilter != nil {
+		fileType = sg.fileTypeFilter.Selected
+	}
+	var groups []string
+	if sg.financeCheck != nil && sg.financeCheck.Checked {
+		groups = append(groups, searcher.GroupFinance)
+	}
+	sg.lastScan = &scanRequest{
+		Dir:          scanDir,
+		ScanDocs:     scanDocs,
+		ScanArchives: scanArchives,
+		EnableOCR:    enableOCR,
+		EnableAI:     enableAI,
+		FileType:     fileType,
+		Groups:       groups,
+	}
+
 	// Update UI
 	sg.scanButton.Disable()
+	sg.rescanButton.Disable()
+	sg.window.SetTitle(searcher.FormatProgressTitle(sg.ui, -1, 0, 0))
 	sg.pauseButton.Enable()
 	sg.cancelButton.Enable()
 	sg.exportButton.Disable()
 	sg.progressBar.SetValue(0)
-	sg.statusLabel.SetText("🔄 Сканирование...")
+	sg.statusLabel.SetText(sg.ui.T("gui.scanning"))
 	sg.updateStatsUI()
+	sg.coverageBanner.Hide()
 
 	// Clear details panel
 	sg.clearDetailsPanel()
@@ -1166,7 +1258,7 @@ func (sg *ScannerGUI) runScanWithOptions(scanDir string, scanDocs, scanArchives,
 	defer func() {
 		sg.scanning.Store(false)
 
-		elapsed := time.Since(sg.startTime)
+		elapsed := sg.scanElapsed()
 		findingsCount := sg.findingsCount.Load()
 		cancelled := sg.cancelled.Load()
 
@@ -1174,7 +1266,7 @@ func (sg *ScannerGUI) runScanWithOptions(scanDir string, scanDocs, scanArchives,
 		fyne.Do(func() {
 			sg.scanButton.Enable()
 			sg.pauseButton.Disable()
-			sg.pauseButton.SetText("⏸️ Пауза")
+			sg.pauseButton.SetText(sg.ui.T("gui.pause"))
 			sg.cancelButton.Disable()
 
 			if findingsCount > 0 {
@@ -1182,13 +1274,14 @@ func (sg *ScannerGUI) runScanWithOptions(scanDir string, scanDocs, scanArchives,
 			}
 
 			if cancelled {
-				sg.statusLabel.SetText(fmt.Sprintf("⏹️ Сканирование отменено через %.2fс", elapsed.Seconds()))
+				sg.statusLabel.SetText(sg.ui.T("gui.cancelled", elapsed.Seconds()))
 			} else {
-				sg.statusLabel.SetText(fmt.Sprintf("✅ Готово! Найдено %d проблем за %.2fс",
+				sg.statusLabel.SetText(sg.ui.T("gui.done",
 					findingsCount, elapsed.Seconds()))
 			}
 
 			sg.progressBar.SetValue(1)
+			sg.updateSummaryBar(elapsed, cancelled)
 			sg.updateStatsUI()
 			sg.updateSelectedCount()
 			sg.updateEncryptButtonState()
@@ -1198,14 +1291,51 @@ func (sg *ScannerGUI) runScanWithOptions(scanDir string, scanDocs, scanArchives,
 
 		// Send notification
 		sg.app.SendNotification(&fyne.Notification{
-			Title:   "Сканирование завершено",
-			Content: fmt.Sprintf("Найдено %d потенциальных проблем", findingsCount),
+			Title:   sg.ui.T("gui.notify_title"),
+			Content: sg.ui.T("gui.notify_content", findingsCount),
 		})
 	}()
 
 	scanner := searcher.NewScanner()
 	scanner.SetMaxFileSize(sg.settings.MaxFileSize)
 	scanner.SetMaxConcurrentFiles(sg.settings.Concurrency)
+	if severity, err := searcher.ParseSeverity(sg.settings.MinSeverity); err == nil {
+		scanner.SetMinSeverity(severity)
+	}
+	scanner.SetMaxFindingsPerFile(sg.settings.MaxPerFile)
+	if notifier := sg.settings.notifier(); notifier != nil {
+		// Saved by the settings form, which only offers known modes
+		mode, _ := searcher.ParseNotifyMode(sg.settings.NotifyOn)
+		scanner.SetNotifier(notifier, mode)
+	}
+	scanner.SetRespectGitignore(sg.settings.Gitignore)
+	scanner.SetDetectHighEntropy(sg.settings.Entropy)
+	scanner.SetFollowSymlinks(sg.settings.FollowSymlinks)
+	scanner.SetFollowExternalSymlinks(sg.settings.ExternalLinks)
+	scanner.SetEnumerate(true)
+	for name, value := range sg.settings.SeverityOverrides {
+		// Saved by the settings editor, which only offers known types and levels
+		if severity, err := searcher.ParseSeverity(value); err == nil {
+			scanner.SetSeverityOverride(searcher.PatternType(name), severity)
+		}
+	}
+	if sg.lastScan != nil {
+		// Groups come from the option checks, so the names are always known
+		scanner.GetPatterns().EnableGroups(sg.lastScan.Groups)
+	}
+	sg.disablePatterns(scanner)
+	sg.currentScanner.Store(scanner)
+	defer sg.currentScanner.Store(nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sg.scanMutex.Lock()
+	sg.cancelScan = cancel
+	sg.scanMutex.Unlock()
+	// Cancelled before the scanner existed
+	if sg.cancelled.Load() {
+		cancel()
+	}
 
 	// Configure file type filter
 	fileTypeFilter := sg.filterFileType
@@ -1216,19 +1346,13 @@ func (sg *ScannerGUI) runScanWithOptions(scanDir string, scanDocs, scanArchives,
 	// Use parameters passed to function (already read in onStartScan)
 	// Auto-adjust options based on file type filter
 	switch fileTypeFilter {
-	case "Только текст/код (.txt, .json, .env, .go, .py...)":
-		scanner.SetOnlyExtensions([]string{
-			".txt", ".json", ".yaml", ".yml", ".xml", ".csv", ".env", ".ini", ".cfg", ".conf",
-			".go", ".py", ".js", ".ts", ".java", ".c", ".cpp", ".h", ".hpp", ".cs", ".rb",
-			".php", ".sh", ".bash", ".zsh", ".ps1", ".sql", ".md", ".rst", ".log",
-		})
+	case sg.ui.T("gui.filetype.text"):
+		scanner.SetOnlyExtensions(controller.TextExtensions)
 		fyne.Do(func() {
-			sg.statusLabel.SetText("🔍 Сканирую только текст/код...")
-		})
-	case "Только документы (.pdf, .docx, .xlsx...)":
-		scanner.SetOnlyExtensions([]string{
-			".pdf", ".docx", ".doc", ".xlsx", ".xls", ".pptx", ".ppt", ".odt", ".ods", ".odp",
+			sg.statusLabel.SetText(sg.ui.T("gui.scan.only_text"))
 		})
+	case sg.ui.T("gui.filetype.documents"):
+		scanner.SetOnlyExtensions(controller.DocumentExtensions)
 		// Auto-enable document extraction if user selected documents filter
 		if !scanDocs {
 			scanDocs = true
@@ -1237,28 +1361,24 @@ func (sg *ScannerGUI) runScanWithOptions(scanDir string, scanDocs, scanArchives,
 			})
 		}
 		fyne.Do(func() {
-			sg.statusLabel.SetText("🔍 Сканирую только документы...")
-		})
-	case "Только изображения (.png, .jpg, .gif...)":
-		scanner.SetOnlyExtensions([]string{
-			".png", ".jpg", ".jpeg", ".gif", ".bmp", ".tiff", ".tif", ".webp",
+			sg.statusLabel.SetText(sg.ui.T("gui.scan.only_documents"))
 		})
+	case sg.ui.T("gui.filetype.images"):
+		scanner.SetOnlyExtensions(controller.ImageExtensions)
 		// Auto-enable OCR if user selected images filter
 		if !enableOCR {
 			enableOCR = true
 			fyne.Do(func() {
 				sg.enableOCRCheck.SetChecked(true)
-				sg.statusLabel.SetText("⚠️ OCR включён автоматически. Требуется Tesseract!")
+				sg.statusLabel.SetText(sg.ui.T("gui.scan.ocr_enabled"))
 			})
 		} else {
 			fyne.Do(func() {
-				sg.statusLabel.SetText("🔍 Сканирую только изображения (OCR)...")
+				sg.statusLabel.SetText(sg.ui.T("gui.scan.only_images"))
 			})
 		}
-	case "Только архивы (.zip, .tar, .gz...)":
-		scanner.SetOnlyExtensions([]string{
-			".zip", ".tar", ".gz", ".tgz", ".rar", ".7z", ".bz2", ".xz",
-		})
+	case sg.ui.T("gui.filetype.archives"):
+		scanner.SetOnlyExtensions(controller.ArchiveExtensions)
 		// Auto-enable archive scanning
 		if !scanArchives {
 			scanArchives = true
@@ -1267,7 +1387,7 @@ func (sg *ScannerGUI) runScanWithOptions(scanDir string, scanDocs, scanArchives,
 			})
 		}
 		fyne.Do(func() {
-			sg.statusLabel.SetText("🔍 Сканирую только архивы...")
+			sg.statusLabel.SetText(sg.ui.T("gui.scan.only_archives"))
 		})
 	default:
 		// All files - no filter
@@ -1284,15 +1404,15 @@ func (sg *ScannerGUI) runScanWithOptions(scanDir string, scanDocs, scanArchives,
 		fyne.Do(func() {
 			var opts []string
 			if scanDocs {
-				opts = append(opts, "извлечение текста из документов")
+				opts = append(opts, sg.ui.T("gui.scan.extra.documents"))
 			}
 			if scanArchives {
-				opts = append(opts, "сканирование архивов")
+				opts = append(opts, sg.ui.T("gui.scan.extra.archives"))
 			}
 			if enableOCR {
 				opts = append(opts, "OCR")
 			}
-			sg.statusLabel.SetText(fmt.Sprintf("🔍 Доп. обработка: %s", strings.Join(opts, ", ")))
+			sg.statusLabel.SetText(sg.ui.T("gui.scan.extra", strings.Join(opts, ", ")))
 		})
 	}
 
@@ -1314,75 +1434,81 @@ func (sg *ScannerGUI) runScanWithOptions(scanDir string, scanDocs, scanArchives,
 		ignoreList.AddIgnoreExtension(ext)
 	}
 
-	result, err := scanner.Scan(scanDir)
-	if err != nil {
+	// Files and counters fill in while the scan runs
+	liveBaseline := sg.baseline.Load()
+	if liveBaseline != nil {
+		liveBaseline = liveBaseline.WithRoot(scanDir)
+	}
+	scanner.SetOnFinding(func(f *searcher.Finding) {
+		if liveBaseline != nil {
+			liveBaseline.Tag(f)
+		}
+		sg.addLiveFinding(f)
+	})
+	scanner.SetOnFileScanned(func(string, int) {
+		sg.filesProcessed.Add(1)
+	})
+	scanner.SetOnProgress(func(_, skipped, errors int64) {
+		sg.filesSkipped.Store(skipped)
+		sg.scanErrors.Store(errors)
+	})
+
+	// A cancelled scan still shows what it found so far
+	result, err := scanner.ScanContext(ctx, scanDir)
+	if err != nil && !errors.Is(err, searcher.ErrCancelled) {
 		fyne.Do(func() {
-			sg.statusLabel.SetText(fmt.Sprintf("❌ Ошибка: %v", err))
+			sg.statusLabel.SetText(sg.ui.T("gui.error", userErrorMessage(sg.ui, err)))
 		})
 		return
 	}
 
+	result.Coverage.SetAI(enableAI, searcher.Dependencies())
+	result.Coverage.SetNetwork(searcher.Network())
+	sg.tagBaseline(result)
 	sg.resultData = result
 
-	// Group findings by file
-	fileMap := make(map[string]*FileWithFindings)
-	for _, f := range result.Findings {
-		file, exists := fileMap[f.FilePath]
-		if !exists {
-			file = &FileWithFindings{
-				FilePath:    f.FilePath,
-				Findings:    make([]*searcher.Finding, 0),
-				Selected:    false,
-				MaxSeverity: f.Severity,
-			}
-			fileMap[f.FilePath] = file
-		}
-		file.Findings = append(file.Findings, f)
-		// Update max severity
-		if f.Severity.Score() > file.MaxSeverity.Score() {
-			file.MaxSeverity = f.Severity
-		}
-	}
-
-	// Convert map to slice
-	sg.filesMutex.Lock()
-	sg.filesData = make([]*FileWithFindings, 0, len(fileMap))
-	for _, file := range fileMap {
-		sg.filesData = append(sg.filesData, file)
-	}
-	// Sort by max severity
-	sort.Slice(sg.filesData, func(i, j int) bool {
-		return sg.filesData[i].MaxSeverity.Score() > sg.filesData[j].MaxSeverity.Score()
-	})
-	sg.filesMutex.Unlock()
+	// The final grouping replaces the live one, keeping the files picked meanwhile
+	sg.results.SetFindings(result.Findings)
+	markTruncated(sg.ui, sg.results, result)
 
 	sg.filesProcessed.Store(int64(result.FilesScanned))
 	sg.findingsCount.Store(int64(result.TotalFindings()))
 
 	// AI Analysis if enabled
-	if enableAI && result.TotalFindings() > 0 {
+	if enableAI && !result.Cancelled && result.TotalFindings() > 0 {
 		analyzer := searcher.NewLocalAnalyzer()
 		ollamaAvailable := analyzer.IsOllamaAvailable()
 
 		if ollamaAvailable {
 			fyne.Do(func() {
-				sg.statusLabel.SetText("🤖 AI-анализ (Ollama)...")
+				sg.statusLabel.SetText(sg.ui.T("gui.ai.running"))
 			})
 			analyzer.EnableAI(true)
+			analyzer.SetOnProgress(func(p searcher.AIProgress) {
+				status := sg.ui.T("gui.ai.progress", len([]rune(p.Text)))
+				if p.FilePath != "" {
+					status = sg.ui.T("gui.ai.image", filepath.Base(p.FilePath))
+				}
+				fyne.Do(func() {
+					sg.statusLabel.SetText(status)
+				})
+			})
 		} else {
 			fyne.Do(func() {
-				sg.statusLabel.SetText("🤖 AI-анализ (базовый режим, Ollama недоступен)...")
+				sg.statusLabel.SetText(sg.ui.T("gui.ai.basic"))
 			})
 			analyzer.EnableAI(false)
 		}
 
-		analysis, err := analyzer.Analyze(result)
-		if err == nil {
-			// Show AI analysis dialog with Ollama status
-			fyne.Do(func() {
-				sg.showAIAnalysisDialogWithStatus(analysis, analyzer, ollamaAvailable)
-			})
+		// The stop button also stops the analysis; what was received is shown
+		analysis, aiErr := analyzer.AnalyzeContext(ctx, result)
+		if !ollamaAvailable {
+			aiErr = searcher.ErrOllamaUnreachable
 		}
+		// Show AI analysis dialog with Ollama status
+		fyne.Do(func() {
+			sg.showAIAnalysisDialogWithStatus(analysis, analyzer, aiErr)
+		})
 	}
 
 	// UI updates on main thread
@@ -1392,55 +1518,170 @@ func (sg *ScannerGUI) runScanWithOptions(scanDir string, scanDocs, scanArchives,
 	})
 }
 
+// addLiveFinding adds a finding of the scan in progress to the files list.
+// It is called from the scanner goroutines; the list is redrawn by the
+// progress loop
+func (sg *ScannerGUI) addLiveFinding(f *searcher.Finding) {
+	sg.results.Add(f)
+	sg.findingsCount.Add(1)
+	sg.listChanged.Store(true)
+}
+
 func (sg *ScannerGUI) updateProgressLoop() {
 	ticker := time.NewTicker(100 * time.Millisecond)
 	defer ticker.Stop()
+	eta := searcher.NewETAEstimator(searcher.DefaultETAWindow)
 
 	for sg.scanning.Load() {
 		<-ticker.C
 
 		// Capture values outside of fyne.Do
-		elapsed := time.Since(sg.startTime)
+		elapsed := sg.scanElapsed()
 		processed := sg.filesProcessed.Load()
+		skipped := sg.filesSkipped.Load()
+		scanErrors := sg.scanErrors.Load()
+		var critical int64
+		totalComplete := false
+		if scanner := sg.currentScanner.Load(); scanner != nil {
+			progress := scanner.Progress()
+			sg.filesQueued.Store(progress.EstimatedTotal)
+			totalComplete = progress.TotalComplete
+			critical = progress.CriticalCount
+		}
 		queued := sg.filesQueued.Load()
+		listChanged := sg.listChanged.Swap(false)
+
+		// Paused time is left out of elapsed, so pauses do not slow the ETA down
+		eta.Add(time.Time{}.Add(elapsed), processed)
+		progressText := sg.ui.T("gui.processed", processed)
+		if queued > 0 {
+			remaining, known := eta.Estimate(queued)
+			progressText = searcher.FormatFileProgress(sg.ui, processed, queued, totalComplete, remaining, known)
+		}
+		if skipped > 0 {
+			progressText += sg.ui.T("gui.skipped", skipped)
+		}
+		if scanErrors > 0 {
+			progressText += sg.ui.T("gui.errors", scanErrors)
+		}
 
-		// Update UI on main thread
+		// The percentage is shown once the enumeration has counted every file
+		percent := -1.0
+		if queued > 0 && totalComplete {
+			percent = float64(processed) / float64(queued) * 100
+		}
+		title := searcher.FormatProgressTitle(sg.ui, percent, int(processed), int(critical))
+
+		// Update UI on main thread. Fyne has no taskbar progress API, so the
+		// window title is the signal visible while the app is minimized.
 		fyne.Do(func() {
-			sg.timeLabel.SetText(fmt.Sprintf("Время: %.1fс", elapsed.Seconds()))
+			sg.timeLabel.SetText(sg.ui.T("gui.time", elapsed.Seconds()))
 
-			if queued > 0 {
+			if queued > 0 && totalComplete {
 				sg.progressBar.SetValue(float64(processed) / float64(queued))
 			}
 
-			sg.progressLabel.SetText(fmt.Sprintf("%d файлов обработано", processed))
+			sg.progressLabel.SetText(progressText)
+			sg.window.SetTitle(title)
+
+			if listChanged {
+				sg.filesList.Refresh()
+				sg.updateStatsUI()
+			}
 		})
 	}
 }
 
+// updateSummaryBar shows the summary of the finished scan above the results
+// and keeps it in the window title until the next scan starts
+func (sg *ScannerGUI) updateSummaryBar(elapsed time.Duration, cancelled bool) {
+	sg.rescanButton.Enable()
+	if sg.resultData == nil || sg.lastScan == nil {
+		sg.window.SetTitle(sg.ui.T("gui.title"))
+		return
+	}
+
+	summary := searcher.FormatScanSummary(sg.ui, sg.resultData, elapsed)
+	if cancelled {
+		summary = sg.ui.T("gui.summary_cancelled") + summary
+	}
+	if distinct := len(sg.resultData.GetSecretGroups()); distinct < sg.resultData.TotalFindings() {
+		summary += sg.ui.T("gui.distinct", distinct)
+	}
+	sg.summaryLabel.SetText(fmt.Sprintf("📂 %s\n%s", sg.lastScan.Dir, summary))
+	sg.summaryBar.Show()
+	sg.updateCoverageBanner()
+	sg.updateErrorsTab()
+
+	sg.window.SetTitle(searcher.FormatSummaryTitle(sg.ui, sg.resultData) + " — " + filepath.Base(sg.lastScan.Dir))
+}
+
+// onRescan repeats the last scan with the same directory and options
+func (sg *ScannerGUI) onRescan() {
+	if sg.lastScan == nil || sg.scanning.Load() {
+		return
+	}
+	last := *sg.lastScan
+
+	sg.scanDir.SetText(last.Dir)
+	if sg.fileTypeFilter != nil && last.FileType != "" {
+		sg.fileTypeFilter.SetSelected(last.FileType)
+	}
+	sg.scanDocsCheck.SetChecked(last.ScanDocs)
+	sg.scanArchivesCheck.SetChecked(last.ScanArchives)
+	sg.enableOCRCheck.SetChecked(last.EnableOCR)
+	sg.enableAICheck.SetChecked(last.EnableAI)
+	sg.financeCheck.SetChecked(len(last.Groups) > 0)
+
+	sg.confirmNewScan(func() {
+		sg.startScanWithOptions(last.Dir, last.ScanDocs, last.ScanArchives, last.EnableOCR, last.EnableAI)
+	})
+}
+
 func (sg *ScannerGUI) onPauseScan() {
+	scanner := sg.currentScanner.Load()
+	if scanner == nil {
+		return
+	}
 	if sg.paused.Load() {
+		scanner.Resume()
 		sg.paused.Store(false)
-		sg.pauseButton.SetText("⏸️ Пауза")
-		sg.statusLabel.SetText("🔄 Сканирование возобновлено...")
+		sg.pauseButton.SetText(sg.ui.T("gui.pause"))
+		sg.statusLabel.SetText(sg.ui.T("gui.resumed"))
 	} else {
+		scanner.Pause()
 		sg.paused.Store(true)
-		sg.pauseButton.SetText("▶️ Продолжить")
-		sg.statusLabel.SetText("⏸️ Сканирование приостановлено")
+		sg.pauseButton.SetText(sg.ui.T("gui.resume"))
+		sg.statusLabel.SetText(sg.ui.T("gui.paused"))
+	}
+}
+
+// scanElapsed returns the time since the scan started, without pauses
+func (sg *ScannerGUI) scanElapsed() time.Duration {
+	if scanner := sg.currentScanner.Load(); scanner != nil {
+		sg.pausedFor.Store(int64(scanner.PausedDuration()))
 	}
+	return time.Since(sg.startTime) - time.Duration(sg.pausedFor.Load())
 }
 
 func (sg *ScannerGUI) onCancelScan() {
-	dialog.ShowConfirm("Отмена сканирования", "Вы уверены, что хотите отменить сканирование?", func(confirm bool) {
+	dialog.ShowConfirm(sg.ui.T("gui.cancel_scan.title"), sg.ui.T("gui.cancel_scan.confirm"), func(confirm bool) {
 		if confirm {
 			sg.cancelled.Store(true)
 			sg.scanning.Store(false)
+			// Stops a paused scanner too, files in progress abort their extraction
+			sg.scanMutex.Lock()
+			if sg.cancelScan != nil {
+				sg.cancelScan()
+			}
+			sg.scanMutex.Unlock()
 		}
 	}, sg.window)
 }
 
 func (sg *ScannerGUI) onExport() {
 	if sg.resultData == nil {
-		dialog.ShowError(fmt.Errorf("нет результатов для экспорта"), sg.window)
+		dialog.ShowError(errors.New(sg.ui.T("gui.export.no_results")), sg.window)
 		return
 	}
 
@@ -1450,54 +1691,39 @@ func (sg *ScannerGUI) onExport() {
 	}
 
 	if err := os.MkdirAll(outputDir, 0755); err != nil {
-		dialog.ShowError(err, sg.window)
+		sg.showUserError(err)
 		return
 	}
 
 	reporter := searcher.NewReportGenerator(sg.resultData)
-	if err := reporter.GenerateReport(outputDir); err != nil {
-		dialog.ShowError(err, sg.window)
+	reporter.SetLocale(sg.ui)
+	reportDir, err := reporter.GenerateReport(outputDir)
+	if err != nil {
+		sg.showUserError(err)
+		return
+	}
+
+	// The .dllreport copy opens back in the GUI by double-click
+	reportFile := filepath.Join(reportDir, sg.ui.T("report.file_name")+searcher.ReportExtension)
+	if err := reporter.ExportJSON(reportFile); err != nil {
+		sg.showUserError(err)
 		return
 	}
 
-	sg.statusLabel.SetText(fmt.Sprintf("✅ Отчёты экспортированы в: %s", outputDir))
+	sg.statusLabel.SetText(sg.ui.T("gui.export.status", reportDir))
 
-	dialog.ShowInformation("Экспорт завершён",
-		fmt.Sprintf("Отчёты сохранены в:\n%s\n\n• JSON отчёт\n• CSV отчёт\n• Текстовый отчёт", outputDir),
-		sg.window)
+	dialog.ShowInformation(sg.ui.T("gui.export.done"), sg.ui.T("gui.export.saved", reportDir, searcher.ReportExtension), sg.window)
 }
 
 // updateStatsUI updates stats labels - must be called from main thread
 func (sg *ScannerGUI) updateStatsUI() {
-	sg.filesMutex.RLock()
-	defer sg.filesMutex.RUnlock()
-
-	var critical, high, medium, low int
-	for _, file := range sg.filesData {
-		if sg.ignoreList[file.FilePath] {
-			continue
-		}
-		for _, f := range file.Findings {
-			switch f.Severity {
-			case searcher.Critical:
-				critical++
-			case searcher.High:
-				high++
-			case searcher.Medium:
-				medium++
-			case searcher.Low:
-				low++
-			}
-		}
-	}
-
-	total := critical + high + medium + low
+	counts := sg.results.Counts()
 
-	sg.totalLabel.SetText(strconv.Itoa(total))
-	sg.criticalLabel.SetText(strconv.Itoa(critical))
-	sg.highLabel.SetText(strconv.Itoa(high))
-	sg.mediumLabel.SetText(strconv.Itoa(medium))
-	sg.lowLabel.SetText(strconv.Itoa(low))
+	sg.totalLabel.SetText(strconv.Itoa(counts.Total()))
+	sg.criticalLabel.SetText(strconv.Itoa(counts.Critical))
+	sg.highLabel.SetText(strconv.Itoa(counts.High))
+	sg.mediumLabel.SetText(strconv.Itoa(counts.Medium))
+	sg.lowLabel.SetText(strconv.Itoa(counts.Low))
 	sg.filesLabel.SetText(strconv.Itoa(int(sg.filesProcessed.Load())))
 }
 
@@ -1518,13 +1744,33 @@ func (sg *ScannerGUI) showSettings() {
 	concurrencyEntry.SetText(strconv.Itoa(sg.settings.Concurrency))
 
 	// Follow symlinks
-	followSymlinks := widget.NewCheck("Следовать по символьным ссылкам", nil)
+	followSymlinks := widget.NewCheck(sg.ui.T("gui.settings.symlinks"), nil)
 	followSymlinks.SetChecked(sg.settings.FollowSymlinks)
+	externalLinks := widget.NewCheck(sg.ui.T("gui.settings.external_links"), nil)
+	externalLinks.SetChecked(sg.settings.ExternalLinks)
+	if !followSymlinks.Checked {
+		externalLinks.Disable()
+	}
+	followSymlinks.OnChanged = func(checked bool) {
+		if checked {
+			externalLinks.Enable()
+		} else {
+			externalLinks.Disable()
+		}
+	}
 
 	// Scan binaries
-	scanBinaries := widget.NewCheck("Сканировать бинарные файлы", nil)
+	scanBinaries := widget.NewCheck(sg.ui.T("gui.settings.binaries"), nil)
 	scanBinaries.SetChecked(sg.settings.ScanBinaries)
 
+	// Respect .gitignore
+	respectGitignore := widget.NewCheck(sg.ui.T("gui.settings.gitignore"), nil)
+	respectGitignore.SetChecked(sg.settings.Gitignore)
+
+	// Generic high-entropy detection
+	detectEntropy := widget.NewCheck(sg.ui.T("gui.settings.entropy"), nil)
+	detectEntropy.SetChecked(sg.settings.Entropy)
+
 	// Excluded directories
 	excludeDirsEntry := widget.NewMultiLineEntry()
 	excludeDirsEntry.SetText(strings.Join(sg.settings.ExcludeDirs, "\n"))
@@ -1535,16 +1781,113 @@ func (sg *ScannerGUI) showSettings() {
 	excludeExtsEntry.SetText(strings.Join(sg.settings.ExcludeExts, "\n"))
 	excludeExtsEntry.SetMinRowsVisible(4)
 
+	// Minimum severity and findings per file
+	allSeverities := sg.ui.T("gui.settings.all_severities")
+	severityOptions := []string{allSeverities}
+	for _, severity := range []searcher.Severity{searcher.Critical, searcher.High, searcher.Medium, searcher.Low} {
+		severityOptions = append(severityOptions, sg.severityName(severity))
+	}
+	minSeveritySelect := widget.NewSelect(severityOptions, nil)
+	minSeveritySelect.SetSelected(allSeverities)
+	if severity, err := searcher.ParseSeverity(sg.settings.MinSeverity); err == nil {
+		minSeveritySelect.SetSelected(sg.severityName(severity))
+	}
+	maxPerFileEntry := widget.NewEntry()
+	maxPerFileEntry.SetPlaceHolder(sg.ui.T("gui.settings.no_limit"))
+	if sg.settings.MaxPerFile > 0 {
+		maxPerFileEntry.SetText(strconv.Itoa(sg.settings.MaxPerFile))
+	}
+
+	// Notifications
+	webhookEntry := widget.NewEntry()
+	webhookEntry.SetPlaceHolder("https://siem.example.com/hooks/dll")
+	webhookEntry.SetText(sg.settings.WebhookURL)
+	slackEntry := widget.NewEntry()
+	slackEntry.SetPlaceHolder("https://hooks.slack.com/services/...")
+	slackEntry.SetText(sg.settings.SlackWebhook)
+	notifyOptions := map[searcher.NotifyMode]string{
+		searcher.NotifySummary:  sg.ui.T("gui.settings.notify_summary"),
+		searcher.NotifyCritical: sg.ui.T("gui.settings.notify_critical"),
+		searcher.NotifyAll:      sg.ui.T("gui.settings.notify_all"),
+	}
+	notifySelect := widget.NewSelect([]string{
+		notifyOptions[searcher.NotifySummary], notifyOptions[searcher.NotifyCritical], notifyOptions[searcher.NotifyAll],
+	}, nil)
+	notifyMode, _ := searcher.ParseNotifyMode(sg.settings.NotifyOn)
+	notifySelect.SetSelected(notifyOptions[notifyMode])
+
+	// Severity per pattern type
+	severityEditor, severityOverrides := sg.severityOverridesEditor()
+
+	// Pattern types searched for
+	patternsEditor, disabledPatterns := sg.patternTogglesEditor()
+
+	// Language, applied on the next start as every label is built once
+	languageOptions := map[string]string{"": sg.ui.T("gui.settings.language_auto"), "ru": "Русский", "en": "English"}
+	languageSelect := widget.NewSelect([]string{languageOptions[""], languageOptions["ru"], languageOptions["en"]}, nil)
+	languageSelect.SetSelected(languageOptions[sg.settings.Language])
+
+	// Theme, applied when saved
+	themeOptions := map[string]string{
+		ThemeSystem: sg.ui.T("gui.settings.theme_system"),
+		ThemeLight:  sg.ui.T("gui.settings.theme_light"),
+		ThemeDark:   sg.ui.T("gui.settings.theme_dark"),
+	}
+	themeSelect := widget.NewSelect([]string{themeOptions[ThemeSystem], themeOptions[ThemeLight], themeOptions[ThemeDark]}, nil)
+	themeSelect.SetSelected(themeOptions[sg.settings.Theme])
+
+	// Editor of the "Открыть в редакторе" buttons, the detected ones and a template
+	editorOptions := map[string]string{EditorCustom: sg.ui.T("gui.settings.editor_custom")}
+	var editorChoices []string
+	for _, editor := range DetectEditors() {
+		editorOptions[editor] = editorTitles[editor]
+		editorChoices = append(editorChoices, editorTitles[editor])
+	}
+	if title, ok := editorTitles[sg.settings.Editor]; ok && editorOptions[sg.settings.Editor] == "" {
+		// Chosen earlier and not found now, kept so saving does not drop it
+		editorOptions[sg.settings.Editor] = title
+		editorChoices = append(editorChoices, title)
+	}
+	editorChoices = append(editorChoices, editorOptions[EditorCustom])
+	editorTemplateEntry := widget.NewEntry()
+	editorTemplateEntry.SetPlaceHolder("myeditor {file} +{line}")
+	editorTemplateEntry.SetText(sg.settings.EditorTemplate)
+	editorSelect := widget.NewSelect(editorChoices, func(title string) {
+		if title == editorOptions[EditorCustom] {
+			editorTemplateEntry.Enable()
+		} else {
+			editorTemplateEntry.Disable()
+		}
+	})
+	editorSelect.SetSelected(editorChoices[0])
+	if title, ok := editorOptions[sg.settings.Editor]; ok {
+		editorSelect.SetSelected(title)
+	}
+
 	formItems := []*widget.FormItem{
-		widget.NewFormItem("Макс. размер файла (МБ)", maxSizeEntry),
-		widget.NewFormItem("Параллельность", concurrencyEntry),
+		widget.NewFormItem(sg.ui.T("gui.settings.max_size"), maxSizeEntry),
+		widget.NewFormItem(sg.ui.T("gui.settings.concurrency"), concurrencyEntry),
 		widget.NewFormItem("", followSymlinks),
+		widget.NewFormItem("", externalLinks),
 		widget.NewFormItem("", scanBinaries),
-		widget.NewFormItem("Исключить директории (по одной на строку)", excludeDirsEntry),
-		widget.NewFormItem("Исключить расширения (по одному на строку)", excludeExtsEntry),
-	}
-
-	dialog.ShowForm("⚙️ Настройки", "Сохранить", "Отмена", formItems, func(confirm bool) {
+		widget.NewFormItem("", respectGitignore),
+		widget.NewFormItem("", detectEntropy),
+		widget.NewFormItem(sg.ui.T("gui.settings.exclude_dirs"), excludeDirsEntry),
+		widget.NewFormItem(sg.ui.T("gui.settings.exclude_exts"), excludeExtsEntry),
+		widget.NewFormItem(sg.ui.T("gui.settings.min_severity"), minSeveritySelect),
+		widget.NewFormItem(sg.ui.T("gui.settings.max_per_file"), maxPerFileEntry),
+		widget.NewFormItem(sg.ui.T("gui.settings.severity_overrides"), severityEditor),
+		widget.NewFormItem(sg.ui.T("gui.settings.patterns"), patternsEditor),
+		widget.NewFormItem(sg.ui.T("gui.settings.webhook"), webhookEntry),
+		widget.NewFormItem(sg.ui.T("gui.settings.slack"), slackEntry),
+		widget.NewFormItem(sg.ui.T("gui.settings.notify_on"), notifySelect),
+		widget.NewFormItem(sg.ui.T("gui.settings.language"), languageSelect),
+		widget.NewFormItem(sg.ui.T("gui.settings.theme"), themeSelect),
+		widget.NewFormItem(sg.ui.T("gui.settings.editor"), editorSelect),
+		widget.NewFormItem(sg.ui.T("gui.settings.editor_template"), editorTemplateEntry),
+	}
+
+	dialog.ShowForm(sg.ui.T("gui.settings"), sg.ui.T("gui.save"), sg.ui.T("gui.cancel_button"), formItems, func(confirm bool) {
 		if !confirm {
 			return
 		}
@@ -1560,7 +1903,10 @@ func (sg *ScannerGUI) showSettings() {
 		}
 
 		sg.settings.FollowSymlinks = followSymlinks.Checked
+		sg.settings.ExternalLinks = externalLinks.Checked
 		sg.settings.ScanBinaries = scanBinaries.Checked
+		sg.settings.Gitignore = respectGitignore.Checked
+		sg.settings.Entropy = detectEntropy.Checked
 
 		// Parse excluded dirs
 		dirs := strings.Split(excludeDirsEntry.Text, "\n")
@@ -1582,68 +1928,148 @@ func (sg *ScannerGUI) showSettings() {
 			}
 		}
 
-		sg.statusLabel.SetText("✅ Настройки сохранены")
+		sg.settings.MinSeverity = ""
+		for _, severity := range []searcher.Severity{searcher.Critical, searcher.High, searcher.Medium, searcher.Low} {
+			if minSeveritySelect.Selected == sg.severityName(severity) {
+				sg.settings.MinSeverity = string(severity)
+			}
+		}
+		if n, err := strconv.Atoi(strings.TrimSpace(maxPerFileEntry.Text)); err == nil && n >= 0 {
+			sg.settings.MaxPerFile = n
+		} else if strings.TrimSpace(maxPerFileEntry.Text) == "" {
+			sg.settings.MaxPerFile = 0
+		}
+
+		sg.settings.SeverityOverrides = severityOverrides()
+		sg.settings.DisabledPatterns = disabledPatterns()
+
+		sg.settings.WebhookURL = strings.TrimSpace(webhookEntry.Text)
+		sg.settings.SlackWebhook = strings.TrimSpace(slackEntry.Text)
+		for mode, title := range notifyOptions {
+			if notifySelect.Selected == title {
+				sg.settings.NotifyOn = string(mode)
+			}
+		}
+
+		for editor, title := range editorOptions {
+			if editorSelect.Selected == title {
+				sg.settings.Editor = editor
+			}
+		}
+		sg.settings.EditorTemplate = strings.TrimSpace(editorTemplateEntry.Text)
+
+		for name, title := range themeOptions {
+			if themeSelect.Selected == title {
+				sg.settings.Theme = name
+			}
+		}
+		sg.applyTheme()
+		sg.refreshFilesList()
+
+		language := sg.settings.Language
+		for code, title := range languageOptions {
+			if languageSelect.Selected == title {
+				sg.settings.Language = code
+			}
+		}
+
+		sg.saveState()
+		sg.statusLabel.SetText(sg.ui.T("gui.settings.saved"))
+		if sg.settings.Language != language {
+			sg.statusLabel.SetText(sg.ui.T("gui.settings.language_restart"))
+		}
 	}, sg.window)
 }
 
+// severityOverridesEditor builds the table of pattern types with the
+// severity chosen for each, the default keeping the one of the rules.
+// The returned function collects the overrides chosen in the table
+func (sg *ScannerGUI) severityOverridesEditor() (fyne.CanvasObject, func() map[string]string) {
+	keep := sg.ui.T("gui.settings.severity_default")
+	severities := []searcher.Severity{searcher.Critical, searcher.High, searcher.Medium, searcher.Low}
+	options := []string{keep}
+	for _, severity := range severities {
+		options = append(options, sg.severityName(severity))
+	}
+
+	types := searcher.AllPatternTypes()
+	selects := make([]*widget.Select, len(types))
+	rows := container.NewGridWithColumns(2)
+	for i, t := range types {
+		selects[i] = widget.NewSelect(options, nil)
+		selects[i].SetSelected(keep)
+		if value, ok := sg.settings.SeverityOverrides[string(t)]; ok {
+			if severity, err := searcher.ParseSeverity(value); err == nil {
+				selects[i].SetSelected(sg.severityName(severity))
+			}
+		}
+		rows.Add(widget.NewLabel(sg.patternName(t)))
+		rows.Add(selects[i])
+	}
+	scroll := container.NewVScroll(rows)
+	scroll.SetMinSize(fyne.NewSize(0, 180))
+
+	return scroll, func() map[string]string {
+		overrides := make(map[string]string)
+		for i, t := range types {
+			for _, severity := range severities {
+				if selects[i].Selected == sg.severityName(severity) {
+					overrides[string(t)] = string(severity)
+				}
+			}
+		}
+		if len(overrides) == 0 {
+			return nil
+		}
+		return overrides
+	}
+}
+
 func (sg *ScannerGUI) showHelp() {
-	helpText := `🔍 Поиск Утечек Данных - Сканер Безопасности
-
-ВОЗМОЖНОСТИ:
-• Сканирование директорий на наличие чувствительных данных
-• Обнаружение паролей, API-ключей, токенов, банковских карт
-• 📄 Сканирование документов (PDF, DOCX, XLSX)
-• 📦 Сканирование архивов (ZIP, TAR)
-• 🔍 OCR для изображений (требуется Tesseract)
-• 🤖 AI-анализ (локальный, через Ollama)
-• 🔐 Шифрование выбранных файлов (AES-256)
-
-ОПЦИИ СКАНИРОВАНИЯ:
-• Документы - извлекает текст из PDF, Word, Excel
-• Архивы - сканирует содержимое ZIP/TAR файлов
-• OCR - распознаёт текст на изображениях
-• AI-анализ - даёт рекомендации по устранению
-
-УРОВНИ СЕРЬЁЗНОСТИ:
-🔴 Критический - Требуется немедленное действие
-🟠 Высокий - Следует исправить в ближайшее время
-🟡 Средний - Рекомендуется проверить
-🟢 Низкий - Незначительная проблема`
-
-	dialog.ShowInformation("Справка", helpText, sg.window)
+	dialog.ShowInformation(sg.ui.T("gui.help.title"), sg.ui.T("gui.help.text"), sg.window)
 }
 
 // showAIAnalysisDialogWithStatus shows the AI analysis results with Ollama status
-func (sg *ScannerGUI) showAIAnalysisDialogWithStatus(analysis *searcher.AnalysisResult, analyzer *searcher.LocalAnalyzer, ollamaAvailable bool) {
+func (sg *ScannerGUI) showAIAnalysisDialogWithStatus(analysis *searcher.AnalysisResult, analyzer *searcher.LocalAnalyzer, aiErr error) {
 	// Create scrollable content
 	var content []fyne.CanvasObject
 
 	// Ollama status banner
-	if !ollamaAvailable {
-		warningCard := widget.NewCard("⚠️ Ollama недоступен",
-			"Используется базовый правило-ориентированный анализ.\nДля полного AI-анализа установите Ollama:",
+	var ollamaErr *searcher.OllamaError
+	switch {
+	case errors.Is(aiErr, context.Canceled):
+		content = append(content, widget.NewCard(sg.ui.T("gui.ai.cancelled"),
+			sg.ui.T("gui.ai.cancelled_hint"), nil), widget.NewSeparator())
+	case errors.Is(aiErr, searcher.ErrOllamaModelNotFound) && errors.As(aiErr, &ollamaErr):
+		warningCard := widget.NewCard(sg.ui.T("gui.ai.no_model"),
+			sg.ui.T("gui.ai.no_model_hint"),
+			widget.NewLabel("ollama pull "+ollamaErr.Model))
+		content = append(content, warningCard, widget.NewSeparator())
+	case aiErr != nil:
+		warningCard := widget.NewCard(sg.ui.T("gui.ai.unavailable"),
+			sg.ui.T("gui.ai.unavailable_hint"),
 			widget.NewLabel("brew install ollama && ollama pull llama3.2"))
 		content = append(content, warningCard, widget.NewSeparator())
-	} else {
-		statusLabel := widget.NewLabel("✅ Анализ выполнен с использованием Ollama AI")
+	default:
+		statusLabel := widget.NewLabel(sg.ui.T("gui.ai.done"))
 		statusLabel.TextStyle.Bold = true
 		content = append(content, statusLabel, widget.NewSeparator())
 	}
 
 	// Summary section
-	summaryLabel := widget.NewLabelWithStyle("📊 СВОДКА", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	summaryLabel := widget.NewLabelWithStyle(sg.ui.T("gui.ai.summary"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
 	summaryText := widget.NewLabel(analysis.Summary)
 	summaryText.Wrapping = fyne.TextWrapWord
 	content = append(content, summaryLabel, summaryText, widget.NewSeparator())
 
 	// Risk assessment
-	riskLabel := widget.NewLabelWithStyle("⚠️ ОЦЕНКА РИСКА", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	riskLabel := widget.NewLabelWithStyle(sg.ui.T("gui.ai.risk"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
 	riskText := widget.NewLabel(analysis.RiskAssessment)
 	riskText.Wrapping = fyne.TextWrapWord
 	content = append(content, riskLabel, riskText, widget.NewSeparator())
 
 	// Recommendations
-	recLabel := widget.NewLabelWithStyle("💡 РЕКОМЕНДАЦИИ", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	recLabel := widget.NewLabelWithStyle(sg.ui.T("gui.ai.recommendations"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
 	content = append(content, recLabel)
 	for i, rec := range analysis.Recommendations {
 		recText := widget.NewLabel(fmt.Sprintf("%d. %s", i+1, rec))
@@ -1654,7 +2080,7 @@ func (sg *ScannerGUI) showAIAnalysisDialogWithStatus(analysis *searcher.Analysis
 
 	// Critical findings (top 5)
 	if len(analysis.CriticalFindings) > 0 {
-		critLabel := widget.NewLabelWithStyle("🔴 КРИТИЧЕСКИЕ НАХОДКИ", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+		critLabel := widget.NewLabelWithStyle(sg.ui.T("gui.ai.critical"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
 		content = append(content, critLabel)
 
 		maxShow := 5
@@ -1664,14 +2090,14 @@ func (sg *ScannerGUI) showAIAnalysisDialogWithStatus(analysis *searcher.Analysis
 
 		for i := 0; i < maxShow; i++ {
 			cf := analysis.CriticalFindings[i]
-			cfText := widget.NewLabel(fmt.Sprintf("• %s\n  %s (Риск: %.0f%%)\n  💡 %s",
+			cfText := widget.NewLabel(sg.ui.T("gui.ai.critical_finding",
 				filepath.Base(cf.FilePath), cf.Description, cf.RiskScore, cf.Suggestion))
 			cfText.Wrapping = fyne.TextWrapWord
 			content = append(content, cfText)
 		}
 
 		if len(analysis.CriticalFindings) > 5 {
-			moreText := widget.NewLabel(fmt.Sprintf("... и ещё %d критических находок", len(analysis.CriticalFindings)-5))
+			moreText := widget.NewLabel(sg.ui.T("gui.ai.critical_more", len(analysis.CriticalFindings)-5))
 			content = append(content, moreText)
 		}
 	}
@@ -1679,7 +2105,7 @@ func (sg *ScannerGUI) showAIAnalysisDialogWithStatus(analysis *searcher.Analysis
 	// AI insights if available
 	if analysis.AIInsights != "" {
 		content = append(content, widget.NewSeparator())
-		aiLabel := widget.NewLabelWithStyle("🤖 AI-АНАЛИЗ (Ollama)", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+		aiLabel := widget.NewLabelWithStyle(sg.ui.T("gui.ai.insights"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
 		aiText := widget.NewLabel(analysis.AIInsights)
 		aiText.Wrapping = fyne.TextWrapWord
 		content = append(content, aiLabel, aiText)
@@ -1691,39 +2117,43 @@ func (sg *ScannerGUI) showAIAnalysisDialogWithStatus(analysis *searcher.Analysis
 	scroll.SetMinSize(fyne.NewSize(600, 400))
 
 	// Save button
-	saveBtn := widget.NewButton("💾 Сохранить отчёт", func() {
+	saveBtn := widget.NewButton(sg.ui.T("gui.ai.save"), func() {
 		report := analyzer.FormatAnalysisReport(analysis)
-		outputPath := filepath.Join(sg.outputDir.Text, "анализ-безопасности.txt")
+		outputPath := filepath.Join(sg.outputDir.Text, sg.ui.T("cli.ai.file_name")+".txt")
 		if err := os.WriteFile(outputPath, []byte(report), 0644); err != nil {
-			dialog.ShowError(err, sg.window)
+			sg.showUserError(err)
 		} else {
-			dialog.ShowInformation("Сохранено", fmt.Sprintf("Отчёт сохранён в:\n%s", outputPath), sg.window)
+			dialog.ShowInformation(sg.ui.T("gui.ai.saved_title"), sg.ui.T("gui.ai.saved", outputPath), sg.window)
 		}
 	})
 
 	dialogContent := container.NewBorder(nil, saveBtn, nil, nil, scroll)
 
-	d := dialog.NewCustom("🤖 AI-Анализ Безопасности", "Закрыть", dialogContent, sg.window)
+	d := dialog.NewCustom(sg.ui.T("gui.ai.title"), sg.ui.T("gui.close"), dialogContent, sg.window)
 	d.Resize(fyne.NewSize(700, 500))
 	d.Show()
 }
 
 // onEncrypt handles the encrypt button click
 func (sg *ScannerGUI) onEncrypt() {
-	selectedPaths := sg.getSelectedFilePaths()
+	selectedPaths := sg.results.GetSelectedPaths()
 	if len(selectedPaths) == 0 {
-		dialog.ShowError(fmt.Errorf("не выбраны файлы для шифрования"), sg.window)
+		dialog.ShowError(errors.New(sg.ui.T("gui.encrypt.no_files")), sg.window)
 		return
 	}
 
 	// Password entry
 	passwordEntry := widget.NewPasswordEntry()
-	passwordEntry.SetPlaceHolder("Введите пароль...")
+	passwordEntry.SetPlaceHolder(sg.ui.T("gui.encrypt.password_hint"))
 
 	confirmPasswordEntry := widget.NewPasswordEntry()
-	confirmPasswordEntry.SetPlaceHolder("Подтвердите пароль...")
+	confirmPasswordEntry.SetPlaceHolder(sg.ui.T("gui.encrypt.confirm_hint"))
+
+	// Strength of the password, updated as it is typed
+	meter := newStrengthMeter(sg.ui)
+	passwordEntry.OnChanged = meter.update
 
-	showPassword := widget.NewCheck("Показать пароль", func(checked bool) {
+	showPassword := widget.NewCheck(sg.ui.T("gui.encrypt.show_password"), func(checked bool) {
 		passwordEntry.Password = !checked
 		confirmPasswordEntry.Password = !checked
 		passwordEntry.Refresh()
@@ -1731,10 +2161,10 @@ func (sg *ScannerGUI) onEncrypt() {
 	})
 
 	// Generate password button
-	generateBtn := widget.NewButton("🎲 Сгенерировать", func() {
+	generateBtn := widget.NewButton(sg.ui.T("gui.encrypt.generate"), func() {
 		pwd, err := encryptor.GeneratePassword(16)
 		if err != nil {
-			dialog.ShowError(err, sg.window)
+			sg.showUserError(err)
 			return
 		}
 		passwordEntry.SetText(pwd)
@@ -1743,8 +2173,21 @@ func (sg *ScannerGUI) onEncrypt() {
 	})
 	generateBtn.Importance = widget.LowImportance
 
+	// Patterns left out of directories, one per line
+	excludeEntry := widget.NewMultiLineEntry()
+	excludeEntry.SetPlaceHolder("**/.git\n**/node_modules")
+	excludeEntry.SetMinRowsVisible(3)
+
+	// Volume size, the archive is written whole when empty
+	splitEntry := widget.NewEntry()
+	splitEntry.SetPlaceHolder(sg.ui.T("gui.encrypt.split_hint"))
+
+	// Manifest of the files and their findings inside the archive
+	includeManifest := widget.NewCheck(sg.ui.T("gui.encrypt.manifest"), nil)
+	includeManifest.SetChecked(true)
+
 	// Delete originals option
-	deleteOriginals := widget.NewCheck("Удалить оригиналы после шифрования (безопасное удаление)", nil)
+	deleteOriginals := widget.NewCheck(sg.ui.T("gui.encrypt.delete"), nil)
 
 	// Output location
 	homeDir, _ := os.UserHomeDir()
@@ -1752,7 +2195,23 @@ func (sg *ScannerGUI) onEncrypt() {
 	outputEntry := widget.NewEntry()
 	outputEntry.SetText(defaultOutput)
 
-	browseOutputBtn := widget.NewButton("📂 Обзор", func() {
+	// Archive format; the extension of the output follows it
+	format := encryptor.FormatZipAES
+	var formatTitles []string
+	for _, f := range encryptor.OutputFormats {
+		formatTitles = append(formatTitles, sg.ui.T(encryptFormatTitles[f]))
+	}
+	formatSelect := widget.NewSelect(formatTitles, func(title string) {
+		for _, f := range encryptor.OutputFormats {
+			if sg.ui.T(encryptFormatTitles[f]) == title {
+				format = f
+			}
+		}
+		outputEntry.SetText(outputWithFormat(outputEntry.Text, format))
+	})
+	formatSelect.SetSelected(sg.ui.T(encryptFormatTitles[format]))
+
+	browseOutputBtn := widget.NewButton(sg.ui.T("gui.encrypt.browse"), func() {
 		dialog.ShowFileSave(func(writer fyne.URIWriteCloser, err error) {
 			if err != nil || writer == nil {
 				return
@@ -1763,19 +2222,24 @@ func (sg *ScannerGUI) onEncrypt() {
 	})
 
 	// File count info
-	fileCountLabel := widget.NewLabel(fmt.Sprintf("📁 Выбрано файлов: %d", len(selectedPaths)))
+	fileCountLabel := widget.NewLabel(sg.ui.T("gui.encrypt.file_count", len(selectedPaths)))
 
 	formItems := []*widget.FormItem{
-		widget.NewFormItem("Файлы", fileCountLabel),
-		widget.NewFormItem("Пароль", container.NewBorder(nil, nil, nil, generateBtn, passwordEntry)),
-		widget.NewFormItem("Подтверждение", confirmPasswordEntry),
+		widget.NewFormItem(sg.ui.T("gui.encrypt.files"), fileCountLabel),
+		widget.NewFormItem(sg.ui.T("gui.encrypt.password"), container.NewBorder(nil, nil, nil, generateBtn, passwordEntry)),
+		widget.NewFormItem("", meter.box),
+		widget.NewFormItem(sg.ui.T("gui.encrypt.confirm"), confirmPasswordEntry),
 		widget.NewFormItem("", showPassword),
 		widget.NewFormItem("", widget.NewSeparator()),
-		widget.NewFormItem("Сохранить в", container.NewBorder(nil, nil, nil, browseOutputBtn, outputEntry)),
+		widget.NewFormItem(sg.ui.T("gui.encrypt.output"), container.NewBorder(nil, nil, nil, browseOutputBtn, outputEntry)),
+		widget.NewFormItem(sg.ui.T("gui.import.format"), formatSelect),
+		widget.NewFormItem(sg.ui.T("gui.encrypt.exclude"), excludeEntry),
+		widget.NewFormItem(sg.ui.T("gui.encrypt.split"), splitEntry),
+		widget.NewFormItem("", includeManifest),
 		widget.NewFormItem("", deleteOriginals),
 	}
 
-	dialog.ShowForm("🔐 Зашифровать и экспортировать", "Зашифровать", "Отмена", formItems, func(confirm bool) {
+	dialog.ShowForm(sg.ui.T("gui.encrypt.title"), sg.ui.T("gui.encrypt.submit"), sg.ui.T("gui.cancel_button"), formItems, func(confirm bool) {
 		if !confirm {
 			return
 		}
@@ -1785,58 +2249,142 @@ func (sg *ScannerGUI) onEncrypt() {
 		confirmPwd := confirmPasswordEntry.Text
 
 		if password != confirmPwd {
-			dialog.ShowError(fmt.Errorf("пароли не совпадают"), sg.window)
+			dialog.ShowError(errors.New(sg.ui.T("gui.encrypt.mismatch")), sg.window)
 			return
 		}
 
 		if err := encryptor.ValidatePassword(password); err != nil {
-			dialog.ShowError(fmt.Errorf("слабый пароль: %v", err), sg.window)
+			sg.showUserError(err)
 			return
 		}
 
 		outputPath := outputEntry.Text
 		if outputPath == "" {
-			dialog.ShowError(fmt.Errorf("укажите путь для сохранения"), sg.window)
+			dialog.ShowError(errors.New(sg.ui.T("gui.encrypt.no_output")), sg.window)
+			return
+		}
+
+		// Ensure the extension of the format
+		outputPath = outputWithFormat(outputPath, format)
+
+		excludeGlobs := excludePatterns(excludeEntry.Text)
+		if err := encryptor.ValidateExcludeGlobs(excludeGlobs); err != nil {
+			dialog.ShowError(errors.New(sg.ui.T("gui.encrypt.bad_exclude", err)), sg.window)
 			return
 		}
 
-		// Ensure .zip extension
-		if !strings.HasSuffix(strings.ToLower(outputPath), ".zip") {
-			outputPath += ".zip"
+		splitSize, err := parseSplitSize(sg.ui, splitEntry.Text)
+		if err != nil {
+			dialog.ShowError(err, sg.window)
+			return
+		}
+
+		var manifest *encryptor.ManifestSource
+		if includeManifest.Checked {
+			manifest = sg.selectedManifestSource()
 		}
 
 		// Confirm deletion if requested
-		if deleteOriginals.Checked {
-			dialog.ShowConfirm("Удалить оригиналы?",
-				fmt.Sprintf("После шифрования %d файлов будут безопасно удалены. Это необратимо!", len(selectedPaths)),
+		start := func() {
+			if deleteOriginals.Checked {
+				dialog.ShowConfirm(sg.ui.T("gui.encrypt.delete_title"),
+					sg.ui.T("gui.encrypt.delete_confirm", len(selectedPaths)),
+					func(confirmed bool) {
+						if confirmed {
+							sg.runEncryption(selectedPaths, password, outputPath, format, excludeGlobs, splitSize, manifest, true)
+						}
+					}, sg.window)
+			} else {
+				sg.runEncryption(selectedPaths, password, outputPath, format, excludeGlobs, splitSize, manifest, false)
+			}
+		}
+
+		// 7-Zip takes the password on its command line, where other users
+		// of the system can see it
+		if format == encryptor.FormatSevenZip {
+			dialog.ShowConfirm(sg.ui.T("gui.encrypt.7z_password_title"),
+				sg.ui.T("gui.encrypt.7z_password_confirm"),
 				func(confirmed bool) {
 					if confirmed {
-						sg.runEncryption(selectedPaths, password, outputPath, true)
+						start()
 					}
 				}, sg.window)
-		} else {
-			sg.runEncryption(selectedPaths, password, outputPath, false)
+			return
 		}
+		start()
 	}, sg.window)
 }
 
-// runEncryption performs the encryption with progress
-func (sg *ScannerGUI) runEncryption(filePaths []string, password, outputPath string, deleteOriginals bool) {
+// encryptFormatTitles are the catalog keys naming the archive formats in
+// the encrypt dialog
+var encryptFormatTitles = map[encryptor.OutputFormat]string{
+	encryptor.FormatZipAES:   "gui.encrypt.format.zip",
+	encryptor.FormatSevenZip: "gui.encrypt.format.7z",
+	encryptor.FormatAge:      "gui.encrypt.format.age",
+}
+
+// outputWithFormat gives an archive path the extension of format, replacing
+// the extension of another format
+func outputWithFormat(path string, format encryptor.OutputFormat) string {
+	lower := strings.ToLower(path)
+	for _, f := range encryptor.OutputFormats {
+		if strings.HasSuffix(lower, f.Ext()) {
+			path = path[:len(path)-len(f.Ext())]
+			break
+		}
+	}
+	return path + format.Ext()
+}
+
+// excludePatterns splits the exclude text area into patterns, one per line
+func excludePatterns(text string) []string {
+	var patterns []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			patterns = append(patterns, line)
+		}
+	}
+	return patterns
+}
+
+// parseSplitSize reads the volume size field of the encrypt dialog; empty
+// means one archive
+func parseSplitSize(l *locale.Localizer, text string) (int64, error) {
+	if strings.TrimSpace(text) == "" {
+		return 0, nil
+	}
+	size, err := fsutil.ParseFileSize(text)
+	if err != nil {
+		return 0, errors.New(l.T("gui.encrypt.split_error", err))
+	}
+	if size < encryptor.MinSplitSize {
+		return 0, errors.New(l.T("gui.encrypt.split_min", controller.FormatFileSize(encryptor.MinSplitSize)))
+	}
+	return size, nil
+}
+
+// runEncryption performs the encryption with progress; manifest is nil for
+// an archive without one
+func (sg *ScannerGUI) runEncryption(filePaths []string, password, outputPath string, format encryptor.OutputFormat, excludeGlobs []string, splitSize int64, manifest *encryptor.ManifestSource, deleteOriginals bool) {
 	sg.encrypting.Store(true)
 	sg.encryptButton.Disable()
 
 	// Progress dialog
 	progressBar := widget.NewProgressBar()
-	progressLabel := widget.NewLabel("Подготовка...")
+	progressLabel := widget.NewLabel(sg.ui.T("gui.encrypt.preparing"))
 
 	progressContent := container.NewVBox(progressLabel, progressBar)
 
-	progressDialog := dialog.NewCustom("🔐 Шифрование...", "Отмена", progressContent, sg.window)
+	progressDialog := dialog.NewCustom(sg.ui.T("gui.encrypt.running"), sg.ui.T("gui.cancel_button"), progressContent, sg.window)
 	progressDialog.Show()
 
 	var cancelled bool
+	var running atomic.Pointer[encryptor.Encryptor]
 	progressDialog.SetOnClosed(func() {
 		cancelled = true
+		if enc := running.Load(); enc != nil {
+			enc.Cancel()
+		}
 	})
 
 	go func() {
@@ -1855,7 +2403,19 @@ func (sg *ScannerGUI) runEncryption(filePaths []string, password, outputPath str
 		config := encryptor.DefaultConfig()
 		config.Password = password
 		config.OutputPath = outputPath
+		config.Format = format
 		config.CompressionLevel = 6
+		config.ExcludeGlobs = excludeGlobs
+		if format == encryptor.FormatSevenZip {
+			config.SevenZipPath = searcher.NewDependencyChecker().Status(searcher.DependencySevenZip).Path
+			// Allowed in the encrypt dialog
+			config.SevenZipPasswordArg = true
+		}
+		// The originals are only deleted from an archive read back intact
+		config.VerifyAfterEncrypt = deleteOriginals
+		config.SplitSize = splitSize
+		config.IncludeManifest = manifest != nil
+		config.ManifestSource = manifest
 
 		config.OnProgress = func(processed, total int64, currentFile string) {
 			if cancelled {
@@ -1867,7 +2427,7 @@ func (sg *ScannerGUI) runEncryption(filePaths []string, password, outputPath str
 			}
 			fyne.Do(func() {
 				progressBar.SetValue(pct)
-				progressLabel.SetText(fmt.Sprintf("Шифрование: %s (%.0f%%)", filepath.Base(currentFile), pct*100))
+				progressLabel.SetText(sg.ui.T("gui.encrypt.progress", filepath.Base(currentFile), pct*100))
 			})
 		}
 
@@ -1875,17 +2435,21 @@ func (sg *ScannerGUI) runEncryption(filePaths []string, password, outputPath str
 		if err != nil {
 			fyne.Do(func() {
 				progressDialog.Hide()
-				dialog.ShowError(fmt.Errorf("ошибка создания шифровальщика: %v", err), sg.window)
+				dialog.ShowError(errors.New(sg.ui.T("gui.encrypt.create_error", userErrorMessage(sg.ui, err))), sg.window)
 			})
 			return
 		}
+		running.Store(enc)
+		if cancelled {
+			enc.Cancel()
+		}
 
 		result, err := enc.EncryptFilesWithResult(entries)
 		if err != nil {
 			if !cancelled {
 				fyne.Do(func() {
 					progressDialog.Hide()
-					dialog.ShowError(fmt.Errorf("ошибка шифрования: %v", err), sg.window)
+					dialog.ShowError(errors.New(sg.ui.T("gui.encrypt.error", userErrorMessage(sg.ui, err))), sg.window)
 				})
 			}
 			return
@@ -1893,16 +2457,16 @@ func (sg *ScannerGUI) runEncryption(filePaths []string, password, outputPath str
 
 		// Secure delete if requested
 		var filesDeleted int
-		if deleteOriginals {
+		if deleteOriginals && result.Verified {
 			fyne.Do(func() {
-				progressLabel.SetText("Безопасное удаление оригиналов...")
+				progressLabel.SetText(sg.ui.T("gui.encrypt.deleting"))
 			})
 
 			err := encryptor.SecureDeleteMultiple(filePaths, 3, func(current, total int, path string) {
 				if !cancelled {
 					fyne.Do(func() {
 						progressBar.SetValue(float64(current) / float64(total))
-						progressLabel.SetText(fmt.Sprintf("Удаление: %s (%d/%d)", filepath.Base(path), current, total))
+						progressLabel.SetText(sg.ui.T("gui.encrypt.deleting_file", filepath.Base(path), current, total))
 					})
 				}
 			})
@@ -1928,32 +2492,30 @@ func (sg *ScannerGUI) runEncryption(filePaths []string, password, outputPath str
 				return fmt.Sprintf("%.1f %s", float64(b)/float64(div), []string{"KB", "MB", "GB"}[exp])
 			}
 
-			successMsg := fmt.Sprintf(
-				"✅ Шифрование завершено!\n\n"+
-					"📦 Архив: %s\n"+
-					"📁 Файлов: %d\n"+
-					"📊 Исходный размер: %s\n"+
-					"📊 Размер архива: %s\n"+
-					"📈 Сжатие: %.1f%%",
+			successMsg := sg.ui.T("gui.encrypt.success",
 				filepath.Base(result.OutputPath),
 				result.FilesEncrypted,
 				formatSize(result.TotalSize),
 				formatSize(result.ArchiveSize),
 				result.CompressionRatio*100,
 			)
+			if len(result.Parts) > 0 {
+				successMsg += "\n" + sg.ui.T("gui.encrypt.volumes", len(result.Parts),
+					filepath.Base(result.Parts[0]), filepath.Base(result.Parts[len(result.Parts)-1]))
+			}
 
 			if filesDeleted > 0 {
-				successMsg += fmt.Sprintf("\n\n🗑️ Удалено оригиналов: %d", filesDeleted)
+				successMsg += "\n\n" + sg.ui.T("gui.encrypt.deleted", filesDeleted)
 			}
 
-			dialog.ShowInformation("Шифрование завершено", successMsg, sg.window)
+			dialog.ShowInformation(sg.ui.T("gui.encrypt.done"), successMsg, sg.window)
 
-			sg.statusLabel.SetText(fmt.Sprintf("✅ Зашифровано %d файлов", result.FilesEncrypted))
+			sg.statusLabel.SetText(sg.ui.T("gui.encrypt.status", result.FilesEncrypted))
 
 			// Send notification
 			sg.app.SendNotification(&fyne.Notification{
-				Title:   "Шифрование завершено",
-				Content: fmt.Sprintf("Зашифровано %d файлов в %s", result.FilesEncrypted, filepath.Base(result.OutputPath)),
+				Title:   sg.ui.T("gui.encrypt.done"),
+				Content: sg.ui.T("gui.encrypt.notification", result.FilesEncrypted, filepath.Base(result.OutputPath)),
 			})
 
 			// Clear selection if files were deleted
@@ -1970,6 +2532,28 @@ func (sg *ScannerGUI) Run() {
 }
 
 func main() {
+	// The terminal messages come before the settings are read
+	l := locale.New(locale.FromEnv())
+	args, err := parseGUIArgs(l, os.Args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "❌", err)
+		fmt.Fprintln(os.Stderr, l.T("gui.usage"))
+		os.Exit(2)
+	}
+
+	if args.registerAssociation {
+		msg, err := registerFileAssociation(l)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "❌", err)
+			os.Exit(1)
+		}
+		fmt.Println("✅", msg)
+		return
+	}
+
 	gui := NewScannerGUI()
+	if args.reportPath != "" {
+		gui.openReport(args.reportPath)
+	}
 	gui.Run()
 }
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/kacebover/password-finder/gui/controller"
+)
+
+// hasSelection reports whether any file is selected for encryption
+func (sg *ScannerGUI) hasSelection() bool {
+	return sg.results.HasSelection()
+}
+
+// confirmNewScan runs start, asking first when a new scan would drop the
+// files selected for encryption
+func (sg *ScannerGUI) confirmNewScan(start func()) {
+	if !sg.hasSelection() {
+		start()
+		return
+	}
+	dialog.ShowConfirm(sg.ui.T("gui.new_scan.title"), sg.ui.T("gui.new_scan.confirm"),
+		func(confirm bool) {
+			if confirm {
+				start()
+			}
+		}, sg.window)
+}
+
+// releaseResults archives the shown result to the history and drops the
+// results from memory. Archiving runs in the background; the result is
+// released once it is written
+func (sg *ScannerGUI) releaseResults() {
+	result := sg.resultData
+	sg.resultData = nil
+
+	sg.results.Clear()
+	sg.selectedFile = nil
+	sg.detailFindings = nil
+
+	if result == nil || sg.history == nil {
+		return
+	}
+	go func() {
+		if _, err := sg.history.Archive(result); err != nil {
+			fyne.Do(func() {
+				sg.showUserError(fmt.Errorf("%s: %w", sg.ui.T("gui.history.archive_error"), err))
+			})
+		}
+	}()
+}
+
+// buildMemorySection creates the "Память" line of the diagnostics dialog
+// with the button that frees memory
+func (sg *ScannerGUI) buildMemorySection() fyne.CanvasObject {
+	label := widget.NewLabel(controller.FormatMemoryUsage(sg.ui, controller.ReadMemoryStats(sg.resultData)))
+	label.Wrapping = fyne.TextWrapWord
+
+	refresh := func() {
+		label.SetText(controller.FormatMemoryUsage(sg.ui, controller.ReadMemoryStats(sg.resultData)))
+	}
+	freeButton := widget.NewButton(sg.ui.T("gui.memory.free"), func() {
+		if sg.resultData == nil || sg.scanning.Load() {
+			controller.ReleaseCaches()
+			refresh()
+			return
+		}
+		message := sg.ui.T("gui.memory.confirm", sg.history.Dir())
+		dialog.ShowConfirm(sg.ui.T("gui.memory.title"), message, func(confirm bool) {
+			if !confirm {
+				return
+			}
+			sg.releaseResults()
+			sg.showReleasedResults()
+			controller.ReleaseCaches()
+			refresh()
+		}, sg.window)
+	})
+
+	return container.NewBorder(nil, nil, nil, freeButton, label)
+}
+
+// showReleasedResults resets the results view after releaseResults
+func (sg *ScannerGUI) showReleasedResults() {
+	sg.filesList.UnselectAll()
+	sg.filesList.Refresh()
+	sg.clearDetailsPanel()
+	sg.summaryBar.Hide()
+	sg.coverageBanner.Hide()
+	sg.updateErrorsTab()
+	sg.exportButton.Disable()
+	sg.updateSelectedCount()
+	sg.statusLabel.SetText(sg.ui.T("gui.memory.freed"))
+	sg.window.SetTitle(sg.ui.T("gui.title"))
+}
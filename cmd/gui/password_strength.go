@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+	"github.com/kacebover/password-finder/encryptor"
+	"github.com/kacebover/password-finder/locale"
+)
+
+// strengthColors are the colours of the PasswordStrength scores; their
+// titles are the password.strength.N messages
+var strengthColors = []color.NRGBA{
+	{R: 220, G: 53, B: 69, A: 255},
+	{R: 253, G: 126, B: 20, A: 255},
+	{R: 255, G: 193, B: 7, A: 255},
+	{R: 140, G: 190, B: 60, A: 255},
+	{R: 40, G: 167, B: 69, A: 255},
+}
+
+// strengthBarEmpty is the colour of the segments above the score
+var strengthBarEmpty = color.NRGBA{R: 90, G: 90, B: 95, A: 255}
+
+// strengthMeter is a bar of four segments under the password entry, filled
+// up to the score, with the title of the score and the first suggestion
+type strengthMeter struct {
+	ui       *locale.Localizer
+	segments []*canvas.Rectangle
+	label    *widget.Label
+	box      fyne.CanvasObject
+}
+
+func newStrengthMeter(l *locale.Localizer) *strengthMeter {
+	m := &strengthMeter{ui: l, label: widget.NewLabel("")}
+	m.label.Wrapping = fyne.TextWrapWord
+	bar := container.NewGridWithColumns(4)
+	for i := 0; i < 4; i++ {
+		segment := canvas.NewRectangle(strengthBarEmpty)
+		segment.SetMinSize(fyne.NewSize(0, 6))
+		segment.CornerRadius = 3
+		m.segments = append(m.segments, segment)
+		bar.Add(segment)
+	}
+	m.box = container.NewVBox(bar, m.label)
+	m.update("")
+	return m
+}
+
+// update scores the password and redraws the meter
+func (m *strengthMeter) update(password string) {
+	if password == "" {
+		for _, segment := range m.segments {
+			segment.FillColor = strengthBarEmpty
+			segment.Refresh()
+		}
+		m.label.SetText(m.ui.T("gui.password.strength"))
+		return
+	}
+	score, feedback := encryptor.PasswordStrength(password)
+	m.label.SetText(strengthText(m.ui, score, feedback))
+	for i, segment := range m.segments {
+		segment.FillColor = strengthBarEmpty
+		// A score of 0 still lights the first segment red
+		if i < score || i == 0 {
+			segment.FillColor = strengthColors[score]
+		}
+		segment.Refresh()
+	}
+}
+
+// strengthText is the title of the score with the first suggestion
+func strengthText(l *locale.Localizer, score int, feedback []string) string {
+	text := l.T(fmt.Sprintf("password.strength.%d", score))
+	if len(feedback) > 0 {
+		text += ": " + l.PasswordFeedback(feedback[0])
+	}
+	return text
+}
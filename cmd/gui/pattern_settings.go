@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+	"github.com/kacebover/password-finder/searcher"
+)
+
+// patternTypeInfo is what the settings show of a pattern type: the highest
+// severity of its built-in rules and the description of the first one.
+// Types found by detectors rather than rules have neither
+type patternTypeInfo struct {
+	severity    searcher.Severity
+	description string
+}
+
+// patternTypeInfos describes the pattern types by their built-in rules
+func patternTypeInfos() map[searcher.PatternType]patternTypeInfo {
+	infos := make(map[searcher.PatternType]patternTypeInfo)
+	for _, p := range searcher.NewPatterns().List() {
+		info, ok := infos[p.Type]
+		if !ok {
+			info.description = p.Description
+		}
+		if !ok || p.Severity.Score() > info.severity.Score() {
+			info.severity = p.Severity
+		}
+		infos[p.Type] = info
+	}
+	return infos
+}
+
+// patternTogglesEditor builds the list of pattern types with a check to
+// turn each off for scans, its severity and description, under a row
+// checking all, none or the types that can be critical. The returned
+// function collects the unchecked types
+func (sg *ScannerGUI) patternTogglesEditor() (fyne.CanvasObject, func() []string) {
+	disabled := make(map[string]bool, len(sg.settings.DisabledPatterns))
+	for _, name := range sg.settings.DisabledPatterns {
+		disabled[name] = true
+	}
+
+	infos := patternTypeInfos()
+	types := searcher.AllPatternTypes()
+	checks := make([]*widget.Check, len(types))
+	rows := container.NewVBox()
+	for i, t := range types {
+		info := infos[t]
+		checks[i] = widget.NewCheck(sg.patternName(t), nil)
+		checks[i].SetChecked(!disabled[string(t)])
+
+		badge := widget.NewLabel("—")
+		if info.severity != "" {
+			badge.SetText(severityIcon(info.severity) + " " + sg.severityName(info.severity))
+		}
+		description := info.description
+		if translated, ok := sg.ui.Description(description); ok {
+			description = translated
+		}
+		descLabel := widget.NewLabel(description)
+		descLabel.Truncation = fyne.TextTruncateEllipsis
+
+		rows.Add(container.NewBorder(nil, nil, container.NewHBox(checks[i], badge), nil, descLabel))
+	}
+
+	setChecked := func(checked func(searcher.PatternType) bool) {
+		for i, t := range types {
+			checks[i].SetChecked(checked(t))
+		}
+	}
+	allBtn := widget.NewButton(sg.ui.T("gui.settings.patterns_all"), func() {
+		setChecked(func(searcher.PatternType) bool { return true })
+	})
+	noneBtn := widget.NewButton(sg.ui.T("gui.settings.patterns_none"), func() {
+		setChecked(func(searcher.PatternType) bool { return false })
+	})
+	criticalBtn := widget.NewButton(sg.ui.T("gui.settings.patterns_critical"), func() {
+		setChecked(func(t searcher.PatternType) bool { return infos[t].severity == searcher.Critical })
+	})
+
+	scroll := container.NewVScroll(rows)
+	scroll.SetMinSize(fyne.NewSize(0, 220))
+	editor := container.NewBorder(container.NewHBox(allBtn, noneBtn, criticalBtn), nil, nil, nil, scroll)
+
+	return editor, func() []string {
+		var names []string
+		for i, t := range types {
+			if !checks[i].Checked {
+				names = append(names, string(t))
+			}
+		}
+		return names
+	}
+}
+
+// disablePatterns turns off the pattern types unchecked in the settings
+func (sg *ScannerGUI) disablePatterns(scanner *searcher.Scanner) {
+	for _, name := range sg.settings.DisabledPatterns {
+		// Saved by the settings editor, which only offers known types
+		if t, err := searcher.ParsePatternType(name); err == nil {
+			scanner.SetPatternEnabled(t, false)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/kacebover/password-finder/locale"
+)
+
+// Settings, the last directories and the ignore list survive restarts in
+// a JSON file under the user config directory
+
+const (
+	stateDirName  = "data-leak-locator"
+	stateFileName = "settings.json"
+)
+
+// guiState is the content of the settings file
+type guiState struct {
+	Settings    *Settings `json:"settings"`
+	OutputDir   string    `json:"output_dir,omitempty"`
+	LastScanDir string    `json:"last_scan_dir,omitempty"`
+	Ignored     []string  `json:"ignored_files,omitempty"` // Keys made by ignoreKey
+}
+
+// defaultGUIState is the state of a first start
+func defaultGUIState() *guiState {
+	return &guiState{Settings: defaultSettings()}
+}
+
+// statePath returns the settings file, os.UserConfigDir()/data-leak-locator/settings.json
+func statePath() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, stateDirName, stateFileName), nil
+}
+
+// loadGUIState reads the settings file. A missing file gives the defaults;
+// a corrupted one gives the defaults and an error to warn about, so a bad
+// file never stops the app from starting
+func loadGUIState(path string) (*guiState, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return defaultGUIState(), nil
+	}
+	if err != nil {
+		return defaultGUIState(), err
+	}
+
+	state := defaultGUIState()
+	if err := json.Unmarshal(data, state); err != nil {
+		return defaultGUIState(), fmt.Errorf("%s: %w", path, err)
+	}
+	if state.Settings == nil {
+		state.Settings = defaultSettings()
+	}
+	if state.Settings.MaxFileSize <= 0 || state.Settings.Concurrency <= 0 {
+		defaults := defaultSettings()
+		state.Settings.MaxFileSize = defaults.MaxFileSize
+		state.Settings.Concurrency = defaults.Concurrency
+	}
+	return state, nil
+}
+
+// saveGUIState writes the settings file through a temporary file, so a
+// crash while saving keeps the previous settings
+func saveGUIState(path string, state *guiState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".settings-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// ignoreKey identifies an ignored file. Files inside the scan root are
+// stored as "<root name>/<relative path>", so the ignore list still applies
+// after the project is moved; other files keep their absolute path
+func ignoreKey(root, path string) string {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		absPath = path
+	}
+	if root == "" {
+		return absPath
+	}
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return absPath
+	}
+	rel, err := filepath.Rel(absRoot, absPath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return absPath
+	}
+	return filepath.Base(absRoot) + "/" + filepath.ToSlash(rel)
+}
+
+// isIgnored reports whether the user ignored the file
+func (sg *ScannerGUI) isIgnored(path string) bool {
+	return sg.ignoreList[ignoreKey(sg.ignoreRoot, path)]
+}
+
+// ignoreFile adds a file to the ignore list and saves it
+func (sg *ScannerGUI) ignoreFile(path string) {
+	sg.ignoreMutex.Lock()
+	sg.ignoreList[ignoreKey(sg.ignoreRoot, path)] = true
+	sg.ignoreMutex.Unlock()
+	sg.results.Invalidate()
+	sg.saveState()
+}
+
+// loadState applies the saved settings; called before the UI is built
+func (sg *ScannerGUI) loadState() *guiState {
+	// The language of the settings is not known yet
+	l := locale.New(locale.FromEnv())
+	path, err := statePath()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, l.T("gui.state.no_path", err))
+		return defaultGUIState()
+	}
+	sg.statePath = path
+
+	state, err := loadGUIState(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, l.T("gui.state.defaults", err))
+	}
+	sg.settings = state.Settings
+	for _, key := range state.Ignored {
+		sg.ignoreList[key] = true
+	}
+	return state
+}
+
+// saveState writes the settings, directories and ignore list
+func (sg *ScannerGUI) saveState() {
+	if sg.statePath == "" {
+		return
+	}
+	state := &guiState{Settings: sg.settings}
+	if sg.outputDir != nil {
+		state.OutputDir = sg.outputDir.Text
+	}
+	if sg.scanDir != nil {
+		state.LastScanDir = sg.scanDir.Text
+	}
+	sg.ignoreMutex.Lock()
+	for key := range sg.ignoreList {
+		state.Ignored = append(state.Ignored, key)
+	}
+	sg.ignoreMutex.Unlock()
+	sort.Strings(state.Ignored)
+
+	if err := saveGUIState(sg.statePath, state); err != nil && sg.statusLabel != nil {
+		sg.statusLabel.SetText(sg.ui.T("gui.state.save_error", err))
+	}
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/kacebover/password-finder/searcher"
+)
+
+// showRulesBench opens the "Проверка правил" dialog where sample text can be
+// run through built-in and custom rules with a full scoring trace
+func (sg *ScannerGUI) showRulesBench() {
+	builtin := searcher.NewPatterns().List()
+	var custom []*searcher.Pattern
+
+	sampleEntry := widget.NewMultiLineEntry()
+	sampleEntry.SetPlaceHolder(sg.ui.T("gui.rules.sample_hint"))
+	sampleEntry.SetMinRowsVisible(6)
+
+	rulesCheck := widget.NewCheckGroup(nil, nil)
+	refreshRules := func() {
+		var names []string
+		for _, p := range builtin {
+			names = append(names, p.Name)
+		}
+		for _, p := range custom {
+			names = append(names, "★ "+p.Name)
+		}
+		selected := rulesCheck.Selected
+		rulesCheck.Options = names
+		if len(selected) == 0 {
+			selected = names
+		}
+		rulesCheck.SetSelected(selected)
+		rulesCheck.Refresh()
+	}
+	refreshRules()
+
+	resultText := widget.NewRichText()
+	resultText.Wrapping = fyne.TextWrapWord
+
+	openSampleBtn := widget.NewButton(sg.ui.T("gui.rules.open_file"), func() {
+		dialog.ShowFileOpen(func(reader fyne.URIReadCloser, err error) {
+			if err != nil || reader == nil {
+				return
+			}
+			defer reader.Close()
+			data, err := io.ReadAll(reader)
+			if err != nil {
+				dialog.ShowError(err, sg.window)
+				return
+			}
+			sampleEntry.SetText(string(data))
+		}, sg.window)
+	})
+
+	loadRulesBtn := widget.NewButton(sg.ui.T("gui.rules.load"), func() {
+		dialog.ShowFileOpen(func(reader fyne.URIReadCloser, err error) {
+			if err != nil || reader == nil {
+				return
+			}
+			path := reader.URI().Path()
+			reader.Close()
+
+			rules, err := searcher.LoadRuleFile(path)
+			if err != nil {
+				dialog.ShowError(err, sg.window)
+				return
+			}
+			custom = append(custom, rules...)
+			for _, p := range rules {
+				rulesCheck.Selected = append(rulesCheck.Selected, "★ "+p.Name)
+			}
+			refreshRules()
+			sg.statusLabel.SetText(sg.ui.T("gui.rules.loaded", len(rules), filepath.Base(path)))
+		}, sg.window)
+	})
+
+	selectAllBtn := widget.NewButton(sg.ui.T("gui.settings.patterns_all"), func() {
+		rulesCheck.SetSelected(rulesCheck.Options)
+	})
+	selectNoneBtn := widget.NewButton(sg.ui.T("gui.settings.patterns_none"), func() {
+		rulesCheck.SetSelected(nil)
+	})
+
+	runBtn := widget.NewButton(sg.ui.T("gui.rules.run"), func() {
+		selected := make(map[string]bool)
+		for _, name := range rulesCheck.Selected {
+			selected[name] = true
+		}
+
+		var list []*searcher.Pattern
+		for _, p := range builtin {
+			if selected[p.Name] {
+				list = append(list, p)
+			}
+		}
+		for _, p := range custom {
+			if selected["★ "+p.Name] {
+				list = append(list, p)
+			}
+		}
+		if len(list) == 0 {
+			dialog.ShowError(errors.New(sg.ui.T("gui.rules.no_rules")), sg.window)
+			return
+		}
+
+		scanner := searcher.NewScanner()
+		scanner.SetPatterns(searcher.NewPatternsFrom(list))
+
+		var traces []*searcher.EvaluationTrace
+		var lineNumbers []int
+		for i, line := range strings.Split(sampleEntry.Text, "\n") {
+			trace := scanner.Evaluate(line, "")
+			if len(trace.Matches) > 0 {
+				traces = append(traces, trace)
+				lineNumbers = append(lineNumbers, i+1)
+			}
+		}
+
+		resultText.Segments = sg.buildTraceSegments(traces, lineNumbers)
+		resultText.Refresh()
+	})
+	runBtn.Importance = widget.HighImportance
+
+	rulesScroll := container.NewVScroll(rulesCheck)
+	rulesScroll.SetMinSize(fyne.NewSize(220, 300))
+	rulesPanel := container.NewBorder(
+		widget.NewLabelWithStyle(sg.ui.T("gui.rules.rules"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		container.NewVBox(container.NewGridWithColumns(2, selectAllBtn, selectNoneBtn), loadRulesBtn),
+		nil, nil,
+		rulesScroll,
+	)
+
+	resultScroll := container.NewVScroll(resultText)
+	resultScroll.SetMinSize(fyne.NewSize(560, 280))
+
+	samplePanel := container.NewBorder(
+		container.NewBorder(nil, nil,
+			widget.NewLabelWithStyle(sg.ui.T("gui.rules.sample"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			container.NewHBox(openSampleBtn, runBtn)),
+		nil, nil, nil,
+		container.NewVSplit(sampleEntry, resultScroll),
+	)
+
+	content := container.NewBorder(nil, nil, rulesPanel, nil, samplePanel)
+
+	d := dialog.NewCustom(sg.ui.T("gui.rules_bench"), sg.ui.T("gui.close"), content, sg.window)
+	d.Resize(fyne.NewSize(950, 650))
+	d.Show()
+}
+
+// buildTraceSegments renders evaluation traces with the matched spans highlighted
+func (sg *ScannerGUI) buildTraceSegments(traces []*searcher.EvaluationTrace, lineNumbers []int) []widget.RichTextSegment {
+	if len(traces) == 0 {
+		return []widget.RichTextSegment{
+			&widget.TextSegment{Text: sg.ui.T("trace.no_matches"), Style: widget.RichTextStyleParagraph},
+		}
+	}
+
+	var segments []widget.RichTextSegment
+	highlight := widget.RichTextStyle{
+		Inline:    true,
+		ColorName: theme.ColorNameError,
+		TextStyle: fyne.TextStyle{Bold: true, Monospace: true},
+	}
+
+	for i, trace := range traces {
+		segments = append(segments, &widget.TextSegment{
+			Text:  sg.ui.T("gui.rules.line", lineNumbers[i]),
+			Style: widget.RichTextStyleSubHeading,
+		})
+
+		for n, match := range trace.Matches {
+			line := trace.Line
+			segments = append(segments,
+				&widget.TextSegment{Text: line[:match.StartIndex], Style: widget.RichTextStyleCodeInline},
+				&widget.TextSegment{Text: line[match.StartIndex:match.EndIndex], Style: highlight},
+				&widget.TextSegment{Text: line[match.EndIndex:], Style: widget.RichTextStyleCodeInline},
+				&widget.TextSegment{Text: "", Style: widget.RichTextStyleParagraph},
+			)
+
+			var details strings.Builder
+			details.WriteString(fmt.Sprintf("[%d] %s (%s) — %s\n", n+1, match.RuleName, sg.patternName(match.Type), searcher.LocalizeDescription(sg.ui, match.Description)))
+			details.WriteString(sg.ui.T("trace.entropy_value", match.Entropy) + "\n")
+			for _, step := range match.Steps {
+				if step.Stage == searcher.StageMatch {
+					continue
+				}
+				details.WriteString(fmt.Sprintf("  %-9s %+6.1f  %s\n", step.Stage, step.Points, searcher.LocalizeTraceStep(sg.ui, step)))
+			}
+			details.WriteString(sg.ui.T("gui.rules.result",
+				match.RiskScore, sg.severityName(match.BaseSeverity), sg.severityName(match.FinalSeverity)))
+
+			segments = append(segments, &widget.TextSegment{Text: details.String(), Style: widget.RichTextStyleCodeBlock})
+		}
+	}
+
+	return segments
+}
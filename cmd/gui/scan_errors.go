@@ -0,0 +1,92 @@
+package main
+
+import (
+	"path/filepath"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+	"github.com/kacebover/password-finder/locale"
+	"github.com/kacebover/password-finder/searcher"
+)
+
+// buildResultTabs puts the files list and the errors of the scan on tabs
+func (sg *ScannerGUI) buildResultTabs() fyne.CanvasObject {
+	sg.errorsList = widget.NewList(
+		func() int {
+			return len(sg.errorsData)
+		},
+		func() fyne.CanvasObject {
+			path := widget.NewLabel("path")
+			path.TextStyle.Bold = true
+			path.Truncation = fyne.TextTruncateEllipsis
+			cause := widget.NewLabel("cause")
+			cause.Truncation = fyne.TextTruncateEllipsis
+			return container.NewVBox(path, cause)
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			if id >= len(sg.errorsData) {
+				return
+			}
+			e := sg.errorsData[id]
+			labels := obj.(*fyne.Container).Objects
+			labels[0].(*widget.Label).SetText(e.FilePath)
+			labels[1].(*widget.Label).SetText(scanErrorLine(sg.ui, e))
+		},
+	)
+	sg.errorsList.OnSelected = func(id widget.ListItemID) {
+		if id < len(sg.errorsData) {
+			sg.window.Clipboard().SetContent(sg.errorsData[id].FilePath)
+			sg.statusLabel.SetText(sg.ui.T("gui.errors.copied", filepath.Base(sg.errorsData[id].FilePath)))
+		}
+		sg.errorsList.UnselectAll()
+	}
+
+	sg.errorsTab = container.NewTabItem(errorsTabTitle(sg.ui, 0), sg.errorsList)
+	sg.resultTabs = container.NewAppTabs(
+		container.NewTabItem(sg.ui.T("gui.files_tab"), sg.filesList),
+		sg.errorsTab,
+	)
+	return sg.resultTabs
+}
+
+// updateErrorsTab shows the errors of the current results
+func (sg *ScannerGUI) updateErrorsTab() {
+	sg.errorsData = nil
+	if sg.resultData != nil {
+		sg.errorsData = sg.resultData.GetErrors()
+		if dropped := sg.resultData.ErrorsDropped; dropped > 0 {
+			sg.errorsData = append(sg.errorsData, searcher.ScanError{
+				FilePath: sg.ui.T("gui.errors.more", dropped),
+				Err:      sg.ui.T("gui.errors.dropped"),
+			})
+		}
+	}
+	count := 0
+	if sg.resultData != nil {
+		count = sg.resultData.ErrorCount
+	}
+	sg.errorsTab.Text = errorsTabTitle(sg.ui, count)
+	sg.resultTabs.Refresh()
+	sg.errorsList.Refresh()
+}
+
+// errorsTabTitle names the errors tab with the number of errors
+func errorsTabTitle(l *locale.Localizer, count int) string {
+	if count == 0 {
+		return l.T("gui.errors.tab")
+	}
+	return l.T("gui.errors.tab_count", count)
+}
+
+// scanErrorLine describes an error under its path
+func scanErrorLine(l *locale.Localizer, e searcher.ScanError) string {
+	if e.Stage == "" {
+		return e.Err
+	}
+	line := searcher.LocalizeErrorStage(l, e.Stage) + ": " + e.Err
+	if !e.Time.IsZero() {
+		line = e.Time.Format("15:04:05") + "  " + line
+	}
+	return line
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/widget"
+	"github.com/kacebover/password-finder/locale"
+	"github.com/kacebover/password-finder/searcher"
+)
+
+// scoreBreakdown lists how much each factor added to the finding's risk score
+func scoreBreakdown(l *locale.Localizer, f *searcher.Finding) string {
+	score, steps := searcher.NewRiskScorer().ExplainFindingScore(f)
+	return searcher.FormatScoreSteps(l, score, steps)
+}
+
+// newScoreExplanation creates the collapsed "Почему такой балл?" section of a finding
+func newScoreExplanation(l *locale.Localizer, f *searcher.Finding) fyne.CanvasObject {
+	breakdown := widget.NewLabelWithStyle(scoreBreakdown(l, f), fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+	return widget.NewAccordion(widget.NewAccordionItem(l.T("gui.score.why"), breakdown))
+}
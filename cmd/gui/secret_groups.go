@@ -0,0 +1,68 @@
+package main
+
+import (
+	"path/filepath"
+	"sort"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+	"github.com/kacebover/password-finder/gui/controller"
+	"github.com/kacebover/password-finder/searcher"
+)
+
+// otherFilesShown is how many other locations of a secret get a button
+const otherFilesShown = 5
+
+// otherSecretFiles returns the files besides current that contain the
+// secret of f, sorted by path
+func otherSecretFiles(results *controller.ResultsModel, f *searcher.Finding, current string) []string {
+	fingerprint := f.SecretFingerprint()
+	if fingerprint == "" {
+		return nil
+	}
+	others := results.FilesWith(func(other *searcher.Finding) bool {
+		return other.FilePath != current && other.SecretFingerprint() == fingerprint
+	})
+	sort.Strings(others)
+	return others
+}
+
+// newAlsoFoundIn shows where else the secret of f was found, with buttons
+// that open those files; nil when the secret is only in this file
+func (sg *ScannerGUI) newAlsoFoundIn(f *searcher.Finding, current string) fyne.CanvasObject {
+	others := otherSecretFiles(sg.results, f, current)
+	if len(others) == 0 {
+		return nil
+	}
+
+	objects := []fyne.CanvasObject{
+		widget.NewLabel("   " + sg.ui.T("gui.secret.also_found", len(others), sg.ui.Plural(len(others), "gui.secret.in_files"))),
+	}
+	for i, path := range others {
+		if i == otherFilesShown {
+			objects = append(objects, widget.NewLabel("      "+sg.ui.T("gui.secret.more", len(others)-i)))
+			break
+		}
+		path := path
+		button := widget.NewButton("→ "+truncatePath(path, 60), func() {
+			sg.selectFilePath(path)
+		})
+		button.Importance = widget.LowImportance
+		button.Alignment = widget.ButtonAlignLeading
+		objects = append(objects, container.NewHBox(widget.NewLabel("     "), button))
+	}
+	return container.NewVBox(objects...)
+}
+
+// selectFilePath selects a file in the results list, if the filters show it
+func (sg *ScannerGUI) selectFilePath(path string) {
+	for id, file := range sg.results.GetFilteredFiles() {
+		if file.FilePath == path {
+			sg.filesList.ScrollTo(id)
+			sg.filesList.Select(id)
+			return
+		}
+	}
+	sg.statusLabel.SetText(sg.ui.T("gui.secret.hidden", filepath.Base(path)))
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"image/color"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/theme"
+	"github.com/kacebover/password-finder/searcher"
+)
+
+// Themes of the settings; ThemeSystem follows the variant of the system
+const (
+	ThemeSystem = ""
+	ThemeLight  = "light"
+	ThemeDark   = "dark"
+)
+
+// Colors of the palette beyond the ones of Fyne, looked up with theme.Color
+const (
+	colorNameCritical         fyne.ThemeColorName = "dllCritical"
+	colorNameHigh             fyne.ThemeColorName = "dllHigh"
+	colorNameMedium           fyne.ThemeColorName = "dllMedium"
+	colorNameLow              fyne.ThemeColorName = "dllLow"
+	colorNamePreview          fyne.ThemeColorName = "dllPreview"          // Background of the context preview
+	colorNamePreviewText      fyne.ThemeColorName = "dllPreviewText"      // Lines around the finding
+	colorNamePreviewMatch     fyne.ThemeColorName = "dllPreviewMatch"     // The line of the finding
+	colorNamePreviewHighlight fyne.ThemeColorName = "dllPreviewHighlight" // Behind the line of the finding
+)
+
+// themeColors is a color of the palette on the light and the dark theme
+type themeColors struct {
+	light, dark color.NRGBA
+}
+
+// palette holds the colors of the app. The light variants are darker, so
+// the severity marks keep a 3:1 contrast with a white window
+var palette = map[fyne.ThemeColorName]themeColors{
+	colorNameCritical: {light: color.NRGBA{R: 198, G: 40, B: 40, A: 255}, dark: color.NRGBA{R: 239, G: 83, B: 80, A: 255}},
+	colorNameHigh:     {light: color.NRGBA{R: 199, G: 84, B: 0, A: 255}, dark: color.NRGBA{R: 255, G: 152, B: 0, A: 255}},
+	colorNameMedium:   {light: color.NRGBA{R: 158, G: 105, B: 0, A: 255}, dark: color.NRGBA{R: 255, G: 202, B: 40, A: 255}},
+	colorNameLow:      {light: color.NRGBA{R: 46, G: 125, B: 50, A: 255}, dark: color.NRGBA{R: 102, G: 187, B: 106, A: 255}},
+
+	colorNamePreview:          {light: color.NRGBA{R: 243, G: 243, B: 243, A: 255}, dark: color.NRGBA{R: 40, G: 40, B: 45, A: 255}},
+	colorNamePreviewText:      {light: color.NRGBA{R: 95, G: 95, B: 100, A: 255}, dark: color.NRGBA{R: 160, G: 160, B: 165, A: 255}},
+	colorNamePreviewMatch:     {light: color.NRGBA{R: 20, G: 20, B: 20, A: 255}, dark: color.NRGBA{R: 255, G: 255, B: 255, A: 255}},
+	colorNamePreviewHighlight: {light: color.NRGBA{R: 255, G: 236, B: 179, A: 255}, dark: color.NRGBA{R: 90, G: 80, B: 30, A: 255}},
+}
+
+// of returns the color for a theme variant
+func (c themeColors) of(variant fyne.ThemeVariant) color.NRGBA {
+	if variant == theme.VariantLight {
+		return c.light
+	}
+	return c.dark
+}
+
+// severityColorName is the palette color of a severity level
+func severityColorName(s searcher.Severity) fyne.ThemeColorName {
+	switch s {
+	case searcher.Critical:
+		return colorNameCritical
+	case searcher.High:
+		return colorNameHigh
+	case searcher.Medium:
+		return colorNameMedium
+	}
+	return colorNameLow
+}
+
+// severityColor returns the color of a severity level on a theme variant
+func severityColor(s searcher.Severity, variant fyne.ThemeVariant) color.Color {
+	return palette[severityColorName(s)].of(variant)
+}
+
+// appTheme is the Fyne theme with the palette of the app, following the
+// system variant or fixed to light or dark
+type appTheme struct {
+	fyne.Theme
+	variant fyne.ThemeVariant
+	fixed   bool
+}
+
+// newAppTheme returns the theme of a setting: ThemeLight, ThemeDark or
+// ThemeSystem
+func newAppTheme(setting string) fyne.Theme {
+	t := &appTheme{Theme: theme.DefaultTheme()}
+	switch setting {
+	case ThemeLight:
+		t.variant, t.fixed = theme.VariantLight, true
+	case ThemeDark:
+		t.variant, t.fixed = theme.VariantDark, true
+	}
+	return t
+}
+
+// Color returns the palette colors and the Fyne ones for the rest
+func (t *appTheme) Color(name fyne.ThemeColorName, variant fyne.ThemeVariant) color.Color {
+	if t.fixed {
+		variant = t.variant
+	}
+	if c, ok := palette[name]; ok {
+		return c.of(variant)
+	}
+	return t.Theme.Color(name, variant)
+}
+
+// applyTheme sets the theme of the settings
+func (sg *ScannerGUI) applyTheme() {
+	sg.app.Settings().SetTheme(newAppTheme(sg.settings.Theme))
+}
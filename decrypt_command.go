@@ -0,0 +1,240 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kacebover/password-finder/encryptor"
+	"github.com/kacebover/password-finder/searcher"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════
+// КОМАНДА РАСШИФРОВКИ
+// ═══════════════════════════════════════════════════════════════════════════
+
+// decryptOptions are the settings of the decrypt command
+type decryptOptions struct {
+	Input    string
+	Output   string
+	Password string
+	File     string // Single member to extract, all files when empty
+	Force    bool
+
+	PasswordArg bool // Let 7-Zip take the password on its command line
+}
+
+// restoredFile is a file written by decryptArchive
+type restoredFile struct {
+	Name string // Path in the archive
+	Path string // Path on disk
+	Size int64
+}
+
+func runDecryptCommand(args []string) {
+	decryptCmd := flag.NewFlagSet("decrypt", flag.ExitOnError)
+
+	inputPath := decryptCmd.String("input", "", "Зашифрованный архив ZIP, 7z или age (обязательно)")
+	outputDir := decryptCmd.String("output", "", "Директория для восстановленных файлов (обязательно, кроме -list)")
+	password := decryptCmd.String("password", "", "Пароль архива (будет запрошен, если не указан)")
+	list := decryptCmd.Bool("list", false, "Показать содержимое архива без расшифровки")
+	member := decryptCmd.String("file", "", "Извлечь только этот файл (путь внутри архива)")
+	force := decryptCmd.Bool("force", false, "Перезаписывать существующие файлы")
+	passwordArg := decryptCmd.Bool("7z-password-arg", false, "Разрешить передать пароль 7-Zip в командной строке, где его видят другие пользователи системы")
+
+	decryptCmd.Usage = func() {
+		fmt.Print(ui.T("cli.decrypt.usage", exitWrongPassword))
+	}
+
+	if err := decryptCmd.Parse(args); err != nil {
+		os.Exit(1)
+	}
+
+	if *inputPath == "" {
+		fmt.Println(ui.T("cli.decrypt.no_input"))
+		decryptCmd.Usage()
+		os.Exit(1)
+	}
+
+	if *list {
+		entries, err := encryptor.ListArchive(*inputPath)
+		if errors.Is(err, encryptor.ErrPasswordRequired) {
+			entries, err = listEncryptedArchive(*inputPath, *password, *passwordArg)
+		}
+		if errors.Is(err, encryptor.ErrPasswordOnCommandLine) {
+			fmt.Println(ui.T("cli.decrypt.7zip_password_arg"))
+			os.Exit(exitCode(err))
+		}
+		if err != nil {
+			fmt.Println(ui.T("cli.error", err))
+			os.Exit(exitCode(err))
+		}
+		printArchiveList(os.Stdout, *inputPath, entries)
+		return
+	}
+
+	if *outputDir == "" {
+		fmt.Println(ui.T("cli.decrypt.no_output"))
+		os.Exit(1)
+	}
+
+	pwd := *password
+	if pwd == "" {
+		var err error
+		if pwd, err = promptPassword(ui.T("cli.decrypt.prompt")); err != nil {
+			fmt.Println(ui.T("cli.error", err))
+			os.Exit(1)
+		}
+	}
+
+	restored, err := decryptArchive(decryptOptions{
+		Input:    *inputPath,
+		Output:   *outputDir,
+		Password: pwd,
+		File:     *member,
+		Force:    *force,
+
+		PasswordArg: *passwordArg,
+	})
+	if err != nil {
+		var splitErr *encryptor.SplitError
+		switch {
+		case errors.Is(err, encryptor.ErrWrongPassword):
+			fmt.Println(ui.T("cli.decrypt.wrong_password"))
+		case errors.Is(err, encryptor.ErrPasswordOnCommandLine):
+			fmt.Println(ui.T("cli.decrypt.7zip_password_arg"))
+		case errors.Is(err, encryptor.ErrFileExists):
+			fmt.Println(ui.T("cli.error", err))
+			fmt.Println(ui.T("cli.decrypt.use_force"))
+		case errors.As(err, &splitErr):
+			fmt.Println(ui.T("cli.decrypt.bad_volume", splitErr.Part, splitErr.Err))
+		default:
+			fmt.Println(ui.T("cli.decrypt.error", err))
+		}
+		os.Exit(exitCode(err))
+	}
+
+	printDecryptSummary(os.Stdout, restored)
+}
+
+// listEncryptedArchive lists a 7z or age archive, whose names are
+// encrypted, asking for the password when it is not given; passwordArg
+// lets 7-Zip take it on its command line
+func listEncryptedArchive(input, password string, passwordArg bool) ([]encryptor.ArchiveEntry, error) {
+	if password == "" {
+		var err error
+		if password, err = promptPassword(ui.T("cli.decrypt.prompt")); err != nil {
+			return nil, err
+		}
+	}
+	dec, err := encryptor.NewDecryptor(encryptor.DecryptConfig{
+		Password:            password,
+		SourcePath:          input,
+		SevenZipPath:        sevenZipPath(),
+		SevenZipPasswordArg: passwordArg,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return dec.List()
+}
+
+// sevenZipPath returns the 7-Zip binary found by the dependency check, or
+// an empty path to let the encryptor look it up
+func sevenZipPath() string {
+	return searcher.NewDependencyChecker().Status(searcher.DependencySevenZip).Path
+}
+
+// decryptArchive restores the files of an archive into opts.Output. Existing
+// files are checked before anything is written, so a refused run leaves
+// the output directory untouched
+func decryptArchive(opts decryptOptions) ([]restoredFile, error) {
+	overwrite := encryptor.OverwriteNever
+	if opts.Force {
+		overwrite = encryptor.OverwriteAlways
+	}
+	dec, err := encryptor.NewDecryptor(encryptor.DecryptConfig{
+		Password:            opts.Password,
+		SourcePath:          opts.Input,
+		OutputDir:           opts.Output,
+		Overwrite:           overwrite,
+		SevenZipPath:        sevenZipPath(),
+		SevenZipPasswordArg: opts.PasswordArg,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := dec.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var conflicts []string
+	var files []restoredFile
+	for _, entry := range entries {
+		if opts.File != "" && entry.Name != opts.File {
+			continue
+		}
+		if !filepath.IsLocal(filepath.FromSlash(entry.Name)) {
+			return nil, fmt.Errorf("%w: %s", encryptor.ErrUnsafePath, entry.Name)
+		}
+		path := filepath.Join(opts.Output, filepath.FromSlash(entry.Name))
+		if _, err := os.Stat(path); err == nil && !opts.Force {
+			conflicts = append(conflicts, path)
+		}
+		files = append(files, restoredFile{Name: entry.Name, Path: path, Size: entry.Size})
+	}
+	if opts.File != "" && len(files) == 0 {
+		return nil, fmt.Errorf("%w: %s", encryptor.ErrNotFound, ui.T("cli.decrypt.not_in_archive", opts.File, opts.Input))
+	}
+	if len(conflicts) > 0 {
+		return nil, fmt.Errorf("%w: %s", encryptor.ErrFileExists, strings.Join(conflicts, ", "))
+	}
+
+	if opts.File != "" {
+		err = dec.DecryptFile(opts.File, files[0].Path)
+	} else {
+		err = dec.DecryptAll()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// printArchiveList prints the files of an archive with their sizes
+func printArchiveList(w io.Writer, archivePath string, entries []encryptor.ArchiveEntry) {
+	fmt.Fprintln(w, ui.T("cli.decrypt.archive", archivePath))
+	fmt.Fprintln(w, "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	var total int64
+	for _, entry := range entries {
+		lock := "  "
+		if entry.Encrypted {
+			lock = "🔒"
+		}
+		fmt.Fprintf(w, "%s %10s  %s  %s\n", lock, formatBytes(entry.Size), entry.Modified.Format("2006-01-02 15:04"), entry.Name)
+		total += entry.Size
+	}
+	fmt.Fprintln(w, "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	fmt.Fprintln(w, ui.T("cli.decrypt.list_total", len(entries), formatBytes(total)))
+}
+
+// printDecryptSummary prints the restored files and the bytes written
+func printDecryptSummary(w io.Writer, restored []restoredFile) {
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, ui.T("cli.decrypt.done"))
+	fmt.Fprintln(w, "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	var total int64
+	for _, file := range restored {
+		fmt.Fprintf(w, "   %10s  %s\n", formatBytes(file.Size), file.Path)
+		total += file.Size
+	}
+	fmt.Fprintln(w, "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	fmt.Fprintln(w, ui.T("cli.decrypt.restored", len(restored)))
+	fmt.Fprintln(w, ui.T("cli.decrypt.written", formatBytes(total)))
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kacebover/password-finder/encryptor"
+)
+
+// encryptFixture encrypts a small directory and returns the archive path
+func encryptFixture(t *testing.T, password string) string {
+	t.Helper()
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "project", "src"), 0755)
+	os.WriteFile(filepath.Join(dir, "project", "config.env"), []byte("password=hunter2\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "project", "src", "main.go"), []byte("package main\n"), 0644)
+
+	config := encryptor.DefaultConfig()
+	config.Password = password
+	config.OutputPath = filepath.Join(t.TempDir(), "secrets.zip")
+	enc, err := encryptor.NewEncryptor(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.EncryptFiles([]encryptor.FileEntry{{SourcePath: filepath.Join(dir, "project")}}); err != nil {
+		t.Fatal(err)
+	}
+	return config.OutputPath
+}
+
+func TestDecryptArchive(t *testing.T) {
+	archive := encryptFixture(t, "correct horse")
+	outDir := t.TempDir()
+
+	restored, err := decryptArchive(decryptOptions{Input: archive, Output: outDir, Password: "correct horse"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(restored) != 2 {
+		t.Fatalf("restored %d files, want 2", len(restored))
+	}
+	if got, _ := os.ReadFile(filepath.Join(outDir, "project", "config.env")); string(got) != "password=hunter2\n" {
+		t.Errorf("config.env = %q", got)
+	}
+
+	var out bytes.Buffer
+	printDecryptSummary(&out, restored)
+	if !strings.Contains(out.String(), "Восстановлено файлов: 2") || !strings.Contains(out.String(), "30 Б") {
+		t.Errorf("summary:\n%s", out.String())
+	}
+
+	// Existing files are reported and nothing is written without -force
+	os.WriteFile(filepath.Join(outDir, "project", "config.env"), []byte("edited\n"), 0644)
+	os.Remove(filepath.Join(outDir, "project", "src", "main.go"))
+	_, err = decryptArchive(decryptOptions{Input: archive, Output: outDir, Password: "correct horse"})
+	if !errors.Is(err, encryptor.ErrFileExists) || !strings.Contains(err.Error(), "config.env") {
+		t.Fatalf("error = %v, want ErrFileExists naming config.env", err)
+	}
+	if _, err := os.Stat(filepath.Join(outDir, "project", "src", "main.go")); err == nil {
+		t.Error("a refused run should not write any file")
+	}
+
+	if _, err := decryptArchive(decryptOptions{Input: archive, Output: outDir, Password: "correct horse", Force: true}); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := os.ReadFile(filepath.Join(outDir, "project", "config.env")); string(got) != "password=hunter2\n" {
+		t.Errorf("-force should overwrite config.env, got %q", got)
+	}
+}
+
+func TestDecryptArchiveSingleFile(t *testing.T) {
+	archive := encryptFixture(t, "pw")
+	outDir := t.TempDir()
+
+	restored, err := decryptArchive(decryptOptions{Input: archive, Output: outDir, Password: "pw", File: "project/src/main.go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(restored) != 1 || restored[0].Path != filepath.Join(outDir, "project", "src", "main.go") {
+		t.Fatalf("restored = %+v", restored)
+	}
+	if _, err := os.Stat(filepath.Join(outDir, "project", "config.env")); err == nil {
+		t.Error("only the requested file should be extracted")
+	}
+
+	_, err = decryptArchive(decryptOptions{Input: archive, Output: outDir, Password: "pw", File: "project/missing.txt"})
+	if exitCode(err) != exitNotFound {
+		t.Errorf("missing member: error %v, exit code %d", err, exitCode(err))
+	}
+}
+
+func TestPrintArchiveList(t *testing.T) {
+	archive := encryptFixture(t, "pw")
+	entries, err := encryptor.ListArchive(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	printArchiveList(&out, archive, entries)
+	for _, want := range []string{"🔒", "project/config.env", "project/src/main.go", "Файлов: 2"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("listing missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+// TestDecryptWrongPasswordExitCode runs the command in a child process,
+// because it ends the process, and checks the exit code and message
+func TestDecryptWrongPasswordExitCode(t *testing.T) {
+	if archive := os.Getenv("DATALEAK_TEST_ARCHIVE"); archive != "" {
+		runDecryptCommand([]string{"-input", archive, "-output", os.Getenv("DATALEAK_TEST_OUTPUT_DIR"), "-password", "wrong"})
+		os.Exit(exitOK)
+	}
+
+	outDir := t.TempDir()
+	cmd := exec.Command(os.Args[0], "-test.run=^TestDecryptWrongPasswordExitCode$")
+	cmd.Env = append(os.Environ(),
+		"DATALEAK_TEST_ARCHIVE="+encryptFixture(t, "right"),
+		"DATALEAK_TEST_OUTPUT_DIR="+outDir)
+	out, err := cmd.CombinedOutput()
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != exitWrongPassword {
+		t.Fatalf("exit error = %v, want code %d\n%s", err, exitWrongPassword, out)
+	}
+	if !strings.Contains(string(out), "Неверный пароль архива") {
+		t.Errorf("output should explain the failure:\n%s", out)
+	}
+	if entries, _ := os.ReadDir(outDir); len(entries) != 0 {
+		t.Errorf("nothing should be restored, got %d entries", len(entries))
+	}
+}
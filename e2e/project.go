@@ -0,0 +1,359 @@
+// Package e2e generates reproducible synthetic "leaky" projects and drives
+// the CLI through the scan → report → baseline → encrypt workflow.
+// The workflow tests run with `go test -tags e2e ./e2e`.
+package e2e
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/kacebover/password-finder/searcher"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// ProjectSize is the number of generated items of each kind
+type ProjectSize struct {
+	Files    int // Source and config files; every fifth one is clean
+	Archives int // ZIP archives with a leaked config inside
+	Docs     int // DOCX documents with a leaked credential
+	Images   int // PNG screenshots with a credential rendered as text
+}
+
+// DefaultSize is small enough for CI
+func DefaultSize() ProjectSize {
+	return ProjectSize{Files: 10, Archives: 2, Docs: 2, Images: 1}
+}
+
+// Scaled multiplies every count of the default size, for stress runs
+func Scaled(scale int) ProjectSize {
+	if scale < 1 {
+		scale = 1
+	}
+	size := DefaultSize()
+	return ProjectSize{
+		Files:    size.Files * scale,
+		Archives: size.Archives * scale,
+		Docs:     size.Docs * scale,
+		Images:   size.Images * scale,
+	}
+}
+
+// Container kinds of a planted secret
+const (
+	InPlainFile = ""
+	InArchive   = "archive"
+	InDocument  = "document"
+	InImage     = "image"
+)
+
+// PlantedSecret is a fake secret written into the project
+type PlantedSecret struct {
+	Path      string               // Slash-separated path relative to the project root
+	Line      int                  // 1-based line in a plain file, 0 inside containers
+	Type      searcher.PatternType // Pattern expected to report it
+	Value     string
+	Container string
+}
+
+// Project is a generated project tree
+type Project struct {
+	Root    string
+	Secrets []PlantedSecret
+	Clean   []string // Files without secrets, relative like PlantedSecret.Path
+	rng     *rand.Rand
+	leaks   int
+}
+
+// Generate writes a project of the given size under root. The same seed
+// always produces the same tree
+func Generate(root string, size ProjectSize, seed int64) (*Project, error) {
+	p := &Project{Root: root, rng: rand.New(rand.NewSource(seed))}
+
+	for i := 0; i < size.Files; i++ {
+		if err := p.writeSourceFile(i); err != nil {
+			return nil, err
+		}
+	}
+	for i := 0; i < size.Archives; i++ {
+		if err := p.writeArchive(i); err != nil {
+			return nil, err
+		}
+	}
+	for i := 0; i < size.Docs; i++ {
+		if err := p.writeDocument(i); err != nil {
+			return nil, err
+		}
+	}
+	for i := 0; i < size.Images; i++ {
+		if err := p.writeImage(i); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// SecretsIn returns the planted secrets stored in the given container kind
+func (p *Project) SecretsIn(container string) []PlantedSecret {
+	var secrets []PlantedSecret
+	for _, s := range p.Secrets {
+		if s.Container == container {
+			secrets = append(secrets, s)
+		}
+	}
+	return secrets
+}
+
+// AddLeak writes a new config file with one fresh secret, simulating a leak
+// introduced after a baseline scan
+func (p *Project) AddLeak() (PlantedSecret, error) {
+	p.leaks++
+	rel := fmt.Sprintf("services/new-%d/settings.env", p.leaks)
+	value := p.awsKey()
+	lines := []string{"# added after the baseline", "region = eu-north-1", "aws_access_key_id = " + value}
+	if err := p.writeFile(rel, []byte(strings.Join(lines, "\n")+"\n")); err != nil {
+		return PlantedSecret{}, err
+	}
+	secret := PlantedSecret{Path: rel, Line: 3, Type: searcher.PatternAWSKey, Value: value}
+	p.Secrets = append(p.Secrets, secret)
+	return secret, nil
+}
+
+// Paths returns every generated file, sorted
+func (p *Project) Paths() []string {
+	seen := make(map[string]bool)
+	for _, s := range p.Secrets {
+		seen[s.Path] = true
+	}
+	for _, c := range p.Clean {
+		seen[c] = true
+	}
+	paths := make([]string, 0, len(seen))
+	for path := range seen {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// sourceLayouts are the file kinds of generated plain files
+var sourceLayouts = []struct {
+	dir, ext string
+}{
+	{"config", ".env"},
+	{"deploy", ".yaml"},
+	{"notes", ".txt"},
+	{"settings", ".json"},
+	{"docs", ".md"},
+}
+
+// fillerLines never match any built-in pattern
+var fillerLines = []string{
+	"# service settings",
+	"region = eu-north-1",
+	"replicas = 3",
+	"timeout_seconds = 30",
+	"feature_flags = search,export",
+	"owner = platform team",
+}
+
+// writeSourceFile writes a plain file; every fifth file stays clean
+func (p *Project) writeSourceFile(i int) error {
+	layout := sourceLayouts[i%len(sourceLayouts)]
+	rel := fmt.Sprintf("%s/module-%03d%s", layout.dir, i, layout.ext)
+
+	var lines []string
+	for n := 0; n < 3+p.rng.Intn(4); n++ {
+		lines = append(lines, fillerLines[p.rng.Intn(len(fillerLines))])
+	}
+
+	if i%5 == 4 {
+		p.Clean = append(p.Clean, rel)
+		return p.writeFile(rel, []byte(strings.Join(lines, "\n")+"\n"))
+	}
+
+	count := 1 + p.rng.Intn(3)
+	for n := 0; n < count; n++ {
+		line, secretType, value := p.secretLine()
+		at := p.rng.Intn(len(lines) + 1)
+		lines = append(lines[:at], append([]string{line}, lines[at:]...)...)
+		// Secrets inserted earlier shift down
+		for j := range p.Secrets {
+			s := &p.Secrets[j]
+			if s.Path == rel && s.Line >= at+1 {
+				s.Line++
+			}
+		}
+		p.Secrets = append(p.Secrets, PlantedSecret{Path: rel, Line: at + 1, Type: secretType, Value: value})
+	}
+	return p.writeFile(rel, []byte(strings.Join(lines, "\n")+"\n"))
+}
+
+// writeArchive writes a ZIP with a leaked .env inside
+func (p *Project) writeArchive(i int) error {
+	rel := fmt.Sprintf("backups/backup-%02d.zip", i)
+	line, secretType, value := p.secretLine()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create("app/.env")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "%s\n%s\n", fillerLines[1], line)
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	p.Secrets = append(p.Secrets, PlantedSecret{Path: rel, Type: secretType, Value: value, Container: InArchive})
+	return p.writeFile(rel, buf.Bytes())
+}
+
+// writeDocument writes a minimal DOCX with a leaked credential
+func (p *Project) writeDocument(i int) error {
+	rel := fmt.Sprintf("office/handover-%02d.docx", i)
+	line, secretType, value := p.secretLine()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	parts := map[string]string{
+		"[Content_Types].xml": `<?xml version="1.0" encoding="UTF-8"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/>
+</Types>`,
+		"word/document.xml": fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>
+<w:p><w:r><w:t>Передача дел</w:t></w:r></w:p>
+<w:p><w:r><w:t>%s</w:t></w:r></w:p>
+</w:body></w:document>`, line),
+	}
+	for _, name := range []string{"[Content_Types].xml", "word/document.xml"} {
+		w, err := zw.Create(name)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write([]byte(parts[name])); err != nil {
+			return err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	p.Secrets = append(p.Secrets, PlantedSecret{Path: rel, Type: secretType, Value: value, Container: InDocument})
+	return p.writeFile(rel, buf.Bytes())
+}
+
+// writeImage renders a password assignment into a PNG screenshot,
+// three times the bitmap font size so OCR can read it
+func (p *Project) writeImage(i int) error {
+	rel := fmt.Sprintf("screenshots/console-%02d.png", i)
+	value := p.word(12)
+	text := "password = " + value
+
+	const scale = 3
+	small := image.NewGray(image.Rect(0, 0, 10+len(text)*7, 24))
+	draw.Draw(small, small.Bounds(), image.White, image.Point{}, draw.Src)
+	drawer := font.Drawer{
+		Dst:  small,
+		Src:  image.NewUniform(color.Black),
+		Face: basicfont.Face7x13,
+		Dot:  fixed.P(5, 17),
+	}
+	drawer.DrawString(text)
+
+	bounds := small.Bounds()
+	img := image.NewGray(image.Rect(0, 0, bounds.Dx()*scale, bounds.Dy()*scale))
+	for y := 0; y < img.Bounds().Dy(); y++ {
+		for x := 0; x < img.Bounds().Dx(); x++ {
+			img.SetGray(x, y, small.GrayAt(x/scale, y/scale))
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return err
+	}
+	p.Secrets = append(p.Secrets, PlantedSecret{Path: rel, Type: searcher.PatternPassword, Value: value, Container: InImage})
+	return p.writeFile(rel, buf.Bytes())
+}
+
+// secretLine returns a config line holding a random fake secret
+func (p *Project) secretLine() (string, searcher.PatternType, string) {
+	switch p.rng.Intn(5) {
+	case 0:
+		value := p.word(16)
+		return fmt.Sprintf(`DB_PASSWORD = "%s"`, value), searcher.PatternPassword, value
+	case 1:
+		value := p.awsKey()
+		return "aws_access_key_id = " + value, searcher.PatternAWSKey, value
+	case 2:
+		value := "ghp_" + p.word(36)
+		return "GITHUB_TOKEN=" + value, searcher.PatternGitHubToken, value
+	case 3:
+		value := fmt.Sprintf("%s.%s@%s.ru", p.lower(5), p.lower(7), p.lower(8))
+		return "Контакт: " + value, searcher.PatternEmail, value
+	default:
+		value := p.cardNumber()
+		return "Карта: " + value, searcher.PatternCreditCard, value
+	}
+}
+
+const (
+	alnum   = "abcdefghijkmnopqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	letters = "abcdefghijklmnopqrstuvwxyz"
+	base32  = "ABCDEFGHIJKLMNOPQRSTUVWXYZ234567"
+)
+
+func (p *Project) pick(alphabet string, n int) string {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = alphabet[p.rng.Intn(len(alphabet))]
+	}
+	return string(b)
+}
+
+func (p *Project) word(n int) string  { return p.pick(alnum, n) }
+func (p *Project) lower(n int) string { return p.pick(letters, n) }
+func (p *Project) awsKey() string     { return "AKIA" + p.pick(base32, 16) }
+
+// cardNumber returns a random Visa number with a valid Luhn check digit
+func (p *Project) cardNumber() string {
+	digits := []byte("4")
+	for len(digits) < 15 {
+		digits = append(digits, byte('0'+p.rng.Intn(10)))
+	}
+	sum := 0
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		// Digits at odd distance from the check digit are doubled
+		if (len(digits)-1-i)%2 == 0 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return string(append(digits, byte('0'+(10-sum%10)%10)))
+}
+
+func (p *Project) writeFile(rel string, data []byte) error {
+	path := filepath.Join(p.Root, filepath.FromSlash(rel))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,103 @@
+package e2e
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kacebover/password-finder/searcher"
+)
+
+// readTree returns the contents of every file under root keyed by relative path
+func readTree(t *testing.T, root string) map[string]string {
+	t.Helper()
+	files := make(map[string]string)
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(root, path)
+		files[filepath.ToSlash(rel)] = string(data)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+// TestGenerateReproducible tests that a seed always produces the same tree
+func TestGenerateReproducible(t *testing.T) {
+	first, err := Generate(t.TempDir(), DefaultSize(), 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := Generate(t.TempDir(), DefaultSize(), 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(first.Secrets, second.Secrets) {
+		t.Error("planted secrets differ for the same seed")
+	}
+	if !reflect.DeepEqual(readTree(t, first.Root), readTree(t, second.Root)) {
+		t.Error("generated files differ for the same seed")
+	}
+
+	other, err := Generate(t.TempDir(), DefaultSize(), 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reflect.DeepEqual(first.Secrets, other.Secrets) {
+		t.Error("different seeds should plant different secrets")
+	}
+
+	size := DefaultSize()
+	if got := len(readTree(t, first.Root)); got != size.Files+size.Archives+size.Docs+size.Images {
+		t.Errorf("generated %d files, want %d", got, size.Files+size.Archives+size.Docs+size.Images)
+	}
+}
+
+// TestPlantedSecretsMatchPatterns tests that every plain-file secret is on
+// its recorded line and is reported with the recorded pattern type
+func TestPlantedSecretsMatchPatterns(t *testing.T) {
+	project, err := Generate(t.TempDir(), Scaled(3), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := project.AddLeak(); err != nil {
+		t.Fatal(err)
+	}
+
+	scanner := searcher.NewScanner()
+	files := readTree(t, project.Root)
+	for _, secret := range project.SecretsIn(InPlainFile) {
+		lines := strings.Split(files[secret.Path], "\n")
+		if secret.Line < 1 || secret.Line > len(lines) || !strings.Contains(lines[secret.Line-1], secret.Value) {
+			t.Errorf("%s:%d does not hold %q", secret.Path, secret.Line, secret.Value)
+			continue
+		}
+		found := false
+		for _, match := range scanner.Evaluate(lines[secret.Line-1], secret.Path).Matches {
+			if match.Type == secret.Type && strings.Contains(match.MatchText, secret.Value) {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("%s:%d: %s %q is not detected", secret.Path, secret.Line, secret.Type, secret.Value)
+		}
+	}
+
+	for _, clean := range project.Clean {
+		for i, line := range strings.Split(files[clean], "\n") {
+			if matches := scanner.Evaluate(line, clean).Matches; len(matches) > 0 {
+				t.Errorf("clean file %s:%d matches %s", clean, i+1, matches[0].RuleName)
+			}
+		}
+	}
+}
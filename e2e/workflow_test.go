@@ -0,0 +1,350 @@
+//go:build e2e
+
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alexmullins/zip"
+	"github.com/kacebover/password-finder/searcher"
+)
+
+var (
+	scale = flag.Int("scale", 1, "multiplies the size of the generated project")
+	seed  = flag.Int64("seed", 1, "seed of the generated project")
+)
+
+// cliPath is the CLI binary built once for the whole suite
+var cliPath string
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+
+	dir, err := os.MkdirTemp("", "dll-e2e-")
+	if err != nil {
+		panic(err)
+	}
+	cliPath = filepath.Join(dir, "data-leak-locator")
+	if os.PathSeparator == '\\' {
+		cliPath += ".exe"
+	}
+	build := exec.Command("go", "build", "-o", cliPath, "github.com/kacebover/password-finder")
+	build.Stdout, build.Stderr = os.Stdout, os.Stderr
+	if err := build.Run(); err != nil {
+		os.RemoveAll(dir)
+		panic("build CLI: " + err.Error())
+	}
+	// Scans keep their OCR cache out of the user's cache directory
+	os.Setenv("XDG_CACHE_HOME", filepath.Join(dir, "cache"))
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// runCLI runs the CLI and returns its output and exit code
+func runCLI(t *testing.T, args ...string) (string, int) {
+	t.Helper()
+	cmd := exec.Command(cliPath, args...)
+	var out bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &out, &out
+	err := cmd.Run()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return out.String(), exitErr.ExitCode()
+	}
+	if err != nil {
+		t.Fatalf("run %v: %v", args, err)
+	}
+	return out.String(), 0
+}
+
+// latestJSONReport returns the JSON report of the last scan written to outputDir
+func latestJSONReport(t *testing.T, outputDir string) string {
+	t.Helper()
+	reports, _ := filepath.Glob(filepath.Join(outputDir, searcher.LatestReportDir, "*.json"))
+	if len(reports) != 1 {
+		t.Fatalf("expected one JSON report in %s, found %v", outputDir, reports)
+	}
+	return reports[0]
+}
+
+// checkReportSchema validates the fields and totals of a JSON report
+func checkReportSchema(t *testing.T, path string) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report struct {
+		Format   string                 `json:"format"`
+		Metadata map[string]interface{} `json:"metadata"`
+		Summary  struct {
+			TotalFindings    int `json:"total_findings"`
+			CriticalFindings int `json:"critical_findings"`
+			HighFindings     int `json:"high_findings"`
+			MediumFindings   int `json:"medium_findings"`
+			LowFindings      int `json:"low_findings"`
+		} `json:"summary"`
+		Findings    []map[string]interface{} `json:"findings"`
+		Coverage    *searcher.CoverageReport `json:"coverage"`
+		GeneratedAt string                   `json:"generated_at"`
+	}
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatalf("report is not valid JSON: %v", err)
+	}
+
+	if report.Format != searcher.ReportFormat {
+		t.Errorf("format = %q, want %q", report.Format, searcher.ReportFormat)
+	}
+	if report.GeneratedAt == "" || report.Metadata == nil || report.Coverage == nil {
+		t.Error("report lacks generated_at, metadata or coverage")
+	}
+	for _, key := range []string{"scan_start_time", "files_scanned", "error_count"} {
+		if _, ok := report.Metadata[key]; !ok {
+			t.Errorf("metadata lacks %s", key)
+		}
+	}
+
+	s := report.Summary
+	if s.TotalFindings != len(report.Findings) {
+		t.Errorf("total_findings = %d, but %d findings listed", s.TotalFindings, len(report.Findings))
+	}
+	if sum := s.CriticalFindings + s.HighFindings + s.MediumFindings + s.LowFindings; sum != s.TotalFindings {
+		t.Errorf("severity counts add up to %d, want %d", sum, s.TotalFindings)
+	}
+
+	severities := map[string]bool{"critical": true, "high": true, "medium": true, "low": true}
+	for i, f := range report.Findings {
+		for _, key := range []string{"FilePath", "LineNumber", "PatternType", "Severity", "MatchedText", "RiskScore"} {
+			if _, ok := f[key]; !ok {
+				t.Fatalf("finding %d lacks %s", i, key)
+			}
+		}
+		if !severities[f["Severity"].(string)] {
+			t.Errorf("finding %d has severity %v", i, f["Severity"])
+		}
+		if score := f["RiskScore"].(float64); score < 0 || score > 100 {
+			t.Errorf("finding %d has risk score %v", i, score)
+		}
+	}
+}
+
+// foundSecret reports whether a planted secret is among the findings of a
+// report written with -include-secrets
+func foundSecret(result *searcher.ScanResult, root string, secret PlantedSecret) bool {
+	return foundAt(result, root, secret, true)
+}
+
+// foundAt reports whether a finding of the type of a planted secret is where
+// it was planted; withValue also requires the matched text to contain it
+func foundAt(result *searcher.ScanResult, root string, secret PlantedSecret, withValue bool) bool {
+	path := filepath.Join(root, filepath.FromSlash(secret.Path))
+	for _, f := range result.Findings {
+		if f.PatternType != secret.Type || (withValue && !strings.Contains(f.MatchedText, secret.Value)) {
+			continue
+		}
+		switch secret.Container {
+		case InPlainFile:
+			if f.FilePath == path && f.LineNumber == secret.Line {
+				return true
+			}
+		default:
+			if strings.HasPrefix(f.FilePath, path) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// TestWorkflow runs scan → report → baseline → rescan → encrypt → decrypt
+// on a generated project the way a user would
+func TestWorkflow(t *testing.T) {
+	work := t.TempDir()
+	project, err := Generate(filepath.Join(work, "project"), Scaled(*scale), *seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("project: %d files, %d planted secrets", len(project.Paths()), len(project.Secrets))
+
+	// First scan, keeping the values to check every planted secret
+	firstOut := filepath.Join(work, "reports-1")
+	output, code := runCLI(t, "scan", "-dir", project.Root, "-output", firstOut, "-docs", "-archives", "-ocr", "-include-secrets")
+	if code != 0 {
+		t.Fatalf("scan exited with %d:\n%s", code, output)
+	}
+	firstReport := latestJSONReport(t, firstOut)
+	checkReportSchema(t, firstReport)
+
+	first, err := searcher.LoadScanResult(firstReport)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, container := range []string{InPlainFile, InArchive, InDocument} {
+		for _, secret := range project.SecretsIn(container) {
+			if !foundSecret(first, project.Root, secret) {
+				t.Errorf("planted %s %q in %s was not reported", secret.Type, secret.Value, secret.Path)
+			}
+		}
+	}
+	for _, clean := range project.Clean {
+		path := filepath.Join(project.Root, filepath.FromSlash(clean))
+		for _, f := range first.Findings {
+			if f.FilePath == path {
+				t.Errorf("clean file %s reported: %s %q", clean, f.PatternType, f.MatchedText)
+			}
+		}
+	}
+
+	// Without Tesseract the images must be reported as not fully checked
+	if len(project.SecretsIn(InImage)) > 0 && !searcher.Dependencies().IsAvailable(searcher.DependencyTesseract) {
+		ocr := false
+		for _, c := range first.Coverage.Capabilities {
+			if c.Capability == searcher.CapabilityImageOCR && c.Status == searcher.CoverageUnavailable {
+				ocr = true
+			}
+		}
+		if !ocr {
+			t.Error("coverage should mark image OCR unavailable without Tesseract")
+		}
+	}
+
+	// Explain the first finding
+	output, code = runCLI(t, "explain", "-finding-id", "1", "-report", firstReport)
+	if code != 0 || !strings.Contains(output, "итого") || strings.Contains(output, "⚠️") {
+		t.Errorf("explain exited with %d:\n%s", code, output)
+	}
+
+	// Rescan against the first report after a new leak
+	leak, err := project.AddLeak()
+	if err != nil {
+		t.Fatal(err)
+	}
+	secondOut := filepath.Join(work, "reports-2")
+	output, code = runCLI(t, "scan", "-dir", project.Root, "-output", secondOut, "-docs", "-archives", "-ocr", "-baseline", firstReport, "-show-baselined")
+	if code != 0 {
+		t.Fatalf("rescan exited with %d:\n%s", code, output)
+	}
+	if !strings.Contains(output, "относительно базового отчёта") {
+		t.Errorf("rescan summary lacks the baseline comparison:\n%s", output)
+	}
+	secondReport := latestJSONReport(t, secondOut)
+	checkReportSchema(t, secondReport)
+
+	second, err := searcher.LoadScanResult(secondReport)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Without -include-secrets the values are masked in every report
+	if !foundAt(second, project.Root, leak, false) {
+		t.Errorf("new leak %q was not reported", leak.Value)
+	}
+	reports, _ := filepath.Glob(filepath.Join(filepath.Dir(secondReport), "*"))
+	for _, path := range reports {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, secret := range append(project.Secrets, leak) {
+			if len(secret.Value) > 8 && strings.Contains(string(data), secret.Value) {
+				t.Errorf("%s contains the planted %s %q", filepath.Base(path), secret.Type, secret.Value)
+			}
+		}
+	}
+	leakPath := filepath.Join(project.Root, filepath.FromSlash(leak.Path))
+	newCount, known := searcher.CountBaseline(second.Findings)
+	for _, f := range second.Findings {
+		if (f.Baseline == searcher.BaselineNew) != (f.FilePath == leakPath) {
+			t.Errorf("%s:%d %s tagged %q", f.FilePath, f.LineNumber, f.PatternType, f.Baseline)
+		}
+	}
+	if newCount == 0 || known != first.TotalFindings() || newCount+known != second.TotalFindings() {
+		t.Errorf("baseline diff: new %d, known %d; first scan had %d findings, second %d",
+			newCount, known, first.TotalFindings(), second.TotalFindings())
+	}
+
+	// Encrypt the project and decrypt every file back
+	archive := filepath.Join(work, "project.zip")
+	password := "E2e-Pr0ject-Passw0rd!"
+	output, code = runCLI(t, "encrypt", "-dir", project.Root, "-output", archive, "-password", password)
+	if code != 0 {
+		t.Fatalf("encrypt exited with %d:\n%s", code, output)
+	}
+	checkDecryptable(t, archive, password, project)
+
+	// Failures exit with a non-zero code
+	for _, args := range [][]string{
+		{"scan", "-dir", filepath.Join(work, "missing")},
+		{"scan", "-dir", project.Root, "-output", filepath.Join(work, "reports-x"), "-baseline", filepath.Join(work, "missing.json")},
+		{"explain", "-finding-id", "100000", "-report", firstReport},
+		{"encrypt", "-dir", project.Root, "-output", filepath.Join(work, "weak.zip"), "-password", "123"},
+	} {
+		if output, code := runCLI(t, args...); code == 0 {
+			t.Errorf("%v should fail:\n%s", args, output)
+		}
+	}
+}
+
+// checkDecryptable opens every entry of the archive with the password,
+// compares it with the original and checks a wrong password is rejected
+func checkDecryptable(t *testing.T, archive, password string, project *Project) {
+	t.Helper()
+	reader, err := zip.OpenReader(archive)
+	if err != nil {
+		t.Fatalf("open archive: %v", err)
+	}
+	defer reader.Close()
+
+	originals := readTree(t, project.Root)
+	if len(reader.File) != len(originals) {
+		t.Errorf("archive has %d entries, project has %d files", len(reader.File), len(originals))
+	}
+
+	matched := 0
+	for _, f := range reader.File {
+		f.SetPassword(password)
+		rc, err := f.Open()
+		if err != nil {
+			t.Errorf("decrypt %s: %v", f.Name, err)
+			continue
+		}
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Errorf("read %s: %v", f.Name, err)
+			continue
+		}
+		for rel, original := range originals {
+			if strings.HasSuffix(filepath.ToSlash(f.Name), rel) {
+				if string(content) != original {
+					t.Errorf("%s differs after decryption", f.Name)
+				}
+				matched++
+				break
+			}
+		}
+	}
+	if matched != len(originals) {
+		t.Errorf("matched %d of %d files in the archive", matched, len(originals))
+	}
+
+	if len(reader.File) > 0 {
+		f := reader.File[0]
+		f.SetPassword("wrong-password")
+		if rc, err := f.Open(); err == nil {
+			_, err = io.ReadAll(rc)
+			rc.Close()
+			if err == nil {
+				t.Error("a wrong password should not decrypt the archive")
+			}
+		}
+	}
+}
@@ -0,0 +1,690 @@
+package encryptor
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/alexmullins/zip"
+	"github.com/kacebover/password-finder/fsutil"
+)
+
+// Decryption errors
+var (
+	ErrWrongPassword = errors.New("wrong password")
+	ErrFileExists    = errors.New("file already exists")
+	ErrInvalidSource = errors.New("invalid source archive")
+	ErrUnsafePath    = errors.New("archive entry escapes the output directory")
+
+	// ErrPasswordRequired is returned by ListArchive for 7z and age
+	// archives, whose names are encrypted too; see Decryptor.List
+	ErrPasswordRequired = errors.New("archive names are encrypted, the password is required")
+)
+
+// OverwritePolicy decides what happens when an extracted file already exists
+type OverwritePolicy int
+
+const (
+	// OverwriteNever fails with ErrFileExists (default)
+	OverwriteNever OverwritePolicy = iota
+	// OverwriteSkip keeps the existing file and continues
+	OverwriteSkip
+	// OverwriteAlways replaces the existing file
+	OverwriteAlways
+)
+
+// DecryptConfig holds decryption configuration
+type DecryptConfig struct {
+	// Password of the encrypted archive (required)
+	Password string
+
+	// SourcePath is the encrypted archive; its format is detected, see
+	// DetectFormat
+	SourcePath string
+
+	// OutputDir receives the extracted files; directories stored in the
+	// archive are recreated below it
+	OutputDir string
+
+	// Overwrite decides what happens to files that already exist
+	Overwrite OverwritePolicy
+
+	// OnProgress is called to report decryption progress
+	OnProgress ProgressCallback
+
+	// BufferSize for streaming operations (default: 32KB)
+	BufferSize int
+
+	// AgeIdentities are X25519 private keys ("AGE-SECRET-KEY-1...") for
+	// age archives encrypted for recipients; the password is not needed
+	// with them
+	AgeIdentities []string
+
+	// SevenZipPath is the 7-Zip binary for 7z archives; it is looked up
+	// on PATH when empty
+	SevenZipPath string
+
+	// SevenZipPasswordArg allows reading 7z archives, which passes the
+	// password to 7-Zip on its command line, see Config.SevenZipPasswordArg
+	SevenZipPasswordArg bool
+}
+
+// Decryptor extracts archives created by Encryptor
+type Decryptor struct {
+	config DecryptConfig
+
+	// Progress tracking
+	bytesProcessed int64
+	totalBytes     int64
+	currentFile    string
+	cancelled      int32
+	filesDecrypted int32
+}
+
+// NewDecryptor creates a new Decryptor with the given config
+func NewDecryptor(config DecryptConfig) (*Decryptor, error) {
+	if config.Password == "" && len(config.AgeIdentities) == 0 {
+		return nil, ErrEmptyPassword
+	}
+
+	if config.SourcePath == "" {
+		return nil, ErrInvalidSource
+	}
+
+	if config.BufferSize <= 0 {
+		config.BufferSize = 32 * 1024
+	}
+
+	return &Decryptor{
+		config: config,
+	}, nil
+}
+
+// DecryptAll extracts every file of the archive into OutputDir
+func (d *Decryptor) DecryptAll() error {
+	// A Cancel made before the call stops it too, the next call starts afresh
+	defer atomic.StoreInt32(&d.cancelled, 0)
+	if atomic.LoadInt32(&d.cancelled) == 1 {
+		return ErrCancelled
+	}
+	if d.config.OutputDir == "" {
+		return ErrInvalidOutput
+	}
+
+	format, err := DetectFormat(d.config.SourcePath)
+	if err != nil {
+		return err
+	}
+	if err := d.checkSplit(); err != nil {
+		return err
+	}
+	switch format {
+	case FormatSevenZip:
+		return d.decryptSevenZip("", "")
+	case FormatAge:
+		return d.decryptAge("", "")
+	}
+
+	reader, err := d.open()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	var totalSize int64
+	for _, file := range reader.File {
+		totalSize += int64(file.UncompressedSize64)
+	}
+	d.reset(totalSize)
+
+	for _, file := range reader.File {
+		if atomic.LoadInt32(&d.cancelled) == 1 {
+			return ErrCancelled
+		}
+
+		destPath, err := d.destination(file.Name)
+		if err != nil {
+			return err
+		}
+		if file.FileInfo().IsDir() {
+			if err := fsutil.MkdirAll(destPath, 0755); err != nil {
+				return pathError(err)
+			}
+			continue
+		}
+		if err := d.extract(file, destPath); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DecryptFile extracts a single file of the archive to destPath
+func (d *Decryptor) DecryptFile(nameInArchive, destPath string) error {
+	// A Cancel made before the call stops it too, the next call starts afresh
+	defer atomic.StoreInt32(&d.cancelled, 0)
+	if atomic.LoadInt32(&d.cancelled) == 1 {
+		return ErrCancelled
+	}
+	format, err := DetectFormat(d.config.SourcePath)
+	if err != nil {
+		return err
+	}
+	if err := d.checkSplit(); err != nil {
+		return err
+	}
+	switch format {
+	case FormatSevenZip:
+		return d.decryptSevenZip(nameInArchive, destPath)
+	case FormatAge:
+		return d.decryptAge(nameInArchive, destPath)
+	}
+
+	reader, err := d.open()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	for _, file := range reader.File {
+		if file.Name == nameInArchive {
+			d.reset(int64(file.UncompressedSize64))
+			return d.extract(file, destPath)
+		}
+	}
+	return fmt.Errorf("%w: %s in %s", ErrNotFound, nameInArchive, d.config.SourcePath)
+}
+
+// Cancel cancels an ongoing decryption; the file being written is removed
+func (d *Decryptor) Cancel() {
+	atomic.StoreInt32(&d.cancelled, 1)
+}
+
+// FilesDecrypted returns how many files the last operation extracted
+func (d *Decryptor) FilesDecrypted() int {
+	return int(atomic.LoadInt32(&d.filesDecrypted))
+}
+
+// ArchiveEntry describes a file stored in an archive
+type ArchiveEntry struct {
+	Name           string
+	Size           int64
+	CompressedSize int64
+	Modified       time.Time
+	Encrypted      bool
+}
+
+// ListArchive returns the files of a ZIP archive without decrypting them;
+// the names are stored in plain text, so no password is needed. Other
+// formats fail with ErrPasswordRequired
+func ListArchive(sourcePath string) ([]ArchiveEntry, error) {
+	format, err := DetectFormat(sourcePath)
+	if err != nil {
+		return nil, err
+	}
+	if format != FormatZipAES {
+		return nil, fmt.Errorf("%w: %s", ErrPasswordRequired, sourcePath)
+	}
+
+	reader, err := openArchive(sourcePath)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	var entries []ArchiveEntry
+	for _, file := range reader.File {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+		entries = append(entries, ArchiveEntry{
+			Name:           file.Name,
+			Size:           int64(file.UncompressedSize64),
+			CompressedSize: int64(file.CompressedSize64),
+			Modified:       file.ModTime(),
+			Encrypted:      file.IsEncrypted(),
+		})
+	}
+	return entries, nil
+}
+
+// List returns the files of the archive in any format, decrypting the
+// names of 7z and age archives
+func (d *Decryptor) List() ([]ArchiveEntry, error) {
+	format, err := DetectFormat(d.config.SourcePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []ArchiveEntry
+	switch format {
+	case FormatSevenZip:
+		c, err := d.sevenZip()
+		if err != nil {
+			return nil, err
+		}
+		listed, err := c.list(sevenZipSource(d.config.SourcePath))
+		if err != nil {
+			return nil, d.archiveError(d.config.SourcePath, err)
+		}
+		for _, entry := range listed {
+			if !entry.dir {
+				entries = append(entries, entry.ArchiveEntry)
+			}
+		}
+	case FormatAge:
+		err = d.readAge(func(header *tar.Header, _ io.Reader) (bool, error) {
+			if header.Typeflag == tar.TypeReg {
+				entries = append(entries, ArchiveEntry{
+					Name:      header.Name,
+					Size:      header.Size,
+					Modified:  header.ModTime,
+					Encrypted: true,
+				})
+			}
+			return true, nil
+		})
+	default:
+		return ListArchive(d.config.SourcePath)
+	}
+	return entries, err
+}
+
+// open opens the source archive
+func (d *Decryptor) open() (*zipSource, error) {
+	return openArchive(d.config.SourcePath)
+}
+
+// zipSource is a ZIP archive opened from a file or the volumes of a split
+// archive
+type zipSource struct {
+	*zip.Reader
+	io.Closer
+}
+
+// openArchive opens a ZIP archive, telling a missing file from one that is
+// not an archive
+func openArchive(sourcePath string) (*zipSource, error) {
+	src, err := openSource(sourcePath)
+	if err != nil {
+		return nil, err
+	}
+	reader, err := zip.NewReader(src, src.Size())
+	if err != nil {
+		src.Close()
+		return nil, fmt.Errorf("%w: %s: %w", ErrInvalidSource, sourcePath, err)
+	}
+	return &zipSource{Reader: reader, Closer: src}, nil
+}
+
+// checkSplit verifies the volumes of a split source archive against its
+// manifest before anything is extracted, reporting progress over the set
+func (d *Decryptor) checkSplit() error {
+	m, err := LoadSplit(d.config.SourcePath)
+	if err != nil || m == nil {
+		return err
+	}
+	d.reset(m.TotalSize)
+	err = m.read(cancelWriter{&d.cancelled}, func(done, _ int64, part string) {
+		atomic.StoreInt64(&d.bytesProcessed, done)
+		d.currentFile = part
+		d.reportProgress()
+	})
+	if errors.Is(err, ErrCancelled) {
+		return ErrCancelled
+	}
+	return err
+}
+
+// cancelWriter discards what is written to it and fails once cancelled is
+// set
+type cancelWriter struct {
+	cancelled *int32
+}
+
+func (w cancelWriter) Write(p []byte) (int, error) {
+	if atomic.LoadInt32(w.cancelled) == 1 {
+		return 0, ErrCancelled
+	}
+	return len(p), nil
+}
+
+// reset clears the progress counters before an operation. The cancelled
+// flag is cleared when the operation returns, so an early Cancel is kept
+func (d *Decryptor) reset(totalSize int64) {
+	atomic.StoreInt64(&d.bytesProcessed, 0)
+	atomic.StoreInt64(&d.totalBytes, totalSize)
+	atomic.StoreInt32(&d.filesDecrypted, 0)
+}
+
+// destination maps an archive path below OutputDir, rejecting entries such
+// as "../x" that would be written outside of it
+func (d *Decryptor) destination(name string) (string, error) {
+	destPath := filepath.Join(d.config.OutputDir, filepath.FromSlash(name))
+	relPath, err := filepath.Rel(d.config.OutputDir, destPath)
+	if err != nil || relPath == ".." || strings.HasPrefix(relPath, ".."+string(os.PathSeparator)) || filepath.IsAbs(name) {
+		return "", fmt.Errorf("%w: %s", ErrUnsafePath, name)
+	}
+	return destPath, nil
+}
+
+// existing applies the overwrite policy to a file about to be written with
+// size bytes; skip is true when the file is kept
+func (d *Decryptor) existing(destPath string, size int64) (skip bool, err error) {
+	if _, err := fsutil.Stat(destPath); err == nil {
+		switch d.config.Overwrite {
+		case OverwriteSkip:
+			atomic.AddInt64(&d.bytesProcessed, size)
+			d.reportProgress()
+			return true, nil
+		case OverwriteNever:
+			return false, fmt.Errorf("%w: %s", ErrFileExists, destPath)
+		}
+	}
+	return false, nil
+}
+
+// extract writes one ZIP entry to destPath
+func (d *Decryptor) extract(file *zip.File, destPath string) error {
+	if skip, err := d.existing(destPath, int64(file.UncompressedSize64)); skip || err != nil {
+		return err
+	}
+
+	if file.IsEncrypted() {
+		file.SetPassword(d.config.Password)
+	}
+	src, err := file.Open()
+	if err != nil {
+		return d.archiveError(file.Name, err)
+	}
+	defer src.Close()
+
+	return d.writeEntry(file.Name, src, destPath, file.Mode())
+}
+
+// writeEntry streams the content of an entry to destPath. The content goes
+// to a temporary file first, so a wrong password, an error or a
+// cancellation never leaves a partial file behind
+func (d *Decryptor) writeEntry(name string, src io.Reader, destPath string, mode os.FileMode) error {
+	d.currentFile = name
+	d.reportProgress()
+
+	if err := fsutil.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", pathError(err))
+	}
+	tmpFile, err := fsutil.CreateTemp(filepath.Dir(destPath), ".decrypt-*")
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", pathError(err))
+	}
+	tmpPath := tmpFile.Name()
+	if err := d.copyContent(tmpFile, src, name); err != nil {
+		tmpFile.Close()
+		fsutil.Remove(tmpPath)
+		return err
+	}
+	if err := tmpFile.Close(); err != nil {
+		fsutil.Remove(tmpPath)
+		return fmt.Errorf("failed to write %s: %w", destPath, err)
+	}
+	if err := fsutil.Chmod(tmpPath, mode.Perm()|0600); err != nil {
+		fsutil.Remove(tmpPath)
+		return pathError(err)
+	}
+	if err := fsutil.Rename(tmpPath, destPath); err != nil {
+		fsutil.Remove(tmpPath)
+		return pathError(err)
+	}
+
+	atomic.AddInt32(&d.filesDecrypted, 1)
+	return nil
+}
+
+// copyContent streams an entry with progress tracking
+func (d *Decryptor) copyContent(dst io.Writer, src io.Reader, name string) error {
+	buf := make([]byte, d.config.BufferSize)
+	for {
+		if atomic.LoadInt32(&d.cancelled) == 1 {
+			return ErrCancelled
+		}
+
+		n, readErr := src.Read(buf)
+		if n > 0 {
+			if _, writeErr := dst.Write(buf[:n]); writeErr != nil {
+				return fmt.Errorf("failed to write %s: %w", name, writeErr)
+			}
+
+			atomic.AddInt64(&d.bytesProcessed, int64(n))
+			d.reportProgress()
+		}
+
+		if readErr == io.EOF {
+			return nil
+		}
+		if readErr != nil {
+			return d.archiveError(name, readErr)
+		}
+	}
+}
+
+// archiveError classifies an error of the archive reader. A failed
+// password check or MAC verification means the password is wrong
+func (d *Decryptor) archiveError(name string, err error) error {
+	if errors.Is(err, ErrCancelled) {
+		return err
+	}
+	if errors.Is(err, zip.ErrPassword) || errors.Is(err, zip.ErrAuthentication) || errors.Is(err, ErrWrongPassword) {
+		return fmt.Errorf("%w: %s", ErrWrongPassword, d.config.SourcePath)
+	}
+	return fmt.Errorf("failed to read %s from archive: %w", name, err)
+}
+
+// reportProgress calls the progress callback if configured
+func (d *Decryptor) reportProgress() {
+	if d.config.OnProgress != nil {
+		d.config.OnProgress(
+			atomic.LoadInt64(&d.bytesProcessed),
+			atomic.LoadInt64(&d.totalBytes),
+			d.currentFile,
+		)
+	}
+}
+
+// readAge decrypts an age archive and calls fn with each entry of the tar
+// inside until it returns false
+func (d *Decryptor) readAge(fn func(header *tar.Header, content io.Reader) (bool, error)) error {
+	f, err := openSource(d.config.SourcePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r, err := openAge(f, d.config.Password, d.config.AgeIdentities)
+	if err != nil {
+		if errors.Is(err, ErrWrongPassword) {
+			return fmt.Errorf("%w: %s", ErrWrongPassword, d.config.SourcePath)
+		}
+		return fmt.Errorf("%w: %s: %w", ErrInvalidSource, d.config.SourcePath, err)
+	}
+	tr := tar.NewReader(r)
+	for {
+		if atomic.LoadInt32(&d.cancelled) == 1 {
+			return ErrCancelled
+		}
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", d.config.SourcePath, err)
+		}
+		more, err := fn(header, tr)
+		if err != nil || !more {
+			return err
+		}
+	}
+}
+
+// decryptAge extracts an age archive: every entry into OutputDir, or only
+// the entry named only to destPath. The entries are known only as the
+// stream is decrypted, so the size of the archive stands in for the total
+func (d *Decryptor) decryptAge(only, destPath string) error {
+	var total int64
+	if f, err := openSource(d.config.SourcePath); err == nil {
+		total = f.Size()
+		f.Close()
+	}
+	d.reset(total)
+
+	found := false
+	err := d.readAge(func(header *tar.Header, content io.Reader) (bool, error) {
+		if only != "" {
+			if header.Name != only || header.Typeflag != tar.TypeReg {
+				return true, nil
+			}
+			found = true
+			if skip, err := d.existing(destPath, header.Size); skip || err != nil {
+				return false, err
+			}
+			return false, d.writeEntry(header.Name, content, destPath, header.FileInfo().Mode())
+		}
+
+		dest, err := d.destination(header.Name)
+		if err != nil {
+			return false, err
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			return true, pathError(fsutil.MkdirAll(dest, 0755))
+		case tar.TypeReg:
+			if skip, err := d.existing(dest, header.Size); skip || err != nil {
+				return err == nil, err
+			}
+			return true, d.writeEntry(header.Name, content, dest, header.FileInfo().Mode())
+		}
+		return true, nil
+	})
+	if err != nil {
+		return err
+	}
+	if only != "" && !found {
+		return fmt.Errorf("%w: %s in %s", ErrNotFound, only, d.config.SourcePath)
+	}
+	// Now the content size is known
+	atomic.StoreInt64(&d.totalBytes, atomic.LoadInt64(&d.bytesProcessed))
+	d.reportProgress()
+	return nil
+}
+
+// sevenZip returns the 7-Zip command for the archive
+func (d *Decryptor) sevenZip() (sevenZipCmd, error) {
+	path, err := findSevenZip(d.config.SevenZipPath)
+	if err == nil && !d.config.SevenZipPasswordArg {
+		err = ErrPasswordOnCommandLine
+	}
+	return sevenZipCmd{path: path, password: d.config.Password, cancelled: &d.cancelled}, err
+}
+
+// decryptSevenZip extracts a 7z archive: every entry into OutputDir, or
+// only the entry named only to destPath. 7-Zip extracts into a temporary
+// directory next to the destination and the files are moved into place,
+// so the overwrite policy applies as for the other formats
+func (d *Decryptor) decryptSevenZip(only, destPath string) error {
+	c, err := d.sevenZip()
+	if err != nil {
+		return err
+	}
+	listed, err := c.list(sevenZipSource(d.config.SourcePath))
+	if err != nil {
+		return d.archiveError(d.config.SourcePath, err)
+	}
+
+	// Existing files are refused before anything is extracted
+	var files []sevenZipEntry
+	var targets []string
+	var total int64
+	for _, entry := range listed {
+		if only != "" && entry.Name != only {
+			continue
+		}
+		dest := destPath
+		if only == "" {
+			if dest, err = d.destination(entry.Name); err != nil {
+				return err
+			}
+		}
+		if entry.dir {
+			if only == "" {
+				if err := fsutil.MkdirAll(dest, 0755); err != nil {
+					return pathError(err)
+				}
+			}
+			continue
+		}
+		total += entry.Size
+		files = append(files, entry)
+		targets = append(targets, dest)
+	}
+	if only != "" && len(files) == 0 {
+		return fmt.Errorf("%w: %s in %s", ErrNotFound, only, d.config.SourcePath)
+	}
+	d.reset(total)
+
+	keep := make([]bool, len(files))
+	for i, entry := range files {
+		skip, err := d.existing(targets[i], entry.Size)
+		if err != nil {
+			return err
+		}
+		keep[i] = !skip
+	}
+
+	workDir := d.config.OutputDir
+	if only != "" {
+		workDir = filepath.Dir(destPath)
+	}
+	if err := fsutil.MkdirAll(workDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", pathError(err))
+	}
+	tmpDir, err := os.MkdirTemp(fsutil.Normalize(workDir), ".decrypt-")
+	if err != nil {
+		return fmt.Errorf("failed to create output directory: %w", pathError(err))
+	}
+	defer os.RemoveAll(tmpDir)
+
+	args := []string{"x", "-bso0", "-bsp1", "-o" + tmpDir, "--", sevenZipSource(d.config.SourcePath)}
+	if only != "" {
+		args = append(args, filepath.FromSlash(only))
+	}
+	d.currentFile = filepath.Base(d.config.SourcePath)
+	err = c.run("", nil, nil, func(pct int) {
+		atomic.StoreInt64(&d.bytesProcessed, total*int64(pct)/100)
+		d.reportProgress()
+	}, args...)
+	if err != nil {
+		return d.archiveError(d.config.SourcePath, err)
+	}
+
+	for i, entry := range files {
+		if !keep[i] {
+			continue
+		}
+		extracted := filepath.Join(tmpDir, filepath.FromSlash(entry.Name))
+		if err := fsutil.MkdirAll(filepath.Dir(targets[i]), 0755); err != nil {
+			return fmt.Errorf("failed to create output directory: %w", pathError(err))
+		}
+		if err := fsutil.Rename(extracted, targets[i]); err != nil {
+			return pathError(err)
+		}
+		atomic.AddInt32(&d.filesDecrypted, 1)
+	}
+	atomic.StoreInt64(&d.bytesProcessed, total)
+	d.reportProgress()
+	return nil
+}
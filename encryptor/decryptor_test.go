@@ -0,0 +1,249 @@
+package encryptor
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// encryptTree creates a nested directory, encrypts it and returns the
+// archive path
+func encryptTree(t *testing.T, password string) (string, map[string]string) {
+	t.Helper()
+	return encryptTreeAs(t, password, FormatZipAES)
+}
+
+// encryptTreeAs is encryptTree writing an archive of the given format
+func encryptTreeAs(t *testing.T, password string, format OutputFormat) (string, map[string]string) {
+	t.Helper()
+	dir := t.TempDir()
+	base := filepath.Join(dir, "project")
+	files := map[string]string{
+		"project/config.env":            "password=hunter2\n",
+		"project/src/db/settings.yaml":  "db_password: s3cret\n",
+		"project/docs/notes/readme.txt": strings.Repeat("секретные данные ", 5000),
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	config := DefaultConfig()
+	config.Password = password
+	config.OutputPath = filepath.Join(dir, "out"+format.Ext())
+	config.Format = format
+	config.SevenZipPasswordArg = true
+	enc, err := NewEncryptor(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc.ageWorkFactor = testAgeWorkFactor
+	if err := enc.EncryptFiles([]FileEntry{{SourcePath: base}}); err != nil {
+		t.Fatal(err)
+	}
+	return config.OutputPath, files
+}
+
+func TestDecryptAllRoundTrip(t *testing.T) {
+	archive, files := encryptTree(t, "correct horse")
+	outDir := t.TempDir()
+
+	var lastProcessed, lastTotal int64
+	dec, err := NewDecryptor(DecryptConfig{
+		Password:   "correct horse",
+		SourcePath: archive,
+		OutputDir:  outDir,
+		OnProgress: func(processed, total int64, _ string) { lastProcessed, lastTotal = processed, total },
+		BufferSize: 1024,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := dec.DecryptAll(); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, want := range files {
+		got, err := os.ReadFile(filepath.Join(outDir, filepath.FromSlash(name)))
+		if err != nil || string(got) != want {
+			t.Errorf("%s: got %d bytes, err %v", name, len(got), err)
+		}
+	}
+	if dec.FilesDecrypted() != len(files) {
+		t.Errorf("FilesDecrypted = %d, want %d", dec.FilesDecrypted(), len(files))
+	}
+	if lastTotal == 0 || lastProcessed != lastTotal {
+		t.Errorf("progress ended at %d of %d", lastProcessed, lastTotal)
+	}
+
+	// A second run does not overwrite by default
+	if err := dec.DecryptAll(); !errors.Is(err, ErrFileExists) {
+		t.Errorf("second run error = %v, want ErrFileExists", err)
+	}
+	dec.config.Overwrite = OverwriteSkip
+	if err := dec.DecryptAll(); err != nil || dec.FilesDecrypted() != 0 {
+		t.Errorf("skip run: err %v, %d files", err, dec.FilesDecrypted())
+	}
+	dec.config.Overwrite = OverwriteAlways
+	if err := dec.DecryptAll(); err != nil || dec.FilesDecrypted() != len(files) {
+		t.Errorf("overwrite run: err %v, %d files", err, dec.FilesDecrypted())
+	}
+}
+
+func TestDecryptFile(t *testing.T) {
+	archive, files := encryptTree(t, "pw")
+	dest := filepath.Join(t.TempDir(), "nested", "settings.yaml")
+
+	dec, _ := NewDecryptor(DecryptConfig{Password: "pw", SourcePath: archive})
+	if err := dec.DecryptFile("project/src/db/settings.yaml", dest); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := os.ReadFile(dest); string(got) != files["project/src/db/settings.yaml"] {
+		t.Errorf("content = %q", got)
+	}
+
+	if err := dec.DecryptFile("project/missing.txt", dest+".2"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("missing entry error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestListArchive(t *testing.T) {
+	archive, files := encryptTree(t, "pw")
+
+	entries, err := ListArchive(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != len(files) {
+		t.Fatalf("got %d entries, want %d", len(entries), len(files))
+	}
+	for _, entry := range entries {
+		content, ok := files[entry.Name]
+		if !ok || entry.Size != int64(len(content)) || !entry.Encrypted {
+			t.Errorf("unexpected entry %+v", entry)
+		}
+	}
+
+	if _, err := ListArchive(filepath.Join(t.TempDir(), "missing.zip")); !errors.Is(err, ErrNotFound) {
+		t.Errorf("missing archive error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestDecryptWrongPassword(t *testing.T) {
+	archive, _ := encryptTree(t, "right")
+	outDir := t.TempDir()
+
+	dec, _ := NewDecryptor(DecryptConfig{Password: "wrong", SourcePath: archive, OutputDir: outDir})
+	err := dec.DecryptAll()
+	if !errors.Is(err, ErrWrongPassword) {
+		t.Fatalf("error = %v, want ErrWrongPassword", err)
+	}
+
+	// No partial files are left behind
+	filepath.Walk(outDir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			t.Errorf("unexpected file after failed decryption: %s", path)
+		}
+		return nil
+	})
+}
+
+func TestDecryptErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	if _, err := NewDecryptor(DecryptConfig{SourcePath: "a.zip"}); !errors.Is(err, ErrEmptyPassword) {
+		t.Errorf("empty password error = %v", err)
+	}
+
+	dec, _ := NewDecryptor(DecryptConfig{Password: "pw", SourcePath: filepath.Join(dir, "missing.zip"), OutputDir: dir})
+	if err := dec.DecryptAll(); !errors.Is(err, ErrNotFound) {
+		t.Errorf("missing archive error = %v, want ErrNotFound", err)
+	}
+
+	notZip := filepath.Join(dir, "plain.zip")
+	os.WriteFile(notZip, []byte("not a zip"), 0644)
+	dec, _ = NewDecryptor(DecryptConfig{Password: "pw", SourcePath: notZip, OutputDir: dir})
+	if err := dec.DecryptAll(); !errors.Is(err, ErrInvalidSource) {
+		t.Errorf("invalid archive error = %v, want ErrInvalidSource", err)
+	}
+
+	dec, _ = NewDecryptor(DecryptConfig{Password: "pw", OutputDir: dir, SourcePath: notZip})
+	for _, name := range []string{"../evil.txt", "a/../../evil.txt"} {
+		if _, err := dec.destination(name); !errors.Is(err, ErrUnsafePath) {
+			t.Errorf("destination(%q) error = %v, want ErrUnsafePath", name, err)
+		}
+	}
+}
+
+func TestDecryptCancelled(t *testing.T) {
+	archive, _ := encryptTree(t, "pw")
+	outDir := t.TempDir()
+
+	var dec *Decryptor
+	var started int64
+	// Cancel halfway through the large file
+	config := DecryptConfig{
+		Password:   "pw",
+		SourcePath: archive,
+		OutputDir:  outDir,
+		BufferSize: 512,
+		OnProgress: func(processed, _ int64, current string) {
+			if !strings.HasSuffix(current, "readme.txt") {
+				return
+			}
+			if started == 0 {
+				started = processed
+			} else if processed-started > 10000 {
+				dec.Cancel()
+			}
+		},
+	}
+	dec, _ = NewDecryptor(config)
+
+	if err := dec.DecryptAll(); !errors.Is(err, ErrCancelled) {
+		t.Fatalf("error = %v, want ErrCancelled", err)
+	}
+
+	// Files finished before the cancellation stay, the partial one is removed
+	var left []string
+	filepath.Walk(outDir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			left = append(left, filepath.Base(path))
+		}
+		return nil
+	})
+	if len(left) != 1 || left[0] != "config.env" {
+		t.Errorf("files after cancellation: %v, want only config.env", left)
+	}
+}
+
+// TestDecryptCancelledBeforeStart tests a Cancel made before DecryptAll
+// stops it without extracting anything, and the next call runs
+func TestDecryptCancelledBeforeStart(t *testing.T) {
+	archive, _ := encryptTree(t, "pw")
+	outDir := t.TempDir()
+	dec, err := NewDecryptor(DecryptConfig{Password: "pw", SourcePath: archive, OutputDir: outDir})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dec.Cancel()
+	if err := dec.DecryptAll(); !errors.Is(err, ErrCancelled) {
+		t.Fatalf("error = %v, want ErrCancelled", err)
+	}
+	if entries, _ := os.ReadDir(outDir); len(entries) != 0 {
+		t.Errorf("cancelled decryption wrote %d entries", len(entries))
+	}
+
+	if err := dec.DecryptAll(); err != nil {
+		t.Fatalf("decryption after a cancelled one: %v", err)
+	}
+	if dec.FilesDecrypted() == 0 {
+		t.Error("no files decrypted")
+	}
+}
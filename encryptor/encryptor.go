@@ -1,28 +1,30 @@
 // Package encryptor provides secure file encryption functionality
-// using AES-256 encrypted ZIP archives.
+// using AES-256 encrypted ZIP archives, 7z archives and age.
 package encryptor
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"errors"
 	"fmt"
+	"hash"
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 
-	"github.com/alexmullins/zip"
+	"github.com/kacebover/password-finder/fsutil"
 )
 
 // Common errors
 var (
-	ErrEmptyPassword   = errors.New("password cannot be empty")
-	ErrNoFiles         = errors.New("no files provided for encryption")
-	ErrFileNotFound    = errors.New("file not found")
-	ErrPermissionDenied = errors.New("permission denied")
-	ErrInvalidOutput   = errors.New("invalid output path")
+	ErrEmptyPassword = errors.New("password cannot be empty")
+	ErrNoFiles       = errors.New("no files provided for encryption")
+	ErrInvalidOutput = errors.New("invalid output path")
 )
 
 // EncryptionMethod specifies the ZIP encryption method
@@ -50,9 +52,27 @@ type Config struct {
 	// Password for the encrypted archive (required, min 1 character)
 	Password string
 
-	// OutputPath is the full path for the output ZIP file
+	// OutputPath is the full path for the output archive
 	OutputPath string
 
+	// Format is the kind of archive written (default: FormatZipAES), see
+	// format.go
+	Format OutputFormat
+
+	// AgeRecipients are X25519 public keys ("age1...") to encrypt a
+	// FormatAge archive for instead of the password
+	AgeRecipients []string
+
+	// SevenZipPath is the 7-Zip binary for FormatSevenZip; it is looked
+	// up on PATH when empty
+	SevenZipPath string
+
+	// SevenZipPasswordArg allows FormatSevenZip. 7-Zip takes a password
+	// only as a command line argument or at its own prompt, and other users
+	// of the system can read the argument in the process list while it
+	// runs; without this NewEncryptor returns ErrPasswordOnCommandLine
+	SevenZipPasswordArg bool
+
 	// Method specifies the encryption method (default: AES256)
 	Method EncryptionMethod
 
@@ -70,6 +90,29 @@ type Config struct {
 
 	// BufferSize for streaming operations (default: 32KB)
 	BufferSize int
+
+	// ContinueOnError leaves out files that cannot be read and records them
+	// in Result.Errors; by default the first one fails the run and no
+	// archive is left
+	ContinueOnError bool
+
+	// ExcludeGlobs leaves matching paths inside directories out of the
+	// archive, see exclude.go
+	ExcludeGlobs []string
+
+	// VerifyAfterEncrypt reads the archive back and checks the content of
+	// every entry, see verify.go. Callers deleting the originals set it
+	VerifyAfterEncrypt bool
+
+	// SplitSize writes the archive as volumes of at most this many bytes
+	// next to a manifest instead of one file, see split.go; 0 disables it
+	SplitSize int64
+
+	// IncludeManifest adds MANIFEST.json and MANIFEST.txt listing the
+	// files with their hashes and the findings of ManifestSource, see
+	// manifest.go
+	IncludeManifest bool
+	ManifestSource  *ManifestSource
 }
 
 // DefaultConfig returns a Config with sensible defaults
@@ -93,17 +136,41 @@ type Encryptor struct {
 	currentFile    string
 	cancelled      int32
 	filesEncrypted int32
+	fileErrors     []FileError // Files left out with ContinueOnError, under mu
+
+	excludes []excludeGlob // Compiled ExcludeGlobs
+	outputs  []os.FileInfo // The archive or its volumes being written, see isOutput
+
+	// Verification, see verify.go
+	hashes         []entryHash
+	verified       bool
+	verifyDuration time.Duration
+	beforeVerify   func(path string) error // Test hook run on the written archive
+
+	manifestFiles []ManifestFile // Files stored, with IncludeManifest
+
+	sevenZip      string   // Resolved SevenZipPath
+	sevenZipNames []string // Entries handed to 7-Zip, for verification
+	ageWorkFactor int      // Scrypt work factor when set, lowered in tests
 }
 
 // NewEncryptor creates a new Encryptor with the given config
 func NewEncryptor(config Config) (*Encryptor, error) {
-	if config.Password == "" {
+	recipients := config.Format == FormatAge && len(config.AgeRecipients) > 0
+	if config.Password == "" && !recipients {
 		return nil, ErrEmptyPassword
 	}
+	if recipients && config.VerifyAfterEncrypt {
+		// Only the holders of the private keys can read the archive back
+		return nil, errors.New("an archive for age recipients cannot be verified")
+	}
 
 	if config.OutputPath == "" {
 		return nil, ErrInvalidOutput
 	}
+	if config.SplitSize < 0 || (config.SplitSize > 0 && config.SplitSize < MinSplitSize) {
+		return nil, fmt.Errorf("split size must be at least %d bytes", MinSplitSize)
+	}
 
 	if config.BufferSize <= 0 {
 		config.BufferSize = 32 * 1024
@@ -116,9 +183,33 @@ func NewEncryptor(config Config) (*Encryptor, error) {
 		config.CompressionLevel = 9
 	}
 
-	return &Encryptor{
-		config: config,
-	}, nil
+	excludes, err := compileExcludes(config.ExcludeGlobs)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &Encryptor{
+		config:   config,
+		excludes: excludes,
+	}
+	switch config.Format {
+	case FormatZipAES:
+	case FormatSevenZip:
+		if e.sevenZip, err = findSevenZip(config.SevenZipPath); err != nil {
+			return nil, err
+		}
+		if !config.SevenZipPasswordArg {
+			return nil, ErrPasswordOnCommandLine
+		}
+	case FormatAge:
+		// Bad recipients fail here rather than after the output is created
+		if _, err := parseAgeRecipients(config.AgeRecipients); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, &ErrUnsupportedFormat{Ext: config.Format.String()}
+	}
+	return e, nil
 }
 
 // FileEntry represents a file to be encrypted
@@ -130,7 +221,26 @@ type FileEntry struct {
 	ArchivePath string
 }
 
-// EncryptFiles encrypts the given files into a password-protected ZIP archive
+// FileError is a file left out of the archive with ContinueOnError
+type FileError struct {
+	Path string
+	Err  error
+}
+
+func (e FileError) Error() string {
+	return e.Path + ": " + e.Err.Error()
+}
+
+func (e FileError) Unwrap() error {
+	return e.Err
+}
+
+// EncryptFiles encrypts the given files into an archive of the configured
+// format.
+// Directories are walked while the archive is written, so entries go out as
+// they are discovered and the progress total grows with the walk. Entries
+// are written in alphabetical order of their paths, so identical trees give
+// the same list of members
 func (e *Encryptor) EncryptFiles(files []FileEntry) error {
 	if len(files) == 0 {
 		return ErrNoFiles
@@ -140,74 +250,116 @@ func (e *Encryptor) EncryptFiles(files []FileEntry) error {
 	atomic.StoreInt32(&e.cancelled, 0)
 	atomic.StoreInt64(&e.bytesProcessed, 0)
 	atomic.StoreInt32(&e.filesEncrypted, 0)
-
-	// Calculate total size and validate files
+	e.mu.Lock()
+	e.fileErrors = nil
+	e.mu.Unlock()
+	e.hashes = nil
+	e.manifestFiles = nil
+	e.sevenZipNames = nil
+	e.verified = false
+	e.verifyDuration = 0
+
+	// Only the given paths are checked up front; the sizes of files in
+	// directories are added to the total as the walk reaches them
+	type source struct {
+		file FileEntry
+		info os.FileInfo
+	}
 	var totalSize int64
-	validFiles := make([]FileEntry, 0, len(files))
+	sources := make([]source, 0, len(files))
 
 	for _, file := range files {
-		info, err := os.Stat(file.SourcePath)
+		info, err := fsutil.Stat(file.SourcePath)
 		if err != nil {
-			if os.IsNotExist(err) {
-				return fmt.Errorf("%w: %s", ErrFileNotFound, file.SourcePath)
-			}
-			if os.IsPermission(err) {
-				return fmt.Errorf("%w: %s", ErrPermissionDenied, file.SourcePath)
-			}
-			return fmt.Errorf("failed to stat file %s: %w", file.SourcePath, err)
-		}
-
-		if info.IsDir() {
-			// Recursively add directory contents
-			dirFiles, dirSize, err := e.walkDirectory(file.SourcePath)
-			if err != nil {
+			if err := e.skipFile(file.SourcePath, pathError(err)); err != nil {
 				return err
 			}
-			validFiles = append(validFiles, dirFiles...)
-			totalSize += dirSize
-		} else {
+			continue
+		}
+		if !info.IsDir() {
 			totalSize += info.Size()
-			validFiles = append(validFiles, file)
 		}
+		sources = append(sources, source{file: file, info: info})
 	}
+	// Directories are walked in lexical order as well
+	sort.SliceStable(sources, func(i, j int) bool {
+		return sources[i].file.SourcePath < sources[j].file.SourcePath
+	})
 
-	if len(validFiles) == 0 {
-		return ErrNoFiles
+	if len(sources) == 0 {
+		return e.nothingEncrypted()
 	}
 
 	atomic.StoreInt64(&e.totalBytes, totalSize)
 
 	// Create output directory if needed
 	outputDir := filepath.Dir(e.config.OutputPath)
-	if err := os.MkdirAll(outputDir, 0755); err != nil {
-		return fmt.Errorf("failed to create output directory: %w", err)
+	if err := fsutil.MkdirAll(outputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", pathError(err))
 	}
 
-	// Create the ZIP file
-	zipFile, err := os.Create(e.config.OutputPath)
+	// Create the output file, or the first volume
+	output, err := e.createOutput()
 	if err != nil {
-		return fmt.Errorf("failed to create output file: %w", err)
+		return err
 	}
-	defer zipFile.Close()
+	defer func() { e.outputs = nil }()
 
-	zipWriter := zip.NewWriter(zipFile)
-	defer zipWriter.Close()
+	// Clean up the partial archive on cancellation or error
+	abort := func(err error) error {
+		output.remove()
+		return err
+	}
+
+	archive, err := e.newArchiveWriter(output)
+	if err != nil {
+		return abort(err)
+	}
 
 	// Process each file
-	for _, file := range validFiles {
+	for _, src := range sources {
 		if atomic.LoadInt32(&e.cancelled) == 1 {
-			// Clean up partial file on cancellation
-			zipWriter.Close()
-			zipFile.Close()
-			os.Remove(e.config.OutputPath)
-			return fmt.Errorf("encryption cancelled")
+			return abort(ErrCancelled)
 		}
 
-		if err := e.addFileToArchive(zipWriter, file); err != nil {
-			// Clean up on error
-			zipWriter.Close()
-			zipFile.Close()
-			os.Remove(e.config.OutputPath)
+		switch {
+		case src.info.IsDir():
+			err = e.archiveDirectory(archive, src.file.SourcePath)
+		case e.isOutput(src.info):
+			atomic.AddInt64(&e.totalBytes, -src.info.Size())
+			continue
+		default:
+			err = e.archiveFile(archive, src.file, src.info.Size())
+		}
+		if err != nil {
+			return abort(err)
+		}
+	}
+
+	if atomic.LoadInt32(&e.filesEncrypted) == 0 {
+		return abort(e.nothingEncrypted())
+	}
+	if e.config.IncludeManifest {
+		if err := e.writeManifest(archive); err != nil {
+			return abort(err)
+		}
+	}
+
+	// Write errors, such as a full disk, surface when the archive is
+	// finished
+	if err := archive.close(); err != nil {
+		if errors.Is(err, ErrCancelled) {
+			return abort(err)
+		}
+		return abort(fmt.Errorf("failed to finish archive: %w", err))
+	}
+	if err := output.finish(); err != nil {
+		return abort(fmt.Errorf("failed to write output file: %w", pathError(err)))
+	}
+
+	if e.config.VerifyAfterEncrypt {
+		if err := e.verifyArchive(); err != nil {
+			output.remove()
 			return err
 		}
 	}
@@ -215,52 +367,152 @@ func (e *Encryptor) EncryptFiles(files []FileEntry) error {
 	return nil
 }
 
+// createOutput creates the file the archive is written to, or the first
+// volume with SplitSize
+func (e *Encryptor) createOutput() (archiveOutput, error) {
+	if e.config.SplitSize > 0 {
+		output := newSplitOutput(e)
+		if err := output.nextPart(); err != nil {
+			return nil, err
+		}
+		return output, nil
+	}
+
+	outFile, err := fsutil.Create(e.config.OutputPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create output file: %w", pathError(err))
+	}
+	info, err := outFile.Stat()
+	if err != nil {
+		outFile.Close()
+		fsutil.Remove(e.config.OutputPath)
+		return nil, fmt.Errorf("failed to stat output file: %w", err)
+	}
+	e.outputs = []os.FileInfo{info}
+	return &fileOutput{File: outFile, path: e.config.OutputPath}, nil
+}
+
 // Cancel cancels an ongoing encryption operation
 func (e *Encryptor) Cancel() {
 	atomic.StoreInt32(&e.cancelled, 1)
 }
 
-// walkDirectory recursively collects files from a directory
-func (e *Encryptor) walkDirectory(dirPath string) ([]FileEntry, int64, error) {
-	var files []FileEntry
-	var totalSize int64
+// Errors returns the files left out of the last archive with
+// ContinueOnError
+func (e *Encryptor) Errors() []FileError {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	errs := make([]FileError, len(e.fileErrors))
+	copy(errs, e.fileErrors)
+	return errs
+}
+
+// skipFile records a file that cannot be read with ContinueOnError and
+// returns nil; otherwise it returns err
+func (e *Encryptor) skipFile(path string, err error) error {
+	if !e.config.ContinueOnError {
+		return err
+	}
+	e.mu.Lock()
+	e.fileErrors = append(e.fileErrors, FileError{Path: path, Err: err})
+	e.mu.Unlock()
+	return nil
+}
+
+// nothingEncrypted is the error of a run that archived no files: the
+// first skipped file when there is one, so the cause is not lost
+func (e *Encryptor) nothingEncrypted() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if len(e.fileErrors) > 0 {
+		return fmt.Errorf("%w: %w", ErrNoFiles, e.fileErrors[0])
+	}
+	return ErrNoFiles
+}
+
+// archiveDirectory walks a directory and adds its files to the archive
+// as they are found
+func (e *Encryptor) archiveDirectory(archive archiveWriter, dirPath string) error {
+	return fsutil.Walk(dirPath, func(path string, info os.FileInfo, err error) error {
+		if atomic.LoadInt32(&e.cancelled) == 1 {
+			return ErrCancelled
+		}
 
-	err := filepath.Walk(dirPath, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
-			return err
+			if err := e.skipFile(path, pathError(err)); err != nil {
+				return err
+			}
+			if info != nil && info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
 		}
 
-		if !info.IsDir() {
-			relPath, err := filepath.Rel(dirPath, path)
-			if err != nil {
-				relPath = filepath.Base(path)
+		relPath, err := filepath.Rel(dirPath, path)
+		if err != nil {
+			relPath = filepath.Base(path)
+		}
+
+		if path != dirPath && e.excluded(relPath) {
+			if info.IsDir() {
+				return filepath.SkipDir
 			}
+			return nil
+		}
 
-			files = append(files, FileEntry{
-				SourcePath:  path,
-				ArchivePath: filepath.Join(filepath.Base(dirPath), relPath),
-			})
-			totalSize += info.Size()
+		if info.IsDir() || e.isOutput(info) {
+			return nil
 		}
 
-		return nil
+		atomic.AddInt64(&e.totalBytes, info.Size())
+		return e.archiveFile(archive, FileEntry{
+			SourcePath:  path,
+			ArchivePath: filepath.Join(filepath.Base(dirPath), relPath),
+		}, info.Size())
 	})
-
-	return files, totalSize, err
 }
 
-// addFileToArchive adds a single file to the ZIP archive
-func (e *Encryptor) addFileToArchive(zipWriter *zip.Writer, file FileEntry) error {
-	// Open source file
-	srcFile, err := os.Open(file.SourcePath)
+// archiveFile opens a file already counted in the total with size bytes
+// and adds it to the archive. A file that cannot be opened is left out of
+// the total and, with ContinueOnError, recorded instead of failing the run
+func (e *Encryptor) archiveFile(archive archiveWriter, file FileEntry, size int64) error {
+	srcFile, err := fsutil.Open(file.SourcePath)
 	if err != nil {
-		if os.IsPermission(err) {
-			return fmt.Errorf("%w: %s", ErrPermissionDenied, file.SourcePath)
-		}
-		return fmt.Errorf("failed to open file %s: %w", file.SourcePath, err)
+		atomic.AddInt64(&e.totalBytes, -size)
+		return e.skipFile(file.SourcePath, pathError(err))
 	}
 	defer srcFile.Close()
 
+	// A walked symlink was counted with the size of the link
+	info, err := srcFile.Stat()
+	if err != nil {
+		atomic.AddInt64(&e.totalBytes, -size)
+		return e.skipFile(file.SourcePath, pathError(err))
+	}
+	atomic.AddInt64(&e.totalBytes, info.Size()-size)
+
+	if fa, ok := archive.(fileArchiver); ok {
+		if e.config.IncludeManifest {
+			// 7-Zip reads the file itself, the manifest needs its hash now
+			sum := sha256.New()
+			n, err := io.Copy(sum, srcFile)
+			if err != nil {
+				return fmt.Errorf("failed to read file %s: %w", file.SourcePath, err)
+			}
+			e.recordManifestFile(file.SourcePath, e.archivePath(file), n, sum)
+		}
+		if err := fa.add(file.SourcePath, e.archivePath(file), info); err != nil {
+			return err
+		}
+		atomic.AddInt32(&e.filesEncrypted, 1)
+		return nil
+	}
+	return e.addFileToArchive(archive, file, srcFile, info)
+}
+
+// archivePath returns the path of a file within the archive, with forward
+// slashes
+func (e *Encryptor) archivePath(file FileEntry) string {
 	// Determine archive path
 	archivePath := file.ArchivePath
 	if archivePath == "" {
@@ -276,25 +528,38 @@ func (e *Encryptor) addFileToArchive(zipWriter *zip.Writer, file FileEntry) erro
 		}
 	}
 
-	// Normalize path separators for ZIP
-	archivePath = strings.ReplaceAll(archivePath, string(os.PathSeparator), "/")
+	// Normalize path separators for the archive
+	return strings.ReplaceAll(archivePath, string(os.PathSeparator), "/")
+}
+
+// addFileToArchive adds a single file to the archive. Once the entry is
+// created a read error fails the run even with ContinueOnError: the entry
+// cannot be taken back and would be truncated
+func (e *Encryptor) addFileToArchive(archive archiveWriter, file FileEntry, srcFile io.Reader, info os.FileInfo) error {
+	archivePath := e.archivePath(file)
 
 	// Update current file for progress reporting
 	e.currentFile = archivePath
 	e.reportProgress()
 
 	// Create encrypted writer for this file
-	// The alexmullins/zip library uses AES-256 encryption by default
-	writer, err := zipWriter.Encrypt(archivePath, e.config.Password)
+	writer, err := archive.create(archivePath, info)
 	if err != nil {
 		return fmt.Errorf("failed to create encrypted archive entry for %s: %w", file.SourcePath, err)
 	}
 
+	// Hash the content for verification and the manifest
+	var contentHash hash.Hash
+	if e.config.VerifyAfterEncrypt || e.config.IncludeManifest {
+		contentHash = sha256.New()
+	}
+	var written int64
+
 	// Copy file content with progress tracking
 	buf := make([]byte, e.config.BufferSize)
 	for {
 		if atomic.LoadInt32(&e.cancelled) == 1 {
-			return fmt.Errorf("encryption cancelled")
+			return ErrCancelled
 		}
 
 		n, readErr := srcFile.Read(buf)
@@ -303,6 +568,10 @@ func (e *Encryptor) addFileToArchive(zipWriter *zip.Writer, file FileEntry) erro
 			if writeErr != nil {
 				return fmt.Errorf("failed to write to archive: %w", writeErr)
 			}
+			if contentHash != nil {
+				contentHash.Write(buf[:n])
+			}
+			written += int64(n)
 
 			atomic.AddInt64(&e.bytesProcessed, int64(n))
 			e.reportProgress()
@@ -316,6 +585,15 @@ func (e *Encryptor) addFileToArchive(zipWriter *zip.Writer, file FileEntry) erro
 		}
 	}
 
+	if e.config.VerifyAfterEncrypt {
+		entry := entryHash{name: archivePath}
+		contentHash.Sum(entry.sum[:0])
+		e.hashes = append(e.hashes, entry)
+	}
+	if e.config.IncludeManifest {
+		e.recordManifestFile(file.SourcePath, archivePath, written, contentHash)
+	}
+
 	// Increment files encrypted counter
 	atomic.AddInt32(&e.filesEncrypted, 1)
 
@@ -386,138 +664,6 @@ func GenerateAlphanumericPassword(length int) (string, error) {
 	return string(password), nil
 }
 
-// ValidatePassword checks if a password meets minimum security requirements
-func ValidatePassword(password string) error {
-	if password == "" {
-		return ErrEmptyPassword
-	}
-	if len(password) < 4 {
-		return errors.New("password must be at least 4 characters")
-	}
-	return nil
-}
-
-// SecureDelete securely deletes a file by overwriting it multiple times
-// before removing it. This provides better protection than simple deletion.
-// passes: number of overwrite passes (recommended: 3)
-func SecureDelete(filePath string, passes int) error {
-	if passes < 1 {
-		passes = 1
-	}
-	if passes > 10 {
-		passes = 10
-	}
-
-	// Open file for writing
-	file, err := os.OpenFile(filePath, os.O_WRONLY, 0)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return nil // File doesn't exist, nothing to delete
-		}
-		return fmt.Errorf("failed to open file for secure deletion: %w", err)
-	}
-
-	// Get file size
-	info, err := file.Stat()
-	if err != nil {
-		file.Close()
-		return fmt.Errorf("failed to stat file: %w", err)
-	}
-	size := info.Size()
-
-	if size == 0 {
-		file.Close()
-		return os.Remove(filePath)
-	}
-
-	// Prepare buffer
-	bufSize := int64(32 * 1024)
-	if size < bufSize {
-		bufSize = size
-	}
-	buf := make([]byte, bufSize)
-
-	// Perform overwrite passes
-	for pass := 0; pass < passes; pass++ {
-		// Seek to beginning
-		if _, err := file.Seek(0, 0); err != nil {
-			file.Close()
-			return fmt.Errorf("failed to seek: %w", err)
-		}
-
-		// Determine pattern for this pass
-		var pattern byte
-		switch pass % 3 {
-		case 0:
-			pattern = 0x00 // Zeros
-		case 1:
-			pattern = 0xFF // Ones
-		case 2:
-			// Random data
-			if _, err := rand.Read(buf); err != nil {
-				file.Close()
-				return fmt.Errorf("failed to generate random data: %w", err)
-			}
-		}
-
-		// Fill buffer with pattern (if not random)
-		if pass%3 != 2 {
-			for i := range buf {
-				buf[i] = pattern
-			}
-		}
-
-		// Write pattern to entire file
-		remaining := size
-		for remaining > 0 {
-			writeSize := bufSize
-			if remaining < bufSize {
-				writeSize = remaining
-			}
-
-			if pass%3 == 2 && writeSize < bufSize {
-				// Generate fresh random data for last chunk
-				if _, err := rand.Read(buf[:writeSize]); err != nil {
-					file.Close()
-					return fmt.Errorf("failed to generate random data: %w", err)
-				}
-			}
-
-			if _, err := file.Write(buf[:writeSize]); err != nil {
-				file.Close()
-				return fmt.Errorf("failed to overwrite file: %w", err)
-			}
-
-			remaining -= writeSize
-		}
-
-		// Sync to disk
-		if err := file.Sync(); err != nil {
-			file.Close()
-			return fmt.Errorf("failed to sync file: %w", err)
-		}
-	}
-
-	file.Close()
-
-	// Finally remove the file
-	return os.Remove(filePath)
-}
-
-// SecureDeleteMultiple securely deletes multiple files
-func SecureDeleteMultiple(filePaths []string, passes int, onProgress func(current int, total int, path string)) error {
-	total := len(filePaths)
-	for i, path := range filePaths {
-		if onProgress != nil {
-			onProgress(i+1, total, path)
-		}
-		if err := SecureDelete(path, passes); err != nil {
-			return fmt.Errorf("failed to securely delete %s: %w", path, err)
-		}
-	}
-	return nil
-}
-
 // Result contains the result of an encryption operation
 type Result struct {
 	// OutputPath is the path to the created archive
@@ -529,11 +675,24 @@ type Result struct {
 	// TotalSize is the total uncompressed size of encrypted files
 	TotalSize int64
 
-	// ArchiveSize is the size of the resulting archive
+	// ArchiveSize is the size of the resulting archive, all of its volumes
+	// with SplitSize
 	ArchiveSize int64
 
+	// Parts are the volumes written with SplitSize, in order; their
+	// manifest is OutputPath + SplitManifestSuffix
+	Parts []string
+
 	// CompressionRatio is the compression ratio (archive size / total size)
 	CompressionRatio float64
+
+	// Errors lists the files left out with ContinueOnError
+	Errors []FileError
+
+	// Verified is true when VerifyAfterEncrypt read every entry back
+	// intact, which took VerifyDuration
+	Verified       bool
+	VerifyDuration time.Duration
 }
 
 // EncryptFilesWithResult encrypts files and returns detailed result
@@ -543,13 +702,26 @@ func (e *Encryptor) EncryptFilesWithResult(files []FileEntry) (*Result, error) {
 	}
 
 	// Get archive info
-	archiveInfo, err := os.Stat(e.config.OutputPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to stat output archive: %w", err)
+	var archiveSize int64
+	var parts []string
+	if e.config.SplitSize > 0 {
+		m, err := LoadSplit(e.config.OutputPath)
+		if err != nil || m == nil {
+			return nil, fmt.Errorf("failed to read split manifest: %w", err)
+		}
+		archiveSize = m.TotalSize
+		for _, part := range m.Parts {
+			parts = append(parts, m.partPath(part))
+		}
+	} else {
+		archiveInfo, err := fsutil.Stat(e.config.OutputPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat output archive: %w", err)
+		}
+		archiveSize = archiveInfo.Size()
 	}
 
 	totalSize := atomic.LoadInt64(&e.totalBytes)
-	archiveSize := archiveInfo.Size()
 	filesCount := int(atomic.LoadInt32(&e.filesEncrypted))
 
 	var ratio float64
@@ -562,7 +734,11 @@ func (e *Encryptor) EncryptFilesWithResult(files []FileEntry) (*Result, error) {
 		FilesEncrypted:   filesCount,
 		TotalSize:        totalSize,
 		ArchiveSize:      archiveSize,
+		Parts:            parts,
 		CompressionRatio: ratio,
+		Errors:           e.Errors(),
+		Verified:         e.verified,
+		VerifyDuration:   e.verifyDuration,
 	}, nil
 }
 
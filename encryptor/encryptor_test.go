@@ -3,6 +3,7 @@ package encryptor
 import (
 	"bytes"
 	"crypto/rand"
+	"errors"
 	"io"
 	"os"
 	"path/filepath"
@@ -445,6 +446,115 @@ func TestPermissionErrors(t *testing.T) {
 	}
 }
 
+// unreadableTree creates a directory with two readable files and one that
+// cannot be opened: without read permission, or a dangling symlink when
+// running as root
+func unreadableTree(t *testing.T) (dir, unreadable string) {
+	t.Helper()
+	dir = filepath.Join(t.TempDir(), "tree")
+	createTestFile(t, dir, "a.txt", "first")
+	createTestFile(t, dir, "sub/b.txt", "second")
+
+	if os.Getuid() == 0 {
+		unreadable = filepath.Join(dir, "sub", "broken.txt")
+		if err := os.Symlink(filepath.Join(dir, "missing.txt"), unreadable); err != nil {
+			t.Skipf("Symlinks not supported: %v", err)
+		}
+		return dir, unreadable
+	}
+	unreadable = createTestFile(t, dir, "noperm.txt", "content")
+	if err := os.Chmod(unreadable, 0000); err != nil {
+		t.Fatalf("Failed to change permissions: %v", err)
+	}
+	t.Cleanup(func() { os.Chmod(unreadable, 0644) })
+	return dir, unreadable
+}
+
+// TestContinueOnError tests that an unreadable file is reported and the
+// rest of the tree is archived
+func TestContinueOnError(t *testing.T) {
+	dir, unreadable := unreadableTree(t)
+
+	config := DefaultConfig()
+	config.Password = "TestPassword!"
+	config.OutputPath = filepath.Join(t.TempDir(), "test.zip")
+	config.ContinueOnError = true
+
+	enc, err := NewEncryptor(config)
+	if err != nil {
+		t.Fatalf("Failed to create encryptor: %v", err)
+	}
+
+	result, err := enc.EncryptFilesWithResult([]FileEntry{{SourcePath: dir}})
+	if err != nil {
+		t.Fatalf("Encryption failed: %v", err)
+	}
+
+	if len(result.Errors) != 1 {
+		t.Fatalf("Expected 1 error, got %v", result.Errors)
+	}
+	if result.Errors[0].Path != unreadable || result.Errors[0].Err == nil {
+		t.Errorf("Unexpected error %+v", result.Errors[0])
+	}
+	if result.FilesEncrypted != 2 || result.TotalSize != int64(len("first")+len("second")) {
+		t.Errorf("Encrypted %d files of %d bytes", result.FilesEncrypted, result.TotalSize)
+	}
+
+	names := verifyZIPWithPassword(t, config.OutputPath, config.Password)
+	if strings.Join(names, ",") != "tree/a.txt,tree/sub/b.txt" {
+		t.Errorf("Archive entries %v", names)
+	}
+}
+
+// TestStrictStopsOnUnreadableFile tests that by default an unreadable file
+// fails the run and leaves no archive
+func TestStrictStopsOnUnreadableFile(t *testing.T) {
+	dir, _ := unreadableTree(t)
+
+	config := DefaultConfig()
+	config.Password = "TestPassword!"
+	config.OutputPath = filepath.Join(t.TempDir(), "test.zip")
+
+	enc, err := NewEncryptor(config)
+	if err != nil {
+		t.Fatalf("Failed to create encryptor: %v", err)
+	}
+
+	if err := enc.EncryptFiles([]FileEntry{{SourcePath: dir}}); err == nil {
+		t.Fatal("Expected an error for the unreadable file")
+	}
+	if _, err := os.Stat(config.OutputPath); !os.IsNotExist(err) {
+		t.Error("Partial archive should be removed")
+	}
+	if errs := enc.Errors(); len(errs) != 0 {
+		t.Errorf("Strict run recorded errors %v", errs)
+	}
+}
+
+// TestContinueOnErrorNothingReadable tests that a run archiving no file
+// fails with the cause of the first skipped file
+func TestContinueOnErrorNothingReadable(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	config := DefaultConfig()
+	config.Password = "TestPassword!"
+	config.OutputPath = filepath.Join(tmpDir, "test.zip")
+	config.ContinueOnError = true
+
+	enc, err := NewEncryptor(config)
+	if err != nil {
+		t.Fatalf("Failed to create encryptor: %v", err)
+	}
+
+	err = enc.EncryptFiles([]FileEntry{{SourcePath: filepath.Join(tmpDir, "missing.txt")}})
+	if !errors.Is(err, ErrNoFiles) || !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNoFiles caused by ErrNotFound, got %v", err)
+	}
+	if _, err := os.Stat(config.OutputPath); !os.IsNotExist(err) {
+		t.Error("No archive should be written")
+	}
+}
+
 // TestInvalidOutputPath tests handling of invalid output path
 func TestInvalidOutputPath(t *testing.T) {
 	config := DefaultConfig()
@@ -673,8 +783,8 @@ func TestGenerateAlphanumericPassword(t *testing.T) {
 	}
 }
 
-// TestValidatePassword tests password validation
-func TestValidatePassword(t *testing.T) {
+// TestValidatePasswordLegacy tests the length-only password validation
+func TestValidatePasswordLegacy(t *testing.T) {
 	tests := []struct {
 		password string
 		wantErr  bool
@@ -687,9 +797,9 @@ func TestValidatePassword(t *testing.T) {
 	}
 
 	for _, tt := range tests {
-		err := ValidatePassword(tt.password)
+		err := ValidatePasswordLegacy(tt.password)
 		if (err != nil) != tt.wantErr {
-			t.Errorf("ValidatePassword(%q) error = %v, wantErr %v", tt.password, err, tt.wantErr)
+			t.Errorf("ValidatePasswordLegacy(%q) error = %v, wantErr %v", tt.password, err, tt.wantErr)
 		}
 	}
 }
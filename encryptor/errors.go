@@ -0,0 +1,60 @@
+package encryptor
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+)
+
+// Errors shared with the searcher package. They are wrapped with the
+// offending path, so compare them with errors.Is
+var (
+	ErrNotFound   = errors.New("file not found")
+	ErrPermission = errors.New("permission denied")
+	ErrTooLarge   = errors.New("file too large")
+	ErrCancelled  = errors.New("operation cancelled")
+
+	// ErrVerifyFailed is matched by a VerifyError
+	ErrVerifyFailed = errors.New("archive verification failed")
+
+	// ErrPasswordOnCommandLine is returned for a 7z archive unless the
+	// configuration allows 7-Zip to take the password as an argument
+	ErrPasswordOnCommandLine = errors.New("7-Zip would take the password as a command line argument, which other users of the system can see")
+
+	// Older names kept for existing callers
+	ErrFileNotFound     = ErrNotFound
+	ErrPermissionDenied = ErrPermission
+)
+
+// ErrDependencyMissing is returned when an external archiver needed for
+// the requested output is not installed
+type ErrDependencyMissing struct {
+	Name string
+}
+
+func (e *ErrDependencyMissing) Error() string {
+	return fmt.Sprintf("%s is not installed", e.Name)
+}
+
+// ErrUnsupportedFormat is returned for an output format the encryptor
+// cannot write
+type ErrUnsupportedFormat struct {
+	Ext string
+}
+
+func (e *ErrUnsupportedFormat) Error() string {
+	return fmt.Sprintf("unsupported format: %s", e.Ext)
+}
+
+// pathError classifies a file system error. The original error stays in
+// the chain, so fs.ErrNotExist and fs.ErrPermission still match
+func pathError(err error) error {
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return fmt.Errorf("%w: %w", ErrNotFound, err)
+	case errors.Is(err, fs.ErrPermission):
+		return fmt.Errorf("%w: %w", ErrPermission, err)
+	default:
+		return err
+	}
+}
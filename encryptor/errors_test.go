@@ -0,0 +1,101 @@
+package encryptor
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEncryptMissingFile(t *testing.T) {
+	dir := t.TempDir()
+	config := DefaultConfig()
+	config.Password = "test-password"
+	config.OutputPath = filepath.Join(dir, "out.zip")
+	enc, err := NewEncryptor(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = enc.EncryptFiles([]FileEntry{{SourcePath: filepath.Join(dir, "missing.txt")}})
+	if !errors.Is(err, ErrNotFound) || !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("error = %v, want ErrNotFound wrapping fs.ErrNotExist", err)
+	}
+	// The old name still matches
+	if !errors.Is(err, ErrFileNotFound) {
+		t.Errorf("error = %v, want ErrFileNotFound", err)
+	}
+}
+
+func TestEncryptUnreadableFile(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("root ignores file permissions")
+	}
+	dir := t.TempDir()
+	locked := filepath.Join(dir, "locked.txt")
+	if err := os.WriteFile(locked, []byte("secret"), 0); err != nil {
+		t.Fatal(err)
+	}
+
+	config := DefaultConfig()
+	config.Password = "test-password"
+	config.OutputPath = filepath.Join(dir, "out.zip")
+	enc, _ := NewEncryptor(config)
+
+	err := enc.EncryptFiles([]FileEntry{{SourcePath: locked}})
+	if !errors.Is(err, ErrPermission) || !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("error = %v, want ErrPermission", err)
+	}
+}
+
+func TestEncryptCancelled(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "a.txt")
+	os.WriteFile(source, []byte("data"), 0644)
+
+	config := DefaultConfig()
+	config.Password = "test-password"
+	config.OutputPath = filepath.Join(dir, "out.zip")
+	var enc *Encryptor
+	// Cancel as soon as the first file starts
+	config.OnProgress = func(_, _ int64, _ string) { enc.Cancel() }
+	enc, _ = NewEncryptor(config)
+
+	err := enc.EncryptFiles([]FileEntry{{SourcePath: source}, {SourcePath: source, ArchivePath: "b.txt"}})
+	if !errors.Is(err, ErrCancelled) {
+		t.Errorf("error = %v, want ErrCancelled", err)
+	}
+	if _, statErr := os.Stat(config.OutputPath); !os.IsNotExist(statErr) {
+		t.Error("partial archive should be removed")
+	}
+}
+
+func TestTypedErrorMessages(t *testing.T) {
+	var dep error = &ErrDependencyMissing{Name: "7z"}
+	var format error = &ErrUnsupportedFormat{Ext: ".rar"}
+	if dep.Error() != "7z is not installed" || format.Error() != "unsupported format: .rar" {
+		t.Errorf("messages: %q, %q", dep, format)
+	}
+}
+
+func TestErrorsNameFailingPath(t *testing.T) {
+	dir := t.TempDir()
+	missing := filepath.Join(dir, "nested", "missing.txt")
+	config := DefaultConfig()
+	config.Password = "test-password"
+	config.OutputPath = filepath.Join(dir, "out.zip")
+	enc, err := NewEncryptor(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.EncryptFiles([]FileEntry{{SourcePath: missing}}); err == nil || !strings.Contains(err.Error(), missing) {
+		t.Errorf("EncryptFiles error %v does not name %s", err, missing)
+	}
+
+	// A directory cannot be opened for writing
+	if err := SecureDelete(dir, 1); err == nil || !strings.Contains(err.Error(), dir) {
+		t.Errorf("SecureDelete error %v does not name %s", err, dir)
+	}
+}
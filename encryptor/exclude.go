@@ -0,0 +1,129 @@
+package encryptor
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ExcludeGlobs leave parts of a directory out of the archive. They follow
+// the -exclude patterns of the scan command and are matched against paths
+// relative to the directory given in FileEntry, with forward slashes:
+//
+//   - "*" matches within one path segment, "**" matches any number of
+//     directories, so "**/.git" is every .git directory and "**/*.log"
+//     every log file
+//   - patterns are anchored at the directory: "*.log" is only its own files
+//   - an excluded directory is skipped with everything in it, and a
+//     trailing slash, as in "build/", is the same as "build/**"
+//
+// Files given directly in FileEntry are not matched. The output archive is
+// always left out when it is written inside a source directory
+
+// excludeGlob is a compiled ExcludeGlobs pattern, split at slashes
+type excludeGlob []string
+
+// compileExcludes checks and splits patterns; empty patterns are dropped
+func compileExcludes(patterns []string) ([]excludeGlob, error) {
+	var globs []excludeGlob
+	for _, pattern := range patterns {
+		p := strings.TrimSpace(filepath.ToSlash(pattern))
+		p = strings.TrimPrefix(strings.TrimPrefix(p, "./"), "/")
+		if p == "" {
+			continue
+		}
+		if strings.HasSuffix(p, "/") {
+			p += "**"
+		}
+		var glob excludeGlob
+		for _, segment := range strings.Split(p, "/") {
+			if segment == "" {
+				continue
+			}
+			if segment != "**" {
+				// "**" inside a segment is an ordinary star
+				for strings.Contains(segment, "**") {
+					segment = strings.ReplaceAll(segment, "**", "*")
+				}
+				if _, err := path.Match(segment, ""); err != nil {
+					return nil, fmt.Errorf("invalid exclude pattern %q: %w", pattern, err)
+				}
+			}
+			glob = append(glob, segment)
+		}
+		globs = append(globs, glob)
+	}
+	return globs, nil
+}
+
+// ValidateExcludeGlobs checks ExcludeGlobs before a run
+func ValidateExcludeGlobs(patterns []string) error {
+	_, err := compileExcludes(patterns)
+	return err
+}
+
+// matchSegments matches path segments, "**" standing for any number of
+// them
+func matchSegments(pattern, parts []string) bool {
+	if len(pattern) == 0 {
+		return len(parts) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(parts); i++ {
+			if matchSegments(pattern[1:], parts[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(parts) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pattern[0], parts[0]); !ok {
+		return false
+	}
+	return matchSegments(pattern[1:], parts[1:])
+}
+
+// Excluded reports whether path inside the source directory dirPath is
+// left out of the archive by ExcludeGlobs, so callers walking the same tree,
+// such as deleting the originals, skip the same files
+func (e *Encryptor) Excluded(dirPath, path string) bool {
+	if path == dirPath {
+		return false
+	}
+	relPath, err := filepath.Rel(dirPath, path)
+	if err != nil {
+		return false
+	}
+	return e.excluded(relPath)
+}
+
+// excluded reports whether a path relative to a source directory matches
+// one of the ExcludeGlobs
+func (e *Encryptor) excluded(relPath string) bool {
+	if len(e.excludes) == 0 {
+		return false
+	}
+	parts := strings.Split(filepath.ToSlash(relPath), "/")
+	for _, glob := range e.excludes {
+		if matchSegments(glob, parts) {
+			return true
+		}
+	}
+	return false
+}
+
+// isOutput reports whether a file is the archive being written, or one of
+// its volumes. The name is compared first, as os.SameFile may have to open
+// the file on Windows
+func (e *Encryptor) isOutput(info os.FileInfo) bool {
+	for _, output := range e.outputs {
+		if info.Name() == output.Name() && os.SameFile(info, output) {
+			return true
+		}
+	}
+	return false
+}
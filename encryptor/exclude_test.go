@@ -0,0 +1,99 @@
+package encryptor
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExcludeGlobs(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"**/.git", ".git", true},
+		{"**/.git", "vendor/lib/.git", true},
+		{"*.log", "app.log", true},
+		{"*.log", "logs/app.log", false},
+		{"**/*.log", "logs/app.log", true},
+		{"**/node_modules/", "web/node_modules", true},
+		{"build/", "build", true},
+		{"build/**", "build/out/app", true},
+		{"build/**", "src/build/app", false},
+		{"/docs/*.pdf", "docs/a.pdf", true},
+		{"docs/*.pdf", "docs/sub/a.pdf", false},
+		{"src/**/gen", "src/a/b/gen", true},
+		{"src/a**", "src/abc", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.path, func(t *testing.T) {
+			excludes, err := compileExcludes([]string{tt.pattern})
+			if err != nil {
+				t.Fatal(err)
+			}
+			e := &Encryptor{excludes: excludes}
+			if got := e.excluded(filepath.FromSlash(tt.path)); got != tt.want {
+				t.Errorf("excluded = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	config := DefaultConfig()
+	config.Password = "TestPassword!"
+	config.OutputPath = "out.zip"
+	config.ExcludeGlobs = []string{"[a-"}
+	if _, err := NewEncryptor(config); err == nil {
+		t.Error("Expected an error for a malformed pattern")
+	}
+}
+
+// TestEncryptOutputInsideSource tests that the archive written into the
+// tree it encrypts is left out and that the order of members is stable
+func TestEncryptOutputInsideSource(t *testing.T) {
+	root := t.TempDir()
+	src := filepath.Join(root, "project")
+	other := filepath.Join(root, "notes")
+	createTestFile(t, src, "main.go", "package main")
+	createTestFile(t, src, "b/config.env", "KEY=1")
+	createTestFile(t, src, "a/readme.md", "# readme")
+	createTestFile(t, src, ".git/HEAD", "ref: refs/heads/main")
+	createTestFile(t, src, "web/node_modules/x/index.js", "module.exports = 1")
+	createTestFile(t, src, "debug.log", "trace")
+	createTestFile(t, other, "todo.txt", "todo")
+
+	encrypt := func(entries []FileEntry) []string {
+		t.Helper()
+		config := DefaultConfig()
+		config.Password = "TestPassword!"
+		config.OutputPath = filepath.Join(src, "backup.zip")
+		config.ExcludeGlobs = []string{"**/.git", "**/node_modules", "*.log"}
+
+		enc, err := NewEncryptor(config)
+		if err != nil {
+			t.Fatalf("Failed to create encryptor: %v", err)
+		}
+		if err := enc.EncryptFiles(entries); err != nil {
+			t.Fatalf("Encryption failed: %v", err)
+		}
+		return verifyZIPWithPassword(t, config.OutputPath, config.Password)
+	}
+
+	first := encrypt([]FileEntry{{SourcePath: src}, {SourcePath: other}})
+	want := []string{"notes/todo.txt", "project/a/readme.md", "project/b/config.env", "project/main.go"}
+	if !reflect.DeepEqual(first, want) {
+		t.Errorf("Archive entries %v, want %v", first, want)
+	}
+	for _, name := range first {
+		if strings.HasSuffix(name, "backup.zip") {
+			t.Errorf("Archive includes itself as %s", name)
+		}
+	}
+
+	// The second run finds the first archive in the tree
+	second := encrypt([]FileEntry{{SourcePath: other}, {SourcePath: src}})
+	if !reflect.DeepEqual(second, first) {
+		t.Errorf("Second run entries %v, want %v", second, first)
+	}
+}
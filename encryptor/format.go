@@ -0,0 +1,267 @@
+package encryptor
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"github.com/alexmullins/zip"
+)
+
+// AES ZIP opens in WinZip and 7-Zip but not in every unpacker, so the
+// encryptor writes two more formats:
+//
+//   - FormatSevenZip: a 7z archive with AES-256 and encrypted file names,
+//     written by the 7-Zip command line tool (7zz, 7z or 7za), which has to
+//     be installed. The password is passed to it as an argument, so other
+//     users of the machine can see it in the process list while it runs
+//   - FormatAge: a tar stream encrypted with age (https://age-encryption.org),
+//     either with the password (scrypt) or for X25519 public keys. It is
+//     written in Go and needs nothing installed
+//
+// Decryption tells the formats apart by their first bytes, see DetectFormat
+
+// OutputFormat is the kind of archive the encryptor writes
+type OutputFormat int
+
+const (
+	// FormatZipAES is a ZIP archive with AES-256 encrypted entries (default)
+	FormatZipAES OutputFormat = iota
+	// FormatSevenZip is a 7z archive with AES-256, written by 7-Zip
+	FormatSevenZip
+	// FormatAge is a tar stream encrypted with age
+	FormatAge
+)
+
+// OutputFormats lists the formats in the order they are offered
+var OutputFormats = []OutputFormat{FormatZipAES, FormatSevenZip, FormatAge}
+
+// String returns the name of the format used by ParseOutputFormat
+func (f OutputFormat) String() string {
+	switch f {
+	case FormatZipAES:
+		return "zip"
+	case FormatSevenZip:
+		return "7z"
+	case FormatAge:
+		return "age"
+	}
+	return fmt.Sprintf("OutputFormat(%d)", int(f))
+}
+
+// Ext returns the file extension of archives in the format
+func (f OutputFormat) Ext() string {
+	switch f {
+	case FormatSevenZip:
+		return ".7z"
+	case FormatAge:
+		return ".tar.age"
+	}
+	return ".zip"
+}
+
+// ParseOutputFormat returns the format with the given name, as returned by
+// String
+func ParseOutputFormat(name string) (OutputFormat, error) {
+	for _, f := range OutputFormats {
+		if strings.EqualFold(strings.TrimSpace(name), f.String()) {
+			return f, nil
+		}
+	}
+	return FormatZipAES, &ErrUnsupportedFormat{Ext: name}
+}
+
+// Magic bytes at the start of each format
+var (
+	zipMagic      = []byte("PK\x03\x04")
+	zipEmptyMagic = []byte("PK\x05\x06")
+	sevenZipMagic = []byte("7z\xBC\xAF\x27\x1C")
+	ageMagic      = []byte("age-encryption.org/v1")
+	ageArmorMagic = []byte(armor.Header)
+)
+
+// DetectFormat tells the format of an archive from its first bytes
+func DetectFormat(path string) (OutputFormat, error) {
+	f, err := openSource(path)
+	if err != nil {
+		return FormatZipAES, err
+	}
+	defer f.Close()
+
+	head := make([]byte, len(ageArmorMagic))
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return FormatZipAES, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	head = head[:n]
+
+	switch {
+	case bytes.HasPrefix(head, zipMagic), bytes.HasPrefix(head, zipEmptyMagic):
+		return FormatZipAES, nil
+	case bytes.HasPrefix(head, sevenZipMagic):
+		return FormatSevenZip, nil
+	case bytes.HasPrefix(head, ageMagic), bytes.HasPrefix(head, ageArmorMagic):
+		return FormatAge, nil
+	}
+	return FormatZipAES, fmt.Errorf("%w: %s: unknown archive format", ErrInvalidSource, path)
+}
+
+// archiveWriter writes the entries of an archive in one format
+type archiveWriter interface {
+	// create starts an entry; its content is written to the returned writer
+	// before the next call
+	create(name string, info os.FileInfo) (io.Writer, error)
+
+	// close finishes the archive; write errors, such as a full disk,
+	// surface here
+	close() error
+}
+
+// fileArchiver is an archiveWriter that reads the files itself, such as the
+// 7-Zip tool. add is called instead of create and the files are read on
+// close
+type fileArchiver interface {
+	archiveWriter
+	add(path, name string, info os.FileInfo) error
+	// addData stores data under name, such as the manifest
+	addData(name string, data []byte) error
+}
+
+// newArchiveWriter returns the writer of the configured format
+func (e *Encryptor) newArchiveWriter(out io.Writer) (archiveWriter, error) {
+	switch e.config.Format {
+	case FormatZipAES:
+		return &zipArchive{zip: zip.NewWriter(out), password: e.config.Password}, nil
+	case FormatAge:
+		return newAgeArchive(out, e.config.Password, e.config.AgeRecipients, e.ageWorkFactor)
+	case FormatSevenZip:
+		return e.newSevenZipArchive(out)
+	}
+	return nil, &ErrUnsupportedFormat{Ext: e.config.Format.String()}
+}
+
+// zipArchive writes an AES-256 encrypted ZIP
+type zipArchive struct {
+	zip      *zip.Writer
+	password string
+}
+
+func (a *zipArchive) create(name string, info os.FileInfo) (io.Writer, error) {
+	// The alexmullins/zip library uses AES-256 encryption by default
+	return a.zip.Encrypt(name, a.password)
+}
+
+func (a *zipArchive) close() error {
+	return a.zip.Close()
+}
+
+// ageArchive writes a tar stream encrypted with age
+type ageArchive struct {
+	age io.WriteCloser
+	tar *tar.Writer
+}
+
+// newAgeArchive encrypts for the X25519 recipients when there are any and
+// with the password otherwise; workFactor overrides the scrypt work factor
+// when set
+func newAgeArchive(out io.Writer, password string, recipients []string, workFactor int) (*ageArchive, error) {
+	rs, err := parseAgeRecipients(recipients)
+	if err != nil {
+		return nil, err
+	}
+	if len(rs) == 0 {
+		r, err := age.NewScryptRecipient(password)
+		if err != nil {
+			return nil, err
+		}
+		if workFactor > 0 {
+			r.SetWorkFactor(workFactor)
+		}
+		rs = append(rs, r)
+	}
+
+	w, err := age.Encrypt(out, rs...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start age encryption: %w", err)
+	}
+	return &ageArchive{age: w, tar: tar.NewWriter(w)}, nil
+}
+
+// parseAgeRecipients parses X25519 public keys
+func parseAgeRecipients(recipients []string) ([]age.Recipient, error) {
+	var rs []age.Recipient
+	for _, s := range recipients {
+		r, err := age.ParseX25519Recipient(strings.TrimSpace(s))
+		if err != nil {
+			return nil, fmt.Errorf("invalid age recipient %q: %w", s, err)
+		}
+		rs = append(rs, r)
+	}
+	return rs, nil
+}
+
+func (a *ageArchive) create(name string, info os.FileInfo) (io.Writer, error) {
+	err := a.tar.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Size:     info.Size(),
+		Mode:     int64(info.Mode().Perm()),
+		ModTime:  info.ModTime(),
+		Format:   tar.FormatPAX,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return a.tar, nil
+}
+
+func (a *ageArchive) close() error {
+	if err := a.tar.Close(); err != nil {
+		return err
+	}
+	return a.age.Close()
+}
+
+// openAge decrypts an age stream, armored or binary, with the X25519
+// identities when there are any and with the password otherwise. A
+// password or key that does not match is ErrWrongPassword
+func openAge(src io.Reader, password string, identities []string) (io.Reader, error) {
+	var ids []age.Identity
+	for _, s := range identities {
+		id, err := age.ParseX25519Identity(strings.TrimSpace(s))
+		if err != nil {
+			return nil, fmt.Errorf("invalid age identity: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		id, err := age.NewScryptIdentity(password)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	br := bufio.NewReader(src)
+	var r io.Reader = br
+	if head, _ := br.Peek(len(ageArmorMagic)); bytes.Equal(head, ageArmorMagic) {
+		r = armor.NewReader(br)
+	}
+
+	decrypted, err := age.Decrypt(r, ids...)
+	if err != nil {
+		var noMatch *age.NoIdentityMatchError
+		if errors.As(err, &noMatch) {
+			return nil, ErrWrongPassword
+		}
+		return nil, err
+	}
+	return decrypted, nil
+}
@@ -0,0 +1,179 @@
+package encryptor
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io/fs"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+// With Config.IncludeManifest the archive ends with MANIFEST.json and
+// MANIFEST.txt: every file stored with its size and SHA-256 and, from
+// Config.ManifestSource, the findings that put it there and the scan they
+// came from. A ManifestFinding has no field for the matched text or its
+// context, so the manifest cannot carry a secret
+
+// Names of the manifest entries, at the root of the archive
+const (
+	ManifestJSONName = "MANIFEST.json"
+	ManifestTextName = "MANIFEST.txt"
+)
+
+// ManifestSource describes the scan behind an archive
+type ManifestSource struct {
+	ScanRoot string
+	ScanTime time.Time
+
+	// Findings by the absolute path of the file they were found in
+	Findings map[string][]ManifestFinding
+}
+
+// ManifestFinding is what the manifest keeps of a finding
+type ManifestFinding struct {
+	Pattern   string  `json:"pattern"`
+	Line      int     `json:"line"`
+	Severity  string  `json:"severity"`
+	RiskScore float64 `json:"risk_score"`
+}
+
+// Manifest is the content of MANIFEST.json
+type Manifest struct {
+	Tool        string         `json:"tool"`
+	ToolVersion string         `json:"tool_version"`
+	Created     time.Time      `json:"created"`
+	ScanRoot    string         `json:"scan_root,omitempty"`
+	ScanTime    *time.Time     `json:"scan_time,omitempty"`
+	Files       []ManifestFile `json:"files"`
+}
+
+// ManifestFile is a file stored in the archive
+type ManifestFile struct {
+	Path     string            `json:"path"` // Archive path
+	Size     int64             `json:"size"`
+	SHA256   string            `json:"sha256"`
+	Findings []ManifestFinding `json:"findings,omitempty"`
+}
+
+// ToolVersion returns the version of the module the binary was built
+// from, "devel" for a build from a checkout
+func ToolVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok || info.Main.Version == "" || info.Main.Version == "(devel)" {
+		return "devel"
+	}
+	return info.Main.Version
+}
+
+// recordManifestFile adds a stored file to the manifest
+func (e *Encryptor) recordManifestFile(sourcePath, archivePath string, size int64, sum hash.Hash) {
+	file := ManifestFile{
+		Path:   archivePath,
+		Size:   size,
+		SHA256: hex.EncodeToString(sum.Sum(nil)),
+	}
+	if source := e.config.ManifestSource; source != nil {
+		if abs, err := filepath.Abs(sourcePath); err == nil {
+			sourcePath = abs
+		}
+		file.Findings = source.Findings[filepath.Clean(sourcePath)]
+	}
+	e.manifestFiles = append(e.manifestFiles, file)
+}
+
+// writeManifest adds the manifest entries after the files
+func (e *Encryptor) writeManifest(archive archiveWriter) error {
+	for _, file := range e.manifestFiles {
+		if file.Path == ManifestJSONName || file.Path == ManifestTextName {
+			return fmt.Errorf("%s is stored in the archive, the manifest would replace it", file.Path)
+		}
+	}
+
+	manifest := Manifest{
+		Tool:        "data-leak-locator",
+		ToolVersion: ToolVersion(),
+		Created:     time.Now().Truncate(time.Second),
+		Files:       e.manifestFiles,
+	}
+	if source := e.config.ManifestSource; source != nil {
+		manifest.ScanRoot = source.ScanRoot
+		if !source.ScanTime.IsZero() {
+			manifest.ScanTime = &source.ScanTime
+		}
+	}
+	data, err := json.MarshalIndent(&manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := e.addManifestEntry(archive, ManifestJSONName, append(data, '\n')); err != nil {
+		return err
+	}
+	return e.addManifestEntry(archive, ManifestTextName, manifestText(&manifest))
+}
+
+// addManifestEntry stores a manifest entry; it is not counted as a file
+func (e *Encryptor) addManifestEntry(archive archiveWriter, name string, data []byte) error {
+	if fa, ok := archive.(fileArchiver); ok {
+		return fa.addData(name, data)
+	}
+	writer, err := archive.create(name, manifestInfo{name: name, size: int64(len(data)), modTime: time.Now()})
+	if err != nil {
+		return fmt.Errorf("failed to create archive entry for %s: %w", name, err)
+	}
+	if _, err := writer.Write(data); err != nil {
+		return fmt.Errorf("failed to write to archive: %w", err)
+	}
+	if e.config.VerifyAfterEncrypt {
+		e.hashes = append(e.hashes, entryHash{name: name, sum: sha256.Sum256(data)})
+	}
+	return nil
+}
+
+// manifestText renders the manifest for people
+func manifestText(m *Manifest) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "Data Leak Locator archive manifest\n\n")
+	fmt.Fprintf(&b, "Created:      %s\n", m.Created.Format(time.RFC3339))
+	fmt.Fprintf(&b, "Tool version: %s\n", m.ToolVersion)
+	if m.ScanRoot != "" {
+		fmt.Fprintf(&b, "Scan root:    %s\n", m.ScanRoot)
+	}
+	if m.ScanTime != nil {
+		fmt.Fprintf(&b, "Scan time:    %s\n", m.ScanTime.Format(time.RFC3339))
+	}
+	fmt.Fprintf(&b, "Files:        %d\n", len(m.Files))
+
+	for _, file := range m.Files {
+		fmt.Fprintf(&b, "\n%s\n", file.Path)
+		fmt.Fprintf(&b, "  %d bytes, SHA-256 %s\n", file.Size, file.SHA256)
+		if len(file.Findings) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "  Findings:\n")
+		for _, f := range file.Findings {
+			fmt.Fprintf(&b, "  - line %d: %s, %s, risk %.1f\n", f.Line, f.Pattern, strings.ToLower(f.Severity), f.RiskScore)
+		}
+	}
+	return b.Bytes()
+}
+
+// manifestInfo is the os.FileInfo of a manifest entry
+type manifestInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+}
+
+func (i manifestInfo) Name() string       { return i.name }
+func (i manifestInfo) Size() int64        { return i.size }
+func (i manifestInfo) Mode() fs.FileMode  { return 0644 }
+func (i manifestInfo) ModTime() time.Time { return i.modTime }
+func (i manifestInfo) IsDir() bool        { return false }
+func (i manifestInfo) Sys() any           { return nil }
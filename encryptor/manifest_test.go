@@ -0,0 +1,133 @@
+package encryptor
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestManifestMatchesArchive tests the manifest lists every member with
+// the size and SHA-256 of its decrypted content and the findings of its
+// source file
+func TestManifestMatchesArchive(t *testing.T) {
+	for _, format := range []OutputFormat{FormatZipAES, FormatAge} {
+		t.Run(format.String(), func(t *testing.T) {
+			dir := t.TempDir()
+			base := filepath.Join(dir, "project")
+			os.MkdirAll(filepath.Join(base, "src"), 0755)
+			os.WriteFile(filepath.Join(base, "config.env"), []byte("password=hunter2\n"), 0644)
+			os.WriteFile(filepath.Join(base, "src", "main.go"), []byte("package main\n"), 0644)
+
+			scanTime := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+			config := DefaultConfig()
+			config.Password = "TestPassword!"
+			config.Format = format
+			config.OutputPath = filepath.Join(dir, "out"+format.Ext())
+			config.VerifyAfterEncrypt = true
+			config.IncludeManifest = true
+			config.ManifestSource = &ManifestSource{
+				ScanRoot: base,
+				ScanTime: scanTime,
+				Findings: map[string][]ManifestFinding{
+					filepath.Join(base, "config.env"): {{Pattern: "password", Line: 1, Severity: "high", RiskScore: 7.5}},
+				},
+			}
+			enc, err := NewEncryptor(config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			enc.ageWorkFactor = testAgeWorkFactor
+			result, err := enc.EncryptFilesWithResult([]FileEntry{{SourcePath: base}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.FilesEncrypted != 2 || !result.Verified {
+				t.Errorf("FilesEncrypted = %d, Verified = %v; want the 2 files, verified", result.FilesEncrypted, result.Verified)
+			}
+
+			outDir := t.TempDir()
+			dec, _ := NewDecryptor(DecryptConfig{Password: "TestPassword!", SourcePath: config.OutputPath, OutputDir: outDir})
+			if err := dec.DecryptAll(); err != nil {
+				t.Fatal(err)
+			}
+			entries, err := dec.List()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			data, err := os.ReadFile(filepath.Join(outDir, ManifestJSONName))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var manifest Manifest
+			if err := json.Unmarshal(data, &manifest); err != nil {
+				t.Fatal(err)
+			}
+			if manifest.ScanRoot != base || manifest.ScanTime == nil || !manifest.ScanTime.Equal(scanTime) || manifest.ToolVersion == "" {
+				t.Errorf("scan metadata = %q %v %q", manifest.ScanRoot, manifest.ScanTime, manifest.ToolVersion)
+			}
+
+			var members, listed []string
+			for _, entry := range entries {
+				if entry.Name != ManifestJSONName && entry.Name != ManifestTextName {
+					members = append(members, entry.Name)
+				}
+			}
+			for _, file := range manifest.Files {
+				listed = append(listed, file.Path)
+				content, err := os.ReadFile(filepath.Join(outDir, filepath.FromSlash(file.Path)))
+				if err != nil {
+					t.Errorf("%s listed but not extracted: %v", file.Path, err)
+					continue
+				}
+				sum := sha256.Sum256(content)
+				if file.Size != int64(len(content)) || file.SHA256 != hex.EncodeToString(sum[:]) {
+					t.Errorf("%s: manifest %d bytes %s, content %d bytes %x", file.Path, file.Size, file.SHA256, len(content), sum)
+				}
+				wantFindings := 0
+				if file.Path == "project/config.env" {
+					wantFindings = 1
+				}
+				if len(file.Findings) != wantFindings {
+					t.Errorf("%s: findings %+v", file.Path, file.Findings)
+				}
+			}
+			sort.Strings(members)
+			sort.Strings(listed)
+			if strings.Join(members, ",") != strings.Join(listed, ",") {
+				t.Errorf("manifest lists %v, archive holds %v", listed, members)
+			}
+
+			text, _ := os.ReadFile(filepath.Join(outDir, ManifestTextName))
+			if !strings.Contains(string(text), "project/config.env") || !strings.Contains(string(text), "line 1: password, high, risk 7.5") {
+				t.Errorf("%s:\n%s", ManifestTextName, text)
+			}
+		})
+	}
+}
+
+func TestManifestNameConflict(t *testing.T) {
+	dir := t.TempDir()
+	source := createTestFile(t, dir, ManifestJSONName, "{}")
+
+	config := DefaultConfig()
+	config.Password = "TestPassword!"
+	config.OutputPath = filepath.Join(dir, "out.zip")
+	config.IncludeManifest = true
+	enc, err := NewEncryptor(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.EncryptFiles([]FileEntry{{SourcePath: source}}); err == nil {
+		t.Error("a file named like the manifest was replaced")
+	}
+	if _, err := os.Stat(config.OutputPath); !os.IsNotExist(err) {
+		t.Error("archive left after the failure")
+	}
+}
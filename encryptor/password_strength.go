@@ -0,0 +1,682 @@
+package encryptor
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Password strength is estimated the way zxcvbn does it: the password is
+// split into the cheapest sequence of patterns an attacker would try
+// (common passwords, also reversed or with l33t substitutions, sequences,
+// keyboard rows, repeats, years and brute force for the rest), the guesses
+// of those patterns are multiplied and the total is mapped to a score from
+// 0 to 4. The patterns of the cheapest split also give the feedback
+//
+// The common password list is the one zxcvbn ships (MIT, Dropbox and Nathan
+// Button, see wordlists/LICENSE): commonPasswordCount passwords ranked by
+// frequency. Ranks follow the line order and entries are lowercased as they
+// are read, so a longer list can replace the file as is
+
+//go:embed wordlists/common_passwords.txt
+var commonPasswordList string
+
+// commonPasswordCount is the number of distinct passwords in the list
+const commonPasswordCount = 7141
+
+var (
+	commonPasswordsOnce sync.Once
+	commonPasswords     map[string]int // Password -> rank from 1
+	commonPasswordMax   int            // Longest password in runes
+)
+
+// commonPasswordRank returns the rank of a lowercase password in the list,
+// zero when it is not there
+func commonPasswordRank(word string) int {
+	commonPasswordsOnce.Do(func() {
+		lines := strings.Split(strings.TrimSpace(commonPasswordList), "\n")
+		commonPasswords = make(map[string]int, commonPasswordCount)
+		for i, line := range lines {
+			line = strings.ToLower(strings.TrimSpace(line))
+			if _, dup := commonPasswords[line]; line == "" || dup {
+				continue
+			}
+			commonPasswords[line] = i + 1
+			if n := utf8.RuneCountInString(line); n > commonPasswordMax {
+				commonPasswordMax = n
+			}
+		}
+	})
+	return commonPasswords[word]
+}
+
+// DefaultMinPasswordScore is the PasswordStrength score ValidatePassword
+// requires
+const DefaultMinPasswordScore = 2
+
+// ErrWeakPassword is matched by a WeakPasswordError
+var ErrWeakPassword = errors.New("password is too weak")
+
+// WeakPasswordError is returned for a password below the required score,
+// with the suggestions of PasswordStrength; it matches ErrWeakPassword
+type WeakPasswordError struct {
+	Score    int
+	MinScore int
+	Feedback []string
+}
+
+func (e *WeakPasswordError) Error() string {
+	return fmt.Sprintf("%v: score %d of 4, %d required", ErrWeakPassword, e.Score, e.MinScore)
+}
+
+func (e *WeakPasswordError) Is(target error) bool {
+	return target == ErrWeakPassword
+}
+
+// ValidatePassword checks that a password is not empty and scores at least
+// DefaultMinPasswordScore
+func ValidatePassword(password string) error {
+	return ValidatePasswordScore(password, DefaultMinPasswordScore)
+}
+
+// ValidatePasswordScore checks that a password is not empty and scores at
+// least minScore (0-4)
+func ValidatePasswordScore(password string, minScore int) error {
+	if password == "" {
+		return ErrEmptyPassword
+	}
+	score, feedback := PasswordStrength(password)
+	if score < minScore {
+		return &WeakPasswordError{Score: score, MinScore: minScore, Feedback: feedback}
+	}
+	return nil
+}
+
+// ValidatePasswordLegacy checks only that a password is not empty and has
+// at least 4 characters, as ValidatePassword did before strength scoring
+func ValidatePasswordLegacy(password string) error {
+	if password == "" {
+		return ErrEmptyPassword
+	}
+	if len(password) < 4 {
+		return errors.New("password must be at least 4 characters")
+	}
+	return nil
+}
+
+// PasswordStrength scores a password from 0 (guessed at once) to 4 (very
+// hard to guess) and suggests how to improve it; a strong password gets no
+// suggestions. The suggestions are in English, the CLI and the GUI show
+// them through locale.Localizer.PasswordFeedback
+func PasswordStrength(password string) (score int, feedback []string) {
+	if password == "" {
+		return 0, []string{"Enter a password"}
+	}
+	log10Guesses, matches := estimateGuesses(password)
+	score = guessesScore(log10Guesses)
+	return score, strengthFeedback(password, score, matches)
+}
+
+// Score thresholds of zxcvbn: 10^3, 10^6, 10^8 and 10^10 guesses, with a
+// small margin so a password exactly at a threshold is not promoted
+var scoreThresholds = []float64{
+	math.Log10(1e3 + 5),
+	math.Log10(1e6 + 5),
+	math.Log10(1e8 + 5),
+	math.Log10(1e10 + 5),
+}
+
+func guessesScore(log10Guesses float64) int {
+	for score, threshold := range scoreThresholds {
+		if log10Guesses < threshold {
+			return score
+		}
+	}
+	return 4
+}
+
+// matchKind is the pattern a part of a password was guessed as
+type matchKind int
+
+const (
+	matchBruteforce matchKind = iota
+	matchDictionary
+	matchSequence
+	matchKeyboard
+	matchRepeat
+	matchYear
+)
+
+// strengthMatch is a pattern found at runes i..j of a password
+type strengthMatch struct {
+	kind    matchKind
+	i, j    int
+	guesses float64 // log10
+
+	rank     int  // Dictionary
+	l33t     bool // Dictionary
+	reversed bool // Dictionary
+	upper    bool // Dictionary, has capitals
+}
+
+// Limits of the estimate
+const (
+	// maxEstimatedRunes bounds the cubic search; a longer password is
+	// estimated in parts of this length
+	maxEstimatedRunes = 64
+	// minGuessesBeforeGrowingSequence is added per extra pattern, so that a
+	// split into many short patterns is not cheaper than brute force
+	minGuessesBeforeGrowingSequence = 4.0 // log10 of 10000
+	minSubmatchGuessesSingleChar    = 1.0 // log10 of 10
+	minSubmatchGuessesMultiChar     = 1.69897
+	// keyboardGuessesPerKey is the starting keys times the average number
+	// of neighbours on a keyboard
+	keyboardGuessesPerKey = 94 * 4.6
+	minYearSpace          = 20
+)
+
+// estimateGuesses returns log10 of the guesses needed for the password and
+// the patterns of its cheapest split
+func estimateGuesses(password string) (float64, []strengthMatch) {
+	runes := []rune(password)
+	// A password made of one block typed over and over costs the block
+	// and the count
+	if p := period(runes); p < len(runes) && p <= maxEstimatedRunes {
+		guesses, _ := estimateGuesses(string(runes[:p]))
+		repeat := strengthMatch{kind: matchRepeat, i: 0, j: len(runes) - 1, guesses: guesses + math.Log10(float64(len(runes)/p))}
+		return repeat.guesses, []strengthMatch{repeat}
+	}
+
+	total := 0.0
+	var sequence []strengthMatch
+	for start := 0; start < len(runes); start += maxEstimatedRunes {
+		part := runes[start:min(start+maxEstimatedRunes, len(runes))]
+		guesses, matches := cheapestSequence(part, findStrengthMatches(part))
+		total += guesses
+		for _, m := range matches {
+			m.i, m.j = m.i+start, m.j+start
+			sequence = append(sequence, m)
+		}
+	}
+	return total, sequence
+}
+
+// findStrengthMatches lists every non brute force pattern in the password
+func findStrengthMatches(runes []rune) []strengthMatch {
+	var matches []strengthMatch
+	matches = append(matches, dictionaryMatches(runes)...)
+	matches = append(matches, sequenceMatches(runes)...)
+	matches = append(matches, keyboardMatches(runes)...)
+	matches = append(matches, repeatMatches(runes)...)
+	matches = append(matches, yearMatches(runes)...)
+	return matches
+}
+
+// cheapestSequence finds the split of the password into patterns with the
+// fewest guesses: for l patterns that is l! times the product of their
+// guesses, plus 10000^(l-1). Runs of text no pattern covers are brute
+// forced, never two such runs in a row
+func cheapestSequence(runes []rune, matches []strengthMatch) (float64, []strengthMatch) {
+	n := len(runes)
+	type state struct {
+		m  strengthMatch
+		pi float64 // log10 of the product of guesses
+		g  float64 // log10 of the total guesses
+	}
+	// optimal[k][l] is the best sequence of l patterns ending at rune k
+	optimal := make([]map[int]state, n)
+	for k := range optimal {
+		optimal[k] = make(map[int]state)
+	}
+	byEnd := make([][]strengthMatch, n)
+	for _, m := range matches {
+		m.guesses = math.Max(m.guesses, minMatchGuesses(m, n))
+		byEnd[m.j] = append(byEnd[m.j], m)
+	}
+
+	update := func(m strengthMatch, l int) {
+		k := m.j
+		pi := m.guesses
+		if l > 1 {
+			pi += optimal[m.i-1][l-1].pi
+		}
+		g := log10Add(log10Factorial(l)+pi, minGuessesBeforeGrowingSequence*float64(l-1))
+		for competingL, competing := range optimal[k] {
+			if competingL <= l && competing.g <= g {
+				return
+			}
+		}
+		optimal[k][l] = state{m: m, pi: pi, g: g}
+	}
+	bruteforce := func(i, j int) strengthMatch {
+		return strengthMatch{kind: matchBruteforce, i: i, j: j, guesses: bruteforceGuesses(j - i + 1)}
+	}
+
+	for k := 0; k < n; k++ {
+		for _, m := range byEnd[k] {
+			if m.i == 0 {
+				update(m, 1)
+				continue
+			}
+			for l := range optimal[m.i-1] {
+				update(m, l+1)
+			}
+		}
+		update(bruteforce(0, k), 1)
+		for i := 1; i <= k; i++ {
+			m := bruteforce(i, k)
+			for l, last := range optimal[i-1] {
+				if last.m.kind != matchBruteforce {
+					update(m, l+1)
+				}
+			}
+		}
+	}
+
+	if n == 0 {
+		return 0, nil
+	}
+	bestL, best := 0, math.Inf(1)
+	for l, s := range optimal[n-1] {
+		if s.g < best || (s.g == best && l < bestL) {
+			bestL, best = l, s.g
+		}
+	}
+	sequence := make([]strengthMatch, bestL)
+	for k, l := n-1, bestL; l > 0; l-- {
+		m := optimal[k][l].m
+		sequence[l-1] = m
+		k = m.i - 1
+	}
+	return best, sequence
+}
+
+// minMatchGuesses keeps a pattern shorter than the password from counting
+// as almost free
+func minMatchGuesses(m strengthMatch, n int) float64 {
+	switch {
+	case m.j-m.i+1 == n:
+		return 0
+	case m.i == m.j:
+		return minSubmatchGuessesSingleChar
+	}
+	return minSubmatchGuessesMultiChar
+}
+
+// bruteforceGuesses counts 10 guesses per character, as zxcvbn does
+func bruteforceGuesses(length int) float64 {
+	if length == 1 {
+		return math.Log10(11)
+	}
+	return math.Max(float64(length), math.Log10(51))
+}
+
+// log10Add returns log10(10^a + 10^b)
+func log10Add(a, b float64) float64 {
+	if a < b {
+		a, b = b, a
+	}
+	return a + math.Log10(1+math.Pow(10, b-a))
+}
+
+func log10Factorial(n int) float64 {
+	f := 0.0
+	for i := 2; i <= n; i++ {
+		f += math.Log10(float64(i))
+	}
+	return f
+}
+
+func log10Binomial(n, k int) float64 {
+	return log10Factorial(n) - log10Factorial(k) - log10Factorial(n-k)
+}
+
+// l33tTable undoes common substitutions; l33tAlternates are the second
+// reading of the ambiguous ones
+var (
+	l33tTable = map[rune]rune{
+		'4': 'a', '@': 'a', '8': 'b', '(': 'c', '{': 'c', '[': 'c', '<': 'c',
+		'3': 'e', '6': 'g', '9': 'g', '1': 'i', '!': 'i', '|': 'i', '0': 'o',
+		'$': 's', '5': 's', '+': 't', '7': 't', '%': 'x', '2': 'z',
+	}
+	l33tAlternates = map[rune]rune{'1': 'l', '|': 'l', '7': 'l'}
+)
+
+// dictionaryMatches finds common passwords, reversed or with l33t
+// substitutions, in any case
+func dictionaryMatches(runes []rune) []strengthMatch {
+	commonPasswordRank("")
+	lower := []rune(strings.ToLower(string(runes)))
+	if len(lower) != len(runes) {
+		// Lowercasing changed the length; compare case sensitively
+		lower = runes
+	}
+	var matches []strengthMatch
+	for i := range runes {
+		for j := i + 2; j < len(runes) && j-i < commonPasswordMax; j++ {
+			token := runes[i : j+1]
+			word := lower[i : j+1]
+			upper := uppercaseVariations(token)
+
+			if rank := commonPasswordRank(string(word)); rank > 0 {
+				matches = append(matches, strengthMatch{kind: matchDictionary, i: i, j: j, rank: rank, upper: upper > 0,
+					guesses: math.Log10(float64(rank)) + upper})
+			}
+			if reversed := reverseRunes(word); reversed != string(word) {
+				if rank := commonPasswordRank(reversed); rank > 0 {
+					matches = append(matches, strengthMatch{kind: matchDictionary, i: i, j: j, rank: rank, upper: upper > 0, reversed: true,
+						guesses: math.Log10(float64(rank)) + upper + math.Log10(2)})
+				}
+			}
+			for _, alternate := range []bool{false, true} {
+				unl33ted, subs := unl33t(word, alternate)
+				if len(subs) == 0 {
+					continue
+				}
+				if rank := commonPasswordRank(unl33ted); rank > 0 {
+					matches = append(matches, strengthMatch{kind: matchDictionary, i: i, j: j, rank: rank, upper: upper > 0, l33t: true,
+						guesses: math.Log10(float64(rank)) + upper + l33tVariations(word, unl33ted, subs)})
+				}
+			}
+		}
+	}
+	return matches
+}
+
+// unl33t replaces the substitutions in word and returns them
+func unl33t(word []rune, alternate bool) (string, map[rune]rune) {
+	subs := make(map[rune]rune)
+	out := make([]rune, len(word))
+	for i, r := range word {
+		letter, ok := l33tTable[r]
+		if alt, has := l33tAlternates[r]; alternate && has {
+			letter, ok = alt, true
+		}
+		if ok {
+			subs[r] = letter
+			r = letter
+		}
+		out[i] = r
+	}
+	return string(out), subs
+}
+
+// uppercaseVariations returns log10 of the ways capitals could be placed
+// in a word: none for lowercase, one bit for a capital first or last
+// letter or all capitals
+func uppercaseVariations(token []rune) float64 {
+	upper, lower := 0, 0
+	for _, r := range token {
+		if unicode.IsUpper(r) {
+			upper++
+		} else if unicode.IsLower(r) {
+			lower++
+		}
+	}
+	switch {
+	case upper == 0:
+		return 0
+	case lower == 0, upper == 1 && (unicode.IsUpper(token[0]) || unicode.IsUpper(token[len(token)-1])):
+		return math.Log10(2)
+	}
+	return log10Combinations(upper, lower)
+}
+
+// l33tVariations returns log10 of the ways the substitutions could be
+// placed in a word
+func l33tVariations(word []rune, unl33ted string, subs map[rune]rune) float64 {
+	variations := 0.0
+	for sub, letter := range subs {
+		subbed, plain := 0, 0
+		for _, r := range word {
+			switch r {
+			case sub:
+				subbed++
+			case letter:
+				plain++
+			}
+		}
+		if subbed == 0 || plain == 0 {
+			variations += math.Log10(2)
+			continue
+		}
+		variations += log10Combinations(subbed, plain)
+	}
+	return variations
+}
+
+// log10Combinations returns log10 of the sum of C(a+b, i) for i from 1 to
+// min(a, b)
+func log10Combinations(a, b int) float64 {
+	sum := math.Inf(-1)
+	for i := 1; i <= a && i <= b; i++ {
+		sum = log10Add(sum, log10Binomial(a+b, i))
+	}
+	return sum
+}
+
+func reverseRunes(r []rune) string {
+	out := make([]rune, len(r))
+	for i, c := range r {
+		out[len(r)-1-i] = c
+	}
+	return string(out)
+}
+
+// sequenceMatches finds runs of at least three characters with a constant
+// step of up to 5, such as abcd, 1357 or zyx
+func sequenceMatches(runes []rune) []strengthMatch {
+	var matches []strengthMatch
+	for i := 0; i < len(runes)-2; {
+		delta := runes[i+1] - runes[i]
+		j := i + 1
+		for j+1 < len(runes) && runes[j+1]-runes[j] == delta {
+			j++
+		}
+		if j-i >= 2 && delta != 0 && delta >= -5 && delta <= 5 {
+			base := 26.0
+			switch first := runes[i]; {
+			case strings.ContainsRune("aAzZ019", first):
+				base = 4
+			case unicode.IsDigit(first):
+				base = 10
+			}
+			if delta < 0 {
+				base *= 2
+			}
+			matches = append(matches, strengthMatch{kind: matchSequence, i: i, j: j, guesses: math.Log10(base * float64(j-i+1))})
+		}
+		if j-i >= 2 {
+			i = j
+		} else {
+			i++
+		}
+	}
+	return matches
+}
+
+// keyboardRows are the rows of the QWERTY and ЙЦУКЕН layouts
+var keyboardRows = []string{
+	"1234567890-=", "qwertyuiop[]", "asdfghjkl;'", "zxcvbnm,./",
+	"йцукенгшщзхъ", "фывапролджэ", "ячсмитьбю",
+}
+
+// keyboardMatches finds at least three neighbouring keys of a row typed in
+// either direction, such as qwerty or лдж
+func keyboardMatches(runes []rune) []strengthMatch {
+	lower := []rune(strings.ToLower(string(runes)))
+	if len(lower) != len(runes) {
+		return nil
+	}
+	var matches []strengthMatch
+	for _, row := range keyboardRows {
+		keys := []rune(row)
+		index := make(map[rune]int, len(keys))
+		for i, r := range keys {
+			index[r] = i
+		}
+		for i := 0; i < len(lower)-2; {
+			pos, ok := index[lower[i]]
+			if !ok {
+				i++
+				continue
+			}
+			j, step := i, 0
+			for j+1 < len(lower) {
+				next, ok := index[lower[j+1]]
+				if !ok || (next-pos != 1 && next-pos != -1) || (step != 0 && next-pos != step) {
+					break
+				}
+				step, pos = next-pos, next
+				j++
+			}
+			if j-i >= 2 {
+				guesses := math.Log10(keyboardGuessesPerKey * float64(j-i))
+				matches = append(matches, strengthMatch{kind: matchKeyboard, i: i, j: j, guesses: guesses + uppercaseVariations(runes[i:j+1])})
+				i = j
+			} else {
+				i++
+			}
+		}
+	}
+	return matches
+}
+
+// repeatMatches finds a character or a block repeated at least twice, such
+// as aaa or abcabc
+func repeatMatches(runes []rune) []strengthMatch {
+	var matches []strengthMatch
+	bases := make(map[string]float64)
+	for i := 0; i < len(runes)-1; i++ {
+		for size := 1; i+2*size <= len(runes); size++ {
+			count := 1
+			for i+(count+1)*size <= len(runes) && string(runes[i+count*size:i+(count+1)*size]) == string(runes[i:i+size]) {
+				count++
+			}
+			if count < 2 || (size == 1 && count < 3) {
+				continue
+			}
+			// abab is found as ab twice, not as abab
+			if p := period(runes[i : i+size]); p < size && size%p == 0 {
+				continue
+			}
+			base := string(runes[i : i+size])
+			baseGuesses, ok := bases[base]
+			if !ok {
+				baseGuesses, _ = estimateGuesses(base)
+				bases[base] = baseGuesses
+			}
+			matches = append(matches, strengthMatch{kind: matchRepeat, i: i, j: i + count*size - 1,
+				guesses: baseGuesses + math.Log10(float64(count))})
+		}
+	}
+	return matches
+}
+
+// period returns the length of the shortest block the runes repeat, the
+// last time possibly cut short; the length of the runes when there is none
+func period(runes []rune) int {
+	// prefix[i] is the longest proper prefix of runes[:i+1] that is also
+	// its suffix
+	prefix := make([]int, len(runes))
+	for i := 1; i < len(runes); i++ {
+		k := prefix[i-1]
+		for k > 0 && runes[i] != runes[k] {
+			k = prefix[k-1]
+		}
+		if runes[i] == runes[k] {
+			k++
+		}
+		prefix[i] = k
+	}
+	if len(runes) == 0 {
+		return 0
+	}
+	p := len(runes) - prefix[len(runes)-1]
+	if len(runes)/p < 2 {
+		return len(runes)
+	}
+	return p
+}
+
+// yearMatches finds years from 1900 to 2099; recent years are guessed
+// first
+func yearMatches(runes []rune) []strengthMatch {
+	var matches []strengthMatch
+	now := time.Now().Year()
+	for i := 0; i+4 <= len(runes); i++ {
+		token := string(runes[i : i+4])
+		if !strings.HasPrefix(token, "19") && !strings.HasPrefix(token, "20") {
+			continue
+		}
+		year, err := strconv.Atoi(token)
+		if err != nil {
+			continue
+		}
+		space := now - year
+		if space < 0 {
+			space = -space
+		}
+		if space < minYearSpace {
+			space = minYearSpace
+		}
+		matches = append(matches, strengthMatch{kind: matchYear, i: i, j: i + 3, guesses: math.Log10(float64(space))})
+	}
+	return matches
+}
+
+// strengthFeedback suggests improvements from the patterns of the cheapest
+// split, the most specific first
+func strengthFeedback(password string, score int, matches []strengthMatch) []string {
+	if score >= 3 {
+		return nil
+	}
+	var feedback []string
+	add := func(s string) {
+		for _, f := range feedback {
+			if f == s {
+				return
+			}
+		}
+		feedback = append(feedback, s)
+	}
+	for _, m := range matches {
+		switch m.kind {
+		case matchDictionary:
+			if len(matches) == 1 && m.rank <= 100 {
+				add("This is one of the most common passwords")
+			} else {
+				add("Common passwords and their parts are easy to guess")
+			}
+			if m.upper {
+				add("Capital letters barely make a known word harder to guess")
+			}
+			if m.l33t {
+				add("Substitutions like @ for a or 0 for o do not help")
+			}
+			if m.reversed {
+				add("Words spelled backwards are easy to guess too")
+			}
+		case matchSequence:
+			add("Avoid sequences like abcd or 1234")
+		case matchKeyboard:
+			add("Avoid keyboard rows like qwerty or asdf")
+		case matchRepeat:
+			add("Avoid repeats like aaa or abcabc")
+		case matchYear:
+			add("Avoid years and dates connected to you")
+		}
+	}
+	if utf8.RuneCountInString(password) < 12 {
+		add("Use at least 12 characters")
+	}
+	add("Add one or two uncommon words: length matters more than special characters")
+	return feedback
+}
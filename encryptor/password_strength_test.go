@@ -0,0 +1,116 @@
+package encryptor
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestPasswordStrength(t *testing.T) {
+	tests := []struct {
+		password string
+		maxScore int // Known weak passwords score at most this
+		minScore int // Known strong passwords score at least this
+	}{
+		{"password", 0, 0},
+		{"drowssap", 0, 0},
+		{"Password1", 1, 0},
+		{"P@ssw0rd!", 1, 0},
+		{"qwerty123", 1, 0},
+		{"йцукен", 1, 0},
+		{"abcdefgh", 0, 0},
+		{"12345678", 0, 0},
+		{"aaaaaaaaaaaa", 0, 0},
+		{"abcabcabcabc", 0, 0},
+		{strings.Repeat("secret", 50), 1, 0},
+		{"Summer2024", 1, 0},
+		{"hunter2", 1, 0},
+		{"xK9#mP2$vL", 4, 3},
+		{"9aY!kq2Lr7xE", 4, 4},
+		{"correct horse battery staple", 4, 4},
+		{"оранжевый кит пьёт чай на крыше", 4, 4},
+	}
+
+	for _, tt := range tests {
+		score, feedback := PasswordStrength(tt.password)
+		if score > tt.maxScore || score < tt.minScore {
+			t.Errorf("PasswordStrength(%q) = %d, want %d..%d", tt.password, score, tt.minScore, tt.maxScore)
+		}
+		if score < 3 && len(feedback) == 0 {
+			t.Errorf("PasswordStrength(%q): a weak password should get suggestions", tt.password)
+		}
+		if score >= 3 && len(feedback) > 0 {
+			t.Errorf("PasswordStrength(%q): a strong password got suggestions %v", tt.password, feedback)
+		}
+	}
+}
+
+func TestPasswordStrengthFeedback(t *testing.T) {
+	tests := []struct {
+		password string
+		want     string
+	}{
+		{"123456", "most common"},
+		{"P@ssw0rd", "Substitutions like @"},
+		{"drowssap", "spelled backwards"},
+		{"abcdefgh", "sequences"},
+		{"sdfghjkl", "keyboard rows"},
+		{"zzzzzzzz", "repeats"},
+		{"kqzm1987", "years"},
+		{"kqzmw", "12 characters"},
+	}
+
+	for _, tt := range tests {
+		_, feedback := PasswordStrength(tt.password)
+		if !strings.Contains(strings.Join(feedback, "\n"), tt.want) {
+			t.Errorf("PasswordStrength(%q) feedback = %v, want %q", tt.password, feedback, tt.want)
+		}
+	}
+}
+
+func TestValidatePassword(t *testing.T) {
+	if err := ValidatePassword(""); !errors.Is(err, ErrEmptyPassword) {
+		t.Errorf("empty password error = %v, want ErrEmptyPassword", err)
+	}
+
+	err := ValidatePassword("password123")
+	var weak *WeakPasswordError
+	if !errors.As(err, &weak) || !errors.Is(err, ErrWeakPassword) {
+		t.Fatalf("weak password error = %v, want WeakPasswordError", err)
+	}
+	if weak.MinScore != DefaultMinPasswordScore || weak.Score >= weak.MinScore || len(weak.Feedback) == 0 {
+		t.Errorf("WeakPasswordError = %+v", weak)
+	}
+
+	for _, password := range []string{"correct horse battery staple", "TestPassword123!"} {
+		if err := ValidatePassword(password); err != nil {
+			t.Errorf("ValidatePassword(%q) = %v", password, err)
+		}
+	}
+
+	if err := ValidatePasswordScore("password123", 0); err != nil {
+		t.Errorf("minimum score 0 should accept any password: %v", err)
+	}
+	if err := ValidatePasswordScore("xK9#mP2$vL", 4); !errors.Is(err, ErrWeakPassword) {
+		t.Errorf("minimum score 4 error = %v, want ErrWeakPassword", err)
+	}
+}
+
+func TestPeriod(t *testing.T) {
+	tests := map[string]int{"": 0, "a": 1, "ab": 2, "aaa": 1, "abab": 2, "ababa": 2, "abcab": 5, "abcabcab": 3}
+	for s, want := range tests {
+		if got := period([]rune(s)); got != want {
+			t.Errorf("period(%q) = %d, want %d", s, got, want)
+		}
+	}
+}
+
+func TestCommonPasswordList(t *testing.T) {
+	commonPasswordRank("")
+	if len(commonPasswords) != commonPasswordCount {
+		t.Errorf("%d common passwords embedded, want %d", len(commonPasswords), commonPasswordCount)
+	}
+	if rank := commonPasswordRank("password"); rank != 1 {
+		t.Errorf("rank of password = %d, want 1", rank)
+	}
+}
@@ -0,0 +1,305 @@
+package encryptor
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/kacebover/password-finder/fsutil"
+)
+
+// Overwriting a file in place only destroys its old content when the file
+// system writes to the same blocks. Copy-on-write file systems (APFS,
+// btrfs, ZFS, ReFS) put new data in new blocks and SSDs remap them, so
+// there the passes only wear the drive: the file is overwritten once,
+// renamed to hide its name and removed, and the result says the deletion
+// was best effort. Full disk encryption is what protects such storage
+
+// DeleteMethod is how files were deleted
+type DeleteMethod string
+
+const (
+	// DeleteOverwrite overwrote the files in place with every pass
+	DeleteOverwrite DeleteMethod = "overwrite"
+	// DeleteBestEffort overwrote once, renamed and removed files whose
+	// old blocks may survive on copy-on-write or solid-state storage
+	DeleteBestEffort DeleteMethod = "best-effort"
+)
+
+// DeleteResult reports a secure deletion
+type DeleteResult struct {
+	// Method is DeleteBestEffort when any file was on copy-on-write or
+	// solid-state storage
+	Method DeleteMethod
+
+	// Reason says why overwriting could not be trusted, such as
+	// "copy-on-write file system btrfs"
+	Reason string
+
+	FilesDeleted int
+	DirsRemoved  int
+
+	storage map[string]storageInfo // By directory, see storageFor
+}
+
+// storageInfo describes the storage a file is on
+type storageInfo struct {
+	fsType string // File system name when known
+	cow    bool   // Copy-on-write file system
+	ssd    bool   // Solid-state drive
+}
+
+// reason describes why overwriting is not trusted on the storage
+func (s storageInfo) reason() string {
+	if s.cow {
+		return "copy-on-write file system " + s.fsType
+	}
+	return "solid-state drive"
+}
+
+// storageOf detects the storage of a directory; tests replace it
+var storageOf = detectStorage
+
+// storageFor detects the storage of a file once per directory
+func (r *DeleteResult) storageFor(filePath string) storageInfo {
+	dir := filepath.Dir(filePath)
+	if info, ok := r.storage[dir]; ok {
+		return info
+	}
+	if r.storage == nil {
+		r.storage = make(map[string]storageInfo)
+	}
+	info := storageOf(dir)
+	r.storage[dir] = info
+	return info
+}
+
+// SecureDelete securely deletes a file by overwriting it multiple times
+// before removing it. This provides better protection than simple deletion.
+// passes: number of overwrite passes (recommended: 3)
+func SecureDelete(filePath string, passes int) error {
+	_, err := SecureDeleteFile(filePath, passes)
+	return err
+}
+
+// SecureDeleteFile deletes a file like SecureDelete and reports the method
+// used for its storage
+func SecureDeleteFile(filePath string, passes int) (*DeleteResult, error) {
+	result := &DeleteResult{Method: DeleteOverwrite}
+	return result, result.deleteFile(filePath, passes)
+}
+
+// SecureDeleteMultiple securely deletes multiple files
+func SecureDeleteMultiple(filePaths []string, passes int, onProgress func(current int, total int, path string)) error {
+	total := len(filePaths)
+	for i, path := range filePaths {
+		if onProgress != nil {
+			onProgress(i+1, total, path)
+		}
+		if err := SecureDelete(path, passes); err != nil {
+			return fmt.Errorf("failed to securely delete %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// SecureDeleteTree securely deletes every file under root and then removes
+// the emptied directories, root included. Symbolic links are removed
+// without touching their targets
+func SecureDeleteTree(root string, passes int, onProgress func(current int, total int, path string)) (*DeleteResult, error) {
+	result := &DeleteResult{Method: DeleteOverwrite}
+
+	var files, dirs []string
+	err := fsutil.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return pathError(err)
+		}
+		if info.IsDir() {
+			dirs = append(dirs, path)
+		} else {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return result, err
+	}
+
+	for i, path := range files {
+		if onProgress != nil {
+			onProgress(i+1, len(files), path)
+		}
+		if err := result.deleteFile(path, passes); err != nil {
+			return result, fmt.Errorf("failed to securely delete %s: %w", path, err)
+		}
+	}
+
+	// The walk lists parents first, so children are removed before them
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if err := fsutil.Remove(dirs[i]); err != nil {
+			return result, fmt.Errorf("failed to remove directory %s: %w", dirs[i], err)
+		}
+		result.DirsRemoved++
+	}
+	return result, nil
+}
+
+// deleteFile overwrites and removes one file, choosing the method for its
+// storage
+func (r *DeleteResult) deleteFile(filePath string, passes int) error {
+	info, err := fsutil.Lstat(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil // File doesn't exist, nothing to delete
+		}
+		return fmt.Errorf("failed to stat file: %w", err)
+	}
+
+	// Links and special files have no content of their own to overwrite;
+	// a directory fails to open below
+	if !info.Mode().IsRegular() && !info.IsDir() {
+		if err := fsutil.Remove(filePath); err != nil {
+			return err
+		}
+		r.FilesDeleted++
+		return nil
+	}
+
+	// Read-only files are made writable to be overwritten
+	if info.Mode().Perm()&0200 == 0 {
+		if err := fsutil.Chmod(filePath, info.Mode().Perm()|0200); err != nil {
+			return fmt.Errorf("failed to make file writable: %w", err)
+		}
+	}
+
+	storage := r.storageFor(filePath)
+	if storage.cow || storage.ssd {
+		if r.Method != DeleteBestEffort {
+			r.Method = DeleteBestEffort
+			r.Reason = storage.reason()
+		}
+		if err := overwriteFile(filePath, 1); err != nil {
+			return err
+		}
+		// The directory entry may keep the name after the blocks are gone
+		if renamed, err := hideName(filePath); err == nil {
+			filePath = renamed
+		}
+	} else if err := overwriteFile(filePath, passes); err != nil {
+		return err
+	}
+
+	if err := fsutil.Remove(filePath); err != nil {
+		return err
+	}
+	r.FilesDeleted++
+	return nil
+}
+
+// hideName renames a file to a random name in the same directory
+func hideName(filePath string) (string, error) {
+	name := make([]byte, 12)
+	if _, err := rand.Read(name); err != nil {
+		return "", err
+	}
+	renamed := filepath.Join(filepath.Dir(filePath), hex.EncodeToString(name))
+	if err := fsutil.Rename(filePath, renamed); err != nil {
+		return "", err
+	}
+	return renamed, nil
+}
+
+// overwriteFile overwrites the content of a file in place, alternating
+// zeros, ones and random data, and syncs every pass to disk
+func overwriteFile(filePath string, passes int) error {
+	if passes < 1 {
+		passes = 1
+	}
+	if passes > 10 {
+		passes = 10
+	}
+
+	// Open file for writing
+	file, err := fsutil.OpenFile(filePath, os.O_WRONLY, 0)
+	if err != nil {
+		return fmt.Errorf("failed to open file for secure deletion: %w", err)
+	}
+	defer file.Close()
+
+	// Get file size
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat file: %w", err)
+	}
+	size := info.Size()
+	if size == 0 {
+		return nil
+	}
+
+	// Prepare buffer
+	bufSize := int64(32 * 1024)
+	if size < bufSize {
+		bufSize = size
+	}
+	buf := make([]byte, bufSize)
+
+	// Perform overwrite passes
+	for pass := 0; pass < passes; pass++ {
+		// Seek to beginning
+		if _, err := file.Seek(0, 0); err != nil {
+			return fmt.Errorf("failed to seek: %w", err)
+		}
+
+		// Determine pattern for this pass
+		var pattern byte
+		switch pass % 3 {
+		case 0:
+			pattern = 0x00 // Zeros
+		case 1:
+			pattern = 0xFF // Ones
+		case 2:
+			// Random data
+			if _, err := rand.Read(buf); err != nil {
+				return fmt.Errorf("failed to generate random data: %w", err)
+			}
+		}
+
+		// Fill buffer with pattern (if not random)
+		if pass%3 != 2 {
+			for i := range buf {
+				buf[i] = pattern
+			}
+		}
+
+		// Write pattern to entire file
+		remaining := size
+		for remaining > 0 {
+			writeSize := bufSize
+			if remaining < bufSize {
+				writeSize = remaining
+			}
+
+			if pass%3 == 2 && writeSize < bufSize {
+				// Generate fresh random data for last chunk
+				if _, err := rand.Read(buf[:writeSize]); err != nil {
+					return fmt.Errorf("failed to generate random data: %w", err)
+				}
+			}
+
+			if _, err := file.Write(buf[:writeSize]); err != nil {
+				return fmt.Errorf("failed to overwrite file: %w", err)
+			}
+
+			remaining -= writeSize
+		}
+
+		// Sync to disk
+		if err := file.Sync(); err != nil {
+			return fmt.Errorf("failed to sync file: %w", err)
+		}
+	}
+
+	return nil
+}
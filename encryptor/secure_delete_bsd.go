@@ -0,0 +1,24 @@
+//go:build darwin || freebsd
+
+package encryptor
+
+import (
+	"bytes"
+
+	"golang.org/x/sys/unix"
+)
+
+// detectStorage reads the file system name with statfs. APFS is
+// copy-on-write and only used on SSDs on current Macs
+func detectStorage(dir string) storageInfo {
+	var fs unix.Statfs_t
+	if err := unix.Statfs(dir, &fs); err != nil {
+		return storageInfo{}
+	}
+	name := string(bytes.TrimRight(fs.Fstypename[:], "\x00"))
+	switch name {
+	case "apfs", "zfs":
+		return storageInfo{fsType: name, cow: true}
+	}
+	return storageInfo{fsType: name}
+}
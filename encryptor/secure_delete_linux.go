@@ -0,0 +1,48 @@
+package encryptor
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// Magic numbers of copy-on-write and log-structured file systems from
+// statfs(2); not all of them are in x/sys/unix
+var cowFileSystems = map[uint32]string{
+	0x9123683e: "btrfs",
+	0x2fc12fc1: "zfs",
+	0xca451a4e: "bcachefs",
+	0x3434:     "nilfs2",
+	0xf2f52010: "f2fs",
+}
+
+// detectStorage reads the file system type with statfs and whether the
+// block device rotates from sysfs
+func detectStorage(dir string) storageInfo {
+	var info storageInfo
+	var fs unix.Statfs_t
+	if err := unix.Statfs(dir, &fs); err == nil {
+		if name, ok := cowFileSystems[uint32(fs.Type)]; ok {
+			info.fsType = name
+			info.cow = true
+			return info
+		}
+	}
+
+	var st unix.Stat_t
+	if err := unix.Stat(dir, &st); err != nil {
+		return info
+	}
+	// A partition has no queue of its own, its disk one level up does
+	device := fmt.Sprintf("/sys/dev/block/%d:%d", unix.Major(uint64(st.Dev)), unix.Minor(uint64(st.Dev)))
+	for _, queue := range []string{device + "/queue/rotational", device + "/../queue/rotational"} {
+		data, err := os.ReadFile(queue)
+		if err == nil {
+			info.ssd = strings.TrimSpace(string(data)) == "0"
+			break
+		}
+	}
+	return info
+}
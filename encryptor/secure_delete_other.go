@@ -0,0 +1,9 @@
+//go:build !linux && !darwin && !freebsd && !windows
+
+package encryptor
+
+// detectStorage knows nothing about the storage on this platform, so
+// files are overwritten with every pass
+func detectStorage(dir string) storageInfo {
+	return storageInfo{}
+}
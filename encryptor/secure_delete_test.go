@@ -0,0 +1,99 @@
+package encryptor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSecureDeleteTree(t *testing.T) {
+	tmpDir := t.TempDir()
+	root := filepath.Join(tmpDir, "tree")
+	createTestFile(t, root, "a.txt", "secret a")
+	createTestFile(t, root, "sub/b.txt", "secret b")
+	createTestFile(t, root, "sub/deeper/empty.txt", "")
+	readOnly := createTestFile(t, root, "sub/readonly.txt", "secret c")
+	if err := os.Chmod(readOnly, 0400); err != nil {
+		t.Fatalf("Failed to change permissions: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(root, "empty-dir"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	// A link out of the tree is removed, its target kept
+	target := createTestFile(t, tmpDir, "outside.txt", "keep me")
+	if err := os.Symlink(target, filepath.Join(root, "link.txt")); err != nil {
+		t.Logf("Symlinks not supported: %v", err)
+	}
+
+	var progress int
+	result, err := SecureDeleteTree(root, 2, func(current, total int, path string) {
+		progress = current
+	})
+	if err != nil {
+		t.Fatalf("SecureDeleteTree failed: %v", err)
+	}
+
+	if _, err := os.Stat(root); !os.IsNotExist(err) {
+		t.Error("Tree should be removed")
+	}
+	if data, err := os.ReadFile(target); err != nil || string(data) != "keep me" {
+		t.Errorf("Link target changed: %q, %v", data, err)
+	}
+	if result.FilesDeleted != progress || result.FilesDeleted < 4 {
+		t.Errorf("FilesDeleted = %d, progress reached %d", result.FilesDeleted, progress)
+	}
+	if result.DirsRemoved != 4 {
+		t.Errorf("DirsRemoved = %d, want 4", result.DirsRemoved)
+	}
+}
+
+func TestSecureDeleteReadOnlyFile(t *testing.T) {
+	path := createTestFile(t, t.TempDir(), "readonly.txt", "secret")
+	if err := os.Chmod(path, 0400); err != nil {
+		t.Fatalf("Failed to change permissions: %v", err)
+	}
+
+	result, err := SecureDeleteFile(path, 3)
+	if err != nil {
+		t.Fatalf("SecureDeleteFile failed: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("Read-only file should be deleted")
+	}
+	if result.FilesDeleted != 1 {
+		t.Errorf("FilesDeleted = %d, want 1", result.FilesDeleted)
+	}
+}
+
+// TestSecureDeleteBestEffort tests the single pass on copy-on-write
+// storage and that the file leaves nothing behind under any name
+func TestSecureDeleteBestEffort(t *testing.T) {
+	defer func(detect func(string) storageInfo) { storageOf = detect }(storageOf)
+	storageOf = func(string) storageInfo { return storageInfo{fsType: "btrfs", cow: true} }
+
+	dir := t.TempDir()
+	createTestFile(t, dir, "secret.txt", "secret")
+	createTestFile(t, dir, "empty.txt", "")
+
+	result, err := SecureDeleteTree(dir, 3, nil)
+	if err != nil {
+		t.Fatalf("SecureDeleteTree failed: %v", err)
+	}
+	if result.Method != DeleteBestEffort || !strings.Contains(result.Reason, "btrfs") {
+		t.Errorf("Method %q, reason %q", result.Method, result.Reason)
+	}
+	if result.FilesDeleted != 2 {
+		t.Errorf("FilesDeleted = %d, want 2", result.FilesDeleted)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Error("Directory should be removed")
+	}
+
+	storageOf = func(string) storageInfo { return storageInfo{} }
+	path := createTestFile(t, t.TempDir(), "plain.txt", "secret")
+	result, err = SecureDeleteFile(path, 3)
+	if err != nil || result.Method != DeleteOverwrite || result.Reason != "" {
+		t.Errorf("Plain storage: method %q, reason %q, err %v", result.Method, result.Reason, err)
+	}
+}
@@ -0,0 +1,77 @@
+package encryptor
+
+import (
+	"strings"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// Storage property query of IOCTL_STORAGE_QUERY_PROPERTY for the seek
+// penalty, which only rotating disks have
+const (
+	ioctlStorageQueryProperty        = 0x2d1400
+	storageDeviceSeekPenaltyProperty = 7
+	propertyStandardQuery            = 0
+)
+
+type storagePropertyQuery struct {
+	PropertyID           uint32
+	QueryType            uint32
+	AdditionalParameters [1]byte
+}
+
+type deviceSeekPenaltyDescriptor struct {
+	Version           uint32
+	Size              uint32
+	IncursSeekPenalty byte
+}
+
+// detectStorage reads the file system name of the volume, ReFS being
+// copy-on-write, and asks the volume's disk whether it has a seek penalty
+func detectStorage(dir string) storageInfo {
+	var info storageInfo
+	path, err := windows.UTF16PtrFromString(dir)
+	if err != nil {
+		return info
+	}
+	volume := make([]uint16, windows.MAX_PATH+1)
+	if err := windows.GetVolumePathName(path, &volume[0], uint32(len(volume))); err != nil {
+		return info
+	}
+
+	fsName := make([]uint16, windows.MAX_PATH+1)
+	if err := windows.GetVolumeInformation(&volume[0], nil, 0, nil, nil, nil, &fsName[0], uint32(len(fsName))); err == nil {
+		info.fsType = windows.UTF16ToString(fsName)
+		if strings.EqualFold(info.fsType, "ReFS") {
+			info.cow = true
+			return info
+		}
+	}
+
+	// \\.\C: opens the volume without access rights, enough for the query
+	root := strings.TrimSuffix(windows.UTF16ToString(volume), `\`)
+	if len(root) != 2 || root[1] != ':' {
+		return info
+	}
+	device, err := windows.UTF16PtrFromString(`\\.\` + root)
+	if err != nil {
+		return info
+	}
+	handle, err := windows.CreateFile(device, 0, windows.FILE_SHARE_READ|windows.FILE_SHARE_WRITE, nil, windows.OPEN_EXISTING, 0, 0)
+	if err != nil {
+		return info
+	}
+	defer windows.CloseHandle(handle)
+
+	query := storagePropertyQuery{PropertyID: storageDeviceSeekPenaltyProperty, QueryType: propertyStandardQuery}
+	var penalty deviceSeekPenaltyDescriptor
+	var returned uint32
+	err = windows.DeviceIoControl(handle, ioctlStorageQueryProperty,
+		(*byte)(unsafe.Pointer(&query)), uint32(unsafe.Sizeof(query)),
+		(*byte)(unsafe.Pointer(&penalty)), uint32(unsafe.Sizeof(penalty)), &returned, nil)
+	if err == nil && uintptr(returned) > unsafe.Offsetof(penalty.IncursSeekPenalty) {
+		info.ssd = penalty.IncursSeekPenalty == 0
+	}
+	return info
+}
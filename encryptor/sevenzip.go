@@ -0,0 +1,413 @@
+package encryptor
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/kacebover/password-finder/fsutil"
+)
+
+// 7z archives are written and read by the 7-Zip command line tool. Files
+// are handed to it in list files, one run per directory the archive paths
+// are relative to; a file whose archive path is not its path below some
+// directory, a symlink or a name 7-Zip would take for a wildcard is piped
+// through stdin instead. Progress comes from the percentages 7-Zip prints
+// and a cancelled run kills the process
+
+// sevenZipBinaries are the names of the 7-Zip tool, the official build first
+var sevenZipBinaries = []string{"7zz", "7z", "7za"}
+
+// findSevenZip returns the 7-Zip binary to use: path when set, the first
+// one on PATH otherwise
+func findSevenZip(path string) (string, error) {
+	if path != "" {
+		if _, err := exec.LookPath(path); err != nil {
+			return "", &ErrDependencyMissing{Name: "7z"}
+		}
+		return path, nil
+	}
+	for _, binary := range sevenZipBinaries {
+		if found, err := exec.LookPath(binary); err == nil {
+			return found, nil
+		}
+	}
+	return "", &ErrDependencyMissing{Name: "7z"}
+}
+
+// sevenZipCmd runs 7-Zip with a password
+type sevenZipCmd struct {
+	path      string
+	password  string
+	cancelled *int32 // Kills the process when set to 1
+}
+
+// percentPattern matches the progress 7-Zip prints with -bsp1
+var percentPattern = regexp.MustCompile(`(\d{1,3})%`)
+
+// progressWriter reports the last percentage 7-Zip printed
+type progressWriter struct {
+	tail      []byte
+	onPercent func(int)
+}
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+	w.tail = append(w.tail, p...)
+	if m := percentPattern.FindAllSubmatch(w.tail, -1); len(m) > 0 && w.onPercent != nil {
+		pct, _ := strconv.Atoi(string(m[len(m)-1][1]))
+		w.onPercent(pct)
+	}
+	// A percentage may be split between writes
+	if len(w.tail) > 8 {
+		w.tail = append(w.tail[:0], w.tail[len(w.tail)-8:]...)
+	}
+	return len(p), nil
+}
+
+// run runs 7-Zip in dir with the password and args. onPercent receives the
+// progress, stdout gets the output instead when set. The password goes on
+// the command line: 7-Zip reads it nowhere else but its prompt, which may
+// read the terminal and shares stdin with -si, so callers opt in with
+// Config.SevenZipPasswordArg
+func (c sevenZipCmd) run(dir string, stdin io.Reader, stdout io.Writer, onPercent func(int), args ...string) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	args = append(append([]string(nil), args[0], "-p"+c.password, "-y"), args[1:]...)
+	cmd := exec.CommandContext(ctx, c.path, args...)
+	cmd.Dir = dir
+	cmd.Stdin = stdin
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	cmd.Stdout = &progressWriter{onPercent: onPercent}
+	if stdout != nil {
+		cmd.Stdout = stdout
+	}
+
+	// Cancellation is polled, the encryptor and decryptor only set a flag
+	done := make(chan struct{})
+	defer close(done)
+	if c.cancelled != nil {
+		go func() {
+			ticker := time.NewTicker(50 * time.Millisecond)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-done:
+					return
+				case <-ticker.C:
+					if atomic.LoadInt32(c.cancelled) == 1 {
+						cancel()
+						return
+					}
+				}
+			}
+		}()
+	}
+
+	err := cmd.Run()
+	if c.cancelled != nil && atomic.LoadInt32(c.cancelled) == 1 {
+		return ErrCancelled
+	}
+	if err != nil {
+		return sevenZipError(err, stderr.String())
+	}
+	return nil
+}
+
+// sevenZipError classifies a failed run by the message 7-Zip printed
+func sevenZipError(err error, output string) error {
+	if strings.Contains(output, "Wrong password") {
+		return ErrWrongPassword
+	}
+	for _, line := range strings.Split(output, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return fmt.Errorf("7-Zip: %s: %w", line, err)
+		}
+	}
+	return fmt.Errorf("7-Zip: %w", err)
+}
+
+// sevenZipEntry is a file listed by 7-Zip
+type sevenZipEntry struct {
+	ArchiveEntry
+	dir bool
+}
+
+// list returns the entries of an archive
+func (c sevenZipCmd) list(archive string) ([]sevenZipEntry, error) {
+	var out bytes.Buffer
+	if err := c.run("", nil, &out, nil, "l", "-slt", "-sccUTF-8", "--", archive); err != nil {
+		return nil, err
+	}
+	return parseSevenZipList(&out), nil
+}
+
+// parseSevenZipList reads the technical listing (-slt): a block of
+// "Key = Value" lines per entry after a line of dashes
+func parseSevenZipList(r io.Reader) []sevenZipEntry {
+	var entries []sevenZipEntry
+	var entry *sevenZipEntry
+	started := false
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if !started {
+			started = strings.HasPrefix(line, "----------")
+			continue
+		}
+		key, value, ok := strings.Cut(line, " = ")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "Path":
+			entries = append(entries, sevenZipEntry{})
+			entry = &entries[len(entries)-1]
+			entry.Name = filepath.ToSlash(value)
+		case "Folder":
+			if entry != nil {
+				entry.dir = value == "+"
+			}
+		case "Attributes":
+			if entry != nil && strings.HasPrefix(value, "D") {
+				entry.dir = true
+			}
+		case "Size":
+			if entry != nil {
+				entry.Size, _ = strconv.ParseInt(value, 10, 64)
+			}
+		case "Packed Size":
+			if entry != nil {
+				entry.CompressedSize, _ = strconv.ParseInt(value, 10, 64)
+			}
+		case "Modified":
+			if entry != nil {
+				entry.Modified, _ = time.ParseInLocation("2006-01-02 15:04:05", strings.SplitN(value, ".", 2)[0], time.Local)
+			}
+		case "Encrypted":
+			if entry != nil {
+				entry.Encrypted = value == "+"
+			}
+		}
+	}
+	return entries
+}
+
+// sevenZipArchive collects the files of a 7z archive and runs 7-Zip on
+// close
+type sevenZipArchive struct {
+	e      *Encryptor
+	out    io.Writer
+	groups map[string]*sevenZipGroup // By the directory names are relative to
+	order  []string
+	piped  []sevenZipFile // Files passed through stdin
+}
+
+// sevenZipGroup is one run of 7-Zip over files below dir
+type sevenZipGroup struct {
+	names []string
+	size  int64
+}
+
+// sevenZipFile is a file or data piped to 7-Zip under its archive path
+type sevenZipFile struct {
+	path, name string
+	size       int64
+	data       []byte // Piped instead of the file at path when set
+}
+
+func (e *Encryptor) newSevenZipArchive(out io.Writer) (*sevenZipArchive, error) {
+	return &sevenZipArchive{e: e, out: out, groups: make(map[string]*sevenZipGroup)}, nil
+}
+
+func (a *sevenZipArchive) create(name string, info os.FileInfo) (io.Writer, error) {
+	return nil, errors.New("7z entries are added by path")
+}
+
+// add lists a file under its archive path
+func (a *sevenZipArchive) add(path, name string, info os.FileInfo) error {
+	a.e.sevenZipNames = append(a.e.sevenZipNames, name)
+
+	nativeName := filepath.FromSlash(name)
+	dir, ok := strings.CutSuffix(path, string(filepath.Separator)+nativeName)
+	literal := !strings.ContainsAny(name, "*?@\n") && strings.TrimSpace(name) == name && !strings.HasPrefix(name, "-")
+	if ok && literal {
+		if linkInfo, err := fsutil.Lstat(path); err == nil && linkInfo.Mode()&os.ModeSymlink == 0 {
+			group := a.groups[dir]
+			if group == nil {
+				group = &sevenZipGroup{}
+				a.groups[dir] = group
+				a.order = append(a.order, dir)
+			}
+			group.names = append(group.names, nativeName)
+			group.size += info.Size()
+			return nil
+		}
+	}
+	a.piped = append(a.piped, sevenZipFile{path: path, name: name, size: info.Size()})
+	return nil
+}
+
+// addData pipes data under name
+func (a *sevenZipArchive) addData(name string, data []byte) error {
+	a.e.sevenZipNames = append(a.e.sevenZipNames, name)
+	a.piped = append(a.piped, sevenZipFile{name: name, size: int64(len(data)), data: data})
+	return nil
+}
+
+// close has 7-Zip write the archive next to the output and copies it to
+// the output once it is done
+func (a *sevenZipArchive) close() error {
+	e := a.e
+	tmp, err := fsutil.CreateTemp(filepath.Dir(e.config.OutputPath), ".encrypt-*.7z")
+	if err != nil {
+		return pathError(err)
+	}
+	tmpPath := tmp.Name()
+	tmp.Close()
+	// 7-Zip does not write to an existing empty file
+	fsutil.Remove(tmpPath)
+	tmpAbs, err := filepath.Abs(tmpPath)
+	if err != nil {
+		return err
+	}
+
+	defer fsutil.Remove(tmpPath)
+
+	if err := a.write(tmpAbs); err != nil {
+		return err
+	}
+	written, err := fsutil.Open(tmpPath)
+	if err != nil {
+		return pathError(err)
+	}
+	defer written.Close()
+	_, err = io.CopyBuffer(a.out, written, make([]byte, e.config.BufferSize))
+	return err
+}
+
+// write runs 7-Zip for every group and piped file
+func (a *sevenZipArchive) write(archive string) error {
+	e := a.e
+	c := sevenZipCmd{path: e.sevenZip, password: e.config.Password, cancelled: &e.cancelled}
+	flags := []string{"-t7z", "-mhe=on", "-mx=" + strconv.Itoa(e.config.CompressionLevel), "-bso0", "-bsp1"}
+
+	var done int64
+	for _, dir := range a.order {
+		group := a.groups[dir]
+		sort.Strings(group.names)
+		listFile, err := writeListFile(group.names)
+		if err != nil {
+			return err
+		}
+		e.currentFile = filepath.Base(dir)
+		// A list file is not read after "--"; archive is an absolute path
+		args := append(append([]string{"a"}, flags...), "-scsUTF-8", archive, "@"+listFile)
+		err = c.run(dir, nil, nil, func(pct int) {
+			atomic.StoreInt64(&e.bytesProcessed, done+group.size*int64(pct)/100)
+			e.reportProgress()
+		}, args...)
+		os.Remove(listFile)
+		if err != nil {
+			return err
+		}
+		done += group.size
+		atomic.StoreInt64(&e.bytesProcessed, done)
+		e.reportProgress()
+	}
+
+	for _, file := range a.piped {
+		args := append(append([]string{"a"}, flags...), "-si"+filepath.FromSlash(file.name), "--", archive)
+		if file.data != nil {
+			if err := c.run("", bytes.NewReader(file.data), nil, nil, args...); err != nil {
+				return err
+			}
+			continue
+		}
+		src, err := fsutil.Open(file.path)
+		if err != nil {
+			return pathError(err)
+		}
+		e.currentFile = file.name
+		counted := &countingReader{r: src, onRead: func(n int) {
+			atomic.AddInt64(&e.bytesProcessed, int64(n))
+			e.reportProgress()
+		}}
+		err = c.run("", counted, nil, nil, args...)
+		src.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeListFile writes names one per line for 7-Zip's @listfile
+func writeListFile(names []string) (string, error) {
+	f, err := os.CreateTemp("", "encrypt-*.txt")
+	if err != nil {
+		return "", err
+	}
+	_, err = io.WriteString(f, strings.Join(names, "\n")+"\n")
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// countingReader reports the bytes read through it
+type countingReader struct {
+	r      io.Reader
+	onRead func(n int)
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if n > 0 {
+		r.onRead(n)
+	}
+	return n, err
+}
+
+// verifySevenZip checks the written archive has every entry and tests
+// their checksums with the password
+func (e *Encryptor) verifySevenZip() error {
+	c := sevenZipCmd{path: e.sevenZip, password: e.config.Password, cancelled: &e.cancelled}
+	entries, err := c.list(sevenZipSource(e.config.OutputPath))
+	if err != nil {
+		return verifyFailed(err)
+	}
+
+	listed := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		listed[entry.Name] = true
+	}
+	verr := &VerifyError{}
+	for _, name := range e.sevenZipNames {
+		if !listed[name] {
+			verr.Failed = append(verr.Failed, name+" (missing)")
+		}
+	}
+	if len(verr.Failed) > 0 {
+		return verr
+	}
+
+	return verifyFailed(c.run("", nil, nil, nil, "t", "-bso0", "--", sevenZipSource(e.config.OutputPath)))
+}
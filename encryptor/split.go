@@ -0,0 +1,542 @@
+package encryptor
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/kacebover/password-finder/fsutil"
+)
+
+// With Config.SplitSize the archive is written as volumes backup.zip.001,
+// backup.zip.002, ... of at most SplitSize bytes each. They are plain byte
+// ranges of the archive, the volumes of 7-Zip, which opens such sets
+// itself; joined in order they give the archive back. The manifest
+// backup.zip.parts.json lists the volumes with their size and SHA-256, so a
+// damaged or missing volume is found before reassembly. The decryptor
+// reads a set through any of its volumes, its manifest or the name of the
+// joined archive
+
+// SplitManifestSuffix is appended to the output path for the manifest of a
+// split archive
+const SplitManifestSuffix = ".parts.json"
+
+// MinSplitSize is the smallest volume size accepted
+const MinSplitSize = 64 * 1024
+
+// ErrSplitDamaged is matched by a SplitError
+var ErrSplitDamaged = errors.New("split archive is damaged")
+
+// SplitError names the volume of a split archive that is missing or does
+// not match its manifest; it matches ErrSplitDamaged
+type SplitError struct {
+	Part string
+	Err  error
+}
+
+func (e *SplitError) Error() string {
+	return fmt.Sprintf("%v: %s: %v", ErrSplitDamaged, e.Part, e.Err)
+}
+
+func (e *SplitError) Is(target error) bool {
+	return target == ErrSplitDamaged
+}
+
+func (e *SplitError) Unwrap() error {
+	return e.Err
+}
+
+// SplitManifest describes the volumes of a split archive
+type SplitManifest struct {
+	Archive   string      `json:"archive"` // File name of the joined archive
+	Format    string      `json:"format"`  // See OutputFormat.String
+	TotalSize int64       `json:"total_size"`
+	PartSize  int64       `json:"part_size"`
+	Parts     []SplitPart `json:"parts"`
+
+	dir string // Directory of the volumes
+}
+
+// SplitPart is one volume of a split archive
+type SplitPart struct {
+	Name   string `json:"name"` // File name, next to the manifest
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256,omitempty"` // Empty for a set found without its manifest
+}
+
+// splitPartName returns the name of volume n, counted from 1
+func splitPartName(base string, n int) string {
+	return fmt.Sprintf("%s.%03d", base, n)
+}
+
+// partPath returns the path of a volume
+func (m *SplitManifest) partPath(part SplitPart) string {
+	return filepath.Join(m.dir, part.Name)
+}
+
+// firstPart returns the path of the first volume, which 7-Zip opens the
+// set with
+func (m *SplitManifest) firstPart() string {
+	return m.partPath(m.Parts[0])
+}
+
+// splitVolumePattern matches the extension of a volume
+var splitVolumePattern = regexp.MustCompile(`\.\d{3,}$`)
+
+// LoadSplit returns the manifest of the split archive at path: its
+// manifest, one of its volumes or the name of the joined archive. A set
+// without its manifest is found by its volumes, which then carry no
+// checksums. It returns nil and no error when path is not a split archive
+func LoadSplit(path string) (*SplitManifest, error) {
+	var base string
+	switch {
+	case strings.HasSuffix(path, SplitManifestSuffix):
+		base = strings.TrimSuffix(path, SplitManifestSuffix)
+	case splitVolumePattern.MatchString(path):
+		base = path[:strings.LastIndex(path, ".")]
+		if _, err := fsutil.Stat(splitPartName(base, 1)); err != nil {
+			return nil, nil
+		}
+	default:
+		if _, err := fsutil.Stat(path); err == nil {
+			return nil, nil
+		}
+		if _, err := fsutil.Stat(splitPartName(path, 1)); err != nil {
+			if _, err := fsutil.Stat(path + SplitManifestSuffix); err != nil {
+				return nil, nil
+			}
+		}
+		base = path
+	}
+
+	m := &SplitManifest{}
+	data, err := fsutil.ReadFile(base + SplitManifestSuffix)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, m); err != nil || len(m.Parts) == 0 {
+			return nil, fmt.Errorf("%w: %s: invalid manifest", ErrInvalidSource, base+SplitManifestSuffix)
+		}
+		for _, part := range m.Parts {
+			// Volumes are only looked up next to the manifest
+			if part.Name != filepath.Base(part.Name) || part.Name == ".." {
+				return nil, fmt.Errorf("%w: %s", ErrUnsafePath, part.Name)
+			}
+		}
+	case errors.Is(err, os.ErrNotExist):
+		m.Archive = filepath.Base(base)
+		for n := 1; ; n++ {
+			info, err := fsutil.Stat(splitPartName(base, n))
+			if err != nil {
+				break
+			}
+			m.Parts = append(m.Parts, SplitPart{Name: info.Name(), Size: info.Size()})
+			m.TotalSize += info.Size()
+		}
+		if len(m.Parts) == 0 {
+			return nil, pathError(err)
+		}
+	default:
+		return nil, pathError(err)
+	}
+	m.dir = filepath.Dir(base)
+	return m, nil
+}
+
+// Verify checks every volume against the manifest, reporting progress over
+// the whole set, and returns a SplitError for the first one missing or
+// damaged. Volumes without a checksum are only checked for their size
+func (m *SplitManifest) Verify(onProgress ProgressCallback) error {
+	return m.read(nil, onProgress)
+}
+
+// read checks the volumes like Verify, copying them in order to w when set
+func (m *SplitManifest) read(w io.Writer, onProgress ProgressCallback) error {
+	var done int64
+	buf := make([]byte, 32*1024)
+	for _, part := range m.Parts {
+		f, err := fsutil.Open(m.partPath(part))
+		if err != nil {
+			return &SplitError{Part: part.Name, Err: pathError(err)}
+		}
+		sum := sha256.New()
+		var dst io.Writer = sum
+		if w != nil {
+			dst = io.MultiWriter(sum, w)
+		}
+		n, err := io.CopyBuffer(&progressCopy{w: dst, done: &done, total: m.TotalSize, name: part.Name, onProgress: onProgress}, f, buf)
+		f.Close()
+		switch {
+		case err != nil:
+			return &SplitError{Part: part.Name, Err: err}
+		case n != part.Size:
+			return &SplitError{Part: part.Name, Err: fmt.Errorf("%d bytes, the manifest says %d", n, part.Size)}
+		case part.SHA256 != "" && hex.EncodeToString(sum.Sum(nil)) != part.SHA256:
+			return &SplitError{Part: part.Name, Err: errors.New("checksum mismatch")}
+		}
+	}
+	return nil
+}
+
+// progressCopy reports the bytes written through it
+type progressCopy struct {
+	w          io.Writer
+	done       *int64
+	total      int64
+	name       string
+	onProgress ProgressCallback
+}
+
+func (p *progressCopy) Write(b []byte) (int, error) {
+	n, err := p.w.Write(b)
+	*p.done += int64(n)
+	if p.onProgress != nil {
+		p.onProgress(*p.done, p.total, p.name)
+	}
+	return n, err
+}
+
+// VerifySplit checks the volumes of the split archive at path against its
+// manifest, see LoadSplit and SplitManifest.Verify
+func VerifySplit(path string, onProgress ProgressCallback) error {
+	m, err := LoadSplit(path)
+	if err != nil {
+		return err
+	}
+	if m == nil {
+		return fmt.Errorf("%w: %s: not a split archive", ErrInvalidSource, path)
+	}
+	return m.Verify(onProgress)
+}
+
+// JoinSplit verifies the volumes of the split archive at path and joins
+// them into outputPath. The archive is written to a temporary file first,
+// so a damaged volume leaves nothing behind
+func JoinSplit(path, outputPath string, onProgress ProgressCallback) error {
+	m, err := LoadSplit(path)
+	if err != nil {
+		return err
+	}
+	if m == nil {
+		return fmt.Errorf("%w: %s: not a split archive", ErrInvalidSource, path)
+	}
+	if _, err := fsutil.Stat(outputPath); err == nil {
+		return fmt.Errorf("%w: %s", ErrFileExists, outputPath)
+	}
+
+	if err := fsutil.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", pathError(err))
+	}
+	tmp, err := fsutil.CreateTemp(filepath.Dir(outputPath), ".join-*")
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", pathError(err))
+	}
+	if err := m.read(tmp, onProgress); err != nil {
+		tmp.Close()
+		fsutil.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		fsutil.Remove(tmp.Name())
+		return fmt.Errorf("failed to write %s: %w", outputPath, err)
+	}
+	if err := fsutil.Rename(tmp.Name(), outputPath); err != nil {
+		fsutil.Remove(tmp.Name())
+		return pathError(err)
+	}
+	return nil
+}
+
+// archiveOutput receives the bytes of the archive being written
+type archiveOutput interface {
+	io.Writer
+	// finish closes the output; write errors surface here
+	finish() error
+	// remove closes the output and deletes everything written
+	remove()
+	// size is the number of bytes written
+	size() int64
+}
+
+// fileOutput writes the archive to a single file
+type fileOutput struct {
+	*os.File
+	path    string
+	written int64
+}
+
+func (o *fileOutput) Write(p []byte) (int, error) {
+	n, err := o.File.Write(p)
+	o.written += int64(n)
+	return n, err
+}
+
+func (o *fileOutput) finish() error {
+	return o.File.Close()
+}
+
+func (o *fileOutput) remove() {
+	o.File.Close()
+	fsutil.Remove(o.path)
+}
+
+func (o *fileOutput) size() int64 {
+	return o.written
+}
+
+// splitOutput writes the archive as volumes of at most partSize bytes and
+// their manifest
+type splitOutput struct {
+	e        *Encryptor
+	base     string
+	partSize int64
+	manifest SplitManifest
+
+	part    *os.File
+	written int64 // Bytes in the current volume
+	hash    hash.Hash
+	paths   []string // Volumes created, for remove
+}
+
+func newSplitOutput(e *Encryptor) *splitOutput {
+	base := e.config.OutputPath
+	return &splitOutput{
+		e:        e,
+		base:     base,
+		partSize: e.config.SplitSize,
+		manifest: SplitManifest{
+			Archive:  filepath.Base(base),
+			Format:   e.config.Format.String(),
+			PartSize: e.config.SplitSize,
+			dir:      filepath.Dir(base),
+		},
+	}
+}
+
+func (o *splitOutput) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		if o.part == nil || o.written == o.partSize {
+			if err := o.nextPart(); err != nil {
+				return written, err
+			}
+		}
+		chunk := p
+		if room := o.partSize - o.written; int64(len(chunk)) > room {
+			chunk = chunk[:room]
+		}
+		n, err := o.part.Write(chunk)
+		o.hash.Write(chunk[:n])
+		o.written += int64(n)
+		o.manifest.TotalSize += int64(n)
+		written += n
+		if err != nil {
+			return written, err
+		}
+		p = p[n:]
+	}
+	return written, nil
+}
+
+// nextPart finishes the current volume and starts the next one
+func (o *splitOutput) nextPart() error {
+	if err := o.closePart(); err != nil {
+		return err
+	}
+	path := splitPartName(o.base, len(o.paths)+1)
+	f, err := fsutil.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", pathError(err))
+	}
+	o.paths = append(o.paths, path)
+	if info, err := f.Stat(); err == nil {
+		o.e.outputs = append(o.e.outputs, info)
+	}
+	o.part, o.written, o.hash = f, 0, sha256.New()
+	return nil
+}
+
+// closePart closes the current volume and records it in the manifest
+func (o *splitOutput) closePart() error {
+	if o.part == nil {
+		return nil
+	}
+	err := o.part.Close()
+	o.part = nil
+	if err != nil {
+		return fmt.Errorf("failed to write output file: %w", pathError(err))
+	}
+	o.manifest.Parts = append(o.manifest.Parts, SplitPart{
+		Name:   filepath.Base(o.paths[len(o.paths)-1]),
+		Size:   o.written,
+		SHA256: hex.EncodeToString(o.hash.Sum(nil)),
+	})
+	return nil
+}
+
+func (o *splitOutput) finish() error {
+	// An empty archive still gets its one volume
+	if o.part == nil && len(o.paths) == 0 {
+		if err := o.nextPart(); err != nil {
+			return err
+		}
+	}
+	if err := o.closePart(); err != nil {
+		return err
+	}
+	// Volumes left over from a longer set at the same path would be taken
+	// for part of this one without the manifest
+	for n := len(o.manifest.Parts) + 1; ; n++ {
+		if fsutil.Remove(splitPartName(o.base, n)) != nil {
+			break
+		}
+	}
+
+	data, err := json.MarshalIndent(&o.manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := o.base + SplitManifestSuffix
+	f, err := fsutil.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create manifest: %w", pathError(err))
+	}
+	o.paths = append(o.paths, path)
+	_, err = f.Write(append(data, '\n'))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write manifest: %w", pathError(err))
+	}
+	return nil
+}
+
+func (o *splitOutput) remove() {
+	if o.part != nil {
+		o.part.Close()
+		o.part = nil
+	}
+	for _, path := range o.paths {
+		fsutil.Remove(path)
+	}
+}
+
+func (o *splitOutput) size() int64 {
+	return o.manifest.TotalSize
+}
+
+// sourceReader reads an archive that is a single file or the volumes of a
+// split archive as one file
+type sourceReader struct {
+	files   []*os.File
+	offsets []int64 // Start of each file in the archive
+	total   int64
+	pos     int64 // Of Read
+}
+
+// openSource opens the archive at path, joining the volumes of a split
+// archive; see LoadSplit. The volumes are not verified here
+func openSource(path string) (*sourceReader, error) {
+	m, err := LoadSplit(path)
+	if err != nil {
+		return nil, err
+	}
+	paths := []string{path}
+	if m != nil {
+		paths = paths[:0]
+		for _, part := range m.Parts {
+			paths = append(paths, m.partPath(part))
+		}
+	}
+
+	r := &sourceReader{}
+	for _, p := range paths {
+		f, err := fsutil.Open(p)
+		if err != nil {
+			r.Close()
+			return nil, pathError(err)
+		}
+		info, err := f.Stat()
+		if err != nil {
+			f.Close()
+			r.Close()
+			return nil, pathError(err)
+		}
+		r.files = append(r.files, f)
+		r.offsets = append(r.offsets, r.total)
+		r.total += info.Size()
+	}
+	return r, nil
+}
+
+// Size returns the size of the whole archive
+func (r *sourceReader) Size() int64 {
+	return r.total
+}
+
+func (r *sourceReader) ReadAt(p []byte, off int64) (int, error) {
+	n := 0
+	for i := len(r.files) - 1; i >= 0 && len(p) > 0; i-- {
+		if off < r.offsets[i] {
+			continue
+		}
+		// Reads crossing volumes continue in the next one
+		for j := i; j < len(r.files) && len(p) > 0; j++ {
+			m, err := r.files[j].ReadAt(p, off-r.offsets[j])
+			n += m
+			off += int64(m)
+			p = p[m:]
+			if err != nil && err != io.EOF {
+				return n, err
+			}
+		}
+		break
+	}
+	if len(p) > 0 {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (r *sourceReader) Read(p []byte) (int, error) {
+	if r.pos >= r.total {
+		return 0, io.EOF
+	}
+	if int64(len(p)) > r.total-r.pos {
+		p = p[:r.total-r.pos]
+	}
+	n, err := r.ReadAt(p, r.pos)
+	r.pos += int64(n)
+	if err == io.EOF && n > 0 {
+		err = nil
+	}
+	return n, err
+}
+
+func (r *sourceReader) Close() error {
+	var err error
+	for _, f := range r.files {
+		if closeErr := f.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}
+	r.files = nil
+	return err
+}
+
+// sevenZipSource returns the path to hand 7-Zip for the archive at path:
+// the first volume of a split archive, which 7-Zip opens the set with
+func sevenZipSource(path string) string {
+	if m, err := LoadSplit(path); err == nil && m != nil {
+		return m.firstPart()
+	}
+	return path
+}
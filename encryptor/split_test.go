@@ -0,0 +1,174 @@
+package encryptor
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// encryptSplit encrypts a file of random data larger than the split size
+// and returns the source file and the result
+func encryptSplit(t *testing.T, format OutputFormat) (string, *Result) {
+	t.Helper()
+	tmpDir := t.TempDir()
+	source := createTestFileWithSize(t, tmpDir, "large.bin", 300*1024)
+
+	config := DefaultConfig()
+	config.Password = "TestPassword!"
+	config.Format = format
+	config.OutputPath = filepath.Join(tmpDir, "out", "backup.zip")
+	config.SplitSize = MinSplitSize
+	config.VerifyAfterEncrypt = true
+
+	enc, err := NewEncryptor(config)
+	if err != nil {
+		t.Fatalf("Failed to create encryptor: %v", err)
+	}
+	result, err := enc.EncryptFilesWithResult([]FileEntry{{SourcePath: source}})
+	if err != nil {
+		t.Fatalf("Encryption failed: %v", err)
+	}
+	return source, result
+}
+
+func TestSplitRoundTrip(t *testing.T) {
+	for _, format := range []OutputFormat{FormatZipAES, FormatAge} {
+		t.Run(format.String(), func(t *testing.T) {
+			source, result := encryptSplit(t, format)
+			if len(result.Parts) < 5 {
+				t.Fatalf("Parts = %v, want at least 5 volumes of 64 KB", result.Parts)
+			}
+			var total int64
+			for i, part := range result.Parts {
+				if want := splitPartName(result.OutputPath, i+1); part != want {
+					t.Errorf("part %d is %s, want %s", i+1, part, want)
+				}
+				info, err := os.Stat(part)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if info.Size() > MinSplitSize {
+					t.Errorf("%s has %d bytes, more than the split size", part, info.Size())
+				}
+				total += info.Size()
+			}
+			if total != result.ArchiveSize {
+				t.Errorf("ArchiveSize = %d, volumes hold %d bytes", result.ArchiveSize, total)
+			}
+			if _, err := os.Stat(result.OutputPath); !os.IsNotExist(err) {
+				t.Errorf("unsplit archive written too: %v", err)
+			}
+			if err := VerifySplit(result.OutputPath, nil); err != nil {
+				t.Errorf("VerifySplit: %v", err)
+			}
+
+			want, _ := os.ReadFile(source)
+			// Any volume, the manifest or the name of the joined archive
+			// opens the set
+			for _, path := range []string{result.OutputPath, result.Parts[2], result.OutputPath + SplitManifestSuffix} {
+				outDir := t.TempDir()
+				dec, _ := NewDecryptor(DecryptConfig{Password: "TestPassword!", SourcePath: path, OutputDir: outDir})
+				if err := dec.DecryptAll(); err != nil {
+					t.Fatalf("DecryptAll(%s): %v", filepath.Base(path), err)
+				}
+				if got, _ := os.ReadFile(filepath.Join(outDir, "large.bin")); !bytes.Equal(got, want) {
+					t.Errorf("DecryptAll(%s): content differs", filepath.Base(path))
+				}
+			}
+
+			joined := filepath.Join(t.TempDir(), "joined")
+			if err := JoinSplit(result.OutputPath, joined, nil); err != nil {
+				t.Fatalf("JoinSplit: %v", err)
+			}
+			if got, err := DetectFormat(joined); err != nil || got != format {
+				t.Errorf("joined archive detected as %v, %v", got, err)
+			}
+		})
+	}
+}
+
+// TestSplitDamagedPart tests a corrupted middle volume is found and named
+// before anything is extracted or joined
+func TestSplitDamagedPart(t *testing.T) {
+	_, result := encryptSplit(t, FormatZipAES)
+	middle := result.Parts[len(result.Parts)/2]
+	data, _ := os.ReadFile(middle)
+	data[100] ^= 0xFF
+	os.WriteFile(middle, data, 0644)
+
+	var splitErr *SplitError
+	err := VerifySplit(result.Parts[0], nil)
+	if !errors.Is(err, ErrSplitDamaged) || !errors.As(err, &splitErr) || splitErr.Part != filepath.Base(middle) {
+		t.Fatalf("VerifySplit error = %v, want one naming %s", err, filepath.Base(middle))
+	}
+
+	outDir := t.TempDir()
+	dec, _ := NewDecryptor(DecryptConfig{Password: "TestPassword!", SourcePath: result.OutputPath, OutputDir: outDir})
+	if err := dec.DecryptAll(); !errors.Is(err, ErrSplitDamaged) {
+		t.Errorf("DecryptAll error = %v, want ErrSplitDamaged", err)
+	}
+	if entries, _ := os.ReadDir(outDir); len(entries) != 0 {
+		t.Errorf("files extracted from a damaged set: %v", entries)
+	}
+
+	joined := filepath.Join(t.TempDir(), "joined.zip")
+	if err := JoinSplit(result.OutputPath, joined, nil); !errors.Is(err, ErrSplitDamaged) {
+		t.Errorf("JoinSplit error = %v, want ErrSplitDamaged", err)
+	}
+	if _, err := os.Stat(joined); !os.IsNotExist(err) {
+		t.Error("JoinSplit left a damaged archive")
+	}
+
+	// A missing volume is named as well
+	os.Remove(result.Parts[1])
+	if err := VerifySplit(result.OutputPath, nil); !errors.As(err, &splitErr) || splitErr.Part != filepath.Base(result.Parts[1]) {
+		t.Errorf("VerifySplit error = %v, want one naming %s", err, filepath.Base(result.Parts[1]))
+	}
+}
+
+// TestSplitCancelled tests cancelling leaves none of the volumes behind
+func TestSplitCancelled(t *testing.T) {
+	tmpDir := t.TempDir()
+	source := createTestFileWithSize(t, tmpDir, "large.bin", 1024*1024)
+	outDir := filepath.Join(tmpDir, "out")
+
+	var enc *Encryptor
+	config := DefaultConfig()
+	config.Password = "TestPassword!"
+	config.OutputPath = filepath.Join(outDir, "backup.zip")
+	config.SplitSize = MinSplitSize
+	config.CompressionLevel = 0
+	// Cancel once a few volumes are written
+	config.OnProgress = func(processed, _ int64, _ string) {
+		if processed > 4*MinSplitSize {
+			enc.Cancel()
+		}
+	}
+	enc, err := NewEncryptor(config)
+	if err != nil {
+		t.Fatalf("Failed to create encryptor: %v", err)
+	}
+
+	if err := enc.EncryptFiles([]FileEntry{{SourcePath: source}}); !errors.Is(err, ErrCancelled) {
+		t.Fatalf("error = %v, want ErrCancelled", err)
+	}
+	if entries, _ := os.ReadDir(outDir); len(entries) != 0 {
+		var names []string
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+		t.Errorf("volumes left after cancellation: %v", names)
+	}
+}
+
+func TestSplitSizeValidation(t *testing.T) {
+	config := DefaultConfig()
+	config.Password = "TestPassword!"
+	config.OutputPath = filepath.Join(t.TempDir(), "out.zip")
+	config.SplitSize = 1000
+	if _, err := NewEncryptor(config); err == nil {
+		t.Error("split size below MinSplitSize accepted")
+	}
+}
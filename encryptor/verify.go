@@ -0,0 +1,204 @@
+package encryptor
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Deleting the originals trusts the archive, so with VerifyAfterEncrypt the
+// archive is read back once it is written: every entry is decrypted with
+// the password and its SHA-256 compared with the one computed while it was
+// encrypted. A truncated write, such as on a full disk, fails here instead
+// of after the sources are gone. 7z archives are tested by 7-Zip, which
+// checks the CRC of every entry
+
+// entryHash is the SHA-256 of an entry's content as it was encrypted
+type entryHash struct {
+	name string
+	sum  [sha256.Size]byte
+}
+
+// VerifyError lists the entries whose content did not match after
+// encryption; it matches ErrVerifyFailed
+type VerifyError struct {
+	Failed []string
+	Err    error // Why the archive could not be read at all, if so
+}
+
+func (e *VerifyError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%v: %v", ErrVerifyFailed, e.Err)
+	}
+	return fmt.Sprintf("%v: %d entries differ: %s", ErrVerifyFailed, len(e.Failed), strings.Join(e.Failed, ", "))
+}
+
+func (e *VerifyError) Is(target error) bool {
+	return target == ErrVerifyFailed
+}
+
+func (e *VerifyError) Unwrap() error {
+	return e.Err
+}
+
+// verifyArchive reads the written archive back and compares the content
+// of each entry with its hash from encryption
+func (e *Encryptor) verifyArchive() error {
+	start := time.Now()
+	defer func() { e.verifyDuration = time.Since(start) }()
+
+	if e.beforeVerify != nil {
+		if err := e.beforeVerify(e.config.OutputPath); err != nil {
+			return err
+		}
+	}
+
+	if e.config.SplitSize > 0 {
+		if err := VerifySplit(e.config.OutputPath, nil); err != nil {
+			return &VerifyError{Err: err}
+		}
+	}
+
+	var err error
+	switch e.config.Format {
+	case FormatSevenZip:
+		// 7-Zip tests the CRC of every entry itself
+		err = e.verifySevenZip()
+	case FormatAge:
+		err = e.verifyEntries(e.ageEntries)
+	default:
+		err = e.verifyEntries(e.zipEntries)
+	}
+	if err != nil {
+		return err
+	}
+	e.verified = true
+	return nil
+}
+
+// entryFunc is called with each entry of the written archive in order,
+// or with the error opening it, until it returns false
+type entryFunc func(name string, content io.Reader, err error) bool
+
+// verifyEntries compares the entries read by readEntries with the hashes
+func (e *Encryptor) verifyEntries(readEntries func(fn entryFunc) error) error {
+	verr := &VerifyError{}
+	buf := make([]byte, e.config.BufferSize)
+	hash := sha256.New()
+	n := 0
+	cancelled := false
+
+	err := readEntries(func(name string, content io.Reader, err error) bool {
+		if atomic.LoadInt32(&e.cancelled) == 1 {
+			cancelled = true
+			return false
+		}
+		i := n
+		n++
+		if i >= len(e.hashes) {
+			return true
+		}
+		want := e.hashes[i]
+		switch {
+		case name != want.name:
+			verr.Failed = append(verr.Failed, want.name+" (missing)")
+		case err != nil:
+			verr.Failed = append(verr.Failed, want.name)
+		default:
+			hash.Reset()
+			_, err = io.CopyBuffer(hash, content, buf)
+			if err != nil || !bytes.Equal(hash.Sum(nil), want.sum[:]) {
+				verr.Failed = append(verr.Failed, want.name)
+			}
+		}
+		return true
+	})
+	if cancelled {
+		return ErrCancelled
+	}
+	if err != nil {
+		return verifyFailed(err)
+	}
+
+	for i := n; i < len(e.hashes); i++ {
+		verr.Failed = append(verr.Failed, e.hashes[i].name+" (missing)")
+	}
+	if n > len(e.hashes) {
+		verr.Failed = append(verr.Failed, fmt.Sprintf("%d unexpected entries", n-len(e.hashes)))
+	}
+	if len(verr.Failed) > 0 {
+		return verr
+	}
+	return nil
+}
+
+// verifyFailed wraps an error that kept the archive from being read
+func verifyFailed(err error) error {
+	var verr *VerifyError
+	if err == nil || errors.Is(err, ErrCancelled) || errors.As(err, &verr) {
+		return err
+	}
+	return &VerifyError{Err: err}
+}
+
+// zipEntries decrypts the entries of the written ZIP
+func (e *Encryptor) zipEntries(fn entryFunc) error {
+	reader, err := openArchive(e.config.OutputPath)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	for _, file := range reader.File {
+		file.SetPassword(e.config.Password)
+		rc, err := file.Open()
+		if err != nil {
+			if !fn(file.Name, nil, err) {
+				return nil
+			}
+			continue
+		}
+		more := fn(file.Name, rc, nil)
+		rc.Close()
+		if !more {
+			return nil
+		}
+	}
+	return nil
+}
+
+// ageEntries decrypts the written age file and reads the tar inside
+func (e *Encryptor) ageEntries(fn entryFunc) error {
+	f, err := openSource(e.config.OutputPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r, err := openAge(f, e.config.Password, nil)
+	if err != nil {
+		return err
+	}
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		if !fn(header.Name, tr, nil) {
+			return nil
+		}
+	}
+}
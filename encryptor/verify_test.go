@@ -0,0 +1,84 @@
+package encryptor
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestVerifyAfterEncrypt(t *testing.T) {
+	tmpDir := t.TempDir()
+	first := createTestFileWithSize(t, tmpDir, "first.bin", 64*1024)
+	second := createTestFile(t, tmpDir, "second.txt", "second")
+
+	for _, verify := range []bool{true, false} {
+		config := DefaultConfig()
+		config.Password = "TestPassword!"
+		config.OutputPath = filepath.Join(tmpDir, "out.zip")
+		config.VerifyAfterEncrypt = verify
+
+		enc, err := NewEncryptor(config)
+		if err != nil {
+			t.Fatalf("Failed to create encryptor: %v", err)
+		}
+		result, err := enc.EncryptFilesWithResult([]FileEntry{{SourcePath: first}, {SourcePath: second}})
+		if err != nil {
+			t.Fatalf("Encryption failed: %v", err)
+		}
+		if result.Verified != verify {
+			t.Errorf("VerifyAfterEncrypt %v: Verified = %v", verify, result.Verified)
+		}
+	}
+}
+
+// TestVerifyBlocksDeletion tests that an archive damaged after it was
+// written fails verification, so the originals are kept
+func TestVerifyBlocksDeletion(t *testing.T) {
+	tmpDir := t.TempDir()
+	first := createTestFileWithSize(t, tmpDir, "first.bin", 64*1024)
+	second := createTestFile(t, tmpDir, "second.txt", "second")
+
+	config := DefaultConfig()
+	config.Password = "TestPassword!"
+	config.OutputPath = filepath.Join(tmpDir, "out.zip")
+	config.CompressionLevel = 0
+	config.VerifyAfterEncrypt = true
+
+	enc, err := NewEncryptor(config)
+	if err != nil {
+		t.Fatalf("Failed to create encryptor: %v", err)
+	}
+	// Flip a byte inside the data of the first entry
+	enc.beforeVerify = func(path string) error {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		data[1024] ^= 0xFF
+		return os.WriteFile(path, data, 0644)
+	}
+
+	// Deleting the originals the way the CLI and GUI do
+	result, err := enc.EncryptFilesWithResult([]FileEntry{{SourcePath: first}, {SourcePath: second}})
+	if err == nil && result.Verified {
+		SecureDeleteMultiple([]string{first, second}, 1, nil)
+	}
+
+	if !errors.Is(err, ErrVerifyFailed) {
+		t.Fatalf("Expected ErrVerifyFailed, got %v", err)
+	}
+	var verr *VerifyError
+	if !errors.As(err, &verr) || !reflect.DeepEqual(verr.Failed, []string{"first.bin"}) {
+		t.Errorf("Expected first.bin to fail, got %v", err)
+	}
+	for _, path := range []string{first, second} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("Original %s was deleted", filepath.Base(path))
+		}
+	}
+	if _, err := os.Stat(config.OutputPath); !os.IsNotExist(err) {
+		t.Error("Damaged archive should be removed")
+	}
+}
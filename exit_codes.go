@@ -0,0 +1,53 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+
+	"github.com/kacebover/password-finder/encryptor"
+	"github.com/kacebover/password-finder/searcher"
+)
+
+// Exit codes of the CLI. 2 is left to the flag package for usage errors
+const (
+	exitOK                = 0
+	exitError             = 1
+	exitNotFound          = 3
+	exitPermission        = 4
+	exitTooLarge          = 5
+	exitDependencyMissing = 6
+	exitUnsupportedFormat = 7
+	exitFindings          = 8   // -fail-on: findings at or above the threshold
+	exitWrongPassword     = 9   // decrypt: the archive password is wrong
+	exitCancelled         = 130 // As for a process stopped with Ctrl+C
+)
+
+// exitCode maps an error of the searcher or encryptor package to the
+// CLI exit code, so scripts can tell failures apart
+func exitCode(err error) int {
+	var searchDep *searcher.ErrDependencyMissing
+	var encryptDep *encryptor.ErrDependencyMissing
+	var searchFormat *searcher.ErrUnsupportedFormat
+	var encryptFormat *encryptor.ErrUnsupportedFormat
+
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.Is(err, searcher.ErrCancelled), errors.Is(err, encryptor.ErrCancelled):
+		return exitCancelled
+	case errors.Is(err, encryptor.ErrWrongPassword):
+		return exitWrongPassword
+	case errors.Is(err, searcher.ErrNotFound), errors.Is(err, encryptor.ErrNotFound), errors.Is(err, fs.ErrNotExist):
+		return exitNotFound
+	case errors.Is(err, searcher.ErrPermission), errors.Is(err, encryptor.ErrPermission), errors.Is(err, fs.ErrPermission):
+		return exitPermission
+	case errors.Is(err, searcher.ErrTooLarge), errors.Is(err, encryptor.ErrTooLarge):
+		return exitTooLarge
+	case errors.As(err, &searchDep), errors.As(err, &encryptDep):
+		return exitDependencyMissing
+	case errors.As(err, &searchFormat), errors.As(err, &encryptFormat):
+		return exitUnsupportedFormat
+	default:
+		return exitError
+	}
+}
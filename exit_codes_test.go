@@ -0,0 +1,88 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/kacebover/password-finder/encryptor"
+	"github.com/kacebover/password-finder/searcher"
+)
+
+func TestExitCode(t *testing.T) {
+	_, statErr := os.Stat(filepath.Join(t.TempDir(), "missing"))
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{nil, exitOK},
+		{errors.New("boom"), exitError},
+		{statErr, exitNotFound},
+		{fmt.Errorf("scan: %w", searcher.ErrNotFound), exitNotFound},
+		{fmt.Errorf("encrypt: %w", encryptor.ErrPermission), exitPermission},
+		{searcher.ErrTooLarge, exitTooLarge},
+		{&searcher.ErrDependencyMissing{Name: searcher.DependencyTesseract}, exitDependencyMissing},
+		{fmt.Errorf("extract: %w", &searcher.ErrUnsupportedFormat{Ext: ".xyz"}), exitUnsupportedFormat},
+		{encryptor.ErrCancelled, exitCancelled},
+		{fmt.Errorf("%w: out.zip", encryptor.ErrWrongPassword), exitWrongPassword},
+	}
+	for _, tt := range tests {
+		if got := exitCode(tt.err); got != tt.want {
+			t.Errorf("exitCode(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
+
+// TestRunScanFailOn runs runScan in a child process, because it ends the
+// process, and checks the exit code -fail-on gives
+func TestRunScanFailOn(t *testing.T) {
+	if dir := os.Getenv("DATALEAK_TEST_SCAN_DIR"); dir != "" {
+		runScan(scanOptions{
+			ScanDir:   dir,
+			OutputDir: os.Getenv("DATALEAK_TEST_OUTPUT_DIR"),
+			MaxSize:   searcher.MaxFileSize,
+			Formats:   []string{searcher.FormatJUnit},
+			FailOn:    searcher.Severity(os.Getenv("DATALEAK_TEST_FAIL_ON")),
+		})
+		os.Exit(exitOK)
+	}
+
+	scanDir := t.TempDir()
+	// Only a Medium finding
+	os.WriteFile(filepath.Join(scanDir, "contacts.txt"), []byte("support: help@example.org\n"), 0644)
+
+	tests := []struct {
+		failOn string
+		want   int
+	}{
+		{"", exitOK},
+		{"high", exitOK},
+		{"medium", exitFindings},
+		{"low", exitFindings},
+	}
+	for _, tt := range tests {
+		outputDir := t.TempDir()
+		cmd := exec.Command(os.Args[0], "-test.run=^TestRunScanFailOn$")
+		cmd.Env = append(os.Environ(),
+			"DATALEAK_TEST_SCAN_DIR="+scanDir,
+			"DATALEAK_TEST_OUTPUT_DIR="+outputDir,
+			"DATALEAK_TEST_FAIL_ON="+tt.failOn)
+		out, err := cmd.CombinedOutput()
+		code := 0
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			code = exitErr.ExitCode()
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		if code != tt.want {
+			t.Errorf("-fail-on %q: exit code %d, want %d\n%s", tt.failOn, code, tt.want, out)
+		}
+		if reports, _ := filepath.Glob(filepath.Join(outputDir, searcher.LatestReportDir, "*.junit.xml")); len(reports) != 1 {
+			t.Errorf("-fail-on %q: the JUnit report should be written before exiting, got %v", tt.failOn, reports)
+		}
+	}
+}
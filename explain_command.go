@@ -0,0 +1,114 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/kacebover/password-finder/searcher"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════
+// КОМАНДА ОБЪЯСНЕНИЯ ОЦЕНКИ РИСКА
+// ═══════════════════════════════════════════════════════════════════════════
+
+func runExplainCommand(args []string) {
+	explainCmd := flag.NewFlagSet("explain", flag.ExitOnError)
+
+	findingID := explainCmd.String("finding-id", "", "Номер находки в отчёте или начало её отпечатка (обязательно)")
+	reportPath := explainCmd.String("report", "", "Отчёт JSON или .dllreport (обязательно)")
+	weightsPath := explainCmd.String("weights", "", "Файл с разделом risk_weights (по умолчанию: встроенные веса)")
+
+	explainCmd.Usage = func() {
+		fmt.Print(ui.T("cli.explain.usage"))
+	}
+
+	if err := explainCmd.Parse(args); err != nil {
+		os.Exit(1)
+	}
+
+	if *findingID == "" || *reportPath == "" {
+		explainCmd.Usage()
+		os.Exit(1)
+	}
+
+	weights := searcher.DefaultRiskWeights()
+	if *weightsPath != "" {
+		var err error
+		if weights, err = searcher.LoadRiskWeights(*weightsPath); err != nil {
+			fmt.Println(ui.T("cli.error", err))
+			os.Exit(1)
+		}
+	}
+
+	result, err := searcher.LoadScanResult(*reportPath)
+	if err != nil {
+		fmt.Println(ui.T("cli.error", err))
+		os.Exit(1)
+	}
+
+	if err := explainFinding(os.Stdout, result, *findingID, weights); err != nil {
+		fmt.Println(ui.T("cli.error", err))
+		os.Exit(1)
+	}
+}
+
+// findReportFinding looks a finding up by its 1-based number in the report
+// or by a prefix of its fingerprint
+func findReportFinding(result *searcher.ScanResult, id string) (int, *searcher.Finding, error) {
+	id = strings.TrimSpace(id)
+	if n, err := strconv.Atoi(id); err == nil {
+		if n < 1 || n > len(result.Findings) {
+			return 0, nil, errors.New(ui.T("cli.explain.no_number", n, len(result.Findings)))
+		}
+		return n, result.Findings[n-1], nil
+	}
+
+	if len(id) < 4 {
+		return 0, nil, errors.New(ui.T("cli.explain.short_id", id))
+	}
+	index := -1
+	for i, f := range result.Findings {
+		if strings.HasPrefix(f.Fingerprint(), strings.ToLower(id)) {
+			if index >= 0 {
+				return 0, nil, errors.New(ui.T("cli.explain.ambiguous", id))
+			}
+			index = i
+		}
+	}
+	if index < 0 {
+		return 0, nil, errors.New(ui.T("cli.explain.not_found", id))
+	}
+	return index + 1, result.Findings[index], nil
+}
+
+// explainFinding prints the factor contributions of a reported finding's risk score
+func explainFinding(w io.Writer, result *searcher.ScanResult, id string, weights searcher.RiskWeights) error {
+	n, finding, err := findReportFinding(result, id)
+	if err != nil {
+		return err
+	}
+
+	score, steps := searcher.NewRiskScorerWithWeights(weights).ForScanRoot(result.Root).ExplainFindingScore(finding)
+
+	fmt.Fprintln(w, ui.T("cli.explain.title", n, finding.Fingerprint()))
+	fmt.Fprintln(w, ui.T("cli.explain.file", finding.FilePath, finding.LineNumber))
+	fmt.Fprintln(w, ui.T("cli.explain.type", ui.Pattern(string(finding.PatternType))))
+	fmt.Fprintln(w, ui.T("cli.explain.severity", ui.Severity(string(finding.Severity))))
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, ui.T("cli.explain.why"))
+	for _, line := range strings.Split(strings.TrimRight(searcher.FormatScoreSteps(ui, score, steps), "\n"), "\n") {
+		fmt.Fprintf(w, "   %s\n", line)
+	}
+
+	if math.Abs(score-finding.RiskScore) >= 0.05 {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, ui.T("cli.explain.stale", finding.RiskScore))
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/kacebover/password-finder/searcher"
+)
+
+// explainFixture returns a report with two findings scored by the default
+// scorer, in a file no path rule weighs
+func explainFixture() *searcher.ScanResult {
+	scanner := searcher.NewScanner()
+	result := searcher.NewScanResult()
+	for _, line := range []string{`db_password = "Xk9#mP2$vL5@nQ8!rT4"`, `contact: admin@company.ru`} {
+		trace := scanner.Evaluate(line, "config.yaml")
+		for _, match := range trace.Matches {
+			result.AddFinding(&searcher.Finding{
+				FilePath:    "config.yaml",
+				LineNumber:  1,
+				PatternType: match.Type,
+				Severity:    match.FinalSeverity,
+				MatchedText: match.MatchText,
+				Context:     line,
+				RiskScore:   match.RiskScore,
+			})
+		}
+	}
+	return result
+}
+
+// TestExplainFinding tests lookup by number and fingerprint and the score breakdown
+func TestExplainFinding(t *testing.T) {
+	result := explainFixture()
+	if len(result.Findings) < 2 {
+		t.Fatalf("fixture has %d findings, want at least 2", len(result.Findings))
+	}
+
+	var out bytes.Buffer
+	if err := explainFinding(&out, result, "1", searcher.DefaultRiskWeights()); err != nil {
+		t.Fatalf("explainFinding: %v", err)
+	}
+	for _, want := range []string{"Находка №1", "severity", "entropy", "length", "context", "итого"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "⚠️") {
+		t.Errorf("default weights should reproduce the stored score:\n%s", out.String())
+	}
+
+	last := result.Findings[len(result.Findings)-1]
+	n, found, err := findReportFinding(result, last.Fingerprint()[:8])
+	if err != nil || found != last || n != len(result.Findings) {
+		t.Errorf("fingerprint lookup = %d, %v, %v", n, found, err)
+	}
+
+	weights := searcher.DefaultRiskWeights()
+	weights.Multipliers.Severity = 2
+	out.Reset()
+	if err := explainFinding(&out, result, "1", weights); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "×2") || !strings.Contains(out.String(), "⚠️") {
+		t.Errorf("custom weights should be noted and the stored score flagged:\n%s", out.String())
+	}
+
+	for _, id := range []string{"0", "99", "ab", "ffffffff"} {
+		if _, _, err := findReportFinding(result, id); err == nil {
+			t.Errorf("findReportFinding(%q) should fail", id)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+// Package fsutil adapts file paths to the platform before they reach the
+// os package. On Windows, paths longer than MAX_PATH and files named like
+// a device (aux.txt, con.log) only work in the extended-length form
+// \\?\C:\...; Normalize returns that form when it is needed and the path
+// unchanged everywhere else. The wrappers below normalize their arguments
+// and report errors with the path the caller passed
+package fsutil
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LongPathLimit is the path length from which Windows needs the extended
+// form. MAX_PATH is 260, but directories must leave room for an 8.3 name
+const LongPathLimit = 248
+
+// Prefixes of extended-length paths
+const (
+	extendedPrefix = `\\?\`
+	extendedUNC    = `\\?\UNC\`
+)
+
+// reservedNames are the Windows device names; "aux.txt" still opens the
+// AUX device, the extension does not help
+var reservedNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true, "CONIN$": true, "CONOUT$": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true,
+	"COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true,
+	"LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// IsReservedName reports whether a file name is a Windows device name,
+// with or without an extension and trailing dots or spaces
+func IsReservedName(name string) bool {
+	if i := strings.IndexByte(name, '.'); i >= 0 {
+		name = name[:i]
+	}
+	name = strings.TrimRight(name, " ")
+	return reservedNames[strings.ToUpper(name)]
+}
+
+// extendedPath converts a clean absolute Windows path to the extended
+// form: C:\dir becomes \\?\C:\dir and \\server\share becomes
+// \\?\UNC\server\share. Paths already in that form are returned as they are
+func extendedPath(abs string) string {
+	switch {
+	case strings.HasPrefix(abs, extendedPrefix):
+		return abs
+	case strings.HasPrefix(abs, `\\`):
+		return extendedUNC + abs[2:]
+	default:
+		return extendedPrefix + abs
+	}
+}
+
+// Display strips the extended-length prefix for messages
+func Display(path string) string {
+	switch {
+	case strings.HasPrefix(path, extendedUNC):
+		return `\\` + path[len(extendedUNC):]
+	case strings.HasPrefix(path, extendedPrefix):
+		return path[len(extendedPrefix):]
+	}
+	return path
+}
+
+// restorePath puts the caller's path back into an error of the os package
+func restorePath(err error, path string) error {
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		pathErr.Path = path
+		return err
+	}
+	var linkErr *os.LinkError
+	if errors.As(err, &linkErr) {
+		linkErr.Old = Display(linkErr.Old)
+		linkErr.New = Display(linkErr.New)
+	}
+	return err
+}
+
+// Open is os.Open with a normalized path
+func Open(name string) (*os.File, error) {
+	f, err := os.Open(Normalize(name))
+	return f, restorePath(err, name)
+}
+
+// OpenFile is os.OpenFile with a normalized path
+func OpenFile(name string, flag int, perm os.FileMode) (*os.File, error) {
+	f, err := os.OpenFile(Normalize(name), flag, perm)
+	return f, restorePath(err, name)
+}
+
+// Create is os.Create with a normalized path
+func Create(name string) (*os.File, error) {
+	f, err := os.Create(Normalize(name))
+	return f, restorePath(err, name)
+}
+
+// CreateTemp is os.CreateTemp in a normalized directory
+func CreateTemp(dir, pattern string) (*os.File, error) {
+	f, err := os.CreateTemp(Normalize(dir), pattern)
+	return f, restorePath(err, dir)
+}
+
+// Stat is os.Stat with a normalized path
+func Stat(name string) (os.FileInfo, error) {
+	info, err := os.Stat(Normalize(name))
+	return info, restorePath(err, name)
+}
+
+// Lstat is os.Lstat with a normalized path
+func Lstat(name string) (os.FileInfo, error) {
+	info, err := os.Lstat(Normalize(name))
+	return info, restorePath(err, name)
+}
+
+// ReadDir is os.ReadDir with a normalized path
+func ReadDir(name string) ([]os.DirEntry, error) {
+	entries, err := os.ReadDir(Normalize(name))
+	return entries, restorePath(err, name)
+}
+
+// ReadFile is os.ReadFile with a normalized path
+func ReadFile(name string) ([]byte, error) {
+	data, err := os.ReadFile(Normalize(name))
+	return data, restorePath(err, name)
+}
+
+// MkdirAll is os.MkdirAll with a normalized path
+func MkdirAll(path string, perm os.FileMode) error {
+	return restorePath(os.MkdirAll(Normalize(path), perm), path)
+}
+
+// Remove is os.Remove with a normalized path
+func Remove(name string) error {
+	return restorePath(os.Remove(Normalize(name)), name)
+}
+
+// Rename is os.Rename with normalized paths
+func Rename(oldpath, newpath string) error {
+	return restorePath(os.Rename(Normalize(oldpath), Normalize(newpath)), oldpath)
+}
+
+// Chmod is os.Chmod with a normalized path
+func Chmod(name string, mode os.FileMode) error {
+	return restorePath(os.Chmod(Normalize(name), mode), name)
+}
+
+// Walk is filepath.Walk over a normalized root; fn gets the paths below
+// root spelled the way the caller passed root
+func Walk(root string, fn filepath.WalkFunc) error {
+	normalized := Normalize(root)
+	return filepath.Walk(normalized, func(path string, info os.FileInfo, err error) error {
+		if normalized != root && strings.HasPrefix(path, normalized) {
+			path = root + path[len(normalized):]
+		}
+		return fn(path, info, restorePath(err, path))
+	})
+}
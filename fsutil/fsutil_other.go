@@ -0,0 +1,9 @@
+//go:build !windows
+
+package fsutil
+
+// Normalize returns path unchanged: only Windows limits path length and
+// reserves device names
+func Normalize(path string) string {
+	return path
+}
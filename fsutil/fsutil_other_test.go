@@ -0,0 +1,20 @@
+//go:build !windows
+
+package fsutil
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalizeIsIdentity(t *testing.T) {
+	for _, path := range []string{
+		"/home/dev/aux.txt",
+		"con.log",
+		"/" + strings.Repeat("node_modules/pkg/", 30) + "index.js",
+	} {
+		if got := Normalize(path); got != path {
+			t.Errorf("Normalize(%q) = %q", path, got)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package fsutil
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIsReservedName(t *testing.T) {
+	for name, want := range map[string]bool{
+		"aux.txt":     true,
+		"CON.log":     true,
+		"nul":         true,
+		"com1.tar.gz": true,
+		"LPT9":        true,
+		"con .txt":    true,
+		"conin$":      true,
+		"auxiliary":   false,
+		"com10.txt":   false,
+		"icon.png":    false,
+		"my-aux.txt":  false,
+		"":            false,
+	} {
+		if got := IsReservedName(name); got != want {
+			t.Errorf("IsReservedName(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestExtendedPath(t *testing.T) {
+	for path, want := range map[string]string{
+		`C:\Users\dev\aux.txt`:        `\\?\C:\Users\dev\aux.txt`,
+		`\\server\share\dir\file.txt`: `\\?\UNC\server\share\dir\file.txt`,
+		`\\?\C:\already`:              `\\?\C:\already`,
+	} {
+		got := extendedPath(path)
+		if got != want {
+			t.Errorf("extendedPath(%q) = %q, want %q", path, got, want)
+		}
+		if back := Display(got); back != path && path != `\\?\C:\already` {
+			t.Errorf("Display(%q) = %q, want %q", got, back, path)
+		}
+	}
+}
+
+func TestErrorsKeepCallerPath(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "deep", "missing.txt")
+
+	_, err := Open(missing)
+	if err == nil || !os.IsNotExist(err) {
+		t.Fatalf("Open error = %v, want not exist", err)
+	}
+	if !strings.Contains(err.Error(), missing) {
+		t.Errorf("error %q does not name %s", err, missing)
+	}
+	if err := Rename(missing, missing+".bak"); err == nil || !strings.Contains(err.Error(), missing) {
+		t.Errorf("Rename error %v does not name %s", err, missing)
+	}
+	if _, err := ReadDir(missing); err == nil || !strings.Contains(err.Error(), missing) {
+		t.Errorf("ReadDir error %v does not name %s", err, missing)
+	}
+}
+
+func TestWalkKeepsRootSpelling(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "a", "b"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "a", "b", "f.txt"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var files []string
+	err := Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || files[0] != filepath.Join(root, "a", "b", "f.txt") {
+		t.Errorf("Walk visited %v", files)
+	}
+}
+
+func TestParseFileSize(t *testing.T) {
+	for s, want := range map[string]int64{
+		"100":     100,
+		"64KB":    64 << 10,
+		"500MB":   500 << 20,
+		"1.5GB":   3 << 29,
+		"1,5 ГБ":  3 << 29,
+		"700 мб":  700 << 20,
+		"2g":      2 << 30,
+		" 10 B ":  10,
+		"1TB":     1 << 40,
+		"0.5 MiB": 1 << 19,
+	} {
+		got, err := ParseFileSize(s)
+		if err != nil || got != want {
+			t.Errorf("ParseFileSize(%q) = %d, %v; want %d", s, got, err, want)
+		}
+	}
+	for _, s := range []string{"", "MB", "10 XB", "1.2.3MB", "-5MB"} {
+		if got, err := ParseFileSize(s); err == nil {
+			t.Errorf("ParseFileSize(%q) = %d, want an error", s, got)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package fsutil
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Normalize returns the extended-length form of path when it is too long
+// for the Win32 API or names a device, and path otherwise
+func Normalize(path string) string {
+	if path == "" || strings.HasPrefix(path, extendedPrefix) {
+		return path
+	}
+	if len(path) < LongPathLimit && !IsReservedName(filepath.Base(path)) {
+		return path
+	}
+	// The extended form is passed to the file system as is, so the path
+	// must be absolute and clean first
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	if len(abs) < LongPathLimit && !IsReservedName(filepath.Base(abs)) {
+		return path
+	}
+	return extendedPath(abs)
+}
@@ -0,0 +1,51 @@
+package fsutil
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeWindows(t *testing.T) {
+	long := `C:\` + strings.Repeat(`node_modules\pkg\`, 20) + "index.js"
+	if got := Normalize(long); got != `\\?\`+long {
+		t.Errorf("long path = %q", got)
+	}
+	if got := Normalize(`C:\project\aux.txt`); got != `\\?\C:\project\aux.txt` {
+		t.Errorf("reserved name = %q", got)
+	}
+	if got := Normalize(`C:\project\main.go`); got != `C:\project\main.go` {
+		t.Errorf("short path should stay unchanged, got %q", got)
+	}
+	if got := Normalize(`\\server\share\` + strings.Repeat("x", 300)); !strings.HasPrefix(got, `\\?\UNC\server\share\`) {
+		t.Errorf("long UNC path = %q", got)
+	}
+	// Relative paths are made absolute and clean, the extended form is not parsed
+	wd, _ := os.Getwd()
+	if got := Normalize(`.\sub\..\con.log`); got != `\\?\`+filepath.Join(wd, "con.log") {
+		t.Errorf("relative reserved name = %q", got)
+	}
+}
+
+func TestLongPathRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	for len(dir) < 300 {
+		dir = filepath.Join(dir, strings.Repeat("d", 40))
+	}
+	if err := MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "aux.txt")
+	f, err := Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	if _, err := Stat(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := Remove(path); err != nil {
+		t.Fatal(err)
+	}
+}
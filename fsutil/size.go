@@ -0,0 +1,48 @@
+package fsutil
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// sizeUnits are the multipliers of ParseFileSize, powers of 1024 as in the
+// sizes the GUI shows
+var sizeUnits = map[string]int64{
+	"": 1, "b": 1, "б": 1,
+	"k": 1 << 10, "kb": 1 << 10, "kib": 1 << 10, "к": 1 << 10, "кб": 1 << 10,
+	"m": 1 << 20, "mb": 1 << 20, "mib": 1 << 20, "м": 1 << 20, "мб": 1 << 20,
+	"g": 1 << 30, "gb": 1 << 30, "gib": 1 << 30, "г": 1 << 30, "гб": 1 << 30,
+	"t": 1 << 40, "tb": 1 << 40, "tib": 1 << 40, "т": 1 << 40, "тб": 1 << 40,
+}
+
+// ParseFileSize parses a size such as "500MB", "1.5 GB" or "700 МБ" into
+// bytes. Units are case-insensitive powers of 1024; a bare number is bytes.
+// Both "." and "," are taken as the decimal separator
+func ParseFileSize(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexFunc(s, func(r rune) bool {
+		return !unicode.IsDigit(r) && r != '.' && r != ','
+	})
+	if i < 0 {
+		i = len(s)
+	}
+	number := strings.ReplaceAll(s[:i], ",", ".")
+	unit := strings.ToLower(strings.TrimSpace(s[i:]))
+
+	multiplier, ok := sizeUnits[unit]
+	if !ok {
+		return 0, fmt.Errorf("неизвестная единица размера %q", s[i:])
+	}
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil || number == "" {
+		return 0, fmt.Errorf("неверный размер %q", s)
+	}
+	bytes := value * float64(multiplier)
+	if bytes >= math.MaxInt64 {
+		return 0, fmt.Errorf("слишком большой размер %q", s)
+	}
+	return int64(math.Round(bytes)), nil
+}
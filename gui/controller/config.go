@@ -5,6 +5,8 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+
+	"github.com/kacebover/password-finder/fsutil"
 )
 
 // AppConfig holds all application configuration
@@ -40,6 +42,9 @@ type AppConfig struct {
 	
 	// Feature flags
 	OCREnabled bool `json:"ocr_enabled"`
+
+	// Optional rule packs enabled for every scan, e.g. "medical", "hr"
+	Packs []string `json:"packs,omitempty"`
 }
 
 // DefaultConfig returns the default configuration
@@ -247,32 +252,10 @@ func formatFloat(f float64) string {
 	return formatInt(whole) + "." + string(rune('0'+frac/10)) + string(rune('0'+frac%10))
 }
 
-// ParseFileSize parses a human readable file size string to bytes
+// ParseFileSize parses a human readable file size string to bytes, see
+// fsutil.ParseFileSize; it returns 0 for a string it cannot parse
 func ParseFileSize(s string) int64 {
-	// Simple implementation
-	var size int64
-	var unit string
-	
-	for i, c := range s {
-		if c >= '0' && c <= '9' {
-			size = size*10 + int64(c-'0')
-		} else {
-			unit = s[i:]
-			break
-		}
-	}
-	
-	switch unit {
-	case "KB", "kb", "K", "k":
-		return size * 1024
-	case "MB", "mb", "M", "m":
-		return size * 1024 * 1024
-	case "GB", "gb", "G", "g":
-		return size * 1024 * 1024 * 1024
-	case "TB", "tb", "T", "t":
-		return size * 1024 * 1024 * 1024 * 1024
-	default:
-		return size
-	}
+	size, _ := fsutil.ParseFileSize(s)
+	return size
 }
 
@@ -4,9 +4,12 @@ package controller
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"os"
+	"fmt"
 	"path/filepath"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/kacebover/password-finder/encryptor"
@@ -29,8 +32,17 @@ type ScanController struct {
 	// State
 	mu            sync.RWMutex
 	currentResult *searcher.ScanResult
+	history       *ResultHistory // Previous results are archived here when a new scan starts
 	isScanning    bool
 	isPaused      bool
+	results       *ResultsModel // Findings of the current scan grouped by file
+	
+	// Baseline index, built once in SetBaseline and read without locks
+	baseline atomic.Pointer[searcher.BaselineIndex]
+
+	// Notifications of the next scans, see SetNotifier
+	notifier   searcher.Notifier
+	notifyMode searcher.NotifyMode
 	
 	// Ignore list (persistent)
 	ignoredFindings map[string]bool // key: filepath:line:pattern
@@ -51,16 +63,27 @@ const (
 func NewScanController() *ScanController {
 	ctrl := &ScanController{
 		config:          LoadConfig(),
+		history:         DefaultResultHistory(),
 		ignoredFindings: make(map[string]bool),
 		ignoredFiles:    make(map[string]bool),
+		results:         NewResultsModel(),
 	}
 	
 	// Load persisted ignore list
 	ctrl.loadIgnoreList()
+	ctrl.results.SetIgnored(func(path string) bool {
+		return ctrl.ignoredFiles[path]
+	})
 	
 	return ctrl
 }
 
+// Results returns the findings of the current scan grouped by file, with
+// the selection and the filters of the results list
+func (sc *ScanController) Results() *ResultsModel {
+	return sc.results
+}
+
 // SetOnFinding sets the callback for new findings
 func (sc *ScanController) SetOnFinding(callback func(*searcher.Finding)) {
 	sc.onFinding = callback
@@ -86,6 +109,39 @@ func (sc *ScanController) SetOnComplete(callback func(*searcher.ScanResult, erro
 	sc.onComplete = callback
 }
 
+// SetBaseline loads a previous report so findings of the next scans are
+// tagged new or known as they arrive; nil removes the baseline
+func (sc *ScanController) SetBaseline(result *searcher.ScanResult) {
+	if result == nil {
+		sc.baseline.Store(nil)
+		return
+	}
+	index := searcher.NewBaselineIndex(result)
+	sc.baseline.Store(index)
+	sc.log(LogInfo, fmt.Sprintf("Baseline loaded: %d findings", index.Len()))
+}
+
+// SetNotifier sends the summary of the next scans, and the findings mode
+// selects, to n; nil turns notifications off
+func (sc *ScanController) SetNotifier(n searcher.Notifier, mode searcher.NotifyMode) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.notifier = n
+	sc.notifyMode = mode
+}
+
+// HasBaseline reports whether a baseline is set
+func (sc *ScanController) HasBaseline() bool {
+	return sc.baseline.Load() != nil
+}
+
+// BaselineCounts returns how many findings of the current scan are new and
+// how many were already in the baseline
+func (sc *ScanController) BaselineCounts() (newCount, known int64) {
+	progress := sc.GetProgress()
+	return progress.NewFindings, progress.KnownFindings
+}
+
 // GetConfig returns the current configuration
 func (sc *ScanController) GetConfig() *AppConfig {
 	return sc.config
@@ -106,8 +162,19 @@ func (sc *ScanController) StartScan(targetDir string) error {
 	}
 	sc.isScanning = true
 	sc.isPaused = false
+	previous := sc.currentResult
+	notifier, notifyMode := sc.notifier, sc.notifyMode
 	sc.mu.Unlock()
 	
+	// Keep only one result in memory: the previous one goes to the history
+	if previous != nil {
+		if _, err := sc.ReleaseResult(); err != nil {
+			sc.log(LogWarning, err.Error())
+			sc.dropResult(previous)
+		}
+	}
+	sc.results.Clear()
+	
 	// Create scanner with current config
 	scannerConfig := searcher.StreamingScannerConfig{
 		MaxFileSize:    sc.config.MaxFileSize,
@@ -121,6 +188,11 @@ func (sc *ScanController) StartScan(targetDir string) error {
 	}
 	
 	sc.scanner = searcher.NewStreamingScanner(scannerConfig)
+	sc.scanner.SetBaseline(sc.baseline.Load())
+	sc.scanner.SetNotifier(notifier, notifyMode)
+	if err := sc.scanner.EnablePacks(sc.config.Packs); err != nil {
+		sc.log(LogWarning, "Rule packs: "+err.Error())
+	}
 	
 	ctx, cancel := context.WithCancel(context.Background())
 	sc.cancelFunc = cancel
@@ -132,12 +204,20 @@ func (sc *ScanController) StartScan(targetDir string) error {
 	}
 	
 	// Start event processing goroutine
-	go sc.processEvents()
+	eventsDone := make(chan struct{})
+	go func() {
+		sc.processEvents()
+		close(eventsDone)
+	}()
 	
 	// Start scan in background
 	go func() {
 		result, err := sc.scanner.Scan(ctx, targetDir)
 		
+		// Scan closes the event channel; deliver the remaining findings
+		// before reporting completion
+		<-eventsDone
+		
 		sc.mu.Lock()
 		sc.currentResult = result
 		sc.isScanning = false
@@ -145,7 +225,7 @@ func (sc *ScanController) StartScan(targetDir string) error {
 		sc.mu.Unlock()
 		
 		if err != nil {
-			if err == context.Canceled {
+			if errors.Is(err, searcher.ErrCancelled) {
 				sc.log(LogInfo, "Scan cancelled by user")
 			} else {
 				sc.log(LogError, "Scan error: "+err.Error())
@@ -153,6 +233,10 @@ func (sc *ScanController) StartScan(targetDir string) error {
 		} else {
 			sc.log(LogInfo, "Scan completed successfully")
 		}
+		if result != nil && sc.HasBaseline() {
+			newCount, known := searcher.CountBaseline(result.Findings)
+			sc.log(LogInfo, fmt.Sprintf("Baseline comparison: %d new, %d known", newCount, known))
+		}
 		
 		if sc.onComplete != nil {
 			sc.onComplete(result, err)
@@ -167,7 +251,11 @@ func (sc *ScanController) processEvents() {
 	for event := range sc.scanner.Events() {
 		switch event.Type {
 		case searcher.EventFinding:
-			if sc.onFinding != nil && !sc.isIgnored(event.Finding) {
+			if sc.isIgnored(event.Finding) {
+				break
+			}
+			sc.results.Add(event.Finding)
+			if sc.onFinding != nil {
 				sc.onFinding(event.Finding)
 			}
 			
@@ -307,24 +395,51 @@ func (sc *ScanController) ExportCSV(filePath string) error {
 	return reporter.ExportCSV(filePath)
 }
 
-// ExportAll exports results to all formats
-func (sc *ScanController) ExportAll(outputDir string) error {
+// ExportAll exports results to all formats and returns the report directory
+func (sc *ScanController) ExportAll(outputDir string) (string, error) {
 	sc.mu.RLock()
 	result := sc.currentResult
 	sc.mu.RUnlock()
 	
 	if result == nil {
-		return nil
+		return "", nil
 	}
 	
 	if err := os.MkdirAll(outputDir, 0755); err != nil {
-		return err
+		return "", err
 	}
 	
 	reporter := searcher.NewReportGenerator(result)
 	return reporter.GenerateReport(outputDir)
 }
 
+// ExportSelected exports the findings of the given files in the given
+// formats, leaving out ignored ones, and returns the report directory
+func (sc *ScanController) ExportSelected(outputDir string, paths []string, formats []string) (string, error) {
+	sc.mu.RLock()
+	result := sc.currentResult
+	sc.mu.RUnlock()
+
+	if result == nil {
+		return "", nil
+	}
+
+	files := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		files[path] = true
+	}
+	selected := result.FilterFindings(func(f *searcher.Finding) bool {
+		return files[f.FilePath] && !sc.isIgnored(f)
+	})
+
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return "", err
+	}
+
+	reporter := searcher.NewReportGenerator(selected)
+	return reporter.GenerateReportFormats(outputDir, formats)
+}
+
 // IgnoreFinding marks a finding as ignored (false positive)
 func (sc *ScanController) IgnoreFinding(finding *searcher.Finding) {
 	key := sc.findingKey(finding)
@@ -335,6 +450,7 @@ func (sc *ScanController) IgnoreFinding(finding *searcher.Finding) {
 // IgnoreFile marks all findings in a file as ignored
 func (sc *ScanController) IgnoreFile(filePath string) {
 	sc.ignoredFiles[filePath] = true
+	sc.results.Invalidate()
 	sc.saveIgnoreList()
 }
 
@@ -428,6 +544,7 @@ func (sc *ScanController) GetIgnoredCount() (int, int) {
 func (sc *ScanController) ClearIgnoreList() {
 	sc.ignoredFindings = make(map[string]bool)
 	sc.ignoredFiles = make(map[string]bool)
+	sc.results.Invalidate()
 	sc.saveIgnoreList()
 }
 
@@ -436,9 +553,24 @@ type EncryptionConfig struct {
 	Password          string
 	OutputPath        string
 	DeleteOriginals   bool
-	DeletePasses      int  // Number of secure deletion passes (default: 3)
-	CompressionLevel  int  // 0-9, default 6
-	UseAES256         bool // default true
+	DeletePasses      int      // Number of secure deletion passes (default: 3)
+	CompressionLevel  int      // 0-9, default 6
+	UseAES256         bool     // default true
+	ExcludeGlobs      []string // Paths left out inside directories, see encryptor.Config
+	Format            encryptor.OutputFormat
+	SevenZipPath      string // 7-Zip binary for encryptor.FormatSevenZip, looked up when empty
+	SplitSize         int64  // Volume size, 0 for one archive, see encryptor.Config
+
+	// SevenZipPasswordArg allows the password on the 7-Zip command line,
+	// see encryptor.Config
+	SevenZipPasswordArg bool
+
+	// IncludeManifest adds MANIFEST.json and MANIFEST.txt listing the files
+	// and the Findings that put them in the archive, see ManifestSource
+	IncludeManifest bool
+	Findings        []*searcher.Finding
+	ScanRoot        string
+	ScanTime        time.Time
 }
 
 // EncryptionProgress represents encryption progress
@@ -457,6 +589,8 @@ type EncryptionResult struct {
 	ArchiveSize      int64
 	CompressionRatio float64
 	FilesDeleted     int
+	Verified         bool     // The archive was read back intact, see encryptor.Config.VerifyAfterEncrypt
+	Parts            []string // Volumes written with SplitSize
 }
 
 // EncryptFiles encrypts the specified files into a password-protected ZIP archive
@@ -490,6 +624,16 @@ func (sc *ScanController) EncryptFiles(
 	if config.UseAES256 {
 		encConfig.Method = encryptor.AES256
 	}
+	encConfig.ExcludeGlobs = config.ExcludeGlobs
+	encConfig.Format = config.Format
+	encConfig.SevenZipPath = config.SevenZipPath
+	encConfig.SevenZipPasswordArg = config.SevenZipPasswordArg
+	encConfig.VerifyAfterEncrypt = config.DeleteOriginals
+	encConfig.SplitSize = config.SplitSize
+	if config.IncludeManifest {
+		encConfig.IncludeManifest = true
+		encConfig.ManifestSource = ManifestSource(config.Findings, config.ScanRoot, config.ScanTime)
+	}
 
 	// Set up progress callback
 	if onProgress != nil {
@@ -529,10 +673,12 @@ func (sc *ScanController) EncryptFiles(
 		TotalSize:        result.TotalSize,
 		ArchiveSize:      result.ArchiveSize,
 		CompressionRatio: result.CompressionRatio,
+		Verified:         result.Verified,
+		Parts:            result.Parts,
 	}
 
-	// Delete originals if requested
-	if config.DeleteOriginals {
+	// Delete originals if requested, only from a verified archive
+	if config.DeleteOriginals && result.Verified {
 		passes := config.DeletePasses
 		if passes <= 0 {
 			passes = 3
@@ -555,6 +701,33 @@ func (sc *ScanController) EncryptFiles(
 	return encResult, nil
 }
 
+// ManifestSource groups findings by file for the manifest of an encrypted
+// archive. Only the pattern, line, severity and risk score are kept, never
+// the matched text. Relative paths are resolved against root
+func ManifestSource(findings []*searcher.Finding, root string, scanTime time.Time) *encryptor.ManifestSource {
+	source := &encryptor.ManifestSource{
+		ScanRoot: root,
+		ScanTime: scanTime,
+		Findings: make(map[string][]encryptor.ManifestFinding),
+	}
+	for _, f := range findings {
+		path := f.FilePath
+		if !filepath.IsAbs(path) && root != "" {
+			path = filepath.Join(root, path)
+		}
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+		source.Findings[path] = append(source.Findings[path], encryptor.ManifestFinding{
+			Pattern:   string(f.PatternType),
+			Line:      f.LineNumber,
+			Severity:  string(f.Severity),
+			RiskScore: f.RiskScore,
+		})
+	}
+	return source
+}
+
 // GenerateSecurePassword generates a cryptographically secure password
 func (sc *ScanController) GenerateSecurePassword(length int, alphanumericOnly bool) (string, error) {
 	if alphanumericOnly {
@@ -1,12 +1,18 @@
 package controller
 
 import (
+	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/kacebover/password-finder/encryptor"
+	"github.com/kacebover/password-finder/locale"
 	"github.com/kacebover/password-finder/searcher"
 )
 
@@ -225,23 +231,69 @@ func TestScanController_ExportResults(t *testing.T) {
 	
 	// Export results
 	exportDir := t.TempDir()
-	err := ctrl.ExportAll(exportDir)
+	reportDir, err := ctrl.ExportAll(exportDir)
 	if err != nil {
 		t.Errorf("ExportAll failed: %v", err)
 	}
 	
 	// Check for exported files
-	files, _ := filepath.Glob(filepath.Join(exportDir, "*.json"))
+	files, _ := filepath.Glob(filepath.Join(reportDir, "*.json"))
 	if len(files) == 0 {
 		t.Log("Warning: No JSON export file found")
 	}
 	
-	csvFiles, _ := filepath.Glob(filepath.Join(exportDir, "*.csv"))
+	csvFiles, _ := filepath.Glob(filepath.Join(reportDir, "*.csv"))
 	if len(csvFiles) == 0 {
 		t.Log("Warning: No CSV export file found")
 	}
 }
 
+// TestScanController_ExportSelected tests the export of picked files holds
+// only their findings that are not ignored
+func TestScanController_ExportSelected(t *testing.T) {
+	ctrl := NewScanController()
+	ctrl.ignoredFindings = make(map[string]bool)
+	ctrl.ignoredFiles = map[string]bool{"/app/ignored.env": true}
+
+	result := searcher.NewScanResult()
+	for _, f := range []*searcher.Finding{
+		{FilePath: "/app/.env", LineNumber: 1, PatternType: searcher.PatternPassword, Severity: searcher.Critical},
+		{FilePath: "/app/.env", LineNumber: 2, PatternType: searcher.PatternAPIKey, Severity: searcher.High},
+		{FilePath: "/app/config.yml", LineNumber: 5, PatternType: searcher.PatternPassword, Severity: searcher.Medium},
+		{FilePath: "/app/ignored.env", LineNumber: 1, PatternType: searcher.PatternPassword, Severity: searcher.Low},
+		{FilePath: "/app/other.txt", LineNumber: 3, PatternType: searcher.PatternEmail, Severity: searcher.Low},
+	} {
+		result.AddFinding(f)
+	}
+	ctrl.currentResult = result
+	// One finding of a picked file was marked a false positive
+	ctrl.ignoredFindings[ctrl.findingKey(result.Findings[1])] = true
+
+	reportDir, err := ctrl.ExportSelected(t.TempDir(), []string{"/app/.env", "/app/config.yml", "/app/ignored.env"}, []string{searcher.FormatJSON})
+	if err != nil {
+		t.Fatalf("ExportSelected failed: %v", err)
+	}
+	reports, _ := filepath.Glob(filepath.Join(reportDir, "*"))
+	if len(reports) != 1 || filepath.Ext(reports[0]) != ".json" {
+		t.Fatalf("reports = %v, want one JSON report", reports)
+	}
+
+	exported, err := searcher.LoadScanResult(reports[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	paths := make(map[string]int)
+	for _, f := range exported.Findings {
+		paths[f.FilePath]++
+	}
+	if len(paths) != 2 || paths["/app/.env"] != 1 || paths["/app/config.yml"] != 1 {
+		t.Errorf("exported findings by file = %v", paths)
+	}
+	if exported.GetSeverityCount(searcher.Critical) != 1 || exported.GetSeverityCount(searcher.High) != 0 || exported.FilesScanned != 2 {
+		t.Errorf("exported counters: %v in %d files", exported.SeveritySummary, exported.FilesScanned)
+	}
+}
+
 // TestScanController_StateTracking tests state management
 func TestScanController_StateTracking(t *testing.T) {
 	ctrl := NewScanController()
@@ -614,8 +666,8 @@ func TestScanController_ValidateEncryptionPassword(t *testing.T) {
 	}{
 		{"", true},
 		{"abc", true},
-		{"abcd", false},
-		{"password123", false},
+		{"abcd", true},        // Sequence
+		{"password123", true}, // Common password
 		{"P@ssw0rd!#$%", false},
 		{"a very long password with spaces and 特殊字符", false},
 	}
@@ -681,6 +733,54 @@ func TestScanController_EncryptFiles(t *testing.T) {
 	}
 }
 
+// TestScanController_EncryptFiles_Manifest tests the manifest lists the
+// findings of each file without their secrets
+func TestScanController_EncryptFiles_Manifest(t *testing.T) {
+	ctrl := NewScanController()
+	tmpDir := t.TempDir()
+	secret := "Zx9Qw8Er7Ty6Ui5Op4As"
+	os.WriteFile(filepath.Join(tmpDir, "settings.py"), []byte("password = '"+secret+"'\n"), 0644)
+
+	// Paths relative to the scan root, as in a report
+	findings := []*searcher.Finding{{
+		FilePath:    "settings.py",
+		LineNumber:  1,
+		PatternType: searcher.PatternPassword,
+		Severity:    searcher.High,
+		MatchedText: secret,
+		Context:     "password = '" + secret + "'",
+		RiskScore:   8,
+	}}
+	config := EncryptionConfig{
+		Password:        "TestPassword123!",
+		OutputPath:      filepath.Join(t.TempDir(), "encrypted.zip"),
+		IncludeManifest: true,
+		Findings:        findings,
+		ScanRoot:        tmpDir,
+	}
+	if _, err := ctrl.EncryptFiles([]string{filepath.Join(tmpDir, "settings.py")}, config, nil); err != nil {
+		t.Fatalf("EncryptFiles failed: %v", err)
+	}
+
+	outDir := t.TempDir()
+	dec, _ := encryptor.NewDecryptor(encryptor.DecryptConfig{Password: config.Password, SourcePath: config.OutputPath, OutputDir: outDir})
+	if err := dec.DecryptAll(); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(filepath.Join(outDir, encryptor.ManifestJSONName))
+	text, _ := os.ReadFile(filepath.Join(outDir, encryptor.ManifestTextName))
+	var manifest encryptor.Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		t.Fatal(err)
+	}
+	if len(manifest.Files) != 1 || len(manifest.Files[0].Findings) != 1 || manifest.Files[0].Findings[0].Line != 1 {
+		t.Errorf("manifest files = %+v", manifest.Files)
+	}
+	if strings.Contains(string(data), secret) || strings.Contains(string(text), secret) {
+		t.Errorf("manifest holds the secret:\n%s\n%s", data, text)
+	}
+}
+
 // TestScanController_EncryptFiles_WithProgress tests progress reporting
 func TestScanController_EncryptFiles_WithProgress(t *testing.T) {
 	ctrl := NewScanController()
@@ -760,6 +860,9 @@ func TestScanController_EncryptFiles_WithDeletion(t *testing.T) {
 	if result.FilesDeleted != 1 {
 		t.Errorf("FilesDeleted = %d, want 1", result.FilesDeleted)
 	}
+	if !result.Verified {
+		t.Error("Archive should be verified before deletion")
+	}
 }
 
 // TestScanController_EncryptFiles_InvalidPassword tests error handling
@@ -910,3 +1013,226 @@ func BenchmarkEncryptFiles(b *testing.B) {
 	}
 }
 
+
+// runBaselineScan runs a scan through the controller and waits for it
+func runBaselineScan(t *testing.T, ctrl *ScanController, dir string) *searcher.ScanResult {
+	t.Helper()
+	done := make(chan *searcher.ScanResult, 1)
+	ctrl.SetOnComplete(func(result *searcher.ScanResult, err error) {
+		done <- result
+	})
+	if err := ctrl.StartScan(dir); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case result := <-done:
+		return result
+	case <-time.After(10 * time.Second):
+		t.Fatal("scan timed out")
+	}
+	return nil
+}
+
+// TestScanController_Baseline tests live findings are tagged against a
+// loaded baseline and counted as new or known
+func TestScanController_Baseline(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "old.env"), []byte("password=Winter2023secret\n"), 0644)
+
+	baseline := runBaselineScan(t, NewScanController(), dir)
+	if baseline.TotalFindings() == 0 {
+		t.Fatal("baseline scan found nothing")
+	}
+	for _, f := range baseline.Findings {
+		if f.Baseline != "" {
+			t.Errorf("findings without a baseline should not be tagged, got %q", f.Baseline)
+		}
+	}
+
+	// The known secret moves down a line, a new one appears
+	os.WriteFile(filepath.Join(dir, "old.env"), []byte("# moved\npassword=Winter2023secret\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "new.env"), []byte("password=Summer2024secret\n"), 0644)
+
+	var mu sync.Mutex
+	live := make(map[string]searcher.BaselineStatus)
+	ctrl := NewScanController()
+	ctrl.SetBaseline(baseline)
+	ctrl.SetOnFinding(func(f *searcher.Finding) {
+		mu.Lock()
+		live[filepath.Base(f.FilePath)] = f.Baseline
+		mu.Unlock()
+	})
+	result := runBaselineScan(t, ctrl, dir)
+
+	for _, f := range result.Findings {
+		want := searcher.BaselineNew
+		if filepath.Base(f.FilePath) == "old.env" {
+			want = searcher.BaselineKnown
+		}
+		if f.Baseline != want {
+			t.Errorf("%s line %d: got %q, want %q", filepath.Base(f.FilePath), f.LineNumber, f.Baseline, want)
+		}
+	}
+
+	newCount, known := ctrl.BaselineCounts()
+	wantNew, wantKnown := searcher.CountBaseline(result.Findings)
+	if newCount != int64(wantNew) || known != int64(wantKnown) || newCount == 0 || known == 0 {
+		t.Errorf("counts %d new, %d known; result has %d new, %d known", newCount, known, wantNew, wantKnown)
+	}
+
+	mu.Lock()
+	if live["old.env"] != searcher.BaselineKnown || live["new.env"] != searcher.BaselineNew {
+		t.Errorf("findings delivered to the UI were not tagged: %v", live)
+	}
+	mu.Unlock()
+
+	ctrl.SetBaseline(nil)
+	if ctrl.HasBaseline() {
+		t.Error("baseline should be cleared")
+	}
+}
+
+// TestResultHistory_Retention tests archived results beyond the limit are
+// removed oldest first
+func TestResultHistory_Retention(t *testing.T) {
+	history := NewResultHistory(t.TempDir(), 2)
+
+	var paths []string
+	for i := 0; i < 3; i++ {
+		result := searcher.NewScanResult()
+		result.AddFinding(&searcher.Finding{FilePath: "a.env", LineNumber: i + 1, PatternType: searcher.PatternPassword})
+		path, err := history.Archive(result)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Distinct modification times order the entries
+		stamp := time.Now().Add(time.Duration(i-3) * time.Minute)
+		os.Chtimes(path, stamp, stamp)
+		paths = append(paths, path)
+	}
+
+	entries, err := history.Entries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Path != paths[2] || entries[1].Path != paths[1] {
+		t.Fatalf("entries = %+v, want the last two of %v", entries, paths)
+	}
+	if _, err := os.Stat(paths[0]); !os.IsNotExist(err) {
+		t.Error("oldest result should have been removed")
+	}
+
+	// Archived results can be reopened
+	loaded, err := searcher.LoadScanResult(entries[0].Path)
+	if err != nil || loaded.TotalFindings() != 1 {
+		t.Errorf("reopened result: %v, err %v", loaded, err)
+	}
+}
+
+// TestScanController_ArchiveOnNewScan tests starting a scan archives the
+// previous result and releases it from memory
+func TestScanController_ArchiveOnNewScan(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "config.env"), []byte("password=Winter2023secret\n"), 0644)
+
+	ctrl := NewScanController()
+	historyDir := t.TempDir()
+	ctrl.SetHistory(NewResultHistory(historyDir, DefaultHistoryLimit))
+
+	first := runBaselineScan(t, ctrl, dir)
+	if ctrl.GetResult() != first || ctrl.MemoryUsage().RetainedFindings != first.TotalFindings() {
+		t.Fatal("first result should be retained until the next scan")
+	}
+
+	second := runBaselineScan(t, ctrl, dir)
+	if second == first || ctrl.GetResult() != second {
+		t.Fatal("second scan should replace the first result")
+	}
+	entries, _ := ctrl.History().Entries()
+	if len(entries) != 1 {
+		t.Fatalf("expected the first result in the history, got %+v", entries)
+	}
+	archived, err := searcher.LoadScanResult(entries[0].Path)
+	if err != nil || archived.TotalFindings() != first.TotalFindings() {
+		t.Errorf("archived result: %v, err %v", archived, err)
+	}
+
+	// Freeing memory archives the current result too
+	if err := ctrl.FreeMemory(); err != nil {
+		t.Fatal(err)
+	}
+	if ctrl.GetResult() != nil || ctrl.MemoryUsage().RetainedFindings != 0 {
+		t.Error("result should be released")
+	}
+	if entries, _ := ctrl.History().Entries(); len(entries) != 2 {
+		t.Errorf("expected 2 archived results, got %d", len(entries))
+	}
+
+	// Nothing to release
+	if path, err := ctrl.ReleaseResult(); path != "" || err != nil {
+		t.Errorf("ReleaseResult on empty controller = %q, %v", path, err)
+	}
+}
+
+// TestScanController_ReleaseKeepsResultOnError tests a result that cannot
+// be archived stays in memory
+func TestScanController_ReleaseKeepsResultOnError(t *testing.T) {
+	blocker := filepath.Join(t.TempDir(), "file")
+	os.WriteFile(blocker, nil, 0644)
+
+	ctrl := NewScanController()
+	// A history directory below a regular file cannot be created
+	ctrl.SetHistory(NewResultHistory(filepath.Join(blocker, "history"), DefaultHistoryLimit))
+	result := searcher.NewScanResult()
+	ctrl.mu.Lock()
+	ctrl.currentResult = result
+	ctrl.mu.Unlock()
+
+	if _, err := ctrl.ReleaseResult(); err == nil {
+		t.Fatal("expected an archive error")
+	}
+	if ctrl.GetResult() != result {
+		t.Error("result should be kept when archiving fails")
+	}
+}
+
+// TestFormatMemoryUsage tests the diagnostics memory line
+func TestFormatMemoryUsage(t *testing.T) {
+	got := FormatMemoryUsage(locale.New(locale.Russian), MemoryStats{
+		HeapAlloc:        3 * 1024 * 1024,
+		Sys:              10 * 1024 * 1024,
+		RetainedFindings: 42,
+		Caches:           []searcher.CacheStats{{Name: "OCR", Entries: 5, Bytes: 2048, MaxBytes: 16 * 1024 * 1024}},
+	})
+	want := "Память: куча 3 MB, всего у ОС 10 MB, находок в памяти: 42, кэш OCR: 5 (2 KB из 16 MB)"
+	if got != want {
+		t.Errorf("got %q\nwant %q", got, want)
+	}
+}
+
+// BenchmarkScanController_RepeatedScans reports the live heap after each of
+// several scans in one session; with results archived on every new scan it
+// stays flat instead of growing with the number of scans
+func BenchmarkScanController_RepeatedScans(b *testing.B) {
+	dir := b.TempDir()
+	for i := 0; i < 200; i++ {
+		os.WriteFile(filepath.Join(dir, fmt.Sprintf("config%d.env", i)), []byte("password=Winter2023secret\napi_key=sk_live_abcdefghijklmnop1234\n"), 0644)
+	}
+
+	ctrl := NewScanController()
+	ctrl.SetHistory(NewResultHistory(b.TempDir(), DefaultHistoryLimit))
+	done := make(chan struct{}, 1)
+	ctrl.SetOnComplete(func(result *searcher.ScanResult, err error) {
+		done <- struct{}{}
+	})
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ctrl.StartScan(dir)
+		<-done
+	}
+	b.StopTimer()
+
+	runtime.GC()
+	b.ReportMetric(float64(ctrl.MemoryUsage().HeapAlloc), "heap-bytes")
+}
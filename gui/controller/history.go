@@ -0,0 +1,126 @@
+package controller
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kacebover/password-finder/searcher"
+)
+
+// DefaultHistoryLimit is how many archived scan results are kept
+const DefaultHistoryLimit = 10
+
+// HistoryEntry is one archived scan result
+type HistoryEntry struct {
+	Path       string
+	ArchivedAt time.Time
+	Size       int64
+}
+
+// ResultHistory archives finished scan results as JSON reports so they can
+// be dropped from memory and reopened later
+type ResultHistory struct {
+	mu    sync.Mutex
+	dir   string
+	limit int
+}
+
+// NewResultHistory creates a history in dir keeping at most limit results;
+// limit <= 0 keeps everything
+func NewResultHistory(dir string, limit int) *ResultHistory {
+	return &ResultHistory{dir: dir, limit: limit}
+}
+
+// DefaultResultHistory returns the history in the configuration directory
+func DefaultResultHistory() *ResultHistory {
+	return NewResultHistory(filepath.Join(getConfigDir(), "history"), DefaultHistoryLimit)
+}
+
+// Dir returns the directory the results are archived to
+func (h *ResultHistory) Dir() string {
+	return h.dir
+}
+
+// Archive writes result to the history and removes the oldest entries over
+// the limit. It returns the path of the archived report
+func (h *ResultHistory) Archive(result *searcher.ScanResult) (string, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if err := os.MkdirAll(h.dir, 0755); err != nil {
+		return "", err
+	}
+
+	name := "scan_" + result.GeneratedAt().Format("20060102_150405")
+	path := filepath.Join(h.dir, name+".json")
+	for i := 2; fileExists(path); i++ {
+		path = filepath.Join(h.dir, fmt.Sprintf("%s_%d.json", name, i))
+	}
+	if err := searcher.NewReportGenerator(result).ExportJSON(path); err != nil {
+		os.Remove(path)
+		return "", err
+	}
+
+	if h.limit > 0 {
+		entries, err := h.entries()
+		if err != nil {
+			return path, err
+		}
+		for _, old := range entries[min(h.limit, len(entries)):] {
+			os.Remove(old.Path)
+		}
+	}
+	return path, nil
+}
+
+// Entries returns the archived results, newest first
+func (h *ResultHistory) Entries() ([]HistoryEntry, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.entries()
+}
+
+// entries lists the archive; the caller holds h.mu
+func (h *ResultHistory) entries() ([]HistoryEntry, error) {
+	dirEntries, err := os.ReadDir(h.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []HistoryEntry
+	for _, de := range dirEntries {
+		if de.IsDir() || !strings.HasSuffix(de.Name(), ".json") {
+			continue
+		}
+		info, err := de.Info()
+		if err != nil {
+			continue
+		}
+		entries = append(entries, HistoryEntry{
+			Path:       filepath.Join(h.dir, de.Name()),
+			ArchivedAt: info.ModTime(),
+			Size:       info.Size(),
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if !entries[i].ArchivedAt.Equal(entries[j].ArchivedAt) {
+			return entries[i].ArchivedAt.After(entries[j].ArchivedAt)
+		}
+		return entries[i].Path > entries[j].Path
+	})
+	return entries, nil
+}
+
+// fileExists reports whether path exists
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
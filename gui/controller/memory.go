@@ -0,0 +1,120 @@
+package controller
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"strings"
+
+	"github.com/kacebover/password-finder/locale"
+	"github.com/kacebover/password-finder/searcher"
+)
+
+// MemoryStats is the memory held by the application
+type MemoryStats struct {
+	HeapAlloc        uint64 // Bytes of live heap objects
+	Sys              uint64 // Bytes obtained from the OS
+	RetainedFindings int    // Findings of the result still in memory
+	Caches           []searcher.CacheStats
+}
+
+// SetHistory replaces the store previous results are archived to
+func (sc *ScanController) SetHistory(history *ResultHistory) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.history = history
+}
+
+// History returns the store previous results are archived to
+func (sc *ScanController) History() *ResultHistory {
+	sc.mu.RLock()
+	defer sc.mu.RUnlock()
+	return sc.history
+}
+
+// ReleaseResult archives the current result to the history and drops it
+// from memory. It returns the path of the archive, or "" when there was no
+// result. If archiving fails the result is kept
+func (sc *ScanController) ReleaseResult() (string, error) {
+	sc.mu.RLock()
+	result := sc.currentResult
+	history := sc.history
+	sc.mu.RUnlock()
+	if result == nil {
+		return "", nil
+	}
+
+	path, err := history.Archive(result)
+	if err != nil {
+		return "", fmt.Errorf("archive previous result: %w", err)
+	}
+	sc.dropResult(result)
+	sc.log(LogInfo, "Previous result archived: "+path)
+	return path, nil
+}
+
+// dropResult forgets result and the scanner that produced it, unless a newer
+// result has replaced it meanwhile
+func (sc *ScanController) dropResult(result *searcher.ScanResult) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	if sc.currentResult == result {
+		sc.currentResult = nil
+		if !sc.isScanning {
+			sc.scanner = nil
+		}
+	}
+}
+
+// MemoryUsage returns the current memory use of the process and its caches
+func (sc *ScanController) MemoryUsage() MemoryStats {
+	return ReadMemoryStats(sc.GetResult())
+}
+
+// ReadMemoryStats returns the memory use of the process and its caches;
+// result is the scan result kept in memory, if any
+func ReadMemoryStats(result *searcher.ScanResult) MemoryStats {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+
+	stats := MemoryStats{
+		HeapAlloc: ms.HeapAlloc,
+		Sys:       ms.Sys,
+		Caches:    searcher.CacheUsage(),
+	}
+	if result != nil {
+		stats.RetainedFindings = result.TotalFindings()
+	}
+	return stats
+}
+
+// FreeMemory archives and releases the current result, empties the caches
+// and returns freed memory to the OS. A running scan keeps its result
+func (sc *ScanController) FreeMemory() error {
+	var err error
+	if !sc.IsScanning() {
+		_, err = sc.ReleaseResult()
+	}
+	ReleaseCaches()
+	return err
+}
+
+// ReleaseCaches empties the caches and returns freed memory to the OS
+func ReleaseCaches() {
+	searcher.ReleaseCaches()
+	debug.FreeOSMemory()
+}
+
+// FormatMemoryUsage renders the "Память" line of the diagnostics dialog
+func FormatMemoryUsage(l *locale.Localizer, stats MemoryStats) string {
+	parts := []string{
+		l.T("gui.memory.heap", FormatFileSize(int64(stats.HeapAlloc))),
+		l.T("gui.memory.sys", FormatFileSize(int64(stats.Sys))),
+		l.T("gui.memory.findings", stats.RetainedFindings),
+	}
+	for _, cache := range stats.Caches {
+		parts = append(parts, l.T("gui.memory.cache",
+			cache.Name, cache.Entries, FormatFileSize(cache.Bytes), FormatFileSize(cache.MaxBytes)))
+	}
+	return l.T("gui.memory.usage", strings.Join(parts, ", "))
+}
@@ -0,0 +1,524 @@
+package controller
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/kacebover/password-finder/searcher"
+)
+
+// ResultsModel groups the findings of a scan by file for the results list:
+// the files picked for encryption and export, and the files list as shown
+// with the filters applied, most severe first. Findings are added as the
+// scan streams them; all methods may be called from any goroutine. The
+// entries it returns are copies, their findings must not be modified
+type ResultsModel struct {
+	mu      sync.Mutex
+	files   []*FileEntry // In the order their first finding arrived
+	byPath  map[string]*FileEntry
+	ignored func(path string) bool // Files left out of the view and the counts
+
+	filter resultsFilter
+	view   []*FileEntry // Filtered and sorted files, nil once out of date
+}
+
+// FileEntry is a file of the results with its findings
+type FileEntry struct {
+	FilePath    string
+	Findings    []*searcher.Finding
+	Selected    bool
+	MaxSeverity searcher.Severity
+	Counts      SeverityCounts // Findings per severity, kept by add
+	Truncated   string         // Why the findings of the file are incomplete, see searcher.TruncationNote
+	Fixed       int            // Findings of an earlier scan missing now, see ApplyDiff
+}
+
+// SeverityCounts counts findings per severity, so the files list does not
+// count them again for every drawn row
+type SeverityCounts struct {
+	Critical, High, Medium, Low int
+}
+
+// resultsFilter is what the files list is filtered by
+type resultsFilter struct {
+	text     string            // Lower-cased
+	severity searcher.Severity // Empty for every level
+	exts     map[string]bool   // Lower-cased extensions, nil for every file type
+	onlyNew  bool
+}
+
+// File type groups for SetFileTypeFilter, also used to limit a scan
+var (
+	TextExtensions = []string{
+		".txt", ".json", ".yaml", ".yml", ".xml", ".csv", ".env", ".ini", ".cfg", ".conf",
+		".go", ".py", ".js", ".ts", ".java", ".c", ".cpp", ".h", ".hpp", ".cs", ".rb",
+		".php", ".sh", ".bash", ".zsh", ".ps1", ".sql", ".md", ".rst", ".log",
+	}
+	DocumentExtensions = []string{
+		".pdf", ".docx", ".doc", ".xlsx", ".xls", ".pptx", ".ppt", ".odt", ".ods", ".odp",
+		".rtf", ".eml", ".msg",
+	}
+	ImageExtensions = []string{
+		".png", ".jpg", ".jpeg", ".gif", ".bmp", ".tiff", ".tif", ".webp",
+	}
+	ArchiveExtensions = []string{
+		".zip", ".tar", ".gz", ".tgz", ".rar", ".7z", ".bz2", ".xz",
+	}
+)
+
+// NewResultsModel creates an empty results model
+func NewResultsModel() *ResultsModel {
+	return &ResultsModel{byPath: make(map[string]*FileEntry)}
+}
+
+// Add adds a finding to its file, listing the file on its first finding.
+// It is called from the streaming findings callback
+func (m *ResultsModel) Add(f *searcher.Finding) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entry(f.FilePath).add(f)
+	m.view = nil
+}
+
+// SetFindings replaces the files with the findings of a finished scan or
+// an opened report. Files picked meanwhile stay selected
+func (m *ResultsModel) SetFindings(findings []*searcher.Finding) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	previous := m.byPath
+	m.files, m.byPath, m.view = nil, make(map[string]*FileEntry), nil
+	for _, f := range findings {
+		m.entry(f.FilePath).add(f)
+	}
+	for _, file := range m.files {
+		if old, ok := previous[file.FilePath]; ok {
+			file.Selected = old.Selected
+		}
+	}
+}
+
+// Clear drops the files together with the selection
+func (m *ResultsModel) Clear() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.files, m.byPath, m.view = nil, make(map[string]*FileEntry), nil
+}
+
+// entry returns the file at path, listing it if needed. The caller holds mu
+func (m *ResultsModel) entry(path string) *FileEntry {
+	file, ok := m.byPath[path]
+	if !ok {
+		file = &FileEntry{FilePath: path}
+		m.byPath[path] = file
+		m.files = append(m.files, file)
+	}
+	return file
+}
+
+// SetTruncated notes why the findings of a file are incomplete
+func (m *ResultsModel) SetTruncated(path, note string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if file, ok := m.byPath[path]; ok {
+		file.Truncated = note
+	}
+}
+
+// SetIgnored sets the files the view and Counts leave out; nil keeps all
+func (m *ResultsModel) SetIgnored(ignored func(path string) bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ignored = ignored
+	m.view = nil
+}
+
+// Invalidate rebuilds the view on its next use, after the ignored files or
+// the baseline tags of the findings changed
+func (m *ResultsModel) Invalidate() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.view = nil
+}
+
+// isIgnored reports whether the file is left out. The caller holds mu
+func (m *ResultsModel) isIgnored(path string) bool {
+	return m.ignored != nil && m.ignored(path)
+}
+
+// ApplyDiff tags the findings as new or known after a comparison with an
+// earlier scan and counts the fixed findings per file; files with only
+// fixed findings are listed too. Entries of an earlier comparison are
+// replaced
+func (m *ResultsModel) ApplyDiff(diff *searcher.ScanDiff) {
+	for _, f := range diff.AddedFindings {
+		f.Baseline = searcher.BaselineNew
+	}
+	for _, f := range diff.PersistedFindings {
+		f.Baseline = searcher.BaselineKnown
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	kept := m.files[:0]
+	for _, file := range m.files {
+		if len(file.Findings) == 0 {
+			delete(m.byPath, file.FilePath)
+			continue
+		}
+		file.Fixed = 0
+		kept = append(kept, file)
+	}
+	m.files = kept
+
+	for _, f := range diff.RemovedFindings {
+		path := f.FilePath
+		if diff.OldRoot != "" && diff.NewRoot != "" {
+			if rel, err := filepath.Rel(diff.OldRoot, f.FilePath); err == nil {
+				path = filepath.Join(diff.NewRoot, rel)
+			}
+		}
+		file := m.entry(path)
+		if len(file.Findings) == 0 && file.Fixed == 0 {
+			file.MaxSeverity = f.Severity
+		}
+		file.Fixed++
+	}
+	m.view = nil
+}
+
+// GetFilesWithFindings returns every file, ignored ones included, most
+// severe first
+func (m *ResultsModel) GetFilesWithFindings() []FileEntry {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	files := make([]*FileEntry, len(m.files))
+	copy(files, m.files)
+	sortBySeverity(files)
+	return copyEntries(files)
+}
+
+// File returns the file at path
+func (m *ResultsModel) File(path string) (FileEntry, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if file, ok := m.byPath[path]; ok {
+		return *file, true
+	}
+	return FileEntry{}, false
+}
+
+// FilesWith returns the paths of the files, ignored ones included, with a
+// finding match reports true for. match must not call the model
+func (m *ResultsModel) FilesWith(match func(f *searcher.Finding) bool) []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var paths []string
+	for _, file := range m.files {
+		for _, f := range file.Findings {
+			if match(f) {
+				paths = append(paths, file.FilePath)
+				break
+			}
+		}
+	}
+	return paths
+}
+
+// Len returns the number of files, ignored ones included
+func (m *ResultsModel) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.files)
+}
+
+// SetTextFilter shows the files whose path, or the description or type of
+// one of whose findings, contains text, ignoring case
+func (m *ResultsModel) SetTextFilter(text string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if text = strings.ToLower(text); text != m.filter.text {
+		m.filter.text, m.view = text, nil
+	}
+}
+
+// SetSeverityFilter shows the files with findings of severity s; an empty
+// severity shows every level
+func (m *ResultsModel) SetSeverityFilter(s searcher.Severity) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if s != m.filter.severity {
+		m.filter.severity, m.view = s, nil
+	}
+}
+
+// SetFileTypeFilter shows the files with one of the extensions, such as
+// DocumentExtensions; findings inside archives count as the archive's.
+// No extensions show every file
+func (m *ResultsModel) SetFileTypeFilter(exts []string) {
+	var set map[string]bool
+	if len(exts) > 0 {
+		set = make(map[string]bool, len(exts))
+		for _, ext := range exts {
+			set[strings.ToLower(ext)] = true
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.filter.exts, m.view = set, nil
+}
+
+// SetOnlyNewFilter shows only the files with a finding missing from the
+// baseline when onlyNew is set
+func (m *ResultsModel) SetOnlyNewFilter(onlyNew bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if onlyNew != m.filter.onlyNew {
+		m.filter.onlyNew, m.view = onlyNew, nil
+	}
+}
+
+// GetFilteredFiles returns the files the filters show, most severe first
+func (m *ResultsModel) GetFilteredFiles() []FileEntry {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return copyEntries(m.filtered())
+}
+
+// FilteredLen returns the number of files the filters show
+func (m *ResultsModel) FilteredLen() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.filtered())
+}
+
+// FilteredFile returns the i-th file the filters show, for drawing a row
+// of the list without copying the others
+func (m *ResultsModel) FilteredFile(i int) (FileEntry, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	view := m.filtered()
+	if i < 0 || i >= len(view) {
+		return FileEntry{}, false
+	}
+	return *view[i], true
+}
+
+// filtered returns the view, rebuilding it if it is out of date. The
+// caller holds mu
+func (m *ResultsModel) filtered() []*FileEntry {
+	if m.view != nil {
+		return m.view
+	}
+	view := make([]*FileEntry, 0, len(m.files))
+	for _, file := range m.files {
+		if !m.isIgnored(file.FilePath) && m.filter.matches(file) {
+			view = append(view, file)
+		}
+	}
+	sortBySeverity(view)
+	m.view = view
+	return view
+}
+
+// matches reports whether the filter shows the file
+func (filter *resultsFilter) matches(file *FileEntry) bool {
+	if filter.onlyNew && !file.HasNew() {
+		return false
+	}
+	if filter.severity != "" && file.Counts.Of(filter.severity) == 0 {
+		return false
+	}
+	if filter.exts != nil && !filter.exts[fileExt(file.FilePath)] {
+		return false
+	}
+	return filter.text == "" || matchesText(file, filter.text)
+}
+
+// fileExt returns the lower-cased extension of the file on disk, which for
+// a member of an archive is the archive
+func fileExt(path string) string {
+	archive, _ := searcher.SplitArchivePath(path)
+	return strings.ToLower(filepath.Ext(archive))
+}
+
+// matchesText searches the lower-cased text in the file path and the
+// descriptions and types of its findings
+func matchesText(file *FileEntry, text string) bool {
+	if strings.Contains(strings.ToLower(file.FilePath), text) {
+		return true
+	}
+	for _, f := range file.Findings {
+		if strings.Contains(strings.ToLower(f.Description), text) ||
+			strings.Contains(strings.ToLower(string(f.PatternType)), text) {
+			return true
+		}
+	}
+	return false
+}
+
+// SelectFile picks a file for encryption and export, or drops it
+func (m *ResultsModel) SelectFile(path string, selected bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if file, ok := m.byPath[path]; ok {
+		file.Selected = selected
+	}
+}
+
+// SelectBySeverity adds the files with findings of severity s to the
+// selection
+func (m *ResultsModel) SelectBySeverity(s searcher.Severity) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, file := range m.files {
+		if file.Counts.Of(s) > 0 {
+			file.Selected = true
+		}
+	}
+}
+
+// SelectAll selects or deselects every file
+func (m *ResultsModel) SelectAll(selected bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, file := range m.files {
+		file.Selected = selected
+	}
+}
+
+// GetSelectedPaths returns the paths of the selected files that have
+// findings; files listed only for their fixed findings have nothing to
+// encrypt
+func (m *ResultsModel) GetSelectedPaths() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var paths []string
+	for _, file := range m.files {
+		if file.Selected && len(file.Findings) > 0 {
+			paths = append(paths, file.FilePath)
+		}
+	}
+	return paths
+}
+
+// SelectedFindings returns the findings of the selected files
+func (m *ResultsModel) SelectedFindings() []*searcher.Finding {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var findings []*searcher.Finding
+	for _, file := range m.files {
+		if file.Selected {
+			findings = append(findings, file.Findings...)
+		}
+	}
+	return findings
+}
+
+// SelectionCount returns the number of selected files and their findings
+func (m *ResultsModel) SelectionCount() (files, findings int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, file := range m.files {
+		if file.Selected {
+			files++
+			findings += len(file.Findings)
+		}
+	}
+	return files, findings
+}
+
+// HasSelection reports whether any file is selected
+func (m *ResultsModel) HasSelection() bool {
+	files, _ := m.SelectionCount()
+	return files > 0
+}
+
+// Counts returns the findings per severity of the files not ignored
+func (m *ResultsModel) Counts() SeverityCounts {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var total SeverityCounts
+	for _, file := range m.files {
+		if m.isIgnored(file.FilePath) {
+			continue
+		}
+		total.Critical += file.Counts.Critical
+		total.High += file.Counts.High
+		total.Medium += file.Counts.Medium
+		total.Low += file.Counts.Low
+	}
+	return total
+}
+
+// add appends a finding to the file, keeping its counts and max severity
+func (file *FileEntry) add(f *searcher.Finding) {
+	if len(file.Findings) == 0 || f.Severity.Score() > file.MaxSeverity.Score() {
+		file.MaxSeverity = f.Severity
+	}
+	file.Findings = append(file.Findings, f)
+	file.Counts.Add(f.Severity)
+}
+
+// HasNew reports whether the file has a finding missing from the baseline
+func (file *FileEntry) HasNew() bool {
+	for _, f := range file.Findings {
+		if f.Baseline == searcher.BaselineNew {
+			return true
+		}
+	}
+	return false
+}
+
+// Add counts one finding of severity s
+func (c *SeverityCounts) Add(s searcher.Severity) {
+	switch s {
+	case searcher.Critical:
+		c.Critical++
+	case searcher.High:
+		c.High++
+	case searcher.Medium:
+		c.Medium++
+	case searcher.Low:
+		c.Low++
+	}
+}
+
+// Of returns the number of findings of severity s
+func (c SeverityCounts) Of(s searcher.Severity) int {
+	switch s {
+	case searcher.Critical:
+		return c.Critical
+	case searcher.High:
+		return c.High
+	case searcher.Medium:
+		return c.Medium
+	case searcher.Low:
+		return c.Low
+	}
+	return 0
+}
+
+// Total returns the number of findings of every severity
+func (c SeverityCounts) Total() int {
+	return c.Critical + c.High + c.Medium + c.Low
+}
+
+// sortBySeverity sorts files by max severity, Critical first, keeping the
+// order of files of the same severity
+func sortBySeverity(files []*FileEntry) {
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].MaxSeverity.Score() > files[j].MaxSeverity.Score()
+	})
+}
+
+// copyEntries copies files for the caller
+func copyEntries(files []*FileEntry) []FileEntry {
+	entries := make([]FileEntry, len(files))
+	for i, file := range files {
+		entries[i] = *file
+	}
+	return entries
+}
@@ -0,0 +1,371 @@
+package controller
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kacebover/password-finder/searcher"
+)
+
+// manyFindings makes n findings spread over n/10 files; even files have
+// every severity, odd ones only the severity of their number
+func manyFindings(n int) []*searcher.Finding {
+	severities := []searcher.Severity{searcher.Low, searcher.Medium, searcher.High, searcher.Critical}
+	findings := make([]*searcher.Finding, n)
+	for i := range findings {
+		file := i / 10
+		severity := severities[i%4]
+		if file%2 == 1 {
+			severity = severities[file/2%4]
+		}
+		findings[i] = &searcher.Finding{
+			FilePath:    fmt.Sprintf("/repo/dir%d/file%d.env", file%97, file),
+			PatternType: searcher.PatternPassword,
+			Severity:    severity,
+			Description: "Password detected",
+		}
+	}
+	return findings
+}
+
+// filteredPaths returns the paths of the files the filters of m show
+func filteredPaths(m *ResultsModel) string {
+	var paths []string
+	for _, file := range m.GetFilteredFiles() {
+		paths = append(paths, file.FilePath)
+	}
+	return strings.Join(paths, ",")
+}
+
+// TestResultsModelGrouping tests findings added one by one are grouped by
+// file with the max severity and the counts per severity the list shows
+func TestResultsModelGrouping(t *testing.T) {
+	findings := manyFindings(100000)
+	m := NewResultsModel()
+	for _, f := range findings {
+		m.Add(f)
+	}
+	files := m.GetFilesWithFindings()
+	if len(files) != 10000 || m.Len() != 10000 {
+		t.Fatalf("got %d files, want 10000", len(files))
+	}
+
+	var total SeverityCounts
+	for _, f := range findings {
+		total.Add(f.Severity)
+	}
+	var counted SeverityCounts
+	for i, file := range files {
+		var want SeverityCounts
+		max := searcher.Low
+		for _, f := range file.Findings {
+			want.Add(f.Severity)
+			if f.Severity.Score() > max.Score() {
+				max = f.Severity
+			}
+		}
+		if file.Counts != want || file.MaxSeverity != max {
+			t.Fatalf("%s: counts %+v max %s, want %+v max %s", file.FilePath, file.Counts, file.MaxSeverity, want, max)
+		}
+		if i > 0 && file.MaxSeverity.Score() > files[i-1].MaxSeverity.Score() {
+			t.Fatalf("files not sorted by severity at %d", i)
+		}
+		counted.Critical += file.Counts.Critical
+		counted.High += file.Counts.High
+		counted.Medium += file.Counts.Medium
+		counted.Low += file.Counts.Low
+	}
+	if counted != total || m.Counts() != total || total.Total() != len(findings) {
+		t.Errorf("counts add up to %+v, model counts %+v, want %+v", counted, m.Counts(), total)
+	}
+
+	// The final findings of the scan give the same grouping
+	final := NewResultsModel()
+	final.SetFindings(findings)
+	if got := final.GetFilesWithFindings(); len(got) != len(files) || got[0].FilePath != files[0].FilePath {
+		t.Errorf("SetFindings grouped %d files, first %s", len(got), got[0].FilePath)
+	}
+}
+
+// TestResultsModelFilters tests the filters alone and combined
+func TestResultsModelFilters(t *testing.T) {
+	m := NewResultsModel()
+	m.SetFindings([]*searcher.Finding{
+		{FilePath: "/app/config.env", Severity: searcher.Critical, PatternType: searcher.PatternAWSKey, Baseline: searcher.BaselineNew},
+		{FilePath: "/app/config.env", Severity: searcher.Low, PatternType: searcher.PatternEmail, Baseline: searcher.BaselineKnown},
+		{FilePath: "/app/notes.txt", Severity: searcher.Medium, PatternType: searcher.PatternEmail, Description: "Email address", Baseline: searcher.BaselineKnown},
+		{FilePath: "/docs/report.PDF", Severity: searcher.High, PatternType: searcher.PatternPassword, Baseline: searcher.BaselineNew},
+		{FilePath: "/backup.zip" + searcher.ArchiveSeparator + "app/.env", Severity: searcher.Low, PatternType: searcher.PatternPassword},
+	})
+	const archived = "/backup.zip" + searcher.ArchiveSeparator + "app/.env"
+
+	tests := []struct {
+		name     string
+		text     string
+		severity searcher.Severity
+		exts     []string
+		onlyNew  bool
+		want     string
+	}{
+		{name: "none", want: "/app/config.env,/docs/report.PDF,/app/notes.txt," + archived},
+		{name: "path", text: "APP/", want: "/app/config.env,/app/notes.txt," + archived},
+		{name: "description", text: "email addr", want: "/app/notes.txt"},
+		{name: "pattern type", text: string(searcher.PatternEmail), want: "/app/config.env,/app/notes.txt"},
+		{name: "severity below max", severity: searcher.Low, want: "/app/config.env," + archived},
+		{name: "document extension ignores case", exts: DocumentExtensions, want: "/docs/report.PDF"},
+		{name: "archive member", exts: ArchiveExtensions, want: archived},
+		{name: "only new", onlyNew: true, want: "/app/config.env,/docs/report.PDF"},
+		{name: "text and severity", text: "app", severity: searcher.Medium, want: "/app/notes.txt"},
+		{name: "type and severity", exts: TextExtensions, severity: searcher.Critical, want: "/app/config.env"},
+		{name: "only new and type", onlyNew: true, exts: TextExtensions, want: "/app/config.env"},
+		{name: "all", text: "config", severity: searcher.Low, exts: []string{".ENV"}, onlyNew: true, want: "/app/config.env"},
+		{name: "nothing", text: "app", exts: DocumentExtensions, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m.SetTextFilter(tt.text)
+			m.SetSeverityFilter(tt.severity)
+			m.SetFileTypeFilter(tt.exts)
+			m.SetOnlyNewFilter(tt.onlyNew)
+			if got := filteredPaths(m); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+			if m.FilteredLen() != len(m.GetFilteredFiles()) {
+				t.Errorf("FilteredLen = %d, %d files shown", m.FilteredLen(), len(m.GetFilteredFiles()))
+			}
+		})
+	}
+
+	// Ignored files are left out of the view and the counts, not the files
+	m.SetTextFilter("")
+	m.SetSeverityFilter("")
+	m.SetFileTypeFilter(nil)
+	m.SetOnlyNewFilter(false)
+	ignored := map[string]bool{"/app/config.env": true}
+	m.SetIgnored(func(path string) bool { return ignored[path] })
+	if got := filteredPaths(m); got != "/docs/report.PDF,/app/notes.txt,"+archived {
+		t.Errorf("ignored file shown: %s", got)
+	}
+	if counts := m.Counts(); counts.Critical != 0 || counts.Low != 1 {
+		t.Errorf("counts of ignored file kept: %+v", counts)
+	}
+	if m.Len() != 4 {
+		t.Errorf("Len = %d, want the ignored file counted", m.Len())
+	}
+	delete(ignored, "/app/config.env")
+	m.Invalidate()
+	if m.FilteredLen() != 4 {
+		t.Errorf("Invalidate did not show the file again")
+	}
+}
+
+// TestResultsModelViewCache tests the view is filtered once until the
+// filter or the files change
+func TestResultsModelViewCache(t *testing.T) {
+	m := NewResultsModel()
+	m.SetFindings(manyFindings(1000))
+	m.SetSeverityFilter(searcher.Low)
+
+	view := m.filtered()
+	m.SetSeverityFilter(searcher.Low)
+	m.SetTextFilter("")
+	if again := m.filtered(); &again[0] != &view[0] {
+		t.Error("unchanged filter should reuse the cached view")
+	}
+
+	m.Add(&searcher.Finding{FilePath: "/repo/live.env", Severity: searcher.Low})
+	if file, ok := m.FilteredFile(len(view)); !ok || file.FilePath != "/repo/live.env" {
+		t.Errorf("live finding not listed: %+v", file)
+	}
+	if _, ok := m.FilteredFile(-1); ok {
+		t.Error("FilteredFile(-1) returned a file")
+	}
+}
+
+// TestResultsModelSelection tests the selection APIs and the counts the
+// encryption flow reads
+func TestResultsModelSelection(t *testing.T) {
+	m := NewResultsModel()
+	m.SetFindings([]*searcher.Finding{
+		{FilePath: "/a.env", Severity: searcher.Critical},
+		{FilePath: "/a.env", Severity: searcher.Low},
+		{FilePath: "/b.env", Severity: searcher.High},
+		{FilePath: "/c.env", Severity: searcher.Low},
+	})
+	if m.HasSelection() {
+		t.Fatal("new files should not be selected")
+	}
+
+	m.SelectBySeverity(searcher.Low)
+	if files, findings := m.SelectionCount(); files != 2 || findings != 3 {
+		t.Errorf("Low selected %d files, %d findings; want 2 and 3", files, findings)
+	}
+	m.SelectBySeverity(searcher.High)
+	if paths := strings.Join(m.GetSelectedPaths(), ","); paths != "/a.env,/b.env,/c.env" {
+		t.Errorf("selection should grow, got %s", paths)
+	}
+
+	m.SelectFile("/a.env", false)
+	m.SelectFile("/missing.env", true)
+	if files, findings := m.SelectionCount(); files != 2 || findings != 2 || len(m.SelectedFindings()) != 2 {
+		t.Errorf("after deselecting /a.env: %d files, %d findings", files, findings)
+	}
+	if file, _ := m.File("/a.env"); file.Selected {
+		t.Error("/a.env still selected")
+	}
+
+	// The final findings keep the files picked during the scan
+	m.SetFindings([]*searcher.Finding{
+		{FilePath: "/b.env", Severity: searcher.High},
+		{FilePath: "/d.env", Severity: searcher.Low},
+	})
+	if paths := strings.Join(m.GetSelectedPaths(), ","); paths != "/b.env" {
+		t.Errorf("after SetFindings selected %s, want /b.env", paths)
+	}
+
+	m.SelectAll(true)
+	if files, _ := m.SelectionCount(); files != 2 {
+		t.Errorf("SelectAll selected %d files", files)
+	}
+	m.SelectAll(false)
+	if m.HasSelection() {
+		t.Error("SelectAll(false) kept a selection")
+	}
+
+	m.SelectAll(true)
+	m.Clear()
+	if m.HasSelection() || m.Len() != 0 || m.FilteredLen() != 0 {
+		t.Error("Clear kept files")
+	}
+}
+
+// TestResultsModelApplyDiff tests added findings are tagged new and a file
+// whose findings were all fixed is listed, without being selectable for
+// encryption
+func TestResultsModelApplyDiff(t *testing.T) {
+	kept := &searcher.Finding{FilePath: "/new/app.env", Severity: searcher.Critical}
+	added := &searcher.Finding{FilePath: "/new/app.env", Severity: searcher.High}
+	diff := &searcher.ScanDiff{
+		OldRoot:           "/old",
+		NewRoot:           "/new",
+		AddedFindings:     []*searcher.Finding{added},
+		PersistedFindings: []*searcher.Finding{kept},
+		RemovedFindings: []*searcher.Finding{
+			{FilePath: "/old/app.env", Severity: searcher.Low},
+			{FilePath: "/old/old.key", Severity: searcher.Critical},
+		},
+	}
+	m := NewResultsModel()
+	m.SetFindings([]*searcher.Finding{kept, added})
+	m.ApplyDiff(diff)
+
+	files := m.GetFilesWithFindings()
+	if len(files) != 2 || files[0].Fixed != 1 || !files[0].HasNew() {
+		t.Fatalf("app.env = %+v, want new findings and one fixed", files[0])
+	}
+	fixed := files[1]
+	if fixed.FilePath != filepath.Join("/new", "old.key") || fixed.Fixed != 1 || len(fixed.Findings) != 0 {
+		t.Errorf("fixed entry = %+v", fixed)
+	}
+
+	m.SelectAll(true)
+	if paths := m.GetSelectedPaths(); len(paths) != 1 || paths[0] != "/new/app.env" {
+		t.Errorf("selected paths = %v, want only the file with findings", paths)
+	}
+	m.ApplyDiff(diff)
+	if again := m.GetFilesWithFindings(); len(again) != 2 || again[1].Fixed != 1 {
+		t.Errorf("comparing again should replace the fixed entries, got %d files", len(again))
+	}
+}
+
+// TestResultsModelConcurrent tests findings stream in while the list is
+// drawn and files are picked; run with -race
+func TestResultsModelConcurrent(t *testing.T) {
+	m := NewResultsModel()
+	findings := manyFindings(5000)
+
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := w; i < len(findings); i += 4 {
+				m.Add(findings[i])
+			}
+		}(w)
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	for reading := true; reading; {
+		select {
+		case <-done:
+			reading = false
+		default:
+		}
+		for i := 0; i < m.FilteredLen(); i += 50 {
+			if file, ok := m.FilteredFile(i); ok {
+				_ = len(file.Findings)
+				m.SelectFile(file.FilePath, true)
+			}
+		}
+		m.SelectBySeverity(searcher.Critical)
+		m.SelectionCount()
+	}
+
+	if m.Len() != 500 || m.Counts().Total() != len(findings) {
+		t.Errorf("got %d files and %d findings", m.Len(), m.Counts().Total())
+	}
+}
+
+// TestScanController_Results tests the findings of a scan reach the results
+// model as they stream in
+func TestScanController_Results(t *testing.T) {
+	ctrl := NewScanController()
+	tempDir := t.TempDir()
+	secret := filepath.Join(tempDir, "config.env")
+	os.WriteFile(secret, []byte(`db_password = "Xk9#mP2$vL5@nQ8!rT4"`+"\n"), 0644)
+	os.WriteFile(filepath.Join(tempDir, "readme.txt"), []byte("nothing here\n"), 0644)
+
+	done := make(chan struct{})
+	ctrl.SetOnComplete(func(*searcher.ScanResult, error) { close(done) })
+	if err := ctrl.StartScan(tempDir); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("scan timed out")
+	}
+
+	files := ctrl.Results().GetFilesWithFindings()
+	if len(files) != 1 || files[0].FilePath != secret || len(files[0].Findings) == 0 {
+		t.Fatalf("results = %+v, want the findings of config.env", files)
+	}
+
+	ctrl.IgnoreFile(secret)
+	defer ctrl.ClearIgnoreList()
+	if got := ctrl.Results().FilteredLen(); got != 0 {
+		t.Errorf("ignored file still listed")
+	}
+}
+
+func BenchmarkResultsModelFilter(b *testing.B) {
+	findings := manyFindings(100000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m := NewResultsModel()
+		m.SetFindings(findings)
+		m.SetTextFilter("dir4")
+		m.SetSeverityFilter(searcher.High)
+		m.FilteredLen()
+	}
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/kacebover/password-finder/searcher"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════
+// ПРОВЕРКА ПЕРЕД КОММИТОМ
+// ═══════════════════════════════════════════════════════════════════════════
+
+// hookMarker marks the pre-commit hooks written by hook install, which may
+// be replaced without -force
+const hookMarker = "# Installed by data-leak-locator hook install"
+
+// runStagedScan scans what the next commit of opts.ScanDir records and
+// prints one file:line line per finding, secrets masked. Findings at or
+// above opts.FailOn exit with exitError, so git aborts the commit
+func runStagedScan(scanner *searcher.Scanner, opts scanOptions, baseline *searcher.BaselineIndex) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	result, err := scanner.ScanStaged(ctx, opts.ScanDir)
+	stop()
+	if err != nil {
+		if errors.Is(err, searcher.ErrCancelled) {
+			fmt.Println(ui.T("cli.cancelled"))
+		} else {
+			fmt.Println(ui.T("cli.error.scan", err))
+		}
+		result.Close()
+		os.Exit(exitCode(err))
+	}
+	if baseline != nil {
+		baseline.TagResult(result)
+		if !opts.ShowBaselined {
+			result.SuppressBaselined()
+		}
+	}
+
+	var findings []*searcher.Finding
+	result.ForEachFinding(func(f *searcher.Finding) bool {
+		findings = append(findings, f)
+		return true
+	})
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].FilePath != findings[j].FilePath {
+			return findings[i].FilePath < findings[j].FilePath
+		}
+		return findings[i].LineNumber < findings[j].LineNumber
+	})
+	for _, f := range findings {
+		fmt.Println(stagedFindingLine(f))
+	}
+	result.Close()
+
+	if opts.Verbose {
+		fmt.Println(ui.T("cli.staged.done", result.FilesScanned, len(findings)))
+	}
+	if count := countAtOrAbove(result, opts.FailOn); count > 0 {
+		fmt.Println(ui.T("cli.staged.blocked", ui.SeverityShort(string(opts.FailOn)), count))
+		os.Exit(exitError)
+	}
+}
+
+// stagedFindingLine is the compact form of a finding: path:line: pattern
+// [severity] masked match
+func stagedFindingLine(f *searcher.Finding) string {
+	return fmt.Sprintf("%s:%d: %s [%s] %s", f.FilePath, f.LineNumber, ui.Pattern(string(f.PatternType)),
+		ui.SeverityShort(string(f.Severity)), f.Masked().MatchedText)
+}
+
+func runHookCommand(args []string) {
+	if len(args) == 0 || (args[0] != "install" && args[0] != "установить") {
+		printHookHelp()
+		os.Exit(1)
+	}
+	runHookInstallCommand(args[1:])
+}
+
+func printHookHelp() {
+	fmt.Print(ui.T("cli.hook.help"))
+}
+
+func runHookInstallCommand(args []string) {
+	installCmd := flag.NewFlagSet("hook install", flag.ExitOnError)
+
+	repoDir := installCmd.String("dir", ".", "Git-репозиторий, в который установить хук")
+	failOn := installCmd.String("fail-on", string(searcher.High), "Уровень находок, останавливающий коммит: critical, high, medium, low")
+	force := installCmd.Bool("force", false, "Заменить существующий хук pre-commit")
+
+	installCmd.Usage = func() {
+		fmt.Print(ui.T("cli.hook.install.usage"))
+	}
+
+	if err := installCmd.Parse(args); err != nil {
+		os.Exit(1)
+	}
+	severity, err := searcher.ParseSeverity(*failOn)
+	if err != nil {
+		fmt.Println(ui.T("cli.error.flag", "-fail-on", err))
+		os.Exit(1)
+	}
+	executable, err := os.Executable()
+	if err != nil {
+		fmt.Println(ui.T("cli.error", err))
+		os.Exit(1)
+	}
+
+	path, err := installPreCommitHook(*repoDir, executable, severity, *force)
+	if err != nil {
+		fmt.Println(ui.T("cli.error", err))
+		os.Exit(exitCode(err))
+	}
+	fmt.Println(ui.T("cli.hook.installed", path))
+}
+
+// installPreCommitHook writes the pre-commit hook of the repository at dir
+// running executable, and returns its path. A hook written by someone else
+// is only replaced with force
+func installPreCommitHook(dir, executable string, failOn searcher.Severity, force bool) (string, error) {
+	// core.hooksPath and worktrees move the hooks away from .git/hooks
+	cmd := exec.Command("git", "-C", dir, "rev-parse", "--git-path", "hooks/pre-commit")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git rev-parse: %s", msg)
+		}
+		return "", fmt.Errorf("git rev-parse: %w", err)
+	}
+	path := strings.TrimSpace(string(out))
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+
+	if existing, err := os.ReadFile(path); err == nil && !force && !bytes.Contains(existing, []byte(hookMarker)) {
+		return "", errors.New(ui.T("cli.hook.exists", path))
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+	// Git runs hooks with sh on every platform, Git for Windows included
+	script := fmt.Sprintf("#!/bin/sh\n%s\nexec %s scan -staged -fail-on %s\n",
+		hookMarker, shellQuote(filepath.ToSlash(executable)), failOn)
+	if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+		return "", err
+	}
+	// WriteFile keeps the mode of an existing file
+	return path, os.Chmod(path, 0755)
+}
+
+// shellQuote quotes s as one word of sh
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kacebover/password-finder/searcher"
+)
+
+// gitTestRepo creates an empty repository and returns its path and a
+// function running git in it
+func gitTestRepo(t *testing.T) (string, func(args ...string)) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+		cmd.Env = append(os.Environ(), "GIT_CONFIG_GLOBAL=/dev/null")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	git("init", "-q")
+	return dir, git
+}
+
+// TestRunStagedScanExit runs the staged scan in a child process, because it
+// ends the process, and checks a staged secret aborts the commit
+func TestRunStagedScanExit(t *testing.T) {
+	if dir := os.Getenv("DATALEAK_TEST_STAGED_DIR"); dir != "" {
+		runScan(scanOptions{
+			ScanDir: dir,
+			MaxSize: searcher.MaxFileSize,
+			Staged:  true,
+			FailOn:  searcher.High,
+		})
+		os.Exit(exitOK)
+	}
+
+	repo, git := gitTestRepo(t)
+	os.WriteFile(filepath.Join(repo, "clean.txt"), []byte("nothing here\n"), 0644)
+	git("add", "clean.txt")
+
+	run := func() (int, string) {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestRunStagedScanExit$")
+		cmd.Env = append(os.Environ(), "DATALEAK_TEST_STAGED_DIR="+repo)
+		out, err := cmd.CombinedOutput()
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return exitErr.ExitCode(), string(out)
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		return exitOK, string(out)
+	}
+
+	if code, out := run(); code != exitOK {
+		t.Fatalf("clean index: exit code %d, want %d\n%s", code, exitOK, out)
+	}
+
+	secret := "Zx9Qw8Er7Ty6Ui5Op4As"
+	os.WriteFile(filepath.Join(repo, "settings.py"), []byte("DEBUG = True\npassword = '"+secret+"'\n"), 0644)
+	git("add", "settings.py")
+	code, out := run()
+	if code != exitError {
+		t.Errorf("staged secret: exit code %d, want %d\n%s", code, exitError, out)
+	}
+	if !strings.Contains(out, "settings.py:2: ") {
+		t.Errorf("output should name file:line:\n%s", out)
+	}
+	if strings.Contains(out, secret) {
+		t.Errorf("secret printed unmasked:\n%s", out)
+	}
+}
+
+func TestInstallPreCommitHook(t *testing.T) {
+	repo, _ := gitTestRepo(t)
+	hookPath := filepath.Join(repo, ".git", "hooks", "pre-commit")
+
+	path, err := installPreCommitHook(repo, "/opt/it's/data-leak-locator", searcher.Medium, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != hookPath {
+		t.Errorf("hook path %q, want %q", path, hookPath)
+	}
+	script, _ := os.ReadFile(hookPath)
+	want := `exec '/opt/it'\''s/data-leak-locator' scan -staged -fail-on medium`
+	if !strings.HasPrefix(string(script), "#!/bin/sh\n") || !strings.Contains(string(script), want) {
+		t.Errorf("hook script:\n%s\nwant a line %s", script, want)
+	}
+	if info, err := os.Stat(hookPath); err != nil || info.Mode().Perm()&0100 == 0 {
+		t.Errorf("hook not executable: %v %v", info, err)
+	}
+
+	// Reinstalling replaces its own hook
+	if _, err := installPreCommitHook(repo, "/usr/bin/data-leak-locator", searcher.High, false); err != nil {
+		t.Errorf("reinstall: %v", err)
+	}
+
+	// Someone else's hook is kept unless forced
+	os.WriteFile(hookPath, []byte("#!/bin/sh\nmake lint\n"), 0755)
+	if _, err := installPreCommitHook(repo, "/usr/bin/data-leak-locator", searcher.High, false); err == nil {
+		t.Error("foreign hook replaced without force")
+	}
+	if script, _ := os.ReadFile(hookPath); !strings.Contains(string(script), "make lint") {
+		t.Errorf("foreign hook changed:\n%s", script)
+	}
+	if _, err := installPreCommitHook(repo, "/usr/bin/data-leak-locator", searcher.High, true); err != nil {
+		t.Errorf("forced install: %v", err)
+	}
+
+	if _, err := installPreCommitHook(t.TempDir(), "/usr/bin/data-leak-locator", searcher.High, false); err == nil {
+		t.Error("installed outside a repository")
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/kacebover/password-finder/locale"
+)
+
+// ui is the language of the output and reports, set by -lang or the
+// locale variables of the environment
+var ui = locale.New(locale.Default)
+
+// errLanguageMissing is returned for a -lang at the end of the arguments
+var errLanguageMissing = errors.New("-lang needs a value")
+
+// localizedError shows a message in the language of the user while
+// errors.Is still matches the error it wraps
+type localizedError struct {
+	message string
+	err     error
+}
+
+func (e *localizedError) Error() string { return e.message }
+
+func (e *localizedError) Unwrap() error { return e.err }
+
+// languageOption takes -lang out of the arguments, as it applies to every
+// command rather than to the flags of one. Without it the language comes
+// from LC_ALL, LC_MESSAGES or LANG
+func languageOption(args []string) ([]string, locale.Lang, error) {
+	lang := locale.FromEnv()
+	l := locale.New(lang) // Errors are shown before -lang is known
+	rest := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		name, value, hasValue := strings.Cut(strings.TrimLeft(args[i], "-"), "=")
+		if !strings.HasPrefix(args[i], "-") || name != "lang" {
+			rest = append(rest, args[i])
+			continue
+		}
+		if !hasValue {
+			if i+1 == len(args) {
+				return nil, "", &localizedError{l.T("cli.lang.missing"), errLanguageMissing}
+			}
+			i++
+			value = args[i]
+		}
+		parsed, err := locale.Parse(value)
+		if err != nil {
+			return nil, "", &localizedError{l.T("cli.lang.unknown", value), err}
+		}
+		lang = parsed
+	}
+	return rest, lang, nil
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/kacebover/password-finder/locale"
+)
+
+func TestLanguageOption(t *testing.T) {
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LC_MESSAGES", "")
+	t.Setenv("LANG", "en_US.UTF-8")
+	tests := []struct {
+		args []string
+		rest string
+		want locale.Lang
+	}{
+		{[]string{"scan", "-dir", "."}, "scan -dir .", locale.English},
+		{[]string{"-lang", "ru", "scan", "-dir", "."}, "scan -dir .", locale.Russian},
+		{[]string{"scan", "--lang=ru", "-dir", "."}, "scan -dir .", locale.Russian},
+		{[]string{"scan", "-dir", "lang", "-lang", "EN"}, "scan -dir lang", locale.English},
+	}
+	for _, tt := range tests {
+		rest, lang, err := languageOption(tt.args)
+		if err != nil || strings.Join(rest, " ") != tt.rest || lang != tt.want {
+			t.Errorf("languageOption(%q) = %q, %q, %v, want %q, %q", tt.args, rest, lang, err, tt.rest, tt.want)
+		}
+	}
+
+	if _, _, err := languageOption([]string{"scan", "-lang"}); !errors.Is(err, errLanguageMissing) {
+		t.Errorf("-lang without a value: %v", err)
+	}
+	if _, _, err := languageOption([]string{"-lang=de"}); err == nil {
+		t.Error("-lang=de accepted")
+	}
+}
@@ -0,0 +1,206 @@
+// Package locale holds the user-facing strings of the scanner, the CLI and
+// the GUI. Messages are looked up by key in embedded catalogues, one per
+// language, so reports and interfaces come out in a single language
+// instead of Russian labels around English finding descriptions.
+//
+// Keys are grouped by prefix: severity.*, pattern.* and group.* name the
+// values of the searcher package, desc.* translate a finding description
+// keyed by its English text, report.*, csv.* and html.* are the exported
+// reports, cli.* and gui.* the interfaces. Messages with arguments are
+// fmt format strings
+package locale
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Lang is a language with a message catalogue
+type Lang string
+
+const (
+	Russian Lang = "ru"
+	English Lang = "en"
+)
+
+// Default is the language of the tool when none is chosen
+const Default = Russian
+
+// Langs are the languages with a catalogue, the default first
+var Langs = []Lang{Russian, English}
+
+// Parse converts a language name such as "en", "EN" or "en_US.UTF-8"
+func Parse(name string) (Lang, error) {
+	code := strings.ToLower(strings.TrimSpace(name))
+	if i := strings.IndexAny(code, "_-.@"); i >= 0 {
+		code = code[:i]
+	}
+	for _, lang := range Langs {
+		if Lang(code) == lang {
+			return lang, nil
+		}
+	}
+	return "", fmt.Errorf("неизвестный язык: %q (допустимо: ru, en)", name)
+}
+
+// FromEnv picks the language of the first locale variable that is set,
+// LC_ALL, LC_MESSAGES or LANG. Russian locales and the C locale keep the
+// default; any other language gets English
+func FromEnv() Lang {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		value := os.Getenv(name)
+		if value == "" {
+			continue
+		}
+		if value == "C" || value == "POSIX" || strings.HasPrefix(value, "C.") {
+			return Default
+		}
+		if lang, err := Parse(value); err == nil {
+			return lang
+		}
+		return English
+	}
+	return Default
+}
+
+//go:embed messages/*.json
+var messageFiles embed.FS
+
+var (
+	catalogsOnce sync.Once
+	catalogs     map[Lang]map[string]string
+)
+
+// catalog returns the messages of lang, loading every catalogue on first use
+func catalog(lang Lang) map[string]string {
+	catalogsOnce.Do(func() {
+		catalogs = make(map[Lang]map[string]string, len(Langs))
+		for _, l := range Langs {
+			data, err := messageFiles.ReadFile("messages/" + string(l) + ".json")
+			if err != nil {
+				panic(err)
+			}
+			messages := make(map[string]string)
+			if err := json.Unmarshal(data, &messages); err != nil {
+				panic(fmt.Sprintf("locale: messages/%s.json: %v", l, err))
+			}
+			catalogs[l] = messages
+		}
+	})
+	return catalogs[lang]
+}
+
+// Localizer looks up messages in one language. A nil Localizer speaks
+// Default
+type Localizer struct {
+	lang     Lang
+	messages map[string]string
+}
+
+// New returns a Localizer of lang; an unknown language gets Default
+func New(lang Lang) *Localizer {
+	messages := catalog(lang)
+	if messages == nil {
+		lang, messages = Default, catalog(Default)
+	}
+	return &Localizer{lang: lang, messages: messages}
+}
+
+// Lang returns the language of l
+func (l *Localizer) Lang() Lang {
+	if l == nil {
+		return Default
+	}
+	return l.lang
+}
+
+// Lookup returns the message of key and whether l has one
+func (l *Localizer) Lookup(key string) (string, bool) {
+	messages := catalog(Default)
+	if l != nil {
+		messages = l.messages
+	}
+	message, ok := messages[key]
+	return message, ok
+}
+
+// T returns the message of key formatted with args. A key missing from the
+// catalogue falls back to Default, then to the key itself
+func (l *Localizer) T(key string, args ...any) string {
+	message, ok := l.Lookup(key)
+	if !ok {
+		if message, ok = catalog(Default)[key]; !ok {
+			message = key
+		}
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(message, args...)
+	}
+	return message
+}
+
+// Plural returns the form of key agreeing with n: key.one, key.few or
+// key.many. English uses only one and many
+func (l *Localizer) Plural(n int, key string) string {
+	if n < 0 {
+		n = -n
+	}
+	form := "many"
+	switch {
+	case l.Lang() == English:
+		if n == 1 {
+			form = "one"
+		}
+	case n%100 >= 11 && n%100 <= 14:
+	case n%10 == 1:
+		form = "one"
+	case n%10 >= 2 && n%10 <= 4:
+		form = "few"
+	}
+	return l.T(key + "." + form)
+}
+
+// name returns the message of prefix+value, or value when there is none
+func (l *Localizer) name(prefix, value string) string {
+	if message, ok := l.Lookup(prefix + value); ok {
+		return message
+	}
+	return value
+}
+
+// Severity names a severity level: "critical" is "Критический"
+func (l *Localizer) Severity(severity string) string {
+	return l.name("severity.", severity)
+}
+
+// SeverityShort is the abbreviated Severity for narrow columns
+func (l *Localizer) SeverityShort(severity string) string {
+	return l.name("severity.short.", severity)
+}
+
+// Pattern names a pattern type: "api_key" is "API-ключ"
+func (l *Localizer) Pattern(pattern string) string {
+	return l.name("pattern.", pattern)
+}
+
+// Group names a detector group or rule pack
+func (l *Localizer) Group(group string) string {
+	return l.name("group.", group)
+}
+
+// PasswordFeedback translates a suggestion of encryptor.PasswordStrength,
+// which makes them in English
+func (l *Localizer) PasswordFeedback(text string) string {
+	return l.name("password.", text)
+}
+
+// Description translates a finding description written in English and
+// reports whether the catalogue has it. Descriptions made of parts, such
+// as the base64 suffix, are put together by searcher.LocalizeDescription
+func (l *Localizer) Description(desc string) (string, bool) {
+	return l.Lookup("desc." + desc)
+}
@@ -0,0 +1,110 @@
+package locale
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// verbs matches the fmt verbs of a message, %% excluded
+var verbs = regexp.MustCompile(`%[-+# 0]*[0-9]*(\.[0-9]+)?[a-zA-Z]`)
+
+// TestCatalogParity tests every language has the keys of the default one,
+// and no others, with the same format verbs
+func TestCatalogParity(t *testing.T) {
+	base := catalog(Default)
+	if len(base) == 0 {
+		t.Fatal("default catalogue is empty")
+	}
+	for _, lang := range Langs {
+		messages := catalog(lang)
+		for key, message := range base {
+			translated, ok := messages[key]
+			if !ok {
+				t.Errorf("%s: missing %q", lang, key)
+				continue
+			}
+			if translated == "" {
+				t.Errorf("%s: empty %q", lang, key)
+			}
+			if got, want := verbList(translated), verbList(message); got != want {
+				t.Errorf("%s: %q has verbs %s, %s has %s", lang, key, got, Default, want)
+			}
+		}
+		for key := range messages {
+			if _, ok := base[key]; !ok {
+				t.Errorf("%s: %q is not in the %s catalogue", lang, key, Default)
+			}
+		}
+	}
+}
+
+// verbList returns the verbs of a message in order, with %% removed
+func verbList(message string) string {
+	return strings.Join(verbs.FindAllString(strings.ReplaceAll(message, "%%", ""), -1), "")
+}
+
+// TestPluralForms tests both languages agree a noun with a number
+func TestPluralForms(t *testing.T) {
+	ru, en := New(Russian), New(English)
+	for n, want := range map[int]string{0: "файлов", 1: "файл", 3: "файла", 11: "файлов", 21: "файл", 104: "файла"} {
+		if got := ru.Plural(n, "plural.file"); got != want {
+			t.Errorf("ru Plural(%d) = %q, want %q", n, got, want)
+		}
+	}
+	for n, want := range map[int]string{0: "files", 1: "file", 21: "files"} {
+		if got := en.Plural(n, "plural.file"); got != want {
+			t.Errorf("en Plural(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	for name, want := range map[string]Lang{"ru": Russian, "EN": English, "en_US.UTF-8": English, "ru-RU": Russian} {
+		if got, err := Parse(name); err != nil || got != want {
+			t.Errorf("Parse(%q) = %q, %v, want %q", name, got, err, want)
+		}
+	}
+	if _, err := Parse("de"); err == nil {
+		t.Error("Parse(de) succeeded")
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	tests := []struct {
+		all, lang string
+		want      Lang
+	}{
+		{"", "", Default},
+		{"", "en_GB.UTF-8", English},
+		{"", "de_DE.UTF-8", English},
+		{"", "C.UTF-8", Default},
+		{"ru_RU.UTF-8", "en_US.UTF-8", Russian},
+	}
+	for _, tt := range tests {
+		t.Setenv("LC_ALL", tt.all)
+		t.Setenv("LC_MESSAGES", "")
+		t.Setenv("LANG", tt.lang)
+		if got := FromEnv(); got != tt.want {
+			t.Errorf("LC_ALL=%q LANG=%q: %q, want %q", tt.all, tt.lang, got, tt.want)
+		}
+	}
+}
+
+// TestFallback tests missing keys and a nil Localizer
+func TestFallback(t *testing.T) {
+	var l *Localizer
+	if got := l.Severity("critical"); got != "Критический" {
+		t.Errorf("nil Localizer: %q", got)
+	}
+	en := New(English)
+	if got := en.T("no.such.key"); got != "no.such.key" {
+		t.Errorf("missing key: %q", got)
+	}
+	if got := en.Pattern("custom_rule"); got != "custom_rule" {
+		t.Errorf("unknown pattern: %q", got)
+	}
+	if New("xx").Lang() != Default {
+		t.Error("unknown language is not the default")
+	}
+}
@@ -1,27 +1,60 @@
 package main
 
 import (
-	"bufio"
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/kacebover/password-finder/encryptor"
+	"github.com/kacebover/password-finder/fsutil"
+	"github.com/kacebover/password-finder/locale"
 	"github.com/kacebover/password-finder/searcher"
 )
 
 func main() {
+	args, lang, err := languageOption(os.Args[1:])
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(2)
+	}
+	ui = locale.New(lang)
+	os.Args = append(os.Args[:1], args...)
+
 	// Проверка подкоманд
 	if len(os.Args) >= 2 {
 		switch os.Args[1] {
 		case "encrypt", "шифровать":
 			runEncryptCommand(os.Args[2:])
 			return
+		case "decrypt", "расшифровать":
+			runDecryptCommand(os.Args[2:])
+			return
 		case "scan", "сканировать":
 			runScanCommand(os.Args[2:])
 			return
+		case "rules", "правила":
+			runRulesCommand(os.Args[2:])
+			return
+		case "report", "отчёт":
+			runReportCommand(os.Args[2:])
+			return
+		case "explain", "объяснить":
+			runExplainCommand(os.Args[2:])
+			return
+		case "serve", "сервер":
+			runServeCommand(os.Args[2:])
+			return
+		case "hook", "хук":
+			runHookCommand(os.Args[2:])
+			return
 		case "help", "--help", "-h", "помощь":
 			printMainHelp()
 			return
@@ -33,24 +66,7 @@ func main() {
 }
 
 func printMainHelp() {
-	fmt.Println("🔍 Поиск Утечек Данных - Сканер и Шифровальщик")
-	fmt.Println("================================================")
-	fmt.Println()
-	fmt.Println("Команды:")
-	fmt.Println("  scan (сканировать)    Сканировать директорию на наличие чувствительных данных")
-	fmt.Println("  encrypt (шифровать)   Зашифровать файлы в защищённый паролем ZIP-архив")
-	fmt.Println("  help (помощь)         Показать эту справку")
-	fmt.Println()
-	fmt.Println("Использование:")
-	fmt.Println("  data-leak-locator scan [опции]")
-	fmt.Println("  data-leak-locator encrypt [опции] <файлы...>")
-	fmt.Println()
-	fmt.Println("Примеры:")
-	fmt.Println("  data-leak-locator scan -dir /путь/к/проекту")
-	fmt.Println("  data-leak-locator encrypt -output secrets.zip file1.txt file2.env")
-	fmt.Println("  data-leak-locator encrypt -dir /sensitive/data -password mypass")
-	fmt.Println()
-	fmt.Println("Запустите 'data-leak-locator <команда> -h' для подробной информации.")
+	fmt.Print(ui.T("cli.help"))
 }
 
 // ═══════════════════════════════════════════════════════════════════════════
@@ -60,57 +76,30 @@ func printMainHelp() {
 func runEncryptCommand(args []string) {
 	encryptCmd := flag.NewFlagSet("encrypt", flag.ExitOnError)
 
-	outputPath := encryptCmd.String("output", "", "Путь к выходному ZIP-файлу (обязательно)")
+	outputPath := encryptCmd.String("output", "", "Путь к выходному архиву (обязательно)")
+	formatName := encryptCmd.String("format", "zip", "Формат архива: zip, 7z или age")
+	passwordArg := encryptCmd.Bool("7z-password-arg", false, "Разрешить передать пароль 7-Zip в командной строке, где его видят другие пользователи системы")
 	password := encryptCmd.String("password", "", "Пароль для шифрования (будет запрошен, если не указан)")
 	dirPath := encryptCmd.String("dir", "", "Директория для шифрования (альтернатива указанию файлов)")
 	deleteOriginals := encryptCmd.Bool("delete", false, "Безопасно удалить оригиналы после шифрования")
 	deletePasses := encryptCmd.Int("delete-passes", 3, "Количество проходов перезаписи для безопасного удаления")
 	generatePwd := encryptCmd.Bool("generate-password", false, "Сгенерировать случайный безопасный пароль")
 	pwdLength := encryptCmd.Int("password-length", 16, "Длина генерируемого пароля")
+	continueOnError := encryptCmd.Bool("continue-on-error", false, "Пропускать нечитаемые файлы и шифровать остальные")
+	verify := encryptCmd.Bool("verify", false, "Проверить архив после шифрования (всегда включено с -delete)")
+	strict := encryptCmd.Bool("strict", false, "Отказаться шифровать слабым паролем")
+	splitSize := encryptCmd.String("split-size", "", "Разбить архив на тома указанного размера, например 500MB или 1.5GB")
+	manifest := encryptCmd.Bool("manifest", false, "Добавить в архив MANIFEST.json и MANIFEST.txt со списком файлов и их SHA-256")
+	manifestFrom := encryptCmd.String("manifest-from", "", "JSON-отчёт сканирования, находки которого попадут в MANIFEST (включает -manifest)")
+	var excludeGlobs []string
+	encryptCmd.Func("exclude", "Не добавлять в архив пути по шаблону, например **/.git (можно указать несколько раз)", func(value string) error {
+		excludeGlobs = append(excludeGlobs, value)
+		return nil
+	})
 	verbose := encryptCmd.Bool("verbose", false, "Подробный вывод")
 
 	encryptCmd.Usage = func() {
-		fmt.Println("🔐 Шифрование и Экспорт Файлов")
-		fmt.Println("==============================")
-		fmt.Println()
-		fmt.Println("Шифрует файлы в защищённый паролем ZIP-архив с использованием AES-256.")
-		fmt.Println()
-		fmt.Println("Использование:")
-		fmt.Println("  data-leak-locator encrypt [опции] <файлы...>")
-		fmt.Println("  data-leak-locator encrypt -dir <директория> [опции]")
-		fmt.Println()
-		fmt.Println("Опции:")
-		fmt.Println("  -output string")
-		fmt.Println("        Путь к выходному ZIP-файлу (обязательно)")
-		fmt.Println("  -password string")
-		fmt.Println("        Пароль для шифрования (будет запрошен, если не указан)")
-		fmt.Println("  -dir string")
-		fmt.Println("        Директория для шифрования (альтернатива указанию файлов)")
-		fmt.Println("  -delete")
-		fmt.Println("        Безопасно удалить оригиналы после шифрования")
-		fmt.Println("  -delete-passes int")
-		fmt.Println("        Количество проходов перезаписи (по умолчанию: 3)")
-		fmt.Println("  -generate-password")
-		fmt.Println("        Сгенерировать случайный безопасный пароль")
-		fmt.Println("  -password-length int")
-		fmt.Println("        Длина генерируемого пароля (по умолчанию: 16)")
-		fmt.Println("  -verbose")
-		fmt.Println("        Подробный вывод")
-		fmt.Println()
-		fmt.Println("Примеры:")
-		fmt.Println("  # Зашифровать файлы с запросом пароля")
-		fmt.Println("  data-leak-locator encrypt -output secrets.zip config.env api_keys.txt")
-		fmt.Println()
-		fmt.Println("  # Зашифровать директорию с генерацией пароля")
-		fmt.Println("  data-leak-locator encrypt -dir ./sensitive -output backup.zip -generate-password")
-		fmt.Println()
-		fmt.Println("  # Зашифровать и безопасно удалить оригиналы")
-		fmt.Println("  data-leak-locator encrypt -output secure.zip -delete -password myP@ss123 file.txt")
-		fmt.Println()
-		fmt.Println("Безопасность:")
-		fmt.Println("  • Используется шифрование AES-256 (совместимо с WinZip)")
-		fmt.Println("  • Пароли не сохраняются и не логируются")
-		fmt.Println("  • Безопасное удаление использует многократную перезапись")
+		fmt.Print(ui.T("cli.encrypt.usage"))
 	}
 
 	if err := encryptCmd.Parse(args); err != nil {
@@ -124,11 +113,11 @@ func runEncryptCommand(args []string) {
 		// Проверка директории
 		info, err := os.Stat(*dirPath)
 		if err != nil {
-			fmt.Printf("❌ Ошибка: Директория не найдена: %s\n", *dirPath)
-			os.Exit(1)
+			fmt.Println(ui.T("cli.encrypt.no_dir", *dirPath))
+			os.Exit(exitCode(err))
 		}
 		if !info.IsDir() {
-			fmt.Printf("❌ Ошибка: Это не директория: %s\n", *dirPath)
+			fmt.Println(ui.T("cli.encrypt.not_dir", *dirPath))
 			os.Exit(1)
 		}
 		files = append(files, *dirPath)
@@ -138,21 +127,65 @@ func runEncryptCommand(args []string) {
 	files = append(files, encryptCmd.Args()...)
 
 	if len(files) == 0 {
-		fmt.Println("❌ Ошибка: Не указаны файлы для шифрования")
-		fmt.Println("Используйте -dir для указания директории или укажите пути к файлам")
+		fmt.Println(ui.T("cli.encrypt.no_files"))
 		encryptCmd.Usage()
 		os.Exit(1)
 	}
 
 	// Проверка пути вывода
 	if *outputPath == "" {
-		fmt.Println("❌ Ошибка: Необходимо указать путь вывода (-output)")
+		fmt.Println(ui.T("cli.encrypt.no_output"))
 		os.Exit(1)
 	}
 
-	// Добавление расширения .zip
-	if !strings.HasSuffix(strings.ToLower(*outputPath), ".zip") {
-		*outputPath += ".zip"
+	format, err := encryptor.ParseOutputFormat(*formatName)
+	if err != nil {
+		fmt.Println(ui.T("cli.encrypt.bad_format", *formatName))
+		os.Exit(exitCode(err))
+	}
+
+	var volumeSize int64
+	if *splitSize != "" {
+		if volumeSize, err = fsutil.ParseFileSize(*splitSize); err != nil {
+			fmt.Println(ui.T("cli.error.flag", "-split-size", err))
+			os.Exit(1)
+		}
+		if volumeSize < encryptor.MinSplitSize {
+			fmt.Println(ui.T("cli.encrypt.min_split", formatBytes(encryptor.MinSplitSize)))
+			os.Exit(1)
+		}
+	}
+
+	var manifestSource *encryptor.ManifestSource
+	if *manifestFrom != "" {
+		report, err := searcher.LoadScanResult(*manifestFrom)
+		if err != nil {
+			fmt.Println(ui.T("cli.error.flag", "-manifest-from", err))
+			os.Exit(exitCode(err))
+		}
+		manifestSource = reportManifestSource(report)
+		report.Close()
+	}
+
+	// Добавление расширения формата
+	if !strings.HasSuffix(strings.ToLower(*outputPath), format.Ext()) {
+		*outputPath += format.Ext()
+	}
+
+	// 7z пишет установленный 7-Zip, получающий пароль в командной строке
+	var sevenZipPath string
+	if format == encryptor.FormatSevenZip {
+		if !*passwordArg {
+			fmt.Println(ui.T("cli.encrypt.7zip_password_arg"))
+			os.Exit(1)
+		}
+		status := searcher.NewDependencyChecker().Status(searcher.DependencySevenZip)
+		if !status.Available {
+			fmt.Println(ui.T("cli.encrypt.no_7zip"))
+			fmt.Println(ui.T("cli.install_hint", status.InstallHint))
+			os.Exit(exitCode(&encryptor.ErrDependencyMissing{Name: "7z"}))
+		}
+		sevenZipPath = status.Path
 	}
 
 	// Обработка пароля
@@ -161,45 +194,50 @@ func runEncryptCommand(args []string) {
 	if *generatePwd {
 		generatedPwd, err := encryptor.GeneratePassword(*pwdLength)
 		if err != nil {
-			fmt.Printf("❌ Ошибка генерации пароля: %v\n", err)
+			fmt.Println(ui.T("cli.encrypt.generate_error", err))
 			os.Exit(1)
 		}
 		pwd = generatedPwd
-		fmt.Println("🔑 Сгенерированный пароль:")
+		fmt.Println(ui.T("cli.encrypt.generated"))
 		fmt.Println()
 		fmt.Printf("   %s\n", pwd)
 		fmt.Println()
-		fmt.Println("⚠️  ВАЖНО: Сохраните этот пароль! Его невозможно восстановить.")
+		fmt.Println(ui.T("cli.encrypt.save_password"))
 		fmt.Println()
 	} else if pwd == "" {
 		// Запрос пароля
-		pwd = promptPassword("Введите пароль для шифрования: ")
-		confirmPwd := promptPassword("Подтвердите пароль: ")
-
-		if pwd != confirmPwd {
-			fmt.Println("❌ Ошибка: Пароли не совпадают")
+		var err error
+		pwd, err = promptNewPassword(ui.T("cli.encrypt.prompt"), ui.T("cli.encrypt.confirm"))
+		if err != nil {
+			fmt.Println(ui.T("cli.error", err))
 			os.Exit(1)
 		}
 	}
 
 	// Проверка пароля
-	if err := encryptor.ValidatePassword(pwd); err != nil {
-		fmt.Printf("❌ Ошибка: %v\n", err)
+	if err := encryptor.ValidatePasswordLegacy(pwd); err != nil {
+		fmt.Println(ui.T("cli.error", err))
 		os.Exit(1)
 	}
+	if score, feedback := encryptor.PasswordStrength(pwd); score < encryptor.DefaultMinPasswordScore {
+		printWeakPassword(os.Stdout, score, feedback, *strict)
+		if *strict {
+			os.Exit(1)
+		}
+	}
 
 	// Проверка существования файлов
 	var fileEntries []encryptor.FileEntry
 	for _, f := range files {
 		absPath, err := filepath.Abs(f)
 		if err != nil {
-			fmt.Printf("❌ Ошибка определения пути %s: %v\n", f, err)
+			fmt.Println(ui.T("cli.encrypt.path_error", f, err))
 			os.Exit(1)
 		}
 
 		if _, err := os.Stat(absPath); err != nil {
-			fmt.Printf("❌ Ошибка: Файл не найден: %s\n", absPath)
-			os.Exit(1)
+			fmt.Println(ui.T("cli.encrypt.not_found", absPath))
+			os.Exit(exitCode(err))
 		}
 
 		fileEntries = append(fileEntries, encryptor.FileEntry{SourcePath: absPath})
@@ -209,100 +247,209 @@ func runEncryptCommand(args []string) {
 	config := encryptor.DefaultConfig()
 	config.Password = pwd
 	config.OutputPath = *outputPath
+	config.Format = format
+	config.SevenZipPath = sevenZipPath
+	config.SevenZipPasswordArg = *passwordArg
 	config.CompressionLevel = 6
+	config.ContinueOnError = *continueOnError
+	config.ExcludeGlobs = excludeGlobs
+	config.VerifyAfterEncrypt = *verify || *deleteOriginals
+	config.SplitSize = volumeSize
+	config.IncludeManifest = *manifest || manifestSource != nil
+	config.ManifestSource = manifestSource
 
 	if *verbose {
 		config.OnProgress = func(processed, total int64, currentFile string) {
 			pct := float64(processed) / float64(total) * 100
-			fmt.Printf("\r🔄 Шифрование: %s (%.1f%%)     ", filepath.Base(currentFile), pct)
+			fmt.Printf("\r%s     ", ui.T("cli.encrypt.progress", filepath.Base(currentFile), pct))
 		}
 	}
 
 	enc, err := encryptor.NewEncryptor(config)
 	if err != nil {
-		fmt.Printf("❌ Ошибка: %v\n", err)
+		fmt.Println(ui.T("cli.error", err))
 		os.Exit(1)
 	}
 
 	if *verbose {
-		fmt.Printf("🔐 Шифрование %d элементов в %s...\n", len(fileEntries), *outputPath)
+		fmt.Println(ui.T("cli.encrypt.start", len(fileEntries), *outputPath))
 	}
 
 	// Запуск шифрования
 	result, err := enc.EncryptFilesWithResult(fileEntries)
 	if err != nil {
-		fmt.Printf("\n❌ Ошибка шифрования: %v\n", err)
-		os.Exit(1)
+		fmt.Println("\n" + ui.T("cli.encrypt.error", err))
+		os.Exit(exitCode(err))
 	}
 
 	if *verbose {
-		fmt.Println() // новая строка после прогресса
+		fmt.Println() // New line after the progress
 	}
 
 	// Вывод результата
 	fmt.Println()
-	fmt.Println("✅ Шифрование завершено!")
+	fmt.Println(ui.T("cli.encrypt.done"))
 	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
-	fmt.Printf("📦 Архив:             %s\n", result.OutputPath)
-	fmt.Printf("📁 Файлов:            %d\n", result.FilesEncrypted)
-	fmt.Printf("📊 Исходный размер:   %s\n", formatBytes(result.TotalSize))
-	fmt.Printf("📊 Размер архива:     %s\n", formatBytes(result.ArchiveSize))
-	fmt.Printf("📈 Сжатие:            %.1f%%\n", result.CompressionRatio*100)
+	fmt.Println(ui.T("cli.encrypt.archive", result.OutputPath))
+	fmt.Println(ui.T("cli.encrypt.files", result.FilesEncrypted))
+	fmt.Println(ui.T("cli.encrypt.source_size", formatBytes(result.TotalSize)))
+	fmt.Println(ui.T("cli.encrypt.archive_size", formatBytes(result.ArchiveSize)))
+	if len(result.Parts) > 0 {
+		fmt.Println(ui.T("cli.encrypt.volumes", len(result.Parts), formatBytes(volumeSize),
+			filepath.Base(result.OutputPath+encryptor.SplitManifestSuffix)))
+		if *verbose {
+			for _, part := range result.Parts {
+				fmt.Printf("   %s\n", part)
+			}
+		}
+	}
+	fmt.Println(ui.T("cli.encrypt.compression", result.CompressionRatio*100))
+	if result.Verified {
+		fmt.Println(ui.T("cli.encrypt.verified", result.VerifyDuration.Round(time.Millisecond)))
+	}
 	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 
-	// Безопасное удаление, если запрошено
-	if *deleteOriginals {
+	// Пропущенные и исключённые файлы не попали в архив и не удаляются
+	absOutput, _ := filepath.Abs(result.OutputPath)
+	skipped := map[string]bool{absOutput: true, absOutput + encryptor.SplitManifestSuffix: true}
+	for _, part := range result.Parts {
+		absPart, _ := filepath.Abs(part)
+		skipped[absPart] = true
+	}
+	if len(result.Errors) > 0 {
 		fmt.Println()
-		fmt.Printf("🗑️  Безопасное удаление %d оригинальных файлов (%d проходов)...\n", len(files), *deletePasses)
+		fmt.Println(ui.T("cli.encrypt.skipped", len(result.Errors)))
+		for _, fileErr := range result.Errors {
+			fmt.Printf("   %s: %v\n", fileErr.Path, fileErr.Err)
+			skipped[fileErr.Path] = true
+		}
+	}
 
-		// Сбор путей к файлам (развёртывание директорий)
-		var filesToDelete []string
-		for _, f := range files {
-			info, _ := os.Stat(f)
+	// Безопасное удаление, если запрошено и архив прошёл проверку
+	if *deleteOriginals && result.Verified {
+		fmt.Println()
+		fmt.Println(ui.T("cli.delete.start", len(files), *deletePasses))
+
+		// Директории, целиком попавшие в архив, удаляются вместе с
+		// поддиректориями; из остальных удаляются только файлы архива
+		wholeTrees := len(excludeGlobs) == 0 && len(result.Errors) == 0
+		var trees, filesToDelete []string
+		for _, entry := range fileEntries {
+			f := entry.SourcePath
+			info, err := os.Stat(f)
+			if err != nil {
+				continue
+			}
 			if info.IsDir() {
+				rel, err := filepath.Rel(f, absOutput)
+				if wholeTrees && err == nil && (rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator))) {
+					trees = append(trees, f)
+					continue
+				}
 				filepath.Walk(f, func(path string, info os.FileInfo, err error) error {
-					if err == nil && !info.IsDir() {
+					if err == nil && enc.Excluded(f, path) {
+						if info.IsDir() {
+							return filepath.SkipDir
+						}
+						return nil
+					}
+					if err == nil && !info.IsDir() && !skipped[path] {
 						filesToDelete = append(filesToDelete, path)
 					}
 					return nil
 				})
-			} else {
+			} else if !skipped[f] {
 				filesToDelete = append(filesToDelete, f)
 			}
 		}
 
-		err := encryptor.SecureDeleteMultiple(filesToDelete, *deletePasses, func(current, total int, path string) {
+		progress := func(current, total int, path string) {
 			if *verbose {
-				fmt.Printf("   Удаление: %s (%d/%d)\n", filepath.Base(path), current, total)
+				fmt.Println(ui.T("cli.delete.progress", filepath.Base(path), current, total))
 			}
-		})
+		}
+		var deleted int
+		var bestEffort string
+		addResult := func(r *encryptor.DeleteResult) {
+			deleted += r.FilesDeleted
+			if r.Method == encryptor.DeleteBestEffort && bestEffort == "" {
+				bestEffort = r.Reason
+			}
+		}
 
-		if err != nil {
-			fmt.Printf("⚠️  Предупреждение: Некоторые файлы не удалось удалить: %v\n", err)
+		var deleteErr error
+		for _, tree := range trees {
+			r, err := encryptor.SecureDeleteTree(tree, *deletePasses, progress)
+			addResult(r)
+			if err != nil {
+				deleteErr = err
+				break
+			}
+		}
+		for i, path := range filesToDelete {
+			if deleteErr != nil {
+				break
+			}
+			progress(i+1, len(filesToDelete), path)
+			r, err := encryptor.SecureDeleteFile(path, *deletePasses)
+			addResult(r)
+			if err != nil {
+				deleteErr = fmt.Errorf("%s: %w", path, err)
+			}
+		}
+
+		if bestEffort != "" {
+			fmt.Println(ui.T("cli.delete.best_effort", bestEffort))
+		}
+		if deleteErr != nil {
+			fmt.Println(ui.T("cli.delete.failed", deleteErr))
 		} else {
-			fmt.Printf("✅ Безопасно удалено %d файлов\n", len(filesToDelete))
+			fmt.Println(ui.T("cli.delete.done", deleted))
 		}
 	}
 }
 
-func promptPassword(prompt string) string {
-	fmt.Print(prompt)
-	reader := bufio.NewReader(os.Stdin)
-	password, _ := reader.ReadString('\n')
-	return strings.TrimSpace(password)
+// reportManifestSource groups the findings of a scan report by file for
+// the archive manifest. Only the pattern, line, severity and risk score are
+// kept, never the matched text; relative paths are taken from the scan root
+func reportManifestSource(report *searcher.ScanResult) *encryptor.ManifestSource {
+	source := &encryptor.ManifestSource{
+		ScanRoot: report.Root,
+		Findings: make(map[string][]encryptor.ManifestFinding),
+	}
+	if report.StartTime > 0 {
+		source.ScanTime = time.Unix(report.StartTime, 0)
+	}
+	report.ForEachFinding(func(f *searcher.Finding) bool {
+		path := f.FilePath
+		if !filepath.IsAbs(path) && report.Root != "" {
+			path = filepath.Join(report.Root, path)
+		}
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+		source.Findings[path] = append(source.Findings[path], encryptor.ManifestFinding{
+			Pattern:   string(f.PatternType),
+			Line:      f.LineNumber,
+			Severity:  string(f.Severity),
+			RiskScore: f.RiskScore,
+		})
+		return true
+	})
+	return source
 }
 
 func formatBytes(bytes int64) string {
 	const unit = 1024
 	if bytes < unit {
-		return fmt.Sprintf("%d Б", bytes)
+		return fmt.Sprintf("%d %s", bytes, ui.T("cli.unit.b"))
 	}
 	div, exp := int64(unit), 0
 	for n := bytes / unit; n >= unit; n /= unit {
 		div *= unit
 		exp++
 	}
-	return fmt.Sprintf("%.1f %s", float64(bytes)/float64(div), []string{"КБ", "МБ", "ГБ", "ТБ"}[exp])
+	return fmt.Sprintf("%.1f %s", float64(bytes)/float64(div), ui.T([]string{"cli.unit.kb", "cli.unit.mb", "cli.unit.gb", "cli.unit.tb"}[exp]))
 }
 
 // ═══════════════════════════════════════════════════════════════════════════
@@ -313,65 +460,287 @@ func runScanCommand(args []string) {
 	scanCmd := flag.NewFlagSet("scan", flag.ExitOnError)
 
 	scanDir := scanCmd.String("dir", "", "Директория для сканирования (обязательно)")
+	s3Location := scanCmd.String("s3", "", "Сканировать бакет S3 вместо директории: бакет/префикс")
+	s3Endpoint := scanCmd.String("s3-endpoint", "", "Адрес S3-совместимого хранилища, например http://localhost:9000 для MinIO")
+	s3Region := scanCmd.String("s3-region", "", "Регион S3 (по умолчанию из AWS_REGION или us-east-1)")
 	outputDir := scanCmd.String("output", ".", "Директория для сохранения отчётов")
+	format := scanCmd.String("format", "", "Форматы отчётов через запятую: json, csv, txt, html, sarif, junit, xlsx")
+	includeSecrets := scanCmd.Bool("include-secrets", false, "Записывать в отчёты найденные секреты целиком, без маскирования")
+	csvColumns := scanCmd.String("csv-columns", "", "Колонки CSV-отчёта через запятую, например path,line,severity,risk")
+	csvNoBOM := scanCmd.Bool("csv-no-bom", false, "Не записывать BOM в начало CSV-отчёта")
+	failOn := scanCmd.String("fail-on", "", "Завершиться с кодом 8, если есть находки этого уровня и выше: critical, high, medium, low")
 	maxSize := scanCmd.Int64("max-size", 100*1024*1024, "Максимальный размер файла для сканирования в байтах")
+	fileTimeout := scanCmd.Duration("file-timeout", searcher.DefaultFileTimeout, "Сколько может длиться проверка одного файла, включая извлечение текста и OCR; 0 — без ограничения")
+	minSeverity := scanCmd.String("min-severity", "", "Отбрасывать находки ниже этого уровня: critical, high, medium, low")
+	heuristics := scanCmd.String("heuristics", string(searcher.HeuristicBalanced), "Отсев ссылок на переменные и заглушек: off, balanced, aggressive")
+	maxPerFile := scanCmd.Int("max-per-file", 0, "Прекращать поиск в файле после стольких находок (0 — без ограничения)")
+	bulkThreshold := scanCmd.Int("bulk-threshold", searcher.DefaultBulkThreshold, "Сколько разных email-адресов или телефонов может быть в файле до находки о массовой выгрузке (0 — не проверять)")
+	watch := scanCmd.Bool("watch", false, "После сканирования наблюдать за директорией и проверять новые и изменённые файлы")
+	watchDebounce := scanCmd.Duration("watch-debounce", searcher.DefaultWatchDebounce, "С -watch: сколько файл не должен меняться перед проверкой")
+	watchLog := scanCmd.String("watch-log", "", "С -watch: журнал находок JSONL (по умолчанию в -output)")
 	verbose := scanCmd.Bool("verbose", false, "Подробный вывод")
+	showProgress := scanCmd.Bool("progress", false, "Показывать ход сканирования: файлы, находки, время, текущую папку")
 	enableOCR := scanCmd.Bool("ocr", false, "Включить OCR для изображений (требуется Tesseract)")
-	scanDocs := scanCmd.Bool("docs", false, "Сканировать документы (PDF, DOCX, XLSX)")
+	ocrCache := scanCmd.Bool("ocr-cache", false, "Сохранять результаты OCR в пользовательском кэше и не распознавать неизменённые изображения повторно")
+	noImageMetadata := scanCmd.Bool("no-image-metadata", false, "Не проверять EXIF и XMP изображений (геоданные, автор, серийный номер)")
+	maxPDFPages := scanCmd.Int("max-pdf-pages", searcher.DefaultMaxPDFPages, "Сколько первых страниц PDF извлекать и распознавать (0 — все)")
+	scanDocs := scanCmd.Bool("docs", false, "Сканировать документы (PDF, DOCX, XLSX, PPTX, ODF, RTF, EML)")
 	scanArchives := scanCmd.Bool("archives", false, "Сканировать содержимое архивов (ZIP, TAR)")
+	respectGitignore := scanCmd.Bool("respect-gitignore", false, "Пропускать пути, исключённые файлами .gitignore")
+	followSymlinks := scanCmd.Bool("follow-symlinks", false, "Следовать по символьным ссылкам внутри -dir")
+	externalLinks := scanCmd.Bool("follow-external-symlinks", false, "С -follow-symlinks следовать и по ссылкам за пределы -dir")
+	spillAfter := scanCmd.Int("spill-after", 0, "Хранить находки на диске, когда их больше N (0 — все в памяти)")
+	contextLines := scanCmd.Int("context-lines", searcher.DefaultContextLines, "Сколько строк до и после совпадения сохранять с находкой (0 — только сама строка)")
+	maxContext := scanCmd.Int("max-context", searcher.DefaultMaxContextLength, "Максимальная длина контекста находки в символах (0 — без ограничения)")
+	incremental := scanCmd.Bool("incremental", false, "Не перечитывать файлы, не изменившиеся с прошлого сканирования")
+	cachePath := scanCmd.String("cache", "", "Файл кэша для -incremental (по умолчанию в пользовательском кэше)")
+	cacheHash := scanCmd.Bool("cache-hash", false, "С -incremental сравнивать и хеш содержимого файлов")
+	gitHistory := scanCmd.Bool("git-history", false, "Сканировать все версии файлов в истории git-репозитория -dir")
+	staged := scanCmd.Bool("staged", false, "Сканировать только проиндексированные изменения git-репозитория -dir (для pre-commit)")
+	lenient := scanCmd.Bool("lenient-validation", false, "Показывать совпадения, не прошедшие проверку (Luhn и др.), с низкой серьёзностью")
+	entropy := scanCmd.Bool("entropy", false, "Искать строки с высокой энтропией (случайные токены без известного ключа)")
+	entropyMin := scanCmd.Float64("entropy-threshold", searcher.DefaultBase64EntropyThreshold, "Порог энтропии для -entropy (base64-строки)")
+	decodeBase64 := scanCmd.Bool("decode-base64", false, "Декодировать base64-строки и искать секреты в расшифрованном тексте")
 	enableAI := scanCmd.Bool("ai", false, "Включить AI-анализ (требуется Ollama)")
 	aiModel := scanCmd.String("ai-model", "llama3.2", "Модель Ollama для AI-анализа")
+	explainTop := scanCmd.Int("explain-top", 0, "С -ai: добавить в отчёты объяснение AI к N самым рискованным критическим находкам")
+	aiTimeout := scanCmd.Duration("ai-timeout", searcher.DefaultOllamaTimeout, "Сколько может длиться один запрос к Ollama")
+	offline := scanCmd.Bool("offline", false, "Запретить любые сетевые запросы")
+	webhookURL := scanCmd.String("webhook-url", "", "Отправлять итоги и находки в JSON POST-запросом на этот адрес")
+	slackWebhook := scanCmd.String("slack-webhook", "", "Входящий вебхук Slack для итогов и находок")
+	notifyOn := scanCmd.String("notify-on", string(searcher.NotifySummary), "Что отправлять в вебхуки: summary, critical, all")
+	groups := scanCmd.String("groups", "", "Дополнительные группы детекторов через запятую (finance)")
+	packs := scanCmd.String("packs", "", "Пакеты правил через запятую: medical, hr или путь к файлу пакета")
+	weightsPath := scanCmd.String("weights", "", "Файл с разделом risk_weights для оценки риска")
+	gitStatus := scanCmd.Bool("git-status", false, "Учитывать в оценке риска, отслеживает ли git файл с находкой")
+	severityConfig := scanCmd.String("severity-config", "", "Файл YAML/JSON с серьёзностью по типам находок: {email: low}")
+	baselinePath := scanCmd.String("baseline", "", "Базовый файл, отчёт JSON или .dllreport: известные находки скрываются")
+	showBaselined := scanCmd.Bool("show-baselined", false, "Показывать находки из базового файла, помечая их как известные")
+	writeBaseline := scanCmd.String("write-baseline", "", "Сохранить базовый файл с находками этого запуска")
+	patternStats := scanCmd.Bool("pattern-stats", false, "Показать статистику по правилам и правила без находок")
+	dominantShare := scanCmd.Float64("dominant-share", searcher.DefaultDominantShare, "Доля находок одного правила, при которой выводится предупреждение")
+	noMetrics := scanCmd.Bool("no-metrics", false, "Не собирать метрики производительности для отчётов")
+	var pdfPasswords, patternFiles, includeGlobs, excludeGlobs, extensions, disabledPatterns, emailAllow []string
+	webhookHeaders := make(map[string]string)
+	addExtensions := func(value string) error {
+		extensions = append(extensions, strings.Split(value, ",")...)
+		return nil
+	}
+	scanCmd.Func("ext", "Сканировать только файлы с этим расширением, например .env (можно указать несколько раз)", addExtensions)
+	scanCmd.Func("only-ext", "То же, что -ext", addExtensions)
+	scanCmd.Func("include", "Сканировать только пути по шаблону, например src/** (можно указать несколько раз)", func(value string) error {
+		includeGlobs = append(includeGlobs, value)
+		return nil
+	})
+	scanCmd.Func("exclude", "Пропускать пути по шаблону, например **/testdata/** (можно указать несколько раз)", func(value string) error {
+		excludeGlobs = append(excludeGlobs, value)
+		return nil
+	})
+	scanCmd.Func("patterns", "Файл своих правил JSON/YAML (можно указать несколько раз)", func(value string) error {
+		patternFiles = append(patternFiles, value)
+		return nil
+	})
+	scanCmd.Func("disable-pattern", "Не искать находки этого типа, например email (можно указать несколько раз)", func(value string) error {
+		for _, name := range splitList(value) {
+			if _, err := searcher.ParsePatternType(name); err != nil {
+				return err
+			}
+			disabledPatterns = append(disabledPatterns, name)
+		}
+		return nil
+	})
+	scanCmd.Func("email-allow", "Домен своих email-адресов, находки с ним получают низкую серьёзность (можно указать несколько раз)", func(value string) error {
+		emailAllow = append(emailAllow, splitList(value)...)
+		return nil
+	})
+	scanCmd.Func("pdf-password", "Пароль для защищённых PDF (можно указать несколько раз)", func(value string) error {
+		pdfPasswords = append(pdfPasswords, value)
+		return nil
+	})
+	scanCmd.Func("webhook-header", "Заголовок запросов -webhook-url, «Имя: значение» (можно указать несколько раз)", func(value string) error {
+		name, headerValue, ok := strings.Cut(value, ":")
+		if name = strings.TrimSpace(name); !ok || name == "" {
+			return errors.New(ui.T("cli.webhook_header.invalid", value))
+		}
+		webhookHeaders[name] = strings.TrimSpace(headerValue)
+		return nil
+	})
 
 	scanCmd.Usage = func() {
-		fmt.Println("🔍 Сканирование на Чувствительные Данные")
-		fmt.Println("========================================")
-		fmt.Println()
-		fmt.Println("Сканирует директорию на наличие чувствительных данных:")
-		fmt.Println("паролей, API-ключей, токенов, банковских карт и т.д.")
-		fmt.Println()
-		fmt.Println("Использование:")
-		fmt.Println("  data-leak-locator scan -dir <директория> [опции]")
-		fmt.Println()
-		fmt.Println("Основные опции:")
-		fmt.Println("  -dir string")
-		fmt.Println("        Директория для сканирования (обязательно)")
-		fmt.Println("  -output string")
-		fmt.Println("        Директория для сохранения отчётов (по умолчанию: .)")
-		fmt.Println("  -max-size int")
-		fmt.Println("        Максимальный размер файла в байтах (по умолчанию: 100МБ)")
-		fmt.Println("  -verbose")
-		fmt.Println("        Подробный вывод")
-		fmt.Println()
-		fmt.Println("Расширенные опции:")
-		fmt.Println("  -ocr")
-		fmt.Println("        Включить OCR для извлечения текста из изображений (требуется Tesseract)")
-		fmt.Println("  -docs")
-		fmt.Println("        Сканировать документы: PDF, DOCX, DOC, XLSX, XLS")
-		fmt.Println("  -archives")
-		fmt.Println("        Сканировать содержимое архивов: ZIP, TAR, GZ")
-		fmt.Println()
-		fmt.Println("AI-анализ (локальный, без внешних запросов):")
-		fmt.Println("  -ai")
-		fmt.Println("        Включить AI-анализ с использованием Ollama")
-		fmt.Println("  -ai-model string")
-		fmt.Println("        Модель Ollama (по умолчанию: llama3.2)")
-		fmt.Println()
-		fmt.Println("Примеры:")
-		fmt.Println("  data-leak-locator scan -dir /путь/к/проекту")
-		fmt.Println("  data-leak-locator scan -dir ./src -docs -archives -verbose")
-		fmt.Println("  data-leak-locator scan -dir ./data -ocr -ai -ai-model mistral")
+		fmt.Print(ui.T("cli.scan.usage", searcher.DefaultMaxContextLength, searcher.DefaultContextLines, searcher.CustomPatternsFile))
 	}
 
 	if err := scanCmd.Parse(args); err != nil {
 		os.Exit(1)
 	}
 
-	if *scanDir == "" {
+	if *staged && *scanDir == "" && *s3Location == "" {
+		*scanDir = "."
+	}
+	if *scanDir == "" && *s3Location == "" {
 		scanCmd.Usage()
 		os.Exit(1)
 	}
+	if *scanDir != "" && *s3Location != "" {
+		fmt.Println(ui.T("cli.scan.dir_or_s3"))
+		os.Exit(1)
+	}
+	if *s3Location != "" && (*watch || *gitHistory || *incremental) {
+		fmt.Println(ui.T("cli.error.conflict", "-s3", "-watch, -git-history, -incremental"))
+		os.Exit(1)
+	}
+
+	if *dominantShare <= 0 || *dominantShare > 1 {
+		fmt.Println(ui.T("cli.scan.dominant_share", *dominantShare))
+		os.Exit(1)
+	}
+
+	formats, err := searcher.ParseReportFormats(*format)
+	if err != nil {
+		fmt.Println(ui.T("cli.error", err))
+		os.Exit(1)
+	}
+	csvColumnList, err := searcher.ParseCSVColumns(*csvColumns)
+	if err != nil {
+		fmt.Println(ui.T("cli.error.flag", "-csv-columns", err))
+		os.Exit(1)
+	}
 
-	runScan(*scanDir, *outputDir, *maxSize, *verbose, *enableOCR, *scanDocs, *scanArchives, *enableAI, *aiModel)
+	var failOnSeverity searcher.Severity
+	if *failOn != "" {
+		if failOnSeverity, err = searcher.ParseSeverity(*failOn); err != nil {
+			fmt.Println(ui.T("cli.error.flag", "-fail-on", err))
+			os.Exit(1)
+		}
+	}
+	var minSeverityLevel searcher.Severity
+	if *minSeverity != "" {
+		if minSeverityLevel, err = searcher.ParseSeverity(*minSeverity); err != nil {
+			fmt.Println(ui.T("cli.error.flag", "-min-severity", err))
+			os.Exit(1)
+		}
+	}
+	heuristicLevel, err := searcher.ParseHeuristicLevel(*heuristics)
+	if err != nil {
+		fmt.Println(ui.T("cli.error.flag", "-heuristics", err))
+		os.Exit(1)
+	}
+	if *maxPerFile < 0 {
+		fmt.Println(ui.T("cli.error.negative", "-max-per-file"))
+		os.Exit(1)
+	}
+	if *bulkThreshold < 0 {
+		fmt.Println(ui.T("cli.error.negative", "-bulk-threshold"))
+		os.Exit(1)
+	}
+	if *explainTop < 0 || (*explainTop > 0 && !*enableAI) {
+		fmt.Println(ui.T("cli.scan.explain_top"))
+		os.Exit(1)
+	}
+	if *watch && *gitHistory {
+		fmt.Println(ui.T("cli.error.conflict", "-watch", "-git-history"))
+		os.Exit(1)
+	}
+	if *staged && (*s3Location != "" || *watch || *gitHistory || *incremental) {
+		fmt.Println(ui.T("cli.error.conflict", "-staged", "-s3, -watch, -git-history, -incremental"))
+		os.Exit(1)
+	}
+	// Коммит останавливают находки от high и выше, если не указано другое
+	if *staged && failOnSeverity == "" {
+		failOnSeverity = searcher.High
+	}
+
+	if *offline && *s3Location != "" {
+		fmt.Println(ui.T("cli.error.conflict", "-s3", "-offline"))
+		os.Exit(1)
+	}
+	if *offline && *enableAI {
+		fmt.Println(ui.T("cli.scan.offline_ignored", "-ai"))
+		*enableAI = false
+	}
+	notifyMode, err := searcher.ParseNotifyMode(*notifyOn)
+	if err != nil {
+		fmt.Println(ui.T("cli.error.flag", "-notify-on", err))
+		os.Exit(1)
+	}
+	if *offline && (*webhookURL != "" || *slackWebhook != "") {
+		fmt.Println(ui.T("cli.scan.offline_ignored", "-webhook-url, -slack-webhook"))
+		*webhookURL, *slackWebhook = "", ""
+	}
+
+	runScan(scanOptions{
+		ScanDir:       *scanDir,
+		OutputDir:     *outputDir,
+		MaxSize:       *maxSize,
+		FileTimeout:   *fileTimeout,
+		Verbose:       *verbose,
+		Progress:      *showProgress,
+		EnableOCR:     *enableOCR,
+		OCRCache:      *ocrCache,
+		NoImageMeta:   *noImageMetadata,
+		MaxPDFPages:   *maxPDFPages,
+		ScanDocs:      *scanDocs,
+		ScanArchives:  *scanArchives,
+		Gitignore:     *respectGitignore,
+		Symlinks:      *followSymlinks,
+		ExternalLinks: *externalLinks,
+		SpillAfter:    *spillAfter,
+		MaxContext:    contextLength(*maxContext),
+		ContextLines:  *contextLines,
+		Incremental:   *incremental,
+		CachePath:     *cachePath,
+		CacheHash:     *cacheHash,
+		GitHistory:    *gitHistory,
+		Staged:        *staged,
+		Lenient:       *lenient,
+		Entropy:       *entropy,
+		EntropyMin:    *entropyMin,
+		DecodeBase64:  *decodeBase64,
+		EnableAI:      *enableAI,
+		AIModel:       *aiModel,
+		AITimeout:     *aiTimeout,
+		ExplainTop:    *explainTop,
+		Groups:        splitList(*groups),
+		Packs:         splitList(*packs),
+		DisabledTypes: disabledPatterns,
+		PDFPasswords:  pdfPasswords,
+		PatternFiles:  patternFiles,
+		Include:       includeGlobs,
+		Exclude:       excludeGlobs,
+		Extensions:    extensions,
+		WeightsPath:   *weightsPath,
+		GitStatus:     *gitStatus,
+		SeverityPath:  *severityConfig,
+		BaselinePath:  *baselinePath,
+		ShowBaselined: *showBaselined,
+		WriteBaseline: *writeBaseline,
+		PatternStats:  *patternStats,
+		DominantShare: *dominantShare,
+		NoMetrics:     *noMetrics,
+		Offline:       *offline,
+		Formats:       formats,
+		Secrets:       *includeSecrets,
+		CSVColumns:    csvColumnList,
+		CSVNoBOM:      *csvNoBOM,
+		FailOn:        failOnSeverity,
+		MinSeverity:   minSeverityLevel,
+		Heuristics:    heuristicLevel,
+		MaxPerFile:    *maxPerFile,
+		EmailAllow:    emailAllow,
+		BulkThreshold: *bulkThreshold,
+		Watch:         *watch,
+		WatchDebounce: *watchDebounce,
+		WatchLog:      *watchLog,
+
+		WebhookURL:     *webhookURL,
+		WebhookHeaders: webhookHeaders,
+		SlackWebhook:   *slackWebhook,
+		NotifyOn:       notifyMode,
+
+		S3:         *s3Location,
+		S3Endpoint: *s3Endpoint,
+		S3Region:   *s3Region,
+	})
 }
 
 // Устаревшая команда для обратной совместимости
@@ -388,200 +757,755 @@ func runScanCommandLegacy() {
 		os.Exit(1)
 	}
 
-	runScan(*scanDir, *outputDir, *maxSize, *verbose, false, false, false, false, "")
+	runScan(scanOptions{ScanDir: *scanDir, OutputDir: *outputDir, MaxSize: *maxSize, FileTimeout: searcher.DefaultFileTimeout, BulkThreshold: searcher.DefaultBulkThreshold, Verbose: *verbose})
+}
+
+// splitList splits a comma-separated flag value, dropping empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// scanOptions holds the settings of a CLI scan
+type scanOptions struct {
+	ScanDir       string
+	OutputDir     string
+	MaxSize       int64
+	FileTimeout   time.Duration // Longest scan of one file, see Scanner.SetFileTimeout
+	Verbose       bool
+	Progress      bool // Show the progress of the scan, see printScanProgress
+	EnableOCR     bool
+	OCRCache      bool // Keep recognised text in the OCR disk cache between runs
+	NoImageMeta   bool // Skip the EXIF and XMP of images, see Scanner.SetScanImageMetadata
+	MaxPDFPages   int  // Pages of a PDF read, 0 reads all
+	ScanDocs      bool
+	ScanArchives  bool
+	Gitignore     bool    // Skip paths excluded by .gitignore files
+	Symlinks      bool    // Follow symbolic links inside ScanDir
+	ExternalLinks bool    // With Symlinks, also follow links leading outside ScanDir
+	SpillAfter    int     // Keep findings on disk past this many; 0 keeps all in memory
+	MaxContext    int     // Longest context kept with a finding, see Scanner.SetMaxContextLength
+	ContextLines  int     // Lines kept before and after a match, see Scanner.SetContextLines
+	Incremental   bool    // Take unchanged files from the cache of the last scan
+	CachePath     string  // Cache file of Incremental; empty means searcher.DefaultCachePath
+	CacheHash     bool    // Also compare content hashes in incremental scans
+	GitHistory    bool    // Scan the blobs of every commit instead of the working tree
+	Staged        bool    // Scan the staged blobs only, see runStagedScan
+	Lenient       bool    // Report matches failing validation, such as Luhn, as Low
+	Entropy       bool    // Report high-entropy strings no pattern matched
+	EntropyMin    float64 // Entropy threshold of base64-like strings
+	DecodeBase64  bool    // Run the patterns over decoded base64 tokens too
+	EnableAI      bool
+	AIModel       string
+	AITimeout     time.Duration // Longest Ollama request, see LocalAnalyzer.SetTimeout
+	ExplainTop    int           // Explain this many riskiest critical findings in the reports
+	Groups        []string
+	Packs         []string
+	PatternFiles  []string // Rules files added to the built-in patterns
+	DisabledTypes []string // Pattern types not searched for, see Patterns.DisableTypes
+	Include       []string // Scan only paths matching these globs, see Scanner.SetIncludeGlobs
+	Exclude       []string // Skip paths matching these globs
+	Extensions    []string // Scan only files with these extensions, see Scanner.SetOnlyExtensions
+	PDFPasswords  []string
+	WeightsPath   string
+	GitStatus     bool     // Weigh whether git tracks the file of a finding, see Scanner.SetGitTracking
+	SeverityPath  string   // Severities per pattern type, see Scanner.LoadSeverityConfig
+	BaselinePath  string   // Baseline file or report to compare the findings with
+	ShowBaselined bool     // Keep known findings in the result instead of hiding them
+	WriteBaseline string   // Write a baseline file of this run's findings
+	PatternStats  bool     // Print per-rule statistics and time the regexes
+	DominantShare float64  // Warn when one rule produces more than this share; 0 means the default
+	NoMetrics     bool     // Do not collect performance metrics, see Scanner.SetMetrics
+	Offline       bool     // Forbid every network request
+	Formats       []string // Report formats, nil means searcher.DefaultReportFormats
+	Secrets       bool     // Write matched secrets into reports unmasked
+	CSVColumns    []string // Columns of the CSV report, nil for the default ones
+	CSVNoBOM      bool     // Do not start the CSV report with a BOM
+
+	// Exit with exitFindings on findings at or above FailOn; "" never fails
+	FailOn searcher.Severity
+
+	MinSeverity searcher.Severity // Drop findings below it, see Scanner.SetMinSeverity
+	MaxPerFile  int               // Stop matching in a file after this many findings, 0 for no limit
+
+	EmailAllow    []string // Domains of the company's own addresses, see Scanner.SetEmailDomainAllowlist
+	BulkThreshold int      // Distinct addresses or numbers a file may hold, 0 turns the check off
+
+	// Filtering of references and placeholders; "" keeps the scanner default
+	Heuristics searcher.HeuristicLevel
+
+	Watch         bool          // Keep watching ScanDir after the scan, see searcher.Scanner.Watch
+	WatchDebounce time.Duration // Quiet period before a changed file is re-scanned
+	WatchLog      string        // Findings log of Watch; empty means one in OutputDir
+
+	WebhookURL     string            // Post summaries and findings as JSON here, see searcher.GenericWebhook
+	WebhookHeaders map[string]string // Headers of the WebhookURL requests
+	SlackWebhook   string            // Slack incoming webhook, see searcher.SlackWebhook
+	NotifyOn       searcher.NotifyMode
+
+	S3         string // Scan bucket/prefix instead of ScanDir, see searcher.S3Source
+	S3Endpoint string // S3-compatible store; empty takes the environment or AWS
+	S3Region   string // Empty takes the environment
 }
 
-func runScan(scanDir, outputDir string, maxSize int64, verbose, enableOCR, scanDocs, scanArchives, enableAI bool, aiModel string) {
+func runScan(opts scanOptions) {
 	// Проверка существования директории
-	if _, err := os.Stat(scanDir); err != nil {
-		fmt.Printf("❌ Ошибка: Директория не существует: %s\n", scanDir)
-		os.Exit(1)
+	if opts.S3 == "" {
+		if _, err := os.Stat(opts.ScanDir); err != nil {
+			fmt.Println(ui.T("cli.scan.no_dir", opts.ScanDir))
+			os.Exit(exitCode(err))
+		}
 	}
 
-	// Проверка зависимостей
+	// Сетевой доступ: только то, что явно включено
+	network := searcher.Network()
+	network.SetOffline(opts.Offline)
+	network.Enable(searcher.NetworkAI, opts.EnableAI)
+	network.Enable(searcher.NetworkNotifications, opts.WebhookURL != "" || opts.SlackWebhook != "")
+	network.Enable(searcher.NetworkStorage, opts.S3 != "")
+	if opts.Verbose {
+		network.SetBlockedHandler(func(attempt searcher.NetworkAttempt) {
+			fmt.Println(ui.T("cli.network.blocked",
+				searcher.LocalizeNetworkCapability(ui, attempt.Capability), attempt.Method, attempt.URL))
+		})
+	}
+
+	// Проверка зависимостей: только нужных выбранным опциям, результаты
+	// кэшируются на всё время работы процесса
 	depChecker := searcher.NewDependencyChecker()
-	depChecker.CheckAll()
 
 	// Проверка необходимых зависимостей для выбранных опций
-	if enableOCR && !depChecker.IsTesseractAvailable() {
-		fmt.Println("⚠️  Tesseract OCR не установлен!")
-		missingDeps := depChecker.GetMissingDependencies()
-		if len(missingDeps) > 0 {
-			fmt.Printf("   📝 Установите: %s\n", missingDeps[0].InstallHint)
-		}
-		fmt.Println("   OCR изображений будет недоступен.")
+	if opts.EnableOCR && !depChecker.IsTesseractAvailable() {
+		fmt.Println(ui.T("cli.dependency.missing", "Tesseract OCR"))
+		fmt.Println(ui.T("cli.install_hint", depChecker.Status(searcher.DependencyTesseract).InstallHint))
+		fmt.Println(ui.T("cli.dependency.no_ocr"))
 		fmt.Println()
 	}
 
-	if scanDocs && !depChecker.IsPopplerAvailable() {
-		fmt.Println("⚠️  Poppler не установлен!")
-		fmt.Println("   Сканированные PDF будут недоступны для OCR.")
-		for _, dep := range depChecker.GetMissingDependencies() {
-			if dep.Name == "Poppler (PDF utils)" {
-				fmt.Printf("   📝 Установите: %s\n", dep.InstallHint)
-				break
-			}
-		}
+	if opts.ScanDocs && !depChecker.IsPopplerAvailable() {
+		fmt.Println(ui.T("cli.dependency.missing", "Poppler"))
+		fmt.Println(ui.T("cli.dependency.no_pdf_ocr"))
+		fmt.Println(ui.T("cli.install_hint", depChecker.Status(searcher.DependencyPoppler).InstallHint))
 		fmt.Println()
 	}
 
-	if enableAI && !depChecker.IsOllamaAvailable() {
-		fmt.Println("⚠️  Ollama не установлен или не запущен!")
-		for _, dep := range depChecker.GetMissingDependencies() {
-			if dep.Name == "Ollama (AI)" {
-				fmt.Printf("   📝 Установите: %s\n", dep.InstallHint)
-				break
-			}
-		}
-		fmt.Println("   AI-анализ будет использовать правило-ориентированный режим.")
+	if opts.EnableAI && !depChecker.IsOllamaAvailable() {
+		fmt.Println(ui.T("cli.dependency.no_ollama"))
+		fmt.Println(ui.T("cli.install_hint", depChecker.Status(searcher.DependencyOllama).InstallHint))
+		fmt.Println(ui.T("cli.dependency.rules_fallback"))
 		fmt.Println()
 	}
 
+	// Устаревшие версии используются, но работают хуже или не полностью
+	needed := []struct {
+		enabled bool
+		name    string
+	}{
+		{opts.EnableOCR, searcher.DependencyTesseract},
+		{opts.ScanDocs, searcher.DependencyPoppler},
+		{opts.EnableAI, searcher.DependencyOllama},
+		{opts.GitHistory || opts.Staged, searcher.DependencyGit},
+	}
+	for _, dep := range needed {
+		if !dep.enabled {
+			continue
+		}
+		if status := depChecker.Status(dep.name); status.Outdated {
+			fmt.Printf("⚠️  %s\n", searcher.LocalizeVersionWarning(ui, status))
+			fmt.Println(ui.T("cli.dependency.update", status.InstallHint))
+			fmt.Println()
+		}
+	}
+
+	var source searcher.FileSource
+	if opts.S3 != "" {
+		s3, err := newS3Source(opts)
+		if err != nil {
+			fmt.Println(ui.T("cli.error.flag", "-s3", err))
+			os.Exit(1)
+		}
+		source = s3
+		opts.ScanDir = s3.Root()
+	}
+
 	// Создание сканера
 	scanner := searcher.NewScanner()
-	scanner.SetMaxFileSize(maxSize)
+	scanner.SetMaxFileSize(opts.MaxSize)
+	scanner.SetFileTimeout(opts.FileTimeout)
+	scanner.SetMetrics(!opts.NoMetrics)
+	scanner.SetMinSeverity(opts.MinSeverity)
+	scanner.SetMaxFindingsPerFile(opts.MaxPerFile)
+	scanner.SetEmailDomainAllowlist(opts.EmailAllow)
+	scanner.SetBulkThreshold(opts.BulkThreshold)
+	if opts.Heuristics != "" {
+		scanner.SetHeuristicFiltering(opts.Heuristics)
+	}
+	if notifier := newNotifier(opts); notifier != nil {
+		scanner.SetNotifier(notifier, opts.NotifyOn)
+	}
+	scanner.SetRespectGitignore(opts.Gitignore)
+	scanner.SetFollowSymlinks(opts.Symlinks)
+	scanner.SetFollowExternalSymlinks(opts.ExternalLinks)
+	if err := scanner.SetIncludeGlobs(opts.Include); err != nil {
+		fmt.Println(ui.T("cli.error", err))
+		os.Exit(1)
+	}
+	if err := scanner.SetExcludeGlobs(opts.Exclude); err != nil {
+		fmt.Println(ui.T("cli.error", err))
+		os.Exit(1)
+	}
+	scanner.SetOnlyExtensions(opts.Extensions)
+	scanner.SetMaxContextLength(opts.MaxContext)
+	scanner.SetContextLines(opts.ContextLines)
+	scanner.SetIncremental(opts.Incremental)
+	scanner.SetCache(opts.CachePath)
+	scanner.SetCacheHash(opts.CacheHash)
+	scanner.SetRawFindings(opts.Secrets)
+	if opts.SpillAfter > 0 {
+		scanner.SetSpill(searcher.SpillConfig{Threshold: opts.SpillAfter})
+	}
+	scanner.GetPatterns().SetStrictValidation(!opts.Lenient)
+	scanner.SetDetectHighEntropy(opts.Entropy)
+	if opts.Entropy && opts.EntropyMin > 0 {
+		scanner.GetHighEntropyDetector().Base64Threshold = opts.EntropyMin
+	}
+	scanner.SetDecodeBase64(opts.DecodeBase64)
+	scanner.SetScanImageMetadata(!opts.NoImageMeta)
+	if opts.PatternStats {
+		scanner.GetPatterns().SetProfiling(true)
+	}
+	if err := scanner.GetPatterns().EnableGroups(opts.Groups); err != nil {
+		fmt.Println(ui.T("cli.error", err))
+		os.Exit(1)
+	}
+	if opts.Verbose && len(opts.Groups) > 0 {
+		fmt.Println(ui.T("cli.verbose.groups", strings.Join(opts.Groups, ", ")))
+	}
+	if err := scanner.GetPatterns().DisableTypes(opts.DisabledTypes); err != nil {
+		fmt.Println(ui.T("cli.error", err))
+		os.Exit(1)
+	}
+	if opts.Verbose && len(opts.DisabledTypes) > 0 {
+		fmt.Println(ui.T("cli.disabled_types", strings.Join(opts.DisabledTypes, ", ")))
+	}
+	if err := scanner.GetPatterns().EnablePacks(opts.Packs); err != nil {
+		fmt.Println(ui.T("cli.error", err))
+		os.Exit(1)
+	}
+	if opts.Verbose {
+		for _, pack := range scanner.GetPatterns().Packs() {
+			fmt.Println(ui.T("cli.verbose.pack", pack.Title, len(pack.Patterns)))
+		}
+	}
+	for _, path := range opts.PatternFiles {
+		if err := scanner.LoadCustomPatterns(path); err != nil {
+			fmt.Println(ui.T("cli.error.load_rules", err))
+			os.Exit(exitCode(err))
+		}
+		if opts.Verbose {
+			fmt.Println(ui.T("cli.verbose.rules", path))
+		}
+	}
+	if domains := scanner.EmailDomainAllowlist(); opts.Verbose && len(domains) > 0 {
+		fmt.Println(ui.T("cli.email_allow", strings.Join(domains, ", ")))
+	}
+	if opts.WeightsPath != "" {
+		weights, err := searcher.LoadRiskWeights(opts.WeightsPath)
+		if err != nil {
+			fmt.Println(ui.T("cli.error", err))
+			os.Exit(1)
+		}
+		scanner.SetRiskWeights(weights)
+		if opts.Verbose {
+			fmt.Println(ui.T("cli.verbose.weights", opts.WeightsPath))
+		}
+	}
+	scanner.SetGitTracking(opts.GitStatus)
+	if opts.SeverityPath != "" {
+		warnings, err := scanner.LoadSeverityConfig(opts.SeverityPath)
+		if err != nil {
+			fmt.Println(ui.T("cli.error", err))
+			os.Exit(1)
+		}
+		for _, warning := range warnings {
+			fmt.Printf("⚠️  %s\n", warning)
+		}
+		if opts.Verbose {
+			fmt.Println(ui.T("cli.verbose.severities", scanner.SeverityOverrides()))
+		}
+	}
+
+	var baseline *searcher.BaselineIndex
+	if opts.BaselinePath != "" {
+		index, err := searcher.LoadBaselineIndex(opts.BaselinePath)
+		if err != nil {
+			fmt.Println(ui.T("cli.error.load_baseline", err))
+			os.Exit(1)
+		}
+		baseline = index
+		if opts.Verbose {
+			fmt.Println(ui.T("cli.verbose.baseline", opts.BaselinePath, baseline.Len()))
+		}
+	}
 
 	// Настройка документ-экстрактора
-	if scanDocs || scanArchives || enableOCR {
-		extractor := searcher.NewDocumentExtractor(enableOCR)
+	if opts.ScanDocs || opts.ScanArchives || opts.EnableOCR {
+		extractor := searcher.NewDocumentExtractor(opts.EnableOCR)
+		extractor.SetPDFPasswords(opts.PDFPasswords)
+		extractor.SetCacheEnabled(opts.OCRCache)
+		extractor.SetMaxPDFPages(opts.MaxPDFPages)
 		scanner.SetDocumentExtractor(extractor)
-		scanner.SetScanDocuments(scanDocs)
-		scanner.SetScanArchives(scanArchives)
+		scanner.SetScanDocuments(opts.ScanDocs)
+		scanner.SetScanArchives(opts.ScanArchives)
 
 		// Разрешить сканирование документов/изображений/архивов в ignore-листе
 		ignoreList := scanner.GetIgnoreList()
-		if scanDocs {
+		if opts.ScanDocs {
 			ignoreList.EnableDocumentScanning()
 		}
-		if enableOCR {
+		if opts.EnableOCR {
 			ignoreList.EnableImageScanning()
 		}
-		if scanArchives {
+		if opts.ScanArchives {
 			ignoreList.EnableArchiveScanning()
 		}
 
-		if verbose {
-			fmt.Println("📄 Расширенное сканирование включено:")
-			if scanDocs {
-				fmt.Println("   • Документы (PDF, DOCX, XLSX)")
+		if opts.Verbose {
+			fmt.Println(ui.T("cli.verbose.extended"))
+			if opts.ScanDocs {
+				fmt.Println(ui.T("cli.verbose.documents"))
 			}
-			if scanArchives {
-				fmt.Println("   • Архивы (ZIP, TAR, GZ)")
+			if opts.ScanArchives {
+				fmt.Println(ui.T("cli.verbose.archives"))
 			}
-			if enableOCR {
-				fmt.Println("   • OCR для изображений")
+			if opts.EnableOCR {
+				fmt.Println(ui.T("cli.verbose.ocr"))
 				// Проверяем Tesseract
+				status := depChecker.Status(searcher.DependencyTesseract)
 				if extractor.IsTesseractAvailable() {
-					fmt.Println("   ✅ Tesseract найден")
+					if status.Version != "" {
+						fmt.Println(ui.T("cli.verbose.tesseract_version", status.Version))
+					} else {
+						fmt.Println(ui.T("cli.verbose.tesseract"))
+					}
 				} else {
-					fmt.Println("   ⚠️  Tesseract НЕ установлен! OCR не будет работать.")
-					fmt.Println("   📝 Установите: brew install tesseract (macOS)")
+					fmt.Println(ui.T("cli.verbose.no_tesseract"))
+					fmt.Println(ui.T("cli.install_hint", status.InstallHint))
 				}
 			}
 		}
 	}
 
-	if verbose {
-		fmt.Printf("🔍 Начинаю сканирование: %s\n", scanDir)
+	if opts.Verbose {
+		if opts.GitHistory {
+			fmt.Println(ui.T("cli.history_start", opts.ScanDir))
+		} else {
+			fmt.Println(ui.T("cli.scan_start", opts.ScanDir))
+		}
+	}
+
+	if opts.Watch {
+		runWatch(scanner, opts, baseline)
+		return
+	}
+	if opts.Staged {
+		runStagedScan(scanner, opts, baseline)
+		return
 	}
 
-	// Выполнение сканирования
-	result, err := scanner.Scan(scanDir)
+	// Выполнение сканирования; Ctrl+C останавливает его и выводит то, что
+	// успели найти. Повторный Ctrl+C завершает программу сразу
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	scan := scanner.ScanContext
+	if opts.GitHistory {
+		scan = scanner.ScanGitHistoryContext
+	}
+	if source != nil {
+		scan = func(ctx context.Context, _ string) (*searcher.ScanResult, error) {
+			return scanner.ScanSource(ctx, source)
+		}
+	}
+	stopProgress := func() {}
+	if opts.Verbose || opts.Progress {
+		// Totals for the ETA come from counting the files of a directory
+		scanner.SetEnumerate(!opts.GitHistory && source == nil)
+		stopProgress = printScanProgress(scanner, opts.ScanDir)
+	}
+	result, err := scan(ctx, opts.ScanDir)
+	stopProgress()
+	stop()
+	if errors.Is(err, searcher.ErrCancelled) {
+		fmt.Println(ui.T("cli.cancelled"))
+		printSummary(result)
+		result.Close()
+		os.Exit(exitCode(err))
+	}
 	if err != nil {
-		fmt.Printf("❌ Ошибка сканирования: %v\n", err)
-		os.Exit(1)
+		fmt.Println(ui.T("cli.error.scan", err))
+		os.Exit(exitCode(err))
+	}
+
+	if opts.Verbose && result.Spilled() {
+		fmt.Println(ui.T("cli.verbose.spilled", opts.SpillAfter))
+	}
+
+	// AI-анализ проверяется отдельно от сканера, отметить его в покрытии
+	result.Coverage.SetAI(opts.EnableAI, searcher.Dependencies())
+	result.Coverage.SetNetwork(network)
+
+	// Пометить находки как новые или известные по базовому отчёту;
+	// известные убираются из итогов, если не указан -show-baselined
+	if baseline != nil {
+		baseline.TagResult(result)
+		if !opts.ShowBaselined {
+			if hidden := result.SuppressBaselined(); hidden > 0 {
+				fmt.Println(ui.T("cli.baseline.hidden", hidden))
+			}
+		}
+	}
+	if opts.WriteBaseline != "" {
+		if err := searcher.WriteBaseline(opts.WriteBaseline, result); err != nil {
+			fmt.Println(ui.T("cli.baseline.write_error", err))
+			result.Close()
+			os.Exit(exitCode(err))
+		}
+		fmt.Println(ui.T("cli.baseline.written", opts.WriteBaseline))
 	}
 
 	// Вывод сводки
 	printSummary(result)
+	if opts.Verbose {
+		printExtensionSkips(result)
+		printHeuristicNotes(result)
+		printMetrics(result)
+	}
+	printPatternStats(result, scanner.GetPatterns(), opts)
 
 	// AI-анализ
-	if enableAI {
-		fmt.Println("\n🤖 Выполняю AI-анализ...")
+	if opts.EnableAI {
+		fmt.Println("\n" + ui.T("cli.ai.start"))
 		analyzer := searcher.NewLocalAnalyzer()
 		analyzer.EnableAI(true)
-		if aiModel != "" {
-			analyzer.SetModel(aiModel)
+		if opts.AIModel != "" {
+			analyzer.SetModel(opts.AIModel)
 		}
 
 		if !analyzer.IsOllamaAvailable() {
-			fmt.Println("⚠️  Ollama недоступен. Используется правило-ориентированный анализ.")
+			fmt.Println(ui.T("cli.ai.unavailable"))
 			analyzer.EnableAI(false)
-		} else if verbose {
+		} else if opts.Verbose {
 			models, _ := analyzer.GetAvailableModels()
-			fmt.Printf("   Доступные модели: %v\n", models)
-			fmt.Printf("   Используется: %s\n", aiModel)
+			fmt.Println(ui.T("cli.ai.models", models))
+			fmt.Println(ui.T("cli.ai.model", opts.AIModel))
 		}
 
-		analysis, err := analyzer.Analyze(result)
-		if err != nil {
-			fmt.Printf("⚠️  Ошибка анализа: %v\n", err)
-		} else {
-			fmt.Println(analyzer.FormatAnalysisReport(analysis))
-
-			// Сохранить анализ в файл
-			analysisPath := outputDir + "/анализ-безопасности_" +
-				strings.ReplaceAll(result.GeneratedAt().Format("20060102_150405"), " ", "_") + ".txt"
-			os.WriteFile(analysisPath, []byte(analyzer.FormatAnalysisReport(analysis)), 0644)
-			if verbose {
-				fmt.Printf("📊 Отчёт анализа сохранён: %s\n", analysisPath)
+		analyzer.SetTimeout(opts.AITimeout)
+		if opts.Verbose {
+			analyzer.SetOnProgress(func(p searcher.AIProgress) {
+				if p.FilePath == "" {
+					fmt.Print("\r" + ui.T("cli.ai.progress", len([]rune(p.Text))))
+				}
+			})
+		}
+
+		// Ctrl+C прерывает только AI-анализ, отчёты всё равно сохраняются
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		analysis, err := analyzer.AnalyzeContext(ctx, result)
+		if opts.Verbose && analysis.AIInsights != "" {
+			fmt.Println()
+		}
+		switch {
+		case errors.Is(err, context.Canceled):
+			fmt.Println(ui.T("cli.ai.cancelled"))
+		case err != nil:
+			fmt.Println(ui.T("cli.ai.failed", err))
+		case opts.ExplainTop > 0 && analysis.UsedOllama:
+			explained, err := analyzer.ExplainTopFindings(ctx, result, opts.ExplainTop)
+			if err != nil {
+				fmt.Println(ui.T("cli.ai.explain_error", err))
+			}
+			if explained > 0 {
+				fmt.Println(ui.T("cli.ai.explained", explained))
 			}
 		}
+		stop()
+		fmt.Println(analyzer.FormatAnalysisReport(analysis))
+
+		// Сохранить анализ в файл
+		analysisPath := opts.OutputDir + "/" + ui.T("cli.ai.file_name") + "_" +
+			strings.ReplaceAll(result.GeneratedAt().Format("20060102_150405"), " ", "_") + ".txt"
+		os.WriteFile(analysisPath, []byte(analyzer.FormatAnalysisReport(analysis)), 0644)
+		if opts.Verbose {
+			fmt.Println(ui.T("cli.ai.saved", analysisPath))
+		}
 	}
 
 	// Генерация отчётов
-	if err := generateReports(result, outputDir); err != nil {
-		fmt.Printf("❌ Ошибка генерации отчётов: %v\n", err)
+	err = generateReports(result, opts.OutputDir, opts.Formats, opts.reportOptions())
+	// Находки, сброшенные на диск, нужны только для отчётов
+	result.Close()
+	if err != nil {
+		fmt.Println(ui.T("cli.error.reports", err))
 		os.Exit(1)
 	}
 
-	if verbose {
-		fmt.Printf("\n📁 Отчёты сохранены в: %s\n", outputDir)
+	if opts.Verbose {
+		fmt.Println(ui.T("cli.reports_saved", opts.OutputDir))
+	}
+
+	if count := countAtOrAbove(result, opts.FailOn); count > 0 {
+		fmt.Println(ui.T("cli.fail_on", ui.SeverityShort(string(opts.FailOn)), count))
+		os.Exit(exitFindings)
+	}
+}
+
+// newNotifier returns the notifiers of the webhook options, nil without any
+func newNotifier(opts scanOptions) searcher.Notifier {
+	var notifiers searcher.Notifiers
+	if opts.WebhookURL != "" {
+		webhook := searcher.NewGenericWebhook(opts.WebhookURL)
+		for name, value := range opts.WebhookHeaders {
+			webhook.SetHeader(name, value)
+		}
+		notifiers = append(notifiers, webhook)
+	}
+	if opts.SlackWebhook != "" {
+		notifiers = append(notifiers, searcher.NewSlackWebhook(opts.SlackWebhook))
+	}
+	if len(notifiers) == 0 {
+		return nil
+	}
+	return notifiers
+}
+
+// newS3Source creates the source of -s3 from the environment and the flags
+func newS3Source(opts scanOptions) (*searcher.S3Source, error) {
+	config, err := searcher.S3ConfigFromEnv(opts.S3)
+	if err != nil {
+		return nil, err
+	}
+	if opts.S3Endpoint != "" {
+		config.Endpoint = opts.S3Endpoint
+	}
+	if opts.S3Region != "" {
+		config.Region = opts.S3Region
+	}
+	return searcher.NewS3Source(config)
+}
+
+// runWatch scans opts.ScanDir and keeps watching it until Ctrl+C, printing
+// every finding as it comes; the reports are written when watching stops
+func runWatch(scanner *searcher.Scanner, opts scanOptions, baseline *searcher.BaselineIndex) {
+	if err := os.MkdirAll(opts.OutputDir, 0755); err != nil {
+		fmt.Println(ui.T("cli.error.output_dir", err))
+		os.Exit(1)
+	}
+	logPath := opts.WatchLog
+	if logPath == "" {
+		logPath = filepath.Join(opts.OutputDir, ui.T("cli.watch.log_name"))
+	}
+	scanner.SetWatchDebounce(opts.WatchDebounce)
+	scanner.SetFindingsLog(logPath)
+
+	fmt.Println(ui.T("cli.watch.start", opts.ScanDir, logPath))
+
+	var printMu sync.Mutex
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	result, err := scanner.Watch(ctx, opts.ScanDir, func(f *searcher.Finding) {
+		if baseline != nil && baseline.Tag(f) == searcher.BaselineKnown && !opts.ShowBaselined {
+			return
+		}
+		printMu.Lock()
+		defer printMu.Unlock()
+		fmt.Printf("🚨 %s  %s:%d  %s (%s)\n", time.Now().Format("15:04:05"), f.FilePath, f.LineNumber,
+			searcher.LocalizeDescription(ui, f.Description), ui.SeverityShort(string(f.Severity)))
+	})
+	stop()
+	if errors.Is(err, searcher.ErrCancelled) {
+		fmt.Println(ui.T("cli.cancelled"))
+		printSummary(result)
+		result.Close()
+		os.Exit(exitCode(err))
+	}
+	if err != nil {
+		fmt.Println(ui.T("cli.watch.error", err))
+		os.Exit(exitCode(err))
+	}
+
+	fmt.Println(ui.T("cli.watch.stopped"))
+	if baseline != nil {
+		baseline.TagResult(result)
+		if !opts.ShowBaselined {
+			result.SuppressBaselined()
+		}
+	}
+	printSummary(result)
+	err = generateReports(result, opts.OutputDir, opts.Formats, opts.reportOptions())
+	result.Close()
+	if err != nil {
+		fmt.Println(ui.T("cli.error.reports", err))
+		os.Exit(1)
+	}
+}
+
+// contextLength turns the -max-context value into a Scanner setting, where
+// zero means the default and a negative length keeps whole lines
+func contextLength(flagValue int) int {
+	if flagValue <= 0 {
+		return -1
+	}
+	return flagValue
+}
+
+// countAtOrAbove counts the findings of the given severity or higher;
+// an empty severity counts nothing
+func countAtOrAbove(result *searcher.ScanResult, threshold searcher.Severity) int {
+	if threshold == "" {
+		return 0
+	}
+	count := 0
+	for severity, n := range result.SeveritySummary {
+		if severity.Score() >= threshold.Score() {
+			count += n
+		}
+	}
+	return count
+}
+
+// printMetrics выводит, на что ушло время сканирования (с -verbose)
+func printMetrics(result *searcher.ScanResult) {
+	if result.Metrics == nil {
+		return
+	}
+	fmt.Println(ui.T("cli.metrics.title"))
+	fmt.Print(searcher.FormatScanMetrics(ui, result.Metrics))
+}
+
+// printPatternStats выводит статистику по правилам (с -pattern-stats)
+// и предупреждает о правиле, которое дало большую часть находок
+func printPatternStats(result *searcher.ScanResult, patterns *searcher.Patterns, opts scanOptions) {
+	stats := result.PatternStats()
+
+	if opts.PatternStats {
+		fmt.Println(ui.T("cli.stats.title"))
+		if table := searcher.FormatPatternStats(ui, stats); table != "" {
+			fmt.Print(table)
+		} else {
+			fmt.Println(ui.T("cli.stats.none"))
+		}
+		if unmatched := searcher.UnmatchedRules(stats, patterns); len(unmatched) > 0 {
+			fmt.Println(ui.T("cli.stats.unmatched", len(unmatched)))
+			for _, rule := range unmatched {
+				fmt.Printf("   %s\n", rule)
+			}
+		}
+	}
+
+	share := opts.DominantShare
+	if share <= 0 {
+		share = searcher.DefaultDominantShare
+	}
+	for _, stat := range searcher.DominantPatterns(stats, share) {
+		fmt.Println(ui.T("cli.stats.dominant", stat.Rule, stat.Share*100, stat.Findings))
+		fmt.Println(ui.T("cli.stats.dominant_hint"))
 	}
 }
 
 // printSummary выводит сводку результатов сканирования
 func printSummary(result *searcher.ScanResult) {
-	fmt.Println("\n========== РЕЗУЛЬТАТЫ СКАНИРОВАНИЯ ==========")
-	fmt.Printf("Просканировано файлов: %d\n", result.FilesScanned)
-	fmt.Printf("Пропущено файлов:      %d\n", result.FilesSkipped)
-	fmt.Printf("Всего находок:         %d\n", result.TotalFindings())
-	fmt.Printf("Ошибок:                %d\n", result.ErrorCount)
-	fmt.Println("\nПо уровням серьёзности:")
-	fmt.Printf("  🔴 Критический: %d\n", result.GetSeverityCount(searcher.Critical))
-	fmt.Printf("  🟠 Высокий:     %d\n", result.GetSeverityCount(searcher.High))
-	fmt.Printf("  🟡 Средний:     %d\n", result.GetSeverityCount(searcher.Medium))
-	fmt.Printf("  🟢 Низкий:      %d\n", result.GetSeverityCount(searcher.Low))
+	fmt.Println(ui.T("cli.summary.title"))
+	fmt.Println(searcher.FormatScanSummary(ui, result, result.Duration()))
+	fmt.Println()
+	fmt.Println(ui.T("cli.summary.scanned", result.FilesScanned))
+	fmt.Println(ui.T("cli.summary.skipped", result.FilesSkipped))
+	fmt.Println(ui.T("cli.summary.total", result.TotalFindings()))
+	fmt.Println(ui.T("cli.summary.errors", result.ErrorCount))
+	if result.SuppressedCount > 0 {
+		fmt.Println(ui.T("cli.summary.suppressed", result.SuppressedCount, searcher.SuppressMarker))
+	}
+	if result.FilteredFindings > 0 {
+		fmt.Println(ui.T("cli.summary.filtered", result.FilteredFindings))
+	}
+	if result.HeuristicDropped > 0 {
+		fmt.Println(ui.T("cli.summary.heuristic_dropped", result.HeuristicDropped))
+	}
+	if result.HeuristicDowngraded > 0 {
+		fmt.Println(ui.T("cli.summary.heuristic_downgraded", result.HeuristicDowngraded))
+	}
+	if len(result.Truncated) > 0 {
+		fmt.Println(ui.T("cli.summary.truncated", len(result.Truncated)))
+	}
+	if result.NestedArchivesScanned > 0 {
+		fmt.Println(ui.T("cli.summary.nested", result.NestedArchivesScanned))
+	}
+	if result.CacheHits > 0 {
+		fmt.Println(ui.T("cli.summary.cache", result.CacheHits))
+	}
+	fmt.Println(ui.T("cli.summary.by_severity"))
+	fmt.Println(ui.T("cli.summary.critical", result.GetSeverityCount(searcher.Critical)))
+	fmt.Println(ui.T("cli.summary.high", result.GetSeverityCount(searcher.High)))
+	fmt.Println(ui.T("cli.summary.medium", result.GetSeverityCount(searcher.Medium)))
+	fmt.Println(ui.T("cli.summary.low", result.GetSeverityCount(searcher.Low)))
 
 	// Показать причины пропуска файлов (если есть)
 	if len(result.SkipReasons) > 0 {
-		fmt.Println("\n⚠️  Пропущенные файлы:")
+		fmt.Println(ui.T("cli.summary.skipped_files"))
 		count := 0
 		for file, reason := range result.SkipReasons {
 			if count >= 10 {
-				fmt.Printf("   ... и ещё %d файлов\n", len(result.SkipReasons)-10)
+				fmt.Println(ui.T("cli.summary.more_files", len(result.SkipReasons)-10))
 				break
 			}
-			fmt.Printf("   • %s: %s\n", file, reason)
+			fmt.Printf("   • %s: %s\n", file, searcher.LocalizeSkipReason(ui, reason))
 			count++
 		}
 	}
 
+	// Файлы, которые не удалось просканировать
+	if len(result.Errors) > 0 {
+		fmt.Println(ui.T("cli.summary.scan_errors"))
+		for i, scanErr := range result.Errors {
+			if i >= 10 {
+				fmt.Println(ui.T("cli.summary.more_errors", result.ErrorCount-10))
+				break
+			}
+			fmt.Printf("   • %s [%s]: %s\n", scanErr.FilePath, searcher.LocalizeErrorStage(ui, scanErr.Stage), scanErr.Err)
+		}
+	}
+
+	// Возможности, которые были запрошены, но недоступны
+	if warnings := searcher.LocalizeCoverageWarnings(ui, result.Coverage); len(warnings) > 0 {
+		fmt.Println(ui.T("cli.summary.coverage"))
+		for _, warning := range warnings {
+			fmt.Printf("   • %s\n", warning)
+		}
+	}
+
 	if result.TotalFindings() > 0 {
-		fmt.Println("\nТоп находок (по уровню риска):")
+		fmt.Println(ui.T("cli.summary.top"))
 		// Показать топ-10 находок
 		shown := 0
 		for _, finding := range result.Findings {
 			if shown >= 10 {
 				break
 			}
-			severityRu := severityToRussian(finding.Severity)
-			fmt.Printf("  [%s] %s:%d - %s (Риск: %.1f)\n",
-				severityRu,
+			fmt.Println(ui.T("cli.summary.finding",
+				ui.SeverityShort(string(finding.Severity)),
 				finding.FilePath,
 				finding.LineNumber,
-				descriptionToRussian(finding.Description),
-				finding.RiskScore)
+				searcher.LocalizeDescription(ui, finding.Description),
+				finding.RiskScore))
+			if finding.Commit != "" {
+				fmt.Println(ui.T("cli.summary.commit", finding.Commit, finding.Author, finding.CommitDate))
+			}
+			if page := finding.LocalizedPageLabel(ui); page != "" {
+				fmt.Printf("      %s\n", page)
+			}
 			shown++
 		}
 	}
@@ -589,63 +1513,89 @@ func printSummary(result *searcher.ScanResult) {
 	fmt.Println("\n==============================================")
 }
 
-func severityToRussian(s searcher.Severity) string {
-	switch s {
-	case searcher.Critical:
-		return "Крит."
-	case searcher.High:
-		return "Высок."
-	case searcher.Medium:
-		return "Сред."
-	case searcher.Low:
-		return "Низк."
-	default:
-		return string(s)
+// printExtensionSkips shows how many files of each extension -ext left
+// out, the most common first
+func printExtensionSkips(result *searcher.ScanResult) {
+	if len(result.ExtensionSkips) == 0 {
+		return
+	}
+	extensions := make([]string, 0, len(result.ExtensionSkips))
+	for ext := range result.ExtensionSkips {
+		extensions = append(extensions, ext)
+	}
+	sort.Slice(extensions, func(i, j int) bool {
+		a, b := result.ExtensionSkips[extensions[i]], result.ExtensionSkips[extensions[j]]
+		if a != b {
+			return a > b
+		}
+		return extensions[i] < extensions[j]
+	})
+	fmt.Println(ui.T("cli.ext_skips"))
+	for _, ext := range extensions {
+		name := ext
+		if name == "" {
+			name = ui.T("table.no_extension")
+		}
+		fmt.Printf("   %-20s %d\n", name, result.ExtensionSkips[ext])
 	}
 }
 
-func descriptionToRussian(desc string) string {
-	translations := map[string]string{
-		"Password assignment detected":             "Обнаружено присвоение пароля",
-		"API Key detected":                         "Обнаружен API-ключ",
-		"Authentication token detected":            "Обнаружен токен аутентификации",
-		"Private key detected":                     "Обнаружен приватный ключ",
-		"AWS Access Key detected":                  "Обнаружен AWS ключ доступа",
-		"GitHub token detected":                    "Обнаружен GitHub токен",
-		"Email address detected":                   "Обнаружен email адрес",
-		"Phone number detected":                    "Обнаружен номер телефона",
-		"Social Security Number detected":          "Обнаружен SSN",
-		"Credit card number detected":              "Обнаружен номер банк. карты",
-		"JSON secret detected":                     "Обнаружен секрет в JSON",
-		"YAML secret detected":                     "Обнаружен секрет в YAML",
-		"Environment variable assignment detected": "Обнаружена переменная окружения",
-		"Connection string detected":               "Обнаружена строка подключения",
-		"Hardcoded secret detected":                "Обнаружен захардкоженный секрет",
-		"IBAN detected":                            "Обнаружен IBAN",
-		"BIC code detected":                        "Обнаружен BIC код",
-		"Passport number detected":                 "Обнаружен номер паспорта",
-	}
-	if ru, ok := translations[desc]; ok {
-		return ru
-	}
-	return desc
+// printHeuristicNotes explains the findings -heuristics dropped or
+// downgraded, in scan order
+func printHeuristicNotes(result *searcher.ScanResult) {
+	if len(result.HeuristicNotes) == 0 {
+		return
+	}
+	fmt.Println(ui.T("cli.heuristics.title"))
+	for _, note := range result.HeuristicNotes {
+		action := ui.T("cli.heuristics.downgraded")
+		if note.Dropped {
+			action = ui.T("cli.heuristics.dropped")
+		}
+		fmt.Printf("   %s:%d %s — %s: %s (%s)\n", note.FilePath, note.LineNumber, note.PatternType, action, note.Reason, note.Value)
+	}
+	if shown, total := len(result.HeuristicNotes), result.HeuristicDropped+result.HeuristicDowngraded; total > shown {
+		fmt.Println(ui.T("cli.heuristics.more", total-shown))
+	}
+}
+
+// reportOptions holds the settings of the written reports
+type reportOptions struct {
+	Secrets    bool     // Write matched secrets unmasked
+	CSVColumns []string // Columns of the CSV report, see ReportGenerator.SetCSVColumns
+	CSVNoBOM   bool     // Do not start the CSV report with a BOM
+}
+
+// reportOptions returns the report settings of a scan
+func (o scanOptions) reportOptions() reportOptions {
+	return reportOptions{Secrets: o.Secrets, CSVColumns: o.CSVColumns, CSVNoBOM: o.CSVNoBOM}
 }
 
-// generateReports создаёт отчёты в JSON, CSV и текстовом формате
-func generateReports(result *searcher.ScanResult, outputDir string) error {
+// generateReports создаёт отчёты в выбранных форматах (по умолчанию JSON,
+// CSV и текст); секреты маскируются, если не задан opts.Secrets
+func generateReports(result *searcher.ScanResult, outputDir string, formats []string, opts reportOptions) error {
 	// Создание директории вывода, если не существует
 	if err := os.MkdirAll(outputDir, 0755); err != nil {
-		return fmt.Errorf("не удалось создать директорию вывода: %v", err)
+		return errors.New(ui.T("cli.output_dir", err))
 	}
 
 	reporter := searcher.NewReportGenerator(result)
+	reporter.SetLocale(ui)
+	reporter.SetIncludeSecrets(opts.Secrets)
+	if err := reporter.SetCSVColumns(opts.CSVColumns); err != nil {
+		return err
+	}
+	reporter.SetCSVBOM(!opts.CSVNoBOM)
 
-	// Экспорт во все форматы
-	if err := reporter.GenerateReport(outputDir); err != nil {
+	if len(formats) == 0 {
+		formats = searcher.DefaultReportFormats
+	}
+	reportDir, err := reporter.GenerateReportFormats(outputDir, formats)
+	if err != nil {
 		return err
 	}
 
 	// Вывод информации о файлах отчётов
-	fmt.Printf("✅ Отчёты сгенерированы в: %s\n", outputDir)
+	fmt.Println(ui.T("cli.reports_written", reportDir))
 	return nil
 }
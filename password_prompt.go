@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/kacebover/password-finder/encryptor"
+)
+
+// maxPasswordAttempts limits how often a mismatched confirmation is retried
+const maxPasswordAttempts = 3
+
+var errPasswordMismatch = errors.New("passwords do not match")
+
+// passwordReader reads passwords from a terminal without echo. When stdin
+// is not a terminal, as in scripts piping the password, lines are read as
+// they are after a warning
+type passwordReader struct {
+	out    io.Writer
+	lines  *bufio.Reader
+	hidden func() (string, error) // nil when stdin is not a terminal
+	warned bool
+}
+
+// stdinPasswords is shared by all prompts, so piped lines buffered by one
+// prompt are not lost to the next
+var stdinPasswords = newPasswordReader(os.Stdin, os.Stdout)
+
+func newPasswordReader(in *os.File, out io.Writer) *passwordReader {
+	r := &passwordReader{out: out, lines: bufio.NewReader(in)}
+	if isTerminal(in) {
+		r.hidden = func() (string, error) { return readHiddenLine(in) }
+	}
+	return r
+}
+
+// read prints prompt and reads one password
+func (r *passwordReader) read(prompt string) (string, error) {
+	if r.hidden != nil {
+		fmt.Fprint(r.out, prompt)
+		password, err := r.hidden()
+		fmt.Fprintln(r.out) // The newline typed by the user was not echoed either
+		return password, err
+	}
+
+	if !r.warned {
+		fmt.Fprintln(r.out, ui.T("cli.password.not_terminal"))
+		r.warned = true
+	}
+	fmt.Fprint(r.out, prompt)
+	line, err := r.lines.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// readNew reads a new password and its confirmation, asking again up to
+// maxPasswordAttempts times while they differ
+func (r *passwordReader) readNew(prompt, confirmPrompt string) (string, error) {
+	for attempt := 1; attempt <= maxPasswordAttempts; attempt++ {
+		password, err := r.read(prompt)
+		if err != nil {
+			return "", err
+		}
+		confirm, err := r.read(confirmPrompt)
+		if err != nil {
+			return "", err
+		}
+		if password == confirm {
+			return password, nil
+		}
+		if attempt < maxPasswordAttempts {
+			fmt.Fprintln(r.out, ui.T("cli.password.retry", attempt+1, maxPasswordAttempts))
+		}
+	}
+	return "", &localizedError{ui.T("cli.password.mismatch", maxPasswordAttempts), errPasswordMismatch}
+}
+
+// readHiddenLine reads a line from the terminal f with echo turned off.
+// Ctrl+C restores the terminal before exiting, so the shell is not left
+// without echo
+func readHiddenLine(f *os.File) (string, error) {
+	restore, err := disableEcho(f)
+	if err != nil {
+		return "", err
+	}
+	defer restore()
+
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt, syscall.SIGTERM)
+	done := make(chan struct{})
+	defer func() {
+		signal.Stop(interrupts)
+		close(done)
+	}()
+	go func() {
+		select {
+		case <-interrupts:
+			restore()
+			fmt.Println()
+			os.Exit(exitCancelled)
+		case <-done:
+		}
+	}()
+
+	var line []byte
+	buf := make([]byte, 1)
+	for {
+		n, err := f.Read(buf)
+		if n > 0 {
+			if buf[0] == '\n' {
+				break
+			}
+			line = append(line, buf[0])
+		}
+		if err == io.EOF && len(line) > 0 {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	return strings.TrimRight(string(line), "\r"), nil
+}
+
+// promptPassword reads a password from stdin without echo
+func promptPassword(prompt string) (string, error) {
+	return stdinPasswords.read(prompt)
+}
+
+// promptNewPassword reads a new password with confirmation
+func promptNewPassword(prompt, confirmPrompt string) (string, error) {
+	return stdinPasswords.readNew(prompt, confirmPrompt)
+}
+
+// printWeakPassword explains why a password is weak; strict turns the
+// warning into an error
+func printWeakPassword(w io.Writer, score int, feedback []string, strict bool) {
+	title := strings.ToLower(ui.T(fmt.Sprintf("password.strength.%d", score)))
+	if strict {
+		fmt.Fprintln(w, ui.T("cli.password.strict", title, score, encryptor.DefaultMinPasswordScore))
+	} else {
+		fmt.Fprintln(w, ui.T("cli.password.weak", title, score))
+	}
+	for _, suggestion := range feedback {
+		fmt.Fprintf(w, "   • %s\n", ui.PasswordFeedback(suggestion))
+	}
+	if !strict {
+		fmt.Fprintln(w, ui.T("cli.password.strict_hint"))
+	}
+	fmt.Fprintln(w)
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestPasswordReaderPiped(t *testing.T) {
+	var out bytes.Buffer
+	r := &passwordReader{out: &out, lines: bufio.NewReader(strings.NewReader("first secret \r\nsecond"))}
+
+	for _, want := range []string{"first secret ", "second"} {
+		got, err := r.read("Пароль: ")
+		if err != nil || got != want {
+			t.Errorf("read = %q, %v; want %q", got, err, want)
+		}
+	}
+	if _, err := r.read("Пароль: "); err != io.EOF {
+		t.Errorf("read after the input ended: %v, want io.EOF", err)
+	}
+	if n := strings.Count(out.String(), "Ввод не из терминала"); n != 1 {
+		t.Errorf("the warning should be printed once, got %d times:\n%s", n, out.String())
+	}
+}
+
+func TestPasswordReaderNotTerminal(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "stdin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if r := newPasswordReader(f, io.Discard); r.hidden != nil {
+		t.Error("a regular file is not a terminal and should be read as piped input")
+	}
+}
+
+func TestPasswordReaderConfirmRetry(t *testing.T) {
+	tests := []struct {
+		name    string
+		inputs  []string
+		want    string
+		wantErr error
+	}{
+		{"match", []string{"s3cret", "s3cret"}, "s3cret", nil},
+		{"second attempt", []string{"s3cret", "typo", "s3cret", "s3cret"}, "s3cret", nil},
+		{"three mismatches", []string{"a", "b", "c", "d", "e", "f", "unused", "unused"}, "", errPasswordMismatch},
+		{"input ends", []string{"a", "b"}, "", io.EOF},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		inputs := tt.inputs
+		r := &passwordReader{out: &out, hidden: func() (string, error) {
+			if len(inputs) == 0 {
+				return "", io.EOF
+			}
+			next := inputs[0]
+			inputs = inputs[1:]
+			return next, nil
+		}}
+
+		got, err := r.readNew("Пароль: ", "Подтвердите: ")
+		if got != tt.want || !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: readNew = %q, %v; want %q, %v", tt.name, got, err, tt.want, tt.wantErr)
+		}
+		if tt.name == "three mismatches" && len(inputs) != 2 {
+			t.Errorf("%s: should stop after %d attempts, %d inputs left", tt.name, maxPasswordAttempts, len(inputs))
+		}
+		if strings.Contains(out.String(), "Ввод не из терминала") {
+			t.Errorf("%s: no warning expected for a terminal", tt.name)
+		}
+	}
+}
+
+func TestPrintWeakPassword(t *testing.T) {
+	var out bytes.Buffer
+	printWeakPassword(&out, 1, []string{"Use at least 12 characters"}, false)
+	if s := out.String(); !strings.Contains(s, "слабый (1 из 4)") || !strings.Contains(s, "• Используйте") || !strings.Contains(s, "-strict") {
+		t.Errorf("warning:\n%s", s)
+	}
+
+	out.Reset()
+	printWeakPassword(&out, 0, nil, true)
+	if s := out.String(); !strings.Contains(s, "❌") || strings.Contains(s, "добавьте -strict") {
+		t.Errorf("strict error:\n%s", s)
+	}
+}
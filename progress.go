@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/kacebover/password-finder/searcher"
+)
+
+// Progress of a CLI scan is one line rewritten with carriage returns on a
+// terminal and a plain log line every 15 seconds elsewhere, so CI logs get
+// no control characters
+const (
+	progressTick     = 200 * time.Millisecond // Updates of the terminal line, 5 a second
+	progressLogEvery = 15 * time.Second       // Log lines when stdout is not a terminal
+	progressWidth    = 80                     // Columns assumed when the terminal does not tell
+)
+
+// progressRenderer writes the progress of a scan to w
+type progressRenderer struct {
+	w        io.Writer
+	tty      bool
+	width    func() int // Columns of the terminal, 0 when unknown
+	root     string     // Current directories are shown relative to it
+	interval time.Duration
+	last     time.Time // Last output, or the start of the scan
+	shown    int       // Length of the line on the terminal, 0 when none
+	eta      *searcher.ETAEstimator
+}
+
+// newProgressRenderer starts the progress of a scan of root at now
+func newProgressRenderer(w io.Writer, tty bool, width func() int, root string, now time.Time) *progressRenderer {
+	r := &progressRenderer{w: w, tty: tty, width: width, root: root, last: now, interval: progressLogEvery,
+		eta: searcher.NewETAEstimator(searcher.DefaultETAWindow)}
+	if tty {
+		r.interval = progressTick
+	}
+	return r
+}
+
+// update writes the progress unless the last output is too recent
+func (r *progressRenderer) update(now time.Time, p searcher.ScanProgress) {
+	if p.EstimatedTotal > 0 {
+		r.eta.Add(time.Time{}.Add(p.ElapsedTime), p.FilesDone)
+	}
+	if now.Sub(r.last) < r.interval {
+		return
+	}
+	r.last = now
+	if !r.tty {
+		line := r.status(p)
+		if dir := r.currentDir(p); dir != "" {
+			line += " — " + dir
+		}
+		fmt.Fprintln(r.w, line)
+		return
+	}
+	r.draw(p)
+}
+
+// finish writes the last progress on a terminal and ends its line, so what
+// follows starts on a clean one however the scan ended
+func (r *progressRenderer) finish(p searcher.ScanProgress) {
+	if !r.tty || r.shown == 0 {
+		return
+	}
+	r.draw(p)
+	fmt.Fprintln(r.w)
+	r.shown = 0
+}
+
+// draw rewrites the terminal line, cutting the current directory to the
+// width of the terminal
+func (r *progressRenderer) draw(p searcher.ScanProgress) {
+	width := r.width()
+	if width <= 0 {
+		width = progressWidth
+	}
+	// The last column is left free so the line never wraps; ⏳ takes two
+	limit := width - 2
+
+	line := []rune("⏳ " + r.status(p))
+	if dir := []rune(r.currentDir(p)); len(dir) > 0 && len(line)+3+4 < limit {
+		if room := limit - len(line) - 3; len(dir) > room {
+			dir = append([]rune("…"), dir[len(dir)-room+1:]...)
+		}
+		line = append(line, []rune(" · "+string(dir))...)
+	}
+	if len(line) > limit {
+		line = line[:limit]
+	}
+	// Pad over the end of a longer previous line
+	fmt.Fprintf(r.w, "\r%s%s", string(line), strings.Repeat(" ", max(r.shown-len(line), 0)))
+	r.shown = len(line)
+}
+
+// status is the progress without the current directory
+func (r *progressRenderer) status(p searcher.ScanProgress) string {
+	elapsed := searcher.FormatElapsed(ui, p.ElapsedTime.Round(time.Second))
+	status := ui.T("cli.progress", p.FilesProcessed, p.FilesSkipped, p.FindingsCount, elapsed)
+	if p.EstimatedTotal > 0 {
+		remaining, known := r.eta.Estimate(p.EstimatedTotal)
+		status = searcher.FormatFileProgress(ui, p.FilesDone, p.EstimatedTotal, p.TotalComplete, remaining, known) + " · " + status
+	}
+	return status
+}
+
+// currentDir is the directory of the file being scanned, relative to the
+// scanned directory
+func (r *progressRenderer) currentDir(p searcher.ScanProgress) string {
+	if p.CurrentFile == "" {
+		return ""
+	}
+	dir := filepath.Dir(p.CurrentFile)
+	if rel, err := filepath.Rel(r.root, dir); err == nil && !strings.HasPrefix(rel, "..") {
+		dir = rel
+	}
+	return dir
+}
+
+// printScanProgress shows the progress of the scan of root until the
+// returned function is called
+func printScanProgress(scanner *searcher.Scanner, root string) (stop func()) {
+	renderer := newProgressRenderer(os.Stdout, isTerminal(os.Stdout), func() int { return terminalWidth(os.Stdout) }, root, time.Now())
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		ticker := time.NewTicker(progressTick)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				renderer.finish(scanner.Progress())
+				return
+			case now := <-ticker.C:
+				renderer.update(now, scanner.Progress())
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-finished
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kacebover/password-finder/searcher"
+)
+
+func progressAt(files int64, dir string) searcher.ScanProgress {
+	return searcher.ScanProgress{
+		FilesProcessed: files,
+		FilesSkipped:   2,
+		FindingsCount:  7,
+		ElapsedTime:    90 * time.Second,
+		CurrentFile:    filepath.Join("/data", dir, "file.txt"),
+	}
+}
+
+func TestProgressRendererThrottles(t *testing.T) {
+	var out bytes.Buffer
+	start := time.Unix(1000, 0)
+	r := newProgressRenderer(&out, true, func() int { return 120 }, "/data", start)
+
+	// A tick every 50ms for one second draws at most 5 times
+	for i := 1; i <= 20; i++ {
+		r.update(start.Add(time.Duration(i)*50*time.Millisecond), progressAt(int64(i), "src"))
+	}
+	if draws := strings.Count(out.String(), "\r"); draws != 5 {
+		t.Errorf("%d draws in a second, want 5: %q", draws, out.String())
+	}
+	if strings.Contains(out.String(), "\n") {
+		t.Error("terminal progress wrote a newline while scanning")
+	}
+
+	r.finish(progressAt(20, "src"))
+	if !strings.HasSuffix(out.String(), "\n") {
+		t.Error("finish does not end the progress line")
+	}
+	last := out.String()[strings.LastIndex(out.String(), "\r")+1:]
+	if !strings.Contains(last, "20") || !strings.Contains(last, "src") {
+		t.Errorf("last line %q, want the final counts and directory", last)
+	}
+}
+
+func TestProgressRendererFitsWidth(t *testing.T) {
+	var out bytes.Buffer
+	start := time.Unix(1000, 0)
+	r := newProgressRenderer(&out, true, func() int { return 70 }, "/data", start)
+	deep := strings.Repeat("very/deep/", 20) + "tail"
+	r.update(start.Add(time.Second), progressAt(3, deep))
+
+	line := strings.TrimPrefix(out.String(), "\r")
+	if n := len([]rune(line)); n > 68 {
+		t.Errorf("line of %d runes on a 70-column terminal: %q", n, line)
+	}
+	if !strings.Contains(line, "…") || !strings.HasSuffix(line, "tail") {
+		t.Errorf("directory not cut from the left: %q", line)
+	}
+}
+
+func TestProgressRendererPlainLog(t *testing.T) {
+	var out bytes.Buffer
+	start := time.Unix(1000, 0)
+	r := newProgressRenderer(&out, false, func() int { return 0 }, "/data", start)
+
+	for i := 1; i <= 40; i++ {
+		r.update(start.Add(time.Duration(i)*time.Second), progressAt(int64(i), "src/app"))
+	}
+	r.finish(progressAt(40, "src/app"))
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("%d log lines in 40s, want 2 (every 15s): %q", len(lines), out.String())
+	}
+	if strings.ContainsAny(out.String(), "\r\x1b") {
+		t.Errorf("control characters in the log: %q", out.String())
+	}
+	want := ui.T("cli.progress", 15, 2, 7, searcher.FormatElapsed(ui, 90*time.Second)) + " — " + filepath.Join("src", "app")
+	if lines[0] != want {
+		t.Errorf("log line %q, want %q", lines[0], want)
+	}
+}
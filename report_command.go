@@ -0,0 +1,236 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/kacebover/password-finder/searcher"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════
+// КОМАНДА РАБОТЫ С ОТЧЁТАМИ
+// ═══════════════════════════════════════════════════════════════════════════
+
+func runReportCommand(args []string) {
+	if len(args) == 0 {
+		printReportHelp()
+		os.Exit(1)
+	}
+
+	switch args[0] {
+	case "import", "импорт":
+		runReportImportCommand(args[1:])
+	case "merge", "объединить":
+		runReportMergeCommand(args[1:])
+	case "diff", "сравнить":
+		runReportDiffCommand(args[1:])
+	default:
+		printReportHelp()
+		os.Exit(1)
+	}
+}
+
+func printReportHelp() {
+	fmt.Print(ui.T("cli.report.help"))
+}
+
+func runReportImportCommand(args []string) {
+	importCmd := flag.NewFlagSet("report import", flag.ExitOnError)
+
+	format := importCmd.String("format", "", "Формат результатов: gitleaks, trufflehog (обязательно)")
+	mappingPath := importCmd.String("mapping", "", "Файл сопоставления правил с типами паттернов (YAML/JSON)")
+	reportPath := importCmd.String("report", "", "JSON-отчёт, в который добавить находки")
+	outputDir := importCmd.String("output", ".", "Директория для сохранения отчётов")
+
+	importCmd.Usage = func() {
+		fmt.Print(ui.T("cli.report.import.usage"))
+	}
+
+	if err := importCmd.Parse(args); err != nil {
+		os.Exit(1)
+	}
+
+	if *format == "" || importCmd.NArg() == 0 {
+		importCmd.Usage()
+		os.Exit(1)
+	}
+
+	importFormat, err := searcher.ParseImportFormat(*format)
+	if err != nil {
+		fmt.Println(ui.T("cli.error", err))
+		os.Exit(1)
+	}
+
+	var mapping searcher.RuleMapping
+	if *mappingPath != "" {
+		mapping, err = searcher.LoadRuleMapping(*mappingPath)
+		if err != nil {
+			fmt.Println(ui.T("cli.report.error.mapping", err))
+			os.Exit(1)
+		}
+	}
+
+	var result *searcher.ScanResult
+	if *reportPath != "" {
+		result, err = searcher.LoadScanResult(*reportPath)
+		if err != nil {
+			fmt.Println(ui.T("cli.error.load_report", err))
+			os.Exit(1)
+		}
+	} else {
+		result = searcher.NewScanResult()
+		result.StartTime = time.Now().Unix()
+		result.EndTime = result.StartTime
+	}
+
+	importer := searcher.NewImporter(importFormat, mapping)
+	for _, path := range importCmd.Args() {
+		findings, err := importer.ImportFile(path)
+		if err != nil {
+			fmt.Println(ui.T("cli.report.error.import", err))
+			os.Exit(1)
+		}
+		added, duplicates := searcher.MergeFindings(result, findings)
+		fmt.Println(ui.T("cli.report.imported", path, added, duplicates))
+	}
+
+	printSummary(result)
+
+	if err := generateReports(result, *outputDir, nil, reportOptions{}); err != nil {
+		fmt.Println(ui.T("cli.error.reports", err))
+		os.Exit(1)
+	}
+}
+
+func runReportMergeCommand(args []string) {
+	mergeCmd := flag.NewFlagSet("report merge", flag.ExitOnError)
+
+	outputDir := mergeCmd.String("output", ".", "Директория для сохранения объединённых отчётов")
+	formatList := mergeCmd.String("format", "", "Форматы отчётов через запятую (json, csv, txt, html, sarif, junit, xlsx)")
+
+	mergeCmd.Usage = func() {
+		fmt.Print(ui.T("cli.report.merge.usage"))
+	}
+
+	// Flags may follow the report files
+	var paths []string
+	rest := args
+	for {
+		if err := mergeCmd.Parse(rest); err != nil {
+			os.Exit(1)
+		}
+		if mergeCmd.NArg() == 0 {
+			break
+		}
+		paths = append(paths, mergeCmd.Arg(0))
+		rest = mergeCmd.Args()[1:]
+	}
+
+	if len(paths) < 2 {
+		mergeCmd.Usage()
+		os.Exit(1)
+	}
+
+	formats, err := searcher.ParseReportFormats(*formatList)
+	if err != nil {
+		fmt.Println(ui.T("cli.error", err))
+		os.Exit(1)
+	}
+
+	result := searcher.NewScanResult()
+	generator := searcher.NewReportGenerator(result)
+	for _, path := range paths {
+		added, duplicates, err := generator.ImportJSON(path)
+		if err != nil {
+			fmt.Println(ui.T("cli.error", err))
+			os.Exit(1)
+		}
+		fmt.Println(ui.T("cli.report.merged", path, added, duplicates))
+	}
+
+	printSummary(result)
+
+	if err := generateReports(result, *outputDir, formats, reportOptions{}); err != nil {
+		fmt.Println(ui.T("cli.error.reports", err))
+		os.Exit(1)
+	}
+}
+
+func runReportDiffCommand(args []string) {
+	diffCmd := flag.NewFlagSet("report diff", flag.ExitOnError)
+
+	outputDir := diffCmd.String("output", "", "Директория для отчёта о сравнении (по умолчанию только вывод в терминал)")
+	formatList := diffCmd.String("format", "json,html", "Форматы отчёта о сравнении через запятую: json, html")
+	limit := diffCmd.Int("limit", 20, "Сколько новых и исправленных находок показать в терминале")
+
+	diffCmd.Usage = func() {
+		fmt.Print(ui.T("cli.report.diff.usage"))
+	}
+
+	// Flags may follow the report files
+	var paths []string
+	rest := args
+	for {
+		if err := diffCmd.Parse(rest); err != nil {
+			os.Exit(1)
+		}
+		if diffCmd.NArg() == 0 {
+			break
+		}
+		paths = append(paths, diffCmd.Arg(0))
+		rest = diffCmd.Args()[1:]
+	}
+
+	if len(paths) != 2 {
+		diffCmd.Usage()
+		os.Exit(1)
+	}
+
+	exporters := map[string]func(*searcher.ScanDiff, string) error{
+		searcher.FormatJSON: (*searcher.ScanDiff).ExportJSON,
+		searcher.FormatHTML: (*searcher.ScanDiff).ExportHTML,
+	}
+	formats := splitList(*formatList)
+	for _, format := range formats {
+		if exporters[format] == nil {
+			fmt.Println(ui.T("cli.report.diff.bad_format", format))
+			os.Exit(1)
+		}
+	}
+
+	results := make([]*searcher.ScanResult, len(paths))
+	for i, path := range paths {
+		result, err := searcher.LoadScanResult(path)
+		if err != nil {
+			fmt.Println(ui.T("cli.error.load_report", err))
+			os.Exit(exitCode(err))
+		}
+		results[i] = result
+	}
+
+	diff := searcher.CompareResults(results[0], results[1])
+	fmt.Println(ui.T("cli.report.diff.title", paths[0], paths[1]))
+	fmt.Println()
+	fmt.Print(searcher.FormatScanDiff(ui, diff, *limit))
+
+	if *outputDir == "" {
+		return
+	}
+	if err := os.MkdirAll(*outputDir, 0755); err != nil {
+		fmt.Println(ui.T("cli.error.output_dir", err))
+		os.Exit(1)
+	}
+	fmt.Println()
+	timestamp := time.Now().Format("20060102_150405")
+	for _, format := range formats {
+		path := filepath.Join(*outputDir, ui.T("cli.report.diff.file_name")+"_"+timestamp+"."+format)
+		if err := exporters[format](diff, path); err != nil {
+			fmt.Println(ui.T("cli.report.error.write", err))
+			os.Exit(1)
+		}
+		fmt.Println(ui.T("cli.report.diff.saved", path))
+	}
+}
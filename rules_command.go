@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/kacebover/password-finder/searcher"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════
+// КОМАНДА ПРОВЕРКИ ПРАВИЛ
+// ═══════════════════════════════════════════════════════════════════════════
+
+func runRulesCommand(args []string) {
+	if len(args) == 0 || (args[0] != "test" && args[0] != "проверить") {
+		printRulesHelp()
+		os.Exit(1)
+	}
+	runRulesTestCommand(args[1:])
+}
+
+func printRulesHelp() {
+	fmt.Print(ui.T("cli.rules.help"))
+}
+
+func runRulesTestCommand(args []string) {
+	testCmd := flag.NewFlagSet("rules test", flag.ExitOnError)
+
+	ruleFiles := testCmd.String("rule", "", "Файл(ы) правил YAML/JSON через запятую")
+	inputPath := testCmd.String("input", "", "Файл с примером текста")
+	text := testCmd.String("text", "", "Пример текста (альтернатива -input)")
+	builtin := testCmd.Bool("builtin", true, "Использовать встроенные правила")
+	only := testCmd.String("only", "", "Проверять только указанные правила (имена или типы через запятую)")
+	showAll := testCmd.Bool("all", false, "Показывать строки без совпадений")
+	weightsPath := testCmd.String("weights", "", "Файл с разделом risk_weights для оценки риска")
+
+	testCmd.Usage = func() {
+		fmt.Print(ui.T("cli.rules.test.usage"))
+	}
+
+	if err := testCmd.Parse(args); err != nil {
+		os.Exit(1)
+	}
+
+	if *inputPath == "" && *text == "" {
+		testCmd.Usage()
+		os.Exit(1)
+	}
+
+	patterns, err := loadTestPatterns(*ruleFiles, *builtin, *only)
+	if err != nil {
+		fmt.Println(ui.T("cli.error", err))
+		os.Exit(1)
+	}
+	if len(patterns.List()) == 0 {
+		fmt.Println(ui.T("cli.rules.none"))
+		os.Exit(1)
+	}
+
+	scanner := searcher.NewScanner()
+	scanner.SetPatterns(patterns)
+	if *weightsPath != "" {
+		weights, err := searcher.LoadRiskWeights(*weightsPath)
+		if err != nil {
+			fmt.Println(ui.T("cli.error", err))
+			os.Exit(1)
+		}
+		scanner.SetRiskWeights(weights)
+	}
+
+	var lines []string
+	if *text != "" {
+		lines = strings.Split(*text, "\n")
+	} else {
+		lines, err = readLines(*inputPath)
+		if err != nil {
+			fmt.Println(ui.T("cli.error.read_file", err))
+			os.Exit(1)
+		}
+	}
+
+	fmt.Println(ui.T("cli.rules.summary", len(patterns.List()), len(lines)))
+
+	totalMatches := 0
+	for i, line := range lines {
+		trace := scanner.Evaluate(line, *inputPath)
+		if len(trace.Matches) == 0 && !*showAll {
+			continue
+		}
+		totalMatches += len(trace.Matches)
+
+		trace.Path = ""
+		fmt.Println()
+		fmt.Println(ui.T("cli.rules.line", i+1))
+		fmt.Print(searcher.FormatEvaluationTrace(ui, trace))
+	}
+
+	fmt.Println()
+	fmt.Println(ui.T("cli.rules.total", totalMatches))
+}
+
+// loadTestPatterns builds the rule set selected for the test bench
+func loadTestPatterns(ruleFiles string, builtin bool, only string) (*searcher.Patterns, error) {
+	var list []*searcher.Pattern
+	if builtin {
+		list = append(list, searcher.NewPatterns().List()...)
+	}
+
+	for _, path := range strings.Split(ruleFiles, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		rules, err := searcher.LoadRuleFile(path)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, rules...)
+	}
+
+	if only != "" {
+		selected := make(map[string]bool)
+		for _, name := range strings.Split(only, ",") {
+			selected[strings.TrimSpace(name)] = true
+		}
+		filtered := list[:0]
+		for _, p := range list {
+			if selected[p.Name] || selected[string(p.Type)] {
+				filtered = append(filtered, p)
+			}
+		}
+		list = filtered
+	}
+
+	return searcher.NewPatternsFrom(list), nil
+}
+
+func readLines(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines, scanner.Err()
+}
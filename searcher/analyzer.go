@@ -1,25 +1,88 @@
 package searcher
 
 import (
+	"bufio"
 	"bytes"
+	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"os"
+	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 )
 
+// defaultOllamaURL is where a locally installed Ollama listens by default
+const defaultOllamaURL = "http://localhost:11434"
+
+// Defaults of Ollama requests. Loading a model can take minutes on the
+// first request, so the timeout is generous and a busy server is retried
+const (
+	DefaultOllamaTimeout      = 5 * time.Minute
+	DefaultOllamaRetries      = 2 // Attempts after the first one
+	DefaultOllamaBackoff      = time.Second
+	DefaultAIImageConcurrency = 2
+)
+
+// Errors of Ollama requests, matched by an OllamaError; callers fall back
+// to the rule-based analysis on them
+var (
+	ErrOllamaUnreachable   = errors.New("Ollama недоступен")
+	ErrOllamaModelNotFound = errors.New("модель Ollama не найдена")
+	ErrOllamaFailed        = errors.New("ошибка Ollama")
+)
+
+// OllamaError is a failed Ollama request; it matches its Kind
+type OllamaError struct {
+	Kind  error // ErrOllamaUnreachable, ErrOllamaModelNotFound or ErrOllamaFailed
+	Model string
+	Err   error // The cause: a connection error or the message of Ollama
+}
+
+func (e *OllamaError) Error() string {
+	if e.Kind == ErrOllamaModelNotFound {
+		return fmt.Sprintf("%v: %s (ollama pull %s)", e.Kind, e.Model, e.Model)
+	}
+	return fmt.Sprintf("%v: %v", e.Kind, e.Err)
+}
+
+func (e *OllamaError) Is(target error) bool {
+	return target == e.Kind
+}
+
+func (e *OllamaError) Unwrap() error {
+	return e.Err
+}
+
+// AIProgress is the output of an Ollama request received so far
+type AIProgress struct {
+	FilePath string // Image described or finding explained; empty for the insights of the scan
+	Chunk    string // Text of the last chunk
+	Text     string // All text received
+}
+
 // LocalAnalyzer provides AI-powered analysis using local LLM (Ollama)
 type LocalAnalyzer struct {
-	ollamaURL  string
-	model      string
-	timeout    time.Duration
-	enabled    bool
-	httpClient *http.Client
+	ollamaURL   string
+	model       string
+	timeout     time.Duration
+	enabled     bool
+	httpClient  *http.Client
+	retries     int
+	backoff     time.Duration
+	imageLimit  int // Images described at the same time
+	onProgress  func(AIProgress)
+	progressMux sync.Mutex // Serializes onProgress calls of parallel requests
+
+	explainMu    sync.Mutex
+	explanations map[string]string // Finding.Fingerprint -> answer of ExplainFinding
 }
 
 // AnalysisResult holds the result of AI analysis
@@ -55,36 +118,45 @@ type CriticalFinding struct {
 	Suggestion  string  `json:"suggestion"`
 }
 
-// AnalysisStatistics holds statistical analysis
-type AnalysisStatistics struct {
-	TotalFindings        int               `json:"total_findings"`
-	UniqueFiles          int               `json:"unique_files"`
-	AverageRiskScore     float64           `json:"average_risk_score"`
-	MaxRiskScore         float64           `json:"max_risk_score"`
-	SeverityDistribution map[string]int    `json:"severity_distribution"`
-	PatternDistribution  map[string]int    `json:"pattern_distribution"`
-	MostAffectedFiles    []FileRiskSummary `json:"most_affected_files"`
-}
-
-// FileRiskSummary summarizes risk for a single file
-type FileRiskSummary struct {
-	FilePath     string  `json:"file_path"`
-	FindingCount int     `json:"finding_count"`
-	MaxSeverity  string  `json:"max_severity"`
-	AvgRiskScore float64 `json:"avg_risk_score"`
-}
-
 // NewLocalAnalyzer creates a new local analyzer
 func NewLocalAnalyzer() *LocalAnalyzer {
 	return &LocalAnalyzer{
-		ollamaURL:  "http://localhost:11434",
+		ollamaURL:  defaultOllamaURL,
 		model:      "llama3.2", // Default model, can be changed
-		timeout:    60 * time.Second,
+		timeout:    DefaultOllamaTimeout,
 		enabled:    false,
-		httpClient: &http.Client{Timeout: 60 * time.Second},
+		httpClient: Network().Client(NetworkAI, DefaultOllamaTimeout),
+		retries:    DefaultOllamaRetries,
+		backoff:    DefaultOllamaBackoff,
+		imageLimit: DefaultAIImageConcurrency,
 	}
 }
 
+// SetTimeout sets how long one Ollama request may take, including the
+// whole streamed response
+func (la *LocalAnalyzer) SetTimeout(timeout time.Duration) {
+	la.timeout = timeout
+	la.httpClient = Network().Client(NetworkAI, timeout)
+}
+
+// SetRetries sets how often a refused connection or a busy server (503) is
+// retried, and the pause before the first retry, doubled on every attempt
+func (la *LocalAnalyzer) SetRetries(retries int, backoff time.Duration) {
+	la.retries = retries
+	la.backoff = backoff
+}
+
+// SetImageConcurrency sets how many images are described at the same time
+func (la *LocalAnalyzer) SetImageConcurrency(n int) {
+	la.imageLimit = max(n, 1)
+}
+
+// SetOnProgress sets a callback receiving the streamed output of the
+// requests; calls do not overlap
+func (la *LocalAnalyzer) SetOnProgress(fn func(AIProgress)) {
+	la.onProgress = fn
+}
+
 // SetModel sets the Ollama model to use
 func (la *LocalAnalyzer) SetModel(model string) {
 	la.model = model
@@ -100,8 +172,13 @@ func (la *LocalAnalyzer) EnableAI(enabled bool) {
 	la.enabled = enabled
 }
 
-// IsOllamaAvailable checks if Ollama is running
+// IsOllamaAvailable checks if Ollama is running.
+// The default endpoint is answered from the shared dependency registry.
 func (la *LocalAnalyzer) IsOllamaAvailable() bool {
+	if la.ollamaURL == defaultOllamaURL {
+		return Dependencies().IsAvailable(DependencyOllama)
+	}
+
 	resp, err := la.httpClient.Get(la.ollamaURL + "/api/tags")
 	if err != nil {
 		return false
@@ -112,9 +189,13 @@ func (la *LocalAnalyzer) IsOllamaAvailable() bool {
 
 // GetAvailableModels returns list of available Ollama models
 func (la *LocalAnalyzer) GetAvailableModels() ([]string, error) {
-	resp, err := la.httpClient.Get(la.ollamaURL + "/api/tags")
+	return la.availableModels(context.Background())
+}
+
+func (la *LocalAnalyzer) availableModels(ctx context.Context) ([]string, error) {
+	resp, err := la.request(ctx, http.MethodGet, "/api/tags", nil)
 	if err != nil {
-		return nil, fmt.Errorf("ollama недоступен: %v", err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
@@ -137,13 +218,21 @@ func (la *LocalAnalyzer) GetAvailableModels() ([]string, error) {
 
 // Analyze performs comprehensive analysis of scan results
 func (la *LocalAnalyzer) Analyze(result *ScanResult) (*AnalysisResult, error) {
+	return la.AnalyzeContext(context.Background(), result)
+}
+
+// AnalyzeContext is Analyze stopping the Ollama requests when ctx is done.
+// The rule-based analysis is always returned. When the AI part fails, the
+// error says why: an OllamaError, or the context error with the output
+// received so far kept in the analysis
+func (la *LocalAnalyzer) AnalyzeContext(ctx context.Context, result *ScanResult) (*AnalysisResult, error) {
 	analysis := &AnalysisResult{
 		AnalyzedAt: time.Now().Format("02.01.2006 15:04:05"),
 		UsedOllama: false,
 	}
 
 	// Calculate statistics
-	analysis.Statistics = la.calculateStatistics(result)
+	analysis.Statistics = ComputeStatistics(result)
 
 	// Generate rule-based analysis
 	analysis.Summary = la.generateSummary(result, &analysis.Statistics)
@@ -151,31 +240,41 @@ func (la *LocalAnalyzer) Analyze(result *ScanResult) (*AnalysisResult, error) {
 	analysis.Recommendations = la.generateRecommendations(result, &analysis.Statistics)
 	analysis.CriticalFindings = la.identifyCriticalFindings(result)
 
-	// If AI is enabled and Ollama is available, get AI insights
-	if la.enabled && la.IsOllamaAvailable() {
-		analysis.UsedOllama = true
-
-		// Get text-based AI insights
-		insights, err := la.getAIInsights(result, &analysis.Statistics)
-		if err == nil {
-			analysis.AIInsights = insights
-		}
+	if !la.enabled {
+		return analysis, nil
+	}
 
-		// Analyze document images with AI
-		analysis.ImageAnalyses = la.analyzeDocumentImages(result)
+	// Get text-based AI insights
+	insights, err := la.getAIInsights(ctx, result, &analysis.Statistics)
+	analysis.AIInsights = insights
+	if err != nil {
+		return analysis, err
 	}
+	analysis.UsedOllama = true
+
+	// Analyze document images with AI
+	analysis.ImageAnalyses = la.analyzeDocumentImages(ctx, result)
+	return analysis, cancelledAI(ctx)
+}
 
-	return analysis, nil
+// cancelledAI returns the context error of a stopped analysis
+func cancelledAI(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("AI-анализ прерван: %w", err)
+	}
+	return nil
 }
 
-// analyzeDocumentImages analyzes detected document images with AI
-func (la *LocalAnalyzer) analyzeDocumentImages(result *ScanResult) []ImageAIAnalysis {
+// analyzeDocumentImages analyzes detected document images with AI; up to
+// imageLimit images are described at the same time
+func (la *LocalAnalyzer) analyzeDocumentImages(ctx context.Context, result *ScanResult) []ImageAIAnalysis {
 	var analyses []ImageAIAnalysis
+	var images []int // Indexes of the analyses to describe
 
-	for _, f := range result.Findings {
+	result.ForEachFinding(func(f *Finding) bool {
 		// Only analyze image-based document findings
 		if f.PatternType != PatternPassport {
-			continue
+			return true
 		}
 
 		// Check if it's an image file
@@ -198,55 +297,77 @@ func (la *LocalAnalyzer) analyzeDocumentImages(result *ScanResult) []ImageAIAnal
 				}
 				analyses = append(analyses, analysis)
 			}
-			continue
+			return true
 		}
 
-		// Try to analyze with vision model (llava)
-		aiDesc := la.analyzeImageWithVision(filePath)
-
 		analysis := ImageAIAnalysis{
 			FilePath:      filePath,
 			DocumentType:  f.MatchedText,
 			Confidence:    f.RiskScore,
 			RiskLevel:     la.getRiskLevelFromScore(f.RiskScore),
-			AIDescription: aiDesc,
+			AIDescription: la.getDefaultImageDescription(),
 			Warnings:      la.generateWarnings(f),
 			DataFound:     la.extractFoundData(f),
 		}
+		images = append(images, len(analyses))
 		analyses = append(analyses, analysis)
+		return true
+	})
+	if len(images) == 0 {
+		return analyses
 	}
 
+	// Try to analyze with vision model (llava)
+	visionModel := la.visionModel(ctx)
+	if visionModel == "" {
+		return analyses
+	}
+	limit := make(chan struct{}, max(la.imageLimit, 1))
+	var wg sync.WaitGroup
+	for _, i := range images {
+		select {
+		case limit <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return analyses
+		}
+		wg.Add(1)
+		go func(analysis *ImageAIAnalysis) {
+			defer wg.Done()
+			defer func() { <-limit }()
+			if desc := la.analyzeImageWithVision(ctx, visionModel, analysis.FilePath); desc != "" {
+				analysis.AIDescription = desc
+			}
+		}(&analyses[i])
+	}
+	wg.Wait()
 	return analyses
 }
 
-// analyzeImageWithVision uses Ollama vision model to analyze image
-func (la *LocalAnalyzer) analyzeImageWithVision(imagePath string) string {
-	// Check if we have a vision model (llava, bakllava, etc.)
-	models, err := la.GetAvailableModels()
+// visionModel returns an installed vision model (llava, bakllava, etc.),
+// or "" when there is none
+func (la *LocalAnalyzer) visionModel(ctx context.Context) string {
+	models, err := la.availableModels(ctx)
 	if err != nil {
-		return la.getDefaultImageDescription()
+		return ""
 	}
-
-	// Look for vision model
-	visionModel := ""
 	for _, m := range models {
 		if strings.Contains(strings.ToLower(m), "llava") ||
 			strings.Contains(strings.ToLower(m), "bakllava") ||
 			strings.Contains(strings.ToLower(m), "moondream") {
-			visionModel = m
-			break
+			return m
 		}
 	}
+	return ""
+}
 
-	if visionModel == "" {
-		// No vision model available, use text description
-		return la.getDefaultImageDescription()
-	}
-
+// analyzeImageWithVision uses Ollama vision model to analyze image; ""
+// when it could not
+func (la *LocalAnalyzer) analyzeImageWithVision(ctx context.Context, visionModel, imagePath string) string {
 	// Read and encode image
 	imageData, err := la.encodeImageBase64(imagePath)
 	if err != nil {
-		return la.getDefaultImageDescription()
+		return ""
 	}
 
 	// Call Ollama with vision model
@@ -260,40 +381,17 @@ func (la *LocalAnalyzer) analyzeImageWithVision(imagePath string) string {
 		"model":  visionModel,
 		"prompt": prompt,
 		"images": []string{imageData},
-		"stream": false,
 		"options": map[string]interface{}{
 			"temperature": 0.3,
 			"num_predict": 200,
 		},
 	}
 
-	jsonBody, _ := json.Marshal(reqBody)
-
-	resp, err := la.httpClient.Post(
-		la.ollamaURL+"/api/generate",
-		"application/json",
-		bytes.NewReader(jsonBody),
-	)
+	response, err := la.generate(ctx, imagePath, reqBody)
 	if err != nil {
-		return la.getDefaultImageDescription()
-	}
-	defer resp.Body.Close()
-
-	body, _ := io.ReadAll(resp.Body)
-
-	var ollamaResp struct {
-		Response string `json:"response"`
-	}
-
-	if err := json.Unmarshal(body, &ollamaResp); err != nil {
-		return la.getDefaultImageDescription()
+		return ""
 	}
-
-	if ollamaResp.Response == "" {
-		return la.getDefaultImageDescription()
-	}
-
-	return ollamaResp.Response
+	return response
 }
 
 // encodeImageBase64 reads and encodes image to base64
@@ -393,80 +491,16 @@ func (la *LocalAnalyzer) extractFoundData(f *Finding) []string {
 	return data
 }
 
-// calculateStatistics calculates detailed statistics
-func (la *LocalAnalyzer) calculateStatistics(result *ScanResult) AnalysisStatistics {
-	stats := AnalysisStatistics{
-		TotalFindings:        len(result.Findings),
-		SeverityDistribution: make(map[string]int),
-		PatternDistribution:  make(map[string]int),
-	}
-
-	fileFindings := make(map[string][]Finding)
-	var totalRisk float64
-	stats.MaxRiskScore = 0
-
-	for _, f := range result.Findings {
-		// Severity distribution
-		stats.SeverityDistribution[string(f.Severity)]++
-
-		// Pattern distribution
-		stats.PatternDistribution[string(f.PatternType)]++
-
-		// Risk scores
-		totalRisk += f.RiskScore
-		if f.RiskScore > stats.MaxRiskScore {
-			stats.MaxRiskScore = f.RiskScore
-		}
-
-		// Group by file
-		fileFindings[f.FilePath] = append(fileFindings[f.FilePath], *f)
-	}
-
-	stats.UniqueFiles = len(fileFindings)
-
-	if len(result.Findings) > 0 {
-		stats.AverageRiskScore = totalRisk / float64(len(result.Findings))
-	}
-
-	// Calculate most affected files
-	for filePath, findings := range fileFindings {
-		var maxSeverity Severity = Low
-		var totalFileRisk float64
-
-		for _, f := range findings {
-			totalFileRisk += f.RiskScore
-			if f.Severity.Score() > maxSeverity.Score() {
-				maxSeverity = f.Severity
-			}
-		}
-
-		stats.MostAffectedFiles = append(stats.MostAffectedFiles, FileRiskSummary{
-			FilePath:     filePath,
-			FindingCount: len(findings),
-			MaxSeverity:  string(maxSeverity),
-			AvgRiskScore: totalFileRisk / float64(len(findings)),
-		})
-	}
-
-	// Sort by finding count
-	sort.Slice(stats.MostAffectedFiles, func(i, j int) bool {
-		return stats.MostAffectedFiles[i].FindingCount > stats.MostAffectedFiles[j].FindingCount
-	})
-
-	// Keep only top 10
-	if len(stats.MostAffectedFiles) > 10 {
-		stats.MostAffectedFiles = stats.MostAffectedFiles[:10]
-	}
-
-	return stats
-}
-
 // generateSummary generates a human-readable summary
 func (la *LocalAnalyzer) generateSummary(result *ScanResult, stats *AnalysisStatistics) string {
 	var sb strings.Builder
 
-	sb.WriteString(fmt.Sprintf("Анализ выявил %d потенциальных утечек данных в %d файлах.\n\n",
+	sb.WriteString(fmt.Sprintf("Анализ выявил %d потенциальных утечек данных в %d файлах.\n",
 		stats.TotalFindings, stats.UniqueFiles))
+	if stats.DistinctSecrets > 0 && stats.DistinctSecrets < stats.TotalFindings {
+		sb.WriteString(fmt.Sprintf("Уникальных секретов: %d — часть значений повторяется в нескольких местах.\n", stats.DistinctSecrets))
+	}
+	sb.WriteString("\n")
 
 	critCount := stats.SeverityDistribution[string(Critical)]
 	highCount := stats.SeverityDistribution[string(High)]
@@ -570,8 +604,15 @@ func (la *LocalAnalyzer) generateRecommendations(result *ScanResult, stats *Anal
 		recs = append(recs, "🔗 Вынесите строки подключения к БД в конфигурацию окружения")
 	}
 
+	// One secret copied into many files has to be replaced everywhere
+	if len(stats.RepeatedSecrets) > 0 {
+		widest := stats.RepeatedSecrets[0]
+		recs = append(recs, fmt.Sprintf("🔁 Один и тот же секрет (%s) встречается %d раз в %d файлах — вынесите его в одно хранилище и замените все копии",
+			patternTypeToRussian(widest.PatternType), len(widest.Occurrences), widest.Files))
+	}
+
 	// General recommendations
-	if len(result.Findings) > 0 {
+	if result.TotalFindings() > 0 {
 		recs = append(recs, "📋 Добавьте pre-commit хук для автоматической проверки секретов")
 		recs = append(recs, "🛡️ Настройте .gitignore для исключения файлов с секретами")
 		recs = append(recs, "📝 Обновите .env.example с примерами переменных (без реальных значений)")
@@ -588,7 +629,7 @@ func (la *LocalAnalyzer) generateRecommendations(result *ScanResult, stats *Anal
 func (la *LocalAnalyzer) identifyCriticalFindings(result *ScanResult) []CriticalFinding {
 	var critical []CriticalFinding
 
-	for _, f := range result.Findings {
+	result.ForEachFinding(func(f *Finding) bool {
 		if f.Severity == Critical || (f.Severity == High && f.RiskScore >= 70) {
 			suggestion := la.getSuggestionForPattern(f.PatternType)
 
@@ -600,7 +641,8 @@ func (la *LocalAnalyzer) identifyCriticalFindings(result *ScanResult) []Critical
 				Suggestion:  suggestion,
 			})
 		}
-	}
+		return true
+	})
 
 	// Sort by risk score
 	sort.Slice(critical, func(i, j int) bool {
@@ -626,6 +668,22 @@ func (la *LocalAnalyzer) getSuggestionForPattern(pattern PatternType) string {
 		PatternCreditCard:    "НЕМЕДЛЕННО удалите! Данные карт не должны храниться в коде",
 		PatternConnectionStr: "Используйте DATABASE_URL из переменных окружения",
 		PatternToken:         "Храните токены в защищённом хранилище секретов",
+		PatternSlackToken:    "Отзовите токен в настройках приложения Slack и выпустите новый",
+		PatternStripeKey:     "Перевыпустите ключ в панели Stripe, секретные ключи храните только на сервере",
+		PatternSendGridKey:   "Удалите ключ в настройках SendGrid и создайте новый с минимальными правами",
+		PatternTwilioKey:     "Смените auth token в консоли Twilio, используйте API-ключи вместо него",
+		PatternGoogleAPIKey:  "Ограничьте ключ по API и доменам в Google Cloud Console или перевыпустите его",
+		PatternRuPassport:    "Удалите паспортные данные или замаскируйте их: 45 ** ******",
+		PatternSNILS:         "Храните СНИЛС только в защищённых системах учёта персональных данных",
+		PatternINN:           "ИНН физического лица — персональные данные, уберите его из кода и документов",
+		PatternBulkEmails:    "Похоже на выгрузку пользователей: удалите файл или обезличьте адреса, проверьте, кому он был доступен",
+		PatternBulkPhones:    "Похоже на выгрузку клиентов: удалите файл или обезличьте номера, проверьте, кому он был доступен",
+		PatternGeoMetadata:   "Удалите метаданные перед публикацией: exiftool -all= photo.jpg",
+		PatternImageMetadata: "Очистите поля автора и серийные номера: exiftool -all= photo.jpg",
+
+		PatternDockerfileSecret: "Передавайте секреты при сборке через RUN --mount=type=secret, а при запуске — через переменные окружения",
+		PatternComposeSecret:    "Перенесите значение в файл .env вне репозитория или в secrets: и ссылайтесь на него как ${NAME}",
+		PatternK8sSecret:        "Не храните Secret в репозитории: используйте Sealed Secrets, External Secrets или valueFrom.secretKeyRef",
 	}
 
 	if suggestion, ok := suggestions[pattern]; ok {
@@ -635,7 +693,7 @@ func (la *LocalAnalyzer) getSuggestionForPattern(pattern PatternType) string {
 }
 
 // getAIInsights gets AI-powered insights from Ollama
-func (la *LocalAnalyzer) getAIInsights(result *ScanResult, stats *AnalysisStatistics) (string, error) {
+func (la *LocalAnalyzer) getAIInsights(ctx context.Context, result *ScanResult, stats *AnalysisStatistics) (string, error) {
 	// Build prompt
 	prompt := la.buildAnalysisPrompt(result, stats)
 
@@ -643,36 +701,234 @@ func (la *LocalAnalyzer) getAIInsights(result *ScanResult, stats *AnalysisStatis
 	reqBody := map[string]interface{}{
 		"model":  la.model,
 		"prompt": prompt,
-		"stream": false,
 		"options": map[string]interface{}{
 			"temperature": 0.3,
 			"num_predict": 500,
 		},
 	}
+	return la.generate(ctx, "", reqBody)
+}
 
-	jsonBody, _ := json.Marshal(reqBody)
-
-	resp, err := la.httpClient.Post(
-		la.ollamaURL+"/api/generate",
-		"application/json",
-		bytes.NewReader(jsonBody),
-	)
+// generate streams a response of /api/generate and joins its chunks,
+// reporting them to onProgress. On an error the text received so far is
+// returned with it
+func (la *LocalAnalyzer) generate(ctx context.Context, filePath string, reqBody map[string]interface{}) (string, error) {
+	reqBody["stream"] = true
+	jsonBody, err := json.Marshal(reqBody)
 	if err != nil {
 		return "", err
 	}
+	model, _ := reqBody["model"].(string)
+	resp, err := la.request(ctx, http.MethodPost, "/api/generate", jsonBody)
+	if err != nil {
+		if e, ok := err.(*OllamaError); ok {
+			e.Model = model
+		}
+		return "", err
+	}
 	defer resp.Body.Close()
 
-	body, _ := io.ReadAll(resp.Body)
+	var text strings.Builder
+	lines := bufio.NewScanner(resp.Body)
+	lines.Buffer(make([]byte, 64<<10), 4<<20)
+	for lines.Scan() {
+		var chunk struct {
+			Response string `json:"response"`
+			Done     bool   `json:"done"`
+			Error    string `json:"error"`
+		}
+		if err := json.Unmarshal(lines.Bytes(), &chunk); err != nil {
+			return text.String(), &OllamaError{Kind: ErrOllamaFailed, Model: model, Err: err}
+		}
+		if chunk.Error != "" {
+			return text.String(), &OllamaError{Kind: ErrOllamaFailed, Model: model, Err: errors.New(chunk.Error)}
+		}
+		if chunk.Response != "" {
+			text.WriteString(chunk.Response)
+			la.progress(AIProgress{FilePath: filePath, Chunk: chunk.Response, Text: text.String()})
+		}
+		if chunk.Done {
+			return text.String(), nil
+		}
+	}
+	if err := cancelledAI(ctx); err != nil {
+		return text.String(), err
+	}
+	err = lines.Err()
+	if err == nil {
+		err = io.ErrUnexpectedEOF
+	}
+	return text.String(), &OllamaError{Kind: ErrOllamaUnreachable, Model: model, Err: err}
+}
 
-	var ollamaResp struct {
-		Response string `json:"response"`
+func (la *LocalAnalyzer) progress(p AIProgress) {
+	if la.onProgress == nil {
+		return
 	}
+	la.progressMux.Lock()
+	defer la.progressMux.Unlock()
+	la.onProgress(p)
+}
 
-	if err := json.Unmarshal(body, &ollamaResp); err != nil {
-		return "", err
+// request sends a request to Ollama and returns a response of a success
+// status. A refused connection and a busy server (503, also while a model
+// loads) are retried with backoff
+func (la *LocalAnalyzer) request(ctx context.Context, method, path string, body []byte) (*http.Response, error) {
+	backoff := la.backoff
+	for attempt := 0; ; attempt++ {
+		resp, err := la.send(ctx, method, path, body)
+		if err == nil {
+			return resp, nil
+		}
+		if ctxErr := cancelledAI(ctx); ctxErr != nil {
+			return nil, ctxErr
+		}
+		var dial *net.OpError
+		retry := errors.Is(err, errOllamaBusy) || (errors.As(err, &dial) && dial.Op == "dial")
+		if !retry || attempt >= la.retries {
+			return nil, err
+		}
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return nil, cancelledAI(ctx)
+		}
+		backoff *= 2
+	}
+}
+
+// errOllamaBusy is a 503 response
+var errOllamaBusy = errors.New("сервер занят (503)")
+
+func (la *LocalAnalyzer) send(ctx context.Context, method, path string, body []byte) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, la.ollamaURL+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := la.httpClient.Do(req)
+	if err != nil {
+		return nil, &OllamaError{Kind: ErrOllamaUnreachable, Err: err}
+	}
+	if resp.StatusCode == http.StatusOK {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	var answer struct {
+		Error string `json:"error"`
+	}
+	json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&answer)
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, &OllamaError{Kind: ErrOllamaModelNotFound, Err: errors.New(answer.Error)}
+	case resp.StatusCode == http.StatusServiceUnavailable:
+		return nil, &OllamaError{Kind: ErrOllamaUnreachable, Err: errOllamaBusy}
+	case answer.Error != "":
+		return nil, &OllamaError{Kind: ErrOllamaFailed, Err: fmt.Errorf("%d: %s", resp.StatusCode, answer.Error)}
+	}
+	return nil, &OllamaError{Kind: ErrOllamaFailed, Err: fmt.Errorf("%d %s", resp.StatusCode, http.StatusText(resp.StatusCode))}
+}
+
+// ExplainFinding asks the model why a finding is risky and how to fix it in
+// its kind of file. Answers are cached by Finding.Fingerprint, so asking
+// again sends no request. Without AI the rule-based suggestion for the
+// pattern is returned at once; when Ollama fails it is returned with the
+// error
+func (la *LocalAnalyzer) ExplainFinding(ctx context.Context, f *Finding) (string, error) {
+	suggestion := la.getSuggestionForPattern(f.PatternType)
+	if !la.enabled {
+		return suggestion, nil
+	}
+	key := f.Fingerprint()
+	la.explainMu.Lock()
+	explanation, ok := la.explanations[key]
+	la.explainMu.Unlock()
+	if ok {
+		return explanation, nil
 	}
 
-	return ollamaResp.Response, nil
+	reqBody := map[string]interface{}{
+		"model":  la.model,
+		"prompt": la.buildExplainPrompt(f),
+		"options": map[string]interface{}{
+			"temperature": 0.2,
+			"num_predict": 300,
+		},
+	}
+	explanation, err := la.generate(ctx, f.FilePath, reqBody)
+	if err != nil {
+		return suggestion, err
+	}
+	explanation = strings.TrimSpace(explanation)
+	if explanation == "" {
+		return suggestion, nil
+	}
+	la.explainMu.Lock()
+	if la.explanations == nil {
+		la.explanations = make(map[string]string)
+	}
+	la.explanations[key] = explanation
+	la.explainMu.Unlock()
+	return explanation, nil
+}
+
+// ExplainTopFindings sets the Explanation of the n riskiest critical
+// findings kept in memory and returns how many were explained. It stops at
+// the first error, keeping the explanations made before
+func (la *LocalAnalyzer) ExplainTopFindings(ctx context.Context, result *ScanResult, n int) (int, error) {
+	var critical []*Finding
+	for _, f := range result.Findings {
+		if f.Severity == Critical {
+			critical = append(critical, f)
+		}
+	}
+	sort.SliceStable(critical, func(i, j int) bool { return critical[i].RiskScore > critical[j].RiskScore })
+	if len(critical) > n {
+		critical = critical[:n]
+	}
+	explained := 0
+	for _, f := range critical {
+		explanation, err := la.ExplainFinding(ctx, f)
+		if err != nil {
+			return explained, err
+		}
+		f.Explanation = explanation
+		explained++
+	}
+	return explained, nil
+}
+
+// explainContextLength is the longest context line sent to the model
+const explainContextLength = 300
+
+// buildExplainPrompt builds the prompt of ExplainFinding. The match is
+// masked; the file name tells the model what kind of file to fix
+func (la *LocalAnalyzer) buildExplainPrompt(f *Finding) string {
+	masked := *f
+	truncateContext(&masked, explainContextLength)
+	maskMatch(&masked)
+
+	var sb strings.Builder
+	sb.WriteString("Ты эксперт по безопасности. Объясни на русском языке, почему эта находка опасна и как исправить её именно в таком файле. ")
+	sb.WriteString("Будь кратким: 3-5 предложений, не повторяй значение секрета.\n\n")
+	sb.WriteString(fmt.Sprintf("Тип находки: %s (%s)\n", patternTypeToRussian(f.PatternType), f.PatternType))
+	sb.WriteString(fmt.Sprintf("Серьёзность: %s\n", severityToRussian(f.Severity)))
+	sb.WriteString(fmt.Sprintf("Описание: %s\n", descriptionToRussian(f.Description)))
+	sb.WriteString(fmt.Sprintf("Файл: %s\n", filepath.Base(f.FilePath)))
+	if ext := strings.ToLower(filepath.Ext(f.FilePath)); ext != "" {
+		sb.WriteString(fmt.Sprintf("Расширение: %s\n", ext))
+	}
+	sb.WriteString(fmt.Sprintf("Совпадение (замаскировано): %s\n", masked.MatchedText))
+	if line := strings.TrimSpace(masked.Context); line != "" {
+		sb.WriteString(fmt.Sprintf("Строка %d: %s\n", f.LineNumber, line))
+	}
+	return sb.String()
 }
 
 // buildAnalysisPrompt builds the prompt for AI analysis
@@ -683,6 +939,7 @@ func (la *LocalAnalyzer) buildAnalysisPrompt(result *ScanResult, stats *Analysis
 
 	sb.WriteString("Статистика:\n")
 	sb.WriteString(fmt.Sprintf("- Всего находок: %d\n", stats.TotalFindings))
+	sb.WriteString(fmt.Sprintf("- Уникальных секретов: %d\n", stats.DistinctSecrets))
 	sb.WriteString(fmt.Sprintf("- Критических: %d\n", stats.SeverityDistribution[string(Critical)]))
 	sb.WriteString(fmt.Sprintf("- Высоких: %d\n", stats.SeverityDistribution[string(High)]))
 	sb.WriteString(fmt.Sprintf("- Средних: %d\n", stats.SeverityDistribution[string(Medium)]))
@@ -694,14 +951,22 @@ func (la *LocalAnalyzer) buildAnalysisPrompt(result *ScanResult, stats *Analysis
 		sb.WriteString(fmt.Sprintf("- %s: %d\n", pattern, count))
 	}
 
+	if len(stats.RepeatedSecrets) > 0 {
+		sb.WriteString("\nПовторяющиеся секреты:\n")
+		for _, group := range stats.RepeatedSecrets {
+			sb.WriteString(fmt.Sprintf("- %s: %d раз в %d файлах\n", group.PatternType, len(group.Occurrences), group.Files))
+		}
+	}
+
 	sb.WriteString("\nПримеры критических находок (первые 5):\n")
 	shown := 0
-	for _, f := range result.Findings {
-		if f.Severity == Critical && shown < 5 {
+	result.ForEachFinding(func(f *Finding) bool {
+		if f.Severity == Critical {
 			sb.WriteString(fmt.Sprintf("- %s:%d - %s\n", f.FilePath, f.LineNumber, f.Description))
 			shown++
 		}
-	}
+		return shown < 5
+	})
 
 	sb.WriteString("\nДай 3-5 конкретных рекомендаций по устранению, учитывая специфику найденных проблем.")
 
@@ -0,0 +1,321 @@
+package searcher
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/kacebover/password-finder/fsutil"
+)
+
+// Default limits of nested archive extraction
+const (
+	DefaultMaxArchiveDepth  = 3
+	DefaultMaxExtractBudget = 500 * 1024 * 1024 // 500MB
+)
+
+// ArchiveSeparator joins an archive and the path of a member inside it, as
+// in "outer.zip!inner.tar.gz!creds/.env"
+const ArchiveSeparator = "!"
+
+// SetMaxArchiveDepth sets how many levels of archives are opened; the
+// scanned archive itself is level 1. Deeper archives are skipped
+func (de *DocumentExtractor) SetMaxArchiveDepth(depth int) {
+	de.maxArchiveDepth = depth
+}
+
+// SetMaxExtractBudget sets how many uncompressed bytes one archive and all
+// the archives inside it may take. Going over it aborts the archive with
+// ErrArchiveLimit
+func (de *DocumentExtractor) SetMaxExtractBudget(size int64) {
+	de.maxExtractBudget = size
+}
+
+// ArchiveEntry is a text file read from an archive or a nested archive
+type ArchiveEntry struct {
+	Path       string // Archive path and the member chain, see ArchiveSeparator
+	Name       string // Path inside the innermost archive
+	Text       string
+	SkipReason SkipReason // Set instead of Text when the member was not read
+}
+
+// SplitArchivePath splits the path of an archive member, such as
+// "backup.zip!config/.env", into the archive on disk and the path inside
+// it. The separator only counts after an archive name, so a directory
+// named "a!b" is not split. A path outside an archive is returned as
+// archive with no inner path
+func SplitArchivePath(path string) (archive, inner string) {
+	for i := 0; ; {
+		j := strings.Index(path[i:], ArchiveSeparator)
+		if j < 0 {
+			return path, ""
+		}
+		i += j
+		if archiveKind(path[:i]) != "" {
+			return path[:i], path[i+len(ArchiveSeparator):]
+		}
+		i += len(ArchiveSeparator)
+	}
+}
+
+// FormatArchivePath shows an archive and the path of a member inside it
+// as "backup.zip → inner.tar.gz → config/.env"
+func FormatArchivePath(archive, inner string) string {
+	if inner == "" {
+		return archive
+	}
+	return archive + " → " + strings.ReplaceAll(inner, ArchiveSeparator, " → ")
+}
+
+// archiveSource is an open archive: a file or a nested archive in memory
+type archiveSource interface {
+	io.Reader
+	io.ReaderAt
+}
+
+// archiveWalk reads the members of one archive and its nested archives
+type archiveWalk struct {
+	ctx    context.Context
+	de     *DocumentExtractor
+	budget int64 // Uncompressed bytes left for the whole tree
+	nested int   // Nested archives opened
+	visit  func(ArchiveEntry) error
+}
+
+// ExtractEntries calls visit for every text member of a ZIP, TAR or gzip
+// archive and of the archives inside it, in archive order. Members that are
+// not read, such as encrypted ones, come with a SkipReason. An error of
+// visit stops the walk and is returned
+func (de *DocumentExtractor) ExtractEntries(filePath string, visit func(ArchiveEntry) error) error {
+	return de.ExtractEntriesContext(context.Background(), filePath, visit)
+}
+
+// ExtractEntriesContext is ExtractEntries stopping with ErrCancelled once
+// ctx is done
+func (de *DocumentExtractor) ExtractEntriesContext(ctx context.Context, filePath string, visit func(ArchiveEntry) error) error {
+	_, err := de.walkArchive(ctx, filePath, visit)
+	return err
+}
+
+// walkArchive visits the text members of an archive and, down to the
+// maximum depth, of the archives inside it. It returns the number of nested
+// archives opened
+func (de *DocumentExtractor) walkArchive(ctx context.Context, filePath string, visit func(ArchiveEntry) error) (int, error) {
+	kind := archiveKind(filePath)
+	if kind == "" {
+		return 0, &ErrUnsupportedFormat{Ext: strings.ToLower(filepath.Ext(filePath))}
+	}
+	f, err := fsutil.Open(filePath)
+	if err != nil {
+		return 0, pathError(err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return 0, pathError(err)
+	}
+
+	w := &archiveWalk{ctx: ctx, de: de, budget: de.maxExtractBudget, visit: visit}
+	err = w.walk(kind, f, info.Size(), filePath, 1)
+	return w.nested, err
+}
+
+// archiveKind returns the archive format of a file name, or "" for other files
+func archiveKind(name string) string {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		return "zip"
+	case strings.HasSuffix(lower, ".tar"):
+		return "tar"
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return "tgz"
+	case strings.HasSuffix(lower, ".gz"):
+		return "gz"
+	}
+	return ""
+}
+
+// walk reads the members of an archive at the given level
+func (w *archiveWalk) walk(kind string, src archiveSource, size int64, chain string, depth int) error {
+	switch kind {
+	case "zip":
+		zr, err := zip.NewReader(src, size)
+		if err != nil {
+			return err
+		}
+		return w.walkZIP(zr, chain, depth)
+	case "tar":
+		return w.walkTAR(tar.NewReader(src), chain, depth)
+	}
+
+	gr, err := gzip.NewReader(src)
+	if err != nil {
+		return err
+	}
+	defer gr.Close()
+	if kind == "tgz" {
+		return w.walkTAR(tar.NewReader(gr), chain, depth)
+	}
+	// A plain .gz holds one file named after the archive
+	outer := chain[strings.LastIndex(chain, ArchiveSeparator)+1:]
+	name := strings.TrimSuffix(path.Base(filepath.ToSlash(outer)), filepath.Ext(outer))
+	return w.read(name, gr, -1, chain, depth)
+}
+
+func (w *archiveWalk) walkZIP(zr *zip.Reader, chain string, depth int) error {
+	for _, f := range zr.File {
+		if err := cancelledError(w.ctx); err != nil {
+			return err
+		}
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		// Bit 0 of Flags marks an encrypted member
+		if f.Flags&0x1 != 0 {
+			if err := w.skip(chain, f.Name, skipReason("skip.encrypted")); err != nil {
+				return err
+			}
+			continue
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			continue
+		}
+		err = w.member(f.Name, rc, int64(f.UncompressedSize64), chain, depth)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *archiveWalk) walkTAR(tr *tar.Reader, chain string, depth int) error {
+	for {
+		if err := cancelledError(w.ctx); err != nil {
+			return err
+		}
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := w.member(header.Name, tr, header.Size, chain, depth); err != nil {
+			return err
+		}
+	}
+}
+
+// member reads the text files, DOCX documents and archives of an archive
+// and ignores other members
+func (w *archiveWalk) member(name string, r io.Reader, size int64, chain string, depth int) error {
+	ext := strings.ToLower(filepath.Ext(name))
+	if archiveKind(name) == "" && ext != ".docx" && !isTextExtension(ext) {
+		return nil
+	}
+	return w.read(name, r, size, chain, depth)
+}
+
+// read visits a member as text or a DOCX document, or walks it when it is
+// an archive and the depth allows. size is -1 when the format does not
+// store it
+func (w *archiveWalk) read(name string, r io.Reader, size int64, chain string, depth int) error {
+	kind := archiveKind(name)
+	ext := strings.ToLower(filepath.Ext(name))
+	if kind != "" && depth >= w.de.maxArchiveDepth {
+		return w.skip(chain, name, skipReason("skip.archive_depth", w.de.maxArchiveDepth))
+	}
+	if size > w.de.maxFileSize {
+		return w.skip(chain, name, skipReason("skip.too_large"))
+	}
+	// The declared size already breaks the budget; an archive bomb is
+	// rejected before it is inflated
+	if size > w.budget {
+		return w.limitError()
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, w.readLimit()+1))
+	w.budget -= int64(len(data))
+	if w.budget < 0 {
+		return w.limitError()
+	}
+	if int64(len(data)) > w.de.maxFileSize {
+		return w.skip(chain, name, skipReason("skip.too_large"))
+	}
+	if err != nil {
+		return w.skip(chain, name, skipReason("skip.read_error", err.Error()))
+	}
+
+	memberPath := chain + ArchiveSeparator + name
+	switch {
+	case kind != "":
+		w.nested++
+		err := w.walk(kind, bytes.NewReader(data), int64(len(data)), memberPath, depth+1)
+		if err != nil && !errors.Is(err, ErrArchiveLimit) && !errors.Is(err, ErrCancelled) {
+			return w.skip(chain, name, skipReason("skip.bad_archive", err.Error()))
+		}
+		return err
+	case ext == ".docx":
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return w.skip(chain, name, skipReason("skip.bad_document", err.Error()))
+		}
+		return w.visit(ArchiveEntry{Path: memberPath, Name: name, Text: w.de.docxText(zr.File)})
+	}
+	return w.visit(ArchiveEntry{Path: memberPath, Name: name, Text: string(data)})
+}
+
+// readLimit is the most a member may inflate to: the smaller of the file
+// size limit and the budget left
+func (w *archiveWalk) readLimit() int64 {
+	if w.de.maxFileSize < w.budget {
+		return w.de.maxFileSize
+	}
+	return w.budget
+}
+
+func (w *archiveWalk) skip(chain, name string, reason SkipReason) error {
+	return w.visit(ArchiveEntry{Path: chain + ArchiveSeparator + name, Name: name, SkipReason: reason})
+}
+
+func (w *archiveWalk) limitError() error {
+	return fmt.Errorf("%w: распакованный размер больше %d байт", ErrArchiveLimit, w.de.maxExtractBudget)
+}
+
+// extractArchive joins the text members of an archive under "=== name ==="
+// headers; skipped members are listed with the reason
+func (de *DocumentExtractor) extractArchive(ctx context.Context, filePath, format string) (*ExtractedContent, error) {
+	var texts []string
+	_, err := de.walkArchive(ctx, filePath, func(m ArchiveEntry) error {
+		inner := strings.TrimPrefix(m.Path, filePath+ArchiveSeparator)
+		if !m.SkipReason.IsZero() {
+			texts = append(texts, fmt.Sprintf("[Пропущен файл: %s — %s]", inner, m.SkipReason))
+			return nil
+		}
+		texts = append(texts, fmt.Sprintf("=== %s ===\n%s", inner, m.Text))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ExtractedContent{
+		SourceFile: filePath,
+		Format:     format,
+		Text:       strings.Join(texts, "\n\n"),
+	}, nil
+}
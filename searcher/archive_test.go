@@ -0,0 +1,223 @@
+package searcher
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// zipBytes builds a ZIP archive from name -> content
+func zipBytes(t *testing.T, files map[string][]byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(content)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// tarGzBytes builds a gzipped TAR archive from name -> content
+func tarGzBytes(t *testing.T, files map[string][]byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	for name, content := range files {
+		tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg})
+		tw.Write(content)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	gw.Close()
+	return buf.Bytes()
+}
+
+func gzipBytes(content []byte) []byte {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	gw.Write(content)
+	gw.Close()
+	return buf.Bytes()
+}
+
+func TestScanNestedArchives(t *testing.T) {
+	dir := t.TempDir()
+	secret := []byte("password = NestedSecret123!\n")
+	// outer.zip (level 1) -> inner.tar.gz (2) -> deep.zip (3) -> creds/.env;
+	// deeper.zip would be level 4
+	deep := zipBytes(t, map[string][]byte{
+		"creds/.env": secret,
+		"deeper.zip": zipBytes(t, map[string][]byte{"too-deep.env": secret}),
+	})
+	inner := tarGzBytes(t, map[string][]byte{"deep.zip": deep, "notes.txt": []byte("nothing here\n")})
+	outer := filepath.Join(dir, "outer.zip")
+	os.WriteFile(outer, zipBytes(t, map[string][]byte{"inner.tar.gz": inner}), 0644)
+
+	scanner := NewScanner()
+	scanner.SetDocumentExtractor(NewDocumentExtractor(false))
+	scanner.SetScanArchives(true)
+	result, err := scanner.Scan(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := outer + "!inner.tar.gz!deep.zip!creds/.env"
+	found := false
+	for _, f := range result.Findings {
+		if strings.Contains(f.FilePath, "too-deep") {
+			t.Errorf("archive below the depth limit was scanned: %s", f.FilePath)
+		}
+		if f.FilePath == want && strings.Contains(f.MatchedText, "NestedSecret123") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("no finding at %s: %+v", want, result.Findings)
+	}
+	if result.NestedArchivesScanned != 2 {
+		t.Errorf("NestedArchivesScanned = %d, want 2", result.NestedArchivesScanned)
+	}
+	if reason := result.SkipReasons[outer+"!inner.tar.gz!deep.zip!deeper.zip"]; reason.Key != "skip.archive_depth" {
+		t.Errorf("skip reason of the too deep archive = %q", reason)
+	}
+	if result.FilesScanned != 1 {
+		t.Errorf("FilesScanned = %d, want the outer archive", result.FilesScanned)
+	}
+}
+
+func TestArchiveBombRejected(t *testing.T) {
+	dir := t.TempDir()
+	zeros := bytes.Repeat([]byte{0}, 4<<20)
+	// The ZIP member declares its size, the gzipped one does not
+	zipBomb := filepath.Join(dir, "bomb.zip")
+	os.WriteFile(zipBomb, zipBytes(t, map[string][]byte{
+		"a.env":     []byte("password = BeforeBomb123!\n"),
+		"inner.zip": zipBytes(t, map[string][]byte{"zeros.txt": zeros}),
+	}), 0644)
+	gzBomb := filepath.Join(dir, "zeros.txt.gz")
+	os.WriteFile(gzBomb, gzipBytes(zeros), 0644)
+
+	de := NewDocumentExtractor(false)
+	de.SetMaxExtractBudget(1 << 20)
+	for _, path := range []string{zipBomb, gzBomb} {
+		if _, err := de.ExtractText(path); !errors.Is(err, ErrArchiveLimit) {
+			t.Errorf("ExtractText(%s) error = %v, want ErrArchiveLimit", filepath.Base(path), err)
+		}
+	}
+
+	scanner := NewScanner()
+	scanner.SetDocumentExtractor(de)
+	scanner.SetScanArchives(true)
+	result, err := scanner.Scan(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{zipBomb, gzBomb} {
+		if !strings.Contains(result.SkipReasons[path].Text, "лимит распаковки") {
+			t.Errorf("skip reason of %s = %q", filepath.Base(path), result.SkipReasons[path])
+		}
+	}
+	if result.FilesSkipped != 2 || result.FilesScanned != 0 {
+		t.Errorf("scanned %d, skipped %d, want both archives skipped", result.FilesScanned, result.FilesSkipped)
+	}
+}
+
+// TestArchiveMemberFindings tests findings in archive members name the
+// member and count lines within it, and an encrypted member is skipped by
+// itself
+func TestArchiveMemberFindings(t *testing.T) {
+	dir := t.TempDir()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, member := range []struct {
+		name    string
+		content string
+		flags   uint16
+	}{
+		{"config/.env", "# settings\nDEBUG=false\npassword = FirstSecret123!\n", 0},
+		{"app/settings.txt", "one\ntwo\nthree\nfour\npassword = SecondSecret456!\n", 0},
+		// Bit 0 marks an encrypted member
+		{"private/keys.txt", "password = Hidden789!\n", 0x1},
+	} {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: member.name, Method: zip.Store, Flags: member.flags})
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(member.content))
+	}
+	zw.Close()
+	backup := filepath.Join(dir, "backup.zip")
+	os.WriteFile(backup, buf.Bytes(), 0644)
+
+	var entries []string
+	err := NewDocumentExtractor(false).ExtractEntries(backup, func(entry ArchiveEntry) error {
+		entries = append(entries, entry.Name+":"+entry.SkipReason.String())
+		return nil
+	})
+	if err != nil || strings.Join(entries, ",") != "config/.env:,app/settings.txt:,private/keys.txt:зашифрованный файл" {
+		t.Errorf("ExtractEntries = %v, %v", entries, err)
+	}
+
+	scanner := NewScanner()
+	scanner.SetDocumentExtractor(NewDocumentExtractor(false))
+	scanner.SetScanArchives(true)
+	result, err := scanner.Scan(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]int{"config/.env": 3, "app/settings.txt": 5}
+	for _, f := range result.Findings {
+		if f.ArchivePath != backup || f.FilePath != backup+ArchiveSeparator+f.InnerPath {
+			t.Errorf("finding at %s: archive %q, inner %q", f.FilePath, f.ArchivePath, f.InnerPath)
+			continue
+		}
+		if line, ok := want[f.InnerPath]; !ok || f.LineNumber != line {
+			t.Errorf("%s:%d, want line %d", f.InnerPath, f.LineNumber, line)
+		}
+		delete(want, f.InnerPath)
+	}
+	if len(want) != 0 {
+		t.Errorf("no findings in %v: %+v", want, result.Findings)
+	}
+	if reason := result.SkipReasons[backup+ArchiveSeparator+"private/keys.txt"]; reason.Key != "skip.encrypted" {
+		t.Errorf("skip reason of the encrypted member = %q", reason)
+	}
+	if _, ok := result.SkipReasons[backup]; ok {
+		t.Error("the archive itself should not be skipped")
+	}
+}
+
+func TestSplitArchivePath(t *testing.T) {
+	tests := []struct {
+		path, archive, inner string
+	}{
+		{"/data/backup.zip!config/.env", "/data/backup.zip", "config/.env"},
+		{"/data/outer.zip!inner.tar.gz!creds/.env", "/data/outer.zip", "inner.tar.gz!creds/.env"},
+		{"/data/wow!/backup.tgz!a.txt", "/data/wow!/backup.tgz", "a.txt"},
+		{"/data/wow!/notes.txt", "/data/wow!/notes.txt", ""},
+	}
+	for _, tt := range tests {
+		if archive, inner := SplitArchivePath(tt.path); archive != tt.archive || inner != tt.inner {
+			t.Errorf("SplitArchivePath(%q) = %q, %q", tt.path, archive, inner)
+		}
+	}
+	if got := FormatArchivePath("outer.zip", "inner.tar.gz!creds/.env"); got != "outer.zip → inner.tar.gz → creds/.env" {
+		t.Errorf("FormatArchivePath = %q", got)
+	}
+}
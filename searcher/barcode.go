@@ -0,0 +1,469 @@
+package searcher
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"math"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Barcode types read from images
+const (
+	BarcodeQR      = "QR"
+	BarcodeCode128 = "Code128"
+	BarcodePDF417  = "PDF417"
+)
+
+// Barcode is a barcode decoded from an image
+type Barcode struct {
+	Type    string `json:"type"`
+	Payload string `json:"payload"`
+}
+
+// DecodeBarcodes reads the QR codes, PDF417 symbols and Code 128 barcodes
+// of an image. QR codes are found in any orientation; PDF417 and Code 128
+// are read along the rows, vertical ones are found by the rotations of the
+// image analyzer. The perspective of photos taken at an angle is not
+// corrected, so flat images such as scans and screenshots are read best
+func DecodeBarcodes(img image.Image) []Barcode {
+	if img == nil || img.Bounds().Empty() {
+		return nil
+	}
+	m := binarize(img)
+	var codes []Barcode
+	seen := make(map[Barcode]bool)
+	add := func(code Barcode) {
+		if !seen[code] {
+			seen[code] = true
+			codes = append(codes, code)
+		}
+	}
+	for _, payload := range decodeQRCodes(m) {
+		add(Barcode{Type: BarcodeQR, Payload: payload})
+	}
+	for _, payload := range decodePDF417(m) {
+		add(Barcode{Type: BarcodePDF417, Payload: payload})
+	}
+	for _, payload := range decodeCode128(m) {
+		add(Barcode{Type: BarcodeCode128, Payload: payload})
+	}
+	return codes
+}
+
+// bitMatrix is a binarized image, true is dark
+type bitMatrix struct {
+	width, height int
+	bits          []bool
+}
+
+func (m *bitMatrix) get(x, y int) bool {
+	if x < 0 || y < 0 || x >= m.width || y >= m.height {
+		return false
+	}
+	return m.bits[y*m.width+x]
+}
+
+func (m *bitMatrix) contains(x, y int) bool {
+	return x >= 0 && y >= 0 && x < m.width && y < m.height
+}
+
+// bitRun is a run of pixels of one color along a row
+type bitRun struct {
+	start, length int
+	dark          bool
+}
+
+func (m *bitMatrix) rowRuns(y int) []bitRun {
+	var runs []bitRun
+	row := m.bits[y*m.width : (y+1)*m.width]
+	for x := 0; x < m.width; {
+		start := x
+		for x < m.width && row[x] == row[start] {
+			x++
+		}
+		runs = append(runs, bitRun{start: start, length: x - start, dark: row[start]})
+	}
+	return runs
+}
+
+// binarizeBlock is the side of the blocks the threshold is computed for
+const binarizeBlock = 32
+
+// binarize thresholds an image by the range of the surrounding blocks, so
+// uneven lighting does not hide codes; blocks of one color use the range
+// of the whole image
+func binarize(img image.Image) *bitMatrix {
+	lum, w, h := luminance(img)
+	bw, bh := (w+binarizeBlock-1)/binarizeBlock, (h+binarizeBlock-1)/binarizeBlock
+	mins, maxs := make([]uint8, bw*bh), make([]uint8, bw*bh)
+	globalMin, globalMax := uint8(255), uint8(0)
+	for by := 0; by < bh; by++ {
+		for bx := 0; bx < bw; bx++ {
+			lo, hi := uint8(255), uint8(0)
+			for y := by * binarizeBlock; y < (by+1)*binarizeBlock && y < h; y++ {
+				for x := bx * binarizeBlock; x < (bx+1)*binarizeBlock && x < w; x++ {
+					v := lum[y*w+x]
+					if v < lo {
+						lo = v
+					}
+					if v > hi {
+						hi = v
+					}
+				}
+			}
+			mins[by*bw+bx], maxs[by*bw+bx] = lo, hi
+			if lo < globalMin {
+				globalMin = lo
+			}
+			if hi > globalMax {
+				globalMax = hi
+			}
+		}
+	}
+	global := (int(globalMin) + int(globalMax)) / 2
+
+	m := &bitMatrix{width: w, height: h, bits: make([]bool, w*h)}
+	for by := 0; by < bh; by++ {
+		for bx := 0; bx < bw; bx++ {
+			lo, hi := 255, 0
+			for ny := by - 1; ny <= by+1; ny++ {
+				for nx := bx - 1; nx <= bx+1; nx++ {
+					if nx < 0 || ny < 0 || nx >= bw || ny >= bh {
+						continue
+					}
+					if v := int(mins[ny*bw+nx]); v < lo {
+						lo = v
+					}
+					hi = max(hi, int(maxs[ny*bw+nx]))
+				}
+			}
+			threshold := global
+			if hi-lo > 48 {
+				threshold = (lo + hi) / 2
+			}
+			for y := by * binarizeBlock; y < (by+1)*binarizeBlock && y < h; y++ {
+				for x := bx * binarizeBlock; x < (bx+1)*binarizeBlock && x < w; x++ {
+					m.bits[y*w+x] = int(lum[y*w+x]) <= threshold
+				}
+			}
+		}
+	}
+	return m
+}
+
+// luminance returns the gray levels of an image, reading the common
+// decoded formats directly
+func luminance(img image.Image) ([]uint8, int, int) {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	lum := make([]uint8, w*h)
+	switch src := img.(type) {
+	case *image.Gray:
+		for y := 0; y < h; y++ {
+			copy(lum[y*w:(y+1)*w], src.Pix[y*src.Stride:])
+		}
+	case *image.YCbCr:
+		for y := 0; y < h; y++ {
+			copy(lum[y*w:(y+1)*w], src.Y[y*src.YStride:])
+		}
+	case *image.RGBA:
+		for y := 0; y < h; y++ {
+			row := src.Pix[y*src.Stride:]
+			for x := 0; x < w; x++ {
+				lum[y*w+x] = grayLevel(row[4*x], row[4*x+1], row[4*x+2], row[4*x+3])
+			}
+		}
+	case *image.NRGBA:
+		for y := 0; y < h; y++ {
+			row := src.Pix[y*src.Stride:]
+			for x := 0; x < w; x++ {
+				lum[y*w+x] = grayLevel(row[4*x], row[4*x+1], row[4*x+2], 255)
+			}
+		}
+	default:
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				lum[y*w+x] = color.GrayModel.Convert(img.At(b.Min.X+x, b.Min.Y+y)).(color.Gray).Y
+			}
+		}
+	}
+	return lum, w, h
+}
+
+// grayLevel converts a pixel to gray; transparent pixels are white
+func grayLevel(r, g, b, a uint8) uint8 {
+	y := (299*int(r) + 587*int(g) + 114*int(b)) / 1000
+	return uint8(y*int(a)/255 + 255 - int(a))
+}
+
+// Code 128 symbols by value as bar and space widths in modules. 103-105
+// start the code sets A, B and C; the stop symbol 106 has a final bar of
+// two modules after these widths
+var code128Patterns = [107]string{
+	"212222", "222122", "222221", "121223", "121322", "131222", "122213", "122312", "132212", "221213",
+	"221312", "231212", "112232", "122132", "122231", "113222", "123122", "123221", "223211", "221132",
+	"221231", "213212", "223112", "312131", "311222", "321122", "321221", "312212", "322112", "322211",
+	"212123", "212321", "232121", "111323", "131123", "131321", "112313", "132113", "132311", "211313",
+	"231113", "231311", "112133", "112331", "132131", "113123", "113321", "133121", "313121", "211331",
+	"231131", "213113", "213311", "213131", "311123", "311321", "331121", "312113", "312311", "332111",
+	"314111", "221411", "431111", "111224", "111422", "121124", "121421", "141122", "141221", "112214",
+	"112412", "122114", "122411", "142112", "142211", "241211", "221114", "413111", "241112", "134111",
+	"111242", "121142", "121241", "114212", "124112", "124211", "411212", "421112", "421211", "212141",
+	"214121", "412121", "111143", "111341", "131141", "114113", "114311", "411113", "411311", "113141",
+	"114131", "311141", "411131", "211412", "211214", "211232", "233111",
+}
+
+// Special Code 128 values
+const (
+	code128Shift  = 98
+	code128CodeC  = 99
+	code128CodeB  = 100
+	code128CodeA  = 101
+	code128StartA = 103
+	code128StartC = 105
+	code128Stop   = 106
+)
+
+// code128Rows is how many rows of the image are scanned for Code 128
+const code128Rows = 24
+
+// decodeCode128 reads the Code 128 barcodes crossed by evenly spaced rows
+func decodeCode128(m *bitMatrix) []string {
+	var found []string
+	seen := make(map[string]bool)
+	for i := 1; i <= code128Rows; i++ {
+		y := m.height * i / (code128Rows + 1)
+		if y >= m.height {
+			continue
+		}
+		// Upside down codes are read from the right
+		runs := m.rowRuns(y)
+		reversed := make([]bitRun, len(runs))
+		for k, r := range runs {
+			reversed[len(runs)-1-k] = r
+		}
+		for _, text := range append(scanCode128Row(runs), scanCode128Row(reversed)...) {
+			if !seen[text] {
+				seen[text] = true
+				found = append(found, text)
+			}
+		}
+	}
+	return found
+}
+
+// scanCode128Row reads the codes along the runs of a row
+func scanCode128Row(runs []bitRun) []string {
+	var found []string
+	for s := 0; s+6 <= len(runs); s++ {
+		if !runs[s].dark {
+			continue
+		}
+		if v, ok := code128Symbol(runs[s : s+6]); !ok || v < code128StartA || v > code128StartC {
+			continue
+		}
+		if text, used, ok := readCode128(runs[s:]); ok {
+			found = append(found, text)
+			s += used - 1
+		}
+	}
+	return found
+}
+
+// code128Symbol matches six runs against the symbols; widths may be off by
+// a quarter of a module on average
+func code128Symbol(runs []bitRun) (int, bool) {
+	total := 0
+	for _, r := range runs {
+		total += r.length
+	}
+	if total < 11 {
+		return 0, false
+	}
+	module := float64(total) / 11
+	best, bestVariance := -1, 0.25
+	for value, pattern := range code128Patterns {
+		variance := 0.0
+		for i, r := range runs {
+			variance += math.Abs(float64(r.length) - float64(pattern[i]-'0')*module)
+		}
+		variance /= float64(total)
+		if variance < bestVariance {
+			best, bestVariance = value, variance
+		}
+	}
+	return best, best >= 0
+}
+
+// readCode128 decodes the symbols from a start symbol to the stop symbol
+// and checks the checksum; it returns the text and the runs read
+func readCode128(runs []bitRun) (string, int, bool) {
+	var values []int
+	used := 0
+	for used+6 <= len(runs) {
+		v, ok := code128Symbol(runs[used : used+6])
+		if !ok {
+			return "", 0, false
+		}
+		values = append(values, v)
+		used += 6
+		if v == code128Stop {
+			break
+		}
+	}
+	if len(values) < 4 || values[len(values)-1] != code128Stop {
+		return "", 0, false
+	}
+	values = values[:len(values)-1]
+	sum := values[0]
+	for i := 1; i < len(values)-1; i++ {
+		if values[i] >= code128StartA {
+			return "", 0, false
+		}
+		sum += i * values[i]
+	}
+	if sum%103 != values[len(values)-1] {
+		return "", 0, false
+	}
+
+	var text strings.Builder
+	set := values[0] - code128StartA // 0 is A, 1 is B, 2 is C
+	shifted := -1
+	for _, v := range values[1 : len(values)-1] {
+		current := set
+		if shifted >= 0 {
+			current, shifted = shifted, -1
+		}
+		if current == 2 {
+			switch {
+			case v < 100:
+				text.WriteByte(byte('0' + v/10))
+				text.WriteByte(byte('0' + v%10))
+			case v == code128CodeB:
+				set = 1
+			case v == code128CodeA:
+				set = 0
+			}
+			continue
+		}
+		switch {
+		case v < 64 || (current == 1 && v < 96):
+			text.WriteByte(byte(v + 32))
+		case current == 0 && v < 96:
+			text.WriteByte(byte(v - 64))
+		case v == code128Shift:
+			shifted = 1 - current
+		case v == code128CodeC:
+			set = 2
+		case v == code128CodeB && current == 0, v == code128CodeA && current == 1:
+			set = 1 - current
+		}
+		// FNC1-4 carry no text
+	}
+	if text.Len() == 0 {
+		return "", 0, false
+	}
+	return text.String(), used, true
+}
+
+// barcodeText converts decoded bytes, which are UTF-8 or ISO 8859-1
+func barcodeText(data []byte) string {
+	if utf8.Valid(data) {
+		return string(data)
+	}
+	runes := make([]rune, len(data))
+	for i, b := range data {
+		runes[i] = rune(b)
+	}
+	return string(runes)
+}
+
+// AAMVA fields of driver's license barcodes shown in findings
+const (
+	aamvaLicenseNumber = "DAQ"
+	aamvaFamilyName    = "DCS"
+	aamvaFirstName     = "DAC"
+	aamvaBirthDate     = "DBB"
+)
+
+// parseAAMVA returns the fields of an AAMVA driver's license payload by
+// their three letter element IDs, or nil for other payloads
+func parseAAMVA(payload string) map[string]string {
+	if !strings.Contains(payload, "ANSI ") && !strings.Contains(payload, "AAMVA") {
+		return nil
+	}
+	fields := make(map[string]string)
+	for _, line := range strings.FieldsFunc(payload, func(r rune) bool { return r == '\n' || r == '\r' || r == 0x1e }) {
+		// The first element follows the subfile designator of the header
+		if i := strings.Index(line, "DL"+aamvaLicenseNumber); i >= 0 {
+			line = line[i+2:]
+		}
+		if len(line) > 3 && aamvaElementID.MatchString(line[:3]) {
+			if _, ok := fields[line[:3]]; !ok {
+				fields[line[:3]] = strings.TrimSpace(line[3:])
+			}
+		}
+	}
+	if fields[aamvaLicenseNumber] == "" {
+		return nil
+	}
+	return fields
+}
+
+var aamvaElementID = regexp.MustCompile(`^D[A-Z]{2}$`)
+
+// Payloads worth more than a plain barcode
+var (
+	vCardPayload = regexp.MustCompile(`(?i)^(BEGIN:VCARD|MECARD:)`)
+	tokenURL     = regexp.MustCompile(`(?i)https?://\S*[?&#](access_token|api_?key|apikey|auth|key|password|secret|sig|signature|token)=[^&\s]{8,}`)
+)
+
+// barcodeScore rates a decoded payload on the 0-20 scale of BarcodeScore:
+// a driver's license is as strong a signal as the OCR of one, contact
+// cards and links with tokens carry personal data or secrets; any other
+// payload counts as much as a barcode guessed from the OCR text
+func barcodeScore(payload string) float64 {
+	switch {
+	case parseAAMVA(payload) != nil:
+		return 20
+	case vCardPayload.MatchString(payload), tokenURL.MatchString(payload):
+		return 15
+	default:
+		return 10
+	}
+}
+
+// barcodeFindings scans the payloads of the barcodes of an image like text,
+// under the path of the image and the barcode type; an AAMVA payload is also
+// reported as the driver's license it is
+func (s *Scanner) barcodeFindings(ctx context.Context, filePath string, barcodes []Barcode) []*Finding {
+	var findings []*Finding
+	for _, code := range barcodes {
+		source := filePath + " (" + code.Type + ")"
+		if fields := parseAAMVA(code.Payload); fields != nil {
+			findings = append(findings, aamvaFinding(source, fields))
+		}
+		findings = append(findings, s.scanTextContent(ctx, source, code.Payload)...)
+	}
+	return findings
+}
+
+func aamvaFinding(source string, fields map[string]string) *Finding {
+	context := "AAMVA: " + strings.TrimSpace(fields[aamvaFirstName]+" "+fields[aamvaFamilyName])
+	if birth := fields[aamvaBirthDate]; birth != "" {
+		context += ", дата рождения " + birth
+	}
+	return &Finding{
+		FilePath:    source,
+		LineNumber:  1,
+		PatternType: PatternPassport,
+		Severity:    Critical,
+		Description: "Водительское удостоверение в штрихкоде",
+		MatchedText: fields[aamvaLicenseNumber],
+		Context:     context,
+		RiskScore:   90,
+	}
+}
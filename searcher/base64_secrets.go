@@ -0,0 +1,80 @@
+package searcher
+
+import (
+	"encoding/base64"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Base64EncodedSuffix is appended to the description of a match found in
+// the decoded text of a base64 token
+const Base64EncodedSuffix = " (base64-encoded)"
+
+// MaxBase64DecodedSize is the largest decoded token checked for secrets
+const MaxBase64DecodedSize = 64 * 1024
+
+// minBase64Length is the shortest token tried as base64
+const minBase64Length = 16
+
+// base64Encodings are tried in order on each token
+var base64Encodings = []*base64.Encoding{
+	base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding,
+}
+
+// decodeBase64Secrets decodes the base64 tokens of a line, as in Kubernetes
+// Secret manifests, and runs the patterns over the decoded text. Matches
+// span the encoded token in the line and carry the decoded secret
+func decodeBase64Secrets(patterns *Patterns, line string) []*DetectedPattern {
+	var results []*DetectedPattern
+	for _, span := range entropyTokens(line, minBase64Length) {
+		decoded, ok := decodeBase64Token(line[span[0]:span[1]])
+		if !ok {
+			continue
+		}
+		for _, decodedLine := range strings.Split(decoded, "\n") {
+			for _, match := range patterns.FindAll(decodedLine) {
+				match.StartIndex, match.EndIndex = span[0], span[1]
+				match.Description += Base64EncodedSuffix
+				results = append(results, match)
+			}
+		}
+	}
+	return results
+}
+
+// decodeBase64Token decodes a token that is valid base64 of mostly
+// printable text; binary data such as images and hashes is rejected
+func decodeBase64Token(token string) (string, bool) {
+	if base64.StdEncoding.DecodedLen(len(token)) > MaxBase64DecodedSize {
+		return "", false
+	}
+	for _, encoding := range base64Encodings {
+		data, err := encoding.DecodeString(token)
+		if err != nil {
+			continue
+		}
+		if !utf8.Valid(data) {
+			return "", false
+		}
+		text := string(data)
+		return text, isMostlyPrintable(text)
+	}
+	return "", false
+}
+
+// isMostlyPrintable reports whether at least 95% of the runes of s are
+// printable or whitespace and there are no NUL bytes
+func isMostlyPrintable(s string) bool {
+	if s == "" || strings.IndexByte(s, 0) >= 0 {
+		return false
+	}
+	printable, total := 0, 0
+	for _, r := range s {
+		total++
+		if unicode.IsPrint(r) || unicode.IsSpace(r) {
+			printable++
+		}
+	}
+	return printable*100 >= total*95
+}
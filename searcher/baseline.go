@@ -0,0 +1,349 @@
+package searcher
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// BaselineStatus tells whether a finding was already present in a baseline report
+type BaselineStatus string
+
+const (
+	BaselineNew   BaselineStatus = "new"   // Not in the baseline
+	BaselineKnown BaselineStatus = "known" // Already reported in the baseline
+)
+
+// Fingerprint identifies a finding across scans by file, pattern type and
+// normalized match. The line number is left out so edits above a secret do
+// not make it look new
+func (f *Finding) Fingerprint() string {
+	return f.fingerprintPath(normalizeFindingPath(f.FilePath))
+}
+
+// FingerprintRelative is Fingerprint with the path taken relative to root,
+// so a checkout in another directory produces the same fingerprints. Files
+// outside root, or an empty root, fall back to Fingerprint
+func (f *Finding) FingerprintRelative(root string) string {
+	return f.fingerprintPath(relativeFindingPath(root, f.FilePath))
+}
+
+func (f *Finding) fingerprintPath(path string) string {
+	h := sha256.New()
+	h.Write([]byte(path))
+	h.Write([]byte{0})
+	h.Write([]byte(f.PatternType))
+	h.Write([]byte{0})
+	h.Write([]byte(normalizeMatch(f.MatchedText)))
+	return hex.EncodeToString(h.Sum(nil)[:12])
+}
+
+// matchSHA256 hashes the normalized match, see Finding.MatchSHA256
+func matchSHA256(matched string) string {
+	sum := sha256.Sum256([]byte(normalizeMatch(matched)))
+	return hex.EncodeToString(sum[:])
+}
+
+// matchMasked reports whether MatchedText was masked after MatchSHA256 was
+// taken, as in reports written without secrets
+func (f *Finding) matchMasked() bool {
+	return f.MatchSHA256 != "" && f.MatchSHA256 != matchSHA256(f.MatchedText)
+}
+
+// maskedFingerprintPath identifies a finding like fingerprintPath when only
+// the hash of the match is known
+func (f *Finding) maskedFingerprintPath(path string) string {
+	digest := f.MatchSHA256
+	if digest == "" {
+		digest = matchSHA256(f.MatchedText)
+	}
+	h := sha256.New()
+	h.Write([]byte(path))
+	h.Write([]byte{0})
+	h.Write([]byte(f.PatternType))
+	h.Write([]byte{0})
+	h.Write([]byte("sha256:" + digest))
+	return hex.EncodeToString(h.Sum(nil)[:12])
+}
+
+// relativeFindingPath returns the normalized path of a file below root
+func relativeFindingPath(root, path string) string {
+	if root != "" {
+		if rel, err := filepath.Rel(root, path); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return normalizeFindingPath(rel)
+		}
+	}
+	return normalizeFindingPath(path)
+}
+
+// BaselineIndex is the set of fingerprints of a baseline report. It is built
+// once and never modified, so lookups from scan workers need no locking
+type BaselineIndex struct {
+	fingerprints map[string]struct{}
+	root         string // Root of the scan being compared, see WithRoot
+	masked       bool   // Some findings were only known by MatchSHA256
+}
+
+// NewBaselineIndex indexes the findings of a loaded report. Both the plain
+// and the root-relative fingerprints are kept, so reports written before
+// the scan root was recorded still match. Findings of reports written with
+// masked secrets are indexed by MatchSHA256 instead
+func NewBaselineIndex(baseline *ScanResult) *BaselineIndex {
+	baseline.mu.Lock()
+	findings := make([]*Finding, 0, len(baseline.Findings)+len(baseline.Baselined))
+	findings = append(findings, baseline.Findings...)
+	findings = append(findings, baseline.Baselined...)
+	root := baseline.Root
+	baseline.mu.Unlock()
+
+	index := &BaselineIndex{fingerprints: make(map[string]struct{}, len(findings))}
+	for _, f := range findings {
+		if f.matchMasked() {
+			index.masked = true
+			index.fingerprints[f.maskedFingerprintPath(normalizeFindingPath(f.FilePath))] = struct{}{}
+			index.fingerprints[f.maskedFingerprintPath(relativeFindingPath(root, f.FilePath))] = struct{}{}
+			continue
+		}
+		index.fingerprints[f.Fingerprint()] = struct{}{}
+		index.fingerprints[f.FingerprintRelative(root)] = struct{}{}
+	}
+	return index
+}
+
+// WithRoot returns the index for comparing a scan of root; findings are
+// then also matched by their path relative to it. The fingerprints are shared
+func (bi *BaselineIndex) WithRoot(root string) *BaselineIndex {
+	if bi == nil {
+		return nil
+	}
+	return &BaselineIndex{fingerprints: bi.fingerprints, root: root, masked: bi.masked}
+}
+
+// Len returns the number of distinct fingerprints in the baseline
+func (bi *BaselineIndex) Len() int {
+	if bi == nil {
+		return 0
+	}
+	return len(bi.fingerprints)
+}
+
+// Status looks a finding up without modifying it; a nil index has no status
+func (bi *BaselineIndex) Status(f *Finding) BaselineStatus {
+	if bi == nil {
+		return ""
+	}
+	if _, ok := bi.fingerprints[f.FingerprintRelative(bi.root)]; ok {
+		return BaselineKnown
+	}
+	if _, ok := bi.fingerprints[f.Fingerprint()]; ok {
+		return BaselineKnown
+	}
+	if bi.masked {
+		if _, ok := bi.fingerprints[f.maskedFingerprintPath(relativeFindingPath(bi.root, f.FilePath))]; ok {
+			return BaselineKnown
+		}
+		if _, ok := bi.fingerprints[f.maskedFingerprintPath(normalizeFindingPath(f.FilePath))]; ok {
+			return BaselineKnown
+		}
+	}
+	return BaselineNew
+}
+
+// Tag sets f.Baseline. Call it before the finding is shared with other goroutines
+func (bi *BaselineIndex) Tag(f *Finding) BaselineStatus {
+	f.Baseline = bi.Status(f)
+	return f.Baseline
+}
+
+// TagAll tags findings that did not come through a scanner with the index,
+// e.g. an opened report
+func (bi *BaselineIndex) TagAll(findings []*Finding) (newCount, known int) {
+	for _, f := range findings {
+		bi.Tag(f)
+	}
+	return CountBaseline(findings)
+}
+
+// TagResult tags the findings of a result, matching paths relative to its
+// root; spilled findings are tagged on disk
+func (bi *BaselineIndex) TagResult(result *ScanResult) (newCount, known int) {
+	bi = bi.WithRoot(result.Root)
+	result.mu.Lock()
+	defer result.mu.Unlock()
+	if result.spill == nil {
+		return bi.TagAll(result.Findings)
+	}
+	result.rewriteSpill(func(f *Finding) bool {
+		switch bi.Tag(f) {
+		case BaselineNew:
+			newCount++
+		case BaselineKnown:
+			known++
+		}
+		return true
+	})
+	return newCount, known
+}
+
+// CountBaseline counts new and known findings; untagged ones are not counted
+func CountBaseline(findings []*Finding) (newCount, known int) {
+	return countBaseline(sliceFindings(findings))
+}
+
+func countBaseline(findings findingSeq) (newCount, known int) {
+	findings(func(f *Finding) bool {
+		switch f.Baseline {
+		case BaselineNew:
+			newCount++
+		case BaselineKnown:
+			known++
+		}
+		return true
+	})
+	return newCount, known
+}
+
+// SuppressBaselined moves the findings tagged known out of Findings into
+// Baselined. They no longer count in TotalFindings or SeveritySummary.
+// It returns the number of findings moved
+func (sr *ScanResult) SuppressBaselined() int {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+
+	moved := 0
+	suppress := func(f *Finding) bool {
+		if f.Baseline != BaselineKnown {
+			return false
+		}
+		sr.Baselined = append(sr.Baselined, f)
+		if sr.SeveritySummary[f.Severity] > 0 {
+			sr.SeveritySummary[f.Severity]--
+		}
+		moved++
+		return true
+	}
+	if sr.spill != nil {
+		sr.rewriteSpill(func(f *Finding) bool { return !suppress(f) })
+		return moved
+	}
+
+	kept := sr.Findings[:0]
+	for _, f := range sr.Findings {
+		if f != nil && suppress(f) {
+			continue
+		}
+		kept = append(kept, f)
+	}
+	for i := len(kept); i < len(sr.Findings); i++ {
+		sr.Findings[i] = nil
+	}
+	sr.Findings = kept
+	return moved
+}
+
+// BaselineFileFormat marks baseline files written by WriteBaseline
+const BaselineFileFormat = "data-leak-locator/baseline"
+
+// BaselineFile is a baseline without the secrets themselves: each entry
+// keeps the fingerprint and enough context to find the finding by hand
+type BaselineFile struct {
+	Format  string          `json:"format"`
+	Created string          `json:"created"`
+	Entries []BaselineEntry `json:"findings"`
+}
+
+// BaselineEntry is one acknowledged finding
+type BaselineEntry struct {
+	Fingerprint string      `json:"fingerprint"`
+	FilePath    string      `json:"file_path"` // Relative to the scan root
+	LineNumber  int         `json:"line_number,omitempty"`
+	PatternType PatternType `json:"pattern_type"`
+}
+
+// WriteBaseline writes every finding of result, suppressed ones included,
+// as a baseline file. Fingerprints use paths relative to result.Root
+func WriteBaseline(path string, result *ScanResult) error {
+	result.mu.Lock()
+	baselined := make([]*Finding, len(result.Baselined))
+	copy(baselined, result.Baselined)
+	root := result.Root
+	end := result.EndTime
+	result.mu.Unlock()
+
+	seen := make(map[string]bool)
+	file := BaselineFile{Format: BaselineFileFormat, Created: time.Unix(end, 0).UTC().Format(time.RFC3339), Entries: []BaselineEntry{}}
+	add := func(f *Finding) bool {
+		fingerprint := f.FingerprintRelative(root)
+		if seen[fingerprint] {
+			return true
+		}
+		seen[fingerprint] = true
+		file.Entries = append(file.Entries, BaselineEntry{
+			Fingerprint: fingerprint,
+			FilePath:    relativeFindingPath(root, f.FilePath),
+			LineNumber:  f.LineNumber,
+			PatternType: f.PatternType,
+		})
+		return true
+	}
+	if err := result.ForEachFinding(add); err != nil {
+		return err
+	}
+	for _, f := range baselined {
+		add(f)
+	}
+	// Sorted, so a baseline committed to a repository diffs cleanly
+	sort.Slice(file.Entries, func(i, j int) bool {
+		a, b := file.Entries[i], file.Entries[j]
+		if a.FilePath != b.FilePath {
+			return a.FilePath < b.FilePath
+		}
+		if a.LineNumber != b.LineNumber {
+			return a.LineNumber < b.LineNumber
+		}
+		return a.Fingerprint < b.Fingerprint
+	})
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, reportFileMode, func(w io.Writer) error {
+		_, err := w.Write(append(data, '\n'))
+		return err
+	})
+}
+
+// LoadBaselineIndex loads a baseline file written by WriteBaseline or a JSON
+// report (.json, .dllreport) and indexes it
+func LoadBaselineIndex(path string) (*BaselineIndex, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, pathError(err)
+	}
+
+	var file BaselineFile
+	if json.Unmarshal(data, &file) == nil && file.Format == BaselineFileFormat {
+		index := &BaselineIndex{fingerprints: make(map[string]struct{}, len(file.Entries))}
+		for _, entry := range file.Entries {
+			if entry.Fingerprint == "" {
+				return nil, fmt.Errorf("некорректный базовый файл %s: запись без отпечатка", filepath.Base(path))
+			}
+			index.fingerprints[entry.Fingerprint] = struct{}{}
+		}
+		return index, nil
+	}
+
+	report, err := LoadScanResult(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewBaselineIndex(report), nil
+}
@@ -2,11 +2,18 @@ package searcher
 
 import (
 	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
 	"math/rand"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 // BenchmarkScanner_SmallFiles benchmarks scanning many small files
@@ -359,3 +366,211 @@ func BenchmarkReportGeneration(b *testing.B) {
 	}
 }
 
+
+// BenchmarkHighEntropyDetection compares the per-line cost of the pipeline
+// with and without the generic high-entropy detector
+func BenchmarkHighEntropyDetection(b *testing.B) {
+	lines := []string{
+		"func main() { fmt.Println(\"hello world\") }",
+		"timeout: 30 # seconds between retries of the upload",
+		"cfg_val: \"q8Zr2LxV9pT4mW7kN1bY6cH3jF5dS0gA-eR_uI8o\"",
+		"checksum 3f786850e387550fdab836ed7e6dc881de23001b",
+		"Lorem ipsum dolor sit amet, consectetur adipiscing elit",
+	}
+	for _, enabled := range []bool{false, true} {
+		name := "off"
+		if enabled {
+			name = "on"
+		}
+		b.Run(name, func(b *testing.B) {
+			scanner := NewScanner()
+			scanner.SetDetectHighEntropy(enabled)
+			pipeline := scanner.pipeline()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				for n, line := range lines {
+					pipeline.analyzeLine("bench.txt", line, n+1, nil)
+				}
+			}
+		})
+	}
+}
+
+// wideTree creates files small files spread over directories of 1000
+func wideTree(b *testing.B, files int) string {
+	b.Helper()
+	dir := b.TempDir()
+	for i := 0; i < files; i++ {
+		sub := filepath.Join(dir, fmt.Sprintf("d%03d", i/1000))
+		if i%1000 == 0 {
+			os.MkdirAll(sub, 0755)
+		}
+		os.WriteFile(filepath.Join(sub, fmt.Sprintf("f%d.txt", i)), []byte("plain text\n"), 0644)
+	}
+	return dir
+}
+
+// peakSampler records the peak goroutine count and memory, heap and
+// goroutine stacks, while running
+type peakSampler struct {
+	goroutines int
+	memory     uint64
+	stop       chan struct{}
+	done       chan struct{}
+}
+
+func startPeakSampler() *peakSampler {
+	p := &peakSampler{stop: make(chan struct{}), done: make(chan struct{})}
+	go func() {
+		defer close(p.done)
+		var stats runtime.MemStats
+		ticker := time.NewTicker(5 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			if n := runtime.NumGoroutine(); n > p.goroutines {
+				p.goroutines = n
+			}
+			runtime.ReadMemStats(&stats)
+			if m := stats.HeapInuse + stats.StackInuse; m > p.memory {
+				p.memory = m
+			}
+			select {
+			case <-p.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return p
+}
+
+func (p *peakSampler) report(b *testing.B) {
+	close(p.stop)
+	<-p.done
+	b.ReportMetric(float64(p.goroutines), "peak-goroutines")
+	b.ReportMetric(float64(p.memory)/(1<<20), "peak-MB")
+}
+
+// goroutinePerFileWalk is the walk the worker pool replaced: a goroutine
+// for every directory and every file, each waiting for a semaphore slot
+func goroutinePerFileWalk(s *Scanner, dir string, sem chan struct{}, wg *sync.WaitGroup) {
+	defer wg.Done()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		wg.Add(1)
+		if entry.IsDir() {
+			go goroutinePerFileWalk(s, path, sem, wg)
+			continue
+		}
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			s.processFile(path)
+		}()
+	}
+}
+
+// BenchmarkWideTree compares the peak goroutines and memory of scanning a
+// 100k-file tree with the worker pool and with a goroutine per file
+func BenchmarkWideTree(b *testing.B) {
+	dir := wideTree(b, 100_000)
+
+	b.Run("worker-pool", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			runtime.GC()
+			peak := startPeakSampler()
+			if _, err := NewScanner().Scan(dir); err != nil {
+				b.Fatal(err)
+			}
+			peak.report(b)
+		}
+	})
+
+	b.Run("goroutine-per-file", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			runtime.GC()
+			peak := startPeakSampler()
+			s := NewScanner()
+			stop := s.beginScan(context.Background(), dir)
+			var wg sync.WaitGroup
+			wg.Add(1)
+			goroutinePerFileWalk(s, dir, make(chan struct{}, MaxConcurrentFiles), &wg)
+			wg.Wait()
+			stop()
+			peak.report(b)
+		}
+	})
+}
+
+// BenchmarkOCRBackends compares OCR through the tesseract command line
+// with the pooled in-process clients of a cgo_ocr build, on as many workers
+// as a scan uses. OCR_BENCH_DIR points it at a directory of PNG scans
+func BenchmarkOCRBackends(b *testing.B) {
+	images := []string{benchmarkScan(b)}
+	if dir := os.Getenv("OCR_BENCH_DIR"); dir != "" {
+		images, _ = filepath.Glob(filepath.Join(dir, "*.png"))
+		if len(images) == 0 {
+			b.Fatalf("no PNG images in %s", dir)
+		}
+	}
+	de := NewDocumentExtractor(true)
+	lang := de.tesseractLangs()
+
+	run := func(b *testing.B, ocr func(ctx context.Context, imagePath, lang string) (string, error)) {
+		b.RunParallel(func(pb *testing.PB) {
+			for i := 0; pb.Next(); i++ {
+				if _, err := ocr(context.Background(), images[i%len(images)], lang); err != nil {
+					b.Error(err)
+					return
+				}
+			}
+		})
+	}
+
+	b.Run("cli", func(b *testing.B) {
+		if !de.isTesseractAvailable() {
+			b.Skip("tesseract is not installed")
+		}
+		run(b, de.runTesseractCLI)
+	})
+
+	b.Run("native", func(b *testing.B) {
+		pool := nativeOCR()
+		if pool == nil {
+			b.Skip("build with -tags cgo_ocr")
+		}
+		run(b, pool.recognize)
+	})
+}
+
+// benchmarkScan writes a page of black bars, a stand-in for lines of text
+func benchmarkScan(b *testing.B) string {
+	page := image.NewGray(image.Rect(0, 0, 800, 600))
+	for i := range page.Pix {
+		page.Pix[i] = 0xff
+	}
+	for y := 40; y < 560; y += 40 {
+		for x := 40; x < 760; x++ {
+			if x%60 < 48 {
+				for dy := 0; dy < 14; dy++ {
+					page.SetGray(x, y+dy, color.Gray{})
+				}
+			}
+		}
+	}
+	path := filepath.Join(b.TempDir(), "scan.png")
+	f, err := os.Create(path)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer f.Close()
+	if err := png.Encode(f, page); err != nil {
+		b.Fatal(err)
+	}
+	return path
+}
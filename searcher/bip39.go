@@ -0,0 +1,78 @@
+package searcher
+
+import (
+	"crypto/sha256"
+	_ "embed"
+	"strings"
+	"sync"
+)
+
+//go:embed wordlists/bip39_english.txt
+var bip39EnglishList string
+
+var (
+	bip39Once  sync.Once
+	bip39Index map[string]int
+)
+
+// bip39Words returns the English BIP-39 wordlist indexed by word
+func bip39Words() map[string]int {
+	bip39Once.Do(func() {
+		words := strings.Fields(bip39EnglishList)
+		bip39Index = make(map[string]int, len(words))
+		for i, word := range words {
+			bip39Index[word] = i
+		}
+	})
+	return bip39Index
+}
+
+// IsBIP39Word reports whether word is in the English BIP-39 wordlist
+func IsBIP39Word(word string) bool {
+	_, ok := bip39Words()[word]
+	return ok
+}
+
+// isSeedPhraseLength reports whether n is a BIP-39 mnemonic length
+func isSeedPhraseLength(n int) bool {
+	return n >= 12 && n <= 24 && n%3 == 0
+}
+
+// ValidateBIP39Mnemonic checks the word count, the wordlist and the
+// checksum bits carried by the last word
+func ValidateBIP39Mnemonic(words []string) bool {
+	if !isSeedPhraseLength(len(words)) {
+		return false
+	}
+
+	index := bip39Words()
+	bits := make([]byte, 0, len(words)*11)
+	for _, word := range words {
+		n, ok := index[word]
+		if !ok {
+			return false
+		}
+		for i := 10; i >= 0; i-- {
+			bits = append(bits, byte(n>>i)&1)
+		}
+	}
+
+	// ENT + ENT/32 bits: 128+4 for 12 words, 256+8 for 24 words
+	checksumBits := len(bits) / 33
+	entropyBits := len(bits) - checksumBits
+
+	entropy := make([]byte, entropyBits/8)
+	for i := range entropy {
+		for j := 0; j < 8; j++ {
+			entropy[i] = entropy[i]<<1 | bits[i*8+j]
+		}
+	}
+
+	hash := sha256.Sum256(entropy)
+	for i := 0; i < checksumBits; i++ {
+		if (hash[0]>>(7-i))&1 != bits[entropyBits+i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,231 @@
+package searcher
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A developer's own address in a comment is noise, a file with thousands
+// of customer addresses is a leaked user list. Addresses of allowlisted
+// domains, such as the company's own, are lowered to Low. The other
+// addresses and phone numbers of a file are counted while its findings are
+// filtered, and a file with more distinct ones than the threshold gets one
+// extra High finding for the whole file. Only the findings of the file at
+// hand are counted, so memory does not grow with the scan
+
+// DefaultBulkThreshold is how many distinct email addresses or phone
+// numbers a file may hold before it is reported as bulk personal data
+const DefaultBulkThreshold = 50
+
+// bulkRiskScore is the risk score of a bulk finding, that of a High finding
+const bulkRiskScore = 70
+
+// SetEmailDomainAllowlist sets the domains whose email addresses are
+// lowered to Low; subdomains are included. A CustomPatternsFile or a rules
+// file can list more under email_domain_allowlist
+func (s *Scanner) SetEmailDomainAllowlist(domains []string) {
+	s.emailAllowlist = normalizeDomains(domains)
+}
+
+// EmailDomainAllowlist returns the allowlisted domains set on the scanner
+// and loaded with LoadCustomPatterns
+func (s *Scanner) EmailDomainAllowlist() []string {
+	return append([]string(nil), s.emailAllowlist...)
+}
+
+// SetBulkThreshold sets how many distinct email addresses or phone numbers
+// a file may hold before a bulk finding is added; zero turns it off
+func (s *Scanner) SetBulkThreshold(n int) {
+	s.bulkThreshold = max(n, 0)
+}
+
+// normalizeDomains lowercases domains and strips "@" and "*." prefixes
+func normalizeDomains(domains []string) []string {
+	var normalized []string
+	for _, domain := range domains {
+		domain = strings.ToLower(strings.TrimSpace(domain))
+		domain = strings.TrimPrefix(strings.TrimPrefix(domain, "@"), "*.")
+		if domain != "" {
+			normalized = append(normalized, domain)
+		}
+	}
+	return normalized
+}
+
+// allowlistedEmail reports whether f is an email address of an
+// allowlisted domain
+func (s *Scanner) allowlistedEmail(f *Finding) bool {
+	if f.PatternType != PatternEmail {
+		return false
+	}
+	at := strings.LastIndexByte(f.MatchedText, '@')
+	if at < 0 {
+		return false
+	}
+	domain := strings.ToLower(f.MatchedText[at+1:])
+	for _, list := range [][]string{s.emailAllowlist, s.rootAllowlist} {
+		for _, allowed := range list {
+			if domain == allowed || strings.HasSuffix(domain, "."+allowed) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// lowerAllowlisted lowers an allowlisted email address to Low in place
+func lowerAllowlisted(f *Finding) {
+	if f.Severity == Low {
+		return
+	}
+	f.Severity = Low
+	f.RiskScore /= 2
+	f.Description += " (домен из списка разрешённых)"
+}
+
+// bulkKinds are the pattern types counted per file and the type of the
+// finding added past the threshold
+var bulkKinds = map[PatternType]PatternType{
+	PatternEmail:       PatternBulkEmails,
+	PatternPhoneNumber: PatternBulkPhones,
+}
+
+// isBulkPattern reports whether t is the type of a bulk finding
+func isBulkPattern(t PatternType) bool {
+	return t == PatternBulkEmails || t == PatternBulkPhones
+}
+
+// bulkCounter counts the distinct addresses and numbers of the files of
+// one batch of findings
+type bulkCounter struct {
+	threshold int
+	files     map[string]*bulkFile
+	order     []string // File paths in the order they were seen
+}
+
+// bulkFile holds the distinct values of one file by bulk type
+type bulkFile struct {
+	values   map[PatternType]map[string]bool
+	first    map[PatternType]*Finding // Where the bulk finding points
+	reported map[PatternType]bool     // Bulk findings already in the batch, e.g. replayed from the cache
+}
+
+func newBulkCounter(threshold int) *bulkCounter {
+	return &bulkCounter{threshold: threshold, files: make(map[string]*bulkFile)}
+}
+
+// file returns the counts of path, adding them when missing
+func (c *bulkCounter) file(path string) *bulkFile {
+	file := c.files[path]
+	if file == nil {
+		file = &bulkFile{
+			values:   make(map[PatternType]map[string]bool),
+			first:    make(map[PatternType]*Finding),
+			reported: make(map[PatternType]bool),
+		}
+		c.files[path] = file
+		c.order = append(c.order, path)
+	}
+	return file
+}
+
+// add counts the value of f if it is an address or a number
+func (c *bulkCounter) add(f *Finding) {
+	if c.threshold <= 0 {
+		return
+	}
+	if isBulkPattern(f.PatternType) {
+		c.file(f.FilePath).reported[f.PatternType] = true
+		return
+	}
+	kind, ok := bulkKinds[f.PatternType]
+	if !ok {
+		return
+	}
+	value := normalizeBulkValue(f.PatternType, f.MatchedText)
+	if value == "" {
+		return
+	}
+	file := c.file(f.FilePath)
+	values := file.values[kind]
+	if values == nil {
+		values = make(map[string]bool)
+		file.values[kind] = values
+		file.first[kind] = f
+	}
+	values[value] = true
+}
+
+// findings returns a bulk finding for every file and type past the
+// threshold
+func (c *bulkCounter) findings() []*Finding {
+	var bulk []*Finding
+	for _, path := range c.order {
+		file := c.files[path]
+		for _, kind := range []PatternType{PatternBulkEmails, PatternBulkPhones} {
+			n := len(file.values[kind])
+			if n <= c.threshold || file.reported[kind] {
+				continue
+			}
+			bulk = append(bulk, bulkFinding(kind, file.first[kind], n))
+		}
+	}
+	return bulk
+}
+
+// reservedEmailDomains are the domains reserved for documentation and
+// tests (RFC 2606); generated samples use them, real people do not
+var reservedEmailDomains = []string{"example.com", "example.org", "example.net", "example", "test", "invalid", "localhost"}
+
+// normalizeBulkValue returns the value an address or number is counted
+// by: an address lowercased, a number as its digits. Addresses at reserved
+// domains are not counted
+func normalizeBulkValue(patternType PatternType, text string) string {
+	if patternType == PatternEmail {
+		address := strings.ToLower(strings.TrimSpace(text))
+		domain := address[strings.LastIndexByte(address, '@')+1:]
+		for _, reserved := range reservedEmailDomains {
+			if domain == reserved || strings.HasSuffix(domain, "."+reserved) {
+				return ""
+			}
+		}
+		return address
+	}
+	var digits strings.Builder
+	for _, r := range text {
+		if r >= '0' && r <= '9' {
+			digits.WriteRune(r)
+		}
+	}
+	number := digits.String()
+	// 8 and +7 start the same Russian number
+	if len(number) == 11 && number[0] == '8' {
+		number = "7" + number[1:]
+	}
+	return number
+}
+
+// bulkFinding is the finding of a file with n distinct values of kind; it
+// points at the first of them
+func bulkFinding(kind PatternType, first *Finding, n int) *Finding {
+	description := fmt.Sprintf("В файле %d разных email-адресов — похоже на выгрузку персональных данных", n)
+	if kind == PatternBulkPhones {
+		description = fmt.Sprintf("В файле %d разных номеров телефонов — похоже на выгрузку персональных данных", n)
+	}
+	return &Finding{
+		FilePath:    first.FilePath,
+		LineNumber:  first.LineNumber,
+		PageNumber:  first.PageNumber,
+		PageLine:    first.PageLine,
+		ArchivePath: first.ArchivePath,
+		InnerPath:   first.InnerPath,
+		Commit:      first.Commit,
+		Author:      first.Author,
+		CommitDate:  first.CommitDate,
+		PatternType: kind,
+		Severity:    High,
+		Description: description,
+		Context:     first.Context,
+		RiskScore:   bulkRiskScore,
+	}
+}
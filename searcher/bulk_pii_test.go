@@ -0,0 +1,120 @@
+package searcher
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEmailDomainAllowlist(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte(
+		"author: dev@ourcompany.com\n"+
+			"ops: oncall@mail.OurCompany.com\n"+
+			"lookalike: someone@notourcompany.com\n"+
+			"customer: jane.doe@gmail.com\n"), 0644)
+
+	scanner := NewScanner()
+	scanner.SetEmailDomainAllowlist([]string{"@OurCompany.com"})
+	result, err := scanner.Scan(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	severities := make(map[string]Severity)
+	for _, f := range findingsOfType(result.Findings, PatternEmail) {
+		severities[f.MatchedText] = f.Severity
+	}
+	want := map[string]Severity{
+		"dev@ourcompany.com":         Low,
+		"oncall@mail.OurCompany.com": Low,
+		"someone@notourcompany.com":  Medium,
+		"jane.doe@gmail.com":         Medium,
+	}
+	if fmt.Sprint(severities) != fmt.Sprint(want) {
+		t.Errorf("severities = %v, want %v", severities, want)
+	}
+
+	// The patterns file of the root lists domains too
+	os.WriteFile(filepath.Join(dir, CustomPatternsFile), []byte("rules: []\nemail_domain_allowlist:\n  - gmail.com\n"), 0644)
+	if result, err = NewScanner().Scan(dir); err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range findingsOfType(result.Findings, PatternEmail) {
+		if allowed := f.MatchedText == "jane.doe@gmail.com"; allowed != (f.Severity == Low) {
+			t.Errorf("%s: severity %s with gmail.com allowlisted by %s", f.MatchedText, f.Severity, CustomPatternsFile)
+		}
+	}
+}
+
+func TestBulkPersonalData(t *testing.T) {
+	addresses := func(n int, domain string) string {
+		var sb strings.Builder
+		for i := 0; i < n; i++ {
+			fmt.Fprintf(&sb, "customer%d@%s\n", i, domain)
+		}
+		return sb.String()
+	}
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "at_threshold.csv"), []byte(addresses(DefaultBulkThreshold, "mail.ru")), 0644)
+	os.WriteFile(filepath.Join(dir, "users.csv"), []byte("email\n"+addresses(DefaultBulkThreshold+1, "mail.ru")), 0644)
+	// The same address many times is not a list of people
+	os.WriteFile(filepath.Join(dir, "repeated.log"), []byte(strings.Repeat("from support@gmail.com\n", 200)), 0644)
+	// Nor are generated samples or the company's own addresses
+	os.WriteFile(filepath.Join(dir, "samples.txt"), []byte(addresses(100, "example.com")), 0644)
+	os.WriteFile(filepath.Join(dir, "team.txt"), []byte(addresses(100, "ourcompany.com")), 0644)
+	var phones strings.Builder
+	for i := 0; i < 60; i++ {
+		fmt.Fprintf(&phones, "+7 (916) 555-%02d-%02d\n", i/10+10, i%10+20)
+	}
+	os.WriteFile(filepath.Join(dir, "phones.txt"), []byte(phones.String()), 0644)
+
+	scanner := NewScanner()
+	scanner.SetEmailDomainAllowlist([]string{"ourcompany.com"})
+	// Individual addresses are dropped, the bulk finding stays
+	scanner.SetMinSeverity(High)
+	result, err := scanner.Scan(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bulk := findingsOfType(result.Findings, PatternBulkEmails)
+	if len(bulk) != 1 || filepath.Base(bulk[0].FilePath) != "users.csv" {
+		t.Fatalf("bulk email findings = %+v, want one for users.csv", bulk)
+	}
+	if f := bulk[0]; f.Severity != High || f.LineNumber != 2 || !strings.Contains(f.Description, "51 разных email-адресов") {
+		t.Errorf("bulk finding = %+v", f)
+	}
+	if n := len(findingsOfType(result.Findings, PatternEmail)); n != 0 {
+		t.Errorf("%d email findings kept below the minimum severity", n)
+	}
+	phoneBulk := findingsOfType(result.Findings, PatternBulkPhones)
+	if len(phoneBulk) != 1 || !strings.Contains(phoneBulk[0].Description, "60 разных номеров") {
+		t.Errorf("bulk phone findings = %+v", phoneBulk)
+	}
+
+	// Off with a zero threshold
+	scanner.SetBulkThreshold(0)
+	if result, _ = scanner.Scan(dir); len(findingsOfType(result.Findings, PatternBulkEmails)) != 0 {
+		t.Error("bulk finding added with the check off")
+	}
+}
+
+// TestBulkFindingReplayed tests findings replayed from the cache, which
+// hold their bulk finding, do not get a second one
+func TestBulkFindingReplayed(t *testing.T) {
+	scanner := NewScanner()
+	var findings []*Finding
+	for i := 0; i < 3; i++ {
+		findings = append(findings, &Finding{FilePath: "a.csv", LineNumber: i + 1, PatternType: PatternEmail, MatchedText: fmt.Sprintf("u%d@mail.ru", i), Severity: Medium})
+	}
+	scanner.SetBulkThreshold(2)
+	kept := scanner.filterFindings(append([]*Finding(nil), findings...))
+	if len(kept) != 4 || kept[3].PatternType != PatternBulkEmails {
+		t.Fatalf("kept %d findings: %+v", len(kept), kept)
+	}
+	if again := scanner.filterFindings(kept); len(again) != 4 {
+		t.Errorf("replay kept %d findings, want the same 4", len(again))
+	}
+}
@@ -0,0 +1,64 @@
+package searcher
+
+import "unicode/utf8"
+
+// Regexp matches report byte offsets. Users and editors count characters,
+// and SARIF counts UTF-16 code units, so findings carry rune columns and
+// the byte offsets they were computed from.
+
+// RuneColumn converts a byte offset in line to a 0-based character column.
+// Offsets past the end of the line are clamped.
+func RuneColumn(line string, byteOffset int) int {
+	return utf8.RuneCountInString(line[:clampOffset(line, byteOffset)])
+}
+
+// UTF16Column converts a byte offset in line to a 0-based UTF-16 code unit
+// column. Characters outside the BMP (most emoji) take two units.
+func UTF16Column(line string, byteOffset int) int {
+	column := 0
+	for _, r := range line[:clampOffset(line, byteOffset)] {
+		if r >= 0x10000 {
+			column += 2
+		} else {
+			column++
+		}
+	}
+	return column
+}
+
+// ByteOffset converts a 0-based character column back to a byte offset in line
+func ByteOffset(line string, runeColumn int) int {
+	if runeColumn <= 0 {
+		return 0
+	}
+	n := 0
+	for i := range line {
+		if n == runeColumn {
+			return i
+		}
+		n++
+	}
+	return len(line)
+}
+
+// UTF16Columns returns the finding's span in UTF-16 code units, as used by SARIF.
+// Context may be a chunk or an excerpt of the line, so the wide characters
+// in it are added to the character columns
+func (f *Finding) UTF16Columns() (start, end int) {
+	if f.Context == "" || f.ByteEnd == 0 {
+		return f.ColumnStart, f.ColumnEnd
+	}
+	start = f.ColumnStart + UTF16Column(f.Context, f.ByteStart) - RuneColumn(f.Context, f.ByteStart)
+	end = f.ColumnEnd + UTF16Column(f.Context, f.ByteEnd) - RuneColumn(f.Context, f.ByteEnd)
+	return start, end
+}
+
+func clampOffset(line string, offset int) int {
+	if offset < 0 {
+		return 0
+	}
+	if offset > len(line) {
+		return len(line)
+	}
+	return offset
+}
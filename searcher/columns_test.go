@@ -0,0 +1,65 @@
+package searcher
+
+import "testing"
+
+// TestColumnConversions tests byte offsets over ASCII, Cyrillic and emoji
+func TestColumnConversions(t *testing.T) {
+	line := "пароль 🔑: key=abc"
+	keyOffset := len("пароль 🔑: ")
+
+	tests := []struct {
+		offset      int
+		rune, utf16 int
+	}{
+		{0, 0, 0},
+		{len("пароль"), 6, 6},
+		{len("пароль 🔑"), 8, 9},
+		{keyOffset, 10, 11},
+		{len(line), 17, 18},
+		{len(line) + 5, 17, 18},
+		{-1, 0, 0},
+	}
+
+	for _, tt := range tests {
+		if got := RuneColumn(line, tt.offset); got != tt.rune {
+			t.Errorf("RuneColumn(%d) = %d, want %d", tt.offset, got, tt.rune)
+		}
+		if got := UTF16Column(line, tt.offset); got != tt.utf16 {
+			t.Errorf("UTF16Column(%d) = %d, want %d", tt.offset, got, tt.utf16)
+		}
+	}
+
+	if got := ByteOffset(line, 10); got != keyOffset {
+		t.Errorf("ByteOffset(10) = %d, want %d", got, keyOffset)
+	}
+	if got := ByteOffset(line, 100); got != len(line) {
+		t.Errorf("ByteOffset past end = %d, want %d", got, len(line))
+	}
+}
+
+// TestFindingColumnsAreRunes tests findings on Cyrillic lines get character columns
+func TestFindingColumnsAreRunes(t *testing.T) {
+	line := "Учётные данные 🔐 password=SuperSecret123"
+	findings := NewScanner().pipeline().analyzeLine("x.txt", line, 1, nil)
+
+	var found *Finding
+	for _, f := range findings {
+		if f.PatternType == PatternPassword {
+			found = f
+		}
+	}
+	if found == nil {
+		t.Fatal("expected a password finding")
+	}
+
+	wantStart := len([]rune("Учётные данные 🔐 "))
+	if found.ColumnStart != wantStart {
+		t.Errorf("ColumnStart = %d, want %d", found.ColumnStart, wantStart)
+	}
+	if line[found.ByteStart:found.ByteEnd] != found.MatchedText {
+		t.Errorf("byte offsets do not slice the match: %q", line[found.ByteStart:found.ByteEnd])
+	}
+	if start, end := found.UTF16Columns(); start != wantStart+1 || end-start != found.ColumnEnd-found.ColumnStart {
+		t.Errorf("UTF16Columns = %d-%d", start, end)
+	}
+}
@@ -0,0 +1,303 @@
+package searcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// DecodeConfigFile reads a JSON or YAML configuration file into v.
+// The format is chosen by extension (.yaml/.yml are YAML, everything else JSON).
+// Only the YAML subset used by our config files is supported: nested maps,
+// block lists, flow lists of scalars and quoted or plain scalars.
+func DecodeConfigFile(path string, v interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext != ".yaml" && ext != ".yml" {
+		if err := json.Unmarshal(data, v); err != nil {
+			return fmt.Errorf("%s: ошибка разбора JSON: %v", path, err)
+		}
+		return nil
+	}
+
+	value, err := parseYAML(string(data))
+	if err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+
+	// Round-trip through JSON so callers can reuse their json tags
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return fmt.Errorf("%s: ошибка разбора YAML: %v", path, err)
+	}
+	return nil
+}
+
+// yamlLine is a significant (non-blank, non-comment) line of a YAML document
+type yamlLine struct {
+	num    int
+	indent int
+	text   string
+}
+
+// parseYAML parses a YAML document into maps, slices and scalars
+func parseYAML(doc string) (interface{}, error) {
+	var lines []yamlLine
+	for i, raw := range strings.Split(doc, "\n") {
+		raw = strings.TrimRight(raw, " \t\r")
+		trimmed := strings.TrimLeft(raw, " ")
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || trimmed == "---" {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "\t") {
+			return nil, fmt.Errorf("строка %d: табуляция в отступах не поддерживается", i+1)
+		}
+		lines = append(lines, yamlLine{num: i + 1, indent: len(raw) - len(trimmed), text: trimmed})
+	}
+
+	if len(lines) == 0 {
+		return map[string]interface{}{}, nil
+	}
+
+	p := &yamlParser{lines: lines}
+	value, err := p.parseBlock(lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.lines) {
+		return nil, fmt.Errorf("строка %d: неожиданный отступ", p.lines[p.pos].num)
+	}
+	return value, nil
+}
+
+type yamlParser struct {
+	lines []yamlLine
+	pos   int
+}
+
+func (p *yamlParser) parseBlock(indent int) (interface{}, error) {
+	if isYAMLListItem(p.lines[p.pos].text) {
+		return p.parseList(indent)
+	}
+	return p.parseMap(indent)
+}
+
+func (p *yamlParser) parseList(indent int) (interface{}, error) {
+	list := make([]interface{}, 0)
+
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+		if line.indent != indent || !isYAMLListItem(line.text) {
+			break
+		}
+
+		rest := strings.TrimLeft(strings.TrimPrefix(line.text, "-"), " ")
+		if rest == "" {
+			p.pos++
+			if p.pos < len(p.lines) && p.lines[p.pos].indent > indent {
+				item, err := p.parseBlock(p.lines[p.pos].indent)
+				if err != nil {
+					return nil, err
+				}
+				list = append(list, item)
+			} else {
+				list = append(list, nil)
+			}
+			continue
+		}
+
+		if _, _, ok := splitYAMLKey(rest); ok {
+			// "- key: value" starts an inline map; its remaining keys are
+			// aligned with the first one
+			p.lines[p.pos] = yamlLine{num: line.num, indent: indent + len(line.text) - len(rest), text: rest}
+			item, err := p.parseMap(p.lines[p.pos].indent)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, item)
+			continue
+		}
+
+		value, err := parseYAMLScalar(rest)
+		if err != nil {
+			return nil, fmt.Errorf("строка %d: %v", line.num, err)
+		}
+		list = append(list, value)
+		p.pos++
+	}
+
+	return list, nil
+}
+
+func (p *yamlParser) parseMap(indent int) (interface{}, error) {
+	m := make(map[string]interface{})
+
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+		if line.indent < indent {
+			break
+		}
+		if line.indent > indent {
+			return nil, fmt.Errorf("строка %d: неожиданный отступ", line.num)
+		}
+		if isYAMLListItem(line.text) {
+			break
+		}
+
+		key, rest, ok := splitYAMLKey(line.text)
+		if !ok {
+			return nil, fmt.Errorf("строка %d: ожидалось \"ключ: значение\"", line.num)
+		}
+		p.pos++
+
+		if rest != "" {
+			value, err := parseYAMLScalar(rest)
+			if err != nil {
+				return nil, fmt.Errorf("строка %d: %v", line.num, err)
+			}
+			m[key] = value
+			continue
+		}
+
+		// Nested block: deeper indentation, or a list at the same indentation
+		if p.pos < len(p.lines) {
+			next := p.lines[p.pos]
+			if next.indent > indent || (next.indent == indent && isYAMLListItem(next.text)) {
+				value, err := p.parseBlock(next.indent)
+				if err != nil {
+					return nil, err
+				}
+				m[key] = value
+				continue
+			}
+		}
+		m[key] = nil
+	}
+
+	return m, nil
+}
+
+func isYAMLListItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// splitYAMLKey splits "key: value" honouring quoted keys
+func splitYAMLKey(text string) (string, string, bool) {
+	if text[0] == '"' || text[0] == '\'' {
+		end := strings.IndexByte(text[1:], text[0])
+		if end < 0 {
+			return "", "", false
+		}
+		key := text[1 : end+1]
+		rest := strings.TrimLeft(text[end+2:], " ")
+		if !strings.HasPrefix(rest, ":") {
+			return "", "", false
+		}
+		return key, strings.TrimSpace(rest[1:]), true
+	}
+
+	for i := 0; i < len(text); i++ {
+		if text[i] != ':' {
+			continue
+		}
+		if i+1 == len(text) || text[i+1] == ' ' {
+			return strings.TrimSpace(text[:i]), strings.TrimSpace(text[i+1:]), true
+		}
+	}
+	return "", "", false
+}
+
+// parseYAMLScalar converts a scalar or a flow list of scalars
+func parseYAMLScalar(text string) (interface{}, error) {
+	switch text[0] {
+	case '"':
+		end := closingDoubleQuote(text)
+		if end < 0 {
+			return nil, fmt.Errorf("незакрытая кавычка: %s", text)
+		}
+		s, err := strconv.Unquote(text[:end+1])
+		if err != nil {
+			return nil, fmt.Errorf("некорректная строка %s: %v", text, err)
+		}
+		return s, nil
+	case '\'':
+		var sb strings.Builder
+		for i := 1; i < len(text); i++ {
+			if text[i] == '\'' {
+				if i+1 < len(text) && text[i+1] == '\'' {
+					sb.WriteByte('\'')
+					i++
+					continue
+				}
+				return sb.String(), nil
+			}
+			sb.WriteByte(text[i])
+		}
+		return nil, fmt.Errorf("незакрытая кавычка: %s", text)
+	case '[':
+		end := strings.LastIndexByte(text, ']')
+		if end < 0 {
+			return nil, fmt.Errorf("незакрытый список: %s", text)
+		}
+		items := make([]interface{}, 0)
+		inner := strings.TrimSpace(text[1:end])
+		if inner == "" {
+			return items, nil
+		}
+		for _, part := range strings.Split(inner, ",") {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+			item, err := parseYAMLScalar(part)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	}
+
+	// Strip trailing comments from plain scalars
+	if idx := strings.Index(text, " #"); idx >= 0 {
+		text = strings.TrimSpace(text[:idx])
+	}
+
+	switch text {
+	case "~", "null", "Null", "NULL":
+		return nil, nil
+	case "true", "True", "TRUE":
+		return true, nil
+	case "false", "False", "FALSE":
+		return false, nil
+	}
+	if i, err := strconv.ParseInt(text, 10, 64); err == nil {
+		return i, nil
+	}
+	if f, err := strconv.ParseFloat(text, 64); err == nil {
+		return f, nil
+	}
+	return text, nil
+}
+
+func closingDoubleQuote(text string) int {
+	for i := 1; i < len(text); i++ {
+		switch text[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
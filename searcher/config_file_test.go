@@ -0,0 +1,116 @@
+package searcher
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeConfig(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// TestParseYAML tests the supported YAML subset
+func TestParseYAML(t *testing.T) {
+	doc := `# comment
+name: "quoted # not a comment"
+count: 3
+ratio: 0.5
+enabled: true
+empty:
+exts: [.txt, ".env"]
+rules:
+  - name: one
+    regex: 'it''s \d+'
+  - plain item
+nested:
+  key: value # trailing comment
+`
+	got, err := parseYAML(doc)
+	if err != nil {
+		t.Fatalf("parseYAML failed: %v", err)
+	}
+
+	want := map[string]interface{}{
+		"name":    "quoted # not a comment",
+		"count":   int64(3),
+		"ratio":   0.5,
+		"enabled": true,
+		"empty":   nil,
+		"exts":    []interface{}{".txt", ".env"},
+		"rules": []interface{}{
+			map[string]interface{}{"name": "one", "regex": `it's \d+`},
+			"plain item",
+		},
+		"nested": map[string]interface{}{"key": "value"},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseYAML mismatch:\ngot  %#v\nwant %#v", got, want)
+	}
+}
+
+// TestParseYAMLErrors tests malformed documents report the line
+func TestParseYAMLErrors(t *testing.T) {
+	tests := []string{
+		"key: 'unterminated",
+		"key: value\n    bad: indent",
+		"just text",
+	}
+	for _, doc := range tests {
+		if _, err := parseYAML(doc); err == nil {
+			t.Errorf("expected error for %q", doc)
+		} else if !strings.Contains(err.Error(), "строка") && !strings.Contains(err.Error(), "кавычка") {
+			t.Errorf("error for %q should point at the problem: %v", doc, err)
+		}
+	}
+}
+
+// TestLoadRuleFileFormats tests JSON and YAML rule files load the same rules
+func TestLoadRuleFileFormats(t *testing.T) {
+	jsonPath := writeConfig(t, "rules.json", `[{"name": "acme", "regex": "ACME-[0-9]{4}", "severity": "critical"}]`)
+	yamlPath := writeConfig(t, "rules.yml", "- name: acme\n  regex: ACME-[0-9]{4}\n  severity: critical\n")
+
+	for _, path := range []string{jsonPath, yamlPath} {
+		rules, err := LoadRuleFile(path)
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		if len(rules) != 1 {
+			t.Fatalf("%s: expected 1 rule, got %d", path, len(rules))
+		}
+		if rules[0].Name != "acme" || rules[0].Severity != Critical || !rules[0].Regex.MatchString("ACME-1234") {
+			t.Errorf("%s: unexpected rule %+v", path, rules[0])
+		}
+	}
+}
+
+// TestLoadRuleFileInvalidRegex tests the error names the broken rule
+func TestLoadRuleFileInvalidRegex(t *testing.T) {
+	path := writeConfig(t, "rules.yaml", "rules:\n  - name: broken-rule\n    regex: '([a-z'\n")
+
+	_, err := LoadRuleFile(path)
+	if err == nil {
+		t.Fatal("expected error for invalid regex")
+	}
+	if !strings.Contains(err.Error(), "broken-rule") {
+		t.Errorf("error should name the rule: %v", err)
+	}
+}
+
+// TestParseSeverity tests severity names from config files
+func TestParseSeverity(t *testing.T) {
+	if s, err := ParseSeverity(" HIGH "); err != nil || s != High {
+		t.Errorf("ParseSeverity(HIGH) = %v, %v", s, err)
+	}
+	if _, err := ParseSeverity("urgent"); err == nil {
+		t.Error("expected error for unknown severity")
+	}
+}
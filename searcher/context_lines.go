@@ -0,0 +1,123 @@
+package searcher
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// A matching line alone often does not tell a test fixture from a real
+// config block, so findings keep a few lines before and after the match in
+// ContextBefore and ContextAfter. Files are read once: a window of the last
+// lines supplies ContextBefore, and findings wait in it for the lines that
+// follow them. Matches of other findings in those lines are masked, they
+// are reported on their own lines
+
+// DefaultContextLines is how many lines before and after a match are kept
+const DefaultContextLines = 2
+
+// SetContextLines sets how many lines before and after a match are kept
+// with a finding; zero keeps only the matching line
+func (s *Scanner) SetContextLines(n int) {
+	if n < 0 {
+		n = 0
+	}
+	s.contextLines = n
+}
+
+// contextWindow collects the surrounding lines of findings while a file is
+// read line by line
+type contextWindow struct {
+	radius  int
+	limit   int        // Longest line kept, see contextLimit
+	recent  []string   // Up to radius previous lines
+	waiting []*Finding // Findings still short of ContextAfter lines
+}
+
+// newContextWindow returns nil when no surrounding lines are kept
+func newContextWindow(radius, limit int) *contextWindow {
+	if radius <= 0 {
+		return nil
+	}
+	return &contextWindow{radius: radius, limit: limit}
+}
+
+// add takes the next line of the file with the findings on it
+func (w *contextWindow) add(line string, findings []*Finding) {
+	if w == nil {
+		return
+	}
+	line = surroundingLine(line, findings, w.limit)
+
+	waiting := w.waiting[:0]
+	for _, f := range w.waiting {
+		f.ContextAfter = append(f.ContextAfter, line)
+		if len(f.ContextAfter) < w.radius {
+			waiting = append(waiting, f)
+		}
+	}
+	w.waiting = waiting
+
+	for _, f := range findings {
+		if len(w.recent) > 0 {
+			f.ContextBefore = append([]string(nil), w.recent...)
+		}
+		w.waiting = append(w.waiting, f)
+	}
+
+	if len(w.recent) == w.radius {
+		w.recent = append(w.recent[:0], w.recent[1:]...)
+	}
+	w.recent = append(w.recent, line)
+}
+
+// attachContextLines fills the surrounding lines of findings in text whose
+// lines are all at hand, such as extracted documents or content detectors.
+// Findings that already have them are left alone
+func attachContextLines(findings []*Finding, lines []string, radius, limit int) {
+	if radius <= 0 || len(findings) == 0 {
+		return
+	}
+	byLine := make(map[int][]*Finding)
+	for _, f := range findings {
+		byLine[f.LineNumber] = append(byLine[f.LineNumber], f)
+	}
+	shown := make(map[int]string)
+	lineAt := func(n int) string {
+		if line, ok := shown[n]; ok {
+			return line
+		}
+		line := surroundingLine(lines[n-1], byLine[n], limit)
+		shown[n] = line
+		return line
+	}
+
+	for _, f := range findings {
+		if f.ContextBefore != nil || f.ContextAfter != nil || f.LineNumber < 1 || f.LineNumber > len(lines) {
+			continue
+		}
+		from := f.LineNumber - radius
+		if from < 1 {
+			from = 1
+		}
+		for n := from; n < f.LineNumber; n++ {
+			f.ContextBefore = append(f.ContextBefore, lineAt(n))
+		}
+		for n := f.LineNumber + 1; n <= f.LineNumber+radius && n <= len(lines); n++ {
+			f.ContextAfter = append(f.ContextAfter, lineAt(n))
+		}
+	}
+}
+
+// surroundingLine prepares a line for the context of other findings: the
+// matches on it are masked and a long line is cut at limit characters
+func surroundingLine(line string, findings []*Finding, limit int) string {
+	for _, f := range findings {
+		if f.MatchedText != "" {
+			line = strings.ReplaceAll(line, f.MatchedText, MaskSecret(f.MatchedText))
+		}
+	}
+	if limit > 0 && utf8.RuneCountInString(line) > limit {
+		line = string([]rune(line)[:limit]) + contextEllipsis
+	}
+	return line
+}
@@ -0,0 +1,124 @@
+package searcher
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// contextFixture has a secret on line 3 and another one on line 4
+const contextFixture = "first\nsecond\npassword = hunter2hunter2\napi_password = s3cretValue99x\nfifth\nsixth\n"
+
+// findingOnLine returns the first finding of line n
+func findingOnLine(t *testing.T, findings []*Finding, n int) *Finding {
+	t.Helper()
+	for _, f := range findings {
+		if f.LineNumber == n {
+			return f
+		}
+	}
+	t.Fatalf("no finding on line %d", n)
+	return nil
+}
+
+func TestContextLines(t *testing.T) {
+	dir := t.TempDir()
+	createTestFile(t, dir, "config.txt", contextFixture)
+
+	scanner := NewScanner()
+	result, err := scanner.Scan(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := findingOnLine(t, result.Findings, 3)
+	if !reflect.DeepEqual(f.ContextBefore, []string{"first", "second"}) {
+		t.Errorf("ContextBefore = %q", f.ContextBefore)
+	}
+	if len(f.ContextAfter) != 2 || f.ContextAfter[1] != "fifth" {
+		t.Fatalf("ContextAfter = %q", f.ContextAfter)
+	}
+	// The neighbouring secret is reported on its own line, not here
+	if strings.Contains(f.ContextAfter[0], "s3cretValue99x") || !strings.HasPrefix(f.ContextAfter[0], "api_") {
+		t.Errorf("neighbouring line not masked: %q", f.ContextAfter[0])
+	}
+
+	next := findingOnLine(t, result.Findings, 4)
+	if len(next.ContextBefore) != 2 || strings.Contains(next.ContextBefore[1], "hunter2hunter2") {
+		t.Errorf("ContextBefore = %q", next.ContextBefore)
+	}
+	if !reflect.DeepEqual(next.ContextAfter, []string{"fifth", "sixth"}) {
+		t.Errorf("ContextAfter = %q", next.ContextAfter)
+	}
+}
+
+func TestContextLinesAtFileEdges(t *testing.T) {
+	dir := t.TempDir()
+	createTestFile(t, dir, "short.txt", "password = hunter2hunter2\nlast")
+
+	scanner := NewScanner()
+	scanner.SetContextLines(3)
+	result, err := scanner.Scan(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := findingOnLine(t, result.Findings, 1)
+	if f.ContextBefore != nil {
+		t.Errorf("ContextBefore = %q, want none at the start of the file", f.ContextBefore)
+	}
+	if !reflect.DeepEqual(f.ContextAfter, []string{"last"}) {
+		t.Errorf("ContextAfter = %q", f.ContextAfter)
+	}
+}
+
+func TestContextLinesDisabled(t *testing.T) {
+	dir := t.TempDir()
+	createTestFile(t, dir, "config.txt", contextFixture)
+
+	scanner := NewScanner()
+	scanner.SetContextLines(0)
+	result, err := scanner.Scan(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range result.Findings {
+		if f.ContextBefore != nil || f.ContextAfter != nil {
+			t.Errorf("line %d has surrounding lines %q %q", f.LineNumber, f.ContextBefore, f.ContextAfter)
+		}
+	}
+}
+
+func TestContextLinesOfExtractedText(t *testing.T) {
+	scanner := NewScanner()
+	scanner.SetContextLines(1)
+	findings := scanner.scanTextContent(context.Background(), "doc.docx", contextFixture)
+
+	f := findingOnLine(t, findings, 3)
+	if !reflect.DeepEqual(f.ContextBefore, []string{"second"}) {
+		t.Errorf("ContextBefore = %q", f.ContextBefore)
+	}
+	if len(f.ContextAfter) != 1 || strings.Contains(f.ContextAfter[0], "s3cretValue99x") {
+		t.Errorf("ContextAfter = %q", f.ContextAfter)
+	}
+}
+
+func TestMaskMatchCoversContextLines(t *testing.T) {
+	f := &Finding{
+		MatchedText:   "hunter2hunter2",
+		Context:       "password = hunter2hunter2",
+		ContextBefore: []string{"# copy: hunter2hunter2"},
+		ContextAfter:  []string{"user = admin"},
+	}
+	before := f.ContextBefore
+	maskMatch(f)
+	if strings.Contains(f.ContextBefore[0], "hunter2hunter2") {
+		t.Errorf("ContextBefore not masked: %q", f.ContextBefore)
+	}
+	if before[0] != "# copy: hunter2hunter2" {
+		t.Error("masking changed the slice shared with a copy of the finding")
+	}
+	if f.ContextAfter[0] != "user = admin" {
+		t.Errorf("ContextAfter = %q", f.ContextAfter)
+	}
+}
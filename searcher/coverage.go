@@ -0,0 +1,240 @@
+package searcher
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kacebover/password-finder/locale"
+)
+
+// Capability is an optional part of a scan that depends on scan options or
+// external tools
+type Capability string
+
+const (
+	CapabilityDocuments Capability = "documents" // Text of PDF, DOCX and XLSX files
+	CapabilityPDFOCR    Capability = "pdf_ocr"   // OCR of scanned PDF pages
+	CapabilityImageOCR  Capability = "image_ocr" // OCR of images
+	CapabilityArchives  Capability = "archives"  // Contents of ZIP and TAR archives
+	CapabilityAI        Capability = "ai"        // Ollama analysis of the results
+)
+
+// capabilityOrder is the order capabilities are listed in reports
+var capabilityOrder = []Capability{
+	CapabilityDocuments, CapabilityPDFOCR, CapabilityImageOCR, CapabilityArchives, CapabilityAI,
+}
+
+// CoverageStatus tells whether a capability took part in the scan
+type CoverageStatus string
+
+const (
+	CoverageEnabled     CoverageStatus = "enabled"
+	CoverageDisabled    CoverageStatus = "disabled"    // Turned off in the scan options
+	CoverageUnavailable CoverageStatus = "unavailable" // Requested, but a dependency is missing
+)
+
+// CapabilityCoverage is the state of one capability after a scan
+type CapabilityCoverage struct {
+	Capability    Capability     `json:"capability"`
+	Status        CoverageStatus `json:"status"`
+	Reason        string         `json:"reason,omitempty"`
+	InstallHint   string         `json:"install_hint,omitempty"`
+	FilesAffected int            `json:"files_affected"` // Files skipped or only partly checked
+}
+
+// CoverageReport lists which optional capabilities a scan could use
+type CoverageReport struct {
+	Capabilities []CapabilityCoverage `json:"capabilities"`
+	Network      *NetworkCoverage     `json:"network,omitempty"` // Set by SetNetwork after the scan
+}
+
+// CoverageOptions are the scan options that request optional capabilities
+type CoverageOptions struct {
+	Documents bool
+	OCR       bool
+	Archives  bool
+	AI        bool
+}
+
+// CapabilityTitle returns the Russian name of a capability
+func CapabilityTitle(c Capability) string {
+	return LocalizeCapability(russian, c)
+}
+
+// CoverageStatusToRussian returns the Russian name of a status
+func CoverageStatusToRussian(s CoverageStatus) string {
+	return LocalizeCoverageStatus(russian, s)
+}
+
+// BuildCoverage combines the scan options, the dependency registry and the
+// per-capability file counts collected during the scan
+func BuildCoverage(result *ScanResult, opts CoverageOptions, deps *DependencyRegistry) *CoverageReport {
+	gaps := result.CapabilityGaps()
+	report := &CoverageReport{}
+
+	for _, capability := range capabilityOrder {
+		entry := CapabilityCoverage{Capability: capability, Status: CoverageEnabled}
+
+		switch capability {
+		case CapabilityDocuments:
+			if !opts.Documents {
+				entry.disable("coverage.reason.documents_off")
+			}
+		case CapabilityPDFOCR:
+			switch {
+			case !opts.Documents:
+				entry.disable("coverage.reason.documents_off")
+			case !opts.OCR:
+				entry.disable("coverage.reason.ocr_off")
+			default:
+				entry.requireAll(deps, DependencyPoppler, DependencyTesseract)
+			}
+		case CapabilityImageOCR:
+			if !opts.OCR {
+				entry.disable("coverage.reason.ocr_off")
+			} else {
+				entry.requireAll(deps, DependencyTesseract)
+			}
+		case CapabilityArchives:
+			if !opts.Archives {
+				entry.disable("coverage.reason.archives_off")
+			}
+		case CapabilityAI:
+			entry = aiCoverage(opts.AI, deps)
+		}
+
+		entry.FilesAffected = gaps[capability]
+		report.Capabilities = append(report.Capabilities, entry)
+	}
+
+	return report
+}
+
+// disable marks the capability as turned off in the options, for the
+// reason with the catalog key
+func (c *CapabilityCoverage) disable(key string) {
+	c.Status = CoverageDisabled
+	c.Reason = russian.T(key)
+}
+
+// requireAll marks the capability unavailable when any dependency is missing
+func (c *CapabilityCoverage) requireAll(deps *DependencyRegistry, names ...string) {
+	for _, name := range names {
+		if deps.IsAvailable(name) {
+			continue
+		}
+		c.Status = CoverageUnavailable
+		c.Reason = russian.T("coverage.reason.unavailable", dependencyTitles[name])
+		if status := deps.Status(name); status != nil {
+			c.InstallHint = status.InstallHint
+		}
+		return
+	}
+}
+
+// dependencyTitles are the short tool names used in coverage reasons
+var dependencyTitles = map[string]string{
+	DependencyTesseract: "Tesseract",
+	DependencyPoppler:   "Poppler",
+	DependencyOllama:    "Ollama",
+}
+
+// aiCoverage reports AI analysis, which falls back to rules without Ollama
+func aiCoverage(requested bool, deps *DependencyRegistry) CapabilityCoverage {
+	entry := CapabilityCoverage{Capability: CapabilityAI, Status: CoverageEnabled}
+	if !requested {
+		entry.disable("coverage.reason.ai_off")
+		return entry
+	}
+	entry.requireAll(deps, DependencyOllama)
+	if entry.Status == CoverageUnavailable {
+		entry.Reason += russian.T("coverage.reason.rules_fallback")
+	}
+	return entry
+}
+
+// SetAI updates the AI entry once the caller knows whether analysis was requested
+func (r *CoverageReport) SetAI(requested bool, deps *DependencyRegistry) {
+	entry := aiCoverage(requested, deps)
+	for i := range r.Capabilities {
+		if r.Capabilities[i].Capability == CapabilityAI {
+			r.Capabilities[i] = entry
+			return
+		}
+	}
+	r.Capabilities = append(r.Capabilities, entry)
+}
+
+// Get returns the entry for a capability, or nil if the report has none
+func (r *CoverageReport) Get(capability Capability) *CapabilityCoverage {
+	for i := range r.Capabilities {
+		if r.Capabilities[i].Capability == capability {
+			return &r.Capabilities[i]
+		}
+	}
+	return nil
+}
+
+// Summary formats the entry as one line, e.g. "47 PDF пропущено: Poppler недоступен"
+func (c CapabilityCoverage) Summary() string {
+	return LocalizeCoverageSummary(russian, c)
+}
+
+// Warnings returns one line per capability that was requested but could
+// not run; these are what the GUI banner and CLI summary show
+func (r *CoverageReport) Warnings() []string {
+	return LocalizeCoverageWarnings(russian, r)
+}
+
+// Degraded reports whether any requested capability was unavailable
+func (r *CoverageReport) Degraded() bool {
+	return len(r.Warnings()) > 0
+}
+
+// FormatCoverage renders the report as indented lines for text output
+func FormatCoverage(l *locale.Localizer, r *CoverageReport) string {
+	var sb strings.Builder
+	for _, c := range r.Capabilities {
+		icon := "✅"
+		switch c.Status {
+		case CoverageDisabled:
+			icon = "⏸️"
+		case CoverageUnavailable:
+			icon = "⚠️"
+		}
+		sb.WriteString(fmt.Sprintf("  %s %s: %s", icon, LocalizeCapability(l, c.Capability), LocalizeCoverageStatus(l, c.Status)))
+		if c.Reason != "" {
+			sb.WriteString(" — " + LocalizeCoverageReason(l, c.Reason))
+		}
+		if c.FilesAffected > 0 {
+			sb.WriteString(l.T("coverage.files_affected", c.FilesAffected))
+		}
+		sb.WriteString("\n")
+		if c.Status == CoverageUnavailable && c.InstallHint != "" {
+			sb.WriteString(l.T("coverage.install", c.InstallHint) + "\n")
+		}
+	}
+	if r.Network != nil {
+		sb.WriteString(formatNetworkCoverage(l, r.Network))
+	}
+	return sb.String()
+}
+
+// capabilityForExtension returns the capability that handles files with ext
+func capabilityForExtension(ext string) (Capability, bool) {
+	for _, list := range []struct {
+		capability Capability
+		exts       []string
+	}{
+		{CapabilityDocuments, documentExtensions},
+		{CapabilityImageOCR, imageExtensions},
+		{CapabilityArchives, archiveExtensions},
+	} {
+		for _, e := range list.exts {
+			if e == ext {
+				return list.capability, true
+			}
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,215 @@
+package searcher
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kacebover/password-finder/locale"
+)
+
+// coverageRegistry returns a mocked registry where only the given tools are available
+func coverageRegistry(available ...string) *DependencyRegistry {
+	r := NewDependencyRegistry()
+	for _, name := range r.Names() {
+		name := name
+		ok := false
+		for _, a := range available {
+			ok = ok || a == name
+		}
+		r.SetProbe(name, func(ctx context.Context) *DependencyStatus {
+			return &DependencyStatus{Name: name, Available: ok, InstallHint: "install " + name}
+		})
+	}
+	return r
+}
+
+// scanCoverage scans a directory with a PDF, an image and an archive using
+// the given options and mocked tools
+func scanCoverage(t *testing.T, opts CoverageOptions, deps *DependencyRegistry) *CoverageReport {
+	t.Helper()
+	dir := t.TempDir()
+	createTestFile(t, dir, "scan.pdf", "%PDF-1.4\n%%EOF\n")
+	createTestFile(t, dir, "photo.png", "\x89PNG\r\n\x1a\n")
+	createTestFile(t, dir, "backup.zip", "PK\x05\x06"+strings.Repeat("\x00", 18))
+	createTestFile(t, dir, "config.txt", "password = hunter2secret\n")
+
+	scanner := NewScanner()
+	scanner.SetDependencyRegistry(deps)
+	if opts.Documents || opts.OCR || opts.Archives {
+		scanner.SetDocumentExtractor(NewDocumentExtractor(opts.OCR))
+		scanner.SetScanDocuments(opts.Documents)
+		scanner.SetScanArchives(opts.Archives)
+	}
+
+	result, err := scanner.Scan(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Coverage == nil {
+		t.Fatal("scan result has no coverage report")
+	}
+	return result.Coverage
+}
+
+// checkCoverage compares one capability's status and affected file count
+func checkCoverage(t *testing.T, report *CoverageReport, capability Capability, status CoverageStatus, files int) {
+	t.Helper()
+	c := report.Get(capability)
+	if c == nil {
+		t.Fatalf("%s missing from the report", capability)
+	}
+	if c.Status != status || c.FilesAffected != files {
+		t.Errorf("%s: got %s with %d files (%s), want %s with %d", capability, c.Status, c.FilesAffected, c.Reason, status, files)
+	}
+}
+
+// TestCoverageOptionsDisabled tests files of disabled capabilities are
+// counted without raising a warning
+func TestCoverageOptionsDisabled(t *testing.T) {
+	report := scanCoverage(t, CoverageOptions{}, coverageRegistry(DependencyTesseract, DependencyPoppler, DependencyOllama))
+
+	checkCoverage(t, report, CapabilityDocuments, CoverageDisabled, 1)
+	checkCoverage(t, report, CapabilityPDFOCR, CoverageDisabled, 0)
+	checkCoverage(t, report, CapabilityImageOCR, CoverageDisabled, 1)
+	checkCoverage(t, report, CapabilityArchives, CoverageDisabled, 1)
+	checkCoverage(t, report, CapabilityAI, CoverageDisabled, 0)
+	if report.Degraded() {
+		t.Errorf("disabled options should not warn: %v", report.Warnings())
+	}
+}
+
+// TestCoverageAllToolsMissing tests requested OCR is reported unavailable
+// with the files it could not check
+func TestCoverageAllToolsMissing(t *testing.T) {
+	opts := CoverageOptions{Documents: true, OCR: true, Archives: true}
+	report := scanCoverage(t, opts, coverageRegistry())
+
+	checkCoverage(t, report, CapabilityDocuments, CoverageEnabled, 0)
+	checkCoverage(t, report, CapabilityPDFOCR, CoverageUnavailable, 1)
+	checkCoverage(t, report, CapabilityImageOCR, CoverageUnavailable, 1)
+	checkCoverage(t, report, CapabilityArchives, CoverageEnabled, 0)
+
+	pdf := report.Get(CapabilityPDFOCR)
+	if pdf.Reason != "Poppler недоступен" || pdf.InstallHint != "install "+DependencyPoppler {
+		t.Errorf("unexpected PDF reason %q, hint %q", pdf.Reason, pdf.InstallHint)
+	}
+	want := []string{"1 PDF пропущен: Poppler недоступен", "1 изображение пропущено: Tesseract недоступен"}
+	if got := report.Warnings(); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("unexpected warnings %q", got)
+	}
+}
+
+// TestCoverageTesseractMissing tests PDF OCR names Tesseract when Poppler is present
+func TestCoverageTesseractMissing(t *testing.T) {
+	opts := CoverageOptions{Documents: true, OCR: true}
+	report := scanCoverage(t, opts, coverageRegistry(DependencyPoppler))
+
+	if c := report.Get(CapabilityPDFOCR); c.Status != CoverageUnavailable || c.Reason != "Tesseract недоступен" {
+		t.Errorf("unexpected PDF OCR entry %+v", c)
+	}
+	checkCoverage(t, report, CapabilityImageOCR, CoverageUnavailable, 1)
+	checkCoverage(t, report, CapabilityArchives, CoverageDisabled, 1)
+}
+
+// TestCoverageAI tests the AI entry falls back to rules without Ollama
+func TestCoverageAI(t *testing.T) {
+	report := BuildCoverage(NewScanResult(), CoverageOptions{}, coverageRegistry())
+	report.SetAI(true, coverageRegistry())
+
+	ai := report.Get(CapabilityAI)
+	if ai.Status != CoverageUnavailable || !strings.Contains(ai.Reason, "анализ по правилам") {
+		t.Errorf("unexpected AI entry %+v", ai)
+	}
+	if got := report.Warnings(); len(got) != 1 || !strings.HasPrefix(got[0], "AI-анализ: Ollama недоступен") {
+		t.Errorf("unexpected warnings %q", got)
+	}
+
+	report.SetAI(true, coverageRegistry(DependencyOllama))
+	if report.Degraded() {
+		t.Error("AI with Ollama running should not warn")
+	}
+}
+
+// TestCoverageWarningsEnglish tests the warnings and reasons of a report
+// kept in Russian are shown in English
+func TestCoverageWarningsEnglish(t *testing.T) {
+	report := scanCoverage(t, CoverageOptions{Documents: true, OCR: true}, coverageRegistry())
+	report.SetAI(true, coverageRegistry())
+
+	l := locale.New(locale.English)
+	want := []string{
+		"1 PDF skipped: Poppler is unavailable",
+		"1 image skipped: Tesseract is unavailable",
+		"AI analysis: Ollama is unavailable, rule-based analysis was used",
+	}
+	if got := LocalizeCoverageWarnings(l, report); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("unexpected warnings %q", got)
+	}
+	if got := LocalizeCoverageReason(l, "сканирование архивов выключено"); got != "archive scanning is off" {
+		t.Errorf("unexpected reason %q", got)
+	}
+}
+
+// TestCoverageSummaryPlurals tests the per-capability wording
+func TestCoverageSummaryPlurals(t *testing.T) {
+	tests := map[string]CapabilityCoverage{
+		"47 PDF пропущено: Poppler недоступен":           {Capability: CapabilityPDFOCR, FilesAffected: 47, Reason: "Poppler недоступен"},
+		"21 документ пропущен: x":                        {Capability: CapabilityDocuments, FilesAffected: 21, Reason: "x"},
+		"3 архива пропущено: x":                          {Capability: CapabilityArchives, FilesAffected: 3, Reason: "x"},
+		"OCR изображений: Tesseract недоступен":          {Capability: CapabilityImageOCR, Reason: "Tesseract недоступен"},
+		"11 изображений пропущено: Tesseract недоступен": {Capability: CapabilityImageOCR, FilesAffected: 11, Reason: "Tesseract недоступен"},
+	}
+	for want, c := range tests {
+		if got := c.Summary(); got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	}
+}
+
+// TestCoverageInReports tests coverage appears in every report format and
+// survives loading a JSON report
+func TestCoverageInReports(t *testing.T) {
+	result := NewScanResult()
+	result.AddCapabilityGap(CapabilityPDFOCR)
+	result.AddCapabilityGap(CapabilityPDFOCR)
+	result.Coverage = BuildCoverage(result, CoverageOptions{Documents: true, OCR: true}, coverageRegistry(DependencyTesseract))
+	rg := NewReportGenerator(result)
+
+	var text bytes.Buffer
+	if err := rg.writePlainText(&text); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(text.String(), "ПОКРЫТИЕ СКАНИРОВАНИЯ") ||
+		!strings.Contains(text.String(), "OCR сканированных PDF: недоступно — Poppler недоступен (затронуто файлов: 2)") {
+		t.Errorf("text report lacks coverage:\n%s", text.String())
+	}
+
+	var csvOut bytes.Buffer
+	if err := rg.writeCSV(&csvOut); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(csvOut.String(), "OCR сканированных PDF,недоступно,Poppler недоступен,2") {
+		t.Errorf("CSV report lacks coverage:\n%s", csvOut.String())
+	}
+
+	path := filepath.Join(t.TempDir(), "report.json")
+	if err := rg.ExportJSON(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadScanResult(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := loaded.Coverage.Get(CapabilityPDFOCR); c == nil || c.FilesAffected != 2 || c.Status != CoverageUnavailable {
+		t.Errorf("coverage not restored from JSON: %+v", loaded.Coverage)
+	}
+
+	data, _ := json.Marshal(result.Coverage)
+	if !strings.Contains(string(data), `"capability":"pdf_ocr","status":"unavailable"`) {
+		t.Errorf("unexpected JSON %s", data)
+	}
+}
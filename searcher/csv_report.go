@@ -0,0 +1,279 @@
+package searcher
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/kacebover/password-finder/locale"
+)
+
+// CSV output for spreadsheets. Every cell is written by encoding/csv, which
+// quotes commas, quotes and line breaks, and a cell that a spreadsheet
+// would take for a formula gets a leading apostrophe: a scanned file may
+// hold "=cmd|' /C calc'!A0" on purpose. The columns can be chosen with
+// SetCSVColumns; by default a UTF-8 BOM comes first so that Excel shows
+// Cyrillic text correctly
+
+// csvColumn is a column of the CSV report
+type csvColumn struct {
+	name  string                                       // Name for SetCSVColumns; the heading is csv.<name>
+	value func(l *locale.Localizer, f *Finding) string // Cell of a finding
+}
+
+// csvColumns are all columns of the CSV report, one per field of Finding
+var csvColumns = []csvColumn{
+	{"path", func(l *locale.Localizer, f *Finding) string { return f.FilePath }},
+	{"line", func(l *locale.Localizer, f *Finding) string { return strconv.Itoa(f.LineNumber) }},
+	{"column_start", func(l *locale.Localizer, f *Finding) string { return strconv.Itoa(f.ColumnStart) }},
+	{"column_end", func(l *locale.Localizer, f *Finding) string { return strconv.Itoa(f.ColumnEnd) }},
+	{"byte_start", func(l *locale.Localizer, f *Finding) string { return strconv.Itoa(f.ByteStart) }},
+	{"byte_end", func(l *locale.Localizer, f *Finding) string { return strconv.Itoa(f.ByteEnd) }},
+	{"type", func(l *locale.Localizer, f *Finding) string { return l.Pattern(string(f.PatternType)) }},
+	{"severity", func(l *locale.Localizer, f *Finding) string { return l.Severity(string(f.Severity)) }},
+	{"risk", func(l *locale.Localizer, f *Finding) string { return strconv.FormatFloat(f.RiskScore, 'f', 2, 64) }},
+	{"base_risk", func(l *locale.Localizer, f *Finding) string { return strconv.FormatFloat(f.BaseRiskScore, 'f', 2, 64) }},
+	{"entropy", func(l *locale.Localizer, f *Finding) string { return strconv.FormatFloat(f.EntropyScore, 'f', 4, 64) }},
+	{"description", func(l *locale.Localizer, f *Finding) string { return LocalizeDescription(l, f.Description) }},
+	{"match", func(l *locale.Localizer, f *Finding) string { return truncateText(f.MatchedText) }},
+	{"context", func(l *locale.Localizer, f *Finding) string { return truncateText(f.Context) }},
+	{"context_before", func(l *locale.Localizer, f *Finding) string { return csvLines(f.ContextBefore) }},
+	{"context_after", func(l *locale.Localizer, f *Finding) string { return csvLines(f.ContextAfter) }},
+	{"sha256", func(l *locale.Localizer, f *Finding) string { return f.MatchSHA256 }},
+	{"secret_id", func(l *locale.Localizer, f *Finding) string { return f.SecretID }},
+	{"key_path", func(l *locale.Localizer, f *Finding) string { return f.KeyPath }},
+	{"rule", func(l *locale.Localizer, f *Finding) string { return f.RuleID }},
+	{"source", func(l *locale.Localizer, f *Finding) string { return f.Source }},
+	{"group", func(l *locale.Localizer, f *Finding) string { return f.Group }},
+	{"baseline", func(l *locale.Localizer, f *Finding) string { return LocalizeBaselineStatus(l, f.Baseline) }},
+	{"git_status", func(l *locale.Localizer, f *Finding) string { return LocalizeGitStatus(l, f.GitStatus) }},
+	{"commit", func(l *locale.Localizer, f *Finding) string { return f.Commit }},
+	{"author", func(l *locale.Localizer, f *Finding) string { return f.Author }},
+	{"commit_date", func(l *locale.Localizer, f *Finding) string { return f.CommitDate }},
+	{"page", func(l *locale.Localizer, f *Finding) string { return pageColumn(f.PageNumber) }},
+	{"page_line", func(l *locale.Localizer, f *Finding) string { return pageColumn(f.PageLine) }},
+	{"archive_path", func(l *locale.Localizer, f *Finding) string { return f.ArchivePath }},
+	{"inner_path", func(l *locale.Localizer, f *Finding) string { return f.InnerPath }},
+	{"explanation", func(l *locale.Localizer, f *Finding) string { return f.Explanation }},
+}
+
+// DefaultCSVColumns are the columns of the CSV report unless SetCSVColumns
+// chose others. The commit, page and location columns are added when a
+// finding has them
+var DefaultCSVColumns = []string{
+	"path", "line", "column_start", "column_end", "type", "severity",
+	"risk", "entropy", "description", "match", "context", "sha256",
+}
+
+// CSVColumnNames returns the names of all columns of the CSV report
+func CSVColumnNames() []string {
+	names := make([]string, len(csvColumns))
+	for i, column := range csvColumns {
+		names[i] = column.name
+	}
+	return names
+}
+
+// lookupCSVColumn returns the column called name
+func lookupCSVColumn(name string) (csvColumn, bool) {
+	for _, column := range csvColumns {
+		if column.name == name {
+			return column, true
+		}
+	}
+	return csvColumn{}, false
+}
+
+// ParseCSVColumns parses a comma-separated list of CSV columns such as
+// "path,line,risk". An empty list means the default columns and returns nil
+func ParseCSVColumns(list string) ([]string, error) {
+	var columns []string
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(list, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || seen[name] {
+			continue
+		}
+		if _, ok := lookupCSVColumn(name); !ok {
+			return nil, fmt.Errorf("неизвестная колонка CSV: %q (допустимо: %s)", name, strings.Join(CSVColumnNames(), ", "))
+		}
+		seen[name] = true
+		columns = append(columns, name)
+	}
+	return columns, nil
+}
+
+// SetCSVColumns sets the columns of the CSV report in order, see
+// CSVColumnNames. An empty list restores DefaultCSVColumns
+func (rg *ReportGenerator) SetCSVColumns(columns []string) error {
+	parsed, err := ParseCSVColumns(strings.Join(columns, ","))
+	if err != nil {
+		return err
+	}
+	rg.csvColumns = parsed
+	return nil
+}
+
+// SetCSVBOM sets whether the CSV report starts with a UTF-8 BOM, on by
+// default. Excel needs it to read the file as UTF-8; other tools may not
+// expect it
+func (rg *ReportGenerator) SetCSVBOM(bom bool) {
+	rg.noCSVBOM = !bom
+}
+
+// ExportCSV exports findings to a CSV file
+func (rg *ReportGenerator) ExportCSV(filePath string) error {
+	return writeFileAtomic(filePath, reportFileMode, rg.writeCSV)
+}
+
+// csvLayout returns the columns of the CSV report
+func (rg *ReportGenerator) csvLayout() []csvColumn {
+	names := rg.csvColumns
+	if names == nil {
+		names = append([]string(nil), DefaultCSVColumns...)
+		findings := rg.result.allFindings()
+		// Commit columns only appear in history scans
+		if hasCommitInfo(findings) {
+			names = append(names, "commit", "author", "commit_date")
+		}
+		// Page columns only appear with PDF findings
+		if hasPageInfo(findings) {
+			names = append(names, "page", "page_line")
+		}
+		// Location columns only appear when a path or git status was weighed
+		if hasLocationInfo(findings) {
+			names = append(names, "base_risk", "git_status")
+		}
+	}
+	columns := make([]csvColumn, 0, len(names))
+	for _, name := range names {
+		if column, ok := lookupCSVColumn(name); ok {
+			columns = append(columns, column)
+		}
+	}
+	return columns
+}
+
+// writeCSV writes the CSV report to w
+func (rg *ReportGenerator) writeCSV(file io.Writer) error {
+	if !rg.noCSVBOM {
+		if _, err := file.Write([]byte{0xEF, 0xBB, 0xBF}); err != nil {
+			return err
+		}
+	}
+
+	writer := csv.NewWriter(file)
+	l := rg.locale
+	columns := rg.csvLayout()
+
+	header := make([]string, len(columns))
+	for i, column := range columns {
+		header[i] = l.T("csv." + column.name)
+	}
+	if err := writeCSVRecord(writer, header); err != nil {
+		return err
+	}
+
+	var writeErr error
+	err := rg.forEachFinding(func(finding *Finding) bool {
+		record := make([]string, len(columns))
+		for i, column := range columns {
+			record[i] = column.value(l, finding)
+		}
+		writeErr = writeCSVRecord(writer, record)
+		return writeErr == nil
+	})
+	if err == nil {
+		err = writeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	// Coverage goes after an empty row so the findings table stays intact
+	if coverage := rg.result.Coverage; coverage != nil {
+		rows := [][]string{
+			{},
+			{l.T("csv.capability"), l.T("csv.status"), l.T("csv.reason"), l.T("csv.files_affected")},
+		}
+		for _, c := range coverage.Capabilities {
+			rows = append(rows, []string{LocalizeCapability(l, c.Capability), LocalizeCoverageStatus(l, c.Status), LocalizeCoverageReason(l, c.Reason), strconv.Itoa(c.FilesAffected)})
+		}
+		for _, row := range rows {
+			if err := writeCSVRecord(writer, row); err != nil {
+				return err
+			}
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// writeCSVRecord writes a row with every cell made safe for spreadsheets
+func writeCSVRecord(writer *csv.Writer, record []string) error {
+	for i, cell := range record {
+		record[i] = neutralizeFormula(cell)
+	}
+	return writer.Write(record)
+}
+
+// neutralizeFormula keeps a cell from being read as a formula: text
+// starting with =, +, -, @ or a control character gets a leading
+// apostrophe, as Excel does for typed text
+func neutralizeFormula(text string) string {
+	if text != "" && strings.ContainsRune("=+-@\t\r\n", rune(text[0])) {
+		return "'" + text
+	}
+	return text
+}
+
+// csvLines joins context lines into one cell
+func csvLines(lines []string) string {
+	truncated := make([]string, len(lines))
+	for i, line := range lines {
+		truncated[i] = truncateText(line)
+	}
+	return strings.Join(truncated, "\n")
+}
+
+// hasCommitInfo reports whether any finding comes from a history scan
+func hasCommitInfo(findings findingSeq) bool {
+	found := false
+	findings(func(f *Finding) bool {
+		found = f.Commit != ""
+		return !found
+	})
+	return found
+}
+
+// hasPageInfo reports whether any finding has a PDF page
+func hasPageInfo(findings findingSeq) bool {
+	found := false
+	findings(func(f *Finding) bool {
+		found = f.PageNumber != 0
+		return !found
+	})
+	return found
+}
+
+// hasLocationInfo reports whether the path of any finding changed its risk
+// score or any finding has a git status
+func hasLocationInfo(findings findingSeq) bool {
+	found := false
+	findings(func(f *Finding) bool {
+		found = f.PathAdjusted() || f.GitStatus != ""
+		return !found
+	})
+	return found
+}
+
+// pageColumn leaves unknown page numbers empty
+func pageColumn(n int) string {
+	if n == 0 {
+		return ""
+	}
+	return strconv.Itoa(n)
+}
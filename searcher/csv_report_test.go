@@ -0,0 +1,100 @@
+package searcher
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+	"testing"
+
+	"github.com/kacebover/password-finder/locale"
+)
+
+// readCSVReport writes the CSV report of rg and parses it back
+func readCSVReport(t *testing.T, rg *ReportGenerator) (bom bool, records [][]string) {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := rg.writeCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	bom = bytes.HasPrefix(data, []byte{0xEF, 0xBB, 0xBF})
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte{0xEF, 0xBB, 0xBF})))
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		t.Fatalf("report does not parse back: %v\n%s", err, data)
+	}
+	return bom, records
+}
+
+// TestCSVFormulaInjection tests cells a spreadsheet would run as formulas
+// are neutralized and the rest survives a round trip
+func TestCSVFormulaInjection(t *testing.T) {
+	result := NewScanResult()
+	result.AddFinding(&Finding{
+		FilePath: "exports/\"odd\", name.csv", LineNumber: 3, PatternType: PatternPassword, Severity: High,
+		RiskScore: 70, MatchedText: "@SUM(1+1)", Context: "=cmd|' /C calc'!A0",
+		ContextBefore: []string{"first line", "-2+3"}, Description: "Пароль",
+	})
+	rg := NewReportGenerator(result)
+	rg.SetIncludeSecrets(true)
+	if err := rg.SetCSVColumns([]string{"path", "match", "context", "context_before", "risk"}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, records := readCSVReport(t, rg)
+	if len(records) != 2 {
+		t.Fatalf("%d records: %q", len(records), records)
+	}
+	want := []string{"exports/\"odd\", name.csv", "'@SUM(1+1)", "'=cmd|' /C calc'!A0", "first line\n-2+3", "70.00"}
+	for i, cell := range records[1] {
+		if cell != want[i] {
+			t.Errorf("%s = %q, want %q", records[0][i], cell, want[i])
+		}
+	}
+	for _, cell := range records[1] {
+		if cell != "" && strings.ContainsRune("=+-@", rune(cell[0])) {
+			t.Errorf("cell %q starts a formula", cell)
+		}
+	}
+}
+
+func TestCSVColumns(t *testing.T) {
+	rg := NewReportGenerator(pruneFixture())
+	bom, records := readCSVReport(t, rg)
+	if !bom || len(records[0]) != len(DefaultCSVColumns) || records[0][0] != "Путь к файлу" {
+		t.Errorf("default report: BOM %v, header %q", bom, records[0])
+	}
+
+	if err := rg.SetCSVColumns([]string{"Risk", " entropy", "path", "risk"}); err != nil {
+		t.Fatal(err)
+	}
+	rg.SetCSVBOM(false)
+	bom, records = readCSVReport(t, rg)
+	if bom {
+		t.Error("BOM written while off")
+	}
+	if got := strings.Join(records[0], "|"); got != "Оценка риска|Энтропия|Путь к файлу" {
+		t.Errorf("header = %s", got)
+	}
+	if got := strings.Join(records[1], "|"); got != "90.00|0.0000|a.env" {
+		t.Errorf("first row = %s", got)
+	}
+
+	if err := rg.SetCSVColumns([]string{"path", "password"}); err == nil {
+		t.Error("unknown column accepted")
+	}
+	if err := rg.SetCSVColumns(nil); err != nil || rg.csvColumns != nil {
+		t.Errorf("columns not reset: %v, %v", err, rg.csvColumns)
+	}
+
+	// Every column has a heading in every language
+	for _, lang := range locale.Langs {
+		l := locale.New(lang)
+		for _, name := range CSVColumnNames() {
+			if _, ok := l.Lookup("csv." + name); !ok {
+				t.Errorf("%s: no heading for column %s", lang, name)
+			}
+		}
+	}
+}
@@ -1,155 +1,466 @@
 package searcher
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"regexp"
 	"runtime"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
 // DependencyStatus represents the status of a single dependency
 type DependencyStatus struct {
-	Name        string `json:"name"`
-	Available   bool   `json:"available"`
-	Version     string `json:"version,omitempty"`
-	Path        string `json:"path,omitempty"`
-	Required    bool   `json:"required"`
-	Description string `json:"description"`
-	InstallHint string `json:"install_hint"`
+	Name        string   `json:"name"`
+	Available   bool     `json:"available"`
+	Version     string   `json:"version,omitempty"`     // Dotted version, such as 5.3.0
+	MinVersion  string   `json:"min_version,omitempty"` // Oldest supported version, empty for any
+	Outdated    bool     `json:"outdated,omitempty"`    // Version is older than MinVersion
+	Models      []string `json:"models,omitempty"`      // Models installed in Ollama
+	Path        string   `json:"path,omitempty"`
+	Required    bool     `json:"required"`
+	Description string   `json:"description"`
+	InstallHint string   `json:"install_hint"`
 }
 
-// DependencyChecker checks for required external dependencies
+// VersionWarning describes an outdated dependency in Russian, "" for a
+// supported one
+func (s *DependencyStatus) VersionWarning() string {
+	return LocalizeVersionWarning(russian, s)
+}
+
+// DependencyProbe detects a single external dependency.
+// Probes must honour ctx so a hung binary cannot stall a scan.
+type DependencyProbe func(ctx context.Context) *DependencyStatus
+
+// Dependency keys used by the registry
+const (
+	DependencyTesseract = "tesseract"
+	DependencyPoppler   = "poppler"
+	DependencyOllama    = "ollama"
+	DependencySevenZip  = "7z"
+)
+
+// DefaultProbeTimeout bounds how long a single dependency probe may run
+const DefaultProbeTimeout = 5 * time.Second
+
+// Oldest versions of the tools known to work; older ones are reported as
+// outdated but still used
+const (
+	MinTesseractVersion = "4.0.0"  // The LSTM engine; 3.x misreads most screenshots
+	MinOllamaVersion    = "0.3.12" // The first to run llama3.2, the default model
+	MinGitVersion       = "2.5.0"  // rev-parse --git-path of hook install
+)
+
+// lookPath, runCommand and executableDirs reach the system; tests replace them
+var (
+	lookPath   = exec.LookPath
+	runCommand = func(ctx context.Context, path string, args ...string) ([]byte, error) {
+		return exec.CommandContext(ctx, path, args...).CombinedOutput()
+	}
+	executableDirs = []string{"/usr/local/bin", "/usr/bin", "/opt/homebrew/bin"}
+)
+
+// dependencyEntry caches the result of one probe
+type dependencyEntry struct {
+	mu     sync.Mutex
+	probe  DependencyProbe
+	status *DependencyStatus
+}
+
+// DependencyRegistry runs dependency probes once and shares the results.
+// It is safe for concurrent use; every probe runs at most once until
+// ForceRefresh is called.
+type DependencyRegistry struct {
+	mu      sync.Mutex
+	entries map[string]*dependencyEntry
+	order   []string
+	tools   map[string]string
+	timeout time.Duration
+}
+
+var (
+	defaultRegistry     *DependencyRegistry
+	defaultRegistryOnce sync.Once
+)
+
+// Dependencies returns the process-wide dependency registry
+func Dependencies() *DependencyRegistry {
+	defaultRegistryOnce.Do(func() {
+		defaultRegistry = NewDependencyRegistry()
+	})
+	return defaultRegistry
+}
+
+// RegisterDependency adds the probe of an optional tool to the process-wide
+// registry, so the checks, warnings and diagnostics list it without changes
+// to the checker
+func RegisterDependency(name string, probe DependencyProbe) {
+	Dependencies().SetProbe(name, probe)
+}
+
+// NewDependencyRegistry creates a registry with the built-in probes
+func NewDependencyRegistry() *DependencyRegistry {
+	r := &DependencyRegistry{
+		entries: make(map[string]*dependencyEntry),
+		tools:   make(map[string]string),
+		timeout: DefaultProbeTimeout,
+	}
+	r.SetProbe(DependencyTesseract, probeTesseract)
+	r.SetProbe(DependencyPoppler, probePoppler)
+	r.SetProbe(DependencyOllama, probeOllama)
+	r.SetProbe(DependencySevenZip, probeSevenZip)
+	return r
+}
+
+// SetProbe registers or replaces a probe and drops its cached result
+func (r *DependencyRegistry) SetProbe(name string, probe DependencyProbe) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if entry, ok := r.entries[name]; ok {
+		entry.mu.Lock()
+		entry.probe = probe
+		entry.status = nil
+		entry.mu.Unlock()
+		return
+	}
+	r.entries[name] = &dependencyEntry{probe: probe}
+	r.order = append(r.order, name)
+}
+
+// SetTimeout changes the per-probe timeout
+func (r *DependencyRegistry) SetTimeout(timeout time.Duration) {
+	r.mu.Lock()
+	r.timeout = timeout
+	r.mu.Unlock()
+}
+
+// Names returns the registered dependency keys in registration order
+func (r *DependencyRegistry) Names() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	names := make([]string, len(r.order))
+	copy(names, r.order)
+	return names
+}
+
+// Status returns the status of a dependency, probing it on first use.
+// The returned value is a copy and may be modified by the caller.
+func (r *DependencyRegistry) Status(name string) *DependencyStatus {
+	r.mu.Lock()
+	entry, ok := r.entries[name]
+	timeout := r.timeout
+	r.mu.Unlock()
+	if !ok {
+		return nil
+	}
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	if entry.status == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		status := entry.probe(ctx)
+		cancel()
+		if status == nil {
+			status = &DependencyStatus{Name: name}
+		}
+		status.Outdated = status.Available && status.Version != "" && status.MinVersion != "" &&
+			compareVersions(status.Version, status.MinVersion) < 0
+		entry.status = status
+	}
+
+	copied := *entry.status
+	copied.Models = append([]string(nil), copied.Models...)
+	return &copied
+}
+
+// CheckAll probes every registered dependency in parallel
+func (r *DependencyRegistry) CheckAll() map[string]*DependencyStatus {
+	names := r.Names()
+	statuses := make([]*DependencyStatus, len(names))
+
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			statuses[i] = r.Status(name)
+		}(i, name)
+	}
+	wg.Wait()
+
+	results := make(map[string]*DependencyStatus, len(names))
+	for i, name := range names {
+		results[name] = statuses[i]
+	}
+	return results
+}
+
+// IsAvailable reports whether a dependency was detected
+func (r *DependencyRegistry) IsAvailable(name string) bool {
+	status := r.Status(name)
+	return status != nil && status.Available
+}
+
+// ToolPath resolves an executable once and caches the result
+func (r *DependencyRegistry) ToolPath(binary string) (string, bool) {
+	r.mu.Lock()
+	path, ok := r.tools[binary]
+	r.mu.Unlock()
+	if ok {
+		return path, path != ""
+	}
+
+	path = lookupExecutable(binary)
+
+	r.mu.Lock()
+	r.tools[binary] = path
+	r.mu.Unlock()
+	return path, path != ""
+}
+
+// ForceRefresh drops all cached results so the next query probes again
+func (r *DependencyRegistry) ForceRefresh() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, entry := range r.entries {
+		entry.mu.Lock()
+		entry.status = nil
+		entry.mu.Unlock()
+	}
+	r.tools = make(map[string]string)
+}
+
+// lookupExecutable finds a binary on PATH or in common install locations
+func lookupExecutable(binary string) string {
+	if path, err := lookPath(binary); err == nil {
+		return path
+	}
+
+	for _, dir := range executableDirs {
+		candidate := filepath.Join(dir, binary)
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// versionPattern matches a dotted version number in the output of a tool
+var versionPattern = regexp.MustCompile(`\d+(?:\.\d+)+`)
+
+// toolVersion runs a binary and returns the first version number it prints,
+// "" if it prints none
+func toolVersion(ctx context.Context, path string, args ...string) string {
+	output, _ := runCommand(ctx, path, args...)
+	return versionPattern.FindString(string(output))
+}
+
+// compareVersions compares dotted versions part by part as numbers and
+// returns -1, 0 or 1; missing parts count as 0
+func compareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+	}
+	return 0
+}
+
+// DependencyChecker checks for required external dependencies.
+// Results come from a shared DependencyRegistry, so creating many checkers
+// does not re-run the probes.
 type DependencyChecker struct {
-	results map[string]*DependencyStatus
+	registry *DependencyRegistry
+	results  map[string]*DependencyStatus
 }
 
-// NewDependencyChecker creates a new dependency checker
+// NewDependencyChecker creates a dependency checker backed by the process-wide registry
 func NewDependencyChecker() *DependencyChecker {
+	return NewDependencyCheckerWithRegistry(Dependencies())
+}
+
+// NewDependencyCheckerWithRegistry creates a dependency checker backed by r
+func NewDependencyCheckerWithRegistry(r *DependencyRegistry) *DependencyChecker {
 	return &DependencyChecker{
-		results: make(map[string]*DependencyStatus),
+		registry: r,
+		results:  make(map[string]*DependencyStatus),
 	}
 }
 
 // CheckAll checks all dependencies and returns their statuses
 func (dc *DependencyChecker) CheckAll() map[string]*DependencyStatus {
-	dc.checkTesseract()
-	dc.checkPoppler()
-	dc.checkOllama()
+	dc.results = dc.registry.CheckAll()
 	return dc.results
 }
 
-// checkTesseract checks if Tesseract OCR is available
-func (dc *DependencyChecker) checkTesseract() {
+// Status returns the status of a single dependency by key
+func (dc *DependencyChecker) Status(name string) *DependencyStatus {
+	if dc.results[name] == nil {
+		dc.results[name] = dc.registry.Status(name)
+	}
+	return dc.results[name]
+}
+
+// Refresh probes every dependency again, picking up tools installed or
+// removed since the results were cached
+func (dc *DependencyChecker) Refresh() map[string]*DependencyStatus {
+	dc.registry.ForceRefresh()
+	return dc.CheckAll()
+}
+
+// probeTesseract checks if Tesseract OCR is available
+func probeTesseract(ctx context.Context) *DependencyStatus {
 	status := &DependencyStatus{
 		Name:        "Tesseract OCR",
 		Required:    false,
-		Description: "Распознавание текста на изображениях (OCR)",
-		InstallHint: dc.getTesseractInstallHint(),
+		Description: russian.T("dependency.desc.tesseract"),
+		InstallHint: tesseractInstallHint(),
+		MinVersion:  MinTesseractVersion,
 	}
 
-	path, err := exec.LookPath("tesseract")
-	if err == nil {
+	if path := lookupExecutable("tesseract"); path != "" {
 		status.Available = true
 		status.Path = path
-		// Try to get version
-		cmd := exec.Command(path, "--version")
-		output, err := cmd.Output()
-		if err == nil {
-			lines := strings.Split(string(output), "\n")
-			if len(lines) > 0 {
-				status.Version = strings.TrimSpace(lines[0])
-			}
-		}
+		// "tesseract 5.3.0", or "tesseract v5.3.0.20221214" in some builds
+		status.Version = toolVersion(ctx, path, "--version")
 	}
 
-	dc.results["tesseract"] = status
+	return status
 }
 
-// checkPoppler checks if Poppler (pdftotext, pdftoppm) is available
-func (dc *DependencyChecker) checkPoppler() {
+// probePoppler checks if Poppler (pdftotext, pdftoppm) is available
+func probePoppler(ctx context.Context) *DependencyStatus {
 	status := &DependencyStatus{
 		Name:        "Poppler (PDF utils)",
 		Required:    false,
-		Description: "Извлечение текста и OCR из PDF файлов",
-		InstallHint: dc.getPopplerInstallHint(),
+		Description: russian.T("dependency.desc.poppler"),
+		InstallHint: popplerInstallHint(),
 	}
 
 	// Check for pdftotext
-	pdftotext, err := exec.LookPath("pdftotext")
-	if err == nil {
+	if pdftotext := lookupExecutable("pdftotext"); pdftotext != "" {
 		status.Available = true
 		status.Path = pdftotext
-		// Try to get version
-		cmd := exec.Command(pdftotext, "-v")
-		output, _ := cmd.CombinedOutput()
-		lines := strings.Split(string(output), "\n")
-		if len(lines) > 0 {
-			status.Version = strings.TrimSpace(lines[0])
-		}
+		// "pdftotext version 22.02.0" on stderr
+		status.Version = toolVersion(ctx, pdftotext, "-v")
 	}
 
 	// Also check pdftoppm
-	pdftoppm, err := exec.LookPath("pdftoppm")
-	if err == nil && !status.Available {
+	if pdftoppm := lookupExecutable("pdftoppm"); pdftoppm != "" && !status.Available {
 		status.Available = true
 		status.Path = pdftoppm
 	}
 
-	dc.results["poppler"] = status
+	return status
 }
 
-// checkOllama checks if Ollama is available and running
-func (dc *DependencyChecker) checkOllama() {
+// probeOllama checks if Ollama is available and running
+func probeOllama(ctx context.Context) *DependencyStatus {
 	status := &DependencyStatus{
 		Name:        "Ollama (AI)",
 		Required:    false,
-		Description: "Локальный AI-анализ результатов сканирования",
-		InstallHint: dc.getOllamaInstallHint(),
+		Description: russian.T("dependency.desc.ollama"),
+		InstallHint: ollamaInstallHint(),
+		MinVersion:  MinOllamaVersion,
 	}
 
 	// Check if ollama binary exists
-	path, err := exec.LookPath("ollama")
-	if err == nil {
+	if path := lookupExecutable("ollama"); path != "" {
 		status.Path = path
-		// Try to get version
-		cmd := exec.Command(path, "--version")
-		output, err := cmd.Output()
-		if err == nil {
-			status.Version = strings.TrimSpace(string(output))
-		}
+		// "ollama version is 0.3.12", after a warning when no server runs
+		status.Version = toolVersion(ctx, path, "--version")
+	}
+
+	// Check if Ollama server is running and get the installed models
+	client := Network().Client(NetworkAI, 2*time.Second)
+	var tags struct {
+		Models []struct {
+			Name string `json:"name"`
+		} `json:"models"`
+	}
+	if !getOllamaJSON(ctx, client, "/api/tags", &tags) {
+		return status
+	}
+	status.Available = true
+	for _, m := range tags.Models {
+		status.Models = append(status.Models, m.Name)
+	}
+	// The server may be newer than a binary left on PATH
+	var version struct {
+		Version string `json:"version"`
+	}
+	if getOllamaJSON(ctx, client, "/api/version", &version) && version.Version != "" {
+		status.Version = versionPattern.FindString(version.Version)
+	}
+
+	return status
+}
+
+// getOllamaJSON decodes the response of a GET request to the local Ollama
+// server into v and reports whether it succeeded
+func getOllamaJSON(ctx context.Context, client *http.Client, path string, v any) bool {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, defaultOllamaURL+path, nil)
+	if err != nil {
+		return false
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+	return resp.StatusCode == http.StatusOK && json.NewDecoder(resp.Body).Decode(v) == nil
+}
+
+// sevenZipBinaries are the names of the 7-Zip command line tool, the
+// official build first
+var sevenZipBinaries = []string{"7zz", "7z", "7za"}
+
+// probeSevenZip checks if the 7-Zip command line tool is available
+func probeSevenZip(ctx context.Context) *DependencyStatus {
+	status := &DependencyStatus{
+		Name:        "7-Zip",
+		Required:    false,
+		Description: russian.T("dependency.desc.7z"),
+		InstallHint: sevenZipInstallHint(),
 	}
 
-	// Check if Ollama server is running
-	client := &http.Client{Timeout: 2 * time.Second}
-	resp, err := client.Get("http://localhost:11434/api/tags")
-	if err == nil {
-		defer resp.Body.Close()
-		if resp.StatusCode == 200 {
+	for _, binary := range sevenZipBinaries {
+		if path := lookupExecutable(binary); path != "" {
 			status.Available = true
-			// Try to get installed models
-			var result struct {
-				Models []struct {
-					Name string `json:"name"`
-				} `json:"models"`
-			}
-			if json.NewDecoder(resp.Body).Decode(&result) == nil && len(result.Models) > 0 {
-				var modelNames []string
-				for _, m := range result.Models {
-					modelNames = append(modelNames, m.Name)
-				}
-				status.Version = fmt.Sprintf("Модели: %s", strings.Join(modelNames, ", "))
-			}
+			status.Path = path
+			// Without arguments 7-Zip prints "7-Zip [64] 16.02 : Copyright" and its usage
+			status.Version = toolVersion(ctx, path)
+			break
 		}
 	}
 
-	dc.results["ollama"] = status
+	return status
 }
 
-// getTesseractInstallHint returns platform-specific install instructions
-func (dc *DependencyChecker) getTesseractInstallHint() string {
+// tesseractInstallHint returns platform-specific install instructions
+func tesseractInstallHint() string {
 	switch runtime.GOOS {
 	case "darwin":
 		return "brew install tesseract tesseract-lang"
@@ -162,8 +473,8 @@ func (dc *DependencyChecker) getTesseractInstallHint() string {
 	}
 }
 
-// getPopplerInstallHint returns platform-specific install instructions
-func (dc *DependencyChecker) getPopplerInstallHint() string {
+// popplerInstallHint returns platform-specific install instructions
+func popplerInstallHint() string {
 	switch runtime.GOOS {
 	case "darwin":
 		return "brew install poppler"
@@ -176,8 +487,8 @@ func (dc *DependencyChecker) getPopplerInstallHint() string {
 	}
 }
 
-// getOllamaInstallHint returns platform-specific install instructions
-func (dc *DependencyChecker) getOllamaInstallHint() string {
+// ollamaInstallHint returns platform-specific install instructions
+func ollamaInstallHint() string {
 	switch runtime.GOOS {
 	case "darwin":
 		return "brew install ollama && ollama pull llama3.2"
@@ -190,28 +501,38 @@ func (dc *DependencyChecker) getOllamaInstallHint() string {
 	}
 }
 
+// sevenZipInstallHint returns platform-specific install instructions
+func sevenZipInstallHint() string {
+	switch runtime.GOOS {
+	case "darwin":
+		return "brew install sevenzip"
+	case "linux":
+		return "sudo apt install 7zip"
+	case "windows":
+		return "Скачать: https://www.7-zip.org/download.html"
+	default:
+		return "Установите 7-Zip для вашей системы"
+	}
+}
+
 // IsTesseractAvailable returns true if Tesseract is available
 func (dc *DependencyChecker) IsTesseractAvailable() bool {
-	if dc.results["tesseract"] == nil {
-		dc.checkTesseract()
-	}
-	return dc.results["tesseract"].Available
+	return dc.Status(DependencyTesseract).Available
 }
 
 // IsPopplerAvailable returns true if Poppler is available
 func (dc *DependencyChecker) IsPopplerAvailable() bool {
-	if dc.results["poppler"] == nil {
-		dc.checkPoppler()
-	}
-	return dc.results["poppler"].Available
+	return dc.Status(DependencyPoppler).Available
 }
 
 // IsOllamaAvailable returns true if Ollama is available and running
 func (dc *DependencyChecker) IsOllamaAvailable() bool {
-	if dc.results["ollama"] == nil {
-		dc.checkOllama()
-	}
-	return dc.results["ollama"].Available
+	return dc.Status(DependencyOllama).Available
+}
+
+// IsSevenZipAvailable returns true if the 7-Zip command line tool is available
+func (dc *DependencyChecker) IsSevenZipAvailable() bool {
+	return dc.Status(DependencySevenZip).Available
 }
 
 // GetMissingDependencies returns a list of dependencies that are not available
@@ -236,6 +557,18 @@ func (dc *DependencyChecker) GetAvailableDependencies() []*DependencyStatus {
 	return available
 }
 
+// GetOutdatedDependencies returns the available dependencies older than
+// their minimum supported version, in registration order
+func (dc *DependencyChecker) GetOutdatedDependencies() []*DependencyStatus {
+	var outdated []*DependencyStatus
+	for _, name := range dc.registry.Names() {
+		if status := dc.results[name]; status != nil && status.Outdated {
+			outdated = append(outdated, status)
+		}
+	}
+	return outdated
+}
+
 // FormatStatusReport returns a formatted string with dependency statuses
 func (dc *DependencyChecker) FormatStatusReport() string {
 	var sb strings.Builder
@@ -248,6 +581,9 @@ func (dc *DependencyChecker) FormatStatusReport() string {
 				sb.WriteString(fmt.Sprintf(" (%s)", status.Version))
 			}
 			sb.WriteString("\n")
+			if warning := status.VersionWarning(); warning != "" {
+				sb.WriteString(fmt.Sprintf("   ⚠️ %s\n", warning))
+			}
 		} else {
 			sb.WriteString(fmt.Sprintf("❌ %s - не установлен\n", status.Name))
 			sb.WriteString(fmt.Sprintf("   💡 %s\n", status.InstallHint))
@@ -273,3 +609,18 @@ func (dc *DependencyChecker) FormatMissingWarning() string {
 	return sb.String()
 }
 
+// FormatOutdatedWarning returns a warning message about outdated dependencies
+func (dc *DependencyChecker) FormatOutdatedWarning() string {
+	outdated := dc.GetOutdatedDependencies()
+	if len(outdated) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("⚠️ Устаревшие зависимости:\n")
+	for _, status := range outdated {
+		sb.WriteString(fmt.Sprintf("   • %s\n", status.VersionWarning()))
+		sb.WriteString(fmt.Sprintf("     📝 %s\n", status.InstallHint))
+	}
+	return sb.String()
+}
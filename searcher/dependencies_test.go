@@ -0,0 +1,240 @@
+package searcher
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newMockRegistry returns a registry whose probes only count their calls
+func newMockRegistry(calls *int64, available bool) *DependencyRegistry {
+	r := NewDependencyRegistry()
+	for _, name := range r.Names() {
+		name := name
+		r.SetProbe(name, func(ctx context.Context) *DependencyStatus {
+			atomic.AddInt64(calls, 1)
+			time.Sleep(5 * time.Millisecond)
+			return &DependencyStatus{Name: name, Available: available, InstallHint: "install " + name}
+		})
+	}
+	return r
+}
+
+// TestDependencyRegistryProbesOnce tests each probe runs once under concurrent access
+func TestDependencyRegistryProbesOnce(t *testing.T) {
+	var calls int64
+	r := newMockRegistry(&calls, true)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			r.CheckAll()
+		}()
+		go func() {
+			defer wg.Done()
+			NewDependencyCheckerWithRegistry(r).IsTesseractAvailable()
+		}()
+		go func() {
+			defer wg.Done()
+			r.IsAvailable(DependencyOllama)
+		}()
+	}
+	wg.Wait()
+
+	if got := atomic.LoadInt64(&calls); got != int64(len(r.Names())) {
+		t.Errorf("expected %d probe calls, got %d", len(r.Names()), got)
+	}
+}
+
+// TestDependencyRegistryForceRefresh tests cached results are dropped on refresh
+func TestDependencyRegistryForceRefresh(t *testing.T) {
+	var calls int64
+	r := newMockRegistry(&calls, false)
+
+	r.CheckAll()
+	r.ForceRefresh()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			r.CheckAll()
+		}()
+		go func() {
+			defer wg.Done()
+			r.ForceRefresh()
+		}()
+	}
+	wg.Wait()
+	r.CheckAll()
+
+	if got := atomic.LoadInt64(&calls); got < int64(2*len(r.Names())) {
+		t.Errorf("expected probes to run again after ForceRefresh, got %d calls", got)
+	}
+}
+
+// TestDependencyRegistryStatusIsCopy tests callers cannot corrupt the cache
+func TestDependencyRegistryStatusIsCopy(t *testing.T) {
+	var calls int64
+	r := newMockRegistry(&calls, true)
+
+	status := r.Status(DependencyTesseract)
+	status.Available = false
+
+	if !r.IsAvailable(DependencyTesseract) {
+		t.Error("modifying a returned status should not change the registry")
+	}
+	if r.Status("unknown") != nil {
+		t.Error("unknown dependency should return nil")
+	}
+}
+
+// TestDependencyRegistryTimeout tests probes receive a deadline
+func TestDependencyRegistryTimeout(t *testing.T) {
+	r := NewDependencyRegistry()
+	r.SetTimeout(20 * time.Millisecond)
+	r.SetProbe("slow", func(ctx context.Context) *DependencyStatus {
+		select {
+		case <-ctx.Done():
+			return &DependencyStatus{Name: "slow"}
+		case <-time.After(5 * time.Second):
+			return &DependencyStatus{Name: "slow", Available: true}
+		}
+	})
+
+	start := time.Now()
+	if r.IsAvailable("slow") {
+		t.Error("timed out probe should report unavailable")
+	}
+	if time.Since(start) > 2*time.Second {
+		t.Error("probe should be cancelled by the registry timeout")
+	}
+}
+
+// TestDependencyCheckerMissing tests the checker reports mocked missing dependencies
+func TestDependencyCheckerMissing(t *testing.T) {
+	var calls int64
+	dc := NewDependencyCheckerWithRegistry(newMockRegistry(&calls, false))
+	dc.CheckAll()
+
+	if len(dc.GetMissingDependencies()) != 4 {
+		t.Errorf("expected 4 missing dependencies, got %d", len(dc.GetMissingDependencies()))
+	}
+	if dc.Status(DependencyPoppler).InstallHint != "install poppler" {
+		t.Errorf("unexpected install hint: %q", dc.Status(DependencyPoppler).InstallHint)
+	}
+	if dc.FormatMissingWarning() == "" {
+		t.Error("missing warning should not be empty")
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"5.3.0", "4.0.0", 1},
+		{"3.05.02", "4.0.0", -1},
+		{"4.0", "4.0.0", 0},
+		{"0.3.9", "0.3.12", -1},
+		{"2.45.1", "2.5.0", 1},
+	}
+	for _, tt := range tests {
+		if got := compareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+// fakeTools makes the probes see only the given tools, each printing its
+// output when run
+func fakeTools(t *testing.T, outputs map[string]string) {
+	origLookPath, origRun, origDirs := lookPath, runCommand, executableDirs
+	t.Cleanup(func() { lookPath, runCommand, executableDirs = origLookPath, origRun, origDirs })
+
+	executableDirs = nil
+	lookPath = func(binary string) (string, error) {
+		if _, ok := outputs[binary]; !ok {
+			return "", errors.New("not found")
+		}
+		return "/fake/bin/" + binary, nil
+	}
+	runCommand = func(ctx context.Context, path string, args ...string) ([]byte, error) {
+		return []byte(outputs[filepath.Base(path)]), nil
+	}
+}
+
+// TestDependencyProbesParseVersions tests versions are parsed from the
+// output of the tools and compared with the minimum ones
+func TestDependencyProbesParseVersions(t *testing.T) {
+	fakeTools(t, map[string]string{
+		"tesseract": "tesseract 3.05.02\n leptonica-1.74.4\n",
+		"pdftotext": "pdftotext version 22.02.0\nCopyright 2005-2022 The Poppler Developers\n",
+		"git":       "git version 2.45.1.windows.1\n",
+		"7za":       "\n7-Zip (a) [64] 16.02 : Copyright (c) 1999-2016 Igor Pavlov : 2016-05-21\n",
+	})
+	r := NewDependencyRegistry()
+	r.SetProbe(DependencyGit, probeGit)
+	dc := NewDependencyCheckerWithRegistry(r)
+
+	tests := []struct {
+		name, version string
+		outdated      bool
+	}{
+		{DependencyTesseract, "3.05.02", true},
+		{DependencyPoppler, "22.02.0", false},
+		{DependencyGit, "2.45.1", false},
+		{DependencySevenZip, "16.02", false},
+	}
+	for _, tt := range tests {
+		status := dc.Status(tt.name)
+		if !status.Available || status.Version != tt.version || status.Outdated != tt.outdated {
+			t.Errorf("%s: available %v, version %q, outdated %v; want %q, outdated %v",
+				tt.name, status.Available, status.Version, status.Outdated, tt.version, tt.outdated)
+		}
+	}
+
+	outdated := dc.GetOutdatedDependencies()
+	if len(outdated) != 1 || outdated[0].Name != "Tesseract OCR" {
+		t.Fatalf("outdated dependencies %+v, want Tesseract only", outdated)
+	}
+	warning := dc.FormatOutdatedWarning()
+	if !strings.Contains(warning, "3.05.02") || !strings.Contains(warning, MinTesseractVersion) {
+		t.Errorf("warning should name both versions: %q", warning)
+	}
+}
+
+// TestDependencyCheckerRefresh tests Refresh picks up a tool installed
+// after the first check
+func TestDependencyCheckerRefresh(t *testing.T) {
+	fakeTools(t, map[string]string{})
+	dc := NewDependencyCheckerWithRegistry(NewDependencyRegistry())
+	if dc.Status(DependencyTesseract).Available {
+		t.Fatal("tesseract found without being installed")
+	}
+
+	fakeTools(t, map[string]string{"tesseract": "tesseract 5.3.0\n"})
+	if dc.registry.IsAvailable(DependencyTesseract) {
+		t.Error("the result should stay cached until Refresh")
+	}
+	if status := dc.Refresh()[DependencyTesseract]; !status.Available || status.Version != "5.3.0" {
+		t.Errorf("after Refresh: %+v", status)
+	}
+}
+
+// TestRegisterDependency tests optional tools register themselves with the
+// process-wide registry
+func TestRegisterDependency(t *testing.T) {
+	if !slices.Contains(Dependencies().Names(), DependencyGit) {
+		t.Errorf("git not registered: %v", Dependencies().Names())
+	}
+}
@@ -0,0 +1,276 @@
+package searcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+	"time"
+
+	_ "embed"
+
+	"github.com/kacebover/password-finder/locale"
+)
+
+// Comparing two scans of the same tree, e.g. before and after fixing leaks,
+// shows which findings were fixed and which are new. Findings are matched by
+// path relative to the scan root, pattern type and the hash of the match, so
+// a secret that moved within its file is the same finding, and reports
+// written with masked secrets compare like full ones
+
+// DiffFormat marks JSON diff reports written by ScanDiff.ExportJSON
+const DiffFormat = "data-leak-locator/diff"
+
+// ScanDiff is the comparison of an old and a new scan, see CompareResults
+type ScanDiff struct {
+	Format  string `json:"format"`
+	OldRoot string `json:"old_root,omitempty"`
+	NewRoot string `json:"new_root,omitempty"`
+	OldTime int64  `json:"old_scan_time"` // End of the old scan, Unix seconds
+	NewTime int64  `json:"new_scan_time"`
+
+	AddedFindings     []*Finding `json:"added"`     // Only in the new scan
+	RemovedFindings   []*Finding `json:"removed"`   // Only in the old scan: fixed
+	PersistedFindings []*Finding `json:"persisted"` // In both, as the new scan found them
+
+	Severities []SeverityDelta `json:"severities"` // Critical first
+}
+
+// SeverityDelta compares the findings of one severity
+type SeverityDelta struct {
+	Severity Severity `json:"severity"`
+	Old      int      `json:"old"`
+	New      int      `json:"new"`
+	Added    int      `json:"added"`
+	Removed  int      `json:"removed"`
+}
+
+// Delta returns how many more findings of the severity the new scan has
+func (d SeverityDelta) Delta() int {
+	return d.New - d.Old
+}
+
+// diffKey identifies a finding of a scan of root across scans
+func diffKey(root string, f *Finding) string {
+	return f.maskedFingerprintPath(relativeFindingPath(root, f.FilePath))
+}
+
+// CompareResults compares the findings of two scans. A finding found twice
+// in a file matches two findings of the other scan, so a copy of a known
+// secret in the same file is still added
+func CompareResults(oldResult, newResult *ScanResult) *ScanDiff {
+	diff := &ScanDiff{
+		Format:  DiffFormat,
+		OldRoot: oldResult.Root,
+		NewRoot: newResult.Root,
+		OldTime: oldResult.EndTime,
+		NewTime: newResult.EndTime,
+	}
+
+	previous := make(map[string][]*Finding)
+	var order []string
+	oldResult.ForEachFinding(func(f *Finding) bool {
+		key := diffKey(oldResult.Root, f)
+		if _, ok := previous[key]; !ok {
+			order = append(order, key)
+		}
+		previous[key] = append(previous[key], f)
+		return true
+	})
+
+	newResult.ForEachFinding(func(f *Finding) bool {
+		key := diffKey(newResult.Root, f)
+		if matches := previous[key]; len(matches) > 0 {
+			previous[key] = matches[1:]
+			diff.PersistedFindings = append(diff.PersistedFindings, f)
+		} else {
+			diff.AddedFindings = append(diff.AddedFindings, f)
+		}
+		return true
+	})
+	for _, key := range order {
+		diff.RemovedFindings = append(diff.RemovedFindings, previous[key]...)
+	}
+
+	for _, severity := range []Severity{Critical, High, Medium, Low} {
+		delta := SeverityDelta{
+			Severity: severity,
+			Old:      oldResult.GetSeverityCount(severity),
+			New:      newResult.GetSeverityCount(severity),
+			Added:    countSeverity(diff.AddedFindings, severity),
+			Removed:  countSeverity(diff.RemovedFindings, severity),
+		}
+		diff.Severities = append(diff.Severities, delta)
+	}
+	return diff
+}
+
+// countSeverity counts the findings of a severity
+func countSeverity(findings []*Finding, severity Severity) int {
+	n := 0
+	for _, f := range findings {
+		if f.Severity == severity {
+			n++
+		}
+	}
+	return n
+}
+
+// FormatScanDiff returns the comparison as text for the terminal: the
+// totals, the counts per severity and up to limit added and fixed findings
+func FormatScanDiff(l *locale.Localizer, d *ScanDiff, limit int) string {
+	var sb strings.Builder
+	sb.WriteString(l.T("diff.added", len(d.AddedFindings)) + "\n")
+	sb.WriteString(l.T("diff.removed", len(d.RemovedFindings)) + "\n")
+	sb.WriteString(l.T("diff.persisted", len(d.PersistedFindings)) + "\n")
+
+	sb.WriteString("\n" + l.T("diff.by_severity") + "\n")
+	for _, s := range d.Severities {
+		sb.WriteString(l.T("diff.severity_line",
+			l.Severity(string(s.Severity))+":", s.Old, s.New, s.Delta(), s.Added, s.Removed) + "\n")
+	}
+
+	list := func(title, root string, findings []*Finding) {
+		if len(findings) == 0 {
+			return
+		}
+		sb.WriteString("\n" + title + "\n")
+		for i, f := range findings {
+			if i == limit {
+				sb.WriteString(l.T("diff.more", len(findings)-limit) + "\n")
+				break
+			}
+			sb.WriteString(fmt.Sprintf("  %s:%d  %s, %s\n", relativeFindingPath(root, f.FilePath), f.LineNumber,
+				l.Pattern(string(f.PatternType)), l.Severity(string(f.Severity))))
+		}
+	}
+	list(l.T("diff.new"), d.NewRoot, d.AddedFindings)
+	list(l.T("diff.fixed"), d.OldRoot, d.RemovedFindings)
+	return sb.String()
+}
+
+// masked returns the diff with copies of the findings whose secrets are
+// masked; diff reports never contain secrets
+func (d *ScanDiff) masked() *ScanDiff {
+	copied := *d
+	mask := func(findings []*Finding) []*Finding {
+		out := make([]*Finding, len(findings))
+		for i, f := range findings {
+			c := *f
+			if c.MatchSHA256 == "" && c.MatchedText != "" {
+				c.MatchSHA256 = matchSHA256(c.MatchedText)
+			}
+			maskMatch(&c)
+			out[i] = &c
+		}
+		return out
+	}
+	copied.AddedFindings = mask(d.AddedFindings)
+	copied.RemovedFindings = mask(d.RemovedFindings)
+	copied.PersistedFindings = mask(d.PersistedFindings)
+	return &copied
+}
+
+// ExportJSON writes the diff as JSON with masked secrets
+func (d *ScanDiff) ExportJSON(path string) error {
+	return writeFileAtomic(path, reportFileMode, d.writeJSON)
+}
+
+func (d *ScanDiff) writeJSON(w io.Writer) error {
+	data, err := json.MarshalIndent(d.masked(), "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+//go:embed templates/diff.html
+var diffReportTemplate string
+
+var diffReport = template.Must(template.New("diff").Parse(diffReportTemplate))
+
+// diffReportData is the data of templates/diff.html
+type diffReportData struct {
+	OldRoot     string
+	NewRoot     string
+	OldTime     string
+	NewTime     string
+	GeneratedAt string
+	Severities  []diffSeverity
+	Sections    []diffSection
+}
+
+// diffSeverity is a row of the severity table
+type diffSeverity struct {
+	SeverityDelta
+	Label  string
+	Change string
+}
+
+// diffSection lists the added, fixed or remaining findings
+type diffSection struct {
+	Class    string
+	Title    string
+	Findings []diffFinding
+}
+
+// diffFinding is one table row
+type diffFinding struct {
+	Path          string
+	Line          int
+	Severity      Severity
+	SeverityLabel string
+	Type          string
+	Description   string
+	Masked        string
+}
+
+// ExportHTML writes the diff as a self-contained HTML page with masked secrets
+func (d *ScanDiff) ExportHTML(path string) error {
+	return writeFileAtomic(path, reportFileMode, d.writeHTML)
+}
+
+func (d *ScanDiff) writeHTML(w io.Writer) error {
+	masked := d.masked()
+	data := diffReportData{
+		OldRoot:     d.OldRoot,
+		NewRoot:     d.NewRoot,
+		OldTime:     formatScanTime(d.OldTime),
+		NewTime:     formatScanTime(d.NewTime),
+		GeneratedAt: time.Now().Format("02.01.2006 15:04:05"),
+	}
+	for _, s := range d.Severities {
+		data.Severities = append(data.Severities, diffSeverity{s, severityToRussian(s.Severity), fmt.Sprintf("%+d", s.Delta())})
+	}
+	section := func(class, title, root string, findings []*Finding) diffSection {
+		rows := make([]diffFinding, len(findings))
+		for i, f := range findings {
+			rows[i] = diffFinding{
+				Path:          relativeFindingPath(root, f.FilePath),
+				Line:          f.LineNumber,
+				Severity:      f.Severity,
+				SeverityLabel: severityToRussian(f.Severity),
+				Type:          patternTypeToRussian(f.PatternType),
+				Description:   descriptionToRussian(f.Description),
+				Masked:        f.MatchedText,
+			}
+		}
+		return diffSection{class, title, rows}
+	}
+	data.Sections = []diffSection{
+		section("added", "🆕 Новые находки", d.NewRoot, masked.AddedFindings),
+		section("removed", "✅ Исправленные находки", d.OldRoot, masked.RemovedFindings),
+		section("persisted", "⏳ Оставшиеся находки", d.NewRoot, masked.PersistedFindings),
+	}
+	return diffReport.Execute(w, data)
+}
+
+// formatScanTime formats the end of a scan, "—" when unknown
+func formatScanTime(unix int64) string {
+	if unix == 0 {
+		return "—"
+	}
+	return time.Unix(unix, 0).Format("02.01.2006 15:04:05")
+}
@@ -6,9 +6,10 @@ import (
 	_ "image/gif"
 	_ "image/jpeg"
 	_ "image/png"
-	"os"
 	"regexp"
 	"strings"
+
+	"github.com/kacebover/password-finder/fsutil"
 )
 
 // DocumentDetector detects identity documents in images and text
@@ -355,7 +356,7 @@ func (dd *DocumentDetector) detectMedicalRecord(text, textLower, filePath string
 
 // AnalyzeImage analyzes an image for document-like characteristics
 func (dd *DocumentDetector) AnalyzeImage(filePath string) (*DetectedDocument, error) {
-	file, err := os.Open(filePath)
+	file, err := fsutil.Open(filePath)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,257 @@
+package searcher
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+
+	"github.com/kacebover/password-finder/fsutil"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+)
+
+// Email exports. An EML message is parsed as MIME: the headers a reader
+// sees, then every text/plain and text/html part, forwarded messages
+// included, with the transfer encoding and charset decoded. Outlook MSG
+// files are OLE compound documents; they are read best-effort as the runs
+// of printable UTF-16 and ASCII text in them
+
+// maxMIMEDepth bounds nested multiparts and forwarded messages
+const maxMIMEDepth = 10
+
+// emailHeaders are the headers put before the body
+var emailHeaders = []string{"From", "To", "Cc", "Reply-To", "Subject", "Date"}
+
+// extractEML extracts the headers and text parts of an email
+func (de *DocumentExtractor) extractEML(filePath string) (*ExtractedContent, error) {
+	data, err := fsutil.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	msg, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка разбора письма: %w", err)
+	}
+
+	var texts []string
+	de.messageText(msg, 0, &texts)
+	return &ExtractedContent{
+		SourceFile: filePath,
+		Format:     "EML",
+		Text:       strings.Join(texts, "\n"),
+	}, nil
+}
+
+// messageText appends the headers and text parts of a message
+func (de *DocumentExtractor) messageText(msg *mail.Message, depth int, texts *[]string) {
+	decoder := mime.WordDecoder{CharsetReader: charsetReader}
+	for _, key := range emailHeaders {
+		value := msg.Header.Get(key)
+		if value == "" {
+			continue
+		}
+		if decoded, err := decoder.DecodeHeader(value); err == nil {
+			value = decoded
+		}
+		*texts = append(*texts, key+": "+value)
+	}
+	de.mimePartText(textproto.MIMEHeader(msg.Header), msg.Body, depth, texts)
+}
+
+// mimePartText appends the text of a MIME part; attachments that are not
+// text are skipped
+func (de *DocumentExtractor) mimePartText(header textproto.MIMEHeader, body io.Reader, depth int, texts *[]string) {
+	if depth > maxMIMEDepth {
+		return
+	}
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		mediaType, params = "text/plain", map[string]string{}
+	}
+
+	body = io.LimitReader(body, de.maxFileSize)
+	switch strings.ToLower(strings.TrimSpace(header.Get("Content-Transfer-Encoding"))) {
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, body)
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	}
+
+	switch {
+	case strings.HasPrefix(mediaType, "multipart/"):
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			// Raw parts keep their transfer encoding for the switch above
+			part, err := mr.NextRawPart()
+			if err != nil {
+				return
+			}
+			de.mimePartText(part.Header, part, depth+1, texts)
+		}
+	case mediaType == "message/rfc822":
+		if msg, err := mail.ReadMessage(body); err == nil {
+			de.messageText(msg, depth+1, texts)
+		}
+	case mediaType == "text/html":
+		if text := htmlText(decodeCharset(body, params["charset"])); text != "" {
+			*texts = append(*texts, text)
+		}
+	case strings.HasPrefix(mediaType, "text/"):
+		// A part cut short still has text
+		data, _ := io.ReadAll(decodeCharset(body, params["charset"]))
+		if text := strings.TrimSpace(strings.ReplaceAll(string(data), "\r\n", "\n")); text != "" {
+			*texts = append(*texts, text)
+		}
+	}
+}
+
+// decodeCharset converts text in a MIME charset to UTF-8; unknown
+// charsets are read as they are
+func decodeCharset(r io.Reader, label string) io.Reader {
+	if decoded, err := charsetReader(label, r); err == nil {
+		return decoded
+	}
+	return r
+}
+
+// charsetReader is the CharsetReader of header words
+func charsetReader(label string, input io.Reader) (io.Reader, error) {
+	label = strings.ToLower(strings.TrimSpace(label))
+	if label == "" || label == "utf-8" || label == "us-ascii" {
+		return input, nil
+	}
+	enc, _ := charset.Lookup(label)
+	if enc == nil {
+		return nil, fmt.Errorf("неизвестная кодировка %q", label)
+	}
+	return enc.NewDecoder().Reader(input), nil
+}
+
+// htmlBlocks are elements that end a line of text
+var htmlBlocks = map[string]bool{
+	"br": true, "p": true, "div": true, "tr": true, "li": true, "h1": true,
+	"h2": true, "h3": true, "h4": true, "h5": true, "h6": true, "table": true,
+	"pre": true, "blockquote": true,
+}
+
+// htmlText returns the visible text of an HTML part, a line per block;
+// scripts and styles are left out. Link targets are kept, tokens in URLs
+// are secrets too
+func htmlText(r io.Reader) string {
+	z := html.NewTokenizer(r)
+	var b strings.Builder
+	hidden := 0
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return strings.TrimSpace(b.String())
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			tag := string(name)
+			switch {
+			case tag == "script" || tag == "style":
+				hidden++
+			case htmlBlocks[tag]:
+				b.WriteByte('\n')
+			}
+			for hasAttr {
+				var key, value []byte
+				key, value, hasAttr = z.TagAttr()
+				if string(key) == "href" && !bytes.HasPrefix(value, []byte("#")) {
+					b.WriteString(" " + string(value) + " ")
+				}
+			}
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			tag := string(name)
+			switch {
+			case tag == "script" || tag == "style":
+				if hidden > 0 {
+					hidden--
+				}
+			case htmlBlocks[tag]:
+				b.WriteByte('\n')
+			}
+		case html.TextToken:
+			if hidden == 0 {
+				b.Write(z.Text())
+			}
+		}
+	}
+}
+
+// extractMSG extracts the text of an Outlook message best-effort
+func (de *DocumentExtractor) extractMSG(filePath string) (*ExtractedContent, error) {
+	data, err := fsutil.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return &ExtractedContent{
+		SourceFile: filePath,
+		Format:     "MSG",
+		Text:       strings.ReplaceAll(strings.Join(printableRuns(data, 6), "\n"), "\r\n", "\n"),
+	}, nil
+}
+
+// msgTextRune reports whether a UTF-16 unit is in the scripts of the
+// messages scanned: Latin, Cyrillic and punctuation. Wider ranges would
+// read pairs of ASCII bytes as CJK text
+func msgTextRune(r rune) bool {
+	return r < 0x530 || r >= 0x2000 && r < 0x2070 || r == '€' || r == '№'
+}
+
+// printableRuns returns the runs of at least min printable characters,
+// UTF-16LE ones (how MSG stores strings) first and then ASCII ones that
+// are not part of them
+func printableRuns(data []byte, min int) []string {
+	var runs []string
+	inUTF16 := make([]bool, len(data))
+
+	for start := 0; start < 2; start++ {
+		var units []uint16
+		runStart := start
+		end := func(i int) {
+			if text := string(utf16.Decode(units)); len(units) >= min && strings.TrimSpace(text) != "" {
+				runs = append(runs, text)
+				for j := runStart; j < i; j++ {
+					inUTF16[j] = true
+				}
+			}
+			units = units[:0]
+			runStart = i + 2
+		}
+		for i := start; i+1 < len(data); i += 2 {
+			unit := uint16(data[i]) | uint16(data[i+1])<<8
+			if r := rune(unit); msgTextRune(r) && (unicode.IsPrint(r) || r == '\t' || r == '\n' || r == '\r') {
+				units = append(units, unit)
+				continue
+			}
+			end(i)
+		}
+		end(len(data))
+	}
+
+	var ascii []byte
+	for i := 0; i <= len(data); i++ {
+		if i < len(data) && !inUTF16[i] {
+			if c := data[i]; c >= 32 && c < 127 || c == '\t' || c == '\n' || c == '\r' {
+				ascii = append(ascii, c)
+				continue
+			}
+		}
+		if len(ascii) >= min {
+			runs = append(runs, string(ascii))
+		}
+		ascii = ascii[:0]
+	}
+	return runs
+}
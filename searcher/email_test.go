@@ -0,0 +1,59 @@
+package searcher
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExtractEMLNested(t *testing.T) {
+	// A koi8-r part inside a forwarded message
+	koi8 := string([]byte{0xf0, 0xc1, 0xd2, 0xcf, 0xcc, 0xd8}) // Пароль
+	eml := "From: a@example.com\r\nSubject: Fwd\r\nContent-Type: multipart/mixed; boundary=outer\r\n\r\n" +
+		"--outer\r\nContent-Type: message/rfc822\r\n\r\n" +
+		"Subject: =?koi8-r?B?8MHSz8zY?=\r\nContent-Type: text/plain; charset=koi8-r\r\n\r\n" +
+		koi8 + ": password = Zx8cVb6Nm4Qw\r\n" +
+		"--outer\r\nContent-Type: image/png\r\nContent-Transfer-Encoding: base64\r\n\r\niVBORw0KGgo=\r\n" +
+		"--outer--\r\n"
+	path := filepath.Join(t.TempDir(), "fwd.eml")
+	if err := os.WriteFile(path, []byte(eml), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := NewDocumentExtractor(false).ExtractText(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Subject: Fwd", "Subject: Пароль", "Пароль: password = Zx8cVb6Nm4Qw"} {
+		if !strings.Contains(content.Text, want) {
+			t.Errorf("text lacks %q:\n%s", want, content.Text)
+		}
+	}
+	if strings.Contains(content.Text, "iVBOR") {
+		t.Errorf("attachment read as text:\n%s", content.Text)
+	}
+}
+
+func TestHTMLText(t *testing.T) {
+	text := htmlText(strings.NewReader(`<p>Вход</p><script>var x = "no";</script><a href="https://example.com/?token=abc123">ссылка</a><a href="#top">вверх</a>`))
+	if !strings.Contains(text, "Вход\n") || !strings.Contains(text, "token=abc123") || strings.Contains(text, "var x") || strings.Contains(text, "#top") {
+		t.Errorf("htmlText = %q", text)
+	}
+}
+
+func TestPrintableRuns(t *testing.T) {
+	var data []byte
+	data = append(data, 0xff, 0xff)
+	for _, r := range "пароль secret" {
+		data = append(data, byte(r), byte(r>>8))
+	}
+	data = append(data, 0x00, 0x00, 0xff)
+	data = append(data, "ascii text run"...)
+	data = append(data, 0x00, 'a', 'b', 0x00)
+
+	runs := printableRuns(data, 6)
+	if len(runs) != 2 || runs[0] != "пароль secret" || runs[1] != "ascii text run" {
+		t.Errorf("runs = %q", runs)
+	}
+}
@@ -0,0 +1,130 @@
+package searcher
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+
+	"github.com/kacebover/password-finder/fsutil"
+)
+
+// Scanner discovers files while scanning, so without enumeration the total
+// is unknown until the end. The enumeration pass walks the tree with the
+// same ignore rules but only reads directory entries, and runs alongside
+// the scan so a huge tree still shows a growing estimate right away
+
+// EnumerationResult counts the files a scan will visit
+type EnumerationResult struct {
+	Files    int64 // Files the scan pass hands to the file workers
+	Bytes    int64 // Size of those passing the extension filter and size limit
+	Complete bool  // False while the walk is still running
+}
+
+// enumeration holds the running totals of the enumeration pass
+type enumeration struct {
+	files    atomic.Int64
+	bytes    atomic.Int64
+	complete atomic.Bool
+}
+
+func (e *enumeration) snapshot() EnumerationResult {
+	return EnumerationResult{Files: e.files.Load(), Bytes: e.bytes.Load(), Complete: e.complete.Load()}
+}
+
+// enumerateProgressEvery is how many files pass between OnEnumerate calls
+const enumerateProgressEvery = 500
+
+// SetEnumerate turns on the enumeration pass during scans; Progress then
+// reports EstimatedTotal and the OnEnumerate callback gets the totals
+func (s *Scanner) SetEnumerate(enabled bool) {
+	s.enumerate = enabled
+}
+
+// SetOnEnumerate sets a callback for the enumeration totals, called every
+// few hundred files and once with Complete set
+func (s *Scanner) SetOnEnumerate(fn func(EnumerationResult)) {
+	s.onEnumerate = fn
+}
+
+// Enumerate counts the files a scan of rootDir would visit without
+// scanning them, for callers that want the total before starting
+func (s *Scanner) Enumerate(ctx context.Context, rootDir string) (EnumerationResult, error) {
+	s.ignoreList.AddDefaultIgnores()
+	s.prepareIgnoreList(rootDir)
+	e := &enumeration{}
+	s.enumerateTree(ctx, rootDir, e)
+	return e.snapshot(), cancelledError(ctx)
+}
+
+// startEnumeration runs the enumeration pass of the scan in progress; the
+// returned channel is closed when it ends
+func (s *Scanner) startEnumeration(ctx context.Context, rootDir string) <-chan struct{} {
+	e := &enumeration{}
+	s.enumerated.Store(e)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.enumerateTree(ctx, rootDir, e)
+	}()
+	return done
+}
+
+// enumerateTree walks rootDir like walkTree, counting the files it
+// would queue, and marks e complete at the end
+func (s *Scanner) enumerateTree(ctx context.Context, rootDir string, e *enumeration) {
+	s.enumerateDirectory(ctx, rootDir, nil, len(s.includeGlobs) == 0, newDirWalk(rootDir), e)
+	if ctx.Err() == nil {
+		e.complete.Store(true)
+	}
+	if s.onEnumerate != nil {
+		s.onEnumerate(e.snapshot())
+	}
+}
+
+func (s *Scanner) enumerateDirectory(ctx context.Context, dir string, ignore *gitignore, included bool, walk *dirWalk, e *enumeration) {
+	if ctx.Err() != nil {
+		return
+	}
+	if s.respectGitignore {
+		ignore = ignore.withFile(dir)
+	}
+	entries, err := fsutil.ReadDir(dir)
+	if err != nil {
+		return
+	}
+
+	for _, entry := range entries {
+		fullPath := filepath.Join(dir, entry.Name())
+		if s.ignoreList.ShouldIgnorePath(fullPath) || ignore.ignored(fullPath, entry.IsDir()) {
+			continue
+		}
+		kind, _ := s.resolveEntry(fullPath, entry, walk)
+		if kind == entrySkip {
+			continue
+		}
+		if kind == entryDir {
+			if s.ignoreList.ShouldIgnoreDirectory(fullPath) {
+				continue
+			}
+			if walkDir, inside, _ := s.globDir(fullPath, included); walkDir {
+				s.enumerateDirectory(ctx, fullPath, ignore, inside, walk, e)
+			}
+			continue
+		}
+		if scan, _ := s.globFile(fullPath, included); !scan {
+			continue
+		}
+
+		if n := e.files.Add(1); n%enumerateProgressEvery == 0 && s.onEnumerate != nil {
+			s.onEnumerate(e.snapshot())
+		}
+		ext := strings.ToLower(filepath.Ext(fullPath))
+		if len(s.onlyExtensions) > 0 && !s.onlyExtensions[ext] {
+			continue
+		}
+		if info, err := fsutil.Stat(fullPath); err == nil && info.Size() <= s.maxFileSize {
+			e.bytes.Add(info.Size())
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package searcher
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// enumerationFixture creates a tree with files the scan reads, skips and
+// never visits
+func enumerationFixture(t *testing.T) string {
+	dir := t.TempDir()
+	createTestFile(t, dir, "config.env", "password=hunter2\n")
+	createTestFile(t, dir, "src/main.go", "package main\n")
+	createTestFile(t, dir, "src/deep/notes.txt", "nothing here\n")
+	createTestFile(t, dir, "big.txt", strings.Repeat("x", 4096))
+	createTestFile(t, dir, "app.exe", "MZ")
+	createTestFile(t, dir, "node_modules/lib/index.js", "token=abc\n")
+	if err := os.WriteFile(filepath.Join(dir, "blob.bin"), []byte{0, 1, 2, 0, 3}, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestEnumerateMatchesScan(t *testing.T) {
+	dir := enumerationFixture(t)
+
+	scanner := NewScanner()
+	scanner.SetMaxFileSize(1024)
+	total, err := scanner.Enumerate(context.Background(), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !total.Complete {
+		t.Error("enumeration should be complete")
+	}
+
+	scanner = NewScanner()
+	scanner.SetMaxFileSize(1024)
+	result, err := scanner.Scan(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if visited := int64(result.FilesScanned + result.FilesSkipped); total.Files != visited {
+		t.Errorf("enumerated %d files, scan visited %d (%d scanned, %d skipped)",
+			total.Files, visited, result.FilesScanned, result.FilesSkipped)
+	}
+	if total.Bytes >= 4096 {
+		t.Errorf("files over the size limit should not count towards bytes, got %d", total.Bytes)
+	}
+}
+
+func TestScanWithEnumeration(t *testing.T) {
+	dir := enumerationFixture(t)
+
+	var last EnumerationResult
+	scanner := NewScanner()
+	scanner.SetEnumerate(true)
+	scanner.SetOnEnumerate(func(r EnumerationResult) { last = r })
+	result, err := scanner.Scan(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !last.Complete || last.Files != int64(result.FilesScanned+result.FilesSkipped) {
+		t.Errorf("last OnEnumerate call = %+v, scan visited %d", last, result.FilesScanned+result.FilesSkipped)
+	}
+	p := scanner.Progress()
+	if !p.TotalComplete || p.EstimatedTotal != p.FilesDone || p.FilesDone != last.Files {
+		t.Errorf("progress after scan = %d / %d (complete %v), want %d", p.FilesDone, p.EstimatedTotal, p.TotalComplete, last.Files)
+	}
+}
+
+func TestEnumerateCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	total, err := NewScanner().Enumerate(ctx, enumerationFixture(t))
+	if err == nil || total.Complete {
+		t.Errorf("cancelled enumeration = %+v, %v", total, err)
+	}
+}
+
+func TestETAEstimator(t *testing.T) {
+	eta := NewETAEstimator(10 * time.Second)
+	start := time.Unix(0, 0)
+	if _, ok := eta.Estimate(100); ok {
+		t.Error("no estimate without throughput")
+	}
+
+	// A slow start falls out of the window
+	eta.Add(start, 0)
+	eta.Add(start.Add(20*time.Second), 10)
+	for i := 1; i <= 10; i++ {
+		eta.Add(start.Add(time.Duration(20+i)*time.Second), int64(10+10*i))
+	}
+	remaining, ok := eta.Estimate(210)
+	if !ok || remaining != 10*time.Second {
+		t.Errorf("Estimate = %v, %v; want 10s at 10 files/s", remaining, ok)
+	}
+	if remaining, ok := eta.Estimate(50); !ok || remaining != 0 {
+		t.Errorf("Estimate past the total = %v, %v", remaining, ok)
+	}
+}
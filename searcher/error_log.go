@@ -0,0 +1,111 @@
+package searcher
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// ErrorCount says how many files failed; the error log says which and why.
+// Every counted error is also recorded as a ScanError, up to MaxScanErrors,
+// so a storm of permission errors on a large share cannot exhaust memory.
+// The errors past the cap are only counted in ErrorsDropped
+
+// MaxScanErrors is how many errors a result keeps in Errors
+const MaxScanErrors = 1000
+
+// ErrorStage is the step of scanning a file that failed
+type ErrorStage string
+
+const (
+	StageRead    ErrorStage = "read"    // Listing a directory or reading a file
+	StageExtract ErrorStage = "extract" // Extracting text from a document or archive
+	StageOCR     ErrorStage = "ocr"     // Recognizing text in an image
+	StageDecode  ErrorStage = "decode"  // Decoding content, such as base64 or an encoding
+	StageNotify  ErrorStage = "notify"  // Sending a notification, see Scanner.SetNotifier
+)
+
+// Title names the stage for the user in Russian, see LocalizeErrorStage
+func (st ErrorStage) Title() string {
+	return LocalizeErrorStage(russian, st)
+}
+
+// ScanError records a file or directory that could not be scanned
+type ScanError struct {
+	FilePath string     `json:"file_path"`
+	Stage    ErrorStage `json:"stage"`
+	Err      string     `json:"error"`
+	Time     time.Time  `json:"time"`
+}
+
+// AddError counts an error and records it in Errors while under
+// MaxScanErrors (thread-safe)
+func (sr *ScanResult) AddError(filePath string, stage ErrorStage, err error) {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	sr.ErrorCount++
+	sr.addErrorLocked(ScanError{FilePath: filePath, Stage: stage, Err: err.Error(), Time: time.Now()})
+}
+
+// addErrorLocked keeps a record without counting it; sr.mu is held
+func (sr *ScanResult) addErrorLocked(e ScanError) {
+	if len(sr.Errors) >= MaxScanErrors {
+		sr.ErrorsDropped++
+		return
+	}
+	sr.Errors = append(sr.Errors, e)
+}
+
+// GetErrors returns a copy of the recorded errors (thread-safe)
+func (sr *ScanResult) GetErrors() []ScanError {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	errs := make([]ScanError, len(sr.Errors))
+	copy(errs, sr.Errors)
+	return errs
+}
+
+// SetLogger sets a structured logger for scan events: the start and end
+// of a scan at Info and every file error at Warn. Nil turns logging off
+func (s *Scanner) SetLogger(logger *slog.Logger) {
+	s.logger = logger
+}
+
+// logEnabled reports whether the logger wants records of level
+func (s *Scanner) logEnabled(level slog.Level) bool {
+	return s.logger != nil && s.logger.Enabled(context.Background(), level)
+}
+
+// scanError records an error of a file at stage in the result and the log
+func (s *Scanner) scanError(path string, stage ErrorStage, err error) {
+	path = s.copies.source(path)
+	s.result.AddError(path, stage, err)
+	if s.logEnabled(slog.LevelWarn) {
+		s.logger.Warn("file error", "path", path, "stage", string(stage), "error", err)
+	}
+}
+
+// logScanStart and logScanEnd log the start and summary of a scan
+func (s *Scanner) logScanStart(root string) {
+	if s.logEnabled(slog.LevelInfo) {
+		s.logger.Info("scan started", "root", root)
+	}
+}
+
+func (s *Scanner) logScanEnd(root string, err error) {
+	if !s.logEnabled(slog.LevelInfo) {
+		return
+	}
+	attrs := []any{
+		"root", root,
+		"files", s.result.FilesScanned,
+		"skipped", s.result.FilesSkipped,
+		"findings", s.result.TotalFindings(),
+		"errors", s.result.ErrorCount,
+		"duration", time.Duration(s.result.EndTime-s.result.StartTime) * time.Second,
+	}
+	if err != nil {
+		attrs = append(attrs, "error", err)
+	}
+	s.logger.Info("scan finished", attrs...)
+}
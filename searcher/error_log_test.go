@@ -0,0 +1,118 @@
+package searcher
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// findScanError returns the recorded error of path
+func findScanError(result *ScanResult, path string) *ScanError {
+	for _, e := range result.GetErrors() {
+		if e.FilePath == path {
+			return &e
+		}
+	}
+	return nil
+}
+
+func TestScanErrorsUnreadableFile(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("root ignores file permissions")
+	}
+	dir := t.TempDir()
+	createTestFile(t, dir, "ok.env", "password = hunter2hunter2\n")
+	createTestFile(t, dir, "locked.env", "password = hunter2hunter2\n")
+	locked := filepath.Join(dir, "locked.env")
+	if err := os.Chmod(locked, 0); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chmod(locked, 0644)
+
+	result, err := NewScanner().Scan(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.ErrorCount != 1 || len(result.Errors) != 1 {
+		t.Fatalf("ErrorCount = %d, %d records", result.ErrorCount, len(result.Errors))
+	}
+	e := findScanError(result, locked)
+	if e == nil || e.Stage != StageRead || !strings.Contains(e.Err, "permission denied") || e.Time.IsZero() {
+		t.Errorf("error record %+v", e)
+	}
+}
+
+func TestScanErrorsExtractStage(t *testing.T) {
+	dir := t.TempDir()
+	createTestFile(t, dir, "backup.zip", "PK\x03\x04 not really a zip")
+	broken := filepath.Join(dir, "backup.zip")
+
+	scanner := NewScanner()
+	scanner.SetDocumentExtractor(NewDocumentExtractor(false))
+	scanner.SetScanArchives(true)
+	result, err := scanner.Scan(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e := findScanError(result, broken); e == nil || e.Stage != StageExtract || e.Err == "" {
+		t.Errorf("error record %+v, want the extract stage", e)
+	}
+
+	// The errors go into the JSON report and back
+	path := filepath.Join(t.TempDir(), "report.json")
+	if err := NewReportGenerator(result).ExportJSON(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadScanResult(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e := findScanError(loaded, broken); e == nil || e.Stage != StageExtract {
+		t.Errorf("loaded error record %+v", e)
+	}
+}
+
+func TestScanErrorsCap(t *testing.T) {
+	result := NewScanResult()
+	for i := 0; i < MaxScanErrors+5; i++ {
+		result.AddError("/share/file", StageRead, errors.New("нет доступа"))
+	}
+	if result.ErrorCount != MaxScanErrors+5 || len(result.Errors) != MaxScanErrors || result.ErrorsDropped != 5 {
+		t.Errorf("ErrorCount = %d, %d records, %d dropped", result.ErrorCount, len(result.Errors), result.ErrorsDropped)
+	}
+}
+
+func TestScannerLogger(t *testing.T) {
+	dir := t.TempDir()
+	createTestFile(t, dir, "backup.zip", "PK\x03\x04 not really a zip")
+	broken := filepath.Join(dir, "backup.zip")
+
+	var buf bytes.Buffer
+	scanner := NewScanner()
+	scanner.SetDocumentExtractor(NewDocumentExtractor(false))
+	scanner.SetScanArchives(true)
+	scanner.SetLogger(slog.New(slog.NewJSONHandler(&buf, nil)))
+	if _, err := scanner.Scan(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	var messages []string
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("bad log line %q: %v", line, err)
+		}
+		messages = append(messages, record["msg"].(string))
+		if record["msg"] == "file error" && (record["path"] != broken || record["stage"] != string(StageExtract)) {
+			t.Errorf("file error record %v", record)
+		}
+	}
+	if strings.Join(messages, ",") != "scan started,file error,scan finished" {
+		t.Errorf("log messages %v", messages)
+	}
+}
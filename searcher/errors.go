@@ -0,0 +1,89 @@
+package searcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"github.com/kacebover/password-finder/fsutil"
+)
+
+// Errors returned by the scanners and the document extractor. They are
+// wrapped with context, so compare them with errors.Is
+var (
+	ErrNotFound     = errors.New("файл или директория не найдены")
+	ErrPermission   = errors.New("нет доступа")
+	ErrTooLarge     = errors.New("файл слишком большой")
+	ErrCancelled    = errors.New("сканирование отменено")
+	ErrArchiveLimit = errors.New("превышен лимит распаковки архива")
+)
+
+// ErrDependencyMissing is returned when an external tool needed for the
+// file is not installed. Name is one of the Dependency* constants
+type ErrDependencyMissing struct {
+	Name string
+}
+
+func (e *ErrDependencyMissing) Error() string {
+	return fmt.Sprintf("%s не установлен", e.Name)
+}
+
+// ErrUnsupportedFormat is returned for a file type the extractor cannot read
+type ErrUnsupportedFormat struct {
+	Ext string // Lower-case extension with the dot, empty for files without one
+}
+
+func (e *ErrUnsupportedFormat) Error() string {
+	if e.Ext == "" {
+		return "неподдерживаемый формат: файл без расширения"
+	}
+	return fmt.Sprintf("неподдерживаемый формат: %s", e.Ext)
+}
+
+// pathError classifies a file system error. The original error stays in
+// the chain, so fs.ErrNotExist and fs.ErrPermission still match
+func pathError(err error) error {
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return fmt.Errorf("%w: %w", ErrNotFound, err)
+	case errors.Is(err, fs.ErrPermission):
+		return fmt.Errorf("%w: %w", ErrPermission, err)
+	default:
+		return err
+	}
+}
+
+// cancelledError wraps the context error of a cancelled scan; nil if the
+// context is still active
+func cancelledError(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("%w: %w", ErrCancelled, err)
+	}
+	return nil
+}
+
+// fileErrorCause describes a file system error without the path, which
+// the caller shows next to it
+func fileErrorCause(err error) string {
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return ErrNotFound.Error()
+	case errors.Is(err, fs.ErrPermission):
+		return ErrPermission.Error()
+	}
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		return pathErr.Op + ": " + pathErr.Err.Error()
+	}
+	return err.Error()
+}
+
+// checkRoot reports whether the scan root exists and can be read
+func checkRoot(rootDir string) error {
+	f, err := fsutil.Open(rootDir)
+	if err != nil {
+		return pathError(err)
+	}
+	return f.Close()
+}
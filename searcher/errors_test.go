@@ -0,0 +1,181 @@
+package searcher
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestScanMissingRoot(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing")
+
+	result, err := NewScanner().Scan(missing)
+	if !errors.Is(err, ErrNotFound) || !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Scan error = %v, want ErrNotFound wrapping fs.ErrNotExist", err)
+	}
+	if result == nil {
+		t.Fatal("Scan should still return a result")
+	}
+
+	scanner := NewStreamingScanner(DefaultStreamingScannerConfig())
+	go func() {
+		for range scanner.Events() {
+		}
+	}()
+	if _, err := scanner.Scan(context.Background(), missing); !errors.Is(err, ErrNotFound) {
+		t.Errorf("streaming Scan error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestScanUnreadableRoot(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("root ignores file permissions")
+	}
+	dir := filepath.Join(t.TempDir(), "locked")
+	if err := os.Mkdir(dir, 0); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chmod(dir, 0755)
+
+	if _, err := NewScanner().Scan(dir); !errors.Is(err, ErrPermission) || !errors.Is(err, fs.ErrPermission) {
+		t.Errorf("Scan error = %v, want ErrPermission wrapping fs.ErrPermission", err)
+	}
+}
+
+func TestStreamingScanCancelled(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "config.env"), []byte("password = hunter2hunter2\n"), 0644)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	scanner := NewStreamingScanner(DefaultStreamingScannerConfig())
+	go func() {
+		for range scanner.Events() {
+		}
+	}()
+	_, err := scanner.Scan(ctx, dir)
+	if !errors.Is(err, ErrCancelled) || !errors.Is(err, context.Canceled) {
+		t.Errorf("Scan error = %v, want ErrCancelled wrapping context.Canceled", err)
+	}
+}
+
+func TestScanContextCancelled(t *testing.T) {
+	dir := t.TempDir()
+	for i := 0; i < 50; i++ {
+		os.WriteFile(filepath.Join(dir, fmt.Sprintf("config%02d.env", i)), []byte("password = hunter2hunter2\n"), 0644)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	result, err := NewScanner().ScanContext(ctx, dir)
+	if !errors.Is(err, ErrCancelled) || !errors.Is(err, context.Canceled) {
+		t.Errorf("ScanContext error = %v, want ErrCancelled wrapping context.Canceled", err)
+	}
+	if result == nil || !result.Cancelled {
+		t.Fatalf("result = %+v, want a partial result marked as cancelled", result)
+	}
+	if result.FilesScanned != 0 {
+		t.Errorf("FilesScanned = %d after cancelling before the scan", result.FilesScanned)
+	}
+
+	// Scan without a context still runs to the end
+	if result, err := NewScanner().Scan(dir); err != nil || result.Cancelled || result.FilesScanned != 50 {
+		t.Errorf("Scan = %d files, cancelled %v, err %v", result.FilesScanned, result.Cancelled, err)
+	}
+}
+
+func TestExtractTextContextCancelled(t *testing.T) {
+	archive := filepath.Join(t.TempDir(), "secrets.zip")
+	f, _ := os.Create(archive)
+	zw := zip.NewWriter(f)
+	for _, name := range []string{"a.env", "b.env"} {
+		w, _ := zw.Create(name)
+		w.Write([]byte("password = hunter2hunter2\n"))
+	}
+	zw.Close()
+	f.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	de := NewDocumentExtractor(false)
+	if _, err := de.ExtractTextContext(ctx, archive); !errors.Is(err, ErrCancelled) {
+		t.Errorf("ExtractTextContext error = %v, want ErrCancelled", err)
+	}
+	// The member loop stops as well
+	if _, err := de.extractZIP(ctx, archive); !errors.Is(err, ErrCancelled) {
+		t.Errorf("extractZIP error = %v, want ErrCancelled", err)
+	}
+	if content, err := de.ExtractText(archive); err != nil || !strings.Contains(content.Text, "hunter2") {
+		t.Errorf("ExtractText = %+v, %v", content, err)
+	}
+}
+
+func TestExtractTextErrors(t *testing.T) {
+	dir := t.TempDir()
+	unsupported := filepath.Join(dir, "data.xyz")
+	large := filepath.Join(dir, "notes.txt")
+	os.WriteFile(unsupported, []byte("x"), 0644)
+	os.WriteFile(large, []byte(strings.Repeat("a", 100)), 0644)
+
+	de := NewDocumentExtractor(false)
+	de.SetMaxFileSize(10)
+
+	_, err := de.ExtractText(unsupported)
+	var format *ErrUnsupportedFormat
+	if !errors.As(err, &format) || format.Ext != ".xyz" {
+		t.Errorf("unsupported format error = %v, want ErrUnsupportedFormat{.xyz}", err)
+	}
+
+	if _, err := de.ExtractText(large); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("large file error = %v, want ErrTooLarge", err)
+	}
+
+	if _, err := de.ExtractText(filepath.Join(dir, "missing.pdf")); !errors.Is(err, ErrNotFound) {
+		t.Errorf("missing file error = %v, want ErrNotFound", err)
+	}
+
+	if _, err := SearchInFile(filepath.Join(dir, "missing.txt"), "password"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("SearchInFile error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestOCRDependencyMissing(t *testing.T) {
+	if Dependencies().IsAvailable(DependencyTesseract) {
+		t.Skip("tesseract is installed")
+	}
+
+	_, err := NewDocumentExtractor(true).performOCR(context.Background(), filepath.Join(t.TempDir(), "scan.png"))
+	var dep *ErrDependencyMissing
+	if !errors.As(err, &dep) || dep.Name != DependencyTesseract {
+		t.Errorf("OCR error = %v, want ErrDependencyMissing{tesseract}", err)
+	}
+}
+
+func TestFileErrorSkipReason(t *testing.T) {
+	dir := t.TempDir()
+	missing := filepath.Join(dir, "deleted.env")
+
+	scanner := NewScanner()
+	stop := scanner.beginScan(context.Background(), dir)
+	scanner.processFile(missing)
+	stop()
+
+	if scanner.result.ErrorCount != 1 {
+		t.Errorf("ErrorCount = %d, want 1", scanner.result.ErrorCount)
+	}
+	if reason := scanner.result.SkipReasons[missing].String(); !strings.Contains(reason, "не найдены") {
+		t.Errorf("skip reason for %s = %q", missing, reason)
+	}
+	if cause := fileErrorCause(&fs.PathError{Op: "read", Path: missing, Err: errors.New("I/O error")}); cause != "read: I/O error" {
+		t.Errorf("fileErrorCause = %q", cause)
+	}
+}
@@ -0,0 +1,56 @@
+package searcher
+
+import "time"
+
+// DefaultETAWindow is how far back ETAEstimator looks for the throughput
+const DefaultETAWindow = 10 * time.Second
+
+// ETAEstimator estimates the remaining time of a scan from the throughput
+// of the last window, so a slow start or a run of large files does not
+// skew the estimate for the rest of the scan
+type ETAEstimator struct {
+	window  time.Duration
+	samples []etaSample
+}
+
+type etaSample struct {
+	at   time.Time
+	done int64
+}
+
+// NewETAEstimator creates an estimator averaging over window
+func NewETAEstimator(window time.Duration) *ETAEstimator {
+	if window <= 0 {
+		window = DefaultETAWindow
+	}
+	return &ETAEstimator{window: window}
+}
+
+// Add records the number of files done at time now
+func (e *ETAEstimator) Add(now time.Time, done int64) {
+	e.samples = append(e.samples, etaSample{at: now, done: done})
+	// Keep one sample older than the window as the start of the rate
+	drop := 0
+	for drop+1 < len(e.samples) && now.Sub(e.samples[drop+1].at) >= e.window {
+		drop++
+	}
+	e.samples = e.samples[drop:]
+}
+
+// Estimate returns the time left to reach total; ok is false until the
+// throughput is known
+func (e *ETAEstimator) Estimate(total int64) (time.Duration, bool) {
+	if len(e.samples) < 2 {
+		return 0, false
+	}
+	first, last := e.samples[0], e.samples[len(e.samples)-1]
+	elapsed := last.at.Sub(first.at)
+	if elapsed <= 0 || last.done <= first.done {
+		return 0, false
+	}
+	if last.done >= total {
+		return 0, true
+	}
+	rate := float64(last.done-first.done) / elapsed.Seconds()
+	return time.Duration(float64(total-last.done) / rate * float64(time.Second)), true
+}
@@ -0,0 +1,341 @@
+package searcher
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/kacebover/password-finder/locale"
+)
+
+// Trace stages, in the order they are applied to a match
+const (
+	StageMatch    = "match"
+	StageOverride = "override"
+	StageSeverity = "severity"
+	StageEntropy  = "entropy"
+	StageLength   = "length"
+	StageContext  = "context"
+	StageRule     = "rule"
+	StageCap      = "cap"
+	StagePath     = "path"
+)
+
+// TraceStep is a single step of the scoring pipeline applied to a match
+type TraceStep struct {
+	Stage    string   `json:"stage"`
+	Detail   string   `json:"detail"`   // In English, LocalizeTraceStep translates it
+	Points   float64  `json:"points"`   // Contribution to the risk score
+	Severity Severity `json:"severity"` // Severity after this step
+
+	key  string // Catalog key of Detail
+	args []any  // Arguments of key
+}
+
+// newTraceStep creates a step whose detail is the catalog message key
+func newTraceStep(stage string, points float64, severity Severity, key string, args ...any) TraceStep {
+	return TraceStep{
+		Stage:    stage,
+		Detail:   english.T(key, args...),
+		Points:   points,
+		Severity: severity,
+		key:      key,
+		args:     args,
+	}
+}
+
+// MatchTrace explains how a single pattern match became a finding
+type MatchTrace struct {
+	RuleName      string      `json:"rule_name"`
+	Type          PatternType `json:"type"`
+	Description   string      `json:"description"`
+	StartIndex    int         `json:"start_index"`
+	EndIndex      int         `json:"end_index"`
+	MatchText     string      `json:"match_text"`
+	Entropy       float64     `json:"entropy"`
+	BaseSeverity  Severity    `json:"base_severity"`
+	FinalSeverity Severity    `json:"final_severity"`
+	RiskScore     float64     `json:"risk_score"`
+	Steps         []TraceStep `json:"steps"`
+}
+
+// EvaluationTrace is the result of running the detection pipeline on one line
+type EvaluationTrace struct {
+	Path    string        `json:"path"`
+	Line    string        `json:"line"`
+	Matches []*MatchTrace `json:"matches"`
+}
+
+// detectionPipeline turns pattern matches on a line into findings.
+// Scanner and StreamingScanner share it so both score matches identically.
+type detectionPipeline struct {
+	patterns    *Patterns
+	riskScorer  *RiskScorer
+	highEntropy *HighEntropyDetector // Optional, for lines no pattern matched
+	severities  SeverityOverrides    // Severities the policy sets per pattern type
+
+	decodeBase64 bool // Also run the patterns over decoded base64 tokens
+}
+
+// analyzeLine runs every pattern over the line and scores the matches.
+// When trace is non-nil each match is recorded with its scoring steps.
+func (dp detectionPipeline) analyzeLine(path, line string, lineNum int, trace *EvaluationTrace) []*Finding {
+	var findings []*Finding
+
+	matches := dp.patterns.FindAll(line)
+	if dp.decodeBase64 {
+		matches = append(matches, decodeBase64Secrets(dp.patterns, line)...)
+	}
+	// A line a specific pattern explains is not reported again as random data
+	if len(matches) == 0 && dp.highEntropy != nil && dp.patterns.Enabled(PatternHighEntropy) {
+		matches = dp.highEntropy.Detect(line)
+	}
+	for _, pattern := range matches {
+		pattern.LineNumber = lineNum
+		pattern.FilePath = path
+		pattern.Context = line
+		findings = append(findings, dp.score(pattern, trace))
+	}
+
+	return findings
+}
+
+// analyzeContent runs the enabled content detectors and the detectors of
+// the file's type over a whole file. Detectors set the line number and the
+// span within that line.
+func (dp detectionPipeline) analyzeContent(path string, lines []string) []*Finding {
+	var findings []*Finding
+
+	for _, detector := range dp.patterns.FileTypeDetectors(path) {
+		for _, pattern := range dp.patterns.enabledMatches(detector.Detect(lines)) {
+			pattern.FilePath = path
+			if pattern.LineNumber >= 1 && pattern.LineNumber <= len(lines) {
+				pattern.Context = lines[pattern.LineNumber-1]
+			}
+			findings = append(findings, dp.score(pattern, nil))
+		}
+	}
+	for _, detector := range dp.patterns.Detectors() {
+		for _, pattern := range dp.patterns.enabledMatches(detector.Detect(lines)) {
+			pattern.FilePath = path
+			if pattern.Group == "" {
+				pattern.Group = detector.Group()
+			}
+			if pattern.LineNumber >= 1 && pattern.LineNumber <= len(lines) {
+				pattern.Context = lines[pattern.LineNumber-1]
+			}
+			findings = append(findings, dp.score(pattern, nil))
+		}
+	}
+
+	return findings
+}
+
+// mergeContentFindings adds the findings of content detectors to those of
+// the lines. A line finding of the same type over the same text is dropped:
+// the content detector saw its context, e.g. the name a pack rule wants
+// next to a СНИЛС, and describes it better
+func mergeContentFindings(findings, found []*Finding) []*Finding {
+	if len(found) == 0 {
+		return findings
+	}
+	type span struct {
+		line  int
+		typ   PatternType
+		start int
+	}
+	covered := make(map[span]int, len(found))
+	for _, f := range found {
+		covered[span{f.LineNumber, f.PatternType, f.ByteStart}] = f.ByteEnd
+	}
+	kept := findings[:0]
+	for _, f := range findings {
+		if end, ok := covered[span{f.LineNumber, f.PatternType, f.ByteStart}]; ok && end == f.ByteEnd {
+			continue
+		}
+		kept = append(kept, f)
+	}
+	return append(kept, found...)
+}
+
+// hasContentDetectors reports whether whole-file detection is needed for
+// the file at path, so scanners only keep file lines in memory when a
+// detector will use them
+func (dp detectionPipeline) hasContentDetectors(path string) bool {
+	return len(dp.patterns.Detectors()) > 0 || len(dp.patterns.FileTypeDetectors(path)) > 0
+}
+
+// score turns a located match into a finding
+func (dp detectionPipeline) score(pattern *DetectedPattern, trace *EvaluationTrace) *Finding {
+	line := pattern.Context
+	baseSeverity := pattern.Severity
+	if severity, ok := dp.severities[pattern.Type]; ok {
+		pattern.Severity = severity
+	}
+	pattern.EntropyScore = dp.riskScorer.entropyCalculator.CalculateEntropy(pattern.MatchText)
+
+	riskScore, baseScore, steps := dp.riskScorer.scoreSteps(pattern)
+	if bumped := dp.pathSeverity(pattern, riskScore, baseScore); bumped != pattern.Severity {
+		steps = append(steps, newTraceStep(StagePath, 0, bumped, "trace.path_severity", pattern.Severity, bumped))
+		pattern.Severity = bumped
+	}
+
+	finding := &Finding{
+		FilePath:      pattern.FilePath,
+		LineNumber:    pattern.LineNumber,
+		ColumnStart:   RuneColumn(line, pattern.StartIndex),
+		ColumnEnd:     RuneColumn(line, pattern.EndIndex),
+		ByteStart:     pattern.StartIndex,
+		ByteEnd:       pattern.EndIndex,
+		PatternType:   pattern.Type,
+		Severity:      pattern.Severity,
+		Description:   pattern.Description,
+		MatchedText:   pattern.MatchText,
+		Context:       line,
+		EntropyScore:  pattern.EntropyScore,
+		RiskScore:     riskScore,
+		BaseRiskScore: baseScore,
+		GitStatus:     dp.riskScorer.gitStatus(dp.riskScorer.relativePath(pattern.FilePath)),
+		RuleID:        pattern.RuleName,
+		Group:         pattern.Group,
+	}
+
+	if trace != nil {
+		match := &MatchTrace{
+			RuleName:      pattern.RuleName,
+			Type:          pattern.Type,
+			Description:   pattern.Description,
+			StartIndex:    pattern.StartIndex,
+			EndIndex:      pattern.EndIndex,
+			MatchText:     pattern.MatchText,
+			Entropy:       pattern.EntropyScore,
+			BaseSeverity:  baseSeverity,
+			FinalSeverity: finding.Severity,
+			RiskScore:     finding.RiskScore,
+		}
+		head := []TraceStep{newTraceStep(StageMatch, 0, baseSeverity, "trace.match", pattern.RuleName, pattern.Pattern)}
+		if pattern.Severity != baseSeverity {
+			head = append(head, newTraceStep(StageOverride, 0, pattern.Severity, "trace.override", pattern.Type, baseSeverity, pattern.Severity))
+		}
+		match.Steps = append(head, steps...)
+		trace.Matches = append(trace.Matches, match)
+	}
+
+	return finding
+}
+
+// pathSeverity returns the severity of a match whose path factor moved its
+// score from base to score: raised when RiskWeights.PathSeverity is on and
+// the path lifted the score into a higher band. A severity the policy sets
+// for the type is kept
+func (dp detectionPipeline) pathSeverity(pattern *DetectedPattern, score, base float64) Severity {
+	severity := pattern.Severity
+	if _, ok := dp.severities[pattern.Type]; ok || !dp.riskScorer.weights.PathSeverity || score <= base {
+		return severity
+	}
+	banded := dp.riskScorer.AssignSeverityFromRiskScore(score)
+	if banded.Score() > dp.riskScorer.AssignSeverityFromRiskScore(base).Score() && banded.Score() > severity.Score() {
+		return banded
+	}
+	return severity
+}
+
+// pipeline returns the detection pipeline configured for this scanner
+func (s *Scanner) pipeline() detectionPipeline {
+	return detectionPipeline{
+		patterns:    s.patterns,
+		riskScorer:  s.riskScorer.forScan(s.globRoot, s.gitTracked),
+		highEntropy: s.highEntropy,
+		severities:  s.severities,
+
+		decodeBase64: s.decodeBase64,
+	}
+}
+
+// Evaluate runs the full detection pipeline on a single line and explains
+// every match: the rule that fired, its entropy, each scoring step and the
+// final severity. It is meant for testing rules and debugging false positives.
+func (s *Scanner) Evaluate(line, path string) *EvaluationTrace {
+	trace := &EvaluationTrace{
+		Path:    path,
+		Line:    line,
+		Matches: make([]*MatchTrace, 0),
+	}
+	s.pipeline().analyzeLine(path, line, 1, trace)
+	return trace
+}
+
+// UnderlineSpan returns a caret line that underlines the byte span
+// [start, end) of line when both are printed in a monospace font
+func UnderlineSpan(line string, start, end int) string {
+	if start < 0 || end > len(line) || start > end {
+		return ""
+	}
+
+	prefix := utf8.RuneCountInString(line[:start])
+	width := utf8.RuneCountInString(line[start:end])
+	if width == 0 {
+		width = 1
+	}
+
+	return strings.Repeat(" ", prefix) + strings.Repeat("^", width)
+}
+
+// LocalizeTraceStep returns the detail of a step in the language of l
+func LocalizeTraceStep(l *locale.Localizer, step TraceStep) string {
+	if step.key == "" {
+		return step.Detail
+	}
+	return l.T(step.key, step.args...)
+}
+
+// FormatScoreSteps renders the contribution of every scoring factor and the total
+func FormatScoreSteps(l *locale.Localizer, score float64, steps []TraceStep) string {
+	var sb strings.Builder
+	for _, step := range steps {
+		if step.Stage == StageMatch {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("%-9s %+6.1f  %s\n", step.Stage, step.Points, LocalizeTraceStep(l, step)))
+	}
+	sb.WriteString(fmt.Sprintf("%-9s %6.1f\n", l.T("trace.total"), score))
+	return sb.String()
+}
+
+// FormatEvaluationTrace renders a trace as human-readable text
+func FormatEvaluationTrace(l *locale.Localizer, trace *EvaluationTrace) string {
+	var sb strings.Builder
+
+	if trace.Path != "" {
+		sb.WriteString(l.T("trace.file", trace.Path) + "\n")
+	}
+	sb.WriteString(l.T("trace.line", trace.Line) + "\n")
+
+	if len(trace.Matches) == 0 {
+		sb.WriteString(l.T("trace.no_matches") + "\n")
+		return sb.String()
+	}
+
+	for i, match := range trace.Matches {
+		underline := UnderlineSpan(trace.Line, match.StartIndex, match.EndIndex)
+
+		sb.WriteString("\n")
+		sb.WriteString(fmt.Sprintf("[%d] %s (%s) — %s\n", i+1, match.RuleName, match.Type, LocalizeDescription(l, match.Description)))
+		sb.WriteString(fmt.Sprintf("    %s\n", trace.Line))
+		sb.WriteString(fmt.Sprintf("    %s\n", underline))
+		sb.WriteString("    " + l.T("trace.span", match.MatchText,
+			RuneColumn(trace.Line, match.StartIndex), RuneColumn(trace.Line, match.EndIndex), match.StartIndex, match.EndIndex) + "\n")
+		sb.WriteString("    " + l.T("trace.entropy_value", match.Entropy) + "\n")
+		sb.WriteString("    " + l.T("trace.steps") + "\n")
+		for _, step := range match.Steps {
+			if step.Stage == StageMatch {
+				sb.WriteString(fmt.Sprintf("      %-9s %s\n", step.Stage, LocalizeTraceStep(l, step)))
+				continue
+			}
+			sb.WriteString(fmt.Sprintf("      %-9s %+6.1f  %s [%s]\n", step.Stage, step.Points, LocalizeTraceStep(l, step), step.Severity))
+		}
+		sb.WriteString("    " + l.T("trace.result", match.RiskScore, match.BaseSeverity, match.FinalSeverity) + "\n")
+	}
+
+	return sb.String()
+}
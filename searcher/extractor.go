@@ -1,11 +1,10 @@
 package searcher
 
 import (
-	"archive/tar"
 	"archive/zip"
-	"bytes"
-	"compress/gzip"
+	"context"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -13,6 +12,9 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
+	"sync"
+
+	"github.com/kacebover/password-finder/fsutil"
 )
 
 // DocumentExtractor extracts text from various document formats
@@ -21,6 +23,27 @@ type DocumentExtractor struct {
 	tesseractCmd string
 	maxFileSize  int64
 	tempDir      string
+	pdfPasswords []string // Tried on password-protected PDFs after the empty password
+	maxPDFPages  int      // Pages of a PDF read, see SetMaxPDFPages
+
+	// Limits of nested archive extraction
+	maxArchiveDepth  int
+	maxExtractBudget int64
+
+	// OCR disk cache, see ocr_cache.go
+	cacheMu         sync.Mutex
+	cacheEnabled    bool
+	cacheDir        string
+	cacheMaxEntries int
+	cacheMaxBytes   int64
+	diskCache       *ocrDiskCache
+
+	langsOnce sync.Once
+	langs     string // Tesseract languages, see tesseractLangs
+
+	// ocr recognises an image with the languages; nil runs Tesseract.
+	// Tests replace it
+	ocr func(ctx context.Context, imagePath, lang string) (string, error)
 }
 
 // NewDocumentExtractor creates a new document extractor
@@ -30,6 +53,10 @@ func NewDocumentExtractor(enableOCR bool) *DocumentExtractor {
 		tesseractCmd: "tesseract",
 		maxFileSize:  100 * 1024 * 1024, // 100MB
 		tempDir:      os.TempDir(),
+		maxPDFPages:  DefaultMaxPDFPages,
+
+		maxArchiveDepth:  DefaultMaxArchiveDepth,
+		maxExtractBudget: DefaultMaxExtractBudget,
 	}
 }
 
@@ -38,145 +65,296 @@ func (de *DocumentExtractor) SetOCREnabled(enabled bool) {
 	de.enableOCR = enabled
 }
 
+// SetMaxFileSize sets the largest file or archive member read into memory
+func (de *DocumentExtractor) SetMaxFileSize(size int64) {
+	de.maxFileSize = size
+}
+
+// SetPDFPasswords sets the passwords tried on protected PDFs
+func (de *DocumentExtractor) SetPDFPasswords(passwords []string) {
+	de.pdfPasswords = passwords
+}
+
 // ExtractedContent holds extracted text and metadata
 type ExtractedContent struct {
 	Text       string
 	SourceFile string
 	Format     string
 	PageCount  int
+	PageLines  []int // Line of Text each page starts on, see Page; nil when the pages are unknown
+	Truncated  bool  // Only the first pages were read, see SetMaxPDFPages
 	Error      error
+	Encrypted  bool // Password-protected PDF; Text is empty unless it was unlocked
 }
 
+// documentFormats are the extensions the scanner hands to ExtractText
+// when documents are scanned. plainDocuments can be read as text as well,
+// so without document scanning they are scanned line by line instead of
+// skipped
+var (
+	documentFormats = map[string]bool{
+		".pdf": true, ".docx": true, ".doc": true, ".xlsx": true, ".xls": true,
+		".pptx": true, ".odt": true, ".ods": true, ".odp": true,
+		".rtf": true, ".eml": true, ".msg": true,
+	}
+	plainDocuments = map[string]bool{".rtf": true, ".eml": true}
+)
+
 // ExtractText extracts text from a file based on its type
 func (de *DocumentExtractor) ExtractText(filePath string) (*ExtractedContent, error) {
+	return de.ExtractTextContext(context.Background(), filePath)
+}
+
+// ExtractTextContext is ExtractText that stops between archive members, PDF
+// pages and OCR runs once ctx is cancelled
+func (de *DocumentExtractor) ExtractTextContext(ctx context.Context, filePath string) (*ExtractedContent, error) {
+	if err := cancelledError(ctx); err != nil {
+		return nil, err
+	}
 	ext := strings.ToLower(filepath.Ext(filePath))
 
+	info, err := fsutil.Stat(filePath)
+	if err != nil {
+		return nil, pathError(err)
+	}
+	if info.Size() > de.maxFileSize {
+		return nil, fmt.Errorf("%w: %s (%d байт, предел %d)", ErrTooLarge, filePath, info.Size(), de.maxFileSize)
+	}
+
 	switch ext {
 	case ".pdf":
-		return de.extractPDF(filePath)
+		return de.extractPDF(ctx, filePath)
 	case ".docx":
 		return de.extractDOCX(filePath)
 	case ".doc":
 		return de.extractDOC(filePath)
 	case ".xlsx", ".xls":
 		return de.extractExcel(filePath)
+	case ".pptx":
+		return de.extractPPTX(filePath)
+	case ".odt", ".ods", ".odp":
+		return de.extractODF(filePath)
+	case ".rtf":
+		return de.extractRTF(filePath)
+	case ".eml":
+		return de.extractEML(filePath)
+	case ".msg":
+		return de.extractMSG(filePath)
 	case ".png", ".jpg", ".jpeg", ".gif", ".bmp", ".tiff", ".tif":
-		return de.extractImage(filePath)
+		return de.extractImage(ctx, filePath)
 	case ".zip":
-		return de.extractZIP(filePath)
+		return de.extractZIP(ctx, filePath)
 	case ".tar":
-		return de.extractTAR(filePath)
+		return de.extractTAR(ctx, filePath)
 	case ".gz", ".tgz":
-		return de.extractGzip(filePath)
+		return de.extractGzip(ctx, filePath)
 	case ".txt", ".md", ".rst", ".csv", ".json", ".xml", ".yaml", ".yml":
 		return de.extractPlainText(filePath)
 	default:
-		return nil, fmt.Errorf("неподдерживаемый формат: %s", ext)
+		return nil, &ErrUnsupportedFormat{Ext: ext}
 	}
 }
 
-// extractPDF extracts text from PDF files
-func (de *DocumentExtractor) extractPDF(filePath string) (*ExtractedContent, error) {
+// extractPDF extracts text from PDF files, page by page
+func (de *DocumentExtractor) extractPDF(ctx context.Context, filePath string) (*ExtractedContent, error) {
 	content := &ExtractedContent{
 		SourceFile: filePath,
 		Format:     "PDF",
 	}
 
-	data, err := os.ReadFile(filePath)
+	data, err := fsutil.ReadFile(filePath)
 	if err != nil {
 		return nil, err
 	}
+	total := pdfPageCount(ctx, filePath, data)
 
-	// Simple PDF text extraction (basic implementation)
-	text := de.extractPDFText(data)
+	// Encrypted streams only yield garbage, unlock them first
+	if enc, encrypted := parsePDFEncryption(data); encrypted {
+		content.Encrypted = true
+		pages, err := de.unlockPDF(ctx, filePath, data, enc)
+		content.setPages(pages, total, de.maxPDFPages)
+		content.Error = err
+		return content, nil
+	}
 
-	if text == "" {
+	// Simple PDF text extraction (basic implementation)
+	var pages []string
+	if text := de.extractPDFText(data); text != "" {
+		pages = pdfPageTexts(data, nil, nil)
+		if strings.TrimSpace(strings.Join(pages, "")) == "" {
+			// Text outside any content stream, pages unknown
+			pages = []string{text}
+		}
+	} else if pdfPages := de.tryPdfToText(ctx, filePath); pdfPages != nil {
 		// Try pdftotext first if available
-		if pdfText := de.tryPdfToText(filePath); pdfText != "" {
-			text = pdfText
-		} else if de.enableOCR {
-			// Try OCR on PDF (convert pages to images)
-			ocrText, ocrErr := de.ocrPDF(filePath)
-			if ocrErr == nil && ocrText != "" {
-				text = ocrText
-			} else if ocrErr != nil {
-				// Return error info for debugging
-				content.Error = ocrErr
-			}
+		pages = pdfPages
+	} else if de.enableOCR {
+		// Try OCR on PDF (convert pages to images)
+		ocrPages, ocrErr := de.ocrPDF(ctx, filePath)
+		if ocrErr == nil {
+			pages = ocrPages
+		} else {
+			// Return error info for debugging
+			content.Error = ocrErr
 		}
 	}
 
-	content.Text = text
+	content.setPages(pages, total, de.maxPDFPages)
 	return content, nil
 }
 
-// tryPdfToText tries to extract text using pdftotext command
-func (de *DocumentExtractor) tryPdfToText(filePath string) string {
-	// Check if pdftotext is available
-	pdftotext, err := exec.LookPath("pdftotext")
+// unlockPDF tries the empty password and the configured ones, first with
+// pdftotext or qpdf and then with the built-in RC4/AES-128 decryptor.
+// It returns the text of the pages, or ErrPDFEncrypted if no password fits.
+func (de *DocumentExtractor) unlockPDF(ctx context.Context, filePath string, data []byte, enc *pdfEncryption) ([]string, error) {
+	passwords := append([]string{""}, de.pdfPasswords...)
+
+	for _, password := range passwords {
+		if pages := de.unlockPDFWithTools(ctx, filePath, password); pages != nil {
+			return pages, nil
+		}
+	}
+
+	if !enc.supported() {
+		return nil, fmt.Errorf("%w (%s не поддерживается без qpdf или Poppler)", ErrPDFEncrypted, enc.describe())
+	}
+	for _, password := range passwords {
+		if key, ok := enc.Unlock(password); ok {
+			return pdfPageTexts(data, enc, key), nil
+		}
+	}
+	return nil, ErrPDFEncrypted
+}
+
+// unlockPDFWithTools extracts the text of a protected PDF with pdftotext,
+// or decrypts it with qpdf and reads the streams. Passwords are passed on
+// the command line, as both tools require.
+func (de *DocumentExtractor) unlockPDFWithTools(ctx context.Context, filePath, password string) []string {
+	if pdftotext, ok := Dependencies().ToolPath("pdftotext"); ok {
+		for _, flag := range []string{"-upw", "-opw"} {
+			args := append(append([]string{flag, password, "-layout"}, de.pdfPageArgs()...), filePath, "-")
+			output, err := exec.CommandContext(ctx, pdftotext, args...).Output()
+			if err == nil && strings.TrimSpace(string(output)) != "" {
+				return splitFormFeeds(string(output))
+			}
+		}
+	}
+
+	qpdf, ok := Dependencies().ToolPath("qpdf")
+	if !ok {
+		return nil
+	}
+	tmp, err := os.CreateTemp("", "pdf_unlock_*.pdf")
 	if err != nil {
-		return ""
+		return nil
 	}
+	tmp.Close()
+	defer os.Remove(tmp.Name())
 
-	cmd := exec.Command(pdftotext, "-layout", filePath, "-")
-	output, err := cmd.Output()
+	// Exit code 3 means success with warnings
+	err = exec.CommandContext(ctx, qpdf, "--password="+password, "--decrypt", filePath, tmp.Name()).Run()
+	var exitErr *exec.ExitError
+	if err != nil && !(errors.As(err, &exitErr) && exitErr.ExitCode() == 3) {
+		return nil
+	}
+	decrypted, err := os.ReadFile(tmp.Name())
 	if err != nil {
-		return ""
+		return nil
+	}
+	pages := pdfPageTexts(decrypted, nil, nil)
+	if strings.TrimSpace(strings.Join(pages, "")) == "" {
+		return nil
+	}
+	return pages
+}
+
+// tryPdfToText tries to extract the pages using pdftotext command; nil
+// when it is missing or finds no text
+func (de *DocumentExtractor) tryPdfToText(ctx context.Context, filePath string) []string {
+	// Check if pdftotext is available
+	pdftotext, ok := Dependencies().ToolPath("pdftotext")
+	if !ok {
+		return nil
 	}
 
-	return strings.TrimSpace(string(output))
+	args := append(append([]string{"-layout"}, de.pdfPageArgs()...), filePath, "-")
+	output, err := exec.CommandContext(ctx, pdftotext, args...).Output()
+	if err != nil || strings.TrimSpace(string(output)) == "" {
+		return nil
+	}
+
+	return splitFormFeeds(string(output))
 }
 
 // ocrPDF performs OCR on a PDF by converting pages to images
-func (de *DocumentExtractor) ocrPDF(filePath string) (string, error) {
+func (de *DocumentExtractor) ocrPDF(ctx context.Context, filePath string) ([]string, error) {
 	if !de.isTesseractAvailable() {
-		return "", fmt.Errorf("tesseract не установлен")
+		return nil, &ErrDependencyMissing{Name: DependencyTesseract}
 	}
 
 	// Check if pdftoppm is available (for converting PDF to images)
-	pdftoppm, err := exec.LookPath("pdftoppm")
-	if err != nil {
+	pdftoppm, ok := Dependencies().ToolPath("pdftoppm")
+	if !ok {
 		// Fallback: try direct OCR on PDF (some Tesseract builds support it)
-		return de.performOCR(filePath)
+		text, err := de.performOCR(ctx, filePath)
+		if err != nil {
+			return nil, err
+		}
+		return []string{text}, nil
 	}
 
 	// Create temp directory for images
 	tmpDir, err := os.MkdirTemp("", "pdf_ocr_")
 	if err != nil {
-		return "", fmt.Errorf("ошибка создания temp директории: %v", err)
+		return nil, fmt.Errorf("ошибка создания temp директории: %v", err)
 	}
 	defer os.RemoveAll(tmpDir)
 
 	// Convert PDF to images
 	outputPrefix := filepath.Join(tmpDir, "page")
-	cmd := exec.Command(pdftoppm, "-png", "-r", "150", filePath, outputPrefix)
+	args := append(append([]string{"-png", "-r", "150"}, de.pdfPageArgs()...), filePath, outputPrefix)
+	cmd := exec.CommandContext(ctx, pdftoppm, args...)
 	output, err := cmd.CombinedOutput()
+	if err := cancelledError(ctx); err != nil {
+		return nil, err
+	}
 	if err != nil {
-		return "", fmt.Errorf("ошибка конвертации PDF: %v - %s", err, string(output))
+		return nil, fmt.Errorf("ошибка конвертации PDF: %v - %s", err, string(output))
 	}
 
 	// OCR each image
-	var allText []string
 	entries, err := os.ReadDir(tmpDir)
 	if err != nil {
-		return "", fmt.Errorf("ошибка чтения temp директории: %v", err)
+		return nil, fmt.Errorf("ошибка чтения temp директории: %v", err)
 	}
 
+	var pages []string
+	recognised := false
 	for _, entry := range entries {
+		if err := cancelledError(ctx); err != nil {
+			return nil, err
+		}
 		if strings.HasSuffix(entry.Name(), ".png") {
 			imgPath := filepath.Join(tmpDir, entry.Name())
-			text, err := de.performOCR(imgPath)
-			if err == nil && text != "" {
-				allText = append(allText, text)
+			text, err := de.performOCR(ctx, imgPath)
+			if err != nil {
+				text = ""
+			}
+			// Rendered pages are numbered from 1
+			page := extractPageNumber(entry.Name())
+			for len(pages) < page {
+				pages = append(pages, "")
 			}
+			pages[page-1] = text
+			recognised = recognised || strings.TrimSpace(text) != ""
 		}
 	}
 
-	if len(allText) == 0 {
-		return "", fmt.Errorf("OCR не извлёк текст из %d страниц (установите языковой пакет: brew install tesseract-lang)", len(entries))
+	if !recognised {
+		return nil, fmt.Errorf("OCR не извлёк текст из %d страниц (установите языковой пакет: brew install tesseract-lang)", len(entries))
 	}
 
-	return strings.Join(allText, "\n\n"), nil
+	return pages, nil
 }
 
 // extractPDFText performs basic PDF text extraction
@@ -238,15 +416,21 @@ func (de *DocumentExtractor) extractDOCX(filePath string) (*ExtractedContent, er
 		Format:     "DOCX",
 	}
 
-	r, err := zip.OpenReader(filePath)
+	r, err := zip.OpenReader(fsutil.Normalize(filePath))
 	if err != nil {
 		return nil, err
 	}
 	defer r.Close()
 
+	content.Text = de.docxText(r.File)
+	return content, nil
+}
+
+// docxText extracts the body, headers and footers of a DOCX document
+func (de *DocumentExtractor) docxText(files []*zip.File) string {
 	var texts []string
 
-	for _, f := range r.File {
+	for _, f := range files {
 		// Main document content
 		if f.Name == "word/document.xml" {
 			text, err := de.extractXMLText(f)
@@ -270,8 +454,7 @@ func (de *DocumentExtractor) extractDOCX(filePath string) (*ExtractedContent, er
 		}
 	}
 
-	content.Text = strings.Join(texts, "\n")
-	return content, nil
+	return strings.Join(texts, "\n")
 }
 
 // extractXMLText extracts text content from an XML file in a ZIP
@@ -326,7 +509,7 @@ func (de *DocumentExtractor) extractDOC(filePath string) (*ExtractedContent, err
 		Format:     "DOC",
 	}
 
-	data, err := os.ReadFile(filePath)
+	data, err := fsutil.ReadFile(filePath)
 	if err != nil {
 		return nil, err
 	}
@@ -369,7 +552,7 @@ func (de *DocumentExtractor) extractExcel(filePath string) (*ExtractedContent, e
 	}
 
 	// Old XLS format - basic extraction
-	data, err := os.ReadFile(filePath)
+	data, err := fsutil.ReadFile(filePath)
 	if err != nil {
 		return nil, err
 	}
@@ -385,7 +568,7 @@ func (de *DocumentExtractor) extractXLSX(filePath string) (*ExtractedContent, er
 		Format:     "XLSX",
 	}
 
-	r, err := zip.OpenReader(filePath)
+	r, err := zip.OpenReader(fsutil.Normalize(filePath))
 	if err != nil {
 		return nil, err
 	}
@@ -439,7 +622,7 @@ func (de *DocumentExtractor) extractXLSX(filePath string) (*ExtractedContent, er
 }
 
 // extractImage extracts text from images using OCR
-func (de *DocumentExtractor) extractImage(filePath string) (*ExtractedContent, error) {
+func (de *DocumentExtractor) extractImage(ctx context.Context, filePath string) (*ExtractedContent, error) {
 	content := &ExtractedContent{
 		SourceFile: filePath,
 		Format:     "Image",
@@ -449,24 +632,68 @@ func (de *DocumentExtractor) extractImage(filePath string) (*ExtractedContent, e
 		return nil, fmt.Errorf("OCR отключён, пропуск изображения: %s", filePath)
 	}
 
-	text, err := de.performOCR(filePath)
+	// An unchanged image is not recognised twice; PDF pages rendered to
+	// temporary images bypass the caches
+	key := ocrCacheKey(filePath)
+	if text, ok := ocrTextCache.Get(key); ok && key != "" {
+		content.Text = text
+		return content, nil
+	}
+
+	// Recognised on an earlier run, maybe under another name
+	entry, diskKey := de.cachedImage(filePath)
+	if entry != nil && entry.Text != nil {
+		content.Text = *entry.Text
+		if key != "" {
+			ocrTextCache.Put(key, content.Text, int64(len(key)+len(content.Text)))
+		}
+		return content, nil
+	}
+
+	text, err := de.performOCR(ctx, filePath)
 	if err != nil {
 		return nil, err
 	}
+	if key != "" {
+		ocrTextCache.Put(key, text, int64(len(key)+len(text)))
+	}
+	de.updateImage(diskKey, func(e *ocrCacheEntry) { e.Text = &text })
 
 	content.Text = text
 	return content, nil
 }
 
 // performOCR runs Tesseract OCR on an image
-func (de *DocumentExtractor) performOCR(filePath string) (string, error) {
+func (de *DocumentExtractor) performOCR(ctx context.Context, filePath string) (string, error) {
+	if de.ocr != nil {
+		return de.ocr(ctx, filePath, de.tesseractLangs())
+	}
+
+	// Tesseract compiled in (cgo_ocr) reuses its clients; a client that
+	// cannot start leaves the image to the command line tool
+	if pool := nativeOCR(); pool != nil {
+		text, err := pool.recognize(ctx, filePath, de.tesseractLangs())
+		if err == nil || errors.Is(err, ErrCancelled) {
+			return text, err
+		}
+	}
+
 	// Check if Tesseract is available
 	if !de.isTesseractAvailable() {
-		return "", fmt.Errorf("tesseract не установлен или недоступен")
+		return "", &ErrDependencyMissing{Name: DependencyTesseract}
 	}
 
-	// Use gosseract if available, otherwise fall back to command line
-	return de.runTesseractCLI(filePath)
+	return de.runTesseractCLI(ctx, filePath, de.tesseractLangs())
+}
+
+// ocrCacheKey identifies a file version by path, size and modification
+// time; empty when the file cannot be read
+func ocrCacheKey(filePath string) string {
+	info, err := fsutil.Stat(filePath)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%s|%d|%d", filePath, info.Size(), info.ModTime().UnixNano())
 }
 
 // IsTesseractAvailable checks if Tesseract is installed (exported)
@@ -474,46 +701,44 @@ func (de *DocumentExtractor) IsTesseractAvailable() bool {
 	return de.isTesseractAvailable()
 }
 
-// isTesseractAvailable checks if Tesseract is installed
+// isTesseractAvailable checks if Tesseract is installed using the shared registry
 func (de *DocumentExtractor) isTesseractAvailable() bool {
-	// Check common locations
-	paths := []string{
-		"/usr/local/bin/tesseract",
-		"/usr/bin/tesseract",
-		"/opt/homebrew/bin/tesseract",
-		"tesseract", // Check PATH
-	}
-
-	for _, p := range paths {
-		if _, err := os.Stat(p); err == nil {
-			de.tesseractCmd = p
-			return true
-		}
-	}
-	
-	// Also check using which/where command
-	if path, err := exec.LookPath("tesseract"); err == nil {
-		de.tesseractCmd = path
-		return true
-	}
+	status := Dependencies().Status(DependencyTesseract)
+	return status != nil && status.Available
+}
 
-	return false
+// tesseractBinary returns the Tesseract found by the registry
+func (de *DocumentExtractor) tesseractBinary() string {
+	if status := Dependencies().Status(DependencyTesseract); status != nil && status.Path != "" {
+		return status.Path
+	}
+	return de.tesseractCmd
 }
 
-// runTesseractCLI runs Tesseract via command line
-func (de *DocumentExtractor) runTesseractCLI(imagePath string) (string, error) {
-	// Create temp file for output
-	tmpFile := filepath.Join(de.tempDir, "ocr_output")
+// runTesseractCLI runs Tesseract via command line. Every run writes to its
+// own output file, so concurrent workers never read each other's text
+func (de *DocumentExtractor) runTesseractCLI(ctx context.Context, imagePath, lang string) (string, error) {
+	// Tesseract appends .txt to the output base
+	tmpFile, err := os.CreateTemp(de.tempDir, "ocr_output_*")
+	if err != nil {
+		return "", fmt.Errorf("ошибка создания временного файла OCR: %w", err)
+	}
+	tmpFile.Close()
+	outputBase := tmpFile.Name()
+	outputPath := outputBase + ".txt"
+	defer os.Remove(outputBase)
+	defer os.Remove(outputPath)
 
-	// Detect available languages
-	lang := de.getAvailableTesseractLangs()
-	
-	cmd := exec.Command(de.tesseractCmd, imagePath, tmpFile, "-l", lang)
+	tesseract := de.tesseractBinary()
+	cmd := exec.CommandContext(ctx, tesseract, imagePath, outputBase, "-l", lang)
 	output, err := cmd.CombinedOutput()
+	if err := cancelledError(ctx); err != nil {
+		return "", err
+	}
 	if err != nil {
 		// If rus+eng fails, try just eng
 		if strings.Contains(string(output), "rus") {
-			cmd = exec.Command(de.tesseractCmd, imagePath, tmpFile, "-l", "eng")
+			cmd = exec.CommandContext(ctx, tesseract, imagePath, outputBase, "-l", "eng")
 			if err := cmd.Run(); err != nil {
 				return "", fmt.Errorf("ошибка Tesseract: %v", err)
 			}
@@ -522,10 +747,6 @@ func (de *DocumentExtractor) runTesseractCLI(imagePath string) (string, error) {
 		}
 	}
 
-	// Read output
-	outputPath := tmpFile + ".txt"
-	defer os.Remove(outputPath)
-
 	data, err := os.ReadFile(outputPath)
 	if err != nil {
 		return "", err
@@ -534,10 +755,22 @@ func (de *DocumentExtractor) runTesseractCLI(imagePath string) (string, error) {
 	return string(data), nil
 }
 
+// tesseractLangs returns the languages OCR runs with, asking Tesseract
+// once per extractor
+func (de *DocumentExtractor) tesseractLangs() string {
+	de.langsOnce.Do(func() {
+		de.langs = "eng"
+		if de.isTesseractAvailable() {
+			de.langs = de.getAvailableTesseractLangs()
+		}
+	})
+	return de.langs
+}
+
 // getAvailableTesseractLangs returns available language string for Tesseract
 func (de *DocumentExtractor) getAvailableTesseractLangs() string {
 	// Check if Russian is available
-	cmd := exec.Command(de.tesseractCmd, "--list-langs")
+	cmd := exec.Command(de.tesseractBinary(), "--list-langs")
 	output, err := cmd.Output()
 	if err != nil {
 		return "eng"
@@ -559,181 +792,18 @@ func (de *DocumentExtractor) getAvailableTesseractLangs() string {
 }
 
 // extractZIP extracts and scans contents of ZIP archives
-func (de *DocumentExtractor) extractZIP(filePath string) (*ExtractedContent, error) {
-	content := &ExtractedContent{
-		SourceFile: filePath,
-		Format:     "ZIP",
-	}
-
-	r, err := zip.OpenReader(filePath)
-	if err != nil {
-		return nil, err
-	}
-	defer r.Close()
-
-	var texts []string
-
-	for _, f := range r.File {
-		// Skip directories
-		if f.FileInfo().IsDir() {
-			continue
-		}
-
-		// Check if encrypted (bit 0 of Flags indicates encryption)
-		if f.Flags&0x1 != 0 {
-			texts = append(texts, fmt.Sprintf("[Зашифрованный файл: %s]", f.Name))
-			continue
-		}
-
-		// Skip large files
-		if f.UncompressedSize64 > uint64(de.maxFileSize) {
-			continue
-		}
-
-		// Extract and process file
-		rc, err := f.Open()
-		if err != nil {
-			continue
-		}
-
-		data, err := io.ReadAll(rc)
-		rc.Close()
-		if err != nil {
-			continue
-		}
-
-		// Process based on file extension
-		ext := strings.ToLower(filepath.Ext(f.Name))
-		switch ext {
-		case ".txt", ".md", ".json", ".xml", ".yaml", ".yml", ".env", ".cfg", ".conf", ".ini":
-			texts = append(texts, fmt.Sprintf("=== %s ===\n%s", f.Name, string(data)))
-		case ".docx":
-			// Create temp file and extract
-			tmpPath := filepath.Join(de.tempDir, "temp_"+filepath.Base(f.Name))
-			if err := os.WriteFile(tmpPath, data, 0644); err == nil {
-				if extracted, err := de.extractDOCX(tmpPath); err == nil {
-					texts = append(texts, fmt.Sprintf("=== %s ===\n%s", f.Name, extracted.Text))
-				}
-				os.Remove(tmpPath)
-			}
-		}
-	}
-
-	content.Text = strings.Join(texts, "\n\n")
-	return content, nil
+func (de *DocumentExtractor) extractZIP(ctx context.Context, filePath string) (*ExtractedContent, error) {
+	return de.extractArchive(ctx, filePath, "ZIP")
 }
 
 // extractTAR extracts and scans contents of TAR archives
-func (de *DocumentExtractor) extractTAR(filePath string) (*ExtractedContent, error) {
-	content := &ExtractedContent{
-		SourceFile: filePath,
-		Format:     "TAR",
-	}
-
-	f, err := os.Open(filePath)
-	if err != nil {
-		return nil, err
-	}
-	defer f.Close()
-
-	tr := tar.NewReader(f)
-	var texts []string
-
-	for {
-		header, err := tr.Next()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			return nil, err
-		}
-
-		// Skip directories
-		if header.Typeflag == tar.TypeDir {
-			continue
-		}
-
-		// Skip large files
-		if header.Size > de.maxFileSize {
-			continue
-		}
-
-		// Read file content
-		data, err := io.ReadAll(tr)
-		if err != nil {
-			continue
-		}
-
-		// Process text files
-		ext := strings.ToLower(filepath.Ext(header.Name))
-		if isTextExtension(ext) {
-			texts = append(texts, fmt.Sprintf("=== %s ===\n%s", header.Name, string(data)))
-		}
-	}
-
-	content.Text = strings.Join(texts, "\n\n")
-	return content, nil
+func (de *DocumentExtractor) extractTAR(ctx context.Context, filePath string) (*ExtractedContent, error) {
+	return de.extractArchive(ctx, filePath, "TAR")
 }
 
 // extractGzip extracts and scans contents of gzipped files
-func (de *DocumentExtractor) extractGzip(filePath string) (*ExtractedContent, error) {
-	content := &ExtractedContent{
-		SourceFile: filePath,
-		Format:     "GZIP",
-	}
-
-	f, err := os.Open(filePath)
-	if err != nil {
-		return nil, err
-	}
-	defer f.Close()
-
-	gr, err := gzip.NewReader(f)
-	if err != nil {
-		return nil, err
-	}
-	defer gr.Close()
-
-	data, err := io.ReadAll(gr)
-	if err != nil {
-		return nil, err
-	}
-
-	// Check if it's a tar.gz
-	if strings.HasSuffix(filePath, ".tar.gz") || strings.HasSuffix(filePath, ".tgz") {
-		tr := tar.NewReader(bytes.NewReader(data))
-		var texts []string
-
-		for {
-			header, err := tr.Next()
-			if err == io.EOF {
-				break
-			}
-			if err != nil {
-				break
-			}
-
-			if header.Typeflag == tar.TypeDir {
-				continue
-			}
-
-			fileData, err := io.ReadAll(tr)
-			if err != nil {
-				continue
-			}
-
-			ext := strings.ToLower(filepath.Ext(header.Name))
-			if isTextExtension(ext) {
-				texts = append(texts, fmt.Sprintf("=== %s ===\n%s", header.Name, string(fileData)))
-			}
-		}
-
-		content.Text = strings.Join(texts, "\n\n")
-	} else {
-		content.Text = string(data)
-	}
-
-	return content, nil
+func (de *DocumentExtractor) extractGzip(ctx context.Context, filePath string) (*ExtractedContent, error) {
+	return de.extractArchive(ctx, filePath, "GZIP")
 }
 
 // extractPlainText reads plain text files
@@ -743,7 +813,7 @@ func (de *DocumentExtractor) extractPlainText(filePath string) (*ExtractedConten
 		Format:     "Text",
 	}
 
-	data, err := os.ReadFile(filePath)
+	data, err := fsutil.ReadFile(filePath)
 	if err != nil {
 		return nil, err
 	}
@@ -798,6 +868,10 @@ func (de *DocumentExtractor) SupportedFormats() []string {
 		"DOC (Word старый)",
 		"XLSX (Excel)",
 		"XLS (Excel старый)",
+		"PPTX (PowerPoint)",
+		"ODT/ODS/ODP (OpenDocument)",
+		"RTF",
+		"EML (письма) и MSG (Outlook, частично)",
 		"ZIP архивы",
 		"TAR архивы",
 		"GZIP/TGZ архивы",
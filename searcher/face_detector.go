@@ -0,0 +1,239 @@
+package searcher
+
+import (
+	_ "embed"
+	"encoding/binary"
+	"image"
+	"math"
+	"sort"
+	"sync"
+)
+
+// Faces are found with pico (Markuš et al., "Object Detection with Pixel
+// Intensity Comparisons Organized in Decision Trees"): a window slides over
+// the image at several sizes and passes a cascade of decision trees, each
+// node comparing the brightness of two pixels placed relative to the
+// window. The cascade is the facefinder trained on frontal faces by the
+// authors of pico, as pigo ships it (MIT, see cascades/LICENSE). Images are
+// scaled down first, so a detection costs a few tens of milliseconds
+// whatever the size of the image
+
+//go:embed cascades/facefinder
+var faceFinderCascade []byte
+
+// Bounds of face detection
+const (
+	faceMaxSide     = 480 // Longest side of the scaled down image
+	faceMinWindow   = 24  // Smallest face in pixels of the scaled image
+	faceScaleStep   = 1.1 // Ratio of successive window sizes
+	faceShift       = 0.1 // Step of the window, in window sizes
+	faceOverlap     = 0.2 // Intersection over union of windows of the same face
+	faceMinQuality  = 5.0 // Summed confidence of a face's windows below which it is dropped
+	faceMaxPerImage = 16  // Faces kept, largest first
+)
+
+// faceRect is a face found in an image, in pixels of the image
+type faceRect struct {
+	x, y, size int
+	quality    float64 // Summed confidence of the merged windows
+}
+
+// cascade is a pico cascade of binary decision trees of equal depth
+type cascade struct {
+	depth       int
+	codes       []int8    // Per tree 4 offsets per node, 1-based; offsets are in 1/256 of the window
+	predictions []float32 // Per tree one output per leaf
+	thresholds  []float32 // Per tree the running sum below which the window is rejected
+}
+
+var (
+	faceCascadeOnce sync.Once
+	faceCascade     *cascade
+)
+
+// loadFaceCascade unpacks the embedded facefinder cascade
+func loadFaceCascade() *cascade {
+	faceCascadeOnce.Do(func() {
+		faceCascade = unpackCascade(faceFinderCascade)
+	})
+	return faceCascade
+}
+
+// unpackCascade reads a cascade in the binary format of pico: a version
+// and the window geometry, the depth and number of trees, then per tree
+// the pixel pairs of the inner nodes, the leaf outputs and the threshold.
+// A truncated cascade returns nil
+func unpackCascade(data []byte) *cascade {
+	if len(data) < 16 {
+		return nil
+	}
+	c := &cascade{depth: int(int32(binary.LittleEndian.Uint32(data[8:])))}
+	trees := int(int32(binary.LittleEndian.Uint32(data[12:])))
+	if c.depth < 1 || c.depth > 16 || trees < 1 {
+		return nil
+	}
+	leaves := 1 << c.depth
+	if len(data) != 16+trees*(4*(leaves-1)+4*leaves+4) {
+		return nil
+	}
+	pos := 16
+	for t := 0; t < trees; t++ {
+		// Node 0 is unused so that the children of node i are 2i and 2i+1
+		c.codes = append(c.codes, 0, 0, 0, 0)
+		for _, b := range data[pos : pos+4*(leaves-1)] {
+			c.codes = append(c.codes, int8(b))
+		}
+		pos += 4 * (leaves - 1)
+		for i := 0; i < leaves; i++ {
+			c.predictions = append(c.predictions, math.Float32frombits(binary.LittleEndian.Uint32(data[pos:])))
+			pos += 4
+		}
+		c.thresholds = append(c.thresholds, math.Float32frombits(binary.LittleEndian.Uint32(data[pos:])))
+		pos += 4
+	}
+	return c
+}
+
+// classify runs the cascade on the window of side s centered at (row,
+// col); it returns the confidence of a face, negative when a tree rejects
+// the window
+func (c *cascade) classify(pixels []uint8, stride, row, col, s int) float32 {
+	leaves := 1 << c.depth
+	row, col = row*256, col*256
+	var out float32
+	for t, threshold := range c.thresholds {
+		codes := c.codes[4*leaves*t:]
+		node := 1
+		for d := 0; d < c.depth; d++ {
+			p1 := ((row+int(codes[4*node])*s)>>8)*stride + (col+int(codes[4*node+1])*s)>>8
+			p2 := ((row+int(codes[4*node+2])*s)>>8)*stride + (col+int(codes[4*node+3])*s)>>8
+			node *= 2
+			if pixels[p1] <= pixels[p2] {
+				node++
+			}
+		}
+		out += c.predictions[leaves*t+node-leaves]
+		if out <= threshold {
+			return -1
+		}
+	}
+	return out - c.thresholds[len(c.thresholds)-1]
+}
+
+// detectFaces returns the frontal faces of an image, largest first
+func detectFaces(img image.Image) []faceRect {
+	c := loadFaceCascade()
+	if img == nil || c == nil {
+		return nil
+	}
+	pixels, w, h, scale := faceLuminance(img)
+	if w < 2*faceMinWindow || h < 2*faceMinWindow {
+		return nil
+	}
+
+	// Windows reach up to half their side beyond the center, the offsets
+	// of the cascade stay inside that
+	var hits []faceRect
+	for size := float64(faceMinWindow); int(size) <= w && int(size) <= h; size *= faceScaleStep {
+		s := int(size)
+		step := max(int(faceShift*size), 1)
+		margin := s/2 + 1
+		for row := margin; row <= h-margin; row += step {
+			for col := margin; col <= w-margin; col += step {
+				if q := c.classify(pixels, w, row, col, s); q > 0 {
+					hits = append(hits, faceRect{x: col - s/2, y: row - s/2, size: s, quality: float64(q)})
+				}
+			}
+		}
+	}
+
+	var faces []faceRect
+	for _, f := range groupFaces(hits) {
+		if f.quality < faceMinQuality {
+			continue
+		}
+		f.x = int(float64(f.x) * scale)
+		f.y = int(float64(f.y) * scale)
+		f.size = int(float64(f.size) * scale)
+		faces = append(faces, f)
+	}
+	sort.Slice(faces, func(i, j int) bool { return faces[i].size > faces[j].size })
+	if len(faces) > faceMaxPerImage {
+		faces = faces[:faceMaxPerImage]
+	}
+	return faces
+}
+
+// groupFaces merges overlapping windows: a face passes the cascade at
+// neighbouring positions and sizes, and the confidences add up
+func groupFaces(hits []faceRect) []faceRect {
+	sort.Slice(hits, func(i, j int) bool { return hits[i].quality > hits[j].quality })
+	assigned := make([]bool, len(hits))
+	var faces []faceRect
+	for i := range hits {
+		if assigned[i] {
+			continue
+		}
+		var x, y, size, n int
+		var quality float64
+		for j := range hits {
+			if !assigned[j] && faceOverlapRatio(hits[i], hits[j]) > faceOverlap {
+				assigned[j] = true
+				x += hits[j].x
+				y += hits[j].y
+				size += hits[j].size
+				quality += hits[j].quality
+				n++
+			}
+		}
+		faces = append(faces, faceRect{x: x / n, y: y / n, size: size / n, quality: quality})
+	}
+	return faces
+}
+
+// faceOverlapRatio is the intersection over union of two windows
+func faceOverlapRatio(a, b faceRect) float64 {
+	dx := minInt(a.x+a.size, b.x+b.size) - max(a.x, b.x)
+	dy := minInt(a.y+a.size, b.y+b.size) - max(a.y, b.y)
+	if dx <= 0 || dy <= 0 {
+		return 0
+	}
+	overlap := float64(dx * dy)
+	return overlap / (float64(a.size*a.size+b.size*b.size) - overlap)
+}
+
+// faceLuminance returns the gray levels of an image box-averaged down to
+// faceMaxSide, with the pixels of the image per returned pixel
+func faceLuminance(img image.Image) ([]uint8, int, int, float64) {
+	lum, w, h := luminance(img)
+	longest := max(w, h)
+	if longest <= faceMaxSide {
+		return lum, w, h, 1
+	}
+	scale := float64(longest) / faceMaxSide
+	fw, fh := int(float64(w)/scale), int(float64(h)/scale)
+	scaled := make([]uint8, fw*fh)
+	for y := 0; y < fh; y++ {
+		y0, y1 := int(float64(y)*scale), minInt(int(float64(y+1)*scale), h)
+		for x := 0; x < fw; x++ {
+			x0, x1 := int(float64(x)*scale), minInt(int(float64(x+1)*scale), w)
+			total, n := 0, 0
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					total += int(lum[sy*w+sx])
+					n++
+				}
+			}
+			scaled[y*fw+x] = uint8(total / max(n, 1))
+		}
+	}
+	return scaled, fw, fh, scale
+}
+
+// minInt is min for ints; the package's min works on float64
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
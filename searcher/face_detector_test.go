@@ -0,0 +1,175 @@
+package searcher
+
+import (
+	"image"
+	"image/draw"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kacebover/password-finder/fsutil"
+)
+
+// loadFaceFixture loads an image of testdata/faces, see generate.go and
+// NOTICE there
+func loadFaceFixture(t *testing.T, name string) image.Image {
+	t.Helper()
+	return loadFixtureImage(t, filepath.Join("testdata", "faces", name))
+}
+
+func loadFixtureImage(t *testing.T, path string) image.Image {
+	t.Helper()
+	f, err := fsutil.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return img
+}
+
+func TestDetectFaces(t *testing.T) {
+	card := loadFaceFixture(t, "id_card.png")
+	gray := image.NewGray(card.Bounds())
+	draw.Draw(gray, gray.Bounds(), card, image.Point{}, draw.Src)
+
+	for name, img := range map[string]image.Image{"color": card, "gray": gray} {
+		start := time.Now()
+		faces := detectFaces(img)
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("%s: detection took %v", name, elapsed)
+		}
+		if len(faces) != 1 {
+			t.Fatalf("%s: faces = %v, want the photo", name, faces)
+		}
+		// The face of the photo is around (170, 280)
+		face := faces[0]
+		if cx, cy := face.x+face.size/2, face.y+face.size/2; absInt(cx-170) > 25 || absInt(cy-280) > 35 {
+			t.Errorf("%s: face centered at (%d, %d)", name, cx, cy)
+		}
+	}
+
+	// Photos, see NOTICE in testdata/faces: a studio portrait and a webcam
+	// shot of a man with glasses in a cluttered room
+	for _, tt := range []struct {
+		name   string
+		cx, cy int
+	}{{"portrait.jpg", 155, 200}, {"webcam.jpg", 355, 270}} {
+		faces := detectFaces(loadFaceFixture(t, tt.name))
+		if len(faces) != 1 {
+			t.Errorf("%s: faces = %v, want one", tt.name, faces)
+			continue
+		}
+		face := faces[0]
+		if cx, cy := face.x+face.size/2, face.y+face.size/2; absInt(cx-tt.cx) > 30 || absInt(cy-tt.cy) > 40 {
+			t.Errorf("%s: face centered at (%d, %d)", tt.name, cx, cy)
+		}
+	}
+
+	// Faces are only found upright
+	if faces := detectFaces(rotateImage(card, 90)); len(faces) != 0 {
+		t.Errorf("turned card: faces = %v", faces)
+	}
+
+	for _, img := range []image.Image{
+		loadFaceFixture(t, "landscape.jpg"),
+		loadFaceFixture(t, "shuttle.jpg"),
+		loadBarcodeFixture(t, "qr_ssn.png"),
+		loadBarcodeFixture(t, "qr_api_key.jpg"),
+		loadFixtureImage(t, filepath.Join("..", "testdata", "images", "screenshot_secrets.png")),
+	} {
+		if faces := detectFaces(img); len(faces) != 0 {
+			t.Errorf("faces = %v in an image without people", faces)
+		}
+	}
+
+	// Images too small to hold a face
+	for _, size := range []int{0, 1, 30} {
+		if faces := detectFaces(image.NewRGBA(image.Rect(0, 0, size, size))); faces != nil {
+			t.Errorf("%dx%d: faces = %v", size, size, faces)
+		}
+	}
+	if faces := detectFaces(nil); faces != nil {
+		t.Errorf("nil image: faces = %v", faces)
+	}
+}
+
+// TestAnalyzeImageFace tests the face signals of the analysis and their
+// toggle
+func TestAnalyzeImageFace(t *testing.T) {
+	dir := filepath.Join("testdata", "faces")
+	analyzer := NewImageAnalyzer(false)
+	if analyzer.faceDetection || !NewImageAnalyzer(true).faceDetection {
+		t.Error("face detection should follow OCR by default")
+	}
+
+	card, err := analyzer.AnalyzeImage(filepath.Join(dir, "id_card.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if card.Signals.FaceDetected || card.Signals.FaceScore != 0 {
+		t.Errorf("face detection is off, signals = %+v", card.Signals)
+	}
+	withoutFace := card.FinalScore
+
+	analyzer.SetFaceDetection(true)
+	card, err = analyzer.AnalyzeImage(filepath.Join(dir, "id_card.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	signals := card.Signals
+	if !signals.FaceDetected || !signals.FaceInExpectedRegion || signals.FaceScore != 15 || signals.Rotation != 0 {
+		t.Errorf("ID card signals = %+v", signals)
+	}
+	if card.FinalScore <= withoutFace {
+		t.Errorf("score with the face %.1f, without %.1f", card.FinalScore, withoutFace)
+	}
+
+	landscape, err := analyzer.AnalyzeImage(filepath.Join(dir, "landscape.jpg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if landscape.Signals.FaceDetected || landscape.Signals.FaceScore != 0 {
+		t.Errorf("landscape signals = %+v", landscape.Signals)
+	}
+}
+
+func TestDetectFaceRegion(t *testing.T) {
+	analyzer := NewImageAnalyzer(false)
+	card := loadFaceFixture(t, "id_card.png")
+
+	// The photo centered in a wide image is not where documents put it
+	wide := image.NewRGBA(image.Rect(0, 0, 1200, 540))
+	draw.Draw(wide, wide.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(wide, image.Rect(470, 0, 730, 540), card, image.Pt(40, 0), draw.Src)
+
+	for _, tt := range []struct {
+		img      image.Image
+		expected bool
+	}{{card, true}, {wide, false}} {
+		result := &ImageAnalysisResult{
+			Signals:     &DetectionSignals{},
+			ImageWidth:  tt.img.Bounds().Dx(),
+			ImageHeight: tt.img.Bounds().Dy(),
+		}
+		analyzer.detectFace(result, tt.img)
+		if !result.Signals.FaceDetected || result.Signals.FaceInExpectedRegion != tt.expected {
+			t.Errorf("%dx%d: signals = %+v", result.ImageWidth, result.ImageHeight, result.Signals)
+		}
+	}
+}
+
+func TestUnpackCascade(t *testing.T) {
+	c := loadFaceCascade()
+	if c == nil || c.depth != 6 || len(c.thresholds) != 468 {
+		t.Fatalf("facefinder cascade = %+v", c)
+	}
+	for _, data := range [][]byte{nil, faceFinderCascade[:16], faceFinderCascade[:len(faceFinderCascade)-1]} {
+		if c := unpackCascade(data); c != nil {
+			t.Errorf("%d bytes: cascade of %d trees", len(data), len(c.thresholds))
+		}
+	}
+}
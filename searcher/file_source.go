@@ -0,0 +1,301 @@
+package searcher
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kacebover/password-finder/fsutil"
+)
+
+// A FileSource is where a scan reads its files from: the local disk or a
+// remote store such as an S3 bucket. The scanner applies the same size
+// limit, extension filter, ignore list and globs to the files of every
+// source; paths of remote files are URLs like s3://bucket/key, so findings
+// point at the object
+type FileSource interface {
+	// Root names the scanned tree, e.g. a directory or s3://bucket/prefix
+	Root() string
+	// List calls fn with every file below Root in lexical order, until fn
+	// returns an error or ctx is done
+	List(ctx context.Context, fn func(path string, info fs.FileInfo) error) error
+	// Stat returns the metadata of a file
+	Stat(path string) (fs.FileInfo, error)
+	// Open opens a file for reading
+	Open(path string) (io.ReadCloser, error)
+}
+
+// LocalSource reads files from the local disk
+type LocalSource struct {
+	root string
+}
+
+// NewLocalSource creates a source of the files below root
+func NewLocalSource(root string) *LocalSource {
+	return &LocalSource{root: root}
+}
+
+// Root returns the scanned directory
+func (l *LocalSource) Root() string {
+	return l.root
+}
+
+// List walks the directory tree; symbolic links are not followed
+func (l *LocalSource) List(ctx context.Context, fn func(path string, info fs.FileInfo) error) error {
+	return fsutil.Walk(l.root, func(path string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		return fn(path, info)
+	})
+}
+
+// Stat returns the metadata of a file
+func (l *LocalSource) Stat(path string) (fs.FileInfo, error) {
+	return fsutil.Stat(path)
+}
+
+// Open opens a file for reading
+func (l *LocalSource) Open(path string) (io.ReadCloser, error) {
+	return fsutil.Open(path)
+}
+
+// sourceFileInfo is the metadata of a remote file
+type sourceFileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+}
+
+func (i sourceFileInfo) Name() string       { return i.name }
+func (i sourceFileInfo) Size() int64        { return i.size }
+func (i sourceFileInfo) Mode() fs.FileMode  { return 0444 }
+func (i sourceFileInfo) ModTime() time.Time { return i.modTime }
+func (i sourceFileInfo) IsDir() bool        { return false }
+func (i sourceFileInfo) Sys() any           { return nil }
+
+// ScanSource scans the files of src. A LocalSource is scanned by
+// ScanContext, whose walker also applies .gitignore files and follows
+// symbolic links. Other sources are listed once, and their files pass the
+// same filters as local ones; the incremental cache and the enumeration
+// of totals are not used for them
+func (s *Scanner) ScanSource(ctx context.Context, src FileSource) (*ScanResult, error) {
+	if local, ok := src.(*LocalSource); ok {
+		return s.ScanContext(ctx, local.Root())
+	}
+	root := src.Root()
+	stopGate := s.beginScan(ctx, root)
+	defer stopGate()
+	s.notify = startNotify(s.notifier, s.notifyMode, s.result)
+	ended := false
+	defer func() {
+		s.notify.finish(ended)
+		s.notify = nil
+	}()
+	s.source = src
+	s.prepareIgnoreList(root)
+	s.logScanStart(root)
+	if s.patterns.Profiling() {
+		s.patterns.SetProfiling(true)
+	}
+
+	files := make(chan string, fileQueueLength)
+	var workers sync.WaitGroup
+	for i := 0; i < s.workers; i++ {
+		workers.Add(1)
+		go s.fileWorker(files, &workers)
+	}
+	listErr := s.walkSource(src, files)
+	close(files)
+	workers.Wait()
+
+	s.result.EndTime = time.Now().Unix()
+	s.finishMetrics()
+	ended = true
+	s.result.Coverage = BuildCoverage(s.result, s.coverageOptions(), s.deps)
+	if s.patterns.Profiling() {
+		s.result.SetRegexTimes(s.patterns.RegexTimes())
+	}
+	if err := cancelledError(ctx); err != nil {
+		s.result.Cancelled = true
+		s.logScanEnd(root, err)
+		return s.result, err
+	}
+	if listErr != nil {
+		// The files listed before the error are scanned and kept
+		listErr = fmt.Errorf("не удалось получить список файлов %s: %w", root, listErr)
+		s.scanError(root, StageRead, listErr)
+	}
+	s.logScanEnd(root, listErr)
+	return s.result, listErr
+}
+
+// sourceDir is the walk decision of a directory of a listed source
+type sourceDir struct {
+	walk     bool
+	included bool
+}
+
+// walkSource queues the listed files that pass the ignore list and the
+// globs. Sources list files, not directories, so the directories of a
+// path are checked the way the tree walker checks them before entering
+func (s *Scanner) walkSource(src FileSource, files chan<- string) error {
+	root := src.Root()
+	dirs := map[string]sourceDir{root: {walk: true, included: len(s.includeGlobs) == 0}}
+	var walkDir func(dir string) sourceDir
+	walkDir = func(dir string) sourceDir {
+		if decision, ok := dirs[dir]; ok {
+			return decision
+		}
+		parent := walkDir(sourceParent(root, dir))
+		decision := sourceDir{}
+		if parent.walk && !s.ignoreList.ShouldIgnoreDirectory(dir) {
+			var reason string
+			decision.walk, decision.included, reason = s.globDir(dir, parent.included)
+			if reason != "" {
+				s.result.IncrementFilesSkipped()
+				s.result.AddSkipReason(dir, skipText(reason))
+			}
+		}
+		dirs[dir] = decision
+		return decision
+	}
+
+	err := src.List(s.ctx, func(path string, info fs.FileInfo) error {
+		s.gate.wait()
+		if err := s.ctx.Err(); err != nil {
+			return err
+		}
+		dir := walkDir(sourceParent(root, path))
+		if !dir.walk {
+			return nil
+		}
+		if s.ignoreList.ShouldIgnorePath(path) {
+			s.noteIgnoredFile(path)
+			return nil
+		}
+		if scan, reason := s.globFile(path, dir.included); !scan {
+			s.result.IncrementFilesSkipped()
+			if reason != "" {
+				s.result.AddSkipReason(path, skipText(reason))
+			}
+			return nil
+		}
+		s.queued.Add(1)
+		select {
+		case files <- path:
+		case <-s.ctx.Done():
+			return s.ctx.Err()
+		}
+		return nil
+	})
+	if s.ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
+
+// sourceParent returns the directory of a path of a source; remote paths
+// are URLs, which always use slashes
+func sourceParent(root, path string) string {
+	if path == root {
+		return root
+	}
+	i := strings.LastIndex(path, "/")
+	if i < len(root) {
+		return root
+	}
+	return path[:i]
+}
+
+// localFiles is the source of the scans of local directories
+var localFiles FileSource = NewLocalSource("")
+
+// withLocalFile runs scan with a path on the local disk for extractors that
+// need one: the file itself, or a temporary copy of a remote file. Findings
+// and errors of the copy carry the path of the remote file
+func (s *Scanner) withLocalFile(ctx context.Context, path string, scan func(local string) int) int {
+	if _, ok := s.source.(*LocalSource); ok {
+		return scan(path)
+	}
+	local, err := s.copies.create(s.source, path)
+	if err != nil {
+		if recordFile(ctx) {
+			s.fileError(path, StageRead, err)
+		}
+		return 0
+	}
+	defer s.copies.remove(local)
+	return scan(local)
+}
+
+// localCopies maps temporary copies of remote files to their paths
+type localCopies struct {
+	mu    sync.Mutex
+	paths map[string]string // Temporary file -> path in the source
+}
+
+// create copies a remote file to a temporary file with the same extension,
+// which extractors choose the format by
+func (c *localCopies) create(src FileSource, path string) (string, error) {
+	in, err := src.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer in.Close()
+	out, err := os.CreateTemp("", "dll-source-*"+strings.ToLower(filepath.Ext(path)))
+	if err != nil {
+		return "", err
+	}
+	_, err = io.Copy(out, in)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(out.Name())
+		return "", err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.paths == nil {
+		c.paths = make(map[string]string)
+	}
+	c.paths[out.Name()] = path
+	return out.Name(), nil
+}
+
+// remove deletes a temporary copy
+func (c *localCopies) remove(local string) {
+	c.mu.Lock()
+	delete(c.paths, local)
+	c.mu.Unlock()
+	os.Remove(local)
+}
+
+// source returns the path in the source of a path inside a temporary
+// copy, such as an archive member, or path itself
+func (c *localCopies) source(path string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for local, remote := range c.paths {
+		if path == local {
+			return remote
+		}
+		if rest, ok := strings.CutPrefix(path, local+ArchiveSeparator); ok {
+			return remote + ArchiveSeparator + rest
+		}
+	}
+	return path
+}
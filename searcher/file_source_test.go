@@ -0,0 +1,175 @@
+package searcher
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memSource is a FileSource of files held in memory, under mem://bucket
+type memSource struct {
+	files  map[string][]byte // Key -> content
+	mu     sync.Mutex
+	opened []string
+}
+
+func (m *memSource) Root() string { return "mem://bucket" }
+
+func (m *memSource) List(ctx context.Context, fn func(path string, info fs.FileInfo) error) error {
+	keys := make([]string, 0, len(m.files))
+	for key := range m.files {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		info, _ := m.Stat(m.Root() + "/" + key)
+		if err := fn(m.Root()+"/"+key, info); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *memSource) Stat(p string) (fs.FileInfo, error) {
+	content, ok := m.files[strings.TrimPrefix(p, m.Root()+"/")]
+	if !ok {
+		return nil, &fs.PathError{Op: "stat", Path: p, Err: fs.ErrNotExist}
+	}
+	return sourceFileInfo{name: path.Base(p), size: int64(len(content)), modTime: time.Now()}, nil
+}
+
+func (m *memSource) Open(p string) (io.ReadCloser, error) {
+	content, ok := m.files[strings.TrimPrefix(p, m.Root()+"/")]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: p, Err: fs.ErrNotExist}
+	}
+	m.mu.Lock()
+	m.opened = append(m.opened, p)
+	m.mu.Unlock()
+	return io.NopCloser(bytes.NewReader(content)), nil
+}
+
+// sourceTree is the tree both the local and the in-memory scans see
+func sourceTree(t *testing.T) map[string][]byte {
+	return map[string][]byte{
+		"app/prod.env":                []byte(diffAWSKey),
+		"app/config.yml":              []byte(diffPassword),
+		"app/node_modules/pkg/.env":   []byte(diffAWSKey),
+		"app/testdata/fixture.env":    []byte(diffAWSKey),
+		"app/huge.log":                append(bytes.Repeat([]byte("x"), 2048), diffAWSKey...),
+		"app/notes.md":                []byte(diffPassword),
+		"backup/creds.zip":            zipBytes(t, map[string][]byte{"inner.env": []byte(diffAWSKey)}),
+		"bin/tool.env":                []byte("\x00\x01" + diffAWSKey),
+		"app/config.yml.swp":          []byte(diffAWSKey),
+		"app/deep/dir/settings.local": []byte(diffPassword),
+	}
+}
+
+// configureSourceScanner sets the filters both scans use
+func configureSourceScanner(t *testing.T) *Scanner {
+	scanner := NewScanner()
+	scanner.SetMaxFileSize(1024)
+	scanner.SetDocumentExtractor(NewDocumentExtractor(false))
+	scanner.SetScanArchives(true)
+	scanner.GetIgnoreList().EnableArchiveScanning()
+	if err := scanner.SetExcludeGlobs([]string{"**/testdata/**"}); err != nil {
+		t.Fatal(err)
+	}
+	scanner.SetOnlyExtensions([]string{".env", ".yml", ".zip", ".local", ".log"})
+	return scanner
+}
+
+// TestScanSourceMatchesLocal tests a listed source is filtered like the
+// same tree on disk, and archive members carry the path of the source
+func TestScanSourceMatchesLocal(t *testing.T) {
+	tree := sourceTree(t)
+	dir := t.TempDir()
+	for key, content := range tree {
+		full := filepath.Join(dir, filepath.FromSlash(key))
+		os.MkdirAll(filepath.Dir(full), 0755)
+		os.WriteFile(full, content, 0644)
+	}
+	local, err := configureSourceScanner(t).ScanSource(context.Background(), NewLocalSource(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := &memSource{files: tree}
+	remote, err := configureSourceScanner(t).ScanSource(context.Background(), src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	found := func(result *ScanResult, root string) []string {
+		var paths []string
+		for _, f := range result.Findings {
+			rel := strings.TrimPrefix(filepath.ToSlash(f.FilePath), filepath.ToSlash(root)+"/")
+			paths = append(paths, rel+" "+string(f.PatternType))
+		}
+		sort.Strings(paths)
+		return paths
+	}
+	want := []string{
+		"app/config.yml password",
+		"app/deep/dir/settings.local password",
+		"app/prod.env aws_key",
+		"backup/creds.zip!inner.env aws_key",
+	}
+	for name, got := range map[string][]string{"local": found(local, dir), "source": found(remote, src.Root())} {
+		if strings.Join(got, "\n") != strings.Join(want, "\n") {
+			t.Errorf("%s findings:\n%s\nwant:\n%s", name, strings.Join(got, "\n"), strings.Join(want, "\n"))
+		}
+	}
+	if local.FilesScanned != remote.FilesScanned || local.FilesSkipped != remote.FilesSkipped {
+		t.Errorf("scanned/skipped local %d/%d, source %d/%d", local.FilesScanned, local.FilesSkipped,
+			remote.FilesScanned, remote.FilesSkipped)
+	}
+	if remote.Root != "mem://bucket" {
+		t.Errorf("Root = %q", remote.Root)
+	}
+
+	// Text files are read once; ignored, large and filtered files not at all
+	for _, p := range src.opened {
+		if strings.Contains(p, "node_modules") || strings.Contains(p, "huge.log") || strings.Contains(p, ".swp") {
+			t.Errorf("%s was read", p)
+		}
+	}
+	matches, _ := filepath.Glob(filepath.Join(os.TempDir(), "dll-source-*"))
+	if len(matches) != 0 {
+		t.Errorf("temporary copies left: %v", matches)
+	}
+}
+
+// TestScanSourceListError tests the files listed before a failure are kept
+// and the failure is reported
+func TestScanSourceListError(t *testing.T) {
+	src := &failingSource{memSource: memSource{files: map[string][]byte{"a.env": []byte(diffAWSKey)}}}
+	result, err := NewScanner().ScanSource(context.Background(), src)
+	if err == nil || !strings.Contains(err.Error(), "список файлов") {
+		t.Errorf("error = %v, want the listing error", err)
+	}
+	if result.TotalFindings() != 1 || result.ErrorCount != 1 {
+		t.Errorf("findings %d, errors %d; want the finding of the listed file and the error",
+			result.TotalFindings(), result.ErrorCount)
+	}
+}
+
+// failingSource fails after listing its files
+type failingSource struct {
+	memSource
+}
+
+func (f *failingSource) List(ctx context.Context, fn func(path string, info fs.FileInfo) error) error {
+	if err := f.memSource.List(ctx, fn); err != nil {
+		return err
+	}
+	return io.ErrUnexpectedEOF
+}
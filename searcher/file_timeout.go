@@ -0,0 +1,154 @@
+package searcher
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"sync"
+	"time"
+)
+
+// A file is read and extracted under its own deadline, so a pathological
+// one cannot hold a worker for the rest of the scan. When the deadline
+// passes, the worker counts the file as skipped and takes the next one;
+// the context of the file is cancelled, which kills its pdftotext, pdftoppm
+// and Tesseract runs and stops the text scan between lines. What the
+// abandoned scan still finds is dropped: it records nothing without asking
+// recordFile, which it does once its slow steps are through, and counters
+// it keeps on the way go through recordLater.
+//
+// A local text file needs no watchdog: the scanner reads it itself and
+// checks the deadline between chunks of a line, so it is scanned in the
+// worker and keeps the findings of the part read before the deadline
+
+// DefaultFileTimeout is how long the scan of one file may take
+const DefaultFileTimeout = 2 * time.Minute
+
+// errFileTimeout is the cause of the context of a file that timed out
+var errFileTimeout = errors.New("file timeout")
+
+// SetFileTimeout sets how long the scan of one file may take, including
+// document extraction and OCR; zero or less means no limit
+func (s *Scanner) SetFileTimeout(d time.Duration) {
+	s.fileTimeout = d
+}
+
+// fileRunKey is the context key of the fileRun of a file
+type fileRunKey struct{}
+
+// States of a fileRun
+const (
+	fileRunning   = iota
+	fileRecording // The scan records its outcome, the timeout no longer applies
+	fileTimedOut  // The worker gave up on the file
+)
+
+// fileRun decides whether the scan of a file or its timeout comes first
+type fileRun struct {
+	mu      sync.Mutex
+	state   int
+	pending []func() // Records kept until the scan is through, see recordLater
+}
+
+// withFileTimeout runs scan for filePath under the file timeout and
+// returns its findings, or counts the file as skipped when it times out
+func (s *Scanner) withFileTimeout(filePath string, scan func(ctx context.Context) int) int {
+	if s.fileTimeout <= 0 {
+		return scan(s.ctx)
+	}
+
+	ctx, cancel := context.WithTimeoutCause(context.WithValue(s.ctx, fileRunKey{}, &fileRun{}), s.fileTimeout, errFileTimeout)
+	found := make(chan int, 1)
+	s.fileScans.Add(1)
+	go func() {
+		defer s.fileScans.Done()
+		defer cancel()
+		found <- scan(ctx)
+	}()
+
+	select {
+	case n := <-found:
+		// Records the scan kept without recording anything itself
+		if recordFile(ctx) {
+			return n
+		}
+	case <-ctx.Done():
+		// A cancelled scan, or one already recording, is waited for
+		if recordFile(ctx) {
+			return <-found
+		}
+	}
+	s.result.IncrementFilesSkipped()
+	s.result.AddSkipReason(filePath, skipReason("skip.timeout", s.fileTimeout.String()))
+	return 0
+}
+
+// withTextTimeout runs scan for a text file under the file timeout. A
+// local file is scanned in the worker, see scanContent; a read from a
+// remote source can stall, so it gets the watchdog of withFileTimeout
+func (s *Scanner) withTextTimeout(filePath string, scan func(ctx context.Context) int) int {
+	if _, ok := s.source.(*LocalSource); !ok || s.fileTimeout <= 0 {
+		return s.withFileTimeout(filePath, scan)
+	}
+	ctx, cancel := context.WithTimeoutCause(s.ctx, s.fileTimeout, errFileTimeout)
+	defer cancel()
+	return scan(ctx)
+}
+
+// recordFile reports whether the scan of the file ctx belongs to may
+// record its findings, counters and errors: false once the file timed out.
+// After it returns true the file no longer times out, so it is asked once
+// the slow steps are done
+func recordFile(ctx context.Context) bool {
+	run, _ := ctx.Value(fileRunKey{}).(*fileRun)
+	if run == nil {
+		return true
+	}
+	run.mu.Lock()
+	if run.state == fileRunning {
+		run.state = fileRecording
+		if context.Cause(ctx) == errFileTimeout {
+			run.state = fileTimedOut
+		}
+	}
+	recording := run.state == fileRecording
+	pending := run.pending
+	run.pending = nil
+	run.mu.Unlock()
+
+	if recording {
+		for _, record := range pending {
+			record()
+		}
+	}
+	return recording
+}
+
+// recordLater runs record, which writes to the result, once recordFile
+// allows it for the file ctx belongs to; it is dropped if the file times
+// out
+func recordLater(ctx context.Context, record func()) {
+	run, _ := ctx.Value(fileRunKey{}).(*fileRun)
+	if run == nil {
+		record()
+		return
+	}
+	run.mu.Lock()
+	state := run.state
+	if state == fileRunning {
+		run.pending = append(run.pending, record)
+	}
+	run.mu.Unlock()
+	if state == fileRecording {
+		record()
+	}
+}
+
+// specialFileModes are the file types that are not scanned: reading a
+// pipe or a device can block forever or never end
+const specialFileModes = fs.ModeNamedPipe | fs.ModeSocket | fs.ModeDevice | fs.ModeCharDevice | fs.ModeIrregular
+
+// isSpecialFile reports whether mode is that of a pipe, socket or device
+func isSpecialFile(mode fs.FileMode) bool {
+	return mode&specialFileModes != 0
+}
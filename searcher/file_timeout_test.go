@@ -0,0 +1,109 @@
+package searcher
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// stallingSource is a memSource whose files under stall/ return their first
+// line and then block until release is closed, ignoring any deadline
+type stallingSource struct {
+	memSource
+	release chan struct{}
+}
+
+func (s *stallingSource) Open(p string) (io.ReadCloser, error) {
+	r, err := s.memSource.Open(p)
+	if err != nil || !strings.Contains(p, "/stall/") {
+		return r, err
+	}
+	return io.NopCloser(io.MultiReader(r, stalledReader(s.release))), nil
+}
+
+type stalledReader chan struct{}
+
+func (r stalledReader) Read(p []byte) (int, error) {
+	<-r
+	return copy(p, "token = 'Zx9Qw8Er7Ty6Ui5Op4As'\n"), io.EOF
+}
+
+// TestFileTimeout tests a file that stalls past its timeout is skipped
+// with its findings dropped and does not hold up the scan
+func TestFileTimeout(t *testing.T) {
+	secret := "password = 'Zx9Qw8Er7Ty6Ui5Op4As'\n"
+	src := &stallingSource{
+		memSource: memSource{files: map[string][]byte{
+			"stall/big.env": []byte(secret),
+			"ok/app.env":    []byte(secret),
+		}},
+		release: make(chan struct{}),
+	}
+	t.Cleanup(func() { close(src.release) })
+
+	scanner := NewScanner()
+	scanner.SetFileTimeout(200 * time.Millisecond)
+	start := time.Now()
+	result, err := scanner.ScanSource(context.Background(), src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("scan took %v with a 200ms file timeout", elapsed)
+	}
+
+	stalled := "mem://bucket/stall/big.env"
+	if want := russian.T("skip.timeout", "200ms"); result.SkipReasons[stalled].String() != want {
+		t.Errorf("skip reason %q, want %q", result.SkipReasons[stalled], want)
+	}
+	if result.FilesSkipped != 1 || result.FilesScanned != 1 {
+		t.Errorf("FilesSkipped = %d, FilesScanned = %d; want 1 and 1", result.FilesSkipped, result.FilesScanned)
+	}
+	for _, finding := range result.Findings {
+		if finding.FilePath == stalled {
+			t.Errorf("finding of the abandoned file kept: %+v", finding)
+		}
+	}
+	if result.TotalFindings() == 0 {
+		t.Error("the finding of the other file is missing")
+	}
+}
+
+func TestFileTimeoutOff(t *testing.T) {
+	src := &memSource{files: map[string][]byte{"app.env": []byte("password = 'Zx9Qw8Er7Ty6Ui5Op4As'\n")}}
+	scanner := NewScanner()
+	scanner.SetFileTimeout(0)
+	result, err := scanner.ScanSource(context.Background(), src)
+	if err != nil || result.FilesScanned != 1 || result.TotalFindings() == 0 {
+		t.Errorf("without a timeout: %d files, %d findings, %v", result.FilesScanned, result.TotalFindings(), err)
+	}
+}
+
+// timeoutReader times the file out when it is read
+type timeoutReader func(error)
+
+func (r timeoutReader) Read([]byte) (int, error) {
+	r(errFileTimeout)
+	return 0, io.EOF
+}
+
+// TestScanContentTimeout tests a text file that times out keeps the
+// findings of the lines read before
+func TestScanContentTimeout(t *testing.T) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+	r := io.MultiReader(
+		strings.NewReader("password = 'Zx9Qw8Er7Ty6Ui5Op4As'\n"),
+		timeoutReader(cancel),
+		strings.NewReader("token = 'Qw8Er7Ty6Ui5Op4AsZx9'\n"),
+	)
+	findings, err := NewScanner().scanContent(ctx, "app.env", r)
+	if err != errFileTimeout {
+		t.Fatalf("err = %v, want the file timeout", err)
+	}
+	if len(findings) != 1 || findings[0].LineNumber != 1 {
+		t.Errorf("findings = %+v, want the one of line 1", findings)
+	}
+}
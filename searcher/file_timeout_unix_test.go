@@ -0,0 +1,34 @@
+//go:build unix
+
+package searcher
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+// TestSpecialFileSkipped tests a named pipe is skipped without being
+// opened, which would block until a writer comes
+func TestSpecialFileSkipped(t *testing.T) {
+	dir := t.TempDir()
+	fifo := filepath.Join(dir, "queue")
+	if err := syscall.Mkfifo(fifo, 0644); err != nil {
+		t.Skipf("mkfifo: %v", err)
+	}
+	os.WriteFile(filepath.Join(dir, "app.env"), []byte("password = 'Zx9Qw8Er7Ty6Ui5Op4As'\n"), 0644)
+
+	scanner := NewScanner()
+	scanner.SetFileTimeout(0)
+	result, err := scanner.Scan(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reason := result.SkipReasons[fifo]; reason.Key != "skip.special" {
+		t.Errorf("skip reason %q, want skip.special", reason)
+	}
+	if result.FilesScanned != 1 || result.TotalFindings() == 0 {
+		t.Errorf("FilesScanned = %d, findings %d; want the other file scanned", result.FilesScanned, result.TotalFindings())
+	}
+}
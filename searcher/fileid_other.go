@@ -0,0 +1,15 @@
+//go:build !unix
+
+package searcher
+
+import "os"
+
+// fileID identifies a directory by its path with links resolved; os.FileInfo
+// carries no inode here
+type fileID struct {
+	path string
+}
+
+func fileIDOf(_ os.FileInfo, path string) fileID {
+	return fileID{path: path}
+}
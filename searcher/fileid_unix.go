@@ -0,0 +1,22 @@
+//go:build unix
+
+package searcher
+
+import (
+	"os"
+	"syscall"
+)
+
+// fileID identifies a directory by device and inode, so different paths to
+// the same directory compare equal
+type fileID struct {
+	dev, ino uint64
+	path     string // Used where there is no inode
+}
+
+func fileIDOf(info os.FileInfo, path string) fileID {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		return fileID{dev: uint64(st.Dev), ino: uint64(st.Ino)}
+	}
+	return fileID{path: path}
+}
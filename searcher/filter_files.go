@@ -0,0 +1,70 @@
+package searcher
+
+// A report of part of a scan, such as the files picked in the GUI to attach
+// to a ticket, is written from a filtered copy of the result. The copy keeps
+// what describes the scan itself (root, time, coverage) and recounts what
+// describes findings from the findings it keeps
+
+// FilterByFiles returns a copy of the result with only the findings of the
+// given files (thread-safe)
+func (sr *ScanResult) FilterByFiles(paths []string) *ScanResult {
+	files := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		files[path] = true
+	}
+	return sr.FilterFindings(func(f *Finding) bool {
+		return files[f.FilePath]
+	})
+}
+
+// FilterFindings returns a copy of the result with the findings keep
+// accepts. Severity and rule counts cover only those findings; baselined
+// findings, truncation notes, errors and skip reasons are kept for their
+// files, and FilesScanned counts those files (thread-safe)
+func (sr *ScanResult) FilterFindings(keep func(*Finding) bool) *ScanResult {
+	filtered := NewScanResult()
+	files := make(map[string]bool)
+	sr.allFindings()(func(f *Finding) bool {
+		if keep(f) {
+			copied := *f
+			filtered.AddFinding(&copied)
+			files[f.FilePath] = true
+		}
+		return true
+	})
+
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	filtered.FilesScanned = len(files)
+	filtered.StartTime = sr.StartTime
+	filtered.EndTime = sr.EndTime
+	filtered.Root = sr.Root
+	filtered.Coverage = sr.Coverage
+	filtered.Cancelled = sr.Cancelled
+	for _, f := range sr.Baselined {
+		if files[f.FilePath] {
+			copied := *f
+			filtered.Baselined = append(filtered.Baselined, &copied)
+		}
+	}
+	for path, n := range sr.Truncated {
+		if files[path] {
+			if filtered.Truncated == nil {
+				filtered.Truncated = make(map[string]int)
+			}
+			filtered.Truncated[path] = n
+		}
+	}
+	for _, e := range sr.Errors {
+		if files[e.FilePath] {
+			filtered.Errors = append(filtered.Errors, e)
+			filtered.ErrorCount++
+		}
+	}
+	for path, reason := range sr.SkipReasons {
+		if files[path] {
+			filtered.SkipReasons[path] = reason
+		}
+	}
+	return filtered
+}
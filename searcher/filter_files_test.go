@@ -0,0 +1,85 @@
+package searcher
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestFilterByFiles(t *testing.T) {
+	result := NewScanResult()
+	result.Root = "/app"
+	result.StartTime, result.EndTime = 100, 160
+	result.FilesScanned, result.FilesSkipped, result.TotalSize = 40, 3, 5000
+	result.AddFinding(&Finding{FilePath: "/app/.env", LineNumber: 1, PatternType: PatternPassword, Severity: Critical, MatchedText: "password=hunter2"})
+	result.AddFinding(&Finding{FilePath: "/app/.env", LineNumber: 2, PatternType: PatternAPIKey, Severity: Medium, MatchedText: "api_key=abc"})
+	result.AddFinding(&Finding{FilePath: "/app/id_rsa", LineNumber: 1, PatternType: PatternPrivateKey, Severity: Critical})
+	result.AddFinding(&Finding{FilePath: "/app/notes.txt", LineNumber: 9, PatternType: PatternEmail, Severity: Low})
+	result.AddTruncated("/app/.env", 2)
+	result.AddTruncated("/app/id_rsa", 1)
+	result.AddError("/app/notes.txt", StageRead, errors.New("denied"))
+	result.AddSkipReason("/app/big.iso", skipReason("skip.too_large"))
+
+	filtered := result.FilterByFiles([]string{"/app/.env", "/app/notes.txt", "/app/missing.txt"})
+	if got := filtered.TotalFindings(); got != 3 {
+		t.Fatalf("%d findings, want 3", got)
+	}
+	for _, f := range filtered.Findings {
+		if f.FilePath == "/app/id_rsa" {
+			t.Error("finding of an unselected file kept")
+		}
+	}
+	if filtered.GetSeverityCount(Critical) != 1 || filtered.GetSeverityCount(Medium) != 1 || filtered.GetSeverityCount(Low) != 1 {
+		t.Errorf("severity summary %v", filtered.SeveritySummary)
+	}
+	if filtered.FilesScanned != 2 || filtered.FilesSkipped != 0 || filtered.ErrorCount != 1 || len(filtered.SkipReasons) != 0 {
+		t.Errorf("counters %d/%d/%d/%d, want 2/0/1/0", filtered.FilesScanned, filtered.FilesSkipped, filtered.ErrorCount, len(filtered.SkipReasons))
+	}
+	if len(filtered.Truncated) != 1 || filtered.Truncated["/app/.env"] != 2 {
+		t.Errorf("truncated = %v", filtered.Truncated)
+	}
+	if filtered.Root != "/app" || filtered.StartTime != 100 || filtered.EndTime != 160 {
+		t.Errorf("scan metadata not copied: %q %d-%d", filtered.Root, filtered.StartTime, filtered.EndTime)
+	}
+
+	// The copy is independent of the result
+	filtered.Findings[0].Severity = Low
+	if result.Findings[0].Severity != Critical {
+		t.Error("filtering shares findings with the result")
+	}
+	if result.TotalFindings() != 4 || result.GetSeverityCount(Critical) != 2 {
+		t.Error("filtering changed the result")
+	}
+}
+
+// TestFilterFindingsPartialFile tests a file with some findings left out
+// keeps the others and its counts drop accordingly
+func TestFilterFindingsPartialFile(t *testing.T) {
+	result := NewScanResult()
+	for i := 1; i <= 3; i++ {
+		result.AddFinding(&Finding{FilePath: "/app/.env", LineNumber: i, PatternType: PatternPassword, Severity: High})
+	}
+	filtered := result.FilterFindings(func(f *Finding) bool { return f.LineNumber != 2 })
+	if filtered.TotalFindings() != 2 || filtered.GetSeverityCount(High) != 2 || filtered.FilesScanned != 1 {
+		t.Errorf("got %d findings, %d high in %d files", filtered.TotalFindings(), filtered.GetSeverityCount(High), filtered.FilesScanned)
+	}
+	if stats := filtered.PatternStats(); len(stats) != 1 || stats[0].Findings != 2 {
+		t.Errorf("rule stats %+v", stats)
+	}
+}
+
+// TestFilterByFilesSpilled tests findings spilled to disk are filtered too
+func TestFilterByFilesSpilled(t *testing.T) {
+	result := NewScanResult()
+	result.SetSpill(SpillConfig{Threshold: 5, KeepTop: 2, Dir: t.TempDir()})
+	defer result.Close()
+	for i := 0; i < 20; i++ {
+		result.AddFinding(&Finding{FilePath: fmt.Sprintf("/app/file%d.env", i%4), LineNumber: i, PatternType: PatternPassword, Severity: Medium})
+	}
+	if !result.Spilled() {
+		t.Fatal("result not spilled")
+	}
+	if got := result.FilterByFiles([]string{"/app/file1.env"}).TotalFindings(); got != 5 {
+		t.Errorf("%d findings, want 5", got)
+	}
+}
@@ -0,0 +1,631 @@
+package searcher
+
+import (
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/json"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// The finance pack finds payment messages, bank statements and
+// cryptocurrency keys. It is a detector group: off by default and enabled
+// as a unit with Patterns.EnableGroups("finance").
+
+// addFinancePack registers the finance group patterns and detectors
+func addFinancePack(p *Patterns) {
+	// WIF private keys (mainnet 5/K/L, testnet 9/c), checked with base58check
+	p.addGroupPattern(GroupFinance, PatternCryptoKey, `\b[5KLc9][1-9A-HJ-NP-Za-km-z]{50,51}\b`, Critical, "Cryptocurrency private key detected", validWIF)
+	// BIP-32 extended private keys
+	p.addGroupPattern(GroupFinance, PatternCryptoKey, `\b[xt]prv[1-9A-HJ-NP-Za-km-z]{100,108}\b`, Critical, "Cryptocurrency private key detected", validExtendedKey)
+
+	p.AddDetector(seedPhraseDetector{})
+	p.AddDetector(keystoreDetector{})
+	p.AddDetector(swiftDetector{})
+	p.AddDetector(sepaDetector{})
+	p.AddDetector(bankStatementDetector{})
+}
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// base58CheckDecode decodes a base58check string and verifies its
+// double SHA-256 checksum, returning the payload without it
+func base58CheckDecode(s string) ([]byte, bool) {
+	n := new(big.Int)
+	radix := big.NewInt(58)
+	for _, c := range s {
+		digit := strings.IndexRune(base58Alphabet, c)
+		if digit < 0 {
+			return nil, false
+		}
+		n.Mul(n, radix)
+		n.Add(n, big.NewInt(int64(digit)))
+	}
+
+	// Leading '1' characters encode leading zero bytes
+	zeros := 0
+	for zeros < len(s) && s[zeros] == '1' {
+		zeros++
+	}
+	decoded := append(make([]byte, zeros), n.Bytes()...)
+	if len(decoded) < 5 {
+		return nil, false
+	}
+
+	payload, checksum := decoded[:len(decoded)-4], decoded[len(decoded)-4:]
+	first := sha256.Sum256(payload)
+	second := sha256.Sum256(first[:])
+	if string(second[:4]) != string(checksum) {
+		return nil, false
+	}
+	return payload, true
+}
+
+// validWIF checks a Wallet Import Format private key
+func validWIF(s string) bool {
+	payload, ok := base58CheckDecode(s)
+	if !ok || (payload[0] != 0x80 && payload[0] != 0xEF) {
+		return false
+	}
+	// 32-byte key, optionally followed by the compressed-key flag
+	return len(payload) == 33 || (len(payload) == 34 && payload[33] == 0x01)
+}
+
+// validExtendedKey checks a BIP-32 xprv/tprv key
+func validExtendedKey(s string) bool {
+	payload, ok := base58CheckDecode(s)
+	if !ok || len(payload) != 78 {
+		return false
+	}
+	version := string(payload[:4])
+	return version == "\x04\x88\xAD\xE4" || version == "\x04\x35\x83\x94"
+}
+
+var bicFormat = regexp.MustCompile(`^[A-Z]{4}([A-Z]{2})[A-Z0-9]{2}([A-Z0-9]{3})?$`)
+
+// isoCountries lists ISO 3166-1 alpha-2 codes, plus XK used by SWIFT for Kosovo
+var isoCountries = strings.Fields(`
+	AD AE AF AG AI AL AM AO AQ AR AS AT AU AW AX AZ BA BB BD BE BF BG BH BI BJ BL BM BN BO BQ
+	BR BS BT BV BW BY BZ CA CC CD CF CG CH CI CK CL CM CN CO CR CU CV CW CX CY CZ DE DJ DK DM
+	DO DZ EC EE EG EH ER ES ET FI FJ FK FM FO FR GA GB GD GE GF GG GH GI GL GM GN GP GQ GR GS
+	GT GU GW GY HK HM HN HR HT HU ID IE IL IM IN IO IQ IR IS IT JE JM JO JP KE KG KH KI KM KN
+	KP KR KW KY KZ LA LB LC LI LK LR LS LT LU LV LY MA MC MD ME MF MG MH MK ML MM MN MO MP MQ
+	MR MS MT MU MV MW MX MY MZ NA NC NE NF NG NI NL NO NP NR NU NZ OM PA PE PF PG PH PK PL PM
+	PN PR PS PT PW PY QA RE RO RS RU RW SA SB SC SD SE SG SH SI SJ SK SL SM SN SO SR SS ST SV
+	SX SY SZ TC TD TF TG TH TJ TK TL TM TN TO TR TT TV TW TZ UA UG UM US UY UZ VA VC VE VG VI
+	VN VU WF WS XK YE YT ZA ZM ZW`)
+
+// ValidBIC checks a SWIFT BIC: 8 or 11 characters with a known country code
+func ValidBIC(bic string) bool {
+	m := bicFormat.FindStringSubmatch(bic)
+	if m == nil {
+		return false
+	}
+	for _, country := range isoCountries {
+		if country == m[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidIBAN checks an IBAN with the ISO 7064 mod-97 checksum
+func ValidIBAN(iban string) bool {
+	iban = strings.ReplaceAll(iban, " ", "")
+	if len(iban) < 15 || len(iban) > 34 {
+		return false
+	}
+
+	remainder := 0
+	for _, c := range iban[4:] + iban[:4] {
+		switch {
+		case c >= '0' && c <= '9':
+			remainder = (remainder*10 + int(c-'0')) % 97
+		case c >= 'A' && c <= 'Z':
+			remainder = (remainder*100 + int(c-'A') + 10) % 97
+		default:
+			return false
+		}
+	}
+	return remainder == 1
+}
+
+// seedPhraseDetector finds BIP-39 mnemonics. Words may be split across
+// lines, numbered ("1. word"), quoted or listed in brackets; other
+// punctuation breaks a run.
+//
+// A run is reported only if it has a valid length and checksum, which
+// keeps ordinary prose made of dictionary words out.
+type seedPhraseDetector struct{}
+
+func (seedPhraseDetector) Name() string  { return "finance_seed_phrase" }
+func (seedPhraseDetector) Group() string { return GroupFinance }
+
+type seedToken struct {
+	word       string
+	line       int
+	start, end int
+}
+
+var seedNumbering = regexp.MustCompile(`^\d{1,2}[.):]?$`)
+
+func (d seedPhraseDetector) Detect(lines []string) []*DetectedPattern {
+	var results []*DetectedPattern
+	var run []seedToken
+
+	flush := func() {
+		if match := d.checkRun(run); match != nil {
+			results = append(results, match)
+		}
+		run = run[:0]
+	}
+
+	for lineIdx, line := range lines {
+		for _, field := range fieldSpans(line) {
+			token := line[field[0]:field[1]]
+			if seedNumbering.MatchString(token) {
+				continue
+			}
+
+			word := strings.TrimLeft(token, `"'[({`)
+			start := field[0] + len(token) - len(word)
+			word = strings.TrimRight(word, `"'])},`)
+			if !IsBIP39Word(word) {
+				flush()
+				continue
+			}
+			run = append(run, seedToken{word: word, line: lineIdx, start: start, end: start + len(word)})
+		}
+	}
+	flush()
+
+	return results
+}
+
+// checkRun looks for a valid mnemonic in a run of wordlist words. Runs may
+// be up to two words longer than the mnemonic, e.g. a leading label word.
+func (seedPhraseDetector) checkRun(run []seedToken) *DetectedPattern {
+	for size := 24; size >= 12; size -= 3 {
+		if len(run) < size || len(run)-size > 2 {
+			continue
+		}
+		for offset := 0; offset+size <= len(run); offset++ {
+			window := run[offset : offset+size]
+			words := make([]string, len(window))
+			for i, token := range window {
+				words[i] = token.word
+			}
+			if !ValidateBIP39Mnemonic(words) {
+				continue
+			}
+
+			first := window[0]
+			end := first.end
+			for _, token := range window {
+				if token.line == first.line {
+					end = token.end
+				}
+			}
+			return &DetectedPattern{
+				Type:        PatternSeedPhrase,
+				RuleName:    "finance_seed_phrase",
+				Severity:    Critical,
+				Description: "BIP-39 seed phrase detected",
+				LineNumber:  first.line + 1,
+				StartIndex:  first.start,
+				EndIndex:    end,
+				MatchText:   strings.Join(words, " "),
+			}
+		}
+	}
+	return nil
+}
+
+// fieldSpans returns the byte spans of whitespace-separated fields
+func fieldSpans(line string) [][2]int {
+	var spans [][2]int
+	start := -1
+	for i, c := range line {
+		space := c == ' ' || c == '\t' || c == '\r'
+		if space && start >= 0 {
+			spans = append(spans, [2]int{start, i})
+			start = -1
+		} else if !space && start < 0 {
+			start = i
+		}
+	}
+	if start >= 0 {
+		spans = append(spans, [2]int{start, len(line)})
+	}
+	return spans
+}
+
+// keystoreDetector finds Ethereum V3 keystore files (encrypted wallets)
+type keystoreDetector struct{}
+
+func (keystoreDetector) Name() string  { return "finance_eth_keystore" }
+func (keystoreDetector) Group() string { return GroupFinance }
+
+// maxKeystoreSize bounds the JSON parsed by the keystore detector
+const maxKeystoreSize = 64 * 1024
+
+func (keystoreDetector) Detect(lines []string) []*DetectedPattern {
+	content := strings.TrimSpace(strings.Join(lines, "\n"))
+	if len(content) > maxKeystoreSize || !strings.HasPrefix(content, "{") || !strings.Contains(content, `"ciphertext"`) {
+		return nil
+	}
+
+	// Field names match case-insensitively, covering both "crypto" and "Crypto"
+	var keystore struct {
+		Version int `json:"version"`
+		Crypto  *struct {
+			Ciphertext string `json:"ciphertext"`
+			KDF        string `json:"kdf"`
+			MAC        string `json:"mac"`
+		} `json:"crypto"`
+	}
+	if err := json.Unmarshal([]byte(content), &keystore); err != nil {
+		return nil
+	}
+	c := keystore.Crypto
+	if keystore.Version != 3 || c == nil || c.Ciphertext == "" || c.MAC == "" || (c.KDF != "scrypt" && c.KDF != "pbkdf2") {
+		return nil
+	}
+
+	for i, line := range lines {
+		if start := strings.Index(line, c.Ciphertext); start >= 0 {
+			return []*DetectedPattern{{
+				Type:        PatternCryptoKeystore,
+				RuleName:    "finance_eth_keystore",
+				Severity:    Critical,
+				Description: "Ethereum keystore file detected",
+				LineNumber:  i + 1,
+				StartIndex:  start,
+				EndIndex:    start + len(c.Ciphertext),
+				MatchText:   c.Ciphertext,
+			}}
+		}
+	}
+	return nil
+}
+
+// swiftDetector finds SWIFT MT103 payments and MT940 statements by their
+// mandatory fields. Amount fields must be well formed, and a basic header
+// block, when present, must carry a valid BIC.
+type swiftDetector struct{}
+
+func (swiftDetector) Name() string  { return "finance_swift" }
+func (swiftDetector) Group() string { return GroupFinance }
+
+var (
+	swiftField   = regexp.MustCompile(`^\s*:(\d{2}[A-Z]?):(.*)$`)
+	swiftHeader  = regexp.MustCompile(`\{1:F01([A-Z0-9]{12})`)
+	swiftAppType = regexp.MustCompile(`\{2:[IO](\d{3})`)
+	// :32A: value date, currency, amount
+	swiftValueDate = regexp.MustCompile(`^(\d{6})[A-Z]{3}\d{1,12},\d{0,2}$`)
+	// :60F: / :62F: debit or credit mark, date, currency, amount
+	swiftBalance = regexp.MustCompile(`^[CD](\d{6})[A-Z]{3}\d{1,12},\d{0,2}$`)
+)
+
+// swiftTag is a field of a message with the span of its value
+type swiftTag struct {
+	value      string
+	line       int
+	start, end int
+}
+
+// swiftMessage is the fields from one :20: reference to the next
+type swiftMessage struct {
+	header  string // Logical terminal address from block 1
+	msgType string // Message type from block 2
+	fields  map[string]swiftTag
+}
+
+func (d swiftDetector) Detect(lines []string) []*DetectedPattern {
+	var results []*DetectedPattern
+	var messages []*swiftMessage
+	var header, msgType string
+
+	for i, line := range lines {
+		if m := swiftHeader.FindStringSubmatch(line); m != nil {
+			header = m[1]
+		}
+		if m := swiftAppType.FindStringSubmatch(line); m != nil {
+			msgType = m[1]
+		}
+
+		m := swiftField.FindStringSubmatchIndex(line)
+		if m == nil {
+			continue
+		}
+		tag := line[m[2]:m[3]]
+		if tag == "20" || len(messages) == 0 {
+			messages = append(messages, &swiftMessage{header: header, msgType: msgType, fields: make(map[string]swiftTag)})
+		}
+		msg := messages[len(messages)-1]
+		if _, seen := msg.fields[tag]; !seen {
+			value := strings.TrimRight(line[m[4]:m[5]], " \r")
+			msg.fields[tag] = swiftTag{value: value, line: i, start: m[4], end: m[4] + len(value)}
+		}
+	}
+
+	for _, msg := range messages {
+		if msg.header != "" && !ValidBIC(msg.header[:8]+msg.header[9:]) {
+			continue
+		}
+		if match := d.checkMT103(msg); match != nil {
+			results = append(results, match)
+		} else if match := d.checkMT940(msg); match != nil {
+			results = append(results, match)
+		}
+	}
+	return results
+}
+
+func (swiftDetector) checkMT103(msg *swiftMessage) *DetectedPattern {
+	if msg.msgType != "" && msg.msgType != "103" {
+		return nil
+	}
+	amount, ok := msg.fields["32A"]
+	if !ok || !hasField(msg, "20") || !hasField(msg, "50A", "50F", "50K") || !hasField(msg, "59", "59A", "59F") {
+		return nil
+	}
+	m := swiftValueDate.FindStringSubmatch(amount.value)
+	if m == nil || !validSwiftDate(m[1]) {
+		return nil
+	}
+	return swiftFinding(amount, Critical, "SWIFT MT103 payment message detected")
+}
+
+func (swiftDetector) checkMT940(msg *swiftMessage) *DetectedPattern {
+	if msg.msgType != "" && msg.msgType != "940" {
+		return nil
+	}
+	account, ok := msg.fields["25"]
+	if !ok || !hasField(msg, "20") || !hasField(msg, "62F", "62M") {
+		return nil
+	}
+	opening, ok := msg.fields["60F"]
+	if !ok {
+		opening, ok = msg.fields["60M"]
+	}
+	if !ok {
+		return nil
+	}
+	m := swiftBalance.FindStringSubmatch(opening.value)
+	if m == nil || !validSwiftDate(m[1]) {
+		return nil
+	}
+	return swiftFinding(account, High, "SWIFT MT940 statement detected")
+}
+
+func hasField(msg *swiftMessage, tags ...string) bool {
+	for _, tag := range tags {
+		if _, ok := msg.fields[tag]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// validSwiftDate checks a YYMMDD date
+func validSwiftDate(date string) bool {
+	month, _ := strconv.Atoi(date[2:4])
+	day, _ := strconv.Atoi(date[4:6])
+	return month >= 1 && month <= 12 && day >= 1 && day <= 31
+}
+
+func swiftFinding(tag swiftTag, severity Severity, description string) *DetectedPattern {
+	return &DetectedPattern{
+		Type:        PatternSWIFTMessage,
+		RuleName:    "finance_swift",
+		Severity:    severity,
+		Description: description,
+		LineNumber:  tag.line + 1,
+		StartIndex:  tag.start,
+		EndIndex:    tag.end,
+		MatchText:   tag.value,
+	}
+}
+
+// sepaDetector finds account holder names paired with valid IBANs in
+// ISO 20022 pain.001 credit transfer files
+type sepaDetector struct{}
+
+func (sepaDetector) Name() string  { return "finance_sepa" }
+func (sepaDetector) Group() string { return GroupFinance }
+
+var (
+	sepaIBAN = regexp.MustCompile(`<IBAN>\s*([A-Z]{2}\d{2}[A-Z0-9]{11,30})\s*</IBAN>`)
+	sepaName = regexp.MustCompile(`<Nm>[^<]*\S[^<]*</Nm>`)
+)
+
+// sepaNameWindow is how many lines before an IBAN its owner's name may be
+const sepaNameWindow = 6
+
+func (sepaDetector) Detect(lines []string) []*DetectedPattern {
+	isPain001 := false
+	for _, line := range lines {
+		if strings.Contains(line, "pain.001") || strings.Contains(line, "<CstmrCdtTrfInitn>") {
+			isPain001 = true
+			break
+		}
+	}
+	if !isPain001 {
+		return nil
+	}
+
+	var results []*DetectedPattern
+	for i, line := range lines {
+		for _, m := range sepaIBAN.FindAllStringSubmatchIndex(line, -1) {
+			iban := line[m[2]:m[3]]
+			if !ValidIBAN(iban) || !sepaNameBefore(lines, i, m[0]) {
+				continue
+			}
+			results = append(results, &DetectedPattern{
+				Type:        PatternSEPAPayment,
+				RuleName:    "finance_sepa",
+				Severity:    High,
+				Description: "SEPA payment with account holder name detected",
+				LineNumber:  i + 1,
+				StartIndex:  m[2],
+				EndIndex:    m[3],
+				MatchText:   iban,
+			})
+		}
+	}
+	return results
+}
+
+// sepaNameBefore reports whether a <Nm> element precedes the IBAN at
+// lines[lineIdx][offset] within sepaNameWindow lines
+func sepaNameBefore(lines []string, lineIdx, offset int) bool {
+	if sepaName.MatchString(lines[lineIdx][:offset]) {
+		return true
+	}
+	for i := lineIdx - 1; i >= 0 && i >= lineIdx-sepaNameWindow; i-- {
+		if sepaName.MatchString(lines[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// bankStatementDetector finds CSV exports of bank statements by their
+// header: an amount column plus at least two other statement columns,
+// followed by data rows with numeric amounts
+type bankStatementDetector struct{}
+
+func (bankStatementDetector) Name() string  { return "finance_bank_statement" }
+func (bankStatementDetector) Group() string { return GroupFinance }
+
+const (
+	statementAmount = "amount"
+	// statementHeaderLines is how far into the file the header may start
+	statementHeaderLines = 10
+	minStatementRows     = 2
+	minStatementColumns  = 3
+)
+
+// statementColumns maps column categories to header keywords (lowercase)
+var statementColumns = []struct {
+	category string
+	keywords []string
+}{
+	{statementAmount, []string{"amount", "сумма", "betrag", "debit", "credit", "дебет", "кредит", "withdrawal", "deposit"}},
+	{"balance", []string{"balance", "остаток", "баланс", "saldo"}},
+	{"date", []string{"date", "дата", "datum"}},
+	{"counterparty", []string{"payee", "payer", "beneficiary", "counterparty", "recipient", "получатель", "плательщик", "контрагент"}},
+	{"account", []string{"account", "iban", "счёт", "счет"}},
+	{"description", []string{"description", "memo", "reference", "details", "purpose", "назначение", "описание"}},
+}
+
+var statementAmountValue = regexp.MustCompile(`^[-+−]?\s*[\d\s ]+([.,]\d{1,2})?$`)
+
+func (d bankStatementDetector) Detect(lines []string) []*DetectedPattern {
+	checked := 0
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if checked++; checked > statementHeaderLines {
+			break
+		}
+
+		delimiter, header := splitStatementRow(line)
+		amountCol, ok := statementHeader(header)
+		if !ok {
+			continue
+		}
+
+		rows := 0
+		firstRow := -1
+		for j := i + 1; j < len(lines) && rows < minStatementRows; j++ {
+			if strings.TrimSpace(lines[j]) == "" {
+				continue
+			}
+			fields := splitWith(lines[j], delimiter)
+			if len(fields) != len(header) || !statementAmountValue.MatchString(strings.TrimSpace(fields[amountCol])) {
+				break
+			}
+			if firstRow < 0 {
+				firstRow = j
+			}
+			rows++
+		}
+		if rows < minStatementRows {
+			continue
+		}
+
+		row := strings.TrimRight(lines[firstRow], " \r")
+		return []*DetectedPattern{{
+			Type:        PatternBankStatement,
+			RuleName:    "finance_bank_statement",
+			Severity:    High,
+			Description: "Bank statement CSV detected",
+			LineNumber:  firstRow + 1,
+			StartIndex:  0,
+			EndIndex:    len(row),
+			MatchText:   row,
+		}}
+	}
+	return nil
+}
+
+// statementHeader reports whether fields look like a statement header and
+// returns the index of the amount column
+func statementHeader(fields []string) (int, bool) {
+	if len(fields) < minStatementColumns {
+		return 0, false
+	}
+
+	amountCol := -1
+	categories := make(map[string]bool)
+	for i, field := range fields {
+		name := strings.ToLower(strings.TrimSpace(field))
+		for _, column := range statementColumns {
+			if !containsAny(name, column.keywords) {
+				continue
+			}
+			if column.category == statementAmount && amountCol < 0 {
+				amountCol = i
+			}
+			categories[column.category] = true
+			break
+		}
+	}
+	return amountCol, amountCol >= 0 && len(categories) >= minStatementColumns
+}
+
+// splitStatementRow splits a header with the delimiter giving most fields
+func splitStatementRow(line string) (rune, []string) {
+	best, bestFields := ',', []string(nil)
+	for _, delimiter := range []rune{',', ';', '\t', '|'} {
+		if fields := splitWith(line, delimiter); len(fields) > len(bestFields) {
+			best, bestFields = delimiter, fields
+		}
+	}
+	return best, bestFields
+}
+
+func splitWith(line string, delimiter rune) []string {
+	reader := csv.NewReader(strings.NewReader(line))
+	reader.Comma = delimiter
+	reader.LazyQuotes = true
+	fields, err := reader.Read()
+	if err != nil {
+		return nil
+	}
+	return fields
+}
+
+func containsAny(s string, keywords []string) bool {
+	for _, keyword := range keywords {
+		if strings.Contains(s, keyword) {
+			return true
+		}
+	}
+	return false
+}
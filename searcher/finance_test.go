@@ -0,0 +1,221 @@
+package searcher
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// financeScanner returns a scanner with the finance group enabled
+func financeScanner(t *testing.T) *Scanner {
+	t.Helper()
+	scanner := NewScanner()
+	if err := scanner.GetPatterns().EnableGroups([]string{"финансы"}); err != nil {
+		t.Fatal(err)
+	}
+	return scanner
+}
+
+// financeFindings scans a finance fixture and keeps findings of one type
+func financeFindings(t *testing.T, scanner *Scanner, name string, patternType PatternType) []*Finding {
+	t.Helper()
+	findings, err := scanner.scanFileContent(filepath.Join("testdata", "finance", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return findingsOfType(findings, patternType)
+}
+
+// TestValidateBIP39Mnemonic tests checksum validation with reference vectors
+func TestValidateBIP39Mnemonic(t *testing.T) {
+	abandon := strings.Repeat("abandon ", 11)
+	tests := []struct {
+		phrase string
+		valid  bool
+	}{
+		{abandon + "about", true},
+		{"legal winner thank year wave sausage worth useful legal winner thank yellow", true},
+		{strings.Repeat("zoo ", 11) + "wrong", true},
+		{strings.Repeat("abandon ", 23) + "art", true},
+		{abandon + "abandon", false},
+		{"legal winner thank year wave sausage worth useful legal winner thank year", false},
+		{abandon + "notaword", false},
+		{"abandon abandon about", false},
+	}
+	for _, tt := range tests {
+		if got := ValidateBIP39Mnemonic(strings.Fields(tt.phrase)); got != tt.valid {
+			t.Errorf("ValidateBIP39Mnemonic(%q) = %v, want %v", tt.phrase, got, tt.valid)
+		}
+	}
+}
+
+// TestFinanceValidators tests the BIC, IBAN and base58check validators
+func TestFinanceValidators(t *testing.T) {
+	for bic, want := range map[string]bool{
+		"DEUTDEFF":    true,
+		"DEUTDEFF500": true,
+		"BNPAFRPPXXX": true,
+		"DEUTQQFF":    false, // Unknown country
+		"DEUT1EFF":    false,
+		"DEUTDEF":     false,
+	} {
+		if got := ValidBIC(bic); got != want {
+			t.Errorf("ValidBIC(%q) = %v, want %v", bic, got, want)
+		}
+	}
+
+	for iban, want := range map[string]bool{
+		"DE89370400440532013000":      true,
+		"FR1420041010050500013M02606": true,
+		"DE89370400440532013001":      false,
+		"DE89":                        false,
+	} {
+		if got := ValidIBAN(iban); got != want {
+			t.Errorf("ValidIBAN(%q) = %v, want %v", iban, got, want)
+		}
+	}
+
+	keys := map[string]bool{
+		"5HueCGU8rMjxEXxiPuD5BDku4MkFqeZyd4dZ1jvhTVqvbTLvyTJ":  true,
+		"KwdMAjGmerYanjeui5SHS7JkmpZvVipYvB2LJGU1ZxJwYvP98617": true,
+		"5HueCGU8rMjxEXxiPuD5BDku4MkFqeZyd4dZ1jvhTVqvbTLvyTK":  false,
+	}
+	for key, want := range keys {
+		if got := validWIF(key); got != want {
+			t.Errorf("validWIF(%q) = %v, want %v", key, got, want)
+		}
+	}
+	if !validExtendedKey("xprv9s21ZrQH143K3QTDL4LXw2F7HEK3wJUD2nW2nRk4stbPy6cq3jPPqjiChkVvvNKmPGJxWUtg6LnF5kejMRNNU3TGtRBeJgk33yuGBxrMPHi") {
+		t.Error("BIP-32 test vector xprv should be valid")
+	}
+}
+
+// TestFinanceGroupDisabledByDefault tests the pack only runs when enabled
+func TestFinanceGroupDisabledByDefault(t *testing.T) {
+	scanner := NewScanner()
+	if scanner.GetPatterns().GroupEnabled(GroupFinance) {
+		t.Fatal("finance group should be off by default")
+	}
+	if len(financeFindings(t, scanner, "seed.txt", PatternSeedPhrase)) != 0 {
+		t.Error("seed phrase reported with the finance group disabled")
+	}
+	if trace := scanner.Evaluate("wif=5HueCGU8rMjxEXxiPuD5BDku4MkFqeZyd4dZ1jvhTVqvbTLvyTJ", ""); hasMatchType(trace, PatternCryptoKey) {
+		t.Error("WIF key reported with the finance group disabled")
+	}
+
+	if _, err := ParseGroup("crypto"); err == nil {
+		t.Error("unknown group should be rejected")
+	}
+}
+
+// TestFinanceCryptoKeys tests WIF keys are reported only with a valid checksum
+func TestFinanceCryptoKeys(t *testing.T) {
+	scanner := financeScanner(t)
+
+	if trace := scanner.Evaluate("wif=5HueCGU8rMjxEXxiPuD5BDku4MkFqeZyd4dZ1jvhTVqvbTLvyTJ", ""); !hasMatchType(trace, PatternCryptoKey) {
+		t.Error("valid WIF key not reported")
+	}
+	if trace := scanner.Evaluate("wif=5HueCGU8rMjxEXxiPuD5BDku4MkFqeZyd4dZ1jvhTVqvbTLvyTK", ""); hasMatchType(trace, PatternCryptoKey) {
+		t.Error("WIF key with a bad checksum reported")
+	}
+}
+
+// TestFinanceSeedPhrase tests a numbered phrase spread over lines is found
+func TestFinanceSeedPhrase(t *testing.T) {
+	findings := financeFindings(t, financeScanner(t), "seed.txt", PatternSeedPhrase)
+	if len(findings) != 1 {
+		t.Fatalf("expected 1 seed phrase, got %d", len(findings))
+	}
+	f := findings[0]
+	if f.LineNumber != 2 || f.Severity != Critical {
+		t.Errorf("unexpected finding: line %d, severity %s", f.LineNumber, f.Severity)
+	}
+	if f.MatchedText != "legal winner thank year wave sausage worth useful legal winner thank yellow" {
+		t.Errorf("unexpected phrase: %q", f.MatchedText)
+	}
+	if got := f.Context[f.ByteStart:f.ByteEnd]; !strings.HasPrefix(got, "legal") || !strings.HasSuffix(got, "year") {
+		t.Errorf("span should cover the words on the first line, got %q", got)
+	}
+}
+
+// TestFinanceSeedPhraseProse tests prose made of wordlist words is not reported
+func TestFinanceSeedPhraseProse(t *testing.T) {
+	if findings := financeFindings(t, financeScanner(t), "prose.txt", PatternSeedPhrase); len(findings) != 0 {
+		t.Errorf("prose reported as seed phrase: %q", findings[0].MatchedText)
+	}
+
+	detector := seedPhraseDetector{}
+	tests := map[string][]string{
+		"quoted JSON array": {`{"mnemonic": ["zoo", "zoo", "zoo", "zoo", "zoo", "zoo",`, `"zoo", "zoo", "zoo", "zoo", "zoo", "wrong"]}`},
+		"label word":        {"seed abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"},
+	}
+	for name, lines := range tests {
+		if got := detector.Detect(lines); len(got) != 1 {
+			t.Errorf("%s: expected 1 seed phrase, got %d", name, len(got))
+		}
+	}
+
+	broken := []string{"abandon abandon abandon abandon abandon abandon. abandon abandon abandon abandon abandon about"}
+	if got := detector.Detect(broken); len(got) != 0 {
+		t.Error("punctuation inside the phrase should break the run")
+	}
+}
+
+// TestFinanceDocuments tests each fixture is reported with its type and severity
+func TestFinanceDocuments(t *testing.T) {
+	scanner := financeScanner(t)
+	tests := []struct {
+		file        string
+		patternType PatternType
+		count       int
+		severity    Severity
+		match       string
+	}{
+		{"mt103.txt", PatternSWIFTMessage, 1, Critical, "240115EUR12500,00"},
+		{"mt940.sta", PatternSWIFTMessage, 1, High, "DEUTDEFF/0532013000"},
+		{"pain001.xml", PatternSEPAPayment, 2, High, "DE89370400440532013000"},
+		{"keystore.json", PatternCryptoKeystore, 1, Critical, "5318b4d5bcd28de64ee5559e671353e16f075ecae9f99c7a79a38af5f869aa46"},
+		{"statement.csv", PatternBankStatement, 1, High, "15.01.2024;Оплата счёта 4711;ООО Ромашка;-12 500,00;2 500,00"},
+	}
+
+	for _, tt := range tests {
+		findings := financeFindings(t, scanner, tt.file, tt.patternType)
+		if len(findings) != tt.count {
+			t.Errorf("%s: expected %d findings, got %d", tt.file, tt.count, len(findings))
+			continue
+		}
+		f := findings[0]
+		if f.Severity != tt.severity || f.MatchedText != tt.match {
+			t.Errorf("%s: got %s %q", tt.file, f.Severity, f.MatchedText)
+		}
+		if f.Context[f.ByteStart:f.ByteEnd] != tt.match {
+			t.Errorf("%s: span %d-%d does not cover the match", tt.file, f.ByteStart, f.ByteEnd)
+		}
+	}
+}
+
+// TestFinanceSWIFTInvalidHeader tests a header with a malformed BIC is ignored
+func TestFinanceSWIFTInvalidHeader(t *testing.T) {
+	lines := []string{
+		"{1:F01DEUTQQFFAXXX0000000000}{2:I103BNPAFRPPXXXXN}{4:",
+		":20:REF1", ":32A:240115EUR100,00", ":50K:ACME", ":59:DUPONT", "-}",
+	}
+	if got := (swiftDetector{}).Detect(lines); len(got) != 0 {
+		t.Errorf("expected no findings, got %d", len(got))
+	}
+
+	lines[0] = "{1:F01DEUTDEFFAXXX0000000000}{2:I103BNPAFRPPXXXXN}{4:"
+	lines[2] = ":32A:241315EUR100,00" // Month 13
+	if got := (swiftDetector{}).Detect(lines); len(got) != 0 {
+		t.Errorf("invalid value date accepted")
+	}
+}
+
+func hasMatchType(trace *EvaluationTrace, patternType PatternType) bool {
+	for _, m := range trace.Matches {
+		if m.Type == patternType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,351 @@
+package searcher
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DependencyGit is the binary history scans shell out to
+const DependencyGit = "git"
+
+func init() {
+	RegisterDependency(DependencyGit, probeGit)
+}
+
+// probeGit checks if git is available for history and staged scans
+func probeGit(ctx context.Context) *DependencyStatus {
+	status := &DependencyStatus{
+		Name:        "Git",
+		Description: "Сканирование истории git и проиндексированных изменений",
+		InstallHint: gitInstallHint(),
+		MinVersion:  MinGitVersion,
+	}
+	if path := lookupExecutable(DependencyGit); path != "" {
+		status.Available = true
+		status.Path = path
+		// "git version 2.39.5", or "git version 2.45.1.windows.1"
+		status.Version = toolVersion(ctx, path, "--version")
+	}
+	return status
+}
+
+// gitInstallHint returns platform-specific install instructions
+func gitInstallHint() string {
+	switch runtime.GOOS {
+	case "darwin":
+		return "xcode-select --install или brew install git"
+	case "linux":
+		return "sudo apt install git"
+	case "windows":
+		return "Скачать: https://git-scm.com/download/win"
+	default:
+		return "Установите git для вашей системы"
+	}
+}
+
+// gitBlobModes are the modes of regular files in git trees; symlinks and
+// submodules are not scanned
+var gitBlobModes = map[string]bool{"100644": true, "100755": true}
+
+// gitCommit is the header of one commit in the history walk
+type gitCommit struct {
+	Hash   string
+	Author string
+	Date   string // ISO 8601 author date
+}
+
+// gitChange is a file added or modified by a commit
+type gitChange struct {
+	Blob string
+	Path string
+}
+
+// ScanGitHistory scans every version of the text files in a repository's
+// history, including secrets that were removed later
+func (s *Scanner) ScanGitHistory(repoPath string) (*ScanResult, error) {
+	return s.ScanGitHistoryContext(context.Background(), repoPath)
+}
+
+// ScanGitHistoryContext walks all commits of all refs from the oldest one
+// and scans each blob a commit added or changed. Findings carry the commit,
+// author and date and the path at that commit; a secret that stays in the
+// history for many commits is reported once, for the commit that introduced it
+func (s *Scanner) ScanGitHistoryContext(ctx context.Context, repoPath string) (*ScanResult, error) {
+	stopGate := s.beginScan(ctx, repoPath)
+	defer stopGate()
+	if s.patterns.Profiling() {
+		s.patterns.SetProfiling(true)
+	}
+	defer func() {
+		s.result.EndTime = time.Now().Unix()
+		s.finishMetrics()
+		s.result.Coverage = BuildCoverage(s.result, s.coverageOptions(), s.deps)
+		if s.patterns.Profiling() {
+			s.result.SetRegexTimes(s.patterns.RegexTimes())
+		}
+	}()
+
+	if err := checkRoot(repoPath); err != nil {
+		return s.result, err
+	}
+	git, ok := s.deps.ToolPath(DependencyGit)
+	if !ok {
+		return s.result, &ErrDependencyMissing{Name: DependencyGit}
+	}
+	rootPatterns, rootAllowlist, err := loadRootPatterns(s.patterns, repoPath, s.rootPatterns)
+	s.rootPatterns = rootPatterns
+	s.rootAllowlist = rootAllowlist
+	if err != nil {
+		return s.result, err
+	}
+
+	blobs, err := newGitBlobReader(ctx, git, repoPath)
+	if err != nil {
+		return s.result, err
+	}
+	defer blobs.Close()
+
+	seenBlobs := make(map[string]bool)
+	seenSecrets := make(map[string]bool)
+	err = walkGitHistory(ctx, git, repoPath, func(commit gitCommit, change gitChange) error {
+		s.gate.wait()
+		if err := cancelledError(ctx); err != nil {
+			return err
+		}
+		// A blob kept by a later commit or copied to another path has the
+		// same findings, which were reported for its first appearance
+		if seenBlobs[change.Blob] {
+			return nil
+		}
+		seenBlobs[change.Blob] = true
+		s.queued.Add(1)
+		s.fileStarted(change.Path)
+
+		found, err := s.scanGitBlob(blobs, commit, change, seenSecrets)
+		if err != nil {
+			return err
+		}
+		s.fileScanned(change.Path, found)
+		return nil
+	})
+
+	if cancelErr := cancelledError(ctx); cancelErr != nil {
+		s.result.Cancelled = true
+		return s.result, cancelErr
+	}
+	return s.result, err
+}
+
+// scanGitBlob scans one file version and records the findings of secrets
+// not seen in earlier commits
+func (s *Scanner) scanGitBlob(blobs *gitBlobReader, commit gitCommit, change gitChange, seenSecrets map[string]bool) (int, error) {
+	if s.ignoreList.ShouldIgnorePath(change.Path) {
+		return 0, nil
+	}
+	content, ok, err := s.readGitBlob(blobs, change.Blob)
+	if !ok {
+		return 0, err
+	}
+
+	var fresh []*Finding
+	for _, finding := range s.scanTextContent(s.ctx, change.Path, string(content)) {
+		key := string(finding.PatternType) + "\x00" + finding.MatchedText
+		if seenSecrets[key] {
+			continue
+		}
+		seenSecrets[key] = true
+		finding.Commit = commit.Hash
+		finding.Author = commit.Author
+		finding.CommitDate = commit.Date
+		fresh = append(fresh, finding)
+	}
+
+	found := s.addFindings("", fresh)
+	s.result.IncrementFilesScanned()
+	s.result.AddTotalSize(int64(len(content)))
+	return found, nil
+}
+
+// readGitBlob reads a blob to scan; ok is false with a nil error for
+// blobs skipped as too large or binary
+func (s *Scanner) readGitBlob(blobs *gitBlobReader, blob string) (content []byte, ok bool, err error) {
+	content, err = blobs.Read(blob, s.maxFileSize)
+	if errors.Is(err, ErrTooLarge) {
+		s.result.IncrementFilesSkipped()
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	head := content
+	if len(head) > 512 {
+		head = head[:512]
+	}
+	if bytes.IndexByte(head, 0) >= 0 {
+		s.result.IncrementFilesSkipped()
+		return nil, false, nil
+	}
+	return content, true, nil
+}
+
+// walkGitHistory lists the file changes of every commit, parents first.
+// Merge commits add no changes of their own
+func walkGitHistory(ctx context.Context, git, repoPath string, visit func(gitCommit, gitChange) error) error {
+	return walkGitRaw(ctx, git, repoPath, visit, "log", "--all", "--reverse", "--topo-order",
+		"--no-renames", "--raw", "-r", "--no-abbrev", "-z", "--format=%x1e%H%x1f%an%x1f%aI")
+}
+
+// walkGitRaw runs a git command printing changes in the NUL-separated raw
+// format, such as log or diff, and visits the changes
+func walkGitRaw(ctx context.Context, git, repoPath string, visit func(gitCommit, gitChange) error, args ...string) error {
+	cmd := exec.CommandContext(ctx, git, append([]string{"-C", repoPath}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	visitErr := parseGitLog(stdout, visit)
+	if visitErr != nil {
+		// Stop git instead of waiting for it to write the rest
+		cmd.Process.Kill()
+	}
+	waitErr := cmd.Wait()
+	if visitErr != nil {
+		return visitErr
+	}
+	if waitErr != nil {
+		if ctx.Err() != nil {
+			return cancelledError(ctx)
+		}
+		return fmt.Errorf("git %s: %s", args[0], gitErrorText(stderr.String(), waitErr))
+	}
+	return nil
+}
+
+// parseGitLog reads the NUL-separated output of walkGitRaw: for git log a
+// "\x1e"-prefixed header per commit, then a ":modes blobs status" and a
+// path token per changed file
+func parseGitLog(r io.Reader, visit func(gitCommit, gitChange) error) error {
+	reader := bufio.NewReader(r)
+	var commit gitCommit
+	for {
+		token, err := reader.ReadString(0)
+		if err == io.EOF && token == "" {
+			return nil
+		}
+		if err != nil && err != io.EOF {
+			return err
+		}
+		token = strings.TrimLeft(strings.TrimSuffix(token, "\x00"), "\n")
+
+		switch {
+		case strings.HasPrefix(token, "\x1e"):
+			fields := strings.Split(token[1:], "\x1f")
+			if len(fields) != 3 {
+				return fmt.Errorf("git log: неожиданный заголовок коммита %q", token)
+			}
+			commit = gitCommit{Hash: fields[0], Author: fields[1], Date: fields[2]}
+		case strings.HasPrefix(token, ":"):
+			path, pathErr := reader.ReadString(0)
+			if pathErr != nil {
+				return fmt.Errorf("git log: нет пути после %q", token)
+			}
+			// :oldmode newmode oldblob newblob status
+			fields := strings.Fields(token[1:])
+			if len(fields) < 5 || fields[4] == "D" || !gitBlobModes[fields[1]] {
+				continue
+			}
+			change := gitChange{Blob: fields[3], Path: strings.TrimSuffix(path, "\x00")}
+			if err := visit(commit, change); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+	}
+}
+
+// gitBlobReader reads blobs through one long-running "git cat-file --batch"
+type gitBlobReader struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout *bufio.Reader
+}
+
+func newGitBlobReader(ctx context.Context, git, repoPath string) (*gitBlobReader, error) {
+	cmd := exec.CommandContext(ctx, git, "-C", repoPath, "cat-file", "--batch")
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return &gitBlobReader{cmd: cmd, stdin: stdin, stdout: bufio.NewReader(stdout)}, nil
+}
+
+// Read returns the content of a blob. Blobs larger than maxSize are skipped
+// without keeping them in memory and give ErrTooLarge
+func (br *gitBlobReader) Read(blob string, maxSize int64) ([]byte, error) {
+	if _, err := io.WriteString(br.stdin, blob+"\n"); err != nil {
+		return nil, err
+	}
+	header, err := br.stdout.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	// <blob> blob <size>, or <blob> missing
+	fields := strings.Fields(header)
+	if len(fields) != 3 {
+		return nil, fmt.Errorf("git cat-file: %s", strings.TrimSpace(header))
+	}
+	size, err := strconv.ParseInt(fields[2], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("git cat-file: %s", strings.TrimSpace(header))
+	}
+
+	if size > maxSize {
+		if _, err := br.stdout.Discard(int(size) + 1); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %s (%d байт)", ErrTooLarge, blob, size)
+	}
+	content := make([]byte, size+1) // The content is followed by a newline
+	if _, err := io.ReadFull(br.stdout, content); err != nil {
+		return nil, err
+	}
+	return content[:size], nil
+}
+
+// Close stops the git process
+func (br *gitBlobReader) Close() error {
+	br.stdin.Close()
+	return br.cmd.Wait()
+}
+
+// gitErrorText returns the first line git printed, or the exit error
+func gitErrorText(stderr string, err error) string {
+	if line, _, _ := strings.Cut(strings.TrimSpace(stderr), "\n"); line != "" {
+		return line
+	}
+	return err.Error()
+}
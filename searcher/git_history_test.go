@@ -0,0 +1,150 @@
+package searcher
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// gitTestRepo creates a repository whose secret is added in the first
+// commit and removed two commits later; it returns the path and the hash of
+// every commit
+func gitTestRepo(t *testing.T) (string, []string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	git := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=Ann Dev", "GIT_AUTHOR_EMAIL=ann@example.org",
+			"GIT_COMMITTER_NAME=Ann Dev", "GIT_COMMITTER_EMAIL=ann@example.org",
+			"GIT_AUTHOR_DATE=2024-03-01T10:00:00+03:00", "GIT_CONFIG_GLOBAL=/dev/null")
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	write := func(name, content string) {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		os.WriteFile(path, []byte(content), 0644)
+	}
+
+	git("init", "-q")
+	var commits []string
+	commit := func(message string) {
+		git("add", "-A")
+		git("commit", "-q", "-m", message)
+		commits = append(commits, git("rev-parse", "HEAD"))
+	}
+
+	write("config/app settings.py", "DEBUG = True\npassword = 'Hunter2Secret!'\n")
+	commit("add settings")
+	write("config/app settings.py", "DEBUG = False\npassword = 'Hunter2Secret!'\n")
+	write("README.md", "docs\n")
+	commit("turn off debug")
+	write("config/app settings.py", "DEBUG = False\npassword = os.environ['PASSWORD']\n")
+	commit("remove secret")
+	return dir, commits
+}
+
+func TestScanGitHistory(t *testing.T) {
+	repo, commits := gitTestRepo(t)
+
+	// The working tree no longer has the secret
+	current, err := NewScanner().Scan(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range current.Findings {
+		if strings.Contains(f.MatchedText, "Hunter2Secret") {
+			t.Fatalf("secret still in the working tree: %+v", f)
+		}
+	}
+
+	result, err := NewScanner().ScanGitHistory(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var secrets []*Finding
+	for _, f := range result.Findings {
+		if strings.Contains(f.MatchedText, "Hunter2Secret") {
+			secrets = append(secrets, f)
+		}
+	}
+	if len(secrets) != 1 {
+		t.Fatalf("the secret of two commits should be reported once, got %d: %+v", len(secrets), result.Findings)
+	}
+	f := secrets[0]
+	if f.Commit != commits[0] || f.Author != "Ann Dev" || !strings.HasPrefix(f.CommitDate, "2024-03-01T10:00:00") {
+		t.Errorf("commit info = %q %q %q, want the first commit %s", f.Commit, f.Author, f.CommitDate, commits[0])
+	}
+	if f.FilePath != "config/app settings.py" || f.LineNumber != 2 {
+		t.Errorf("location = %s:%d", f.FilePath, f.LineNumber)
+	}
+	// Three versions of the settings file and the README
+	if result.FilesScanned != 4 {
+		t.Errorf("FilesScanned = %d, want 4 blobs", result.FilesScanned)
+	}
+
+	// Reports carry the commit
+	var csv, text bytes.Buffer
+	rg := NewReportGenerator(result)
+	rg.writeCSV(&csv)
+	rg.writePlainText(&text)
+	if !strings.Contains(csv.String(), "Коммит") || !strings.Contains(csv.String(), commits[0]) {
+		t.Error("CSV report should have the commit columns")
+	}
+	if !strings.Contains(text.String(), "Коммит:      "+commits[0]) {
+		t.Error("text report should name the commit")
+	}
+}
+
+func TestScanGitHistoryErrors(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	if _, err := NewScanner().ScanGitHistory(t.TempDir()); err == nil || !strings.Contains(err.Error(), "git log") {
+		t.Errorf("a directory without a repository should fail, got %v", err)
+	}
+
+	registry := NewDependencyRegistry()
+	registry.tools["git"] = ""
+	scanner := NewScanner()
+	scanner.SetDependencyRegistry(registry)
+	var missing *ErrDependencyMissing
+	if _, err := scanner.ScanGitHistory(t.TempDir()); !errors.As(err, &missing) || missing.Name != DependencyGit {
+		t.Errorf("missing git error = %v", err)
+	}
+}
+
+func TestParseGitLog(t *testing.T) {
+	zero := strings.Repeat("0", 40)
+	blob := strings.Repeat("a", 40)
+	link := strings.Repeat("b", 40)
+	out := "\x1ec1\x1fAnn\x1f2024-01-01T00:00:00Z\x00\n" +
+		":000000 100644 " + zero + " " + blob + " A\x00dir/with space.txt\x00" +
+		":000000 120000 " + zero + " " + link + " A\x00link\x00" +
+		"\x1ec2\x1fBob\x1f2024-01-02T00:00:00Z\x00\n" +
+		":100644 000000 " + blob + " " + zero + " D\x00dir/with space.txt\x00"
+
+	var got []string
+	err := parseGitLog(strings.NewReader(out), func(c gitCommit, ch gitChange) error {
+		got = append(got, c.Hash+" "+c.Author+" "+ch.Blob[:1]+" "+ch.Path)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0] != "c1 Ann a dir/with space.txt" {
+		t.Errorf("changes = %q, want only the added regular file", got)
+	}
+}
@@ -0,0 +1,100 @@
+package searcher
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// ScanStaged scans what the next commit of a repository would record: the
+// staged blob of every added, copied or modified file rather than the
+// working tree, so a partially staged file is checked as it will be
+// committed. Deleted files have nothing to scan; binary and too large
+// blobs are skipped. Findings carry paths relative to the repository root
+func (s *Scanner) ScanStaged(ctx context.Context, repoPath string) (*ScanResult, error) {
+	stopGate := s.beginScan(ctx, repoPath)
+	defer stopGate()
+	if s.patterns.Profiling() {
+		s.patterns.SetProfiling(true)
+	}
+	defer func() {
+		s.result.EndTime = time.Now().Unix()
+		s.finishMetrics()
+		s.result.Coverage = BuildCoverage(s.result, s.coverageOptions(), s.deps)
+		if s.patterns.Profiling() {
+			s.result.SetRegexTimes(s.patterns.RegexTimes())
+		}
+	}()
+
+	if err := checkRoot(repoPath); err != nil {
+		return s.result, err
+	}
+	git, ok := s.deps.ToolPath(DependencyGit)
+	if !ok {
+		return s.result, &ErrDependencyMissing{Name: DependencyGit}
+	}
+	// Outside a repository git diff falls back to comparing two paths
+	// and rejects --cached with its whole usage
+	check := exec.CommandContext(ctx, git, "-C", repoPath, "rev-parse", "--git-dir")
+	var stderr bytes.Buffer
+	check.Stderr = &stderr
+	if err := check.Run(); err != nil {
+		return s.result, fmt.Errorf("git rev-parse: %s", gitErrorText(stderr.String(), err))
+	}
+	rootPatterns, rootAllowlist, err := loadRootPatterns(s.patterns, repoPath, s.rootPatterns)
+	s.rootPatterns = rootPatterns
+	s.rootAllowlist = rootAllowlist
+	if err != nil {
+		return s.result, err
+	}
+
+	blobs, err := newGitBlobReader(ctx, git, repoPath)
+	if err != nil {
+		return s.result, err
+	}
+	defer blobs.Close()
+
+	// Paths of diff are relative to the repository root whatever repoPath is
+	err = walkGitRaw(ctx, git, repoPath, func(_ gitCommit, change gitChange) error {
+		s.gate.wait()
+		if err := cancelledError(ctx); err != nil {
+			return err
+		}
+		// Files added with --intent-to-add have no staged content yet
+		if strings.Trim(change.Blob, "0") == "" {
+			return nil
+		}
+		s.queued.Add(1)
+		s.fileStarted(change.Path)
+		found, err := s.scanStagedBlob(blobs, change)
+		if err != nil {
+			return err
+		}
+		s.fileScanned(change.Path, found)
+		return nil
+	}, "diff", "--cached", "--raw", "-z", "--no-abbrev", "--no-renames", "--no-ext-diff")
+
+	if cancelErr := cancelledError(ctx); cancelErr != nil {
+		s.result.Cancelled = true
+		return s.result, cancelErr
+	}
+	return s.result, err
+}
+
+// scanStagedBlob scans the staged content of a file
+func (s *Scanner) scanStagedBlob(blobs *gitBlobReader, change gitChange) (int, error) {
+	if s.ignoreList.ShouldIgnorePath(change.Path) {
+		return 0, nil
+	}
+	content, ok, err := s.readGitBlob(blobs, change.Blob)
+	if !ok {
+		return 0, err
+	}
+	found := s.addFindings("", s.scanTextContent(s.ctx, change.Path, string(content)))
+	s.result.IncrementFilesScanned()
+	s.result.AddTotalSize(int64(len(content)))
+	return found, nil
+}
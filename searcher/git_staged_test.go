@@ -0,0 +1,114 @@
+package searcher
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// stagedTestRepo creates an empty repository and returns its path and a
+// function running git in it
+func stagedTestRepo(t *testing.T) (string, func(args ...string)) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=Ann Dev", "GIT_AUTHOR_EMAIL=ann@example.org",
+			"GIT_COMMITTER_NAME=Ann Dev", "GIT_COMMITTER_EMAIL=ann@example.org", "GIT_CONFIG_GLOBAL=/dev/null")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	git("init", "-q")
+	return dir, git
+}
+
+func hasSecret(result *ScanResult, secret string) bool {
+	for _, f := range result.Findings {
+		if strings.Contains(f.MatchedText, secret) {
+			return true
+		}
+	}
+	return false
+}
+
+// TestScanStagedContent tests the staged content is scanned, not the
+// working tree, on a repository without commits
+func TestScanStagedContent(t *testing.T) {
+	repo, git := stagedTestRepo(t)
+	write := func(name, content string) {
+		os.WriteFile(filepath.Join(repo, name), []byte(content), 0644)
+	}
+
+	// Staged with the secret, then fixed in the working tree only
+	write("settings.py", "password = 'Hunter2Secret!'\n")
+	git("add", "settings.py")
+	write("settings.py", "password = os.environ['PASSWORD']\n")
+	// Staged clean, the secret added afterwards is not committed
+	write("config.ini", "debug = true\n")
+	git("add", "config.ini")
+	write("config.ini", "debug = true\napi_key = 'Zx9Qw8Er7Ty6Ui5Op4As'\n")
+	// Untracked files are not part of the commit
+	write("notes.txt", "password = 'Untracked123!'\n")
+
+	result, err := NewScanner().ScanStaged(context.Background(), repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !hasSecret(result, "Hunter2Secret") {
+		t.Errorf("staged secret not found: %+v", result.Findings)
+	}
+	if hasSecret(result, "Zx9Qw8Er7Ty6Ui5Op4As") || hasSecret(result, "Untracked123") {
+		t.Errorf("content outside the index scanned: %+v", result.Findings)
+	}
+	for _, f := range result.Findings {
+		if f.FilePath != "settings.py" {
+			t.Errorf("finding path %q, want relative to the repository", f.FilePath)
+		}
+	}
+	if result.FilesScanned != 2 {
+		t.Errorf("FilesScanned = %d, want the 2 staged files", result.FilesScanned)
+	}
+}
+
+// TestScanStagedDeletedAndBinary tests staged deletions are not scanned and
+// binary blobs are skipped
+func TestScanStagedDeletedAndBinary(t *testing.T) {
+	repo, git := stagedTestRepo(t)
+	os.WriteFile(filepath.Join(repo, "old.env"), []byte("password = 'Hunter2Secret!'\n"), 0644)
+	git("add", "old.env")
+	git("commit", "-q", "-m", "add env")
+
+	git("rm", "-q", "old.env")
+	os.WriteFile(filepath.Join(repo, "blob.bin"), []byte("\x00\x01password = 'Binary2Secret!'"), 0644)
+	git("add", "blob.bin")
+
+	result, err := NewScanner().ScanStaged(context.Background(), repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.TotalFindings() != 0 {
+		t.Errorf("findings in deleted or binary files: %+v", result.Findings)
+	}
+	if result.FilesScanned != 0 || result.FilesSkipped != 1 {
+		t.Errorf("scanned %d, skipped %d; want the binary file skipped", result.FilesScanned, result.FilesSkipped)
+	}
+}
+
+func TestScanStagedNotRepository(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	_, err := NewScanner().ScanStaged(context.Background(), t.TempDir())
+	// git's own message, not the usage of git diff --no-index
+	if err == nil || !strings.HasPrefix(err.Error(), "git rev-parse:") || strings.Contains(err.Error(), "usage") {
+		t.Errorf("error %v, want the one of git rev-parse", err)
+	}
+}
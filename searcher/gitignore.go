@@ -0,0 +1,178 @@
+package searcher
+
+import (
+	"bufio"
+	"io"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/kacebover/password-finder/fsutil"
+)
+
+// GitignoreFile is the name of the files read when gitignore support is on
+const GitignoreFile = ".gitignore"
+
+// gitignoreRule is one pattern line of a .gitignore file
+type gitignoreRule struct {
+	base     string   // Directory of the .gitignore, "" for paths relative to it
+	segments []string // Slash-separated parts of the pattern, "**" kept as is
+	negate   bool     // "!pattern" re-includes a path
+	dirOnly  bool     // "pattern/" only matches directories
+}
+
+// gitignore holds the rules of the .gitignore files from the scan root down
+// to one directory. It is never modified, so subdirectories scanned in
+// parallel can share it; nil ignores nothing
+type gitignore struct {
+	rules []gitignoreRule
+}
+
+// withFile returns the rules extended by the .gitignore of dir, or the same
+// rules when dir has none
+func (g *gitignore) withFile(dir string) *gitignore {
+	file, err := fsutil.Open(filepath.Join(dir, GitignoreFile))
+	if err != nil {
+		return g
+	}
+	defer file.Close()
+
+	// Paths of the walk are joined, and so cleaned, from the scan root
+	base := filepath.Clean(dir)
+	if base == "." {
+		base = ""
+	}
+	rules := parseGitignore(base, file)
+	if len(rules) == 0 {
+		return g
+	}
+	child := &gitignore{}
+	if g != nil {
+		child.rules = append(child.rules, g.rules...)
+	}
+	child.rules = append(child.rules, rules...)
+	return child
+}
+
+// ignored reports whether a path is excluded. As in git the last matching
+// rule wins, so deeper files and later lines override earlier ones
+func (g *gitignore) ignored(filePath string, isDir bool) bool {
+	if g == nil {
+		return false
+	}
+	for i := len(g.rules) - 1; i >= 0; i-- {
+		rule := g.rules[i]
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		rel, ok := relativeToBase(rule.base, filePath)
+		if !ok {
+			continue
+		}
+		if matchSegments(rule.segments, strings.Split(rel, "/")) {
+			return !rule.negate
+		}
+	}
+	return false
+}
+
+// relativeToBase returns filePath relative to base with forward slashes
+func relativeToBase(base, filePath string) (string, bool) {
+	if base == "" {
+		return filepath.ToSlash(filePath), true
+	}
+	rel, ok := strings.CutPrefix(filePath, base+string(filepath.Separator))
+	if !ok || rel == "" {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
+
+// parseGitignore reads the rules of a .gitignore file located in base
+func parseGitignore(base string, r io.Reader) []gitignoreRule {
+	var rules []gitignoreRule
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if rule, ok := parseGitignoreLine(base, scanner.Text()); ok {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// parseGitignoreLine parses one line; comments and blank lines give no rule
+func parseGitignoreLine(base, line string) (gitignoreRule, bool) {
+	line = strings.TrimSuffix(line, "\r")
+	// Trailing spaces are dropped unless escaped with a backslash
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, "\\ ") {
+		line = line[:len(line)-1]
+	}
+	if line == "" || strings.HasPrefix(line, "#") {
+		return gitignoreRule{}, false
+	}
+
+	rule := gitignoreRule{base: base}
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return gitignoreRule{}, false
+	}
+
+	// A slash at the start or in the middle anchors the pattern to base,
+	// otherwise it matches at any depth
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+	for _, segment := range strings.Split(line, "/") {
+		if segment == "" {
+			continue
+		}
+		if segment != "**" {
+			// "**" inside a segment is an ordinary star; gitignore also
+			// negates classes with "!" where path.Match wants "^"
+			for strings.Contains(segment, "**") {
+				segment = strings.ReplaceAll(segment, "**", "*")
+			}
+			segment = strings.ReplaceAll(segment, "[!", "[^")
+		}
+		rule.segments = append(rule.segments, segment)
+	}
+	if !anchored && (len(rule.segments) == 0 || rule.segments[0] != "**") {
+		rule.segments = append([]string{"**"}, rule.segments...)
+	}
+	return rule, true
+}
+
+// matchSegments matches path parts against pattern parts. A "**" part matches
+// any number of directories; a trailing one needs at least one part, so
+// "dir/**" matches what is inside dir but not dir itself
+func matchSegments(pattern, parts []string) bool {
+	if len(pattern) == 0 {
+		return len(parts) == 0
+	}
+	if pattern[0] == "**" {
+		if len(pattern) == 1 {
+			return len(parts) > 0
+		}
+		for i := 0; i <= len(parts); i++ {
+			if matchSegments(pattern[1:], parts[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(parts) == 0 {
+		return false
+	}
+	if ok, err := path.Match(pattern[0], parts[0]); err != nil || !ok {
+		return false
+	}
+	return matchSegments(pattern[1:], parts[1:])
+}
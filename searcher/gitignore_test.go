@@ -0,0 +1,150 @@
+package searcher
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGitignoreMatching(t *testing.T) {
+	tests := []struct {
+		rules string
+		path  string
+		isDir bool
+		want  bool
+	}{
+		// Unanchored names match at any depth
+		{"node_modules", "node_modules", true, true},
+		{"node_modules", "web/app/node_modules", true, true},
+		{"*.log", "debug.log", false, true},
+		{"*.log", "logs/debug.log", false, true},
+		{"*.log", "debug.log.txt", false, false},
+		{"debug?.log", "debug1.log", false, true},
+		{"debug?.log", "debug10.log", false, false},
+		{"debug[0-9].log", "debug5.log", false, true},
+		{"debug[!0-9].log", "debug5.log", false, false},
+		{"debug[!0-9].log", "debuga.log", false, true},
+
+		// A leading or middle slash anchors the pattern to the .gitignore directory
+		{"/build", "build", true, true},
+		{"/build", "src/build", true, false},
+		{"doc/frotz", "doc/frotz", true, true},
+		{"doc/frotz", "a/doc/frotz", true, false},
+		{"logs/*.log", "logs/debug.log", false, true},
+		{"logs/*.log", "logs/2024/debug.log", false, false},
+
+		// A trailing slash only matches directories
+		{"dist/", "dist", true, true},
+		{"dist/", "dist", false, false},
+		{"dist/", "pkg/dist", true, true},
+
+		// Double asterisks
+		{"**/logs", "logs", true, true},
+		{"**/logs", "a/b/logs", true, true},
+		{"**/logs/debug.log", "build/logs/debug.log", false, true},
+		{"logs/**", "logs/a/b.log", false, true},
+		{"logs/**", "logs", true, false},
+		{"a/**/b", "a/b", true, true},
+		{"a/**/b", "a/x/y/b", true, true},
+		{"a/**/b", "a/x/c", true, false},
+		{"foo**bar", "fooxbar", false, true},
+
+		// Negation: the last matching rule wins
+		{"*.log\n!important.log", "important.log", false, false},
+		{"*.log\n!important.log", "trace.log", false, true},
+		{"!important.log\n*.log", "important.log", false, true},
+		{"logs/**\n!logs/keep.txt", "logs/keep.txt", false, false},
+
+		// Comments, blank lines and escapes
+		{"# comment\n\n", "# comment", false, false},
+		{`\#notes`, "#notes", false, true},
+		{`\!bang`, "!bang", false, true},
+		{"trailing.txt   ", "trailing.txt", false, true},
+		{`space\ `, "space ", false, true},
+	}
+
+	for _, tt := range tests {
+		g := &gitignore{rules: parseGitignore("", strings.NewReader(tt.rules))}
+		if got := g.ignored(filepath.FromSlash(tt.path), tt.isDir); got != tt.want {
+			t.Errorf("rules %q, path %q (dir %v): ignored = %v, want %v", tt.rules, tt.path, tt.isDir, got, tt.want)
+		}
+	}
+}
+
+func TestGitignoreNestedBase(t *testing.T) {
+	root := "repo"
+	g := &gitignore{rules: parseGitignore(root, strings.NewReader("*.tmp\n"))}
+	g.rules = append(g.rules, parseGitignore(filepath.Join(root, "web"), strings.NewReader("/dist\n!keep.tmp\n"))...)
+
+	tests := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"repo/a.tmp", false, true},
+		{"repo/web/keep.tmp", false, false}, // Re-included by the deeper file
+		{"repo/keep.tmp", false, true},      // The deeper file does not apply here
+		{"repo/web/dist", true, true},
+		{"repo/dist", true, false},
+		{"other/a.tmp", false, false},
+	}
+	for _, tt := range tests {
+		if got := g.ignored(filepath.FromSlash(tt.path), tt.isDir); got != tt.want {
+			t.Errorf("%s: ignored = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestScanner_RespectGitignore(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		".gitignore":              "generated/\n*.secret\n",
+		"app.env":                 "password=Hunter2Secret!\n",
+		"generated/lib/config.js": "password=Hunter3Secret!\n",
+		"debug.secret":            "password=Hunter4Secret!\n",
+		"web/.gitignore":          "!keep.secret\n",
+		"web/keep.secret":         "password=Hunter5Secret!\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(tmpDir, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(path), 0755)
+		os.WriteFile(path, []byte(content), 0644)
+	}
+
+	scanFiles := func(respect bool) (map[string]bool, *ScanResult) {
+		scanner := NewScanner()
+		scanner.SetRespectGitignore(respect)
+		result, err := scanner.Scan(tmpDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		found := make(map[string]bool)
+		for _, f := range result.Findings {
+			rel, _ := filepath.Rel(tmpDir, f.FilePath)
+			found[filepath.ToSlash(rel)] = true
+		}
+		return found, result
+	}
+
+	// Off by default: everything is scanned
+	if found, _ := scanFiles(false); !found["generated/lib/config.js"] || !found["debug.secret"] {
+		t.Errorf("without gitignore support all files should be scanned: %v", found)
+	}
+
+	found, result := scanFiles(true)
+	if !found["app.env"] || !found["web/keep.secret"] {
+		t.Errorf("files not ignored should be scanned: %v", found)
+	}
+	if found["generated/lib/config.js"] || found["debug.secret"] {
+		t.Errorf("gitignored files should be skipped: %v", found)
+	}
+	for _, name := range []string{"generated", "debug.secret"} {
+		if reason := result.SkipReasons[filepath.Join(tmpDir, name)]; reason.Text != "gitignore" {
+			t.Errorf("skip reason of %s = %q", name, reason)
+		}
+	}
+	if result.FilesSkipped < 2 {
+		t.Errorf("FilesSkipped = %d, want the ignored paths counted", result.FilesSkipped)
+	}
+}
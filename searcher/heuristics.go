@@ -0,0 +1,254 @@
+package searcher
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Many matches are references to a secret rather than the secret itself:
+// password = os.environ["DB_PASSWORD"], token: ${VAULT_TOKEN},
+// api_key = config.get("api_key") or secret = "<YOUR_SECRET_HERE>". The
+// heuristics below read the value a finding assigns, from the context line
+// as the pattern may stop at a quote or a bracket, and drop or downgrade
+// the finding when the value is clearly not a literal secret
+
+// HeuristicLevel sets how eagerly findings of references and placeholders
+// are filtered, see Scanner.SetHeuristicFiltering
+type HeuristicLevel string
+
+const (
+	HeuristicOff        HeuristicLevel = "off"        // Every match is reported
+	HeuristicBalanced   HeuristicLevel = "balanced"   // References and placeholders are dropped, variables lowered to Low
+	HeuristicAggressive HeuristicLevel = "aggressive" // Variables and test-looking values are dropped too
+)
+
+// ParseHeuristicLevel converts a level name from the command line
+func ParseHeuristicLevel(name string) (HeuristicLevel, error) {
+	switch level := HeuristicLevel(strings.ToLower(strings.TrimSpace(name))); level {
+	case HeuristicOff, HeuristicBalanced, HeuristicAggressive:
+		return level, nil
+	}
+	return "", fmt.Errorf("неизвестный уровень эвристик: %q (допустимо: off, balanced, aggressive)", name)
+}
+
+// MaxHeuristicNotes is how many decisions a result keeps in HeuristicNotes
+const MaxHeuristicNotes = 1000
+
+// HeuristicNote tells why the heuristics dropped or downgraded a finding
+type HeuristicNote struct {
+	FilePath    string
+	LineNumber  int
+	PatternType PatternType
+	Value       string // The value judged, masked
+	Reason      string
+	Dropped     bool // Dropped, or else lowered to Low
+}
+
+// valueKind is what the value of a finding turned out to be
+type valueKind int
+
+const (
+	valueLiteral       valueKind = iota
+	valueEmpty                   // Empty, null or a boolean
+	valueInterpolation           // ${VAR}, $VAR, {{ .Var }}, %s and the like
+	valueEnvLookup               // os.getenv, process.env, System.getenv
+	valuePlaceholder             // changeme, <YOUR_SECRET_HERE>, xxxx
+	valueIdentifier              // A variable of the source code
+	valueExpression              // A call or an index expression
+	valueTestData                // A literal that looks like test data
+)
+
+// valueKindReasons explain the decisions in HeuristicNote.Reason
+var valueKindReasons = map[valueKind]string{
+	valueEmpty:         "пустое значение, null или логическое значение",
+	valueInterpolation: "подстановка переменной (${...}, $VAR, {{ }}, %s)",
+	valueEnvLookup:     "значение читается из переменной окружения",
+	valuePlaceholder:   "заглушка вместо секрета",
+	valueIdentifier:    "имя переменной, а не строковый литерал",
+	valueExpression:    "значение вычисляется выражением или вызовом функции",
+	valueTestData:      "значение похоже на тестовые данные",
+}
+
+var (
+	// An assignment at the start of a match: a key, maybe quoted, and the operator
+	heuristicAssignment = regexp.MustCompile(`^(?:export\s+)?["'\x60]?[\p{L}\w.\-]*["'\x60\]]*\s*(:=|=>|==|=|:)\s*`)
+
+	// Interpolations of shells, templates, format strings and languages
+	interpolation = regexp.MustCompile(`\$\{[^}]*\}|\$\([^)]*\)|\$[A-Z_][A-Z0-9_]*|\{\{[^}]*\}\}|%\([A-Za-z_]\w*\)[sd]|%[-+ #0-9.]*[svdqxXfT]|#\{[^}]*\}|\{[A-Za-z_][\w.]*\}|\{\d*\}`)
+
+	// Reads of environment variables in common languages
+	envLookup = regexp.MustCompile(`^(os\.(getenv|Getenv|LookupEnv|environ)|process\.env|import\.meta\.env|System\.getenv|Environment\.GetEnvironmentVariable|ENV(\[|\.fetch)|getenv\s*\(|\$_ENV|\$_SERVER|env\s*\(|(std::)?env::var|Deno\.env)`)
+
+	// Values that stand for a secret, matched whole
+	placeholderText = regexp.MustCompile(`(?i)^(<[^>]*>|\[[^\]]*\]|\*{3,}|x{3,}|\.{3,}|-{3,}|_+|changeme|change[_-]?(me|it|this)|replace[_-]?(me|this)|todo|tbd|fixme|placeholder|example|sample|dummy|redacted|(your|my|insert|enter|put)[_-].*|.*[_-]here)$`)
+
+	// Parts of a literal that suggest test data
+	testDataText = regexp.MustCompile(`(?i)(example|sample|dummy|fake|placeholder|changeme|xxxx|12345|(^|[^a-z])test([^a-z]|$))`)
+
+	codeIdentifier = regexp.MustCompile(`^[A-Za-z_$][\w$]*(\.[A-Za-z_$][\w$]*)*$`)
+	codeExpression = regexp.MustCompile(`^(new\s+|await\s+)?[A-Za-z_$][\w$.]*\s*[(\[]`)
+)
+
+// emptyValues are literals of no value in configs and code
+var emptyValues = map[string]bool{
+	"null": true, "none": true, "nil": true, "undefined": true, "true": true, "false": true, `""`: true, "''": true,
+}
+
+// sourceCodeExtensions are languages where an unquoted value is a variable;
+// in configs, .env files and shell scripts it is a literal
+var sourceCodeExtensions = map[string]bool{
+	".go": true, ".py": true, ".js": true, ".jsx": true, ".mjs": true, ".cjs": true, ".ts": true, ".tsx": true,
+	".java": true, ".kt": true, ".kts": true, ".scala": true, ".groovy": true, ".rb": true, ".php": true,
+	".cs": true, ".swift": true, ".rs": true, ".c": true, ".h": true, ".cpp": true, ".hpp": true, ".dart": true,
+}
+
+// SetHeuristicFiltering sets how findings of references and placeholders
+// are filtered: HeuristicOff reports every match, HeuristicBalanced, the
+// default, drops references and placeholders and lowers variables to Low,
+// HeuristicAggressive drops variables and test-looking values as well.
+// Decisions are counted and explained in ScanResult.HeuristicNotes
+func (s *Scanner) SetHeuristicFiltering(level HeuristicLevel) {
+	s.heuristics = level
+}
+
+// applyHeuristics judges a finding by its value; it reports whether the
+// finding is dropped, a downgraded one is lowered to Low in place
+func (s *Scanner) applyHeuristics(f *Finding) bool {
+	// A bulk finding has no value of its own to judge
+	if s.heuristics == "" || s.heuristics == HeuristicOff || f.Source != "" || isBulkPattern(f.PatternType) {
+		return false
+	}
+	value, kind := classifyFindingValue(f, s.heuristics == HeuristicAggressive)
+	if kind == valueLiteral {
+		return false
+	}
+
+	drop := true
+	if (kind == valueIdentifier || kind == valueExpression) && s.heuristics == HeuristicBalanced {
+		drop = false
+		if f.Severity == Low {
+			return false
+		}
+		f.Severity = Low
+		// A variable may still hold a secret, it is only less likely to
+		f.RiskScore /= 2
+	}
+	s.result.AddHeuristicNote(HeuristicNote{
+		FilePath:    f.FilePath,
+		LineNumber:  f.LineNumber,
+		PatternType: f.PatternType,
+		Value:       MaskSecret(value),
+		Reason:      valueKindReasons[kind],
+		Dropped:     drop,
+	})
+	return drop
+}
+
+// classifyFindingValue returns the value a finding assigns and what it is.
+// Matches that assign nothing, such as a token, are judged by the matched
+// text alone
+func classifyFindingValue(f *Finding, aggressive bool) (string, valueKind) {
+	op := heuristicAssignment.FindStringSubmatch(f.MatchedText)
+	if op == nil || op[1] == "==" || len(op[0]) == len(f.MatchedText) && !spacedOperator(op) {
+		// An "=" ending the match with no space around it is the padding
+		// of a base64 token rather than an assignment
+		return f.MatchedText, classifyLiteral(f.MatchedText, aggressive)
+	}
+	rest := f.MatchedText[len(op[0]):]
+	// The context line shows the value past where the pattern stopped
+	if f.ByteStart >= 0 && f.ByteEnd <= len(f.Context) && f.ByteStart <= f.ByteEnd && f.Context[f.ByteStart:f.ByteEnd] == f.MatchedText {
+		rest = f.Context[f.ByteStart+len(op[0]):]
+	}
+	if op[1] == ":" && strings.HasPrefix(rest, "//") {
+		// The scheme of a URL, not a key
+		return f.MatchedText, classifyLiteral(f.MatchedText, aggressive)
+	}
+	return classifyAssigned(strings.TrimSpace(rest), f.FilePath, aggressive)
+}
+
+// spacedOperator reports whether an assignment operator is written the way
+// code writes it, as := or => or with spaces around
+func spacedOperator(op []string) bool {
+	return op[1] == ":=" || op[1] == "=>" || strings.TrimSpace(op[0]) != op[0] || strings.Contains(op[0], " "+op[1])
+}
+
+// classifyAssigned judges the right-hand side of an assignment
+func classifyAssigned(rest, path string, aggressive bool) (string, valueKind) {
+	if rest == "" {
+		return rest, valueEmpty
+	}
+	// Python string prefixes: f"{token}", r'...'
+	if len(rest) > 1 && strings.ContainsRune("fFrRbBuU", rune(rest[0])) && strings.ContainsRune(`"'`, rune(rest[1])) {
+		rest = rest[1:]
+	}
+	if quote := rest[0]; quote == '"' || quote == '\'' || quote == '`' {
+		literal := rest[1:]
+		if end := closingQuote(literal, quote); end >= 0 {
+			literal = literal[:end]
+		}
+		return literal, classifyLiteral(literal, aggressive)
+	}
+
+	if envLookup.MatchString(rest) {
+		return rest, valueEnvLookup
+	}
+	if strings.HasPrefix(rest, "$(") {
+		// Command substitution of a shell
+		return rest, valueInterpolation
+	}
+	token := rest
+	if end := strings.IndexAny(token, " \t;,"); end >= 0 {
+		token = token[:end]
+	}
+	if kind := classifyLiteral(token, aggressive); kind != valueLiteral {
+		return token, kind
+	}
+	token = strings.TrimRight(token, ")]}")
+	if sourceCodeExtensions[strings.ToLower(filepath.Ext(path))] {
+		if codeExpression.MatchString(rest) {
+			return token, valueExpression
+		}
+		if codeIdentifier.MatchString(token) {
+			return token, valueIdentifier
+		}
+	}
+	return token, valueLiteral
+}
+
+// classifyLiteral judges a value written as is
+func classifyLiteral(value string, aggressive bool) valueKind {
+	value = strings.TrimSpace(value)
+	switch {
+	case value == "" || emptyValues[strings.ToLower(value)]:
+		return valueEmpty
+	case isInterpolation(value):
+		return valueInterpolation
+	case placeholderText.MatchString(value):
+		return valuePlaceholder
+	case aggressive && testDataText.MatchString(value):
+		return valueTestData
+	}
+	return valueLiteral
+}
+
+// isInterpolation reports whether a value is made of interpolations, with
+// no run of four letters or digits between them that could be a secret
+func isInterpolation(value string) bool {
+	rest := interpolation.ReplaceAllString(value, " ")
+	if rest == value {
+		return false
+	}
+	run := 0
+	for _, r := range rest {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			if run++; run >= 4 {
+				return false
+			}
+		} else {
+			run = 0
+		}
+	}
+	return true
+}
@@ -0,0 +1,199 @@
+package searcher
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Default settings of the generic high-entropy detector
+const (
+	DefaultBase64EntropyThreshold = 4.3
+	DefaultHexEntropyThreshold    = 3.0
+	DefaultEntropyMinLength       = 20
+)
+
+// HighEntropyDetector reports random-looking strings that no specific
+// pattern matched, such as a token stored under an unusual key name. It is
+// noisy, so scanners only run it when asked to
+type HighEntropyDetector struct {
+	Base64Threshold float64  // Minimum entropy of base64-like tokens
+	HexThreshold    float64  // Minimum entropy of hex tokens
+	MinLength       int      // Shorter tokens are not checked
+	Severity        Severity // Severity of the findings
+
+	entropy *EntropyCalculator
+}
+
+// NewHighEntropyDetector creates a detector with the default thresholds
+func NewHighEntropyDetector() *HighEntropyDetector {
+	return &HighEntropyDetector{
+		Base64Threshold: DefaultBase64EntropyThreshold,
+		HexThreshold:    DefaultHexEntropyThreshold,
+		MinLength:       DefaultEntropyMinLength,
+		Severity:        Medium,
+		entropy:         NewEntropyCalculator(),
+	}
+}
+
+// Detect checks the quoted strings and base64 or hex words of a line
+func (d *HighEntropyDetector) Detect(line string) []*DetectedPattern {
+	var results []*DetectedPattern
+	for _, span := range entropyTokens(line, d.MinLength) {
+		token := line[span[0]:span[1]]
+		threshold := d.Base64Threshold
+		switch {
+		case isDigits(token):
+			// Numbers, dates and IDs; card numbers have their own pattern
+			continue
+		case isHexToken(token):
+			threshold = d.HexThreshold
+		case looksLikeWords(token):
+			continue
+		}
+		entropy := d.entropy.CalculateEntropy(token)
+		if entropy < threshold {
+			continue
+		}
+		results = append(results, &DetectedPattern{
+			Type:         PatternHighEntropy,
+			RuleName:     string(PatternHighEntropy),
+			Pattern:      fmt.Sprintf("entropy >= %.1f", threshold),
+			Severity:     d.Severity,
+			Description:  "High-entropy string detected",
+			StartIndex:   span[0],
+			EndIndex:     span[1],
+			MatchText:    token,
+			EntropyScore: entropy,
+		})
+	}
+	return results
+}
+
+// entropyTokens returns the spans of the candidate tokens of a line: the
+// content of quoted strings without whitespace, and runs of base64 and hex
+// characters elsewhere
+func entropyTokens(line string, minLength int) [][2]int {
+	var spans [][2]int
+	for i := 0; i < len(line); {
+		c := line[i]
+		if c == '"' || c == '\'' || c == '`' {
+			if end := strings.IndexByte(line[i+1:], c); end >= 0 {
+				content := line[i+1 : i+1+end]
+				// A quoted sentence is split into words below instead
+				if !strings.ContainsAny(content, " \t") {
+					if len(content) >= minLength {
+						spans = append(spans, [2]int{i + 1, i + 1 + end})
+					}
+					i += end + 2
+					continue
+				}
+			}
+			i++
+			continue
+		}
+		if !isTokenChar(c) {
+			i++
+			continue
+		}
+		start := i
+		for i < len(line) && isTokenChar(line[i]) {
+			i++
+		}
+		if i-start >= minLength {
+			spans = append(spans, [2]int{start, i})
+		}
+	}
+	return spans
+}
+
+// isTokenChar reports whether c belongs to the base64, base64url or hex alphabet
+func isTokenChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+		c == '+' || c == '/' || c == '=' || c == '_' || c == '-'
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// isHexToken reports whether s is hex with both digits and letters
+func isHexToken(s string) bool {
+	digits, letters := false, false
+	for i := 0; i < len(s); i++ {
+		c := s[i] | 0x20 // Lower case letters, digits are unchanged
+		switch {
+		case s[i] >= '0' && s[i] <= '9':
+			digits = true
+		case c >= 'a' && c <= 'f':
+			letters = true
+		default:
+			return false
+		}
+	}
+	return digits && letters
+}
+
+// looksLikeWords reports whether at least half of the letters of s belong
+// to common words, as in identifiers, paths and lorem ipsum text
+func looksLikeWords(s string) bool {
+	letters, known := 0, 0
+	for _, word := range splitWords(s) {
+		letters += len(word)
+		if len(word) >= 3 && commonWords[strings.ToLower(word)] {
+			known += len(word)
+		}
+	}
+	return letters > 0 && known*2 >= letters
+}
+
+// splitWords splits s into runs of ASCII letters, also at camelCase humps
+func splitWords(s string) []string {
+	var words []string
+	start := -1
+	for i := 0; i <= len(s); i++ {
+		isLetter := i < len(s) && (s[i] >= 'a' && s[i] <= 'z' || s[i] >= 'A' && s[i] <= 'Z')
+		hump := isLetter && start >= 0 && s[i] >= 'A' && s[i] <= 'Z' && s[i-1] >= 'a' && s[i-1] <= 'z'
+		if start >= 0 && (!isLetter || hump) {
+			words = append(words, s[start:i])
+			start = -1
+		}
+		if isLetter && start < 0 {
+			start = i
+		}
+	}
+	return words
+}
+
+// commonWords are the words looksLikeWords recognizes: frequent English and
+// programming words and the lorem ipsum vocabulary
+var commonWords = func() map[string]bool {
+	words := strings.Fields(`
+		the and for are but not you all any can had her was one our out day get has him his how
+		man new now old see two way who boy did its let put say she too use that with have this
+		will your from they know want been good much some time very when come here just like long
+		make many more only over such take than them well were what also back after first work
+		value name type file path user data test example default config setting settings server
+		client service request response error message string number list item items index count
+		size length width height start end begin next prev last min max true false null none
+		http https www com org net html json yaml xml api url uri host port local localhost
+		usr bin lib var etc tmp home src dist build vendor node modules python java script
+		public private static class interface function return import export module package
+		get set add remove update delete create read write open close load save find search
+		enable enabled disable disabled option options version release debug info warn warning
+		image images text content header footer body title description label button input
+		lorem ipsum dolor sit amet consectetur adipiscing elit sed eiusmod tempor incididunt
+		labore dolore magna aliqua enim minim veniam quis nostrud exercitation ullamco laboris
+		nisi aliquip commodo consequat duis aute irure reprehenderit voluptate velit esse cillum
+		fugiat nulla pariatur excepteur sint occaecat cupidatat non proident sunt culpa qui
+		officia deserunt mollit anim est laborum`)
+	set := make(map[string]bool, len(words))
+	for _, w := range words {
+		set[w] = true
+	}
+	return set
+}()
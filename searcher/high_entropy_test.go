@@ -0,0 +1,93 @@
+package searcher
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const randomToken = "q8Zr2LxV9pT4mW7kN1bY6cH3jF5dS0gA-eR_uI8o"
+
+func TestHighEntropyDetector(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want string // Reported token, "" for none
+	}{
+		{"quoted token", `cfg_val: "` + randomToken + `"`, randomToken},
+		{"bare token", "X_VAL=" + randomToken, "X_VAL=" + randomToken},
+		{"hex digest", "checksum 3f786850e387550fdab836ed7e6dc881de23001b", "3f786850e387550fdab836ed7e6dc881de23001b"},
+		{"quoted with punctuation", `seed = 'xK9#mQ2$vL7!pR4&nT8*wZ3^bY6'`, "xK9#mQ2$vL7!pR4&nT8*wZ3^bY6"},
+		{"short token", `key = "xK9mQ2vL7p"`, ""},
+		{"low entropy", "padding aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", ""},
+		{"digits", "order 12345678901234567890123", ""},
+		{"lorem ipsum", `text: "Lorem-ipsum-dolor-sit-amet-consectetur-adipiscing"`, ""},
+		{"sentence", `msg = "Lorem ipsum dolor sit amet, consectetur adipiscing elit"`, ""},
+		{"identifier", "getDefaultConfigurationSettingsValueForServer()", ""},
+		{"path", "/usr/local/lib/python3/site-packages/module", ""},
+	}
+
+	d := NewHighEntropyDetector()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			found := d.Detect(tt.line)
+			if tt.want == "" {
+				if len(found) != 0 {
+					t.Errorf("expected nothing, got %q (entropy %.2f)", found[0].MatchText, found[0].EntropyScore)
+				}
+				return
+			}
+			if len(found) != 1 || found[0].MatchText != tt.want {
+				t.Fatalf("found %v, want %q", found, tt.want)
+			}
+			if got := tt.line[found[0].StartIndex:found[0].EndIndex]; got != tt.want {
+				t.Errorf("span covers %q", got)
+			}
+			if found[0].Type != PatternHighEntropy || found[0].Severity != Medium {
+				t.Errorf("type %s, severity %s", found[0].Type, found[0].Severity)
+			}
+		})
+	}
+
+	// A stricter threshold drops the token
+	d.Base64Threshold = 5.5
+	if found := d.Detect("X_VAL=" + randomToken); len(found) != 0 {
+		t.Errorf("threshold 5.5 should drop the token, got %v", found)
+	}
+}
+
+func TestScanner_DetectHighEntropy(t *testing.T) {
+	dir := t.TempDir()
+	content := "unusual_name = \"" + randomToken + "\"\npassword = \"" + randomToken + "\"\n"
+	os.WriteFile(filepath.Join(dir, "app.conf"), []byte(content), 0644)
+
+	scan := func(enabled bool) map[int][]PatternType {
+		scanner := NewScanner()
+		scanner.SetDetectHighEntropy(enabled)
+		result, err := scanner.Scan(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines := make(map[int][]PatternType)
+		for _, f := range result.Findings {
+			lines[f.LineNumber] = append(lines[f.LineNumber], f.PatternType)
+		}
+		return lines
+	}
+
+	if lines := scan(false); len(lines[1]) != 0 {
+		t.Errorf("high-entropy detection should be off by default, got %v", lines)
+	}
+	lines := scan(true)
+	if len(lines[1]) != 1 || lines[1][0] != PatternHighEntropy {
+		t.Errorf("line 1 = %v, want a high_entropy finding", lines[1])
+	}
+	for _, typ := range lines[2] {
+		if typ == PatternHighEntropy {
+			t.Errorf("line 2 is matched by a specific pattern and should not be reported again: %v", lines[2])
+		}
+	}
+	if len(lines[2]) == 0 {
+		t.Error("the password on line 2 should still be found")
+	}
+}
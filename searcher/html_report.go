@@ -0,0 +1,178 @@
+package searcher
+
+import (
+	_ "embed"
+	"html/template"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/kacebover/password-finder/locale"
+)
+
+// HTML report: a single self-contained page without external requests, so
+// it can be mailed or opened offline. Matched text is masked, and with
+// SetIncludeSecrets revealed by a click; the context shows the match masked
+// either way
+
+//go:embed templates/report.html
+var htmlReportTemplate string
+
+// htmlReport is cloned for every report with T looking up the messages of
+// its locale
+var htmlReport = template.Must(template.New("report").Funcs(template.FuncMap{
+	"T": locale.New(locale.Default).T,
+}).Parse(htmlReportTemplate))
+
+// htmlReportData is the data of templates/report.html
+type htmlReportData struct {
+	Lang        locale.Lang
+	Root        string
+	GeneratedAt string
+	Duration    int64
+	Metadata    ReportMetadata
+	Summary     ReportSummary
+	Severities  []htmlSeverityCount
+	Files       []htmlFile
+}
+
+// htmlSeverityCount is a summary card and a filter checkbox
+type htmlSeverityCount struct {
+	Severity Severity
+	Label    string
+	Count    int
+}
+
+// htmlFile groups the findings of one file
+type htmlFile struct {
+	Path      string
+	Truncated string // LocalizeTruncation of a file matching stopped in
+	Findings  []htmlFinding
+	maxRisk   float64
+}
+
+// htmlFinding is one table row
+type htmlFinding struct {
+	Line          int
+	Page          string // LocalizedPageLabel of PDF findings
+	Severity      Severity
+	SeverityLabel string
+	Rank          int // Severity score, for sorting
+	Type          string
+	Risk          float64
+	BaseRisk      float64 // Risk before the path factor, 0 when the path changed nothing
+	GitStatus     string
+	Description   string
+	KeyPath       string // Key of the value in a JSON, YAML or .env file
+	Masked        string
+	Secret        string
+	Context       string
+	Before        []string // Surrounding lines, shown around the highlighted Context
+	After         []string
+	Explanation   string // Of LocalAnalyzer.ExplainFinding
+}
+
+// ExportHTML exports findings to a self-contained HTML file
+func (rg *ReportGenerator) ExportHTML(filePath string) error {
+	return writeFileAtomic(filePath, reportFileMode, rg.writeHTML)
+}
+
+// writeHTML writes the HTML report to w
+func (rg *ReportGenerator) writeHTML(w io.Writer) error {
+	l := rg.locale
+	summary := rg.generateSummary()
+	data := htmlReportData{
+		Lang:        l.Lang(),
+		Root:        rg.result.Root,
+		GeneratedAt: time.Now().Format("02.01.2006 15:04:05"),
+		Duration:    rg.result.EndTime - rg.result.StartTime,
+		Metadata:    rg.generateMetadata(),
+		Summary:     summary,
+		Severities: []htmlSeverityCount{
+			{Critical, l.T("html.critical"), summary.CriticalFindings},
+			{High, l.T("html.high"), summary.HighFindings},
+			{Medium, l.T("html.medium"), summary.MediumFindings},
+			{Low, l.T("html.low"), summary.LowFindings},
+		},
+		Files: rg.htmlFiles(),
+	}
+	report, err := htmlReport.Clone()
+	if err != nil {
+		return err
+	}
+	return report.Funcs(template.FuncMap{"T": l.T}).Execute(w, data)
+}
+
+// htmlFiles groups findings by file, riskiest files first and findings in
+// line order
+func (rg *ReportGenerator) htmlFiles() []htmlFile {
+	index := make(map[string]int)
+	var files []htmlFile
+	rg.result.ForEachFinding(func(finding *Finding) bool {
+		path := rg.relativePath(finding.FilePath)
+		if finding.ArchivePath != "" {
+			path = FormatArchivePath(rg.relativePath(finding.ArchivePath), finding.InnerPath)
+		}
+		i, ok := index[path]
+		if !ok {
+			i = len(files)
+			index[path] = i
+			file := htmlFile{Path: path}
+			if n, truncated := rg.result.truncatedCount(finding.FilePath); truncated {
+				file.Truncated = LocalizeTruncation(rg.locale, n)
+			}
+			files = append(files, file)
+		}
+		file := &files[i]
+		file.Findings = append(file.Findings, rg.newHTMLFinding(finding))
+		if finding.RiskScore > file.maxRisk {
+			file.maxRisk = finding.RiskScore
+		}
+		return true
+	})
+
+	sort.SliceStable(files, func(i, j int) bool {
+		if files[i].maxRisk != files[j].maxRisk {
+			return files[i].maxRisk > files[j].maxRisk
+		}
+		return files[i].Path < files[j].Path
+	})
+	for _, file := range files {
+		sort.SliceStable(file.Findings, func(i, j int) bool { return file.Findings[i].Line < file.Findings[j].Line })
+	}
+	return files
+}
+
+// newHTMLFinding shows the match masked; with secrets included it is revealed
+// by a click, the context stays masked either way
+func (rg *ReportGenerator) newHTMLFinding(f *Finding) htmlFinding {
+	secret := ""
+	if rg.includeSecrets {
+		secret = f.MatchedText
+	}
+	f = rg.reportFinding(f)
+	maskMatch(f)
+	baseRisk := 0.0
+	if f.PathAdjusted() {
+		baseRisk = f.BaseRiskScore
+	}
+	return htmlFinding{
+		Line:          f.LineNumber,
+		Page:          f.LocalizedPageLabel(rg.locale),
+		Severity:      f.Severity,
+		SeverityLabel: rg.locale.Severity(string(f.Severity)),
+		Rank:          f.Severity.Score(),
+		Type:          rg.locale.Pattern(string(f.PatternType)),
+		Risk:          f.RiskScore,
+		BaseRisk:      baseRisk,
+		GitStatus:     LocalizeGitStatus(rg.locale, f.GitStatus),
+		Description:   LocalizeDescription(rg.locale, f.Description),
+		KeyPath:       f.KeyPath,
+		Masked:        f.MatchedText,
+		Secret:        secret,
+		Context:       truncateText(f.Context),
+		Before:        f.ContextBefore,
+		After:         f.ContextAfter,
+		Explanation:   f.Explanation,
+	}
+}
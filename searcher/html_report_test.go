@@ -0,0 +1,142 @@
+package searcher
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+// htmlAttr returns the value of an attribute of n
+func htmlAttr(n *html.Node, key string) string {
+	for _, attr := range n.Attr {
+		if attr.Key == key {
+			return attr.Val
+		}
+	}
+	return ""
+}
+
+// htmlNodes returns the elements of the document matching match
+func htmlNodes(root *html.Node, match func(*html.Node) bool) []*html.Node {
+	var nodes []*html.Node
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && match(n) {
+			nodes = append(nodes, n)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(root)
+	return nodes
+}
+
+func TestExportHTML(t *testing.T) {
+	result := statisticsFixture()
+	secret := `ghp_<script>alert(1)</script>0123456789`
+	result.AddFinding(&Finding{
+		FilePath: "/app/config/prod.env", LineNumber: 3, Severity: Critical, RiskScore: 90,
+		PatternType: PatternGitHubToken, Description: "GitHub Token detected",
+		MatchedText: secret, Context: "GITHUB_TOKEN=" + secret,
+	})
+	result.Root = "/app"
+
+	path := filepath.Join(t.TempDir(), "report.html")
+	if err := NewReportGenerator(result).ExportHTML(path); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, err := html.Parse(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rows := htmlNodes(doc, func(n *html.Node) bool { return n.Data == "tr" && htmlAttr(n, "class") == "finding" })
+	if len(rows) != len(result.Findings) {
+		t.Errorf("table has %d finding rows, want %d", len(rows), len(result.Findings))
+	}
+	groups := htmlNodes(doc, func(n *html.Node) bool { return n.Data == "tbody" })
+	if len(groups) != 7 {
+		t.Errorf("findings should be grouped into 7 files, got %d groups", len(groups))
+	}
+
+	// Self-contained: no scripts, styles or images loaded from elsewhere
+	external := htmlNodes(doc, func(n *html.Node) bool { return htmlAttr(n, "src") != "" || htmlAttr(n, "href") != "" })
+	if len(external) > 0 {
+		t.Errorf("report references external resources: %v", external[0].Attr)
+	}
+
+	// The secret is masked in the page and not kept anywhere in it
+	if bytes.Contains(data, []byte("<script>alert")) {
+		t.Error("matched text must be escaped")
+	}
+	if bytes.Contains(data, []byte("0123456789")) {
+		t.Error("the report contains the secret")
+	}
+	for _, pre := range htmlNodes(doc, func(n *html.Node) bool { return n.Data == "pre" }) {
+		if pre.FirstChild != nil && strings.Contains(pre.FirstChild.Data, secret) {
+			t.Errorf("context shows the secret unmasked: %q", pre.FirstChild.Data)
+		}
+	}
+
+	for _, want := range []string{"config/prod.env", "Критический", "GitHub токен"} {
+		if !bytes.Contains(data, []byte(want)) {
+			t.Errorf("report missing %q", want)
+		}
+	}
+}
+
+func TestExportHTMLIncludeSecrets(t *testing.T) {
+	result := NewScanResult()
+	secret := `ghp_<script>alert(1)</script>0123456789`
+	result.AddFinding(&Finding{
+		FilePath: "/app/prod.env", LineNumber: 3, Severity: Critical, PatternType: PatternGitHubToken,
+		MatchedText: secret, Context: "GITHUB_TOKEN=" + secret,
+	})
+
+	var buf bytes.Buffer
+	generator := NewReportGenerator(result)
+	generator.SetIncludeSecrets(true)
+	if err := generator.writeHTML(&buf); err != nil {
+		t.Fatal(err)
+	}
+	doc, err := html.Parse(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The secret is shown masked and kept for click-to-reveal
+	var revealable bool
+	for _, code := range htmlNodes(doc, func(n *html.Node) bool { return n.Data == "code" }) {
+		if htmlAttr(code, "data-secret") == secret {
+			revealable = code.FirstChild != nil && code.FirstChild.Data == MaskSecret(secret)
+		}
+	}
+	if !revealable {
+		t.Error("the secret should be shown masked with the original in data-secret")
+	}
+	for _, pre := range htmlNodes(doc, func(n *html.Node) bool { return n.Data == "pre" }) {
+		if pre.FirstChild != nil && strings.Contains(pre.FirstChild.Data, secret) {
+			t.Errorf("context shows the secret unmasked: %q", pre.FirstChild.Data)
+		}
+	}
+}
+
+func TestExportHTMLEmpty(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.html")
+	if err := NewReportGenerator(NewScanResult()).ExportHTML(path); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	if !bytes.Contains(data, []byte("Находок нет")) || bytes.Contains(data, []byte(`class="finding"`)) {
+		t.Errorf("empty report:\n%s", data)
+	}
+}
@@ -0,0 +1,116 @@
+package searcher
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ruleValidators are the checks rules and packs can reference by name
+var ruleValidators = map[string]func(string) bool{
+	"luhn":        NewLuhnValidator().IsValid,
+	"card":        ValidCardNumber,
+	"iban":        ValidIBAN,
+	"bic":         ValidBIC,
+	"icd10":       ValidICD10,
+	"oms":         ValidOMS,
+	"snils":       ValidSNILS,
+	"inn":         ValidINN,
+	"ru_passport": ValidRuPassport,
+}
+
+// ValidatorNames returns the names accepted in a rule's "validate" field
+func ValidatorNames() []string {
+	names := make([]string, 0, len(ruleValidators))
+	for name := range ruleValidators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// digitsOnly strips spaces and dashes, returning "" if anything else remains
+func digitsOnly(s string) string {
+	var sb strings.Builder
+	for _, c := range s {
+		switch {
+		case c >= '0' && c <= '9':
+			sb.WriteRune(c)
+		case c == ' ' || c == '-':
+		default:
+			return ""
+		}
+	}
+	return sb.String()
+}
+
+var icd10Format = regexp.MustCompile(`^([A-Z])(\d{2})(?:\.(\d{1,2}))?$`)
+
+// ValidICD10 checks the format of an ICD-10 diagnosis code such as "J45" or
+// "E11.9". Chapter U is reserved and only U00-U85 are assigned.
+func ValidICD10(code string) bool {
+	m := icd10Format.FindStringSubmatch(code)
+	if m == nil {
+		return false
+	}
+	if m[1] == "U" && m[2] > "85" {
+		return false
+	}
+	return true
+}
+
+// ValidOMS checks the control digit of a 16-digit ОМС policy number.
+// Digits in odd positions from the right (excluding the control digit)
+// form a number that is doubled; the digits of the result and the
+// remaining digits are summed and the control digit completes the sum
+// to a multiple of 10.
+func ValidOMS(number string) bool {
+	digits := digitsOnly(number)
+	if len(digits) != 16 {
+		return false
+	}
+
+	body := digits[:15]
+	var odd []byte
+	sum := 0
+	for i := len(body) - 1; i >= 0; i-- {
+		if (len(body)-1-i)%2 == 0 {
+			odd = append(odd, body[i])
+		} else {
+			sum += int(body[i] - '0')
+		}
+	}
+
+	// Double the odd-position number digit by digit, keeping the carry
+	carry := 0
+	for i := 0; i < len(odd); i++ {
+		d := int(odd[i]-'0')*2 + carry
+		sum += d % 10
+		carry = d / 10
+	}
+	sum += carry
+
+	return int(digits[15]-'0') == (10-sum%10)%10
+}
+
+// ValidSNILS checks the control number of a СНИЛС ("112-233-445 95").
+// Numbers up to 001-001-998 were issued without a control number.
+func ValidSNILS(number string) bool {
+	digits := digitsOnly(number)
+	if len(digits) != 11 {
+		return false
+	}
+	if digits[:9] <= "001001998" {
+		return true
+	}
+
+	sum := 0
+	for i := 0; i < 9; i++ {
+		sum += int(digits[i]-'0') * (9 - i)
+	}
+	control := sum % 101
+	if control == 100 {
+		control = 0
+	}
+	return digits[9:] == string([]byte{byte('0' + control/10), byte('0' + control%10)})
+}
@@ -8,6 +8,13 @@ import (
 	"strings"
 )
 
+// Extensions that are skipped unless the matching scan option is enabled
+var (
+	documentExtensions = []string{".pdf", ".docx", ".doc", ".xlsx", ".xls", ".pptx", ".ppt", ".odt", ".ods", ".odp", ".rtf", ".eml", ".msg"}
+	imageExtensions    = []string{".jpg", ".jpeg", ".png", ".gif", ".bmp", ".tiff", ".tif", ".webp"}
+	archiveExtensions  = []string{".zip", ".tar", ".gz", ".tgz", ".rar", ".7z", ".bz2", ".xz"}
+)
+
 // IgnoreList manages file/directory exclusions and pattern whitelisting
 type IgnoreList struct {
 	ignorePatterns   []*regexp.Regexp
@@ -106,24 +113,29 @@ func (il *IgnoreList) RemoveIgnoreExtension(ext string) {
 
 // EnableDocumentScanning removes document extensions from ignore list
 func (il *IgnoreList) EnableDocumentScanning() {
-	docExts := []string{".pdf", ".docx", ".doc", ".xlsx", ".xls", ".pptx", ".ppt", ".odt", ".ods", ".odp"}
-	for _, ext := range docExts {
+	for _, ext := range documentExtensions {
 		il.RemoveIgnoreExtension(ext)
 	}
 }
 
 // EnableImageScanning removes image extensions from ignore list
 func (il *IgnoreList) EnableImageScanning() {
-	imageExts := []string{".jpg", ".jpeg", ".png", ".gif", ".bmp", ".tiff", ".tif", ".webp"}
-	for _, ext := range imageExts {
+	for _, ext := range imageExtensions {
+		il.RemoveIgnoreExtension(ext)
+	}
+}
+
+// EnableImageMetadata removes the images whose metadata is read from the
+// ignore list
+func (il *IgnoreList) EnableImageMetadata() {
+	for ext := range imageMetadataFormats {
 		il.RemoveIgnoreExtension(ext)
 	}
 }
 
 // EnableArchiveScanning removes archive extensions from ignore list
 func (il *IgnoreList) EnableArchiveScanning() {
-	archiveExts := []string{".zip", ".tar", ".gz", ".tgz", ".rar", ".7z", ".bz2", ".xz"}
-	for _, ext := range archiveExts {
+	for _, ext := range archiveExtensions {
 		il.RemoveIgnoreExtension(ext)
 	}
 }
@@ -13,6 +13,8 @@ import (
 	"regexp"
 	"strings"
 	"unicode"
+
+	"github.com/kacebover/password-finder/fsutil"
 )
 
 // ImageAnalyzer performs multi-signal document detection in images
@@ -22,6 +24,9 @@ type ImageAnalyzer struct {
 	mrzPatterns   []*regexp.Regexp
 	keywordScores map[string]int
 	docTypeScores map[string]int
+	packKeywords  map[string]string // Pack keyword -> document type it indicates
+	docTitles     map[string]string // Titles of document types added by packs
+	faceDetection bool              // Look for the holder's photo
 }
 
 // TessClient interface for Tesseract operations (allows mocking)
@@ -47,6 +52,9 @@ type ImageAnalysisResult struct {
 	MRZData       *MRZData `json:"mrz_data,omitempty"`
 	Keywords      []string `json:"keywords,omitempty"`
 
+	// Barcodes decoded from the image
+	Barcodes []Barcode `json:"barcodes,omitempty"`
+
 	// Metadata
 	ImageWidth  int     `json:"image_width"`
 	ImageHeight int     `json:"image_height"`
@@ -68,6 +76,9 @@ type DetectionSignals struct {
 	BarcodeScore float64 `json:"barcode_score"`
 	BarcodeType  string  `json:"barcode_type,omitempty"`
 
+	// The barcode was decoded, not guessed from the OCR text
+	BarcodeDecoded bool `json:"barcode_decoded,omitempty"`
+
 	// Face detection (0-15 points)
 	FaceScore            float64 `json:"face_score"`
 	FaceDetected         bool    `json:"face_detected"`
@@ -157,10 +168,17 @@ func NewImageAnalyzer(ocrEnabled bool) *ImageAnalyzer {
 		mrzPatterns:   compileMRZPatterns(),
 		keywordScores: initKeywordScores(),
 		docTypeScores: initDocTypeScores(),
+		faceDetection: ocrEnabled,
 	}
 	return ia
 }
 
+// SetFaceDetection enables or disables looking for faces; it is on by
+// default when OCR is enabled
+func (ia *ImageAnalyzer) SetFaceDetection(enabled bool) {
+	ia.faceDetection = enabled
+}
+
 // compileMRZPatterns compiles regex patterns for MRZ detection
 func compileMRZPatterns() []*regexp.Regexp {
 	patterns := []string{
@@ -276,12 +294,16 @@ func (ia *ImageAnalyzer) AnalyzeImage(imagePath string) (*ImageAnalysisResult, e
 	rotations := []int{90, 180, 270}
 	bestResult := result
 	bestScore := result.FinalScore
+	decoded := result
 
 	for _, rotation := range rotations {
 		rotatedResult, err := ia.analyzeImageAtRotation(imagePath, rotation)
 		if err != nil {
 			continue // Skip failed rotations
 		}
+		if len(decoded.Barcodes) == 0 {
+			decoded = rotatedResult
+		}
 
 		if rotatedResult.FinalScore > bestScore {
 			bestScore = rotatedResult.FinalScore
@@ -295,6 +317,16 @@ func (ia *ImageAnalyzer) AnalyzeImage(imagePath string) (*ImageAnalysisResult, e
 		}
 	}
 
+	// Barcodes read only at another rotation, such as vertical Code 128
+	// next to upright text, are kept
+	if len(bestResult.Barcodes) == 0 && len(decoded.Barcodes) > 0 {
+		bestResult.Barcodes = decoded.Barcodes
+		bestResult.Signals.BarcodeScore = decoded.Signals.BarcodeScore
+		bestResult.Signals.BarcodeType = decoded.Signals.BarcodeType
+		bestResult.Signals.BarcodeDecoded = true
+		ia.calculateFinalScore(bestResult)
+	}
+
 	return bestResult, nil
 }
 
@@ -306,7 +338,7 @@ func (ia *ImageAnalyzer) analyzeImageAtRotation(imagePath string, rotation int)
 	}
 
 	// Load image
-	file, err := os.Open(imagePath)
+	file, err := fsutil.Open(imagePath)
 	if err != nil {
 		return nil, err
 	}
@@ -333,6 +365,14 @@ func (ia *ImageAnalyzer) analyzeImageAtRotation(imagePath string, rotation int)
 
 		// Analyze geometry (aspect ratio matching)
 		ia.analyzeGeometry(result)
+
+		// Barcodes are read at every rotation, like the text
+		ia.detectBarcodes(result, img)
+
+		// Faces are only found upright, so the rotations find turned photos
+		if ia.faceDetection {
+			ia.detectFace(result, img)
+		}
 	}
 
 	// OCR analysis (if enabled)
@@ -545,7 +585,7 @@ func (ia *ImageAnalyzer) extractTextFromImage(imagePath string) (string, error)
 // fallbackOCR provides basic text extraction without full OCR
 func (ia *ImageAnalyzer) fallbackOCR(imagePath string) (string, error) {
 	// Read file content and look for embedded text (PDFs, etc.)
-	content, err := os.ReadFile(imagePath)
+	content, err := fsutil.ReadFile(imagePath)
 	if err != nil {
 		return "", err
 	}
@@ -738,12 +778,53 @@ func (ia *ImageAnalyzer) detectKeywords(result *ImageAnalysisResult, textLower s
 			result.DocumentType = "snils"
 		} else if strings.Contains(textLower, "инн") {
 			result.DocumentType = "inn"
+		} else if docType := ia.packDocumentType(foundKeywords); docType != "" {
+			result.DocumentType = docType
 		} else if strings.Contains(textLower, "id") || strings.Contains(textLower, "identity") {
 			result.DocumentType = "id_card"
 		}
 	}
 }
 
+// AddPackKeywords adds a pack's OCR keywords to the keyword score, so
+// e.g. a scanned трудовая книжка is recognized as a document
+func (ia *ImageAnalyzer) AddPackKeywords(pack *Pack) {
+	if len(pack.Keywords) == 0 {
+		return
+	}
+	if ia.packKeywords == nil {
+		ia.packKeywords = make(map[string]string)
+		ia.docTitles = make(map[string]string)
+	}
+	for keyword, score := range pack.Keywords {
+		if score > ia.keywordScores[keyword] {
+			ia.keywordScores[keyword] = score
+		}
+		ia.packKeywords[keyword] = pack.DocumentType
+	}
+	if pack.DocumentTitle != "" {
+		ia.docTitles[pack.DocumentType] = pack.DocumentTitle
+	}
+}
+
+// packDocumentType returns the document type whose pack keywords scored
+// highest among the found keywords
+func (ia *ImageAnalyzer) packDocumentType(found []string) string {
+	scores := make(map[string]int)
+	best := ""
+	for _, keyword := range found {
+		docType, ok := ia.packKeywords[keyword]
+		if !ok {
+			continue
+		}
+		scores[docType] += ia.keywordScores[keyword]
+		if best == "" || scores[docType] > scores[best] || (scores[docType] == scores[best] && docType < best) {
+			best = docType
+		}
+	}
+	return best
+}
+
 // detectDatePatterns detects date patterns in text
 func (ia *ImageAnalyzer) detectDatePatterns(result *ImageAnalysisResult, text string) {
 	datePatterns := []string{
@@ -790,12 +871,55 @@ func (ia *ImageAnalyzer) detectNamePatterns(result *ImageAnalysisResult, text st
 	}
 }
 
+// detectBarcodes decodes the barcodes of an image and scores the one that
+// carries the most sensitive payload
+func (ia *ImageAnalyzer) detectBarcodes(result *ImageAnalysisResult, img image.Image) {
+	result.Barcodes = DecodeBarcodes(img)
+	for _, code := range result.Barcodes {
+		score := barcodeScore(code.Payload)
+		if score <= result.Signals.BarcodeScore {
+			continue
+		}
+		result.Signals.BarcodeScore = score
+		result.Signals.BarcodeType = code.Type
+		result.Signals.BarcodeDecoded = true
+		if parseAAMVA(code.Payload) != nil {
+			result.DocumentType = "driver_license"
+		}
+	}
+}
+
+// detectFace looks for the holder's photo: ID cards and passport pages put
+// it at the left or right edge, a fifth to a half of the height in size
+func (ia *ImageAnalyzer) detectFace(result *ImageAnalysisResult, img image.Image) {
+	faces := detectFaces(img)
+	if len(faces) == 0 {
+		return
+	}
+	result.Signals.FaceDetected = true
+	result.Signals.FaceScore = 6
+
+	face := faces[0]
+	centerX := float64(face.x) + float64(face.size)/2
+	width, height := float64(result.ImageWidth), float64(result.ImageHeight)
+	relative := float64(face.size) / math.Min(width, height)
+	if (centerX < width/3 || centerX > width*2/3) && relative >= 0.2 && relative <= 0.5 {
+		result.Signals.FaceInExpectedRegion = true
+		result.Signals.FaceScore = 15
+	}
+}
+
 // detectCodePatterns detects barcode/QR patterns in text
 func (ia *ImageAnalyzer) detectCodePatterns(result *ImageAnalysisResult, text string) {
+	// Decoded barcodes are not guessed from the text
+	if result.Signals.BarcodeDecoded {
+		return
+	}
+
 	// PDF417 often contains structured data
 	if strings.Contains(text, "ANSI ") || strings.Contains(text, "AAMVA") {
 		result.Signals.BarcodeScore = 20
-		result.Signals.BarcodeType = "PDF417"
+		result.Signals.BarcodeType = BarcodePDF417
 		return
 	}
 
@@ -830,13 +954,18 @@ func (ia *ImageAnalyzer) calculateFinalScore(result *ImageAnalysisResult) {
 	qualityNorm := (signals.QualityScore / 10) * 100
 
 	// If MRZ not found, redistribute weights to other signals
+	decodedLicense := signals.BarcodeDecoded && signals.BarcodeScore >= 20
 	weights := make(map[string]float64)
 	for k, v := range signalWeights {
 		weights[k] = v
 	}
 
 	// Adjust weights when MRZ is absent but other strong signals present
-	if signals.MRZScore == 0 {
+	if signals.MRZScore == 0 && decodedLicense {
+		// A decoded driver's license barcode takes the place of the MRZ
+		weights["barcode"] += weights["mrz"]
+		weights["mrz"] = 0
+	} else if signals.MRZScore == 0 {
 		// Redistribute MRZ weight to keywords, geometry, and structure
 		weights["keywords"] += 0.15  // More weight on keywords
 		weights["geometry"] += 0.10  // More weight on document shape
@@ -855,7 +984,7 @@ func (ia *ImageAnalyzer) calculateFinalScore(result *ImageAnalysisResult) {
 
 	// Require multiple signals for document detection (not just geometry)
 	// Must have keywords or MRZ or structure to be considered a document
-	hasTextSignals := signals.KeywordScore > 10 || signals.MRZScore > 0 || signals.StructureScore > 10
+	hasTextSignals := signals.KeywordScore > 10 || signals.MRZScore > 0 || signals.StructureScore > 10 || decodedLicense
 
 	// Determine confidence level
 	if result.FinalScore >= 65 && hasTextSignals {
@@ -905,6 +1034,9 @@ func (ia *ImageAnalyzer) GetDocumentTypeDescription(docType string) string {
 	if desc, ok := descriptions[docType]; ok {
 		return desc
 	}
+	if title, ok := ia.docTitles[docType]; ok {
+		return title
+	}
 	return "Документ"
 }
 
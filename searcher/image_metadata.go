@@ -0,0 +1,505 @@
+package searcher
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// Photos carry metadata nobody sees: EXIF of JPEG, PNG, TIFF and HEIC and
+// the XMP packet next to it. The place a photo was taken, the name of the
+// photographer or camera owner and the serial number of the camera are
+// read from it without decoding the image, so it needs no OCR
+
+// imageMetadataLimit bounds how much of an image is read for its metadata
+const imageMetadataLimit = 32 << 20
+
+// imageMetadataFormats are the images whose metadata is read
+var imageMetadataFormats = map[string]bool{
+	".jpg": true, ".jpeg": true, ".png": true, ".tif": true, ".tiff": true, ".heic": true, ".heif": true,
+}
+
+// metadataOnlyFormats are images that cannot be decoded here, so only their
+// metadata is scanned
+var metadataOnlyFormats = map[string]bool{".tif": true, ".heic": true, ".heif": true}
+
+// imageMetadata is what the metadata of an image tells about where and by
+// whom it was taken
+type imageMetadata struct {
+	hasGPS              bool
+	latitude, longitude float64
+	gpsField            string          // Where the coordinates were read, such as "EXIF GPSLatitude"
+	fields              []metadataField // Author, owner and serial number fields
+}
+
+// metadataField is a non-empty personal field of the metadata
+type metadataField struct {
+	name   string // Such as "EXIF Artist" or "XMP dc:creator"
+	value  string
+	serial bool // A serial number rather than a name
+}
+
+// EXIF tags read from the IFDs
+const (
+	tagArtist           = 0x013B
+	tagCopyright        = 0x8298
+	tagXPAuthor         = 0x9C9D
+	tagExifIFD          = 0x8769
+	tagGPSIFD           = 0x8825
+	tagCameraOwnerName  = 0xA430
+	tagBodySerialNumber = 0xA431
+	tagLensSerialNumber = 0xA435
+	tagGPSLatitudeRef   = 1
+	tagGPSLatitude      = 2
+	tagGPSLongitudeRef  = 3
+	tagGPSLongitude     = 4
+)
+
+// exifFieldNames names the personal fields of IFD0 and the Exif IFD
+var exifFieldNames = map[uint16]string{
+	tagArtist:           "Artist",
+	tagCopyright:        "Copyright",
+	tagXPAuthor:         "XPAuthor",
+	tagCameraOwnerName:  "CameraOwnerName",
+	tagBodySerialNumber: "BodySerialNumber",
+	tagLensSerialNumber: "LensSerialNumber",
+}
+
+// readImageMetadata reads the metadata of the image in r; the format is
+// told by the content, not the extension
+func readImageMetadata(r io.Reader) (*imageMetadata, error) {
+	data, err := io.ReadAll(io.LimitReader(r, imageMetadataLimit))
+	if err != nil {
+		return nil, err
+	}
+	return parseImageMetadata(data), nil
+}
+
+// parseImageMetadata parses the EXIF and XMP of a JPEG, PNG, TIFF or HEIF
+// image
+func parseImageMetadata(data []byte) *imageMetadata {
+	meta := &imageMetadata{}
+	header := data
+	switch {
+	case bytes.HasPrefix(data, []byte{0xFF, 0xD8}):
+		header = meta.readJPEG(data)
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		meta.readPNG(data)
+	case bytes.HasPrefix(data, []byte("II*\x00")) || bytes.HasPrefix(data, []byte("MM\x00*")):
+		meta.readTIFF(data)
+	case len(data) >= 12 && string(data[4:8]) == "ftyp":
+		// HEIF keeps EXIF as an item of its meta box, behind the
+		// "Exif\0\0" header; it is searched for rather than walking the boxes
+		if i := bytes.Index(data, []byte("Exif\x00\x00")); i >= 0 {
+			meta.readTIFF(data[i+6:])
+		}
+	default:
+		return meta
+	}
+	meta.readXMP(header)
+	return meta
+}
+
+// readJPEG reads the EXIF segment of a JPEG and returns the part before the
+// image data, where the XMP segment is
+func (m *imageMetadata) readJPEG(data []byte) []byte {
+	for pos := 2; pos+4 <= len(data); {
+		if data[pos] != 0xFF {
+			return data[:pos]
+		}
+		marker := data[pos+1]
+		if marker == 0xFF { // Fill byte
+			pos++
+			continue
+		}
+		if marker == 0xDA || marker == 0xD9 { // Start of scan, end of image
+			return data[:pos]
+		}
+		length := int(binary.BigEndian.Uint16(data[pos+2:]))
+		end := pos + 2 + length
+		if length < 2 || end > len(data) {
+			return data[:pos]
+		}
+		segment := data[pos+4 : end]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			m.readTIFF(segment[6:])
+		}
+		pos = end
+	}
+	return data
+}
+
+// readPNG reads the eXIf chunk of a PNG
+func (m *imageMetadata) readPNG(data []byte) {
+	for pos := 8; pos+8 <= len(data); {
+		length := int(binary.BigEndian.Uint32(data[pos:]))
+		kind := string(data[pos+4 : pos+8])
+		if length < 0 || pos+8+length > len(data) {
+			return
+		}
+		if kind == "eXIf" {
+			m.readTIFF(data[pos+8 : pos+8+length])
+		}
+		if kind == "IEND" {
+			return
+		}
+		pos += 12 + length
+	}
+}
+
+// tiffReader reads the IFDs of a TIFF structure, the form EXIF has in every
+// format
+type tiffReader struct {
+	data  []byte
+	order binary.ByteOrder
+}
+
+// tiffEntry is an entry of an IFD with its value bytes
+type tiffEntry struct {
+	tag   uint16
+	kind  uint16
+	count int
+	value []byte
+}
+
+// readTIFF reads the personal fields and coordinates of a TIFF structure
+func (m *imageMetadata) readTIFF(data []byte) {
+	if len(data) < 8 {
+		return
+	}
+	t := &tiffReader{data: data}
+	switch string(data[:2]) {
+	case "II":
+		t.order = binary.LittleEndian
+	case "MM":
+		t.order = binary.BigEndian
+	default:
+		return
+	}
+	if t.order.Uint16(data[2:]) != 42 {
+		return
+	}
+
+	for _, entry := range t.ifd(t.order.Uint32(data[4:])) {
+		switch entry.tag {
+		case tagExifIFD:
+			for _, sub := range t.ifd(t.offset(entry)) {
+				m.addExifField(sub)
+			}
+		case tagGPSIFD:
+			m.readGPS(t, t.ifd(t.offset(entry)))
+		default:
+			m.addExifField(entry)
+		}
+	}
+}
+
+// ifd returns the entries of the IFD at offset, nil when it is out of the
+// data
+func (t *tiffReader) ifd(offset uint32) []tiffEntry {
+	if offset == 0 || int64(offset)+2 > int64(len(t.data)) {
+		return nil
+	}
+	count := int(t.order.Uint16(t.data[offset:]))
+	var entries []tiffEntry
+	for i := 0; i < count; i++ {
+		pos := int(offset) + 2 + i*12
+		if pos+12 > len(t.data) {
+			break
+		}
+		entry := tiffEntry{
+			tag:   t.order.Uint16(t.data[pos:]),
+			kind:  t.order.Uint16(t.data[pos+2:]),
+			count: int(t.order.Uint32(t.data[pos+4:])),
+		}
+		size := tiffTypeSize(entry.kind) * entry.count
+		if size <= 0 || size > len(t.data) {
+			continue
+		}
+		if size <= 4 {
+			entry.value = t.data[pos+8 : pos+8+size]
+		} else {
+			start := int64(t.order.Uint32(t.data[pos+8:]))
+			if start+int64(size) > int64(len(t.data)) {
+				continue
+			}
+			entry.value = t.data[start : start+int64(size)]
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// tiffTypeSize is the size of one value of a TIFF field type
+func tiffTypeSize(kind uint16) int {
+	switch kind {
+	case 1, 2, 6, 7: // BYTE, ASCII, SBYTE, UNDEFINED
+		return 1
+	case 3, 8: // SHORT, SSHORT
+		return 2
+	case 4, 9: // LONG, SLONG
+		return 4
+	case 5, 10: // RATIONAL, SRATIONAL
+		return 8
+	}
+	return 0
+}
+
+// offset is the value of a LONG entry pointing to another IFD
+func (t *tiffReader) offset(entry tiffEntry) uint32 {
+	if entry.kind != 4 || len(entry.value) < 4 {
+		return 0
+	}
+	return t.order.Uint32(entry.value)
+}
+
+// addExifField keeps a non-empty personal field
+func (m *imageMetadata) addExifField(entry tiffEntry) {
+	name, ok := exifFieldNames[entry.tag]
+	if !ok {
+		return
+	}
+	var value string
+	if entry.tag == tagXPAuthor {
+		// Windows writes it as UTF-16LE whatever the byte order
+		units := make([]uint16, len(entry.value)/2)
+		for i := range units {
+			units[i] = binary.LittleEndian.Uint16(entry.value[2*i:])
+		}
+		value = strings.TrimRight(string(utf16.Decode(units)), "\x00")
+	} else {
+		// Copyright separates the photographer from the editor with a NUL
+		value = strings.Join(strings.FieldsFunc(string(entry.value), func(r rune) bool { return r == 0 }), " / ")
+	}
+	serial := entry.tag == tagBodySerialNumber || entry.tag == tagLensSerialNumber
+	m.addField("EXIF "+name, value, serial)
+}
+
+// readGPS reads the coordinates of the GPS IFD
+func (m *imageMetadata) readGPS(t *tiffReader, entries []tiffEntry) {
+	var lat, lon float64
+	var latRef, lonRef string
+	found := 0
+	for _, entry := range entries {
+		switch entry.tag {
+		case tagGPSLatitudeRef:
+			latRef = strings.TrimRight(string(entry.value), "\x00")
+		case tagGPSLongitudeRef:
+			lonRef = strings.TrimRight(string(entry.value), "\x00")
+		case tagGPSLatitude, tagGPSLongitude:
+			if entry.kind != 5 || entry.count != 3 {
+				continue
+			}
+			// Degrees, minutes and seconds as rationals
+			var degrees float64
+			for i, unit := range []float64{1, 60, 3600} {
+				num := t.order.Uint32(entry.value[8*i:])
+				den := t.order.Uint32(entry.value[8*i+4:])
+				if den != 0 {
+					degrees += float64(num) / float64(den) / unit
+				}
+			}
+			if entry.tag == tagGPSLatitude {
+				lat = degrees
+			} else {
+				lon = degrees
+			}
+			found++
+		}
+	}
+	if found < 2 {
+		return
+	}
+	if latRef == "S" {
+		lat = -lat
+	}
+	if lonRef == "W" {
+		lon = -lon
+	}
+	m.setGPS(lat, lon, "EXIF GPSLatitude, GPSLongitude")
+}
+
+// setGPS keeps the first plausible coordinates; zeros are what cameras
+// without a fix write
+func (m *imageMetadata) setGPS(lat, lon float64, field string) {
+	if m.hasGPS || math.Abs(lat) > 90 || math.Abs(lon) > 180 || (lat == 0 && lon == 0) {
+		return
+	}
+	m.hasGPS = true
+	m.latitude, m.longitude = lat, lon
+	m.gpsField = field
+}
+
+// addField keeps a personal field once, whichever of EXIF and XMP has it
+// first
+func (m *imageMetadata) addField(name, value string, serial bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return
+	}
+	for _, f := range m.fields {
+		if f.value == value && f.serial == serial {
+			return
+		}
+	}
+	m.fields = append(m.fields, metadataField{name: name, value: value, serial: serial})
+}
+
+// XMP properties, written as attributes or elements of the rdf:Description
+var (
+	xmpProperty = regexp.MustCompile(`(?s)\b((?:exif|exifEX|aux|photoshop):[A-Za-z]+)\s*=\s*"([^"]*)"|<((?:exif|exifEX|aux|photoshop):[A-Za-z]+)>([^<]*)</`)
+	xmpList     = regexp.MustCompile(`(?s)<(dc:creator|dc:rights)>(.*?)</(?:dc:creator|dc:rights)>`)
+	xmpListItem = regexp.MustCompile(`(?s)<rdf:li[^>]*>([^<]*)</rdf:li>`)
+	xmpGPS      = regexp.MustCompile(`^(\d+),(\d+(?:\.\d+)?)(?:,(\d+(?:\.\d+)?))?([NSEW])$`)
+)
+
+// xmpFields names the personal XMP properties, true for serial numbers
+var xmpFields = map[string]bool{
+	"exifEX:CameraOwnerName":  false,
+	"aux:OwnerName":           false,
+	"photoshop:Credit":        false,
+	"exifEX:BodySerialNumber": true,
+	"aux:SerialNumber":        true,
+	"exifEX:LensSerialNumber": true,
+	"aux:LensSerialNumber":    true,
+}
+
+// readXMP reads the XMP packet of an image, stored as plain text in every
+// format
+func (m *imageMetadata) readXMP(data []byte) {
+	start := bytes.Index(data, []byte("<x:xmpmeta"))
+	if start < 0 {
+		return
+	}
+	end := bytes.Index(data[start:], []byte("</x:xmpmeta>"))
+	if end < 0 {
+		return
+	}
+	packet := string(data[start : start+end])
+
+	for _, list := range xmpList.FindAllStringSubmatch(packet, -1) {
+		for _, item := range xmpListItem.FindAllStringSubmatch(list[2], -1) {
+			m.addField("XMP "+list[1], html.UnescapeString(item[1]), false)
+		}
+	}
+
+	var lat, lon string
+	for _, match := range xmpProperty.FindAllStringSubmatch(packet, -1) {
+		name, value := match[1]+match[3], html.UnescapeString(match[2]+match[4])
+		switch name {
+		case "exif:GPSLatitude":
+			lat = value
+		case "exif:GPSLongitude":
+			lon = value
+		default:
+			if serial, ok := xmpFields[name]; ok {
+				m.addField("XMP "+name, value, serial)
+			}
+		}
+	}
+	latitude, okLat := parseXMPCoordinate(lat)
+	longitude, okLon := parseXMPCoordinate(lon)
+	if okLat && okLon {
+		m.setGPS(latitude, longitude, "XMP exif:GPSLatitude, exif:GPSLongitude")
+	}
+}
+
+// parseXMPCoordinate parses the "DDD,MM.mmk" and "DDD,MM,SSk" coordinates
+// of XMP
+func parseXMPCoordinate(s string) (float64, bool) {
+	match := xmpGPS.FindStringSubmatch(strings.TrimSpace(s))
+	if match == nil {
+		return 0, false
+	}
+	degrees, _ := strconv.ParseFloat(match[1], 64)
+	minutes, _ := strconv.ParseFloat(match[2], 64)
+	seconds, _ := strconv.ParseFloat(match[3], 64)
+	value := degrees + minutes/60 + seconds/3600
+	if match[4] == "S" || match[4] == "W" {
+		value = -value
+	}
+	return value, true
+}
+
+// metadataFindings reports the coordinates and personal fields of image
+// metadata; the findings have no line
+func metadataFindings(filePath string, meta *imageMetadata) []*Finding {
+	var findings []*Finding
+	if meta.hasGPS {
+		findings = append(findings, &Finding{
+			FilePath:    filePath,
+			LineNumber:  0,
+			PatternType: PatternGeoMetadata,
+			Severity:    High,
+			Description: fmt.Sprintf("GPS-координаты в метаданных изображения: %.2f, %.2f", meta.latitude, meta.longitude),
+			MatchedText: fmt.Sprintf("%.6f, %.6f", meta.latitude, meta.longitude),
+			Context:     "Поле метаданных: " + meta.gpsField,
+			RiskScore:   65,
+		})
+	}
+	for _, field := range meta.fields {
+		finding := &Finding{
+			FilePath:    filePath,
+			LineNumber:  0,
+			PatternType: PatternImageMetadata,
+			Severity:    Medium,
+			Description: "Имя автора или владельца в метаданных изображения",
+			MatchedText: field.value,
+			Context:     "Поле метаданных: " + field.name,
+			RiskScore:   45,
+		}
+		if field.serial {
+			finding.Severity = Low
+			finding.Description = "Серийный номер камеры в метаданных изображения"
+			finding.RiskScore = 20
+		}
+		findings = append(findings, finding)
+	}
+	return findings
+}
+
+// scanImageMetadata adds the findings of the metadata of an image; it runs
+// whether or not the image itself can be scanned
+func (s *Scanner) scanImageMetadata(filePath string) (int, error) {
+	if !s.imageMetadata || !imageMetadataFormats[strings.ToLower(filepath.Ext(filePath))] {
+		return 0, nil
+	}
+	file, err := s.source.Open(filePath)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+	meta, err := readImageMetadata(file)
+	if err != nil {
+		return 0, err
+	}
+	return s.addFindings(filePath, metadataFindings(filePath, meta)), nil
+}
+
+// scanImageMetadataOnly scans the metadata of an image whose content is not
+// scanned for want of capability; without metadata to read the image is
+// skipped for it
+func (s *Scanner) scanImageMetadataOnly(filePath string, size int64, capability Capability, reason SkipReason) int {
+	if !s.imageMetadata || !imageMetadataFormats[strings.ToLower(filepath.Ext(filePath))] {
+		s.skipForCapability(filePath, capability, reason)
+		return 0
+	}
+	found, err := s.scanImageMetadata(filePath)
+	if err != nil {
+		s.fileError(filePath, StageRead, err)
+		return found
+	}
+	if capability != "" {
+		s.result.AddCapabilityGap(capability)
+	}
+	s.fileDone(filePath, size)
+	return found
+}
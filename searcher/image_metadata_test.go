@@ -0,0 +1,261 @@
+package searcher
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// testIFDEntry is an entry of a crafted EXIF IFD
+type testIFDEntry struct {
+	tag   uint16
+	kind  uint16
+	count uint32
+	value []byte
+}
+
+func asciiEntry(tag uint16, s string) testIFDEntry {
+	return testIFDEntry{tag, 2, uint32(len(s) + 1), append([]byte(s), 0)}
+}
+
+// buildEXIF lays out a TIFF structure of IFD0 pointing to the Exif and GPS
+// IFDs; gps entries are built with the byte order
+func buildEXIF(order binary.ByteOrder, ifd0, exif []testIFDEntry, gps func(binary.ByteOrder) []testIFDEntry) []byte {
+	buf := make([]byte, 8)
+	if order == binary.LittleEndian {
+		copy(buf, "II")
+	} else {
+		copy(buf, "MM")
+	}
+	order.PutUint16(buf[2:], 42)
+
+	writeIFD := func(entries []testIFDEntry) uint32 {
+		offset := len(buf)
+		data := offset + 2 + len(entries)*12 + 4
+		ifd := make([]byte, data-offset)
+		order.PutUint16(ifd, uint16(len(entries)))
+		var extra []byte
+		for i, e := range entries {
+			p := 2 + i*12
+			order.PutUint16(ifd[p:], e.tag)
+			order.PutUint16(ifd[p+2:], e.kind)
+			order.PutUint32(ifd[p+4:], e.count)
+			if len(e.value) <= 4 {
+				copy(ifd[p+8:], e.value)
+			} else {
+				order.PutUint32(ifd[p+8:], uint32(data+len(extra)))
+				extra = append(extra, e.value...)
+			}
+		}
+		buf = append(append(buf, ifd...), extra...)
+		return uint32(offset)
+	}
+	pointer := func(tag uint16, offset uint32) testIFDEntry {
+		value := make([]byte, 4)
+		order.PutUint32(value, offset)
+		return testIFDEntry{tag, 4, 1, value}
+	}
+
+	if exif != nil {
+		ifd0 = append(ifd0, pointer(tagExifIFD, writeIFD(exif)))
+	}
+	if gps != nil {
+		ifd0 = append(ifd0, pointer(tagGPSIFD, writeIFD(gps(order))))
+	}
+	offset := writeIFD(ifd0)
+	order.PutUint32(buf[4:], offset)
+	return buf
+}
+
+// gpsEntries writes coordinates as degrees, minutes and hundredths of
+// seconds
+func gpsEntries(latRef string, lat [3]uint32, lonRef string, lon [3]uint32) func(binary.ByteOrder) []testIFDEntry {
+	return func(order binary.ByteOrder) []testIFDEntry {
+		rationals := func(dms [3]uint32) []byte {
+			b := make([]byte, 24)
+			for i, den := range []uint32{1, 1, 100} {
+				order.PutUint32(b[8*i:], dms[i])
+				order.PutUint32(b[8*i+4:], den)
+			}
+			return b
+		}
+		return []testIFDEntry{
+			{tagGPSLatitudeRef, 2, 2, []byte(latRef + "\x00")},
+			{tagGPSLatitude, 5, 3, rationals(lat)},
+			{tagGPSLongitudeRef, 2, 2, []byte(lonRef + "\x00")},
+			{tagGPSLongitude, 5, 3, rationals(lon)},
+		}
+	}
+}
+
+// Red Square: 55°45'20.90"N 37°37'03.70"E
+var testMoscowGPS = gpsEntries("N", [3]uint32{55, 45, 2090}, "E", [3]uint32{37, 37, 370})
+
+// withJPEGSegment inserts an APPn segment after the SOI of a JPEG
+func withJPEGSegment(jpg []byte, marker byte, payload []byte) []byte {
+	segment := []byte{0xFF, marker, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+	out := append([]byte{}, jpg[:2]...)
+	out = append(append(out, segment...), payload...)
+	return append(out, jpg[2:]...)
+}
+
+func testJPEG(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, 16, 16)), nil); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// withPNGChunk inserts a chunk before the IEND of a PNG
+func withPNGChunk(t *testing.T, kind string, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 16, 16))); err != nil {
+		t.Fatal(err)
+	}
+	encoded := buf.Bytes()
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(len(data)))
+	chunk = append(append(chunk, kind...), data...)
+	chunk = binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+	iend := len(encoded) - 12
+	return append(append(append([]byte{}, encoded[:iend]...), chunk...), encoded[iend:]...)
+}
+
+const testXMP = `<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">
+<rdf:Description rdf:about="" xmlns:exif="http://ns.adobe.com/exif/1.0/" xmlns:aux="http://ns.adobe.com/exif/1.0/aux/"
+ exif:GPSLatitude="59,56.3520N" exif:GPSLongitude="30,18.9660E" aux:SerialNumber="083024001234">
+<dc:creator><rdf:Seq><rdf:li>Мария Иванова</rdf:li></rdf:Seq></dc:creator>
+<dc:rights><rdf:Alt><rdf:li xml:lang="x-default">© Studio &amp; Co</rdf:li></rdf:Alt></dc:rights>
+</rdf:Description></rdf:RDF></x:xmpmeta>`
+
+func TestParseImageMetadata(t *testing.T) {
+	xpAuthor := []byte{'I', 0, 'v', 0, 'a', 0, 'n', 0, 0, 0}
+	ifd0 := []testIFDEntry{
+		asciiEntry(tagArtist, "Ivan Petrov"),
+		asciiEntry(tagCopyright, "Ivan Petrov\x00Photo Agency"),
+		{tagXPAuthor, 1, uint32(len(xpAuthor)), xpAuthor},
+		asciiEntry(0x010F, "Canon"), // Make is not personal
+	}
+	exif := []testIFDEntry{
+		asciiEntry(tagCameraOwnerName, "  "), // Blank fields are skipped
+		asciiEntry(tagBodySerialNumber, "012345678901"),
+	}
+	little := buildEXIF(binary.LittleEndian, ifd0, exif, testMoscowGPS)
+	big := buildEXIF(binary.BigEndian, []testIFDEntry{asciiEntry(tagArtist, "Ana Souza")}, nil,
+		gpsEntries("S", [3]uint32{22, 54, 3000}, "W", [3]uint32{43, 12, 2700}))
+
+	heic := append([]byte("\x00\x00\x00\x18ftypheic\x00\x00\x00\x00mif1heic"), make([]byte, 64)...)
+	heic = append(append(heic, "\x00\x00\x00\x06Exif\x00\x00"...), big...)
+
+	tests := []struct {
+		name     string
+		data     []byte
+		lat, lon float64
+		gpsField string
+		fields   []string
+	}{
+		{"JPEG", withJPEGSegment(testJPEG(t), 0xE1, append([]byte("Exif\x00\x00"), little...)), 55.7558, 37.6177,
+			"EXIF GPSLatitude, GPSLongitude",
+			[]string{"EXIF Artist=Ivan Petrov", "EXIF Copyright=Ivan Petrov / Photo Agency", "EXIF XPAuthor=Ivan", "EXIF BodySerialNumber=012345678901 (serial)"}},
+		{"TIFF", big, -22.9083, -43.2075, "EXIF GPSLatitude, GPSLongitude", []string{"EXIF Artist=Ana Souza"}},
+		{"PNG", withPNGChunk(t, "eXIf", big), -22.9083, -43.2075, "EXIF GPSLatitude, GPSLongitude", []string{"EXIF Artist=Ana Souza"}},
+		{"HEIC", heic, -22.9083, -43.2075, "EXIF GPSLatitude, GPSLongitude", []string{"EXIF Artist=Ana Souza"}},
+		{"XMP", withJPEGSegment(testJPEG(t), 0xE1, []byte("http://ns.adobe.com/xap/1.0/\x00"+testXMP)), 59.9392, 30.3161,
+			"XMP exif:GPSLatitude, exif:GPSLongitude",
+			[]string{"XMP dc:creator=Мария Иванова", "XMP dc:rights=© Studio & Co", "XMP aux:SerialNumber=083024001234 (serial)"}},
+		// Cameras without a fix write zeros
+		{"zero GPS", buildEXIF(binary.LittleEndian, nil, nil, gpsEntries("N", [3]uint32{}, "E", [3]uint32{})), 0, 0, "", nil},
+		{"plain JPEG", testJPEG(t), 0, 0, "", nil},
+		{"text", []byte("Artist: Ivan Petrov"), 0, 0, "", nil},
+	}
+	for _, tt := range tests {
+		meta := parseImageMetadata(tt.data)
+		if meta.hasGPS != (tt.gpsField != "") || meta.gpsField != tt.gpsField ||
+			math.Abs(meta.latitude-tt.lat) > 1e-4 || math.Abs(meta.longitude-tt.lon) > 1e-4 {
+			t.Errorf("%s: GPS %v %.4f, %.4f from %q", tt.name, meta.hasGPS, meta.latitude, meta.longitude, meta.gpsField)
+		}
+		var fields []string
+		for _, f := range meta.fields {
+			field := f.name + "=" + f.value
+			if f.serial {
+				field += " (serial)"
+			}
+			fields = append(fields, field)
+		}
+		if strings.Join(fields, "\n") != strings.Join(tt.fields, "\n") {
+			t.Errorf("%s: fields\n%s\nwant\n%s", tt.name, strings.Join(fields, "\n"), strings.Join(tt.fields, "\n"))
+		}
+	}
+
+	// Truncated metadata is read as far as it goes
+	jpg := withJPEGSegment(testJPEG(t), 0xE1, append([]byte("Exif\x00\x00"), little...))
+	for n := range jpg {
+		parseImageMetadata(jpg[:n])
+	}
+}
+
+// TestScanImageMetadata tests metadata is reported without OCR and with it
+func TestScanImageMetadata(t *testing.T) {
+	t.Cleanup(ReleaseCaches)
+	dir := t.TempDir()
+	exif := buildEXIF(binary.LittleEndian, []testIFDEntry{asciiEntry(tagArtist, "Ivan Petrov")},
+		[]testIFDEntry{asciiEntry(tagBodySerialNumber, "012345678901")}, testMoscowGPS)
+	os.WriteFile(filepath.Join(dir, "photo.jpg"), withJPEGSegment(testJPEG(t), 0xE1, append([]byte("Exif\x00\x00"), exif...)), 0644)
+	os.WriteFile(filepath.Join(dir, "phone.heic"), append([]byte("\x00\x00\x00\x18ftypheic\x00\x00\x00\x00Exif\x00\x00"), exif...), 0644)
+	os.WriteFile(filepath.Join(dir, "plain.png"), withPNGChunk(t, "tEXt", []byte("Comment\x00nothing")), 0644)
+
+	scan := func(ocr bool, metadata bool) []string {
+		t.Helper()
+		scanner := NewScanner()
+		if ocr {
+			de := NewDocumentExtractor(true)
+			de.SetCacheEnabled(false)
+			de.ocr = func(_ context.Context, _, _ string) (string, error) { return "", nil }
+			scanner.SetDocumentExtractor(de)
+		}
+		scanner.SetScanImageMetadata(metadata)
+		result, err := scanner.Scan(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var found []string
+		for _, f := range result.Findings {
+			if f.LineNumber != 0 {
+				t.Errorf("%s: line %d", f.Description, f.LineNumber)
+			}
+			found = append(found, strings.Join([]string{filepath.Base(f.FilePath), string(f.PatternType), string(f.Severity), f.Description, f.Context}, " | "))
+		}
+		sort.Strings(found)
+		return found
+	}
+
+	var want []string
+	for _, name := range []string{"phone.heic", "photo.jpg"} {
+		want = append(want,
+			name+" | geo_metadata | high | GPS-координаты в метаданных изображения: 55.76, 37.62 | Поле метаданных: EXIF GPSLatitude, GPSLongitude",
+			name+" | image_metadata | low | Серийный номер камеры в метаданных изображения | Поле метаданных: EXIF BodySerialNumber",
+			name+" | image_metadata | medium | Имя автора или владельца в метаданных изображения | Поле метаданных: EXIF Artist")
+	}
+	sort.Strings(want)
+	for _, ocr := range []bool{false, true} {
+		if got := scan(ocr, true); strings.Join(got, "\n") != strings.Join(want, "\n") {
+			t.Errorf("OCR %v: findings\n%s\nwant\n%s", ocr, strings.Join(got, "\n"), strings.Join(want, "\n"))
+		}
+	}
+	if got := scan(false, false); len(got) != 0 {
+		t.Errorf("metadata off: findings\n%s", strings.Join(got, "\n"))
+	}
+}
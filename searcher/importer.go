@@ -0,0 +1,479 @@
+package searcher
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ImportFormat identifies the output format of an external scanner
+type ImportFormat string
+
+const (
+	ImportGitleaks   ImportFormat = "gitleaks"   // gitleaks JSON report (array)
+	ImportTrufflehog ImportFormat = "trufflehog" // trufflehog --json output (NDJSON)
+)
+
+// ParseImportFormat converts a format name from the command line into an ImportFormat
+func ParseImportFormat(name string) (ImportFormat, error) {
+	switch ImportFormat(strings.ToLower(strings.TrimSpace(name))) {
+	case ImportGitleaks:
+		return ImportGitleaks, nil
+	case ImportTrufflehog:
+		return ImportTrufflehog, nil
+	}
+	return "", fmt.Errorf("неизвестный формат импорта: %q (допустимо: gitleaks, trufflehog)", name)
+}
+
+// RuleMapping maps external rule ids (lowercased) to pattern types
+type RuleMapping map[string]PatternType
+
+// DefaultRuleMapping returns the built-in mapping for common gitleaks rule ids
+// and trufflehog detector names
+func DefaultRuleMapping() RuleMapping {
+	return RuleMapping{
+		// gitleaks
+		"aws-access-token":        PatternAWSKey,
+		"github-pat":              PatternGitHubToken,
+		"github-fine-grained-pat": PatternGitHubToken,
+		"github-oauth":            PatternGitHubToken,
+		"github-app-token":        PatternGitHubToken,
+		"github-refresh-token":    PatternGitHubToken,
+		"private-key":             PatternPrivateKey,
+		"generic-api-key":         PatternAPIKey,
+		"gcp-api-key":             PatternGoogleAPIKey,
+		"stripe-access-token":     PatternStripeKey,
+		"sendgrid-api-token":      PatternSendGridKey,
+		"twilio-api-key":          PatternTwilioKey,
+		"slack-bot-token":         PatternSlackToken,
+		"slack-user-token":        PatternSlackToken,
+		"slack-webhook-url":       PatternSlackToken,
+		"jwt":                     PatternJWT,
+		"gitlab-pat":              PatternToken,
+		"npm-access-token":        PatternToken,
+		"telegram-bot-api-token":  PatternToken,
+		// trufflehog
+		"aws":        PatternAWSKey,
+		"github":     PatternGitHubToken,
+		"privatekey": PatternPrivateKey,
+		"stripe":     PatternStripeKey,
+		"sendgrid":   PatternSendGridKey,
+		"gcp":        PatternGCPServiceAccount,
+		"twilio":     PatternTwilioKey,
+		"slack":      PatternSlackToken,
+		"gitlab":     PatternToken,
+		"uri":        PatternConnectionStr,
+		"jdbc":       PatternConnectionStr,
+		"postgres":   PatternConnectionStr,
+		"mongodb":    PatternConnectionStr,
+		"redis":      PatternConnectionStr,
+	}
+}
+
+// LoadRuleMapping reads a YAML/JSON mapping file ("rule-id: pattern_type")
+// and merges it over the default mapping
+func LoadRuleMapping(path string) (RuleMapping, error) {
+	var raw map[string]string
+	if err := DecodeConfigFile(path, &raw); err != nil {
+		return nil, err
+	}
+
+	mapping := DefaultRuleMapping()
+	for rule, pt := range raw {
+		pt = strings.TrimSpace(pt)
+		if pt == "" {
+			return nil, fmt.Errorf("правило %q: не указан тип паттерна", rule)
+		}
+		mapping[strings.ToLower(strings.TrimSpace(rule))] = PatternType(pt)
+	}
+	return mapping, nil
+}
+
+// Resolve returns the pattern type for an external rule id. Unknown ids are
+// guessed from keywords and fall back to PatternHardcodedSecret.
+func (m RuleMapping) Resolve(ruleID string) PatternType {
+	id := strings.ToLower(strings.TrimSpace(ruleID))
+	if pt, ok := m[id]; ok {
+		return pt
+	}
+
+	switch {
+	case strings.Contains(id, "private-key"), strings.Contains(id, "privatekey"):
+		return PatternPrivateKey
+	case strings.Contains(id, "password"):
+		return PatternPassword
+	case strings.Contains(id, "api-key"), strings.Contains(id, "apikey"):
+		return PatternAPIKey
+	case strings.Contains(id, "token"):
+		return PatternToken
+	}
+	return PatternHardcodedSecret
+}
+
+// Importer converts findings of external scanners into searcher findings
+type Importer struct {
+	format     ImportFormat
+	mapping    RuleMapping
+	severities map[PatternType]Severity
+	riskScorer *RiskScorer
+}
+
+// NewImporter creates an importer for the format. A nil mapping uses DefaultRuleMapping.
+func NewImporter(format ImportFormat, mapping RuleMapping) *Importer {
+	if mapping == nil {
+		mapping = DefaultRuleMapping()
+	}
+
+	// Imported findings get the severity of the matching built-in pattern
+	severities := make(map[PatternType]Severity)
+	for _, p := range NewPatterns().List() {
+		if _, ok := severities[p.Type]; !ok {
+			severities[p.Type] = p.Severity
+		}
+	}
+
+	return &Importer{
+		format:     format,
+		mapping:    mapping,
+		severities: severities,
+		riskScorer: NewRiskScorer(),
+	}
+}
+
+// gitleaksFinding is one entry of a gitleaks JSON report
+type gitleaksFinding struct {
+	RuleID      string
+	Description string
+	StartLine   int
+	StartColumn int
+	Match       string
+	Secret      string
+	File        string
+	Entropy     float64
+}
+
+// trufflehogFinding is one line of trufflehog --json output
+type trufflehogFinding struct {
+	DetectorName   string
+	Verified       bool
+	Raw            string
+	SourceMetadata struct {
+		Data map[string]struct {
+			File string `json:"file"`
+			Line int    `json:"line"`
+		}
+	}
+}
+
+// Import streams findings from r, calling fn for every converted finding.
+// Returning an error from fn stops the import.
+func (im *Importer) Import(r io.Reader, fn func(*Finding) error) error {
+	switch im.format {
+	case ImportGitleaks:
+		return im.importGitleaks(r, fn)
+	case ImportTrufflehog:
+		return im.importTrufflehog(r, fn)
+	}
+	return fmt.Errorf("неизвестный формат импорта: %q", im.format)
+}
+
+// ImportFile reads all findings from a results file
+func (im *Importer) ImportFile(path string) ([]*Finding, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var findings []*Finding
+	err = im.Import(file, func(f *Finding) error {
+		findings = append(findings, f)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	return findings, nil
+}
+
+// importGitleaks decodes the report array element by element so large
+// reports are never held in memory as a whole
+func (im *Importer) importGitleaks(r io.Reader, fn func(*Finding) error) error {
+	dec := json.NewDecoder(r)
+
+	tok, err := dec.Token()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		return fmt.Errorf("некорректный отчёт gitleaks: %w", err)
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return fmt.Errorf("некорректный отчёт gitleaks: ожидался массив находок")
+	}
+
+	for n := 1; dec.More(); n++ {
+		var gf gitleaksFinding
+		if err := dec.Decode(&gf); err != nil {
+			return fmt.Errorf("некорректный отчёт gitleaks: находка %d: %w", n, err)
+		}
+
+		secret := gf.Secret
+		if secret == "" {
+			secret = gf.Match
+		}
+		column := gf.StartColumn - 1
+		if column < 0 {
+			column = 0
+		}
+
+		finding := im.newFinding(gf.RuleID, gf.File, gf.StartLine, gf.Match, secret)
+		finding.ColumnStart = column
+		finding.ColumnEnd = column + utf8.RuneCountInString(finding.MatchedText)
+		if gf.Description != "" {
+			finding.Description = gf.Description
+		}
+		if gf.Entropy > 0 {
+			finding.EntropyScore = gf.Entropy
+		}
+		if err := fn(finding); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// importTrufflehog decodes one JSON object per line
+func (im *Importer) importTrufflehog(r io.Reader, fn func(*Finding) error) error {
+	dec := json.NewDecoder(r)
+
+	for n := 1; ; n++ {
+		var tf trufflehogFinding
+		if err := dec.Decode(&tf); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("некорректный вывод trufflehog: запись %d: %w", n, err)
+		}
+
+		// Filesystem and Git sources both carry file/line
+		var path string
+		var line int
+		for _, data := range tf.SourceMetadata.Data {
+			if data.File != "" {
+				path, line = data.File, data.Line
+				break
+			}
+		}
+
+		finding := im.newFinding(tf.DetectorName, path, line, tf.Raw, tf.Raw)
+		finding.ColumnEnd = utf8.RuneCountInString(finding.MatchedText)
+		if tf.Verified {
+			finding.Severity = Critical
+			finding.Description += " (подтверждён)"
+		}
+		if err := fn(finding); err != nil {
+			return err
+		}
+	}
+}
+
+// newFinding builds a finding with the mapped type, severity and risk score
+func (im *Importer) newFinding(ruleID, path string, line int, match, secret string) *Finding {
+	patternType := im.mapping.Resolve(ruleID)
+	severity, ok := im.severities[patternType]
+	if !ok {
+		severity = High
+	}
+	if match == "" {
+		match = secret
+	}
+
+	detected := &DetectedPattern{
+		Type:      patternType,
+		Severity:  severity,
+		MatchText: secret,
+		Context:   match,
+	}
+
+	return &Finding{
+		FilePath:     path,
+		LineNumber:   line,
+		PatternType:  patternType,
+		Severity:     severity,
+		Description:  fmt.Sprintf("%s: %s", im.format, ruleID),
+		MatchedText:  match,
+		Context:      match,
+		EntropyScore: im.riskScorer.entropyCalculator.CalculateEntropy(secret),
+		RiskScore:    im.riskScorer.CalculateRiskScore(detected),
+		RuleID:       ruleID,
+		Source:       string(im.format),
+	}
+}
+
+// MergeFindings adds imported findings to result, skipping those that duplicate
+// an existing finding by file, line and normalized match
+func MergeFindings(result *ScanResult, imported []*Finding) (added, duplicates int) {
+	result.mu.Lock()
+	existing := make([]*Finding, len(result.Findings))
+	copy(existing, result.Findings)
+	result.mu.Unlock()
+
+	index := newFindingIndex(existing)
+	for _, f := range imported {
+		if index.contains(f) {
+			duplicates++
+			continue
+		}
+		index.add(f)
+		result.AddFinding(f)
+		added++
+	}
+	return added, duplicates
+}
+
+// findingIndex groups normalized matches by file name and line for dedup
+type findingIndex map[string][]indexedMatch
+
+type indexedMatch struct {
+	path  string
+	line  int
+	match string
+}
+
+func newFindingIndex(findings []*Finding) findingIndex {
+	index := make(findingIndex)
+	for _, f := range findings {
+		index.add(f)
+	}
+	return index
+}
+
+func (fi findingIndex) add(f *Finding) {
+	path := normalizeFindingPath(f.FilePath)
+	base := filepath.Base(path)
+	fi[base] = append(fi[base], indexedMatch{path: path, line: f.LineNumber, match: normalizeMatch(f.MatchedText)})
+}
+
+func (fi findingIndex) contains(f *Finding) bool {
+	path := normalizeFindingPath(f.FilePath)
+	match := normalizeMatch(f.MatchedText)
+
+	for _, m := range fi[filepath.Base(path)] {
+		if !samePath(m.path, path) {
+			continue
+		}
+		// A zero line means the tool did not report one
+		if m.line != f.LineNumber && m.line != 0 && f.LineNumber != 0 {
+			continue
+		}
+		if m.match == match || (m.match != "" && match != "" &&
+			(strings.Contains(m.match, match) || strings.Contains(match, m.match))) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeFindingPath makes paths from different tools comparable
+func normalizeFindingPath(path string) string {
+	path = filepath.ToSlash(filepath.Clean(path))
+	return strings.TrimPrefix(path, "./")
+}
+
+// samePath treats a relative path reported by another tool as equal to the
+// absolute path it is a suffix of
+func samePath(a, b string) bool {
+	return a == b || strings.HasSuffix(a, "/"+b) || strings.HasSuffix(b, "/"+a)
+}
+
+// normalizeMatch drops case, whitespace and quotes so `password = "x"` and
+// `password=x` compare equal
+func normalizeMatch(text string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(text) {
+		switch r {
+		case ' ', '\t', '"', '\'', '`':
+			continue
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// IsReportFile reports whether path has the .dllreport extension
+func IsReportFile(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ReportExtension)
+}
+
+// LoadScanResult reads a JSON report written by ExportJSON back into a
+// ScanResult. Older .json reports without the Format field are accepted;
+// .dllreport files must carry it
+func LoadScanResult(path string) (*ScanResult, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var report JSONReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("некорректный JSON-отчёт %s: %w", filepath.Base(path), err)
+	}
+	if IsReportFile(path) && report.Format != ReportFormat {
+		return nil, fmt.Errorf("файл %s не является отчётом Data Leak Locator", filepath.Base(path))
+	}
+
+	result := NewScanResult()
+	result.StartTime = report.Metadata.ScanStartTime
+	result.EndTime = report.Metadata.ScanEndTime
+	result.FilesScanned = report.Metadata.FilesScanned
+	result.FilesSkipped = report.Metadata.FilesSkipped
+	result.ErrorCount = report.Metadata.ErrorCount
+	result.Errors = report.Errors
+	result.ErrorsDropped = report.Metadata.ErrorsDropped
+	result.Coverage = report.Coverage
+	result.Metrics = report.Metrics
+	result.Root = report.Metadata.ScanRoot
+	result.SuppressedCount = report.Metadata.Suppressed
+	result.FilteredFindings = report.Metadata.Filtered
+	result.Truncated = report.Metadata.Truncated
+	result.NestedArchivesScanned = report.Metadata.NestedArchives
+	result.CacheHits = report.Metadata.CacheHits
+	result.ExtensionSkips = report.Metadata.ExtensionSkips
+	result.HeuristicDropped = report.Metadata.HeuristicDropped
+	result.HeuristicDowngraded = report.Metadata.HeuristicDowngraded
+	fmt.Sscanf(report.Metadata.TotalDataScanned, "%d", &result.TotalSize)
+
+	for _, f := range report.Findings {
+		if f != nil {
+			result.AddFinding(f)
+		}
+	}
+
+	// Counts are rebuilt by AddFinding, only the regex times need restoring
+	regexTimes := make(map[string]time.Duration)
+	for _, stat := range report.PatternStats {
+		if stat.RegexTime > 0 {
+			regexTimes[stat.Rule] = stat.RegexTime
+		}
+	}
+	if len(regexTimes) > 0 {
+		result.SetRegexTimes(regexTimes)
+	}
+
+	// Pruned reports list fewer findings than the summary counts
+	if report.Metadata.PrunedFindings > 0 {
+		result.PrunedFindings = report.Metadata.PrunedFindings
+		result.SeveritySummary[Critical] = report.Summary.CriticalFindings
+		result.SeveritySummary[High] = report.Summary.HighFindings
+		result.SeveritySummary[Medium] = report.Summary.MediumFindings
+		result.SeveritySummary[Low] = report.Summary.LowFindings
+	}
+	return result, nil
+}
@@ -0,0 +1,365 @@
+package searcher
+
+import (
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Infrastructure files leak secrets in idioms of their own: ENV and ARG
+// lines of a Dockerfile, environment entries of docker-compose services,
+// Kubernetes Secrets with base64 data and container env values. The
+// detectors below know these constructs, so a finding names the construct
+// and references such as ${DB_PASSWORD} or valueFrom are not reported
+
+// addInfraDetectors registers the detectors of infrastructure files
+func addInfraDetectors(p *Patterns) {
+	p.AddFileTypeDetector(dockerfileDetector{})
+	p.AddFileTypeDetector(composeDetector{})
+	p.AddFileTypeDetector(kubernetesDetector{})
+}
+
+// isYAMLFile reports whether a path has a YAML extension
+func isYAMLFile(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// isLiteralSecret reports whether a value assigned to a sensitive name is
+// written in the file, rather than a reference or a placeholder
+func isLiteralSecret(value string) bool {
+	return !strings.HasPrefix(value, "$") && !isPlaceholderValue(value)
+}
+
+// dockerfileDetector checks ENV and ARG instructions of Dockerfiles
+type dockerfileDetector struct{}
+
+func (dockerfileDetector) Name() string { return "dockerfile" }
+
+// Match accepts Dockerfile, Dockerfile.prod, app.dockerfile and Containerfile
+func (dockerfileDetector) Match(path string) bool {
+	name := strings.ToLower(filepath.Base(path))
+	return strings.HasPrefix(name, "dockerfile") || strings.HasSuffix(name, ".dockerfile") ||
+		strings.HasPrefix(name, "containerfile")
+}
+
+// dockerAssignment is a NAME[=value] of an ENV or ARG instruction, with
+// byte spans in its line
+type dockerAssignment struct {
+	name, value          string
+	hasValue             bool
+	nameStart, nameEnd   int
+	valueStart, valueEnd int
+}
+
+func (d dockerfileDetector) Detect(lines []string) []*DetectedPattern {
+	var results []*DetectedPattern
+	instruction := "" // Instruction a continued line belongs to
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		continued := instruction != ""
+		if !continued && (trimmed == "" || strings.HasPrefix(trimmed, "#")) {
+			continue
+		}
+
+		rest := 0
+		if !continued {
+			start := len(line) - len(strings.TrimLeft(line, " \t"))
+			end := start
+			for end < len(line) && line[end] != ' ' && line[end] != '\t' {
+				end++
+			}
+			instruction = strings.ToUpper(line[start:end])
+			rest = end
+		}
+		if instruction == "ENV" || instruction == "ARG" {
+			for _, a := range dockerAssignments(line, rest, !continued && instruction == "ENV") {
+				if match := d.check(instruction, a); match != nil {
+					match.LineNumber = i + 1
+					results = append(results, match)
+				}
+			}
+		}
+		if !strings.HasSuffix(trimmed, `\`) {
+			instruction = ""
+		}
+	}
+	return results
+}
+
+// check flags an assignment to a sensitive name: a literal value is
+// Critical, a bare ARG is Low, as build arguments are kept in the image
+// history
+func (d dockerfileDetector) check(instruction string, a dockerAssignment) *DetectedPattern {
+	if !isSensitiveKey(a.name) {
+		return nil
+	}
+	match := &DetectedPattern{
+		Type:     PatternDockerfileSecret,
+		RuleName: d.Name() + "_" + strings.ToLower(instruction),
+		Pattern:  instruction + " " + a.name,
+	}
+	switch {
+	case a.hasValue && isLiteralSecret(a.value):
+		match.Severity = Critical
+		match.Description = "Dockerfile " + instruction + " with hardcoded secret"
+		match.StartIndex, match.EndIndex = a.valueStart, a.valueEnd
+		match.MatchText = a.value
+	case instruction == "ARG" && !a.hasValue:
+		match.Severity = Low
+		match.Description = "Dockerfile ARG declares a secret build argument"
+		match.StartIndex, match.EndIndex = a.nameStart, a.nameEnd
+		match.MatchText = a.name
+	default:
+		return nil
+	}
+	return match
+}
+
+// dockerAssignments parses the NAME=value pairs of line from offset; with
+// legacy set the first name may be followed by its value after a space, as
+// in ENV NAME value
+func dockerAssignments(line string, offset int, legacy bool) []dockerAssignment {
+	var list []dockerAssignment
+	i := offset
+	for {
+		for i < len(line) && (line[i] == ' ' || line[i] == '\t') {
+			i++
+		}
+		if i >= len(line) || line[i] == '\\' || line[i] == '#' {
+			return list
+		}
+		a := dockerAssignment{nameStart: i}
+		for i < len(line) && line[i] != '=' && line[i] != ' ' && line[i] != '\t' {
+			i++
+		}
+		a.name, a.nameEnd = line[a.nameStart:i], i
+		if i < len(line) && line[i] == '=' {
+			a.hasValue = true
+			a.value, a.valueStart, a.valueEnd, i = dockerValue(line, i+1, false)
+		} else if legacy && len(list) == 0 {
+			// ENV NAME value takes the rest of the line
+			value := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(line[i:]), `\`))
+			if value != "" {
+				a.hasValue = true
+				a.value, a.valueStart, a.valueEnd, _ = dockerValue(line, strings.Index(line[i:], value)+i, true)
+			}
+			return append(list, a)
+		}
+		list = append(list, a)
+	}
+}
+
+// dockerValue reads a quoted or bare value at start and returns it with
+// its span, quotes excluded, and where parsing goes on; whole reads to the
+// end of the line
+func dockerValue(line string, start int, whole bool) (string, int, int, int) {
+	if start < len(line) && (line[start] == '"' || line[start] == '\'') {
+		quote := line[start]
+		if end := strings.IndexByte(line[start+1:], quote); end >= 0 {
+			return line[start+1 : start+1+end], start + 1, start + 1 + end, start + end + 2
+		}
+	}
+	end := start
+	for end < len(line) && (whole || (line[end] != ' ' && line[end] != '\t')) {
+		end++
+	}
+	value := strings.TrimRight(line[start:end], " \t\\")
+	return value, start, start + len(value), end
+}
+
+// composeDetector checks the environment entries of docker-compose services
+type composeDetector struct{}
+
+func (composeDetector) Name() string { return "compose" }
+
+// Match accepts docker-compose.yml, docker-compose.prod.yaml, compose.yaml
+func (composeDetector) Match(path string) bool {
+	name := strings.ToLower(filepath.Base(path))
+	return isYAMLFile(name) && (strings.HasPrefix(name, "docker-compose") || strings.HasPrefix(name, "compose"))
+}
+
+func (d composeDetector) Detect(lines []string) []*DetectedPattern {
+	var root yaml.Node
+	if yaml.Unmarshal([]byte(strings.Join(lines, "\n")), &root) != nil || len(root.Content) == 0 {
+		return nil
+	}
+	services := yamlMappingValue(root.Content[0], "services")
+	if services == nil || services.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	var results []*DetectedPattern
+	for i := 1; i < len(services.Content); i += 2 {
+		env := yamlMappingValue(services.Content[i], "environment")
+		if env == nil {
+			continue
+		}
+		// environment is a map or a list of NAME=value
+		type entry struct {
+			name, value string
+			line        int
+		}
+		var entries []entry
+		switch env.Kind {
+		case yaml.MappingNode:
+			for j := 0; j+1 < len(env.Content); j += 2 {
+				if v := env.Content[j+1]; v.Kind == yaml.ScalarNode && v.Tag != "!!null" {
+					entries = append(entries, entry{env.Content[j].Value, v.Value, v.Line})
+				}
+			}
+		case yaml.SequenceNode:
+			for _, item := range env.Content {
+				if name, value, ok := strings.Cut(item.Value, "="); ok && item.Kind == yaml.ScalarNode {
+					entries = append(entries, entry{name, value, item.Line})
+				}
+			}
+		}
+
+		for _, e := range entries {
+			if !isSensitiveKey(e.name) || !isLiteralSecret(e.value) || e.line < 1 || e.line > len(lines) {
+				continue
+			}
+			start, end := valueSpan(lines[e.line-1], e.name, e.value)
+			if start < 0 {
+				start, end = 0, 0
+			}
+			results = append(results, &DetectedPattern{
+				Type:        PatternComposeSecret,
+				RuleName:    d.Name() + "_environment",
+				Pattern:     "environment " + e.name,
+				Severity:    Critical,
+				Description: "docker-compose environment entry with hardcoded secret",
+				LineNumber:  e.line,
+				StartIndex:  start,
+				EndIndex:    end,
+				MatchText:   e.value,
+			})
+		}
+	}
+	return results
+}
+
+// kubernetesDetector checks Secret manifests and the env of containers
+type kubernetesDetector struct{}
+
+func (kubernetesDetector) Name() string { return "kubernetes" }
+
+// Match accepts YAML files; Detect looks for manifests in them
+func (kubernetesDetector) Match(path string) bool {
+	return isYAMLFile(path)
+}
+
+func (d kubernetesDetector) Detect(lines []string) []*DetectedPattern {
+	text := strings.Join(lines, "\n")
+	if !strings.Contains(text, "kind:") {
+		return nil
+	}
+
+	var results []*DetectedPattern
+	add := func(node *yaml.Node, key, value, rule, description string) {
+		if node.Line < 1 || node.Line > len(lines) || value == "" {
+			return
+		}
+		start, end := valueSpan(lines[node.Line-1], key, node.Value)
+		if start < 0 {
+			start, end = 0, 0
+		}
+		results = append(results, &DetectedPattern{
+			Type:        PatternK8sSecret,
+			RuleName:    d.Name() + "_" + rule,
+			Pattern:     rule + " " + key,
+			Severity:    Critical,
+			Description: description,
+			LineNumber:  node.Line,
+			StartIndex:  start,
+			EndIndex:    end,
+			MatchText:   value,
+		})
+	}
+
+	dec := yaml.NewDecoder(strings.NewReader(text))
+	for {
+		var doc yaml.Node
+		if dec.Decode(&doc) != nil {
+			// The end of the stream or a document that is not YAML
+			break
+		}
+		if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+			continue
+		}
+		manifest := doc.Content[0]
+		kind := yamlMappingValue(manifest, "kind")
+		if kind == nil || yamlMappingValue(manifest, "apiVersion") == nil {
+			continue
+		}
+
+		if kind.Value == "Secret" {
+			// data holds base64; the decoded text is what leaks, binary
+			// values such as keystores are reported as written
+			if data := yamlMappingValue(manifest, "data"); data != nil && data.Kind == yaml.MappingNode {
+				for i := 0; i+1 < len(data.Content); i += 2 {
+					v := data.Content[i+1]
+					value := v.Value
+					if decoded, ok := decodeBase64Token(value); ok {
+						value = decoded
+					}
+					add(v, data.Content[i].Value, value, "secret_data", "Kubernetes Secret data value")
+				}
+			}
+			if data := yamlMappingValue(manifest, "stringData"); data != nil && data.Kind == yaml.MappingNode {
+				for i := 0; i+1 < len(data.Content); i += 2 {
+					v := data.Content[i+1]
+					add(v, data.Content[i].Value, v.Value, "secret_string_data", "Kubernetes Secret stringData value")
+				}
+			}
+			continue
+		}
+
+		// Containers of workloads; valueFrom references are not values
+		walkYAMLEnv(manifest, func(name string, value *yaml.Node) {
+			if isSensitiveKey(name) && isLiteralSecret(value.Value) {
+				add(value, name, value.Value, "env", "Kubernetes env with hardcoded secret")
+			}
+		})
+	}
+	return results
+}
+
+// walkYAMLEnv calls fn for every name/value pair of the env lists under n
+func walkYAMLEnv(n *yaml.Node, fn func(name string, value *yaml.Node)) {
+	switch n.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			if k, v := n.Content[i], n.Content[i+1]; k.Value == "env" && v.Kind == yaml.SequenceNode {
+				for _, item := range v.Content {
+					name, value := yamlMappingValue(item, "name"), yamlMappingValue(item, "value")
+					if name != nil && value != nil && value.Kind == yaml.ScalarNode {
+						fn(name.Value, value)
+					}
+				}
+				continue
+			}
+			walkYAMLEnv(n.Content[i+1], fn)
+		}
+	case yaml.SequenceNode:
+		for _, c := range n.Content {
+			walkYAMLEnv(c, fn)
+		}
+	}
+}
+
+// yamlMappingValue returns the value of key in a mapping node, nil when n
+// is no mapping or has no such key
+func yamlMappingValue(n *yaml.Node, key string) *yaml.Node {
+	if n == nil || n.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			return n.Content[i+1]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package searcher
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// TestInfraDetectors tests the Dockerfile, docker-compose and Kubernetes
+// detectors on fixtures, including references that must not be reported
+func TestInfraDetectors(t *testing.T) {
+	type want struct {
+		line     int
+		severity Severity
+		match    string
+	}
+	tests := []struct {
+		fixture     string
+		patternType PatternType
+		want        []want
+	}{
+		{"Dockerfile", PatternDockerfileSecret, []want{
+			{2, Low, "GITHUB_TOKEN"},
+			{3, Critical, "n7Pq2Lx9Vb4Kd8Wz"},
+			{5, Critical, "S3cr3t-Passw0rd"},
+			{7, Critical, "s7Kd9Lq2Xz8Vb4Np"},
+		}},
+		{"safe.dockerfile", PatternDockerfileSecret, nil},
+		{"docker-compose.yml", PatternComposeSecret, []want{
+			{6, Critical, "pg-Sup3r-s3cret"},
+			{11, Critical, "x8Lq2Vz7Kd4Np9Wb"},
+		}},
+		{"docker-compose.safe.yml", PatternComposeSecret, nil},
+		{"secret.yaml", PatternK8sSecret, []want{
+			{7, Critical, "admin"},
+			{8, Critical, "hunter2-prod-db"},
+			{10, Critical, "tok_9f8e7d6c5b4a"},
+		}},
+		// valueFrom.secretKeyRef and $(VAR) references are not values
+		{"deployment.yaml", PatternK8sSecret, []want{
+			{20, Critical, "rk_9Lq2Vz7Kd4Np9Wb8"},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			findings, err := NewScanner().scanFileContent(filepath.Join("testdata", "infra", tt.fixture))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []*Finding
+			for _, f := range findings {
+				if f.PatternType == tt.patternType {
+					got = append(got, f)
+				}
+			}
+			if len(got) != len(tt.want) {
+				for _, f := range got {
+					t.Logf("line %d: %q", f.LineNumber, f.MatchedText)
+				}
+				t.Fatalf("got %d findings, want %d", len(got), len(tt.want))
+			}
+			for i, w := range tt.want {
+				f := got[i]
+				if f.LineNumber != w.line || f.Severity != w.severity || f.MatchedText != w.match {
+					t.Errorf("finding %d: line %d, %s, %q; want line %d, %s, %q",
+						i, f.LineNumber, f.Severity, f.MatchedText, w.line, w.severity, w.match)
+				}
+			}
+		})
+	}
+}
+
+// TestInfraDescriptions tests that findings name the construct
+func TestInfraDescriptions(t *testing.T) {
+	findings, err := NewScanner().scanFileContent(filepath.Join("testdata", "infra", "secret.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	descriptions := make(map[int]string)
+	for _, f := range findings {
+		if f.PatternType == PatternK8sSecret {
+			descriptions[f.LineNumber] = f.Description
+		}
+	}
+	if got, want := descriptions[8], "Kubernetes Secret data value"; got != want {
+		t.Errorf("data description %q, want %q", got, want)
+	}
+	if got := descriptionToRussian(descriptions[10]); got != "Значение stringData в Kubernetes Secret" {
+		t.Errorf("stringData description %q", got)
+	}
+}
+
+// TestFileTypeDetectorsMatch tests selection of detectors by file name
+func TestFileTypeDetectorsMatch(t *testing.T) {
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{"Dockerfile", []string{"dockerfile"}},
+		{"build/Dockerfile.prod", []string{"dockerfile"}},
+		{"api.dockerfile", []string{"dockerfile"}},
+		{"docker-compose.override.yml", []string{"compose", "kubernetes"}},
+		{"compose.yaml", []string{"compose", "kubernetes"}},
+		{"k8s/secret.yml", []string{"kubernetes"}},
+		{"main.go", nil},
+	}
+	patterns := NewPatterns()
+	for _, tt := range tests {
+		var got []string
+		for _, d := range patterns.FileTypeDetectors(tt.path) {
+			got = append(got, d.Name())
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: detectors %v, want %v", tt.path, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s: detectors %v, want %v", tt.path, got, tt.want)
+			}
+		}
+	}
+}
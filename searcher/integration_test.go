@@ -315,6 +315,17 @@ func createTestFile(t *testing.T, dir string, filename string, content string) {
 	}
 }
 
+// findingsOfType returns the findings of one pattern type
+func findingsOfType(findings []*Finding, patternType PatternType) []*Finding {
+	var matched []*Finding
+	for _, f := range findings {
+		if f.PatternType == patternType {
+			matched = append(matched, f)
+		}
+	}
+	return matched
+}
+
 // Helper function for performance test data
 func createLargeTestStructure(t *testing.T, baseDir string, numFiles int) {
 	for i := 0; i < numFiles; i++ {
@@ -0,0 +1,176 @@
+package searcher
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/kacebover/password-finder/locale"
+)
+
+// JUnit XML output for CI systems that gate on test results. Each file with
+// findings is a test case that fails on Critical and High findings; files
+// are grouped into a test suite per top-level directory of the scan root
+
+// JUnitTestSuites is the root element of a JUnit report
+type JUnitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []JUnitTestSuite `xml:"testsuite"`
+}
+
+// JUnitTestSuite holds the files of one top-level directory
+type JUnitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Time     string          `xml:"time,attr"`
+	Cases    []JUnitTestCase `xml:"testcase"`
+}
+
+// JUnitTestCase is one file; it passes when it has no Critical or High findings
+type JUnitTestCase struct {
+	Name      string         `xml:"name,attr"`
+	ClassName string         `xml:"classname,attr"`
+	Failures  []JUnitFailure `xml:"failure"`
+	SystemOut string         `xml:"system-out,omitempty"`
+}
+
+// JUnitFailure is one Critical or High finding
+type JUnitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// junitFailsAt is the lowest severity that fails a test case
+const junitFailsAt = High
+
+// ExportJUnit exports findings to a JUnit XML file
+func (rg *ReportGenerator) ExportJUnit(filePath string) error {
+	return writeFileAtomic(filePath, reportFileMode, rg.writeJUnit)
+}
+
+// writeJUnit writes the JUnit report to w
+func (rg *ReportGenerator) writeJUnit(w io.Writer) error {
+	data, err := xml.MarshalIndent(rg.BuildJUnit(), "", "  ")
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// BuildJUnit converts the scan result into JUnit test suites. A scan without
+// findings gives a single passing test case, so the pipeline still sees a
+// test that ran
+func (rg *ReportGenerator) BuildJUnit() *JUnitTestSuites {
+	byFile := make(map[string][]*Finding)
+	rg.forEachFinding(func(finding *Finding) bool {
+		byFile[finding.FilePath] = append(byFile[finding.FilePath], finding)
+		return true
+	})
+	files := make([]string, 0, len(byFile))
+	for path := range byFile {
+		files = append(files, path)
+	}
+	sort.Strings(files)
+
+	report := &JUnitTestSuites{
+		Name: "data-leak-locator",
+		Time: strconv.FormatInt(rg.result.EndTime-rg.result.StartTime, 10),
+	}
+	suites := make(map[string]int) // Suite name -> index in report.Suites
+	for _, path := range files {
+		rel := rg.relativePath(path)
+		suiteName := junitSuiteName(rel)
+		i, ok := suites[suiteName]
+		if !ok {
+			i = len(report.Suites)
+			suites[suiteName] = i
+			report.Suites = append(report.Suites, JUnitTestSuite{Name: suiteName, Time: "0"})
+		}
+
+		testCase := junitTestCase(rg.locale, rel, suiteName, byFile[path])
+		suite := &report.Suites[i]
+		suite.Cases = append(suite.Cases, testCase)
+		suite.Tests++
+		if len(testCase.Failures) > 0 {
+			suite.Failures++
+		}
+	}
+
+	if len(report.Suites) == 0 {
+		report.Suites = append(report.Suites, JUnitTestSuite{
+			Name:  ".",
+			Tests: 1,
+			Time:  report.Time,
+			Cases: []JUnitTestCase{{
+				Name:      rg.locale.T("junit.scan"),
+				ClassName: ".",
+				SystemOut: rg.locale.T("junit.clean", rg.result.FilesScanned),
+			}},
+		})
+	}
+	for _, suite := range report.Suites {
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+	}
+	return report
+}
+
+// junitTestCase turns the findings of one file into a test case with a
+// failure per Critical or High finding; lower ones are listed in system-out
+func junitTestCase(l *locale.Localizer, rel, suiteName string, findings []*Finding) JUnitTestCase {
+	testCase := JUnitTestCase{Name: rel, ClassName: suiteName}
+	var minor []string
+	for _, finding := range findings {
+		where := l.T("junit.line", finding.LineNumber)
+		pattern, severity := l.Pattern(string(finding.PatternType)), l.Severity(string(finding.Severity))
+		if finding.Severity.Score() < junitFailsAt.Score() {
+			minor = append(minor, fmt.Sprintf("%s: %s (%s)", where, pattern, severity))
+			continue
+		}
+		testCase.Failures = append(testCase.Failures, JUnitFailure{
+			Message: fmt.Sprintf("%s: %s", pattern, where),
+			Type:    string(finding.Severity),
+			Text: l.T("junit.details", LocalizeDescription(l, finding.Description), finding.LineNumber,
+				severity, finding.MatchedText),
+		})
+	}
+	if len(minor) > 0 {
+		testCase.SystemOut = strings.Join(minor, "\n")
+	}
+	return testCase
+}
+
+// relativePath returns a finding path relative to the scan root with
+// forward slashes, or the path as is when it is outside the root
+func (rg *ReportGenerator) relativePath(path string) string {
+	if rg.result.Root != "" {
+		if rel, err := filepath.Rel(rg.result.Root, path); err == nil && !strings.HasPrefix(rel, "..") {
+			return filepath.ToSlash(rel)
+		}
+	}
+	return filepath.ToSlash(path)
+}
+
+// junitSuiteName returns the top-level directory of a relative path, "."
+// for files in the root itself
+func junitSuiteName(rel string) string {
+	dir, _, found := strings.Cut(strings.TrimPrefix(rel, "/"), "/")
+	if !found {
+		return "."
+	}
+	return dir
+}
@@ -0,0 +1,113 @@
+package searcher
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// jwtRegex matches the header, payload and signature of a JWT; both JSON
+// parts start with "{" and so with "eyJ" in base64url. The signature is
+// empty for unsigned tokens
+const jwtRegex = `\beyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]*`
+
+const jwtDescription = "JWT detected"
+
+// jwtPrivilegedBonus is added to the risk score of tokens granting admin rights
+const jwtPrivilegedBonus = 15.0
+
+// jwtPrivilegedClaims are the payload claims holding scopes and roles
+var jwtPrivilegedClaims = []string{"scope", "scp", "scopes", "roles", "role", "groups", "permissions", "authorities"}
+
+// jwtPrivilegedValues mark a scope or role as privileged
+var jwtPrivilegedValues = []string{"admin", "root", "superuser", "*"}
+
+// jwtNow returns the time expiry is checked against
+var jwtNow = time.Now
+
+// refineJWT decodes the header and payload of a matched JWT without checking
+// the signature. Tokens that are not JSON are dropped; expired tokens are
+// Low, unexpired ones or those without exp stay High, and admin scopes or
+// roles add to the risk score. The description names the algorithm and
+// the expiry date
+func refineJWT(d *DetectedPattern) bool {
+	parts := strings.Split(d.MatchText, ".")
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	var payload map[string]any
+	if len(parts) != 3 || decodeJWTPart(parts[0], &header) != nil || decodeJWTPart(parts[1], &payload) != nil {
+		return false
+	}
+	alg := header.Alg
+	if alg == "" {
+		alg = "none"
+	}
+
+	expiry := "no expiry"
+	if exp, ok := payload["exp"].(float64); ok {
+		expires := time.Unix(int64(exp), 0).UTC()
+		if expires.Before(jwtNow()) {
+			d.Severity = Low
+			expiry = "expired " + expires.Format("2006-01-02")
+		} else {
+			d.Severity = High
+			expiry = "expires " + expires.Format("2006-01-02")
+		}
+	}
+	d.Description = fmt.Sprintf("%s (alg %s, %s)", jwtDescription, alg, expiry)
+
+	if claim, value, ok := jwtPrivilegedClaim(payload); ok {
+		d.RiskBonus = jwtPrivilegedBonus
+		d.BonusReason = "trace.jwt_privileged"
+		d.BonusArgs = []any{claim, value}
+	}
+	return true
+}
+
+// decodeJWTPart decodes a base64url JSON part of a token
+func decodeJWTPart(part string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(part, "="))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// jwtPrivilegedClaim finds a scope or role claim granting admin rights. Claims
+// are space-separated strings or arrays of strings
+func jwtPrivilegedClaim(payload map[string]any) (claim, value string, ok bool) {
+	for _, claim := range jwtPrivilegedClaims {
+		var values []string
+		switch v := payload[claim].(type) {
+		case string:
+			values = strings.Fields(v)
+		case []any:
+			for _, item := range v {
+				if s, isString := item.(string); isString {
+					values = append(values, s)
+				}
+			}
+		}
+		for _, value := range values {
+			lower := strings.ToLower(value)
+			for _, privileged := range jwtPrivilegedValues {
+				if lower == privileged || privileged != "*" && strings.Contains(lower, privileged) {
+					return claim, value, true
+				}
+			}
+		}
+	}
+	return "", "", false
+}
+
+// JWTDescriptionToRussian translates the description of a JWT finding,
+// which names the algorithm and expiry, and reports whether desc was one
+func JWTDescriptionToRussian(desc string) (string, bool) {
+	if !strings.HasPrefix(desc, jwtDescription+" (") {
+		return "", false
+	}
+	return LocalizeDescription(russian, desc), true
+}
@@ -0,0 +1,93 @@
+package searcher
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+// makeJWT builds an unverified token with a dummy signature
+func makeJWT(header, payload map[string]any) string {
+	part := func(v map[string]any) string {
+		data, _ := json.Marshal(v)
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+	return part(header) + "." + part(payload) + ".c2lnbmF0dXJlLW5vdC1jaGVja2Vk"
+}
+
+func findJWT(t *testing.T, line string) *Finding {
+	t.Helper()
+	for _, f := range NewScanner().pipeline().analyzeLine("app.log", line, 1, nil) {
+		if f.PatternType == PatternJWT {
+			return f
+		}
+	}
+	return nil
+}
+
+func TestJWTDetection(t *testing.T) {
+	hs256 := map[string]any{"alg": "HS256", "typ": "JWT"}
+	expired := makeJWT(hs256, map[string]any{"sub": "42", "exp": time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC).Unix()})
+	valid := makeJWT(map[string]any{"alg": "RS256"}, map[string]any{"sub": "42", "exp": time.Date(2099, 5, 6, 0, 0, 0, 0, time.UTC).Unix()})
+	admin := makeJWT(map[string]any{"alg": "RS256"}, map[string]any{"sub": "42", "exp": time.Date(2099, 5, 6, 0, 0, 0, 0, time.UTC).Unix(), "roles": []string{"user", "Admin"}})
+	noExp := makeJWT(hs256, map[string]any{"sub": "42", "scope": "read write"})
+
+	tests := []struct {
+		name     string
+		token    string
+		severity Severity
+		desc     string
+	}{
+		{"expired", expired, Low, "JWT detected (alg HS256, expired 2020-01-02)"},
+		{"valid", valid, High, "JWT detected (alg RS256, expires 2099-05-06)"},
+		{"without exp", noExp, High, "JWT detected (alg HS256, no expiry)"},
+	}
+	for _, tt := range tests {
+		f := findJWT(t, "Authorization: Bearer "+tt.token)
+		if f == nil {
+			t.Errorf("%s: token not found", tt.name)
+			continue
+		}
+		if f.Severity != tt.severity || f.Description != tt.desc || f.MatchedText != tt.token {
+			t.Errorf("%s: got %s %q %q", tt.name, f.Severity, f.Description, f.MatchedText)
+		}
+	}
+
+	plain, privileged := findJWT(t, "jwt "+valid), findJWT(t, "jwt "+admin)
+	if plain == nil || privileged == nil {
+		t.Fatal("tokens not found")
+	}
+	if privileged.RiskScore <= plain.RiskScore {
+		t.Errorf("admin role should raise the risk score: %.1f <= %.1f", privileged.RiskScore, plain.RiskScore)
+	}
+	trace := NewScanner().Evaluate("jwt "+admin, "app.log")
+	var bonus bool
+	for _, m := range trace.Matches {
+		for _, step := range m.Steps {
+			bonus = bonus || step.Stage == StageRule && strings.Contains(step.Detail, "roles = Admin")
+		}
+	}
+	if !bonus {
+		t.Error("trace should explain the admin bonus")
+	}
+
+	if got := descriptionToRussian(tests[0].desc); got != "Обнаружен JWT (алгоритм HS256, истёк 2020-01-02)" {
+		t.Errorf("translated description = %q", got)
+	}
+}
+
+func TestJWTMalformed(t *testing.T) {
+	notJSON := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256"`)) // Cut short
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"42"}`))
+	for _, line := range []string{
+		"eyJ" + strings.Repeat("x", 20) + "." + payload + ".sig",
+		notJSON + "." + payload + ".sig",
+		"eyJhbGciOiJIUzI1NiJ9.eyJzdWIiOiI0MiJ9", // No signature part
+	} {
+		if f := findJWT(t, line); f != nil {
+			t.Errorf("malformed token reported: %q", f.MatchedText)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package searcher
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"unicode/utf8"
+)
+
+// DefaultMaxLineLength is the longest line scanned in one piece; longer
+// lines, as in minified bundles and one-line dumps, are scanned in chunks
+const DefaultMaxLineLength = 1024 * 1024
+
+// lineChunkOverlap is how much consecutive chunks of a long line share, so
+// a secret crossing a chunk boundary is still matched whole
+const lineChunkOverlap = 4096
+
+// lineChunk is a line or, for lines over the length cap, a piece of one
+type lineChunk struct {
+	Text   string
+	Offset int  // Byte offset of Text in the line
+	Runes  int  // Rune offset of Text in the line
+	Final  bool // Last chunk of the line
+	Owned  int  // Matches starting at or after this byte of Text belong to the next chunk
+}
+
+// lineReader reads lines of any length without holding more than one chunk
+// of a line in memory. Unlike bufio.Scanner it never fails on long lines
+type lineReader struct {
+	r         *bufio.Reader
+	maxLength int
+	overlap   int
+	pending   []byte // Unread part of the current line
+	eol       bool   // pending holds the rest of the line
+	offset    int
+	runes     int
+}
+
+func newLineReader(r io.Reader, maxLength int) *lineReader {
+	if maxLength <= 0 {
+		maxLength = DefaultMaxLineLength
+	}
+	overlap := lineChunkOverlap
+	if overlap > maxLength/4 {
+		overlap = maxLength / 4
+	}
+	return &lineReader{r: bufio.NewReader(r), maxLength: maxLength, overlap: overlap}
+}
+
+// Next returns the next line or chunk, and io.EOF after the last one
+func (lr *lineReader) Next() (lineChunk, error) {
+	for !lr.eol && len(lr.pending) <= lr.maxLength {
+		fragment, err := lr.r.ReadSlice('\n')
+		lr.pending = append(lr.pending, fragment...)
+		switch err {
+		case nil:
+			lr.eol = true
+		case bufio.ErrBufferFull:
+		case io.EOF:
+			if len(lr.pending) == 0 {
+				return lineChunk{}, io.EOF
+			}
+			lr.eol = true
+		default:
+			return lineChunk{}, err
+		}
+	}
+
+	line := lr.pending
+	if lr.eol {
+		line = bytes.TrimSuffix(bytes.TrimSuffix(line, []byte("\n")), []byte("\r"))
+		if len(line) <= lr.maxLength {
+			chunk := lineChunk{Text: string(line), Offset: lr.offset, Runes: lr.runes, Final: true, Owned: len(line)}
+			lr.pending, lr.eol, lr.offset, lr.runes = lr.pending[:0], false, 0, 0
+			return chunk, nil
+		}
+	}
+
+	// Cut at a rune boundary and keep the overlap for the next chunk
+	end := runeStart(line, lr.maxLength)
+	next := runeStart(line, end-lr.overlap)
+	chunk := lineChunk{Text: string(line[:end]), Offset: lr.offset, Runes: lr.runes, Owned: next}
+	lr.offset += next
+	lr.runes += utf8.RuneCount(line[:next])
+	lr.pending = append(lr.pending[:0], lr.pending[next:]...)
+	return chunk, nil
+}
+
+// runeStart moves i back to the start of the rune it falls in
+func runeStart(b []byte, i int) int {
+	for i > 0 && i < len(b) && !utf8.RuneStart(b[i]) {
+		i--
+	}
+	return i
+}
+
+// analyzeChunk analyzes a line or one chunk of a long line. Columns of the
+// findings are shifted to the whole line, and matches that start in the
+// overlap are left to the next chunk, which sees them whole
+func (dp detectionPipeline) analyzeChunk(path string, chunk lineChunk, lineNum int) []*Finding {
+	findings := dp.analyzeLine(path, chunk.Text, lineNum, nil)
+	if chunk.Offset == 0 && chunk.Final {
+		return findings
+	}
+	kept := findings[:0]
+	for _, f := range findings {
+		if f.ByteStart >= chunk.Owned {
+			continue
+		}
+		f.ColumnStart += chunk.Runes
+		f.ColumnEnd += chunk.Runes
+		kept = append(kept, f)
+	}
+	return kept
+}
@@ -0,0 +1,210 @@
+package searcher
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kacebover/password-finder/locale"
+)
+
+// Findings describe themselves in English; reports, the CLI and the GUI
+// show them in the language of a locale.Localizer. The *ToRussian helpers
+// are the same lookups in Russian, for output that takes no locale
+
+// russian localizes the output that takes no Localizer
+var russian = locale.New(locale.Russian)
+
+// english renders the texts kept in English, such as the details of trace steps
+var english = locale.New(locale.English)
+
+// LocalizeDescription translates a finding description, including the
+// parts added to it: the base64 suffix, the JWT details and the failed
+// check of lenient validation
+func LocalizeDescription(l *locale.Localizer, desc string) string {
+	if text, ok := l.Description(desc); ok {
+		return text
+	}
+	// Matches found in the decoded text of a base64 token
+	if base, ok := strings.CutSuffix(desc, Base64EncodedSuffix); ok {
+		return LocalizeDescription(l, base) + l.T("description.base64")
+	}
+	// JWT descriptions name the algorithm and the expiry date
+	if details, ok := strings.CutPrefix(desc, jwtDescription+" ("); ok {
+		return LocalizeDescription(l, jwtDescription) + " (" + localizeJWTDetails(l, details)
+	}
+	// Matches kept without strict validation name the check they failed
+	if base, check, ok := SplitFailedCheck(desc); ok {
+		return LocalizeDescription(l, base) + l.T("description.failed_check", check)
+	}
+	return desc
+}
+
+// localizeJWTDetails translates the details refineJWT puts in parentheses
+func localizeJWTDetails(l *locale.Localizer, details string) string {
+	return strings.NewReplacer(
+		"alg ", l.T("jwt.alg"),
+		"no expiry", l.T("jwt.no_expiry"),
+		"expired ", l.T("jwt.expired"),
+		"expires ", l.T("jwt.expires"),
+	).Replace(details)
+}
+
+// LocalizeSkipReason translates a reason of ScanResult.SkipReasons. Error
+// texts and paths among its arguments are kept as they are
+func LocalizeSkipReason(l *locale.Localizer, reason SkipReason) string {
+	if reason.Key == "" {
+		return reason.Text
+	}
+	return l.T(reason.Key, reason.Args...)
+}
+
+// LocalizeGitStatus describes the git status of a finding's file
+func LocalizeGitStatus(l *locale.Localizer, status string) string {
+	if text, ok := l.Lookup("git." + status); ok && status != "" {
+		return text
+	}
+	return status
+}
+
+// LocalizeBaselineStatus names the baseline status of a finding
+func LocalizeBaselineStatus(l *locale.Localizer, status BaselineStatus) string {
+	if text, ok := l.Lookup("baseline_status." + string(status)); ok && status != "" {
+		return text
+	}
+	return string(status)
+}
+
+// LocalizeCapability names an optional capability of the scan
+func LocalizeCapability(l *locale.Localizer, c Capability) string {
+	if text, ok := l.Lookup("capability." + string(c)); ok {
+		return text
+	}
+	return string(c)
+}
+
+// LocalizeCoverageStatus names the status of a capability
+func LocalizeCoverageStatus(l *locale.Localizer, s CoverageStatus) string {
+	if text, ok := l.Lookup("coverage." + string(s)); ok {
+		return text
+	}
+	return string(s)
+}
+
+// coverageReasonKeys are the catalog keys of the reasons BuildCoverage
+// gives, which reports keep in Russian
+var coverageReasonKeys = []string{
+	"coverage.reason.documents_off",
+	"coverage.reason.ocr_off",
+	"coverage.reason.archives_off",
+	"coverage.reason.ai_off",
+}
+
+// LocalizeCoverageReason translates the reason a capability did not run;
+// reasons it does not know, e.g. of imported reports, are kept
+func LocalizeCoverageReason(l *locale.Localizer, reason string) string {
+	for _, key := range coverageReasonKeys {
+		if reason == russian.T(key) {
+			return l.T(key)
+		}
+	}
+	suffix := ""
+	if base, ok := strings.CutSuffix(reason, russian.T("coverage.reason.rules_fallback")); ok {
+		reason, suffix = base, l.T("coverage.reason.rules_fallback")
+	}
+	for _, title := range dependencyTitles {
+		if reason == russian.T("coverage.reason.unavailable", title) {
+			return l.T("coverage.reason.unavailable", title) + suffix
+		}
+	}
+	if suffix != "" {
+		return reason + suffix
+	}
+	return reason
+}
+
+// LocalizeCoverageSummary formats a coverage entry as one line, e.g.
+// "47 PDFs skipped: Poppler is unavailable"
+func LocalizeCoverageSummary(l *locale.Localizer, c CapabilityCoverage) string {
+	reason := LocalizeCoverageReason(l, c.Reason)
+	if _, ok := l.Lookup("coverage.skipped." + string(c.Capability) + ".one"); !ok || c.FilesAffected == 0 {
+		return LocalizeCapability(l, c.Capability) + ": " + reason
+	}
+	skipped := l.Plural(c.FilesAffected, "coverage.skipped."+string(c.Capability))
+	return fmt.Sprintf("%d %s: %s", c.FilesAffected, skipped, reason)
+}
+
+// LocalizeCoverageWarnings returns one line per capability that was
+// requested but could not run
+func LocalizeCoverageWarnings(l *locale.Localizer, r *CoverageReport) []string {
+	if r == nil {
+		return nil
+	}
+	var warnings []string
+	for _, c := range r.Capabilities {
+		if c.Status == CoverageUnavailable {
+			warnings = append(warnings, LocalizeCoverageSummary(l, c))
+		}
+	}
+	return warnings
+}
+
+// LocalizeErrorStage names the step of scanning a file that failed
+func LocalizeErrorStage(l *locale.Localizer, st ErrorStage) string {
+	if text, ok := l.Lookup("stage." + string(st)); ok && st != "" {
+		return text
+	}
+	return string(st)
+}
+
+// LocalizeNetworkCapability names a capability that may use the network
+func LocalizeNetworkCapability(l *locale.Localizer, c NetworkCapability) string {
+	if text, ok := l.Lookup("network." + string(c)); ok {
+		return text
+	}
+	return string(c)
+}
+
+// LocalizeTruncation is the note of a file matching stopped in after n
+// findings
+func LocalizeTruncation(l *locale.Localizer, n int) string {
+	return l.T("truncation", n)
+}
+
+// LocalizedPageLabel is PageLabel in the language of l
+func (f *Finding) LocalizedPageLabel(l *locale.Localizer) string {
+	switch {
+	case f.PageNumber == 0:
+		return ""
+	case f.PageLine == 0:
+		return l.T("page.number", f.PageNumber)
+	}
+	return l.T("page.line", f.PageNumber, f.PageLine)
+}
+
+// dependencyDescriptionKeys are the catalog keys of the descriptions of
+// the external tools, which statuses keep in Russian
+var dependencyDescriptionKeys = []string{
+	"dependency.desc.tesseract",
+	"dependency.desc.poppler",
+	"dependency.desc.ollama",
+	"dependency.desc.7z",
+}
+
+// LocalizeDependencyDescription translates what an external tool is used for
+func LocalizeDependencyDescription(l *locale.Localizer, s *DependencyStatus) string {
+	for _, key := range dependencyDescriptionKeys {
+		if s.Description == russian.T(key) {
+			return l.T(key)
+		}
+	}
+	return s.Description
+}
+
+// LocalizeVersionWarning describes an outdated dependency, "" for a
+// supported one
+func LocalizeVersionWarning(l *locale.Localizer, s *DependencyStatus) string {
+	if !s.Outdated {
+		return ""
+	}
+	return l.T("dependency.outdated", s.Name, s.Version, s.MinVersion)
+}
@@ -0,0 +1,94 @@
+package searcher
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/kacebover/password-finder/locale"
+)
+
+// cyrillic matches Russian text left in an English report
+var cyrillic = regexp.MustCompile(`\p{Cyrillic}+`)
+
+// TestEnglishReports tests reports in English carry no Russian text
+func TestEnglishReports(t *testing.T) {
+	result := statisticsFixture()
+	result.AddFinding(&Finding{
+		FilePath: "/app/docs/manual.pdf", LineNumber: 4, PageNumber: 2, PageLine: 3, Severity: High, RiskScore: 60,
+		PatternType: PatternJWT, Description: "JWT detected (alg HS256, no expiry)" + Base64EncodedSuffix,
+		MatchedText: "eyJhbGciOiJIUzI1NiJ9.e30.sig", Context: "token: eyJhbGciOiJIUzI1NiJ9.e30.sig",
+	})
+	result.Truncated = map[string]int{"/app/config/prod.env": 50}
+	result.FilesScanned = 12
+	result.Root = "/app"
+
+	generator := NewReportGenerator(result)
+	generator.SetLocale(locale.New(locale.English))
+	dir := t.TempDir()
+	for name, export := range map[string]func(string) error{
+		"report.txt":   generator.ExportPlainText,
+		"report.csv":   generator.ExportCSV,
+		"report.html":  generator.ExportHTML,
+		"report.sarif": generator.ExportSARIF,
+		"report.xml":   generator.ExportJUnit,
+	} {
+		path := filepath.Join(dir, name)
+		if err := export(path); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if words := cyrillic.FindAllString(string(data), 5); len(words) > 0 {
+			t.Errorf("%s: Russian text %q", name, words)
+		}
+	}
+}
+
+// TestLocalizedRules tests every built-in rule is named and described in
+// every language
+func TestLocalizedRules(t *testing.T) {
+	for _, lang := range locale.Langs {
+		l := locale.New(lang)
+		for _, p := range NewPatterns().List() {
+			if _, ok := l.Lookup("pattern." + string(p.Type)); !ok {
+				t.Errorf("%s: %s: no name for type %s", lang, p.Name, p.Type)
+			}
+			if _, ok := l.Description(p.Description); !ok {
+				t.Errorf("%s: %s: no translation for %q", lang, p.Name, p.Description)
+			}
+		}
+	}
+	en := locale.New(locale.English)
+	if got := LocalizeDescription(en, "JWT detected (alg RS256, expired 2024-01-02)"); got != "JWT detected (alg RS256, expired 2024-01-02)" {
+		t.Errorf("English JWT description = %q", got)
+	}
+	if got := LocalizeDescription(russian, "API Key detected"+Base64EncodedSuffix); got != "Обнаружен API-ключ (в base64)" {
+		t.Errorf("Russian base64 description = %q", got)
+	}
+}
+
+func TestLocalizeSkipReason(t *testing.T) {
+	en := locale.New(locale.English)
+	tests := []struct {
+		reason   SkipReason
+		want, ru string
+	}{
+		{skipReason("skip.no_extractor"), "no extractor (turn on -docs or -ocr)", "нет экстрактора (включите -docs или -ocr)"},
+		{skipReason("skip.ocr_off"), "OCR is off", "OCR отключён"},
+		{skipReason("skip.read_error", "нет доступа"), "read error: нет доступа", "ошибка чтения: нет доступа"},
+		{skipReason("skip.pdf_pages", 50, 120), "checked the first 50 of 120 PDF pages", "проверены первые 50 из 120 страниц PDF"},
+		{skipText(ExtensionFilteredReason), ExtensionFilteredReason, ExtensionFilteredReason},
+	}
+	for _, tt := range tests {
+		if got := LocalizeSkipReason(en, tt.reason); got != tt.want {
+			t.Errorf("LocalizeSkipReason(%+v) = %q, want %q", tt.reason, got, tt.want)
+		}
+		if got := tt.reason.String(); got != tt.ru {
+			t.Errorf("%+v.String() = %q, want %q", tt.reason, got, tt.ru)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package searcher
+
+import (
+	"container/list"
+	"sync"
+)
+
+// LRUCache is a size-bounded cache that evicts the least recently used
+// entries once the total size exceeds its limit. It is safe for concurrent use
+type LRUCache[V any] struct {
+	mu        sync.Mutex
+	maxBytes  int64
+	size      int64
+	evictions int64
+	order     *list.List // Front is the most recently used
+	items     map[string]*list.Element
+}
+
+type lruEntry[V any] struct {
+	key   string
+	value V
+	size  int64
+}
+
+// NewLRUCache creates a cache holding at most maxBytes
+func NewLRUCache[V any](maxBytes int64) *LRUCache[V] {
+	return &LRUCache[V]{
+		maxBytes: maxBytes,
+		order:    list.New(),
+		items:    make(map[string]*list.Element),
+	}
+}
+
+// Get returns the value for key and marks it as recently used
+func (c *LRUCache[V]) Get(key string) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.items[key]; ok {
+		c.order.MoveToFront(elem)
+		return elem.Value.(*lruEntry[V]).value, true
+	}
+	var zero V
+	return zero, false
+}
+
+// Put stores a value of the given size, evicting old entries as needed.
+// Values larger than the whole cache are not stored
+func (c *LRUCache[V]) Put(key string, value V, size int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.items[key]; ok {
+		c.removeElement(elem)
+	}
+	if size > c.maxBytes {
+		return
+	}
+	c.items[key] = c.order.PushFront(&lruEntry[V]{key: key, value: value, size: size})
+	c.size += size
+	for c.size > c.maxBytes {
+		c.removeElement(c.order.Back())
+		c.evictions++
+	}
+}
+
+// removeElement drops an entry; the caller holds c.mu
+func (c *LRUCache[V]) removeElement(elem *list.Element) {
+	entry := c.order.Remove(elem).(*lruEntry[V])
+	delete(c.items, entry.key)
+	c.size -= entry.size
+}
+
+// Clear drops every entry
+func (c *LRUCache[V]) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.order.Init()
+	c.items = make(map[string]*list.Element)
+	c.size = 0
+}
+
+// Stats returns the current usage of the cache
+func (c *LRUCache[V]) Stats(name string) CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return CacheStats{Name: name, Entries: len(c.items), Bytes: c.size, MaxBytes: c.maxBytes, Evictions: c.evictions}
+}
+
+// CacheStats describes the usage of one cache
+type CacheStats struct {
+	Name      string `json:"name"`
+	Entries   int    `json:"entries"`
+	Bytes     int64  `json:"bytes"`
+	MaxBytes  int64  `json:"max_bytes"`
+	Evictions int64  `json:"evictions"`
+}
+
+// DefaultOCRCacheBytes bounds the text kept for recently recognised images
+const DefaultOCRCacheBytes = 16 * 1024 * 1024
+
+// ocrTextCache keeps OCR output across scans, so a rescan of the same
+// images in the GUI does not run Tesseract again
+var ocrTextCache = NewLRUCache[string](DefaultOCRCacheBytes)
+
+// CacheUsage returns the usage of the process-wide caches
+func CacheUsage() []CacheStats {
+	return []CacheStats{ocrTextCache.Stats("OCR")}
+}
+
+// ReleaseCaches empties the process-wide caches and closes idle Tesseract
+// clients
+func ReleaseCaches() {
+	ocrTextCache.Clear()
+	if pool := nativeOCR(); pool != nil {
+		pool.close()
+	}
+}
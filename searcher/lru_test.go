@@ -0,0 +1,78 @@
+package searcher
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLRUCacheEviction(t *testing.T) {
+	cache := NewLRUCache[string](10)
+	cache.Put("a", "aaaa", 4)
+	cache.Put("b", "bbbb", 4)
+
+	// Reading a makes b the least recently used
+	if v, ok := cache.Get("a"); !ok || v != "aaaa" {
+		t.Fatalf("Get(a) = %q, %v", v, ok)
+	}
+	cache.Put("c", "cccc", 4)
+
+	if _, ok := cache.Get("b"); ok {
+		t.Error("b should have been evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := cache.Get(key); !ok {
+			t.Errorf("%s should still be cached", key)
+		}
+	}
+	stats := cache.Stats("test")
+	if stats.Entries != 2 || stats.Bytes != 8 || stats.Evictions != 1 {
+		t.Errorf("stats = %+v", stats)
+	}
+
+	// Replacing a key does not count it twice
+	cache.Put("a", "aa", 2)
+	if stats := cache.Stats("test"); stats.Bytes != 6 {
+		t.Errorf("size after replace = %d, want 6", stats.Bytes)
+	}
+
+	// Values larger than the cache are not stored
+	cache.Put("huge", "x", 11)
+	if _, ok := cache.Get("huge"); ok {
+		t.Error("oversized value should not be stored")
+	}
+
+	cache.Clear()
+	if stats := cache.Stats("test"); stats.Entries != 0 || stats.Bytes != 0 {
+		t.Errorf("stats after Clear = %+v", stats)
+	}
+}
+
+func TestOCRTextCache(t *testing.T) {
+	t.Cleanup(ReleaseCaches)
+	image := filepath.Join(t.TempDir(), "receipt.png")
+	os.WriteFile(image, []byte("not really a png"), 0644)
+
+	// A cached result is returned without running Tesseract
+	key := ocrCacheKey(image)
+	ocrTextCache.Put(key, "password = hunter2", int64(len(key)))
+	content, err := NewDocumentExtractor(true).extractImage(context.Background(), image)
+	if err != nil || content.Text != "password = hunter2" {
+		t.Fatalf("extractImage = %+v, %v", content, err)
+	}
+	if usage := CacheUsage(); len(usage) != 1 || usage[0].Entries != 1 {
+		t.Errorf("cache usage = %+v", usage)
+	}
+
+	// A changed file is a different key
+	os.WriteFile(image, []byte("another image, longer"), 0644)
+	if ocrCacheKey(image) == key {
+		t.Error("key should change with the file")
+	}
+
+	ReleaseCaches()
+	if usage := CacheUsage(); usage[0].Entries != 0 {
+		t.Errorf("cache not released: %+v", usage)
+	}
+}
@@ -1,5 +1,7 @@
 package searcher
 
+import "strconv"
+
 // LuhnValidator validates credit card numbers using the Luhn algorithm
 type LuhnValidator struct{}
 
@@ -96,3 +98,44 @@ func (lv *LuhnValidator) ValidateAndClassify(cardNumber string) (bool, string) {
 	}
 	return false, ""
 }
+
+// ValidCardNumber reports whether a number, with optional spaces or dashes,
+// belongs to a known card network and passes the Luhn check
+func ValidCardNumber(number string) bool {
+	digits := digitsOnly(number)
+	return cardNetwork(digits) != "" && luhnCheck(digits)
+}
+
+// cardNetwork returns the network of a card number from its issuer
+// identification number (IIN) prefix and length, or "" if none matches
+func cardNetwork(digits string) string {
+	n := len(digits)
+	if n < 13 || n > 19 {
+		return ""
+	}
+	prefix := func(width int) int {
+		v, _ := strconv.Atoi(digits[:width])
+		return v
+	}
+	p2, p3, p4 := prefix(2), prefix(3), prefix(4)
+
+	switch {
+	case digits[0] == '4' && (n == 13 || n == 16 || n == 19):
+		return "Visa"
+	case n == 16 && (p2 >= 51 && p2 <= 55 || p4 >= 2221 && p4 <= 2720):
+		return "Mastercard"
+	case n == 15 && (p2 == 34 || p2 == 37):
+		return "American Express"
+	case n == 16 && p4 >= 2200 && p4 <= 2204:
+		return "Mir"
+	case n >= 16 && (p4 == 6011 || p2 == 65 || p3 >= 644 && p3 <= 649):
+		return "Discover"
+	case n >= 16 && p4 >= 3528 && p4 <= 3589:
+		return "JCB"
+	case n >= 14 && (p3 >= 300 && p3 <= 305 || p2 == 36 || p2 == 38):
+		return "Diners Club"
+	case n >= 16 && p2 == 62:
+		return "UnionPay"
+	}
+	return ""
+}
@@ -164,3 +164,84 @@ func BenchmarkLuhnCheckWithFormatting(b *testing.B) {
 	}
 }
 
+
+func TestValidCardNumber(t *testing.T) {
+	tests := []struct {
+		number  string
+		network string
+		valid   bool
+	}{
+		{"4111111111111111", "Visa", true},
+		{"4111-1111-1111-1111", "Visa", true},
+		{"4111 1111 1111 1111", "Visa", true},
+		{"4222222222222", "Visa", true},
+		{"5500000000000004", "Mastercard", true},
+		{"2221000000000009", "Mastercard", true},
+		{"378282246310005", "American Express", true},
+		{"3782 822463 10005", "American Express", true},
+		{"2200000000000004", "Mir", true},
+		{"6011000990139424", "Discover", true},
+		{"3530111333300000", "JCB", true},
+		{"30569309025904", "Diners Club", true},
+		{"6200000000000005", "UnionPay", true},
+
+		{"4111111111111112", "Visa", false},    // Fails Luhn
+		{"4111-1111-1111-1112", "Visa", false}, // Fails Luhn
+		{"0000000000000000", "", false},        // Passes Luhn, no network
+		{"9999999999999995", "", false},        // Passes Luhn, no network
+		{"2721000000000008", "", false},        // Past the Mastercard 2-series
+		{"37828224631000", "", false},          // Amex is 15 digits
+		{"4111.1111.1111.1111", "", false},     // Only spaces and dashes separate groups
+	}
+
+	for _, tt := range tests {
+		if got := ValidCardNumber(tt.number); got != tt.valid {
+			t.Errorf("ValidCardNumber(%q) = %v, want %v", tt.number, got, tt.valid)
+		}
+		if got := cardNetwork(digitsOnly(tt.number)); got != tt.network {
+			t.Errorf("cardNetwork(%q) = %q, want %q", tt.number, got, tt.network)
+		}
+	}
+}
+
+func TestCreditCardStrictValidation(t *testing.T) {
+	text := "paid with 4111-1111-1111-1111, ref 4111 1111 1111 1112 and 5500000000000004"
+	cards := func(p *Patterns) map[string]*DetectedPattern {
+		found := make(map[string]*DetectedPattern)
+		for _, d := range p.FindAll(text) {
+			if d.Type == PatternCreditCard {
+				found[d.MatchText] = d
+			}
+		}
+		return found
+	}
+
+	patterns := NewPatterns()
+	if !patterns.StrictValidation() {
+		t.Fatal("validation should be strict by default")
+	}
+	found := cards(patterns)
+	if len(found) != 2 || found["4111-1111-1111-1111"] == nil || found["5500000000000004"] == nil {
+		t.Fatalf("strict mode should keep only valid cards, got %v", found)
+	}
+	if d := found["4111-1111-1111-1111"]; d.Severity != Critical || d.Description != "Credit card number detected" {
+		t.Errorf("valid card = %s %q", d.Severity, d.Description)
+	}
+
+	patterns.SetStrictValidation(false)
+	found = cards(patterns)
+	invalid := found["4111 1111 1111 1112"]
+	if len(found) != 3 || invalid == nil {
+		t.Fatalf("lenient mode should keep the invalid card, got %v", found)
+	}
+	if invalid.Severity != Low || invalid.Description != "Credit card number detected (failed Luhn check)" {
+		t.Errorf("invalid card = %s %q", invalid.Severity, invalid.Description)
+	}
+	if found["5500000000000004"].Severity != Critical {
+		t.Error("valid cards keep their severity in lenient mode")
+	}
+
+	if got := descriptionToRussian(invalid.Description); got != "Обнаружен номер банковской карты (не прошёл проверку Luhn)" {
+		t.Errorf("translated description = %q", got)
+	}
+}
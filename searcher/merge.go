@@ -0,0 +1,169 @@
+package searcher
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Separate scans, of several volumes or on several machines, are combined
+// into one result with Merge. Roots may overlap, so a finding both scans
+// reported, at the same path and line with the same rule and match, is kept
+// once. Unlike MergeFindings this is exact matching: both sides come from
+// this scanner and report identical fields
+
+// mergeKey identifies a finding for exact deduplication
+func mergeKey(f *Finding) string {
+	return f.FilePath + "\x00" + strconv.Itoa(f.LineNumber) + "\x00" + string(f.PatternType) + "\x00" + f.MatchedText
+}
+
+// Merge adds the findings and counters of other to the result: findings
+// not already present are added, file, size and error counters are summed,
+// skip reasons are combined and the scan window grows to cover both. It
+// returns how many findings were added and how many were duplicates
+func (sr *ScanResult) Merge(other *ScanResult) (added, duplicates int) {
+	if other == nil || other == sr {
+		return 0, 0
+	}
+	seen := make(map[string]struct{})
+	sr.allFindings()(func(f *Finding) bool {
+		seen[mergeKey(f)] = struct{}{}
+		return true
+	})
+
+	// Pruned findings of other are only in its severity counts
+	pruned := make(map[Severity]int)
+	other.mu.Lock()
+	for severity, n := range other.SeveritySummary {
+		pruned[severity] = n
+	}
+	other.mu.Unlock()
+
+	other.allFindings()(func(f *Finding) bool {
+		pruned[f.Severity]--
+		key := mergeKey(f)
+		if _, ok := seen[key]; ok {
+			duplicates++
+			return true
+		}
+		seen[key] = struct{}{}
+		copied := *f
+		sr.AddFinding(&copied)
+		added++
+		return true
+	})
+
+	other.mu.Lock()
+	defer other.mu.Unlock()
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+
+	for severity, n := range pruned {
+		if n > 0 {
+			sr.SeveritySummary[severity] += n
+		}
+	}
+	sr.FilesScanned += other.FilesScanned
+	sr.FilesSkipped += other.FilesSkipped
+	sr.ErrorCount += other.ErrorCount
+	sr.ErrorsDropped += other.ErrorsDropped
+	for _, e := range other.Errors {
+		sr.addErrorLocked(e)
+	}
+	sr.TotalSize += other.TotalSize
+	sr.PrunedFindings += other.PrunedFindings
+	sr.SuppressedCount += other.SuppressedCount
+	sr.FilteredFindings += other.FilteredFindings
+	sr.HeuristicDropped += other.HeuristicDropped
+	sr.HeuristicDowngraded += other.HeuristicDowngraded
+	for _, note := range other.HeuristicNotes {
+		if len(sr.HeuristicNotes) < MaxHeuristicNotes {
+			sr.HeuristicNotes = append(sr.HeuristicNotes, note)
+		}
+	}
+	if len(other.Truncated) > 0 && sr.Truncated == nil {
+		sr.Truncated = make(map[string]int)
+	}
+	for path, n := range other.Truncated {
+		sr.Truncated[path] = n
+	}
+	sr.NestedArchivesScanned += other.NestedArchivesScanned
+	sr.CacheHits += other.CacheHits
+	if len(other.ExtensionSkips) > 0 && sr.ExtensionSkips == nil {
+		sr.ExtensionSkips = make(map[string]int)
+	}
+	for ext, n := range other.ExtensionSkips {
+		sr.ExtensionSkips[ext] += n
+	}
+	sr.Cancelled = sr.Cancelled || other.Cancelled
+
+	if sr.StartTime == 0 || (other.StartTime != 0 && other.StartTime < sr.StartTime) {
+		sr.StartTime = other.StartTime
+	}
+	if other.EndTime > sr.EndTime {
+		sr.EndTime = other.EndTime
+	}
+
+	if sr.SkipReasons == nil {
+		sr.SkipReasons = make(map[string]SkipReason)
+	}
+	for path, reason := range other.SkipReasons {
+		if _, ok := sr.SkipReasons[path]; !ok {
+			sr.SkipReasons[path] = reason
+		}
+	}
+	if sr.capabilityGaps == nil {
+		sr.capabilityGaps = make(map[Capability]int)
+	}
+	for capability, n := range other.capabilityGaps {
+		sr.capabilityGaps[capability] += n
+	}
+	if len(other.regexTimes) > 0 {
+		times := make(map[string]time.Duration, len(sr.regexTimes)+len(other.regexTimes))
+		for rule, elapsed := range sr.regexTimes {
+			times[rule] += elapsed
+		}
+		for rule, elapsed := range other.regexTimes {
+			times[rule] += elapsed
+		}
+		sr.regexTimes = times
+	}
+	sr.Baselined = append(sr.Baselined, other.Baselined...)
+
+	// Coverage of imported reports is kept as is; a merged result is only
+	// as covered as the first report that described it
+	if sr.Coverage == nil {
+		sr.Coverage = other.Coverage
+	}
+	// Baseline paths are relative to a single root
+	if sr.Root != other.Root {
+		sr.Root = ""
+	}
+	return added, duplicates
+}
+
+// MergeResults combines results into a new one, see ScanResult.Merge
+func MergeResults(results ...*ScanResult) *ScanResult {
+	merged := NewScanResult()
+	for i, result := range results {
+		if result == nil {
+			continue
+		}
+		if i == 0 {
+			merged.Root = result.Root
+		}
+		merged.Merge(result)
+	}
+	return merged
+}
+
+// ImportJSON loads a JSON report written by ExportJSON, possibly on another
+// machine, and merges it into the result of the generator
+func (rg *ReportGenerator) ImportJSON(path string) (added, duplicates int, err error) {
+	imported, err := LoadScanResult(path)
+	if err != nil {
+		return 0, 0, fmt.Errorf("не удалось импортировать отчёт: %w", err)
+	}
+	added, duplicates = rg.result.Merge(imported)
+	return added, duplicates, nil
+}
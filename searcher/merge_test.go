@@ -0,0 +1,103 @@
+package searcher
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestMergeOverlappingRoots(t *testing.T) {
+	shared := &Finding{FilePath: "/mnt/data/shared/app.env", LineNumber: 3, PatternType: PatternPassword, Severity: High, MatchedText: "password=hunter2"}
+
+	a := NewScanResult()
+	a.Root = "/mnt/data"
+	a.StartTime, a.EndTime = 100, 200
+	a.FilesScanned, a.FilesSkipped, a.ErrorCount, a.TotalSize = 10, 2, 1, 1000
+	a.SkipReasons["/mnt/data/big.iso"] = skipReason("skip.too_large")
+	a.AddFinding(shared)
+	a.AddFinding(&Finding{FilePath: "/mnt/data/a.env", LineNumber: 1, PatternType: PatternAWSKey, Severity: Critical, MatchedText: "AKIAEXAMPLE"})
+
+	// The second scan covers part of the first root
+	copied := *shared
+	b := NewScanResult()
+	b.Root = "/mnt/data/shared"
+	b.StartTime, b.EndTime = 50, 150
+	b.FilesScanned, b.FilesSkipped, b.ErrorCount, b.TotalSize = 4, 1, 0, 300
+	b.SkipReasons["/mnt/data/shared/x.bin"] = skipText("двоичный файл")
+	b.AddFinding(&copied)
+	b.AddFinding(&Finding{FilePath: "/mnt/data/shared/app.env", LineNumber: 4, PatternType: PatternPassword, Severity: Medium, MatchedText: "password=other"})
+
+	merged := MergeResults(a, b)
+	if merged.TotalFindings() != 3 {
+		t.Errorf("%d findings, want 3 with the shared one kept once", merged.TotalFindings())
+	}
+	if merged.GetSeverityCount(High) != 1 || merged.GetSeverityCount(Critical) != 1 || merged.GetSeverityCount(Medium) != 1 {
+		t.Errorf("severity summary %v", merged.SeveritySummary)
+	}
+	if merged.FilesScanned != 14 || merged.FilesSkipped != 3 || merged.ErrorCount != 1 || merged.TotalSize != 1300 {
+		t.Errorf("counters %d/%d/%d/%d, want 14/3/1/1300", merged.FilesScanned, merged.FilesSkipped, merged.ErrorCount, merged.TotalSize)
+	}
+	if merged.StartTime != 50 || merged.EndTime != 200 {
+		t.Errorf("scan window %d-%d, want 50-200", merged.StartTime, merged.EndTime)
+	}
+	if len(merged.SkipReasons) != 2 {
+		t.Errorf("skip reasons %v", merged.SkipReasons)
+	}
+	if merged.Root != "" {
+		t.Errorf("Root = %q, want empty for different roots", merged.Root)
+	}
+
+	// Merging the same result again only finds duplicates
+	if added, duplicates := merged.Merge(b); added != 0 || duplicates != 2 {
+		t.Errorf("Merge = %d added, %d duplicates", added, duplicates)
+	}
+	if added, _ := merged.Merge(merged); added != 0 {
+		t.Error("merging a result into itself added findings")
+	}
+}
+
+func TestMergeKeepsPrunedCounts(t *testing.T) {
+	b := NewScanResult()
+	for i := 0; i < 4; i++ {
+		b.AddFinding(syntheticFinding(i))
+	}
+	b.Prune(PruneOptions{MinSeverity: High})
+
+	merged := MergeResults(NewScanResult(), b)
+	if merged.TotalFindings() != 2 || merged.PrunedFindings != 2 || merged.GetSeverityCount(Low) != 1 {
+		t.Errorf("%d findings, %d pruned, %d low", merged.TotalFindings(), merged.PrunedFindings, merged.GetSeverityCount(Low))
+	}
+}
+
+func TestReportGeneratorImportJSON(t *testing.T) {
+	dir := t.TempDir()
+	var paths []string
+	for i, name := range []string{"host1.json", "host2.json"} {
+		result := NewScanResult()
+		result.FilesScanned = 5
+		result.AddFinding(syntheticFinding(0))
+		result.AddFinding(syntheticFinding(i + 1))
+		path := filepath.Join(dir, name)
+		if err := NewReportGenerator(result).ExportJSON(path); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+
+	combined := NewScanResult()
+	generator := NewReportGenerator(combined)
+	for i, path := range paths {
+		added, duplicates, err := generator.ImportJSON(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if added != 2-i || duplicates != i {
+			t.Errorf("%s: %d added, %d duplicates", path, added, duplicates)
+		}
+	}
+	if combined.TotalFindings() != 3 || combined.FilesScanned != 10 {
+		t.Errorf("%d findings, %d files", combined.TotalFindings(), combined.FilesScanned)
+	}
+	if _, _, err := generator.ImportJSON(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("expected an error for a missing report")
+	}
+}
@@ -0,0 +1,301 @@
+package searcher
+
+import (
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kacebover/password-finder/locale"
+)
+
+// Metrics of a scan tell where its time went. Every interval is measured
+// with time.Now and Sub, which use the monotonic clock, so a change of the
+// wall clock during the scan does not distort them. Collecting costs two
+// clock reads and a short lock per file and is on by default, see
+// Scanner.SetMetrics
+
+// MetricCategory is a kind of processing whose time is summed up
+type MetricCategory string
+
+// Processing categories of ScanMetrics
+const (
+	CategoryText     MetricCategory = "text"     // Files read as text
+	CategoryDocument MetricCategory = "document" // Text extraction from documents
+	CategoryArchive  MetricCategory = "archive"  // Archives opened and their members scanned
+	CategoryOCR      MetricCategory = "ocr"      // Text recognition in images
+	CategoryImage    MetricCategory = "image"    // EXIF and XMP metadata of images
+)
+
+// metricCategories is the order categories are shown in
+var metricCategories = []MetricCategory{CategoryText, CategoryDocument, CategoryArchive, CategoryOCR, CategoryImage}
+
+// SlowestFilesKept is how many of the slowest files ScanMetrics lists
+const SlowestFilesKept = 10
+
+// MaxUtilizationSamples is how many utilization samples a scan keeps; when
+// they run out, every other one is dropped and sampling slows down by half
+const MaxUtilizationSamples = 60
+
+// utilizationInterval is the time between utilization samples at the start
+// of a scan
+const utilizationInterval = time.Second
+
+// ScanMetrics describes the performance of a scan
+type ScanMetrics struct {
+	WallTime       time.Duration                      `json:"wall_time_ns"`
+	PausedTime     time.Duration                      `json:"paused_time_ns,omitempty"`
+	BytesScanned   int64                              `json:"bytes_scanned"`
+	BytesPerSecond float64                            `json:"bytes_per_second"` // Over the time the scan was not paused
+	Categories     map[MetricCategory]CategoryMetrics `json:"categories,omitempty"`
+	SlowestFiles   []FileTiming                       `json:"slowest_files,omitempty"` // Slowest first
+	Utilization    []UtilizationSample                `json:"utilization,omitempty"`
+}
+
+// CategoryMetrics is the number of files of a category and the time spent
+// on them, summed over the workers
+type CategoryMetrics struct {
+	Files int           `json:"files"`
+	Time  time.Duration `json:"time_ns"`
+}
+
+// FileTiming is how long the scan of one file took
+type FileTiming struct {
+	Path     string        `json:"path"`
+	Duration time.Duration `json:"duration_ns"`
+}
+
+// UtilizationSample is the state of the workers at a moment of the scan
+type UtilizationSample struct {
+	Elapsed     time.Duration `json:"elapsed_ns"`
+	Goroutines  int           `json:"goroutines"`
+	BusyWorkers int           `json:"busy_workers"`
+	Workers     int           `json:"workers"`
+}
+
+// timingHook is called with the category and file of a timed step when
+// it starts; tests use it to make steps slow
+type timingHook func(category MetricCategory, path string)
+
+// SetMetrics turns collection of ScanResult.Metrics on or off
+func (s *Scanner) SetMetrics(enabled bool) {
+	s.noMetrics = !enabled
+}
+
+// scanMetrics collects the metrics of the scan in progress. A nil
+// scanMetrics collects nothing
+type scanMetrics struct {
+	now      func() time.Time // time.Now, replaced by tests
+	start    time.Time
+	workers  int
+	busy     atomic.Int32  // Workers in processFile
+	interval time.Duration // Time to the next utilization sample
+
+	mu         sync.Mutex
+	categories map[MetricCategory]CategoryMetrics
+	slowest    []FileTiming
+	samples    []UtilizationSample
+	stop       chan struct{}
+	stopOnce   sync.Once
+	sampled    chan struct{}
+}
+
+// startMetrics starts collecting the metrics of a new scan, unless they are
+// off
+func (s *Scanner) startMetrics() {
+	s.metrics = nil
+	if s.noMetrics {
+		return
+	}
+	now := time.Now
+	if s.metricsClock != nil {
+		now = s.metricsClock
+	}
+	m := &scanMetrics{
+		now:        now,
+		start:      now(),
+		workers:    s.workers,
+		interval:   utilizationInterval,
+		categories: make(map[MetricCategory]CategoryMetrics),
+		stop:       make(chan struct{}),
+		sampled:    make(chan struct{}),
+	}
+	go m.sample()
+	s.metrics = m
+}
+
+// finishMetrics stops the collection and stores the metrics in the result
+func (s *Scanner) finishMetrics() {
+	if s.metrics == nil {
+		return
+	}
+	s.result.Metrics = s.metrics.finish(s.result.TotalSize, s.gate.pausedFor())
+}
+
+// timed runs scan, which processes filePath as category, and adds its
+// time to the category
+func (s *Scanner) timed(category MetricCategory, filePath string, scan func() int) int {
+	m := s.metrics
+	if m == nil {
+		return scan()
+	}
+	start := m.now()
+	if s.metricsHook != nil {
+		s.metricsHook(category, filePath)
+	}
+	found := scan()
+	elapsed := m.now().Sub(start)
+
+	m.mu.Lock()
+	c := m.categories[category]
+	c.Files++
+	c.Time += elapsed
+	m.categories[category] = c
+	m.mu.Unlock()
+	return found
+}
+
+// timeFile runs processFile for filePath and keeps its time if it is among
+// the slowest
+func (s *Scanner) timeFile(filePath string) int {
+	m := s.metrics
+	if m == nil {
+		return s.processFile(filePath)
+	}
+	m.busy.Add(1)
+	start := m.now()
+	found := s.processFile(filePath)
+	m.addFile(filePath, m.now().Sub(start))
+	m.busy.Add(-1)
+	return found
+}
+
+// addFile keeps the time of a file if it is among the slowest
+func (m *scanMetrics) addFile(path string, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.slowest) == SlowestFilesKept && d <= m.slowest[len(m.slowest)-1].Duration {
+		return
+	}
+	i := sort.Search(len(m.slowest), func(i int) bool { return m.slowest[i].Duration < d })
+	if len(m.slowest) < SlowestFilesKept {
+		m.slowest = append(m.slowest, FileTiming{})
+	}
+	copy(m.slowest[i+1:], m.slowest[i:])
+	m.slowest[i] = FileTiming{Path: path, Duration: d}
+}
+
+// sample records the utilization of the workers until the scan ends
+func (m *scanMetrics) sample() {
+	defer close(m.sampled)
+	timer := time.NewTimer(m.interval)
+	defer timer.Stop()
+	for {
+		select {
+		case <-m.stop:
+			return
+		case <-timer.C:
+		}
+		sample := UtilizationSample{
+			Elapsed:     m.now().Sub(m.start),
+			Goroutines:  runtime.NumGoroutine(),
+			BusyWorkers: int(m.busy.Load()),
+			Workers:     m.workers,
+		}
+		m.mu.Lock()
+		if len(m.samples) == MaxUtilizationSamples {
+			for i := 0; i < len(m.samples)/2; i++ {
+				m.samples[i] = m.samples[2*i+1]
+			}
+			m.samples = m.samples[:len(m.samples)/2]
+			m.interval *= 2
+		}
+		m.samples = append(m.samples, sample)
+		m.mu.Unlock()
+		timer.Reset(m.interval)
+	}
+}
+
+// halt stops the sampling; a scan that ends early with an error has no
+// metrics but must not leave the sampler running
+func (m *scanMetrics) halt() {
+	m.stopOnce.Do(func() { close(m.stop) })
+	<-m.sampled
+}
+
+// finish stops the sampling and returns the metrics of the scan
+func (m *scanMetrics) finish(bytes int64, paused time.Duration) *ScanMetrics {
+	m.halt()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	metrics := &ScanMetrics{
+		WallTime:     m.now().Sub(m.start),
+		PausedTime:   paused,
+		BytesScanned: bytes,
+		Categories:   make(map[MetricCategory]CategoryMetrics, len(m.categories)),
+		SlowestFiles: append([]FileTiming(nil), m.slowest...),
+		Utilization:  append([]UtilizationSample(nil), m.samples...),
+	}
+	for category, c := range m.categories {
+		metrics.Categories[category] = c
+	}
+	if active := metrics.WallTime - paused; active > 0 {
+		metrics.BytesPerSecond = float64(bytes) / active.Seconds()
+	}
+	return metrics
+}
+
+// PeakBusyWorkers returns the most workers busy at once in the samples
+// and the size of the pool, zeros without samples
+func (m *ScanMetrics) PeakBusyWorkers() (busy, workers int) {
+	for _, sample := range m.Utilization {
+		busy = max(busy, sample.BusyWorkers)
+		workers = max(workers, sample.Workers)
+	}
+	return busy, workers
+}
+
+// FormatScanMetrics renders the performance block of the text report and
+// the CLI summary
+func FormatScanMetrics(l *locale.Localizer, m *ScanMetrics) string {
+	if m == nil {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString(l.T("metrics.wall", roundDuration(m.WallTime), m.BytesPerSecond/(1024*1024)) + "\n")
+	if m.PausedTime > 0 {
+		sb.WriteString(l.T("metrics.paused", roundDuration(m.PausedTime)) + "\n")
+	}
+	for _, category := range metricCategories {
+		c, ok := m.Categories[category]
+		if !ok {
+			continue
+		}
+		sb.WriteString(l.T("metrics.category", l.T("metrics.category."+string(category)),
+			c.Files, l.Plural(c.Files, "plural.file"), roundDuration(c.Time)) + "\n")
+	}
+	if busy, workers := m.PeakBusyWorkers(); workers > 0 {
+		sb.WriteString(l.T("metrics.workers", busy, workers) + "\n")
+	}
+	if len(m.SlowestFiles) > 0 {
+		sb.WriteString(l.T("metrics.slowest") + "\n")
+		for _, file := range m.SlowestFiles {
+			sb.WriteString("    " + roundDuration(file.Duration).String() + "  " + file.Path + "\n")
+		}
+	}
+	return sb.String()
+}
+
+// roundDuration rounds d for display, keeping about three digits
+func roundDuration(d time.Duration) time.Duration {
+	switch {
+	case d >= time.Second:
+		return d.Round(10 * time.Millisecond)
+	case d >= time.Millisecond:
+		return d.Round(10 * time.Microsecond)
+	}
+	return d.Round(time.Microsecond)
+}
@@ -0,0 +1,255 @@
+package searcher
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// metricsTree writes a file of every category without external tools
+func metricsTree(t *testing.T) string {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("password = FirstSecret123!\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "b.txt"), []byte("nothing here\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "mail.eml"), []byte("Subject: hi\n\npassword = MailSecret456!\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "photo.jpg"), testJPEG(t), 0644)
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, _ := zw.Create("config/.env")
+	w.Write([]byte("password = ZipSecret789!\n"))
+	zw.Close()
+	os.WriteFile(filepath.Join(dir, "backup.zip"), buf.Bytes(), 0644)
+	return dir
+}
+
+// fakeClock is a clock that only moves when told to
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Sleep(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// TestScanMetricsCategories tests the time of every step is added to its
+// category and the file it belongs to, using a clock the steps advance
+func TestScanMetricsCategories(t *testing.T) {
+	dir := metricsTree(t)
+	delays := map[MetricCategory]time.Duration{
+		CategoryText:     5 * time.Millisecond,
+		CategoryDocument: 20 * time.Millisecond,
+		CategoryArchive:  40 * time.Millisecond,
+		CategoryImage:    30 * time.Millisecond,
+	}
+
+	clock := &fakeClock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	scanner := NewScanner()
+	scanner.SetDocumentExtractor(NewDocumentExtractor(false))
+	scanner.SetScanDocuments(true)
+	scanner.SetScanArchives(true)
+	scanner.SetMaxConcurrentFiles(1)
+	scanner.metricsClock = clock.Now
+	scanner.metricsHook = func(category MetricCategory, path string) {
+		clock.Sleep(delays[category])
+	}
+	result, err := scanner.Scan(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := result.Metrics
+	if m == nil {
+		t.Fatal("no metrics collected")
+	}
+	want := map[MetricCategory]CategoryMetrics{
+		CategoryText:     {Files: 2, Time: 10 * time.Millisecond},
+		CategoryDocument: {Files: 1, Time: 20 * time.Millisecond},
+		CategoryArchive:  {Files: 1, Time: 40 * time.Millisecond},
+		CategoryImage:    {Files: 1, Time: 30 * time.Millisecond},
+	}
+	if fmt.Sprint(m.Categories) != fmt.Sprint(want) {
+		t.Errorf("Categories = %v, want %v", m.Categories, want)
+	}
+	if m.WallTime != 100*time.Millisecond {
+		t.Errorf("WallTime = %v, want the 100ms of the steps", m.WallTime)
+	}
+	if m.BytesScanned != result.TotalSize || m.BytesPerSecond != float64(result.TotalSize)/0.1 {
+		t.Errorf("BytesScanned = %d, BytesPerSecond = %v for %d bytes", m.BytesScanned, m.BytesPerSecond, result.TotalSize)
+	}
+
+	var slowest []string
+	for _, file := range m.SlowestFiles {
+		slowest = append(slowest, filepath.Base(file.Path)+"="+file.Duration.String())
+	}
+	if got := strings.Join(slowest, ","); !strings.HasPrefix(got, "backup.zip=40ms,photo.jpg=30ms,mail.eml=20ms,") || len(slowest) != 5 {
+		t.Errorf("SlowestFiles = %s", got)
+	}
+}
+
+// TestScanMetricsMonotonic tests real sleeps are measured with the
+// default clock
+func TestScanMetricsMonotonic(t *testing.T) {
+	dir := metricsTree(t)
+	scanner := NewScanner()
+	scanner.SetDocumentExtractor(NewDocumentExtractor(false))
+	scanner.SetScanArchives(true)
+	scanner.metricsHook = func(category MetricCategory, path string) {
+		if category == CategoryArchive {
+			time.Sleep(30 * time.Millisecond)
+		}
+	}
+	result, err := scanner.Scan(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := result.Metrics
+	if archive := m.Categories[CategoryArchive]; archive.Files != 1 || archive.Time < 30*time.Millisecond {
+		t.Errorf("archive = %+v, want the 30ms sleep", archive)
+	}
+	if m.WallTime < 30*time.Millisecond || len(m.SlowestFiles) == 0 || filepath.Base(m.SlowestFiles[0].Path) != "backup.zip" {
+		t.Errorf("WallTime = %v, SlowestFiles = %+v", m.WallTime, m.SlowestFiles)
+	}
+
+	scanner.SetMetrics(false)
+	if result, _ = scanner.Scan(dir); result.Metrics != nil {
+		t.Errorf("metrics collected while off: %+v", result.Metrics)
+	}
+}
+
+func TestSlowestFilesKept(t *testing.T) {
+	m := &scanMetrics{}
+	for i := 1; i <= SlowestFilesKept+5; i++ {
+		// Durations arrive out of order
+		d := time.Duration((i*7)%(SlowestFilesKept+5)+1) * time.Millisecond
+		m.addFile(fmt.Sprintf("f%d", i), d)
+	}
+	if len(m.slowest) != SlowestFilesKept {
+		t.Fatalf("kept %d files", len(m.slowest))
+	}
+	for i, file := range m.slowest {
+		if want := time.Duration(SlowestFilesKept+5-i) * time.Millisecond; file.Duration != want {
+			t.Errorf("slowest[%d] = %+v, want %v", i, file, want)
+		}
+	}
+}
+
+func TestUtilizationSamples(t *testing.T) {
+	m := &scanMetrics{now: time.Now, start: time.Now(), workers: 4, interval: time.Millisecond,
+		stop: make(chan struct{}), sampled: make(chan struct{})}
+	m.busy.Store(3)
+	go m.sample()
+	// Wait until the samples ran out once and were thinned
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+		m.mu.Lock()
+		thinned := m.interval > time.Millisecond && len(m.samples) > MaxUtilizationSamples/2
+		m.mu.Unlock()
+		if thinned {
+			break
+		}
+	}
+	metrics := m.finish(0, 0)
+	if n := len(metrics.Utilization); n == 0 || n > MaxUtilizationSamples {
+		t.Fatalf("%d samples", n)
+	}
+	for i, sample := range metrics.Utilization {
+		if sample.BusyWorkers != 3 || sample.Workers != 4 || sample.Goroutines == 0 {
+			t.Errorf("sample %d = %+v", i, sample)
+		}
+		if i > 0 && sample.Elapsed <= metrics.Utilization[i-1].Elapsed {
+			t.Errorf("samples out of order at %d", i)
+		}
+	}
+	if busy, workers := metrics.PeakBusyWorkers(); busy != 3 || workers != 4 {
+		t.Errorf("PeakBusyWorkers = %d, %d", busy, workers)
+	}
+}
+
+func TestScanMetricsReports(t *testing.T) {
+	dir := metricsTree(t)
+	scanner := NewScanner()
+	scanner.SetDocumentExtractor(NewDocumentExtractor(false))
+	scanner.SetScanArchives(true)
+	result, err := scanner.Scan(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := t.TempDir()
+	rg := NewReportGenerator(result)
+	jsonPath := filepath.Join(out, "report.json")
+	textPath := filepath.Join(out, "report.txt")
+	if err := rg.ExportJSON(jsonPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := rg.ExportPlainText(textPath); err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ := os.ReadFile(jsonPath)
+	for _, field := range []string{`"metrics": {`, `"wall_time_ns"`, `"bytes_per_second"`, `"archive": {`, `"slowest_files"`} {
+		if !strings.Contains(string(data), field) {
+			t.Errorf("JSON report misses %s", field)
+		}
+	}
+	imported, err := LoadScanResult(jsonPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if imported.Metrics == nil || imported.Metrics.Categories[CategoryArchive].Files != 1 {
+		t.Errorf("imported metrics = %+v", imported.Metrics)
+	}
+
+	text, _ := os.ReadFile(textPath)
+	for _, line := range []string{"ПРОИЗВОДИТЕЛЬНОСТЬ", "Распаковка архивов", "Самые медленные файлы:", "backup.zip"} {
+		if !strings.Contains(string(text), line) {
+			t.Errorf("text report misses %q", line)
+		}
+	}
+}
+
+// TestMetricsSamplerStops tests the sampler has exited when a scan
+// returns, also when it fails
+func TestMetricsSamplerStops(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("password = FirstSecret123!\n"), 0644)
+	scanner := NewScanner()
+	if _, err := scanner.Scan(dir); err != nil {
+		t.Fatal(err)
+	}
+	sampled := scanner.metrics.sampled
+	select {
+	case <-sampled:
+	default:
+		t.Error("sampler still running after the scan")
+	}
+
+	os.WriteFile(filepath.Join(dir, CustomPatternsFile), []byte("rules: [\n"), 0644)
+	if _, err := scanner.Scan(dir); err == nil {
+		t.Fatal("broken patterns file accepted")
+	}
+	if scanner.metrics.sampled == sampled {
+		t.Fatal("the failed scan collected no metrics")
+	}
+	select {
+	case <-scanner.metrics.sampled:
+	default:
+		t.Error("sampler still running after a failed scan")
+	}
+}
@@ -0,0 +1,228 @@
+package searcher
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kacebover/password-finder/locale"
+)
+
+// NetworkCapability is a feature that may open network connections. Every
+// one of them is off until explicitly enabled, so a default scan never
+// leaves the machine
+type NetworkCapability string
+
+const (
+	NetworkAI            NetworkCapability = "ai"            // Ollama requests, including the dependency probe
+	NetworkVerification  NetworkCapability = "verification"  // Checking whether a found credential is live
+	NetworkNotifications NetworkCapability = "notifications" // Sending scan results to chats or webhooks
+	NetworkUpdates       NetworkCapability = "updates"       // Checking for a newer release
+	NetworkStorage       NetworkCapability = "storage"       // Reading files from remote stores such as S3
+)
+
+// networkCapabilityOrder is the order capabilities are listed in reports
+var networkCapabilityOrder = []NetworkCapability{
+	NetworkAI, NetworkVerification, NetworkNotifications, NetworkUpdates, NetworkStorage,
+}
+
+// NetworkCapabilityTitle returns the Russian name of a network capability
+func NetworkCapabilityTitle(c NetworkCapability) string {
+	return LocalizeNetworkCapability(russian, c)
+}
+
+// ErrNetworkDisabled is returned by HTTP clients of a capability that is
+// not enabled
+var ErrNetworkDisabled = errors.New("сетевой доступ выключен")
+
+// NetworkAttempt is a request refused by the policy
+type NetworkAttempt struct {
+	Capability NetworkCapability `json:"capability"`
+	Method     string            `json:"method"`
+	URL        string            `json:"url"`
+	Time       time.Time         `json:"time"`
+}
+
+// NetworkPolicy decides which capabilities may use the network. All HTTP
+// clients of the program come from Client, so the policy sees every request
+type NetworkPolicy struct {
+	mu        sync.Mutex
+	enabled   map[NetworkCapability]bool
+	offline   bool // Overrides enabled: nothing may connect
+	blocked   []NetworkAttempt
+	onBlocked func(NetworkAttempt)
+	transport http.RoundTripper // Used for allowed requests; nil means http.DefaultTransport
+}
+
+var (
+	defaultNetwork     *NetworkPolicy
+	defaultNetworkOnce sync.Once
+)
+
+// Network returns the process-wide network policy
+func Network() *NetworkPolicy {
+	defaultNetworkOnce.Do(func() {
+		defaultNetwork = NewNetworkPolicy()
+	})
+	return defaultNetwork
+}
+
+// NewNetworkPolicy creates a policy with every capability disabled
+func NewNetworkPolicy() *NetworkPolicy {
+	return &NetworkPolicy{enabled: make(map[NetworkCapability]bool)}
+}
+
+// Enable allows or forbids network access for a capability. It has no
+// effect while the policy is offline
+func (p *NetworkPolicy) Enable(c NetworkCapability, enabled bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.enabled[c] = enabled
+}
+
+// SetOffline forbids every capability regardless of Enable (-offline)
+func (p *NetworkPolicy) SetOffline(offline bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.offline = offline
+}
+
+// Offline reports whether the policy was forced offline
+func (p *NetworkPolicy) Offline() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.offline
+}
+
+// Allowed reports whether a capability may use the network
+func (p *NetworkPolicy) Allowed(c NetworkCapability) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return !p.offline && p.enabled[c]
+}
+
+// AllowedCapabilities returns the capabilities that may use the network
+func (p *NetworkPolicy) AllowedCapabilities() []NetworkCapability {
+	var allowed []NetworkCapability
+	for _, c := range networkCapabilityOrder {
+		if p.Allowed(c) {
+			allowed = append(allowed, c)
+		}
+	}
+	return allowed
+}
+
+// SetTransport replaces the transport of allowed requests, e.g. with a
+// recording one in tests; nil restores http.DefaultTransport
+func (p *NetworkPolicy) SetTransport(rt http.RoundTripper) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.transport = rt
+}
+
+// SetBlockedHandler sets a function called for every refused request
+func (p *NetworkPolicy) SetBlockedHandler(handler func(NetworkAttempt)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.onBlocked = handler
+}
+
+// Blocked returns the requests refused since the last ResetBlocked
+func (p *NetworkPolicy) Blocked() []NetworkAttempt {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]NetworkAttempt(nil), p.blocked...)
+}
+
+// ResetBlocked forgets the refused requests
+func (p *NetworkPolicy) ResetBlocked() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.blocked = nil
+}
+
+// Client returns an HTTP client for a capability. The policy is checked on
+// every request, so enabling the capability later takes effect at once
+func (p *NetworkPolicy) Client(c NetworkCapability, timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: policyTransport{policy: p, capability: c},
+	}
+}
+
+// policyTransport refuses requests of a disabled capability
+type policyTransport struct {
+	policy     *NetworkPolicy
+	capability NetworkCapability
+}
+
+func (t policyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	p := t.policy
+	p.mu.Lock()
+	allowed := !p.offline && p.enabled[t.capability]
+	transport := p.transport
+	var handler func(NetworkAttempt)
+	var attempt NetworkAttempt
+	if !allowed {
+		attempt = NetworkAttempt{Capability: t.capability, Method: req.Method, URL: req.URL.Redacted(), Time: time.Now()}
+		p.blocked = append(p.blocked, attempt)
+		handler = p.onBlocked
+	}
+	p.mu.Unlock()
+
+	if !allowed {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		if handler != nil {
+			handler(attempt)
+		}
+		return nil, fmt.Errorf("%w: %s (%s)", ErrNetworkDisabled, NetworkCapabilityTitle(t.capability), req.URL.Host)
+	}
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return transport.RoundTrip(req)
+}
+
+// NetworkCoverage is the network part of a coverage report
+type NetworkCoverage struct {
+	Offline bool                `json:"offline"`
+	Allowed []NetworkCapability `json:"allowed,omitempty"`
+	Blocked []NetworkAttempt    `json:"blocked,omitempty"`
+}
+
+// SetNetwork records the state of the policy and the refused requests
+func (r *CoverageReport) SetNetwork(p *NetworkPolicy) {
+	r.Network = &NetworkCoverage{
+		Offline: p.Offline(),
+		Allowed: p.AllowedCapabilities(),
+		Blocked: p.Blocked(),
+	}
+}
+
+// formatNetworkCoverage renders the network line of FormatCoverage
+func formatNetworkCoverage(l *locale.Localizer, n *NetworkCoverage) string {
+	var line string
+	switch {
+	case n.Offline:
+		line = l.T("coverage.network.offline")
+	case len(n.Allowed) == 0:
+		line = l.T("coverage.network.none")
+	default:
+		names := make([]string, len(n.Allowed))
+		for i, c := range n.Allowed {
+			names[i] = LocalizeNetworkCapability(l, c)
+		}
+		sort.Strings(names)
+		line = l.T("coverage.network.allowed", strings.Join(names, ", "))
+	}
+	if len(n.Blocked) > 0 {
+		line += l.T("coverage.network.blocked", len(n.Blocked))
+	}
+	return line + "\n"
+}
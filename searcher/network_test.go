@@ -0,0 +1,126 @@
+package searcher
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// recordingTransport records every request that reached the network
+type recordingTransport struct {
+	mu       sync.Mutex
+	requests []string
+}
+
+func (rt *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt.mu.Lock()
+	rt.requests = append(rt.requests, req.Method+" "+req.URL.String())
+	rt.mu.Unlock()
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(strings.NewReader(`{"models":[]}`)),
+		Request:    req,
+	}, nil
+}
+
+func (rt *recordingTransport) count() int {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	return len(rt.requests)
+}
+
+// recordNetwork routes allowed requests of the global policy to a recording
+// transport and restores the policy when the test ends
+func recordNetwork(t *testing.T) *recordingTransport {
+	t.Helper()
+	rt := &recordingTransport{}
+	network := Network()
+	network.SetTransport(rt)
+	network.ResetBlocked()
+	t.Cleanup(func() {
+		network.SetTransport(nil)
+		network.SetOffline(false)
+		network.Enable(NetworkAI, false)
+		network.SetBlockedHandler(nil)
+		network.ResetBlocked()
+	})
+	return rt
+}
+
+func TestDefaultScanMakesNoRequests(t *testing.T) {
+	rt := recordNetwork(t)
+	var handled []NetworkAttempt
+	Network().SetBlockedHandler(func(attempt NetworkAttempt) { handled = append(handled, attempt) })
+
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "config.txt"), []byte("password = hunter2hunter2\n"), 0644)
+
+	// A fresh registry so the Ollama probe really runs
+	NewDependencyRegistry().CheckAll()
+	result, err := NewScanner().Scan(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result.Coverage.SetNetwork(Network())
+
+	if n := rt.count(); n != 0 {
+		t.Fatalf("default scan made %d network requests: %v", n, rt.requests)
+	}
+	blocked := result.Coverage.Network.Blocked
+	if len(blocked) == 0 || blocked[0].Capability != NetworkAI || !strings.Contains(blocked[0].URL, "/api/tags") {
+		t.Errorf("Ollama probe not recorded as blocked: %+v", blocked)
+	}
+	if len(handled) != len(blocked) {
+		t.Errorf("handler saw %d attempts, coverage %d", len(handled), len(blocked))
+	}
+	if !strings.Contains(FormatCoverage(russian, result.Coverage), "Сеть: не разрешена (заблокировано запросов:") {
+		t.Errorf("coverage does not mention the network:\n%s", FormatCoverage(russian, result.Coverage))
+	}
+}
+
+func TestNetworkPolicyEnable(t *testing.T) {
+	rt := recordNetwork(t)
+	network := Network()
+	client := network.Client(NetworkAI, 0)
+
+	_, err := client.Get("http://localhost:11434/api/tags")
+	if !errors.Is(err, ErrNetworkDisabled) {
+		t.Errorf("error = %v, want ErrNetworkDisabled", err)
+	}
+
+	// The client checks the policy per request
+	network.Enable(NetworkAI, true)
+	resp, err := client.Get("http://localhost:11434/api/tags")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if rt.count() != 1 {
+		t.Errorf("expected 1 request, got %v", rt.requests)
+	}
+
+	// Other capabilities stay off
+	if _, err := network.Client(NetworkNotifications, 0).Get("http://example.com/hook"); !errors.Is(err, ErrNetworkDisabled) {
+		t.Errorf("notifications error = %v, want ErrNetworkDisabled", err)
+	}
+
+	// Offline overrides Enable
+	network.SetOffline(true)
+	if _, err := client.Get("http://localhost:11434/api/tags"); !errors.Is(err, ErrNetworkDisabled) {
+		t.Errorf("offline error = %v, want ErrNetworkDisabled", err)
+	}
+	if rt.count() != 1 || len(network.Blocked()) != 3 {
+		t.Errorf("requests %v, blocked %+v", rt.requests, network.Blocked())
+	}
+
+	report := &CoverageReport{}
+	report.SetNetwork(network)
+	if !report.Network.Offline || len(report.Network.Allowed) != 0 {
+		t.Errorf("coverage = %+v", report.Network)
+	}
+}
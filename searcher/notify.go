@@ -0,0 +1,387 @@
+package searcher
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Notifications push scan results out of the machine: a summary when a scan
+// ends and, depending on NotifyMode, single findings as they are found.
+// Findings are always sent masked. Requests go through the
+// NetworkNotifications capability of the network policy, run on a queue
+// beside the scan and are retried with backoff; a failure is recorded in the
+// error log of the scan and never fails the scan itself
+
+// NotifyMode selects what is sent
+type NotifyMode string
+
+const (
+	NotifySummary  NotifyMode = "summary"  // Only the summary of a finished scan
+	NotifyCritical NotifyMode = "critical" // Critical findings as they are found, and the summary
+	NotifyAll      NotifyMode = "all"      // Every finding, and the summary
+)
+
+// ParseNotifyMode parses a -notify-on value
+func ParseNotifyMode(value string) (NotifyMode, error) {
+	switch mode := NotifyMode(strings.ToLower(strings.TrimSpace(value))); mode {
+	case NotifySummary, NotifyCritical, NotifyAll:
+		return mode, nil
+	case "":
+		return NotifySummary, nil
+	}
+	return "", fmt.Errorf("неизвестный режим уведомлений: %q (допустимо: summary, critical, all)", value)
+}
+
+// sends reports whether a finding is sent in this mode
+func (m NotifyMode) sends(f *Finding) bool {
+	return m == NotifyAll || (m == NotifyCritical && f.Severity == Critical)
+}
+
+// Kinds of notification events
+const (
+	NotifyEventSummary = "summary"
+	NotifyEventFinding = "finding"
+)
+
+// NotificationEvent is one notification: a scan summary or a finding
+type NotificationEvent struct {
+	Kind    string               `json:"event"`
+	Time    time.Time            `json:"time"`
+	Root    string               `json:"root,omitempty"`
+	Finding *Finding             `json:"finding,omitempty"` // Masked
+	Summary *NotificationSummary `json:"summary,omitempty"`
+}
+
+// NotificationSummary is the outcome of a scan
+type NotificationSummary struct {
+	FilesScanned  int              `json:"files_scanned"`
+	FilesSkipped  int              `json:"files_skipped"`
+	TotalFindings int              `json:"total_findings"`
+	Severities    map[Severity]int `json:"severities"`
+	Errors        int              `json:"errors"`
+	Duration      float64          `json:"duration_seconds"`
+	Cancelled     bool             `json:"cancelled,omitempty"`
+	Text          string           `json:"text"` // FormatScanSummary of the result
+}
+
+// Notifier sends notification events
+type Notifier interface {
+	Notify(event NotificationEvent) error
+}
+
+// Notifiers sends every event to each of its notifiers
+type Notifiers []Notifier
+
+// Notify sends the event to all notifiers and joins their errors
+func (ns Notifiers) Notify(event NotificationEvent) error {
+	var errs []error
+	for _, n := range ns {
+		if err := n.Notify(event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// NewFindingEvent returns the event of a finding, with a masked copy of it
+func NewFindingEvent(root string, f *Finding) NotificationEvent {
+	return NotificationEvent{Kind: NotifyEventFinding, Time: time.Now(), Root: root, Finding: f.Masked()}
+}
+
+// NewSummaryEvent returns the event of a finished scan
+func NewSummaryEvent(result *ScanResult) NotificationEvent {
+	elapsed := result.Duration()
+	summary := &NotificationSummary{
+		FilesScanned:  result.FilesScanned,
+		FilesSkipped:  result.FilesSkipped,
+		TotalFindings: result.TotalFindings(),
+		Severities:    make(map[Severity]int),
+		Errors:        result.ErrorCount,
+		Duration:      elapsed.Seconds(),
+		Cancelled:     result.Cancelled,
+		Text:          FormatScanSummary(russian, result, elapsed),
+	}
+	for _, severity := range []Severity{Critical, High, Medium, Low} {
+		summary.Severities[severity] = result.GetSeverityCount(severity)
+	}
+	return NotificationEvent{Kind: NotifyEventSummary, Time: time.Now(), Root: result.Root, Summary: summary}
+}
+
+// Defaults of webhook requests
+const (
+	DefaultNotifyTimeout = 10 * time.Second
+	DefaultNotifyRetries = 2 // Attempts after the first one
+	DefaultNotifyBackoff = time.Second
+)
+
+// webhookRequest posts JSON with retries: failed connections, 429 and 5xx
+// responses are retried after Backoff, doubled on every attempt
+type webhookRequest struct {
+	URL     string
+	Headers map[string]string
+	Retries int
+	Backoff time.Duration
+	client  *http.Client
+}
+
+func newWebhookRequest(url string) webhookRequest {
+	return webhookRequest{
+		URL:     url,
+		Retries: DefaultNotifyRetries,
+		Backoff: DefaultNotifyBackoff,
+		client:  Network().Client(NetworkNotifications, DefaultNotifyTimeout),
+	}
+}
+
+func (r webhookRequest) post(payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	backoff := r.Backoff
+	for attempt := 0; ; attempt++ {
+		err = r.send(body)
+		var status statusError
+		retry := err != nil && !errors.Is(err, ErrNetworkDisabled) &&
+			(!errors.As(err, &status) || status.code == http.StatusTooManyRequests || status.code >= 500)
+		if !retry || attempt >= r.Retries {
+			return err
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+func (r webhookRequest) send(body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, r.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range r.Headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return statusError{code: resp.StatusCode, host: req.URL.Host}
+	}
+	return nil
+}
+
+// statusError is a webhook answering with an error status
+type statusError struct {
+	code int
+	host string
+}
+
+func (e statusError) Error() string {
+	return fmt.Sprintf("%s ответил %d %s", e.host, e.code, http.StatusText(e.code))
+}
+
+// GenericWebhook posts events as JSON, e.g. to a SIEM collector
+type GenericWebhook struct {
+	webhookRequest
+}
+
+// NewGenericWebhook creates a webhook notifier posting to url
+func NewGenericWebhook(url string) *GenericWebhook {
+	return &GenericWebhook{newWebhookRequest(url)}
+}
+
+// SetHeader sets a header of every request, e.g. Authorization
+func (w *GenericWebhook) SetHeader(name, value string) {
+	if w.Headers == nil {
+		w.Headers = make(map[string]string)
+	}
+	w.Headers[name] = value
+}
+
+// Notify posts the event
+func (w *GenericWebhook) Notify(event NotificationEvent) error {
+	if err := w.post(event); err != nil {
+		return fmt.Errorf("вебхук: %w", err)
+	}
+	return nil
+}
+
+// SlackWebhook posts events to a Slack incoming webhook as Block Kit
+// messages
+type SlackWebhook struct {
+	webhookRequest
+}
+
+// NewSlackWebhook creates a Slack notifier posting to an incoming webhook url
+func NewSlackWebhook(url string) *SlackWebhook {
+	return &SlackWebhook{newWebhookRequest(url)}
+}
+
+// Notify posts the event as a Slack message
+func (w *SlackWebhook) Notify(event NotificationEvent) error {
+	if err := w.post(slackMessage(event)); err != nil {
+		return fmt.Errorf("Slack: %w", err)
+	}
+	return nil
+}
+
+// slackEmoji marks the severity in Slack messages
+var slackEmoji = map[Severity]string{
+	Critical: "🔴",
+	High:     "🟠",
+	Medium:   "🟡",
+	Low:      "🟢",
+}
+
+// slackMessage builds the Block Kit message of an event
+func slackMessage(event NotificationEvent) map[string]any {
+	text := func(s string) map[string]any {
+		return map[string]any{"type": "mrkdwn", "text": s}
+	}
+	header := func(s string) map[string]any {
+		return map[string]any{"type": "header", "text": map[string]any{"type": "plain_text", "text": s}}
+	}
+	footer := map[string]any{"type": "context", "elements": []any{text("data-leak-locator · " + slackEscape(event.Root))}}
+
+	if f := event.Finding; f != nil {
+		title := fmt.Sprintf("%s %s: %s", slackEmoji[f.Severity], severityToRussian(f.Severity), patternTypeToRussian(f.PatternType))
+		fields := []any{
+			text("*Файл:*\n`" + slackEscape(f.FilePath) + "`"),
+			text(fmt.Sprintf("*Строка:*\n%d", f.LineNumber)),
+			text("*Описание:*\n" + slackEscape(descriptionToRussian(f.Description))),
+			text("*Совпадение:*\n`" + slackEscape(f.MatchedText) + "`"),
+		}
+		return map[string]any{
+			"text": fmt.Sprintf("%s %s:%d", title, f.FilePath, f.LineNumber),
+			"blocks": []any{
+				header(title),
+				map[string]any{"type": "section", "fields": fields},
+				footer,
+			},
+		}
+	}
+
+	s := event.Summary
+	if s == nil {
+		s = &NotificationSummary{}
+	}
+	title := "🔍 Сканирование завершено"
+	if s.Cancelled {
+		title = "⏹️ Сканирование прервано"
+	}
+	var counts []string
+	for _, severity := range []Severity{Critical, High, Medium, Low} {
+		counts = append(counts, fmt.Sprintf("%s %s: %d", slackEmoji[severity], severityToRussian(severity), s.Severities[severity]))
+	}
+	return map[string]any{
+		"text": title + ": " + s.Text,
+		"blocks": []any{
+			header(title),
+			map[string]any{"type": "section", "text": text(slackEscape(s.Text))},
+			map[string]any{"type": "section", "text": text(strings.Join(counts, "\n"))},
+			footer,
+		},
+	}
+}
+
+// slackEscape escapes the characters Slack gives a meaning in mrkdwn
+func slackEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+// notifyQueueLength is how many events wait to be sent; events past it
+// are dropped, so a slow webhook does not hold up the scan
+const notifyQueueLength = 256
+
+// notifyQueue sends the events of a scan on its own goroutine and records
+// failures in the result. A nil queue sends nothing
+type notifyQueue struct {
+	notifier Notifier
+	mode     NotifyMode
+	result   *ScanResult
+	events   chan NotificationEvent
+	done     chan struct{}
+	dropped  int
+	mu       sync.Mutex // Protects dropped
+}
+
+// SetNotifier sets where notifications of scans go and what is sent; nil
+// sends nothing
+func (s *Scanner) SetNotifier(n Notifier, mode NotifyMode) {
+	s.notifier = n
+	s.notifyMode = mode
+}
+
+// startNotify starts the queue of a scan writing errors to result; nil
+// without a notifier
+func startNotify(n Notifier, mode NotifyMode, result *ScanResult) *notifyQueue {
+	if n == nil {
+		return nil
+	}
+	q := &notifyQueue{
+		notifier: n,
+		mode:     mode,
+		result:   result,
+		events:   make(chan NotificationEvent, notifyQueueLength),
+		done:     make(chan struct{}),
+	}
+	go func() {
+		defer close(q.done)
+		for event := range q.events {
+			if err := n.Notify(event); err != nil {
+				target := event.Root
+				if event.Finding != nil {
+					target = event.Finding.FilePath
+				}
+				result.AddError(target, StageNotify, err)
+			}
+		}
+	}()
+	return q
+}
+
+// finding queues a finding if the mode sends it
+func (q *notifyQueue) finding(f *Finding) {
+	if q == nil || !q.mode.sends(f) {
+		return
+	}
+	q.enqueue(NewFindingEvent(q.result.Root, f))
+}
+
+func (q *notifyQueue) enqueue(event NotificationEvent) {
+	select {
+	case q.events <- event:
+	default:
+		q.mu.Lock()
+		q.dropped++
+		q.mu.Unlock()
+	}
+}
+
+// finish sends the summary of the result, waits for the queued events and
+// stops the queue
+func (q *notifyQueue) finish(summary bool) {
+	if q == nil {
+		return
+	}
+	if summary {
+		// The summary waits for room rather than being dropped
+		q.events <- NewSummaryEvent(q.result)
+	}
+	close(q.events)
+	<-q.done
+	if q.dropped > 0 {
+		q.result.AddError(q.result.Root, StageNotify,
+			fmt.Errorf("очередь уведомлений переполнена, не отправлено: %d", q.dropped))
+	}
+}
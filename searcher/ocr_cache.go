@@ -0,0 +1,350 @@
+package searcher
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kacebover/password-finder/fsutil"
+)
+
+// OCR is by far the slowest step of a scan, so besides the in-memory
+// ocrTextCache the recognised text and the image analysis can be kept on
+// disk between runs. The text of a scanned passport is as sensitive as the
+// passport, so the cache is off until SetCacheEnabled and its entries are
+// readable by the user only. Entries are keyed by the SHA-256 of the image content, the
+// Tesseract languages and OCRCacheVersion: a renamed or copied image is not
+// recognised again, a changed one is. Every entry is a JSON file replaced
+// atomically and written by one goroutine at a time, so concurrent workers
+// and processes never see half an entry. A hit touches the file; past the
+// entry or byte limit the files touched longest ago are removed
+
+// OCRCacheVersion is the format of cache entries; it is part of the key
+const OCRCacheVersion = 2
+
+// Default limits of the OCR disk cache
+const (
+	DefaultOCRDiskCacheEntries = 20000
+	DefaultOCRDiskCacheBytes   = 256 * 1024 * 1024
+)
+
+// DefaultOCRCacheDir returns the directory of the OCR disk cache when
+// SetCacheDir was not called: os.UserCacheDir()/data-leak-locator/ocr-cache
+func DefaultOCRCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "data-leak-locator", "ocr-cache"), nil
+}
+
+// SetCacheEnabled turns the OCR disk cache on or off; it is off by default
+func (de *DocumentExtractor) SetCacheEnabled(enabled bool) {
+	de.cacheMu.Lock()
+	defer de.cacheMu.Unlock()
+	de.cacheEnabled = enabled
+	de.diskCache = nil
+}
+
+// SetCacheDir sets the directory of the OCR disk cache; empty means
+// DefaultOCRCacheDir
+func (de *DocumentExtractor) SetCacheDir(dir string) {
+	de.cacheMu.Lock()
+	defer de.cacheMu.Unlock()
+	de.cacheDir = dir
+	de.diskCache = nil
+}
+
+// SetCacheLimits bounds the OCR disk cache by entries and bytes; zero keeps
+// the default of a limit
+func (de *DocumentExtractor) SetCacheLimits(maxEntries int, maxBytes int64) {
+	de.cacheMu.Lock()
+	defer de.cacheMu.Unlock()
+	de.cacheMaxEntries = maxEntries
+	de.cacheMaxBytes = maxBytes
+	de.diskCache = nil
+}
+
+// ocrCache returns the disk cache, nil when it is off or has no directory
+func (de *DocumentExtractor) ocrCache() *ocrDiskCache {
+	de.cacheMu.Lock()
+	defer de.cacheMu.Unlock()
+	if !de.cacheEnabled {
+		return nil
+	}
+	if de.diskCache == nil {
+		dir := de.cacheDir
+		if dir == "" {
+			var err error
+			if dir, err = DefaultOCRCacheDir(); err != nil {
+				return nil
+			}
+		}
+		de.diskCache = newOCRDiskCache(dir, de.cacheMaxEntries, de.cacheMaxBytes)
+	}
+	return de.diskCache
+}
+
+// ocrCacheEntry is what is known about one image
+type ocrCacheEntry struct {
+	Version int     `json:"version"`
+	Text    *string `json:"text,omitempty"` // Nil until the image was recognised
+
+	// Analysis is the result of the image analyzer with the keywords hashed
+	// in AnalysisRules
+	Analysis      *ImageAnalysisResult `json:"analysis,omitempty"`
+	AnalysisRules string               `json:"analysis_rules,omitempty"`
+}
+
+// cachedImage returns the cache entry of an image and its key; the entry
+// is nil when the image is not cached and the key empty when the cache is
+// off or the image cannot be read
+func (de *DocumentExtractor) cachedImage(filePath string) (*ocrCacheEntry, string) {
+	cache := de.ocrCache()
+	if cache == nil {
+		return nil, ""
+	}
+	hash := hashFile(filePath)
+	if hash == "" {
+		return nil, ""
+	}
+	key := ocrEntryKey(hash, de.tesseractLangs())
+	return cache.get(key), key
+}
+
+// updateImage changes the cache entry of an image; failures only cost a
+// later OCR run
+func (de *DocumentExtractor) updateImage(key string, change func(*ocrCacheEntry)) {
+	if cache := de.ocrCache(); cache != nil && key != "" {
+		cache.update(key, change)
+	}
+}
+
+// analyzeImage returns the analysis of an image, from the cache when the
+// same image was analysed with the same keywords before
+func (de *DocumentExtractor) analyzeImage(filePath string, analyzer *ImageAnalyzer) (*ImageAnalysisResult, error) {
+	entry, key := de.cachedImage(filePath)
+	rules := analyzer.rulesKey()
+
+	// With Tesseract compiled in the analyzer reads the text with a pooled
+	// client, which changes the result
+	pool := nativeOCR()
+	if pool != nil && analyzer.ocrEnabled {
+		rules += "+native"
+	}
+	if entry != nil && entry.Analysis != nil && entry.AnalysisRules == rules {
+		result := *entry.Analysis
+		result.FilePath = filePath
+		return &result, nil
+	}
+
+	if pool != nil && analyzer.ocrEnabled && analyzer.tessClient == nil {
+		lang := de.tesseractLangs()
+		if client, err := pool.get(lang); err == nil {
+			analyzer.tessClient = client
+			defer func() {
+				analyzer.tessClient = nil
+				pool.put(lang, client)
+			}()
+		}
+	}
+
+	result, err := analyzer.AnalyzeImage(filePath)
+	if err == nil && result != nil {
+		de.updateImage(key, func(e *ocrCacheEntry) {
+			e.Analysis = result
+			e.AnalysisRules = rules
+		})
+	}
+	return result, err
+}
+
+// rulesKey hashes the keywords, document titles and visual signals the
+// analysis scores
+func (ia *ImageAnalyzer) rulesKey() string {
+	var lines []string
+	for keyword, score := range ia.keywordScores {
+		lines = append(lines, fmt.Sprintf("k %s=%d %s", keyword, score, ia.packKeywords[keyword]))
+	}
+	for docType, title := range ia.docTitles {
+		lines = append(lines, fmt.Sprintf("t %s=%s", docType, title))
+	}
+	if ia.faceDetection {
+		lines = append(lines, "faces")
+	}
+	sort.Strings(lines)
+	sum := sha256.Sum256([]byte(strings.Join(lines, "\n")))
+	return hex.EncodeToString(sum[:8])
+}
+
+// ocrEntryKey is the file name of an entry without the extension
+func ocrEntryKey(contentHash, lang string) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("v%d|%s|%s", OCRCacheVersion, lang, contentHash)))
+	return hex.EncodeToString(sum[:])
+}
+
+// ocrDiskCache is a directory of entries
+type ocrDiskCache struct {
+	dir        string
+	maxEntries int
+	maxBytes   int64
+
+	// Writes are serialized; the totals are counted on the first write
+	mu      sync.Mutex
+	counted bool
+	entries int
+	bytes   int64
+}
+
+func newOCRDiskCache(dir string, maxEntries int, maxBytes int64) *ocrDiskCache {
+	if maxEntries <= 0 {
+		maxEntries = DefaultOCRDiskCacheEntries
+	}
+	if maxBytes <= 0 {
+		maxBytes = DefaultOCRDiskCacheBytes
+	}
+	return &ocrDiskCache{dir: dir, maxEntries: maxEntries, maxBytes: maxBytes}
+}
+
+func (c *ocrDiskCache) path(key string) string {
+	return filepath.Join(c.dir, key+".json")
+}
+
+// get reads an entry and marks it as recently used
+func (c *ocrDiskCache) get(key string) *ocrCacheEntry {
+	path := c.path(key)
+	entry := c.read(path)
+	if entry != nil {
+		now := time.Now()
+		os.Chtimes(path, now, now)
+	}
+	return entry
+}
+
+// read returns nil for a missing, corrupted or outdated entry
+func (c *ocrDiskCache) read(path string) *ocrCacheEntry {
+	data, err := fsutil.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var entry ocrCacheEntry
+	if json.Unmarshal(data, &entry) != nil || entry.Version != OCRCacheVersion {
+		return nil
+	}
+	return &entry
+}
+
+// update applies change to an entry, new or existing, writes it and
+// evicts old entries when the cache is over its limits
+func (c *ocrDiskCache) update(key string, change func(*ocrCacheEntry)) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := fsutil.MkdirAll(c.dir, 0700); err != nil {
+		return err
+	}
+	c.count()
+
+	path := c.path(key)
+	entry := c.read(path)
+	oldSize := int64(-1)
+	if info, err := fsutil.Stat(path); err == nil {
+		oldSize = info.Size()
+	}
+	if entry == nil {
+		entry = &ocrCacheEntry{}
+	}
+	change(entry)
+	entry.Version = OCRCacheVersion
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(path, privateFileMode, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	}); err != nil {
+		return err
+	}
+
+	if oldSize >= 0 {
+		c.bytes -= oldSize
+	} else {
+		c.entries++
+	}
+	c.bytes += int64(len(data))
+	if c.entries > c.maxEntries || c.bytes > c.maxBytes {
+		c.evict()
+	}
+	return nil
+}
+
+// count totals the entries already on disk; the caller holds c.mu
+func (c *ocrDiskCache) count() {
+	if c.counted {
+		return
+	}
+	c.counted = true
+	for _, file := range c.files() {
+		c.entries++
+		c.bytes += file.size
+	}
+}
+
+type ocrCacheFile struct {
+	path string
+	size int64
+	used time.Time
+}
+
+// files lists the entries on disk
+func (c *ocrDiskCache) files() []ocrCacheFile {
+	dirEntries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return nil
+	}
+	var files []ocrCacheFile
+	for _, d := range dirEntries {
+		if d.IsDir() || !strings.HasSuffix(d.Name(), ".json") {
+			continue
+		}
+		info, err := d.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, ocrCacheFile{path: filepath.Join(c.dir, d.Name()), size: info.Size(), used: info.ModTime()})
+	}
+	return files
+}
+
+// evict removes the least recently used entries until the cache is at 90%
+// of its limits, so that not every write evicts; the caller holds c.mu
+func (c *ocrDiskCache) evict() {
+	files := c.files()
+	sort.Slice(files, func(i, j int) bool { return files[i].used.Before(files[j].used) })
+
+	// Entries of other processes count too
+	c.entries, c.bytes = len(files), 0
+	for _, file := range files {
+		c.bytes += file.size
+	}
+	for _, file := range files {
+		if c.entries <= c.maxEntries*9/10 && c.bytes <= c.maxBytes*9/10 {
+			break
+		}
+		if err := os.Remove(file.path); err != nil && !os.IsNotExist(err) {
+			continue
+		}
+		c.entries--
+		c.bytes -= file.size
+	}
+}
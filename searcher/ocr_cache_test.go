@@ -0,0 +1,178 @@
+package searcher
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingOCR is a fake Tesseract that counts its runs
+func countingOCR(runs *int32) func(context.Context, string, string) (string, error) {
+	return func(_ context.Context, imagePath, _ string) (string, error) {
+		atomic.AddInt32(runs, 1)
+		data, err := os.ReadFile(imagePath)
+		return "text of " + string(data), err
+	}
+}
+
+func TestOCRDiskCacheSkipsTesseract(t *testing.T) {
+	t.Cleanup(ReleaseCaches)
+	cacheDir := t.TempDir()
+	dir := t.TempDir()
+	var runs int32
+
+	newExtractor := func() *DocumentExtractor {
+		de := NewDocumentExtractor(true)
+		de.SetCacheEnabled(true)
+		de.SetCacheDir(cacheDir)
+		de.ocr = countingOCR(&runs)
+		return de
+	}
+	extract := func(de *DocumentExtractor, name, content string) string {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		os.WriteFile(path, []byte(content), 0644)
+		// Only the disk cache is tested
+		ReleaseCaches()
+		result, err := de.extractImage(context.Background(), path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result.Text
+	}
+
+	de := newExtractor()
+	if text := extract(de, "receipt.png", "total 10"); text != "text of total 10" || runs != 1 {
+		t.Fatalf("first run: %q after %d OCR runs", text, runs)
+	}
+	// A copy under another name is the same image
+	if text := extract(de, "copy.png", "total 10"); text != "text of total 10" || runs != 1 {
+		t.Errorf("identical image: %q after %d OCR runs, want 1", text, runs)
+	}
+	// A later run reads the cache of the earlier one
+	if text := extract(newExtractor(), "receipt.png", "total 10"); text != "text of total 10" || runs != 1 {
+		t.Errorf("next run: %q after %d OCR runs, want 1", text, runs)
+	}
+	if text := extract(de, "receipt.png", "total 20"); text != "text of total 20" || runs != 2 {
+		t.Errorf("changed image: %q after %d OCR runs, want 2", text, runs)
+	}
+
+	disabled := newExtractor()
+	disabled.SetCacheEnabled(false)
+	extract(disabled, "receipt.png", "total 20")
+	if runs != 3 {
+		t.Errorf("disabled cache: %d OCR runs, want 3", runs)
+	}
+}
+
+func TestOCRDiskCachePrivate(t *testing.T) {
+	// Off by default: the recognised text of documents is sensitive
+	if NewDocumentExtractor(true).ocrCache() != nil {
+		t.Error("OCR disk cache on by default")
+	}
+
+	de := NewDocumentExtractor(true)
+	de.SetCacheEnabled(true)
+	de.SetCacheDir(t.TempDir())
+	image := filepath.Join(t.TempDir(), "passport.png")
+	os.WriteFile(image, []byte("not really a png"), 0644)
+	_, key := de.cachedImage(image)
+	text := "PASSPORT 4509 123456"
+	de.updateImage(key, func(e *ocrCacheEntry) { e.Text = &text })
+
+	info, err := os.Stat(de.ocrCache().path(key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm() != 0600 {
+		t.Errorf("entry mode %v, want 0600", info.Mode().Perm())
+	}
+}
+
+func TestOCRDiskCacheAnalysis(t *testing.T) {
+	de := NewDocumentExtractor(true)
+	de.SetCacheEnabled(true)
+	de.SetCacheDir(t.TempDir())
+	image := filepath.Join(t.TempDir(), "passport.png")
+	os.WriteFile(image, []byte("not really a png"), 0644)
+
+	analyzer := NewImageAnalyzer(true)
+	_, key := de.cachedImage(image)
+	de.updateImage(key, func(e *ocrCacheEntry) {
+		e.Analysis = &ImageAnalysisResult{FilePath: "elsewhere.png", DocumentType: "passport", IsDocument: true}
+		e.AnalysisRules = analyzer.rulesKey()
+	})
+
+	result, err := de.analyzeImage(image, analyzer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.DocumentType != "passport" || result.FilePath != image {
+		t.Errorf("cached analysis = %+v", result)
+	}
+
+	// Other keywords analyse the image again
+	analyzer.AddPackKeywords(&Pack{Keywords: map[string]int{"медицинская карта": 30}, DocumentType: "medical"})
+	if result, err := de.analyzeImage(image, analyzer); err != nil || result.DocumentType == "passport" {
+		t.Errorf("analysis with other keywords = %+v, %v", result, err)
+	}
+}
+
+func TestOCRDiskCacheEviction(t *testing.T) {
+	cache := newOCRDiskCache(t.TempDir(), 3, 0)
+	old := time.Now().Add(-time.Hour)
+	for i := 0; i < 3; i++ {
+		key := fmt.Sprintf("key%d", i)
+		if err := cache.update(key, func(e *ocrCacheEntry) {}); err != nil {
+			t.Fatal(err)
+		}
+		used := old.Add(time.Duration(i) * time.Minute)
+		os.Chtimes(cache.path(key), used, used)
+	}
+	// Reading key0 makes key1 the least recently used
+	if cache.get("key0") == nil {
+		t.Fatal("key0 should be cached")
+	}
+
+	cache.update("key3", func(e *ocrCacheEntry) {})
+	for key, want := range map[string]bool{"key0": true, "key1": false, "key2": false, "key3": true} {
+		if got := cache.read(cache.path(key)) != nil; got != want {
+			t.Errorf("%s cached = %v, want %v", key, got, want)
+		}
+	}
+	if cache.entries != 2 {
+		t.Errorf("entries = %d, want 2", cache.entries)
+	}
+}
+
+func TestOCRDiskCacheConcurrent(t *testing.T) {
+	cache := newOCRDiskCache(t.TempDir(), 0, 0)
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			text := fmt.Sprintf("text %d", i%4)
+			if err := cache.update(fmt.Sprintf("key%d", i%4), func(e *ocrCacheEntry) { e.Text = &text }); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for i := 0; i < 4; i++ {
+		entry := cache.get(fmt.Sprintf("key%d", i))
+		if entry == nil || entry.Text == nil || *entry.Text != fmt.Sprintf("text %d", i) {
+			t.Errorf("key%d = %+v", i, entry)
+		}
+	}
+	if cache.entries != 4 {
+		t.Errorf("entries = %d, want 4", cache.entries)
+	}
+}
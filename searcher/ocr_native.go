@@ -0,0 +1,23 @@
+//go:build cgo_ocr && cgo
+
+package searcher
+
+import (
+	"strings"
+
+	"github.com/otiai10/gosseract/v2"
+)
+
+// Build with -tags cgo_ocr after go get github.com/otiai10/gosseract/v2;
+// libtesseract and leptonica with their headers have to be installed
+
+func init() {
+	newNativeTessClient = func(lang string) (TessClient, error) {
+		client := gosseract.NewClient()
+		if err := client.SetLanguage(strings.Split(lang, "+")...); err != nil {
+			client.Close()
+			return nil, err
+		}
+		return client, nil
+	}
+}
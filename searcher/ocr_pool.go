@@ -0,0 +1,114 @@
+package searcher
+
+import (
+	"context"
+	"runtime"
+	"sync"
+)
+
+// Built with the cgo_ocr tag, Tesseract runs in-process through gosseract
+// instead of one tesseract process per image. A client holds a loaded
+// model, so clients are pooled per language and lent to one worker at a
+// time: SetImage and Text of two images never interleave on a client.
+// Without the tag newNativeTessClient is nil and OCR uses the command line
+
+// newNativeTessClient creates an in-process Tesseract client for the
+// languages, "+" separated as for the command line; nil when Tesseract is
+// not compiled in
+var newNativeTessClient func(lang string) (TessClient, error)
+
+// NativeOCRAvailable reports whether Tesseract is compiled in (cgo_ocr)
+func NativeOCRAvailable() bool {
+	return newNativeTessClient != nil
+}
+
+// tessPool lends Tesseract clients; idle clients are kept per language up
+// to maxIdle each
+type tessPool struct {
+	newClient func(lang string) (TessClient, error)
+	maxIdle   int
+
+	mu   sync.Mutex
+	idle map[string][]TessClient
+}
+
+func newTessPool(newClient func(lang string) (TessClient, error), maxIdle int) *tessPool {
+	return &tessPool{newClient: newClient, maxIdle: maxIdle, idle: make(map[string][]TessClient)}
+}
+
+// get returns an idle client for lang or a new one
+func (p *tessPool) get(lang string) (TessClient, error) {
+	p.mu.Lock()
+	if clients := p.idle[lang]; len(clients) > 0 {
+		client := clients[len(clients)-1]
+		p.idle[lang] = clients[:len(clients)-1]
+		p.mu.Unlock()
+		return client, nil
+	}
+	p.mu.Unlock()
+	return p.newClient(lang)
+}
+
+// put returns a client to the pool, closing it when enough are idle
+func (p *tessPool) put(lang string, client TessClient) {
+	p.mu.Lock()
+	if len(p.idle[lang]) < p.maxIdle {
+		p.idle[lang] = append(p.idle[lang], client)
+		client = nil
+	}
+	p.mu.Unlock()
+	if client != nil {
+		client.Close()
+	}
+}
+
+// recognize returns the text of an image. A client that failed is closed
+// rather than lent again
+func (p *tessPool) recognize(ctx context.Context, imagePath, lang string) (string, error) {
+	if err := cancelledError(ctx); err != nil {
+		return "", err
+	}
+	client, err := p.get(lang)
+	if err != nil {
+		return "", err
+	}
+	if err := client.SetImage(imagePath); err != nil {
+		client.Close()
+		return "", err
+	}
+	text, err := client.Text()
+	if err != nil {
+		client.Close()
+		return "", err
+	}
+	p.put(lang, client)
+	return text, nil
+}
+
+// close closes the idle clients
+func (p *tessPool) close() {
+	p.mu.Lock()
+	idle := p.idle
+	p.idle = make(map[string][]TessClient)
+	p.mu.Unlock()
+	for _, clients := range idle {
+		for _, client := range clients {
+			client.Close()
+		}
+	}
+}
+
+var (
+	nativeOCROnce sync.Once
+	nativeOCRPool *tessPool
+)
+
+// nativeOCR returns the process-wide client pool, nil without cgo_ocr
+func nativeOCR() *tessPool {
+	nativeOCROnce.Do(func() {
+		if newNativeTessClient != nil {
+			nativeOCRPool = newTessPool(newNativeTessClient, runtime.NumCPU())
+		}
+	})
+	return nativeOCRPool
+}
@@ -0,0 +1,161 @@
+package searcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeTessClient reads the image as its text, slowly, so that a client
+// shared between two images would return the wrong one
+type fakeTessClient struct {
+	image  string
+	closed *int32
+}
+
+func (c *fakeTessClient) SetImage(path string) error {
+	c.image = path
+	return nil
+}
+
+func (c *fakeTessClient) Text() (string, error) {
+	time.Sleep(time.Duration(rand.Intn(500)) * time.Microsecond)
+	data, err := os.ReadFile(c.image)
+	return string(data), err
+}
+
+func (c *fakeTessClient) Close() error {
+	atomic.AddInt32(c.closed, 1)
+	return nil
+}
+
+// ocrImages writes n images whose content names them
+func ocrImages(t testing.TB, n int) []string {
+	dir := t.TempDir()
+	images := make([]string, n)
+	for i := range images {
+		images[i] = filepath.Join(dir, fmt.Sprintf("scan%03d.png", i))
+		os.WriteFile(images[i], []byte(fmt.Sprintf("image %d", i)), 0644)
+	}
+	return images
+}
+
+func TestTessPoolNoTextBleed(t *testing.T) {
+	var created, closed int32
+	pool := newTessPool(func(lang string) (TessClient, error) {
+		atomic.AddInt32(&created, 1)
+		return &fakeTessClient{closed: &closed}, nil
+	}, 4)
+	images := ocrImages(t, 200)
+
+	const workers = 16
+	var wg sync.WaitGroup
+	errs := make(chan error, len(images))
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := w; i < len(images); i += workers {
+				text, err := pool.recognize(context.Background(), images[i], "rus+eng")
+				if err != nil {
+					errs <- err
+				} else if want := fmt.Sprintf("image %d", i); text != want {
+					errs <- fmt.Errorf("%s: text %q, want %q", filepath.Base(images[i]), text, want)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	if created > workers {
+		t.Errorf("%d clients created for %d workers", created, workers)
+	}
+	if idle := len(pool.idle["rus+eng"]); idle > 4 {
+		t.Errorf("%d idle clients, want at most 4", idle)
+	}
+	pool.close()
+	if closed != created {
+		t.Errorf("%d of %d clients closed", closed, created)
+	}
+}
+
+func TestTessPoolDropsFailedClient(t *testing.T) {
+	var closed int32
+	pool := newTessPool(func(lang string) (TessClient, error) {
+		return &fakeTessClient{closed: &closed}, nil
+	}, 4)
+
+	if _, err := pool.recognize(context.Background(), filepath.Join(t.TempDir(), "missing.png"), "eng"); err == nil {
+		t.Fatal("a missing image should fail")
+	}
+	if closed != 1 || len(pool.idle["eng"]) != 0 {
+		t.Errorf("failed client: %d closed, %d idle", closed, len(pool.idle["eng"]))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := pool.recognize(ctx, ocrImages(t, 1)[0], "eng"); !errors.Is(err, ErrCancelled) {
+		t.Errorf("cancelled error = %v, want ErrCancelled", err)
+	}
+}
+
+// fakeTesseract installs a script that writes the image content as the
+// recognised text after a delay
+func fakeTesseract(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake tesseract is a shell script")
+	}
+	script := filepath.Join(t.TempDir(), "tesseract")
+	os.WriteFile(script, []byte(`#!/bin/sh
+if [ "$1" = "--list-langs" ]; then echo eng; exit 0; fi
+sleep 0.0$(( $$ % 5 ))
+cat "$1" > "$2.txt"
+`), 0755)
+
+	Dependencies().SetProbe(DependencyTesseract, func(context.Context) *DependencyStatus {
+		return &DependencyStatus{Name: "Tesseract OCR", Available: true, Path: script}
+	})
+	t.Cleanup(func() { Dependencies().SetProbe(DependencyTesseract, probeTesseract) })
+}
+
+func TestTesseractCLIConcurrent(t *testing.T) {
+	fakeTesseract(t)
+	de := NewDocumentExtractor(true)
+	de.tempDir = t.TempDir()
+	images := ocrImages(t, 24)
+
+	var wg sync.WaitGroup
+	texts := make([]string, len(images))
+	errs := make([]error, len(images))
+	for i := range images {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			texts[i], errs[i] = de.runTesseractCLI(context.Background(), images[i], de.tesseractLangs())
+		}(i)
+	}
+	wg.Wait()
+
+	for i, text := range texts {
+		if errs[i] != nil {
+			t.Errorf("%s: %v", filepath.Base(images[i]), errs[i])
+		} else if want := fmt.Sprintf("image %d", i); text != want {
+			t.Errorf("%s: text %q, want %q", filepath.Base(images[i]), text, want)
+		}
+	}
+	if left, _ := os.ReadDir(de.tempDir); len(left) != 0 {
+		t.Errorf("%d output files left behind", len(left))
+	}
+}
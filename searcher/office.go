@@ -0,0 +1,406 @@
+package searcher
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/kacebover/password-finder/fsutil"
+	"golang.org/x/net/html/charset"
+)
+
+// Presentations, OpenDocument files and RTF memos. The XML formats are
+// read with a namespace-aware decoder, one line per paragraph; members are
+// cut at maxFileSize like the files themselves, so a zip bomb cannot fill
+// memory
+
+// XML namespaces of the text the extractors read
+const (
+	drawingMLNS = "http://schemas.openxmlformats.org/drawingml/2006/main"
+	odfTextNS   = "urn:oasis:names:tc:opendocument:xmlns:text:1.0"
+	odfTableNS  = "urn:oasis:names:tc:opendocument:xmlns:table:1.0"
+)
+
+// pptxPartPattern matches slides and speaker notes with their number
+var pptxPartPattern = regexp.MustCompile(`^ppt/(slides/slide|notesSlides/notesSlide)(\d+)\.xml$`)
+
+// extractPPTX extracts the text of the slides in order, then of the
+// speaker notes
+func (de *DocumentExtractor) extractPPTX(filePath string) (*ExtractedContent, error) {
+	content := &ExtractedContent{
+		SourceFile: filePath,
+		Format:     "PPTX",
+	}
+
+	r, err := zip.OpenReader(fsutil.Normalize(filePath))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	type part struct {
+		notes bool
+		num   int
+		file  *zip.File
+	}
+	var parts []part
+	for _, f := range r.File {
+		m := pptxPartPattern.FindStringSubmatch(f.Name)
+		if m == nil {
+			continue
+		}
+		num, _ := strconv.Atoi(m[2])
+		notes := m[1] != "slides/slide"
+		parts = append(parts, part{notes: notes, num: num, file: f})
+		if !notes {
+			content.PageCount++
+		}
+	}
+	sort.Slice(parts, func(i, j int) bool {
+		if parts[i].notes != parts[j].notes {
+			return !parts[i].notes
+		}
+		return parts[i].num < parts[j].num
+	})
+
+	var texts []string
+	for _, p := range parts {
+		if text := de.zipXMLText(p.file, drawingMLText); text != "" {
+			texts = append(texts, text)
+		}
+	}
+	content.Text = strings.Join(texts, "\n")
+	return content, nil
+}
+
+// extractODF extracts the text of OpenDocument text, spreadsheet and
+// presentation files: the body in content.xml, then headers and footers
+// in styles.xml
+func (de *DocumentExtractor) extractODF(filePath string) (*ExtractedContent, error) {
+	content := &ExtractedContent{
+		SourceFile: filePath,
+		Format:     strings.ToUpper(strings.TrimPrefix(strings.ToLower(filepath.Ext(filePath)), ".")),
+	}
+
+	r, err := zip.OpenReader(fsutil.Normalize(filePath))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var texts []string
+	for _, name := range []string{"content.xml", "styles.xml"} {
+		for _, f := range r.File {
+			if f.Name != name {
+				continue
+			}
+			if text := de.zipXMLText(f, odfText); text != "" {
+				texts = append(texts, text)
+			}
+		}
+	}
+	content.Text = strings.Join(texts, "\n")
+	return content, nil
+}
+
+// zipXMLText reads up to maxFileSize bytes of an XML member with walk
+func (de *DocumentExtractor) zipXMLText(f *zip.File, walk func(io.Reader) string) string {
+	rc, err := f.Open()
+	if err != nil {
+		return ""
+	}
+	defer rc.Close()
+	return walk(io.LimitReader(rc, de.maxFileSize))
+}
+
+// drawingMLText returns the a:t runs of slide XML, a line per paragraph.
+// Text read before an XML error is kept
+func drawingMLText(r io.Reader) string {
+	dec := xml.NewDecoder(r)
+	var b strings.Builder
+	inRun := false
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			break
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if t.Name.Space != drawingMLNS {
+				continue
+			}
+			switch t.Name.Local {
+			case "t":
+				inRun = true
+			case "br":
+				b.WriteByte('\n')
+			}
+		case xml.EndElement:
+			if t.Name.Space != drawingMLNS {
+				continue
+			}
+			switch t.Name.Local {
+			case "t":
+				inRun = false
+			case "p":
+				b.WriteByte('\n')
+			}
+		case xml.CharData:
+			if inRun {
+				b.Write(t)
+			}
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// odfText returns the paragraphs and headings of OpenDocument XML, spans
+// included. Spreadsheet cells are separated by tabs and rows by lines, so
+// a key and its value in neighbouring cells stay on one line
+func odfText(r io.Reader) string {
+	dec := xml.NewDecoder(r)
+	var b strings.Builder
+	paragraphs, cells := 0, 0
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			break
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name {
+			case xml.Name{Space: odfTextNS, Local: "p"}, xml.Name{Space: odfTextNS, Local: "h"}:
+				paragraphs++
+			case xml.Name{Space: odfTextNS, Local: "s"}:
+				// Runs of spaces are stored as a count
+				count := 1
+				for _, attr := range t.Attr {
+					if attr.Name.Local == "c" {
+						if n, err := strconv.Atoi(attr.Value); err == nil && n > 0 && n < 1000 {
+							count = n
+						}
+					}
+				}
+				b.WriteString(strings.Repeat(" ", count))
+			case xml.Name{Space: odfTextNS, Local: "tab"}:
+				b.WriteByte('\t')
+			case xml.Name{Space: odfTextNS, Local: "line-break"}:
+				b.WriteByte('\n')
+			case xml.Name{Space: odfTableNS, Local: "table-cell"}:
+				cells++
+			}
+		case xml.EndElement:
+			switch t.Name {
+			case xml.Name{Space: odfTextNS, Local: "p"}, xml.Name{Space: odfTextNS, Local: "h"}:
+				paragraphs--
+				if cells > 0 {
+					b.WriteByte(' ')
+				} else {
+					b.WriteByte('\n')
+				}
+			case xml.Name{Space: odfTableNS, Local: "table-cell"}:
+				cells--
+				b.WriteByte('\t')
+			case xml.Name{Space: odfTableNS, Local: "table-row"}:
+				b.WriteByte('\n')
+			}
+		case xml.CharData:
+			if paragraphs > 0 {
+				b.Write(t)
+			}
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// extractRTF extracts the text of an RTF document
+func (de *DocumentExtractor) extractRTF(filePath string) (*ExtractedContent, error) {
+	data, err := fsutil.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return &ExtractedContent{
+		SourceFile: filePath,
+		Format:     "RTF",
+		Text:       rtfText(data),
+	}, nil
+}
+
+// rtfSkipped are destinations without document text
+var rtfSkipped = map[string]bool{
+	"fonttbl": true, "colortbl": true, "stylesheet": true, "info": true,
+	"pict": true, "object": true, "objdata": true, "themedata": true,
+	"colorschememapping": true, "datastore": true, "latentstyles": true,
+	"listtable": true, "listoverridetable": true, "rsidtbl": true,
+	"generator": true, "xmlnstbl": true, "filetbl": true, "revtbl": true,
+	"mmathPr": true, "pgdsctbl": true,
+}
+
+// rtfSymbols are control words that stand for a character
+var rtfSymbols = map[string]string{
+	"par": "\n", "line": "\n", "row": "\n", "sect": "\n", "page": "\n",
+	"tab": "\t", "cell": "\t",
+	"emdash": "—", "endash": "–", "bullet": "•",
+	"lquote": "‘", "rquote": "’", "ldblquote": "“", "rdblquote": "”",
+}
+
+// rtfText strips control words and skipped destinations from RTF. \'hh
+// bytes are decoded in the code page of \ansicpg and \uN escapes are
+// taken instead of their \ucN fallback characters
+func rtfText(data []byte) string {
+	type group struct {
+		skip bool
+		uc   int // Fallback characters after \u
+	}
+	state := group{uc: 1}
+	var stack []group
+	var out strings.Builder
+	var pending []byte // \'hh bytes not decoded yet
+	codepage := 1252
+	fallback := 0 // Characters still to skip after \u
+
+	flush := func() {
+		if len(pending) > 0 {
+			out.WriteString(decodeCodepage(pending, codepage))
+			pending = pending[:0]
+		}
+	}
+	emit := func(s string) {
+		if !state.skip {
+			flush()
+			out.WriteString(s)
+		}
+	}
+
+	for i := 0; i < len(data); {
+		c := data[i]
+		switch c {
+		case '{':
+			stack = append(stack, state)
+			i++
+			continue
+		case '}':
+			if len(stack) > 0 {
+				state = stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+			}
+			i++
+			continue
+		case '\r', '\n':
+			i++
+			continue
+		case '\\':
+		default:
+			if fallback > 0 {
+				fallback--
+			} else if !state.skip {
+				pending = append(pending, c)
+			}
+			i++
+			continue
+		}
+
+		// Control word or symbol
+		i++
+		if i >= len(data) {
+			break
+		}
+		c = data[i]
+		switch {
+		case c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+			start := i
+			for i < len(data) && (data[i] >= 'a' && data[i] <= 'z' || data[i] >= 'A' && data[i] <= 'Z') {
+				i++
+			}
+			word := string(data[start:i])
+			paramStart := i
+			if i < len(data) && data[i] == '-' {
+				i++
+			}
+			for i < len(data) && data[i] >= '0' && data[i] <= '9' {
+				i++
+			}
+			param, hasParam := 0, i > paramStart
+			if hasParam {
+				param, _ = strconv.Atoi(string(data[paramStart:i]))
+			}
+			if i < len(data) && data[i] == ' ' {
+				i++
+			}
+
+			switch {
+			case word == "u" && hasParam:
+				emit(string(rune(uint16(int16(param)))))
+				fallback = state.uc
+			case word == "uc" && hasParam:
+				state.uc = param
+			case word == "ansicpg" && hasParam:
+				codepage = param
+			case word == "bin" && hasParam:
+				i += param
+			case rtfSkipped[word]:
+				state.skip = true
+			case rtfSymbols[word] != "":
+				emit(rtfSymbols[word])
+			}
+		case c == '\'':
+			if i+2 < len(data) {
+				if b, err := strconv.ParseUint(string(data[i+1:i+3]), 16, 8); err == nil {
+					if fallback > 0 {
+						fallback--
+					} else if !state.skip {
+						pending = append(pending, byte(b))
+					}
+				}
+			}
+			i += 3
+		case c == '*':
+			// Destinations a reader may ignore
+			state.skip = true
+			i++
+		case c == '~':
+			emit(" ")
+			i++
+		case c == '_':
+			emit("-")
+			i++
+		case c == '\r' || c == '\n':
+			emit("\n")
+			i++
+		case c == '\\' || c == '{' || c == '}':
+			if !state.skip {
+				pending = append(pending, c)
+			}
+			i++
+		default:
+			i++
+		}
+	}
+	flush()
+	return strings.TrimSpace(out.String())
+}
+
+// decodeCodepage decodes bytes of a Windows code page; bytes of an unknown
+// one are taken as Latin-1
+func decodeCodepage(b []byte, codepage int) string {
+	if codepage == 65001 && utf8.Valid(b) {
+		return string(b)
+	}
+	if enc, _ := charset.Lookup(fmt.Sprintf("windows-%d", codepage)); enc != nil {
+		if decoded, err := enc.NewDecoder().Bytes(b); err == nil {
+			return string(decoded)
+		}
+	}
+	runes := make([]rune, len(b))
+	for i, c := range b {
+		runes[i] = rune(c)
+	}
+	return string(runes)
+}
@@ -0,0 +1,236 @@
+package searcher
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Packs are data-driven sets of rules and OCR keywords for a domain such as
+// medical or HR records. They are off by default; an enabled pack tags its
+// findings with the pack name so reports can group them.
+
+//go:embed packs/*.json
+var builtinPackFiles embed.FS
+
+// packAliases maps Russian pack names to the built-in ones
+var packAliases = map[string]string{
+	"медицина": "medical",
+	"кадры":    "hr",
+}
+
+// maxKeywordScore caps a single pack keyword, matching the largest built-in weight
+const maxKeywordScore = 20
+
+// PackDefinition is the on-disk layout of a pack file
+type PackDefinition struct {
+	Name          string           `json:"name"`
+	Title         string           `json:"title,omitempty"`
+	Description   string           `json:"description,omitempty"`
+	Rules         []RuleDefinition `json:"rules"`
+	Keywords      map[string]int   `json:"keywords,omitempty"`       // OCR keyword weights for images
+	DocumentType  string           `json:"document_type,omitempty"`  // Image document type the keywords indicate
+	DocumentTitle string           `json:"document_title,omitempty"` // Russian name of that document type
+}
+
+// Pack is a compiled pack
+type Pack struct {
+	Name          string
+	Title         string
+	Description   string
+	Patterns      []*Pattern
+	Keywords      map[string]int
+	DocumentType  string
+	DocumentTitle string
+}
+
+// Compile validates the definition and compiles its rules into the pack's group
+func (pd PackDefinition) Compile() (*Pack, error) {
+	name := strings.ToLower(strings.TrimSpace(pd.Name))
+	if name == "" {
+		return nil, fmt.Errorf("у пакета не указано имя")
+	}
+	if len(pd.Rules) == 0 && len(pd.Keywords) == 0 {
+		return nil, fmt.Errorf("пакет %q: нет ни правил, ни ключевых слов", name)
+	}
+	if len(pd.Keywords) > 0 && pd.DocumentType == "" {
+		return nil, fmt.Errorf("пакет %q: для ключевых слов нужен document_type", name)
+	}
+
+	pack := &Pack{
+		Name:          name,
+		Title:         pd.Title,
+		Description:   pd.Description,
+		Keywords:      make(map[string]int, len(pd.Keywords)),
+		DocumentType:  pd.DocumentType,
+		DocumentTitle: pd.DocumentTitle,
+	}
+	if pack.Title == "" {
+		pack.Title = name
+	}
+
+	for _, rule := range pd.Rules {
+		pattern, err := rule.Compile()
+		if err != nil {
+			return nil, fmt.Errorf("пакет %q: %v", name, err)
+		}
+		pattern.Group = name
+		pack.Patterns = append(pack.Patterns, pattern)
+	}
+
+	for keyword, score := range pd.Keywords {
+		if score < 1 || score > maxKeywordScore {
+			return nil, fmt.Errorf("пакет %q: вес ключевого слова %q должен быть от 1 до %d", name, keyword, maxKeywordScore)
+		}
+		pack.Keywords[strings.ToLower(keyword)] = score
+	}
+
+	return pack, nil
+}
+
+// LoadPackFile reads a pack from a JSON or YAML file
+func LoadPackFile(path string) (*Pack, error) {
+	var def PackDefinition
+	if err := DecodeConfigFile(path, &def); err != nil {
+		return nil, err
+	}
+	pack, err := def.Compile()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return pack, nil
+}
+
+// BuiltinPacks returns the names of the packs shipped with the scanner
+func BuiltinPacks() []string {
+	entries, _ := builtinPackFiles.ReadDir("packs")
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, strings.TrimSuffix(entry.Name(), ".json"))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LoadPack loads a built-in pack by name (English or Russian) or a pack file
+// by path
+func LoadPack(nameOrPath string) (*Pack, error) {
+	name := strings.ToLower(strings.TrimSpace(nameOrPath))
+	if alias, ok := packAliases[name]; ok {
+		name = alias
+	}
+
+	data, err := builtinPackFiles.ReadFile(path.Join("packs", name+".json"))
+	if err != nil {
+		if strings.ContainsAny(nameOrPath, `/\.`) {
+			return LoadPackFile(nameOrPath)
+		}
+		return nil, fmt.Errorf("неизвестный пакет: %s (доступны: %s)", nameOrPath, strings.Join(BuiltinPacks(), ", "))
+	}
+
+	var def PackDefinition
+	if err := json.Unmarshal(data, &def); err != nil {
+		return nil, fmt.Errorf("встроенный пакет %s: %v", name, err)
+	}
+	return def.Compile()
+}
+
+// AddPack registers the pack's rules and enables it
+func (p *Patterns) AddPack(pack *Pack) {
+	for _, pattern := range pack.Patterns {
+		p.Add(pattern)
+	}
+	p.packs = append(p.packs, pack)
+	p.SetGroupEnabled(pack.Name, true)
+}
+
+// EnablePacks loads and enables packs given by name or file path
+func (p *Patterns) EnablePacks(names []string) error {
+	for _, name := range names {
+		pack, err := LoadPack(name)
+		if err != nil {
+			return err
+		}
+		p.AddPack(pack)
+	}
+	return nil
+}
+
+// Packs returns the enabled packs
+func (p *Patterns) Packs() []*Pack {
+	var list []*Pack
+	for _, pack := range p.packs {
+		if p.isEnabled(pack.Name) {
+			list = append(list, pack)
+		}
+	}
+	return list
+}
+
+// proximityDetector runs a rule whose match only counts when its Near
+// expression appears within NearLines lines, e.g. a СНИЛС next to a name
+type proximityDetector struct {
+	pattern *Pattern
+	lenient bool
+}
+
+func (d proximityDetector) Name() string  { return d.pattern.Name }
+func (d proximityDetector) Group() string { return d.pattern.Group }
+
+func (d proximityDetector) Detect(lines []string) []*DetectedPattern {
+	var results []*DetectedPattern
+	pattern := d.pattern
+
+	for i, line := range lines {
+		for _, match := range pattern.Regex.FindAllStringIndex(line, -1) {
+			text := line[match[0]:match[1]]
+			ok, failed := pattern.accepts(text, d.lenient)
+			if !ok {
+				continue
+			}
+			if !d.nearby(lines, i) {
+				continue
+			}
+			detected := &DetectedPattern{
+				Type:        pattern.Type,
+				RuleName:    pattern.Name,
+				Pattern:     pattern.Regex.String(),
+				Severity:    pattern.Severity,
+				Description: pattern.Description,
+				StartIndex:  match[0],
+				EndIndex:    match[1],
+				MatchText:   text,
+				LineNumber:  i + 1,
+				Group:       pattern.Group,
+			}
+			if failed {
+				detected.markFailedCheck(pattern)
+			}
+			if pattern.Refine != nil && !pattern.Refine(detected) {
+				continue
+			}
+			results = append(results, detected)
+		}
+	}
+	return results
+}
+
+// nearby reports whether Near matches within NearLines lines of lines[i]
+func (d proximityDetector) nearby(lines []string, i int) bool {
+	from, to := i-d.pattern.NearLines, i+d.pattern.NearLines
+	if from < 0 {
+		from = 0
+	}
+	if to >= len(lines) {
+		to = len(lines) - 1
+	}
+	for j := from; j <= to; j++ {
+		if d.pattern.Near.MatchString(lines[j]) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,222 @@
+package searcher
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// packScanner returns a scanner with the given packs enabled
+func packScanner(t *testing.T, packs ...string) *Scanner {
+	t.Helper()
+	scanner := NewScanner()
+	if err := scanner.GetPatterns().EnablePacks(packs); err != nil {
+		t.Fatal(err)
+	}
+	return scanner
+}
+
+// scanPackFixture scans a file from testdata/packs and indexes findings by type
+func scanPackFixture(t *testing.T, scanner *Scanner, name string) map[PatternType][]*Finding {
+	t.Helper()
+	findings, err := scanner.scanFileContent(filepath.Join("testdata", "packs", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	byType := make(map[PatternType][]*Finding)
+	for _, f := range findings {
+		byType[f.PatternType] = append(byType[f.PatternType], f)
+	}
+	return byType
+}
+
+// TestIdentifierValidators tests the ICD-10, ОМС and СНИЛС checks
+func TestIdentifierValidators(t *testing.T) {
+	tests := []struct {
+		name  string
+		check func(string) bool
+		value string
+		valid bool
+	}{
+		{"icd10", ValidICD10, "E11.9", true},
+		{"icd10", ValidICD10, "J45", true},
+		{"icd10", ValidICD10, "U07.1", true},
+		{"icd10", ValidICD10, "U99", false},
+		{"icd10", ValidICD10, "E1.19", false},
+		{"icd10", ValidICD10, "e11", false},
+		{"oms", ValidOMS, "7756 1234 5678 9016", true},
+		{"oms", ValidOMS, "5000000000000009", true},
+		{"oms", ValidOMS, "7756123456789017", false},
+		{"oms", ValidOMS, "775612345678901", false},
+		{"snils", ValidSNILS, "112-233-445 95", true},
+		{"snils", ValidSNILS, "112-233-445 96", false},
+		{"snils", ValidSNILS, "001-001-998 00", true},
+	}
+	for _, tt := range tests {
+		if got := tt.check(tt.value); got != tt.valid {
+			t.Errorf("%s(%q) = %v, want %v", tt.name, tt.value, got, tt.valid)
+		}
+	}
+}
+
+// TestValidOMSMatchesLuhn tests the ОМС control digit agrees with Luhn,
+// since doubling the odd-position number equals doubling each digit
+func TestValidOMSMatchesLuhn(t *testing.T) {
+	for _, base := range []string{"775612345678901", "123456789012345", "999999999999999", "000000000000000"} {
+		valid := 0
+		for d := '0'; d <= '9'; d++ {
+			number := base + string(d)
+			if ValidOMS(number) != luhnCheck(number) {
+				t.Errorf("ValidOMS and Luhn disagree on %s", number)
+			}
+			if ValidOMS(number) {
+				valid++
+			}
+		}
+		if valid != 1 {
+			t.Errorf("%s: expected exactly one control digit, got %d", base, valid)
+		}
+	}
+}
+
+// TestBuiltinPacksLoad tests every shipped pack compiles and has keywords
+func TestBuiltinPacksLoad(t *testing.T) {
+	names := BuiltinPacks()
+	if strings.Join(names, ",") != "hr,medical" {
+		t.Fatalf("unexpected built-in packs: %v", names)
+	}
+	for _, name := range names {
+		pack, err := LoadPack(name)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if len(pack.Patterns) == 0 || len(pack.Keywords) == 0 || pack.DocumentType == "" {
+			t.Errorf("%s: incomplete pack %+v", name, pack)
+		}
+		for _, p := range pack.Patterns {
+			if p.Group != name {
+				t.Errorf("%s: rule %s not tagged with the pack", name, p.Name)
+			}
+		}
+	}
+
+	if pack, err := LoadPack("Кадры"); err != nil || pack.Name != "hr" {
+		t.Errorf("Russian alias not resolved: %v", err)
+	}
+	if _, err := LoadPack("dental"); err == nil {
+		t.Error("unknown pack should be rejected")
+	}
+}
+
+// TestLoadPackFile tests YAML packs load and invalid ones are rejected
+func TestLoadPackFile(t *testing.T) {
+	pack, err := LoadPack(filepath.Join("testdata", "packs", "custom.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pack.Name != "contracts" || len(pack.Patterns) != 1 || pack.Patterns[0].Near == nil || pack.Patterns[0].NearLines != 3 {
+		t.Errorf("unexpected pack: %+v", pack)
+	}
+
+	_, err = LoadPackFile(filepath.Join("testdata", "packs", "bad_validator.yaml"))
+	if err == nil || !strings.Contains(err.Error(), "crc32") {
+		t.Errorf("expected unknown validator error, got %v", err)
+	}
+
+	bad := []PackDefinition{
+		{Rules: []RuleDefinition{{Name: "x", Regex: "x"}}},
+		{Name: "empty"},
+		{Name: "keywords", Keywords: map[string]int{"приказ": 5}},
+		{Name: "weights", Keywords: map[string]int{"приказ": 50}, DocumentType: "order"},
+		{Name: "within", Rules: []RuleDefinition{{Name: "x", Regex: "x", Near: "y", Within: -1}}},
+	}
+	for _, def := range bad {
+		if _, err := def.Compile(); err == nil {
+			t.Errorf("pack %q should be rejected", def.Name)
+		}
+	}
+}
+
+// TestPacksDisabledByDefault tests pack rules only fire once enabled
+func TestPacksDisabledByDefault(t *testing.T) {
+	found := scanPackFixture(t, NewScanner(), "patient.txt")
+	if len(found[PatternDiagnosisCode]) != 0 || len(found[PatternHealthInsurance]) != 0 {
+		t.Error("medical findings without the pack enabled")
+	}
+}
+
+// TestMedicalPack tests diagnosis codes and policies need nearby context
+func TestMedicalPack(t *testing.T) {
+	found := scanPackFixture(t, packScanner(t, "medical"), "patient.txt")
+
+	codes := found[PatternDiagnosisCode]
+	if len(codes) != 1 || codes[0].MatchedText != "E11.9" || codes[0].LineNumber != 3 {
+		t.Errorf("expected only E11.9 next to the diagnosis, got %d findings", len(codes))
+	}
+
+	policies := found[PatternHealthInsurance]
+	if len(policies) != 1 || policies[0].LineNumber != 5 {
+		t.Fatalf("expected the ОМС policy on line 5, got %d findings", len(policies))
+	}
+	if policies[0].Group != "medical" || policies[0].Context[policies[0].ByteStart:policies[0].ByteEnd] != "7756 1234 5678 9016" {
+		t.Errorf("unexpected policy finding: %+v", policies[0])
+	}
+
+	if len(found[PatternMedicalRecord]) != 1 {
+		t.Errorf("expected the discharge summary marker, got %d", len(found[PatternMedicalRecord]))
+	}
+}
+
+// TestHRPackTagsFindings tests СНИЛС validation and pack tags in reports
+func TestHRPackTagsFindings(t *testing.T) {
+	scanner := packScanner(t, "hr")
+	found := scanPackFixture(t, scanner, "staff.csv")
+
+	snils := found[PatternSNILS]
+	if len(snils) != 1 || snils[0].MatchedText != "112-233-445 95" {
+		t.Errorf("expected only the valid СНИЛС, got %d findings", len(snils))
+	}
+	if len(found[PatternSalary]) != 1 {
+		t.Errorf("expected the salary table header, got %d", len(found[PatternSalary]))
+	}
+
+	result := NewScanResult()
+	for _, findings := range found {
+		for _, f := range findings {
+			result.AddFinding(f)
+		}
+	}
+	summary := NewReportGenerator(result).generateSummary()
+	if summary.GroupCounts["hr"] != len(snils)+len(found[PatternSalary]) {
+		t.Errorf("unexpected group counts: %v", summary.GroupCounts)
+	}
+}
+
+// TestPackKeywordsInImageAnalyzer tests pack keywords score OCR text and
+// set the document type
+func TestPackKeywordsInImageAnalyzer(t *testing.T) {
+	text := strings.ToLower("ТРУДОВАЯ КНИЖКА\nСведения о работе\nпринят на должность инженера")
+
+	plain := NewImageAnalyzer(false)
+	before := &ImageAnalysisResult{Signals: &DetectionSignals{}}
+	plain.detectKeywords(before, text)
+
+	analyzer := NewImageAnalyzer(false)
+	pack, err := LoadPack("hr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	analyzer.AddPackKeywords(pack)
+	after := &ImageAnalysisResult{Signals: &DetectionSignals{}}
+	analyzer.detectKeywords(after, text)
+
+	if after.Signals.KeywordScore <= before.Signals.KeywordScore {
+		t.Errorf("pack keywords did not raise the score: %.0f -> %.0f", before.Signals.KeywordScore, after.Signals.KeywordScore)
+	}
+	if after.DocumentType != "employment_record" {
+		t.Errorf("expected employment_record, got %q", after.DocumentType)
+	}
+	if got := analyzer.GetDocumentTypeDescription(after.DocumentType); got != "Трудовая книжка" {
+		t.Errorf("unexpected document title %q", got)
+	}
+}
@@ -0,0 +1,215 @@
+package searcher
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Include and exclude globs narrow a scan to parts of the tree. They are
+// matched against the path relative to the scan root with forward slashes,
+// after the ignore list and .gitignore:
+//
+//   - "*" matches within one path segment, "**" matches any number of
+//     directories, so "**/*.min.js" is every minified file and
+//     "src/**/vendor/**" everything in a vendor directory under src
+//   - patterns are anchored at the root: "*.env" is only the root files,
+//     "**/*.env" is every .env file
+//   - a pattern matching a directory covers everything in it, and a
+//     trailing slash, as in "testdata/", is the same as "testdata/**"
+//   - with include globs only the matching files are scanned; exclude
+//     globs always win, so a file matching both is skipped
+//
+// Excluded files and directories are counted as skipped with the reason
+// "excluded by glob"; files outside the include globs are skipped without
+// one, like files of other extensions with SetOnlyExtensions
+
+// pathGlob is a compiled include or exclude pattern
+type pathGlob struct {
+	pattern  string   // As given, for skip reasons
+	segments []string // Split at slashes, see matchSegments
+}
+
+// compileGlobs checks and splits patterns; empty patterns are dropped
+func compileGlobs(patterns []string) ([]pathGlob, error) {
+	var globs []pathGlob
+	for _, pattern := range patterns {
+		p := strings.TrimSpace(filepath.ToSlash(pattern))
+		p = strings.TrimPrefix(strings.TrimPrefix(p, "./"), "/")
+		if p == "" {
+			continue
+		}
+		if strings.HasSuffix(p, "/") {
+			p += "**"
+		}
+		glob := pathGlob{pattern: pattern}
+		for _, segment := range strings.Split(p, "/") {
+			if segment == "" {
+				continue
+			}
+			if segment != "**" {
+				// "**" inside a segment is an ordinary star
+				for strings.Contains(segment, "**") {
+					segment = strings.ReplaceAll(segment, "**", "*")
+				}
+				if _, err := path.Match(segment, ""); err != nil {
+					return nil, fmt.Errorf("некорректный шаблон пути %q: %w", pattern, err)
+				}
+			}
+			glob.segments = append(glob.segments, segment)
+		}
+		globs = append(globs, glob)
+	}
+	return globs, nil
+}
+
+// matches reports whether the glob matches a relative path or one of the
+// directories it is in
+func (g pathGlob) matches(parts []string) bool {
+	for i := 1; i <= len(parts); i++ {
+		if matchSegments(g.segments, parts[:i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// coversDir reports whether the glob matches everything inside the
+// directory parts: it matches the directory, or ends in "**" and matches
+// the directory without it
+func (g pathGlob) coversDir(parts []string) bool {
+	if g.matches(parts) {
+		return true
+	}
+	n := len(g.segments)
+	if n < 2 || g.segments[n-1] != "**" {
+		return false
+	}
+	for i := 1; i <= len(parts); i++ {
+		if matchSegments(g.segments[:n-1], parts[:i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// mayMatchBelow reports whether a path inside the directory parts could
+// match the glob, so the directory has to be walked
+func (g pathGlob) mayMatchBelow(parts []string) bool {
+	return matchPrefix(g.segments, parts)
+}
+
+// matchPrefix reports whether parts can be the start of a path matching
+// pattern
+func matchPrefix(pattern, parts []string) bool {
+	if len(parts) == 0 {
+		return true
+	}
+	if len(pattern) == 0 {
+		return false
+	}
+	if pattern[0] == "**" {
+		return true
+	}
+	if ok, err := path.Match(pattern[0], parts[0]); err != nil || !ok {
+		return false
+	}
+	return matchPrefix(pattern[1:], parts[1:])
+}
+
+// SetIncludeGlobs limits the scan to paths matching one of the globs;
+// nil scans everything. See the globs description at the top of the file
+func (s *Scanner) SetIncludeGlobs(globs []string) error {
+	compiled, err := compileGlobs(globs)
+	if err != nil {
+		return err
+	}
+	s.includeGlobs = compiled
+	return nil
+}
+
+// SetExcludeGlobs skips paths matching one of the globs, even when they
+// match an include glob
+func (s *Scanner) SetExcludeGlobs(globs []string) error {
+	compiled, err := compileGlobs(globs)
+	if err != nil {
+		return err
+	}
+	s.excludeGlobs = compiled
+	return nil
+}
+
+// globParts returns a walked path relative to the scan root, split at
+// slashes; nil for the root itself or a path outside it
+func (s *Scanner) globParts(fullPath string) []string {
+	rel, err := filepath.Rel(s.globRoot, fullPath)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil
+	}
+	return strings.Split(filepath.ToSlash(rel), "/")
+}
+
+// excludedBy returns the exclude glob matching a file, or covering a
+// directory
+func (s *Scanner) excludedBy(parts []string, isDir bool) (string, bool) {
+	for _, glob := range s.excludeGlobs {
+		if (isDir && glob.coversDir(parts)) || (!isDir && glob.matches(parts)) {
+			return glob.pattern, true
+		}
+	}
+	return "", false
+}
+
+// globDir decides whether a directory is walked. included is true when
+// an include glob covers the whole directory, so its files need no check
+func (s *Scanner) globDir(fullPath string, parentIncluded bool) (walk, included bool, reason string) {
+	if len(s.includeGlobs) == 0 && len(s.excludeGlobs) == 0 {
+		return true, true, ""
+	}
+	parts := s.globParts(fullPath)
+	if parts == nil {
+		return true, parentIncluded, ""
+	}
+	if pattern, ok := s.excludedBy(parts, true); ok {
+		return false, false, "excluded by glob " + pattern
+	}
+	if parentIncluded || len(s.includeGlobs) == 0 {
+		return true, true, ""
+	}
+	for _, glob := range s.includeGlobs {
+		if glob.coversDir(parts) {
+			return true, true, ""
+		}
+	}
+	for _, glob := range s.includeGlobs {
+		if glob.mayMatchBelow(parts) {
+			return true, false, ""
+		}
+	}
+	return false, false, ""
+}
+
+// globFile decides whether a file is scanned; reason is set for files
+// skipped by an exclude glob
+func (s *Scanner) globFile(fullPath string, parentIncluded bool) (scan bool, reason string) {
+	if len(s.includeGlobs) == 0 && len(s.excludeGlobs) == 0 {
+		return true, ""
+	}
+	parts := s.globParts(fullPath)
+	if parts == nil {
+		return true, ""
+	}
+	if pattern, ok := s.excludedBy(parts, false); ok {
+		return false, "excluded by glob " + pattern
+	}
+	if parentIncluded || len(s.includeGlobs) == 0 {
+		return true, ""
+	}
+	for _, glob := range s.includeGlobs {
+		if glob.matches(parts) {
+			return true, ""
+		}
+	}
+	return false, ""
+}
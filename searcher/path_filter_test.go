@@ -0,0 +1,264 @@
+package searcher
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestPathGlobs(t *testing.T) {
+	tests := []struct {
+		name    string
+		include []string
+		exclude []string
+		path    string
+		want    bool
+	}{
+		{"no globs", nil, nil, "a/b.txt", true},
+		{"minified at any depth", nil, []string{"**/*.min.js"}, "web/static/app.min.js", false},
+		{"minified at the root", nil, []string{"**/*.min.js"}, "app.min.js", false},
+		{"not minified", nil, []string{"**/*.min.js"}, "web/app.js", true},
+		{"vendor under src", nil, []string{"src/**/vendor/**"}, "src/a/b/vendor/lib/x.go", false},
+		{"vendor directly in src", nil, []string{"src/**/vendor/**"}, "src/vendor/x.go", false},
+		{"vendor outside src", nil, []string{"src/**/vendor/**"}, "vendor/x.go", true},
+		{"anchored at the root", nil, []string{"*.env"}, "sub/.env.local", true},
+		{"root file", nil, []string{"*.env"}, "prod.env", false},
+		{"directory covers contents", nil, []string{"build"}, "build/out/config.env", false},
+		{"trailing slash", nil, []string{"testdata/"}, "testdata/x/y.txt", false},
+		{"star stays in one segment", nil, []string{"src/*.go"}, "src/pkg/a.go", true},
+		{"leading dot slash", nil, []string{"./docs/**"}, "docs/readme.md", false},
+		{"include allowlist", []string{"src/**", "config/**"}, nil, "config/app.yaml", true},
+		{"outside the include", []string{"src/**", "config/**"}, nil, "docs/readme.md", false},
+		{"include needs the whole path", []string{"src/**"}, nil, "srcfoo/a.go", false},
+		{"include by extension", []string{"**/*.env"}, nil, "deploy/prod/env.txt", false},
+		{"include by extension matches", []string{"**/*.env"}, nil, "deploy/prod/app.env", true},
+		{"exclude wins over include", []string{"src/**"}, []string{"**/testdata/**"}, "src/pkg/testdata/key.pem", false},
+		{"include with exclude elsewhere", []string{"src/**"}, []string{"**/testdata/**"}, "src/pkg/key.pem", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewScanner()
+			if err := s.SetIncludeGlobs(tt.include); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.SetExcludeGlobs(tt.exclude); err != nil {
+				t.Fatal(err)
+			}
+			s.globRoot = filepath.FromSlash("/scan")
+			if got := globAllows(s, tt.path); got != tt.want {
+				t.Errorf("%s: scanned = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+
+	if err := NewScanner().SetExcludeGlobs([]string{"src/[a"}); err == nil {
+		t.Error("expected an error for a malformed glob")
+	}
+}
+
+// globAllows walks the directories of a relative path like the scan
+// does and reports whether the file would be scanned
+func globAllows(s *Scanner, rel string) bool {
+	parts := strings.Split(rel, "/")
+	included := len(s.includeGlobs) == 0
+	dir := s.globRoot
+	for _, part := range parts[:len(parts)-1] {
+		dir = filepath.Join(dir, part)
+		walk, inside, _ := s.globDir(dir, included)
+		if !walk {
+			return false
+		}
+		included = inside
+	}
+	scan, _ := s.globFile(filepath.Join(dir, parts[len(parts)-1]), included)
+	return scan
+}
+
+func TestScanWithGlobs(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{
+		"src/app/config.env",
+		"src/app/generated/lib/config.env",
+		"src/app/bundle.min.js",
+		"config/prod.env",
+		"docs/example.env",
+		"root.env",
+	} {
+		content := "password = hunter2hunter2\n"
+		if strings.HasSuffix(name, ".js") {
+			content = "var password = 'hunter2hunter2';\n"
+		}
+		createTestFile(t, dir, name, content)
+	}
+
+	scanner := NewScanner()
+	scanner.SetIncludeGlobs([]string{"src/**", "config/**"})
+	scanner.SetExcludeGlobs([]string{"**/*.min.js", "src/**/generated/**"})
+	result, err := scanner.Scan(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var scanned []string
+	for _, f := range result.Findings {
+		rel, _ := filepath.Rel(dir, f.FilePath)
+		scanned = append(scanned, filepath.ToSlash(rel))
+	}
+	sort.Strings(scanned)
+	if strings.Join(scanned, ",") != "config/prod.env,src/app/config.env" {
+		t.Errorf("findings in %v", scanned)
+	}
+
+	// Excluded paths are skipped with a reason, a directory as a whole
+	reasons := map[string]string{}
+	for path, reason := range result.SkipReasons {
+		rel, _ := filepath.Rel(dir, path)
+		reasons[filepath.ToSlash(rel)] = reason.Text
+	}
+	if reasons["src/app/bundle.min.js"] != "excluded by glob **/*.min.js" || reasons["src/app/generated"] != "excluded by glob src/**/generated/**" {
+		t.Errorf("skip reasons %v", reasons)
+	}
+	if _, ok := reasons["docs/example.env"]; ok {
+		t.Error("files outside the include globs should be skipped without a reason")
+	}
+
+	// The enumeration pass counts the same files
+	total, err := scanner.Enumerate(context.Background(), dir)
+	if err != nil || total.Files != 2 {
+		t.Errorf("Enumerate = %d files, err %v", total.Files, err)
+	}
+}
+
+func TestSetOnlyExtensions(t *testing.T) {
+	tests := []struct {
+		given []string
+		want  []string
+	}{
+		{[]string{"env", ".PEM", "*.Key", " txt "}, []string{".env", ".key", ".pem", ".txt"}},
+		{[]string{"", "."}, nil},
+		{nil, nil},
+	}
+	for _, tt := range tests {
+		s := NewScanner()
+		s.SetOnlyExtensions(tt.given)
+		var got []string
+		for ext := range s.onlyExtensions {
+			got = append(got, ext)
+		}
+		sort.Strings(got)
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("SetOnlyExtensions(%q) = %v, want %v", tt.given, got, tt.want)
+		}
+	}
+}
+
+func TestOnlyExtensionsSkips(t *testing.T) {
+	dir := t.TempDir()
+	secret := "password = hunter2hunter2\n"
+	createTestFile(t, dir, "app.ENV", secret)
+	createTestFile(t, dir, "notes.txt", secret)
+	createTestFile(t, dir, "Makefile", secret)
+	createTestFile(t, dir, "report.docx", secret)
+	createTestFile(t, dir, "scan.pdf", secret)
+	os.WriteFile(filepath.Join(dir, "bundle.zip"), zipBytes(t, map[string][]byte{"inner.txt": []byte(secret)}), 0644)
+
+	tests := []struct {
+		name     string
+		only     []string
+		archives bool
+		found    []string       // Base names of files with findings
+		skips    map[string]int // Want ExtensionSkips
+		gaps     map[Capability]int
+	}{
+		{
+			name:  "plain files",
+			only:  []string{"env"},
+			found: []string{"app.ENV"},
+			skips: map[string]int{".txt": 1, "": 1, ".docx": 1},
+			// Filtered documents and archives are no capability gap
+			gaps: map[Capability]int{},
+		},
+		{
+			name:  "document without extraction",
+			only:  []string{"docx", "pdf"},
+			skips: map[string]int{".txt": 1, "": 1, ".env": 1},
+			gaps:  map[Capability]int{CapabilityDocuments: 2},
+		},
+		{
+			// The filter applies to files on disk, not archive members;
+			// scan.pdf is left out earlier by the default ignore list
+			name:     "archive members",
+			only:     []string{".zip"},
+			archives: true,
+			found:    []string{"bundle.zip"},
+			skips:    map[string]int{".txt": 1, "": 1, ".env": 1, ".docx": 1},
+			gaps:     map[Capability]int{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewScanner()
+			scanner.SetOnlyExtensions(tt.only)
+			if tt.archives {
+				scanner.SetDocumentExtractor(NewDocumentExtractor(false))
+				scanner.SetScanArchives(true)
+			}
+			result, err := scanner.Scan(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			found := map[string]bool{}
+			for _, f := range result.Findings {
+				found[filepath.Base(strings.SplitN(f.FilePath, "!", 2)[0])] = true
+			}
+			var names []string
+			for name := range found {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			if strings.Join(names, ",") != strings.Join(tt.found, ",") {
+				t.Errorf("findings in %v, want %v", names, tt.found)
+			}
+			if !reflect.DeepEqual(result.ExtensionSkips, tt.skips) {
+				t.Errorf("ExtensionSkips = %v, want %v", result.ExtensionSkips, tt.skips)
+			}
+			if gaps := result.CapabilityGaps(); !reflect.DeepEqual(gaps, tt.gaps) {
+				t.Errorf("CapabilityGaps = %v, want %v", gaps, tt.gaps)
+			}
+			if reason := result.SkipReasons[filepath.Join(dir, "notes.txt")]; reason.Text != ExtensionFilteredReason {
+				t.Errorf("notes.txt skip reason %q", reason)
+			}
+		})
+	}
+}
+
+func TestExtensionSkipReasonsCapped(t *testing.T) {
+	dir := t.TempDir()
+	n := MaxExtensionSkipReasons + 50
+	for i := 0; i < n; i++ {
+		createTestFile(t, dir, fmt.Sprintf("f%03d.txt", i), "nothing\n")
+	}
+	scanner := NewScanner()
+	scanner.SetOnlyExtensions([]string{"env"})
+	result, err := scanner.Scan(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reasons := 0
+	for _, reason := range result.SkipReasons {
+		if reason.Text == ExtensionFilteredReason {
+			reasons++
+		}
+	}
+	if reasons != MaxExtensionSkipReasons || result.ExtensionSkips[".txt"] != n || result.FilesSkipped != n {
+		t.Errorf("%d reasons, %d counted, %d skipped; want %d, %d, %d",
+			reasons, result.ExtensionSkips[".txt"], result.FilesSkipped, MaxExtensionSkipReasons, n, n)
+	}
+}
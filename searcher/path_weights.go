@@ -0,0 +1,204 @@
+package searcher
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Where a secret lies says as much as the secret itself: a key in
+// deploy/prod.env is likelier to be live than the same key in
+// docs/examples/sample.env. Path weights multiply the risk score of a
+// finding by the factors of the path rules its file matches
+
+// PathWeights map path rules to risk score multipliers. A rule is
+//   - a directory or file name, matched against every segment of the path
+//     and the file name without its extension: "test", "prod", "id_rsa";
+//     a name starting with a dot matches the extension too: ".env" matches
+//     .env, .env.local and prod.env
+//   - a glob with * or ?, matched against every segment: "*_test.*"
+//   - a path of several segments: ".github/workflows"
+//   - GitTracked or GitUntracked, matched by the git status of the file,
+//     see Scanner.SetGitTracking
+//
+// Paths are matched relative to the scan root and case-insensitively; the
+// factors of every matching rule are multiplied
+type PathWeights map[string]float64
+
+// Rules of PathWeights matching the git status of a file
+const (
+	GitTracked   = "git:tracked"   // Committed: the secret leaked into the repository
+	GitUntracked = "git:untracked" // Not committed: likely a real local secret
+)
+
+// Git status of a finding's file, see Finding.GitStatus
+const (
+	GitStatusTracked   = "tracked"
+	GitStatusUntracked = "untracked"
+)
+
+// DefaultPathWeights returns the path rules the scorer uses by default
+func DefaultPathWeights() PathWeights {
+	return PathWeights{
+		// Tests, fixtures and documentation hold fake secrets
+		"test": 0.5, "tests": 0.5, "__tests__": 0.5, "testdata": 0.5, "spec": 0.5,
+		"fixtures": 0.5, "fixture": 0.5, "mock": 0.5, "mocks": 0.5,
+		"*_test.*": 0.5, "*.test.*": 0.5, "*.spec.*": 0.5,
+		"examples": 0.6, "example": 0.6, "samples": 0.6, "sample": 0.6, "docs": 0.6,
+		"*.example": 0.5, "*.sample": 0.5,
+
+		// Deployment configuration holds live ones
+		"prod": 1.3, "production": 1.3, "deploy": 1.2, "deployment": 1.2,
+		".github/workflows": 1.2, "terraform": 1.2, ".tfvars": 1.3,
+
+		// Files made to keep credentials
+		".env": 1.5, ".npmrc": 1.5, ".netrc": 1.5, ".pgpass": 1.5, ".pypirc": 1.5,
+		".git-credentials": 1.5, "id_rsa": 1.5, "id_ecdsa": 1.5, "id_ed25519": 1.5,
+
+		GitTracked: 1.2,
+	}
+}
+
+// Validate checks the rules and their factors
+func (w PathWeights) Validate() error {
+	for _, rule := range w.rules() {
+		name := "risk_weights.paths." + rule
+		if strings.TrimSpace(rule) == "" {
+			return fmt.Errorf("risk_weights.paths: пустое правило")
+		}
+		if _, err := path.Match(strings.ToLower(rule), ""); err != nil {
+			return fmt.Errorf("%s: неверный шаблон: %v", name, err)
+		}
+		if err := checkRange(name, w[rule], 0, maxRiskMultiplier); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rules returns the rules in a stable order
+func (w PathWeights) rules() []string {
+	rules := make([]string, 0, len(w))
+	for rule := range w {
+		rules = append(rules, rule)
+	}
+	sort.Strings(rules)
+	return rules
+}
+
+// factor returns the multiplier of a path relative to the scan root and
+// the rules that matched it. gitStatus is "" when unknown
+func (w PathWeights) factor(rel, gitStatus string) (float64, []string) {
+	rel = strings.ToLower(filepath.ToSlash(rel))
+	segments := strings.Split(strings.Trim(rel, "/"), "/")
+	base := segments[len(segments)-1]
+	ext := path.Ext(base)
+	stem := strings.TrimSuffix(base, ext)
+
+	factor := 1.0
+	var matched []string
+	for _, rule := range w.rules() {
+		if w[rule] == 1 {
+			continue
+		}
+		if pathRuleMatches(strings.ToLower(rule), rel, segments, stem, ext, gitStatus) {
+			factor *= w[rule]
+			matched = append(matched, rule)
+		}
+	}
+	return factor, matched
+}
+
+// pathRuleMatches reports whether a lowercase rule matches a path, see PathWeights
+func pathRuleMatches(rule, rel string, segments []string, stem, ext, gitStatus string) bool {
+	switch {
+	case rule == GitTracked:
+		return gitStatus == GitStatusTracked
+	case rule == GitUntracked:
+		return gitStatus == GitStatusUntracked
+	case strings.Contains(strings.Trim(rule, "/"), "/"):
+		return strings.Contains("/"+strings.Trim(rel, "/")+"/", "/"+strings.Trim(rule, "/")+"/")
+	case strings.ContainsAny(rule, "*?["):
+		for _, segment := range segments {
+			if ok, _ := path.Match(rule, segment); ok {
+				return true
+			}
+		}
+		return false
+	}
+	if rule == stem || strings.HasPrefix(rule, ".") && rule == ext {
+		return true
+	}
+	for _, segment := range segments {
+		if segment == rule {
+			return true
+		}
+	}
+	return false
+}
+
+// PathAdjusted reports whether the location of the file changed the risk
+// score, so BaseRiskScore differs from RiskScore
+func (f *Finding) PathAdjusted() bool {
+	return f.BaseRiskScore != 0 && f.BaseRiskScore != f.RiskScore
+}
+
+// GitStatusToRussian describes the git status of a finding's file
+func GitStatusToRussian(status string) string {
+	return LocalizeGitStatus(russian, status)
+}
+
+// SetPathWeights replaces the path rules of the risk score, see
+// PathWeights; nil turns path weighting off. The rules are expected to be
+// validated, e.g. by LoadRiskWeights
+func (s *Scanner) SetPathWeights(weights PathWeights) {
+	w := s.riskScorer.Weights()
+	w.Paths = weights
+	s.riskScorer = NewRiskScorerWithWeights(w)
+}
+
+// SetPathSeverity raises the severity of a finding when its path lifts
+// the risk score into a higher band of AssignSeverityFromRiskScore
+func (s *Scanner) SetPathSeverity(enabled bool) {
+	w := s.riskScorer.Weights()
+	w.PathSeverity = enabled
+	s.riskScorer = NewRiskScorerWithWeights(w)
+}
+
+// SetGitTracking looks up whether the files of a scanned directory are
+// committed to git, for the GitTracked and GitUntracked rules and
+// Finding.GitStatus. Outside a repository or without git it does nothing
+func (s *Scanner) SetGitTracking(enabled bool) {
+	s.gitTracking = enabled
+}
+
+// loadGitTracked lists the files git tracks under root, relative to it;
+// nil when root is not in a repository
+func (s *Scanner) loadGitTracked(ctx context.Context, root string) map[string]bool {
+	if !s.gitTracking {
+		return nil
+	}
+	if _, ok := s.source.(*LocalSource); !ok {
+		return nil
+	}
+	git, ok := s.deps.ToolPath(DependencyGit)
+	if !ok {
+		return nil
+	}
+	output, err := exec.CommandContext(ctx, git, "-C", root, "ls-files", "-z").Output()
+	if err != nil {
+		return nil
+	}
+	tracked := make(map[string]bool)
+	for _, name := range bytes.Split(output, []byte{0}) {
+		if len(name) > 0 {
+			tracked[string(name)] = true
+		}
+	}
+	return tracked
+}
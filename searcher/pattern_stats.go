@@ -0,0 +1,242 @@
+package searcher
+
+import (
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/kacebover/password-finder/locale"
+)
+
+// DefaultDominantShare is the share of all findings above which a single
+// rule is reported as dominating the scan
+const DefaultDominantShare = 0.5
+
+// minFindingsForDominance keeps a handful of findings from all being
+// reported as one dominating rule
+const minFindingsForDominance = 20
+
+// PatternStat summarises what one rule produced during a scan
+type PatternStat struct {
+	Rule      string        `json:"rule"`
+	Type      PatternType   `json:"type"`
+	Findings  int           `json:"findings"`
+	Files     int           `json:"files"`
+	RegexTime time.Duration `json:"regex_time_ns,omitempty"` // Zero unless pattern profiling was on
+	Share     float64       `json:"share"`                   // Fraction of all findings, 0..1
+}
+
+// ruleCounter is the running tally of one rule, updated by AddFinding
+type ruleCounter struct {
+	patternType PatternType
+	findings    int
+	files       map[string]struct{}
+}
+
+// ruleKey names the rule of a finding; findings without a rule id are
+// grouped by their pattern type
+func ruleKey(f *Finding) string {
+	if f.RuleID != "" {
+		return f.RuleID
+	}
+	return string(f.PatternType)
+}
+
+// countRule adds a finding to the per-rule counters; the caller holds sr.mu
+func (sr *ScanResult) countRule(f *Finding) {
+	if sr.ruleCounters == nil {
+		sr.ruleCounters = make(map[string]*ruleCounter)
+	}
+	key := ruleKey(f)
+	counter := sr.ruleCounters[key]
+	if counter == nil {
+		counter = &ruleCounter{patternType: f.PatternType, files: make(map[string]struct{})}
+		sr.ruleCounters[key] = counter
+	}
+	counter.findings++
+	counter.files[f.FilePath] = struct{}{}
+}
+
+// uncountRule takes a finding of a file whose findings are all being
+// removed out of the per-rule counters; the caller holds sr.mu
+func (sr *ScanResult) uncountRule(f *Finding) {
+	counter := sr.ruleCounters[ruleKey(f)]
+	if counter == nil {
+		return
+	}
+	counter.findings--
+	delete(counter.files, f.FilePath)
+	if counter.findings <= 0 {
+		delete(sr.ruleCounters, ruleKey(f))
+	}
+}
+
+// SetRegexTimes records the regex time per rule measured by pattern profiling
+func (sr *ScanResult) SetRegexTimes(times map[string]time.Duration) {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	sr.regexTimes = times
+}
+
+// PatternStats returns per-rule statistics, most findings first. The counts
+// come from the counters AddFinding keeps, so they include pruned findings.
+// Rules that were timed but never matched are listed with zero findings
+func (sr *ScanResult) PatternStats() []PatternStat {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+
+	total := 0
+	for _, counter := range sr.ruleCounters {
+		total += counter.findings
+	}
+
+	stats := make([]PatternStat, 0, len(sr.ruleCounters))
+	for rule, counter := range sr.ruleCounters {
+		stat := PatternStat{
+			Rule:      rule,
+			Type:      counter.patternType,
+			Findings:  counter.findings,
+			Files:     len(counter.files),
+			RegexTime: sr.regexTimes[rule],
+		}
+		if total > 0 {
+			stat.Share = float64(counter.findings) / float64(total)
+		}
+		stats = append(stats, stat)
+	}
+	for rule, elapsed := range sr.regexTimes {
+		if _, ok := sr.ruleCounters[rule]; !ok {
+			stats = append(stats, PatternStat{Rule: rule, RegexTime: elapsed})
+		}
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Findings != stats[j].Findings {
+			return stats[i].Findings > stats[j].Findings
+		}
+		return stats[i].Rule < stats[j].Rule
+	})
+	return stats
+}
+
+// DominantPatterns returns the rules whose share of findings exceeds
+// threshold. Scans with few findings never have a dominating rule
+func DominantPatterns(stats []PatternStat, threshold float64) []PatternStat {
+	total := 0
+	for _, stat := range stats {
+		total += stat.Findings
+	}
+	if total < minFindingsForDominance {
+		return nil
+	}
+
+	var dominant []PatternStat
+	for _, stat := range stats {
+		if stat.Share > threshold {
+			dominant = append(dominant, stat)
+		}
+	}
+	return dominant
+}
+
+// UnmatchedRules returns the enabled rules and detectors of patterns that
+// produced no finding, sorted
+func UnmatchedRules(stats []PatternStat, patterns *Patterns) []string {
+	matched := make(map[string]bool, len(stats))
+	for _, stat := range stats {
+		if stat.Findings > 0 {
+			matched[stat.Rule] = true
+		}
+	}
+
+	seen := make(map[string]bool)
+	var unmatched []string
+	add := func(name string) {
+		if !matched[name] && !seen[name] {
+			seen[name] = true
+			unmatched = append(unmatched, name)
+		}
+	}
+	for _, pattern := range patterns.List() {
+		// Proximity rules are listed below as detectors
+		if patterns.isEnabled(pattern.Group) && pattern.Near == nil {
+			add(pattern.Name)
+		}
+	}
+	for _, detector := range patterns.Detectors() {
+		add(detector.Name())
+	}
+
+	sort.Strings(unmatched)
+	return unmatched
+}
+
+// FormatPatternStats renders the per-rule table of the text report and the
+// CLI summary
+func FormatPatternStats(l *locale.Localizer, stats []PatternStat) string {
+	var sb strings.Builder
+	for _, stat := range stats {
+		if stat.Findings == 0 {
+			continue
+		}
+		line := l.T("stats.rule", stat.Rule, stat.Findings, l.Plural(stat.Findings, "plural.finding"),
+			stat.Files, l.Plural(stat.Files, "plural.in_file"), stat.Share*100)
+		if stat.RegexTime > 0 {
+			line += ", regex " + stat.RegexTime.Round(time.Microsecond).String()
+		}
+		sb.WriteString(line + "\n")
+	}
+	return sb.String()
+}
+
+// patternProfile accumulates the time spent in each pattern's regex.
+// Slots follow the order of Patterns.patterns at the time profiling started
+type patternProfile struct {
+	names []string
+	nanos []atomic.Int64
+}
+
+// SetProfiling turns timing of every regex on or off and resets the totals
+func (p *Patterns) SetProfiling(enabled bool) {
+	if !enabled {
+		p.profile = nil
+		return
+	}
+	profile := &patternProfile{
+		names: make([]string, len(p.patterns)),
+		nanos: make([]atomic.Int64, len(p.patterns)),
+	}
+	for i, pattern := range p.patterns {
+		profile.names[i] = pattern.Name
+	}
+	p.profile = profile
+}
+
+// Profiling reports whether regex timing is on
+func (p *Patterns) Profiling() bool {
+	return p.profile != nil
+}
+
+// RegexTimes returns the regex time per rule name since profiling started;
+// nil when profiling is off
+func (p *Patterns) RegexTimes() map[string]time.Duration {
+	if p.profile == nil {
+		return nil
+	}
+	times := make(map[string]time.Duration)
+	for i, name := range p.profile.names {
+		// Patterns of disabled groups never run
+		if n := p.profile.nanos[i].Load(); n > 0 {
+			times[name] += time.Duration(n)
+		}
+	}
+	return times
+}
+
+// timeRegex adds the time since start to the slot of the i-th pattern
+func (profile *patternProfile) timeRegex(i int, start time.Time) {
+	if i < len(profile.nanos) {
+		profile.nanos[i].Add(int64(time.Since(start)))
+	}
+}
@@ -0,0 +1,148 @@
+package searcher
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPatternStatsCounters(t *testing.T) {
+	result := NewScanResult()
+	for i := 0; i < 6; i++ {
+		// Two findings per file in three files
+		result.AddFinding(&Finding{FilePath: string(rune('a'+i/2)) + ".txt", PatternType: PatternEmail, RuleID: "email", Severity: Medium})
+	}
+	result.AddFinding(&Finding{FilePath: "a.txt", PatternType: PatternPassword, RuleID: "password", Severity: Critical})
+	result.AddFinding(&Finding{FilePath: "b.txt", PatternType: PatternCustom, Severity: Low})
+
+	// Counters are kept as findings arrive, so pruning does not change them
+	result.Prune(PruneOptions{MinSeverity: Critical})
+
+	stats := result.PatternStats()
+	if len(stats) != 3 {
+		t.Fatalf("expected 3 rules, got %+v", stats)
+	}
+	email := stats[0]
+	if email.Rule != "email" || email.Findings != 6 || email.Files != 3 || email.Share != 0.75 {
+		t.Errorf("email stat = %+v", email)
+	}
+	// Findings without a rule id are grouped by pattern type; ties sort by name
+	if stats[1].Rule != string(PatternCustom) || stats[1].Findings != 1 {
+		t.Errorf("custom stat = %+v", stats[1])
+	}
+}
+
+func TestDominantPatterns(t *testing.T) {
+	result := NewScanResult()
+	for i := 0; i < 18; i++ {
+		result.AddFinding(&Finding{FilePath: "a.txt", PatternType: PatternEmail, RuleID: "email"})
+	}
+	result.AddFinding(&Finding{FilePath: "a.txt", PatternType: PatternPassword, RuleID: "password"})
+
+	// Below the minimum number of findings nothing dominates
+	if dominant := DominantPatterns(result.PatternStats(), DefaultDominantShare); len(dominant) != 0 {
+		t.Errorf("expected no dominant rule with %d findings, got %+v", result.TotalFindings(), dominant)
+	}
+
+	for i := 0; i < 5; i++ {
+		result.AddFinding(&Finding{FilePath: "b.txt", PatternType: PatternPassword, RuleID: "password"})
+	}
+	stats := result.PatternStats()
+	dominant := DominantPatterns(stats, DefaultDominantShare)
+	if len(dominant) != 1 || dominant[0].Rule != "email" {
+		t.Errorf("expected email to dominate, got %+v", dominant)
+	}
+	if dominant := DominantPatterns(stats, 0.8); len(dominant) != 0 {
+		t.Errorf("expected no rule above 80%%, got %+v", dominant)
+	}
+}
+
+func TestUnmatchedRules(t *testing.T) {
+	patterns := NewPatterns()
+	stats := []PatternStat{{Rule: "email", Findings: 2}, {Rule: "aws_key"}}
+
+	unmatched := UnmatchedRules(stats, patterns)
+	joined := "," + strings.Join(unmatched, ",") + ","
+	if strings.Contains(joined, ",email,") || !strings.Contains(joined, ",aws_key,") {
+		t.Errorf("unexpected unmatched rules: %v", unmatched)
+	}
+	// Disabled groups are not reported
+	if strings.Contains(joined, "finance_") {
+		t.Errorf("finance rules listed while the group is off: %v", unmatched)
+	}
+
+	patterns.SetGroupEnabled(GroupFinance, true)
+	unmatched = UnmatchedRules(stats, patterns)
+	if !strings.Contains(strings.Join(unmatched, ","), "finance_swift") {
+		t.Errorf("finance detectors missing once enabled: %v", unmatched)
+	}
+}
+
+func TestPatternProfiling(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("contact: alice@example.com\nregion = eu-north-1\n"), 0644)
+
+	scanner := NewScanner()
+	result, err := scanner.Scan(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, stat := range result.PatternStats() {
+		if stat.RegexTime != 0 {
+			t.Errorf("regex time recorded without profiling: %+v", stat)
+		}
+	}
+
+	scanner = NewScanner()
+	scanner.GetPatterns().SetProfiling(true)
+	if result, err = scanner.Scan(dir); err != nil {
+		t.Fatal(err)
+	}
+	stats := result.PatternStats()
+	byRule := make(map[string]PatternStat)
+	for _, stat := range stats {
+		byRule[stat.Rule] = stat
+	}
+	if email := byRule["email"]; email.Findings != 1 || email.RegexTime <= 0 {
+		t.Errorf("email stat = %+v", email)
+	}
+	// Rules that ran but matched nothing are timed too
+	if aws, ok := byRule["aws_key"]; !ok || aws.Findings != 0 || aws.RegexTime <= 0 {
+		t.Errorf("aws_key stat = %+v", aws)
+	}
+
+	// The JSON report keeps the times
+	path := filepath.Join(t.TempDir(), "report.json")
+	if err := NewReportGenerator(result).ExportJSON(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadScanResult(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, stat := range loaded.PatternStats() {
+		if stat.Rule == "email" && (stat.Findings != 1 || stat.RegexTime != byRule["email"].RegexTime) {
+			t.Errorf("loaded email stat = %+v, want %+v", stat, byRule["email"])
+		}
+	}
+}
+
+func TestFormatPatternStats(t *testing.T) {
+	got := FormatPatternStats(russian, []PatternStat{
+		{Rule: "email", Findings: 3, Files: 2, Share: 0.75, RegexTime: 1500 * time.Microsecond},
+		{Rule: "password", Findings: 1, Files: 1, Share: 0.25},
+		{Rule: "aws_key", RegexTime: time.Millisecond},
+	})
+	lines := strings.Split(strings.TrimRight(got, "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("rules without findings should be left out:\n%s", got)
+	}
+	if !strings.Contains(lines[0], "3 находки в 2 файлах (75.0%), regex 1.5ms") {
+		t.Errorf("line = %q", lines[0])
+	}
+	if !strings.Contains(lines[1], "1 находка в 1 файле (25.0%)") || strings.Contains(lines[1], "regex") {
+		t.Errorf("line = %q", lines[1])
+	}
+}
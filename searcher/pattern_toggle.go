@@ -0,0 +1,94 @@
+package searcher
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Pattern types can be switched off for a scan, e.g. email and phone
+// numbers where they are noise. Patterns of a disabled type are not run at
+// all, saving their regex work; content detectors cannot be told apart by
+// type before they run, so their findings of a disabled type are dropped
+
+// ParsePatternType parses the name of a pattern type, such as "email"
+func ParsePatternType(name string) (PatternType, error) {
+	t := PatternType(strings.ToLower(strings.TrimSpace(name)))
+	for _, known := range AllPatternTypes() {
+		if t == known {
+			return t, nil
+		}
+	}
+	valid := make([]string, 0, len(AllPatternTypes()))
+	for _, known := range AllPatternTypes() {
+		valid = append(valid, string(known))
+	}
+	return "", fmt.Errorf("неизвестный тип находок: %q (допустимо: %s)", name, strings.Join(valid, ", "))
+}
+
+// SetEnabled turns the patterns of a type on or off. Every type is enabled
+// by default
+func (p *Patterns) SetEnabled(t PatternType, enabled bool) {
+	if p.disabledTypes == nil {
+		p.disabledTypes = make(map[PatternType]bool)
+	}
+	if enabled {
+		delete(p.disabledTypes, t)
+	} else {
+		p.disabledTypes[t] = true
+	}
+}
+
+// Enabled reports whether the patterns of a type run
+func (p *Patterns) Enabled(t PatternType) bool {
+	return !p.disabledTypes[t]
+}
+
+// DisableTypes turns off the named pattern types
+func (p *Patterns) DisableTypes(names []string) error {
+	for _, name := range names {
+		t, err := ParsePatternType(name)
+		if err != nil {
+			return err
+		}
+		p.SetEnabled(t, false)
+	}
+	return nil
+}
+
+// DisabledTypes returns the disabled pattern types in the order of
+// AllPatternTypes
+func (p *Patterns) DisabledTypes() []PatternType {
+	var types []PatternType
+	for _, t := range AllPatternTypes() {
+		if p.disabledTypes[t] {
+			types = append(types, t)
+		}
+	}
+	return types
+}
+
+// SetPatternEnabled turns the patterns of a type on or off, see
+// Patterns.SetEnabled
+func (s *Scanner) SetPatternEnabled(t PatternType, enabled bool) {
+	s.patterns.SetEnabled(t, enabled)
+}
+
+// SetPatternEnabled turns the patterns of a type on or off; call it before
+// Start
+func (ss *StreamingScanner) SetPatternEnabled(t PatternType, enabled bool) {
+	ss.patterns.SetEnabled(t, enabled)
+}
+
+// enabledMatches drops the matches of disabled types
+func (p *Patterns) enabledMatches(matches []*DetectedPattern) []*DetectedPattern {
+	if len(p.disabledTypes) == 0 {
+		return matches
+	}
+	kept := matches[:0]
+	for _, match := range matches {
+		if p.Enabled(match.Type) {
+			kept = append(kept, match)
+		}
+	}
+	return kept
+}
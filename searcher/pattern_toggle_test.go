@@ -0,0 +1,92 @@
+package searcher
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestDisabledPatternType tests a disabled type gives no findings on a file
+// it matched before, while the other types still do
+func TestDisabledPatternType(t *testing.T) {
+	dir := t.TempDir()
+	content := "contact = admin@example.com\npassword = Sup3rS3cretPass!\n"
+	os.WriteFile(filepath.Join(dir, "config.ini"), []byte(content), 0644)
+
+	scanner := NewScanner()
+	result, err := scanner.Scan(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(findingsOfType(result.Findings, PatternEmail)) == 0 {
+		t.Fatalf("fixture gives no email finding: %+v", result.Findings)
+	}
+
+	scanner = NewScanner()
+	scanner.SetPatternEnabled(PatternEmail, false)
+	result, err = scanner.Scan(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(findingsOfType(result.Findings, PatternEmail)); n != 0 {
+		t.Errorf("%d email findings with email disabled", n)
+	}
+	if len(findingsOfType(result.Findings, PatternPassword)) == 0 {
+		t.Error("disabling email dropped the password finding")
+	}
+
+	scanner.SetPatternEnabled(PatternEmail, true)
+	result, err = scanner.Scan(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(findingsOfType(result.Findings, PatternEmail)) == 0 {
+		t.Error("re-enabled email gives no findings")
+	}
+}
+
+func TestFindAllSkipsDisabledTypes(t *testing.T) {
+	patterns := NewPatterns()
+	text := "mail me at admin@example.com"
+	if len(patterns.FindAll(text)) == 0 {
+		t.Fatal("no match before disabling")
+	}
+	if err := patterns.DisableTypes([]string{"email"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range patterns.FindAll(text) {
+		if m.Type == PatternEmail {
+			t.Errorf("disabled type matched: %+v", m)
+		}
+	}
+	if got := patterns.DisabledTypes(); len(got) != 1 || got[0] != PatternEmail {
+		t.Errorf("DisabledTypes() = %v", got)
+	}
+}
+
+func TestParsePatternType(t *testing.T) {
+	if got, err := ParsePatternType(" Email "); err != nil || got != PatternEmail {
+		t.Errorf("ParsePatternType(Email) = %q, %v", got, err)
+	}
+	if _, err := ParsePatternType("mail"); err == nil {
+		t.Error("unknown type accepted")
+	}
+	if err := NewPatterns().DisableTypes([]string{"phone", "nope"}); err == nil {
+		t.Error("DisableTypes accepted an unknown type")
+	}
+}
+
+// TestDisabledTypesInvalidateCache tests the incremental cache does not
+// replay findings recorded with other types enabled
+func TestDisabledTypesInvalidateCache(t *testing.T) {
+	scanner := NewScanner()
+	before := scanner.rulesVersion()
+	scanner.SetPatternEnabled(PatternEmail, false)
+	if scanner.rulesVersion() == before {
+		t.Error("disabling a type keeps the rules version")
+	}
+	scanner.SetPatternEnabled(PatternEmail, true)
+	if scanner.rulesVersion() != before {
+		t.Error("re-enabling a type does not restore the rules version")
+	}
+}
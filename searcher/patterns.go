@@ -1,7 +1,10 @@
 package searcher
 
 import (
+	"fmt"
 	"regexp"
+	"strings"
+	"time"
 )
 
 // PatternType represents the category of sensitive data detected
@@ -15,12 +18,28 @@ const (
 	PatternPrivateKey  PatternType = "private_key"
 	PatternAWSKey      PatternType = "aws_key"
 	PatternGitHubToken PatternType = "github_token"
+	PatternJWT         PatternType = "jwt"
+	PatternLogin       PatternType = "login"
+
+	// Provider keys
+	PatternSlackToken        PatternType = "slack_token"
+	PatternStripeKey         PatternType = "stripe_key"
+	PatternGoogleAPIKey      PatternType = "google_api_key"
+	PatternGCPServiceAccount PatternType = "gcp_service_account"
+	PatternTwilioKey         PatternType = "twilio_key"
+	PatternSendGridKey       PatternType = "sendgrid_key"
 
 	// Personal Data
 	PatternEmail       PatternType = "email"
 	PatternPhoneNumber PatternType = "phone_number"
 	PatternSSN         PatternType = "ssn"
 	PatternPassport    PatternType = "passport"
+	PatternRuPassport  PatternType = "ru_passport"
+	PatternINN         PatternType = "inn"
+
+	// Files with many distinct addresses or numbers, see bulkCounter
+	PatternBulkEmails PatternType = "bulk_emails"
+	PatternBulkPhones PatternType = "bulk_phones"
 
 	// Financial Data
 	PatternCreditCard PatternType = "credit_card"
@@ -35,56 +54,252 @@ const (
 	// Hardcoded Secrets
 	PatternHardcodedSecret PatternType = "hardcoded_secret"
 	PatternConnectionStr   PatternType = "connection_string"
+	PatternHighEntropy     PatternType = "high_entropy" // Generic detector, see HighEntropyDetector
+
+	// Financial pack (detector group "finance")
+	PatternCryptoKey      PatternType = "crypto_private_key"
+	PatternSeedPhrase     PatternType = "seed_phrase"
+	PatternCryptoKeystore PatternType = "crypto_keystore"
+	PatternSWIFTMessage   PatternType = "swift_message"
+	PatternSEPAPayment    PatternType = "sepa_payment"
+	PatternBankStatement  PatternType = "bank_statement"
+
+	// Medical and HR packs
+	PatternDiagnosisCode    PatternType = "diagnosis_code"
+	PatternHealthInsurance  PatternType = "health_insurance"
+	PatternMedicalRecord    PatternType = "medical_record"
+	PatternSNILS            PatternType = "snils"
+	PatternSalary           PatternType = "salary"
+	PatternEmploymentRecord PatternType = "employment_record"
+
+	// Files whose content could not be checked
+	PatternProtectedPDF PatternType = "protected_pdf"
+
+	// Image metadata, see readImageMetadata
+	PatternGeoMetadata   PatternType = "geo_metadata"
+	PatternImageMetadata PatternType = "image_metadata"
+
+	// Infrastructure files, see FileTypeDetector
+	PatternDockerfileSecret PatternType = "dockerfile_secret"
+	PatternComposeSecret    PatternType = "compose_secret"
+	PatternK8sSecret        PatternType = "k8s_secret"
+
+	// User-defined rules
+	PatternCustom PatternType = "custom"
 )
 
+// AllPatternTypes returns every built-in pattern type, in declaration order
+func AllPatternTypes() []PatternType {
+	return []PatternType{
+		PatternPassword, PatternAPIKey, PatternToken, PatternPrivateKey, PatternAWSKey, PatternGitHubToken, PatternJWT, PatternLogin,
+		PatternSlackToken, PatternStripeKey, PatternGoogleAPIKey, PatternGCPServiceAccount, PatternTwilioKey, PatternSendGridKey,
+		PatternEmail, PatternPhoneNumber, PatternSSN, PatternPassport, PatternRuPassport, PatternINN,
+		PatternBulkEmails, PatternBulkPhones,
+		PatternCreditCard, PatternIBAN, PatternBIC,
+		PatternEnvVar, PatternJSONSecret, PatternYAMLSecret,
+		PatternHardcodedSecret, PatternConnectionStr, PatternHighEntropy,
+		PatternCryptoKey, PatternSeedPhrase, PatternCryptoKeystore, PatternSWIFTMessage, PatternSEPAPayment, PatternBankStatement,
+		PatternDiagnosisCode, PatternHealthInsurance, PatternMedicalRecord, PatternSNILS, PatternSalary, PatternEmploymentRecord,
+		PatternProtectedPDF,
+		PatternGeoMetadata, PatternImageMetadata,
+		PatternDockerfileSecret, PatternComposeSecret, PatternK8sSecret,
+		PatternCustom,
+	}
+}
+
 // Pattern defines a regex pattern and its metadata
 type Pattern struct {
+	Name        string
 	Type        PatternType
 	Regex       *regexp.Regexp
 	Severity    Severity
 	Description string
+	Group       string            // Optional detector group; empty means always enabled
+	Validate    func(string) bool // Optional check of the matched text, e.g. a checksum
+	Near        *regexp.Regexp    // Optional context that must appear close to the match
+	NearLines   int               // How many lines around the match Near may be on
+	MinEntropy  float64           // Optional minimum Shannon entropy of the match, 0 means no check
+
+	// ValidateName names the Validate check in the description of matches
+	// kept without strict validation, e.g. "Luhn"
+	ValidateName string
+
+	// Refine optionally inspects an accepted match and adjusts its severity,
+	// description or risk bonus; returning false drops the match
+	Refine func(*DetectedPattern) bool
+
+	// Keywords optionally lists lowercase strings one of which every match
+	// contains; the regex is not run on text without any of them
+	Keywords []string
+}
+
+// hasKeyword reports whether lower, a lowercased text, may hold a match
+func (p *Pattern) hasKeyword(lower string) bool {
+	if len(p.Keywords) == 0 {
+		return true
+	}
+	for _, keyword := range p.Keywords {
+		if strings.Contains(lower, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// ruleEntropy measures matches of rules with MinEntropy
+var ruleEntropy = NewEntropyCalculator()
+
+// accepts applies the optional checks of the rule to a match. A match that
+// fails Validate is kept when lenient, and failed reports it
+func (p *Pattern) accepts(text string, lenient bool) (ok, failed bool) {
+	if p.MinEntropy > 0 && ruleEntropy.CalculateEntropy(text) < p.MinEntropy {
+		return false, false
+	}
+	if p.Validate == nil || p.Validate(text) {
+		return true, false
+	}
+	return lenient, true
+}
+
+// failedCheckFormat is appended to the description of a match that failed
+// its rule's Validate check
+const failedCheckFormat = " (failed %s check)"
+
+// markFailedCheck downgrades a match that failed the Validate check of its
+// rule to Low and notes the check in the description
+func (d *DetectedPattern) markFailedCheck(p *Pattern) {
+	name := p.ValidateName
+	if name == "" {
+		name = "validation"
+	}
+	d.Severity = Low
+	d.Description += fmt.Sprintf(failedCheckFormat, name)
+}
+
+// SplitFailedCheck splits a description marked by a failed Validate check
+// into the rule's description and the check name
+func SplitFailedCheck(desc string) (base, check string, ok bool) {
+	rest, found := strings.CutSuffix(desc, " check)")
+	if !found {
+		return desc, "", false
+	}
+	i := strings.LastIndex(rest, " (failed ")
+	if i < 0 {
+		return desc, "", false
+	}
+	return rest[:i], rest[i+len(" (failed "):], true
+}
+
+// ContentDetector finds data that spans several lines or needs the whole
+// file, such as a seed phrase or a SWIFT message. Detect returns matches
+// with LineNumber (1-based) and the byte span within that line set.
+type ContentDetector interface {
+	Name() string
+	Group() string
+	Detect(lines []string) []*DetectedPattern
+}
+
+// FileTypeDetector finds the leak idioms of one kind of file, such as the
+// ENV lines of a Dockerfile. Match selects the files by path; Detect gets
+// their lines and returns matches like a ContentDetector.
+type FileTypeDetector interface {
+	Name() string
+	Match(path string) bool
+	Detect(lines []string) []*DetectedPattern
+}
+
+// Optional detector groups, disabled by default
+const GroupFinance = "finance"
+
+// groupNames maps accepted group names, including Russian ones, to groups
+var groupNames = map[string]string{
+	"finance": GroupFinance,
+	"финансы": GroupFinance,
+}
+
+// ParseGroup resolves a detector group name
+func ParseGroup(name string) (string, error) {
+	group, ok := groupNames[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return "", fmt.Errorf("неизвестная группа детекторов: %s", name)
+	}
+	return group, nil
 }
 
 // Patterns contains all detection patterns
 type Patterns struct {
-	patterns []*Pattern
+	patterns      []*Pattern
+	detectors     []ContentDetector
+	fileDetectors []FileTypeDetector
+	packs         []*Pack
+	enabledGroups map[string]bool
+	disabledTypes map[PatternType]bool // Types not run, see SetEnabled
+	profile       *patternProfile      // Regex timing, nil unless SetProfiling(true)
+	lenient       bool                 // Keep matches failing Validate, see SetStrictValidation
 }
 
 // NewPatterns creates a new Patterns instance with all predefined patterns
 func NewPatterns() *Patterns {
 	p := &Patterns{
-		patterns: make([]*Pattern, 0),
+		patterns:      make([]*Pattern, 0),
+		enabledGroups: make(map[string]bool),
 	}
 
 	// Credentials Patterns
 	p.addPattern(PatternPassword, `(?i)(password\s*[=:]\s*['"]?[^\s'";]+['"]?)`, Critical, "Password assignment detected")
+	p.setKeywords("password")
 	p.addPattern(PatternAPIKey, `(?i)(api[_-]?key\s*[=:]\s*['"]?[^\s'";]{20,}['"]?)`, Critical, "API Key detected")
+	p.setKeywords("api")
 	p.addPattern(PatternToken, `(?i)(token\s*[=:]\s*['"]?[A-Za-z0-9\-_.]{20,}['"]?)`, Critical, "Authentication token detected")
+	p.setKeywords("token")
 	p.addPattern(PatternPrivateKey, `-----BEGIN\s+(RSA\s+)?PRIVATE\s+KEY`, Critical, "Private key detected")
 	p.addPattern(PatternAWSKey, `(AKIA[0-9A-Z]{16})`, Critical, "AWS Access Key detected")
 	p.addPattern(PatternGitHubToken, `(gh[pousr]_[A-Za-z0-9_]{36,255})`, Critical, "GitHub token detected")
+	p.addPattern(PatternJWT, jwtRegex, High, jwtDescription)
+	p.patterns[len(p.patterns)-1].Refine = refineJWT
+	addProviderPatterns(p)
 
 	// Personal Data Patterns
 	p.addPattern(PatternEmail, `\b[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Z|a-z]{2,}\b`, Medium, "Email address detected")
-	p.addPattern(PatternPhoneNumber, `(?:\+?1[-.\s]?)?\(?[0-9]{3}\)?[-.\s]?[0-9]{3}[-.\s]?[0-9]{4}\b`, Medium, "Phone number detected")
+	// The area code starts a word, so the tail of a longer number such as
+	// +7 999 123-45-67 is not taken for one
+	p.addPattern(PatternPhoneNumber, `(?:\+?1[-.\s]?(?:\([0-9]{3}\)|[0-9]{3})|\([0-9]{3}\)|\b[0-9]{3})[-.\s]?[0-9]{3}[-.\s]?[0-9]{4}\b`, Medium, "Phone number detected")
 	// SSN pattern - Go RE2 doesn't support lookaheads, so we use a simpler pattern
 	// This matches XXX-XX-XXXX where first digit is 0-8 (excludes 9xx and catches most valid SSNs)
 	p.addPattern(PatternSSN, `\b[0-8]\d{2}-[0-9]{2}-[0-9]{4}\b`, High, "Social Security Number detected")
 	p.addPattern(PatternPassport, `(?i)passport\s*[:=]\s*([A-Z]{1,2}[0-9]{6,9})`, High, "Passport number detected")
+	p.setKeywords("passport")
+	addRussianPatterns(p)
 
 	// Financial Data Patterns
-	p.addPattern(PatternCreditCard, `\b(?:4[0-9]{12}(?:[0-9]{3})?|5[1-5][0-9]{14}|3[47][0-9]{13}|3(?:0[0-5]|[68][0-9])[0-9]{11}|6(?:011|5[0-9]{2})[0-9]{12})\b`, Critical, "Credit card number detected")
+	// Card numbers may be grouped with spaces or dashes; candidates are
+	// checked against the network prefixes and the Luhn checksum
+	p.addPattern(PatternCreditCard, `\b(?:(?:4\d{3}|5[1-5]\d{2}|2[2-7]\d{2}|35\d{2}|6(?:011|2\d{2}|4[4-9]\d|5\d{2}))(?:[ -]?\d{4}){3}|3[47]\d{2}[ -]?\d{6}[ -]?\d{5}|3(?:0[0-5]|[68]\d)\d[ -]?\d{6}[ -]?\d{4}|4\d{12})\b`, Critical, "Credit card number detected")
+	card := p.patterns[len(p.patterns)-1]
+	card.Validate = ValidCardNumber
+	card.ValidateName = "Luhn"
 	p.addPattern(PatternIBAN, `\b[A-Z]{2}[0-9]{2}[A-Z0-9]{1,30}\b`, High, "IBAN detected")
 	p.addPattern(PatternBIC, `\b[A-Z]{6}[A-Z0-9]{2}([A-Z0-9]{3})?\b`, Medium, "BIC code detected")
 
 	// Configuration & Secrets Patterns
 	p.addPattern(PatternEnvVar, `(?i)export\s+[A-Z_][A-Z0-9_]*\s*=\s*['"]?[^\s'";]+['"]?`, High, "Environment variable assignment detected")
+	p.setKeywords("export")
 	p.addPattern(PatternJSONSecret, `(?i)(["\']?(api_key|password|secret|token|private_key|access_key)["\']?\s*:\s*["\']?[A-Za-z0-9\-_.]{8,}["\']?)`, High, "JSON secret detected")
+	p.setKeywords("api_key", "password", "secret", "token", "private_key", "access_key")
 	p.addPattern(PatternYAMLSecret, `(?i)([a-z_]+_key|secret|password)\s*:\s*[A-Za-z0-9\-_.]{8,}`, High, "YAML secret detected")
+	p.setKeywords("_key", "secret", "password")
 
 	// Hardcoded Secrets
 	p.addPattern(PatternConnectionStr, `(?i)(connection_string|database_url|db_connection)\s*[=:]\s*['"]?[^\s'";]+['"]?`, High, "Connection string detected")
+	p.setKeywords("connection_string", "database_url", "db_connection")
 	p.addPattern(PatternHardcodedSecret, `(?i)(secret|api_secret|private_secret)\s*[=:]\s*['"]?[A-Za-z0-9\-_.=+/]{16,}['"]?`, Critical, "Hardcoded secret detected")
+	p.setKeywords("secret")
+
+	// Dockerfiles, docker-compose files and Kubernetes manifests
+	addInfraDetectors(p)
+
+	// Optional detector groups, disabled until enabled with SetGroupEnabled
+	addFinancePack(p)
 
 	return p
 }
@@ -98,6 +313,7 @@ func (p *Patterns) addPattern(patternType PatternType, regexStr string, severity
 	}
 
 	p.patterns = append(p.patterns, &Pattern{
+		Name:        string(patternType),
 		Type:        patternType,
 		Regex:       regex,
 		Severity:    severity,
@@ -105,28 +321,203 @@ func (p *Patterns) addPattern(patternType PatternType, regexStr string, severity
 	})
 }
 
+// NewPatternsFrom creates a Patterns instance holding only the given patterns
+func NewPatternsFrom(patterns []*Pattern) *Patterns {
+	p := &Patterns{
+		patterns:      make([]*Pattern, 0, len(patterns)),
+		enabledGroups: make(map[string]bool),
+	}
+	for _, pattern := range patterns {
+		p.Add(pattern)
+	}
+	return p
+}
+
+// Add appends an already compiled pattern
+func (p *Patterns) Add(pattern *Pattern) {
+	if pattern == nil || pattern.Regex == nil {
+		return
+	}
+	if pattern.Name == "" {
+		pattern.Name = string(pattern.Type)
+	}
+	p.patterns = append(p.patterns, pattern)
+}
+
+// remove drops the given patterns, compared by identity
+func (p *Patterns) remove(patterns []*Pattern) {
+	if len(patterns) == 0 {
+		return
+	}
+	drop := make(map[*Pattern]bool, len(patterns))
+	for _, pattern := range patterns {
+		drop[pattern] = true
+	}
+	kept := p.patterns[:0]
+	for _, pattern := range p.patterns {
+		if !drop[pattern] {
+			kept = append(kept, pattern)
+		}
+	}
+	p.patterns = kept
+}
+
+// List returns the registered patterns in match order
+func (p *Patterns) List() []*Pattern {
+	list := make([]*Pattern, len(p.patterns))
+	copy(list, p.patterns)
+	return list
+}
+
 // FindAll returns all patterns matching in the given text
 func (p *Patterns) FindAll(text string) []*DetectedPattern {
 	var results []*DetectedPattern
 
-	for _, pattern := range p.patterns {
+	profile := p.profile
+	lower, lowered := "", false
+	for i, pattern := range p.patterns {
+		// Proximity rules need surrounding lines and run as content detectors
+		if !p.isEnabled(pattern.Group) || pattern.Near != nil || p.disabledTypes[pattern.Type] {
+			continue
+		}
+		if len(pattern.Keywords) > 0 {
+			if !lowered {
+				lower, lowered = strings.ToLower(text), true
+			}
+			if !pattern.hasKeyword(lower) {
+				continue
+			}
+		}
+		var start time.Time
+		if profile != nil {
+			start = time.Now()
+		}
 		matches := pattern.Regex.FindAllStringIndex(text, -1)
+		if profile != nil {
+			profile.timeRegex(i, start)
+		}
 		for _, match := range matches {
-			results = append(results, &DetectedPattern{
+			ok, failed := pattern.accepts(text[match[0]:match[1]], p.lenient)
+			if !ok {
+				continue
+			}
+			detected := &DetectedPattern{
 				Type:        pattern.Type,
+				RuleName:    pattern.Name,
 				Pattern:     pattern.Regex.String(),
 				Severity:    pattern.Severity,
 				Description: pattern.Description,
 				StartIndex:  match[0],
 				EndIndex:    match[1],
 				MatchText:   text[match[0]:match[1]],
-			})
+				Group:       pattern.Group,
+			}
+			if failed {
+				detected.markFailedCheck(pattern)
+			}
+			if pattern.Refine != nil && !pattern.Refine(detected) {
+				continue
+			}
+			results = append(results, detected)
 		}
 	}
 
 	return results
 }
 
+// SetStrictValidation sets whether matches failing their rule's Validate
+// check, such as card numbers failing Luhn, are dropped (the default). When
+// off they are reported with Low severity and the failed check in the
+// description
+func (p *Patterns) SetStrictValidation(strict bool) {
+	p.lenient = !strict
+}
+
+// StrictValidation reports whether matches failing validation are dropped
+func (p *Patterns) StrictValidation() bool {
+	return !p.lenient
+}
+
+// SetGroupEnabled turns an optional detector group on or off as a unit
+func (p *Patterns) SetGroupEnabled(group string, enabled bool) {
+	if p.enabledGroups == nil {
+		p.enabledGroups = make(map[string]bool)
+	}
+	p.enabledGroups[group] = enabled
+}
+
+// GroupEnabled reports whether a detector group is enabled
+func (p *Patterns) GroupEnabled(group string) bool {
+	return p.isEnabled(group)
+}
+
+// EnableGroups enables the named detector groups
+func (p *Patterns) EnableGroups(names []string) error {
+	for _, name := range names {
+		group, err := ParseGroup(name)
+		if err != nil {
+			return err
+		}
+		p.SetGroupEnabled(group, true)
+	}
+	return nil
+}
+
+func (p *Patterns) isEnabled(group string) bool {
+	return group == "" || p.enabledGroups[group]
+}
+
+// AddDetector registers a whole-file content detector
+func (p *Patterns) AddDetector(d ContentDetector) {
+	p.detectors = append(p.detectors, d)
+}
+
+// AddFileTypeDetector registers a detector for files of one kind
+func (p *Patterns) AddFileTypeDetector(d FileTypeDetector) {
+	p.fileDetectors = append(p.fileDetectors, d)
+}
+
+// FileTypeDetectors returns the detectors that check the file at path
+func (p *Patterns) FileTypeDetectors(path string) []FileTypeDetector {
+	var list []FileTypeDetector
+	for _, d := range p.fileDetectors {
+		if d.Match(path) {
+			list = append(list, d)
+		}
+	}
+	return list
+}
+
+// Detectors returns the content detectors of enabled groups, including
+// one per proximity rule
+func (p *Patterns) Detectors() []ContentDetector {
+	var list []ContentDetector
+	for _, d := range p.detectors {
+		if p.isEnabled(d.Group()) {
+			list = append(list, d)
+		}
+	}
+	for _, pattern := range p.patterns {
+		if pattern.Near != nil && p.isEnabled(pattern.Group) && p.Enabled(pattern.Type) {
+			list = append(list, proximityDetector{pattern: pattern, lenient: p.lenient})
+		}
+	}
+	return list
+}
+
+// addGroupPattern adds a pattern that belongs to an optional detector group
+func (p *Patterns) addGroupPattern(group string, patternType PatternType, regexStr string, severity Severity, description string, validate func(string) bool) {
+	p.patterns = append(p.patterns, &Pattern{
+		Name:        string(patternType),
+		Type:        patternType,
+		Regex:       regexp.MustCompile(regexStr),
+		Severity:    severity,
+		Description: description,
+		Group:       group,
+		Validate:    validate,
+	})
+}
+
 // GetPatternByType returns all patterns of a specific type
 func (p *Patterns) GetPatternByType(patternType PatternType) *Pattern {
 	for _, pattern := range p.patterns {
@@ -1,6 +1,8 @@
 package searcher
 
 import (
+	"regexp"
+	"strings"
 	"testing"
 )
 
@@ -87,7 +89,8 @@ func TestCreditCardPattern(t *testing.T) {
 		shouldFind bool
 	}{
 		{"Card: 4532015112830366", true}, // Valid Visa
-		{"5425233010103010", true},       // Valid Mastercard
+		{"5425233430109903", true},       // Valid Mastercard
+		{"5425233010103010", false},      // Fails the Luhn check
 		{"1234567890123456", false},      // Invalid
 		{"Card number hidden", false},
 	}
@@ -282,3 +285,26 @@ func TestNoMatches(t *testing.T) {
 		t.Errorf("Regular text should return no detections, got %d", len(detections))
 	}
 }
+
+// TestPatternKeywords tests a rule only runs on text with one of its
+// keywords, in any case
+func TestPatternKeywords(t *testing.T) {
+	patterns := NewPatternsFrom([]*Pattern{{
+		Name: "order", Type: PatternToken, Regex: regexp.MustCompile(`(?i)заказ\s*\d+`),
+		Severity: Low, Keywords: []string{"заказ"},
+	}})
+	for text, want := range map[string]int{"заказ 12": 1, "ЗАКАЗ 34": 1, "счёт 56": 0} {
+		if got := len(patterns.FindAll(text)); got != want {
+			t.Errorf("%q: %d matches, want %d", text, got, want)
+		}
+	}
+
+	// Every keyword of a built-in rule is lowercase, or it would never be found
+	for _, p := range NewPatterns().List() {
+		for _, keyword := range p.Keywords {
+			if keyword != strings.ToLower(keyword) {
+				t.Errorf("%s: keyword %q is not lowercase", p.Name, keyword)
+			}
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package searcher
+
+import (
+	"sync"
+	"time"
+)
+
+// pauseGate holds workers back while a scan is paused
+type pauseGate struct {
+	mu       sync.Mutex
+	cond     *sync.Cond
+	paused   bool
+	stopped  bool // Set when the scan is cancelled; wait no longer blocks
+	pausedAt time.Time
+	total    time.Duration // Paused time of finished pauses
+}
+
+func newPauseGate() *pauseGate {
+	g := &pauseGate{}
+	g.cond = sync.NewCond(&g.mu)
+	return g
+}
+
+// pause closes the gate; it reports false if it was already closed
+func (g *pauseGate) pause() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.paused {
+		return false
+	}
+	g.paused = true
+	g.pausedAt = time.Now()
+	return true
+}
+
+// resume opens the gate and wakes the waiting workers
+func (g *pauseGate) resume() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if !g.paused {
+		return false
+	}
+	g.paused = false
+	g.total += time.Since(g.pausedAt)
+	g.cond.Broadcast()
+	return true
+}
+
+// stop wakes the waiting workers of a cancelled scan without resuming it
+func (g *pauseGate) stop() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.stopped = true
+	g.cond.Broadcast()
+}
+
+// wait blocks while the gate is closed
+func (g *pauseGate) wait() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for g.paused && !g.stopped {
+		g.cond.Wait()
+	}
+}
+
+func (g *pauseGate) isPaused() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.paused
+}
+
+// pausedFor returns the total paused time, including a pause in progress
+func (g *pauseGate) pausedFor() time.Duration {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.paused {
+		return g.total + time.Since(g.pausedAt)
+	}
+	return g.total
+}
+
+// resetTotal forgets the paused time and the cancellation of a previous scan
+func (g *pauseGate) resetTotal() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.total = 0
+	g.stopped = false
+	if g.paused {
+		g.pausedAt = time.Now()
+	}
+}
+
+// Pause stops the scan from starting new files; files already being
+// scanned finish. It has no effect on a paused scanner
+func (s *Scanner) Pause() {
+	s.gate.pause()
+}
+
+// Resume continues a paused scan
+func (s *Scanner) Resume() {
+	s.gate.resume()
+}
+
+// IsPaused reports whether the scanner is paused
+func (s *Scanner) IsPaused() bool {
+	return s.gate.isPaused()
+}
+
+// PausedDuration returns how long the current scan has been paused in total
+func (s *Scanner) PausedDuration() time.Duration {
+	return s.gate.pausedFor()
+}
@@ -0,0 +1,119 @@
+package searcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestScannerPauseResume(t *testing.T) {
+	dir := t.TempDir()
+	for i := 0; i < 300; i++ {
+		content := fmt.Sprintf("line %d\npassword = Secret%04dValue\n", i, i)
+		os.WriteFile(filepath.Join(dir, fmt.Sprintf("config%03d.txt", i)), []byte(content), 0644)
+	}
+
+	scanner := NewScanner()
+	scanner.SetMaxConcurrentFiles(2)
+	// Paused before the walk starts, nothing is scanned until Resume
+	scanner.Pause()
+	if !scanner.IsPaused() {
+		t.Fatal("IsPaused should report true")
+	}
+
+	done := make(chan *ScanResult)
+	go func() {
+		result, _ := scanner.Scan(dir)
+		done <- result
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	first := scanner.Progress().FilesProcessed
+	time.Sleep(200 * time.Millisecond)
+	if second := scanner.Progress().FilesProcessed; second != first {
+		t.Fatalf("files scanned while paused: %d -> %d", first, second)
+	}
+	select {
+	case <-done:
+		t.Fatal("scan finished while paused")
+	default:
+	}
+
+	scanner.Resume()
+	// Pause again mid-scan: in-flight files may finish, then the count stops
+	time.Sleep(5 * time.Millisecond)
+	scanner.Pause()
+	time.Sleep(50 * time.Millisecond)
+	settled := scanner.Progress().FilesProcessed
+	time.Sleep(200 * time.Millisecond)
+	if now := scanner.Progress().FilesProcessed; now != settled {
+		t.Errorf("files scanned while paused mid-scan: %d -> %d", settled, now)
+	}
+	if scanner.PausedDuration() < 400*time.Millisecond {
+		t.Errorf("paused duration = %v, want at least 400ms", scanner.PausedDuration())
+	}
+
+	scanner.Resume()
+	select {
+	case result := <-done:
+		if result.FilesScanned != 300 {
+			t.Errorf("FilesScanned = %d, want 300", result.FilesScanned)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("scan did not complete after Resume")
+	}
+	if scanner.IsPaused() {
+		t.Error("IsPaused should report false after Resume")
+	}
+}
+
+func TestScannerCancelWhilePaused(t *testing.T) {
+	dir := t.TempDir()
+	for i := 0; i < 100; i++ {
+		os.WriteFile(filepath.Join(dir, fmt.Sprintf("config%03d.txt", i)), []byte("password = Secret1234Value\n"), 0644)
+	}
+
+	scanner := NewScanner()
+	scanner.SetMaxConcurrentFiles(2)
+	scanner.Pause()
+	defer scanner.Resume()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	type outcome struct {
+		result *ScanResult
+		err    error
+	}
+	done := make(chan outcome)
+	go func() {
+		result, err := scanner.ScanContext(ctx, dir)
+		done <- outcome{result, err}
+	}()
+
+	// Cancelling a paused scan returns without resuming it
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	select {
+	case got := <-done:
+		if !errors.Is(got.err, ErrCancelled) || !got.result.Cancelled {
+			t.Errorf("err = %v, Cancelled = %v", got.err, got.result.Cancelled)
+		}
+		if got.result.FilesScanned >= 100 {
+			t.Errorf("FilesScanned = %d, want a partial count", got.result.FilesScanned)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("cancelled scan did not return while paused")
+	}
+	if !scanner.IsPaused() {
+		t.Error("cancelling should not resume the scanner")
+	}
+
+	// The next scan is not affected by the cancellation
+	scanner.Resume()
+	if result, err := scanner.Scan(dir); err != nil || result.FilesScanned != 100 {
+		t.Errorf("next Scan = %d files, err %v", result.FilesScanned, err)
+	}
+}
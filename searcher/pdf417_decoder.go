@@ -0,0 +1,661 @@
+package searcher
+
+import (
+	"errors"
+	"math"
+	"math/big"
+)
+
+// PDF417 symbols are read along the rows of pixels: a row crossing the
+// start pattern yields the codewords of one row of the symbol, numbered by
+// the row indicator next to it. The codewords seen on the many rows of
+// pixels of each symbol row are voted on, corrected with Reed-Solomon over
+// GF(929) and decoded from the text, byte and numeric compaction modes.
+// Like Code 128, symbols are read left to right and upside down, vertical
+// ones are found by the rotations of the image analyzer; Macro PDF417
+// control blocks are skipped
+
+var errPDF417Unreadable = errors.New("PDF417 не читается")
+
+// Widths in modules of the start and stop patterns
+var (
+	pdf417Start = []int{8, 1, 1, 1, 1, 1, 1, 3}
+	pdf417Stop  = []int{7, 1, 1, 3, 1, 1, 1, 2, 1}
+)
+
+// Bounds of a symbol
+const (
+	pdf417MaxColumns   = 30
+	pdf417MaxRows      = 90
+	pdf417MaxCodewords = 928
+)
+
+// pdf417Line is a row of a symbol read along a row of pixels
+type pdf417Line struct {
+	x, y      int // Start of the start pattern
+	module    float64
+	row       int   // Row of the symbol, -1 when the row indicators are unreadable
+	codewords []int // Row indicators and data, -1 where unreadable
+	stopped   bool  // The line ends with the stop pattern
+}
+
+// decodePDF417 reads the PDF417 symbols of a binarized image
+func decodePDF417(m *bitMatrix) []string {
+	var symbols [][]pdf417Line
+	add := func(line pdf417Line, reversed bool) {
+		// Lines of one symbol start at the same place on consecutive rows
+		for i := len(symbols) - 1; i >= 0; i-- {
+			last := symbols[i][len(symbols[i])-1]
+			if line.y-last.y <= int(8*line.module)+1 && math.Abs(float64(line.x-last.x)) <= 2*line.module &&
+				math.Abs(line.module-last.module) <= line.module/4 && (last.x < 0) == reversed {
+				symbols[i] = append(symbols[i], line)
+				return
+			}
+		}
+		symbols = append(symbols, []pdf417Line{line})
+	}
+	for y := 0; y < m.height; y++ {
+		runs := m.rowRuns(y)
+		for _, line := range scanPDF417Row(runs, y) {
+			add(line, false)
+		}
+		// Upside down symbols are read from the right; their lines are
+		// told apart by a negative x
+		reversed := make([]bitRun, len(runs))
+		for k, r := range runs {
+			reversed[len(runs)-1-k] = bitRun{start: -(r.start + r.length), length: r.length, dark: r.dark}
+		}
+		for _, line := range scanPDF417Row(reversed, y) {
+			add(line, true)
+		}
+	}
+
+	var found []string
+	seen := make(map[string]bool)
+	for _, lines := range symbols {
+		if len(lines) < 3 {
+			continue
+		}
+		if text, err := decodePDF417Lines(lines); err == nil && !seen[text] {
+			seen[text] = true
+			found = append(found, text)
+		}
+	}
+	return found
+}
+
+// scanPDF417Row reads the symbol rows crossed by the runs of a row
+func scanPDF417Row(runs []bitRun, y int) []pdf417Line {
+	var lines []pdf417Line
+	for s := 0; s+len(pdf417Start) <= len(runs); s++ {
+		if !runs[s].dark {
+			continue
+		}
+		module, ok := pdf417Pattern(runs[s:s+len(pdf417Start)], pdf417Start)
+		if !ok {
+			continue
+		}
+		line, used := readPDF417Line(runs[s+len(pdf417Start):], module)
+		if len(line.codewords) < 2 {
+			continue
+		}
+		line.x, line.y = runs[s].start, y
+		lines = append(lines, line)
+		s += len(pdf417Start) + used - 1
+	}
+	return lines
+}
+
+// pdf417Pattern matches runs against the widths of a pattern and returns
+// the width of a module; each width may be off by 0.6 modules, less than
+// tells the 7 module bar of the stop pattern from the 6 of a codeword
+func pdf417Pattern(runs []bitRun, widths []int) (float64, bool) {
+	total, modules := 0, 0
+	for i, r := range runs {
+		total += r.length
+		modules += widths[i]
+	}
+	if total < modules {
+		return 0, false
+	}
+	module := float64(total) / float64(modules)
+	for i, r := range runs {
+		if math.Abs(float64(r.length)-float64(widths[i])*module) > 0.6*module {
+			return 0, false
+		}
+	}
+	return module, true
+}
+
+// readPDF417Line reads the codewords following a start pattern up to the
+// stop pattern; it returns the line and the runs read. Reading stops where
+// the runs no longer add up to codewords
+func readPDF417Line(runs []bitRun, module float64) (pdf417Line, int) {
+	line := pdf417Line{module: module, row: -1}
+	clusters := make([]int, 0, pdf417MaxColumns+2)
+	used := 0
+	for len(line.codewords) < pdf417MaxColumns+2 {
+		if used+len(pdf417Stop) <= len(runs) {
+			if _, ok := pdf417Pattern(runs[used:used+len(pdf417Stop)], pdf417Stop); ok {
+				line.stopped = true
+				used += len(pdf417Stop)
+				break
+			}
+		}
+		if used+8 > len(runs) {
+			break
+		}
+		total := 0
+		for _, r := range runs[used : used+8] {
+			total += r.length
+		}
+		if math.Abs(float64(total)-17*module) > 17*module/4 {
+			break
+		}
+		value, cluster := pdf417Codeword(runs[used : used+8])
+		line.codewords = append(line.codewords, value)
+		clusters = append(clusters, cluster)
+		used += 8
+	}
+
+	// All codewords of a row are of the cluster of the row; the others are
+	// misread
+	var votes [3]int
+	for _, c := range clusters {
+		if c >= 0 {
+			votes[c]++
+		}
+	}
+	cluster := 0
+	for c := range votes {
+		if votes[c] > votes[cluster] {
+			cluster = c
+		}
+	}
+	for i, c := range clusters {
+		if c != cluster {
+			line.codewords[i] = -1
+		}
+	}
+	indicator := -1
+	if len(line.codewords) > 0 {
+		indicator = line.codewords[0]
+	}
+	if indicator < 0 && line.stopped && len(line.codewords) > 1 {
+		indicator = line.codewords[len(line.codewords)-1]
+	}
+	if indicator >= 0 {
+		line.row = indicator/30*3 + cluster
+	}
+	return line, used
+}
+
+// pdf417Codeword reads the codeword of eight runs, 17 modules wide; it
+// returns the value and the cluster index 0-2, or -1 for both
+func pdf417Codeword(runs []bitRun) (int, int) {
+	total := 0
+	for _, r := range runs {
+		total += r.length
+	}
+	// Widths are rounded, then the ones rounded the furthest are moved
+	// until they add up to 17 modules
+	var widths [8]int
+	var residuals [8]float64
+	sum := 0
+	for i, r := range runs {
+		exact := float64(r.length) * 17 / float64(total)
+		widths[i] = max(int(math.Round(exact)), 1)
+		residuals[i] = exact - float64(widths[i])
+		sum += widths[i]
+	}
+	for sum != 17 {
+		best := -1
+		for i := range widths {
+			if sum < 17 && (best < 0 || residuals[i] > residuals[best]) {
+				best = i
+			}
+			if sum > 17 && widths[i] > 1 && (best < 0 || residuals[i] < residuals[best]) {
+				best = i
+			}
+		}
+		if best < 0 {
+			return -1, -1
+		}
+		if sum < 17 {
+			widths[best]++
+			residuals[best]--
+			sum++
+		} else {
+			widths[best]--
+			residuals[best]++
+			sum--
+		}
+	}
+
+	pattern := uint32(0)
+	for i, w := range widths {
+		for k := 0; k < w; k++ {
+			pattern = pattern<<1 | uint32(1-i%2)
+		}
+	}
+	code, ok := pdf417Values[pattern]
+	if !ok {
+		return -1, -1
+	}
+	return code % 929, code / 929
+}
+
+// pdf417Values maps the patterns to cluster index * 929 + value
+var pdf417Values = pdf417ValueTable()
+
+func pdf417ValueTable() map[uint32]int {
+	values := make(map[uint32]int, 3*929)
+	for cluster, patterns := range pdf417Patterns {
+		for value, pattern := range patterns {
+			values[pattern] = cluster*929 + value
+		}
+	}
+	return values
+}
+
+// decodePDF417Lines votes on the codewords of the lines of a symbol,
+// corrects and decodes them
+func decodePDF417Lines(lines []pdf417Line) (string, error) {
+	// The row indicators carry the number of rows, in an upper part of
+	// (rows-1)/3 and a lower part of (rows-1)%3, the number of columns and
+	// the error correction level, each in the indicators of every third row
+	var columns [pdf417MaxColumns + 1]int
+	var upper [30]int
+	var lower [3]int
+	var level [10]int
+	highest := -1
+	for _, line := range lines {
+		if line.row < 0 {
+			continue
+		}
+		highest = max(highest, line.row)
+		left, right := line.codewords[0], -1
+		if line.stopped && len(line.codewords) > 1 {
+			right = line.codewords[len(line.codewords)-1]
+			columns[len(line.codewords)-2]++
+		}
+		vote := func(indicator int, kind int) {
+			if indicator < 0 {
+				return
+			}
+			v := indicator % 30
+			switch kind {
+			case 0:
+				upper[v]++
+			case 1:
+				level[v/3]++
+				lower[v%3]++
+			case 2:
+				columns[v+1]++
+			}
+		}
+		// Left indicators carry the upper part, the level and the columns
+		// on rows 0, 1 and 2 modulo 3, right ones the columns, the upper
+		// part and the level
+		vote(left, line.row%3)
+		vote(right, (line.row%3+2)%3)
+	}
+	cols := mostVoted(columns[:])
+	// Some encoders write (rows-3)/3 for the upper part; the rows seen
+	// count when they are more
+	rows := max(mostVoted(upper[:])*3+mostVoted(lower[:])+1, highest+1)
+	ecLen := 2 << mostVoted(level[:9])
+	if cols < 1 || cols > pdf417MaxColumns || rows < 3 || rows > pdf417MaxRows ||
+		rows*cols > pdf417MaxCodewords || ecLen >= rows*cols {
+		return "", errPDF417Unreadable
+	}
+
+	votes := make([]map[int]int, rows*cols)
+	for _, line := range lines {
+		if line.row < 0 || line.row >= rows {
+			continue
+		}
+		for c := 0; c < cols && c+1 < len(line.codewords); c++ {
+			if v := line.codewords[c+1]; v >= 0 {
+				if votes[line.row*cols+c] == nil {
+					votes[line.row*cols+c] = make(map[int]int)
+				}
+				votes[line.row*cols+c][v]++
+			}
+		}
+	}
+	codewords := make([]int, rows*cols)
+	for i, counts := range votes {
+		best := 0
+		for v, n := range counts {
+			if n > best || (n == best && v < codewords[i]) {
+				codewords[i], best = v, n
+			}
+		}
+	}
+
+	if err := pdf417Correct(codewords, ecLen); err != nil {
+		return "", err
+	}
+	// The symbol length descriptor counts the data codewords with itself,
+	// padding included
+	n := codewords[0]
+	if n == 0 {
+		n = len(codewords) - ecLen
+	}
+	if n > len(codewords)-ecLen {
+		return "", errPDF417Unreadable
+	}
+	return parsePDF417Data(codewords[1:n])
+}
+
+// mostVoted returns the index with the most votes, the lowest on a tie
+func mostVoted(votes []int) int {
+	best := 0
+	for i, n := range votes {
+		if n > votes[best] {
+			best = i
+		}
+	}
+	return best
+}
+
+// Mode codewords
+const (
+	pdf417TextLatch    = 900
+	pdf417ByteLatch    = 901
+	pdf417NumericLatch = 902
+	pdf417ByteShift    = 913
+	pdf417MacroEnd     = 922
+	pdf417MacroField   = 923
+	pdf417Byte6Latch   = 924
+	pdf417ECIUser      = 925
+	pdf417ECIGeneral   = 926
+	pdf417ECICharset   = 927
+	pdf417MacroBlock   = 928
+)
+
+// Text compaction submodes
+const (
+	pdf417Alpha = iota
+	pdf417Lower
+	pdf417Mixed
+	pdf417Punct
+)
+
+// Characters of the mixed and punctuation submodes by value
+const (
+	pdf417MixedChars = "0123456789&\r\t,:#-.$/+%*=^"
+	pdf417PunctChars = ";<>@[\\]_`~!\r\t,:\n-.$/\"|*()?{}'"
+)
+
+// parsePDF417Data decodes the data codewords that follow the symbol length
+// descriptor; the data starts in text compaction, bytes are read as UTF-8
+func parsePDF417Data(codewords []int) (string, error) {
+	var out []byte
+	var err error
+	for i := 0; i < len(codewords); {
+		switch code := codewords[i]; {
+		case code < pdf417TextLatch, code == pdf417TextLatch:
+			i = pdf417Text(codewords, i, &out)
+		case code == pdf417ByteLatch, code == pdf417Byte6Latch:
+			i, err = pdf417Bytes(codewords, i+1, code == pdf417Byte6Latch, &out)
+		case code == pdf417NumericLatch:
+			i, err = pdf417Numeric(codewords, i+1, &out)
+		case code == pdf417ByteShift:
+			if i+1 < len(codewords) && codewords[i+1] < 256 {
+				out = append(out, byte(codewords[i+1]))
+			}
+			i += 2
+		case code == pdf417ECIUser, code == pdf417ECICharset:
+			i += 2
+		case code == pdf417ECIGeneral:
+			i += 3
+		case code == pdf417MacroBlock, code == pdf417MacroField, code == pdf417MacroEnd:
+			i = len(codewords)
+		default:
+			return "", errPDF417Unreadable
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	if len(out) == 0 {
+		return "", errPDF417Unreadable
+	}
+	return barcodeText(out), nil
+}
+
+// pdf417Text decodes text compaction from codeword i up to the next mode
+// codeword and returns its index. Each codeword holds two values of 0-29,
+// characters of the current submode or latches and shifts to another;
+// latching to text starts over in upper case
+func pdf417Text(codewords []int, i int, out *[]byte) int {
+	submode, shifted := pdf417Alpha, -1
+	for ; i < len(codewords); i++ {
+		code := codewords[i]
+		switch {
+		case code == pdf417TextLatch:
+			submode, shifted = pdf417Alpha, -1
+			continue
+		case code == pdf417ByteShift:
+			if i+1 < len(codewords) && codewords[i+1] < 256 {
+				i++
+				*out = append(*out, byte(codewords[i]))
+			}
+			continue
+		case code > pdf417TextLatch:
+			return i
+		}
+		for _, v := range [2]int{code / 30, code % 30} {
+			current := submode
+			if shifted >= 0 {
+				current, shifted = shifted, -1
+			}
+			switch current {
+			case pdf417Alpha, pdf417Lower:
+				switch {
+				case v < 26 && current == pdf417Alpha:
+					*out = append(*out, byte('A'+v))
+				case v < 26:
+					*out = append(*out, byte('a'+v))
+				case v == 26:
+					*out = append(*out, ' ')
+				case v == 27 && current == pdf417Alpha:
+					submode = pdf417Lower
+				case v == 27:
+					shifted = pdf417Alpha
+				case v == 28:
+					submode = pdf417Mixed
+				default:
+					shifted = pdf417Punct
+				}
+			case pdf417Mixed:
+				switch {
+				case v < 25:
+					*out = append(*out, pdf417MixedChars[v])
+				case v == 25:
+					submode = pdf417Punct
+				case v == 26:
+					*out = append(*out, ' ')
+				case v == 27:
+					submode = pdf417Lower
+				case v == 28:
+					submode = pdf417Alpha
+				default:
+					shifted = pdf417Punct
+				}
+			case pdf417Punct:
+				if v < 29 {
+					*out = append(*out, pdf417PunctChars[v])
+				} else {
+					submode = pdf417Alpha
+				}
+			}
+		}
+	}
+	return i
+}
+
+// pdf417Bytes decodes byte compaction from codeword i and returns the
+// index of the next mode codeword. Groups of five codewords are six bytes
+// in base 900; after 901 the codewords of a last group of fewer than six
+// bytes are a byte each
+func pdf417Bytes(codewords []int, i int, grouped bool, out *[]byte) (int, error) {
+	for i < len(codewords) && codewords[i] < pdf417TextLatch {
+		end := i
+		for end < len(codewords) && end-i < 5 && codewords[end] < pdf417TextLatch {
+			end++
+		}
+		if end-i == 5 && (grouped || (end < len(codewords) && codewords[end] < pdf417TextLatch)) {
+			var v uint64
+			for _, c := range codewords[i:end] {
+				v = v*900 + uint64(c)
+			}
+			var group [6]byte
+			for k := 5; k >= 0; k-- {
+				group[k] = byte(v)
+				v >>= 8
+			}
+			*out = append(*out, group[:]...)
+		} else {
+			for _, c := range codewords[i:end] {
+				if c > 255 {
+					return 0, errPDF417Unreadable
+				}
+				*out = append(*out, byte(c))
+			}
+		}
+		i = end
+	}
+	return i, nil
+}
+
+// pdf417Numeric decodes numeric compaction from codeword i and returns the
+// index of the next mode codeword. Groups of up to 15 codewords are a
+// number in base 900 whose decimal digits follow a leading 1
+func pdf417Numeric(codewords []int, i int, out *[]byte) (int, error) {
+	base := big.NewInt(900)
+	for i < len(codewords) && codewords[i] < pdf417TextLatch {
+		v := new(big.Int)
+		end := i
+		for ; end < len(codewords) && end-i < 15 && codewords[end] < pdf417TextLatch; end++ {
+			v.Mul(v, base)
+			v.Add(v, big.NewInt(int64(codewords[end])))
+		}
+		digits := v.String()
+		if digits[0] != '1' {
+			return 0, errPDF417Unreadable
+		}
+		*out = append(*out, digits[1:]...)
+		i = end
+	}
+	return i, nil
+}
+
+// Arithmetic modulo the prime 929, whose powers of 3 give all the nonzero
+// values
+var pdf417Exp, pdf417Log = pdf417Tables()
+
+func pdf417Tables() (exp [929]int, log [929]int) {
+	x := 1
+	for i := 0; i < 928; i++ {
+		exp[i] = x
+		log[x] = i
+		x = x * 3 % 929
+	}
+	exp[928] = 1
+	return exp, log
+}
+
+func pdf417Inverse(a int) int {
+	return pdf417Exp[(928-pdf417Log[a])%928]
+}
+
+// pdf417Eval evaluates a polynomial with the lowest coefficient first
+func pdf417Eval(poly []int, x int) int {
+	v := 0
+	for i := len(poly) - 1; i >= 0; i-- {
+		v = (v*x + poly[i]) % 929
+	}
+	return v
+}
+
+// pdf417Correct corrects codewords in place, the last ecLen of them for
+// error correction whose generator has the roots 3 to 3^ecLen. Like
+// rsCorrect: Berlekamp-Massey finds the error locator, the Chien search its
+// roots and Forney the error values
+func pdf417Correct(codewords []int, ecLen int) error {
+	n := len(codewords)
+	syndromes := make([]int, ecLen)
+	clean := true
+	for j := range syndromes {
+		x, s := pdf417Exp[j+1], 0
+		for _, c := range codewords {
+			s = (s*x + c) % 929
+		}
+		syndromes[j] = s
+		clean = clean && s == 0
+	}
+	if clean {
+		return nil
+	}
+
+	locator, prev := []int{1}, []int{1}
+	errs, shift, lastDelta := 0, 1, 1
+	for k := 0; k < ecLen; k++ {
+		delta := syndromes[k]
+		for i := 1; i <= errs && i < len(locator); i++ {
+			delta = (delta + locator[i]*syndromes[k-i]) % 929
+		}
+		if delta == 0 {
+			shift++
+			continue
+		}
+		saved := append([]int(nil), locator...)
+		if need := len(prev) + shift; len(locator) < need {
+			locator = append(locator, make([]int, need-len(locator))...)
+		}
+		coef := delta * pdf417Inverse(lastDelta) % 929
+		for i, p := range prev {
+			locator[i+shift] = (locator[i+shift] + 929 - coef*p%929) % 929
+		}
+		if 2*errs <= k {
+			errs, prev, lastDelta, shift = k+1-errs, saved, delta, 1
+		} else {
+			shift++
+		}
+	}
+	if 2*errs > ecLen {
+		return errPDF417Unreadable
+	}
+
+	// Omega = S(x) * locator(x) mod x^ecLen
+	omega := make([]int, ecLen)
+	for i := range omega {
+		for j := 0; j <= i && j < len(locator); j++ {
+			omega[i] = (omega[i] + locator[j]*syndromes[i-j]) % 929
+		}
+	}
+	found := 0
+	for p := 0; p < n; p++ {
+		inverse := pdf417Inverse(pdf417Exp[(n-1-p)%928])
+		if pdf417Eval(locator, inverse) != 0 {
+			continue
+		}
+		found++
+		derivative := 0
+		for i := len(locator) - 1; i >= 1; i-- {
+			derivative = (derivative*inverse + i*locator[i]) % 929
+		}
+		if derivative == 0 {
+			return errPDF417Unreadable
+		}
+		e := pdf417Eval(omega, inverse) * pdf417Inverse(derivative) % 929
+		codewords[p] = (codewords[p] + e) % 929
+	}
+	if found != errs {
+		return errPDF417Unreadable
+	}
+	return nil
+}
@@ -0,0 +1,600 @@
+package searcher
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/md5"
+	"crypto/rc4"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Password-protected PDFs use the standard security handler (ISO 32000-1,
+// 7.6.3). This file detects the /Encrypt dictionary and decrypts RC4 and
+// AES-128 documents (revisions 2-4) so they can be scanned without qpdf or
+// Poppler. AES-256 (revision 5/6) is only detected.
+
+// ErrPDFEncrypted is returned when a PDF needs a password that was not supplied
+var ErrPDFEncrypted = errors.New("PDF защищён паролем")
+
+// pdfPadding pads passwords to 32 bytes (Algorithm 2, step a)
+var pdfPadding = []byte{
+	0x28, 0xBF, 0x4E, 0x5E, 0x4E, 0x75, 0x8A, 0x41, 0x64, 0x00, 0x4E, 0x56, 0xFF, 0xFA, 0x01, 0x08,
+	0x2E, 0x2E, 0x00, 0xB6, 0xD0, 0x68, 0x3E, 0x80, 0x2F, 0x0C, 0xA9, 0xFE, 0x64, 0x53, 0x69, 0x7A,
+}
+
+var (
+	pdfEncryptPattern = regexp.MustCompile(`/Encrypt\s*(?:(\d+)\s+(\d+)\s+R|<<)`)
+	pdfIDPattern      = regexp.MustCompile(`/ID\s*\[\s*`)
+	pdfObjectPattern  = regexp.MustCompile(`(\d+)\s+(\d+)\s+obj\s*<<`)
+	pdfRefPattern     = regexp.MustCompile(`^\d+\s+\d+\s+R`)
+)
+
+// pdfEncryption holds the standard security handler parameters
+type pdfEncryption struct {
+	V, R            int
+	P               uint32
+	KeyLength       int // Bytes
+	O, U            []byte
+	ID              []byte // First element of the trailer /ID
+	AES             bool   // AESV2 crypt filter instead of RC4
+	EncryptMetadata bool
+	Filter          string
+	objNum          int // The /Encrypt dictionary's own object, not encrypted
+}
+
+// parsePDFEncryption reports whether the PDF is encrypted and, if the
+// dictionary could be read, returns its parameters
+func parsePDFEncryption(data []byte) (*pdfEncryption, bool) {
+	matches := pdfEncryptPattern.FindAllSubmatchIndex(data, -1)
+	if len(matches) == 0 {
+		return nil, false
+	}
+	// Incremental updates append trailers; the last one wins
+	m := matches[len(matches)-1]
+
+	enc := &pdfEncryption{objNum: -1, EncryptMetadata: true}
+	dictStart := m[1] - 2
+	if m[2] >= 0 {
+		num, _ := strconv.Atoi(string(data[m[2]:m[3]]))
+		gen, _ := strconv.Atoi(string(data[m[4]:m[5]]))
+		obj := regexp.MustCompile(fmt.Sprintf(`(?:^|\D)%d\s+%d\s+obj\s*<<`, num, gen)).FindIndex(data)
+		if obj == nil {
+			return nil, true
+		}
+		enc.objNum = num
+		dictStart = obj[1] - 2
+	}
+
+	dict, _ := parsePDFDict(data, dictStart)
+	if dict == nil || dict["Filter"] == nil {
+		return nil, true
+	}
+	enc.Filter = strings.TrimPrefix(string(dict["Filter"]), "/")
+	enc.V = pdfInt(dict["V"])
+	enc.R = pdfInt(dict["R"])
+	enc.P = uint32(int64(pdfInt(dict["P"])))
+	enc.O = pdfString(dict["O"])
+	enc.U = pdfString(dict["U"])
+	if string(dict["EncryptMetadata"]) == "false" {
+		enc.EncryptMetadata = false
+	}
+
+	switch enc.V {
+	case 1:
+		enc.KeyLength = 5
+	case 2:
+		enc.KeyLength = 5
+		if bits := pdfInt(dict["Length"]); bits >= 40 && bits <= 128 {
+			enc.KeyLength = bits / 8
+		}
+	case 4:
+		enc.KeyLength = 16
+		enc.AES = bytes.Contains(dict["CF"], []byte("/AESV2"))
+	}
+
+	if idx := pdfIDPattern.FindAllIndex(data, -1); len(idx) > 0 {
+		start := idx[len(idx)-1][1]
+		enc.ID = pdfString(data[start:pdfValueEnd(data, start)])
+	}
+	return enc, true
+}
+
+// supported reports whether the built-in decryptor handles this document
+func (e *pdfEncryption) supported() bool {
+	if e == nil || e.Filter != "Standard" || len(e.O) < 32 || len(e.U) < 32 {
+		return false
+	}
+	switch e.V {
+	case 1, 2:
+		return e.R == 2 || e.R == 3
+	case 4:
+		return e.R == 4
+	}
+	return false
+}
+
+// describe names the encryption method for messages
+func (e *pdfEncryption) describe() string {
+	switch {
+	case e == nil:
+		return "неизвестное шифрование"
+	case e.Filter != "Standard":
+		return "обработчик " + e.Filter
+	case e.V == 5:
+		return "AES-256"
+	case e.AES:
+		return "AES-128"
+	default:
+		return fmt.Sprintf("RC4 %d бит", e.KeyLength*8)
+	}
+}
+
+// Unlock tries password as the user and then the owner password and
+// returns the file key
+func (e *pdfEncryption) Unlock(password string) ([]byte, bool) {
+	if key := e.fileKey(padPDFPassword([]byte(password))); e.checkUserKey(key) {
+		return key, true
+	}
+	if key := e.fileKey(e.userPasswordFromOwner([]byte(password))); e.checkUserKey(key) {
+		return key, true
+	}
+	return nil, false
+}
+
+// padPDFPassword truncates or pads a password to 32 bytes
+func padPDFPassword(password []byte) []byte {
+	padded := make([]byte, 32)
+	n := copy(padded, password)
+	copy(padded[n:], pdfPadding)
+	return padded
+}
+
+// fileKey computes the file encryption key from a padded user password (Algorithm 2)
+func (e *pdfEncryption) fileKey(padded []byte) []byte {
+	h := md5.New()
+	h.Write(padded)
+	h.Write(e.O[:32])
+	var p [4]byte
+	binary.LittleEndian.PutUint32(p[:], e.P)
+	h.Write(p[:])
+	h.Write(e.ID)
+	if e.R >= 4 && !e.EncryptMetadata {
+		h.Write([]byte{0xFF, 0xFF, 0xFF, 0xFF})
+	}
+	key := h.Sum(nil)
+
+	n := e.KeyLength
+	if e.R >= 3 {
+		for i := 0; i < 50; i++ {
+			sum := md5.Sum(key[:n])
+			key = sum[:]
+		}
+	}
+	return key[:n]
+}
+
+// checkUserKey compares the key against the /U entry (Algorithms 4 and 5)
+func (e *pdfEncryption) checkUserKey(key []byte) bool {
+	if e.R == 2 {
+		out := make([]byte, 32)
+		rc4XOR(key, out, pdfPadding)
+		return bytes.Equal(out, e.U[:32])
+	}
+
+	h := md5.New()
+	h.Write(pdfPadding)
+	h.Write(e.ID)
+	out := h.Sum(nil)
+	for i := 0; i < 20; i++ {
+		rc4XOR(xorKey(key, byte(i)), out, out)
+	}
+	return bytes.Equal(out, e.U[:16])
+}
+
+// userPasswordFromOwner decrypts /O with the owner password, giving the
+// padded user password (Algorithm 7)
+func (e *pdfEncryption) userPasswordFromOwner(owner []byte) []byte {
+	sum := md5.Sum(padPDFPassword(owner))
+	key := sum[:]
+	if e.R >= 3 {
+		for i := 0; i < 50; i++ {
+			sum = md5.Sum(key)
+			key = sum[:]
+		}
+	}
+	key = key[:e.KeyLength]
+
+	out := append([]byte(nil), e.O[:32]...)
+	if e.R == 2 {
+		rc4XOR(key, out, out)
+		return out
+	}
+	for i := 19; i >= 0; i-- {
+		rc4XOR(xorKey(key, byte(i)), out, out)
+	}
+	return out
+}
+
+// objectKey derives the key for one object (Algorithm 1)
+func (e *pdfEncryption) objectKey(key []byte, num, gen int) []byte {
+	h := md5.New()
+	h.Write(key)
+	h.Write([]byte{byte(num), byte(num >> 8), byte(num >> 16), byte(gen), byte(gen >> 8)})
+	if e.AES {
+		h.Write([]byte("sAlT"))
+	}
+	n := len(key) + 5
+	if n > 16 {
+		n = 16
+	}
+	return h.Sum(nil)[:n]
+}
+
+// decrypt decrypts a string or stream of object num
+func (e *pdfEncryption) decrypt(key []byte, num, gen int, data []byte) ([]byte, error) {
+	objKey := e.objectKey(key, num, gen)
+	if !e.AES {
+		out := make([]byte, len(data))
+		rc4XOR(objKey, out, data)
+		return out, nil
+	}
+
+	if len(data) < 2*aes.BlockSize || len(data)%aes.BlockSize != 0 {
+		return nil, fmt.Errorf("некорректная длина AES-данных: %d", len(data))
+	}
+	block, err := aes.NewCipher(objKey)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, len(data)-aes.BlockSize)
+	cipher.NewCBCDecrypter(block, data[:aes.BlockSize]).CryptBlocks(out, data[aes.BlockSize:])
+
+	pad := int(out[len(out)-1])
+	if pad == 0 || pad > aes.BlockSize {
+		return nil, fmt.Errorf("некорректное дополнение AES")
+	}
+	return out[:len(out)-pad], nil
+}
+
+func rc4XOR(key, dst, src []byte) {
+	c, _ := rc4.NewCipher(key)
+	c.XORKeyStream(dst, src)
+}
+
+func xorKey(key []byte, b byte) []byte {
+	out := make([]byte, len(key))
+	for i := range key {
+		out[i] = key[i] ^ b
+	}
+	return out
+}
+
+// pdfStreamsText decrypts (if enc is set) and inflates the content streams
+// and extracts their text
+func pdfStreamsText(data []byte, enc *pdfEncryption, key []byte) string {
+	texts, order := pdfStreamTexts(data, enc, key)
+	joined := make([]string, len(order))
+	for i, num := range order {
+		joined[i] = texts[num]
+	}
+	return strings.Join(joined, "\n")
+}
+
+// pdfStreamTexts returns the text of the streams that show any by object
+// number, and the numbers in file order
+func pdfStreamTexts(data []byte, enc *pdfEncryption, key []byte) (map[int]string, []int) {
+	texts := make(map[int]string)
+	var order []int
+	for _, m := range pdfObjectPattern.FindAllSubmatchIndex(data, -1) {
+		num, _ := strconv.Atoi(string(data[m[2]:m[3]]))
+		gen, _ := strconv.Atoi(string(data[m[4]:m[5]]))
+
+		dict, end := parsePDFDict(data, m[1]-2)
+		if dict == nil || string(dict["Type"]) == "/XRef" || string(dict["Type"]) == "/ObjStm" || string(dict["Subtype"]) == "/Image" {
+			continue
+		}
+		stream, ok := pdfStreamData(data, end, dict)
+		if !ok {
+			continue
+		}
+
+		if enc != nil && num != enc.objNum {
+			decrypted, err := enc.decrypt(key, num, gen, stream)
+			if err != nil {
+				continue
+			}
+			stream = decrypted
+		}
+
+		switch filter := strings.Trim(string(dict["Filter"]), "[] \r\n"); filter {
+		case "":
+		case "/FlateDecode":
+			inflated, err := io.ReadAll(zlibReader(stream))
+			if err != nil && len(inflated) == 0 {
+				continue
+			}
+			stream = inflated
+		default:
+			continue
+		}
+
+		if text := extractContentText(stream); text != "" {
+			if _, seen := texts[num]; !seen {
+				order = append(order, num)
+			}
+			texts[num] = text
+		}
+	}
+	return texts, order
+}
+
+// zlibReader returns a reader that yields nothing for invalid data
+func zlibReader(data []byte) io.Reader {
+	r, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return bytes.NewReader(nil)
+	}
+	return r
+}
+
+// extractContentText extracts the strings shown by Tj and TJ operators
+func extractContentText(content []byte) string {
+	return (&DocumentExtractor{}).extractPDFText(content)
+}
+
+// pdfStreamData returns the bytes between "stream" and "endstream" after a
+// dictionary ending at i
+func pdfStreamData(data []byte, i int, dict map[string][]byte) ([]byte, bool) {
+	for i < len(data) && (data[i] == ' ' || data[i] == '\r' || data[i] == '\n' || data[i] == '\t') {
+		i++
+	}
+	if !bytes.HasPrefix(data[i:], []byte("stream")) {
+		return nil, false
+	}
+	i += len("stream")
+	if bytes.HasPrefix(data[i:], []byte("\r\n")) {
+		i += 2
+	} else if i < len(data) && (data[i] == '\n' || data[i] == '\r') {
+		i++
+	}
+
+	if length, err := strconv.Atoi(strings.TrimSpace(string(dict["Length"]))); err == nil && length >= 0 && i+length <= len(data) {
+		return data[i : i+length], true
+	}
+	end := bytes.Index(data[i:], []byte("endstream"))
+	if end < 0 {
+		return nil, false
+	}
+	return bytes.TrimRight(data[i:i+end], "\r\n"), true
+}
+
+// parsePDFDict parses the top-level entries of the dictionary at data[i]
+// ("<<") and returns them with the offset just past its closing ">>"
+func parsePDFDict(data []byte, i int) (map[string][]byte, int) {
+	if !bytes.HasPrefix(data[i:], []byte("<<")) {
+		return nil, i
+	}
+	end := pdfValueEnd(data, i)
+	dict := make(map[string][]byte)
+
+	i += 2
+	for {
+		i = pdfSkipSpace(data, i)
+		if i >= end-2 {
+			return dict, end
+		}
+		if data[i] != '/' {
+			i++
+			continue
+		}
+		keyEnd := pdfValueEnd(data, i)
+		key := string(data[i+1 : keyEnd])
+		valueStart := pdfSkipSpace(data, keyEnd)
+		if valueStart >= end-2 {
+			return dict, end
+		}
+		valueEnd := pdfValueEnd(data, valueStart)
+		dict[key] = data[valueStart:valueEnd]
+		i = valueEnd
+	}
+}
+
+// pdfValueEnd returns the offset just past the object starting at data[i]
+func pdfValueEnd(data []byte, i int) int {
+	if i >= len(data) {
+		return len(data)
+	}
+	switch {
+	case bytes.HasPrefix(data[i:], []byte("<<")):
+		depth := 0
+		for i < len(data) {
+			switch {
+			case data[i] == '(':
+				i = pdfValueEnd(data, i)
+			case bytes.HasPrefix(data[i:], []byte("<<")):
+				depth++
+				i += 2
+			case bytes.HasPrefix(data[i:], []byte(">>")):
+				depth--
+				i += 2
+				if depth == 0 {
+					return i
+				}
+			case data[i] == '<':
+				i = pdfValueEnd(data, i)
+			default:
+				i++
+			}
+		}
+		return i
+	case data[i] == '<':
+		if end := bytes.IndexByte(data[i:], '>'); end >= 0 {
+			return i + end + 1
+		}
+		return len(data)
+	case data[i] == '(':
+		depth := 0
+		for ; i < len(data); i++ {
+			switch data[i] {
+			case '\\':
+				i++
+			case '(':
+				depth++
+			case ')':
+				depth--
+				if depth == 0 {
+					return i + 1
+				}
+			}
+		}
+		return len(data)
+	case data[i] == '[':
+		depth := 0
+		for i < len(data) {
+			switch data[i] {
+			case '(', '<':
+				i = pdfValueEnd(data, i)
+				continue
+			case '[':
+				depth++
+			case ']':
+				depth--
+				if depth == 0 {
+					return i + 1
+				}
+			}
+			i++
+		}
+		return i
+	case data[i] == '/':
+		i++
+		for i < len(data) && !pdfDelimiter(data[i]) {
+			i++
+		}
+		return i
+	}
+
+	if ref := pdfRefPattern.FindIndex(data[i:]); ref != nil {
+		return i + ref[1]
+	}
+	for i < len(data) && !pdfDelimiter(data[i]) {
+		i++
+	}
+	return i
+}
+
+// pdfSkipSpace skips whitespace and comments
+func pdfSkipSpace(data []byte, i int) int {
+	for i < len(data) {
+		switch data[i] {
+		case ' ', '\t', '\r', '\n', '\f', 0:
+			i++
+		case '%':
+			for i < len(data) && data[i] != '\n' && data[i] != '\r' {
+				i++
+			}
+		default:
+			return i
+		}
+	}
+	return i
+}
+
+func pdfDelimiter(c byte) bool {
+	return strings.IndexByte(" \t\r\n\f\x00()<>[]{}/%", c) >= 0
+}
+
+// pdfInt parses a direct integer value, returning 0 otherwise
+func pdfInt(value []byte) int {
+	n, _ := strconv.ParseInt(strings.TrimSpace(string(value)), 10, 64)
+	return int(n)
+}
+
+// pdfString decodes a literal "(...)" or hex "<...>" string
+func pdfString(value []byte) []byte {
+	value = bytes.TrimSpace(value)
+	if len(value) < 2 {
+		return nil
+	}
+
+	if value[0] == '<' {
+		var out []byte
+		var hi byte
+		half := false
+		for _, c := range value[1 : len(value)-1] {
+			var v byte
+			switch {
+			case c >= '0' && c <= '9':
+				v = c - '0'
+			case c >= 'a' && c <= 'f':
+				v = c - 'a' + 10
+			case c >= 'A' && c <= 'F':
+				v = c - 'A' + 10
+			default:
+				continue
+			}
+			if half {
+				out = append(out, hi<<4|v)
+			} else {
+				hi = v
+			}
+			half = !half
+		}
+		if half {
+			out = append(out, hi<<4)
+		}
+		return out
+	}
+
+	if value[0] != '(' {
+		return nil
+	}
+	body := value[1 : len(value)-1]
+	out := make([]byte, 0, len(body))
+	for i := 0; i < len(body); i++ {
+		c := body[i]
+		if c != '\\' || i+1 >= len(body) {
+			out = append(out, c)
+			continue
+		}
+		i++
+		switch e := body[i]; e {
+		case 'n':
+			out = append(out, '\n')
+		case 'r':
+			out = append(out, '\r')
+		case 't':
+			out = append(out, '\t')
+		case 'b':
+			out = append(out, '\b')
+		case 'f':
+			out = append(out, '\f')
+		case '\r':
+			if i+1 < len(body) && body[i+1] == '\n' {
+				i++
+			}
+		case '\n':
+		default:
+			if e >= '0' && e <= '7' {
+				v := 0
+				j := i
+				for ; j < len(body) && j < i+3 && body[j] >= '0' && body[j] <= '7'; j++ {
+					v = v*8 + int(body[j]-'0')
+				}
+				out = append(out, byte(v))
+				i = j - 1
+			} else {
+				out = append(out, e)
+			}
+		}
+	}
+	return out
+}
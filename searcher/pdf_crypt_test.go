@@ -0,0 +1,172 @@
+package searcher
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// readPDFFixture reads a fixture from testdata/pdf
+func readPDFFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "pdf", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// TestParsePDFEncryption tests the /Encrypt dictionary of each fixture is read
+func TestParsePDFEncryption(t *testing.T) {
+	tests := []struct {
+		file      string
+		v, r, key int
+		aes       bool
+		supported bool
+	}{
+		{"rc4_40.pdf", 1, 2, 5, false, true},
+		{"rc4_128_open.pdf", 2, 3, 16, false, true},
+		{"aes128.pdf", 4, 4, 16, true, true},
+		{"aes256.pdf", 5, 6, 0, false, false},
+	}
+	for _, tt := range tests {
+		enc, encrypted := parsePDFEncryption(readPDFFixture(t, tt.file))
+		if !encrypted || enc == nil {
+			t.Fatalf("%s: encryption not detected", tt.file)
+		}
+		if enc.V != tt.v || enc.R != tt.r || enc.KeyLength != tt.key || enc.AES != tt.aes || enc.supported() != tt.supported {
+			t.Errorf("%s: unexpected parameters %+v", tt.file, enc)
+		}
+		if enc.objNum != 6 || string(enc.ID) != "data-leak-fixtur" {
+			t.Errorf("%s: object %d, ID %q", tt.file, enc.objNum, enc.ID)
+		}
+	}
+
+	plain := []byte("%PDF-1.4\n1 0 obj\n<< /Length 44 >>\nstream\nBT (the /Encrypt key is optional) Tj ET\nendstream\nendobj\ntrailer\n<< /Root 1 0 R >>\n")
+	if _, encrypted := parsePDFEncryption(plain); encrypted {
+		t.Error("the word /Encrypt in a content stream is not encryption")
+	}
+}
+
+// TestPDFUnlock tests user and owner passwords for RC4 and AES-128
+func TestPDFUnlock(t *testing.T) {
+	tests := []struct {
+		file     string
+		password string
+		ok       bool
+	}{
+		{"rc4_40.pdf", "secret", true},
+		{"rc4_40.pdf", "owner", true},
+		{"rc4_40.pdf", "", false},
+		{"rc4_40.pdf", "Secret", false},
+		{"rc4_128_open.pdf", "", true},
+		{"rc4_128_open.pdf", "owner", true},
+		{"aes128.pdf", "secret", true},
+		{"aes128.pdf", "owner", true},
+		{"aes128.pdf", "", false},
+	}
+	for _, tt := range tests {
+		data := readPDFFixture(t, tt.file)
+		enc, _ := parsePDFEncryption(data)
+		key, ok := enc.Unlock(tt.password)
+		if ok != tt.ok {
+			t.Errorf("%s with %q: unlocked = %v, want %v", tt.file, tt.password, ok, tt.ok)
+			continue
+		}
+		if ok {
+			if text := pdfStreamsText(data, enc, key); text != "password = Kx9mQ2vLp7Zr" {
+				t.Errorf("%s: unexpected text %q", tt.file, text)
+			}
+		}
+	}
+}
+
+// TestExtractProtectedPDF tests the extractor classifies locked PDFs and
+// uses the configured passwords
+func TestExtractProtectedPDF(t *testing.T) {
+	path := filepath.Join("testdata", "pdf", "rc4_40.pdf")
+
+	content, err := NewDocumentExtractor(false).ExtractText(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !content.Encrypted || !errors.Is(content.Error, ErrPDFEncrypted) || content.Text != "" {
+		t.Errorf("locked PDF not classified: %+v", content)
+	}
+
+	extractor := NewDocumentExtractor(false)
+	extractor.SetPDFPasswords([]string{"wrong", "secret"})
+	content, err = extractor.ExtractText(path)
+	if err != nil || content.Error != nil || !strings.Contains(content.Text, "Kx9mQ2vLp7Zr") {
+		t.Errorf("PDF not unlocked with the password list: %+v, %v", content, err)
+	}
+
+	content, _ = NewDocumentExtractor(false).ExtractText(filepath.Join("testdata", "pdf", "rc4_128_open.pdf"))
+	if !content.Encrypted || content.Error != nil || !strings.Contains(content.Text, "Kx9mQ2vLp7Zr") {
+		t.Errorf("PDF with an empty user password should open: %+v", content)
+	}
+
+	content, _ = NewDocumentExtractor(false).ExtractText(filepath.Join("testdata", "pdf", "aes256.pdf"))
+	if !errors.Is(content.Error, ErrPDFEncrypted) || !strings.Contains(content.Error.Error(), "AES-256") {
+		t.Errorf("unexpected AES-256 result: %v", content.Error)
+	}
+}
+
+// TestScanProtectedPDF tests a locked PDF becomes a Medium finding instead
+// of an empty-text skip, and is scanned once the password is given
+func TestScanProtectedPDF(t *testing.T) {
+	dir := t.TempDir()
+	createTestFile(t, dir, "contract.pdf", string(readPDFFixture(t, "aes128.pdf")))
+
+	scan := func(passwords ...string) *ScanResult {
+		extractor := NewDocumentExtractor(false)
+		extractor.SetPDFPasswords(passwords)
+		scanner := NewScanner()
+		scanner.SetDocumentExtractor(extractor)
+		scanner.SetScanDocuments(true)
+		result, err := scanner.Scan(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	locked := scan()
+	if len(locked.Findings) != 1 || locked.Findings[0].PatternType != PatternProtectedPDF || locked.Findings[0].Severity != Medium {
+		t.Fatalf("expected one protected PDF finding, got %d", len(locked.Findings))
+	}
+	if reason := locked.SkipReasons[filepath.Join(dir, "contract.pdf")]; reason.Key != "skip.pdf_protected" {
+		t.Errorf("unexpected skip reason %q", reason)
+	}
+
+	unlocked := scan("secret")
+	found := false
+	for _, f := range unlocked.Findings {
+		found = found || f.PatternType == PatternPassword
+		if f.PatternType == PatternProtectedPDF {
+			t.Error("unlocked PDF still reported as protected")
+		}
+	}
+	if !found || unlocked.FilesScanned != 1 {
+		t.Errorf("password in the decrypted PDF not found (%d findings)", len(unlocked.Findings))
+	}
+}
+
+// TestPDFString tests literal string escapes and hex strings
+func TestPDFString(t *testing.T) {
+	tests := map[string]string{
+		`(a\(b\)c)`:               "a(b)c",
+		`(\101\102\n)`:            "AB\n",
+		`(nested (x))`:            "nested (x)",
+		`<414243>`:                "ABC",
+		`<41 42 4>`:               "AB@",
+		`(line\` + "\n" + `join)`: "linejoin",
+	}
+	for raw, want := range tests {
+		if got := string(pdfString([]byte(raw))); got != want {
+			t.Errorf("pdfString(%q) = %q, want %q", raw, got, want)
+		}
+	}
+}
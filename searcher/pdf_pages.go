@@ -0,0 +1,167 @@
+package searcher
+
+import (
+	"context"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PDF text is kept per page: pdftotext ends every page with a form feed,
+// pdftoppm numbers the images it renders, and the built-in extractor reads
+// the content streams each page object refers to. The pages are joined
+// into ExtractedContent.Text, one after another, and PageLines records the
+// line each one starts on, so findings get a page and a line within it.
+// Only the first maxPDFPages pages are read; the rest of a huge PDF would
+// keep OCR busy for an hour
+
+// DefaultMaxPDFPages is how many pages of a PDF are extracted or OCRed
+const DefaultMaxPDFPages = 100
+
+// SetMaxPDFPages limits the pages read from a PDF; zero or less reads all
+func (de *DocumentExtractor) SetMaxPDFPages(n int) {
+	de.maxPDFPages = n
+}
+
+// MaxPDFPages returns the page limit, see SetMaxPDFPages
+func (de *DocumentExtractor) MaxPDFPages() int {
+	return de.maxPDFPages
+}
+
+// pdfPageArgs are the pdftotext and pdftoppm flags that stop at the limit
+func (de *DocumentExtractor) pdfPageArgs() []string {
+	if de.maxPDFPages <= 0 {
+		return nil
+	}
+	return []string{"-l", strconv.Itoa(de.maxPDFPages)}
+}
+
+// setPages joins the text of the pages, up to limit of them, and records
+// where each one starts. total is the page count of the document, 0 when
+// unknown
+func (c *ExtractedContent) setPages(pages []string, total, limit int) {
+	if total < len(pages) {
+		total = len(pages)
+	}
+	c.PageCount = total
+	if limit > 0 && total > limit {
+		c.Truncated = true
+		if len(pages) > limit {
+			pages = pages[:limit]
+		}
+	}
+
+	var b strings.Builder
+	lines := make([]int, 0, len(pages))
+	line := 1
+	for i, page := range pages {
+		page = strings.TrimRight(page, "\r\n")
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		lines = append(lines, line)
+		b.WriteString(page)
+		line += strings.Count(page, "\n") + 1
+	}
+
+	c.Text, c.PageLines = b.String(), lines
+	if strings.TrimSpace(c.Text) == "" {
+		c.Text, c.PageLines = "", nil
+	}
+}
+
+// Page returns the page a line of Text is on and the line within that
+// page, both 1-based; 0, 0 when the pages are unknown
+func (c *ExtractedContent) Page(line int) (page, pageLine int) {
+	if len(c.PageLines) == 0 || line < 1 {
+		return 0, 0
+	}
+	i := sort.SearchInts(c.PageLines, line+1) - 1
+	if i < 0 {
+		return 0, 0
+	}
+	return i + 1, line - c.PageLines[i] + 1
+}
+
+// setFindingPages gives findings in the text their page
+func setFindingPages(findings []*Finding, content *ExtractedContent) {
+	for _, f := range findings {
+		f.PageNumber, f.PageLine = content.Page(f.LineNumber)
+	}
+}
+
+// splitFormFeeds splits pdftotext output into pages
+func splitFormFeeds(output string) []string {
+	pages := strings.Split(output, "\f")
+	// The last page ends with a form feed too
+	if len(pages) > 1 && strings.TrimSpace(pages[len(pages)-1]) == "" {
+		pages = pages[:len(pages)-1]
+	}
+	return pages
+}
+
+var (
+	pdfPageObjectPattern = regexp.MustCompile(`/Type\s*/Page\b`)
+	pdfRefsPattern       = regexp.MustCompile(`(\d+)\s+\d+\s+R`)
+	pdfInfoPagesPattern  = regexp.MustCompile(`(?m)^Pages:\s+(\d+)`)
+)
+
+// pdfPageCount returns the number of pages, from pdfinfo when it is
+// installed and by counting page objects otherwise; 0 when unknown
+func pdfPageCount(ctx context.Context, filePath string, data []byte) int {
+	if pdfinfo, ok := Dependencies().ToolPath("pdfinfo"); ok {
+		output, err := exec.CommandContext(ctx, pdfinfo, filePath).Output()
+		if m := pdfInfoPagesPattern.FindSubmatch(output); err == nil && m != nil {
+			n, _ := strconv.Atoi(string(m[1]))
+			return n
+		}
+	}
+	return len(pdfPageObjectPattern.FindAllIndex(data, -1))
+}
+
+// pdfPageTexts returns the text of each page, read from the content
+// streams the page objects refer to. Pages come in the order of their
+// objects in the file, the page order of nearly every writer. Text in
+// streams no page refers to, such as form XObjects, goes to the last page;
+// a PDF whose page objects are compressed yields its text streams as pages
+func pdfPageTexts(data []byte, enc *pdfEncryption, key []byte) []string {
+	texts, order := pdfStreamTexts(data, enc, key)
+	used := make(map[int]bool)
+	var pages []string
+	for _, m := range pdfObjectPattern.FindAllSubmatchIndex(data, -1) {
+		dict, _ := parsePDFDict(data, m[1]-2)
+		if dict == nil || string(dict["Type"]) != "/Page" {
+			continue
+		}
+		var page []string
+		for _, ref := range pdfRefsPattern.FindAllSubmatch(dict["Contents"], -1) {
+			num, _ := strconv.Atoi(string(ref[1]))
+			if text, ok := texts[num]; ok {
+				page = append(page, text)
+				used[num] = true
+			}
+		}
+		pages = append(pages, strings.Join(page, "\n"))
+	}
+
+	if len(pages) == 0 {
+		for _, num := range order {
+			pages = append(pages, texts[num])
+		}
+		return pages
+	}
+	for _, num := range order {
+		if !used[num] {
+			pages[len(pages)-1] += "\n" + texts[num]
+		}
+	}
+	return pages
+}
+
+// PageLabel describes where a PDF finding is, "стр. 3, строка 12"; empty
+// for findings without a page
+func (f *Finding) PageLabel() string {
+	return f.LocalizedPageLabel(russian)
+}
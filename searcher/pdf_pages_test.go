@@ -0,0 +1,156 @@
+package searcher
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeMultiPagePDF writes an uncompressed PDF with one line of text per
+// page; the content streams come after all page objects, as many writers
+// order them
+func writeMultiPagePDF(t *testing.T, dir, name string, pages []string) string {
+	t.Helper()
+	var objects []string
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 3+i)
+	}
+	objects = append(objects,
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	for i := range pages {
+		objects = append(objects, fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents %d 0 R >>", 3+len(pages)+i))
+	}
+	for _, text := range pages {
+		stream := fmt.Sprintf("BT /F1 12 Tf 72 720 Td (%s) Tj ET", text)
+		objects = append(objects, fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(stream), stream))
+	}
+
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = b.Len()
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, b.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// fourPages has secrets on pages 1 and 3
+var fourPages = []string{
+	"Invoice 42 password = Kx9mQ2vLp7Zr",
+	"Terms and conditions",
+	"Appendix db_password = Tq4wZp8Lr2Nx",
+	"Signatures",
+}
+
+func TestExtractPDFPages(t *testing.T) {
+	path := writeMultiPagePDF(t, t.TempDir(), "invoice.pdf", fourPages)
+
+	content, err := NewDocumentExtractor(false).ExtractText(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content.PageCount != 4 || len(content.PageLines) != 4 || content.Truncated {
+		t.Fatalf("pages = %d, lines %v, truncated %v", content.PageCount, content.PageLines, content.Truncated)
+	}
+	for i, want := range fourPages {
+		line := strings.Split(content.Text, "\n")[content.PageLines[i]-1]
+		if !strings.Contains(line, want) {
+			t.Errorf("page %d starts with %q, want %q", i+1, line, want)
+		}
+	}
+
+	limited := NewDocumentExtractor(false)
+	limited.SetMaxPDFPages(2)
+	content, err = limited.ExtractText(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !content.Truncated || content.PageCount != 4 || len(content.PageLines) != 2 || strings.Contains(content.Text, "Appendix") {
+		t.Errorf("limited: pages %d, lines %v, truncated %v, text %q", content.PageCount, content.PageLines, content.Truncated, content.Text)
+	}
+}
+
+func TestContentPage(t *testing.T) {
+	content := &ExtractedContent{}
+	content.setPages([]string{"a\nb\n", "", "c\nd\ne"}, 0, 0)
+	if content.Text != "a\nb\n\nc\nd\ne" {
+		t.Fatalf("text = %q", content.Text)
+	}
+	tests := []struct{ line, page, pageLine int }{
+		{1, 1, 1}, {2, 1, 2}, {3, 2, 1}, {4, 3, 1}, {6, 3, 3}, {0, 0, 0},
+	}
+	for _, tt := range tests {
+		if page, pageLine := content.Page(tt.line); page != tt.page || pageLine != tt.pageLine {
+			t.Errorf("Page(%d) = %d, %d; want %d, %d", tt.line, page, pageLine, tt.page, tt.pageLine)
+		}
+	}
+
+	if pages := splitFormFeeds("one\n\ftwo\n\f"); len(pages) != 2 || pages[1] != "two\n" {
+		t.Errorf("splitFormFeeds = %q", pages)
+	}
+}
+
+func TestScanPDFPageNumbers(t *testing.T) {
+	dir := t.TempDir()
+	path := writeMultiPagePDF(t, dir, "invoice.pdf", fourPages)
+
+	scan := func(maxPages int) *ScanResult {
+		extractor := NewDocumentExtractor(false)
+		extractor.SetMaxPDFPages(maxPages)
+		scanner := NewScanner()
+		scanner.SetDocumentExtractor(extractor)
+		scanner.SetScanDocuments(true)
+		result, err := scanner.Scan(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	pages := map[int]bool{}
+	result := scan(DefaultMaxPDFPages)
+	for _, f := range result.Findings {
+		if f.PageNumber == 0 || f.PageLine != 1 {
+			t.Errorf("%s finding on page %d, line %d", f.PatternType, f.PageNumber, f.PageLine)
+		}
+		pages[f.PageNumber] = true
+	}
+	if !pages[1] || !pages[3] || len(pages) != 2 {
+		t.Errorf("findings on pages %v, want 1 and 3", pages)
+	}
+
+	var report bytes.Buffer
+	if err := NewReportGenerator(result).writePlainText(&report); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(report.String(), "Страница:    стр. 3, строка 1") {
+		t.Errorf("text report lacks the page:\n%s", report.String())
+	}
+
+	truncated := scan(2)
+	for _, f := range truncated.Findings {
+		if f.PageNumber > 2 {
+			t.Errorf("finding on page %d past the limit", f.PageNumber)
+		}
+	}
+	if reason := truncated.SkipReasons[path]; reason.String() != "проверены первые 2 из 4 страниц PDF" {
+		t.Errorf("skip reason = %q", reason)
+	}
+}
@@ -0,0 +1,184 @@
+package searcher
+
+import (
+	"strings"
+	"unsafe"
+)
+
+// PruneOptions selects what ScanResult.Prune drops
+type PruneOptions struct {
+	DropContext bool     // Clear the Context line and surrounding lines of every finding
+	MaskMatches bool     // Replace MatchedText (and its span in Context) with the masked form
+	MinSeverity Severity // Remove findings below this severity; empty keeps all
+}
+
+// PruneStats reports what Prune removed
+type PruneStats struct {
+	ContextsDropped int
+	MatchesMasked   int
+	FindingsRemoved int
+	FreedBytes      int64 // Rough estimate of string and struct memory released
+}
+
+// findingSize is the in-memory size of a Finding without its string data
+var findingSize = int64(unsafe.Sizeof(Finding{}))
+
+// MaskSecret masks text for display, keeping the first and last 4 characters
+// so findings stay distinguishable: "ghp_************wxyz"
+func MaskSecret(text string) string {
+	runes := []rune(text)
+	if len(runes) <= 8 {
+		return strings.Repeat("*", len(runes))
+	}
+	return string(runes[:4]) + strings.Repeat("*", len(runes)-8) + string(runes[len(runes)-4:])
+}
+
+// maskMatch replaces MatchedText with its masked form and redacts it in
+// Context. SecretID and MatchSHA256 are taken first, so the finding can
+// still be grouped and compared with other scans. It reports whether the
+// finding changed
+func maskMatch(f *Finding) bool {
+	if f.MatchedText == "" {
+		return false
+	}
+	masked := MaskSecret(f.MatchedText)
+	if masked == f.MatchedText {
+		return false
+	}
+	if f.SecretID == "" {
+		f.SecretID = f.SecretFingerprint()
+	}
+	if f.MatchSHA256 == "" {
+		f.MatchSHA256 = matchSHA256(f.MatchedText)
+	}
+	switch {
+	case strings.Contains(f.Context, f.MatchedText):
+		f.Context = strings.ReplaceAll(f.Context, f.MatchedText, masked)
+	case f.ByteStart >= 0 && f.ByteStart < f.ByteEnd && f.ByteEnd <= len(f.Context):
+		// A normalized match differs from the line, mask its span instead
+		f.Context = f.Context[:f.ByteStart] + MaskSecret(f.Context[f.ByteStart:f.ByteEnd]) + f.Context[f.ByteEnd:]
+	}
+	f.ContextBefore = maskLines(f.ContextBefore, f.MatchedText, masked)
+	f.ContextAfter = maskLines(f.ContextAfter, f.MatchedText, masked)
+	f.MatchedText = masked
+	return true
+}
+
+// Masked returns a copy of f with the secret masked as in the reports,
+// for output that must never carry it; f is left as it is
+func (f *Finding) Masked() *Finding {
+	masked := *f
+	maskMatch(&masked)
+	return &masked
+}
+
+// maskLines replaces matched with masked in surrounding lines. The lines
+// may be shared with a copy of the finding, so changes go to a new slice
+func maskLines(lines []string, matched, masked string) []string {
+	var out []string
+	for i, line := range lines {
+		if !strings.Contains(line, matched) {
+			continue
+		}
+		if out == nil {
+			out = append([]string(nil), lines...)
+		}
+		out[i] = strings.ReplaceAll(line, matched, masked)
+	}
+	if out == nil {
+		return lines
+	}
+	return out
+}
+
+// Prune drops detail from the result to release memory. SeveritySummary and
+// the scan counters keep describing the full scan; removed findings are
+// counted in PrunedFindings. A spilled result is pruned on disk as well
+func (sr *ScanResult) Prune(opts PruneOptions) PruneStats {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+
+	var stats PruneStats
+	minScore := 0
+	if opts.MinSeverity != "" {
+		minScore = opts.MinSeverity.Score()
+	}
+
+	if sr.spill != nil {
+		if err := sr.rewriteSpill(func(f *Finding) bool { return pruneFinding(f, opts, minScore, &stats) }); err == nil {
+			sr.PrunedFindings += stats.FindingsRemoved
+		}
+		return stats
+	}
+
+	kept := sr.Findings[:0]
+	for _, f := range sr.Findings {
+		if f != nil && pruneFinding(f, opts, minScore, &stats) {
+			kept = append(kept, f)
+		}
+	}
+
+	// Release pointers held by the tail of the backing array
+	for i := len(kept); i < len(sr.Findings); i++ {
+		sr.Findings[i] = nil
+	}
+	sr.Findings = kept
+	sr.PrunedFindings += stats.FindingsRemoved
+
+	return stats
+}
+
+// Compact reallocates the findings slice to its length, releasing capacity
+// left behind by removals. It returns the number of slots freed.
+func (sr *ScanResult) Compact() int {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+
+	freed := cap(sr.Findings) - len(sr.Findings)
+	if freed == 0 {
+		return 0
+	}
+	compacted := make([]*Finding, len(sr.Findings))
+	copy(compacted, sr.Findings)
+	sr.Findings = compacted
+	return freed
+}
+
+// pruneFinding applies opts to f and reports whether it is kept
+func pruneFinding(f *Finding, opts PruneOptions, minScore int, stats *PruneStats) bool {
+	if f.Severity.Score() < minScore {
+		stats.FindingsRemoved++
+		stats.FreedBytes += findingSize + findingStringBytes(f)
+		return false
+	}
+
+	if opts.MaskMatches {
+		// Masking keeps the length, so only the original copy is freed
+		raw := len(f.MatchedText)
+		if maskMatch(f) {
+			stats.FreedBytes += int64(raw)
+			stats.MatchesMasked++
+		}
+	}
+	if opts.DropContext && (f.Context != "" || f.ContextBefore != nil || f.ContextAfter != nil) {
+		stats.FreedBytes += int64(len(f.Context)) + linesBytes(f.ContextBefore) + linesBytes(f.ContextAfter)
+		f.Context = ""
+		f.ContextBefore, f.ContextAfter = nil, nil
+		stats.ContextsDropped++
+	}
+	return true
+}
+
+func findingStringBytes(f *Finding) int64 {
+	return int64(len(f.FilePath)+len(f.Description)+len(f.MatchedText)+len(f.Context)+len(f.RuleID)+len(f.Source)) +
+		linesBytes(f.ContextBefore) + linesBytes(f.ContextAfter)
+}
+
+// linesBytes is the string data of surrounding lines
+func linesBytes(lines []string) int64 {
+	var n int64
+	for _, line := range lines {
+		n += int64(len(line))
+	}
+	return n
+}
@@ -0,0 +1,740 @@
+package searcher
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+// QR codes are found by their three finder patterns, sampled on the grid
+// the patterns span, unmasked and corrected with Reed-Solomon. Versions 1
+// to 10 are read, up to 271 bytes, which covers tokens, links and contact
+// cards; larger codes are left to the OCR heuristics
+
+// maxQRVersion is the largest version read
+const maxQRVersion = 10
+
+var errQRUnreadable = errors.New("QR-код не читается")
+
+// qrFinder is a finder pattern candidate
+type qrFinder struct {
+	x, y   float64
+	module float64
+	count  int // Rows the pattern was seen on
+}
+
+// decodeQRCodes reads the QR codes of a binarized image
+func decodeQRCodes(m *bitMatrix) []string {
+	finders := findQRFinders(m)
+	if len(finders) < 3 {
+		return nil
+	}
+	if len(finders) > 12 {
+		finders = finders[:12]
+	}
+
+	var found []string
+	used := make([]bool, len(finders))
+	for i := 0; i < len(finders); i++ {
+		for j := i + 1; j < len(finders); j++ {
+			for k := j + 1; k < len(finders); k++ {
+				if used[i] || used[j] || used[k] {
+					continue
+				}
+				tl, tr, bl, ok := orderQRFinders(finders[i], finders[j], finders[k])
+				if !ok {
+					continue
+				}
+				if text, ok := decodeQRAt(m, tl, tr, bl); ok {
+					found = append(found, text)
+					used[i], used[j], used[k] = true, true, true
+				}
+			}
+		}
+	}
+	return found
+}
+
+// findQRFinders looks for the 1:1:3:1:1 dark and light runs of finder
+// patterns along every row and checks them along the column; the most often
+// seen candidates come first
+func findQRFinders(m *bitMatrix) []*qrFinder {
+	var finders []*qrFinder
+	for y := 0; y < m.height; y++ {
+		runs := m.rowRuns(y)
+		for i := 0; i+4 < len(runs); i++ {
+			if !runs[i].dark {
+				continue
+			}
+			var counts [5]int
+			for k := range counts {
+				counts[k] = runs[i+k].length
+			}
+			if !qrFinderRatio(counts) {
+				continue
+			}
+			cx := int(float64(runs[i+2].start) + float64(runs[i+2].length)/2)
+			cy, vertical, ok := qrCrossCheck(m, cx, y, 0, 1)
+			if !ok || !similarSize(vertical, sum5(counts)) {
+				continue
+			}
+			fx, horizontal, ok := qrCrossCheck(m, cx, int(cy), 1, 0)
+			if !ok || !similarSize(horizontal, vertical) {
+				continue
+			}
+			addQRFinder(&finders, &qrFinder{x: fx, y: cy, module: float64(horizontal+vertical) / 14, count: 1})
+		}
+	}
+
+	kept := finders[:0]
+	for _, f := range finders {
+		if f.count >= 2 {
+			kept = append(kept, f)
+		}
+	}
+	sort.SliceStable(kept, func(i, j int) bool { return kept[i].count > kept[j].count })
+	return kept
+}
+
+// addQRFinder merges a candidate into one seen on an earlier row
+func addQRFinder(finders *[]*qrFinder, f *qrFinder) {
+	for _, other := range *finders {
+		if math.Abs(other.x-f.x) <= other.module && math.Abs(other.y-f.y) <= other.module &&
+			math.Abs(other.module-f.module) <= math.Max(1, other.module/2) {
+			n := float64(other.count)
+			other.x = (other.x*n + f.x) / (n + 1)
+			other.y = (other.y*n + f.y) / (n + 1)
+			other.module = (other.module*n + f.module) / (n + 1)
+			other.count++
+			return
+		}
+	}
+	*finders = append(*finders, f)
+}
+
+func sum5(counts [5]int) int {
+	return counts[0] + counts[1] + counts[2] + counts[3] + counts[4]
+}
+
+// qrFinderRatio tells if runs are close to 1:1:3:1:1
+func qrFinderRatio(counts [5]int) bool {
+	total := sum5(counts)
+	if total < 7 {
+		return false
+	}
+	module := float64(total) / 7
+	tolerance := module / 2
+	return math.Abs(float64(counts[0])-module) < tolerance &&
+		math.Abs(float64(counts[1])-module) < tolerance &&
+		math.Abs(float64(counts[2])-3*module) < 3*tolerance &&
+		math.Abs(float64(counts[3])-module) < tolerance &&
+		math.Abs(float64(counts[4])-module) < tolerance
+}
+
+// similarSize tells if two widths of a pattern differ by less than half
+func similarSize(a, b int) bool {
+	return 2*absInt(a-b) < b
+}
+
+func absInt(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// qrCrossCheck counts the runs of a finder pattern along the line through
+// (x, y) in direction (dx, dy); it returns the center along that line and
+// the width of the pattern
+func qrCrossCheck(m *bitMatrix, x, y, dx, dy int) (float64, int, bool) {
+	if !m.get(x, y) {
+		return 0, 0, false
+	}
+	var counts [5]int
+	run := func(px, py, step, dark int) (int, int, int) {
+		n := 0
+		for m.contains(px, py) && m.get(px, py) == (dark == 1) {
+			n++
+			px += step * dx
+			py += step * dy
+		}
+		return n, px, py
+	}
+
+	// The center run backwards from (x, y), then its outer runs
+	back, px, py := run(x, y, -1, 1)
+	counts[1], px, py = run(px, py, -1, 0)
+	counts[0], _, _ = run(px, py, -1, 1)
+	forward, px, py := run(x+dx, y+dy, 1, 1)
+	counts[3], px, py = run(px, py, 1, 0)
+	counts[4], _, _ = run(px, py, 1, 1)
+	counts[2] = back + forward
+	if !qrFinderRatio(counts) {
+		return 0, 0, false
+	}
+	pos := x*dx + y*dy
+	return float64(pos-back+1) + float64(counts[2])/2, sum5(counts), true
+}
+
+// orderQRFinders returns the top-left, top-right and bottom-left patterns
+// when three candidates can be the corners of one code
+func orderQRFinders(a, b, c *qrFinder) (tl, tr, bl *qrFinder, ok bool) {
+	module := (a.module + b.module + c.module) / 3
+	for _, f := range []*qrFinder{a, b, c} {
+		if math.Abs(f.module-module) > module*0.4 {
+			return nil, nil, nil, false
+		}
+	}
+
+	// The top-left pattern is across the longest side
+	ab, ac, bc := qrDistance(a, b), qrDistance(a, c), qrDistance(b, c)
+	switch {
+	case bc >= ab && bc >= ac:
+		tl, tr, bl = a, b, c
+	case ac >= ab && ac >= bc:
+		tl, tr, bl = b, a, c
+	default:
+		tl, tr, bl = c, a, b
+	}
+	leg1, leg2, hyp := qrDistance(tl, tr), qrDistance(tl, bl), qrDistance(tr, bl)
+	if leg1 < 7*module || leg1/leg2 < 0.75 || leg1/leg2 > 1.33 ||
+		math.Abs(hyp*hyp-leg1*leg1-leg2*leg2) > 0.2*hyp*hyp {
+		return nil, nil, nil, false
+	}
+
+	// In image coordinates the top-right pattern is clockwise of the
+	// bottom-left one
+	if (tr.x-tl.x)*(bl.y-tl.y)-(tr.y-tl.y)*(bl.x-tl.x) < 0 {
+		tr, bl = bl, tr
+	}
+	return tl, tr, bl, true
+}
+
+func qrDistance(a, b *qrFinder) float64 {
+	return math.Hypot(a.x-b.x, a.y-b.y)
+}
+
+// decodeQRAt samples the code the finder patterns span, trying the versions
+// next to the one their distance suggests
+func decodeQRAt(m *bitMatrix, tl, tr, bl *qrFinder) (string, bool) {
+	module := (tl.module + tr.module + bl.module) / 3
+	side := (qrDistance(tl, tr)+qrDistance(tl, bl))/2/module + 7
+	estimate := int(math.Round((side - 17) / 4))
+	for _, version := range []int{estimate, estimate - 1, estimate + 1} {
+		if version < 1 || version > maxQRVersion {
+			continue
+		}
+		dim := 17 + 4*version
+		grid := make([][]bool, dim)
+		for row := range grid {
+			grid[row] = make([]bool, dim)
+			for col := range grid[row] {
+				// Finder centers are at module 3.5 from the corners
+				u := (float64(col) + 0.5 - 3.5) / float64(dim-7)
+				v := (float64(row) + 0.5 - 3.5) / float64(dim-7)
+				x := tl.x + u*(tr.x-tl.x) + v*(bl.x-tl.x)
+				y := tl.y + u*(tr.y-tl.y) + v*(bl.y-tl.y)
+				grid[row][col] = m.get(int(math.Floor(x)), int(math.Floor(y)))
+			}
+		}
+		if text, err := decodeQRGrid(grid, version); err == nil {
+			return text, true
+		}
+	}
+	return "", false
+}
+
+// qrBlocks is the error correction of a version and level: codewords per
+// block, then the count and data codewords of the blocks of both groups
+type qrBlocks struct {
+	ecPerBlock     int
+	blocks1, data1 int
+	blocks2, data2 int
+}
+
+// Error correction by version, levels L, M, Q and H
+var qrECBlocks = [maxQRVersion + 1][4]qrBlocks{
+	1:  {{7, 1, 19, 0, 0}, {10, 1, 16, 0, 0}, {13, 1, 13, 0, 0}, {17, 1, 9, 0, 0}},
+	2:  {{10, 1, 34, 0, 0}, {16, 1, 28, 0, 0}, {22, 1, 22, 0, 0}, {28, 1, 16, 0, 0}},
+	3:  {{15, 1, 55, 0, 0}, {26, 1, 44, 0, 0}, {18, 2, 17, 0, 0}, {22, 2, 13, 0, 0}},
+	4:  {{20, 1, 80, 0, 0}, {18, 2, 32, 0, 0}, {26, 2, 24, 0, 0}, {16, 4, 9, 0, 0}},
+	5:  {{26, 1, 108, 0, 0}, {24, 2, 43, 0, 0}, {18, 2, 15, 2, 16}, {22, 2, 11, 2, 12}},
+	6:  {{18, 2, 68, 0, 0}, {16, 4, 27, 0, 0}, {24, 4, 19, 0, 0}, {28, 4, 15, 0, 0}},
+	7:  {{20, 2, 78, 0, 0}, {18, 4, 31, 0, 0}, {18, 2, 14, 4, 15}, {26, 4, 13, 1, 14}},
+	8:  {{24, 2, 97, 0, 0}, {22, 2, 38, 2, 39}, {22, 4, 18, 2, 19}, {26, 4, 14, 2, 15}},
+	9:  {{30, 2, 116, 0, 0}, {22, 3, 36, 2, 37}, {20, 4, 16, 4, 17}, {24, 4, 12, 4, 13}},
+	10: {{18, 2, 68, 2, 69}, {26, 4, 43, 1, 44}, {24, 6, 19, 2, 20}, {28, 6, 15, 2, 16}},
+}
+
+// Centers of alignment patterns by version
+var qrAlignment = [maxQRVersion + 1][]int{
+	2: {6, 18}, 3: {6, 22}, 4: {6, 26}, 5: {6, 30}, 6: {6, 34},
+	7: {6, 22, 38}, 8: {6, 24, 42}, 9: {6, 26, 46}, 10: {6, 28, 50},
+}
+
+// qrLevels maps the level bits of the format to the index in qrECBlocks
+var qrLevels = [4]int{1, 0, 3, 2}
+
+// qrFormatBits is the BCH encoded and masked format of a level and mask
+func qrFormatBits(format int) int {
+	bits := format << 10
+	for i := 14; i >= 10; i-- {
+		if bits&(1<<i) != 0 {
+			bits ^= 0x537 << (i - 10)
+		}
+	}
+	return (format<<10 | bits) ^ 0x5412
+}
+
+// readQRFormat reads both copies of the format and returns the level index
+// and mask of the closest valid one
+func readQRFormat(grid [][]bool) (level, mask int, err error) {
+	dim := len(grid)
+	bit := func(v int, dark bool) int {
+		v <<= 1
+		if dark {
+			v |= 1
+		}
+		return v
+	}
+	first, second := 0, 0
+	for col := 0; col <= 5; col++ {
+		first = bit(first, grid[8][col])
+	}
+	first = bit(first, grid[8][7])
+	first = bit(first, grid[8][8])
+	first = bit(first, grid[7][8])
+	for row := 5; row >= 0; row-- {
+		first = bit(first, grid[row][8])
+	}
+	for row := dim - 1; row >= dim-7; row-- {
+		second = bit(second, grid[row][8])
+	}
+	for col := dim - 8; col < dim; col++ {
+		second = bit(second, grid[8][col])
+	}
+
+	best, bestDistance := -1, 4
+	for format := 0; format < 32; format++ {
+		bits := qrFormatBits(format)
+		for _, read := range []int{first, second} {
+			if d := bitCount(bits ^ read); d < bestDistance {
+				best, bestDistance = format, d
+			}
+		}
+	}
+	if best < 0 {
+		return 0, 0, errQRUnreadable
+	}
+	return qrLevels[best>>3], best & 7, nil
+}
+
+func bitCount(v int) int {
+	n := 0
+	for ; v != 0; v &= v - 1 {
+		n++
+	}
+	return n
+}
+
+// qrMasked tells if a data module is inverted by a mask
+func qrMasked(mask, row, col int) bool {
+	switch mask {
+	case 0:
+		return (row+col)%2 == 0
+	case 1:
+		return row%2 == 0
+	case 2:
+		return col%3 == 0
+	case 3:
+		return (row+col)%3 == 0
+	case 4:
+		return (row/2+col/3)%2 == 0
+	case 5:
+		return row*col%2+row*col%3 == 0
+	case 6:
+		return (row*col%2+row*col%3)%2 == 0
+	default:
+		return ((row+col)%2+row*col%3)%2 == 0
+	}
+}
+
+// qrFunctionModules marks the modules of a version that carry no data
+func qrFunctionModules(version int) [][]bool {
+	dim := 17 + 4*version
+	fn := make([][]bool, dim)
+	for row := range fn {
+		fn[row] = make([]bool, dim)
+	}
+	fill := func(row, col, rows, cols int) {
+		for r := row; r < row+rows; r++ {
+			for c := col; c < col+cols; c++ {
+				fn[r][c] = true
+			}
+		}
+	}
+	// Finders with separators and format
+	fill(0, 0, 9, 9)
+	fill(0, dim-8, 9, 8)
+	fill(dim-8, 0, 8, 9)
+	// Timing
+	fill(6, 0, 1, dim)
+	fill(0, 6, dim, 1)
+	positions := qrAlignment[version]
+	last := len(positions) - 1
+	for i, row := range positions {
+		for j, col := range positions {
+			// None next to the finders
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			fill(row-2, col-2, 5, 5)
+		}
+	}
+	if version >= 7 {
+		fill(0, dim-11, 6, 3)
+		fill(dim-11, 0, 3, 6)
+	}
+	return fn
+}
+
+// decodeQRGrid decodes the sampled modules of a code
+func decodeQRGrid(grid [][]bool, version int) (string, error) {
+	level, mask, err := readQRFormat(grid)
+	if err != nil {
+		return "", err
+	}
+	dim := len(grid)
+	fn := qrFunctionModules(version)
+
+	// Modules are read in two column wide strips, alternately upwards and
+	// downwards from the bottom right; the vertical timing column is skipped
+	var codewords []byte
+	var current byte
+	bits := 0
+	upwards := true
+	for right := dim - 1; right > 0; right -= 2 {
+		if right == 6 {
+			right--
+		}
+		for i := 0; i < dim; i++ {
+			row := i
+			if upwards {
+				row = dim - 1 - i
+			}
+			for col := right; col > right-2; col-- {
+				if fn[row][col] {
+					continue
+				}
+				current <<= 1
+				if grid[row][col] != qrMasked(mask, row, col) {
+					current |= 1
+				}
+				if bits++; bits == 8 {
+					codewords = append(codewords, current)
+					current, bits = 0, 0
+				}
+			}
+		}
+		upwards = !upwards
+	}
+
+	blocks := qrECBlocks[version][level]
+	data, err := qrCorrectBlocks(codewords, blocks)
+	if err != nil {
+		return "", err
+	}
+	return parseQRData(data, version)
+}
+
+// qrCorrectBlocks splits the interleaved codewords into blocks, corrects
+// them and returns their data codewords in order
+func qrCorrectBlocks(codewords []byte, b qrBlocks) ([]byte, error) {
+	count := b.blocks1 + b.blocks2
+	total := count*b.ecPerBlock + b.blocks1*b.data1 + b.blocks2*b.data2
+	if len(codewords) < total {
+		return nil, errQRUnreadable
+	}
+	dataLen := func(block int) int {
+		if block < b.blocks1 {
+			return b.data1
+		}
+		return b.data2
+	}
+	blocks := make([][]byte, count)
+	for i := range blocks {
+		blocks[i] = make([]byte, 0, dataLen(i)+b.ecPerBlock)
+	}
+	next := 0
+	for i := 0; i < b.data1 || i < b.data2; i++ {
+		for block := range blocks {
+			if i < dataLen(block) {
+				blocks[block] = append(blocks[block], codewords[next])
+				next++
+			}
+		}
+	}
+	for i := 0; i < b.ecPerBlock; i++ {
+		for block := range blocks {
+			blocks[block] = append(blocks[block], codewords[next])
+			next++
+		}
+	}
+
+	var data []byte
+	for i, block := range blocks {
+		if err := rsCorrect(block, b.ecPerBlock); err != nil {
+			return nil, err
+		}
+		data = append(data, block[:dataLen(i)]...)
+	}
+	return data, nil
+}
+
+// qrBitReader reads the bit stream of the data codewords
+type qrBitReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *qrBitReader) left() int {
+	return len(r.data)*8 - r.pos
+}
+
+func (r *qrBitReader) read(n int) (int, error) {
+	if n > r.left() {
+		return 0, errQRUnreadable
+	}
+	v := 0
+	for i := 0; i < n; i++ {
+		v <<= 1
+		if r.data[r.pos/8]&(0x80>>(r.pos%8)) != 0 {
+			v |= 1
+		}
+		r.pos++
+	}
+	return v, nil
+}
+
+const qrAlphanumeric = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ $%*+-./:"
+
+// parseQRData decodes the numeric, alphanumeric and byte segments of the
+// data; ECI designators are skipped, bytes are read as UTF-8
+func parseQRData(data []byte, version int) (string, error) {
+	r := &qrBitReader{data: data}
+	// Character count bits of numeric, alphanumeric and byte segments
+	countBits := [3]int{10, 9, 8}
+	if version >= 10 {
+		countBits = [3]int{12, 11, 16}
+	}
+
+	var out []byte
+	for r.left() >= 4 {
+		mode, _ := r.read(4)
+		switch mode {
+		case 0: // Terminator
+			return barcodeText(out), nil
+		case 1:
+			count, err := r.read(countBits[0])
+			if err != nil {
+				return "", err
+			}
+			for ; count > 0; count -= 3 {
+				digits, width := 3, 10
+				if count == 2 {
+					digits, width = 2, 7
+				} else if count == 1 {
+					digits, width = 1, 4
+				}
+				v, err := r.read(width)
+				if err != nil {
+					return "", err
+				}
+				for d := digits - 1; d >= 0; d-- {
+					out = append(out, byte('0'+v/int(math.Pow10(d))%10))
+				}
+			}
+		case 2:
+			count, err := r.read(countBits[1])
+			if err != nil {
+				return "", err
+			}
+			for ; count > 1; count -= 2 {
+				v, err := r.read(11)
+				if err != nil || v >= 45*45 {
+					return "", errQRUnreadable
+				}
+				out = append(out, qrAlphanumeric[v/45], qrAlphanumeric[v%45])
+			}
+			if count == 1 {
+				v, err := r.read(6)
+				if err != nil || v >= 45 {
+					return "", errQRUnreadable
+				}
+				out = append(out, qrAlphanumeric[v])
+			}
+		case 4:
+			count, err := r.read(countBits[2])
+			if err != nil {
+				return "", err
+			}
+			for ; count > 0; count-- {
+				v, err := r.read(8)
+				if err != nil {
+					return "", err
+				}
+				out = append(out, byte(v))
+			}
+		case 7: // ECI, one to three bytes
+			first, err := r.read(8)
+			if err != nil {
+				return "", err
+			}
+			if first&0x80 != 0 {
+				extra := 8
+				if first&0xc0 == 0xc0 {
+					extra = 16
+				}
+				if _, err := r.read(extra); err != nil {
+					return "", err
+				}
+			}
+		case 3: // Structured append
+			if _, err := r.read(16); err != nil {
+				return "", err
+			}
+		case 5: // FNC1 in the first position
+		case 9:
+			if _, err := r.read(8); err != nil {
+				return "", err
+			}
+		default: // Kanji and unknown modes
+			return "", errQRUnreadable
+		}
+	}
+	return barcodeText(out), nil
+}
+
+// Arithmetic of GF(256) with the QR polynomial x^8+x^4+x^3+x^2+1
+var gfExp, gfLog = gfTables()
+
+func gfTables() (exp [512]byte, log [256]int) {
+	x := 1
+	for i := 0; i < 255; i++ {
+		exp[i] = byte(x)
+		log[x] = i
+		x <<= 1
+		if x&0x100 != 0 {
+			x ^= 0x11d
+		}
+	}
+	for i := 255; i < 512; i++ {
+		exp[i] = exp[i-255]
+	}
+	return exp, log
+}
+
+func gfMul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return gfExp[gfLog[a]+gfLog[b]]
+}
+
+func gfDiv(a, b byte) byte {
+	if a == 0 {
+		return 0
+	}
+	return gfExp[gfLog[a]+255-gfLog[b]]
+}
+
+// gfEval evaluates a polynomial with the lowest coefficient first
+func gfEval(poly []byte, x byte) byte {
+	var v byte
+	for i := len(poly) - 1; i >= 0; i-- {
+		v = gfMul(v, x) ^ poly[i]
+	}
+	return v
+}
+
+// rsCorrect corrects a block of data and ecLen error correction codewords
+// in place: Berlekamp-Massey finds the error locator, the Chien search its
+// roots and Forney the error values
+func rsCorrect(block []byte, ecLen int) error {
+	n := len(block)
+	syndromes := make([]byte, ecLen)
+	clean := true
+	for j := range syndromes {
+		var s byte
+		for _, c := range block {
+			s = gfMul(s, gfExp[j]) ^ c
+		}
+		syndromes[j] = s
+		clean = clean && s == 0
+	}
+	if clean {
+		return nil
+	}
+
+	locator, prev := []byte{1}, []byte{1}
+	errs, shift, lastDelta := 0, 1, byte(1)
+	for k := 0; k < ecLen; k++ {
+		delta := syndromes[k]
+		for i := 1; i <= errs && i < len(locator); i++ {
+			delta ^= gfMul(locator[i], syndromes[k-i])
+		}
+		if delta == 0 {
+			shift++
+			continue
+		}
+		saved := append([]byte(nil), locator...)
+		if need := len(prev) + shift; len(locator) < need {
+			locator = append(locator, make([]byte, need-len(locator))...)
+		}
+		coef := gfDiv(delta, lastDelta)
+		for i, p := range prev {
+			locator[i+shift] ^= gfMul(coef, p)
+		}
+		if 2*errs <= k {
+			errs, prev, lastDelta, shift = k+1-errs, saved, delta, 1
+		} else {
+			shift++
+		}
+	}
+	if 2*errs > ecLen {
+		return errQRUnreadable
+	}
+
+	// Omega = S(x) * locator(x) mod x^ecLen
+	omega := make([]byte, ecLen)
+	for i := range omega {
+		for j := 0; j <= i && j < len(locator); j++ {
+			omega[i] ^= gfMul(locator[j], syndromes[i-j])
+		}
+	}
+	found := 0
+	for p := 0; p < n; p++ {
+		e := n - 1 - p
+		inverse := gfExp[(255-e)%255]
+		if gfEval(locator, inverse) != 0 {
+			continue
+		}
+		found++
+		var derivative byte
+		for i := 1; i < len(locator); i += 2 {
+			term := locator[i]
+			for k := 0; k < i-1; k++ {
+				term = gfMul(term, inverse)
+			}
+			derivative ^= term
+		}
+		if derivative == 0 {
+			return errQRUnreadable
+		}
+		block[p] ^= gfMul(gfExp[e], gfDiv(gfEval(omega, inverse), derivative))
+	}
+	if found != errs {
+		return errQRUnreadable
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package searcher
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"time"
+)
+
+// LatestReportDir is the name of the link (or copy on Windows) that points
+// at the most recent report directory
+const LatestReportDir = "latest"
+
+// reportDirLayout is the timestamp layout of per-run report directories
+const reportDirLayout = "2006-01-02_150405"
+
+// useSymlinkForLatest is false on Windows, where creating symlinks needs
+// elevated rights, so "latest" is a copy there
+var useSymlinkForLatest = runtime.GOOS != "windows"
+
+// Permissions of written files: reports get the umask like any file the
+// user creates, caches holding what scans found stay private
+const (
+	reportFileMode  os.FileMode = 0666
+	privateFileMode os.FileMode = 0600
+)
+
+// writeFileAtomic writes a file via a temp file in the same directory,
+// fsyncs it and renames it into place, so readers never see a partial file.
+// The temp file is created with perm, less the umask.
+// If write fails the previous content of path is left untouched.
+func writeFileAtomic(path string, perm os.FileMode, write func(w io.Writer) error) (err error) {
+	tmp, err := createTempFile(path, perm)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	if err = write(tmp); err != nil {
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// createTempFile creates a new file next to path; unlike os.CreateTemp,
+// which always uses 0600, the mode is perm less the umask
+func createTempFile(path string, perm os.FileMode) (*os.File, error) {
+	for i := 0; ; i++ {
+		name := filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".tmp-"+strconv.FormatUint(uint64(rand.Uint32()), 10))
+		f, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, perm)
+		if !errors.Is(err, os.ErrExist) || i == 100 {
+			return f, err
+		}
+	}
+}
+
+// createRunDir creates a timestamped report directory inside outputDir.
+// If a directory for the same second already exists a numeric suffix is added.
+func createRunDir(outputDir string, now time.Time) (string, error) {
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return "", fmt.Errorf("не удалось создать директорию отчётов: %w", err)
+	}
+
+	base := now.Format(reportDirLayout)
+	for i := 1; i <= 100; i++ {
+		name := base
+		if i > 1 {
+			name = fmt.Sprintf("%s_%d", base, i)
+		}
+		dir := filepath.Join(outputDir, name)
+
+		err := os.Mkdir(dir, 0755)
+		if err == nil {
+			return dir, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return "", fmt.Errorf("не удалось создать директорию отчётов: %w", err)
+		}
+	}
+	return "", fmt.Errorf("не удалось создать директорию отчётов: слишком много запусков в %s", base)
+}
+
+// updateLatest points outputDir/latest at runDir: a relative symlink where
+// supported, otherwise a copy of the report files
+func updateLatest(outputDir, runDir string) error {
+	latest := filepath.Join(outputDir, LatestReportDir)
+	tmp := latest + ".tmp"
+	os.RemoveAll(tmp)
+
+	if useSymlinkForLatest {
+		if err := os.Symlink(filepath.Base(runDir), tmp); err == nil {
+			return replacePath(tmp, latest)
+		}
+		// Fall back to a copy, e.g. on filesystems without symlinks
+	}
+
+	if err := copyDir(runDir, tmp); err != nil {
+		os.RemoveAll(tmp)
+		return err
+	}
+	return replacePath(tmp, latest)
+}
+
+// replacePath renames src over dst, removing dst first if rename cannot replace it
+// (a directory left by an earlier copy)
+func replacePath(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+	if err := os.RemoveAll(dst); err != nil {
+		os.RemoveAll(src)
+		return err
+	}
+	return os.Rename(src, dst)
+}
+
+// copyDir copies the regular files of src into a new directory dst
+func copyDir(src, dst string) error {
+	if err := os.Mkdir(dst, 0755); err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(src, entry.Name()))
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(dst, entry.Name()), data, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -1,31 +1,92 @@
 package searcher
 
 import (
-	"encoding/csv"
+	"bufio"
 	"encoding/json"
-	"os"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
+	"unicode/utf8"
+
+	"github.com/kacebover/password-finder/locale"
 )
 
-// ReportGenerator generates findings reports in various formats
+// ReportGenerator generates findings reports in various formats. Reports
+// get copied around, so matched secrets are masked in every format unless
+// SetIncludeSecrets is on; MatchSHA256 still tells findings apart
 type ReportGenerator struct {
-	result *ScanResult
+	result         *ScanResult
+	includeSecrets bool
+	locale         *locale.Localizer
+	csvColumns     []string // Columns of the CSV report, nil for the default ones
+	noCSVBOM       bool
 }
 
-// NewReportGenerator creates a new ReportGenerator
+// NewReportGenerator creates a new ReportGenerator writing Russian reports
 func NewReportGenerator(result *ScanResult) *ReportGenerator {
 	return &ReportGenerator{
 		result: result,
+		locale: russian,
+	}
+}
+
+// SetLocale sets the language of the text, CSV, HTML, XLSX, SARIF and
+// JUnit reports; JSON keys and values stay as they are
+func (rg *ReportGenerator) SetLocale(l *locale.Localizer) {
+	rg.locale = l
+}
+
+// SetIncludeSecrets writes matched text and context as found instead of
+// masked. SARIF and JUnit reports, meant for CI systems, are always masked
+func (rg *ReportGenerator) SetIncludeSecrets(include bool) {
+	rg.includeSecrets = include
+}
+
+// reportFinding returns a copy of f as reports show it, masked unless
+// secrets are included
+func (rg *ReportGenerator) reportFinding(f *Finding) *Finding {
+	copied := *f
+	if copied.MatchSHA256 == "" && copied.MatchedText != "" {
+		copied.MatchSHA256 = matchSHA256(copied.MatchedText)
+	}
+	if !rg.includeSecrets {
+		maskMatch(&copied)
 	}
+	return &copied
+}
+
+// forEachFinding is ScanResult.ForEachFinding over the findings as reports
+// show them
+func (rg *ReportGenerator) forEachFinding(fn func(*Finding) bool) error {
+	return rg.result.ForEachFinding(func(f *Finding) bool {
+		return fn(rg.reportFinding(f))
+	})
 }
 
+// ReportFormat marks JSON reports written by ExportJSON so a .dllreport file
+// can be told apart from arbitrary JSON
+const ReportFormat = "data-leak-locator/report"
+
+// ReportExtension is the extension associated with the GUI; the file is the
+// JSON report with the Format field set
+const ReportExtension = ".dllreport"
+
 // JSONReport represents the structure for JSON export
 type JSONReport struct {
-	Metadata    ReportMetadata `json:"metadata"`
-	Summary     ReportSummary  `json:"summary"`
-	Findings    []*Finding     `json:"findings"`
-	GeneratedAt string         `json:"generated_at"`
+	Format       string          `json:"format"`
+	Metadata     ReportMetadata  `json:"metadata"`
+	Summary      ReportSummary   `json:"summary"`
+	Findings     []*Finding      `json:"findings"`
+	SecretGroups []SecretGroup   `json:"secret_groups,omitempty"`
+	Coverage     *CoverageReport `json:"coverage,omitempty"`
+	PatternStats []PatternStat   `json:"pattern_stats,omitempty"`
+	Metrics      *ScanMetrics    `json:"metrics,omitempty"`
+	Errors       []ScanError     `json:"errors,omitempty"` // Files that could not be scanned and why
+	GeneratedAt  string          `json:"generated_at"`
 }
 
 // ReportMetadata contains scan metadata
@@ -37,6 +98,20 @@ type ReportMetadata struct {
 	FilesSkipped     int    `json:"files_skipped"`
 	TotalDataScanned string `json:"total_data_scanned_bytes"`
 	ErrorCount       int    `json:"error_count"`
+	ErrorsDropped    int    `json:"errors_dropped,omitempty"` // Errors counted but left out of "errors"
+	PrunedFindings   int    `json:"pruned_findings,omitempty"`
+	ScanRoot         string `json:"scan_root,omitempty"`
+	Baselined        int    `json:"baselined_findings,omitempty"`  // Known findings left out of the report
+	Suppressed       int    `json:"suppressed_findings,omitempty"` // Matches skipped by dataleak:ignore comments
+	Filtered         int    `json:"filtered_findings,omitempty"`   // Findings below the minimum severity
+	NestedArchives   int    `json:"nested_archives_scanned,omitempty"`
+	CacheHits        int    `json:"cache_hits,omitempty"` // Unchanged files taken from the incremental cache
+
+	ExtensionSkips map[string]int `json:"extension_filtered,omitempty"` // Files left out by the extension filter, by extension
+	Truncated      map[string]int `json:"truncated_files,omitempty"`    // Files matching stopped in, path -> findings kept
+
+	HeuristicDropped    int `json:"heuristic_dropped,omitempty"`    // References and placeholders left out
+	HeuristicDowngraded int `json:"heuristic_downgraded,omitempty"` // Variables lowered to Low
 }
 
 // ReportSummary contains summary statistics
@@ -49,143 +124,299 @@ type ReportSummary struct {
 	AverageRiskScore float64        `json:"average_risk_score"`
 	HighestRiskScore float64        `json:"highest_risk_score"`
 	PatternCounts    map[string]int `json:"pattern_counts"`
+	GroupCounts      map[string]int `json:"group_counts,omitempty"`         // Findings per detector group or pack
+	DistinctSecrets  int            `json:"distinct_secrets"`               // Secret groups; the counts above are per occurrence
+	DistinctCounts   map[string]int `json:"distinct_by_severity,omitempty"` // Secret groups per highest severity
 }
 
 // ExportJSON exports findings to a JSON file
 func (rg *ReportGenerator) ExportJSON(filePath string) error {
-	summary := rg.generateSummary()
-	metadata := rg.generateMetadata()
+	return writeFileAtomic(filePath, reportFileMode, rg.writeJSON)
+}
+
+// jsonFindingsField is the empty findings array of the marshalled report
+// that writeJSON replaces with the streamed findings
+const jsonFindingsField = "\n  \"findings\": []"
 
+// writeJSON writes the JSON report to w. Findings are marshalled one at a
+// time, so a spilled result streams from disk; the output is the same as
+// json.MarshalIndent of the whole report
+func (rg *ReportGenerator) writeJSON(w io.Writer) error {
 	report := JSONReport{
-		Metadata:    metadata,
-		Summary:     summary,
-		Findings:    rg.result.Findings,
-		GeneratedAt: time.Now().Format(time.RFC3339),
+		Format:       ReportFormat,
+		Metadata:     rg.generateMetadata(),
+		Summary:      rg.generateSummary(),
+		Findings:     []*Finding{},
+		SecretGroups: rg.result.GetSecretGroups(),
+		Coverage:     rg.result.Coverage,
+		PatternStats: rg.result.PatternStats(),
+		Metrics:      rg.result.Metrics,
+		Errors:       rg.result.GetErrors(),
+		GeneratedAt:  time.Now().Format(time.RFC3339),
 	}
 
 	data, err := json.MarshalIndent(report, "", "  ")
 	if err != nil {
 		return err
 	}
-
-	return os.WriteFile(filePath, data, 0644)
-}
-
-// ExportCSV exports findings to a CSV file
-func (rg *ReportGenerator) ExportCSV(filePath string) error {
-	file, err := os.Create(filePath)
+	head, tail, _ := strings.Cut(string(data), jsonFindingsField)
+
+	out := bufio.NewWriter(w)
+	out.WriteString(head + "\n  \"findings\": [")
+	written := 0
+	var marshalErr error
+	err = rg.forEachFinding(func(f *Finding) bool {
+		item, err := json.MarshalIndent(f, "    ", "  ")
+		if err != nil {
+			marshalErr = err
+			return false
+		}
+		if written > 0 {
+			out.WriteString(",")
+		}
+		out.WriteString("\n    ")
+		out.Write(item)
+		written++
+		return true
+	})
+	if err == nil {
+		err = marshalErr
+	}
 	if err != nil {
 		return err
 	}
-	defer file.Close()
-
-	// Write UTF-8 BOM for Excel compatibility
-	file.Write([]byte{0xEF, 0xBB, 0xBF})
-
-	writer := csv.NewWriter(file)
-	defer writer.Flush()
-
-	// Write header (Russian)
-	header := []string{
-		"Путь к файлу",
-		"Строка",
-		"Начало колонки",
-		"Конец колонки",
-		"Тип паттерна",
-		"Уровень серьёзности",
-		"Оценка риска",
-		"Энтропия",
-		"Описание",
-		"Найденный текст",
-		"Контекст",
-	}
-	if err := writer.Write(header); err != nil {
-		return err
+	if written > 0 {
+		out.WriteString("\n  ")
 	}
-
-	// Write findings
-	for _, finding := range rg.result.Findings {
-		record := []string{
-			finding.FilePath,
-			strconv.Itoa(finding.LineNumber),
-			strconv.Itoa(finding.ColumnStart),
-			strconv.Itoa(finding.ColumnEnd),
-			patternTypeToRussian(finding.PatternType),
-			severityToRussian(finding.Severity),
-			strconv.FormatFloat(finding.RiskScore, 'f', 2, 64),
-			strconv.FormatFloat(finding.EntropyScore, 'f', 4, 64),
-			descriptionToRussian(finding.Description),
-			maskSensitiveText(finding.MatchedText),
-			maskSensitiveText(finding.Context),
-		}
-		if err := writer.Write(record); err != nil {
-			return err
-		}
-	}
-
-	return nil
+	out.WriteString("]" + tail)
+	return out.Flush()
 }
 
 // ExportPlainText exports findings to a plain text file
 func (rg *ReportGenerator) ExportPlainText(filePath string) error {
-	file, err := os.Create(filePath)
-	if err != nil {
-		return err
+	return writeFileAtomic(filePath, reportFileMode, rg.writePlainText)
+}
+
+// writePlainText writes the text report to w
+func (rg *ReportGenerator) writePlainText(w io.Writer) error {
+	file := bufio.NewWriter(w)
+	l := rg.locale
+	line := func(key string, args ...any) {
+		file.WriteString(l.T(key, args...) + "\n")
 	}
-	defer file.Close()
 
 	summary := rg.generateSummary()
 
 	// Write header
-	file.WriteString("ОТЧЁТ ОБ ОБНАРУЖЕНИИ УТЕЧЕК ДАННЫХ\n")
-	file.WriteString("==================================\n\n")
+	file.WriteString(underline(l.T("report.title"), "=") + "\n")
 
-	file.WriteString("Дата создания: " + time.Now().Format("02.01.2006 15:04:05") + "\n")
-	file.WriteString("Длительность сканирования: " + strconv.FormatInt(rg.result.EndTime-rg.result.StartTime, 10) + " сек.\n\n")
+	line("report.created", time.Now().Format("02.01.2006 15:04:05"))
+	line("report.duration", rg.result.EndTime-rg.result.StartTime)
+	file.WriteString("\n")
 
 	// Write summary
-	file.WriteString("СВОДКА\n")
-	file.WriteString("------\n")
-	file.WriteString("Всего находок:     " + strconv.Itoa(summary.TotalFindings) + "\n")
-	file.WriteString("🔴 Критических:    " + strconv.Itoa(summary.CriticalFindings) + "\n")
-	file.WriteString("🟠 Высоких:        " + strconv.Itoa(summary.HighFindings) + "\n")
-	file.WriteString("🟡 Средних:        " + strconv.Itoa(summary.MediumFindings) + "\n")
-	file.WriteString("🟢 Низких:         " + strconv.Itoa(summary.LowFindings) + "\n")
-	file.WriteString("Средняя оценка риска: " + strconv.FormatFloat(summary.AverageRiskScore, 'f', 2, 64) + "\n\n")
+	file.WriteString(underline(l.T("report.summary"), "-"))
+	line("report.total", summary.TotalFindings)
+	line("report.distinct", summary.DistinctSecrets)
+	line("report.critical", summary.CriticalFindings)
+	line("report.high", summary.HighFindings)
+	line("report.medium", summary.MediumFindings)
+	line("report.low", summary.LowFindings)
+	line("report.average_risk", summary.AverageRiskScore)
+	if rg.result.SuppressedCount > 0 {
+		line("report.suppressed", rg.result.SuppressedCount)
+	}
+	if rg.result.FilteredFindings > 0 {
+		line("report.filtered", rg.result.FilteredFindings)
+	}
+	if n := rg.result.HeuristicDropped; n > 0 {
+		line("report.heuristic_dropped", n)
+	}
+	if n := rg.result.HeuristicDowngraded; n > 0 {
+		line("report.heuristic_downgraded", n)
+	}
+	if rg.result.NestedArchivesScanned > 0 {
+		line("report.nested_archives", rg.result.NestedArchivesScanned)
+	}
+	file.WriteString("\n")
 
 	// Pattern statistics
 	if len(summary.PatternCounts) > 0 {
-		file.WriteString("СТАТИСТИКА ПО ТИПАМ\n")
-		file.WriteString("-------------------\n")
+		file.WriteString(underline(l.T("report.by_type"), "-"))
 		for pattern, count := range summary.PatternCounts {
-			file.WriteString("  " + patternTypeToRussian(PatternType(pattern)) + ": " + strconv.Itoa(count) + "\n")
+			file.WriteString("  " + l.Pattern(pattern) + ": " + strconv.Itoa(count) + "\n")
 		}
 		file.WriteString("\n")
 	}
 
-	// Write findings
-	file.WriteString("ДЕТАЛИ НАХОДОК\n")
-	file.WriteString("--------------\n\n")
+	// The same secret in several places
+	if repeated := RepeatedSecrets(rg.result.GetSecretGroups()); len(repeated) > 0 {
+		file.WriteString(underline(l.T("report.repeated"), "-"))
+		file.WriteString(rg.formatRepeatedSecrets(repeated))
+		file.WriteString("\n")
+	}
 
-	for i, finding := range rg.result.Findings {
-		file.WriteString(strconv.Itoa(i+1) + ". " + finding.FilePath + ":" + strconv.Itoa(finding.LineNumber) + "\n")
-		file.WriteString("   Тип:         " + patternTypeToRussian(finding.PatternType) + "\n")
-		file.WriteString("   Серьёзность: " + severityToRussian(finding.Severity) + "\n")
-		file.WriteString("   Оценка риска: " + strconv.FormatFloat(finding.RiskScore, 'f', 2, 64) + "\n")
-		file.WriteString("   Описание:    " + descriptionToRussian(finding.Description) + "\n")
-		file.WriteString("   Контекст:    " + maskSensitiveText(finding.Context) + "\n\n")
+	// Per-rule statistics
+	if stats := rg.result.PatternStats(); len(stats) > 0 && summary.TotalFindings > 0 {
+		file.WriteString(underline(l.T("report.by_rule"), "-"))
+		file.WriteString(FormatPatternStats(l, stats))
+		file.WriteString("\n")
 	}
 
-	file.WriteString("==================================\n")
-	file.WriteString("Конец отчёта\n")
+	// Where the time of the scan went
+	if rg.result.Metrics != nil {
+		file.WriteString(underline(l.T("report.performance"), "-"))
+		file.WriteString(FormatScanMetrics(l, rg.result.Metrics))
+		file.WriteString("\n")
+	}
 
-	return nil
+	// Optional groups and packs
+	if len(summary.GroupCounts) > 0 {
+		file.WriteString(underline(l.T("report.by_pack"), "-"))
+		groups := make([]string, 0, len(summary.GroupCounts))
+		for group := range summary.GroupCounts {
+			groups = append(groups, group)
+		}
+		sort.Strings(groups)
+		for _, group := range groups {
+			file.WriteString("  " + l.Group(group) + ": " + strconv.Itoa(summary.GroupCounts[group]) + "\n")
+		}
+		file.WriteString("\n")
+	}
+
+	// Files matching stopped in
+	if len(rg.result.Truncated) > 0 {
+		file.WriteString(underline(l.T("report.truncated"), "-"))
+		paths := make([]string, 0, len(rg.result.Truncated))
+		for path := range rg.result.Truncated {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+		for _, path := range paths {
+			file.WriteString("  " + rg.relativePath(path) + ": " + LocalizeTruncation(l, rg.result.Truncated[path]) + "\n")
+		}
+		file.WriteString("\n")
+	}
+
+	// Optional capabilities
+	if rg.result.Coverage != nil {
+		file.WriteString(underline(l.T("report.coverage"), "-"))
+		file.WriteString(FormatCoverage(l, rg.result.Coverage))
+		file.WriteString("\n")
+	}
+
+	// Risk distribution
+	if rg.result.TotalFindings() > 0 {
+		stats := ComputeStatistics(rg.result)
+		file.WriteString(rg.formatDistribution(stats))
+	}
+
+	// Write findings
+	file.WriteString(underline(l.T("report.details"), "-") + "\n")
+
+	i := 0
+	err := rg.forEachFinding(func(finding *Finding) bool {
+		i++
+		file.WriteString(strconv.Itoa(i) + ". " + finding.FilePath + ":" + strconv.Itoa(finding.LineNumber) + "\n")
+		if page := finding.LocalizedPageLabel(l); page != "" {
+			line("report.finding.page", page)
+		}
+		line("report.finding.type", l.Pattern(string(finding.PatternType)))
+		line("report.finding.severity", l.Severity(string(finding.Severity)))
+		risk := strconv.FormatFloat(finding.RiskScore, 'f', 2, 64)
+		if finding.PathAdjusted() {
+			risk += l.T("report.finding.base_risk", finding.BaseRiskScore)
+		}
+		line("report.finding.risk", risk)
+		line("report.finding.description", LocalizeDescription(l, finding.Description))
+		if finding.KeyPath != "" {
+			line("report.finding.key", finding.KeyPath)
+		}
+		if finding.GitStatus != "" {
+			line("report.finding.git", LocalizeGitStatus(l, finding.GitStatus))
+		}
+		if finding.Commit != "" {
+			line("report.finding.commit", finding.Commit, finding.Author, finding.CommitDate)
+		}
+		line("report.finding.context", truncateText(finding.Context))
+		if finding.Explanation != "" {
+			// Lines of the explanation line up after the label
+			label := l.T("report.finding.explanation")
+			indent := "\n" + strings.Repeat(" ", utf8.RuneCountInString(label))
+			file.WriteString(label + strings.ReplaceAll(finding.Explanation, "\n", indent) + "\n")
+		}
+		file.WriteString("\n")
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	file.WriteString(strings.Repeat("=", utf8.RuneCountInString(l.T("report.title"))) + "\n")
+	line("report.end")
+
+	return file.Flush()
+}
+
+// underline returns a heading of the text report underlined with rule
+func underline(title, rule string) string {
+	return title + "\n" + strings.Repeat(rule, utf8.RuneCountInString(title)) + "\n"
+}
+
+// formatDistribution renders the risk histogram, riskiest files and
+// per-extension tables for the text report
+func (rg *ReportGenerator) formatDistribution(stats AnalysisStatistics) string {
+	var sb strings.Builder
+	l := rg.locale
+
+	sb.WriteString(underline(l.T("report.distribution"), "-"))
+	sb.WriteString(l.T("report.severity_spark", Sparkline([]int{
+		stats.SeverityDistribution[string(Low)],
+		stats.SeverityDistribution[string(Medium)],
+		stats.SeverityDistribution[string(High)],
+		stats.SeverityDistribution[string(Critical)],
+	})) + "\n\n")
+	sb.WriteString(FormatRiskHistogram(stats.RiskHistogram, 30))
+	sb.WriteString("\n")
+
+	sb.WriteString(underline(l.T("report.riskiest"), "-"))
+	sb.WriteString(FormatTopFiles(l, stats.RiskiestFiles))
+	sb.WriteString("\n")
+
+	sb.WriteString(underline(l.T("report.by_extension"), "-"))
+	sb.WriteString(FormatExtensionTable(l, stats.Extensions))
+	sb.WriteString("\n")
+
+	return sb.String()
+}
+
+// repeatedSecretsShown is how many locations of a repeated secret the text
+// report lists
+const repeatedSecretsShown = 5
+
+// formatRepeatedSecrets lists secrets found in several places for the text
+// report, by fingerprint only
+func (rg *ReportGenerator) formatRepeatedSecrets(groups []SecretGroup) string {
+	var sb strings.Builder
+	l := rg.locale
+	for _, group := range groups {
+		sb.WriteString(l.T("report.repeated_line", l.Pattern(string(group.PatternType)),
+			l.Severity(string(group.Severity)), group.Fingerprint, len(group.Occurrences), group.Files) + "\n")
+		for i, loc := range group.Occurrences {
+			if i == repeatedSecretsShown {
+				sb.WriteString(l.T("report.more", len(group.Occurrences)-i) + "\n")
+				break
+			}
+			sb.WriteString(fmt.Sprintf("    %s:%d\n", rg.relativePath(loc.FilePath), loc.LineNumber))
+		}
+	}
+	return sb.String()
 }
 
 // generateSummary creates a summary of findings
 func (rg *ReportGenerator) generateSummary() ReportSummary {
 	summary := ReportSummary{
-		TotalFindings:    len(rg.result.Findings),
+		TotalFindings:    rg.result.TotalFindings() + rg.result.PrunedFindings,
 		PatternCounts:    make(map[string]int),
 		HighestRiskScore: 0,
 		AverageRiskScore: 0,
@@ -197,20 +428,38 @@ func (rg *ReportGenerator) generateSummary() ReportSummary {
 	summary.LowFindings = rg.result.SeveritySummary[Low]
 
 	totalRiskScore := 0.0
+	counted := 0
 
-	for _, finding := range rg.result.Findings {
+	rg.result.ForEachFinding(func(finding *Finding) bool {
+		counted++
 		// Count patterns
 		summary.PatternCounts[string(finding.PatternType)]++
+		if finding.Group != "" {
+			if summary.GroupCounts == nil {
+				summary.GroupCounts = make(map[string]int)
+			}
+			summary.GroupCounts[finding.Group]++
+		}
 
 		// Track risk scores
 		totalRiskScore += finding.RiskScore
 		if finding.RiskScore > summary.HighestRiskScore {
 			summary.HighestRiskScore = finding.RiskScore
 		}
+		return true
+	})
+
+	if counted > 0 {
+		summary.AverageRiskScore = totalRiskScore / float64(counted)
 	}
 
-	if len(rg.result.Findings) > 0 {
-		summary.AverageRiskScore = totalRiskScore / float64(len(rg.result.Findings))
+	groups := rg.result.GetSecretGroups()
+	summary.DistinctSecrets = len(groups)
+	if len(groups) > 0 {
+		summary.DistinctCounts = make(map[string]int)
+		for severity, n := range CountDistinctSecrets(groups) {
+			summary.DistinctCounts[string(severity)] = n
+		}
 	}
 
 	return summary
@@ -226,11 +475,25 @@ func (rg *ReportGenerator) generateMetadata() ReportMetadata {
 		FilesSkipped:     rg.result.FilesSkipped,
 		TotalDataScanned: strconv.FormatInt(rg.result.TotalSize, 10) + " bytes",
 		ErrorCount:       rg.result.ErrorCount,
+		ErrorsDropped:    rg.result.ErrorsDropped,
+		PrunedFindings:   rg.result.PrunedFindings,
+		ScanRoot:         rg.result.Root,
+		Baselined:        len(rg.result.Baselined),
+		Suppressed:       rg.result.SuppressedCount,
+		Filtered:         rg.result.FilteredFindings,
+		NestedArchives:   rg.result.NestedArchivesScanned,
+		CacheHits:        rg.result.CacheHits,
+		ExtensionSkips:   rg.result.ExtensionSkips,
+		Truncated:        rg.result.Truncated,
+
+		HeuristicDropped:    rg.result.HeuristicDropped,
+		HeuristicDowngraded: rg.result.HeuristicDowngraded,
 	}
 }
 
-// maskSensitiveText masks sensitive parts of text for display
-func maskSensitiveText(text string) string {
+// truncateText shortens long matches and context lines for the CSV and
+// text reports
+func truncateText(text string) string {
 	// If text is very long, truncate it
 	if len(text) > 100 {
 		return text[:97] + "..."
@@ -238,101 +501,114 @@ func maskSensitiveText(text string) string {
 	return text
 }
 
-// GenerateReport generates a complete report in multiple formats
-func (rg *ReportGenerator) GenerateReport(outputDir string) error {
-	timestamp := time.Now().Format("20060102_150405")
+// Report file formats written by GenerateReportFormats
+const (
+	FormatJSON  = "json"
+	FormatCSV   = "csv"
+	FormatText  = "txt"
+	FormatSARIF = "sarif"
+	FormatJUnit = "junit"
+	FormatHTML  = "html"
+	FormatXLSX  = "xlsx"
+)
 
-	// JSON report
-	jsonPath := outputDir + "/отчёт-утечки_" + timestamp + ".json"
-	if err := rg.ExportJSON(jsonPath); err != nil {
-		return err
+// DefaultReportFormats are the formats written by GenerateReport
+var DefaultReportFormats = []string{FormatJSON, FormatCSV, FormatText, FormatHTML}
+
+// ParseReportFormats parses a comma-separated list such as "json,sarif".
+// An empty list means DefaultReportFormats
+func ParseReportFormats(list string) ([]string, error) {
+	var formats []string
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(list, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || seen[name] {
+			continue
+		}
+		switch name {
+		case FormatJSON, FormatCSV, FormatText, FormatSARIF, FormatJUnit, FormatHTML, FormatXLSX:
+		default:
+			return nil, fmt.Errorf("неизвестный формат отчёта: %q (допустимо: json, csv, txt, html, sarif, junit, xlsx)", name)
+		}
+		seen[name] = true
+		formats = append(formats, name)
+	}
+	if len(formats) == 0 {
+		return DefaultReportFormats, nil
 	}
+	return formats, nil
+}
 
-	// CSV report
-	csvPath := outputDir + "/отчёт-утечки_" + timestamp + ".csv"
-	if err := rg.ExportCSV(csvPath); err != nil {
-		return err
+// GenerateReport generates a complete report in multiple formats.
+// Reports go into a new timestamped subdirectory of outputDir
+// (reports/2024-06-01_154233/) and outputDir/latest is updated to point at it.
+// It returns the directory the reports were written to.
+func (rg *ReportGenerator) GenerateReport(outputDir string) (string, error) {
+	return rg.GenerateReportFormats(outputDir, DefaultReportFormats)
+}
+
+// GenerateReportFormats is GenerateReport limited to the given formats
+func (rg *ReportGenerator) GenerateReportFormats(outputDir string, formats []string) (string, error) {
+	now := time.Now()
+	runDir, err := createRunDir(outputDir, now)
+	if err != nil {
+		return "", err
 	}
+	timestamp := now.Format("20060102_150405")
 
-	// Text report
-	txtPath := outputDir + "/отчёт-утечки_" + timestamp + ".txt"
-	if err := rg.ExportPlainText(txtPath); err != nil {
-		return err
+	for _, format := range formats {
+		ext := format
+		if format == FormatJUnit {
+			ext = "junit.xml" // CI systems look for *.xml
+		}
+		path := filepath.Join(runDir, rg.locale.T("report.file_name")+"_"+timestamp+"."+ext)
+		if err := rg.ExportFormat(format, path); err != nil {
+			return runDir, err
+		}
 	}
 
-	return nil
+	if err := updateLatest(outputDir, runDir); err != nil {
+		return runDir, fmt.Errorf("не удалось обновить %s: %w", LatestReportDir, err)
+	}
+
+	return runDir, nil
+}
+
+// ExportFormat writes the report in one of the formats of
+// ParseReportFormats to path
+func (rg *ReportGenerator) ExportFormat(format, path string) error {
+	exporters := map[string]func(string) error{
+		FormatJSON:  rg.ExportJSON,
+		FormatCSV:   rg.ExportCSV,
+		FormatText:  rg.ExportPlainText,
+		FormatSARIF: rg.ExportSARIF,
+		FormatJUnit: rg.ExportJUnit,
+		FormatHTML:  rg.ExportHTML,
+		FormatXLSX:  rg.ExportXLSX,
+	}
+	export, ok := exporters[format]
+	if !ok {
+		return fmt.Errorf("неизвестный формат отчёта: %q", format)
+	}
+	return export(path)
 }
 
 // severityToRussian converts severity to Russian
 func severityToRussian(s Severity) string {
-	switch s {
-	case Critical:
-		return "Критический"
-	case High:
-		return "Высокий"
-	case Medium:
-		return "Средний"
-	case Low:
-		return "Низкий"
-	default:
-		return string(s)
-	}
+	return russian.Severity(string(s))
 }
 
 // patternTypeToRussian converts pattern type to Russian
 func patternTypeToRussian(p PatternType) string {
-	translations := map[PatternType]string{
-		PatternPassword:      "Пароль",
-		PatternAPIKey:        "API-ключ",
-		PatternToken:         "Токен",
-		PatternPrivateKey:    "Приватный ключ",
-		PatternAWSKey:        "AWS ключ",
-		PatternGitHubToken:   "GitHub токен",
-		PatternEmail:         "Email",
-		PatternPhoneNumber:   "Телефон",
-		PatternSSN:           "SSN",
-		PatternCreditCard:    "Банковская карта",
-		PatternJSONSecret:    "JSON секрет",
-		PatternEnvVar:        "Переменная окружения",
-		PatternConnectionStr: "Строка подключения",
-		// Additional patterns
-		"bic":              "BIC код",
-		"iban":             "IBAN",
-		"yaml_secret":      "YAML секрет",
-		"hardcoded_secret": "Захардкоженный секрет",
-		"passport":         "Паспорт",
-	}
-
-	if ru, ok := translations[p]; ok {
-		return ru
-	}
-	return string(p)
+	return russian.Pattern(string(p))
+}
+
+// groupToRussian converts a detector group or pack name to Russian
+func groupToRussian(group string) string {
+	return russian.Group(group)
 }
 
 // descriptionToRussian converts description to Russian
 func descriptionToRussian(desc string) string {
-	translations := map[string]string{
-		"Password assignment detected":             "Обнаружено присвоение пароля",
-		"API Key detected":                         "Обнаружен API-ключ",
-		"Authentication token detected":            "Обнаружен токен аутентификации",
-		"Private key detected":                     "Обнаружен приватный ключ",
-		"AWS Access Key detected":                  "Обнаружен AWS ключ доступа",
-		"GitHub token detected":                    "Обнаружен GitHub токен",
-		"Email address detected":                   "Обнаружен email адрес",
-		"Phone number detected":                    "Обнаружен номер телефона",
-		"Social Security Number detected":          "Обнаружен SSN",
-		"Credit card number detected":              "Обнаружен номер банковской карты",
-		"JSON secret detected":                     "Обнаружен секрет в JSON",
-		"YAML secret detected":                     "Обнаружен секрет в YAML",
-		"Environment variable assignment detected": "Обнаружена переменная окружения",
-		"Connection string detected":               "Обнаружена строка подключения",
-		"Hardcoded secret detected":                "Обнаружен захардкоженный секрет",
-		"IBAN detected":                            "Обнаружен IBAN",
-		"BIC code detected":                        "Обнаружен BIC код",
-		"Passport number detected":                 "Обнаружен номер паспорта",
-	}
-	if ru, ok := translations[desc]; ok {
-		return ru
-	}
-	return desc
+	return LocalizeDescription(russian, desc)
 }
@@ -1,93 +1,207 @@
 package searcher
 
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
 // RiskScorer calculates comprehensive risk scores for findings
 type RiskScorer struct {
 	entropyCalculator *EntropyCalculator
+	weights           RiskWeights
+	root              string          // Paths are weighted relative to it, see forScan
+	tracked           map[string]bool // Files git tracks under root, nil if unknown
 }
 
-// NewRiskScorer creates a new risk scorer
+// NewRiskScorer creates a new risk scorer with the default weights
 func NewRiskScorer() *RiskScorer {
+	return NewRiskScorerWithWeights(DefaultRiskWeights())
+}
+
+// NewRiskScorerWithWeights creates a risk scorer with custom weights.
+// The weights are expected to be validated, e.g. by LoadRiskWeights
+func NewRiskScorerWithWeights(weights RiskWeights) *RiskScorer {
+	weights.Entropy = sortedTiers(weights.Entropy)
+	weights.Length = sortedTiers(weights.Length)
 	return &RiskScorer{
 		entropyCalculator: NewEntropyCalculator(),
+		weights:           weights,
 	}
 }
 
+// Weights returns the weights used by the scorer
+func (rs *RiskScorer) Weights() RiskWeights {
+	return rs.weights
+}
+
+// forScan returns a scorer weighting paths relative to the root of a scan
+// and the files git tracks there, nil if unknown
+func (rs *RiskScorer) forScan(root string, tracked map[string]bool) *RiskScorer {
+	scoped := *rs
+	scoped.root, scoped.tracked = root, tracked
+	return &scoped
+}
+
+// ForScanRoot returns a scorer weighting paths relative to root, as a scan
+// of root does, e.g. to explain the findings of its report
+func (rs *RiskScorer) ForScanRoot(root string) *RiskScorer {
+	return rs.forScan(root, rs.tracked)
+}
+
 // CalculateRiskScore calculates a composite risk score (0-100)
-// considering pattern severity, entropy, and context
+// considering pattern severity, entropy, context and file location
 func (rs *RiskScorer) CalculateRiskScore(pattern *DetectedPattern) float64 {
-	// Base score from severity (0-40 points)
-	severityScore := float64(pattern.Severity.Score()) * 10.0
+	score, _ := rs.ExplainRiskScore(pattern)
+	return score
+}
+
+// PathFactor returns the multiplier of a file's location and the rules of
+// RiskWeights.Paths that matched it
+func (rs *RiskScorer) PathFactor(filePath string) (float64, []string) {
+	if len(rs.weights.Paths) == 0 || filePath == "" {
+		return 1, nil
+	}
+	rel := rs.relativePath(filePath)
+	return rs.weights.Paths.factor(rel, rs.gitStatus(rel))
+}
+
+// relativePath returns a path relative to the scan root in slash form
+func (rs *RiskScorer) relativePath(filePath string) string {
+	if rs.root != "" {
+		if rel, err := filepath.Rel(rs.root, filePath); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			filePath = rel
+		}
+	}
+	return filepath.ToSlash(filePath)
+}
+
+// gitStatus returns the git status of a path relative to the scan root,
+// "" when the files git tracks are not known
+func (rs *RiskScorer) gitStatus(rel string) string {
+	if rs.tracked == nil {
+		return ""
+	}
+	if rs.tracked[rel] {
+		return GitStatusTracked
+	}
+	return GitStatusUntracked
+}
 
-	// Entropy bonus (0-30 points)
-	entropyScore := rs.calculateEntropyBonus(pattern.MatchText)
+// ExplainFindingScore recomputes the risk score of a finding loaded from a
+// report and explains it. The result differs from the stored score when the
+// report was produced with other weights. The git status is the one the
+// scan recorded, see ForScanRoot for the path
+func (rs *RiskScorer) ExplainFindingScore(finding *Finding) (float64, []TraceStep) {
+	if finding.GitStatus != "" {
+		rel := rs.relativePath(finding.FilePath)
+		rs = rs.forScan(rs.root, map[string]bool{rel: finding.GitStatus == GitStatusTracked})
+	}
+	return rs.ExplainRiskScore(&DetectedPattern{
+		FilePath:  finding.FilePath,
+		Type:      finding.PatternType,
+		Severity:  finding.Severity,
+		MatchText: finding.MatchedText,
+		Context:   finding.Context,
+	})
+}
 
-	// Pattern length bonus (0-20 points) - longer matches are more suspicious
-	lengthScore := rs.calculateLengthBonus(pattern.MatchText)
+// ExplainRiskScore calculates the risk score and returns the contribution
+// of every factor in the order they were applied
+func (rs *RiskScorer) ExplainRiskScore(pattern *DetectedPattern) (float64, []TraceStep) {
+	score, _, steps := rs.scoreSteps(pattern)
+	return score, steps
+}
 
-	// Additional context clues (0-10 points)
-	contextScore := rs.calculateContextBonus(pattern)
+// scoreSteps calculates the risk score, the score before the path factor
+// and the contribution of every factor
+func (rs *RiskScorer) scoreSteps(pattern *DetectedPattern) (score, base float64, steps []TraceStep) {
+	steps = make([]TraceStep, 0, 6)
+	multipliers := rs.weights.Multipliers
+
+	// Base score from severity (0-40 points by default)
+	severityScore := rs.weights.Severity[pattern.Severity] * multipliers.Severity
+	steps = append(steps, newTraceStep(StageSeverity, severityScore, pattern.Severity,
+		"trace.severity", pattern.Severity, multiplierNote(multipliers.Severity)))
+
+	// Entropy bonus (0-30 points by default)
+	entropy := rs.entropyCalculator.CalculateEntropy(pattern.MatchText)
+	entropyScore := tierPoints(rs.weights.Entropy, entropy) * multipliers.Entropy
+	steps = append(steps, newTraceStep(StageEntropy, entropyScore, pattern.Severity,
+		"trace.entropy", entropy, multiplierNote(multipliers.Entropy)))
+
+	// Pattern length bonus (0-20 points by default) - longer matches are more suspicious
+	lengthScore := rs.calculateLengthBonus(pattern.MatchText) * multipliers.Length
+	steps = append(steps, newTraceStep(StageLength, lengthScore, pattern.Severity,
+		"trace.length", len(pattern.MatchText), multiplierNote(multipliers.Length)))
+
+	// Additional context clues (0-10 points by default)
+	contextScore := rs.calculateContextBonus(pattern) * multipliers.Context
+	steps = append(steps, newTraceStep(StageContext, contextScore, pattern.Severity,
+		"trace.context", multiplierNote(multipliers.Context)))
 
 	totalScore := severityScore + entropyScore + lengthScore + contextScore
 
+	// Points the rule added after inspecting the match, e.g. admin claims of a JWT
+	if pattern.RiskBonus > 0 {
+		steps = append(steps, newTraceStep(StageRule, pattern.RiskBonus, pattern.Severity, pattern.BonusReason, pattern.BonusArgs...))
+		totalScore += pattern.RiskBonus
+	}
+
 	// Cap at 100
 	if totalScore > 100 {
+		steps = append(steps, newTraceStep(StageCap, 100-totalScore, pattern.Severity, "trace.cap"))
 		totalScore = 100
 	}
+	base = totalScore
 
-	return totalScore
+	// Location of the file, e.g. tests lower the score and prod.env raises it
+	if factor, rules := rs.PathFactor(pattern.FilePath); factor != 1 {
+		adjusted := totalScore * factor
+		if adjusted > 100 {
+			adjusted = 100
+		}
+		steps = append(steps, newTraceStep(StagePath, adjusted-totalScore, pattern.Severity,
+			"trace.path", factor, strings.Join(rules, ", ")))
+		totalScore = adjusted
+	}
+
+	return totalScore, base, steps
 }
 
-// calculateEntropyBonus returns bonus points (0-30) based on entropy
-func (rs *RiskScorer) calculateEntropyBonus(matchText string) float64 {
-	entropy := rs.entropyCalculator.CalculateEntropy(matchText)
-
-	if entropy >= 5.5 {
-		return 30.0
-	} else if entropy >= 4.5 {
-		return 20.0
-	} else if entropy >= 3.5 {
-		return 10.0
+// multiplierNote describes a non-default factor multiplier in a trace step
+func multiplierNote(multiplier float64) string {
+	if multiplier == 1 {
+		return ""
 	}
-	return 0.0
+	return fmt.Sprintf(" (×%g)", multiplier)
+}
+
+// calculateEntropyBonus returns bonus points based on entropy, before the multiplier
+func (rs *RiskScorer) calculateEntropyBonus(matchText string) float64 {
+	return tierPoints(rs.weights.Entropy, rs.entropyCalculator.CalculateEntropy(matchText))
 }
 
-// calculateLengthBonus returns bonus points (0-20) based on match length
+// calculateLengthBonus returns bonus points based on match length, before the multiplier.
 // Longer secrets are generally more complex and suspicious
 func (rs *RiskScorer) calculateLengthBonus(matchText string) float64 {
-	length := len(matchText)
-
-	if length >= 64 {
-		return 20.0
-	} else if length >= 32 {
-		return 15.0
-	} else if length >= 16 {
-		return 10.0
-	} else if length >= 8 {
-		return 5.0
-	}
-	return 0.0
+	return tierPoints(rs.weights.Length, float64(len(matchText)))
 }
 
-// calculateContextBonus returns bonus points (0-10) based on context
+// calculateContextBonus returns bonus points based on context, before the multiplier
 func (rs *RiskScorer) calculateContextBonus(pattern *DetectedPattern) float64 {
 	score := 0.0
 
 	// Check for keywords indicating higher risk
-	lowerContext := pattern.Context
-	riskKeywords := []string{
-		"password", "secret", "token", "key", "private",
-		"credential", "auth", "api", "access", "aws",
-	}
-
-	for _, keyword := range riskKeywords {
-		if contains(lowerContext, keyword) {
-			score += 2.0
+	for _, keyword := range rs.weights.Context.Keywords {
+		if contains(pattern.Context, keyword) {
+			score += rs.weights.Context.PerKeyword
 		}
 	}
 
-	// Cap at 10
-	if score > 10.0 {
-		score = 10.0
+	if score > rs.weights.Context.Max {
+		score = rs.weights.Context.Max
 	}
 
 	return score
@@ -111,10 +225,14 @@ func contains(text, substr string) bool {
 	return stringContains(lower, toLower(substr))
 }
 
-// toLower converts a string to lowercase
+// toLower converts a string to lowercase, Cyrillic and other non-ASCII
+// letters included
 func toLower(s string) string {
 	result := make([]byte, len(s))
 	for i, c := range []byte(s) {
+		if c >= utf8.RuneSelf {
+			return strings.ToLower(s)
+		}
 		if c >= 'A' && c <= 'Z' {
 			result[i] = c + 32
 		} else {
@@ -212,6 +212,7 @@ func TestToLower(t *testing.T) {
 		{"123ABC", "123abc"},
 		{"already_lower", "already_lower"},
 		{"MiXeD_CaSe", "mixed_case"},
+		{"ПАРОЛЬ=Secret", "пароль=secret"},
 	}
 
 	for _, test := range tests {
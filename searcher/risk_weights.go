@@ -0,0 +1,207 @@
+package searcher
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// RiskTier awards Points to a match whose measured value is at least Min
+type RiskTier struct {
+	Min    float64 `json:"min"`
+	Points float64 `json:"points"`
+}
+
+// RiskMultipliers scale the contribution of each scoring factor
+type RiskMultipliers struct {
+	Severity float64 `json:"severity"`
+	Entropy  float64 `json:"entropy"`
+	Length   float64 `json:"length"`
+	Context  float64 `json:"context"`
+}
+
+// ContextWeights configures the bonus for risk keywords on the matched line
+type ContextWeights struct {
+	Keywords   []string `json:"keywords"`
+	PerKeyword float64  `json:"per_keyword"`
+	Max        float64  `json:"max"`
+}
+
+// RiskWeights are the tunable parameters of RiskScorer. They are read from
+// the "risk_weights" section of a rules or config file; omitted fields keep
+// their default values
+type RiskWeights struct {
+	Severity     map[Severity]float64 `json:"severity"` // Base points per severity
+	Multipliers  RiskMultipliers      `json:"multipliers"`
+	Entropy      []RiskTier           `json:"entropy"` // Min is Shannon entropy in bits
+	Length       []RiskTier           `json:"length"`  // Min is the match length in bytes
+	Context      ContextWeights       `json:"context"`
+	Paths        PathWeights          `json:"paths"`         // Multipliers by file location, see PathWeights
+	PathSeverity bool                 `json:"path_severity"` // Raise the severity when the path lifts the score a band
+}
+
+// Allowed ranges of the weights
+const (
+	maxRiskPoints     = 100.0
+	maxRiskMultiplier = 10.0
+	maxEntropyBits    = 8.0
+	maxLengthTier     = 4096.0
+)
+
+// DefaultRiskWeights returns the weights the scorer has always used
+func DefaultRiskWeights() RiskWeights {
+	return RiskWeights{
+		Severity: map[Severity]float64{
+			Critical: 40,
+			High:     30,
+			Medium:   20,
+			Low:      10,
+		},
+		Multipliers: RiskMultipliers{Severity: 1, Entropy: 1, Length: 1, Context: 1},
+		Entropy: []RiskTier{
+			{Min: 5.5, Points: 30},
+			{Min: 4.5, Points: 20},
+			{Min: 3.5, Points: 10},
+		},
+		Length: []RiskTier{
+			{Min: 64, Points: 20},
+			{Min: 32, Points: 15},
+			{Min: 16, Points: 10},
+			{Min: 8, Points: 5},
+		},
+		Context: ContextWeights{
+			Keywords: []string{
+				"password", "secret", "token", "key", "private",
+				"credential", "auth", "api", "access", "aws",
+				"пароль", "секрет", "токен", "ключ",
+			},
+			PerKeyword: 2,
+			Max:        10,
+		},
+		Paths: DefaultPathWeights(),
+	}
+}
+
+// riskWeightsFile is the part of a rules or config file holding the weights
+type riskWeightsFile struct {
+	RiskWeights json.RawMessage `json:"risk_weights"`
+}
+
+// LoadRiskWeights reads the "risk_weights" section of a JSON or YAML file
+// over the defaults and validates the result
+func LoadRiskWeights(path string) (RiskWeights, error) {
+	weights := DefaultRiskWeights()
+
+	var file riskWeightsFile
+	if err := DecodeConfigFile(path, &file); err != nil {
+		return weights, err
+	}
+	if len(file.RiskWeights) == 0 || string(file.RiskWeights) == "null" {
+		return weights, fmt.Errorf("%s: нет раздела risk_weights", path)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(file.RiskWeights))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&weights); err != nil {
+		return weights, fmt.Errorf("%s: risk_weights: %v", path, err)
+	}
+	if err := weights.Validate(); err != nil {
+		return weights, fmt.Errorf("%s: %v", path, err)
+	}
+	return weights, nil
+}
+
+// Validate checks that every weight is within its allowed range
+func (w RiskWeights) Validate() error {
+	severities := make([]string, 0, len(w.Severity))
+	for severity := range w.Severity {
+		severities = append(severities, string(severity))
+	}
+	sort.Strings(severities)
+	for _, name := range severities {
+		severity, points := Severity(name), w.Severity[Severity(name)]
+		if parsed, err := ParseSeverity(string(severity)); err != nil || parsed != severity {
+			return fmt.Errorf("risk_weights.severity.%s: неизвестная серьёзность (допустимо: critical, high, medium, low)", severity)
+		}
+		if err := checkRange(fmt.Sprintf("risk_weights.severity.%s", severity), points, 0, maxRiskPoints); err != nil {
+			return err
+		}
+	}
+
+	multipliers := []struct {
+		name  string
+		value float64
+	}{
+		{"severity", w.Multipliers.Severity},
+		{"entropy", w.Multipliers.Entropy},
+		{"length", w.Multipliers.Length},
+		{"context", w.Multipliers.Context},
+	}
+	for _, m := range multipliers {
+		if err := checkRange("risk_weights.multipliers."+m.name, m.value, 0, maxRiskMultiplier); err != nil {
+			return err
+		}
+	}
+
+	if err := checkTiers("risk_weights.entropy", w.Entropy, maxEntropyBits); err != nil {
+		return err
+	}
+	if err := checkTiers("risk_weights.length", w.Length, maxLengthTier); err != nil {
+		return err
+	}
+
+	for i, keyword := range w.Context.Keywords {
+		if strings.TrimSpace(keyword) == "" {
+			return fmt.Errorf("risk_weights.context.keywords[%d]: пустое ключевое слово", i)
+		}
+	}
+	if err := checkRange("risk_weights.context.per_keyword", w.Context.PerKeyword, 0, maxRiskPoints); err != nil {
+		return err
+	}
+	if err := checkRange("risk_weights.context.max", w.Context.Max, 0, maxRiskPoints); err != nil {
+		return err
+	}
+	return w.Paths.Validate()
+}
+
+// checkTiers validates the thresholds and points of a tier list
+func checkTiers(name string, tiers []RiskTier, maxMin float64) error {
+	for i, tier := range tiers {
+		if err := checkRange(fmt.Sprintf("%s[%d].min", name, i), tier.Min, 0, maxMin); err != nil {
+			return err
+		}
+		if err := checkRange(fmt.Sprintf("%s[%d].points", name, i), tier.Points, 0, maxRiskPoints); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkRange reports a value outside [min, max] together with its key
+func checkRange(name string, value, min, max float64) error {
+	if value < min || value > max {
+		return fmt.Errorf("%s = %g: допустимо от %g до %g", name, value, min, max)
+	}
+	return nil
+}
+
+// sortedTiers returns a copy of tiers ordered from the highest threshold down
+func sortedTiers(tiers []RiskTier) []RiskTier {
+	sorted := append([]RiskTier(nil), tiers...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Min > sorted[j].Min
+	})
+	return sorted
+}
+
+// tierPoints returns the points of the first tier whose threshold value reaches
+func tierPoints(tiers []RiskTier, value float64) float64 {
+	for _, tier := range tiers {
+		if value >= tier.Min {
+			return tier.Points
+		}
+	}
+	return 0
+}
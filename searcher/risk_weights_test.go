@@ -0,0 +1,173 @@
+package searcher
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// riskCorpusExtensions limits the regression corpus to text fixtures
+var riskCorpusExtensions = map[string]bool{
+	".txt": true, ".env": true, ".json": true, ".yaml": true,
+	".csv": true, ".pem": true, ".xml": true, ".sta": true,
+}
+
+// riskScoreCorpus scores every line of the text fixtures and lists each match
+func riskScoreCorpus(t *testing.T, scanner *Scanner) string {
+	t.Helper()
+	var paths []string
+	for _, dir := range []string{"../testdata", "testdata/finance", "testdata/packs"} {
+		err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !info.IsDir() && riskCorpusExtensions[filepath.Ext(path)] {
+				paths = append(paths, path)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	sort.Strings(paths)
+
+	var sb strings.Builder
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, line := range strings.Split(string(data), "\n") {
+			for _, match := range scanner.Evaluate(line, path).Matches {
+				fmt.Fprintf(&sb, "%s:%d %s %.4f\n", filepath.ToSlash(path), i+1, match.RuleName, match.RiskScore)
+			}
+		}
+	}
+	return sb.String()
+}
+
+// TestDefaultRiskWeightsRegression checks that the default weights keep the
+// scores of the fixture corpus unchanged
+func TestDefaultRiskWeightsRegression(t *testing.T) {
+	scanner := NewScanner()
+	scanner.SetRiskWeights(DefaultRiskWeights())
+	checkGolden(t, "risk_scores.golden", riskScoreCorpus(t, scanner))
+}
+
+// TestLoadRiskWeights tests that a partial section overrides only the given weights
+func TestLoadRiskWeights(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	content := `rules:
+  - name: ticket
+    regex: 'TCK-[0-9]+'
+risk_weights:
+  severity:
+    critical: 50
+  multipliers:
+    entropy: 1.5
+  entropy:
+    - min: 3
+      points: 5
+    - min: 5.5
+      points: 30
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	weights, err := LoadRiskWeights(path)
+	if err != nil {
+		t.Fatalf("LoadRiskWeights: %v", err)
+	}
+	if weights.Severity[Critical] != 50 || weights.Severity[High] != 30 {
+		t.Errorf("severity = %v, want critical overridden and high kept", weights.Severity)
+	}
+	if weights.Multipliers.Entropy != 1.5 || weights.Multipliers.Length != 1 {
+		t.Errorf("multipliers = %+v", weights.Multipliers)
+	}
+	if len(weights.Length) != len(DefaultRiskWeights().Length) {
+		t.Errorf("length tiers = %v, want defaults", weights.Length)
+	}
+
+	// Tiers are matched from the highest threshold regardless of file order
+	scorer := NewRiskScorerWithWeights(weights)
+	pattern := &DetectedPattern{Severity: Critical, MatchText: "aB3$xY9!qW2@eR5#tZ8%", Context: "none"}
+	score, steps := scorer.ExplainRiskScore(pattern)
+	if steps[0].Points != 50 {
+		t.Errorf("severity points = %v, want 50", steps[0].Points)
+	}
+	if steps[1].Points != 7.5 || !strings.Contains(steps[1].Detail, "×1.5") {
+		t.Errorf("entropy step = %+v, want 7.5 points with the multiplier noted", steps[1])
+	}
+	if score != 50+7.5+10 {
+		t.Errorf("score = %v, want 67.5", score)
+	}
+}
+
+// TestLoadRiskWeightsErrors tests that invalid files name the offending key
+func TestLoadRiskWeightsErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"missing section", `{"rules": []}`, "нет раздела risk_weights"},
+		{"entropy out of range", `{"risk_weights": {"entropy": [{"min": 4, "points": 10}, {"min": 9.5, "points": 5}]}}`, "risk_weights.entropy[1].min = 9.5: допустимо от 0 до 8"},
+		{"negative multiplier", `{"risk_weights": {"multipliers": {"context": -1}}}`, "risk_weights.multipliers.context = -1"},
+		{"severity points", `{"risk_weights": {"severity": {"high": 150}}}`, "risk_weights.severity.high = 150: допустимо от 0 до 100"},
+		{"unknown severity", `{"risk_weights": {"severity": {"urgent": 50}}}`, "risk_weights.severity.urgent: неизвестная серьёзность"},
+		{"empty keyword", `{"risk_weights": {"context": {"keywords": ["token", " "]}}}`, "risk_weights.context.keywords[1]"},
+		{"unknown field", `{"risk_weights": {"entropi": []}}`, `unknown field "entropi"`},
+		{"wrong type", `{"risk_weights": {"length": [{"min": "long"}]}}`, "risk_weights"},
+	}
+
+	dir := t.TempDir()
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, fmt.Sprintf("weights%d.json", i))
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := LoadRiskWeights(path)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if !strings.Contains(err.Error(), tt.want) || !strings.Contains(err.Error(), path) {
+				t.Errorf("error = %q, want it to mention %q and the file", err, tt.want)
+			}
+		})
+	}
+}
+
+// TestExplainFindingScore tests that a reported finding is rescored identically
+func TestExplainFindingScore(t *testing.T) {
+	scanner := NewScanner()
+	trace := scanner.Evaluate(`db_password = "Xk9#mP2$vL5@nQ8!rT4"`, "config.env")
+	if len(trace.Matches) == 0 {
+		t.Fatal("no matches")
+	}
+	match := trace.Matches[0]
+	finding := &Finding{
+		FilePath:    "config.env",
+		PatternType: match.Type,
+		Severity:    match.FinalSeverity,
+		MatchedText: match.MatchText,
+		Context:     trace.Line,
+	}
+
+	score, steps := NewRiskScorer().ExplainFindingScore(finding)
+	if score != match.RiskScore {
+		t.Errorf("score = %v, want %v", score, match.RiskScore)
+	}
+	total := 0.0
+	for _, step := range steps {
+		total += step.Points
+	}
+	if total != score {
+		t.Errorf("steps add up to %v, want %v", total, score)
+	}
+}
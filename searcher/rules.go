@@ -0,0 +1,171 @@
+package searcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// RuleDefinition describes a user-defined detection rule as stored in a rules file
+type RuleDefinition struct {
+	Name        string  `json:"name"`
+	Regex       string  `json:"regex"`
+	Type        string  `json:"type,omitempty"`
+	Severity    string  `json:"severity,omitempty"`
+	Description string  `json:"description,omitempty"`
+	Validate    string  `json:"validate,omitempty"`    // Named check of the match, e.g. "luhn" or "oms"
+	Near        string  `json:"near,omitempty"`        // Regex that must appear close to the match
+	Within      int     `json:"within,omitempty"`      // Lines around the match searched for Near
+	MinEntropy  float64 `json:"min_entropy,omitempty"` // Matches with a lower Shannon entropy are ignored
+}
+
+// ruleFile is the on-disk layout of a rules file: either a bare list of
+// rules or an object with a "rules" key
+type ruleFile struct {
+	Rules          []RuleDefinition `json:"rules"`
+	EmailAllowlist []string         `json:"email_domain_allowlist,omitempty"` // See Scanner.SetEmailDomainAllowlist
+}
+
+// RuleConfig is the content of a rules file
+type RuleConfig struct {
+	Patterns             []*Pattern
+	EmailDomainAllowlist []string // Normalized domains whose addresses are lowered to Low
+}
+
+// Compile validates the definition and turns it into a Pattern
+func (rd RuleDefinition) Compile() (*Pattern, error) {
+	name := strings.TrimSpace(rd.Name)
+	if name == "" {
+		return nil, fmt.Errorf("у правила не указано имя (regex: %q)", rd.Regex)
+	}
+	if rd.Regex == "" {
+		return nil, fmt.Errorf("правило %q: не указано регулярное выражение", name)
+	}
+
+	regex, err := regexp.Compile(rd.Regex)
+	if err != nil {
+		return nil, fmt.Errorf("правило %q: некорректное регулярное выражение: %v", name, err)
+	}
+
+	severity := Medium
+	if rd.Severity != "" {
+		severity, err = ParseSeverity(rd.Severity)
+		if err != nil {
+			return nil, fmt.Errorf("правило %q: %v", name, err)
+		}
+	}
+
+	patternType := PatternCustom
+	if rd.Type != "" {
+		patternType = PatternType(strings.ToLower(strings.TrimSpace(rd.Type)))
+	}
+
+	description := rd.Description
+	if description == "" {
+		description = "Custom rule " + name + " matched"
+	}
+
+	pattern := &Pattern{
+		Name:        name,
+		Type:        patternType,
+		Regex:       regex,
+		Severity:    severity,
+		Description: description,
+	}
+
+	if rd.Validate != "" {
+		validate, ok := ruleValidators[strings.ToLower(rd.Validate)]
+		if !ok {
+			return nil, fmt.Errorf("правило %q: неизвестный валидатор %q (доступны: %s)", name, rd.Validate, strings.Join(ValidatorNames(), ", "))
+		}
+		pattern.Validate = validate
+		pattern.ValidateName = strings.ToLower(rd.Validate)
+	}
+
+	if rd.MinEntropy < 0 {
+		return nil, fmt.Errorf("правило %q: min_entropy не может быть отрицательным", name)
+	}
+	pattern.MinEntropy = rd.MinEntropy
+
+	if rd.Within < 0 {
+		return nil, fmt.Errorf("правило %q: within не может быть отрицательным", name)
+	}
+	if rd.Near != "" {
+		near, err := regexp.Compile(rd.Near)
+		if err != nil {
+			return nil, fmt.Errorf("правило %q: некорректное выражение near: %v", name, err)
+		}
+		pattern.Near = near
+		pattern.NearLines = rd.Within
+	}
+
+	return pattern, nil
+}
+
+// LoadRuleFile reads rule definitions from a JSON or YAML file and compiles them
+func LoadRuleFile(path string) ([]*Pattern, error) {
+	config, err := LoadRuleConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	return config.Patterns, nil
+}
+
+// LoadRuleConfig reads a JSON or YAML rules file: its rules, compiled, and
+// its allowlisted email domains
+func LoadRuleConfig(path string) (*RuleConfig, error) {
+	var raw json.RawMessage
+	if err := DecodeConfigFile(path, &raw); err != nil {
+		return nil, err
+	}
+
+	var file ruleFile
+	trimmed := strings.TrimSpace(string(raw))
+	if strings.HasPrefix(trimmed, "[") {
+		if err := json.Unmarshal(raw, &file.Rules); err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+	} else if err := json.Unmarshal(raw, &file); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+
+	config := &RuleConfig{
+		Patterns:             make([]*Pattern, 0, len(file.Rules)),
+		EmailDomainAllowlist: normalizeDomains(file.EmailAllowlist),
+	}
+	for _, def := range file.Rules {
+		pattern, err := def.Compile()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		config.Patterns = append(config.Patterns, pattern)
+	}
+	return config, nil
+}
+
+// CustomPatternsFile is loaded from the scan root, if present, in addition
+// to the patterns given with -patterns
+const CustomPatternsFile = ".dataleak-patterns.yaml"
+
+// loadRootPatterns replaces the patterns loaded from a previous scan root
+// with those of CustomPatternsFile in rootDir and returns them with the
+// allowlisted email domains of the file. A missing file is not an error
+func loadRootPatterns(patterns *Patterns, rootDir string, previous []*Pattern) ([]*Pattern, []string, error) {
+	patterns.remove(previous)
+
+	path := filepath.Join(rootDir, CustomPatternsFile)
+	if _, err := os.Stat(path); err != nil {
+		return nil, nil, nil
+	}
+	config, err := LoadRuleConfig(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, pattern := range config.Patterns {
+		patterns.Add(pattern)
+	}
+	return config.Patterns, config.EmailDomainAllowlist, nil
+}
@@ -0,0 +1,103 @@
+package searcher
+
+import (
+	"strings"
+)
+
+// Credentials in Russian-language projects are written with Russian
+// keywords, and their personal data has its own identifiers: passports,
+// СНИЛС and ИНН. Go's (?i) folds Cyrillic case, so "ПАРОЛЬ" matches too;
+// \b only knows ASCII word characters and is not used next to Cyrillic.
+// СНИЛС and ИНН carry check digits, which keep random numbers out.
+// A (?i) Cyrillic keyword gives the regexp engine no literal prefix to look
+// for, so every byte of a line goes through the matcher; the keywords of
+// these patterns skip them on lines without the word
+
+// addRussianPatterns registers the Russian keyword and identifier patterns
+func addRussianPatterns(p *Patterns) {
+	// Credentials
+	p.addProviderPattern("password_ru", PatternPassword, `(?i)(пароль\s*[=:]\s*['"]?[^\s'";]+['"]?)`, Critical, "Password assignment detected", nil)
+	p.setKeywords("пароль")
+	p.addProviderPattern("secret_ru", PatternHardcodedSecret, `(?i)(секрет\s*[=:]\s*['"]?[^\s'";]{8,}['"]?)`, Critical, "Hardcoded secret detected", nil)
+	p.setKeywords("секрет")
+	p.addProviderPattern("login_ru", PatternLogin, `(?i)(логин\s*[=:]\s*['"]?[^\s'";]+['"]?)`, Medium, "Login detected", nil)
+	p.setKeywords("логин")
+
+	// +7 (999) 123-45-67, 8 999 123 45 67, +79991234567
+	p.addProviderPattern("phone_number_ru", PatternPhoneNumber, `(?:\+7|\b8)[\s-]?\(?\d{3}\)?[\s-]?\d{3}[\s-]?\d{2}[\s-]?\d{2}\b`, Medium, "Phone number detected", validRuPhone)
+
+	// "Паспорт: серия 45 10 № 123456", "серия 4510 номер 123456"
+	p.addProviderPattern("ru_passport", PatternRuPassport, `(?i)(?:паспорт[а-яё]*|серия)[^\d\n]{0,30}\d{2}\s?\d{2}[^\d\n]{0,15}\d{6}\b`, Critical, "Russian passport series and number detected",
+		func(match string) bool { return ValidRuPassport(matchDigits(match)) })
+	p.setKeywords("паспорт", "серия")
+	p.addProviderPattern("snils", PatternSNILS, `(?i)(?:\b\d{3}-\d{3}-\d{3}[ -]\d{2}\b|снилс[^\d\n]{0,10}\d{11}\b)`, High, "SNILS detected",
+		func(match string) bool { return ValidSNILS(matchDigits(match)) })
+	// Without the keyword СНИЛС is written with dashes
+	p.setKeywords("-", "снилс")
+	p.addProviderPattern("inn", PatternINN, `(?i)инн[^\d\n]{0,10}(?:\d{12}|\d{10})\b`, High, "INN detected",
+		func(match string) bool { return ValidINN(matchDigits(match)) })
+	p.setKeywords("инн")
+	p.patterns[len(p.patterns)-1].Refine = refineINN
+}
+
+// matchDigits returns the digits of a match, keywords and separators left out
+func matchDigits(match string) string {
+	var sb strings.Builder
+	for _, c := range match {
+		if c >= '0' && c <= '9' {
+			sb.WriteRune(c)
+		}
+	}
+	return sb.String()
+}
+
+// validRuPhone checks the code after +7 or 8: Russian geographic codes
+// start with 3, 4 or 8 and mobile ones with 9
+func validRuPhone(match string) bool {
+	digits := matchDigits(match)
+	return len(digits) == 11 && strings.IndexByte("3489", digits[1]) >= 0
+}
+
+// ValidRuPassport checks a Russian passport series and number, 10 digits.
+// The series starts with the region code, 01 or more, and no number is 000000
+func ValidRuPassport(number string) bool {
+	digits := digitsOnly(number)
+	return len(digits) == 10 && digits[:2] != "00" && digits[4:] != "000000"
+}
+
+// innWeights are the check digit weights of ИНН, the last 11 of them for
+// the 11th digit of a personal ИНН and for the 10th of an organisation's
+var innWeights = []int{3, 7, 2, 4, 10, 3, 5, 9, 4, 6, 8}
+
+// innCheckDigit computes the check digit of the digits before it
+func innCheckDigit(digits string) byte {
+	weights := innWeights[len(innWeights)-len(digits):]
+	sum := 0
+	for i := range digits {
+		sum += int(digits[i]-'0') * weights[i]
+	}
+	return byte('0' + sum%11%10)
+}
+
+// ValidINN checks the check digits of an ИНН: one for the 10 digits of an
+// organisation, two for the 12 digits of a person
+func ValidINN(number string) bool {
+	digits := digitsOnly(number)
+	switch len(digits) {
+	case 10:
+		return digits[9] == innCheckDigit(digits[:9])
+	case 12:
+		return digits[10] == innCheckDigit(digits[:10]) && digits[11] == innCheckDigit(digits[:11])
+	}
+	return false
+}
+
+// refineINN lowers organisation ИНН, which are published in the state
+// register, to Low; a person's ИНН is personal data
+func refineINN(d *DetectedPattern) bool {
+	if len(matchDigits(d.MatchText)) == 10 && d.Severity != Low {
+		d.Severity = Low
+		d.Description = "Organisation INN detected"
+	}
+	return true
+}
@@ -0,0 +1,150 @@
+package searcher
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// TestRussianValidators tests the ИНН, СНИЛС and passport checks with
+// published valid numbers and the same numbers with a wrong check digit
+func TestRussianValidators(t *testing.T) {
+	tests := []struct {
+		name     string
+		validate func(string) bool
+		number   string
+		want     bool
+	}{
+		{"inn org", ValidINN, "7707083893", true},
+		{"inn org", ValidINN, "7830002293", true},
+		{"inn org bad check", ValidINN, "7707083894", false},
+		{"inn person", ValidINN, "500100732259", true},
+		{"inn person", ValidINN, "526317984689", true},
+		{"inn person bad 11th", ValidINN, "500100732269", false},
+		{"inn person bad 12th", ValidINN, "500100732258", false},
+		{"inn sequence", ValidINN, "123456789012", false},
+		{"inn length", ValidINN, "77070838931", false},
+		{"inn letters", ValidINN, "77070838AB", false},
+		{"snils", ValidSNILS, "112-233-445 95", true},
+		{"snils", ValidSNILS, "123-456-789 64", true},
+		{"snils bad check", ValidSNILS, "123-456-789 65", false},
+		{"snils length", ValidSNILS, "123-456-789 6", false},
+		{"passport", ValidRuPassport, "4510 123456", true},
+		{"passport no region", ValidRuPassport, "0010 123456", false},
+		{"passport zero number", ValidRuPassport, "4510 000000", false},
+		{"passport length", ValidRuPassport, "4510 12345", false},
+	}
+	for _, tt := range tests {
+		if got := tt.validate(tt.number); got != tt.want {
+			t.Errorf("%s: %q = %v, want %v", tt.name, tt.number, got, tt.want)
+		}
+	}
+}
+
+// TestRussianPatterns tests the rules on lines as they are written in
+// Russian documents and configs
+func TestRussianPatterns(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string // rule:severity:match
+	}{
+		{`пароль=Qw3rty!9`, []string{"password_ru:critical:пароль=Qw3rty!9"}},
+		{`ПАРОЛЬ: "Zx8cVb6N"`, []string{`password_ru:critical:ПАРОЛЬ: "Zx8cVb6N"`}},
+		{`Логин: admin`, []string{"login_ru:medium:Логин: admin"}},
+		{`секрет = 9f8e7d6c5b4a`, []string{"secret_ru:critical:секрет = 9f8e7d6c5b4a"}},
+		{`секрет: abc`, nil},
+		{`Тел.: +7 (999) 123-45-67`, []string{"phone_number_ru:medium:+7 (999) 123-45-67"}},
+		{`звоните 8 800 555-35-35`, []string{"phone_number_ru:medium:8 800 555-35-35"}},
+		{`+79991234567`, []string{"phone_number_ru:medium:+79991234567"}},
+		{`код +7 (123) 456-78-90`, nil},
+		{`Паспорт: серия 45 10 № 123456`, []string{"ru_passport:critical:Паспорт: серия 45 10 № 123456"}},
+		{`серия 4510 номер 123456`, []string{"ru_passport:critical:серия 4510 номер 123456"}},
+		{`серия 0010 номер 123456`, nil},
+		{`СНИЛС 112-233-445 95`, []string{"snils:high:112-233-445 95"}},
+		{`снилс: 11223344595`, []string{"snils:high:снилс: 11223344595"}},
+		{`СНИЛС 112-233-445 96`, nil},
+		{`ИНН 500100732259`, []string{"inn:high:ИНН 500100732259"}},
+		{`ИНН/КПП: 7707083893/773601001`, []string{"inn:low:ИНН/КПП: 7707083893"}},
+		{`ИНН: 123456789012`, nil},
+	}
+
+	patterns := NewPatternsFrom(nil)
+	addRussianPatterns(patterns)
+	for _, tt := range tests {
+		var got []string
+		for _, m := range patterns.FindAll(tt.line) {
+			got = append(got, fmt.Sprintf("%s:%s:%s", m.RuleName, m.Severity, m.MatchText))
+		}
+		sort.Strings(got)
+		if strings.Join(got, " | ") != strings.Join(tt.want, " | ") {
+			t.Errorf("%q: matches = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+// TestPhoneNumberTail tests the US phone rule leaves Russian numbers to
+// their own rule instead of matching their last ten digits
+func TestPhoneNumberTail(t *testing.T) {
+	for _, line := range []string{"+79991234567", "89991234567", "+7 (999) 123-45-67"} {
+		var rules []string
+		for _, m := range NewPatterns().FindAll(line) {
+			rules = append(rules, m.RuleName)
+		}
+		if strings.Join(rules, " ") != "phone_number_ru" {
+			t.Errorf("%q: rules = %v, want phone_number_ru", line, rules)
+		}
+	}
+	if len(NewPatterns().FindAll("+1 (555) 123-4567")) != 1 {
+		t.Error("a US number is no longer reported")
+	}
+}
+
+// TestRussianDescriptions tests the new rules are translated in reports
+func TestRussianDescriptions(t *testing.T) {
+	for _, p := range NewPatterns().List() {
+		if strings.HasSuffix(p.Name, "_ru") || p.Type == PatternRuPassport || p.Type == PatternINN {
+			if descriptionToRussian(p.Description) == p.Description {
+				t.Errorf("%s: no translation for %q", p.Name, p.Description)
+			}
+			if patternTypeToRussian(p.Type) == string(p.Type) {
+				t.Errorf("%s: no translation for type %s", p.Name, p.Type)
+			}
+		}
+	}
+	if descriptionToRussian("Organisation INN detected") != "Обнаружен ИНН организации" {
+		t.Error("organisation ИНН description is not translated")
+	}
+}
+
+// TestRiskScoreCyrillicKeywords tests Russian context words raise the score
+// whatever their case
+func TestRiskScoreCyrillicKeywords(t *testing.T) {
+	scorer := NewRiskScorer()
+	plain := &DetectedPattern{Severity: High, MatchText: "Qw3rty!9", Context: "значение Qw3rty!9"}
+	keyword := &DetectedPattern{Severity: High, MatchText: "Qw3rty!9", Context: "ПАРОЛЬ Qw3rty!9"}
+	if scorer.CalculateRiskScore(keyword) <= scorer.CalculateRiskScore(plain) {
+		t.Errorf("ПАРОЛЬ in the context did not raise the score")
+	}
+}
+
+// TestMergeContentFindings tests a pack finding replaces the core finding
+// of the same type and text
+func TestMergeContentFindings(t *testing.T) {
+	line := []*Finding{
+		{LineNumber: 2, PatternType: PatternSNILS, ByteStart: 10, ByteEnd: 24},
+		{LineNumber: 2, PatternType: PatternEmail, ByteStart: 30, ByteEnd: 45},
+		{LineNumber: 3, PatternType: PatternSNILS, ByteStart: 10, ByteEnd: 24},
+	}
+	pack := []*Finding{{LineNumber: 2, PatternType: PatternSNILS, ByteStart: 10, ByteEnd: 24, Group: "hr"}}
+
+	merged := mergeContentFindings(line, pack)
+	if len(merged) != 3 || merged[2].Group != "hr" {
+		t.Fatalf("merged = %d findings", len(merged))
+	}
+	for _, f := range merged[:2] {
+		if f.LineNumber == 2 && f.PatternType == PatternSNILS {
+			t.Error("the core СНИЛС finding was kept next to the pack one")
+		}
+	}
+}
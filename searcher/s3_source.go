@@ -0,0 +1,357 @@
+package searcher
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// S3 buckets are read with the REST API: ListObjectsV2 for the listing and
+// GetObject for the content, signed with Signature Version 4, so any
+// S3-compatible store such as MinIO works. Requests go through the
+// NetworkStorage capability of the network policy
+
+// DefaultS3Region is the region of requests when none is configured
+const DefaultS3Region = "us-east-1"
+
+// DefaultS3Timeout bounds one request, including reading an object
+const DefaultS3Timeout = 10 * time.Minute
+
+// S3Config configures an S3Source
+type S3Config struct {
+	Bucket       string
+	Prefix       string // Only keys starting with it are scanned
+	Endpoint     string // URL of an S3-compatible store, e.g. http://localhost:9000 for MinIO; empty means AWS
+	Region       string // Empty means DefaultS3Region
+	AccessKey    string // Empty sends unsigned requests, for public buckets
+	SecretKey    string
+	SessionToken string // Of temporary credentials
+}
+
+// ParseS3Location splits a location like bucket/prefix or
+// s3://bucket/prefix
+func ParseS3Location(location string) (bucket, prefix string, err error) {
+	rest := strings.TrimPrefix(location, "s3://")
+	bucket, prefix, _ = strings.Cut(rest, "/")
+	if bucket == "" {
+		return "", "", fmt.Errorf("не указан бакет S3: %q (ожидается бакет/префикс)", location)
+	}
+	return bucket, prefix, nil
+}
+
+// S3ConfigFromEnv returns the configuration of a location with the
+// credentials, region and endpoint of the standard AWS environment
+// variables
+func S3ConfigFromEnv(location string) (S3Config, error) {
+	bucket, prefix, err := ParseS3Location(location)
+	if err != nil {
+		return S3Config{}, err
+	}
+	config := S3Config{
+		Bucket:       bucket,
+		Prefix:       prefix,
+		AccessKey:    os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+		Region:       os.Getenv("AWS_REGION"),
+		Endpoint:     os.Getenv("AWS_ENDPOINT_URL_S3"),
+	}
+	if config.Region == "" {
+		config.Region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if config.Endpoint == "" {
+		config.Endpoint = os.Getenv("AWS_ENDPOINT_URL")
+	}
+	return config, nil
+}
+
+// S3Source reads the objects of a bucket. Paths are s3://bucket/key
+type S3Source struct {
+	config S3Config
+	base   *url.URL // Bucket URL, path style for custom endpoints
+	client *http.Client
+
+	mu     sync.Mutex
+	listed map[string]fs.FileInfo // Metadata from the listing, so Stat needs no request
+}
+
+// NewS3Source creates a source of the objects of a bucket
+func NewS3Source(config S3Config) (*S3Source, error) {
+	if config.Bucket == "" {
+		return nil, fmt.Errorf("не указан бакет S3")
+	}
+	if config.Region == "" {
+		config.Region = DefaultS3Region
+	}
+	if (config.AccessKey == "") != (config.SecretKey == "") {
+		return nil, fmt.Errorf("для S3 нужны и ключ доступа, и секретный ключ")
+	}
+	var base *url.URL
+	if config.Endpoint == "" {
+		base = &url.URL{Scheme: "https", Host: config.Bucket + ".s3." + config.Region + ".amazonaws.com", Path: "/"}
+	} else {
+		endpoint, err := url.Parse(config.Endpoint)
+		if err != nil || endpoint.Host == "" || (endpoint.Scheme != "http" && endpoint.Scheme != "https") {
+			return nil, fmt.Errorf("неверный адрес S3: %q", config.Endpoint)
+		}
+		base = &url.URL{Scheme: endpoint.Scheme, Host: endpoint.Host,
+			Path: strings.TrimSuffix(endpoint.Path, "/") + "/" + config.Bucket + "/"}
+	}
+	return &S3Source{
+		config: config,
+		base:   base,
+		client: Network().Client(NetworkStorage, DefaultS3Timeout),
+		listed: make(map[string]fs.FileInfo),
+	}, nil
+}
+
+// Root returns s3://bucket/prefix
+func (src *S3Source) Root() string {
+	return strings.TrimSuffix("s3://"+src.config.Bucket+"/"+src.config.Prefix, "/")
+}
+
+// s3ListResult is a page of ListObjectsV2
+type s3ListResult struct {
+	Contents []struct {
+		Key          string
+		Size         int64
+		LastModified time.Time
+	}
+	IsTruncated           bool
+	NextContinuationToken string
+}
+
+// List lists the objects below the prefix page by page; keys ending with a
+// slash are folder markers and are left out
+func (src *S3Source) List(ctx context.Context, fn func(path string, info fs.FileInfo) error) error {
+	query := map[string]string{"list-type": "2", "prefix": src.config.Prefix}
+	for {
+		resp, err := src.do(ctx, http.MethodGet, "", query)
+		if err != nil {
+			return err
+		}
+		var page s3ListResult
+		err = xml.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("неверный ответ S3: %w", err)
+		}
+		for _, object := range page.Contents {
+			if strings.HasSuffix(object.Key, "/") {
+				continue
+			}
+			info := sourceFileInfo{name: path.Base(object.Key), size: object.Size, modTime: object.LastModified}
+			p := src.path(object.Key)
+			src.mu.Lock()
+			src.listed[p] = info
+			src.mu.Unlock()
+			if err := fn(p, info); err != nil {
+				return err
+			}
+		}
+		if !page.IsTruncated || page.NextContinuationToken == "" {
+			return nil
+		}
+		query["continuation-token"] = page.NextContinuationToken
+	}
+}
+
+// Stat returns the metadata of an object, from the listing when it was listed
+func (src *S3Source) Stat(p string) (fs.FileInfo, error) {
+	src.mu.Lock()
+	info, ok := src.listed[p]
+	src.mu.Unlock()
+	if ok {
+		return info, nil
+	}
+	key, err := src.key(p)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := src.do(context.Background(), http.MethodHead, key, nil)
+	if err != nil {
+		return nil, s3PathError("stat", p, err)
+	}
+	resp.Body.Close()
+	modTime, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+	return sourceFileInfo{name: path.Base(key), size: resp.ContentLength, modTime: modTime}, nil
+}
+
+// Open starts reading an object
+func (src *S3Source) Open(p string) (io.ReadCloser, error) {
+	key, err := src.key(p)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := src.do(context.Background(), http.MethodGet, key, nil)
+	if err != nil {
+		return nil, s3PathError("open", p, err)
+	}
+	return resp.Body, nil
+}
+
+// path returns the path of a key
+func (src *S3Source) path(key string) string {
+	return "s3://" + src.config.Bucket + "/" + key
+}
+
+// key returns the key of a path
+func (src *S3Source) key(p string) (string, error) {
+	key, ok := strings.CutPrefix(p, "s3://"+src.config.Bucket+"/")
+	if !ok || key == "" {
+		return "", &fs.PathError{Op: "open", Path: p, Err: fs.ErrInvalid}
+	}
+	return key, nil
+}
+
+// s3Error is an error response of the store
+type s3Error struct {
+	Status  int    `xml:"-"`
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+func (e *s3Error) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("S3 ответил %d %s", e.Status, http.StatusText(e.Status))
+	}
+	return fmt.Sprintf("S3 ответил %d %s: %s", e.Status, e.Code, e.Message)
+}
+
+// s3PathError makes a missing object fs.ErrNotExist
+func s3PathError(op, p string, err error) error {
+	if e, ok := err.(*s3Error); ok && e.Status == http.StatusNotFound {
+		return &fs.PathError{Op: op, Path: p, Err: fs.ErrNotExist}
+	}
+	return &fs.PathError{Op: op, Path: p, Err: err}
+}
+
+// do sends a signed request for a key, or for the bucket when key is empty,
+// and returns the response of a success status
+func (src *S3Source) do(ctx context.Context, method, key string, query map[string]string) (*http.Response, error) {
+	u := *src.base
+	u.RawPath = s3Escape(u.Path, true) + s3Escape(key, true)
+	u.Path += key
+	u.RawQuery = s3Query(query)
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	signS3(req, src.config, time.Now())
+	resp, err := src.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	e := &s3Error{Status: resp.StatusCode}
+	xml.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(e)
+	return nil, e
+}
+
+// s3EmptyHash is the SHA-256 of an empty payload
+const s3EmptyHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// signS3 signs a request without a body with Signature Version 4; without
+// credentials the request stays anonymous
+func signS3(req *http.Request, config S3Config, now time.Time) {
+	if config.AccessKey == "" {
+		return
+	}
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("x-amz-date", amzDate)
+	req.Header.Set("x-amz-content-sha256", s3EmptyHash)
+	if config.SessionToken != "" {
+		req.Header.Set("x-amz-security-token", config.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name := range req.Header {
+		if lower := strings.ToLower(name); strings.HasPrefix(lower, "x-amz-") {
+			headers[lower] = strings.TrimSpace(req.Header.Get(name))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		s3EmptyHash,
+	}, "\n")
+	scope := date + "/" + config.Region + "/s3/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := []byte("AWS4" + config.SecretKey)
+	for _, part := range []string{date, config.Region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+config.AccessKey+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// s3Query encodes a query sorted by name, which is also its canonical form
+func s3Query(query map[string]string) string {
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = s3Escape(name, false) + "=" + s3Escape(query[name], false)
+	}
+	return strings.Join(parts, "&")
+}
+
+// s3Escape percent-encodes all but the unreserved characters, and slashes
+// when keepSlash is set
+func s3Escape(s string, keepSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~', c == '/' && keepSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,274 @@
+package searcher
+
+import (
+	"encoding/json"
+	"io"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/kacebover/password-finder/locale"
+)
+
+// SARIF 2.1.0 output for code scanning tools such as GitHub Actions
+
+const (
+	SARIFVersion = "2.1.0"
+	SARIFSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+
+	// sarifToolURI is shown by code scanning UIs next to the tool name
+	sarifToolURI = "https://github.com/Kacebover/data-leak-locator1"
+
+	// sarifSourceRoot is the base of relative file URIs; CI resolves it to
+	// the checkout directory
+	sarifSourceRoot = "%SRCROOT%"
+)
+
+// SARIFReport is the top-level SARIF log
+type SARIFReport struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []SARIFRun `json:"runs"`
+}
+
+// SARIFRun is one run of the scanner
+type SARIFRun struct {
+	Tool        SARIFTool         `json:"tool"`
+	Invocations []SARIFInvocation `json:"invocations,omitempty"`
+	Results     []SARIFResult     `json:"results"`
+}
+
+// SARIFTool describes the scanner and its rules
+type SARIFTool struct {
+	Driver SARIFDriver `json:"driver"`
+}
+
+// SARIFDriver is the tool component that produced the results
+type SARIFDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri,omitempty"`
+	Rules          []SARIFRule `json:"rules"`
+}
+
+// SARIFRule describes one pattern type
+type SARIFRule struct {
+	ID                   string             `json:"id"`
+	Name                 string             `json:"name,omitempty"`
+	ShortDescription     SARIFMessage       `json:"shortDescription"`
+	FullDescription      *SARIFMessage      `json:"fullDescription,omitempty"`
+	DefaultConfiguration *SARIFRuleDefaults `json:"defaultConfiguration,omitempty"`
+}
+
+// SARIFRuleDefaults holds the default level of a rule
+type SARIFRuleDefaults struct {
+	Level string `json:"level"`
+}
+
+// SARIFInvocation records whether the scan ran to the end
+type SARIFInvocation struct {
+	ExecutionSuccessful bool `json:"executionSuccessful"`
+}
+
+// SARIFMessage is a plain text message
+type SARIFMessage struct {
+	Text string `json:"text"`
+}
+
+// SARIFResult is a single finding
+type SARIFResult struct {
+	RuleID     string            `json:"ruleId"`
+	RuleIndex  int               `json:"ruleIndex"`
+	Level      string            `json:"level"`
+	Message    SARIFMessage      `json:"message"`
+	Locations  []SARIFLocation   `json:"locations"`
+	Properties map[string]string `json:"properties,omitempty"` // Commit of history findings, page of PDF findings
+}
+
+// SARIFLocation points at the line of a finding
+type SARIFLocation struct {
+	PhysicalLocation SARIFPhysicalLocation `json:"physicalLocation"`
+}
+
+// SARIFPhysicalLocation is a file and a region in it
+type SARIFPhysicalLocation struct {
+	ArtifactLocation SARIFArtifactLocation `json:"artifactLocation"`
+	Region           *SARIFRegion          `json:"region,omitempty"`
+}
+
+// SARIFArtifactLocation is the file URI, relative to UriBaseID when set
+type SARIFArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+// SARIFRegion uses 1-based lines and columns; columns count UTF-16 code
+// units and EndColumn is exclusive
+type SARIFRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+	EndColumn   int `json:"endColumn,omitempty"`
+}
+
+// SARIFLevel maps a severity to a SARIF result level
+func SARIFLevel(s Severity) string {
+	switch s {
+	case Critical, High:
+		return "error"
+	case Medium:
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+// ExportSARIF exports findings to a SARIF 2.1.0 file
+func (rg *ReportGenerator) ExportSARIF(filePath string) error {
+	return writeFileAtomic(filePath, reportFileMode, rg.writeSARIF)
+}
+
+// writeSARIF writes the SARIF report to w
+func (rg *ReportGenerator) writeSARIF(w io.Writer) error {
+	data, err := json.MarshalIndent(rg.BuildSARIF(), "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// BuildSARIF converts the scan result into a SARIF log. Every built-in
+// pattern type is listed as a rule, so rule indexes stay stable between runs
+func (rg *ReportGenerator) BuildSARIF() *SARIFReport {
+	findings := rg.result.allFindings()
+	rules, index := sarifRules(rg.locale, findings)
+
+	results := make([]SARIFResult, 0, rg.result.TotalFindings())
+	findings(func(finding *Finding) bool {
+		results = append(results, sarifResult(rg.locale, finding, index[finding.PatternType]))
+		return true
+	})
+
+	return &SARIFReport{
+		Schema:  SARIFSchema,
+		Version: SARIFVersion,
+		Runs: []SARIFRun{{
+			Tool: SARIFTool{Driver: SARIFDriver{
+				Name:           "data-leak-locator",
+				InformationURI: sarifToolURI,
+				Rules:          rules,
+			}},
+			Invocations: []SARIFInvocation{{ExecutionSuccessful: !rg.result.Cancelled}},
+			Results:     results,
+		}},
+	}
+}
+
+// sarifRules lists the built-in pattern types followed by any other type
+// found in the result (imported findings), with the index of each rule
+func sarifRules(l *locale.Localizer, findings findingSeq) ([]SARIFRule, map[PatternType]int) {
+	types := AllPatternTypes()
+	index := make(map[PatternType]int, len(types))
+	for i, t := range types {
+		index[t] = i
+	}
+	findings(func(finding *Finding) bool {
+		if _, ok := index[finding.PatternType]; !ok {
+			index[finding.PatternType] = len(types)
+			types = append(types, finding.PatternType)
+		}
+		return true
+	})
+
+	patterns := NewPatterns()
+	rules := make([]SARIFRule, 0, len(types))
+	for _, t := range types {
+		rule := SARIFRule{
+			ID:               string(t),
+			Name:             sarifRuleName(t),
+			ShortDescription: SARIFMessage{Text: l.Pattern(string(t))},
+		}
+		if pattern := patterns.GetPatternByType(t); pattern != nil {
+			rule.FullDescription = &SARIFMessage{Text: LocalizeDescription(l, pattern.Description)}
+			rule.DefaultConfiguration = &SARIFRuleDefaults{Level: SARIFLevel(pattern.Severity)}
+		}
+		rules = append(rules, rule)
+	}
+	return rules, index
+}
+
+// sarifRuleName turns "api_key" into "ApiKey", the form SARIF suggests for names
+func sarifRuleName(t PatternType) string {
+	var b strings.Builder
+	for _, part := range strings.Split(string(t), "_") {
+		if part != "" {
+			b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+		}
+	}
+	return b.String()
+}
+
+// sarifResult converts one finding. The matched text is left out of the
+// message, so the secret is not copied into the code scanning alerts
+func sarifResult(l *locale.Localizer, finding *Finding, ruleIndex int) SARIFResult {
+	// A finding in an archive member points at the archive; the member and
+	// its line go to the properties
+	location := SARIFPhysicalLocation{ArtifactLocation: sarifArtifact(finding.FilePath)}
+	if finding.ArchivePath != "" {
+		location.ArtifactLocation = sarifArtifact(finding.ArchivePath)
+	} else if finding.LineNumber > 0 {
+		region := &SARIFRegion{StartLine: finding.LineNumber}
+		if start, end := finding.UTF16Columns(); end > start {
+			region.StartColumn = start + 1
+			region.EndColumn = end + 1
+		}
+		location.Region = region
+	}
+
+	result := SARIFResult{
+		RuleID:    string(finding.PatternType),
+		RuleIndex: ruleIndex,
+		Level:     SARIFLevel(finding.Severity),
+		Message:   SARIFMessage{Text: l.Pattern(string(finding.PatternType)) + ": " + LocalizeDescription(l, finding.Description)},
+		Locations: []SARIFLocation{{PhysicalLocation: location}},
+	}
+	if finding.Commit != "" {
+		result.Properties = map[string]string{
+			"commit":     finding.Commit,
+			"author":     finding.Author,
+			"commitDate": finding.CommitDate,
+		}
+	}
+	if finding.ArchivePath != "" {
+		if result.Properties == nil {
+			result.Properties = make(map[string]string)
+		}
+		result.Properties["innerPath"] = finding.InnerPath
+		result.Properties["innerLine"] = strconv.Itoa(finding.LineNumber)
+	}
+	if finding.PageNumber != 0 {
+		if result.Properties == nil {
+			result.Properties = make(map[string]string)
+		}
+		result.Properties["page"] = strconv.Itoa(finding.PageNumber)
+		if finding.PageLine != 0 {
+			result.Properties["pageLine"] = strconv.Itoa(finding.PageLine)
+		}
+	}
+	return result
+}
+
+// sarifArtifact builds the file URI. Relative paths stay relative to the
+// source root, absolute ones become file:// URIs
+func sarifArtifact(path string) SARIFArtifactLocation {
+	slashed := filepath.ToSlash(path)
+	if !filepath.IsAbs(path) {
+		slashed = strings.TrimPrefix(slashed, "./")
+		return SARIFArtifactLocation{URI: (&url.URL{Path: slashed}).EscapedPath(), URIBaseID: sarifSourceRoot}
+	}
+	if !strings.HasPrefix(slashed, "/") {
+		// Windows drive letter: C:/x -> file:///C:/x
+		slashed = "/" + slashed
+	}
+	return SARIFArtifactLocation{URI: (&url.URL{Scheme: "file", Path: slashed}).String()}
+}
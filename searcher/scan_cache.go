@@ -0,0 +1,299 @@
+package searcher
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/kacebover/password-finder/fsutil"
+)
+
+// Incremental scans keep a cache of the files of the last scan of a root:
+// size, modification time, optionally a content hash, and the findings.
+// A file that has not changed since is not read again; its cached findings
+// are replayed into the new result, so reports stay complete. The cache is
+// rewritten after every finished scan, so deleted files drop out of it.
+//
+// The cache never holds a secret in plain text: findings are stored masked
+// (see Finding.Masked), which keeps their fingerprints for grouping and
+// baselines, and the file is private to the user. A scan that has to show
+// the secrets reads files with findings again instead of replaying them
+
+// ScanCacheVersion is the format of the cache file; other versions are ignored
+const ScanCacheVersion = 3
+
+// scanCacheFile is the content of the cache file
+type scanCacheFile struct {
+	Version  int                    `json:"version"`
+	Root     string                 `json:"root"`     // Absolute scan root
+	Patterns string                 `json:"patterns"` // Hash of the rules and settings that produced the findings
+	Files    map[string]*cachedFile `json:"files"`    // By path as the scan saw it
+}
+
+// cachedFile is what the last scan learned about one file
+type cachedFile struct {
+	Size     int64      `json:"size"`
+	ModTime  int64      `json:"mtime"`              // UnixNano
+	Hash     string     `json:"hash,omitempty"`     // SHA-256 of the content, with hash checking on
+	Findings []*Finding `json:"findings,omitempty"` // Masked
+
+	Truncated map[string]int `json:"truncated,omitempty"` // Sources of the file matching stopped in, see ScanResult.Truncated
+}
+
+// scanCache is the cache of the scan in progress: the previous state to
+// replay from and the next state being built
+type scanCache struct {
+	path     string
+	checkSum bool
+	raw      bool                   // Files with findings are scanned again, see SetRawFindings
+	prev     map[string]*cachedFile // Nil when the cache was missing or stale
+	file     scanCacheFile
+
+	mu      sync.Mutex
+	pending map[string]*cachedFile // Files being scanned
+}
+
+// SetCache sets the cache file of incremental scans; empty means a file per
+// scan root under the user cache directory
+func (s *Scanner) SetCache(path string) {
+	s.cachePath = path
+}
+
+// SetIncremental turns incremental scanning on: unchanged files are taken
+// from the cache of the previous scan of the same root instead of read
+func (s *Scanner) SetIncremental(enabled bool) {
+	s.incremental = enabled
+}
+
+// SetCacheHash makes incremental scans also compare a hash of the content,
+// for file systems where a changed file can keep its size and mtime
+func (s *Scanner) SetCacheHash(enabled bool) {
+	s.cacheHash = enabled
+}
+
+// SetRawFindings tells incremental scans the findings must carry the
+// secrets unmasked, as for reports with secrets: files with findings are
+// then read again instead of replayed masked from the cache
+func (s *Scanner) SetRawFindings(enabled bool) {
+	s.rawFindings = enabled
+}
+
+// DefaultCachePath returns the cache file used for root when SetCache was
+// not called: os.UserCacheDir()/data-leak-locator/scan-<hash of root>.json
+func DefaultCachePath(root string) (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(abs))
+	return filepath.Join(dir, "data-leak-locator", "scan-"+hex.EncodeToString(sum[:8])+".json"), nil
+}
+
+// openCache loads the cache for the scan of root; nil when incremental
+// scanning is off or the cache location is unknown
+func (s *Scanner) openCache(root string) *scanCache {
+	if !s.incremental {
+		return nil
+	}
+	path := s.cachePath
+	if path == "" {
+		var err error
+		if path, err = DefaultCachePath(root); err != nil {
+			return nil
+		}
+	}
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		abs = root
+	}
+
+	cache := &scanCache{
+		path:     path,
+		checkSum: s.cacheHash,
+		raw:      s.rawFindings,
+		file: scanCacheFile{
+			Version:  ScanCacheVersion,
+			Root:     abs,
+			Patterns: s.rulesVersion(),
+			Files:    make(map[string]*cachedFile),
+		},
+		pending: make(map[string]*cachedFile),
+	}
+
+	// A cache of another root, format or rule set is rebuilt from scratch
+	var prev scanCacheFile
+	if data, err := fsutil.ReadFile(path); err == nil && json.Unmarshal(data, &prev) == nil &&
+		prev.Version == ScanCacheVersion && prev.Root == abs && prev.Patterns == cache.file.Patterns {
+		cache.prev = prev.Files
+	}
+	return cache
+}
+
+// rulesVersion hashes everything that decides which findings a file gives,
+// so changed rules or settings invalidate the cache
+func (s *Scanner) rulesVersion() string {
+	h := sha256.New()
+	fmt.Fprintf(h, "v%d strict=%v\n", ScanCacheVersion, s.patterns.StrictValidation())
+	for _, p := range s.patterns.List() {
+		if !s.patterns.isEnabled(p.Group) || !s.patterns.Enabled(p.Type) {
+			continue
+		}
+		fmt.Fprintf(h, "%s|%s|%s|%s|%s|%v|%d|%g\n", p.Name, p.Type, p.Severity, p.Regex, p.Group, p.Near, p.NearLines, p.MinEntropy)
+	}
+	for _, d := range s.patterns.Detectors() {
+		fmt.Fprintf(h, "detector %T\n", d)
+	}
+	for _, d := range s.patterns.fileDetectors {
+		fmt.Fprintf(h, "file detector %s\n", d.Name())
+	}
+	fmt.Fprintf(h, "disabled %v\n", s.patterns.DisabledTypes())
+	fmt.Fprintf(h, "weights %+v git-tracking=%v\n", s.riskScorer.weights, s.gitTracking)
+	fmt.Fprintf(h, "severities %s min=%s per-file=%d heuristics=%s\n", s.severities, s.minSeverity, s.maxPerFile, s.heuristics)
+	if s.highEntropy != nil {
+		fmt.Fprintf(h, "entropy %g %g %d %s\n", s.highEntropy.Base64Threshold, s.highEntropy.HexThreshold, s.highEntropy.MinLength, s.highEntropy.Severity)
+	}
+	ocr := s.docExtractor != nil && s.docExtractor.enableOCR
+	fmt.Fprintf(h, "base64=%v docs=%v archives=%v ocr=%v line=%d context=%d lines=%d\n",
+		s.decodeBase64, s.scanDocuments, s.scanArchives, ocr, s.maxLineLength, contextLimit(s.maxContextLength), s.contextLines)
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+// lookup returns the cache entry of an unchanged file and starts
+// recording the file otherwise
+func (c *scanCache) lookup(path string, info fs.FileInfo) (*cachedFile, bool) {
+	entry := &cachedFile{Size: info.Size(), ModTime: info.ModTime().UnixNano()}
+	old := c.prev[path]
+	unchanged := old != nil && old.Size == entry.Size && old.ModTime == entry.ModTime
+	if c.checkSum {
+		entry.Hash = hashFile(path)
+		unchanged = unchanged && entry.Hash != "" && old.Hash == entry.Hash
+	}
+	if c.raw && old != nil && len(old.Findings) > 0 {
+		unchanged = false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if unchanged {
+		entry.Findings = old.Findings
+		entry.Truncated = old.Truncated
+		c.file.Files[path] = entry
+		return old, true
+	}
+	c.pending[path] = entry
+	return nil, false
+}
+
+// add records findings of a file being scanned, masked
+func (c *scanCache) add(path string, findings []*Finding) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if entry := c.pending[path]; entry != nil {
+		for _, f := range findings {
+			entry.Findings = append(entry.Findings, f.Masked())
+		}
+	}
+}
+
+// truncated records the sources of findings of a file being scanned that
+// matching stopped in, so a replay lists them again
+func (c *scanCache) truncated(path string, findings []*Finding, result *ScanResult) {
+	for i, f := range findings {
+		if i > 0 && f.FilePath == findings[i-1].FilePath {
+			continue
+		}
+		n, ok := result.truncatedCount(f.FilePath)
+		if !ok {
+			continue
+		}
+		c.mu.Lock()
+		if entry := c.pending[path]; entry != nil {
+			if entry.Truncated == nil {
+				entry.Truncated = make(map[string]int)
+			}
+			entry.Truncated[f.FilePath] = n
+		}
+		c.mu.Unlock()
+	}
+}
+
+// commit keeps a file that was scanned through for the next scan
+func (c *scanCache) commit(path string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if entry := c.pending[path]; entry != nil {
+		c.file.Files[path] = entry
+		delete(c.pending, path)
+	}
+}
+
+// discard forgets a file that was skipped or failed, so it is read again
+// next time
+func (c *scanCache) discard(path string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.pending, path)
+}
+
+// save writes the cache of the finished scan
+func (c *scanCache) save() error {
+	if err := fsutil.MkdirAll(filepath.Dir(c.path), 0700); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	data, err := json.Marshal(c.file)
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(c.path, privateFileMode, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+// hashFile returns the SHA-256 of the content, empty when it cannot be read
+func hashFile(path string) string {
+	file, err := fsutil.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// replayCached adds the cached findings of an unchanged file to the result;
+// ok is false when the file has to be scanned
+func (s *Scanner) replayCached(path string, info fs.FileInfo) (found int, ok bool) {
+	cached, ok := s.cache.lookup(path, info)
+	if !ok {
+		return 0, false
+	}
+	for source, n := range cached.Truncated {
+		s.result.AddTruncated(source, n)
+	}
+	findings := make([]*Finding, len(cached.Findings))
+	for i, f := range cached.Findings {
+		copied := *f
+		findings[i] = &copied
+	}
+	found = s.addFindings("", findings)
+	s.result.AddCacheHit()
+	s.result.IncrementFilesScanned()
+	s.result.AddTotalSize(info.Size())
+	return found, true
+}
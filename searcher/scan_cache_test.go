@@ -0,0 +1,168 @@
+package searcher
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// incrementalScan scans dir with the cache file cache
+func incrementalScan(t *testing.T, dir, cache string) *ScanResult {
+	t.Helper()
+	scanner := NewScanner()
+	scanner.SetIncremental(true)
+	scanner.SetCache(cache)
+	result, err := scanner.Scan(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+// findingsIn counts the findings of result in the file named name
+func findingsIn(result *ScanResult, name string) int {
+	n := 0
+	for _, f := range result.Findings {
+		if filepath.Base(f.FilePath) == name {
+			n++
+		}
+	}
+	return n
+}
+
+func TestIncrementalScan(t *testing.T) {
+	dir := t.TempDir()
+	cache := filepath.Join(t.TempDir(), "cache.json")
+	createTestFile(t, dir, "a.env", "password = hunter2hunter2\n")
+	createTestFile(t, dir, "b.env", "api_key = sk_live_abcdefghijklmnop1234\n")
+	createTestFile(t, dir, "c.txt", "nothing to see here\n")
+
+	// The first scan reads everything and fills the cache
+	first := incrementalScan(t, dir, cache)
+	if first.CacheHits != 0 || first.FilesScanned != 3 || len(first.Findings) == 0 {
+		t.Fatalf("first scan: %d hits, %d files, %d findings", first.CacheHits, first.FilesScanned, len(first.Findings))
+	}
+	if _, err := os.Stat(cache); err != nil {
+		t.Fatalf("cache not written: %v", err)
+	}
+
+	// Nothing changed: every file comes from the cache with its findings
+	second := incrementalScan(t, dir, cache)
+	if second.CacheHits != 3 || second.FilesScanned != 3 || len(second.Findings) != len(first.Findings) {
+		t.Errorf("second scan: %d hits, %d files, %d findings, want 3, 3, %d",
+			second.CacheHits, second.FilesScanned, len(second.Findings), len(first.Findings))
+	}
+
+	// Only the modified file is read again
+	path := filepath.Join(dir, "c.txt")
+	if err := os.WriteFile(path, []byte("password = correcthorsebattery\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	third := incrementalScan(t, dir, cache)
+	if third.CacheHits != 2 {
+		t.Errorf("third scan: %d hits, want 2", third.CacheHits)
+	}
+	if findingsIn(third, "c.txt") == 0 || findingsIn(third, "a.env") != findingsIn(first, "a.env") {
+		t.Errorf("third scan findings: c.txt %d, a.env %d", findingsIn(third, "c.txt"), findingsIn(third, "a.env"))
+	}
+
+	// Deleted files drop out of the cache
+	if err := os.Remove(filepath.Join(dir, "b.env")); err != nil {
+		t.Fatal(err)
+	}
+	fourth := incrementalScan(t, dir, cache)
+	if fourth.CacheHits != 2 || findingsIn(fourth, "b.env") != 0 {
+		t.Errorf("fourth scan: %d hits, %d findings in b.env", fourth.CacheHits, findingsIn(fourth, "b.env"))
+	}
+}
+
+func TestIncrementalScanRulesChange(t *testing.T) {
+	dir := t.TempDir()
+	cache := filepath.Join(t.TempDir(), "cache.json")
+	createTestFile(t, dir, "a.env", "password = hunter2hunter2\n")
+	incrementalScan(t, dir, cache)
+
+	// Different settings give different findings, so the cache is not used
+	scanner := NewScanner()
+	scanner.SetIncremental(true)
+	scanner.SetCache(cache)
+	scanner.SetDecodeBase64(true)
+	result, err := scanner.Scan(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.CacheHits != 0 {
+		t.Errorf("%d hits after a settings change, want 0", result.CacheHits)
+	}
+
+	// Without SetIncremental the cache is neither read nor written
+	os.Remove(cache)
+	scanner = NewScanner()
+	scanner.SetCache(cache)
+	if _, err := scanner.Scan(dir); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(cache); !os.IsNotExist(err) {
+		t.Errorf("cache written without SetIncremental: %v", err)
+	}
+}
+
+func TestIncrementalScanKeepsSecretsOut(t *testing.T) {
+	dir := t.TempDir()
+	cache := filepath.Join(t.TempDir(), "cache.json")
+	createTestFile(t, dir, "a.env", "password = hunter2hunter2\n")
+	createTestFile(t, dir, "c.txt", "nothing to see here\n")
+	first := incrementalScan(t, dir, cache)
+	if findingsIn(first, "a.env") == 0 {
+		t.Fatal("no findings in a.env")
+	}
+
+	// The cache holds the findings masked, readable by the user only
+	data, err := os.ReadFile(cache)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "hunter2hunter2") {
+		t.Error("cache holds the secret in plain text")
+	}
+	if info, err := os.Stat(cache); err != nil {
+		t.Fatal(err)
+	} else if runtime.GOOS != "windows" && info.Mode().Perm() != 0600 {
+		t.Errorf("cache mode %v, want 0600", info.Mode().Perm())
+	}
+
+	// Replayed findings are masked but keep their fingerprints
+	second := incrementalScan(t, dir, cache)
+	for _, f := range second.Findings {
+		if strings.Contains(f.MatchedText+f.Context, "hunter2hunter2") || f.SecretID == "" || f.MatchSHA256 == "" {
+			t.Errorf("replayed finding %q %q, id %q", f.MatchedText, f.Context, f.SecretID)
+		}
+	}
+
+	// With raw findings the file with findings is read again
+	scanner := NewScanner()
+	scanner.SetIncremental(true)
+	scanner.SetCache(cache)
+	scanner.SetRawFindings(true)
+	raw, err := scanner.Scan(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if raw.CacheHits != 1 {
+		t.Errorf("%d hits with raw findings, want 1", raw.CacheHits)
+	}
+	found := false
+	for _, f := range raw.Findings {
+		found = found || strings.Contains(f.MatchedText, "hunter2hunter2")
+	}
+	if !found {
+		t.Error("raw findings lost the secret")
+	}
+}
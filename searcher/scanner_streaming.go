@@ -1,14 +1,16 @@
 package searcher
 
 import (
-	"bufio"
 	"context"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"github.com/kacebover/password-finder/fsutil"
 )
 
 // ScanEvent represents different types of events emitted during scanning
@@ -49,11 +51,16 @@ type ScanProgress struct {
 	FilesProcessed  int64
 	FilesSkipped    int64
 	FindingsCount   int64
+	CriticalCount   int64
 	ErrorCount      int64
 	BytesScanned    int64
+	NewFindings     int64 // Findings not in the baseline, 0 without one
+	KnownFindings   int64 // Findings already in the baseline
 	CurrentFile     string
 	ElapsedTime     time.Duration
-	EstimatedTotal  int64
+	EstimatedTotal  int64 // Files the scan will visit, from the enumeration pass
+	TotalComplete   bool  // EstimatedTotal is final, the enumeration has finished
+	FilesDone       int64 // Files finished, scanned or not, out of EstimatedTotal
 }
 
 // ScanState represents the current state of the scanner
@@ -70,8 +77,10 @@ const (
 // StreamingScanner performs scans with real-time event streaming
 type StreamingScanner struct {
 	patterns          *Patterns
+	rootPatterns      []*Pattern // Loaded from CustomPatternsFile of the last scan root
 	ignoreList        *IgnoreList
 	riskScorer        *RiskScorer
+	severities        SeverityOverrides // Severities the policy sets per pattern type
 	entropyCalculator *EntropyCalculator
 	
 	// Configuration
@@ -83,6 +92,8 @@ type StreamingScanner struct {
 	excludeExts      map[string]bool
 	includeDirs      []string
 	excludeDirs      []string
+	maxLineLength    int
+	maxContextLength int
 	
 	// State management
 	state          atomic.Int32
@@ -94,8 +105,19 @@ type StreamingScanner struct {
 	filesProcessed atomic.Int64
 	filesSkipped   atomic.Int64
 	findingsCount  atomic.Int64
+	criticalCount  atomic.Int64
 	errorCount     atomic.Int64
 	bytesScanned   atomic.Int64
+	newCount       atomic.Int64
+	knownCount     atomic.Int64
+	
+	// Baseline to tag findings against, read without locks by the workers
+	baseline atomic.Pointer[BaselineIndex]
+
+	// Notifications, see SetNotifier
+	notifier   Notifier
+	notifyMode NotifyMode
+	notify     *notifyQueue
 	
 	// Results
 	result      *ScanResult
@@ -108,14 +130,17 @@ type StreamingScanner struct {
 
 // StreamingScannerConfig holds configuration for the streaming scanner
 type StreamingScannerConfig struct {
-	MaxFileSize    int64
-	MaxConcurrent  int
-	FollowSymlinks bool
-	ScanBinaries   bool
-	IncludeExts    []string
-	ExcludeExts    []string
-	IncludeDirs    []string
-	ExcludeDirs    []string
+	MaxFileSize      int64
+	MaxConcurrent    int
+	FollowSymlinks   bool
+	ScanBinaries     bool
+	IncludeExts      []string
+	ExcludeExts      []string
+	IncludeDirs      []string
+	ExcludeDirs      []string
+	Groups           []string // Optional detector groups to enable, e.g. "finance"
+	MaxLineLength    int      // Longer lines are scanned in chunks; 0 means DefaultMaxLineLength
+	MaxContextLength int      // Longest context kept with a finding; 0 means DefaultMaxContextLength, negative keeps whole lines
 }
 
 // DefaultStreamingScannerConfig returns default configuration
@@ -144,8 +169,12 @@ func NewStreamingScanner(config StreamingScannerConfig) *StreamingScanner {
 		excludeExts[strings.ToLower(ext)] = true
 	}
 	
+	// Unknown group names are rejected by ParseGroup where they are entered
+	patterns := NewPatterns()
+	patterns.EnableGroups(config.Groups)
+	
 	return &StreamingScanner{
-		patterns:          NewPatterns(),
+		patterns:          patterns,
 		ignoreList:        NewIgnoreList(),
 		riskScorer:        NewRiskScorer(),
 		entropyCalculator: NewEntropyCalculator(),
@@ -157,12 +186,42 @@ func NewStreamingScanner(config StreamingScannerConfig) *StreamingScanner {
 		excludeExts:       excludeExts,
 		includeDirs:       config.IncludeDirs,
 		excludeDirs:       config.ExcludeDirs,
+		maxLineLength:     config.MaxLineLength,
+		maxContextLength:  contextLimit(config.MaxContextLength),
 		pauseChan:         make(chan struct{}),
 		resumeChan:        make(chan struct{}),
 		eventChan:         make(chan ScanEvent, 1000),
 	}
 }
 
+// EnablePacks loads and enables rule packs, e.g. "medical" or a pack file
+func (ss *StreamingScanner) EnablePacks(names []string) error {
+	return ss.patterns.EnablePacks(names)
+}
+
+// SetRiskWeights replaces the weights used to score findings; call it before Start
+func (ss *StreamingScanner) SetRiskWeights(weights RiskWeights) {
+	ss.riskScorer = NewRiskScorerWithWeights(weights)
+}
+
+// SetSeverityOverrides makes findings of the types get the severities of
+// overrides; call it before Start
+func (ss *StreamingScanner) SetSeverityOverrides(overrides SeverityOverrides) {
+	ss.severities = overrides
+}
+
+// SetBaseline tags every finding as new or known against index; nil disables tagging
+func (ss *StreamingScanner) SetBaseline(index *BaselineIndex) {
+	ss.baseline.Store(index)
+}
+
+// SetNotifier sends the summary of every scan, and the findings mode
+// selects, to n; nil sends nothing. Call it before Start
+func (ss *StreamingScanner) SetNotifier(n Notifier, mode NotifyMode) {
+	ss.notifier = n
+	ss.notifyMode = mode
+}
+
 // Events returns the event channel for receiving scan events
 func (ss *StreamingScanner) Events() <-chan ScanEvent {
 	return ss.eventChan
@@ -180,8 +239,11 @@ func (ss *StreamingScanner) GetProgress() ScanProgress {
 		FilesProcessed: ss.filesProcessed.Load(),
 		FilesSkipped:   ss.filesSkipped.Load(),
 		FindingsCount:  ss.findingsCount.Load(),
+		CriticalCount:  ss.criticalCount.Load(),
 		ErrorCount:     ss.errorCount.Load(),
 		BytesScanned:   ss.bytesScanned.Load(),
+		NewFindings:    ss.newCount.Load(),
+		KnownFindings:  ss.knownCount.Load(),
 		ElapsedTime:    time.Since(ss.startTime),
 	}
 }
@@ -215,14 +277,19 @@ func (ss *StreamingScanner) Scan(ctx context.Context, rootDir string) (*ScanResu
 	ss.state.Store(int32(StateRunning))
 	ss.result = NewScanResult()
 	ss.result.StartTime = ss.startTime.Unix()
+	ss.result.Root = rootDir
+	ss.notify = startNotify(ss.notifier, ss.notifyMode, ss.result)
 	
 	// Reset counters
 	ss.filesQueued.Store(0)
 	ss.filesProcessed.Store(0)
 	ss.filesSkipped.Store(0)
 	ss.findingsCount.Store(0)
+	ss.criticalCount.Store(0)
 	ss.errorCount.Store(0)
 	ss.bytesScanned.Store(0)
+	ss.newCount.Store(0)
+	ss.knownCount.Store(0)
 	
 	// Initialize ignore list
 	ss.ignoreList.AddDefaultIgnores()
@@ -234,6 +301,19 @@ func (ss *StreamingScanner) Scan(ctx context.Context, rootDir string) (*ScanResu
 	ignoreFilePath := filepath.Join(rootDir, ".dataLeak-ignore")
 	_ = ss.ignoreList.LoadFromFile(ignoreFilePath)
 	
+	rootErr := checkRoot(rootDir)
+
+	// A broken patterns file is reported once the scan with the other
+	// rules is over, like an unreadable root
+	rootPatterns, _, err := loadRootPatterns(ss.patterns, rootDir, ss.rootPatterns)
+	ss.rootPatterns = rootPatterns
+	if err != nil && rootErr == nil {
+		rootErr = err
+	}
+	if ss.patterns.Profiling() {
+		ss.patterns.SetProfiling(true)
+	}
+
 	ss.emitEvent(ScanEvent{
 		Type:      EventScanStarted,
 		Message:   "Scan started: " + rootDir,
@@ -267,6 +347,9 @@ func (ss *StreamingScanner) Scan(ctx context.Context, rootDir string) (*ScanResu
 	ss.result.TotalSize = ss.bytesScanned.Load()
 	ss.result.ErrorCount = int(ss.errorCount.Load())
 	result := ss.result
+	if ss.patterns.Profiling() {
+		result.SetRegexTimes(ss.patterns.RegexTimes())
+	}
 	ss.resultMutex.Unlock()
 	
 	// Emit completion event
@@ -291,7 +374,14 @@ func (ss *StreamingScanner) Scan(ctx context.Context, rootDir string) (*ScanResu
 	// Close event channel
 	close(ss.eventChan)
 	
-	return result, ctx.Err()
+	err = cancelledError(ctx)
+	result.Cancelled = err != nil
+	ss.notify.finish(true)
+	ss.notify = nil
+	if err != nil {
+		return result, err
+	}
+	return result, rootErr
 }
 
 // worker processes files from the channel
@@ -332,9 +422,10 @@ func (ss *StreamingScanner) walkDirectory(ctx context.Context, dir string, fileC
 	default:
 	}
 	
-	entries, err := os.ReadDir(dir)
+	entries, err := fsutil.ReadDir(dir)
 	if err != nil {
 		ss.errorCount.Add(1)
+		ss.result.AddError(dir, StageRead, err)
 		ss.emitEvent(ScanEvent{
 			Type:      EventError,
 			FilePath:  dir,
@@ -398,9 +489,10 @@ func (ss *StreamingScanner) scanFile(ctx context.Context, filePath string) {
 		Timestamp: time.Now(),
 	})
 	
-	fileInfo, err := os.Stat(filePath)
+	fileInfo, err := fsutil.Stat(filePath)
 	if err != nil {
 		ss.errorCount.Add(1)
+		ss.result.AddError(filePath, StageRead, err)
 		ss.emitEvent(ScanEvent{
 			Type:      EventError,
 			FilePath:  filePath,
@@ -453,6 +545,7 @@ func (ss *StreamingScanner) scanFile(ctx context.Context, filePath string) {
 	findings, err := ss.scanFileContent(ctx, filePath)
 	if err != nil {
 		ss.errorCount.Add(1)
+		ss.result.AddError(filePath, StageRead, err)
 		ss.emitEvent(ScanEvent{
 			Type:      EventError,
 			FilePath:  filePath,
@@ -463,13 +556,26 @@ func (ss *StreamingScanner) scanFile(ctx context.Context, filePath string) {
 		return
 	}
 	
-	// Add findings
+	// Add findings, tagged before they are shared
+	baseline := ss.baseline.Load().WithRoot(ss.result.Root)
 	for _, finding := range findings {
+		truncateContext(finding, ss.maxContextLength)
+		switch baseline.Tag(finding) {
+		case BaselineNew:
+			ss.newCount.Add(1)
+		case BaselineKnown:
+			ss.knownCount.Add(1)
+		}
+		
 		ss.resultMutex.Lock()
 		ss.result.AddFinding(finding)
 		ss.resultMutex.Unlock()
 		
 		ss.findingsCount.Add(1)
+		if finding.Severity == Critical {
+			ss.criticalCount.Add(1)
+		}
+		ss.notify.finding(finding)
 		ss.emitEvent(ScanEvent{
 			Type:      EventFinding,
 			Finding:   finding,
@@ -500,65 +606,80 @@ func (ss *StreamingScanner) scanFile(ctx context.Context, filePath string) {
 
 // scanFileContent scans file content for sensitive patterns
 func (ss *StreamingScanner) scanFileContent(ctx context.Context, filePath string) ([]*Finding, error) {
-	file, err := os.Open(filePath)
+	file, err := fsutil.Open(filePath)
 	if err != nil {
 		return nil, err
 	}
 	defer file.Close()
 	
 	var findings []*Finding
-	scanner := bufio.NewScanner(file)
+	var lines []string
+	keepLines := ss.pipeline().hasContentDetectors(filePath)
+	sup := suppressions{}
+	var r io.Reader = file
+	doc := newStructuredBuffer(filePath)
+	if doc != nil {
+		r = io.TeeReader(file, doc)
+	}
+	reader := newLineReader(r, ss.maxLineLength)
 	lineNum := 1
+	var readErr error
 	
-	for scanner.Scan() {
+	for {
 		select {
 		case <-ctx.Done():
 			return findings, ctx.Err()
 		default:
 		}
 		
-		line := scanner.Text()
-		
-		if strings.TrimSpace(line) == "" {
-			lineNum++
-			continue
+		chunk, err := reader.Next()
+		if err != nil {
+			if err != io.EOF {
+				readErr = err
+			}
+			break
+		}
+		line := chunk.Text
+		if keepLines && chunk.Offset == 0 {
+			lines = append(lines, line)
+		}
+		if strings.Contains(line, SuppressMarker) {
+			sup.note(lineNum, line)
 		}
 		
-		patterns := ss.patterns.FindAll(line)
-		for _, pattern := range patterns {
-			pattern.LineNumber = lineNum
-			pattern.FilePath = filePath
-			pattern.Context = line
-			pattern.EntropyScore = ss.entropyCalculator.CalculateEntropy(pattern.MatchText)
-			
-			riskScore := ss.riskScorer.CalculateRiskScore(pattern)
-			
-			finding := &Finding{
-				FilePath:     filePath,
-				LineNumber:   lineNum,
-				ColumnStart:  pattern.StartIndex,
-				ColumnEnd:    pattern.EndIndex,
-				PatternType:  pattern.Type,
-				Severity:     pattern.Severity,
-				Description:  pattern.Description,
-				MatchedText:  pattern.MatchText,
-				Context:      line,
-				EntropyScore: pattern.EntropyScore,
-				RiskScore:    riskScore,
-			}
-			
-			findings = append(findings, finding)
+		if strings.TrimSpace(line) != "" {
+			findings = append(findings, ss.pipeline().analyzeChunk(filePath, chunk, lineNum)...)
 		}
 		
-		lineNum++
+		if chunk.Final {
+			lineNum++
+		}
 	}
 	
-	return findings, scanner.Err()
+	if keepLines && readErr == nil {
+		findings = mergeContentFindings(findings, ss.pipeline().analyzeContent(filePath, lines))
+	}
+	if readErr == nil {
+		findings = ss.pipeline().scanStructured(filePath, doc, findings, 0, 0)
+	}
+	
+	findings, suppressed := sup.filter(findings)
+	ss.result.AddSuppressed(suppressed)
+	return findings, readErr
+}
+
+// pipeline returns the detection pipeline configured for this scanner
+func (ss *StreamingScanner) pipeline() detectionPipeline {
+	return detectionPipeline{
+		patterns:   ss.patterns,
+		riskScorer: ss.riskScorer.forScan(ss.result.Root, nil),
+		severities: ss.severities,
+	}
 }
 
 // isBinaryFile checks if a file is likely binary
 func (ss *StreamingScanner) isBinaryFile(filePath string) bool {
-	file, err := os.Open(filePath)
+	file, err := fsutil.Open(filePath)
 	if err != nil {
 		return true
 	}
@@ -2,21 +2,30 @@ package searcher
 
 import (
 	"bufio"
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/kacebover/password-finder/fsutil"
 )
 
 const (
 	// MaxFileSize is the maximum file size to scan (100MB)
 	MaxFileSize = 100 * 1024 * 1024
 
-	// MaxConcurrentFiles limits goroutines for file scanning
+	// MaxConcurrentFiles is the default number of file workers
 	MaxConcurrentFiles = 16
 )
 
@@ -26,13 +35,68 @@ type Scanner struct {
 	ignoreList        *IgnoreList
 	riskScorer        *RiskScorer
 	maxFileSize       int64
-	semaphore         chan struct{}
+	workers           int // Files scanned at once, see SetMaxConcurrentFiles
 	result            *ScanResult
 	startTime         int64
 	docExtractor      *DocumentExtractor
 	scanDocuments     bool
 	scanArchives      bool
 	onlyExtensions    map[string]bool // If set, only scan files with these extensions
+	includeGlobs      []pathGlob      // If set, only scan paths matching one, see SetIncludeGlobs
+	excludeGlobs      []pathGlob      // Skip paths matching one, see SetExcludeGlobs
+	globRoot          string          // Root the globs of the scan in progress are relative to
+	current           atomic.Pointer[ScanResult] // Result of the scan in progress, for Progress
+	deps              *DependencyRegistry        // Decides which optional capabilities can run
+	gate              *pauseGate                 // Closed while the scan is paused
+	ctx               context.Context            // Context of the scan in progress
+	rootPatterns      []*Pattern                 // Loaded from CustomPatternsFile of the last scan root
+	queued            atomic.Int64               // Files queued for the workers in the scan in progress
+	respectGitignore  bool                       // Skip paths excluded by .gitignore files
+	maxLineLength     int                        // Longer lines are scanned in overlapping chunks
+	decodeBase64      bool                       // Run the patterns over decoded base64 tokens too
+	imageMetadata     bool                       // Report coordinates and names in image metadata
+	highEntropy       *HighEntropyDetector       // Generic detection of random strings, nil when off
+	enumerate         bool                       // Count the files of the tree alongside the scan
+	enumerated        atomic.Pointer[enumeration] // Totals of the scan in progress, nil without enumeration
+	done              atomic.Int64               // Files finished in the scan in progress
+	currentFile       atomic.Pointer[string]     // File started last in the scan in progress
+	followSymlinks    bool                       // Follow links to files and directories inside the root
+	externalSymlinks  bool                       // Also follow links leading outside the root
+	maxContextLength  int                        // Longest context kept with a finding, see contextLimit
+	contextLines      int                        // Lines kept before and after a match, see SetContextLines
+	spill             SpillConfig                // Applied to the result of every scan
+	cachePath         string                     // Cache file of incremental scans, see SetCache
+	incremental       bool                       // Replay unchanged files from the cache
+	cacheHash         bool                       // Also compare content hashes with the cache
+	rawFindings       bool                       // Findings must keep their secrets, see SetRawFindings
+	cache             *scanCache                 // Cache of the scan in progress, nil when not incremental
+	logger            *slog.Logger               // Structured log of scan events, see SetLogger
+	severities        SeverityOverrides          // Severities the policy sets per pattern type
+	minSeverity       Severity                   // Findings below it are dropped, see SetMinSeverity
+	heuristics        HeuristicLevel             // Filtering of references and placeholders, see SetHeuristicFiltering
+	gitTracking       bool                       // Look up the git status of files, see SetGitTracking
+	gitTracked        map[string]bool            // Files git tracks under the root of the scan in progress
+	maxPerFile        int                        // Matching in a file stops after this many findings, 0 for no limit
+	emailAllowlist    []string                   // Domains whose addresses are lowered to Low, see SetEmailDomainAllowlist
+	rootAllowlist     []string                   // Allowlisted domains of the CustomPatternsFile of the last scan root
+	bulkThreshold     int                        // Distinct addresses or numbers a file may hold, see SetBulkThreshold
+	watchDebounce     time.Duration              // Quiet period before watch mode re-scans a file, see SetWatchDebounce
+	findingsLog       string                     // JSONL log of the findings of watch mode, see SetFindingsLog
+	notifier          Notifier                   // Where notifications go, see SetNotifier
+	notifyMode        NotifyMode                 // Which findings are sent besides the summary
+	notify            *notifyQueue               // Notifications of the scan in progress, nil without a notifier
+	source            FileSource                 // Where the files of the scan in progress are read from
+	copies            localCopies                // Temporary copies of remote files for extractors
+	fileTimeout       time.Duration              // Longest scan of one file, see SetFileTimeout
+	fileScans         sync.WaitGroup             // Files scanning under the timeout, including abandoned ones
+	noMetrics         bool                       // Do not collect ScanResult.Metrics, see SetMetrics
+	metrics           *scanMetrics               // Metrics of the scan in progress, nil when off
+	metricsClock      func() time.Time           // Replaces time.Now in tests
+	metricsHook       timingHook                 // Called when a timed step starts, for tests
+	onEnumerate       func(EnumerationResult)
+	onFinding         func(*Finding)
+	onFileScanned     func(path string, findings int)
+	onProgress        func(scanned, skipped, errors int64)
 }
 
 // NewScanner creates a new Scanner instance
@@ -42,11 +106,55 @@ func NewScanner() *Scanner {
 		ignoreList:   NewIgnoreList(),
 		riskScorer:   NewRiskScorer(),
 		maxFileSize:  MaxFileSize,
-		semaphore:    make(chan struct{}, MaxConcurrentFiles),
+		workers:      MaxConcurrentFiles,
+		fileTimeout:  DefaultFileTimeout,
+		contextLines: DefaultContextLines,
 		result:       NewScanResult(),
 		scanDocuments: false,
 		scanArchives:  false,
+		imageMetadata: true,
+		heuristics:    HeuristicBalanced,
+		bulkThreshold: DefaultBulkThreshold,
+		deps:          Dependencies(),
+		gate:          newPauseGate(),
+		ctx:           context.Background(),
+		source:        localFiles,
+	}
+}
+
+// Progress returns the counters of the scan in progress (thread-safe).
+// Percentages are unknown because files are discovered while scanning.
+func (s *Scanner) Progress() ScanProgress {
+	result := s.current.Load()
+	if result == nil {
+		return ScanProgress{}
+	}
+
+	result.mu.Lock()
+	defer result.mu.Unlock()
+	progress := ScanProgress{
+		FilesQueued:    s.queued.Load(),
+		FilesProcessed: int64(result.FilesScanned),
+		FilesSkipped:   int64(result.FilesSkipped),
+		FindingsCount:  int64(result.totalFindingsLocked()),
+		CriticalCount:  int64(result.SeveritySummary[Critical]),
+		ErrorCount:     int64(result.ErrorCount),
+		BytesScanned:   result.TotalSize,
+		ElapsedTime:    time.Since(time.Unix(result.StartTime, 0)) - s.gate.pausedFor(),
+		FilesDone:      s.done.Load(),
+	}
+	if path := s.currentFile.Load(); path != nil {
+		progress.CurrentFile = *path
 	}
+	if e := s.enumerated.Load(); e != nil {
+		// The scan can run ahead of a still running enumeration
+		progress.EstimatedTotal = e.files.Load()
+		if progress.FilesQueued > progress.EstimatedTotal {
+			progress.EstimatedTotal = progress.FilesQueued
+		}
+		progress.TotalComplete = e.complete.Load()
+	}
+	return progress
 }
 
 // SetDocumentExtractor sets the document extractor
@@ -64,15 +172,234 @@ func (s *Scanner) SetScanArchives(enabled bool) {
 	s.scanArchives = enabled
 }
 
+// SetDependencyRegistry sets the registry used to decide which optional
+// capabilities are available (tests pass a mocked one)
+func (s *Scanner) SetDependencyRegistry(r *DependencyRegistry) {
+	s.deps = r
+}
+
+// SetOnFinding sets a callback fired for every finding as soon as it is
+// recorded. Like the other callbacks it is called from the scanning
+// goroutines, possibly concurrently, and without scanner locks held, so it
+// must be safe for concurrent use. Set callbacks before the scan starts
+func (s *Scanner) SetOnFinding(fn func(*Finding)) {
+	s.onFinding = fn
+}
+
+// SetOnFileScanned sets a callback fired once for every file the scan
+// reached, including skipped and unreadable ones, with its finding count
+func (s *Scanner) SetOnFileScanned(fn func(path string, findings int)) {
+	s.onFileScanned = fn
+}
+
+// SetOnProgress sets a callback fired after every file with the scanned,
+// skipped and error counters of the scan
+func (s *Scanner) SetOnProgress(fn func(scanned, skipped, errors int64)) {
+	s.onProgress = fn
+}
+
+// SetRespectGitignore makes the scan skip paths excluded by the .gitignore
+// files of the scanned tree (off by default)
+func (s *Scanner) SetRespectGitignore(enabled bool) {
+	s.respectGitignore = enabled
+}
+
+// SetDetectHighEntropy turns on the generic detection of high-entropy
+// strings on lines no specific pattern matched (off by default, it is noisy)
+func (s *Scanner) SetDetectHighEntropy(enabled bool) {
+	switch {
+	case !enabled:
+		s.highEntropy = nil
+	case s.highEntropy == nil:
+		s.highEntropy = NewHighEntropyDetector()
+	}
+}
+
+// SetDecodeBase64 turns on decoding base64 tokens, as in Kubernetes Secret
+// manifests, and scanning the decoded text with the same patterns
+func (s *Scanner) SetDecodeBase64(enabled bool) {
+	s.decodeBase64 = enabled
+}
+
+// SetScanImageMetadata enables or disables reading the EXIF and XMP of
+// images for coordinates, author names and serial numbers (on by default,
+// it needs no OCR)
+func (s *Scanner) SetScanImageMetadata(enabled bool) {
+	s.imageMetadata = enabled
+}
+
+// GetHighEntropyDetector returns the high-entropy detector to tune its
+// thresholds, or nil when the detection is off
+func (s *Scanner) GetHighEntropyDetector() *HighEntropyDetector {
+	return s.highEntropy
+}
+
+// coverageOptions returns the optional capabilities this scanner was asked for
+func (s *Scanner) coverageOptions() CoverageOptions {
+	if s.docExtractor == nil {
+		return CoverageOptions{}
+	}
+	return CoverageOptions{
+		Documents: s.scanDocuments,
+		OCR:       s.docExtractor.enableOCR,
+		Archives:  s.scanArchives,
+	}
+}
+
+// capabilityEnabled reports whether files of a capability are scanned at all
+func (s *Scanner) capabilityEnabled(capability Capability) bool {
+	opts := s.coverageOptions()
+	switch capability {
+	case CapabilityDocuments:
+		return opts.Documents
+	case CapabilityImageOCR:
+		return opts.OCR
+	case CapabilityArchives:
+		return opts.Archives
+	}
+	return true
+}
+
+// noteIgnoredFile counts a file ignored by extension against the capability
+// that would have scanned it
+func (s *Scanner) noteIgnoredFile(filePath string) {
+	if !s.ignoreList.shouldIgnoreByExtension(filePath) {
+		return
+	}
+	ext := strings.ToLower(filepath.Ext(filePath))
+	if len(s.onlyExtensions) > 0 && !s.onlyExtensions[ext] {
+		return
+	}
+	if capability, ok := capabilityForExtension(ext); ok && !s.capabilityEnabled(capability) {
+		s.result.AddCapabilityGap(capability)
+	}
+}
+
+// fileError records a file or directory that could not be read, keeping
+// the cause in SkipReasons
+func (s *Scanner) fileError(path string, stage ErrorStage, err error) {
+	path = s.copies.source(path)
+	s.scanError(path, stage, err)
+	s.result.AddSkipReason(path, skipReason("skip.read_error", fileErrorCause(err)))
+}
+
+// skipForCapability skips a file that needs a capability which is off
+func (s *Scanner) skipForCapability(filePath string, capability Capability, reason SkipReason) {
+	s.result.IncrementFilesSkipped()
+	s.result.AddSkipReason(filePath, reason)
+	s.result.AddCapabilityGap(capability)
+}
+
 // Scan recursively scans a directory for sensitive data
 func (s *Scanner) Scan(rootDir string) (*ScanResult, error) {
+	return s.ScanContext(context.Background(), rootDir)
+}
+
+// ScanContext is Scan that stops when ctx is cancelled. No new file or
+// directory is started after that, files already being scanned abort their
+// extraction, and the partial result is returned with Cancelled set together
+// with an error matching ErrCancelled
+func (s *Scanner) ScanContext(ctx context.Context, rootDir string) (*ScanResult, error) {
+	stopGate := s.beginScan(ctx, rootDir)
+	defer stopGate()
+	// The summary is sent once the result is final, after the last finding
+	s.notify = startNotify(s.notifier, s.notifyMode, s.result)
+	ended := false
+	defer func() {
+		s.notify.finish(ended)
+		s.notify = nil
+	}()
+	s.prepareIgnoreList(rootDir)
+	s.gitTracked = s.loadGitTracked(ctx, rootDir)
+	s.logScanStart(rootDir)
+
+	// A broken patterns file stops the scan, so its rules are not silently lost
+	rootPatterns, rootAllowlist, err := loadRootPatterns(s.patterns, rootDir, s.rootPatterns)
+	s.rootPatterns = rootPatterns
+	s.rootAllowlist = rootAllowlist
+	if err != nil {
+		return s.result, err
+	}
+
+	// Restart the regex timers of this scan
+	if s.patterns.Profiling() {
+		s.patterns.SetProfiling(true)
+	}
+	s.cache = s.openCache(rootDir)
+	defer func() { s.cache = nil }()
+
+	// A missing or unreadable root is still walked (and counted as an
+	// error), but the caller gets a typed error alongside the result
+	rootErr := checkRoot(rootDir)
+
+	var enumerated <-chan struct{}
+	if s.enumerate {
+		enumerated = s.startEnumeration(ctx, rootDir)
+	}
+
+	s.runWalk(rootDir)
+	if enumerated != nil {
+		<-enumerated
+	}
+
+	s.result.EndTime = time.Now().Unix()
+	s.finishMetrics()
+	ended = true
+	s.result.Coverage = BuildCoverage(s.result, s.coverageOptions(), s.deps)
+	if s.patterns.Profiling() {
+		s.result.SetRegexTimes(s.patterns.RegexTimes())
+	}
+	if err := cancelledError(ctx); err != nil {
+		s.result.Cancelled = true
+		s.logScanEnd(rootDir, err)
+		return s.result, err
+	}
+	// A cache that cannot be written only makes the next scan a full one
+	if s.cache != nil && rootErr == nil {
+		s.cache.save()
+	}
+	s.logScanEnd(rootDir, rootErr)
+	return s.result, rootErr
+}
+
+// beginScan resets the result and counters for a new scan of root. The
+// returned function must be called when the scan ends
+func (s *Scanner) beginScan(ctx context.Context, root string) (stop func() bool) {
+	// Files a previous scan abandoned must not see this one; their
+	// contexts are cancelled, so they end soon
+	s.fileScans.Wait()
 	s.startTime = time.Now().Unix()
 	s.result = NewScanResult()
 	s.result.StartTime = s.startTime
+	s.result.Root = root
+	s.result.SetSpill(s.spill)
+	s.current.Store(s.result)
+	s.queued.Store(0)
+	s.done.Store(0)
+	s.currentFile.Store(nil)
+	s.enumerated.Store(nil)
+	s.gate.resetTotal()
+	s.ctx = ctx
+	s.source = localFiles
+	s.startMetrics()
 
 	// Initialize ignore list with defaults
 	s.ignoreList.AddDefaultIgnores()
-	
+
+	// A paused scan must still notice the cancellation
+	stopAfter := context.AfterFunc(ctx, s.gate.stop)
+	metrics := s.metrics
+	return func() bool {
+		if metrics != nil {
+			metrics.halt()
+		}
+		return stopAfter()
+	}
+}
+
+// prepareIgnoreList applies the scan options and the .dataLeak-ignore file
+// of root to the ignore list
+func (s *Scanner) prepareIgnoreList(root string) {
 	// Enable document/image/archive scanning if configured
 	if s.scanDocuments {
 		s.ignoreList.EnableDocumentScanning()
@@ -80,65 +407,172 @@ func (s *Scanner) Scan(rootDir string) (*ScanResult, error) {
 	if s.docExtractor != nil && s.docExtractor.enableOCR {
 		s.ignoreList.EnableImageScanning()
 	}
+	if s.imageMetadata {
+		s.ignoreList.EnableImageMetadata()
+	}
 	if s.scanArchives {
 		s.ignoreList.EnableArchiveScanning()
 	}
+	// A raised -max-size applies to documents too
+	if s.docExtractor != nil && s.maxFileSize > s.docExtractor.maxFileSize {
+		s.docExtractor.SetMaxFileSize(s.maxFileSize)
+	}
 
 	// Try to load .dataLeak-ignore file
-	ignoreFilePath := filepath.Join(rootDir, ".dataLeak-ignore")
-	_ = s.ignoreList.LoadFromFile(ignoreFilePath)
+	if _, ok := s.source.(*LocalSource); ok {
+		_ = s.ignoreList.LoadFromFile(filepath.Join(root, ".dataLeak-ignore"))
+	}
+	s.globRoot = root
+}
 
-	// Start recursive scan
-	var wg sync.WaitGroup
-	wg.Add(1)
-	go s.scanDirectory(rootDir, &wg)
-	wg.Wait()
+// fileQueueLength is how many discovered files wait for a worker; the
+// walk blocks when the queue is full, so memory does not grow with the tree
+const fileQueueLength = 1024
 
-	s.result.EndTime = time.Now().Unix()
-	return s.result, nil
+// walkTask is a directory waiting to be listed. ignore holds the .gitignore
+// rules of the parent directories when gitignore support is on
+type walkTask struct {
+	dir      string
+	ignore   *gitignore
+	included bool // An include glob covers the whole directory, see globDir
 }
 
-// scanDirectory recursively scans a directory
-func (s *Scanner) scanDirectory(dir string, wg *sync.WaitGroup) {
-	defer wg.Done()
+// runWalk walks rootDir and scans its files with a fixed pool of workers:
+// one walker lists directories and queues files, the workers take them
+// from the queue, so the goroutine count does not depend on the tree size
+func (s *Scanner) runWalk(rootDir string) {
+	files := make(chan string, fileQueueLength)
+	var workers sync.WaitGroup
+	for i := 0; i < s.workers; i++ {
+		workers.Add(1)
+		go s.fileWorker(files, &workers)
+	}
+	s.walkTree(rootDir, newDirWalk(rootDir), files)
+	close(files)
+	workers.Wait()
+}
+
+// walkTree lists the directories of rootDir depth first with an explicit
+// stack and queues the files that pass the ignore rules
+func (s *Scanner) walkTree(rootDir string, walk *dirWalk, files chan<- string) {
+	stack := []walkTask{{dir: rootDir, included: len(s.includeGlobs) == 0}}
+	for len(stack) > 0 {
+		task := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		subdirs, ok := s.walkDirectory(task, walk, files)
+		if !ok {
+			return
+		}
+		// Reversed, so subdirectories are walked in listing order
+		for i := len(subdirs) - 1; i >= 0; i-- {
+			stack = append(stack, subdirs[i])
+		}
+	}
+}
 
-	entries, err := os.ReadDir(dir)
+// walkDirectory queues the files of one directory and returns its
+// subdirectories; ok is false once the scan is cancelled
+func (s *Scanner) walkDirectory(task walkTask, walk *dirWalk, files chan<- string) (subdirs []walkTask, ok bool) {
+	s.gate.wait()
+	if s.ctx.Err() != nil {
+		return nil, false
+	}
+	dir, ignore := task.dir, task.ignore
+	if s.respectGitignore {
+		ignore = ignore.withFile(dir)
+	}
+
+	entries, err := fsutil.ReadDir(dir)
 	if err != nil {
-		s.result.ErrorCount++
-		return
+		s.fileError(dir, StageRead, err)
+		return nil, true
 	}
 
 	for _, entry := range entries {
+		if s.ctx.Err() != nil {
+			return nil, false
+		}
 		fullPath := filepath.Join(dir, entry.Name())
 
 		if s.ignoreList.ShouldIgnorePath(fullPath) {
+			if !entry.IsDir() {
+				s.noteIgnoredFile(fullPath)
+			}
+			continue
+		}
+
+		if ignore.ignored(fullPath, entry.IsDir()) {
+			s.result.IncrementFilesSkipped()
+			s.result.AddSkipReason(fullPath, skipText("gitignore"))
 			continue
 		}
 
-		if entry.IsDir() {
-			if !s.ignoreList.ShouldIgnoreDirectory(fullPath) {
-				wg.Add(1)
-				go s.scanDirectory(fullPath, wg)
+		kind, reason := s.resolveEntry(fullPath, entry, walk)
+		switch kind {
+		case entrySkip:
+			s.result.IncrementFilesSkipped()
+			s.result.AddSkipReason(fullPath, reason)
+		case entryDir:
+			if s.ignoreList.ShouldIgnoreDirectory(fullPath) {
+				continue
+			}
+			walkDir, included, reason := s.globDir(fullPath, task.included)
+			if reason != "" {
+				s.result.IncrementFilesSkipped()
+				s.result.AddSkipReason(fullPath, skipText(reason))
+			}
+			if walkDir {
+				subdirs = append(subdirs, walkTask{dir: fullPath, ignore: ignore, included: included})
+			}
+		default:
+			if scan, reason := s.globFile(fullPath, task.included); !scan {
+				s.result.IncrementFilesSkipped()
+				if reason != "" {
+					s.result.AddSkipReason(fullPath, skipText(reason))
+				}
+				continue
+			}
+			s.queued.Add(1)
+			select {
+			case files <- fullPath:
+			case <-s.ctx.Done():
+				return nil, false
 			}
-		} else {
-			wg.Add(1)
-			go s.scanFile(fullPath, wg)
 		}
 	}
+	return subdirs, true
 }
 
-// scanFile scans a single file for sensitive patterns
-func (s *Scanner) scanFile(filePath string, wg *sync.WaitGroup) {
+// fileWorker scans queued files until the queue is closed
+func (s *Scanner) fileWorker(files <-chan string, wg *sync.WaitGroup) {
 	defer wg.Done()
+	for filePath := range files {
+		// No new file starts while the scan is paused
+		s.gate.wait()
+		if s.ctx.Err() != nil {
+			continue // Drain the queue so the walker is not blocked
+		}
+		s.fileStarted(filePath)
+		found := s.timeFile(filePath)
+		s.fileScanned(filePath, found)
+	}
+}
 
-	// Acquire semaphore slot
-	s.semaphore <- struct{}{}
-	defer func() { <-s.semaphore }()
-
-	fileInfo, err := os.Stat(filePath)
+// processFile scans a file according to its type and returns the number of
+// findings recorded for it. Reading and extracting the file run under the
+// file timeout
+func (s *Scanner) processFile(filePath string) int {
+	fileInfo, err := s.source.Stat(filePath)
 	if err != nil {
-		s.result.IncrementErrorCount()
-		return
+		s.fileError(filePath, StageRead, err)
+		return 0
+	}
+
+	// Reading a pipe, socket or device could block the worker
+	if isSpecialFile(fileInfo.Mode()) {
+		s.result.IncrementFilesSkipped()
+		s.result.AddSkipReason(filePath, skipReason("skip.special"))
+		return 0
 	}
 
 	ext := strings.ToLower(filepath.Ext(filePath))
@@ -146,140 +580,308 @@ func (s *Scanner) scanFile(filePath string, wg *sync.WaitGroup) {
 	// Check if only specific extensions should be scanned
 	if len(s.onlyExtensions) > 0 {
 		if !s.onlyExtensions[ext] {
-			s.result.IncrementFilesSkipped()
-			return
+			s.result.AddExtensionSkip(filePath, ext)
+			return 0
 		}
 	}
 
 	// Skip files that are too large
 	if fileInfo.Size() > s.maxFileSize {
 		s.result.IncrementFilesSkipped()
-		return
+		return 0
+	}
+
+	// Unchanged files of an incremental scan come from the cache
+	if s.cache != nil {
+		if found, ok := s.replayCached(filePath, fileInfo); ok {
+			return found
+		}
+		defer s.cache.discard(filePath)
+	}
+
+	// HEIC and TIF photos are not decoded here, only their metadata is read
+	if s.imageMetadata && metadataOnlyFormats[ext] {
+		return s.timed(CategoryImage, filePath, func() int {
+			return s.scanImageMetadataOnly(filePath, fileInfo.Size(), "", SkipReason{})
+		})
 	}
 
 	// Check if it's a document or archive that needs special handling
 	if s.docExtractor != nil {
-		isDocument := ext == ".pdf" || ext == ".docx" || ext == ".doc" || ext == ".xlsx" || ext == ".xls"
+		isDocument := documentFormats[ext] && (s.scanDocuments || !plainDocuments[ext])
 		isArchive := ext == ".zip" || ext == ".tar" || ext == ".gz" || ext == ".tgz"
 		isImage := ext == ".png" || ext == ".jpg" || ext == ".jpeg" || ext == ".gif" || ext == ".bmp" || ext == ".tiff"
 
 		// Handle documents
 		if isDocument && s.scanDocuments {
-			s.scanDocumentFile(filePath, fileInfo.Size())
-			return
+			return s.withFileTimeout(filePath, func(ctx context.Context) int {
+				return s.timed(CategoryDocument, filePath, func() int {
+					return s.withLocalFile(ctx, filePath, func(local string) int {
+						return s.scanDocumentFile(ctx, local, fileInfo.Size())
+					})
+				})
+			})
 		}
 
 		// Handle archives
 		if isArchive && s.scanArchives {
-			s.scanArchiveFile(filePath, fileInfo.Size())
-			return
+			return s.withFileTimeout(filePath, func(ctx context.Context) int {
+				return s.timed(CategoryArchive, filePath, func() int {
+					return s.withLocalFile(ctx, filePath, func(local string) int {
+						return s.scanArchiveFile(ctx, local, fileInfo.Size())
+					})
+				})
+			})
 		}
 
 		// Handle images (OCR)
 		if isImage && s.docExtractor.enableOCR {
-			s.scanImageFile(filePath, fileInfo.Size())
-			return
+			return s.withFileTimeout(filePath, func(ctx context.Context) int {
+				found := s.timed(CategoryOCR, filePath, func() int {
+					return s.withLocalFile(ctx, filePath, func(local string) int {
+						return s.scanImageFile(ctx, local, fileInfo.Size())
+					})
+				})
+				if !recordFile(ctx) {
+					return 0
+				}
+				metadata := s.timed(CategoryImage, filePath, func() int {
+					found, _ := s.scanImageMetadata(filePath)
+					return found
+				})
+				return metadata + found
+			})
 		}
 		
 		// If it's a document/image but scanning is not enabled for that type
 		if isDocument && !s.scanDocuments {
-			s.result.IncrementFilesSkipped()
-			s.result.AddSkipReason(filePath, "сканирование документов отключено")
-			return
+			s.skipForCapability(filePath, CapabilityDocuments, skipReason("skip.documents_off"))
+			return 0
 		}
 		if isImage && !s.docExtractor.enableOCR {
-			s.result.IncrementFilesSkipped()
-			s.result.AddSkipReason(filePath, "OCR отключён (установите Tesseract)")
-			return
+			return s.timed(CategoryImage, filePath, func() int {
+				return s.scanImageMetadataOnly(filePath, fileInfo.Size(), CapabilityImageOCR, skipReason("skip.ocr_off_install"))
+			})
 		}
 	} else {
 		// No document extractor - skip documents/images
-		isDocument := ext == ".pdf" || ext == ".docx" || ext == ".doc" || ext == ".xlsx" || ext == ".xls"
+		isDocument := documentFormats[ext] && !plainDocuments[ext]
 		isImage := ext == ".png" || ext == ".jpg" || ext == ".jpeg" || ext == ".gif" || ext == ".bmp" || ext == ".tiff"
-		if isDocument || isImage {
+		if isDocument {
+			s.skipForCapability(filePath, CapabilityDocuments, skipReason("skip.no_extractor"))
+			return 0
+		}
+		if isImage {
+			return s.timed(CategoryImage, filePath, func() int {
+				return s.scanImageMetadataOnly(filePath, fileInfo.Size(), CapabilityImageOCR, skipReason("skip.no_extractor"))
+			})
+		}
+	}
+
+	return s.withTextTimeout(filePath, func(ctx context.Context) int {
+		return s.timed(CategoryText, filePath, func() int {
+			return s.scanTextFile(ctx, filePath, fileInfo.Size())
+		})
+	})
+}
+
+// scanTextFile scans a file that is read as text
+func (s *Scanner) scanTextFile(ctx context.Context, filePath string, fileSize int64) int {
+	// Remote files are read once, so the binary check peeks at the stream
+	file, err := s.source.Open(filePath)
+	if err != nil {
+		if recordFile(ctx) {
+			s.fileError(filePath, StageRead, err)
+		}
+		return 0
+	}
+	defer file.Close()
+	content := bufio.NewReader(file)
+	if isBinaryContent(content) {
+		if recordFile(ctx) {
 			s.result.IncrementFilesSkipped()
-			s.result.AddSkipReason(filePath, "нет экстрактора (включите -docs или -ocr)")
-			return
 		}
+		return 0
 	}
 
-	// Try to detect if file is binary
-	if s.isBinaryFile(filePath) {
+	findings, err := s.scanContent(ctx, filePath, content)
+	if !recordFile(ctx) {
+		return 0
+	}
+	if errors.Is(err, errFileTimeout) {
+		// The findings of the part read are kept, the file counts as skipped
+		found := s.addFindings(filePath, findings)
 		s.result.IncrementFilesSkipped()
-		return
+		s.result.AddSkipReason(filePath, skipReason("skip.timeout_partial", s.fileTimeout.String()))
+		return found
 	}
-
-	findings, err := s.scanFileContent(filePath)
 	if err != nil {
-		s.result.IncrementErrorCount()
-		return
+		if !errors.Is(err, ErrCancelled) {
+			s.fileError(filePath, StageRead, err)
+		}
+		return 0
 	}
 
+	found := s.addFindings(filePath, findings)
+	s.fileDone(filePath, fileSize)
+	return found
+}
+
+// addFindings records the findings of a file and reports each one to the
+// finding callback; it returns how many were added. owner is the scanned
+// file the findings come from, for the cache of incremental scans
+func (s *Scanner) addFindings(owner string, findings []*Finding) int {
+	// Findings of documents and protected files are not built by the pipeline,
+	// the overrides are applied to them here
+	findings = s.filterFindings(findings)
+	if s.cache != nil && owner != "" {
+		s.cache.add(owner, findings)
+		s.cache.truncated(owner, findings, s.result)
+	}
+	limit := contextLimit(s.maxContextLength)
 	for _, finding := range findings {
+		truncateContext(finding, limit)
+		finding.FilePath = s.copies.source(finding.FilePath)
+		if finding.ArchivePath != "" {
+			finding.ArchivePath = s.copies.source(finding.ArchivePath)
+		}
 		s.result.AddFinding(finding)
+		if s.onFinding != nil {
+			s.onFinding(finding)
+		}
+		s.notify.finding(finding)
 	}
+	return len(findings)
+}
 
+// fileDone counts a file that was scanned through and keeps it in the
+// cache of an incremental scan
+func (s *Scanner) fileDone(filePath string, size int64) {
 	s.result.IncrementFilesScanned()
-	s.result.AddTotalSize(fileInfo.Size())
+	s.result.AddTotalSize(size)
+	if s.cache != nil {
+		s.cache.commit(filePath)
+	}
+}
+
+// fileStarted records the file a worker starts on for Progress
+func (s *Scanner) fileStarted(filePath string) {
+	s.currentFile.Store(&filePath)
+}
+
+// fileScanned reports a finished file to the file and progress callbacks
+func (s *Scanner) fileScanned(filePath string, findings int) {
+	s.done.Add(1)
+	if s.onFileScanned != nil {
+		s.onFileScanned(filePath, findings)
+	}
+	if s.onProgress != nil {
+		progress := s.Progress()
+		s.onProgress(progress.FilesProcessed, progress.FilesSkipped, progress.ErrorCount)
+	}
 }
 
 // scanDocumentFile scans a document file (PDF, DOCX, etc.)
-func (s *Scanner) scanDocumentFile(filePath string, fileSize int64) {
+func (s *Scanner) scanDocumentFile(ctx context.Context, filePath string, fileSize int64) int {
 	if s.docExtractor == nil {
 		s.result.IncrementFilesSkipped()
-		s.result.AddSkipReason(filePath, "нет экстрактора документов")
-		return
+		s.result.AddSkipReason(filePath, skipReason("skip.no_document_extractor"))
+		return 0
 	}
 
 	ext := strings.ToLower(filepath.Ext(filePath))
-	hasFindings := false
 
-	content, err := s.docExtractor.ExtractText(filePath)
+	content, err := s.docExtractor.ExtractTextContext(ctx, filePath)
 	if err != nil {
-		s.result.IncrementErrorCount()
-		s.result.AddSkipReason(filePath, "ошибка извлечения: "+err.Error())
-		return
+		if recordFile(ctx) {
+			s.scanError(filePath, StageExtract, err)
+			s.result.AddSkipReason(filePath, skipReason("skip.extract_error", err.Error()))
+		}
+		return 0
+	}
+
+	// Protected PDF that no supplied password unlocked
+	if content.Encrypted && errors.Is(content.Error, ErrPDFEncrypted) {
+		if !recordFile(ctx) {
+			return 0
+		}
+		found := s.addFindings(filePath, []*Finding{protectedPDFFinding(filePath, content.Error)})
+		s.result.IncrementFilesSkipped()
+		s.result.AddSkipReason(filePath, skipReason("skip.pdf_protected"))
+		return found
 	}
 
 	// Scan extracted text for patterns if we have text
+	var findings []*Finding
 	if content.Text != "" {
-		findings := s.scanTextContent(filePath, content.Text)
-		for _, finding := range findings {
-			s.result.AddFinding(finding)
-			hasFindings = true
-		}
+		findings = s.scanTextContent(ctx, filePath, content.Text)
+		setFindingPages(findings, content)
 	}
 
 	// For PDFs, also run image analysis on pages if OCR is enabled
+	pdfOCRMissing := false
 	if ext == ".pdf" && s.docExtractor.enableOCR {
-		pdfFindings := s.analyzePDFAsDocument(filePath)
-		for _, finding := range pdfFindings {
-			s.result.AddFinding(finding)
-			hasFindings = true
+		if s.deps.IsAvailable(DependencyPoppler) {
+			findings = append(findings, s.analyzePDFAsDocument(ctx, filePath)...)
+		} else {
+			pdfOCRMissing = true
 		}
 	}
 
-	if !hasFindings && content.Text == "" {
+	// Nothing is recorded before the slow steps are through
+	if !recordFile(ctx) {
+		return 0
+	}
+	found := s.addFindings(filePath, findings)
+
+	// The pages past the limit were not read
+	if content.Truncated {
+		s.result.AddSkipReason(filePath, skipReason("skip.pdf_pages", s.docExtractor.maxPDFPages, content.PageCount))
+	}
+
+	if found == 0 && content.Text == "" {
 		s.result.IncrementFilesSkipped()
 		if content.Error != nil {
-			s.result.AddSkipReason(filePath, "OCR ошибка: "+content.Error.Error())
+			s.result.AddSkipReason(filePath, skipReason("skip.ocr_error", content.Error.Error()))
 		} else {
-			s.result.AddSkipReason(filePath, "пустой текст (возможно сканированный PDF, нужен OCR)")
+			s.result.AddSkipReason(filePath, skipReason("skip.empty_text"))
 		}
-		return
+		if ext == ".pdf" {
+			s.result.AddCapabilityGap(CapabilityPDFOCR)
+		}
+		return found
 	}
 
-	s.result.IncrementFilesScanned()
-	s.result.AddTotalSize(fileSize)
+	// Text was found, but the pages could not be checked for document images
+	if pdfOCRMissing {
+		s.result.AddCapabilityGap(CapabilityPDFOCR)
+	}
+
+	s.fileDone(filePath, fileSize)
+	return found
+}
+
+// protectedPDFFinding reports a PDF whose content could not be checked
+func protectedPDFFinding(filePath string, err error) *Finding {
+	return &Finding{
+		FilePath:    filePath,
+		LineNumber:  1,
+		PatternType: PatternProtectedPDF,
+		Severity:    Medium,
+		Description: "Password-protected PDF, content not checked",
+		MatchedText: "/Encrypt",
+		Context:     err.Error(),
+		RiskScore:   40,
+	}
 }
 
 // analyzePDFAsDocument converts PDF pages to images and runs document detection
-func (s *Scanner) analyzePDFAsDocument(filePath string) []*Finding {
+func (s *Scanner) analyzePDFAsDocument(ctx context.Context, filePath string) []*Finding {
 	var findings []*Finding
 
 	// Check if pdftoppm is available
-	pdftoppm, err := exec.LookPath("pdftoppm")
-	if err != nil {
+	pdftoppm, ok := s.deps.ToolPath("pdftoppm")
+	if !ok {
 		return findings // Can't convert PDF without pdftoppm
 	}
 
@@ -292,7 +894,8 @@ func (s *Scanner) analyzePDFAsDocument(filePath string) []*Finding {
 
 	// Convert PDF to images
 	outputPrefix := filepath.Join(tmpDir, "page")
-	cmd := exec.Command(pdftoppm, "-png", "-r", "150", filePath, outputPrefix)
+	args := append(append([]string{"-png", "-r", "150"}, s.docExtractor.pdfPageArgs()...), filePath, outputPrefix)
+	cmd := exec.CommandContext(ctx, pdftoppm, args...)
 	if err := cmd.Run(); err != nil {
 		return findings
 	}
@@ -303,7 +906,7 @@ func (s *Scanner) analyzePDFAsDocument(filePath string) []*Finding {
 		return findings
 	}
 
-	imageAnalyzer := NewImageAnalyzer(true)
+	imageAnalyzer := s.newImageAnalyzer()
 	
 	for _, entry := range entries {
 		if !strings.HasSuffix(entry.Name(), ".png") {
@@ -319,6 +922,7 @@ func (s *Scanner) analyzePDFAsDocument(filePath string) []*Finding {
 			finding := &Finding{
 				FilePath:     filePath,
 				LineNumber:   pageNum,
+				PageNumber:   pageNum,
 				PatternType:  PatternPassport,
 				Severity:     Critical,
 				Description:  imageAnalyzer.GetDocumentTypeDescription(analysisResult.DocumentType) + " обнаружен в PDF",
@@ -333,6 +937,9 @@ func (s *Scanner) analyzePDFAsDocument(filePath string) []*Finding {
 			
 			findings = append(findings, finding)
 		}
+		if err == nil && analysisResult != nil {
+			findings = append(findings, s.barcodeFindings(ctx, filePath, analysisResult.Barcodes)...)
+		}
 	}
 
 	return findings
@@ -351,44 +958,90 @@ func extractPageNumber(filename string) int {
 }
 
 // scanArchiveFile scans contents of an archive
-func (s *Scanner) scanArchiveFile(filePath string, fileSize int64) {
+func (s *Scanner) scanArchiveFile(ctx context.Context, filePath string, fileSize int64) int {
 	if s.docExtractor == nil {
 		s.result.IncrementFilesSkipped()
-		return
+		return 0
 	}
 
-	content, err := s.docExtractor.ExtractText(filePath)
+	// Each member is scanned under its own path inside the archive; the
+	// members are recorded once the whole archive is read
+	var findings []*Finding
+	skipped := make(map[string]SkipReason)
+	read := 0
+	nested, err := s.docExtractor.walkArchive(ctx, filePath, func(member ArchiveEntry) error {
+		if !member.SkipReason.IsZero() {
+			skipped[member.Path] = member.SkipReason
+			return nil
+		}
+		read++
+		inner := strings.TrimPrefix(member.Path, filePath+ArchiveSeparator)
+		for _, finding := range s.scanTextContent(ctx, member.Path, member.Text) {
+			finding.ArchivePath, finding.InnerPath = filePath, inner
+			findings = append(findings, finding)
+		}
+		return nil
+	})
+	if !recordFile(ctx) {
+		return 0
+	}
+	paths := make([]string, 0, len(skipped))
+	for path := range skipped {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		s.result.AddSkipReason(path, skipped[path])
+	}
+	found := s.addFindings(filePath, findings)
+	s.result.AddNestedArchives(nested)
+	if errors.Is(err, ErrArchiveLimit) {
+		// Findings of the members read before the limit are kept
+		s.result.AddSkipReason(filePath, skipText(err.Error()))
+		s.result.IncrementFilesSkipped()
+		return found
+	}
 	if err != nil {
-		s.result.IncrementErrorCount()
-		return
+		s.fileError(filePath, StageExtract, err)
+		return found
 	}
 
-	if content.Text == "" {
+	if read == 0 {
 		s.result.IncrementFilesSkipped()
-		return
+		return 0
 	}
 
-	// Scan extracted text for patterns
-	findings := s.scanTextContent(filePath+" (архив)", content.Text)
-	for _, finding := range findings {
-		s.result.AddFinding(finding)
-	}
+	s.fileDone(filePath, fileSize)
+	return found
+}
 
-	s.result.IncrementFilesScanned()
-	s.result.AddTotalSize(fileSize)
+// newImageAnalyzer creates an image analyzer that also scores the OCR
+// keywords of enabled packs
+func (s *Scanner) newImageAnalyzer() *ImageAnalyzer {
+	analyzer := NewImageAnalyzer(true)
+	for _, pack := range s.patterns.Packs() {
+		analyzer.AddPackKeywords(pack)
+	}
+	return analyzer
 }
 
 // scanImageFile scans an image using OCR
-func (s *Scanner) scanImageFile(filePath string, fileSize int64) {
+func (s *Scanner) scanImageFile(ctx context.Context, filePath string, fileSize int64) int {
 	if s.docExtractor == nil || !s.docExtractor.enableOCR {
-		s.result.IncrementFilesSkipped()
-		s.result.AddSkipReason(filePath, "OCR отключён")
-		return
+		s.skipForCapability(filePath, CapabilityImageOCR, skipReason("skip.ocr_off"))
+		return 0
+	}
+
+	// Without Tesseract only the visual signals are checked
+	if !s.deps.IsAvailable(DependencyTesseract) && !NativeOCRAvailable() {
+		recordLater(ctx, func() { s.result.AddCapabilityGap(CapabilityImageOCR) })
 	}
 
+	var findings []*Finding
+
 	// Use multi-signal image analyzer
-	imageAnalyzer := NewImageAnalyzer(true)
-	analysisResult, err := imageAnalyzer.AnalyzeImage(filePath)
+	imageAnalyzer := s.newImageAnalyzer()
+	analysisResult, err := s.docExtractor.analyzeImage(filePath, imageAnalyzer)
 	
 	if err == nil && analysisResult != nil && analysisResult.IsDocument {
 		// Document detected with high confidence - add as critical finding
@@ -409,156 +1062,176 @@ func (s *Scanner) scanImageFile(filePath string, fileSize int64) {
 			finding.Context = "MRZ: " + analysisResult.MRZData.Surname + " " + analysisResult.MRZData.GivenNames
 		}
 		
-		s.result.AddFinding(finding)
+		findings = append(findings, finding)
 	}
 
-	// Also try OCR text extraction
-	content, err := s.docExtractor.ExtractText(filePath)
-	if err != nil {
-		s.result.IncrementFilesScanned()
-		s.result.AddTotalSize(fileSize)
-		return
+	// What the barcodes of the image carry is scanned like its text
+	if err == nil && analysisResult != nil {
+		findings = append(findings, s.barcodeFindings(ctx, filePath, analysisResult.Barcodes)...)
 	}
 
-	if content.Text == "" {
-		s.result.IncrementFilesScanned()
-		s.result.AddTotalSize(fileSize)
-		return
+	// Also try OCR text extraction
+	content, err := s.docExtractor.ExtractTextContext(ctx, filePath)
+	if !recordFile(ctx) {
+		return 0
 	}
-
-	// Scan extracted text for patterns
-	findings := s.scanTextContent(filePath+" (OCR)", content.Text)
-	for _, finding := range findings {
-		s.result.AddFinding(finding)
+	if err != nil {
+		// A missing Tesseract is reported by the coverage, not per image
+		var missing *ErrDependencyMissing
+		if !errors.As(err, &missing) && !errors.Is(err, ErrCancelled) {
+			s.scanError(filePath, StageOCR, err)
+		}
+	} else if content.Text != "" {
+		// Scan extracted text for patterns
+		findings = append(findings, s.scanTextContent(ctx, filePath+" (OCR)", content.Text)...)
 	}
 
-	s.result.IncrementFilesScanned()
-	s.result.AddTotalSize(fileSize)
+	found := s.addFindings(filePath, findings)
+	s.fileDone(filePath, fileSize)
+	return found
 }
 
 // scanTextContent scans text content for patterns
-func (s *Scanner) scanTextContent(sourcePath, text string) []*Finding {
+func (s *Scanner) scanTextContent(ctx context.Context, sourcePath, text string) []*Finding {
 	var findings []*Finding
 	lines := strings.Split(text, "\n")
+	sup := suppressions{}
+	sup.noteLines(lines)
 
+	kept := 0
+	stopped := false
 	for lineNum, line := range lines {
 		if strings.TrimSpace(line) == "" {
 			continue
 		}
+		if s.capReached(kept) {
+			stopped = true
+			lines = lines[:lineNum]
+			break
+		}
 
-		patterns := s.patterns.FindAll(line)
-		for _, pattern := range patterns {
-			pattern.LineNumber = lineNum + 1
-			pattern.FilePath = sourcePath
-			pattern.Context = line
-			pattern.EntropyScore = s.riskScorer.entropyCalculator.CalculateEntropy(pattern.MatchText)
-
-			riskScore := s.riskScorer.CalculateRiskScore(pattern)
+		lineFindings := s.pipeline().analyzeLine(sourcePath, line, lineNum+1, nil)
+		kept += s.countKept(lineFindings)
+		findings = append(findings, lineFindings...)
+	}
 
-			finding := &Finding{
-				FilePath:     sourcePath,
-				LineNumber:   lineNum + 1,
-				ColumnStart:  pattern.StartIndex,
-				ColumnEnd:    pattern.EndIndex,
-				PatternType:  pattern.Type,
-				Severity:     pattern.Severity,
-				Description:  pattern.Description,
-				MatchedText:  pattern.MatchText,
-				Context:      line,
-				EntropyScore: pattern.EntropyScore,
-				RiskScore:    riskScore,
-			}
-			findings = append(findings, finding)
-		}
+	if s.pipeline().hasContentDetectors(sourcePath) {
+		findings = mergeContentFindings(findings, s.pipeline().analyzeContent(sourcePath, lines))
 	}
+	attachContextLines(findings, lines, s.contextLines, contextLimit(s.maxContextLength))
 
-	return findings
+	findings, suppressed := sup.filter(findings)
+	recordLater(ctx, func() { s.result.AddSuppressed(suppressed) })
+	return s.capFindings(ctx, sourcePath, findings, stopped)
 }
 
 // scanFileContent scans the content of a single file
 func (s *Scanner) scanFileContent(filePath string) ([]*Finding, error) {
-	file, err := os.Open(filePath)
+	file, err := s.source.Open(filePath)
 	if err != nil {
 		return nil, err
 	}
 	defer file.Close()
+	return s.scanContent(s.ctx, filePath, file)
+}
 
-	var findings []*Finding
-	scanner := bufio.NewScanner(file)
+// scanContent scans the content of a file read from r. When the file
+// times out, the findings of the part read are returned with errFileTimeout
+func (s *Scanner) scanContent(ctx context.Context, filePath string, r io.Reader) ([]*Finding, error) {
+	var findings, lineFindings []*Finding
+	var lines []string
+	var firstChunk string
+	keepLines := s.pipeline().hasContentDetectors(filePath)
+	limit := contextLimit(s.maxContextLength)
+	window := newContextWindow(s.contextLines, limit)
+	sup := suppressions{}
+	// JSON, YAML and .env files are also read as documents
+	doc := newStructuredBuffer(filePath)
+	if doc != nil {
+		r = io.TeeReader(r, doc)
+	}
+	reader := newLineReader(r, s.maxLineLength)
 	lineNum := 1
-
-	for scanner.Scan() {
-		line := scanner.Text()
-
-		// Skip empty lines
-		if strings.TrimSpace(line) == "" {
-			lineNum++
-			continue
+	kept := 0
+	stopped := false
+	timedOut := false
+
+	for {
+		chunk, err := reader.Next()
+		if err == io.EOF {
+			break
 		}
-
-		// Find patterns in line
-		patterns := s.patterns.FindAll(line)
-		for _, pattern := range patterns {
-			// Calculate risk score
-			pattern.LineNumber = lineNum
-			pattern.FilePath = filePath
-			pattern.Context = line
-			pattern.EntropyScore = s.riskScorer.entropyCalculator.CalculateEntropy(pattern.MatchText)
-
-			riskScore := s.riskScorer.CalculateRiskScore(pattern)
-
-			// Create finding
-			finding := &Finding{
-				FilePath:     filePath,
-				LineNumber:   lineNum,
-				ColumnStart:  pattern.StartIndex,
-				ColumnEnd:    pattern.EndIndex,
-				PatternType:  pattern.Type,
-				Severity:     pattern.Severity,
-				Description:  pattern.Description,
-				MatchedText:  pattern.MatchText,
-				Context:      line,
-				EntropyScore: pattern.EntropyScore,
-				RiskScore:    riskScore,
+		if err != nil {
+			return nil, err
+		}
+		// A file that timed out or a cancelled scan stops between chunks
+		if ctx.Err() != nil {
+			if context.Cause(ctx) != errFileTimeout {
+				return nil, cancelledError(ctx)
 			}
+			timedOut = true
+			break
+		}
+		// The rest of the file is not matched once the cap is reached
+		if chunk.Offset == 0 && s.capReached(kept) {
+			stopped = true
+			break
+		}
+		line := chunk.Text
+		// Content detectors and surrounding lines use the first chunk of a long line
+		if chunk.Offset == 0 {
+			firstChunk = line
+			if keepLines {
+				lines = append(lines, line)
+			}
+		}
+		if strings.Contains(line, SuppressMarker) {
+			sup.note(lineNum, line)
+		}
 
-			findings = append(findings, finding)
+		// Skip empty lines
+		if strings.TrimSpace(line) != "" {
+			// Find patterns in line
+			lineFindings = append(lineFindings, s.pipeline().analyzeChunk(filePath, chunk, lineNum)...)
 		}
 
-		lineNum++
+		if chunk.Final {
+			window.add(firstChunk, lineFindings)
+			kept += s.countKept(lineFindings)
+			findings = append(findings, lineFindings...)
+			lineFindings = lineFindings[:0]
+			lineNum++
+		}
 	}
-
-	if err := scanner.Err(); err != nil {
-		return nil, err
+	// The line the deadline passed in keeps the findings of its chunks read
+	if timedOut && len(lineFindings) > 0 {
+		window.add(firstChunk, lineFindings)
+		findings = append(findings, lineFindings...)
 	}
 
-	return findings, nil
-}
-
-// isBinaryFile checks if a file is likely binary
-func (s *Scanner) isBinaryFile(filePath string) bool {
-	file, err := os.Open(filePath)
-	if err != nil {
-		return true
+	if keepLines {
+		found := s.pipeline().analyzeContent(filePath, lines)
+		attachContextLines(found, lines, s.contextLines, limit)
+		findings = mergeContentFindings(findings, found)
 	}
-	defer file.Close()
-
-	// Read first 512 bytes
-	buf := make([]byte, 512)
-	n, _ := file.Read(buf)
-
-	if n == 0 {
-		return false
+	if !stopped && !timedOut {
+		findings = s.pipeline().scanStructured(filePath, doc, findings, s.contextLines, limit)
 	}
 
-	// Check for null bytes (typical of binary files)
-	for i := 0; i < n; i++ {
-		if buf[i] == 0 {
-			return true
-		}
+	findings, suppressed := sup.filter(findings)
+	recordLater(ctx, func() { s.result.AddSuppressed(suppressed) })
+	findings = s.capFindings(ctx, filePath, findings, stopped)
+	if timedOut {
+		return findings, errFileTimeout
 	}
+	return findings, nil
+}
 
-	return false
+// isBinaryContent checks if content is likely binary by a null byte in
+// its first 512 bytes, which stay buffered for reading
+func isBinaryContent(content *bufio.Reader) bool {
+	head, _ := content.Peek(512)
+	return bytes.IndexByte(head, 0) >= 0
 }
 
 // SetMaxFileSize sets the maximum file size to scan
@@ -566,9 +1239,31 @@ func (s *Scanner) SetMaxFileSize(size int64) {
 	s.maxFileSize = size
 }
 
-// SetMaxConcurrentFiles sets the maximum number of concurrent file scans
+// SetMaxLineLength sets the longest line scanned in one piece; longer lines
+// are scanned in overlapping chunks. Zero restores DefaultMaxLineLength
+func (s *Scanner) SetMaxLineLength(length int) {
+	s.maxLineLength = length
+}
+
+// SetMaxContextLength sets the longest context line, in characters, kept
+// with a finding; longer lines are cut around the match. Zero restores
+// DefaultMaxContextLength, a negative length keeps whole lines
+func (s *Scanner) SetMaxContextLength(length int) {
+	s.maxContextLength = length
+}
+
+// SetSpill makes the results of the next scans spill findings to disk past
+// config.Threshold, see SpillConfig. The caller closes the result when done
+func (s *Scanner) SetSpill(config SpillConfig) {
+	s.spill = config
+}
+
+// SetMaxConcurrentFiles sets the number of workers scanning files at once
 func (s *Scanner) SetMaxConcurrentFiles(max int) {
-	s.semaphore = make(chan struct{}, max)
+	if max < 1 {
+		max = 1
+	}
+	s.workers = max
 }
 
 // GetIgnoreList returns the ignore list for configuration
@@ -576,23 +1271,62 @@ func (s *Scanner) GetIgnoreList() *IgnoreList {
 	return s.ignoreList
 }
 
+// AddCustomPattern compiles a user-defined rule and adds it to the scanner's
+// patterns. An invalid rule is reported with its name
+func (s *Scanner) AddCustomPattern(def RuleDefinition) error {
+	pattern, err := def.Compile()
+	if err != nil {
+		return err
+	}
+	s.patterns.Add(pattern)
+	return nil
+}
+
+// LoadCustomPatterns adds the rules and the allowlisted email domains of a
+// JSON or YAML rules file
+func (s *Scanner) LoadCustomPatterns(path string) error {
+	config, err := LoadRuleConfig(path)
+	if err != nil {
+		return err
+	}
+	for _, pattern := range config.Patterns {
+		s.patterns.Add(pattern)
+	}
+	s.emailAllowlist = append(s.emailAllowlist, config.EmailDomainAllowlist...)
+	return nil
+}
+
+// SetPatterns replaces the detection patterns used by the scanner
+func (s *Scanner) SetPatterns(patterns *Patterns) {
+	s.patterns = patterns
+}
+
+// SetRiskWeights replaces the weights used to score findings
+func (s *Scanner) SetRiskWeights(weights RiskWeights) {
+	s.riskScorer = NewRiskScorerWithWeights(weights)
+}
+
 // GetPatterns returns the patterns for configuration
 func (s *Scanner) GetPatterns() *Patterns {
 	return s.patterns
 }
 
-// SetOnlyExtensions sets which file extensions to scan (nil = all)
+// SetOnlyExtensions sets which file extensions to scan (nil = all).
+// Extensions are matched case-insensitively and may be given as "env",
+// ".env" or "*.env"
 func (s *Scanner) SetOnlyExtensions(extensions []string) {
-	if len(extensions) == 0 {
-		s.onlyExtensions = nil
-		return
-	}
-	s.onlyExtensions = make(map[string]bool)
+	s.onlyExtensions = nil
 	for _, ext := range extensions {
-		ext = strings.ToLower(ext)
+		ext = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(ext), "*"))
+		if ext == "" || ext == "." {
+			continue
+		}
 		if !strings.HasPrefix(ext, ".") {
 			ext = "." + ext
 		}
+		if s.onlyExtensions == nil {
+			s.onlyExtensions = make(map[string]bool)
+		}
 		s.onlyExtensions[ext] = true
 	}
 }
@@ -604,9 +1338,9 @@ func (s *Scanner) ClearOnlyExtensions() {
 
 // SearchInFile searches for the keyword in a single file (legacy function)
 func SearchInFile(filePath string, keyword string) ([]int, error) {
-	file, err := os.Open(filePath)
+	file, err := fsutil.Open(filePath)
 	if err != nil {
-		return nil, err
+		return nil, pathError(err)
 	}
 	defer file.Close()
 
@@ -0,0 +1,128 @@
+package searcher
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+)
+
+// The same key pasted into many files is one leak with many locations.
+// Secret groups collect the findings of one secret by a hash of the
+// normalized match, so reports can count distinct secrets. The hash is taken
+// when the finding is added, before any masking, and only the hash is
+// written to reports
+
+// SecretLocation is one place a secret was found
+type SecretLocation struct {
+	FilePath   string `json:"file_path"`
+	LineNumber int    `json:"line"`
+}
+
+// SecretGroup is one secret and everywhere it was found
+type SecretGroup struct {
+	Fingerprint string           `json:"fingerprint"`
+	PatternType PatternType      `json:"pattern_type"`
+	Severity    Severity         `json:"severity"` // Highest severity of the occurrences
+	Files       int              `json:"files"`    // Distinct files among the occurrences
+	Occurrences []SecretLocation `json:"occurrences"`
+}
+
+// secretFingerprint hashes the normalized match per pattern type
+func secretFingerprint(patternType PatternType, matched string) string {
+	h := sha256.New()
+	h.Write([]byte(patternType))
+	h.Write([]byte{0})
+	h.Write([]byte(normalizeMatch(matched)))
+	return hex.EncodeToString(h.Sum(nil)[:12])
+}
+
+// SecretFingerprint identifies the secret of the finding regardless of where
+// it was found; empty when the finding has no matched text
+func (f *Finding) SecretFingerprint() string {
+	if f.SecretID != "" {
+		return f.SecretID
+	}
+	if f.MatchedText == "" {
+		return ""
+	}
+	return secretFingerprint(f.PatternType, f.MatchedText)
+}
+
+// GetSecretGroups groups the findings by secret, most severe and most
+// widespread first. Findings without matched text are left out
+func (sr *ScanResult) GetSecretGroups() []SecretGroup {
+	return groupSecrets(sr.allFindings())
+}
+
+// GroupSecrets groups findings by SecretFingerprint, see GetSecretGroups
+func GroupSecrets(findings []*Finding) []SecretGroup {
+	return groupSecrets(sliceFindings(findings))
+}
+
+func groupSecrets(findings findingSeq) []SecretGroup {
+	index := make(map[string]int)
+	var groups []SecretGroup
+	files := make(map[string]map[string]bool)
+	findings(func(f *Finding) bool {
+		fingerprint := f.SecretFingerprint()
+		if fingerprint == "" {
+			return true
+		}
+		i, ok := index[fingerprint]
+		if !ok {
+			i = len(groups)
+			index[fingerprint] = i
+			groups = append(groups, SecretGroup{Fingerprint: fingerprint, PatternType: f.PatternType, Severity: f.Severity})
+			files[fingerprint] = make(map[string]bool)
+		}
+		group := &groups[i]
+		if f.Severity.Score() > group.Severity.Score() {
+			group.Severity = f.Severity
+		}
+		group.Occurrences = append(group.Occurrences, SecretLocation{FilePath: f.FilePath, LineNumber: f.LineNumber})
+		files[fingerprint][f.FilePath] = true
+		return true
+	})
+
+	for i := range groups {
+		group := &groups[i]
+		group.Files = len(files[group.Fingerprint])
+		sort.SliceStable(group.Occurrences, func(a, b int) bool {
+			x, y := group.Occurrences[a], group.Occurrences[b]
+			if x.FilePath != y.FilePath {
+				return x.FilePath < y.FilePath
+			}
+			return x.LineNumber < y.LineNumber
+		})
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].Severity.Score() != groups[j].Severity.Score() {
+			return groups[i].Severity.Score() > groups[j].Severity.Score()
+		}
+		if len(groups[i].Occurrences) != len(groups[j].Occurrences) {
+			return len(groups[i].Occurrences) > len(groups[j].Occurrences)
+		}
+		return groups[i].Fingerprint < groups[j].Fingerprint
+	})
+	return groups
+}
+
+// CountDistinctSecrets counts the groups per highest severity
+func CountDistinctSecrets(groups []SecretGroup) map[Severity]int {
+	counts := make(map[Severity]int)
+	for _, group := range groups {
+		counts[group.Severity]++
+	}
+	return counts
+}
+
+// RepeatedSecrets returns the groups found in more than one place
+func RepeatedSecrets(groups []SecretGroup) []SecretGroup {
+	var repeated []SecretGroup
+	for _, group := range groups {
+		if len(group.Occurrences) > 1 {
+			repeated = append(repeated, group)
+		}
+	}
+	return repeated
+}
@@ -0,0 +1,108 @@
+package searcher
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SeverityOverrides replaces the severity of pattern types with the one a
+// policy sets, e.g. email Low and connection strings Critical. Findings
+// get the overridden severity when they are built, before risk scoring,
+// so scores, counters, reports and the GUI all agree on it
+type SeverityOverrides map[PatternType]Severity
+
+// ReadSeverityConfig reads a YAML or JSON map of pattern types to
+// severities, {email: low, connection_string: critical}. Names that are not
+// pattern types are skipped with a warning listing the valid ones; a bad
+// severity is an error
+func ReadSeverityConfig(path string) (SeverityOverrides, []string, error) {
+	var raw map[string]string
+	if err := DecodeConfigFile(path, &raw); err != nil {
+		return nil, nil, err
+	}
+
+	known := make(map[PatternType]bool)
+	for _, t := range AllPatternTypes() {
+		known[t] = true
+	}
+
+	overrides := make(SeverityOverrides, len(raw))
+	var unknown []string
+	for name, value := range raw {
+		severity, err := ParseSeverity(value)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %s: %v", path, name, err)
+		}
+		t := PatternType(strings.ToLower(strings.TrimSpace(name)))
+		if !known[t] {
+			unknown = append(unknown, name)
+			continue
+		}
+		overrides[t] = severity
+	}
+
+	var warnings []string
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		valid := make([]string, 0, len(known))
+		for _, t := range AllPatternTypes() {
+			valid = append(valid, string(t))
+		}
+		warnings = append(warnings, fmt.Sprintf("%s: неизвестные типы находок пропущены: %s (допустимо: %s)",
+			path, strings.Join(unknown, ", "), strings.Join(valid, ", ")))
+	}
+	return overrides, warnings, nil
+}
+
+// apply sets the overridden severity of a finding
+func (o SeverityOverrides) apply(f *Finding) {
+	if severity, ok := o[f.PatternType]; ok {
+		f.Severity = severity
+	}
+}
+
+// String lists the overrides sorted by type, "email=low connection_string=critical"
+func (o SeverityOverrides) String() string {
+	parts := make([]string, 0, len(o))
+	for t, severity := range o {
+		parts = append(parts, fmt.Sprintf("%s=%s", t, severity))
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, " ")
+}
+
+// SetSeverityOverride makes findings of a pattern type get the severity
+func (s *Scanner) SetSeverityOverride(patternType PatternType, severity Severity) {
+	if s.severities == nil {
+		s.severities = make(SeverityOverrides)
+	}
+	s.severities[patternType] = severity
+}
+
+// ClearSeverityOverrides restores the severities of the rules
+func (s *Scanner) ClearSeverityOverrides() {
+	s.severities = nil
+}
+
+// SeverityOverrides returns the overrides set on the scanner
+func (s *Scanner) SeverityOverrides() SeverityOverrides {
+	overrides := make(SeverityOverrides, len(s.severities))
+	for t, severity := range s.severities {
+		overrides[t] = severity
+	}
+	return overrides
+}
+
+// LoadSeverityConfig adds the overrides of a config file, see
+// ReadSeverityConfig; the warnings name the types that were skipped
+func (s *Scanner) LoadSeverityConfig(path string) ([]string, error) {
+	overrides, warnings, err := ReadSeverityConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	for t, severity := range overrides {
+		s.SetSeverityOverride(t, severity)
+	}
+	return warnings, nil
+}
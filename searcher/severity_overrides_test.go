@@ -0,0 +1,101 @@
+package searcher
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestReadSeverityConfig tests YAML and JSON configs and the warning about
+// names that are not pattern types
+func TestReadSeverityConfig(t *testing.T) {
+	dir := t.TempDir()
+	yamlPath := filepath.Join(dir, "severity.yaml")
+	if err := os.WriteFile(yamlPath, []byte("email: low\nConnection_String: CRITICAL\nemails: low\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	overrides, warnings, err := ReadSeverityConfig(yamlPath)
+	if err != nil {
+		t.Fatalf("ReadSeverityConfig: %v", err)
+	}
+	if len(overrides) != 2 || overrides[PatternEmail] != Low || overrides[PatternConnectionStr] != Critical {
+		t.Errorf("overrides = %v", overrides)
+	}
+	if overrides.String() != "connection_string=critical email=low" {
+		t.Errorf("String() = %q", overrides.String())
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "emails") || !strings.Contains(warnings[0], "api_key") {
+		t.Errorf("warnings = %q, want the unknown name and the valid ones", warnings)
+	}
+
+	jsonPath := filepath.Join(dir, "severity.json")
+	if err := os.WriteFile(jsonPath, []byte(`{"email": "urgent"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := ReadSeverityConfig(jsonPath); err == nil || !strings.Contains(err.Error(), "неизвестный уровень серьёзности") {
+		t.Errorf("err = %v, want an unknown severity error", err)
+	}
+}
+
+// TestSeverityOverridesCounts tests the counts of a scan follow the
+// overridden severities
+func TestSeverityOverridesCounts(t *testing.T) {
+	dir := t.TempDir()
+	content := "contact = alice@example.com\nconnection_string = Server=db01;User=sa\n"
+	if err := os.WriteFile(filepath.Join(dir, "app.conf"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	count := func(scanner *Scanner) map[Severity]int {
+		result, err := scanner.Scan(dir)
+		if err != nil {
+			t.Fatalf("Scan: %v", err)
+		}
+		counts := make(map[Severity]int)
+		for _, severity := range []Severity{Critical, High, Medium, Low} {
+			counts[severity] = result.GetSeverityCount(severity)
+		}
+		return counts
+	}
+
+	before := count(NewScanner())
+	scanner := NewScanner()
+	scanner.SetSeverityOverride(PatternEmail, Low)
+	scanner.SetSeverityOverride(PatternConnectionStr, Critical)
+	after := count(scanner)
+
+	if after[Critical] != before[Critical]+1 || after[High] != before[High]-1 {
+		t.Errorf("critical/high = %d/%d, want %d/%d", after[Critical], after[High], before[Critical]+1, before[High]-1)
+	}
+	if after[Medium] != before[Medium]-1 || after[Low] != before[Low]+1 {
+		t.Errorf("medium/low = %d/%d, want %d/%d", after[Medium], after[Low], before[Medium]-1, before[Low]+1)
+	}
+
+	scanner.ClearSeverityOverrides()
+	if got := count(scanner); got[Critical] != before[Critical] || got[Low] != before[Low] {
+		t.Errorf("counts after ClearSeverityOverrides = %v, want %v", got, before)
+	}
+}
+
+// TestSeverityOverrideTrace tests the trace keeps the severity of the rule
+// and records the override
+func TestSeverityOverrideTrace(t *testing.T) {
+	scanner := NewScanner()
+	scanner.SetSeverityOverride(PatternEmail, Low)
+	trace := scanner.Evaluate("contact = alice@example.com", "app.conf")
+
+	for _, match := range trace.Matches {
+		if match.Type != PatternEmail {
+			continue
+		}
+		if match.BaseSeverity != Medium || match.FinalSeverity != Low {
+			t.Errorf("severity %s → %s, want medium → low", match.BaseSeverity, match.FinalSeverity)
+		}
+		if len(match.Steps) < 2 || match.Steps[1].Stage != StageOverride {
+			t.Errorf("steps = %+v, want the override after the match", match.Steps)
+		}
+		return
+	}
+	t.Fatal("no email match in the trace")
+}
@@ -0,0 +1,330 @@
+package searcher
+
+import (
+	"bufio"
+	"container/heap"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"unicode/utf8"
+)
+
+// A scan of a large file share can find millions of secrets, more than fit
+// in memory with their context lines. With spilling on, once the result
+// holds SpillConfig.Threshold findings every finding is appended to a
+// temporary newline-delimited JSON file and Findings keeps only the riskiest
+// KeepTop of them. Counters, SeveritySummary and rule tallies still cover
+// every finding, and ForEachFinding walks all of them from the file, so
+// reports stream from disk
+
+// DefaultSpillKeepTop is how many findings stay in memory after spilling
+const DefaultSpillKeepTop = 1000
+
+// DefaultMaxContextLength is the longest context line, in characters, kept
+// with a finding
+const DefaultMaxContextLength = 500
+
+// SpillConfig turns on spilling findings to disk
+type SpillConfig struct {
+	Threshold int    // Findings held in memory before spilling; 0 never spills
+	KeepTop   int    // Riskiest findings kept in Findings after spilling; 0 means DefaultSpillKeepTop
+	Dir       string // Directory of the temporary file; empty means os.TempDir()
+}
+
+// findingSpill is the temporary file of a spilled result
+type findingSpill struct {
+	file  *os.File
+	w     *bufio.Writer
+	count int   // Findings written to the file
+	err   error // First write error; the file is incomplete after it
+}
+
+// SetSpill configures spilling; call it before findings are added
+func (sr *ScanResult) SetSpill(config SpillConfig) {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	if config.KeepTop <= 0 {
+		config.KeepTop = DefaultSpillKeepTop
+	}
+	sr.spillConfig = config
+}
+
+// Spilled reports whether the findings were moved to disk, so Findings
+// holds only the riskiest of them
+func (sr *ScanResult) Spilled() bool {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	return sr.spill != nil
+}
+
+// ForEachFinding calls fn for every finding in the order they were added,
+// reading spilled findings back from disk, until fn returns false. Reports
+// and the analyzer walk findings with it instead of ranging over Findings
+func (sr *ScanResult) ForEachFinding(fn func(*Finding) bool) error {
+	sr.mu.Lock()
+	if sr.spill == nil {
+		findings := make([]*Finding, len(sr.Findings))
+		copy(findings, sr.Findings)
+		sr.mu.Unlock()
+		for _, f := range findings {
+			if f != nil && !fn(f) {
+				break
+			}
+		}
+		return nil
+	}
+	spill := sr.spill
+	if err := spill.flush(); err != nil {
+		sr.mu.Unlock()
+		return err
+	}
+	// Findings added while reading are past count and not visited
+	count := spill.count
+	file, err := os.Open(spill.file.Name())
+	sr.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("не удалось прочитать находки с диска: %w", err)
+	}
+	defer file.Close()
+	return readFindings(file, count, fn)
+}
+
+// Close removes the temporary file of a spilled result; Findings keeps the
+// riskiest findings but ForEachFinding no longer sees the rest
+func (sr *ScanResult) Close() error {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	if sr.spill == nil {
+		return nil
+	}
+	spill := sr.spill
+	sr.spill = nil
+	sr.spillConfig.Threshold = 0
+	sr.spilledCount = spill.count
+	return spill.remove()
+}
+
+// readFindings decodes up to count findings from r
+func readFindings(r io.Reader, count int, fn func(*Finding) bool) error {
+	dec := json.NewDecoder(bufio.NewReader(r))
+	for i := 0; i < count; i++ {
+		f := &Finding{}
+		if err := dec.Decode(f); err != nil {
+			return fmt.Errorf("повреждён файл находок: %w", err)
+		}
+		if !fn(f) {
+			break
+		}
+	}
+	return nil
+}
+
+// addFindingLocked stores a finding in memory or on disk; sr.mu is held
+func (sr *ScanResult) addFindingLocked(finding *Finding) {
+	if sr.spill == nil {
+		sr.Findings = append(sr.Findings, finding)
+		if sr.spillConfig.Threshold > 0 && len(sr.Findings) >= sr.spillConfig.Threshold {
+			sr.startSpill()
+		}
+		return
+	}
+	sr.spill.write(finding)
+	sr.Findings = keepRiskiest(sr.Findings, finding, sr.spillConfig.KeepTop)
+}
+
+// startSpill moves the findings held so far to a new temporary file. If the
+// file cannot be created the findings stay in memory
+func (sr *ScanResult) startSpill() {
+	file, err := os.CreateTemp(sr.spillConfig.Dir, "dll-findings-*.ndjson")
+	if err != nil {
+		sr.spillConfig.Threshold = 0
+		return
+	}
+	spill := &findingSpill{file: file, w: bufio.NewWriter(file)}
+	top := make([]*Finding, 0, sr.spillConfig.KeepTop)
+	for _, f := range sr.Findings {
+		spill.write(f)
+		top = keepRiskiest(top, f, sr.spillConfig.KeepTop)
+	}
+	sr.spill = spill
+	sr.Findings = top
+}
+
+// rewriteSpill passes every spilled finding to fn, which may change it,
+// and keeps those it returns true for; Findings is rebuilt from the kept
+// ones. sr.mu is held
+func (sr *ScanResult) rewriteSpill(fn func(*Finding) bool) error {
+	old := sr.spill
+	if err := old.flush(); err != nil {
+		return err
+	}
+	file, err := os.CreateTemp(sr.spillConfig.Dir, "dll-findings-*.ndjson")
+	if err != nil {
+		return err
+	}
+	spill := &findingSpill{file: file, w: bufio.NewWriter(file)}
+	var top []*Finding
+	if _, err := old.file.Seek(0, io.SeekStart); err != nil {
+		spill.remove()
+		return err
+	}
+	err = readFindings(old.file, old.count, func(f *Finding) bool {
+		if fn(f) {
+			spill.write(f)
+			top = keepRiskiest(top, f, sr.spillConfig.KeepTop)
+		}
+		return true
+	})
+	if err == nil {
+		err = spill.flush()
+	}
+	if err != nil {
+		spill.remove()
+		return err
+	}
+	old.remove()
+	sr.spill = spill
+	sr.Findings = top
+	return nil
+}
+
+func (sp *findingSpill) write(f *Finding) {
+	if sp.err != nil {
+		return
+	}
+	data, err := json.Marshal(f)
+	if err == nil {
+		data = append(data, '\n')
+		_, err = sp.w.Write(data)
+	}
+	if err != nil {
+		sp.err = fmt.Errorf("не удалось записать находки на диск: %w", err)
+		return
+	}
+	sp.count++
+}
+
+func (sp *findingSpill) flush() error {
+	if sp.err != nil {
+		return sp.err
+	}
+	if err := sp.w.Flush(); err != nil {
+		sp.err = fmt.Errorf("не удалось записать находки на диск: %w", err)
+	}
+	return sp.err
+}
+
+func (sp *findingSpill) remove() error {
+	sp.file.Close()
+	return os.Remove(sp.file.Name())
+}
+
+// riskHeap is a min-heap of findings by risk score, the least risky on top
+type riskHeap []*Finding
+
+func (h riskHeap) Len() int           { return len(h) }
+func (h riskHeap) Less(i, j int) bool { return h[i].RiskScore < h[j].RiskScore }
+func (h riskHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *riskHeap) Push(x any)        { *h = append(*h, x.(*Finding)) }
+func (h *riskHeap) Pop() any {
+	old := *h
+	f := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	return f
+}
+
+// keepRiskiest adds f to the heap top, dropping the least risky finding
+// once it holds n
+func keepRiskiest(top []*Finding, f *Finding, n int) []*Finding {
+	h := riskHeap(top)
+	if len(h) < n {
+		heap.Push(&h, f)
+		return h
+	}
+	if len(h) > 0 && f.RiskScore > h[0].RiskScore {
+		h[0] = f
+		heap.Fix(&h, 0)
+	}
+	return h
+}
+
+// contextEllipsis marks the cut ends of a truncated context
+const contextEllipsis = "…"
+
+// contextLimit resolves a configured context length: zero means
+// DefaultMaxContextLength and a negative length keeps whole lines
+func contextLimit(length int) int {
+	switch {
+	case length == 0:
+		return DefaultMaxContextLength
+	case length < 0:
+		return 0
+	}
+	return length
+}
+
+// truncateContext shortens the context of f to limit characters around
+// the match, marking the cut ends; limit <= 0 keeps the whole line
+func truncateContext(f *Finding, limit int) {
+	if limit <= 0 || utf8.RuneCountInString(f.Context) <= limit {
+		return
+	}
+	line := f.Context
+	start, end := clampOffset(line, f.ByteStart), clampOffset(line, f.ByteEnd)
+	if end < start {
+		end = start
+	}
+
+	// Spread the room left after the match evenly on both sides
+	runes := []rune(line)
+	matchStart, matchEnd := RuneColumn(line, start), RuneColumn(line, end)
+	from := matchStart - (limit-(matchEnd-matchStart))/2
+	if from > matchStart {
+		from = matchStart // The match alone is longer than limit
+	}
+	if from > len(runes)-limit {
+		from = len(runes) - limit
+	}
+	if from < 0 {
+		from = 0
+	}
+	to := from + limit
+
+	prefix, suffix := "", ""
+	if from > 0 {
+		prefix = contextEllipsis
+	}
+	if to < len(runes) {
+		suffix = contextEllipsis
+	}
+	cut := len(string(runes[:from]))
+	f.Context = prefix + string(runes[from:to]) + suffix
+	f.ByteStart = clampOffset(f.Context, start-cut+len(prefix))
+	f.ByteEnd = clampOffset(f.Context, end-cut+len(prefix))
+}
+
+// findingSeq yields findings to fn until it returns false, so the same
+// aggregation works over a slice and over a spilled result
+type findingSeq func(fn func(*Finding) bool)
+
+// sliceFindings yields the non-nil findings of a slice
+func sliceFindings(findings []*Finding) findingSeq {
+	return func(fn func(*Finding) bool) {
+		for _, f := range findings {
+			if f != nil && !fn(f) {
+				return
+			}
+		}
+	}
+}
+
+// allFindings yields every finding of the result. A read error of the
+// spill file ends the walk early; report writers walk ForEachFinding
+// themselves and return it
+func (sr *ScanResult) allFindings() findingSeq {
+	return func(fn func(*Finding) bool) {
+		sr.ForEachFinding(fn)
+	}
+}
@@ -0,0 +1,240 @@
+package searcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// syntheticFinding returns the i-th finding of a large scan, with a long
+// context line and a risk score spread over 0-99
+func syntheticFinding(i int) *Finding {
+	secret := fmt.Sprintf("ghp_%036d", i)
+	return &Finding{
+		FilePath:    fmt.Sprintf("/share/project%03d/config%d.env", i%500, i%7),
+		LineNumber:  i%200 + 1,
+		PatternType: PatternGitHubToken,
+		Severity:    []Severity{Low, Medium, High, Critical}[i%4],
+		Description: "GitHub Token detected",
+		MatchedText: secret,
+		Context:     "GITHUB_TOKEN=" + secret + " # " + strings.Repeat("x", 400),
+		RiskScore:   float64((i * 37) % 100),
+	}
+}
+
+// collectFindings returns every finding of result through ForEachFinding
+func collectFindings(t *testing.T, result *ScanResult) []*Finding {
+	t.Helper()
+	var findings []*Finding
+	if err := result.ForEachFinding(func(f *Finding) bool {
+		findings = append(findings, f)
+		return true
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return findings
+}
+
+func TestSpillKeepsEveryFinding(t *testing.T) {
+	dir := t.TempDir()
+	memory, spilled := NewScanResult(), NewScanResult()
+	spilled.SetSpill(SpillConfig{Threshold: 20, KeepTop: 5, Dir: dir})
+	for i := 0; i < 100; i++ {
+		memory.AddFinding(syntheticFinding(i))
+		spilled.AddFinding(syntheticFinding(i))
+	}
+
+	if !spilled.Spilled() || memory.Spilled() {
+		t.Fatalf("Spilled() = %v, %v", spilled.Spilled(), memory.Spilled())
+	}
+	if spilled.TotalFindings() != 100 || spilled.GetSeverityCount(Critical) != 25 {
+		t.Errorf("TotalFindings = %d, critical = %d", spilled.TotalFindings(), spilled.GetSeverityCount(Critical))
+	}
+
+	// Only the riskiest stay in memory
+	if len(spilled.Findings) != 5 {
+		t.Fatalf("%d findings in memory, want 5", len(spilled.Findings))
+	}
+	var risks []float64
+	for _, f := range spilled.Findings {
+		risks = append(risks, f.RiskScore)
+	}
+	sort.Float64s(risks)
+	if risks[0] != 95 || risks[4] != 99 {
+		t.Errorf("kept risk scores %v, want the top 5 (95-99)", risks)
+	}
+
+	// Every finding is read back in order
+	want, got := collectFindings(t, memory), collectFindings(t, spilled)
+	if len(got) != len(want) {
+		t.Fatalf("ForEachFinding visited %d, want %d", len(got), len(want))
+	}
+	for i := range want {
+		a, _ := json.Marshal(want[i])
+		b, _ := json.Marshal(got[i])
+		if string(a) != string(b) {
+			t.Fatalf("finding %d differs:\n%s\n%s", i, a, b)
+		}
+	}
+
+	// Reports stream from disk and match the in-memory ones
+	for _, r := range []*ScanResult{memory, spilled} {
+		path := filepath.Join(t.TempDir(), "report.json")
+		if err := NewReportGenerator(r).ExportJSON(path); err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := LoadScanResult(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(loaded.Findings) != 100 {
+			t.Errorf("report has %d findings, want 100", len(loaded.Findings))
+		}
+	}
+	if a, b := ComputeStatistics(memory), ComputeStatistics(spilled); a.TotalFindings != b.TotalFindings ||
+		a.UniqueFiles != b.UniqueFiles || a.RiskHistogram != b.RiskHistogram || a.DistinctSecrets != b.DistinctSecrets {
+		t.Errorf("statistics differ:\n%+v\n%+v", a, b)
+	}
+
+	// Close removes the temporary file and keeps the totals
+	if err := spilled.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("spill file left behind: %v", entries)
+	}
+	if spilled.TotalFindings() != 100 {
+		t.Errorf("TotalFindings after Close = %d", spilled.TotalFindings())
+	}
+}
+
+func TestSpillPruneAndBaseline(t *testing.T) {
+	result := NewScanResult()
+	result.SetSpill(SpillConfig{Threshold: 10, KeepTop: 3, Dir: t.TempDir()})
+	defer result.Close()
+	for i := 0; i < 40; i++ {
+		result.AddFinding(syntheticFinding(i))
+	}
+
+	// Known findings are tagged and moved out on disk
+	known := NewScanResult()
+	known.AddFinding(syntheticFinding(2))
+	newCount, knownCount := NewBaselineIndex(known).TagResult(result)
+	if newCount != 39 || knownCount != 1 {
+		t.Errorf("TagResult = %d new, %d known", newCount, knownCount)
+	}
+	if moved := result.SuppressBaselined(); moved != 1 || result.TotalFindings() != 39 || len(result.Baselined) != 1 {
+		t.Errorf("SuppressBaselined moved %d, %d left", moved, result.TotalFindings())
+	}
+
+	stats := result.Prune(PruneOptions{MinSeverity: High, MaskMatches: true})
+	if stats.FindingsRemoved != 20 || stats.MatchesMasked != 19 || result.TotalFindings() != 19 {
+		t.Errorf("Prune stats %+v, %d left", stats, result.TotalFindings())
+	}
+	for _, f := range collectFindings(t, result) {
+		if f.Severity.Score() < High.Score() || f.Baseline != BaselineNew || !strings.Contains(f.MatchedText, "*") {
+			t.Errorf("finding not updated on disk: %+v", f)
+		}
+	}
+}
+
+func TestTruncateContext(t *testing.T) {
+	long := strings.Repeat("a", 300) + "SECRET" + strings.Repeat("б", 300)
+	f := &Finding{Context: long, ByteStart: 300, ByteEnd: 306, MatchedText: "SECRET"}
+	truncateContext(f, 50)
+	if got := []rune(f.Context); len(got) != 52 || got[0] != '…' || got[len(got)-1] != '…' {
+		t.Errorf("context %q, want 50 characters between ellipses", f.Context)
+	}
+	if f.Context[f.ByteStart:f.ByteEnd] != "SECRET" {
+		t.Errorf("match offsets %d-%d point at %q", f.ByteStart, f.ByteEnd, f.Context[f.ByteStart:f.ByteEnd])
+	}
+
+	// A match at the start keeps the start of the line
+	f = &Finding{Context: "SECRET" + strings.Repeat("x", 100), ByteStart: 0, ByteEnd: 6}
+	truncateContext(f, 20)
+	if f.Context != "SECRET"+strings.Repeat("x", 14)+"…" || f.ByteStart != 0 || f.ByteEnd != 6 {
+		t.Errorf("context %q, offsets %d-%d", f.Context, f.ByteStart, f.ByteEnd)
+	}
+
+	// Short lines and a disabled limit are left alone
+	for _, limit := range []int{0, 1000} {
+		f = &Finding{Context: long, ByteStart: 300, ByteEnd: 306}
+		truncateContext(f, limit)
+		if f.Context != long {
+			t.Errorf("limit %d changed the context", limit)
+		}
+	}
+}
+
+func TestScannerTruncatesContext(t *testing.T) {
+	dir := t.TempDir()
+	line := strings.Repeat("#", 2000) + " password = hunter2hunter2 " + strings.Repeat("#", 2000)
+	createTestFile(t, dir, "config.env", line+"\n")
+
+	scanner := NewScanner()
+	scanner.SetMaxContextLength(100)
+	result, err := scanner.Scan(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Findings) == 0 {
+		t.Fatal("no findings")
+	}
+	for _, f := range result.Findings {
+		if n := len([]rune(f.Context)); n > 102 || !strings.Contains(f.Context, "hunter2hunter2") {
+			t.Errorf("context of %d characters: %q", n, f.Context)
+		}
+		if f.ColumnStart < 2000 {
+			t.Errorf("column %d should still count from the start of the line", f.ColumnStart)
+		}
+	}
+}
+
+// TestSpillMemoryBudget adds 100k findings with long context lines and
+// checks the heap stays far below what keeping them all would take
+func TestSpillMemoryBudget(t *testing.T) {
+	if testing.Short() {
+		t.Skip("stress test")
+	}
+	const total = 100_000
+	const budget = 32 << 20 // Keeping them all takes well over 60 MB
+
+	var stats runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&stats)
+	base := stats.HeapAlloc
+
+	result := NewScanResult()
+	result.SetSpill(SpillConfig{Threshold: 1000, KeepTop: 100, Dir: t.TempDir()})
+	defer result.Close()
+
+	var peak uint64
+	for i := 0; i < total; i++ {
+		f := syntheticFinding(i)
+		truncateContext(f, DefaultMaxContextLength)
+		result.AddFinding(f)
+		if i%10_000 == 9_999 {
+			runtime.GC()
+			runtime.ReadMemStats(&stats)
+			if stats.HeapAlloc > base && stats.HeapAlloc-base > peak {
+				peak = stats.HeapAlloc - base
+			}
+		}
+	}
+	if peak > budget {
+		t.Errorf("heap grew by %d MB, budget %d MB", peak>>20, budget>>20)
+	}
+	if result.TotalFindings() != total || len(result.Findings) != 100 {
+		t.Errorf("TotalFindings = %d, %d in memory", result.TotalFindings(), len(result.Findings))
+	}
+
+	counted := 0
+	if err := result.ForEachFinding(func(*Finding) bool { counted++; return true }); err != nil || counted != total {
+		t.Errorf("ForEachFinding visited %d, err %v", counted, err)
+	}
+}
@@ -0,0 +1,305 @@
+package searcher
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/kacebover/password-finder/locale"
+)
+
+// RiskHistogramBuckets is the number of 10-point risk score buckets
+const RiskHistogramBuckets = 10
+
+// topFilesLimit is how many files the statistics tables keep
+const topFilesLimit = 10
+
+// AnalysisStatistics holds statistical analysis
+type AnalysisStatistics struct {
+	TotalFindings        int                       `json:"total_findings"`
+	UniqueFiles          int                       `json:"unique_files"`
+	AverageRiskScore     float64                   `json:"average_risk_score"`
+	MaxRiskScore         float64                   `json:"max_risk_score"`
+	SeverityDistribution map[string]int            `json:"severity_distribution"`
+	PatternDistribution  map[string]int            `json:"pattern_distribution"`
+	MostAffectedFiles    []FileRiskSummary         `json:"most_affected_files"`
+	RiskiestFiles        []FileRiskSummary         `json:"riskiest_files"`
+	RiskHistogram        [RiskHistogramBuckets]int `json:"risk_histogram"`
+	Extensions           []ExtensionSummary        `json:"extensions"`
+	DistinctSecrets      int                       `json:"distinct_secrets"`
+	RepeatedSecrets      []SecretGroup             `json:"repeated_secrets,omitempty"` // Secrets found in several places, most widespread first
+}
+
+// FileRiskSummary summarizes risk for a single file
+type FileRiskSummary struct {
+	FilePath     string  `json:"file_path"`
+	FindingCount int     `json:"finding_count"`
+	MaxSeverity  string  `json:"max_severity"`
+	AvgRiskScore float64 `json:"avg_risk_score"`
+	MaxRiskScore float64 `json:"max_risk_score"`
+}
+
+// ExtensionSummary counts findings for one file extension
+type ExtensionSummary struct {
+	Extension    string `json:"extension"`
+	FindingCount int    `json:"finding_count"`
+	FileCount    int    `json:"file_count"`
+	MaxSeverity  string `json:"max_severity"`
+}
+
+// ComputeStatistics calculates distribution statistics over a scan result.
+// It is shared by the AI analyzer and the report generator.
+func ComputeStatistics(result *ScanResult) AnalysisStatistics {
+	stats := AnalysisStatistics{
+		SeverityDistribution: make(map[string]int),
+		PatternDistribution:  make(map[string]int),
+	}
+
+	findings := result.allFindings()
+	var totalRisk float64
+	findings(func(f *Finding) bool {
+		stats.TotalFindings++
+		stats.SeverityDistribution[string(f.Severity)]++
+		stats.PatternDistribution[string(f.PatternType)]++
+
+		totalRisk += f.RiskScore
+		if f.RiskScore > stats.MaxRiskScore {
+			stats.MaxRiskScore = f.RiskScore
+		}
+		return true
+	})
+	if stats.TotalFindings > 0 {
+		stats.AverageRiskScore = totalRisk / float64(stats.TotalFindings)
+	}
+
+	files := fileRiskSummaries(findings)
+	stats.UniqueFiles = len(files)
+
+	// Most affected: by finding count
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].FindingCount > files[j].FindingCount
+	})
+	stats.MostAffectedFiles = limitFiles(files, topFilesLimit)
+
+	// Riskiest: by highest single risk score, then by count
+	stats.RiskiestFiles = TopRiskyFiles(files, topFilesLimit)
+
+	stats.RiskHistogram = riskHistogram(findings)
+	stats.Extensions = extensionStats(findings)
+
+	groups := groupSecrets(findings)
+	stats.DistinctSecrets = len(groups)
+	repeated := RepeatedSecrets(groups)
+	sort.SliceStable(repeated, func(i, j int) bool {
+		return len(repeated[i].Occurrences) > len(repeated[j].Occurrences)
+	})
+	if len(repeated) > topFilesLimit {
+		repeated = repeated[:topFilesLimit]
+	}
+	stats.RepeatedSecrets = repeated
+
+	return stats
+}
+
+// FileRiskSummaries groups findings by file, sorted by path
+func FileRiskSummaries(findings []*Finding) []FileRiskSummary {
+	return fileRiskSummaries(sliceFindings(findings))
+}
+
+func fileRiskSummaries(findings findingSeq) []FileRiskSummary {
+	byFile := make(map[string]*FileRiskSummary)
+	totals := make(map[string]float64)
+	findings(func(f *Finding) bool {
+		summary, ok := byFile[f.FilePath]
+		if !ok {
+			summary = &FileRiskSummary{FilePath: f.FilePath, MaxSeverity: string(Low)}
+			byFile[f.FilePath] = summary
+		}
+		summary.FindingCount++
+		totals[f.FilePath] += f.RiskScore
+		if f.Severity.Score() > Severity(summary.MaxSeverity).Score() {
+			summary.MaxSeverity = string(f.Severity)
+		}
+		if f.RiskScore > summary.MaxRiskScore {
+			summary.MaxRiskScore = f.RiskScore
+		}
+		return true
+	})
+
+	files := make([]FileRiskSummary, 0, len(byFile))
+	for path, summary := range byFile {
+		summary.AvgRiskScore = totals[path] / float64(summary.FindingCount)
+		files = append(files, *summary)
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].FilePath < files[j].FilePath
+	})
+	return files
+}
+
+// TopRiskyFiles returns up to n files ordered by their highest risk score
+func TopRiskyFiles(files []FileRiskSummary, n int) []FileRiskSummary {
+	sorted := make([]FileRiskSummary, len(files))
+	copy(sorted, files)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].MaxRiskScore != sorted[j].MaxRiskScore {
+			return sorted[i].MaxRiskScore > sorted[j].MaxRiskScore
+		}
+		if sorted[i].FindingCount != sorted[j].FindingCount {
+			return sorted[i].FindingCount > sorted[j].FindingCount
+		}
+		return sorted[i].FilePath < sorted[j].FilePath
+	})
+	return limitFiles(sorted, n)
+}
+
+func limitFiles(files []FileRiskSummary, n int) []FileRiskSummary {
+	if len(files) > n {
+		files = files[:n]
+	}
+	out := make([]FileRiskSummary, len(files))
+	copy(out, files)
+	return out
+}
+
+// RiskHistogram counts findings in 10-point risk score buckets: 0-10, 10-20, … 90-100.
+// A score of exactly 100 falls into the last bucket.
+func RiskHistogram(findings []*Finding) [RiskHistogramBuckets]int {
+	return riskHistogram(sliceFindings(findings))
+}
+
+func riskHistogram(findings findingSeq) [RiskHistogramBuckets]int {
+	var buckets [RiskHistogramBuckets]int
+	findings(func(f *Finding) bool {
+		i := int(f.RiskScore / 10)
+		if i < 0 {
+			i = 0
+		}
+		if i >= RiskHistogramBuckets {
+			i = RiskHistogramBuckets - 1
+		}
+		buckets[i]++
+		return true
+	})
+	return buckets
+}
+
+// NoExtension is the ExtensionSummary.Extension of files without one
+const NoExtension = "(без расширения)"
+
+// extensionLabel names an extension in a table, NoExtension in the language of l
+func extensionLabel(l *locale.Localizer, ext string) string {
+	if ext == NoExtension {
+		return l.T("table.no_extension")
+	}
+	return ext
+}
+
+// ExtensionStats counts findings per file extension, most findings first
+func ExtensionStats(findings []*Finding) []ExtensionSummary {
+	return extensionStats(sliceFindings(findings))
+}
+
+func extensionStats(findings findingSeq) []ExtensionSummary {
+	byExt := make(map[string]*ExtensionSummary)
+	files := make(map[string]map[string]bool)
+	findings(func(f *Finding) bool {
+		ext := strings.ToLower(filepath.Ext(f.FilePath))
+		if ext == "" {
+			ext = NoExtension
+		}
+		summary, ok := byExt[ext]
+		if !ok {
+			summary = &ExtensionSummary{Extension: ext, MaxSeverity: string(Low)}
+			byExt[ext] = summary
+			files[ext] = make(map[string]bool)
+		}
+		summary.FindingCount++
+		files[ext][f.FilePath] = true
+		if f.Severity.Score() > Severity(summary.MaxSeverity).Score() {
+			summary.MaxSeverity = string(f.Severity)
+		}
+		return true
+	})
+
+	exts := make([]ExtensionSummary, 0, len(byExt))
+	for ext, summary := range byExt {
+		summary.FileCount = len(files[ext])
+		exts = append(exts, *summary)
+	}
+	sort.Slice(exts, func(i, j int) bool {
+		if exts[i].FindingCount != exts[j].FindingCount {
+			return exts[i].FindingCount > exts[j].FindingCount
+		}
+		return exts[i].Extension < exts[j].Extension
+	})
+	return exts
+}
+
+// sparkBars are the block characters used by Sparkline, lowest to highest
+var sparkBars = []rune("▁▂▃▄▅▆▇█")
+
+// Sparkline renders values as a one-line bar chart: "▁▃█▅"
+func Sparkline(values []int) string {
+	maxValue := 0
+	for _, v := range values {
+		if v > maxValue {
+			maxValue = v
+		}
+	}
+
+	var sb strings.Builder
+	for _, v := range values {
+		if v <= 0 || maxValue == 0 {
+			sb.WriteRune(' ')
+			continue
+		}
+		sb.WriteRune(sparkBars[v*(len(sparkBars)-1)/maxValue])
+	}
+	return sb.String()
+}
+
+// FormatRiskHistogram renders the risk histogram as ASCII bars scaled to width
+func FormatRiskHistogram(buckets [RiskHistogramBuckets]int, width int) string {
+	maxCount := 0
+	for _, n := range buckets {
+		if n > maxCount {
+			maxCount = n
+		}
+	}
+
+	var sb strings.Builder
+	for i, n := range buckets {
+		bar := 0
+		if maxCount > 0 {
+			bar = (n*width + maxCount - 1) / maxCount
+		}
+		sb.WriteString(fmt.Sprintf("  %3d-%-3d | %-*s %d\n", i*10, (i+1)*10, width, strings.Repeat("#", bar), n))
+	}
+	return sb.String()
+}
+
+// FormatTopFiles renders the riskiest files as a text table
+func FormatTopFiles(l *locale.Localizer, files []FileRiskSummary) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("  %-3s %-6s %-7s %-7s %-12s %s\n", "#",
+		l.T("table.max"), l.T("table.avg"), l.T("table.findings"), l.T("table.severity"), l.T("table.file")))
+	for i, f := range files {
+		sb.WriteString(fmt.Sprintf("  %-3d %-6.1f %-7.1f %-7d %-12s %s\n",
+			i+1, f.MaxRiskScore, f.AvgRiskScore, f.FindingCount, l.Severity(f.MaxSeverity), f.FilePath))
+	}
+	return sb.String()
+}
+
+// FormatExtensionTable renders per-extension counts as a text table
+func FormatExtensionTable(l *locale.Localizer, exts []ExtensionSummary) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("  %-18s %-8s %-7s %s\n",
+		l.T("table.extension"), l.T("table.ext_findings"), l.T("table.files"), l.T("table.max_severity")))
+	for _, e := range exts {
+		sb.WriteString(fmt.Sprintf("  %-18s %-8d %-7d %s\n",
+			extensionLabel(l, e.Extension), e.FindingCount, e.FileCount, l.Severity(e.MaxSeverity)))
+	}
+	return sb.String()
+}
@@ -0,0 +1,121 @@
+package searcher
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite golden files in testdata/golden")
+
+// statisticsFixture returns a fixed result spread over several files and extensions
+func statisticsFixture() *ScanResult {
+	result := NewScanResult()
+	for _, f := range []*Finding{
+		{FilePath: "/app/config/prod.env", Severity: Critical, RiskScore: 100, PatternType: PatternAWSKey},
+		{FilePath: "/app/config/prod.env", Severity: High, RiskScore: 72.5, PatternType: PatternPassword},
+		{FilePath: "/app/config/prod.env", Severity: High, RiskScore: 68, PatternType: PatternPassword},
+		{FilePath: "/app/src/db.go", Severity: High, RiskScore: 55, PatternType: PatternConnectionStr},
+		{FilePath: "/app/src/client.go", Severity: Medium, RiskScore: 41, PatternType: PatternAPIKey},
+		{FilePath: "/app/src/client.go", Severity: Low, RiskScore: 12, PatternType: PatternEmail},
+		{FilePath: "/app/docs/README.MD", Severity: Low, RiskScore: 9.9, PatternType: PatternEmail},
+		{FilePath: "/app/docs/contacts.md", Severity: Low, RiskScore: 15, PatternType: PatternPhoneNumber},
+		{FilePath: "/app/Dockerfile", Severity: Medium, RiskScore: 38, PatternType: PatternEnvVar},
+		{FilePath: "/app/keys/id_rsa", Severity: Critical, RiskScore: 94, PatternType: PatternPrivateKey},
+	} {
+		result.AddFinding(f)
+	}
+	return result
+}
+
+// checkGolden compares got with testdata/golden/name, rewriting it with -update
+func checkGolden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", "golden", name)
+	if *updateGolden {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read golden file: %v (run with -update to create it)", err)
+	}
+	if got != string(want) {
+		t.Errorf("%s mismatch:\n--- got ---\n%s\n--- want ---\n%s", name, got, want)
+	}
+}
+
+// TestRiskHistogram tests bucket boundaries including a score of exactly 100
+func TestRiskHistogram(t *testing.T) {
+	buckets := RiskHistogram(statisticsFixture().Findings)
+	want := [RiskHistogramBuckets]int{1, 2, 0, 1, 1, 1, 1, 1, 0, 2}
+	if buckets != want {
+		t.Errorf("RiskHistogram = %v, want %v", buckets, want)
+	}
+	checkGolden(t, "risk_histogram.golden", FormatRiskHistogram(buckets, 20))
+}
+
+// TestTopRiskyFiles tests files are ranked by highest risk score
+func TestTopRiskyFiles(t *testing.T) {
+	stats := ComputeStatistics(statisticsFixture())
+	if stats.UniqueFiles != 7 {
+		t.Errorf("expected 7 files, got %d", stats.UniqueFiles)
+	}
+	if stats.RiskiestFiles[0].FilePath != "/app/config/prod.env" || stats.RiskiestFiles[1].FilePath != "/app/keys/id_rsa" {
+		t.Errorf("unexpected ranking: %+v", stats.RiskiestFiles[:2])
+	}
+	if stats.MostAffectedFiles[0].FindingCount != 3 {
+		t.Errorf("most affected file should have 3 findings, got %d", stats.MostAffectedFiles[0].FindingCount)
+	}
+	if len(TopRiskyFiles(FileRiskSummaries(statisticsFixture().Findings), 3)) != 3 {
+		t.Error("TopRiskyFiles should respect the limit")
+	}
+	checkGolden(t, "top_files.golden", FormatTopFiles(russian, stats.RiskiestFiles))
+}
+
+// TestExtensionStats tests extensions are lowercased and files counted once
+func TestExtensionStats(t *testing.T) {
+	exts := ExtensionStats(statisticsFixture().Findings)
+	if exts[0].Extension != ".env" || exts[0].FindingCount != 3 || exts[0].FileCount != 1 {
+		t.Errorf("unexpected first extension: %+v", exts[0])
+	}
+	for _, e := range exts {
+		if e.Extension == ".md" && (e.FindingCount != 2 || e.FileCount != 2) {
+			t.Errorf(".md and .MD should be merged: %+v", e)
+		}
+	}
+	checkGolden(t, "extensions.golden", FormatExtensionTable(russian, exts))
+}
+
+// TestSparkline tests the lowest and highest bars
+func TestSparkline(t *testing.T) {
+	if got := Sparkline([]int{0, 1, 4, 8}); got != " ▁▄█" {
+		t.Errorf("Sparkline = %q", got)
+	}
+	if got := Sparkline([]int{0, 0}); got != "  " {
+		t.Errorf("Sparkline of zeros = %q", got)
+	}
+}
+
+// TestPlainTextReportDistribution tests the text report includes the new sections
+func TestPlainTextReportDistribution(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.txt")
+	if err := NewReportGenerator(statisticsFixture()).ExportPlainText(path); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, section := range []string{"РАСПРЕДЕЛЕНИЕ ОЦЕНОК РИСКА", "САМЫЕ РИСКОВАННЫЕ ФАЙЛЫ", "НАХОДКИ ПО РАСШИРЕНИЯМ"} {
+		if !strings.Contains(string(data), section) {
+			t.Errorf("text report is missing section %q", section)
+		}
+	}
+}
@@ -0,0 +1,595 @@
+package searcher
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// JSON, YAML and .env files are scanned twice: by lines, like any text, and
+// as documents. The document walk knows the key of every value, so a value
+// is flagged by its key name even when no pattern matches it, and findings
+// get the full key path, e.g. services.db.credentials.password. Documents
+// that do not parse keep the line findings alone
+
+// Structured formats of files
+const (
+	structuredJSON = "json"
+	structuredYAML = "yaml"
+	structuredEnv  = "env"
+)
+
+// Bounds of structured scanning
+const (
+	maxStructuredSize   = 8 * 1024 * 1024 // Larger files are scanned by lines only
+	maxStructuredValues = 100000          // Values walked per document, bounds alias expansion
+	maxStructuredDepth  = 100             // Deeper nodes are not walked, bounds recursive aliases
+)
+
+// structuredEntropy checks values when the scanner runs no entropy
+// detector of its own; a whole value is a far better candidate than a word
+// of a line, so the noise the detector is off for does not apply
+var structuredEntropy = NewHighEntropyDetector()
+
+// structuredValue is a leaf value of a parsed document
+type structuredValue struct {
+	path  string // Full key path, array items as [i]
+	key   string // Nearest key name, the array's for its items
+	value string
+	line  int // 1-based, as reported by the parser
+	start int // Byte span of the value in its line, -1 when not found there
+	end   int
+}
+
+// structuredFormat returns the format of a file scanned as a document,
+// empty for other files
+func structuredFormat(path string) string {
+	name := strings.ToLower(filepath.Base(path))
+	switch {
+	case strings.HasSuffix(name, ".json"):
+		return structuredJSON
+	case strings.HasSuffix(name, ".yaml"), strings.HasSuffix(name, ".yml"):
+		return structuredYAML
+	case name == ".env", strings.HasPrefix(name, ".env."), strings.HasSuffix(name, ".env"):
+		return structuredEnv
+	}
+	return ""
+}
+
+// structuredBuffer keeps what the line scanner reads of a structured file,
+// up to maxStructuredSize
+type structuredBuffer struct {
+	format   string
+	data     bytes.Buffer
+	overflow bool
+}
+
+// newStructuredBuffer returns nil for files of no structured format
+func newStructuredBuffer(path string) *structuredBuffer {
+	format := structuredFormat(path)
+	if format == "" {
+		return nil
+	}
+	return &structuredBuffer{format: format}
+}
+
+// Write never fails, so the line scanner reading through it does not either
+func (b *structuredBuffer) Write(p []byte) (int, error) {
+	if b.overflow {
+		return len(p), nil
+	}
+	if b.data.Len()+len(p) > maxStructuredSize {
+		b.overflow = true
+		b.data = bytes.Buffer{}
+		return len(p), nil
+	}
+	return b.data.Write(p)
+}
+
+// scanStructured parses a document the line scanner read and merges what
+// its keys tell into the line findings. radius and limit are those of
+// attachContextLines
+func (dp detectionPipeline) scanStructured(path string, doc *structuredBuffer, findings []*Finding, radius, limit int) []*Finding {
+	if doc == nil || doc.overflow {
+		return findings
+	}
+	data := doc.data.Bytes()
+	values, err := parseStructured(doc.format, data)
+	if err != nil || len(values) == 0 {
+		return findings
+	}
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	locateValues(values, lines)
+
+	found := dp.analyzeStructured(path, doc.format, values, lines)
+	attachContextLines(found, lines, radius, limit)
+	return mergeStructuredFindings(findings, found, values)
+}
+
+// parseStructured returns the leaf values of a document
+func parseStructured(format string, data []byte) ([]structuredValue, error) {
+	switch format {
+	case structuredJSON:
+		return parseJSONValues(data)
+	case structuredYAML:
+		return parseYAMLValues(data)
+	case structuredEnv:
+		return parseEnvValues(data)
+	}
+	return nil, fmt.Errorf("unknown structured format %q", format)
+}
+
+// analyzeStructured flags the values of a document: those a pattern
+// matches, those under a sensitive key and random-looking ones
+func (dp detectionPipeline) analyzeStructured(path, format string, values []structuredValue, lines []string) []*Finding {
+	var findings []*Finding
+	for _, v := range values {
+		matches := dp.patterns.FindAll(v.value)
+		if len(matches) == 0 && isSensitiveKey(v.key) && !isPlaceholderValue(v.value) {
+			matches = []*DetectedPattern{sensitiveKeyMatch(format, v)}
+		}
+		if len(matches) == 0 && dp.patterns.Enabled(PatternHighEntropy) {
+			entropy := dp.highEntropy
+			if entropy == nil {
+				entropy = structuredEntropy
+			}
+			matches = entropy.Detect(v.value)
+		}
+		matches = dp.patterns.enabledMatches(matches)
+
+		line := ""
+		if v.line >= 1 && v.line <= len(lines) {
+			line = lines[v.line-1]
+		}
+		for _, pattern := range matches {
+			// Spans are in the value; they carry over to a line holding it as is
+			if v.start >= 0 {
+				pattern.StartIndex += v.start
+				pattern.EndIndex += v.start
+			} else {
+				pattern.StartIndex, pattern.EndIndex = 0, 0
+			}
+			pattern.LineNumber = v.line
+			pattern.FilePath = path
+			pattern.Context = line
+			finding := dp.score(pattern, nil)
+			finding.KeyPath = v.path
+			findings = append(findings, finding)
+		}
+	}
+	return findings
+}
+
+// sensitiveKeyMatch flags a whole value by the name of its key, with the
+// type the line patterns give such assignments in the format
+func sensitiveKeyMatch(format string, v structuredValue) *DetectedPattern {
+	typ := PatternYAMLSecret
+	switch format {
+	case structuredJSON:
+		typ = PatternJSONSecret
+	case structuredEnv:
+		typ = PatternEnvVar
+	}
+	return &DetectedPattern{
+		Type:        typ,
+		RuleName:    string(typ),
+		Pattern:     "key " + v.key,
+		Severity:    High,
+		Description: "Value under a sensitive key detected",
+		StartIndex:  0,
+		EndIndex:    len(v.value),
+		MatchText:   v.value,
+	}
+}
+
+// mergeStructuredFindings gives line findings the key path of the value
+// they cover and adds the document findings no line finding covers, such
+// as a password under a key name the line patterns do not know
+func mergeStructuredFindings(findings, found []*Finding, values []structuredValue) []*Finding {
+	byLine := make(map[int][]structuredValue)
+	for _, v := range values {
+		if v.start >= 0 {
+			byLine[v.line] = append(byLine[v.line], v)
+		}
+	}
+	for _, f := range findings {
+		if f.KeyPath != "" {
+			continue
+		}
+		for _, v := range byLine[f.LineNumber] {
+			if f.ByteStart < v.end && v.start < f.ByteEnd {
+				f.KeyPath = v.path
+				break
+			}
+		}
+	}
+
+	lineFindings := make(map[int][]*Finding)
+	for _, f := range findings {
+		lineFindings[f.LineNumber] = append(lineFindings[f.LineNumber], f)
+	}
+	for _, f := range found {
+		covered := false
+		for _, g := range lineFindings[f.LineNumber] {
+			if f.ByteEnd > f.ByteStart {
+				covered = g.ByteStart < f.ByteEnd && f.ByteStart < g.ByteEnd
+			} else {
+				covered = strings.Contains(g.MatchedText, f.MatchedText) || strings.Contains(f.MatchedText, g.MatchedText)
+			}
+			if covered {
+				if g.KeyPath == "" {
+					g.KeyPath = f.KeyPath
+				}
+				break
+			}
+		}
+		if !covered {
+			findings = append(findings, f)
+		}
+	}
+	return findings
+}
+
+// locateValues finds each value in its line, after its key when the key
+// is there too
+func locateValues(values []structuredValue, lines []string) {
+	for i := range values {
+		v := &values[i]
+		v.start, v.end = -1, -1
+		if v.line >= 1 && v.line <= len(lines) {
+			v.start, v.end = valueSpan(lines[v.line-1], v.key, v.value)
+		}
+	}
+}
+
+// valueSpan returns the byte span of value in line, looked for after key
+// first; -1, -1 when the line does not hold it as is
+func valueSpan(line, key, value string) (int, int) {
+	if value == "" {
+		return -1, -1
+	}
+	from := 0
+	if k := strings.Index(line, key); k >= 0 && key != "" {
+		from = k + len(key)
+	}
+	at := strings.Index(line[from:], value)
+	if at < 0 && from > 0 {
+		from = 0
+		at = strings.Index(line, value)
+	}
+	if at < 0 {
+		return -1, -1
+	}
+	return from + at, from + at + len(value)
+}
+
+// Sensitive key names, matched against the words of a key
+var (
+	sensitiveKeyWords = map[string]bool{
+		"password": true, "passwd": true, "pwd": true, "pass": true, "passphrase": true,
+		"secret": true, "secrets": true, "token": true, "tokens": true,
+		"credential": true, "credentials": true, "creds": true,
+		"apikey": true, "privatekey": true, "accesskey": true,
+	}
+	// Keys ending in key that name no secret
+	plainKeyWords = map[string]bool{
+		"public": true, "primary": true, "foreign": true, "sort": true, "partition": true,
+		"cache": true, "hash": true, "range": true, "index": true, "lookup": true,
+	}
+	keyWordBoundary = regexp.MustCompile(`[^A-Za-z0-9]+`)
+)
+
+// isSensitiveKey reports whether a key name suggests a secret value. The
+// last word decides, so db_password counts and token_ttl does not: a
+// password, secret, token or credential, or a key named by another word
+// than public, primary, sort and the like
+func isSensitiveKey(key string) bool {
+	words := keyWords(key)
+	if len(words) == 0 {
+		return false
+	}
+	last := words[len(words)-1]
+	if last == "key" {
+		return len(words) > 1 && !plainKeyWords[words[len(words)-2]]
+	}
+	return sensitiveKeyWords[last]
+}
+
+// keyWords splits a key name into lower case words at separators and
+// camelCase humps: DB_PASSWORD, dbPassword and db-password give db, password
+func keyWords(key string) []string {
+	var words []string
+	for _, part := range keyWordBoundary.Split(key, -1) {
+		start := 0
+		runes := []rune(part)
+		for i := 1; i < len(runes); i++ {
+			if unicode.IsUpper(runes[i]) && unicode.IsLower(runes[i-1]) {
+				words = append(words, strings.ToLower(string(runes[start:i])))
+				start = i
+			}
+		}
+		if start < len(runes) {
+			words = append(words, strings.ToLower(string(runes[start:])))
+		}
+	}
+	return words
+}
+
+// placeholderValue matches values that stand in for a secret: references
+// to variables or templates, masks and the usual fillers
+var placeholderValue = regexp.MustCompile(`(?i)^(\$\{.*\}|\$[A-Z_][A-Z0-9_]*|\{\{.*\}\}|<.*>|%\(.*\)s|\*+|x+|\.+|-+|changeme|change_me|your[_-].*|example.*|dummy|placeholder|todo|none|null|nil|true|false|secret|password)$`)
+
+// isPlaceholderValue reports whether a value under a sensitive key holds
+// no secret
+func isPlaceholderValue(value string) bool {
+	value = strings.TrimSpace(value)
+	return len(value) < 4 || placeholderValue.MatchString(value)
+}
+
+// joinKeyPath appends a key to a path
+func joinKeyPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// indexKeyPath appends an array index to a path
+func indexKeyPath(path string, i int) string {
+	return path + "[" + strconv.Itoa(i) + "]"
+}
+
+// parseJSONValues walks a JSON document token by token, so every value
+// keeps the line it ends on
+func parseJSONValues(data []byte) ([]structuredValue, error) {
+	var newlines []int
+	for i, c := range data {
+		if c == '\n' {
+			newlines = append(newlines, i)
+		}
+	}
+	lineAt := func(offset int64) int {
+		return sort.SearchInts(newlines, int(offset)) + 1
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var values []structuredValue
+	if err := walkJSON(dec, lineAt, "", "", &values); err != nil {
+		if err == io.EOF && len(values) == 0 {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("data after the JSON document")
+	}
+	return values, nil
+}
+
+// walkJSON reads the next value of dec and its children
+func walkJSON(dec *json.Decoder, lineAt func(int64) int, path, key string, values *[]structuredValue) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	switch t := tok.(type) {
+	case json.Delim:
+		for i := 0; dec.More(); i++ {
+			if t == '{' {
+				name, err := dec.Token()
+				if err != nil {
+					return err
+				}
+				k, _ := name.(string)
+				err = walkJSON(dec, lineAt, joinKeyPath(path, k), k, values)
+				if err != nil {
+					return err
+				}
+				continue
+			}
+			if err := walkJSON(dec, lineAt, indexKeyPath(path, i), key, values); err != nil {
+				return err
+			}
+		}
+		// The closing delimiter
+		_, err = dec.Token()
+		return err
+	case string:
+		addStructuredValue(values, path, key, t, lineAt(dec.InputOffset()-1))
+	case json.Number:
+		addStructuredValue(values, path, key, t.String(), lineAt(dec.InputOffset()-1))
+	}
+	return nil
+}
+
+// addStructuredValue records a leaf value until the document reaches
+// maxStructuredValues
+func addStructuredValue(values *[]structuredValue, path, key, value string, line int) {
+	if len(*values) < maxStructuredValues && value != "" {
+		*values = append(*values, structuredValue{path: path, key: key, value: value, line: line})
+	}
+}
+
+// parseYAMLValues walks every document of a YAML stream. Aliases are
+// followed, so a key that reuses an anchored value is checked under its
+// own path; values merged in with << keep the line of the anchor, where
+// they are written, and are reported there once
+func parseYAMLValues(data []byte) ([]structuredValue, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	var values []structuredValue
+	for {
+		var doc yaml.Node
+		err := dec.Decode(&doc)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		walkYAML(&doc, "", "", 0, 0, &values)
+	}
+
+	// A value reached through several paths is kept under the first
+	type spot struct {
+		line  int
+		value string
+	}
+	seen := make(map[spot]bool, len(values))
+	kept := values[:0]
+	for _, v := range values {
+		if !seen[spot{v.line, v.value}] {
+			seen[spot{v.line, v.value}] = true
+			kept = append(kept, v)
+		}
+	}
+	return kept, nil
+}
+
+// walkYAML collects the scalars under a node. line is the line of the
+// alias the node was reached through, 0 for none
+func walkYAML(n *yaml.Node, path, key string, line, depth int, values *[]structuredValue) {
+	if len(*values) >= maxStructuredValues || depth > maxStructuredDepth {
+		return
+	}
+	depth++
+	switch n.Kind {
+	case yaml.DocumentNode:
+		for _, c := range n.Content {
+			walkYAML(c, path, key, 0, depth, values)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			k, v := n.Content[i], n.Content[i+1]
+			if k.Tag != "!!merge" {
+				walkYAML(v, joinKeyPath(path, k.Value), k.Value, 0, depth, values)
+				continue
+			}
+			// << merges a mapping or a list of them into this one
+			merged := []*yaml.Node{v}
+			if v.Kind == yaml.SequenceNode {
+				merged = v.Content
+			}
+			for _, m := range merged {
+				walkYAML(m, path, key, 0, depth, values)
+			}
+		}
+	case yaml.SequenceNode:
+		for i, c := range n.Content {
+			walkYAML(c, indexKeyPath(path, i), key, 0, depth, values)
+		}
+	case yaml.AliasNode:
+		if n.Alias != nil {
+			walkYAML(n.Alias, path, key, n.Line, depth, values)
+		}
+	case yaml.ScalarNode:
+		// Booleans and nulls hold no secret
+		if n.Tag == "!!bool" || n.Tag == "!!null" {
+			return
+		}
+		if line == 0 {
+			line = n.Line
+		}
+		addStructuredValue(values, path, key, n.Value, line)
+	}
+}
+
+// envKey is the name of a variable in a .env file
+var envKey = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.\-]*$`)
+
+// parseEnvValues reads KEY=value lines as dotenv loaders do: export
+// prefixes, comments, single quoted values taken as written and double
+// quoted ones with escapes, both allowed to span lines. A line of any
+// other shape makes the file no .env document
+func parseEnvValues(data []byte) ([]structuredValue, error) {
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	var values []structuredValue
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimSpace(strings.TrimPrefix(line, "export "))
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || !envKey.MatchString(key) {
+			return nil, fmt.Errorf("line %d is no KEY=value assignment", i+1)
+		}
+		lineNum := i + 1
+		value = strings.TrimSpace(value)
+
+		if value != "" && (value[0] == '"' || value[0] == '\'') {
+			quote := value[0]
+			text := value[1:]
+			end := closingQuote(text, quote)
+			for end < 0 && i+1 < len(lines) {
+				i++
+				text += "\n" + lines[i]
+				end = closingQuote(text, quote)
+			}
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated quoted value", lineNum)
+			}
+			value = text[:end]
+			if quote == '"' {
+				value = unescapeEnv(value)
+			}
+		} else if at := strings.Index(value, " #"); at >= 0 {
+			value = strings.TrimSpace(value[:at])
+		}
+		addStructuredValue(&values, key, key, value, lineNum)
+	}
+	return values, nil
+}
+
+// closingQuote returns the index of the quote ending a value, -1 when the
+// value goes on; double quotes can be escaped
+func closingQuote(text string, quote byte) int {
+	for i := 0; i < len(text); i++ {
+		switch text[i] {
+		case '\\':
+			if quote == '"' {
+				i++
+			}
+		case quote:
+			return i
+		}
+	}
+	return -1
+}
+
+// unescapeEnv resolves the escapes of a double quoted .env value
+func unescapeEnv(value string) string {
+	if !strings.Contains(value, `\`) {
+		return value
+	}
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] != '\\' || i+1 == len(value) {
+			b.WriteByte(value[i])
+			continue
+		}
+		i++
+		switch value[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case 'r':
+			b.WriteByte('\r')
+		default:
+			b.WriteByte(value[i])
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,140 @@
+package searcher
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// scanStructuredFixture scans a fixture of testdata/structured and indexes
+// the findings by key path
+func scanStructuredFixture(t *testing.T, name string) ([]*Finding, map[string]*Finding) {
+	t.Helper()
+	findings, err := NewScanner().scanFileContent(filepath.Join("testdata", "structured", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	byKey := make(map[string]*Finding)
+	for _, f := range findings {
+		if f.KeyPath != "" && byKey[f.KeyPath] == nil {
+			byKey[f.KeyPath] = f
+		}
+	}
+	return findings, byKey
+}
+
+// checkKeyPath checks the line and matched text of the finding of a key path
+func checkKeyPath(t *testing.T, byKey map[string]*Finding, path string, line int, match string) {
+	t.Helper()
+	f := byKey[path]
+	if f == nil {
+		t.Errorf("no finding for %s", path)
+		return
+	}
+	if f.LineNumber != line {
+		t.Errorf("%s: line %d, want %d", path, f.LineNumber, line)
+	}
+	if match != "" && f.MatchedText != match {
+		t.Errorf("%s: matched %q, want %q", path, f.MatchedText, match)
+	}
+}
+
+// TestStructuredJSON tests key paths and lines of a multi-line JSON document
+func TestStructuredJSON(t *testing.T) {
+	_, byKey := scanStructuredFixture(t, "config.json")
+
+	// Found by the line patterns, the key path is added
+	checkKeyPath(t, byKey, "services.db.credentials.password", 8, "")
+	// Key names the line patterns do not know
+	checkKeyPath(t, byKey, "services.queue.clientSecret", 13, "q!7Zr#pL2v")
+	checkKeyPath(t, byKey, "users[0].pwd", 17, "Adm1n#2024")
+	if f := byKey["services.queue.clientSecret"]; f != nil && f.PatternType != PatternJSONSecret {
+		t.Errorf("clientSecret: type %s, want %s", f.PatternType, PatternJSONSecret)
+	}
+
+	for _, path := range []string{"users[1].pwd", "timeouts.token_ttl", "services.db.host", "name"} {
+		if f := byKey[path]; f != nil {
+			t.Errorf("unexpected finding for %s: %q", path, f.MatchedText)
+		}
+	}
+}
+
+// TestStructuredYAML tests anchors, aliases and merge keys
+func TestStructuredYAML(t *testing.T) {
+	findings, byKey := scanStructuredFixture(t, "compose.yaml")
+
+	// The merged copy of the anchored mapping is reported once, where it is written
+	checkKeyPath(t, byKey, "defaults.db_password", 2, "")
+	if f := byKey["production.db_password"]; f != nil {
+		t.Errorf("merged value reported again under %s", f.KeyPath)
+	}
+	// An alias under a sensitive key is reported on its own line
+	checkKeyPath(t, byKey, "services.api.auth_token", 11, "d3pl0y!Key")
+	for _, f := range findings {
+		if f.LineNumber == 12 {
+			t.Errorf("unexpected finding on line 12: %q", f.MatchedText)
+		}
+	}
+}
+
+// TestStructuredEnv tests quoted and unquoted .env values
+func TestStructuredEnv(t *testing.T) {
+	_, byKey := scanStructuredFixture(t, "app.env")
+
+	checkKeyPath(t, byKey, "DB_PASSWORD", 2, "")
+	checkKeyPath(t, byKey, "API_SECRET", 3, "single-quoted secret")
+	// The comment after an unquoted value is not part of it
+	checkKeyPath(t, byKey, "SMTP_PASS", 4, "plainValue123")
+	for _, path := range []string{"DEBUG", "EMPTY"} {
+		if f := byKey[path]; f != nil {
+			t.Errorf("unexpected finding for %s: %q", path, f.MatchedText)
+		}
+	}
+}
+
+// TestStructuredMalformed tests that a document that does not parse keeps
+// the line findings
+func TestStructuredMalformed(t *testing.T) {
+	findings, byKey := scanStructuredFixture(t, "broken.json")
+	if len(findings) == 0 {
+		t.Fatal("line findings lost")
+	}
+	if len(byKey) != 0 {
+		t.Errorf("key paths set for a malformed document: %v", byKey)
+	}
+
+	if _, err := parseEnvValues([]byte("just text\n")); err == nil {
+		t.Error("text parsed as .env")
+	}
+	if _, err := parseEnvValues([]byte("KEY=\"unterminated\n")); err == nil {
+		t.Error("unterminated quote parsed")
+	}
+}
+
+// TestIsSensitiveKey tests key name heuristics
+func TestIsSensitiveKey(t *testing.T) {
+	tests := []struct {
+		key       string
+		sensitive bool
+	}{
+		{"password", true},
+		{"DB_PASSWORD", true},
+		{"dbPassword", true},
+		{"client-secret", true},
+		{"auth_token", true},
+		{"aws_credentials", true},
+		{"api_key", true},
+		{"apiKey", true},
+		{"signing.key", true},
+		{"token_ttl", false},
+		{"public_key", false},
+		{"primaryKey", false},
+		{"key", false},
+		{"host", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := isSensitiveKey(tt.key); got != tt.sensitive {
+			t.Errorf("isSensitiveKey(%q) = %v, want %v", tt.key, got, tt.sensitive)
+		}
+	}
+}
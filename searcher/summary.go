@@ -0,0 +1,133 @@
+package searcher
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kacebover/password-finder/locale"
+)
+
+// Formatting helpers shared by the CLI summary and the GUI title/summary bar
+// so both show the same wording.
+
+// PluralRu picks the Russian plural form for n: one (1 находка),
+// few (2 находки) or many (5 находок)
+func PluralRu(n int, one, few, many string) string {
+	if n < 0 {
+		n = -n
+	}
+	switch {
+	case n%100 >= 11 && n%100 <= 14:
+		return many
+	case n%10 == 1:
+		return one
+	case n%10 >= 2 && n%10 <= 4:
+		return few
+	default:
+		return many
+	}
+}
+
+// FormatElapsed formats a scan duration: "4.2с", "3м 05с", "1ч 02м"
+func FormatElapsed(l *locale.Localizer, d time.Duration) string {
+	if d < time.Minute {
+		return l.T("elapsed.seconds", d.Seconds())
+	}
+	if d < time.Hour {
+		return l.T("elapsed.minutes", int(d.Minutes()), int(d.Seconds())%60)
+	}
+	return l.T("elapsed.hours", int(d.Hours()), int(d.Minutes())%60)
+}
+
+// FormatSeverityCounts lists non-zero severity counts: "12 крит., 3 выс."
+func FormatSeverityCounts(l *locale.Localizer, critical, high, medium, low int) string {
+	var parts []string
+	if critical > 0 {
+		parts = append(parts, l.T("counts.critical", critical))
+	}
+	if high > 0 {
+		parts = append(parts, l.T("counts.high", high))
+	}
+	if medium > 0 {
+		parts = append(parts, l.T("counts.medium", medium))
+	}
+	if low > 0 {
+		parts = append(parts, l.T("counts.low", low))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// FormatProgressTitle formats the window title during a scan.
+// A negative percent means the total is not known yet.
+func FormatProgressTitle(l *locale.Localizer, percent float64, filesProcessed, critical int) string {
+	var title string
+	if percent >= 0 {
+		if percent > 100 {
+			percent = 100
+		}
+		title = l.T("progress.title", percent)
+	} else {
+		title = l.T("progress.title_counting", filesProcessed, l.Plural(filesProcessed, "plural.file"))
+	}
+	if critical > 0 {
+		title += " — " + l.T("counts.critical", critical)
+	}
+	return title
+}
+
+// FormatFileProgress formats the progress of a scan with a known total:
+// "1234 / 56789 файлов, осталось ~3м 05с". A total still being counted is
+// shown as "≥56789"
+func FormatFileProgress(l *locale.Localizer, done, total int64, complete bool, eta time.Duration, etaKnown bool) string {
+	bound := ""
+	if !complete {
+		bound = "≥"
+	}
+	text := fmt.Sprintf("%d / %s%d %s", done, bound, total, l.Plural(int(total), "plural.file"))
+	if etaKnown && complete {
+		text += l.T("progress.eta", FormatElapsed(l, eta.Round(time.Second)))
+	}
+	return text
+}
+
+// FormatScanSummary formats a one-line summary of a finished scan:
+// "Найдено 15 находок (12 крит., 3 выс.) в 340 файлах за 4.2с"
+func FormatScanSummary(l *locale.Localizer, result *ScanResult, elapsed time.Duration) string {
+	result.mu.Lock()
+	total := result.totalFindingsLocked()
+	files := result.FilesScanned
+	counts := FormatSeverityCounts(l, result.SeveritySummary[Critical], result.SeveritySummary[High],
+		result.SeveritySummary[Medium], result.SeveritySummary[Low])
+	hidden := len(result.Baselined)
+	result.mu.Unlock()
+	newCount, known := countBaseline(result.allFindings())
+
+	summary := l.T("summary.found", total, l.Plural(total, "plural.finding"))
+	if counts != "" {
+		summary += " (" + counts + ")"
+	}
+	summary += l.T("summary.files", files, l.Plural(files, "plural.in_file"), FormatElapsed(l, elapsed))
+	if newCount+known+hidden > 0 {
+		summary += l.T("summary.baseline", newCount, known+hidden)
+		if hidden > 0 {
+			summary += l.T("summary.hidden")
+		}
+	}
+	return summary
+}
+
+// FormatSummaryTitle formats the window title shown after a scan completes
+func FormatSummaryTitle(l *locale.Localizer, result *ScanResult) string {
+	critical := result.GetSeverityCount(Critical)
+	total := result.TotalFindings()
+
+	if total == 0 {
+		return l.T("summary.title_clean")
+	}
+	title := fmt.Sprintf("%d %s", total, l.Plural(total, "plural.finding"))
+	if critical > 0 {
+		return "🔴 " + title + " — " + l.T("counts.critical", critical)
+	}
+	return "🟠 " + title
+}
@@ -0,0 +1,121 @@
+package searcher
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestPluralRu tests Russian plural forms
+func TestPluralRu(t *testing.T) {
+	tests := []struct {
+		n    int
+		want string
+	}{
+		{0, "находок"},
+		{1, "находка"},
+		{2, "находки"},
+		{4, "находки"},
+		{5, "находок"},
+		{11, "находок"},
+		{14, "находок"},
+		{21, "находка"},
+		{22, "находки"},
+		{111, "находок"},
+	}
+	for _, tt := range tests {
+		if got := PluralRu(tt.n, "находка", "находки", "находок"); got != tt.want {
+			t.Errorf("PluralRu(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
+
+// TestFormatElapsed tests duration formatting
+func TestFormatElapsed(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{4200 * time.Millisecond, "4.2с"},
+		{3*time.Minute + 5*time.Second, "3м 05с"},
+		{time.Hour + 2*time.Minute + 30*time.Second, "1ч 02м"},
+	}
+	for _, tt := range tests {
+		if got := FormatElapsed(russian, tt.d); got != tt.want {
+			t.Errorf("FormatElapsed(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
+
+// TestFormatProgressTitle tests the window title during a scan
+func TestFormatProgressTitle(t *testing.T) {
+	if got := FormatProgressTitle(russian, 43.4, 10, 12); got != "Сканирование 43% — 12 крит." {
+		t.Errorf("unexpected title: %q", got)
+	}
+	if got := FormatProgressTitle(russian, 150, 10, 0); got != "Сканирование 100%" {
+		t.Errorf("percent should be capped: %q", got)
+	}
+	if got := FormatProgressTitle(russian, -1, 3, 0); got != "Сканирование… 3 файла" {
+		t.Errorf("unknown total should show file count: %q", got)
+	}
+}
+
+// TestFormatFileProgress tests the file counter with the ETA
+func TestFormatFileProgress(t *testing.T) {
+	eta := 3*time.Minute + 5*time.Second
+	if got := FormatFileProgress(russian, 1234, 56789, true, eta, true); got != "1234 / 56789 файлов, осталось ~3м 05с" {
+		t.Errorf("complete total: %q", got)
+	}
+	if got := FormatFileProgress(russian, 10, 42, false, eta, true); got != "10 / ≥42 файла" {
+		t.Errorf("growing total should be marked and hide the ETA: %q", got)
+	}
+	if got := FormatFileProgress(russian, 0, 1, true, 0, false); got != "0 / 1 файл" {
+		t.Errorf("unknown ETA: %q", got)
+	}
+}
+
+// TestFormatScanSummary tests the one-line scan summary
+func TestFormatScanSummary(t *testing.T) {
+	result := NewScanResult()
+	for i := 0; i < 12; i++ {
+		result.AddFinding(&Finding{Severity: Critical})
+	}
+	for i := 0; i < 3; i++ {
+		result.AddFinding(&Finding{Severity: High})
+	}
+	result.FilesScanned = 340
+
+	want := "Найдено 15 находок (12 крит., 3 выс.) в 340 файлах за 4.2с"
+	if got := FormatScanSummary(russian, result, 4200*time.Millisecond); got != want {
+		t.Errorf("FormatScanSummary = %q, want %q", got, want)
+	}
+
+	if got := FormatSummaryTitle(russian, result); got != "🔴 15 находок — 12 крит." {
+		t.Errorf("FormatSummaryTitle = %q", got)
+	}
+	if got := FormatSummaryTitle(russian, NewScanResult()); got != "✅ Утечек не найдено" {
+		t.Errorf("FormatSummaryTitle(empty) = %q", got)
+	}
+}
+
+// TestScannerProgress tests progress counters are readable after a scan
+func TestScannerProgress(t *testing.T) {
+	scanner := NewScanner()
+	if p := scanner.Progress(); p.FilesProcessed != 0 {
+		t.Errorf("idle scanner should report zero progress, got %+v", p)
+	}
+
+	dir := t.TempDir()
+	createTestFile(t, dir, "secret.txt", "password=hunter2\n")
+
+	if _, err := scanner.Scan(dir); err != nil {
+		t.Fatal(err)
+	}
+	p := scanner.Progress()
+	if p.FilesProcessed != 1 || p.FindingsCount == 0 || p.CriticalCount == 0 {
+		t.Errorf("unexpected progress after scan: %+v", p)
+	}
+	if p.CurrentFile != filepath.Join(dir, "secret.txt") {
+		t.Errorf("CurrentFile = %q, want the last file started", p.CurrentFile)
+	}
+}
@@ -0,0 +1,109 @@
+package searcher
+
+import "strings"
+
+// Inline suppression comments. A line containing "dataleak:ignore" produces
+// no findings; "dataleak:ignore-next-line" does the same for the following
+// line. "=email,password" limits either form to those pattern types. The
+// marker is matched anywhere on the line, so any comment syntax works:
+//
+//	token := "test-token" // dataleak:ignore
+//	# dataleak:ignore-next-line=email
+//	admin: root@example.com  # dataleak:ignore=email
+
+const (
+	SuppressMarker         = "dataleak:ignore"
+	suppressNextLineSuffix = "-next-line"
+)
+
+// lineSuppression tells which findings of one line are suppressed
+type lineSuppression struct {
+	all   bool
+	types map[PatternType]bool
+}
+
+func (ls *lineSuppression) covers(t PatternType) bool {
+	return ls.all || ls.types[t]
+}
+
+// add widens the suppression by a marker's qualifier; an empty one means all types
+func (ls *lineSuppression) add(qualifier string) {
+	if qualifier == "" {
+		ls.all = true
+		return
+	}
+	if ls.types == nil {
+		ls.types = make(map[PatternType]bool)
+	}
+	for _, name := range strings.Split(qualifier, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			ls.types[PatternType(strings.ToLower(name))] = true
+		}
+	}
+}
+
+// suppressions maps line numbers to their suppression. It is filled line by
+// line while a file is read and applied to all its findings at the end, so
+// content detectors spanning lines are covered too
+type suppressions map[int]*lineSuppression
+
+// note records the markers found on line lineNum (1-based)
+func (sup suppressions) note(lineNum int, line string) {
+	for {
+		i := strings.Index(line, SuppressMarker)
+		if i < 0 {
+			return
+		}
+		line = line[i+len(SuppressMarker):]
+
+		target := lineNum
+		if strings.HasPrefix(line, suppressNextLineSuffix) {
+			target = lineNum + 1
+			line = line[len(suppressNextLineSuffix):]
+		}
+		qualifier := ""
+		if strings.HasPrefix(line, "=") {
+			line = line[1:]
+			end := strings.IndexFunc(line, func(r rune) bool {
+				return !(r == '_' || r == ',' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+			})
+			if end < 0 {
+				end = len(line)
+			}
+			qualifier, line = line[:end], line[end:]
+		}
+
+		ls := sup[target]
+		if ls == nil {
+			ls = &lineSuppression{}
+			sup[target] = ls
+		}
+		ls.add(qualifier)
+	}
+}
+
+// noteLines records the markers of a whole text
+func (sup suppressions) noteLines(lines []string) {
+	for i, line := range lines {
+		if strings.Contains(line, SuppressMarker) {
+			sup.note(i+1, line)
+		}
+	}
+}
+
+// filter drops the suppressed findings and returns how many were dropped
+func (sup suppressions) filter(findings []*Finding) ([]*Finding, int) {
+	if len(sup) == 0 {
+		return findings, 0
+	}
+	kept := findings[:0]
+	suppressed := 0
+	for _, f := range findings {
+		if ls := sup[f.LineNumber]; ls != nil && ls.covers(f.PatternType) {
+			suppressed++
+			continue
+		}
+		kept = append(kept, f)
+	}
+	return kept, suppressed
+}
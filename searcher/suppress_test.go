@@ -0,0 +1,110 @@
+package searcher
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// findingTypesByLine groups the pattern types of the findings by line
+func findingTypesByLine(findings []*Finding) map[int]map[PatternType]bool {
+	lines := make(map[int]map[PatternType]bool)
+	for _, f := range findings {
+		if lines[f.LineNumber] == nil {
+			lines[f.LineNumber] = make(map[PatternType]bool)
+		}
+		lines[f.LineNumber][f.PatternType] = true
+	}
+	return lines
+}
+
+func TestInlineSuppression(t *testing.T) {
+	tests := []struct {
+		name       string
+		file       string
+		content    string
+		suppressed []int // Lines that must have no findings
+		reported   []int // Lines that must still have findings
+	}{
+		{
+			name: "go",
+			file: "config.go",
+			content: "password := \"Hunter2Secret!\" // dataleak:ignore\n" +
+				"// dataleak:ignore-next-line\n" +
+				"password := \"Hunter3Secret!\"\n" +
+				"password := \"Hunter4Secret!\"\n",
+			suppressed: []int{1, 3},
+			reported:   []int{4},
+		},
+		{
+			name: "python",
+			file: "settings.py",
+			content: "PASSWORD = 'Hunter2Secret!'  # dataleak:ignore\n" +
+				"# dataleak:ignore-next-line\n" +
+				"PASSWORD = 'Hunter3Secret!'\n" +
+				"PASSWORD = 'Hunter4Secret!'\n",
+			suppressed: []int{1, 3},
+			reported:   []int{4},
+		},
+		{
+			name: "yaml",
+			file: "app.yaml",
+			content: "password: Hunter2Secret!  # dataleak:ignore\n" +
+				"# dataleak:ignore-next-line\n" +
+				"password: Hunter3Secret!\n" +
+				"password: Hunter4Secret!\n",
+			suppressed: []int{1, 3},
+			reported:   []int{4},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			os.WriteFile(filepath.Join(dir, tt.file), []byte(tt.content), 0644)
+
+			result, err := NewScanner().Scan(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			lines := findingTypesByLine(result.Findings)
+			for _, line := range tt.suppressed {
+				if len(lines[line]) > 0 {
+					t.Errorf("line %d should be suppressed, got %v", line, lines[line])
+				}
+			}
+			for _, line := range tt.reported {
+				if len(lines[line]) == 0 {
+					t.Errorf("line %d should still be reported", line)
+				}
+			}
+			if result.SuppressedCount < len(tt.suppressed) {
+				t.Errorf("SuppressedCount = %d, want at least %d", result.SuppressedCount, len(tt.suppressed))
+			}
+		})
+	}
+}
+
+func TestInlineSuppressionQualifier(t *testing.T) {
+	text := "admin@example.com password=Hunter2Secret! # dataleak:ignore=email\n" +
+		"# dataleak:ignore-next-line=password,email\n" +
+		"admin@example.com password=Hunter3Secret!\n" +
+		"admin@example.com # dataleak:ignore=phone_number\n"
+
+	scanner := NewScanner()
+	lines := findingTypesByLine(scanner.scanTextContent(context.Background(), "notes.txt", text))
+
+	if lines[1][PatternEmail] || !lines[1][PatternPassword] {
+		t.Errorf("line 1: only the email should be suppressed, got %v", lines[1])
+	}
+	if lines[3][PatternEmail] || lines[3][PatternPassword] {
+		t.Errorf("line 3: email and password should be suppressed, got %v", lines[3])
+	}
+	if !lines[4][PatternEmail] {
+		t.Errorf("line 4: a qualifier for another type should not hide the email, got %v", lines[4])
+	}
+	if scanner.result.SuppressedCount == 0 {
+		t.Error("suppressed matches should be counted")
+	}
+}
@@ -0,0 +1,123 @@
+package searcher
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/kacebover/password-finder/fsutil"
+)
+
+// Symbolic links are skipped unless SetFollowSymlinks is on. Followed links
+// to directories are descended into once per target: every directory a walk
+// enters is remembered by its file id, so a link back to an ancestor, or
+// a chain like a -> b -> a, ends the walk instead of looping. Links leading
+// outside the scan root are skipped unless SetFollowExternalSymlinks is on
+
+// entryKind is what a directory entry is after resolving links
+type entryKind int
+
+const (
+	entrySkip entryKind = iota
+	entryFile
+	entryDir
+)
+
+// dirWalk is the state of one walk of the tree. The scan and the
+// enumeration pass each have their own
+type dirWalk struct {
+	root    string // Scan root with links resolved, empty if it cannot be resolved
+	mu      sync.Mutex
+	visited map[fileID]bool
+}
+
+// SetFollowSymlinks makes the scan follow symbolic links to files and
+// directories inside the scan root
+func (s *Scanner) SetFollowSymlinks(follow bool) {
+	s.followSymlinks = follow
+}
+
+// SetFollowExternalSymlinks also follows links that lead outside the scan
+// root; it has no effect without SetFollowSymlinks
+func (s *Scanner) SetFollowExternalSymlinks(follow bool) {
+	s.externalSymlinks = follow
+}
+
+// newDirWalk starts a walk of root
+func newDirWalk(root string) *dirWalk {
+	walk := &dirWalk{visited: make(map[fileID]bool)}
+	if resolved, err := filepath.EvalSymlinks(root); err == nil {
+		if abs, err := filepath.Abs(resolved); err == nil {
+			walk.root = abs
+		}
+	}
+	if info, err := fsutil.Stat(root); err == nil {
+		walk.visited[fileIDOf(info, walk.root)] = true
+	}
+	return walk
+}
+
+// enter remembers a directory; it returns false if the walk was already there
+func (w *dirWalk) enter(info os.FileInfo, path string) bool {
+	id := fileIDOf(info, path)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.visited[id] {
+		return false
+	}
+	w.visited[id] = true
+	return true
+}
+
+// inside reports whether a resolved path is the scan root or below it
+func (w *dirWalk) inside(path string) bool {
+	if w.root == "" {
+		return false
+	}
+	rel, err := filepath.Rel(w.root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// resolveEntry tells whether an entry is scanned as a file, walked as a
+// directory or skipped; reason explains a skipped link
+func (s *Scanner) resolveEntry(fullPath string, entry os.DirEntry, walk *dirWalk) (kind entryKind, reason SkipReason) {
+	if entry.Type()&os.ModeSymlink == 0 {
+		if !entry.IsDir() {
+			return entryFile, SkipReason{}
+		}
+		// Real directories are always walked; they are only remembered
+		// so a link back to them is recognised
+		if s.followSymlinks {
+			if info, err := entry.Info(); err == nil {
+				walk.enter(info, fullPath)
+			}
+		}
+		return entryDir, SkipReason{}
+	}
+
+	if !s.followSymlinks {
+		return entrySkip, skipReason("skip.symlink")
+	}
+	target, err := filepath.EvalSymlinks(fullPath)
+	if err != nil {
+		return entrySkip, skipReason("skip.symlink_broken")
+	}
+	if abs, err := filepath.Abs(target); err == nil {
+		target = abs
+	}
+	if !s.externalSymlinks && !walk.inside(target) {
+		return entrySkip, skipReason("skip.symlink_outside", target)
+	}
+	info, err := fsutil.Stat(target)
+	if err != nil {
+		return entrySkip, skipReason("skip.symlink_broken")
+	}
+	if !info.IsDir() {
+		return entryFile, SkipReason{}
+	}
+	if !walk.enter(info, target) {
+		return entrySkip, skipReason("skip.symlink_loop", target)
+	}
+	return entryDir, SkipReason{}
+}
@@ -0,0 +1,154 @@
+package searcher
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// symlinkFixture creates a tree with a self-referential link, a two-link
+// loop x -> y -> x, a link to a file, a broken link and a link leading
+// outside the root. It returns the root and the outside directory
+func symlinkFixture(t *testing.T) (root, outside string) {
+	root, outside = t.TempDir(), t.TempDir()
+	createTestFile(t, root, "x/x.env", "password=XSecret123\n")
+	createTestFile(t, root, "y/y.env", "password=YSecret123\n")
+	createTestFile(t, outside, "ext.env", "password=External123\n")
+
+	links := map[string]string{
+		"self":         ".",
+		"x/to_y":       filepath.Join("..", "y"),
+		"y/to_x":       filepath.Join("..", "x"),
+		"linked.env":   filepath.Join("x", "x.env"),
+		"broken":       "missing",
+		"external":     outside,
+		"x/to_outside": filepath.Join(outside, "ext.env"),
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(root, name)); err != nil {
+			t.Skipf("symlinks are not available: %v", err)
+		}
+	}
+	return root, outside
+}
+
+// scanWithTimeout fails the test if the scan does not terminate
+func scanWithTimeout(t *testing.T, scanner *Scanner, root string) *ScanResult {
+	t.Helper()
+	done := make(chan *ScanResult, 1)
+	go func() {
+		result, err := scanner.Scan(root)
+		if err != nil {
+			t.Error(err)
+		}
+		done <- result
+	}()
+	select {
+	case result := <-done:
+		return result
+	case <-time.After(20 * time.Second):
+		t.Fatal("scan did not terminate, symlink loop?")
+		return nil
+	}
+}
+
+// foundSecrets returns the matched passwords by file path
+func foundSecrets(result *ScanResult) map[string]bool {
+	found := make(map[string]bool)
+	for _, f := range result.Findings {
+		for _, secret := range []string{"XSecret123", "YSecret123", "External123"} {
+			if strings.Contains(f.Context, secret) {
+				found[secret] = true
+			}
+		}
+	}
+	return found
+}
+
+func TestSymlinksSkippedByDefault(t *testing.T) {
+	root, _ := symlinkFixture(t)
+	result := scanWithTimeout(t, NewScanner(), root)
+
+	if found := foundSecrets(result); !found["XSecret123"] || !found["YSecret123"] || found["External123"] {
+		t.Errorf("found %v, want only the real files", found)
+	}
+	for _, name := range []string{"self", "linked.env", "broken", "external", filepath.Join("x", "to_y")} {
+		if reason := result.SkipReasons[filepath.Join(root, name)]; !strings.HasPrefix(reason.Key, "skip.symlink") {
+			t.Errorf("%s: skip reason %q", name, reason)
+		}
+	}
+	if result.FilesScanned != 2 {
+		t.Errorf("scanned %d files, want 2", result.FilesScanned)
+	}
+}
+
+func TestFollowSymlinksTerminatesOnLoops(t *testing.T) {
+	root, outside := symlinkFixture(t)
+	scanner := NewScanner()
+	scanner.SetFollowSymlinks(true)
+	result := scanWithTimeout(t, scanner, root)
+
+	found := foundSecrets(result)
+	if !found["XSecret123"] || !found["YSecret123"] {
+		t.Errorf("found %v", found)
+	}
+	if found["External123"] {
+		t.Error("links outside the root should be skipped by default")
+	}
+	if reason := result.SkipReasons[filepath.Join(root, "self")]; reason.Key != "skip.symlink_loop" {
+		t.Errorf("self link: skip reason %q", reason)
+	}
+	if reason := result.SkipReasons[filepath.Join(root, "external")]; reason.Key != "skip.symlink_outside" {
+		t.Errorf("external link: skip reason %q", reason)
+	}
+	if reason := result.SkipReasons[filepath.Join(root, "broken")]; reason.Key != "skip.symlink_broken" {
+		t.Errorf("broken link: skip reason %q", reason)
+	}
+
+	// The linked file is scanned under the link's path
+	var linked bool
+	for _, f := range result.Findings {
+		linked = linked || f.FilePath == filepath.Join(root, "linked.env")
+	}
+	if !linked {
+		t.Error("the link to a file should be scanned")
+	}
+
+	// Each directory is walked at most once per path to it, so x and y
+	// are read at most twice (directly and through one link)
+	if result.FilesScanned > 5 {
+		t.Errorf("scanned %d files, loops were followed", result.FilesScanned)
+	}
+
+	scanner.SetFollowExternalSymlinks(true)
+	result = scanWithTimeout(t, scanner, root)
+	if !foundSecrets(result)["External123"] {
+		t.Errorf("external links should be followed when allowed; skipped: %v", result.SkipReasons)
+	}
+	if _, skipped := result.SkipReasons[filepath.Join(outside, "ext.env")]; skipped {
+		t.Error("external file was skipped")
+	}
+}
+
+func TestEnumerateFollowsSymlinks(t *testing.T) {
+	root, _ := symlinkFixture(t)
+	scanner := NewScanner()
+	scanner.SetFollowSymlinks(true)
+
+	done := make(chan EnumerationResult, 1)
+	go func() {
+		total, _ := scanner.Enumerate(context.Background(), root)
+		done <- total
+	}()
+	select {
+	case total := <-done:
+		if !total.Complete || total.Files < 3 {
+			t.Errorf("enumeration = %+v", total)
+		}
+	case <-time.After(20 * time.Second):
+		t.Fatal("enumeration did not terminate")
+	}
+}
@@ -0,0 +1,70 @@
+//go:build ignore
+// +build ignore
+
+// Generates the PDF417 fixtures with an encoder independent of the
+// decoder. It needs github.com/boombuler/barcode, which the module does
+// not depend on, so it runs in a module of its own:
+//
+//	mkdir /tmp/pdf417 && cp pdf417.go /tmp/pdf417 && cd /tmp/pdf417 &&
+//	go mod init pdf417 && go get github.com/boombuler/barcode@v1.1.0 &&
+//	go run pdf417.go && cp pdf417_*.* -t "$OLDPWD"
+package main
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"os"
+
+	"github.com/boombuler/barcode/pdf417"
+)
+
+// render encodes a payload on a white image with a quiet zone of two
+// modules; modules are scale pixels wide and rows three modules high
+func render(payload string, level byte, scale int) image.Image {
+	code, err := pdf417.Encode(payload, level)
+	if err != nil {
+		panic(err)
+	}
+	// Rows of the encoder are two pixels high
+	b := code.Bounds()
+	quiet := 2 * scale
+	img := image.NewGray(image.Rect(0, 0, b.Dx()*scale+2*quiet, b.Dy()/2*3*scale+2*quiet))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	for y := 0; y < b.Dy(); y += 2 {
+		for x := 0; x < b.Dx(); x++ {
+			if code.At(x, y) == color.Black {
+				module := image.Rect(x*scale, y/2*3*scale, (x+1)*scale, (y/2+1)*3*scale)
+				draw.Draw(img, module.Add(image.Pt(quiet, quiet)), image.Black, image.Point{}, draw.Src)
+			}
+		}
+	}
+	return img
+}
+
+func write(name string, img image.Image) {
+	f, err := os.Create(name)
+	if err != nil {
+		panic(err)
+	}
+	defer f.Close()
+	if name[len(name)-4:] == ".png" {
+		err = png.Encode(f, img)
+	} else {
+		err = jpeg.Encode(f, img, &jpeg.Options{Quality: 70})
+	}
+	if err != nil {
+		panic(err)
+	}
+}
+
+func main() {
+	// The payloads of barcode_test.go: a driver's license, mostly text
+	// compaction with byte shifts, and UTF-8 in byte compaction with a run of
+	// digits in numeric compaction
+	write("pdf417_license.png", render("@\n\x1e\rANSI 636014090002DL00410230ZA02710015DLDAQD1234562\nDCSPUBLIC\nDACJOHN\nDDEN\nDBB01311980\n"+
+		"DBA01312030\nDBC1\nDAG123 MAIN STREET\nDAISACRAMENTO\nDAJCA\nDAK958230000\n\r", 3, 2))
+	write("pdf417_ssn.jpg", render("Сотрудник: Иван Петров\nSSN: 536-22-8147\nEmail: ivan.petrov@example.com\nИНН 7707083893 БИК 044525225 Счёт 40817810099910004312", 2, 3))
+}
@@ -0,0 +1,134 @@
+//go:build ignore
+// +build ignore
+
+// Generates the face detection fixtures: an ID card with the holder's photo
+// on the left, cut from portrait.jpg, and a landscape without people:
+//
+//	cd searcher/testdata/faces && go run generate.go
+package main
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"math/rand"
+	"os"
+)
+
+type canvas struct {
+	*image.RGBA
+}
+
+func newCanvas(w, h int, background color.RGBA) canvas {
+	c := canvas{image.NewRGBA(image.Rect(0, 0, w, h))}
+	c.fill(func(x, y int) bool { return true }, background)
+	return c
+}
+
+func (c canvas) fill(inside func(x, y int) bool, col color.RGBA) {
+	b := c.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if inside(x, y) {
+				c.SetRGBA(x, y, col)
+			}
+		}
+	}
+}
+
+func (c canvas) rect(x0, y0, x1, y1 int, col color.RGBA) {
+	c.fill(func(x, y int) bool { return x >= x0 && x < x1 && y >= y0 && y < y1 }, col)
+}
+
+func (c canvas) ellipse(cx, cy, rx, ry float64, col color.RGBA) {
+	c.fill(func(x, y int) bool {
+		dx, dy := (float64(x)-cx)/rx, (float64(y)-cy)/ry
+		return dx*dx+dy*dy <= 1
+	}, col)
+}
+
+// polygon fills by the even-odd rule
+func (c canvas) polygon(points [][2]float64, col color.RGBA) {
+	c.fill(func(x, y int) bool {
+		px, py := float64(x)+0.5, float64(y)+0.5
+		inside := false
+		for i, j := 0, len(points)-1; i < len(points); j, i = i, i+1 {
+			a, b := points[i], points[j]
+			if (a[1] > py) != (b[1] > py) && px < (b[0]-a[0])*(py-a[1])/(b[1]-a[1])+a[0] {
+				inside = !inside
+			}
+		}
+		return inside
+	}, col)
+}
+
+// photo reads the holder's photo, see NOTICE
+func photo() image.Image {
+	f, err := os.Open("portrait.jpg")
+	if err != nil {
+		panic(err)
+	}
+	defer f.Close()
+	img, err := jpeg.Decode(f)
+	if err != nil {
+		panic(err)
+	}
+	return img
+}
+
+func write(name string, img image.Image) {
+	f, err := os.Create(name)
+	if err != nil {
+		panic(err)
+	}
+	defer f.Close()
+	if name[len(name)-4:] == ".png" {
+		err = png.Encode(f, img)
+	} else {
+		err = jpeg.Encode(f, img, &jpeg.Options{Quality: 88})
+	}
+	if err != nil {
+		panic(err)
+	}
+}
+
+func main() {
+	// An ID-1 card, 85.6 x 54 mm: header, photo on the left, text fields
+	card := newCanvas(856, 540, color.RGBA{236, 240, 232, 255})
+	card.rect(0, 0, 856, 90, color.RGBA{40, 80, 140, 255})
+	card.rect(40, 20, 420, 34, color.RGBA{230, 235, 245, 255})
+	card.rect(40, 50, 300, 62, color.RGBA{230, 235, 245, 255})
+	draw.Draw(card, image.Rect(40, 125, 300, 480), photo(), image.Pt(25, 45), draw.Src)
+	for i, width := range []int{300, 420, 260, 380, 200, 340} {
+		card.rect(340, 140+i*52, 340+width, 156+i*52, color.RGBA{70, 70, 82, 255})
+	}
+	write("id_card.png", card)
+
+	// A landscape: sky, sun, mountains, hills with trees and a lake
+	const w, h = 900, 600
+	land := newCanvas(w, h, color.RGBA{})
+	for y := 0; y < h; y++ {
+		t := float64(y) / float64(h)
+		land.rect(0, y, w, y+1, color.RGBA{uint8(120 + 80*t), uint8(170 + 55*t), uint8(230 + 20*t), 255})
+	}
+	land.ellipse(700, 120, 50, 50, color.RGBA{255, 230, 120, 255})
+	land.polygon([][2]float64{{0, 380}, {180, 170}, {330, 330}, {480, 140}, {700, 360}, {900, 220}, {900, 420}, {0, 420}}, color.RGBA{110, 120, 150, 255})
+	land.polygon([][2]float64{{150, 205}, {180, 170}, {212, 208}}, color.RGBA{240, 244, 250, 255})
+	land.polygon([][2]float64{{445, 182}, {480, 140}, {517, 184}}, color.RGBA{240, 244, 250, 255})
+	land.fill(func(x, y int) bool {
+		return float64(y) > 400+30*math.Sin(float64(x)/90)
+	}, color.RGBA{86, 140, 62, 255})
+	land.ellipse(300, 520, 220, 45, color.RGBA{70, 120, 170, 255})
+	rng := rand.New(rand.NewSource(7))
+	for i := 0; i < 14; i++ {
+		x := 40 + rng.Float64()*820
+		y := 430 + rng.Float64()*120
+		size := 30 + rng.Float64()*40
+		land.rect(int(x-4), int(y), int(x+4), int(y+size*0.3), color.RGBA{90, 60, 40, 255})
+		land.polygon([][2]float64{{x - size/2, y}, {x, y - size}, {x + size/2, y}}, color.RGBA{34, 80, 40, 255})
+	}
+	write("landscape.jpg", land)
+}
@@ -0,0 +1,204 @@
+//go:build ignore
+// +build ignore
+
+// Generates the password-protected PDF fixtures:
+//
+//	cd searcher/testdata/pdf && go run generate_encrypted.go
+package main
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/md5"
+	"crypto/rc4"
+	"encoding/binary"
+	"fmt"
+	"os"
+)
+
+var padding = []byte{
+	0x28, 0xBF, 0x4E, 0x5E, 0x4E, 0x75, 0x8A, 0x41, 0x64, 0x00, 0x4E, 0x56, 0xFF, 0xFA, 0x01, 0x08,
+	0x2E, 0x2E, 0x00, 0xB6, 0xD0, 0x68, 0x3E, 0x80, 0x2F, 0x0C, 0xA9, 0xFE, 0x64, 0x53, 0x69, 0x7A,
+}
+
+// Fixed ID and IVs keep the output reproducible
+var fileID = []byte("data-leak-fixtur")
+
+const content = "BT /F1 12 Tf 72 720 Td (password = Kx9mQ2vLp7Zr) Tj ET"
+
+type handler struct {
+	v, r, keyLen int
+	aes          bool
+	user, owner  string
+	p            int32
+}
+
+func main() {
+	write("rc4_40.pdf", handler{v: 1, r: 2, keyLen: 5, user: "secret", owner: "owner", p: -44})
+	write("rc4_128_open.pdf", handler{v: 2, r: 3, keyLen: 16, user: "", owner: "owner", p: -3904})
+	write("aes128.pdf", handler{v: 4, r: 4, keyLen: 16, aes: true, user: "secret", owner: "owner", p: -3904})
+	writeAES256("aes256.pdf")
+}
+
+func pad(password string) []byte {
+	return append([]byte(password), padding...)[:32]
+}
+
+func rc4Crypt(key, data []byte) []byte {
+	c, _ := rc4.NewCipher(key)
+	out := make([]byte, len(data))
+	c.XORKeyStream(out, data)
+	return out
+}
+
+func xorEach(key []byte, b byte) []byte {
+	out := make([]byte, len(key))
+	for i, k := range key {
+		out[i] = k ^ b
+	}
+	return out
+}
+
+// ownerEntry computes /O (Algorithm 3)
+func (h handler) ownerEntry() []byte {
+	sum := md5.Sum(pad(h.owner))
+	digest := sum[:]
+	if h.r >= 3 {
+		for i := 0; i < 50; i++ {
+			sum = md5.Sum(digest)
+			digest = sum[:]
+		}
+	}
+	key := digest[:h.keyLen]
+	o := rc4Crypt(key, pad(h.user))
+	if h.r >= 3 {
+		for i := 1; i <= 19; i++ {
+			o = rc4Crypt(xorEach(key, byte(i)), o)
+		}
+	}
+	return o
+}
+
+// fileKey computes the encryption key (Algorithm 2)
+func (h handler) fileKey(o []byte) []byte {
+	var buf bytes.Buffer
+	buf.Write(pad(h.user))
+	buf.Write(o)
+	binary.Write(&buf, binary.LittleEndian, h.p)
+	buf.Write(fileID)
+	sum := md5.Sum(buf.Bytes())
+	digest := sum[:]
+	if h.r >= 3 {
+		for i := 0; i < 50; i++ {
+			sum = md5.Sum(digest[:h.keyLen])
+			digest = sum[:]
+		}
+	}
+	return digest[:h.keyLen]
+}
+
+// userEntry computes /U (Algorithms 4 and 5)
+func (h handler) userEntry(key []byte) []byte {
+	if h.r == 2 {
+		return rc4Crypt(key, padding)
+	}
+	sum := md5.Sum(append(append([]byte{}, padding...), fileID...))
+	u := sum[:]
+	for i := 0; i <= 19; i++ {
+		u = rc4Crypt(xorEach(key, byte(i)), u)
+	}
+	return append(u, make([]byte, 16)...)
+}
+
+func (h handler) encryptObject(key []byte, num int, data []byte) []byte {
+	material := append(append([]byte{}, key...), byte(num), byte(num>>8), byte(num>>16), 0, 0)
+	if h.aes {
+		material = append(material, "sAlT"...)
+	}
+	sum := md5.Sum(material)
+	n := len(key) + 5
+	if n > 16 {
+		n = 16
+	}
+	objKey := sum[:n]
+	if !h.aes {
+		return rc4Crypt(objKey, data)
+	}
+
+	padLen := 16 - len(data)%16
+	plain := append(append([]byte{}, data...), bytes.Repeat([]byte{byte(padLen)}, padLen)...)
+	iv := []byte("fixture-iv-16byt")
+	block, _ := aes.NewCipher(objKey)
+	out := make([]byte, len(plain))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(out, plain)
+	return append(iv, out...)
+}
+
+func compressed() []byte {
+	var buf bytes.Buffer
+	w := zlib.NewWriter(&buf)
+	w.Write([]byte(content))
+	w.Close()
+	return buf.Bytes()
+}
+
+func write(name string, h handler) {
+	o := h.ownerEntry()
+	key := h.fileKey(o)
+	u := h.userEntry(key)
+
+	encrypt := fmt.Sprintf("<< /Filter /Standard /V %d /R %d /O <%x> /U <%x> /P %d", h.v, h.r, o, u, h.p)
+	switch {
+	case h.v == 2:
+		encrypt += fmt.Sprintf(" /Length %d", h.keyLen*8)
+	case h.v == 4:
+		encrypt += " /Length 128 /CF << /StdCF << /AuthEvent /DocOpen /CFM /AESV2 /Length 16 >> >> /StmF /StdCF /StrF /StdCF"
+	}
+	encrypt += " >>"
+
+	stream := h.encryptObject(key, 4, compressed())
+	writePDF(name, stream, encrypt)
+}
+
+// writeAES256 writes a revision 6 document, which is only detected
+func writeAES256(name string) {
+	entry := bytes.Repeat([]byte{0xA5}, 48)
+	key := bytes.Repeat([]byte{0x5A}, 32)
+	encrypt := fmt.Sprintf("<< /Filter /Standard /V 5 /R 6 /Length 256 /O <%x> /U <%x> /OE <%x> /UE <%x> /Perms <%x> /P -3904"+
+		" /CF << /StdCF << /AuthEvent /DocOpen /CFM /AESV3 /Length 32 >> >> /StmF /StdCF /StrF /StdCF >>",
+		entry, entry, key, key, key[:16])
+	writePDF(name, bytes.Repeat([]byte{0x42}, 64), encrypt)
+}
+
+func writePDF(name string, stream []byte, encrypt string) {
+	var buf bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	buf.WriteString("%PDF-1.6\n%\xE2\xE3\xCF\xD3\n")
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object("<< /Type /Pages /Kids [3 0 R] /Count 1 >>")
+	object("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents 4 0 R /Resources << /Font << /F1 5 0 R >> >> >>")
+	object(fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", len(stream), stream))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>")
+	object(encrypt)
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R /Encrypt 6 0 R /ID [<%x> <%x>] >>\nstartxref\n%d\n%%%%EOF\n",
+		len(offsets)+1, fileID, fileID, xref)
+
+	if err := os.WriteFile(name, buf.Bytes(), 0644); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	fmt.Println("✅", name)
+}
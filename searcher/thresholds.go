@@ -0,0 +1,110 @@
+package searcher
+
+import "context"
+
+// A generated file, e.g. a localization with thousands of emails, can give
+// thousands of Low findings that swamp the result. A minimum severity drops
+// findings below it before they are recorded, and a cap per file stops
+// matching in a file after that many findings at or above the minimum
+
+// SetMinSeverity drops findings below severity before they are added to the
+// result; they are counted in ScanResult.FilteredFindings. Empty keeps all
+func (s *Scanner) SetMinSeverity(severity Severity) {
+	s.minSeverity = severity
+}
+
+// SetMaxFindingsPerFile stops matching in a file after n findings at or above
+// the minimum severity; the file is listed in ScanResult.Truncated. Zero
+// means no limit
+func (s *Scanner) SetMaxFindingsPerFile(n int) {
+	s.maxPerFile = max(n, 0)
+}
+
+// belowMin reports whether a finding is dropped by the minimum severity
+func (s *Scanner) belowMin(f *Finding) bool {
+	return s.minSeverity != "" && f.Severity.Score() < s.minSeverity.Score()
+}
+
+// countKept returns how many findings count towards the cap of a file
+func (s *Scanner) countKept(findings []*Finding) int {
+	n := 0
+	for _, f := range findings {
+		if !s.belowMin(f) {
+			n++
+		}
+	}
+	return n
+}
+
+// capReached reports whether matching in a file stops after kept findings
+func (s *Scanner) capReached(kept int) bool {
+	return s.maxPerFile > 0 && kept >= s.maxPerFile
+}
+
+// capFindings keeps the first findings of a file up to the cap. A line can
+// give several findings and content detectors add their own, so the cap is
+// enforced here too; stopped tells matching ended before the file did
+func (s *Scanner) capFindings(ctx context.Context, path string, findings []*Finding, stopped bool) []*Finding {
+	if s.maxPerFile == 0 {
+		return findings
+	}
+	kept := findings[:0]
+	n := 0
+	for _, f := range findings {
+		if !s.belowMin(f) {
+			if n == s.maxPerFile {
+				stopped = true
+				continue
+			}
+			n++
+		}
+		kept = append(kept, f)
+	}
+	if stopped {
+		recordLater(ctx, func() { s.result.AddTruncated(path, n) })
+	}
+	return kept
+}
+
+// filterFindings applies the severity overrides, the heuristics and the
+// email allowlist, then drops the findings below the minimum severity.
+// Findings of disabled types from detectors other than patterns, such as
+// image metadata, go too. Addresses and numbers are counted before the
+// minimum severity applies, see bulkCounter
+func (s *Scanner) filterFindings(findings []*Finding) []*Finding {
+	kept := findings[:0]
+	filtered := 0
+	bulk := newBulkCounter(s.bulkThreshold)
+	for _, f := range findings {
+		if !s.patterns.Enabled(f.PatternType) {
+			continue
+		}
+		s.severities.apply(f)
+		if s.applyHeuristics(f) {
+			continue
+		}
+		if s.allowlistedEmail(f) {
+			lowerAllowlisted(f)
+		} else {
+			bulk.add(f)
+		}
+		if s.belowMin(f) {
+			filtered++
+			continue
+		}
+		kept = append(kept, f)
+	}
+	for _, f := range bulk.findings() {
+		if !s.patterns.Enabled(f.PatternType) {
+			continue
+		}
+		s.severities.apply(f)
+		if s.belowMin(f) {
+			filtered++
+			continue
+		}
+		kept = append(kept, f)
+	}
+	s.result.AddFiltered(filtered)
+	return kept
+}
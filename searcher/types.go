@@ -1,6 +1,8 @@
 package searcher
 
 import (
+	"fmt"
+	"strings"
 	"sync"
 	"time"
 )
@@ -31,9 +33,25 @@ func (s Severity) Score() int {
 	}
 }
 
+// ParseSeverity converts a severity name from a config file into a Severity
+func ParseSeverity(name string) (Severity, error) {
+	switch Severity(strings.ToLower(strings.TrimSpace(name))) {
+	case Critical:
+		return Critical, nil
+	case High:
+		return High, nil
+	case Medium:
+		return Medium, nil
+	case Low:
+		return Low, nil
+	}
+	return "", fmt.Errorf("неизвестный уровень серьёзности: %q (допустимо: critical, high, medium, low)", name)
+}
+
 // DetectedPattern represents a single pattern match with context
 type DetectedPattern struct {
 	Type         PatternType
+	RuleName     string
 	Pattern      string
 	Severity     Severity
 	Description  string
@@ -44,35 +62,91 @@ type DetectedPattern struct {
 	FilePath     string  // Set during file scanning
 	Context      string  // Line context where match was found
 	EntropyScore float64 // Entropy score if applicable
+	Group        string  // Detector group or pack of the rule, empty for core rules
+	RiskBonus    float64 // Extra risk points the rule's Refine check added
+	BonusReason  string  // Catalog key explaining RiskBonus in score traces
+	BonusArgs    []any   // Arguments of BonusReason
 }
 
 // Finding represents a complete finding with all details
 type Finding struct {
-	FilePath     string
-	LineNumber   int
-	ColumnStart  int
-	ColumnEnd    int
-	PatternType  PatternType
-	Severity     Severity
-	Description  string
-	MatchedText  string
-	Context      string // The full line of context
-	EntropyScore float64
-	RiskScore    float64 // Combined score including entropy
+	FilePath      string
+	LineNumber    int
+	ColumnStart   int // Character (rune) column, 0-based
+	ColumnEnd     int // Character (rune) column, exclusive
+	ByteStart     int // Byte offset of the match in Context
+	ByteEnd       int
+	PatternType   PatternType
+	Severity      Severity
+	Description   string
+	MatchedText   string
+	Context       string   // The full line of context; a chunk of lines longer than the line length cap
+	ContextBefore []string `json:",omitempty"` // Lines before the match, nearest last, see Scanner.SetContextLines
+	ContextAfter  []string `json:",omitempty"` // Lines after the match
+	EntropyScore  float64
+	RiskScore     float64        // Combined score including entropy and the file location
+	BaseRiskScore float64        `json:",omitempty"` // RiskScore before the path factor, see PathWeights
+	GitStatus     string         `json:",omitempty"` // GitStatusTracked or GitStatusUntracked, see Scanner.SetGitTracking
+	RuleID        string         // Rule that produced the finding (original id for imported findings)
+	Source        string         // External tool for imported findings, empty for native ones
+	Group         string         // Detector group or pack that produced the finding
+	Baseline      BaselineStatus // New or known relative to a baseline report, empty without one
+	Commit        string         `json:",omitempty"` // Commit that introduced the secret, history scans only
+	Author        string         `json:",omitempty"`
+	CommitDate    string         `json:",omitempty"` // ISO 8601 author date of Commit
+	SecretID      string         `json:",omitempty"` // SecretFingerprint, kept when MatchedText is masked
+	MatchSHA256   string         `json:",omitempty"` // SHA-256 of the normalized MatchedText, kept when it is masked
+	PageNumber    int            `json:",omitempty"` // Page of a PDF finding, 1-based; LineNumber counts from the first page
+	PageLine      int            `json:",omitempty"` // Line within PageNumber, 0 when unknown
+	Explanation   string         `json:",omitempty"` // Why it is risky and how to fix it, see LocalAnalyzer.ExplainFinding
+	KeyPath       string         `json:",omitempty"` // Key of the value in a JSON, YAML or .env file, e.g. services.db.credentials.password
+	ArchivePath   string         `json:",omitempty"` // Archive on disk of a finding in an archive member; FilePath is the member, see ArchiveSeparator
+	InnerPath     string         `json:",omitempty"` // Path of the member inside ArchivePath, nested archives joined by ArchiveSeparator
 }
 
 // ScanResult holds all results from a scan
 type ScanResult struct {
-	Findings        []*Finding
+	Findings        []*Finding // All findings, or the riskiest ones once spilled to disk, see SetSpill
 	FilesScanned    int
 	FilesSkipped    int
 	StartTime       int64
 	EndTime         int64
 	TotalSize       int64
 	ErrorCount      int
+	Errors          []ScanError // Why files failed, the first MaxScanErrors of ErrorCount
+	ErrorsDropped   int         // Errors counted but not kept in Errors
 	SeveritySummary map[Severity]int
-	SkipReasons     map[string]string // file path -> reason
-	mu              sync.Mutex        // Protects concurrent access
+	SkipReasons     map[string]SkipReason // file path -> reason
+	PrunedFindings  int                   // Findings removed by Prune, still counted in SeveritySummary
+	Coverage        *CoverageReport       // Optional capabilities the scan could use, nil if unknown
+	Cancelled       bool                  // The scan was stopped early; the counters are partial
+	Root            string                // Scanned directory; baseline fingerprints use paths relative to it
+	Baselined       []*Finding            // Known findings moved out by SuppressBaselined, not counted in SeveritySummary
+	SuppressedCount int                   // Matches skipped because of a dataleak:ignore comment
+
+	NestedArchivesScanned int // Archives found inside scanned archives and opened
+	CacheHits             int // Unchanged files whose findings came from the incremental cache
+
+	FilteredFindings int            // Findings below the minimum severity, see Scanner.SetMinSeverity
+	Truncated        map[string]int // Files matching stopped in, path -> findings kept, see Scanner.SetMaxFindingsPerFile
+
+	ExtensionSkips map[string]int // Files left out by SetOnlyExtensions, by extension ("" for none)
+
+	HeuristicDropped    int             // References and placeholders dropped, see Scanner.SetHeuristicFiltering
+	HeuristicDowngraded int             // Variables lowered to Low by the same heuristics
+	HeuristicNotes      []HeuristicNote // Why, the first MaxHeuristicNotes decisions
+
+	Metrics *ScanMetrics // Where the time of the scan went, nil when not collected, see Scanner.SetMetrics
+
+	extensionReasons int // Entries of ExtensionFilteredReason in SkipReasons
+
+	capabilityGaps map[Capability]int
+	ruleCounters   map[string]*ruleCounter  // Per-rule tallies behind PatternStats
+	regexTimes     map[string]time.Duration // Set when pattern profiling was on
+	spillConfig    SpillConfig
+	spill          *findingSpill // Temporary file holding every finding, nil until spilled
+	spilledCount   int           // Findings of a spill removed by Close
+	mu             sync.Mutex    // Protects concurrent access
 }
 
 // NewScanResult creates a new ScanResult
@@ -80,23 +154,79 @@ func NewScanResult() *ScanResult {
 	return &ScanResult{
 		Findings:        make([]*Finding, 0),
 		SeveritySummary: make(map[Severity]int),
-		SkipReasons:     make(map[string]string),
+		SkipReasons:     make(map[string]SkipReason),
+		capabilityGaps:  make(map[Capability]int),
 	}
 }
 
+// SkipReason is why a file was skipped: a catalog key with its arguments,
+// translated only when shown, see LocalizeSkipReason. Reasons from outside
+// the catalog, such as error texts and glob patterns, are kept in Text
+type SkipReason struct {
+	Key  string
+	Args []any
+	Text string
+}
+
+// skipReason is the reason of a catalog key
+func skipReason(key string, args ...any) SkipReason {
+	return SkipReason{Key: key, Args: args}
+}
+
+// skipText is a reason shown as it is in every language
+func skipText(text string) SkipReason {
+	return SkipReason{Text: text}
+}
+
+// IsZero reports whether no reason is set
+func (r SkipReason) IsZero() bool {
+	return r.Key == "" && r.Text == ""
+}
+
+// String is the reason in Russian, for output that takes no locale
+func (r SkipReason) String() string {
+	return LocalizeSkipReason(russian, r)
+}
+
 // AddSkipReason records why a file was skipped (thread-safe)
-func (sr *ScanResult) AddSkipReason(filePath, reason string) {
+func (sr *ScanResult) AddSkipReason(filePath string, reason SkipReason) {
 	sr.mu.Lock()
 	defer sr.mu.Unlock()
 	sr.SkipReasons[filePath] = reason
 }
 
+// AddCapabilityGap counts a file that was skipped or only partly checked
+// because a capability was off or unavailable (thread-safe)
+func (sr *ScanResult) AddCapabilityGap(capability Capability) {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	if sr.capabilityGaps == nil {
+		sr.capabilityGaps = make(map[Capability]int)
+	}
+	sr.capabilityGaps[capability]++
+}
+
+// CapabilityGaps returns the per-capability file counts (thread-safe)
+func (sr *ScanResult) CapabilityGaps() map[Capability]int {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	gaps := make(map[Capability]int, len(sr.capabilityGaps))
+	for capability, n := range sr.capabilityGaps {
+		gaps[capability] = n
+	}
+	return gaps
+}
+
 // AddFinding adds a finding to the result (thread-safe)
 func (sr *ScanResult) AddFinding(finding *Finding) {
+	if finding.SecretID == "" {
+		finding.SecretID = finding.SecretFingerprint()
+	}
 	sr.mu.Lock()
 	defer sr.mu.Unlock()
-	sr.Findings = append(sr.Findings, finding)
+	sr.addFindingLocked(finding)
 	sr.SeveritySummary[finding.Severity]++
+	sr.countRule(finding)
 }
 
 // GetSeverityCount returns the count of findings for a specific severity (thread-safe)
@@ -106,10 +236,21 @@ func (sr *ScanResult) GetSeverityCount(severity Severity) int {
 	return sr.SeveritySummary[severity]
 }
 
-// TotalFindings returns the total number of findings (thread-safe)
+// TotalFindings returns the total number of findings, spilled ones
+// included (thread-safe)
 func (sr *ScanResult) TotalFindings() int {
 	sr.mu.Lock()
 	defer sr.mu.Unlock()
+	return sr.totalFindingsLocked()
+}
+
+func (sr *ScanResult) totalFindingsLocked() int {
+	switch {
+	case sr.spill != nil:
+		return sr.spill.count
+	case sr.spilledCount > 0:
+		return sr.spilledCount
+	}
 	return len(sr.Findings)
 }
 
@@ -127,6 +268,116 @@ func (sr *ScanResult) IncrementFilesSkipped() {
 	sr.FilesSkipped++
 }
 
+// AddSuppressed counts matches skipped by suppression comments (thread-safe)
+func (sr *ScanResult) AddSuppressed(n int) {
+	if n == 0 {
+		return
+	}
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	sr.SuppressedCount += n
+}
+
+// AddHeuristicNote counts a finding the heuristics dropped or downgraded
+// and keeps why, up to MaxHeuristicNotes (thread-safe)
+func (sr *ScanResult) AddHeuristicNote(note HeuristicNote) {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	if note.Dropped {
+		sr.HeuristicDropped++
+	} else {
+		sr.HeuristicDowngraded++
+	}
+	if len(sr.HeuristicNotes) < MaxHeuristicNotes {
+		sr.HeuristicNotes = append(sr.HeuristicNotes, note)
+	}
+}
+
+// AddFiltered counts findings dropped below the minimum severity (thread-safe)
+func (sr *ScanResult) AddFiltered(n int) {
+	if n == 0 {
+		return
+	}
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	sr.FilteredFindings += n
+}
+
+// AddTruncated records that matching stopped in a file after n findings (thread-safe)
+func (sr *ScanResult) AddTruncated(filePath string, n int) {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	if sr.Truncated == nil {
+		sr.Truncated = make(map[string]int)
+	}
+	sr.Truncated[filePath] = n
+}
+
+// TruncationNote returns the note shown with a file matching stopped in,
+// empty for other files (thread-safe)
+func (sr *ScanResult) TruncationNote(filePath string) string {
+	n, ok := sr.truncatedCount(filePath)
+	if !ok {
+		return ""
+	}
+	return TruncationNote(n)
+}
+
+// truncatedCount returns the findings kept of a file matching stopped in
+func (sr *ScanResult) truncatedCount(filePath string) (int, bool) {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	n, ok := sr.Truncated[filePath]
+	return n, ok
+}
+
+// TruncationNote is the note of a file matching stopped in after n findings
+func TruncationNote(n int) string {
+	return LocalizeTruncation(russian, n)
+}
+
+// AddNestedArchives counts archives opened inside other archives (thread-safe)
+func (sr *ScanResult) AddNestedArchives(n int) {
+	if n == 0 {
+		return
+	}
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	sr.NestedArchivesScanned += n
+}
+
+// ExtensionFilteredReason is the skip reason of files left out by
+// SetOnlyExtensions. Only the first MaxExtensionSkipReasons of them are
+// listed in SkipReasons, a filter can leave out most of a tree; all of them
+// are counted in ExtensionSkips
+const (
+	ExtensionFilteredReason = "extension filtered"
+	MaxExtensionSkipReasons = 100
+)
+
+// AddExtensionSkip counts a file of extension ext skipped by the extension
+// filter (thread-safe)
+func (sr *ScanResult) AddExtensionSkip(filePath, ext string) {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	sr.FilesSkipped++
+	if sr.ExtensionSkips == nil {
+		sr.ExtensionSkips = make(map[string]int)
+	}
+	sr.ExtensionSkips[ext]++
+	if sr.extensionReasons < MaxExtensionSkipReasons {
+		sr.extensionReasons++
+		sr.SkipReasons[filePath] = skipText(ExtensionFilteredReason)
+	}
+}
+
+// AddCacheHit counts a file taken from the incremental cache (thread-safe)
+func (sr *ScanResult) AddCacheHit() {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	sr.CacheHits++
+}
+
 // IncrementErrorCount increments the error counter (thread-safe)
 func (sr *ScanResult) IncrementErrorCount() {
 	sr.mu.Lock()
@@ -145,3 +396,8 @@ func (sr *ScanResult) AddTotalSize(size int64) {
 func (sr *ScanResult) GeneratedAt() time.Time {
 	return time.Unix(sr.EndTime, 0)
 }
+
+// Duration returns how long the scan took, with second precision
+func (sr *ScanResult) Duration() time.Duration {
+	return time.Duration(sr.EndTime-sr.StartTime) * time.Second
+}
@@ -0,0 +1,502 @@
+package searcher
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Watch mode keeps a directory under observation: after a full scan, files
+// created, written or renamed into the tree are re-scanned once they have
+// been quiet for the debounce period, so a burst of writes is scanned once.
+// A re-scan replaces the findings of the file, and deleted files and
+// directories lose theirs. Every finding and removal is also appended to a
+// JSONL log with the secrets masked, which is rotated when it grows large
+
+// DefaultWatchDebounce is how long a file must stay unchanged before watch
+// mode re-scans it
+const DefaultWatchDebounce = 2 * time.Second
+
+// DefaultFindingsLogSize is the size at which the findings log is rotated;
+// the previous log is kept with the suffix ".1"
+const DefaultFindingsLogSize = 10 << 20
+
+// Events of the findings log
+const (
+	WatchEventFound   = "found"   // A finding was recorded
+	WatchEventRemoved = "removed" // The findings of a changed or deleted file were dropped
+)
+
+// WatchLogEntry is one line of the findings log
+type WatchLogEntry struct {
+	Time     time.Time `json:"time"`
+	Event    string    `json:"event"`
+	FilePath string    `json:"file_path"`
+	Removed  int       `json:"removed,omitempty"` // Findings dropped, for WatchEventRemoved
+	Finding  *Finding  `json:"finding,omitempty"` // Masked finding, for WatchEventFound
+}
+
+// SetWatchDebounce sets how long a file must stay unchanged before watch
+// mode re-scans it; zero or less means DefaultWatchDebounce
+func (s *Scanner) SetWatchDebounce(d time.Duration) {
+	s.watchDebounce = d
+}
+
+// SetFindingsLog sets the JSONL file watch mode appends findings to; empty
+// keeps no log
+func (s *Scanner) SetFindingsLog(path string) {
+	s.findingsLog = path
+}
+
+// Watch scans dir, then watches it for changes until ctx is cancelled and
+// returns the result as it stands then. onFinding, like the callback of
+// SetOnFinding, gets every finding of the initial scan and of re-scans.
+// A cancelled initial scan returns its error; cancelling later is the
+// normal end of watching and returns no error
+func (s *Scanner) Watch(ctx context.Context, dir string, onFinding func(*Finding)) (*ScanResult, error) {
+	if err := checkRoot(dir); err != nil {
+		return s.result, err
+	}
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return s.result, fmt.Errorf("не удалось начать наблюдение за %s: %w", dir, err)
+	}
+	defer fsw.Close()
+
+	log, err := openFindingsLog(s.findingsLog, DefaultFindingsLogSize)
+	if err != nil {
+		return s.result, err
+	}
+
+	previous := s.onFinding
+	s.onFinding = func(f *Finding) {
+		if previous != nil {
+			previous(f)
+		}
+		if onFinding != nil {
+			onFinding(f)
+		}
+		log.found(f)
+	}
+	defer func() { s.onFinding = previous }()
+
+	w := &watcher{
+		scanner:  s,
+		fsw:      fsw,
+		root:     dir,
+		log:      log,
+		debounce: s.watchDebounce,
+		pending:  make(map[string]time.Time),
+	}
+	if w.debounce <= 0 {
+		w.debounce = DefaultWatchDebounce
+	}
+
+	// Directories are watched before the initial scan, so files written
+	// while it runs are re-scanned after it
+	s.ignoreList.AddDefaultIgnores()
+	if log != nil {
+		// Masked lines of the log can still match, so the walk skips it
+		s.ignoreList.AddIgnoreFile(filepath.Base(log.path))
+		s.ignoreList.AddIgnoreFile(filepath.Base(log.path) + ".1")
+	}
+	w.addTree(dir, nil)
+	err = w.run(ctx)
+	// The initial scan sent its summary; this one is of the watch as a whole
+	s.notify.finish(err == nil)
+	s.notify = nil
+	if closeErr := log.close(); err == nil {
+		err = closeErr
+	}
+	return s.result, err
+}
+
+// watcher is the state of Scanner.Watch. Events only mark paths pending;
+// the initial scan owns the result until it ends, and then pending paths
+// are handled one at a time by run
+type watcher struct {
+	scanner  *Scanner
+	fsw      *fsnotify.Watcher
+	root     string
+	log      *findingsLog
+	debounce time.Duration
+	pending  map[string]time.Time // Path -> time of its last event
+	errs     []error              // Errors to record once the initial scan is over
+}
+
+// run performs the initial scan and handles events until ctx is done
+func (w *watcher) run(ctx context.Context) error {
+	scanned := make(chan error, 1)
+	go func() {
+		_, err := w.scanner.ScanContext(ctx, w.root)
+		scanned <- err
+	}()
+	scanning := true
+
+	tick := time.NewTicker(max(w.debounce/4, 10*time.Millisecond))
+	defer tick.Stop()
+	done := ctx.Done()
+	for {
+		select {
+		case err := <-scanned:
+			if err != nil {
+				return err
+			}
+			scanning = false
+			// Findings of re-scans are sent too
+			s := w.scanner
+			s.notify = startNotify(s.notifier, s.notifyMode, s.result)
+		case event, ok := <-w.fsw.Events:
+			if !ok {
+				return nil
+			}
+			// Attribute changes do not change what the file contains
+			if event.Op != fsnotify.Chmod {
+				w.pending[event.Name] = time.Now()
+			}
+		case err, ok := <-w.fsw.Errors:
+			if !ok {
+				return nil
+			}
+			w.errs = append(w.errs, err)
+		case now := <-tick.C:
+			if !scanning {
+				w.flush(now)
+			}
+		case <-done:
+			if !scanning {
+				return nil
+			}
+			// The initial scan stops and reports the cancellation
+			done = nil
+		}
+	}
+}
+
+// flush handles the paths that stayed quiet for the debounce period.
+// Deleted paths go first, so a directory renamed within the tree drops
+// its old findings before it is scanned under the new name
+func (w *watcher) flush(now time.Time) {
+	for _, err := range w.errs {
+		var pathErr *fs.PathError
+		switch {
+		case errors.Is(err, fsnotify.ErrEventOverflow):
+			// Events were lost, so everything is checked again
+			w.pending[w.root] = time.Time{}
+		case errors.As(err, &pathErr):
+			w.scanner.scanError(pathErr.Path, StageRead, pathErr.Err)
+		default:
+			w.scanner.scanError(w.root, StageRead, err)
+		}
+	}
+	w.errs = nil
+
+	var due []string
+	for path, last := range w.pending {
+		if now.Sub(last) >= w.debounce {
+			due = append(due, path)
+			delete(w.pending, path)
+		}
+	}
+	if len(due) == 0 {
+		return
+	}
+	sort.Strings(due)
+
+	var present []string
+	for _, path := range due {
+		if _, err := os.Lstat(path); err != nil {
+			w.fsw.Remove(path)
+			w.remove(path)
+			continue
+		}
+		present = append(present, path)
+	}
+	for _, path := range present {
+		info, err := os.Lstat(path)
+		switch {
+		case err != nil:
+			w.remove(path)
+		case info.IsDir():
+			if !w.skipped(path, true) {
+				w.addTree(path, w.rescan)
+			}
+		default:
+			w.rescan(path)
+		}
+	}
+	w.scanner.result.EndTime = now.Unix()
+}
+
+// addTree watches dir and its subdirectories, passing their files to visit
+// when it is not nil
+func (w *watcher) addTree(dir string, visit func(path string)) {
+	ignore := w.scanner.ignoreList
+	filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			// The scan reports unreadable directories
+			return nil
+		}
+		if !entry.IsDir() {
+			if visit != nil {
+				visit(path)
+			}
+			return nil
+		}
+		if path != w.root && (ignore.ShouldIgnorePath(path) || ignore.ShouldIgnoreDirectory(path)) {
+			return filepath.SkipDir
+		}
+		if err := w.fsw.Add(path); err != nil {
+			w.errs = append(w.errs, &fs.PathError{Op: "watch", Path: path, Err: fmt.Errorf("не удалось наблюдать за директорией: %w", err)})
+		}
+		return nil
+	})
+}
+
+// rescan replaces the findings of a changed file
+func (w *watcher) rescan(path string) {
+	s := w.scanner
+	// The log may be kept inside the watched directory
+	if w.log.owns(path) || w.skipped(path, false) {
+		return
+	}
+	if info, err := os.Lstat(path); err == nil && info.Mode()&fs.ModeSymlink != 0 && !s.followSymlinks {
+		return
+	}
+	w.remove(path)
+	found := s.processFile(path)
+	s.fileScanned(path, found)
+}
+
+// remove drops the findings of a path and of everything below it
+func (w *watcher) remove(path string) {
+	if n := w.scanner.result.RemoveFileFindings(path); n > 0 {
+		w.log.removed(path, n)
+	}
+}
+
+// skipped reports whether the walk of a scan would leave out a path: by the
+// ignore list, which covers editor backups and swap files, the globs or
+// the .gitignore files of its directories
+func (w *watcher) skipped(path string, isDir bool) bool {
+	s := w.scanner
+	if s.ignoreList.ShouldIgnorePath(path) {
+		return true
+	}
+	rel, err := filepath.Rel(w.root, path)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return false
+	}
+
+	parts := strings.Split(rel, string(filepath.Separator))
+	var ignore *gitignore
+	dir := w.root
+	included := len(s.includeGlobs) == 0
+	for i, part := range parts {
+		if s.respectGitignore {
+			ignore = ignore.withFile(dir)
+		}
+		dir = filepath.Join(dir, part)
+		last := i == len(parts)-1
+		if ignore.ignored(dir, !last || isDir) {
+			return true
+		}
+		if !last || isDir {
+			if s.ignoreList.ShouldIgnoreDirectory(dir) {
+				return true
+			}
+			walk, inc, _ := s.globDir(dir, included)
+			if !walk {
+				return true
+			}
+			included = inc
+			continue
+		}
+		if scan, _ := s.globFile(dir, included); !scan {
+			return true
+		}
+	}
+	return false
+}
+
+// RemoveFileFindings removes the findings of a file, of the members of an
+// archive or of every file below a directory, and returns how many were
+// removed (thread-safe)
+func (sr *ScanResult) RemoveFileFindings(path string) int {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+
+	removed := 0
+	drop := func(f *Finding) bool {
+		if !underPath(f.FilePath, path) {
+			return false
+		}
+		if sr.SeveritySummary[f.Severity] > 0 {
+			sr.SeveritySummary[f.Severity]--
+		}
+		sr.uncountRule(f)
+		removed++
+		return true
+	}
+	for file := range sr.Truncated {
+		if underPath(file, path) {
+			delete(sr.Truncated, file)
+		}
+	}
+	if sr.spill != nil {
+		sr.rewriteSpill(func(f *Finding) bool { return !drop(f) })
+		return removed
+	}
+
+	kept := sr.Findings[:0]
+	for _, f := range sr.Findings {
+		if f != nil && drop(f) {
+			continue
+		}
+		kept = append(kept, f)
+	}
+	for i := len(kept); i < len(sr.Findings); i++ {
+		sr.Findings[i] = nil
+	}
+	sr.Findings = kept
+	return removed
+}
+
+// underPath reports whether a finding path is path itself, a member of the
+// archive at path or a file below the directory at path
+func underPath(filePath, path string) bool {
+	return filePath == path ||
+		strings.HasPrefix(filePath, path+ArchiveSeparator) ||
+		strings.HasPrefix(filePath, path+string(filepath.Separator))
+}
+
+// findingsLog appends WatchLogEntry lines to a file; a nil log writes
+// nothing. Secrets are always masked, as the log is meant to be left
+// running on shared folders
+type findingsLog struct {
+	mu      sync.Mutex
+	path    string
+	maxSize int64
+	file    *os.File
+	size    int64
+	err     error // First write error; later entries are dropped
+}
+
+// openFindingsLog opens the log at path for appending; empty path means no log
+func openFindingsLog(path string, maxSize int64) (*findingsLog, error) {
+	if path == "" {
+		return nil, nil
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось открыть журнал находок: %w", err)
+	}
+	l := &findingsLog{path: abs, maxSize: maxSize}
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (l *findingsLog) open() error {
+	file, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("не удалось открыть журнал находок: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("не удалось открыть журнал находок: %w", err)
+	}
+	l.file, l.size = file, info.Size()
+	return nil
+}
+
+// owns reports whether path is the log or the previous, rotated one
+func (l *findingsLog) owns(path string) bool {
+	if l == nil {
+		return false
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	return abs == l.path || abs == l.path+".1"
+}
+
+// found logs a finding with its secret masked
+func (l *findingsLog) found(f *Finding) {
+	if l == nil {
+		return
+	}
+	masked := *f
+	maskMatch(&masked)
+	l.write(WatchLogEntry{Time: time.Now(), Event: WatchEventFound, FilePath: f.FilePath, Finding: &masked})
+}
+
+// removed logs that the findings of a path were dropped
+func (l *findingsLog) removed(path string, n int) {
+	if l == nil {
+		return
+	}
+	l.write(WatchLogEntry{Time: time.Now(), Event: WatchEventRemoved, FilePath: path, Removed: n})
+}
+
+func (l *findingsLog) write(entry WatchLogEntry) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	data = append(data, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.err != nil {
+		return
+	}
+	if l.size > 0 && l.size+int64(len(data)) > l.maxSize {
+		l.err = l.rotate()
+		if l.err != nil {
+			return
+		}
+	}
+	n, err := l.file.Write(data)
+	l.size += int64(n)
+	if err != nil {
+		l.err = fmt.Errorf("не удалось записать журнал находок: %w", err)
+	}
+}
+
+// rotate moves the full log aside and starts a new one; l.mu is held
+func (l *findingsLog) rotate() error {
+	if err := l.file.Close(); err != nil {
+		return fmt.Errorf("не удалось записать журнал находок: %w", err)
+	}
+	if err := os.Rename(l.path, l.path+".1"); err != nil {
+		return fmt.Errorf("не удалось сменить журнал находок: %w", err)
+	}
+	return l.open()
+}
+
+// close closes the log and returns the first error writing it
+func (l *findingsLog) close() error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.file.Close(); err != nil && l.err == nil {
+		l.err = err
+	}
+	return l.err
+}
@@ -0,0 +1,427 @@
+package searcher
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Excel workbook output for reviewers who sort and filter findings in a
+// spreadsheet. The workbook is written as OOXML parts straight into a zip:
+// a Summary sheet, then a sheet of findings per severity with a frozen
+// header row, an autofilter and the severity cells filled in the colors of
+// the GUI
+
+// xlsxSeverities are the severities with a sheet, in sheet order
+var xlsxSeverities = []Severity{Critical, High, Medium, Low}
+
+// Cell styles, indexes into cellXfs of xlsxStyles
+const (
+	xlsxStyleDefault = iota
+	xlsxStyleHeader
+	xlsxStyleTitle
+	xlsxStyleScore
+	xlsxStyleCritical
+	xlsxStyleHigh
+	xlsxStyleMedium
+	xlsxStyleLow
+)
+
+// xlsxSeverityStyles fill severity cells with the colors of the GUI
+var xlsxSeverityStyles = map[Severity]int{
+	Critical: xlsxStyleCritical,
+	High:     xlsxStyleHigh,
+	Medium:   xlsxStyleMedium,
+	Low:      xlsxStyleLow,
+}
+
+// xlsxStyles has a bold header on grey, a title, two-decimal scores and
+// the severity fills: critical DC3545, high FD7E14, medium FFC107 and low
+// 28A745, white text except on the yellow of medium
+const xlsxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<fonts count="4">
+<font><sz val="11"/><name val="Calibri"/></font>
+<font><b/><sz val="11"/><name val="Calibri"/></font>
+<font><b/><sz val="14"/><name val="Calibri"/></font>
+<font><b/><sz val="11"/><color rgb="FFFFFFFF"/><name val="Calibri"/></font>
+</fonts>
+<fills count="7">
+<fill><patternFill patternType="none"/></fill>
+<fill><patternFill patternType="gray125"/></fill>
+<fill><patternFill patternType="solid"><fgColor rgb="FFE9ECEF"/><bgColor indexed="64"/></patternFill></fill>
+<fill><patternFill patternType="solid"><fgColor rgb="FFDC3545"/><bgColor indexed="64"/></patternFill></fill>
+<fill><patternFill patternType="solid"><fgColor rgb="FFFD7E14"/><bgColor indexed="64"/></patternFill></fill>
+<fill><patternFill patternType="solid"><fgColor rgb="FFFFC107"/><bgColor indexed="64"/></patternFill></fill>
+<fill><patternFill patternType="solid"><fgColor rgb="FF28A745"/><bgColor indexed="64"/></patternFill></fill>
+</fills>
+<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>
+<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>
+<cellXfs count="8">
+<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>
+<xf numFmtId="0" fontId="1" fillId="2" borderId="0" xfId="0" applyFont="1" applyFill="1"/>
+<xf numFmtId="0" fontId="2" fillId="0" borderId="0" xfId="0" applyFont="1"/>
+<xf numFmtId="2" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>
+<xf numFmtId="0" fontId="3" fillId="3" borderId="0" xfId="0" applyFont="1" applyFill="1"/>
+<xf numFmtId="0" fontId="3" fillId="4" borderId="0" xfId="0" applyFont="1" applyFill="1"/>
+<xf numFmtId="0" fontId="1" fillId="5" borderId="0" xfId="0" applyFont="1" applyFill="1"/>
+<xf numFmtId="0" fontId="3" fillId="6" borderId="0" xfId="0" applyFont="1" applyFill="1"/>
+</cellXfs>
+<cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles>
+</styleSheet>
+`
+
+// xlsxMaxCell is the longest text an Excel cell holds
+const xlsxMaxCell = 32767
+
+// xlsxCell is one cell of a row
+type xlsxCell struct {
+	text   string
+	number float64
+	isNum  bool
+	style  int
+}
+
+// xlsxText is a text cell
+func xlsxText(text string, style int) xlsxCell {
+	return xlsxCell{text: text, style: style}
+}
+
+// xlsxNumber is a number cell
+func xlsxNumber(n float64, style int) xlsxCell {
+	return xlsxCell{number: n, isNum: true, style: style}
+}
+
+// xlsxSafeText keeps a cell from being read as a formula when the workbook
+// is edited or saved as CSV, see neutralizeFormula. It also cuts text to
+// the length a cell holds
+func xlsxSafeText(text string) string {
+	text = neutralizeFormula(text)
+	if len(text) > xlsxMaxCell {
+		text = strings.ToValidUTF8(text[:xlsxMaxCell-3], "") + "..."
+	}
+	return text
+}
+
+// xlsxColumn returns the letters of a 0-based column: 0 is A, 26 is AA
+func xlsxColumn(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
+
+// xlsxSheetName makes a valid and unique sheet name: at most 31
+// characters, none of []:*?/\
+func xlsxSheetName(name string, taken map[string]bool) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '_'
+		}
+		return r
+	}, name)
+	if runes := []rune(name); len(runes) > 31 {
+		name = string(runes[:31])
+	}
+	base := name
+	for i := 2; taken[strings.ToLower(name)]; i++ {
+		suffix := " " + strconv.Itoa(i)
+		runes := []rune(base)
+		if len(runes)+len(suffix) > 31 {
+			runes = runes[:31-len(suffix)]
+		}
+		name = string(runes) + suffix
+	}
+	taken[strings.ToLower(name)] = true
+	return name
+}
+
+// xlsxSheet writes the XML of one worksheet
+type xlsxSheet struct {
+	name string
+	w    *bufio.Writer
+	rows int
+	cols int // Widest row, for the autofilter
+}
+
+// start writes the sheet header; frozen keeps the first row in view
+func (s *xlsxSheet) start(widths []float64, frozen bool) {
+	s.w.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	s.w.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	s.w.WriteString(`<sheetViews><sheetView workbookViewId="0">`)
+	if frozen {
+		s.w.WriteString(`<pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/>`)
+	}
+	s.w.WriteString(`</sheetView></sheetViews><cols>`)
+	for i, width := range widths {
+		fmt.Fprintf(s.w, `<col min="%d" max="%d" width="%g" customWidth="1"/>`, i+1, i+1, width)
+	}
+	s.w.WriteString(`</cols><sheetData>`)
+}
+
+// row writes the next row
+func (s *xlsxSheet) row(cells ...xlsxCell) {
+	s.rows++
+	if len(cells) > s.cols {
+		s.cols = len(cells)
+	}
+	fmt.Fprintf(s.w, `<row r="%d">`, s.rows)
+	for i, cell := range cells {
+		ref := xlsxColumn(i) + strconv.Itoa(s.rows)
+		style := ""
+		if cell.style != xlsxStyleDefault {
+			style = ` s="` + strconv.Itoa(cell.style) + `"`
+		}
+		if cell.isNum {
+			fmt.Fprintf(s.w, `<c r="%s"%s><v>%s</v></c>`, ref, style, strconv.FormatFloat(cell.number, 'f', -1, 64))
+			continue
+		}
+		fmt.Fprintf(s.w, `<c r="%s"%s t="inlineStr"><is><t xml:space="preserve">`, ref, style)
+		xml.EscapeText(s.w, []byte(xlsxSafeText(cell.text)))
+		s.w.WriteString(`</t></is></c>`)
+	}
+	s.w.WriteString(`</row>`)
+}
+
+// lastRow is the last row of the table, the header of an empty one
+func (s *xlsxSheet) lastRow() int {
+	if s.rows == 0 {
+		return 1
+	}
+	return s.rows
+}
+
+// end closes the sheet; filter puts an autofilter on the table
+func (s *xlsxSheet) end(filter bool) error {
+	s.w.WriteString(`</sheetData>`)
+	if filter {
+		fmt.Fprintf(s.w, `<autoFilter ref="A1:%s%d"/>`, xlsxColumn(s.cols-1), s.lastRow())
+	}
+	s.w.WriteString(`</worksheet>`)
+	return s.w.Flush()
+}
+
+// ExportXLSX exports findings to an Excel workbook: a summary sheet and a
+// sheet of findings per severity
+func (rg *ReportGenerator) ExportXLSX(filePath string) error {
+	return writeFileAtomic(filePath, reportFileMode, rg.writeXLSX)
+}
+
+// writeXLSX writes the workbook to w
+func (rg *ReportGenerator) writeXLSX(w io.Writer) error {
+	archive := zip.NewWriter(w)
+	l := rg.locale
+	taken := make(map[string]bool)
+
+	var sheets []*xlsxSheet
+	newSheet := func(name string) (*xlsxSheet, error) {
+		part, err := archive.Create(fmt.Sprintf("xl/worksheets/sheet%d.xml", len(sheets)+1))
+		if err != nil {
+			return nil, err
+		}
+		sheet := &xlsxSheet{name: xlsxSheetName(name, taken), w: bufio.NewWriter(part)}
+		sheets = append(sheets, sheet)
+		return sheet, nil
+	}
+
+	summary, err := newSheet(l.T("xlsx.summary"))
+	if err != nil {
+		return err
+	}
+	if err := rg.writeXLSXSummary(summary); err != nil {
+		return err
+	}
+
+	for _, severity := range xlsxSeverities {
+		sheet, err := newSheet(l.Severity(string(severity)))
+		if err != nil {
+			return err
+		}
+		if err := rg.writeXLSXFindings(sheet, severity); err != nil {
+			return err
+		}
+	}
+
+	if err := writeXLSXPackage(archive, sheets); err != nil {
+		return err
+	}
+	return archive.Close()
+}
+
+// writeXLSXSummary writes the scan metadata, the counts by severity and
+// pattern type and the riskiest files
+func (rg *ReportGenerator) writeXLSXSummary(sheet *xlsxSheet) error {
+	l := rg.locale
+	summary := rg.generateSummary()
+	text := func(s string) xlsxCell { return xlsxText(s, xlsxStyleDefault) }
+	number := func(n int) xlsxCell { return xlsxNumber(float64(n), xlsxStyleDefault) }
+	heading := func(cells ...string) {
+		row := make([]xlsxCell, len(cells))
+		for i, c := range cells {
+			row[i] = xlsxText(c, xlsxStyleHeader)
+		}
+		sheet.row(row...)
+	}
+
+	sheet.start([]float64{60, 18, 22, 16}, false)
+	sheet.row(xlsxText(l.T("html.title"), xlsxStyleTitle))
+	sheet.row()
+	if rg.result.Root != "" {
+		sheet.row(text(l.T("html.directory")), text(rg.result.Root))
+	}
+	sheet.row(text(l.T("html.created")), text(time.Now().Format("02.01.2006 15:04:05")))
+	if rg.result.StartTime > 0 {
+		sheet.row(text(l.T("xlsx.started")), text(time.Unix(rg.result.StartTime, 0).Format("02.01.2006 15:04:05")))
+	}
+	sheet.row(text(l.T("xlsx.duration")), number(int(rg.result.EndTime-rg.result.StartTime)))
+	sheet.row(text(l.T("html.files_scanned")), number(rg.result.FilesScanned))
+	sheet.row(text(l.T("html.skipped")), number(rg.result.FilesSkipped))
+	sheet.row(text(l.T("html.errors")), number(rg.result.ErrorCount))
+	sheet.row(text(l.T("html.total")), number(summary.TotalFindings))
+	sheet.row(text(l.T("xlsx.distinct")), number(summary.DistinctSecrets))
+	sheet.row(text(l.T("html.average_risk")), xlsxNumber(summary.AverageRiskScore, xlsxStyleScore))
+
+	sheet.row()
+	heading(l.T("csv.severity"), l.T("xlsx.count"))
+	for _, severity := range xlsxSeverities {
+		sheet.row(xlsxText(l.Severity(string(severity)), xlsxSeverityStyles[severity]), number(rg.result.SeveritySummary[severity]))
+	}
+
+	if len(summary.PatternCounts) > 0 {
+		patterns := make([]string, 0, len(summary.PatternCounts))
+		for pattern := range summary.PatternCounts {
+			patterns = append(patterns, pattern)
+		}
+		sort.Slice(patterns, func(i, j int) bool {
+			a, b := patterns[i], patterns[j]
+			if summary.PatternCounts[a] != summary.PatternCounts[b] {
+				return summary.PatternCounts[a] > summary.PatternCounts[b]
+			}
+			return a < b
+		})
+		sheet.row()
+		heading(l.T("csv.type"), l.T("xlsx.count"))
+		for _, pattern := range patterns {
+			sheet.row(text(l.Pattern(pattern)), number(summary.PatternCounts[pattern]))
+		}
+	}
+
+	if files := TopRiskyFiles(fileRiskSummaries(rg.result.allFindings()), topFilesLimit); len(files) > 0 {
+		sheet.row()
+		heading(l.T("xlsx.top_files"), l.T("xlsx.count"), l.T("xlsx.max_severity"), l.T("xlsx.max_risk"))
+		for _, file := range files {
+			severity := Severity(file.MaxSeverity)
+			sheet.row(
+				text(file.FilePath),
+				number(file.FindingCount),
+				xlsxText(l.Severity(file.MaxSeverity), xlsxSeverityStyles[severity]),
+				xlsxNumber(file.MaxRiskScore, xlsxStyleScore),
+			)
+		}
+	}
+	return sheet.end(false)
+}
+
+// writeXLSXFindings writes the findings of one severity as a table
+func (rg *ReportGenerator) writeXLSXFindings(sheet *xlsxSheet, severity Severity) error {
+	l := rg.locale
+	sheet.start([]float64{60, 8, 22, 14, 32, 12, 50}, true)
+	header := []string{
+		l.T("csv.path"), l.T("csv.line"), l.T("csv.type"), l.T("csv.severity"),
+		l.T("csv.match"), l.T("csv.risk"), l.T("csv.description"),
+	}
+	cells := make([]xlsxCell, len(header))
+	for i, h := range header {
+		cells[i] = xlsxText(h, xlsxStyleHeader)
+	}
+	sheet.row(cells...)
+
+	err := rg.forEachFinding(func(f *Finding) bool {
+		if f.Severity != severity {
+			return true
+		}
+		sheet.row(
+			xlsxText(f.FilePath, xlsxStyleDefault),
+			xlsxNumber(float64(f.LineNumber), xlsxStyleDefault),
+			xlsxText(l.Pattern(string(f.PatternType)), xlsxStyleDefault),
+			xlsxText(l.Severity(string(f.Severity)), xlsxSeverityStyles[severity]),
+			xlsxText(truncateText(f.MatchedText), xlsxStyleDefault),
+			xlsxNumber(f.RiskScore, xlsxStyleScore),
+			xlsxText(LocalizeDescription(l, f.Description), xlsxStyleDefault),
+		)
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	return sheet.end(true)
+}
+
+// writeXLSXPackage writes the parts tying the sheets into a workbook
+func writeXLSXPackage(archive *zip.Writer, sheets []*xlsxSheet) error {
+	var types, workbook, rels strings.Builder
+	types.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>
+`)
+	workbook.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+	rels.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+
+	var filters strings.Builder
+	for i, sheet := range sheets {
+		n := i + 1
+		fmt.Fprintf(&types, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`+"\n", n)
+		fmt.Fprintf(&workbook, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, xmlAttr(sheet.name), n, n)
+		fmt.Fprintf(&rels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, n, n)
+		if i > 0 {
+			// Excel keeps the range of an autofilter in a hidden name
+			fmt.Fprintf(&filters, `<definedName name="_xlnm._FilterDatabase" localSheetId="%d" hidden="1">%s!$A$1:$%s$%d</definedName>`,
+				i, xmlAttr("'"+strings.ReplaceAll(sheet.name, "'", "''")+"'"), xlsxColumn(sheet.cols-1), sheet.lastRow())
+		}
+	}
+	types.WriteString(`</Types>`)
+	workbook.WriteString(`</sheets>`)
+	if filters.Len() > 0 {
+		workbook.WriteString(`<definedNames>` + filters.String() + `</definedNames>`)
+	}
+	workbook.WriteString(`</workbook>`)
+	fmt.Fprintf(&rels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/></Relationships>`, len(sheets)+1)
+
+	parts := []struct{ name, content string }{
+		{"[Content_Types].xml", types.String()},
+		{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`},
+		{"xl/workbook.xml", workbook.String()},
+		{"xl/_rels/workbook.xml.rels", rels.String()},
+		{"xl/styles.xml", xlsxStyles},
+	}
+	for _, part := range parts {
+		w, err := archive.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, part.content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// xmlAttr escapes text for an XML attribute or element
+func xmlAttr(text string) string {
+	var sb strings.Builder
+	xml.EscapeText(&sb, []byte(text))
+	return sb.String()
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/kacebover/password-finder/server"
+)
+
+// ═══════════════════════════════════════════════════════════════════════════
+// КОМАНДА HTTP-СЕРВЕРА
+// ═══════════════════════════════════════════════════════════════════════════
+
+// defaultServeAddr keeps the API on the local machine unless asked otherwise
+const defaultServeAddr = "127.0.0.1:8765"
+
+// serveTokenEnv holds the token when it should not show in the process list
+const serveTokenEnv = "DLL_API_TOKEN"
+
+func runServeCommand(args []string) {
+	serveCmd := flag.NewFlagSet("serve", flag.ExitOnError)
+
+	addr := serveCmd.String("addr", defaultServeAddr, ui.T("cli.serve.flag.addr"))
+	token := serveCmd.String("token", os.Getenv(serveTokenEnv), ui.T("cli.serve.flag.token", serveTokenEnv))
+	maxScans := serveCmd.Int("max-scans", server.DefaultMaxScans, ui.T("cli.serve.flag.max_scans"))
+	ttl := serveCmd.Duration("ttl", server.DefaultTTL, ui.T("cli.serve.flag.ttl"))
+
+	serveCmd.Usage = func() {
+		fmt.Print(ui.T("cli.serve.usage", defaultServeAddr, serveTokenEnv, server.DefaultMaxScans, server.DefaultTTL))
+	}
+
+	if err := serveCmd.Parse(args); err != nil {
+		os.Exit(1)
+	}
+	if *maxScans < 1 {
+		fmt.Println(ui.T("cli.error", ui.T("cli.serve.max_scans")))
+		os.Exit(2)
+	}
+	if *ttl <= 0 {
+		fmt.Println(ui.T("cli.error", ui.T("cli.serve.ttl")))
+		os.Exit(2)
+	}
+
+	listener, err := net.Listen("tcp", *addr)
+	if err != nil {
+		fmt.Println(ui.T("cli.error", err))
+		os.Exit(1)
+	}
+	if *token == "" && !isLoopback(listener.Addr()) {
+		fmt.Println(ui.T("cli.serve.no_token", serveTokenEnv))
+	}
+
+	api := server.New(server.Config{MaxScans: *maxScans, TTL: *ttl, Token: *token})
+	httpServer := &http.Server{Handler: api, ReadHeaderTimeout: 10 * time.Second}
+
+	// Ctrl+C stops the server and cancels the running scans
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		httpServer.Shutdown(shutdown)
+	}()
+
+	fmt.Println(ui.T("cli.serve.started", listener.Addr()))
+	if err := httpServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fmt.Println(ui.T("cli.serve.error", err))
+		api.Close()
+		os.Exit(1)
+	}
+	api.Close()
+	fmt.Println(ui.T("cli.serve.stopped"))
+}
+
+// isLoopback reports whether a listener only accepts local connections
+func isLoopback(addr net.Addr) bool {
+	tcp, ok := addr.(*net.TCPAddr)
+	return ok && tcp.IP.IsLoopback()
+}
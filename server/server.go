@@ -0,0 +1,638 @@
+// Package server exposes the scanner over a local HTTP API, so other tools
+// can drive scans without running the CLI and parsing its output:
+//
+//	POST   /scans                start a scan, returns its id
+//	GET    /scans/{id}           state and progress of a scan
+//	GET    /scans/{id}/findings  masked findings, ?offset=&limit=&min_severity=
+//	DELETE /scans/{id}           cancel a scan, or forget a finished one
+//	GET    /healthz              liveness, answered without the token
+//
+// Scans run in the background, at most Config.MaxScans at once; the rest
+// wait in a queue. Finished scans are kept in memory for Config.TTL.
+// Requests and responses are JSON; errors are ErrorResponse, whose code
+// is one of the Code constants
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kacebover/password-finder/searcher"
+)
+
+// Defaults of Config
+const (
+	DefaultMaxScans = 2
+	DefaultTTL      = time.Hour
+)
+
+// Limits of the findings pages
+const (
+	DefaultPageSize = 100
+	MaxPageSize     = 1000
+)
+
+// maxRequestBody caps the body of POST /scans
+const maxRequestBody = 1 << 20
+
+// Config configures a Server
+type Config struct {
+	MaxScans int           // Scans running at once, 0 for DefaultMaxScans
+	TTL      time.Duration // How long finished scans are kept, 0 for DefaultTTL
+	Token    string        // Bearer token every request but /healthz must carry; empty allows any
+}
+
+// Codes of ErrorResponse. They stay the same between versions, unlike the
+// messages, so clients should check them instead
+const (
+	CodeUnauthorized     = "unauthorized"       // Missing or wrong Bearer token
+	CodeBadRequest       = "bad_request"        // Body is not a ScanRequest
+	CodeBadDirectory     = "bad_directory"      // Dir is empty, missing or not a directory
+	CodeBadOption        = "bad_option"         // An option of the request is invalid
+	CodeBadParameter     = "bad_parameter"      // A query parameter is invalid
+	CodeNotFound         = "not_found"          // No such scan or path
+	CodeMethodNotAllowed = "method_not_allowed" // See the Allow header
+	CodeNotFinished      = "not_finished"       // Findings asked for before the scan finished
+	CodeScanFailed       = "scan_failed"        // The scan stopped with an error
+	CodeShuttingDown     = "shutting_down"      // The server is closing
+	CodeInternal         = "internal"           // Any other error
+)
+
+// ErrorResponse is the body of every response with an error status
+type ErrorResponse struct {
+	Code  string `json:"code"`
+	Error string `json:"error"`
+}
+
+// State is the state of a scan
+type State string
+
+const (
+	StateQueued    State = "queued"    // Waiting for a free slot
+	StateRunning   State = "running"   // Scanning
+	StateDone      State = "done"      // Finished, the findings are ready
+	StateCancelled State = "cancelled" // Stopped by DELETE; the findings are partial
+	StateFailed    State = "failed"    // Stopped by an error
+)
+
+// finished reports whether the scan is over
+func (s State) finished() bool {
+	return s == StateDone || s == StateCancelled || s == StateFailed
+}
+
+// ScanRequest is the body of POST /scans
+type ScanRequest struct {
+	Dir     string      `json:"dir"` // Directory to scan, on the machine of the server
+	Options ScanOptions `json:"options"`
+}
+
+// ScanOptions are the scanner options a request may set; the rest keep the
+// defaults of searcher.NewScanner
+type ScanOptions struct {
+	Documents   bool     `json:"documents"`     // Extract the text of PDF and office documents
+	Archives    bool     `json:"archives"`      // Look inside ZIP and TAR archives
+	OCR         bool     `json:"ocr"`           // Recognize text in images, needs Tesseract
+	MinSeverity string   `json:"min_severity"`  // Drop findings below it, see Scanner.SetMinSeverity
+	Extensions  []string `json:"extensions"`    // Scan only these extensions, see Scanner.SetOnlyExtensions
+	Include     []string `json:"include"`       // Scan only paths matching these globs
+	Exclude     []string `json:"exclude"`       // Skip paths matching these globs
+	MaxFileSize int64    `json:"max_file_size"` // Skip larger files, 0 keeps the default
+}
+
+// Status is the response of GET /scans/{id}
+type Status struct {
+	ID       string     `json:"id"`
+	Dir      string     `json:"dir"`
+	State    State      `json:"state"`
+	Error    string     `json:"error,omitempty"`
+	Created  time.Time  `json:"created"`
+	Started  *time.Time `json:"started,omitempty"`
+	Finished *time.Time `json:"finished,omitempty"`
+	Progress Progress   `json:"progress"`
+}
+
+// Progress are the counters of a scan, see searcher.ScanProgress. While
+// the scan runs FilesTotal is an estimate until TotalComplete
+type Progress struct {
+	FilesDone      int64   `json:"files_done"`
+	FilesTotal     int64   `json:"files_total"`
+	TotalComplete  bool    `json:"total_complete"`
+	FilesScanned   int64   `json:"files_scanned"`
+	FilesSkipped   int64   `json:"files_skipped"`
+	Findings       int64   `json:"findings"`
+	Critical       int64   `json:"critical"`
+	Errors         int64   `json:"errors"`
+	BytesScanned   int64   `json:"bytes_scanned"`
+	ElapsedSeconds float64 `json:"elapsed_seconds"`
+}
+
+// FindingsPage is the response of GET /scans/{id}/findings. Findings come
+// in the order of the JSON report, with the secrets masked
+type FindingsPage struct {
+	Total    int                 `json:"total"` // Findings passing the filter
+	Offset   int                 `json:"offset"`
+	Limit    int                 `json:"limit"`
+	Findings []*searcher.Finding `json:"findings"`
+}
+
+// Server is the http.Handler of the API
+type Server struct {
+	cfg   Config
+	slots chan struct{}
+	mux   *http.ServeMux
+	now   func() time.Time
+
+	mu     sync.Mutex
+	scans  map[string]*scan
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// scan is one scan of the server
+type scan struct {
+	id      string
+	dir     string
+	scanner *searcher.Scanner
+	cancel  context.CancelFunc
+	created time.Time
+
+	mu       sync.Mutex
+	state    State
+	err      error
+	result   *searcher.ScanResult
+	started  time.Time
+	finished time.Time
+}
+
+// New returns a Server of cfg
+func New(cfg Config) *Server {
+	if cfg.MaxScans <= 0 {
+		cfg.MaxScans = DefaultMaxScans
+	}
+	if cfg.TTL <= 0 {
+		cfg.TTL = DefaultTTL
+	}
+	s := &Server{
+		cfg:   cfg,
+		slots: make(chan struct{}, cfg.MaxScans),
+		mux:   http.NewServeMux(),
+		now:   time.Now,
+		scans: make(map[string]*scan),
+	}
+	s.mux.HandleFunc("/healthz", s.handleHealth)
+	s.mux.HandleFunc("/scans", s.handleScans)
+	s.mux.HandleFunc("/scans/", s.handleScan)
+	s.mux.HandleFunc("/", s.handleUnknown)
+	return s
+}
+
+// ServeHTTP checks the token, evicts expired scans and routes the request
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/healthz" && !s.authorized(r) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="data-leak-locator"`)
+		writeError(w, http.StatusUnauthorized, CodeUnauthorized, "the Authorization: Bearer <token> header is required")
+		return
+	}
+	s.evict()
+	s.mux.ServeHTTP(w, r)
+}
+
+// Close cancels every scan, waits for them to stop and frees the results.
+// Later requests to start a scan are refused
+func (s *Server) Close() {
+	s.mu.Lock()
+	s.closed = true
+	for _, sc := range s.scans {
+		sc.cancel()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, sc := range s.scans {
+		sc.close()
+		delete(s.scans, id)
+	}
+}
+
+// authorized reports whether r carries the token, if one is set
+func (s *Server) authorized(r *http.Request) bool {
+	if s.cfg.Token == "" {
+		return true
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(strings.TrimSpace(token)), []byte(s.cfg.Token)) == 1
+}
+
+// evict forgets the scans finished longer than TTL ago
+func (s *Server) evict() {
+	now := s.now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, sc := range s.scans {
+		sc.mu.Lock()
+		expired := sc.state.finished() && now.Sub(sc.finished) > s.cfg.TTL
+		sc.mu.Unlock()
+		if expired {
+			sc.close()
+			delete(s.scans, id)
+		}
+	}
+}
+
+// lookup returns the scan of id or nil
+func (s *Server) lookup(id string) *scan {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.scans[id]
+}
+
+// handleUnknown answers the paths of no other handler with an ErrorResponse
+// rather than the plain text of http.NotFound
+func (s *Server) handleUnknown(w http.ResponseWriter, r *http.Request) {
+	writeError(w, http.StatusNotFound, CodeNotFound, fmt.Sprintf("unknown path: %s", r.URL.Path))
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		methodNotAllowed(w, http.MethodGet, http.MethodHead)
+		return
+	}
+	counts := map[State]int{}
+	s.mu.Lock()
+	for _, sc := range s.scans {
+		sc.mu.Lock()
+		counts[sc.state]++
+		sc.mu.Unlock()
+	}
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, map[string]any{
+		"status":  "ok",
+		"running": counts[StateRunning],
+		"queued":  counts[StateQueued],
+	})
+}
+
+// handleScans starts a scan
+func (s *Server) handleScans(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w, http.MethodPost)
+		return
+	}
+	var req ScanRequest
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBody))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, CodeBadRequest, fmt.Sprintf("invalid request: %v", err))
+		return
+	}
+	if strings.TrimSpace(req.Dir) == "" {
+		writeError(w, http.StatusBadRequest, CodeBadDirectory, "dir is required")
+		return
+	}
+	dir, err := filepath.Abs(req.Dir)
+	if err == nil {
+		var info os.FileInfo
+		if info, err = os.Stat(dir); err == nil && !info.IsDir() {
+			err = fmt.Errorf("%s is not a directory", dir)
+		}
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, CodeBadDirectory, fmt.Sprintf("directory not accessible: %v", err))
+		return
+	}
+	scanner, err := newScanner(req.Options)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, CodeBadOption, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sc := &scan{
+		id:      newID(),
+		dir:     dir,
+		scanner: scanner,
+		cancel:  cancel,
+		created: s.now(),
+		state:   StateQueued,
+	}
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		cancel()
+		writeError(w, http.StatusServiceUnavailable, CodeShuttingDown, "the server is shutting down")
+		return
+	}
+	s.scans[sc.id] = sc
+	s.wg.Add(1)
+	s.mu.Unlock()
+	go s.run(ctx, sc)
+
+	w.Header().Set("Location", "/scans/"+sc.id)
+	writeJSON(w, http.StatusAccepted, sc.status())
+}
+
+// run waits for a free slot and scans
+func (s *Server) run(ctx context.Context, sc *scan) {
+	defer s.wg.Done()
+	defer sc.cancel()
+	select {
+	case s.slots <- struct{}{}:
+	case <-ctx.Done():
+		sc.finish(nil, searcher.ErrCancelled, s.now())
+		return
+	}
+	defer func() { <-s.slots }()
+
+	sc.mu.Lock()
+	sc.state = StateRunning
+	sc.started = s.now()
+	sc.mu.Unlock()
+	result, err := sc.scanner.ScanContext(ctx, sc.dir)
+	sc.finish(result, err, s.now())
+}
+
+// handleScan serves /scans/{id} and /scans/{id}/findings
+func (s *Server) handleScan(w http.ResponseWriter, r *http.Request) {
+	id, sub, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/scans/"), "/")
+	sc := s.lookup(id)
+	if sc == nil {
+		writeError(w, http.StatusNotFound, CodeNotFound, fmt.Sprintf("scan %q not found", id))
+		return
+	}
+	switch sub {
+	case "":
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, http.StatusOK, sc.status())
+		case http.MethodDelete:
+			s.deleteScan(w, sc)
+		default:
+			methodNotAllowed(w, http.MethodGet, http.MethodDelete)
+		}
+	case "findings":
+		if r.Method != http.MethodGet {
+			methodNotAllowed(w, http.MethodGet)
+			return
+		}
+		s.listFindings(w, r, sc)
+	default:
+		writeError(w, http.StatusNotFound, CodeNotFound, fmt.Sprintf("unknown path: %s", r.URL.Path))
+	}
+}
+
+// deleteScan cancels a scan that has not finished, or forgets a finished one
+func (s *Server) deleteScan(w http.ResponseWriter, sc *scan) {
+	sc.mu.Lock()
+	finished := sc.state.finished()
+	sc.mu.Unlock()
+	if !finished {
+		sc.cancel()
+		writeJSON(w, http.StatusAccepted, sc.status())
+		return
+	}
+	s.mu.Lock()
+	delete(s.scans, sc.id)
+	s.mu.Unlock()
+	sc.close()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// listFindings writes a page of the findings of a finished scan
+func (s *Server) listFindings(w http.ResponseWriter, r *http.Request, sc *scan) {
+	query := r.URL.Query()
+	offset, err := intParam(query.Get("offset"), 0, 0, -1)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, CodeBadParameter, "offset: "+err.Error())
+		return
+	}
+	limit, err := intParam(query.Get("limit"), DefaultPageSize, 1, MaxPageSize)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, CodeBadParameter, "limit: "+err.Error())
+		return
+	}
+	var minSeverity searcher.Severity
+	if name := query.Get("min_severity"); name != "" {
+		if minSeverity, err = parseSeverity(name); err != nil {
+			writeError(w, http.StatusBadRequest, CodeBadParameter, "min_severity: "+err.Error())
+			return
+		}
+	}
+
+	// A finished scan no longer changes its result, so the findings are
+	// read without holding the lock
+	sc.mu.Lock()
+	state, scanErr, result := sc.state, sc.err, sc.result
+	sc.mu.Unlock()
+	switch state {
+	case StateQueued, StateRunning:
+		writeError(w, http.StatusConflict, CodeNotFinished, "the scan has not finished yet")
+		return
+	case StateFailed:
+		writeError(w, http.StatusConflict, CodeScanFailed, fmt.Sprintf("the scan failed: %v", scanErr))
+		return
+	}
+	page := FindingsPage{Offset: offset, Limit: limit, Findings: []*searcher.Finding{}}
+	if result != nil {
+		err = result.ForEachFinding(func(f *searcher.Finding) bool {
+			if minSeverity != "" && f.Severity.Score() < minSeverity.Score() {
+				return true
+			}
+			if page.Total >= offset && len(page.Findings) < limit {
+				page.Findings = append(page.Findings, f.Masked())
+			}
+			page.Total++
+			return true
+		})
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, CodeInternal, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, page)
+}
+
+// newScanner returns a scanner configured by opts, as the scan command
+// configures one from its flags
+func newScanner(opts ScanOptions) (*searcher.Scanner, error) {
+	scanner := searcher.NewScanner()
+	scanner.SetEnumerate(true)
+	if opts.MinSeverity != "" {
+		severity, err := parseSeverity(opts.MinSeverity)
+		if err != nil {
+			return nil, fmt.Errorf("min_severity: %w", err)
+		}
+		scanner.SetMinSeverity(severity)
+	}
+	if opts.MaxFileSize < 0 {
+		return nil, errors.New("max_file_size: the size cannot be negative")
+	}
+	if opts.MaxFileSize > 0 {
+		scanner.SetMaxFileSize(opts.MaxFileSize)
+	}
+	if err := scanner.SetIncludeGlobs(opts.Include); err != nil {
+		return nil, err
+	}
+	if err := scanner.SetExcludeGlobs(opts.Exclude); err != nil {
+		return nil, err
+	}
+	scanner.SetOnlyExtensions(opts.Extensions)
+
+	if opts.Documents || opts.Archives || opts.OCR {
+		scanner.SetDocumentExtractor(searcher.NewDocumentExtractor(opts.OCR))
+		scanner.SetScanDocuments(opts.Documents)
+		scanner.SetScanArchives(opts.Archives)
+		ignoreList := scanner.GetIgnoreList()
+		if opts.Documents {
+			ignoreList.EnableDocumentScanning()
+		}
+		if opts.OCR {
+			ignoreList.EnableImageScanning()
+		}
+		if opts.Archives {
+			ignoreList.EnableArchiveScanning()
+		}
+	}
+	return scanner, nil
+}
+
+// finish records the outcome of the scan
+func (sc *scan) finish(result *searcher.ScanResult, err error, now time.Time) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.result, sc.err, sc.finished = result, err, now
+	switch {
+	case errors.Is(err, searcher.ErrCancelled):
+		sc.state, sc.err = StateCancelled, nil
+	case err != nil:
+		sc.state = StateFailed
+	default:
+		sc.state = StateDone
+	}
+}
+
+// close frees the result of the scan
+func (sc *scan) close() {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	if sc.result != nil {
+		sc.result.Close()
+	}
+}
+
+// status returns the state and the counters of the scan
+func (sc *scan) status() Status {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	status := Status{ID: sc.id, Dir: sc.dir, State: sc.state, Created: sc.created}
+	if sc.err != nil {
+		status.Error = sc.err.Error()
+	}
+	if !sc.started.IsZero() {
+		started := sc.started
+		status.Started = &started
+	}
+	if !sc.finished.IsZero() {
+		finished := sc.finished
+		status.Finished = &finished
+	}
+
+	switch {
+	case sc.state == StateRunning:
+		p := sc.scanner.Progress()
+		status.Progress = Progress{
+			FilesDone:      p.FilesDone,
+			FilesTotal:     p.EstimatedTotal,
+			TotalComplete:  p.TotalComplete,
+			FilesScanned:   p.FilesProcessed,
+			FilesSkipped:   p.FilesSkipped,
+			Findings:       p.FindingsCount,
+			Critical:       p.CriticalCount,
+			Errors:         p.ErrorCount,
+			BytesScanned:   p.BytesScanned,
+			ElapsedSeconds: p.ElapsedTime.Seconds(),
+		}
+	case sc.result != nil:
+		r := sc.result
+		files := int64(r.FilesScanned + r.FilesSkipped)
+		status.Progress = Progress{
+			FilesDone:      files,
+			FilesTotal:     files,
+			TotalComplete:  !r.Cancelled,
+			FilesScanned:   int64(r.FilesScanned),
+			FilesSkipped:   int64(r.FilesSkipped),
+			Findings:       int64(r.TotalFindings()),
+			Critical:       int64(r.GetSeverityCount(searcher.Critical)),
+			Errors:         int64(r.ErrorCount),
+			BytesScanned:   r.TotalSize,
+			ElapsedSeconds: sc.finished.Sub(sc.started).Seconds(),
+		}
+	}
+	return status
+}
+
+// intParam parses a query parameter between lo and hi (hi < 0 for no
+// upper bound); empty gives def
+func intParam(value string, def, lo, hi int) (int, error) {
+	if value == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("expected a number, got %q", value)
+	}
+	if n < lo || hi >= 0 && n > hi {
+		if hi < 0 {
+			return 0, fmt.Errorf("%d: must be at least %d", n, lo)
+		}
+		return 0, fmt.Errorf("%d: must be from %d to %d", n, lo, hi)
+	}
+	return n, nil
+}
+
+// parseSeverity parses a severity name with an English error, as every
+// message of the API is; the one of searcher.ParseSeverity is Russian
+func parseSeverity(name string) (searcher.Severity, error) {
+	severity, err := searcher.ParseSeverity(name)
+	if err != nil {
+		return "", fmt.Errorf("unknown severity %q, expected critical, high, medium or low", name)
+	}
+	return severity, nil
+}
+
+// newID returns a random scan id
+func newID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, ErrorResponse{Code: code, Error: message})
+}
+
+func methodNotAllowed(w http.ResponseWriter, allowed ...string) {
+	w.Header().Set("Allow", strings.Join(allowed, ", "))
+	writeError(w, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "method not allowed")
+}
@@ -0,0 +1,22 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd && !windows
+
+package main
+
+import (
+	"errors"
+	"os"
+)
+
+// isTerminal reports false: echo cannot be turned off on this platform, so
+// passwords are read like piped input
+func isTerminal(f *os.File) bool {
+	return false
+}
+
+func disableEcho(f *os.File) (func(), error) {
+	return nil, errors.New(ui.T("cli.echo_unsupported"))
+}
+
+func terminalWidth(f *os.File) int {
+	return 0
+}